@@ -38,6 +38,8 @@ echo "Version: ${VERSION}"
 echo "GitCommit: ${GIT_COMMIT}"
 echo "GitTreeState: ${GIT_TREE_STATE}"
 echo "=================================="
-# 执行构建命令
 cd ${PROJECT_ROOT}
+# 解密出的私密材料必须留在 SecureBytes 中并被清除，检查不通过时不构建
+go test -run TestSecretHygiene ./internal/security || exit 1
+# 执行构建命令
 go build -v -ldflags "${GO_LDFLAGS}" -o ${OUTPUT_DIR}/${APP_NAME} ./main.go
\ No newline at end of file
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/web"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiKeyName  string
+	apiKeyScope string
+	apiKeyUser  string
+)
+
+// apiKeyCmd 管理 serve 模式使用的 API 密钥
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys for serve mode",
+	Long: `Manage the API keys accepted by the web server. Each key carries one scope:
+
+  read    list accounts, addresses and balances
+  derive  everything in read, plus deriving new addresses
+  sign    everything in derive, plus signing (requires an unlocked wallet)
+
+A key created with --user only reaches that user's wallet namespace (see
+"slowmade user"); keys without a user reach the default wallet.`,
+}
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new API key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scope, err := web.ParseScope(apiKeyScope)
+		if err != nil {
+			return err
+		}
+		if apiKeyUser != "" {
+			if _, err := container.UserStore().Get(apiKeyUser); err != nil {
+				return err
+			}
+		}
+		key, token, err := container.KeyStore().Create(apiKeyName, scope, apiKeyUser)
+		if err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "apikey.create", key.ID, "ok")
+
+		fmt.Printf("Created API key %s (%s, scope=%s)\n", key.ID, key.Name, key.Scope)
+		if key.User != "" {
+			fmt.Printf("Bound to user %s\n", key.User)
+		}
+		fmt.Printf("Token: %s\n", token)
+		fmt.Println("Store this token now, it cannot be shown again.")
+		return nil
+	},
+}
+
+var apiKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := container.KeyStore().List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSCOPE\tUSER\tCREATED\tSTATUS")
+		for _, key := range keys {
+			status := "active"
+			if key.Revoked {
+				status = "revoked"
+			}
+			user := key.User
+			if user == "" {
+				user = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Scope, user,
+				view.CurrentFormatter().Date(time.Unix(key.CreatedAt, 0)), status)
+		}
+		return w.Flush()
+	},
+}
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := container.KeyStore().Revoke(args[0]); err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "apikey.revoke", args[0], "ok")
+		fmt.Printf("Revoked API key %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiKeyCmd)
+	apiKeyCmd.AddCommand(apiKeyCreateCmd, apiKeyListCmd, apiKeyRevokeCmd)
+
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyName, "name", "", "Human readable name of the key")
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyScope, "scope", string(web.ScopeRead), "Key scope (read|derive|sign|admin)")
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyUser, "user", "", "Bind the key to a user's wallet namespace")
+	apiKeyCreateCmd.MarkFlagRequired("name")
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/palagend/slowmade/internal/admin"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/passprompt"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ctlSocket      string
+	ctlAuditLines  int
+	ctlAuditFollow bool
+)
+
+// ctlTimeout 除跟踪审计日志外，每个管理请求的超时
+const ctlTimeout = 2 * time.Minute
+
+// ctlCmd 通过管理接口控制运行中的 serve，不打开钱包，也不需要数据目录的实例锁
+var ctlCmd = &cobra.Command{
+	Use:   "ctl",
+	Short: "Control a running slowmade serve",
+	Long: `Talk to a running "slowmade serve" through its admin socket, so operators
+don't need to attach to the daemon's terminal. The socket is admin.sock in the
+data directory (or web.admin_socket) and only the user running serve can
+connect to it.
+
+Examples:
+  slowmade ctl status
+  slowmade ctl unlock
+  slowmade ctl audit --follow
+  slowmade --data-dir /var/lib/slowmade ctl backup`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return setPasswordSource()
+	},
+}
+
+var ctlStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether serve is running, its version and whether the wallet is locked",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), ctlTimeout)
+		defer cancel()
+		status, err := ctlClient().Status(ctx)
+		if err != nil {
+			return err
+		}
+		wallet := "unlocked"
+		if status.Locked {
+			wallet = "locked"
+		}
+		fmt.Printf("Version:     %s\n", status.Version)
+		fmt.Printf("PID:         %d\n", status.PID)
+		fmt.Printf("Running for: %s (since %s)\n", time.Since(status.Started).Round(time.Second), status.Started.Local().Format(time.DateTime))
+		fmt.Printf("Data dir:    %s\n", status.DataDir)
+		fmt.Printf("Listening:   %s\n", status.Listen)
+		fmt.Printf("Wallet:      %s\n", wallet)
+		if status.LastBackup != nil {
+			fmt.Printf("Last backup: %s\n", status.LastBackup.Local().Format(time.DateTime))
+		} else {
+			fmt.Println("Last backup: never")
+		}
+		return nil
+	},
+}
+
+var ctlLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the wallet and clear the password from serve's memory",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), ctlTimeout)
+		defer cancel()
+		if err := ctlClient().Lock(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Wallet locked")
+		return nil
+	},
+}
+
+var ctlUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the wallet in serve, prompting for the password",
+	Long: `Prompt for the wallet password and unlock the wallet in the running serve.
+The password is sent only over the admin socket. With --password-file or
+--unlock-fd the password is read from there instead of the terminal.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := ctlPassword()
+		if err != nil {
+			return err
+		}
+		defer security.WipeSensitiveData(password)
+		ctx, cancel := context.WithTimeout(context.Background(), ctlTimeout)
+		defer cancel()
+		if err := ctlClient().Unlock(ctx, string(password)); err != nil {
+			return err
+		}
+		fmt.Println("Wallet unlocked")
+		return nil
+	},
+}
+
+var ctlReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Re-read the config file in serve",
+	Long: `Make the running serve re-read its config file. An invalid file is rejected
+and serve keeps its current settings. The data directory, listen address,
+admin socket and tracing only change after a restart.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), ctlTimeout)
+		defer cancel()
+		if err := ctlClient().Reload(ctx); err != nil {
+			return fmt.Errorf("reload rejected, serve keeps its current config: %w", err)
+		}
+		fmt.Println("Config reloaded")
+		return nil
+	},
+}
+
+var ctlBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a backup now to backup.dir, encrypted to backup.recipients",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), ctlTimeout)
+		defer cancel()
+		path, err := ctlClient().Backup(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return nil
+	},
+}
+
+var ctlAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the latest audit events, with --follow keep printing new ones",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		if !ctlAuditFollow {
+			var timeout context.CancelFunc
+			ctx, timeout = context.WithTimeout(ctx, ctlTimeout)
+			defer timeout()
+		}
+		return ctlClient().Audit(ctx, ctlAuditLines, ctlAuditFollow, func(entry audit.Entry) error {
+			line := fmt.Sprintf("%s  %-12s %-20s %s", entry.Time, entry.Actor, entry.Action, entry.Result)
+			if entry.Target != "" {
+				line += "  " + entry.Target
+			}
+			fmt.Println(line)
+			return nil
+		})
+	},
+}
+
+// adminSocketPath 管理接口的 socket 路径，web.admin_socket 为 off 时返回默认路径和 false
+func adminSocketPath() (string, bool) {
+	appConfig := config.GetAppConfig()
+	path := appConfig.GetWebConfig().AdminSocket
+	if path == "" || path == "off" {
+		return filepath.Join(appConfig.GetStorageConfig().BaseDir, admin.SocketName), path == ""
+	}
+	return path, true
+}
+
+func ctlClient() *admin.Client {
+	if ctlSocket != "" {
+		return admin.NewClient(ctlSocket)
+	}
+	path, _ := adminSocketPath()
+	return admin.NewClient(path)
+}
+
+// ctlPassword 读取解锁密码：--password-file、--unlock-fd，否则提示输入（--password-fd 时从描述符读取）
+func ctlPassword() ([]byte, error) {
+	switch {
+	case unlockFD >= 0 && passwordFile != "":
+		return nil, fmt.Errorf("--unlock-fd and --password-file cannot be used together")
+	case unlockFD >= 0:
+		return passprompt.ReadFD(unlockFD)
+	case passwordFile != "":
+		return passprompt.ReadFile(passwordFile)
+	}
+	password, err := passprompt.New().Read("Enter password: ")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(password), nil
+}
+
+func init() {
+	rootCmd.AddCommand(ctlCmd)
+	ctlCmd.AddCommand(ctlStatusCmd, ctlLockCmd, ctlUnlockCmd, ctlReloadCmd, ctlBackupCmd, ctlAuditCmd)
+
+	ctlCmd.PersistentFlags().StringVar(&ctlSocket, "socket", "", "admin socket of the serve instance (default web.admin_socket or admin.sock in the data directory)")
+	ctlAuditCmd.Flags().IntVarP(&ctlAuditLines, "lines", "n", 10, "number of past events to show")
+	ctlAuditCmd.Flags().BoolVarP(&ctlAuditFollow, "follow", "f", false, "keep printing new events until interrupted")
+}
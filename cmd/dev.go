@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/devseed"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devSeedFrom  string
+	devSeedDir   string
+	devSeedCoins []string
+	devSeedCount int
+)
+
+// devCmd 开发者工具命令组，不打开配置的钱包
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Developer tools (never use with real funds)",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return nil
+	},
+}
+
+// devSeedCmd 从字符串确定性地生成开发钱包
+var devSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Create a deterministic INSECURE wallet from a string for tests and tutorials",
+	Long: `Derive a 24-word mnemonic from a string and create a wallet from it in a
+development directory, so integration tests and tutorials get the same
+addresses on every machine without shipping a real seed.
+
+The wallet is INSECURE: anyone who knows the string knows the keys. It is
+written to its own directory (default <tmp>/slowmade-dev/<id>) marked with a
+DEV-INSECURE file, never to the configured data directory, and every slowmade
+command opened on that directory prints a warning. The password is fixed and
+stored in the dev-password file next to the wallet.
+
+Running the command again with the same string and directory reuses the
+wallet and prints the same addresses.
+
+Examples:
+  slowmade dev seed --from-string abc
+  slowmade dev seed --from-string tutorial --coin BTC --coin ETH --count 5
+  slowmade --data-dir /tmp/slowmade-dev/<id> --password-file /tmp/slowmade-dev/<id>/dev-password exec "account.list ETH"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if devSeedFrom == "" {
+			return fmt.Errorf("--from-string is required")
+		}
+		if devSeedCount < 1 || devSeedCount > 100 {
+			return fmt.Errorf("invalid address count %d", devSeedCount)
+		}
+		dir := devSeedDir
+		if dir == "" {
+			dir = devseed.DefaultDir(devSeedFrom)
+		}
+		result, err := devseed.Create(dir, devSeedFrom, devSeedCoins, devSeedCount)
+		if err != nil {
+			return err
+		}
+		fmt.Println("!!! " + devseed.Watermark + " !!!")
+		fmt.Println()
+		fmt.Printf("Directory:          %s\n", result.Dir)
+		if result.Reused {
+			fmt.Printf("                    (existing development wallet reused)\n")
+		}
+		fmt.Printf("Mnemonic:           %s\n", result.Mnemonic)
+		fmt.Printf("Password:           %s (in %s)\n", devseed.Password, devseed.PasswordFileName)
+		fmt.Printf("Wallet fingerprint: %s\n", result.MasterFingerprint)
+		for _, account := range result.Accounts {
+			fmt.Printf("\n%s %s\n", account.Coin, account.Path)
+			for i, address := range account.Addresses {
+				fmt.Printf("  %d  %s\n", i, address)
+			}
+		}
+		fmt.Println()
+		fmt.Printf("Open with: slowmade --data-dir %s --password-file %s\n",
+			result.Dir, filepath.Join(result.Dir, devseed.PasswordFileName))
+		fmt.Println()
+		fmt.Println("!!! " + devseed.Watermark + " !!!")
+		return nil
+	},
+}
+
+// warnDevWallet 数据目录是开发目录时在标准错误输出警告，不影响脚本解析标准输出
+func warnDevWallet(dir string) {
+	if devseed.IsDevDir(dir) {
+		fmt.Fprintln(os.Stderr, "WARNING: "+devseed.Watermark)
+	}
+}
+
+func init() {
+	devSeedCmd.Flags().StringVar(&devSeedFrom, "from-string", "", "string the wallet is derived from")
+	devSeedCmd.Flags().StringVar(&devSeedDir, "dir", "", "development directory (default <tmp>/slowmade-dev/<id>)")
+	devSeedCmd.Flags().StringSliceVar(&devSeedCoins, "coin", []string{"BTC", "ETH"}, "coins to create account 0 for")
+	devSeedCmd.Flags().IntVar(&devSeedCount, "count", 3, "number of receive addresses to show per coin")
+	devCmd.AddCommand(devSeedCmd)
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+var diagnosticsOut string
+
+// diagnosticsCmd 生成附在问题报告里的诊断包
+var diagnosticsCmd = &cobra.Command{
+	Use:   "diagnostics",
+	Short: "Create a scrubbed diagnostics bundle for bug reports",
+	Long: `Write a tar.gz bundle with the information needed to investigate a bug:
+
+  version.json     version, commit and build information
+  config.json      the effective configuration with passwords, keys and tokens masked
+  storage.txt      storage health check and the file list (names, sizes, permissions, no contents)
+  terminal.txt     terminal, color profile and locale settings
+  log.txt          the last lines of the log file with mnemonics and keys redacted
+  goroutines.txt   a goroutine dump
+
+Redaction is best effort: review the bundle before attaching it to a bug report.
+
+Examples:
+  slowmade diagnostics
+  slowmade diagnostics --out /tmp/report.tar.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := diagnosticsOut
+		if out == "" {
+			out = diagnostics.DefaultName()
+		}
+		opts := container.Diagnostics()
+		opts.Template = "default"
+		names, err := diagnostics.Write(out, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create diagnostics bundle: %w", err)
+		}
+		fmt.Printf("Diagnostics bundle written to %s (%d files)\n", out, len(names))
+		return nil
+	},
+}
+
+func init() {
+	diagnosticsCmd.Flags().StringVar(&diagnosticsOut, "out", "", "output file (default slowmade-diagnostics-<time>.tar.gz)")
+	rootCmd.AddCommand(diagnosticsCmd)
+}
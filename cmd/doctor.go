@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/palagend/slowmade/internal/doctor"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorOffline bool
+	doctorTimeout int
+	// deferredConfigErr 配置加载失败时 doctor 和 upgrade-check 不退出，把错误作为检查结果报告
+	deferredConfigErr error
+)
+
+// doctorCmd 供定时任务和监控使用的自检
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check storage, config, templates, crypto, wordlist and RPC nodes",
+	Long: `Run the health checks and print a report:
+
+  storage     the data directory is writable and the wallet and account files parse
+  config      the config file loads and the RPC nodes, time zone, log level and limits are valid
+  templates   the display templates and the custom template functions render
+  crypto      an encrypt/decrypt round trip with the configured algorithm and KDF
+  wordlist    the BIP39 wordlist has 2048 sorted words with unique prefixes
+  rpc         a TCP connection to every node configured for the current network
+
+doctor does not unlock the wallet. When another slowmade process holds the data
+directory it is checked read-only.
+
+Exit status: 0 when every check passes, 1 when some check has a warning (for
+example no wallet yet or an unreachable node), 2 when some check fails.
+
+Examples:
+  slowmade doctor
+  slowmade doctor --offline
+  */15 * * * * slowmade doctor --offline >/dev/null || notify-admin`,
+	// 不构建依赖也不在启动时解锁，检查自己打开存储
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		results := doctor.Run(doctor.Options{
+			ConfigErr:    deferredConfigErr,
+			Offline:      doctorOffline,
+			ProbeTimeout: time.Duration(doctorTimeout) * time.Second,
+		})
+		tmpl := view.NewTemplate()
+		counts := make(map[doctor.Status]int)
+		for _, result := range results {
+			counts[result.Status]++
+			label := fmt.Sprintf("%-10s", result.Name)
+			switch result.Status {
+			case doctor.OK:
+				label = tmpl.Success(label)
+			case doctor.Warn:
+				label = tmpl.Warning(label)
+			default:
+				label = tmpl.Error(label)
+			}
+			fmt.Printf("%s %s\n", label, result.Detail)
+		}
+		fmt.Printf("\n%d ok, %d warnings, %d failed\n", counts[doctor.OK], counts[doctor.Warn], counts[doctor.Fail])
+		os.Exit(doctor.ExitCode(results))
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "skip the RPC connectivity check")
+	doctorCmd.Flags().IntVar(&doctorTimeout, "timeout", 5, "connect timeout per RPC node in seconds")
+	rootCmd.AddCommand(doctorCmd)
+}
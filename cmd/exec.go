@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// execCmd 不进入交互界面，执行一行 REPL 命令后退出
+var execCmd = &cobra.Command{
+	Use:   "exec <command line>",
+	Short: "Run REPL commands without starting the interactive shell",
+	Long: `Run one line of REPL commands and exit. The line is parsed exactly like
+input typed at the prompt: single and double quotes group words, backslash
+escapes the next character, and &&, |, > and >> work as in the REPL. Pass the
+line as a single quoted argument so the shell does not interpret the operators.
+
+Passwords are never accepted as arguments; use --password-file or --unlock-fd
+to unlock at startup and --password-fd to answer prompts.
+
+Examples:
+  slowmade exec 'account.list BTC'
+  slowmade --password-file pw.txt exec 'account.list ETH && address.list my-savings > "my addresses.txt"'
+  slowmade exec 'wallet.restore "word1 word2 ... word24"'
+
+The exit status tells scripts why a command failed; see slowmade --help.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		replApp, err := container.REPL()
+		if err != nil {
+			return fmt.Errorf("error creating REPL: %v", err)
+		}
+		// 多个参数时按空格拼接，与在提示符下输入相同
+		err = replApp.Exec(strings.Join(args, " "))
+		replApp.Close()
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
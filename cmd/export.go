@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/pubexport"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var exportOut string
+
+// exportCmd 导出命令组
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export wallet data",
+}
+
+// exportPublicCmd 导出不含私密材料的公开数据
+var exportPublicCmd = &cobra.Command{
+	Use:   "public",
+	Short: "Export xpubs, descriptors, addresses and history without any secrets",
+	Long: `Write the public data of every account to a directory, suitable for an
+accountant or a watch-only importer:
+
+  accounts.json      account paths, xpubs and (for BTC) output descriptors
+  descriptors.txt    BTC receive and change descriptors with checksums
+  addresses.csv      every derived address with its full derivation path
+  transactions.csv   incoming payments recorded by the payment watcher
+
+Every file is scanned for extended private keys, WIF keys, unknown 32-byte
+hex strings, secret field names and mnemonic phrases before anything is
+written; the export is rejected if the scanner finds anything. Note that
+xpubs reveal all past and future addresses of an account.
+
+Examples:
+  slowmade export public --out ./for-accountant`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportOut == "" {
+			return fmt.Errorf("--out is required")
+		}
+		if err := promptUnlock(); err != nil {
+			return err
+		}
+		defer lockWallet()
+
+		txStore, err := watch.LoadTxStore(filepath.Join(container.BaseDir, watch.TxFileName))
+		if err != nil {
+			return err
+		}
+		summary, err := pubexport.Export(container.AccountMgr, txStore, exportOut)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d accounts, %d addresses and %d transactions to %s: %v\n",
+			summary.Accounts, summary.Addresses, summary.Transactions, exportOut, summary.Files)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportPublicCmd)
+
+	exportPublicCmd.Flags().StringVar(&exportOut, "out", "", "Output directory (created if missing, must be empty)")
+}
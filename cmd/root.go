@@ -4,69 +4,240 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/palagend/slowmade/internal/app"
 	"github.com/palagend/slowmade/internal/config"
-	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/hardening"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/passprompt"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/tracing"
+	"github.com/palagend/slowmade/internal/walletstats"
+	"github.com/palagend/slowmade/pkg/i18n"
 	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/palagend/slowmade/pkg/mnemonic"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 var (
-	debug      bool
-	cloak      string
-	walletMgr  core.WalletManager
-	accountMgr core.AccountManager
+	debug        bool
+	cloak        string
+	container    *app.Container
+	passwordFD   int
+	unlockFD     int
+	passwordFile string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "slowmade",
 	Short: "A secure cryptocurrency wallet",
-	Long:  `Slowmade is a secure HD wallet supporting multiple cryptocurrencies with REPL interface.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		initDependencies()
+	Long: `Slowmade is a secure HD wallet supporting multiple cryptocurrencies with REPL interface.
+
+Exit status (for scripts using exec or piping commands into the REPL):
+  0  success
+  1  other error
+  2  usage error or unknown command
+  3  invalid configuration
+  4  wallet is locked
+  5  wrong password
+  6  data directory unavailable (I/O error, in use by another process,
+     accessible by other users, opened read-only, or written by a newer
+     version, see upgrade-check)
+The doctor command uses its own 0/1/2 convention.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 参数已经解析完成，之后的错误不是用法问题，不再显示用法
+		cmd.SilenceUsage = true
+		return initDependencies()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// 进入 REPL 模式
-		replApp, err := app.NewREPL(walletMgr, accountMgr)
+		replApp, err := container.REPL()
 		if err != nil {
-			fmt.Printf("Error creating REPL: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error creating REPL: %w", err)
 		}
-		replApp.Run()
+		// REPL 已经显示过命令的错误，这里只用于决定退出码
+		cmd.SilenceErrors = true
+		return replApp.Run()
 	},
 }
 
-func initDependencies() {
-	// 创建 WalletManager 实例（具体实现）
+func initDependencies() error {
 	appConfig := config.GetAppConfig()
 	if debug {
 		appConfigStr, _ := json.MarshalIndent(appConfig, "", "  ")
 		logging.Debugf("AppConfig is: %s", appConfigStr)
 	}
-	stor, err := core.NewFileStorage(appConfig.GetStorageConfig())
+	hardenProcess(appConfig.GetHardeningConfig())
+	initLanguage(appConfig.GetUIConfig())
+	checkRNG()
+	if err := setPasswordSource(); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	// 所有命令共用同一个组合根构建的依赖
+	var err error
+	if container, err = app.Wire(cloak); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	warnDevWallet(container.BaseDir)
+	if err := checkUpgrade(); err != nil {
+		return err
+	}
+	if err := unlockAtStartup(); err != nil {
+		return fmt.Errorf("failed to unlock wallet at startup: %w", err)
+	}
+	return nil
+}
+
+// initLanguage 加载内置和外部的翻译并切换到配置的语言；外部文件有错误时只警告
+func initLanguage(cfg config.UIConfig) {
+	dir := cfg.LocalesDir
+	if dir == "" {
+		if file := viper.ConfigFileUsed(); file != "" {
+			dir = filepath.Join(filepath.Dir(file), "locales")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".slowmade", "locales")
+		}
+	}
+	if err := i18n.Init(dir); err != nil {
+		logging.Warnf("Translations: %v", err)
+	}
+	i18n.SetLanguage(cfg.Lang)
+}
+
+// hardenProcess 在读取任何密钥之前加固进程，并记录每项保护是否生效
+func hardenProcess(cfg config.HardeningConfig) {
+	status := hardening.Apply(hardening.Options{
+		MlockAll:         cfg.MlockAll,
+		DisableCoreDumps: cfg.DisableCoreDumps,
+		NoDumpable:       cfg.NoDumpable,
+	})
+	for _, p := range status.Protections {
+		if p.Enabled {
+			logging.Infof("Hardening: %s enabled (%s)", p.Name, p.Detail)
+		} else {
+			logging.Warnf("Hardening: %s not enabled (%s)", p.Name, p.Detail)
+		}
+	}
+	logging.Infof("Hardening level: %s", status.Level())
+}
+
+// checkRNG 启动时自检系统随机数；失败时仍然启动，已有的钱包可以使用，但拒绝生成新的助记词
+func checkRNG() {
+	if err := mnemonic.CheckRNG(); err != nil {
+		logging.Errorf("RNG self-test: %v", err)
+		return
+	}
+	logging.Debug("RNG self-test passed")
+}
+
+// setPasswordSource 自动化场景下从文件描述符逐行读取提示的密码
+func setPasswordSource() error {
+	if passwordFD >= 0 {
+		return passprompt.SetFD(passwordFD)
+	}
+	return nil
+}
+
+// unlockAtStartup 用 --unlock-fd 或 --password-file 提供的密码在启动时解锁，
+// 密码只读取一次，不经过参数或环境变量，读取后清除缓冲区
+func unlockAtStartup() error {
+	var password []byte
+	var err error
+	switch {
+	case unlockFD >= 0 && passwordFile != "":
+		return fmt.Errorf("--unlock-fd and --password-file cannot be used together")
+	case unlockFD >= 0:
+		password, err = passprompt.ReadFD(unlockFD)
+	case passwordFile != "":
+		password, err = passprompt.ReadFile(passwordFile)
+	default:
+		return nil
+	}
 	if err != nil {
-		log.Error(err.Error())
+		return err
 	}
-	walletMgr = core.NewDefaultWalletManager(stor, cloak)
-	accountMgr = core.NewDefaultAccountManager(walletMgr, stor)
+	defer security.WipeSensitiveData(password)
+	return unlockWith(string(password))
 }
 
+// promptUnlock 提示输入密码并解锁钱包，供长期运行的签名类命令使用；启动时已经解锁则不再提示
+func promptUnlock() error {
+	if !container.WalletMgr.IsLocked() {
+		return nil
+	}
+	password, err := passprompt.New().Read("Enter password: ")
+	if err != nil {
+		return err
+	}
+	return unlockWith(password)
+}
+
+// unlockWith 解锁钱包并校验存储目录，发现外部修改时拒绝继续
+func unlockWith(password string) (err error) {
+	span := tracing.Command("unlock", "source", "cli")
+	defer func() { span.End(err) }()
+	err = container.WalletMgr.UnlockWallet(password)
+	metrics.Inc(metrics.Unlocks, "source", "cli", "result", metrics.Result(err))
+	if err != nil {
+		return fmt.Errorf("failed to unlock wallet: %w", err)
+	}
+	security.GetPasswordManager().SetPassword(password)
+	if container.Integrity != nil {
+		report, err := container.Integrity.Unlock(password)
+		if err != nil {
+			return fmt.Errorf("integrity check failed: %v", err)
+		}
+		if !report.Clean() {
+			fmt.Println("WARNING: wallet files were changed outside slowmade:")
+			for _, line := range report.Problems() {
+				fmt.Println("  " + line)
+			}
+			return fmt.Errorf("refusing to continue, verify the changes and run integrity.accept in the REPL")
+		}
+	}
+	if err := walletstats.RecordUnlock(container.BaseDir, "cli"); err != nil {
+		logging.Warnf("记录解锁时间失败: %v", err)
+	}
+	container.MigrateEnvelopes(password)
+	return nil
+}
+
+// lockWallet 锁定钱包并清除内存中的密码
+func lockWallet() {
+	container.WalletMgr.LockWallet()
+	security.GetPasswordManager().Clear()
+	if container.Integrity != nil {
+		container.Integrity.Lock()
+	}
+}
+
+// Execute 执行命令，失败时按错误类型以 app.ExitCode 的退出码退出
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		logging.Get().Error("Command execution failed", zap.Error(err))
-		os.Exit(1)
+	err := rootCmd.Execute()
+	if err != nil {
+		logging.Get().Debug("Command execution failed", zap.Error(err))
+	}
+	// 发送远程日志和链路追踪队列中剩下的内容
+	tracing.Shutdown()
+	logging.Sync()
+	if err != nil {
+		os.Exit(app.ExitCode(err))
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().String("config", "", "config file")
-	rootCmd.PersistentFlags().String("lang", "en", "language preference (en/zh/ja)")
+	rootCmd.PersistentFlags().String("lang", "en", "language preference (en, zh, ja or one from the locales directory)")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug")
 	rootCmd.PersistentFlags().String("data-dir", "", "storage base directory")
+	rootCmd.PersistentFlags().Bool("accessible", false, "plain, linear output without box drawing, icons, colors or columns, for screen readers (also ui.accessible; on by default when TERM=dumb)")
+	rootCmd.PersistentFlags().Bool("read-only", false, "open the data directory as a read-only follower: no instance lock, wallet writes rejected and only non-mutating REPL commands available, to inspect it while another slowmade process is running")
+	rootCmd.PersistentFlags().IntVar(&passwordFD, "password-fd", -1, "read passwords from this file descriptor, one per line, instead of prompting")
+	rootCmd.PersistentFlags().IntVar(&unlockFD, "unlock-fd", -1, "unlock the wallet at startup with a password read once from this file descriptor")
+	rootCmd.PersistentFlags().StringVar(&passwordFile, "password-file", "", "unlock the wallet at startup with the password in this file (mode 0600, e.g. a systemd credential or docker secret)")
 	rootCmd.PersistentFlags().StringVar(&cloak, "cloak", "", "Advanced feature: a cloak provides optional added security, but it is not stored so it must be remembered!")
 
 	cobra.OnInitialize(initConfig)
@@ -81,16 +252,27 @@ func initConfig() {
 		fmt.Printf("Failed to bind lang flag: %v\n", err)
 	}
 
+	if err := viper.BindPFlag("ui.accessible", rootCmd.PersistentFlags().Lookup("accessible")); err != nil {
+		fmt.Printf("Failed to bind accessible flag: %v\n", err)
+	}
+
 	if err := viper.BindPFlag("storage.base_dir", rootCmd.PersistentFlags().Lookup("data-dir")); err != nil {
 		fmt.Printf("Failed to bind data-dir flag: %v\n", err)
 	}
+	if err := viper.BindPFlag("storage.read_only", rootCmd.PersistentFlags().Lookup("read-only")); err != nil {
+		fmt.Printf("Failed to bind read-only flag: %v\n", err)
+	}
 
 	if debug {
 		viper.Set("log.level", "debug")
 	}
 
 	if err := config.Load(); err != nil {
+		if doctorCmd.CalledAs() != "" || upgradeCheckCmd.CalledAs() != "" {
+			deferredConfigErr = err
+			return
+		}
 		fmt.Printf("Failed to initialize config: %v\n", err)
-		os.Exit(1)
+		os.Exit(app.ExitConfig)
 	}
 }
@@ -1,7 +1,16 @@
 package cmd
 
 import (
-	"github.com/palagend/slowmade/internal/web"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/admin"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/version"
 	"github.com/palagend/slowmade/pkg/logging"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,6 +30,9 @@ var serveCmd = &cobra.Command{
 	Long: `Start the Slowmade web server that provides HTTP API endpoints
 and a web interface for interacting with the cryptocurrency wallet service.
 
+While it runs, "slowmade ctl" controls it through a unix socket in the data
+directory (web.admin_socket): status, lock, unlock, reload, backup and audit.
+
 Examples:
   # Start server with default configuration
   slowmade serve
@@ -31,8 +43,8 @@ Examples:
   # Start server in production mode
   slowmade serve --mode release`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// 创建服务器实例
-		server := web.NewServer()
+		// 创建服务器实例，钱包 API、鉴权密钥和审计日志由组合根配置
+		server := container.WebServer()
 
 		// 应用配置（命令行参数优先，然后是配置文件）
 		if serveHost != "" {
@@ -57,6 +69,19 @@ Examples:
 		server.Use(server.RecoveryMiddleware)
 		server.Use(server.CORSMiddleware)
 		server.Use(server.LoggingMiddleware)
+		server.Use(server.AuthMiddleware)
+
+		// 公钥加密的定时备份不需要钱包密码
+		if err := startScheduledBackups(); err != nil {
+			logging.Get().Error("Scheduled backups disabled", zap.Error(err))
+		}
+
+		// 本地管理接口，slowmade ctl 通过它控制运行中的 serve
+		if adminServer, err := startAdmin(server.Addr()); err != nil {
+			logging.Get().Error("Admin socket disabled", zap.Error(err))
+		} else if adminServer != nil {
+			defer adminServer.Close()
+		}
 
 		// 启动服务器
 		if err := server.Start(); err != nil {
@@ -65,6 +90,96 @@ Examples:
 	},
 }
 
+// serveAdmin serve 的管理操作，供 slowmade ctl 调用
+type serveAdmin struct {
+	started time.Time
+	listen  string
+}
+
+// startAdmin 在 web.admin_socket 上启动管理接口，配置为 off 时返回 nil
+func startAdmin(listen string) (*admin.Server, error) {
+	path, enabled := adminSocketPath()
+	if !enabled {
+		return nil, nil
+	}
+	handler := &serveAdmin{started: time.Now(), listen: listen}
+	server, err := admin.Listen(path, handler, filepath.Join(container.BaseDir, audit.FileName))
+	if err != nil {
+		return nil, err
+	}
+	logging.Infof("Admin socket listening on %s", path)
+	return server, nil
+}
+
+func (a *serveAdmin) Status() admin.Status {
+	status := admin.Status{
+		Version: version.Get().GitVersion,
+		PID:     os.Getpid(),
+		Started: a.started.UTC(),
+		DataDir: container.BaseDir,
+		Listen:  a.listen,
+		Locked:  container.WalletMgr.IsLocked(),
+	}
+	if last, err := backup.LastBackup(container.BaseDir); err == nil && last != nil {
+		status.LastBackup = &last.At
+	}
+	return status
+}
+
+func (a *serveAdmin) Lock() error {
+	lockWallet()
+	container.Audit().Record("ctl", "wallet.lock", "", "ok")
+	return nil
+}
+
+// Unlock 与启动时解锁相同，校验存储目录；发现外部修改时重新锁定，已经解锁时不做任何事
+func (a *serveAdmin) Unlock(password string) error {
+	if !container.WalletMgr.IsLocked() {
+		return nil
+	}
+	if err := unlockWith(password); err != nil {
+		if !container.WalletMgr.IsLocked() {
+			lockWallet()
+		}
+		container.Audit().Record("ctl", "wallet.unlock", "", "error")
+		return err
+	}
+	container.Audit().Record("ctl", "wallet.unlock", "", "ok")
+	return nil
+}
+
+func (a *serveAdmin) Reload() error {
+	if err := config.Reload(); err != nil {
+		container.Audit().Record("ctl", "config.reload", viper.ConfigFileUsed(), "error")
+		return err
+	}
+	container.Audit().Record("ctl", "config.reload", viper.ConfigFileUsed(), "ok")
+	return nil
+}
+
+// Backup 按 backup.dir 和 backup.recipients 写入一个公钥加密备份，与定时备份相同
+func (a *serveAdmin) Backup() (string, error) {
+	appConfig := config.GetAppConfig()
+	backupConfig := appConfig.GetBackupConfig()
+	if backupConfig.Dir == "" {
+		return "", fmt.Errorf("backup.dir is not set")
+	}
+	recipients, err := backup.ParseRecipients(backupConfig.Recipients)
+	if err != nil {
+		return "", fmt.Errorf("backup.recipients: %w", err)
+	}
+	path, _, err := backup.WriteFile(container.BaseDir, backupConfig.Dir, recipients, "ctl backup")
+	if path == "" {
+		container.Audit().Record("ctl", "backup.create", backupConfig.Dir, "error")
+		return "", err
+	}
+	container.Audit().Record("ctl", "backup.create", path, "ok")
+	if err != nil {
+		logging.Warnf("Failed to record the backup time: %v", err)
+	}
+	return path, nil
+}
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/palagend/slowmade/internal/app"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	signerHost     string
+	signerPort     int
+	signerChainID  int64
+	signerABIFiles []string
+)
+
+// signerCmd 以外部签名器模式运行，每个签名请求都需要在终端确认
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Run a local JSON-RPC signer for external tools",
+	Long: `Expose the wallet's derived ETH addresses through a local JSON-RPC endpoint,
+similar to Clef, so tools like Hardhat or Foundry can use slowmade as their signer.
+
+Supported methods: eth_accounts, eth_signTransaction, eth_signTypedData(_v4).
+Every signing request is shown in this terminal and must be approved with "y".
+Contract calls and typed data are decoded before approval; ABI files in the
+data directory's abi/ folder and those given with --abi are used for calldata.
+The signer never talks to a node, so transactions must include nonce and gas.
+
+Examples:
+  # Sign for mainnet on the default port
+  slowmade signer
+
+  # Sign for a local Hardhat network
+  slowmade signer --port 8550 --chain-id 31337`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := promptUnlock(); err != nil {
+			return err
+		}
+		defer lockWallet()
+
+		dec, err := newDecoder(signerABIFiles)
+		if err != nil {
+			return err
+		}
+		s := container.Signer(signer.NewTerminalApprover(os.Stdin, os.Stdout), big.NewInt(signerChainID)).
+			Preview(dec)
+		accounts, err := s.Accounts()
+		if err != nil {
+			return err
+		}
+		if len(accounts) == 0 {
+			fmt.Println("Warning: no ETH addresses derived yet, eth_accounts will be empty")
+		}
+		for _, account := range accounts {
+			fmt.Printf("  %s\n", account.Hex())
+		}
+
+		server := &http.Server{
+			Addr:    net.JoinHostPort(signerHost, strconv.Itoa(signerPort)),
+			Handler: signer.NewRPCServer(s),
+		}
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			server.Close()
+		}()
+
+		fmt.Printf("Signer listening on http://%s (chain id %d), press Ctrl+C to stop\n", server.Addr, signerChainID)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		fmt.Println("Signer stopped, wallet locked")
+		return nil
+	},
+}
+
+// newDecoder 创建签名预览解码器，加载数据目录 abi/ 下和命令行指定的 ABI 文件
+func newDecoder(abiFiles []string) (*decoder.Decoder, error) {
+	dec := decoder.NewDecoder()
+	if err := dec.LoadABIDir(filepath.Join(container.BaseDir, app.ABIDirName)); err != nil {
+		return nil, err
+	}
+	for _, file := range abiFiles {
+		if err := dec.LoadABIFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return dec, nil
+}
+
+func init() {
+	rootCmd.AddCommand(signerCmd)
+
+	signerCmd.Flags().StringVar(&signerHost, "host", "127.0.0.1", "Host to bind the signer to")
+	signerCmd.Flags().IntVarP(&signerPort, "port", "p", 8550, "Port to listen on")
+	signerCmd.Flags().Int64Var(&signerChainID, "chain-id", 1, "Default chain id for transactions without chainId")
+	signerCmd.Flags().StringSliceVar(&signerABIFiles, "abi", nil, "Contract ABI file used to decode calldata (repeatable)")
+}
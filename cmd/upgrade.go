@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/doctor"
+	"github.com/palagend/slowmade/internal/upgrade"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeApply bool
+	upgradeYes   bool
+)
+
+// upgradeCheckCmd 换用新版本后检查数据目录和配置的兼容性
+var upgradeCheckCmd = &cobra.Command{
+	Use:   "upgrade-check",
+	Short: "Check that the data directory and config work with this version",
+	Long: `Check that this binary can use the data directory and config file, and list
+the migrations they need:
+
+  storage     the storage schema version recorded in the data directory and the
+              envelope version of every encrypted key record
+  config      deprecated and unknown keys in the config file
+  templates   the display templates render with the current formatting
+
+The check runs automatically on the first start after the version changes. When
+the data was written by a newer version, slowmade refuses to start (exit status 6)
+instead of risking it. Migrations that only add files run on their own; ones that
+rewrite your files, such as the config file, are listed and wait for --apply --yes.
+Legacy encrypted records are re-encrypted on the next unlock, after a backup.
+
+Without --apply nothing is written. Exit status: 0 when everything is current,
+1 with warnings or pending migrations, 2 when this version cannot use the data.
+
+Examples:
+  slowmade upgrade-check
+  slowmade upgrade-check --apply
+  slowmade upgrade-check --apply --yes`,
+	// 不构建依赖也不在启动时解锁，检查自己以只读方式打开存储
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		if upgradeYes && !upgradeApply {
+			fmt.Println("--yes only takes effect with --apply")
+			os.Exit(2)
+		}
+		appConfig := config.GetAppConfig()
+		report, err := upgrade.Check(upgrade.Options{BaseDir: appConfig.GetStorageConfig().BaseDir, ConfigErr: deferredConfigErr})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		printUpgradeReport(os.Stdout, report)
+		if !upgradeApply {
+			os.Exit(doctor.ExitCode(report.Results))
+		}
+		applied, err := report.Apply(upgradeYes)
+		printApplied(os.Stdout, applied)
+		switch {
+		case errors.Is(err, upgrade.ErrNeedsConsent):
+			fmt.Println("Run again with --apply --yes to apply the migrations that rewrite your files")
+			os.Exit(1)
+		case err != nil:
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		fmt.Printf("Recorded %s as checked\n", report.To)
+	},
+}
+
+// checkUpgrade 版本变化后第一次启动时检查：数据不兼容时拒绝启动，执行不改写用户文件的迁移并记录版本；
+// 需要同意的迁移只提示，之后每次启动都会提醒，直到用 upgrade-check --apply --yes 执行
+func checkUpgrade() error {
+	state, err := upgrade.LoadState(container.BaseDir)
+	if err != nil {
+		return err
+	}
+	if state.Current() {
+		return nil
+	}
+	report, err := upgrade.Check(upgrade.Options{BaseDir: container.BaseDir})
+	if err != nil {
+		return err
+	}
+	switch {
+	case state == nil && !report.HasWallet && report.Clean():
+		// 新的数据目录，没有需要检查的旧数据
+	case report.Clean():
+		fmt.Fprintf(os.Stderr, "Upgrade check for %s passed\n", report.To)
+	default:
+		printUpgradeReport(os.Stderr, report)
+	}
+	if report.Blocked() {
+		return fmt.Errorf("%w, run slowmade upgrade-check for details", upgrade.ErrIncompatible)
+	}
+	appConfig := config.GetAppConfig()
+	if appConfig.GetStorageConfig().ReadOnly {
+		return nil
+	}
+	applied, err := report.Apply(false)
+	printApplied(os.Stderr, applied)
+	if errors.Is(err, upgrade.ErrNeedsConsent) {
+		fmt.Fprintln(os.Stderr, "Run slowmade upgrade-check --apply --yes to apply the migrations that rewrite your files")
+		return nil
+	}
+	return err
+}
+
+// printUpgradeReport 显示检查结果和需要的迁移
+func printUpgradeReport(w io.Writer, report *upgrade.Report) {
+	tmpl := view.NewTemplate()
+	from := report.From
+	if from == "" {
+		from = "an unrecorded version"
+	}
+	fmt.Fprintf(w, "Upgrade check: %s -> %s\n", from, report.To)
+	for _, result := range report.Results {
+		label := fmt.Sprintf("%-10s", result.Name)
+		switch result.Status {
+		case doctor.OK:
+			label = tmpl.Success(label)
+		case doctor.Warn:
+			label = tmpl.Warning(label)
+		default:
+			label = tmpl.Error(label)
+		}
+		fmt.Fprintf(w, "%s %s\n", label, result.Detail)
+	}
+	if len(report.Migrations) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nMigrations:")
+	for _, migration := range report.Migrations {
+		when := migration.Deferred
+		switch {
+		case migration.Automatic() && migration.Destructive:
+			when = "needs --apply --yes"
+		case migration.Automatic():
+			when = "with --apply"
+		}
+		fmt.Fprintf(w, "  %-20s [%s] %s\n", migration.Name, when, migration.Detail)
+	}
+}
+
+func printApplied(w io.Writer, applied []string) {
+	for _, line := range applied {
+		fmt.Fprintf(w, "Applied %s\n", line)
+	}
+}
+
+func init() {
+	upgradeCheckCmd.Flags().BoolVar(&upgradeApply, "apply", false, "apply the migrations that do not rewrite your files and record this version as checked")
+	upgradeCheckCmd.Flags().BoolVar(&upgradeYes, "yes", false, "with --apply, also apply the migrations that rewrite your files (the originals are kept)")
+	rootCmd.AddCommand(upgradeCheckCmd)
+}
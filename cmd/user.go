@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/web"
+	"github.com/spf13/cobra"
+)
+
+var userRoles string
+
+// userCmd 管理 serve 模式的用户
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users for multi-user serve mode",
+	Long: `Manage the users hosted by one serve instance. Each user gets an isolated
+wallet namespace under <data-dir>/users/<name>; API keys created with
+"apikey create --user <name>" can only reach that namespace.
+
+Create the user's wallet by running slowmade against the namespace directory:
+
+  slowmade user add alice
+  slowmade --data-dir <data-dir>/users/alice
+
+Roles control the signing approval queue of the shared (default) wallet:
+
+  requester  submit transactions for approval
+  approver   approve or reject transactions submitted by others`,
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a user and its wallet namespace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, err := web.ParseRoles(userRoles)
+		if err != nil {
+			return err
+		}
+		user, err := container.UserStore().Add(args[0], roles)
+		if err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "user.add", user.Name, "ok")
+		fmt.Printf("Created user %s\n", user.Name)
+		fmt.Printf("Wallet namespace: %s\n", web.UserDir(container.BaseDir, user.Name))
+		return nil
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := container.UserStore().List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tROLES\tCREATED\tSTATUS")
+		for _, user := range users {
+			status := "active"
+			if user.Disabled {
+				status = "disabled"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", user.Name, formatRoles(user.Roles),
+				view.CurrentFormatter().Date(time.Unix(user.CreatedAt, 0)), status)
+		}
+		return w.Flush()
+	},
+}
+
+var userDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a user, rejecting all of its API keys (data is kept)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := container.UserStore().SetDisabled(args[0], true); err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "user.disable", args[0], "ok")
+		fmt.Printf("Disabled user %s\n", args[0])
+		return nil
+	},
+}
+
+var userEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a disabled user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := container.UserStore().SetDisabled(args[0], false); err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "user.enable", args[0], "ok")
+		fmt.Printf("Enabled user %s\n", args[0])
+		return nil
+	},
+}
+
+var userRolesCmd = &cobra.Command{
+	Use:   "roles <name> <roles>",
+	Short: "Replace a user's roles (comma separated, \"\" for none)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, err := web.ParseRoles(args[1])
+		if err != nil {
+			return err
+		}
+		if err := container.UserStore().SetRoles(args[0], roles); err != nil {
+			return err
+		}
+		container.Audit().Record("cli", "user.roles", args[0]+" "+formatRoles(roles), "ok")
+		fmt.Printf("User %s roles: %s\n", args[0], formatRoles(roles))
+		return nil
+	},
+}
+
+func formatRoles(roles []web.Role) string {
+	if len(roles) == 0 {
+		return "-"
+	}
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ",")
+}
+
+func init() {
+	rootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(userAddCmd, userListCmd, userDisableCmd, userEnableCmd, userRolesCmd)
+
+	userAddCmd.Flags().StringVar(&userRoles, "role", "", "Comma separated roles (requester,approver)")
+}
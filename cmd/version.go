@@ -14,11 +14,18 @@ var versionCmd = &cobra.Command{
 	Long:  `The version command prints detailed information about the build of this application, including the version number, Git commit, and build environment.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		v := version.Get()
+		if versionJSON {
+			fmt.Println(v.JSON())
+			return
+		}
 		fmt.Println(v.String())
 	},
 }
 
+var versionJSON bool
+
 func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the version information as JSON")
 	// 将 versionCmd 添加到根命令 (rootCmd) 下
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/walletconnect"
+	"github.com/spf13/cobra"
+)
+
+// walletConnectCmd 通过 WalletConnect v2 与 dApp 配对，会话提案和签名请求都在终端确认
+var walletConnectCmd = &cobra.Command{
+	Use:     "walletconnect <wc-uri>",
+	Aliases: []string{"wc"},
+	Short:   "Pair with a dApp over WalletConnect v2",
+	Long: `Pair with a dApp using the "wc:" URI it displays (the same content as its
+WalletConnect QR code), then approve the session proposal and each signing
+request in this terminal. Signatures are produced by the same signer used by
+"slowmade signer"; transactions are signed but never broadcast.
+
+A WalletConnect project id is required, set walletconnect.project_id in the
+config file or SLOWMADE_WALLETCONNECT_PROJECT_ID.
+
+Examples:
+  slowmade walletconnect "wc:7f6e...@2?relay-protocol=irn&symKey=587d..."`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		uri, err := walletconnect.ParseURI(args[0])
+		if err != nil {
+			return err
+		}
+
+		appConfig := config.GetAppConfig()
+		wcConfig := appConfig.GetWalletConnectConfig()
+
+		if err := promptUnlock(); err != nil {
+			return err
+		}
+		defer lockWallet()
+
+		dec, err := newDecoder(wcABIFiles)
+		if err != nil {
+			return err
+		}
+		approver := signer.NewTerminalApprover(os.Stdin, os.Stdout)
+		s := container.Signer(approver, big.NewInt(1)).
+			Preview(dec)
+		client, err := walletconnect.NewClient(walletconnect.Config{
+			ProjectID: wcConfig.ProjectID,
+			RelayURL:  wcConfig.RelayURL,
+			Metadata: walletconnect.Metadata{
+				Name:        "slowmade",
+				Description: "A secure HD wallet",
+				URL:         "https://github.com/palagend/slowmade",
+				Icons:       []string{},
+			},
+		}, s, approver)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := client.Connect(ctx); err != nil {
+			return err
+		}
+		if err := client.Pair(ctx, uri); err != nil {
+			return err
+		}
+		fmt.Println("Paired, waiting for the session proposal. Press Ctrl+C to disconnect.")
+
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case <-quit:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			client.Disconnect(ctx)
+			fmt.Println("Disconnected, wallet locked")
+			return nil
+		case <-client.Done():
+			return fmt.Errorf("relay connection lost: %v", client.Err())
+		}
+	},
+}
+
+var wcABIFiles []string
+
+func init() {
+	rootCmd.AddCommand(walletConnectCmd)
+
+	walletConnectCmd.Flags().StringSliceVar(&wcABIFiles, "abi", nil, "Contract ABI file used to decode calldata (repeatable)")
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/spf13/cobra"
+)
+
+var watchInterval int
+
+// watchCmd 以守护进程模式运行收款监控
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch receive addresses for incoming payments",
+	Long: `Poll the configured Bitcoin and explorer backends for the wallet's derived
+receive addresses. Incoming funds are recorded in the transaction store,
+matched against open payment requests (request.create), printed as JSON lines
+and POSTed to the webhooks configured under [watch].
+
+After every poll the alert rules added with alert.add (balance below a
+threshold, a large outgoing transaction, address reuse) are evaluated;
+firing rules publish alert.fired events and are recorded in the audit log.
+
+Funds already present the first time an address is polled are recorded but
+not announced.
+
+Examples:
+  # Poll every minute (watch.interval)
+  slowmade watch
+
+  # Poll every 5 minutes
+  slowmade watch --interval 300`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appConfig := config.GetAppConfig()
+		watchConfig := appConfig.GetWatchConfig()
+		interval := watchConfig.Interval
+		if watchInterval > 0 {
+			interval = watchInterval
+		}
+		if interval < 10 {
+			interval = 10
+		}
+
+		if err := promptUnlock(); err != nil {
+			return err
+		}
+		defer lockWallet()
+
+		bus := container.EventBus()
+		bus.Subscribe(func(event events.Event) {
+			line, _ := json.Marshal(event)
+			fmt.Println(string(line))
+		})
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		fmt.Fprintf(os.Stderr, "Watching receive addresses every %ds, press Ctrl+C to stop\n", interval)
+		container.Watcher(bus).Run(ctx, time.Duration(interval)*time.Second)
+		fmt.Fprintln(os.Stderr, "Watcher stopped, wallet locked")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().IntVar(&watchInterval, "interval", 0, "Seconds between polls (default watch.interval)")
+}
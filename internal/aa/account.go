@@ -0,0 +1,83 @@
+package aa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	selectorCreateAccount = selector("createAccount(address,uint256)")
+	selectorGetAddress    = selector("getAddress(address,uint256)")
+	selectorExecute       = selector("execute(address,uint256,bytes)")
+	selectorGetNonce      = selector("getNonce(address,uint192)")
+)
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// Node 读取链上状态的以太坊节点
+type Node interface {
+	Call(ctx context.Context, to string, data []byte) ([]byte, error)
+	Code(ctx context.Context, address string) ([]byte, error)
+}
+
+// Account 一个 SimpleAccount：所有者是钱包中的 ETH 地址，同一所有者用不同 salt 得到不同账户
+type Account struct {
+	Owner      common.Address
+	Salt       *big.Int
+	Factory    common.Address
+	EntryPoint common.Address
+	Address    common.Address // 工厂 getAddress 给出的 CREATE2 地址，部署前即可收款
+}
+
+// LoadAccount 通过工厂的 getAddress 计算智能账户地址
+func LoadAccount(ctx context.Context, node Node, factory, entryPoint, owner common.Address, salt *big.Int) (*Account, error) {
+	out, err := node.Call(ctx, factory.Hex(), bytes.Join([][]byte{selectorGetAddress, common.LeftPadBytes(owner.Bytes(), 32), word(salt)}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("getAddress on factory %s: %w", factory.Hex(), err)
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("getAddress on factory %s: short result, is it a SimpleAccountFactory?", factory.Hex())
+	}
+	return &Account{Owner: owner, Salt: salt, Factory: factory, EntryPoint: entryPoint, Address: common.BytesToAddress(out[12:32])}, nil
+}
+
+// Deployed 账户合约是否已部署
+func (a *Account) Deployed(ctx context.Context, node Node) (bool, error) {
+	code, err := node.Code(ctx, a.Address.Hex())
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// InitCode 首个用户操作中部署账户的 initCode：工厂地址加 createAccount(owner, salt)
+func (a *Account) InitCode() []byte {
+	return bytes.Join([][]byte{a.Factory.Bytes(), selectorCreateAccount, common.LeftPadBytes(a.Owner.Bytes(), 32), word(a.Salt)}, nil)
+}
+
+// Nonce EntryPoint 中账户的下一个 nonce（key 为 0 的序列）
+func (a *Account) Nonce(ctx context.Context, node Node) (*big.Int, error) {
+	out, err := node.Call(ctx, a.EntryPoint.Hex(), bytes.Join([][]byte{selectorGetNonce, common.LeftPadBytes(a.Address.Bytes(), 32), word(new(big.Int))}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("getNonce on entry point %s: %w", a.EntryPoint.Hex(), err)
+	}
+	if len(out) < 32 {
+		return nil, errors.New("getNonce: short result")
+	}
+	return new(big.Int).SetBytes(out[:32]), nil
+}
+
+// ExecuteData SimpleAccount.execute(dest, value, func) 的调用数据
+func ExecuteData(to common.Address, value *big.Int, data []byte) []byte {
+	padded := common.RightPadBytes(data, (len(data)+31)/32*32)
+	return bytes.Join([][]byte{selectorExecute, common.LeftPadBytes(to.Bytes(), 32), word(value),
+		word(big.NewInt(3 * 32)), word(big.NewInt(int64(len(data)))), padded}, nil)
+}
@@ -0,0 +1,244 @@
+package aa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// 错误定义
+var (
+	ErrNoBundler       = errors.New("no bundler configured, set aa.bundler_url")
+	ErrNoPaymaster     = errors.New("no paymaster configured, set aa.paymaster_url")
+	ErrEntryPoint      = errors.New("bundler does not support the configured entry point")
+	ErrRejected        = errors.New("user operation rejected")
+	ErrSponsorDeclined = errors.New("paymaster declined to sponsor the user operation")
+)
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// rpcClient bundler 和 paymaster 共用的 JSON-RPC 客户端
+type rpcClient struct {
+	url string
+}
+
+// Name 日志和提示中显示的主机名，URL 中常带有 API key
+func (c *rpcClient) Name() string {
+	if u, err := url.Parse(c.url); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return c.url
+}
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+func (c *rpcClient) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", c.Name(), resp.Status, strings.TrimSpace(string(data)))
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("%s %s: %w", c.Name(), method, envelope.Error)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+// quantity bundler 返回的 gas 数量，不同实现使用十六进制字符串、十进制字符串或 JSON 数字
+type quantity big.Int
+
+func (q *quantity) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") {
+		n, ok = n.SetString(s[2:], 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return fmt.Errorf("invalid quantity %s", data)
+	}
+	*q = quantity(*n)
+	return nil
+}
+
+func (q *quantity) big() *hexutil.Big {
+	if q == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	n := big.Int(*q)
+	return (*hexutil.Big)(&n)
+}
+
+// Bundler ERC-4337 bundler 的 eth_* 用户操作接口
+type Bundler struct {
+	rpcClient
+}
+
+// NewBundler 创建 bundler 客户端
+func NewBundler(url string) (*Bundler, error) {
+	if url == "" {
+		return nil, ErrNoBundler
+	}
+	return &Bundler{rpcClient{url: url}}, nil
+}
+
+// CheckEntryPoint 确认 bundler 支持指定的 EntryPoint
+func (b *Bundler) CheckEntryPoint(ctx context.Context, entryPoint common.Address) error {
+	var supported []common.Address
+	if err := b.call(ctx, "eth_supportedEntryPoints", []interface{}{}, &supported); err != nil {
+		return err
+	}
+	for _, address := range supported {
+		if address == entryPoint {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrEntryPoint, entryPoint.Hex())
+}
+
+// EstimateGas 用 eth_estimateUserOperationGas 估算并填入三项 gas 上限，op 的签名应为 DummySignature
+func (b *Bundler) EstimateGas(ctx context.Context, op *UserOperation, entryPoint common.Address) error {
+	var result struct {
+		PreVerificationGas   *quantity `json:"preVerificationGas"`
+		VerificationGasLimit *quantity `json:"verificationGasLimit"`
+		CallGasLimit         *quantity `json:"callGasLimit"`
+	}
+	if err := b.call(ctx, "eth_estimateUserOperationGas", []interface{}{op, entryPoint}, &result); err != nil {
+		return fmt.Errorf("estimate user operation gas: %w", err)
+	}
+	op.PreVerificationGas, op.VerificationGasLimit, op.CallGasLimit = result.PreVerificationGas.big(), result.VerificationGasLimit.big(), result.CallGasLimit.big()
+	return nil
+}
+
+// Send 提交已签名的用户操作，返回 bundler 计算的 userOpHash
+func (b *Bundler) Send(ctx context.Context, op *UserOperation, entryPoint common.Address) (common.Hash, error) {
+	var hash common.Hash
+	if err := b.call(ctx, "eth_sendUserOperation", []interface{}{op, entryPoint}, &hash); err != nil {
+		return common.Hash{}, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return hash, nil
+}
+
+// Receipt 用户操作的执行结果
+type Receipt struct {
+	UserOpHash    common.Hash `json:"userOpHash"`
+	Sender        string      `json:"sender"`
+	Success       bool        `json:"success"`
+	Reason        string      `json:"reason"`
+	ActualGasCost *quantity   `json:"actualGasCost"`
+	ActualGasUsed *quantity   `json:"actualGasUsed"`
+	Receipt       struct {
+		TransactionHash string `json:"transactionHash"`
+		BlockNumber     string `json:"blockNumber"`
+	} `json:"receipt"`
+}
+
+// GasCost 实际支付的 gas 费用（wei）
+func (r *Receipt) GasCost() *big.Int {
+	return r.ActualGasCost.big().ToInt()
+}
+
+// Receipt 查询用户操作回执，尚未打包时返回 nil
+func (b *Bundler) Receipt(ctx context.Context, userOpHash common.Hash) (*Receipt, error) {
+	var receipt *Receipt
+	if err := b.call(ctx, "eth_getUserOperationReceipt", []interface{}{userOpHash}, &receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// Paymaster 按 pm_sponsorUserOperation 约定代付 gas 的服务
+type Paymaster struct {
+	rpcClient
+	policy string // 服务商的 sponsorshipPolicyId，为空时不传
+	token  string // 用 ERC-20 代币支付 gas 时的代币合约，为空表示完全代付
+}
+
+// NewPaymaster 创建 paymaster 客户端
+func NewPaymaster(url, policy, token string) (*Paymaster, error) {
+	if url == "" {
+		return nil, ErrNoPaymaster
+	}
+	return &Paymaster{rpcClient: rpcClient{url: url}, policy: policy, token: token}, nil
+}
+
+// Sponsor 请求 paymaster 代付，填入 paymasterAndData；服务商返回的 gas 上限覆盖 bundler 的估算。
+// 之后任何字段的修改都会使 paymaster 签名失效，应在签名前最后调用
+func (p *Paymaster) Sponsor(ctx context.Context, op *UserOperation, entryPoint common.Address) error {
+	params := []interface{}{op, entryPoint}
+	if p.policy != "" || p.token != "" {
+		sponsorContext := map[string]string{}
+		if p.policy != "" {
+			sponsorContext["sponsorshipPolicyId"] = p.policy
+		}
+		if p.token != "" {
+			sponsorContext["token"] = p.token
+		}
+		params = append(params, sponsorContext)
+	}
+	var result struct {
+		PaymasterAndData     hexutil.Bytes `json:"paymasterAndData"`
+		PreVerificationGas   *quantity     `json:"preVerificationGas"`
+		VerificationGasLimit *quantity     `json:"verificationGasLimit"`
+		CallGasLimit         *quantity     `json:"callGasLimit"`
+	}
+	if err := p.call(ctx, "pm_sponsorUserOperation", params, &result); err != nil {
+		return fmt.Errorf("%w: %v", ErrSponsorDeclined, err)
+	}
+	if len(result.PaymasterAndData) < common.AddressLength {
+		return fmt.Errorf("%w: empty paymasterAndData", ErrSponsorDeclined)
+	}
+	op.PaymasterAndData = result.PaymasterAndData
+	if result.PreVerificationGas != nil {
+		op.PreVerificationGas = result.PreVerificationGas.big()
+	}
+	if result.VerificationGasLimit != nil {
+		op.VerificationGasLimit = result.VerificationGasLimit.big()
+	}
+	if result.CallGasLimit != nil {
+		op.CallGasLimit = result.CallGasLimit.big()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package aa ERC-4337 账户抽象（实验性）：EntryPoint v0.6 的 UserOperation 和哈希、SimpleAccount 智能账户的
+// 地址和调用数据，以及 bundler 和 paymaster 的 JSON-RPC 接口
+package aa
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// eth-infinitism 在各链上以相同地址部署的 EntryPoint v0.6 和 SimpleAccountFactory v0.6
+const (
+	DefaultEntryPoint = "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"
+	DefaultFactory    = "0x9406Cc6185a346906296840746125a0E44976454"
+)
+
+// DummySignature 估算 gas 时使用的占位签名：格式有效、能被 ecrecover 处理但不对应所有者，
+// SimpleAccount 校验返回失败而不是回滚，bundler 据此模拟验证阶段
+var DummySignature = common.FromHex("0x" + strings.Repeat("ff", 15) + "f0" + strings.Repeat("00", 16) + "7a" + strings.Repeat("aa", 31) + "1c")
+
+// UserOperation EntryPoint v0.6 的用户操作，JSON 字段与 bundler 的 eth_sendUserOperation 一致
+type UserOperation struct {
+	Sender               common.Address `json:"sender"`
+	Nonce                *hexutil.Big   `json:"nonce"`
+	InitCode             hexutil.Bytes  `json:"initCode"` // 账户未部署时为工厂地址加 createAccount 调用
+	CallData             hexutil.Bytes  `json:"callData"`
+	CallGasLimit         *hexutil.Big   `json:"callGasLimit"`
+	VerificationGasLimit *hexutil.Big   `json:"verificationGasLimit"`
+	PreVerificationGas   *hexutil.Big   `json:"preVerificationGas"`
+	MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     hexutil.Bytes  `json:"paymasterAndData"` // 为空表示由智能账户自己支付 gas
+	Signature            hexutil.Bytes  `json:"signature"`
+}
+
+// NewUserOperation 创建 gas 字段为 0、带占位签名的用户操作，用于估算
+func NewUserOperation(sender common.Address, nonce *big.Int, initCode, callData []byte) *UserOperation {
+	zero := func() *hexutil.Big { return (*hexutil.Big)(new(big.Int)) }
+	return &UserOperation{Sender: sender, Nonce: (*hexutil.Big)(nonce), InitCode: initCode, CallData: callData,
+		CallGasLimit: zero(), VerificationGasLimit: zero(), PreVerificationGas: zero(), MaxFeePerGas: zero(), MaxPriorityFeePerGas: zero(),
+		PaymasterAndData: hexutil.Bytes{}, Signature: DummySignature}
+}
+
+// MaxCost 最多需要预付的 gas 费用（wei）：三项 gas 上限之和乘以 maxFeePerGas，使用 paymaster 时验证 gas 按三倍计
+func (op *UserOperation) MaxCost() *big.Int {
+	verification := new(big.Int).Set(op.VerificationGasLimit.ToInt())
+	if len(op.PaymasterAndData) > 0 {
+		verification.Mul(verification, big.NewInt(3))
+	}
+	gas := new(big.Int).Add(op.CallGasLimit.ToInt(), verification)
+	gas.Add(gas, op.PreVerificationGas.ToInt())
+	return gas.Mul(gas, op.MaxFeePerGas.ToInt())
+}
+
+// PaymasterAddress paymasterAndData 开头的 paymaster 合约地址
+func (op *UserOperation) PaymasterAddress() (common.Address, bool) {
+	if len(op.PaymasterAndData) < common.AddressLength {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(op.PaymasterAndData[:common.AddressLength]), true
+}
+
+// Hash userOpHash：keccak256(abi.encode(keccak256(pack(op)), entryPoint, chainId))，签名和查询回执都用它
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	packed := bytes.Join([][]byte{
+		common.LeftPadBytes(op.Sender.Bytes(), 32),
+		word(op.Nonce.ToInt()),
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		word(op.CallGasLimit.ToInt()),
+		word(op.VerificationGasLimit.ToInt()),
+		word(op.PreVerificationGas.ToInt()),
+		word(op.MaxFeePerGas.ToInt()),
+		word(op.MaxPriorityFeePerGas.ToInt()),
+		crypto.Keccak256(op.PaymasterAndData),
+	}, nil)
+	return crypto.Keccak256Hash(crypto.Keccak256(packed), common.LeftPadBytes(entryPoint.Bytes(), 32), word(chainID))
+}
+
+// word uint256 的 32 字节大端编码
+func word(n *big.Int) []byte {
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
@@ -0,0 +1,181 @@
+// Package addrcheck 校验外部地址：校验和、所属网络和地址类型，供 validate 命令和 /api/v1/validate 使用，
+// 让其他脚本在发送前用本钱包检查收款地址。BTC、ETH、BNB、SOL 和 SUI 在这里识别，插件币种由插件实现
+package addrcheck
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 网络名
+const (
+	Mainnet = "mainnet"
+	Testnet = "testnet"
+	Regtest = "regtest"
+)
+
+// 错误定义
+var (
+	ErrUnsupported = errors.New("address validation is not supported for this coin")
+	ErrNoNode      = errors.New("checking for contract code needs an Ethereum node")
+	ErrNoContracts = errors.New("contract detection is only available for ETH and BNB")
+)
+
+// Result 一个地址的校验结果，地址无效时 Valid 为 false，Error 给出原因
+type Result struct {
+	Coin       string   `json:"coin"`
+	Address    string   `json:"address"`
+	Valid      bool     `json:"valid"`
+	Normalized string   `json:"normalized,omitempty"`
+	Network    string   `json:"network,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Format     string   `json:"format,omitempty"`
+	Checksum   string   `json:"checksum,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// BTC 地址参数：测试网和 regtest 的 Base58 版本字节相同，都归为 testnet
+var bitcoinParams = Params{
+	HRPs: map[string]string{"bc": Mainnet, "tb": Testnet, "bcrt": Regtest},
+	Versions: map[byte]Base58Version{
+		0x00: {Mainnet, "p2pkh"}, 0x05: {Mainnet, "p2sh"},
+		0x6f: {Testnet, "p2pkh"}, 0xc4: {Testnet, "p2sh"},
+	},
+}
+
+// Validate 校验 symbol 币种的地址。network 为钱包使用的网络（rpc.network），地址属于其他网络时无效；为空时不比较。
+// 只有不认识或不支持的币种返回错误
+func Validate(symbol, address, network string) (*Result, error) {
+	symbol = strings.ToUpper(symbol)
+	if _, ok := coin.LookupSymbol(symbol); !ok {
+		return nil, fmt.Errorf("unknown coin %q", symbol)
+	}
+	info, err := classify(symbol, strings.TrimSpace(address))
+	if errors.Is(err, ErrUnsupported) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, symbol)
+	}
+	result := &Result{Coin: symbol, Address: address}
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Valid = true
+	result.Normalized, result.Network, result.Type = info.Normalized, info.Network, info.Type
+	result.Format, result.Checksum = info.Format, info.Checksum
+	if info.Note != "" {
+		result.Notes = append(result.Notes, info.Note)
+	}
+	if info.Checksum == "none" {
+		result.Notes = append(result.Notes, "the address has no checksum, a mistyped address cannot be detected")
+	}
+	if !sameNetwork(info.Network, network) {
+		result.Valid = false
+		result.Error = fmt.Sprintf("%s address, but the wallet uses %s", info.Network, network)
+	}
+	return result, nil
+}
+
+// CheckContract 通过 rpc.<coin>.<network> 的节点查询地址是否有合约代码，据此把 ETH 和 BNB 地址的类型定为 contract 或 account
+func CheckContract(ctx context.Context, result *Result, appConfig config.AppConfig) error {
+	if result.Coin != "ETH" && result.Coin != "BNB" {
+		return ErrNoContracts
+	}
+	client, err := chain.ForCoin(result.Coin, appConfig)
+	if err != nil {
+		return err
+	}
+	node, ok := client.(interface {
+		Code(ctx context.Context, address string) ([]byte, error)
+	})
+	if !ok {
+		return fmt.Errorf("%w, configure rpc.%s.%s", ErrNoNode, strings.ToLower(result.Coin), appConfig.GetRPCConfig().CurrentNetwork())
+	}
+	code, err := node.Code(ctx, result.Normalized)
+	if err != nil {
+		return fmt.Errorf("eth_getCode: %w", err)
+	}
+	result.Type = "account"
+	if len(code) > 0 {
+		result.Type = "contract"
+		result.Notes = append(result.Notes, "a contract: make sure it accepts plain transfers of this coin")
+	}
+	return nil
+}
+
+// classify 识别地址，内置币种在这里处理，其他币种交给插件
+func classify(symbol, address string) (core.AddressInfo, error) {
+	switch symbol {
+	case "BTC":
+		return bitcoinParams.Classify(address)
+	case "ETH", "BNB":
+		return classifyEVM(address)
+	case "SOL":
+		if key, err := base58.Decode(address); err != nil || len(key) != 32 {
+			return core.AddressInfo{}, fmt.Errorf("not a base58 encoded 32-byte public key")
+		}
+		return core.AddressInfo{Normalized: address, Type: "account", Format: "base58", Checksum: "none"}, nil
+	case "SUI":
+		raw := strings.ToLower(address)
+		if key, err := hex.DecodeString(strings.TrimPrefix(raw, "0x")); err != nil || len(key) != 32 || !strings.HasPrefix(raw, "0x") {
+			return core.AddressInfo{}, fmt.Errorf("expected 0x and 64 hex digits")
+		}
+		return core.AddressInfo{Normalized: raw, Type: "account", Format: "hex", Checksum: "none"}, nil
+	}
+	plugin, ok := core.LookupCoinPlugin(symbol)
+	if !ok {
+		return core.AddressInfo{}, ErrUnsupported
+	}
+	if classifier, ok := plugin.(core.AddressClassifier); ok {
+		return classifier.ClassifyAddress(address)
+	}
+	normalized, err := plugin.NormalizeAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	return core.AddressInfo{Normalized: normalized}, nil
+}
+
+// classifyEVM 以太坊地址：大小写混合时必须符合 EIP-55，全小写或全大写没有校验
+func classifyEVM(address string) (core.AddressInfo, error) {
+	if !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x") {
+		return core.AddressInfo{}, fmt.Errorf("expected 0x and 40 hex digits")
+	}
+	normalized := common.HexToAddress(address).Hex()
+	info := core.AddressInfo{Normalized: normalized, Format: "checksum", Checksum: "eip-55",
+		Note: "the same address is valid on every EVM chain, make sure the recipient expects this one"}
+	switch {
+	case address == normalized:
+	case address == strings.ToLower(address) || address == "0x"+strings.ToUpper(address[2:]):
+		info.Format, info.Checksum = "lowercase", "none"
+	default:
+		return core.AddressInfo{}, fmt.Errorf("bad EIP-55 checksum, expected %s if the letters are right", normalized)
+	}
+	if common.HexToAddress(address) == (common.Address{}) {
+		info.Note = "the zero address: coins sent here are burned"
+	}
+	return info, nil
+}
+
+// sameNetwork 地址的网络与钱包的网络是否一致；只比较 mainnet、testnet 和 regtest，signet 与测试网使用相同的地址
+func sameNetwork(address, wallet string) bool {
+	known := func(network string) bool { return network == Mainnet || network == Testnet || network == Regtest }
+	if wallet == "signet" {
+		wallet = Testnet
+	}
+	if !known(address) || !known(wallet) {
+		return true
+	}
+	// regtest 的 Base58 地址与测试网相同
+	return address == wallet || (address == Testnet && wallet == Regtest)
+}
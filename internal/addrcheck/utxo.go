@@ -0,0 +1,82 @@
+package addrcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/bech32"
+)
+
+// Base58Version Base58Check 版本字节对应的网络和脚本类型
+type Base58Version struct {
+	Network string
+	Type    string
+}
+
+// Params 与比特币编码方式相同的币种（BTC、LTC、DOGE）的地址参数
+type Params struct {
+	HRPs     map[string]string      // 隔离见证地址的前缀及其网络，没有隔离见证的币种为空
+	Versions map[byte]Base58Version // Base58Check 地址的版本字节
+}
+
+// Classify 识别隔离见证地址或 21 字节的 Base58Check 地址
+func (p Params) Classify(address string) (core.AddressInfo, error) {
+	if hrp, network, ok := p.segwitPrefix(address); ok {
+		return classifySegwit(hrp, network, address)
+	}
+	payload, err := base58.CheckDecode(address)
+	if err != nil {
+		return core.AddressInfo{}, fmt.Errorf("bad base58check checksum or characters")
+	}
+	if len(payload) != 21 {
+		return core.AddressInfo{}, fmt.Errorf("base58check payload has %d bytes, expected 21", len(payload))
+	}
+	version, ok := p.Versions[payload[0]]
+	if !ok {
+		return core.AddressInfo{}, fmt.Errorf("unknown version byte 0x%02x", payload[0])
+	}
+	info := core.AddressInfo{Normalized: address, Network: version.Network, Type: version.Type, Format: "base58", Checksum: "base58check"}
+	if version.Type == "p2sh" {
+		info.Note = "the script behind a p2sh address (multisig, wrapped SegWit) is not visible in the address"
+	}
+	return info, nil
+}
+
+// segwitPrefix 地址是否以某个隔离见证前缀加分隔符 1 开头，较长的前缀优先（bcrt 先于 bc）
+func (p Params) segwitPrefix(address string) (string, string, bool) {
+	lower := strings.ToLower(address)
+	best := ""
+	for hrp := range p.HRPs {
+		if strings.HasPrefix(lower, hrp+"1") && len(hrp) > len(best) {
+			best = hrp
+		}
+	}
+	return best, p.HRPs[best], best != ""
+}
+
+// classifySegwit 按见证版本和程序长度给出类型：v0 为 P2WPKH 或 P2WSH（bech32），v1 的 32 字节为 Taproot（bech32m）
+func classifySegwit(hrp, network, address string) (core.AddressInfo, error) {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return core.AddressInfo{}, fmt.Errorf("mixed-case bech32 address")
+	}
+	lower := strings.ToLower(address)
+	version, program, err := bech32.DecodeSegwitAddress(hrp, lower)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	info := core.AddressInfo{Normalized: lower, Network: network, Format: "bech32", Checksum: "bech32"}
+	switch {
+	case version == 0 && len(program) == 20:
+		info.Type = "p2wpkh"
+	case version == 0:
+		info.Type = "p2wsh"
+	case version == 1 && len(program) == 32:
+		info.Type, info.Format, info.Checksum = "p2tr", "bech32m", "bech32m"
+	default:
+		info.Type, info.Format, info.Checksum = fmt.Sprintf("witness-v%d", version), "bech32m", "bech32m"
+		info.Note = "a witness version without defined spending rules, coins sent here may be lost"
+	}
+	return info, nil
+}
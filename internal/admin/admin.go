@@ -0,0 +1,35 @@
+// Package admin serve 模式的本地管理接口。接口监听数据目录中的 unix socket（权限 0600，
+// 只有运行 serve 的用户能连接），slowmade ctl 通过它查看状态、锁定和解锁钱包、
+// 重新加载配置、触发备份和跟踪审计日志，不需要连接到 serve 所在的终端
+package admin
+
+import (
+	"errors"
+	"time"
+)
+
+// SocketName 管理接口的 socket 在数据目录中的文件名
+const SocketName = "admin.sock"
+
+// ErrNotRunning 没有 serve 在监听管理接口
+var ErrNotRunning = errors.New("no running slowmade serve found")
+
+// Status serve 进程的运行状态
+type Status struct {
+	Version    string     `json:"version"`
+	PID        int        `json:"pid"`
+	Started    time.Time  `json:"started"`
+	DataDir    string     `json:"data_dir"`
+	Listen     string     `json:"listen"` // Web 服务的监听地址
+	Locked     bool       `json:"locked"`
+	LastBackup *time.Time `json:"last_backup,omitempty"`
+}
+
+// Handler 管理操作的实现，由 serve 提供
+type Handler interface {
+	Status() Status
+	Lock() error
+	Unlock(password string) error // 密码错误时返回 core.ErrInvalidPassword
+	Reload() error
+	Backup() (string, error) // 返回备份文件路径
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// Client 连接 serve 管理接口的客户端
+type Client struct {
+	path string
+	http *http.Client
+}
+
+// NewClient 创建连接 path 上管理接口的客户端，连接在每次请求时建立
+func NewClient(path string) *Client {
+	dialer := &net.Dialer{}
+	return &Client{
+		path: path,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		}},
+	}
+}
+
+// Status 查询 serve 的运行状态
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.call(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Lock 锁定钱包并清除 serve 内存中的密码
+func (c *Client) Lock(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/lock", nil, nil)
+}
+
+// Unlock 用密码解锁钱包，密码错误时返回 core.ErrInvalidPassword
+func (c *Client) Unlock(ctx context.Context, password string) error {
+	return c.call(ctx, http.MethodPost, "/unlock", unlockRequest{Password: password}, nil)
+}
+
+// Reload 让 serve 重新读取配置文件
+func (c *Client) Reload(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/reload", nil, nil)
+}
+
+// Backup 让 serve 按 backup.dir 和 backup.recipients 写入一个备份，返回备份文件路径
+func (c *Client) Backup(ctx context.Context) (string, error) {
+	var resp struct {
+		Path string `json:"path"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/backup", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Path, nil
+}
+
+// Audit 读取最后 lines 条审计记录并逐条交给 fn；follow 时继续等待新的记录，直到 ctx 取消或 fn 返回错误
+func (c *Client) Audit(ctx context.Context, lines int, follow bool, fn func(audit.Entry) error) error {
+	query := url.Values{"lines": {strconv.Itoa(lines)}, "follow": {strconv.FormatBool(follow)}}
+	resp, err := c.do(ctx, http.MethodGet, "/audit?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry audit.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("解码审计日志失败: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解码管理接口响应失败: %w", err)
+	}
+	return nil
+}
+
+// do 发送请求，非 200 响应转换为错误
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://slowmade"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w on %s", ErrNotRunning, c.path)
+		}
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	var failure errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == "" {
+		failure.Error = resp.Status
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, &remoteError{message: failure.Error, kind: core.ErrInvalidPassword}
+	}
+	return nil, errors.New(failure.Error)
+}
+
+// remoteError serve 返回的错误，消息原样显示，errors.Is 可以判断错误类型（用于退出码）
+type remoteError struct {
+	message string
+	kind    error
+}
+
+func (e *remoteError) Error() string { return e.message }
+func (e *remoteError) Unwrap() error { return e.kind }
@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// auditPoll 跟踪审计日志时检查新记录的间隔
+const auditPoll = time.Second
+
+// Server 管理接口的 unix socket 服务
+type Server struct {
+	listener  net.Listener
+	server    *http.Server
+	handler   Handler
+	auditPath string
+}
+
+// Listen 在 path 上监听管理接口，auditPath 为跟踪的审计日志；
+// 已有 serve 在监听时返回错误，上次异常退出留下的 socket 文件会被删除
+func Listen(path string, handler Handler, auditPath string) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another slowmade serve is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("删除失效的管理 socket 失败: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("监听管理 socket 失败: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("设置管理 socket 权限失败: %w", err)
+	}
+	s := &Server{listener: listener, handler: handler, auditPath: auditPath}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.status)
+	mux.HandleFunc("POST /lock", s.lock)
+	mux.HandleFunc("POST /unlock", s.unlock)
+	mux.HandleFunc("POST /reload", s.reload)
+	mux.HandleFunc("POST /backup", s.backup)
+	mux.HandleFunc("GET /audit", s.audit)
+	// 跟踪审计日志的连接会一直打开，不设置写超时
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Warnf("Admin socket stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Close 停止服务并删除 socket 文件
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.handler.Status())
+}
+
+func (s *Server) lock(w http.ResponseWriter, r *http.Request) {
+	if err := s.handler.Lock(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"locked": true})
+}
+
+type unlockRequest struct {
+	Password string `json:"password"`
+}
+
+func (s *Server) unlock(w http.ResponseWriter, r *http.Request) {
+	var req unlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := s.handler.Unlock(req.Password); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidPassword) {
+			status = http.StatusUnauthorized
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"locked": false})
+}
+
+func (s *Server) reload(w http.ResponseWriter, r *http.Request) {
+	if err := s.handler.Reload(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"reloaded": true})
+}
+
+func (s *Server) backup(w http.ResponseWriter, r *http.Request) {
+	path, err := s.handler.Backup()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"path": path})
+}
+
+// audit 以每行一个 JSON 的形式返回最后 lines 条审计记录，follow=true 时继续发送新的记录直到连接断开
+func (s *Server) audit(w http.ResponseWriter, r *http.Request) {
+	lines := 10
+	if value := r.URL.Query().Get("lines"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid lines %q", value))
+			return
+		}
+		lines = n
+	}
+	follow := r.URL.Query().Get("follow") == "true"
+
+	tail, offset, err := readFrom(s.auditPath, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(tail) > lines {
+		tail = tail[len(tail)-lines:]
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(records [][]byte) error {
+		for _, record := range records {
+			if _, err := w.Write(append(record, '\n')); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	if err := send(tail); err != nil || !follow {
+		return
+	}
+
+	ticker := time.NewTicker(auditPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		records, next, err := readFrom(s.auditPath, offset)
+		if err != nil {
+			logging.Warnf("Failed to follow the audit log: %v", err)
+			return
+		}
+		offset = next
+		if err := send(records); err != nil {
+			return
+		}
+	}
+}
+
+// readFrom 从 offset 开始读取完整的审计记录行，返回这些行和读到的位置；
+// 文件被截断或替换（变短）时从头读取，不存在时没有记录
+func readFrom(path string, offset int64) ([][]byte, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, offset, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	var records [][]byte
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// 没有换行符的最后一行还在写入，下次再读
+			return records, offset, nil
+		}
+		if err != nil {
+			return nil, offset, err
+		}
+		offset += int64(len(line))
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			records = append(records, line)
+		}
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
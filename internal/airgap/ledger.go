@@ -0,0 +1,162 @@
+package airgap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// LedgerFileName 签名请求记录在数据目录中的文件名
+const LedgerFileName = "airgap.json"
+
+// replayGrace 签名端在请求过期后继续保留请求 ID 的时间，容忍两台机器的时钟偏差
+const replayGrace = 24 * time.Hour
+
+// 观察实例中请求的状态
+const (
+	IssuedPending  = "pending"
+	IssuedSigned   = StatusSigned
+	IssuedRejected = StatusRejected
+)
+
+// Issued 观察实例发出的请求及其结果
+type Issued struct {
+	Request    *Request  `json:"request"`
+	Status     string    `json:"status"`
+	AcceptedAt time.Time `json:"accepted_at,omitempty"`
+	Result     string    `json:"result,omitempty"`
+	TxID       string    `json:"txid,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Expired 请求仍在等待响应但已过期
+func (i *Issued) Expired(now time.Time) bool {
+	return i.Status == IssuedPending && i.Request.Expired(now)
+}
+
+// Ledger 数据目录中的签名请求记录：观察实例发出的请求，以及签名实例处理过的请求 ID
+type Ledger struct {
+	mu        sync.Mutex
+	path      string
+	Requests  []*Issued            `json:"requests"`
+	Processed map[string]time.Time `json:"processed"` // 请求 ID -> 请求的过期时间
+}
+
+// LoadLedger 加载签名请求记录，文件不存在时返回空记录
+func LoadLedger(path string) (*Ledger, error) {
+	l := &Ledger{path: path, Processed: make(map[string]time.Time)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("解码签名请求记录失败: %w", err)
+	}
+	if l.Processed == nil {
+		l.Processed = make(map[string]time.Time)
+	}
+	return l, nil
+}
+
+// Issue 记录观察实例发出的请求
+func (l *Ledger) Issue(req *Request) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Requests = append(l.Requests, &Issued{Request: req, Status: IssuedPending})
+	if err := l.save(); err != nil {
+		l.Requests = l.Requests[:len(l.Requests)-1]
+		return err
+	}
+	return nil
+}
+
+// List 返回发出的请求，按创建时间从新到旧排序；all 为 false 时只返回等待响应的请求
+func (l *Ledger) List(all bool) []*Issued {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []*Issued
+	for _, issued := range l.Requests {
+		if all || issued.Status == IssuedPending {
+			result = append(result, issued)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Request.CreatedAt.After(result[j].Request.CreatedAt)
+	})
+	return result
+}
+
+// Accept 接收签名实例的响应：必须对应本实例发出、尚未完成的请求，且签名的是原请求内容
+func (l *Ledger) Accept(resp *Response) (*Issued, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var issued *Issued
+	for _, candidate := range l.Requests {
+		if candidate.Request.ID == resp.RequestID {
+			issued = candidate
+			break
+		}
+	}
+	if issued == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRequest, resp.RequestID)
+	}
+	if issued.Status != IssuedPending {
+		return nil, fmt.Errorf("%w: %s is already %s", ErrReplayed, resp.RequestID, issued.Status)
+	}
+	hash, err := issued.Request.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != resp.RequestHash {
+		return nil, fmt.Errorf("%w: %s", ErrRequestMismatch, resp.RequestID)
+	}
+	issued.Status = resp.Status
+	issued.AcceptedAt = time.Now().UTC().Truncate(time.Second)
+	issued.Result, issued.TxID, issued.Error = resp.Result, resp.TxID, resp.Error
+	return issued, l.save()
+}
+
+// Consume 签名实例处理请求前调用：拒绝过期和已处理过的请求，并在签名前记下请求 ID，
+// 签名中途失败的请求也不能再次提交。过期足够久的记录同时被清理
+func (l *Ledger) Consume(req *Request, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if req.Expired(now) {
+		return fmt.Errorf("%w: %s expired %s", ErrExpired, req.ID, req.ExpiresAt.Format(time.RFC3339))
+	}
+	if _, ok := l.Processed[req.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrReplayed, req.ID)
+	}
+	for id, expires := range l.Processed {
+		if now.After(expires.Add(replayGrace)) {
+			delete(l.Processed, id)
+		}
+	}
+	l.Processed[req.ID] = req.ExpiresAt
+	return l.save()
+}
+
+func (l *Ledger) save() error {
+	data, err := canonjson.MarshalIndent(l, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := l.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入签名请求记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, l.path); err != nil {
+		return fmt.Errorf("重命名签名请求记录失败: %w", err)
+	}
+	return nil
+}
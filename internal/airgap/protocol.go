@@ -0,0 +1,385 @@
+// Package airgap 冷热分离的签名请求协议：联网的观察实例准备签名请求，离线的签名实例校验、签名后生成响应，
+// 观察实例再接收响应取回签名结果。请求和响应都是规范 JSON（见 pkg/canonjson），可以保存为文件拷贝，
+// 也可以编码为 ur:bytes 二维码序列传递。
+//
+// 签名请求（type 为 slowmade/sign-request）：
+//
+//	version     协议版本，当前为 1
+//	id          32 位十六进制随机请求 ID
+//	created_at  创建时间（RFC 3339，UTC）
+//	expires_at  过期时间，签名端拒绝签名过期的请求
+//	wallet      可选，主密钥指纹，与签名端钱包不一致时拒绝
+//	method      eth_signTransaction、personal_sign 或 btc_signTransaction
+//	payload     待签名内容，格式由 method 决定，见下
+//	paths       可选，签名地址到完整派生路径的映射，签名端用自己派生的路径核对
+//	metadata    可选，字符串键值（如 memo），只用于展示
+//
+// payload 格式：
+//
+//	eth_signTransaction  以太坊 JSON-RPC 的交易参数：from、to、nonce、gas、chainId、value、data 等，
+//	                     数量为 0x 十六进制；签名端不连接节点，nonce、gas 和 chainId 必须给出
+//	personal_sign        {"account": "0x...", "message": "0x..."}
+//	btc_signTransaction  {"inputs": [{"txid", "vout", "value", "address", "script"}], "outputs": [{"address", "value"}]}，
+//	                     金额单位为聪，输入和输出之差为手续费
+//
+// 签名响应（type 为 slowmade/sign-response）：
+//
+//	version       协议版本
+//	request_id    对应的请求 ID
+//	request_hash  请求规范编码的 SHA-256（十六进制），观察实例据此确认响应签的是自己发出的内容
+//	signed_at     签名端处理请求的时间
+//	status        signed 或 rejected
+//	result        signed 时的结果：ETH 交易和消息签名为 0x 十六进制，BTC 为原始交易的十六进制
+//	txid          BTC 交易 ID
+//	error         rejected 时的原因
+//
+// 行情快照（type 为 slowmade/market-snapshot）：联网实例导出当前的手续费和汇率，用自己的 ed25519 密钥签名，
+// 离线签名端导入后在签名时据此核对手续费和法币价值，并提示快照是否过期：
+//
+//	version        协议版本
+//	created_at     导出时间
+//	currency       prices 的法币
+//	prices         币种 -> 每个币的法币价格（十进制字符串）
+//	btc_fee_rates  确认目标区块数 -> 费率（sat/vB）
+//	eth_gas_price  gas 价格（wei，十进制字符串）
+//	public_key     签名公钥（十六进制），签名端首次导入后固定，更换需要确认
+//	signature      去掉 signature 字段后规范编码的 ed25519 签名（十六进制）
+//
+// 防重放：签名端记录处理过的请求 ID，同一请求只处理一次，记录保留到请求过期之后；
+// 观察实例只接受自己发出、尚未完成的请求的响应，每个请求只接受一次
+package airgap
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// 协议常量
+const (
+	Version      = 1
+	RequestType  = "slowmade/sign-request"
+	ResponseType = "slowmade/sign-response"
+)
+
+// 签名方法
+const (
+	MethodETHTransaction = "eth_signTransaction"
+	MethodPersonalSign   = "personal_sign"
+	MethodBTCTransaction = "btc_signTransaction"
+)
+
+// 响应状态
+const (
+	StatusSigned   = "signed"
+	StatusRejected = "rejected"
+)
+
+// 错误定义
+var (
+	ErrMalformed          = errors.New("malformed air-gap message")
+	ErrUnsupportedVersion = errors.New("unsupported air-gap protocol version")
+	ErrUnknownMethod      = errors.New("unknown signing method")
+	ErrExpired            = errors.New("signing request expired")
+	ErrReplayed           = errors.New("signing request already processed")
+	ErrUnknownRequest     = errors.New("response does not match an outstanding request")
+	ErrRequestMismatch    = errors.New("response was made for different request content")
+	ErrWrongWallet        = errors.New("signing request is for a different wallet")
+)
+
+// Request 观察实例发给签名实例的签名请求
+type Request struct {
+	Type      string            `json:"type"`
+	Version   int               `json:"version"`
+	ID        string            `json:"id"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Wallet    string            `json:"wallet,omitempty"`
+	Method    string            `json:"method"`
+	Payload   json.RawMessage   `json:"payload"`
+	Paths     map[string]string `json:"paths,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// MessagePayload personal_sign 的待签名内容
+type MessagePayload struct {
+	Account common.Address `json:"account"`
+	Message hexutil.Bytes  `json:"message"`
+}
+
+// BTCPayload btc_signTransaction 的待签名内容
+type BTCPayload struct {
+	Inputs  []btc.UTXO  `json:"inputs"`
+	Outputs []BTCOutput `json:"outputs"`
+}
+
+// BTCOutput 比特币交易输出
+type BTCOutput struct {
+	Address string `json:"address"`
+	Value   int64  `json:"value"` // 金额（聪）
+}
+
+// Response 签名实例对请求的响应
+type Response struct {
+	Type        string    `json:"type"`
+	Version     int       `json:"version"`
+	RequestID   string    `json:"request_id"`
+	RequestHash string    `json:"request_hash"`
+	SignedAt    time.Time `json:"signed_at"`
+	Status      string    `json:"status"`
+	Result      string    `json:"result,omitempty"`
+	TxID        string    `json:"txid,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// NewRequest 创建 ttl 后过期的签名请求，payload 先按方法校验
+func NewRequest(method string, payload json.RawMessage, ttl time.Duration) (*Request, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	req := &Request{
+		Type:      RequestType,
+		Version:   Version,
+		ID:        hex.EncodeToString(id),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Method:    method,
+		Payload:   payload,
+	}
+	if err := req.validatePayload(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ParseRequest 解码并校验签名请求，不检查是否过期
+func ParseRequest(data []byte) (*Request, error) {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if req.Type != RequestType {
+		return nil, fmt.Errorf("%w: not a signing request (type %q)", ErrMalformed, req.Type)
+	}
+	if req.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, req.Version)
+	}
+	if !validID(req.ID) {
+		return nil, fmt.Errorf("%w: invalid request id %q", ErrMalformed, req.ID)
+	}
+	if !req.ExpiresAt.After(req.CreatedAt) {
+		return nil, fmt.Errorf("%w: expires_at must be after created_at", ErrMalformed)
+	}
+	if err := req.validatePayload(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Expired 请求在 now 时是否已过期
+func (req *Request) Expired(now time.Time) bool {
+	return !now.Before(req.ExpiresAt)
+}
+
+// Hash 请求规范编码的 SHA-256
+func (req *Request) Hash() (string, error) {
+	data, err := canonjson.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Encode 请求的规范 JSON 编码
+func (req *Request) Encode() ([]byte, error) {
+	return canonjson.MarshalIndent(req, "  ")
+}
+
+// ETHTransaction 解码 eth_signTransaction 的交易参数
+func (req *Request) ETHTransaction() (*signer.TransactionArgs, error) {
+	var args signer.TransactionArgs
+	if err := req.decodePayload(MethodETHTransaction, &args); err != nil {
+		return nil, err
+	}
+	return &args, nil
+}
+
+// Message 解码 personal_sign 的账户和消息
+func (req *Request) Message() (*MessagePayload, error) {
+	var payload MessagePayload
+	if err := req.decodePayload(MethodPersonalSign, &payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// BTCTransaction 解码 btc_signTransaction 的输入和输出
+func (req *Request) BTCTransaction() (*BTCPayload, error) {
+	var payload BTCPayload
+	if err := req.decodePayload(MethodBTCTransaction, &payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// Signers 请求需要签名的地址
+func (req *Request) Signers() ([]string, error) {
+	switch req.Method {
+	case MethodETHTransaction:
+		args, err := req.ETHTransaction()
+		if err != nil {
+			return nil, err
+		}
+		return []string{args.From.Hex()}, nil
+	case MethodPersonalSign:
+		payload, err := req.Message()
+		if err != nil {
+			return nil, err
+		}
+		return []string{payload.Account.Hex()}, nil
+	case MethodBTCTransaction:
+		payload, err := req.BTCTransaction()
+		if err != nil {
+			return nil, err
+		}
+		var addresses []string
+		seen := make(map[string]bool)
+		for _, input := range payload.Inputs {
+			if !seen[input.Address] {
+				seen[input.Address] = true
+				addresses = append(addresses, input.Address)
+			}
+		}
+		return addresses, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownMethod, req.Method)
+}
+
+func (req *Request) validatePayload() error {
+	switch req.Method {
+	case MethodETHTransaction:
+		args, err := req.ETHTransaction()
+		if err != nil {
+			return err
+		}
+		if _, err := args.ToTransaction(nil); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+	case MethodPersonalSign:
+		if _, err := req.Message(); err != nil {
+			return err
+		}
+	case MethodBTCTransaction:
+		payload, err := req.BTCTransaction()
+		if err != nil {
+			return err
+		}
+		if len(payload.Inputs) == 0 || len(payload.Outputs) == 0 {
+			return fmt.Errorf("%w: a bitcoin transaction needs inputs and outputs", ErrMalformed)
+		}
+		var in, out int64
+		for _, input := range payload.Inputs {
+			in += input.Value
+		}
+		for _, output := range payload.Outputs {
+			if output.Value <= 0 {
+				return fmt.Errorf("%w: output to %s has no value", ErrMalformed, output.Address)
+			}
+			out += output.Value
+		}
+		if out > in {
+			return fmt.Errorf("%w: outputs exceed inputs", ErrMalformed)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownMethod, req.Method)
+	}
+	return nil
+}
+
+func (req *Request) decodePayload(method string, v interface{}) error {
+	if req.Method != method {
+		return fmt.Errorf("%w: request is %s, not %s", ErrMalformed, req.Method, method)
+	}
+	if err := json.Unmarshal(req.Payload, v); err != nil {
+		return fmt.Errorf("%w: %s payload: %v", ErrMalformed, method, err)
+	}
+	return nil
+}
+
+// NewResponse 创建签名成功的响应
+func NewResponse(req *Request, result, txid string) (*Response, error) {
+	return newResponse(req, StatusSigned, result, txid, "")
+}
+
+// RejectResponse 创建拒绝签名的响应
+func RejectResponse(req *Request, reason string) (*Response, error) {
+	return newResponse(req, StatusRejected, "", "", reason)
+}
+
+func newResponse(req *Request, status, result, txid, reason string) (*Response, error) {
+	hash, err := req.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Type:        ResponseType,
+		Version:     Version,
+		RequestID:   req.ID,
+		RequestHash: hash,
+		SignedAt:    time.Now().UTC().Truncate(time.Second),
+		Status:      status,
+		Result:      result,
+		TxID:        txid,
+		Error:       reason,
+	}, nil
+}
+
+// ParseResponse 解码并校验签名响应
+func ParseResponse(data []byte) (*Response, error) {
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if resp.Type != ResponseType {
+		return nil, fmt.Errorf("%w: not a signing response (type %q)", ErrMalformed, resp.Type)
+	}
+	if resp.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, resp.Version)
+	}
+	if !validID(resp.RequestID) {
+		return nil, fmt.Errorf("%w: invalid request id %q", ErrMalformed, resp.RequestID)
+	}
+	switch resp.Status {
+	case StatusSigned:
+		if resp.Result == "" {
+			return nil, fmt.Errorf("%w: signed response without a result", ErrMalformed)
+		}
+	case StatusRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrMalformed, resp.Status)
+	}
+	return &resp, nil
+}
+
+// Encode 响应的规范 JSON 编码
+func (resp *Response) Encode() ([]byte, error) {
+	return canonjson.MarshalIndent(resp, "  ")
+}
+
+func validID(id string) bool {
+	decoded, err := hex.DecodeString(id)
+	return err == nil && len(decoded) == 16
+}
@@ -0,0 +1,204 @@
+package airgap
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// SnapshotType 行情快照的类型
+const SnapshotType = "slowmade/market-snapshot"
+
+// SnapshotFileName 签名端导入的快照在数据目录中的文件名
+const SnapshotFileName = "market_snapshot.json"
+
+// SnapshotKeyFileName 联网实例签名快照的私钥在数据目录中的文件名
+const SnapshotKeyFileName = "snapshot_key"
+
+// 快照错误
+var (
+	ErrSnapshotSignature = errors.New("market snapshot signature is invalid")
+	ErrSnapshotKey       = errors.New("market snapshot is signed by a different key than the one trusted here")
+	ErrSnapshotOlder     = errors.New("market snapshot is older than the one already imported")
+)
+
+// Snapshot 联网实例导出的手续费和汇率
+type Snapshot struct {
+	Type        string            `json:"type"`
+	Version     int               `json:"version"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Currency    string            `json:"currency,omitempty"`
+	Prices      map[string]string `json:"prices,omitempty"`
+	BTCFeeRates map[string]int64  `json:"btc_fee_rates,omitempty"`
+	ETHGasPrice string            `json:"eth_gas_price,omitempty"`
+	PublicKey   string            `json:"public_key"`
+	Signature   string            `json:"signature,omitempty"`
+}
+
+// NewSnapshot 创建当前时间的空快照
+func NewSnapshot(currency string) *Snapshot {
+	return &Snapshot{Type: SnapshotType, Version: Version, CreatedAt: time.Now().UTC().Truncate(time.Second), Currency: currency,
+		Prices: make(map[string]string), BTCFeeRates: make(map[string]int64)}
+}
+
+// signingBytes 签名覆盖的内容：不含 signature 的规范编码
+func (s *Snapshot) signingBytes() ([]byte, error) {
+	unsigned := *s
+	unsigned.Signature = ""
+	return canonjson.Marshal(&unsigned)
+}
+
+// Sign 用 key 签名快照并写入公钥
+func (s *Snapshot) Sign(key ed25519.PrivateKey) error {
+	s.PublicKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	data, err := s.signingBytes()
+	if err != nil {
+		return err
+	}
+	s.Signature = hex.EncodeToString(ed25519.Sign(key, data))
+	return nil
+}
+
+// Encode 快照的规范 JSON 编码
+func (s *Snapshot) Encode() ([]byte, error) {
+	return canonjson.MarshalIndent(s, "  ")
+}
+
+// ParseSnapshot 解码快照并校验签名，不检查是否过期或公钥是否可信
+func ParseSnapshot(data []byte) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if s.Type != SnapshotType {
+		return nil, fmt.Errorf("%w: not a market snapshot (type %q)", ErrMalformed, s.Type)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, s.Version)
+	}
+	pub, err := hex.DecodeString(s.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: invalid public key", ErrMalformed)
+	}
+	sig, err := hex.DecodeString(s.Signature)
+	if err != nil {
+		return nil, ErrSnapshotSignature
+	}
+	data, err = s.signingBytes()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return nil, ErrSnapshotSignature
+	}
+	for coin, value := range s.Prices {
+		if rate, ok := new(big.Rat).SetString(value); !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: invalid %s price %q", ErrMalformed, coin, value)
+		}
+	}
+	if s.ETHGasPrice != "" {
+		if _, ok := new(big.Int).SetString(s.ETHGasPrice, 10); !ok {
+			return nil, fmt.Errorf("%w: invalid gas price %q", ErrMalformed, s.ETHGasPrice)
+		}
+	}
+	return &s, nil
+}
+
+// Fingerprint 签名公钥的简短形式，用于向用户展示和核对
+func (s *Snapshot) Fingerprint() string {
+	if len(s.PublicKey) < 16 {
+		return s.PublicKey
+	}
+	return s.PublicKey[:16]
+}
+
+// Price 币种的法币价格
+func (s *Snapshot) Price(coin string) (*big.Rat, bool) {
+	value, ok := s.Prices[coin]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(value)
+}
+
+// GasPrice ETH gas 价格（wei）
+func (s *Snapshot) GasPrice() (*big.Int, bool) {
+	if s.ETHGasPrice == "" {
+		return nil, false
+	}
+	return new(big.Int).SetString(s.ETHGasPrice, 10)
+}
+
+// Trust 检查 s 可以替换已导入的快照 current：公钥一致（trustNew 时允许更换），且不早于 current
+func (s *Snapshot) Trust(current *Snapshot, trustNew bool) error {
+	if current == nil {
+		return nil
+	}
+	if current.PublicKey != s.PublicKey && !trustNew {
+		return fmt.Errorf("%w: trusted %s, got %s", ErrSnapshotKey, current.Fingerprint(), s.Fingerprint())
+	}
+	if current.PublicKey == s.PublicKey && s.CreatedAt.Before(current.CreatedAt) {
+		return fmt.Errorf("%w (%s)", ErrSnapshotOlder, current.CreatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// LoadSnapshot 读取签名端已导入的快照，文件不存在时返回 nil
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseSnapshot(data)
+}
+
+// SaveSnapshot 保存导入的快照
+func SaveSnapshot(path string, s *Snapshot) error {
+	data, err := s.Encode()
+	if err != nil {
+		return err
+	}
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入行情快照失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名行情快照失败: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshotKey 读取联网实例的快照签名私钥，不存在时生成；created 表示新生成
+func LoadSnapshotKey(path string) (key ed25519.PrivateKey, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := hex.DecodeString(string(data))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, false, fmt.Errorf("invalid snapshot key file %s", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), false, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+	_, key, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	// 私钥的前 32 字节即种子（ed25519.PrivateKey.Seed）
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key[:ed25519.SeedSize])), 0600); err != nil {
+		return nil, false, fmt.Errorf("写入快照签名密钥失败: %w", err)
+	}
+	return key, true, nil
+}
@@ -0,0 +1,339 @@
+// Package alert 告警规则：余额低于阈值、单笔支出超过阈值和地址复用，由收款监控每轮查询后求值，
+// 触发时发布 alert.fired 事件，用于无人值守的部署
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// FileName 告警规则在数据目录中的文件名
+const FileName = "alerts.json"
+
+// Fired 告警触发的事件类型
+const Fired = "alert.fired"
+
+// 规则类型
+const (
+	BalanceBelow = "balance.below" // 账户余额低于阈值，恢复到阈值以上之前不再重复触发
+	TxAbove      = "tx.above"      // 单笔支出（不含手续费）超过阈值
+	AddressReuse = "address.reuse" // 已收过款的收款地址再次收到付款
+)
+
+// Kinds 全部规则类型
+var Kinds = []string{BalanceBelow, TxAbove, AddressReuse}
+
+// 错误定义
+var (
+	ErrRuleNotFound = errors.New("alert rule not found")
+	ErrUnknownKind  = errors.New("unknown alert kind")
+)
+
+// Rule 一条告警规则，Coin 和 AccountID 为空表示不限；阈值规则必须指定币种
+type Rule struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Coin      string    `json:"coin,omitempty"`
+	AccountID string    `json:"account_id,omitempty"`
+	Threshold string    `json:"threshold,omitempty"` // 最小单位
+	CreatedAt time.Time `json:"created_at"`
+	Firing    bool      `json:"firing,omitempty"`  // balance.below 已触发，余额恢复后复位
+	Checked   time.Time `json:"checked,omitempty"` // tx.above 已检查到的支出时间
+}
+
+// Describe 规则的一行说明
+func (r *Rule) Describe() string {
+	scope := "any coin"
+	if r.Coin != "" {
+		scope = r.Coin
+	}
+	if r.AccountID != "" {
+		scope += " account " + r.AccountID
+	}
+	switch r.Kind {
+	case BalanceBelow:
+		return fmt.Sprintf("balance of %s below %s", scope, formatAmount(r.Coin, r.Threshold))
+	case TxAbove:
+		return fmt.Sprintf("single outgoing tx of %s above %s", scope, formatAmount(r.Coin, r.Threshold))
+	default:
+		return "address reuse on " + scope
+	}
+}
+
+// matches 规则是否适用于该币种和账户
+func (r *Rule) matches(symbol, accountID string) bool {
+	return (r.Coin == "" || r.Coin == symbol) && (r.AccountID == "" || r.AccountID == accountID)
+}
+
+// Alert alert.fired 事件的数据
+type Alert struct {
+	RuleID    string `json:"rule_id"`
+	Kind      string `json:"kind"`
+	Coin      string `json:"coin"`
+	AccountID string `json:"account_id,omitempty"`
+	Address   string `json:"address,omitempty"`
+	TxID      string `json:"txid,omitempty"`
+	Amount    string `json:"amount,omitempty"`    // 最小单位：余额或支出金额
+	Threshold string `json:"threshold,omitempty"` // 最小单位
+	Message   string `json:"message"`
+}
+
+// Describe 通知的标题和正文
+func (a Alert) Describe() (string, string) {
+	return "Alert: " + a.Kind, a.Message
+}
+
+// Balance 一个账户本轮查询到的余额
+type Balance struct {
+	Coin      string
+	AccountID string
+	Amount    *big.Int
+}
+
+// Spend 一笔本钱包广播的支出
+type Spend struct {
+	Coin      string
+	AccountID string
+	TxID      string
+	To        string
+	Amount    *big.Int
+	SentAt    time.Time
+}
+
+// Reuse 已收过款的地址再次收到的一笔付款
+type Reuse struct {
+	Coin      string
+	AccountID string
+	Address   string
+	TxID      string
+}
+
+// Snapshot 一轮查询后用于求值的数据
+type Snapshot struct {
+	Balances []Balance
+	Spends   []Spend // 全部支出，按规则的 Checked 时间过滤
+	Reuses   []Reuse
+}
+
+// Store 告警规则存储
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	Rules []*Rule `json:"rules"`
+}
+
+// Load 加载告警规则，文件不存在时返回空存储
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解码告警规则失败: %w", err)
+	}
+	return s, nil
+}
+
+// NewRule 校验参数并创建规则，amount 为币种单位的十进制金额，address.reuse 不需要金额
+func NewRule(kind, symbol, accountID, amount string) (*Rule, error) {
+	rule := &Rule{Kind: kind, Coin: strings.ToUpper(symbol), AccountID: accountID}
+	switch kind {
+	case BalanceBelow, TxAbove:
+		info, ok := coin.LookupSymbol(rule.Coin)
+		if !ok {
+			return nil, fmt.Errorf("%s needs a known coin, got %q", kind, symbol)
+		}
+		value, err := coin.ParseUnits(amount, info.Decimal)
+		if err != nil {
+			return nil, err
+		}
+		rule.Threshold = value.String()
+	case AddressReuse:
+		if amount != "" {
+			return nil, fmt.Errorf("%s takes no amount", kind)
+		}
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownKind, kind, strings.Join(Kinds, ", "))
+	}
+	return rule, nil
+}
+
+// Add 保存新规则，分配 ID 和创建时间
+func (s *Store) Add(rule *Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	rule.ID = hex.EncodeToString(id)
+	rule.CreatedAt = time.Now().UTC()
+	s.Rules = append(s.Rules, rule)
+	if err := s.save(); err != nil {
+		s.Rules = s.Rules[:len(s.Rules)-1]
+		return err
+	}
+	return nil
+}
+
+// Remove 删除规则
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, rule := range s.Rules {
+		if rule.ID == id {
+			s.Rules = append(s.Rules[:i], s.Rules[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrRuleNotFound, id)
+}
+
+// List 返回全部规则，按创建顺序
+func (s *Store) List() []*Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Rule(nil), s.Rules...)
+}
+
+// Evaluate 对一轮查询的结果求值，返回触发的告警；规则状态有变化时保存
+func (s *Store) Evaluate(snapshot Snapshot) ([]Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var alerts []Alert
+	changed := false
+	for _, rule := range s.Rules {
+		threshold, _ := new(big.Int).SetString(rule.Threshold, 10)
+		switch rule.Kind {
+		case BalanceBelow:
+			if threshold == nil {
+				continue
+			}
+			// 只看本轮查询到的账户，后端不可用的币种保持原状态
+			seen, total := false, new(big.Int)
+			for _, balance := range snapshot.Balances {
+				if rule.matches(balance.Coin, balance.AccountID) {
+					seen = true
+					total.Add(total, balance.Amount)
+				}
+			}
+			if !seen {
+				continue
+			}
+			below := total.Cmp(threshold) < 0
+			if below && !rule.Firing {
+				alerts = append(alerts, Alert{
+					RuleID: rule.ID, Kind: rule.Kind, Coin: rule.Coin, AccountID: rule.AccountID,
+					Amount: total.String(), Threshold: rule.Threshold,
+					Message: fmt.Sprintf("Balance of %s is %s, below %s", scopeName(rule.Coin, rule.AccountID),
+						formatAmount(rule.Coin, total.String()), formatAmount(rule.Coin, rule.Threshold)),
+				})
+			}
+			if below != rule.Firing {
+				rule.Firing = below
+				changed = true
+			}
+		case TxAbove:
+			if threshold == nil {
+				continue
+			}
+			since := rule.Checked
+			if since.IsZero() {
+				since = rule.CreatedAt
+			}
+			for _, spend := range snapshot.Spends {
+				if !spend.SentAt.After(since) || !rule.matches(spend.Coin, spend.AccountID) {
+					continue
+				}
+				if spend.SentAt.After(rule.Checked) {
+					rule.Checked = spend.SentAt
+					changed = true
+				}
+				if spend.Amount.Cmp(threshold) <= 0 {
+					continue
+				}
+				alerts = append(alerts, Alert{
+					RuleID: rule.ID, Kind: rule.Kind, Coin: spend.Coin, AccountID: spend.AccountID,
+					Address: spend.To, TxID: spend.TxID, Amount: spend.Amount.String(), Threshold: rule.Threshold,
+					Message: fmt.Sprintf("Sent %s to %s in %s, above %s", formatAmount(spend.Coin, spend.Amount.String()),
+						spend.To, spend.TxID, formatAmount(rule.Coin, rule.Threshold)),
+				})
+			}
+		case AddressReuse:
+			for _, reuse := range snapshot.Reuses {
+				if !rule.matches(reuse.Coin, reuse.AccountID) {
+					continue
+				}
+				message := fmt.Sprintf("Address %s of %s received another payment", reuse.Address, scopeName(reuse.Coin, reuse.AccountID))
+				if reuse.TxID != "" {
+					message += " in " + reuse.TxID
+				}
+				alerts = append(alerts, Alert{
+					RuleID: rule.ID, Kind: rule.Kind, Coin: reuse.Coin, AccountID: reuse.AccountID,
+					Address: reuse.Address, TxID: reuse.TxID, Message: message,
+				})
+			}
+		}
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			return alerts, err
+		}
+	}
+	return alerts, nil
+}
+
+func (s *Store) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入告警规则失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名告警规则失败: %w", err)
+	}
+	return nil
+}
+
+// scopeName 告警消息中的账户或币种名称
+func scopeName(symbol, accountID string) string {
+	if accountID != "" {
+		return "account " + accountID
+	}
+	if symbol != "" {
+		return symbol
+	}
+	return "the wallet"
+}
+
+// formatAmount 按币种精度格式化最小单位金额，未知币种原样返回
+func formatAmount(symbol, amount string) string {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return amount
+	}
+	if info, ok := coin.LookupSymbol(symbol); ok {
+		return coin.FormatUnits(value, info.Decimal) + " " + symbol
+	}
+	return amount
+}
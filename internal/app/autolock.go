@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/events"
+)
+
+// autoLockTick 检查是否空闲超时的间隔
+const autoLockTick = 5 * time.Second
+
+// autoLockTimeout 配置的空闲锁定时间，0 表示不自动锁定
+func autoLockTimeout() time.Duration {
+	appConfig := config.GetAppConfig()
+	return time.Duration(appConfig.GetWalletConfig().AutoLockMinutes) * time.Minute
+}
+
+// startAutoLock 在后台检查空闲时间，提示符处无操作超过配置时间后锁定钱包，返回停止函数
+func (r *REPL) startAutoLock() func() {
+	timeout := autoLockTimeout()
+	if timeout <= 0 {
+		return func() {}
+	}
+	r.markActivity(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(autoLockTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.lockIfIdle(timeout)
+			}
+		}
+	}()
+	return cancel
+}
+
+// markActivity 命令开始时标记为忙碌，结束时记录时间；忙碌期间不会自动锁定
+func (r *REPL) markActivity(busy bool) {
+	r.activityMu.Lock()
+	defer r.activityMu.Unlock()
+	r.busy = busy
+	r.lastActivity = time.Now()
+}
+
+func (r *REPL) lockIfIdle(timeout time.Duration) {
+	r.activityMu.Lock()
+	defer r.activityMu.Unlock()
+	if r.busy || r.walletMgr.IsLocked() || time.Since(r.lastActivity) < timeout {
+		return
+	}
+	r.lockWallet()
+	reason := fmt.Sprintf("Wallet locked after %s of inactivity", timeout)
+	r.noticeMu.Lock()
+	r.notices = append(r.notices, r.template.Warning(reason))
+	r.noticeMu.Unlock()
+	r.bus.Publish(events.Event{Type: events.WalletLocked, Data: events.Locked{Reason: reason}})
+}
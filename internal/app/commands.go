@@ -0,0 +1,842 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// command 命令注册表中的一条命令：处理函数、只读模式是否可用，以及生成帮助和用法提示的元数据
+type command struct {
+	name     string
+	aliases  []string
+	handler  CommandHandler
+	readOnly bool // 只读模式（--read-only）下可用：只查看数据、不修改存储目录
+	// 会提示输入、显示私密材料或控制终端：输出被管道或重定向捕获时提示看不到，命令像是卡住，因此不能捕获
+	interactive bool
+	usages      []view.HelpUsage
+	args        []view.HelpArg
+	examples    []string
+
+	// 第一个参数是账户（或币种）时，连同它在内必需的参数个数；少一个时由 withContext 补上 use 设置的默认值
+	accountArgs int
+	coinArgs    int
+}
+
+// commandGroup 帮助中的一组命令
+type commandGroup struct {
+	title    string
+	commands []command
+}
+
+// usages 按 参数, 说明 成对给出的用法
+func usages(pairs ...string) []view.HelpUsage {
+	result := make([]view.HelpUsage, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, view.HelpUsage{Args: pairs[i], Summary: pairs[i+1]})
+	}
+	return result
+}
+
+// arguments 按 名称, 说明 成对给出的参数说明
+func arguments(pairs ...string) []view.HelpArg {
+	result := make([]view.HelpArg, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, view.HelpArg{Name: pairs[i], Description: pairs[i+1]})
+	}
+	return result
+}
+
+// commandGroups 全部命令，按帮助中的显示顺序；分发、补全、只读模式和帮助都由这里生成
+func (r *REPL) commandGroups() []commandGroup {
+	accountID := "<accountID>"
+	accountIDArg := "accountID, alias or label of an account (see account.list)"
+	formatArg := "show BCH (cashaddr, legacy) or ETH (checksum, lowercase) addresses in this format, default [address.formats]"
+	sortArg := "index (default: coin, account and address index), created (oldest first, records from older versions have no time) or label (labelled first)"
+	return []commandGroup{
+		{"BASIC COMMANDS", []command{
+			{name: "exit", aliases: []string{"quit"}, handler: r.handleExit, interactive: true, readOnly: true,
+				usages: usages("", "Exit the REPL")},
+			{name: "help", handler: r.handleHelp, readOnly: true,
+				usages:   usages("", "Show help", "<command>", "Show usage, arguments and examples of one command"),
+				examples: []string{"help btc.send", "btc.send --help"}},
+			{name: "tutorial", handler: r.handleTutorial, interactive: true, readOnly: true,
+				usages: usages("", "Guided tour in a sandbox (practice wallet, testnet, mock chain): create, back up, derive, receive and sign")},
+			{name: "clear", handler: r.handleClear, interactive: true, readOnly: true,
+				usages: usages("", "Clear screen")},
+			{name: "set", handler: r.handleSet, readOnly: true,
+				usages:   usages("<name> <value>", "Set a session variable, used as $name or ${name} in later commands"),
+				examples: []string{"set ACC file_ab12 && address.list $ACC", `set MEMO "rent march"`}},
+			{name: "unset", handler: r.handleUnset, readOnly: true,
+				usages: usages("<name>...", "Remove session variables")},
+			{name: "env", handler: r.handleEnv, readOnly: true,
+				usages: usages("", "List session variables, including LAST_ACCOUNT, LAST_ADDRESS and LAST_TXID set by commands")},
+			{name: "use", handler: r.handleUse, readOnly: true,
+				usages: usages("coin <symbol> | account <account> | none", "Set session defaults: commands whose coin or account argument comes first can leave it out"),
+				args: arguments("coin", "default for account.list, account.create, tax.lots and tax.report",
+					"account", "default account (ID, alias or label) for address.derive, address.list, balances and sends; also sets the coin",
+					"none", "clear both; without arguments use shows the current defaults"),
+				examples: []string{"use account savings && address.derive receive 5", "use coin ETH && account.list"}},
+			{name: "lang.list", handler: r.handleLangList, readOnly: true,
+				usages: usages("", "List available languages with how complete their translation is and where it was loaded from")},
+			{name: "history", handler: r.handleHistory, readOnly: true,
+				usages: usages("[limit]", "Show the commands of this session (last 50 by default)",
+					"metadata [--kind k] [--key key] [--limit n]", "Show the metadata event log, newest first (last 20 by default)",
+					"metadata --at <YYYY-MM-DD|YYYY-MM-DDTHH:MM> [--kind k] [--key key]", "Show labels, tags, contacts and policies as they were at that time",
+					"metadata --verify", "Check the hash chain of the metadata event log"),
+				examples: []string{"history metadata --key btc-1a2b", "history metadata --at 2026-01-31 --kind labels"}},
+			{name: "version", handler: r.handleVersion, readOnly: true,
+				usages: usages("[--json]", "Show version, commit and build date")},
+			{name: "session.record", handler: r.handleSessionRecord, interactive: true,
+				usages:   usages("<file>", "Record commands and output to a transcript (secrets redacted)"),
+				examples: []string{"session.record support.txt"}},
+			{name: "session.stop", handler: r.handleSessionStop, interactive: true,
+				usages: usages("", "Stop recording the session")},
+			{name: "net.stats", handler: r.handleNetStats, readOnly: true,
+				usages: usages("", "Show calls, failures and circuit breaker state of external endpoints")},
+			{name: "stats", handler: r.handleStats, readOnly: true,
+				usages: usages("[--prometheus]", "Summarize commands, failures, timings, unlocks and signatures of this session",
+					"wallet [--json]", "Show accounts per coin, addresses per account, storage size, last backup and unlock, and KDF parameters")},
+		}},
+		{"WALLET MANAGEMENT", []command{
+			{name: "wallet.create", handler: r.handleWalletCreate, interactive: true,
+				usages: usages("[--dice] [--entropy-file <file>] [--device <path>]", "Create a new HD wallet (password entered at a hidden prompt)"),
+				args: arguments(
+					"--dice", "enter dice rolls at a prompt and mix them into the system randomness",
+					"--entropy-file", "mix in the SHA-256 of a file, such as a photo or recording only you have",
+					"--device", "mix in bytes from a hardware RNG such as /dev/hwrng (see entropy.device)"),
+				examples: []string{"wallet.create", "wallet.create --dice --device /dev/hwrng"}},
+			{name: "wallet.restore", handler: r.handleWalletRestore, interactive: true,
+				usages: usages("[mnemonic words] [--yes]", "Restore wallet from mnemonic (prompts for the mnemonic when omitted), "+
+					"after showing its first addresses for confirmation"),
+				args: arguments("mnemonic words", "12 to 24 BIP39 words, quoted as one argument or separate; the password is always prompted",
+					"--yes", "restore without asking, the preview is still shown"),
+				examples: []string{"wallet.restore", `wallet.restore "word1 word2 ... word24"`}},
+			{name: "wallet.mnemonic", handler: r.handleWalletMnemonic, interactive: true, readOnly: true,
+				usages: usages("", "Show the mnemonic phrase in a full-screen view that clears itself (terminal only, password prompted)")},
+			{name: "wallet.unlock", handler: r.handleWalletUnlock, interactive: true, readOnly: true,
+				usages: usages("", "Unlock wallet (password entered at a hidden prompt)")},
+			{name: "wallet.lock", handler: r.handleWalletLock, readOnly: true,
+				usages: usages("", "Lock wallet")},
+			{name: "wallet.status", handler: r.handleWalletStatus, readOnly: true,
+				usages: usages("[--offline]", "Show wallet details, auto-lock and RPC node connectivity"),
+				args:   arguments("--offline", "skip the RPC node connectivity check")},
+			{name: "wallet.test-restore", handler: r.handleWalletTestRestore, interactive: true, readOnly: true,
+				usages: usages("[<backupFile> [--identity <ageKeyFile>] | mnemonic words] [--count n]",
+					"Restore a backup or mnemonic into a wiped temporary directory and compare it with this wallet"),
+				args: arguments("backupFile", "a file written by 'slowmade backup create'; the mnemonic is prompted when neither is given",
+					"--count", "receive addresses compared per account (default 3)"),
+				examples: []string{"wallet.test-restore", "wallet.test-restore slowmade-backup-20260101T000000Z.age --identity recovery-key.txt"}},
+		}},
+		{"KEY CEREMONY", []command{
+			{name: "ceremony.start", handler: r.handleCeremonyStart, interactive: true,
+				usages: usages("[--shares k-of-n] [--org <name>] [--purpose <text>] [--out <dir>] [--template <file>] [--allow-network] [--dice] [--entropy-file <file>] [--device <path>]",
+					"Create the wallet in a guided ceremony: operators confirm each step, shares go to named custodians, an attestation is written and the record is added to the audit log"),
+				args: arguments(
+					"--shares", "split the recovery words so that any k of n custodians recover the wallet; without it the words are shown once",
+					"--org", "organization name printed on the attestation",
+					"--purpose", "what the wallet is for, printed on the attestation",
+					"--out", "directory for ceremony-<id>.txt and ceremony-<id>.json, the current directory by default",
+					"--template", "text/template file for the attestation instead of the built-in one",
+					"--allow-network", "continue although network interfaces are up; the attestation records them",
+					"--dice", "as for wallet.create, also --entropy-file and --device"),
+				examples: []string{"ceremony.start --shares 2-of-3 --org \"Acme Treasury\" --out /media/usb", "ceremony.start --dice --template attestation.tmpl"}},
+			{name: "ceremony.list", handler: r.handleCeremonyList, readOnly: true,
+				usages: usages("", "List key ceremonies recorded in the audit log")},
+			{name: "ceremony.show", handler: r.handleCeremonyShow, readOnly: true,
+				usages:   usages("<id> [--template <file>]", "Check the audit log and print a ceremony's attestation again"),
+				examples: []string{"ceremony.show 3f9a01c2b4d6 > attestation.txt"}},
+		}},
+		{"ACCOUNT MANAGEMENT", []command{
+			{name: "account.create", handler: r.handleAccountCreate, coinArgs: 1,
+				usages: usages(
+					"<derivationPath> [--convention <name>]", "Create new account",
+					"<coin> [--format <name>] [--convention <standard|ledger-live|mew>] [--index n]", "Create the next account of a coin, optionally using another wallet's path layout"),
+				args: arguments(
+					"derivationPath", "address path such as m/44'/60'/0'/0/0 or account path such as m/84'/0'/0'",
+					"--format", "address format, default [address.formats]; for BTC legacy (m/44'), p2sh (m/49'), bech32 (m/84') or bech32m (m/86')",
+					"--convention", "standard (BIP44), ledger-live (one address per account) or mew (no change level)",
+					"--index", "account index instead of the next free one"),
+				examples: []string{"account.create m/84'/0'/0'", "account.create BTC --format bech32", "account.create ETH --convention ledger-live"}},
+			{name: "account.list", handler: r.handleAccountList, readOnly: true, coinArgs: 1,
+				usages: usages("<CoinSymbol> [--all] [--tag <tag>] [--sort created|index|label]", "List accounts (--all includes archived)"),
+				args: arguments("--tag", "only accounts with this tag or one below it (clients matches clients/acme)",
+					"--sort", sortArg),
+				examples: []string{"account.list BTC", "account.list ETH --all", "account.list BTC --tag clients", "account.list BTC --sort label"}},
+			{name: "account.balance", handler: r.handleAccountBalance, readOnly: true, accountArgs: 1,
+				usages: usages(accountID+" [--refresh]", "Fetch balances via block explorer (third party)"),
+				args:   arguments("accountID", accountIDArg, "--refresh", "ignore cached balances")},
+			{name: "account.export", handler: r.handleAccountExport, interactive: true, accountArgs: 1,
+				usages: usages(accountID+" --encrypt-to-password [file]", "Export one account, encrypted with a separate password"),
+				args:   arguments("accountID", accountIDArg, "file", "output file, account-<id>.json by default")},
+			{name: "account.export-whitelist", handler: r.handleAccountExportWhitelist, interactive: true,
+				usages: usages(accountID+" --count n --out <file.csv|file.json> [--start i] [--message text]",
+					"Export receive addresses with signed ownership proofs for exchange withdrawal whitelists (ETH)"),
+				args: arguments("accountID", accountIDArg, "--out", "CSV when the name ends in .csv, JSON otherwise",
+					"--message", "included in every signed statement, e.g. the exchange account"),
+				examples: []string{`account.export-whitelist 0xabc --count 5 --out whitelist.csv --message "withdrawals for alice@exchange"`}},
+			{name: "account.import", handler: r.handleAccountImport, interactive: true,
+				usages: usages("<file>", "Import an exported account as a standalone account")},
+			{name: "export.public", handler: r.handleExportPublic,
+				usages:   usages("--out <dir>", "Export xpubs, descriptors, addresses and history with no secrets (for accountants)"),
+				examples: []string{"export.public --out ./for-accountant"}},
+			{name: "report.spending", handler: r.handleReportSpending, readOnly: true,
+				usages: usages("--account <id>|--tag <tag> --period <period> [--csv file] [--template file]", "Summarize inflows, outflows, counterparties and fees per month"),
+				args: arguments("--account", accountIDArg, "--tag", "one report per account with this tag or one below it", "--period", "YYYY, YYYYQ1-4 or YYYY-MM in the ui.timezone calendar",
+					"--csv", "also write every transaction to this CSV file (for tax preparation)",
+					"--template", "render with this Go text/template instead (functions: date, day, number, decimal, amount, size)"),
+				examples: []string{"report.spending --account savings --period 2024Q4", "report.spending --tag clients --period 2024", "report.spending --account savings --period 2024 --csv spending-2024.csv"}},
+			{name: "tax.lots", handler: r.handleTaxLots, coinArgs: 1,
+				usages: usages("<coin> [--backfill]", "List open acquisition lots with their cost basis in [tax] currency"),
+				args:   arguments("--backfill", "first record missing prices from the historical daily rate of the price source")},
+			{name: "tax.report", handler: r.handleTaxReport, readOnly: true, coinArgs: 1,
+				usages: usages("<coin> --period <period> [--method fifo|lifo] [--csv file]", "Cost basis and gains of disposals, matched to lots"),
+				args: arguments("--period", "YYYY, YYYYQ1-4 or YYYY-MM", "--method", "lot matching, default [tax] method",
+					"--csv", "also write Form 8949 style rows (Description, Date Acquired, Date Sold, Proceeds, Cost Basis, Gain or Loss)"),
+				examples: []string{"tax.report BTC --period 2024 --csv btc-2024-8949.csv", "tax.report BTC --period 2024 --method lifo"}},
+			{name: "account.rotate", handler: r.handleAccountRotate, interactive: true, accountArgs: 1,
+				usages: usages(accountID+" [--fee-rate n] [--broadcast]", "Derive a successor account, sweep funds to it and archive the old one"),
+				args: arguments("accountID", accountIDArg, "--fee-rate", "sweep fee rate in sat/vB (BTC)",
+					"--broadcast", "send the sweep, otherwise only show the plan")},
+			{name: "account.set", handler: r.handleAccountSet,
+				usages: usages(accountID+" change-policy [fresh|sender|fixed <address>]", "Show or set where btc.send and btc.consolidate put change"),
+				args: arguments("accountID", accountIDArg,
+					"fresh", "a new internal (change) address every time, the default",
+					"sender", "back to the address of the largest input",
+					"fixed", "always the given address of this wallet, e.g. a consolidation address"),
+				examples: []string{"account.set savings change-policy sender", "account.set shop change-policy fixed bc1q..."}},
+			{name: "account.freeze", handler: r.handleAccountFreeze, accountArgs: 1,
+				usages: usages(accountID, "Freeze an account when a device may be compromised: signing, deriving new addresses and exporting its keys fail until unfrozen"),
+				args:   arguments("accountID", accountIDArg)},
+			{name: "account.unfreeze", handler: r.handleAccountUnfreeze, interactive: true, accountArgs: 1,
+				usages: usages(accountID, "Allow a frozen account to sign again (asks for the wallet password)"),
+				args:   arguments("accountID", accountIDArg)},
+			{name: "address.derive", handler: r.handleAddressDerive, accountArgs: 3,
+				usages: usages(accountID+" <receive|change> <index> [--format <name>]", "Derive an address"),
+				args: arguments("accountID", accountIDArg, "receive|change", "address chain; anything other than change derives a receive address",
+					"index", "address index", "--format", formatArg),
+				examples: []string{"address.derive savings receive 0", "address.derive $ACC receive 0 --format legacy"}},
+			{name: "address.next", handler: r.handleAddressNext, accountArgs: 1,
+				usages:   usages(accountID+" [--format <name>]", "Hand out the first unused receive address, deriving one if needed, within address.gap_limit"),
+				args:     arguments("accountID", accountIDArg, "--format", formatArg),
+				examples: []string{"address.next savings", "address.next $ACC --format legacy"}},
+			{name: "address.list", handler: r.handleAddressList, readOnly: true, accountArgs: 1,
+				usages: usages(accountID+" [--tag <tag>] [--format <name>] [--sort created|index|label]", "List addresses"),
+				args: arguments("accountID", accountIDArg, "--tag", "only addresses with this tag or one below it", "--format", formatArg,
+					"--sort", sortArg)},
+			{name: "address.convert", handler: r.handleAddressConvert, readOnly: true,
+				usages: usages("<address> [format] [--coin <symbol>]", "Show an address in another format, or in all formats of its coin"),
+				args: arguments("format", "BTC legacy, p2sh, bech32 or bech32m (a different address); BCH cashaddr or legacy; ETH checksum or lowercase",
+					"--coin", "coin of the address when it is not from this wallet and the prefix is ambiguous (1... is BTC or BCH)"),
+				examples: []string{"address.convert bitcoincash:qpmmlusvvrjj9ha2xdgv8xcrpfwsqn5rngt3k26ve2 legacy",
+					"address.convert 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2 --coin BCH", "address.convert $ADDR"}},
+			{name: "address.prove", handler: r.handleAddressProve, interactive: true,
+				usages: usages("<address> [--message text] [--out file]", "Sign a proof that you control an ETH address (timestamp, message, derivation path hash)"),
+				args: arguments("--message", "free text included in the signed statement, e.g. the exchange's challenge",
+					"--out", "write the proof JSON to this file instead of printing it"),
+				examples: []string{`address.prove 0x... --message "withdrawal whitelist for alice@exchange" --out proof.json`}},
+			{name: "address.verify-proof", handler: r.handleAddressVerifyProof, readOnly: true,
+				usages:   usages("<proofFile> [--path <derivationPath>]", "Check an ownership proof; no wallet needed"),
+				args:     arguments("--path", "also check that the proof was made for this derivation path"),
+				examples: []string{"address.verify-proof proof.json", "address.verify-proof proof.json --path \"m/44'/60'/0'/0/0\""}},
+			{name: "path.explain", handler: r.handlePathExplain, readOnly: true,
+				usages:   usages("<derivationPath>", "Decode a derivation path"),
+				examples: []string{"path.explain m/44'/60'/0'/0/0"}},
+		}},
+		{"COINS", []command{
+			{name: "coin.register", handler: r.handleCoinRegister,
+				usages: usages("<symbol> <coinType> <decimals> [--curve ed25519|secp256k1]", "Register a custom coin"),
+				args: arguments("coinType", "SLIP-44 coin type", "decimals", "number of decimals of the smallest unit",
+					"--curve", "signing curve, secp256k1 by default")},
+			{name: "coin.list", handler: r.handleCoinList, readOnly: true,
+				usages: usages("", "List registered coins")},
+			{name: "validate", handler: r.handleValidate, readOnly: true, coinArgs: 2,
+				usages: usages("<coin> <address> [--json] [--onchain]", "Check an address from elsewhere before sending to it: checksum, network and address type; fails when it is not valid"),
+				args: arguments("coin", "coin symbol, e.g. BTC, ETH, LTC, DOT",
+					"--json", "print the result as JSON, the same as GET /api/v1/validate",
+					"--onchain", "ETH and BNB: ask the node in rpc.<coin>.<network> whether the address is a contract"),
+				examples: []string{"validate BTC bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "validate ETH 0x52908400098527886E0F7030069857D2E4169EE7 --onchain",
+					"validate DOT 15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5 --json"}},
+		}},
+		{"SIGNING", []command{
+			{name: "message.sign", handler: r.handleMessageSign, interactive: true,
+				usages: usages(
+					"<address> <message>", "Sign a message (personal_sign)",
+					"<address> --hex <0x...>", "Sign raw bytes given as hex",
+					"<address> --typed <file>", "Sign EIP-712 typed data with decoded preview")},
+			{name: "tx.decode", handler: r.handleTxDecode, readOnly: true,
+				usages: usages("<hex|file>", "Decode a raw ETH, BTC or Solana transaction")},
+			{name: "tx.bump", handler: r.handleTxBump, interactive: true,
+				usages: usages("<btc-txid> [--fee-rate N] [--broadcast]", "Replace an unconfirmed BTC transaction sent by this wallet with a higher fee (RBF)",
+					"<eth-hex|file> [--gas-price G | --max-fee G --priority-fee G]", "Re-sign a signed ETH transaction with the same nonce and higher fees"),
+				args: arguments("--fee-rate", "sat/vB, default the backend estimate and at least the BIP125 minimum",
+					"--gas-price", "gwei, legacy ETH transactions", "--max-fee", "gwei, EIP-1559 transactions", "--priority-fee", "gwei, EIP-1559 transactions"),
+				examples: []string{"tx.bump 3f2a...c9 --fee-rate 25 --broadcast", "tx.bump signed.hex --max-fee 40 --priority-fee 2"}},
+			{name: "tx.cancel", handler: r.handleTxCancel, interactive: true,
+				usages: usages("<btc-txid> [--fee-rate N] [--broadcast]", "Replace an unconfirmed BTC transaction with one that pays its inputs back to this wallet",
+					"<eth-hex|file> [--gas-price G | --max-fee G --priority-fee G]", "Sign a 0 ETH self-transfer with the same nonce and higher fees"),
+				args: arguments("--fee-rate", "sat/vB, default the backend estimate and at least the BIP125 minimum",
+					"--gas-price", "gwei, legacy ETH transactions", "--max-fee", "gwei, EIP-1559 transactions", "--priority-fee", "gwei, EIP-1559 transactions"),
+				examples: []string{"tx.cancel 3f2a...c9 --broadcast"}},
+			{name: "send.batch", handler: r.handleSendBatch, interactive: true,
+				usages: usages("<file.csv> --from <accountID|eth-address> [--from ...] [--fee-rate n] [--gas-price G | --max-fee G --priority-fee G] [--nonce n] [--chain-id n] [--report file] [--broadcast]",
+					"Pay every row of a CSV file: one multi-output BTC transaction, sequential-nonce ETH transactions; writes a results report"),
+				args: arguments("file.csv", "address,amount[,memo[,coin]] per row, optional header; addresses may be contacts",
+					"--from", "paying BTC account or ETH address, one per coin; rows without a coin column go to the matching one",
+					"--fee-rate", "BTC sat/vB, default the backend estimate", "--gas-price", "gwei, default the node's gas price",
+					"--max-fee", "gwei, EIP-1559", "--priority-fee", "gwei, EIP-1559", "--nonce", "first ETH nonce, default the node's pending nonce",
+					"--chain-id", "default the node's chain, or 1", "--report", "default <file>-results.csv",
+					"--broadcast", "send the transactions, otherwise the report holds the signed raw transactions"),
+				examples: []string{"send.batch payroll.csv --from savings --fee-rate 8", "send.batch payroll.csv --from savings --from vault --broadcast",
+					"send.batch bonus.csv --from 0x9858EfFD232B4033E47d90003D41EC34EcaEda94 --nonce 12 --gas-price 20"}},
+			{name: "policy.list", handler: r.handlePolicyList, readOnly: true,
+				usages: usages("", "List the signing policy rules from policies/*.policy in evaluation order")},
+			{name: "policy.test", handler: r.handlePolicyTest, readOnly: true,
+				usages: usages("<ETH|BTC> <destination> <amount> [--method m] [--origin o] [--at YYYY-MM-DDTHH:MM]", "Show what the signing policy decides for a request, without signing"),
+				args: arguments("amount", "in whole coins", "--method", "signing method, default the coin's transaction method",
+					"--origin", "requesting dApp or client", "--at", "evaluate at this local time instead of now"),
+				examples: []string{"policy.test ETH 0x000000000000000000000000000000000000dEaD 2.5", "policy.test BTC bc1q... 0.1 --at 2026-01-03T23:30"}},
+			{name: "signing.stats", handler: r.handleSigningStats, readOnly: true,
+				usages: usages("[key]", "Show per-key signing counts, typical amounts, hours and destinations used to flag unusual requests"),
+				args:   arguments("key", "ETH address, or BTC account ID, short ID or alias; default all keys")},
+		}},
+		{"AIR-GAPPED SIGNING", []command{
+			{name: "airgap.request", handler: r.handleAirgapRequest,
+				usages: usages("eth|message|btc <payloadFile> [--memo text] [--out file] [--qr]", "Prepare a signing request for an offline signer (online instance)"),
+				args: arguments("eth", "payload is eth_signTransaction parameters; from, nonce, gas and chainId are required",
+					"message", `payload is {"account": "0x...", "message": "0x..."} for personal_sign`,
+					"btc", `payload is {"inputs": [utxo...], "outputs": [{"address", "value"}]}, amounts in satoshi`,
+					"--out", "request file, default sign-request-<id>.json", "--qr", "also show the request as ur:bytes QR frames"),
+				examples: []string{`airgap.request eth tx.json --memo "rent march"`, "airgap.request btc spend.json --qr"}},
+			{name: "airgap.sign", handler: r.handleAirgapSign, interactive: true,
+				usages: usages("[requestFile] [--out file] [--qr]", "Check, sign and answer a request (offline signer); without a file, paste scanned frames"),
+				args: arguments("requestFile", "request JSON, or scanned ur:bytes frames one per line",
+					"--out", "response file, default sign-response-<id>.json", "--qr", "also show the response as QR frames")},
+			{name: "airgap.accept", handler: r.handleAirgapAccept,
+				usages: usages("[responseFile]", "Load the signer's response to a request made here"),
+				args:   arguments("responseFile", "response JSON, or scanned ur:bytes frames one per line")},
+			{name: "airgap.list", handler: r.handleAirgapList, readOnly: true,
+				usages: usages("[--all]", "List outstanding (or all) signing requests made here")},
+			{name: "airgap.snapshot", handler: r.handleAirgapSnapshot,
+				usages: usages("[--currency code] [--out file] [--qr]", "Export signed fee rates and prices for the offline signer (online instance)"),
+				args: arguments("--currency", "price currency, default tax.currency or USD",
+					"--out", "snapshot file, default market-snapshot-<time>.json", "--qr", "also show the snapshot as ur:bytes QR frames"),
+				examples: []string{"airgap.snapshot --currency EUR", "airgap.snapshot --qr"}},
+			{name: "airgap.import-snapshot", handler: r.handleAirgapImportSnapshot,
+				usages: usages("[snapshotFile] [--trust-new-key]", "Import a market snapshot; airgap.sign then compares fees against it (offline signer)"),
+				args: arguments("snapshotFile", "snapshot JSON, or scanned ur:bytes frames one per line",
+					"--trust-new-key", "accept a snapshot signed by a different key than the one imported before")},
+		}},
+		{"BITCOIN", []command{
+			{name: "btc.utxos", handler: r.handleBTCUTXOs, readOnly: true, accountArgs: 1,
+				usages: usages(accountID+" [--refresh]", "List tracked UTXOs of an account")},
+			{name: "btc.balance", handler: r.handleBTCBalance, readOnly: true, accountArgs: 1,
+				usages: usages(accountID, "Show confirmed and pending balance")},
+			{name: "btc.history", handler: r.handleBTCHistory, readOnly: true, accountArgs: 1,
+				usages: usages(accountID, "List transactions (electrum backend)")},
+			{name: "btc.send", handler: r.handleBTCSend, interactive: true, accountArgs: 3,
+				usages: usages(
+					accountID+" <address> <amount> [--fee-rate n] [--strategy bnb|largest] [--from-utxo txid:vout] [--broadcast]", "Select coins, sign and optionally broadcast",
+					accountID+" <bitcoin:uri> [amount] [options]", "Pay a BIP21 URI, the amount defaults to the one in the URI",
+					"", "Guided send: pick the account and addresses from a list"),
+				args: arguments("address", "recipient address, contact or Unstoppable Domains name", "amount", "amount in BTC, or in fiat with a currency suffix (50usd) converted at the current rate",
+					"--fee-rate", "fee rate in sat/vB", "--strategy", "coin selection: bnb (branch and bound) or largest first",
+					"--from-utxo", "spend this output, can be repeated", "--broadcast", "send the transaction, otherwise only print it"),
+				examples: []string{"btc.send savings bc1q... 0.01 --fee-rate 5", "btc.send savings alice 0.002 --broadcast", "btc.send savings brad.crypto 0.001", "btc.send savings alice 50usd --fee-rate 5",
+					`btc.send savings "bitcoin:bc1q...?amount=0.1&label=Coffee" --fee-rate 5`}},
+			{name: "btc.consolidate", handler: r.handleBTCConsolidate, interactive: true,
+				usages: usages("--account "+accountID+" [--fee-rate n] [--future-fee-rate n] [--below BTC]", "Merge small UTXOs into a fresh change address when fees are low"),
+				args: arguments("--fee-rate", "fee rate now in sat/vB", "--future-fee-rate", "expected fee rate when the outputs would be spent",
+					"--below", "only merge outputs smaller than this amount")},
+			{name: "sweep", handler: r.handleSweep, interactive: true,
+				usages: usages("BTC --wif|--hex [key] --to <accountID|address> [--fee-rate n] [--broadcast]", "Move all funds of an external key to this wallet (key prompted if omitted)")},
+		}},
+		{"TRON", []command{
+			{name: "trx.balance", handler: r.handleTRXBalance, readOnly: true, accountArgs: 1,
+				usages:   usages("<address|"+accountID+"> [--token <contract>]", "Show TRX balance, and a TRC-20 token balance with --token"),
+				examples: []string{"trx.balance savings", "trx.balance T... --token TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"}},
+			{name: "trx.send", handler: r.handleTRXSend, interactive: true, accountArgs: 3,
+				usages: usages("<address|"+accountID+"> <to> <amount> [--token <contract>] [--fee-limit TRX] [--broadcast]", "Sign a TRX or TRC-20 transfer and optionally broadcast it via TronGrid"),
+				args: arguments("to", "recipient address or contact", "amount", "amount in TRX, or in token units with --token",
+					"--token", "TRC-20 contract address", "--fee-limit", "most TRX the token transfer may burn for energy (default tron.fee_limit)",
+					"--broadcast", "send the transaction, otherwise only print it"),
+				examples: []string{"trx.send savings T... 25 --broadcast", "trx.send savings alice 100 --token TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t --fee-limit 20"}},
+		}},
+		{"COSMOS", []command{
+			{name: "cosmos.chains", handler: r.handleCosmosChains, readOnly: true,
+				usages: usages("", "List the configured Cosmos SDK chains, * marks the default")},
+			{name: "cosmos.address", handler: r.handleCosmosAddress, readOnly: true, accountArgs: 1,
+				usages:   usages(accountID+" [--chain name]", "Show the receive addresses of a Cosmos account on a chain"),
+				examples: []string{"cosmos.address staking --chain osmosis"}},
+			{name: "cosmos.balance", handler: r.handleCosmosBalance, readOnly: true, accountArgs: 1,
+				usages:   usages("<address|"+accountID+"> [--chain name]", "Show the balance on a chain via its LCD endpoint"),
+				examples: []string{"cosmos.balance staking", "cosmos.balance staking --chain juno"}},
+			{name: "cosmos.send", handler: r.handleCosmosSend, interactive: true, accountArgs: 3,
+				usages: usages("<address|"+accountID+"> <to> <amount> [--chain name] [--memo text] [--gas n] [--amino] [--broadcast]", "Sign a bank transfer and optionally broadcast it"),
+				args: arguments("to", "recipient address with the chain's prefix, or contact", "amount", "amount in the chain's display unit (ATOM, OSMO, ...)",
+					"--chain", "chain from [cosmos.chains], default cosmos.default_chain", "--memo", "transaction memo, often required by exchanges",
+					"--gas", "gas limit, default the chain's gas_limit", "--amino", "sign the legacy amino JSON instead of the protobuf sign doc",
+					"--broadcast", "send the transaction, otherwise only print it"),
+				examples: []string{"cosmos.send staking cosmos1... 1.5 --memo 104729 --broadcast", "cosmos.send staking osmo1... 20 --chain osmosis"}},
+		}},
+		{"SUBSTRATE", []command{
+			{name: "substrate.balance", handler: r.handleSubstrateBalance, readOnly: true, accountArgs: 1,
+				usages:   usages("<address|"+accountID+">", "Show the free DOT or KSM balance via the node's JSON-RPC"),
+				examples: []string{"substrate.balance polkadot"}},
+			{name: "substrate.send", handler: r.handleSubstrateSend, interactive: true, accountArgs: 3,
+				usages: usages("<address|"+accountID+"> <to> <amount> [--tip planck] [--broadcast]", "Sign a transfer_keep_alive and optionally submit it"),
+				args: arguments("to", "recipient SS58 address (network or generic prefix) or contact", "amount", "amount in DOT or KSM",
+					"--tip", "tip for the block author in planck", "--broadcast", "submit the transaction, otherwise only print it"),
+				examples: []string{"substrate.send polkadot 1... 2.5 --broadcast", "substrate.send kusama alice 0.1 --tip 1000000"}},
+		}},
+		{"STELLAR", []command{
+			{name: "xlm.balance", handler: r.handleXLMBalance, readOnly: true, accountArgs: 1,
+				usages:   usages("<address|"+accountID+">", "Show the XLM balance via Horizon"),
+				examples: []string{"xlm.balance savings"}},
+			{name: "xlm.send", handler: r.handleXLMSend, interactive: true, accountArgs: 3,
+				usages: usages("<address|"+accountID+"> <to> <amount> [--memo text|--memo-id n] [--broadcast]", "Sign an XLM payment and optionally submit it via Horizon"),
+				args: arguments("to", "recipient G... address or contact", "amount", "amount in XLM, at least 1 XLM to an unfunded account",
+					"--memo", "text memo, up to 28 bytes", "--memo-id", "numeric memo, as most exchanges ask for",
+					"--broadcast", "send the transaction, otherwise only print it"),
+				examples: []string{"xlm.send savings G... 100 --memo-id 4412093 --broadcast"}},
+		}},
+		{"XRP LEDGER", []command{
+			{name: "xrp.balance", handler: r.handleXRPBalance, readOnly: true, accountArgs: 1,
+				usages:   usages("<address|"+accountID+">", "Show the XRP balance via rippled"),
+				examples: []string{"xrp.balance savings"}},
+			{name: "xrp.send", handler: r.handleXRPSend, interactive: true, accountArgs: 3,
+				usages: usages("<address|"+accountID+"> <to> <amount> [--tag n] [--broadcast]", "Sign an XRP payment and optionally submit it via rippled"),
+				args: arguments("to", "recipient r... address, X-address (carries the tag) or contact", "amount", "amount in XRP",
+					"--tag", "destination tag, required by exchanges", "--broadcast", "send the transaction, otherwise only print it"),
+				examples: []string{"xrp.send savings r... 25 --tag 104729 --broadcast", "xrp.send savings X7Acg... 25"}},
+		}},
+		{"NFT (ERC-721/1155)", []command{
+			{name: "nft.list", handler: r.handleNFTList, readOnly: true,
+				usages: usages("<eth-address> [--no-metadata]", "List the NFTs an ETH address holds, found in its transfer history and checked on chain"),
+				args:   arguments("--no-metadata", "skip reading names and images from the token metadata URLs")},
+			{name: "nft.show", handler: r.handleNFTShow, readOnly: true,
+				usages: usages("<contract> <tokenId> [eth-address]", "Show a token's collection, standard and metadata, and how many the address holds"),
+				args:   arguments("tokenId", "decimal, or hex with 0x")},
+			{name: "nft.send", handler: r.handleNFTSend, interactive: true,
+				usages: usages("<eth-address> <contract> <tokenId> <to> [--amount n] [--nonce n] [--gas n] [--gas-price G | --max-fee G --priority-fee G] [--chain-id n]",
+					"Sign a safeTransferFrom after confirming the collection and token ID; the raw transaction is not broadcast"),
+				args: arguments("--amount", "ERC-1155 only, default 1", "--nonce", "default the node's pending nonce",
+					"--gas", "default the node's estimate plus 20%", "--gas-price", "gwei, default the node's gas price",
+					"--max-fee", "gwei, EIP-1559", "--priority-fee", "gwei, EIP-1559", "--chain-id", "default the node's chain, or 1 with etherscan"),
+				examples: []string{"nft.send vault 0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D 8817 0x000000000000000000000000000000000000dEaD",
+					"nft.send vault 0x76BE3b62873462d2142405439777e971754E8E77 10339 alice.eth --amount 2 --nonce 14 --gas 90000 --gas-price 12"}},
+		}},
+		{"SMART ACCOUNTS (ERC-4337, EXPERIMENTAL)", []command{
+			{name: "aa.address", handler: r.handleAAAddress, readOnly: true,
+				usages: usages("<eth-address> [--salt n]", "Show the SimpleAccount owned by an ETH address, whether it is deployed and its balance"),
+				args:   arguments("--salt", "picks one of several accounts of the same owner, default 0")},
+			{name: "aa.deploy", handler: r.handleAADeploy, interactive: true,
+				usages: usages("<eth-address> [--salt n] [--sponsored] [--max-fee G --priority-fee G] [--broadcast]", "Deploy the smart account with a user operation that only carries its initCode"),
+				args: arguments("--sponsored", "ask aa.paymaster_url to pay the gas", "--max-fee", "gwei, default the node's gas price plus 25%",
+					"--priority-fee", "gwei", "--broadcast", "submit through aa.bundler_url, otherwise only print the signed user operation"),
+				examples: []string{"aa.deploy vault --sponsored --broadcast"}},
+			{name: "aa.send", handler: r.handleAASend, interactive: true,
+				usages: usages("<eth-address> <to> <amount> [--data hex] [--salt n] [--sponsored] [--max-fee G --priority-fee G] [--broadcast]",
+					"Send ETH or call a contract from the smart account; the first operation also deploys it"),
+				args: arguments("to", "recipient address, contact or ENS name", "amount", "ETH sent from the smart account, may be 0 with --data",
+					"--data", "calldata for a contract call", "--salt", "default 0", "--sponsored", "ask aa.paymaster_url to pay the gas",
+					"--max-fee", "gwei, default the node's gas price plus 25%", "--priority-fee", "gwei",
+					"--broadcast", "submit through aa.bundler_url, otherwise only print the signed user operation"),
+				examples: []string{"aa.send vault alice.eth 0.05 --broadcast", "aa.send vault 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48 0 --data 0xa9059cbb... --sponsored"}},
+			{name: "aa.status", handler: r.handleAAStatus, readOnly: true,
+				usages: usages("<userOpHash>", "Show whether a user operation was included, its transaction and gas cost")},
+		}},
+		{"MONERO (WATCH-ONLY)", []command{
+			{name: "xmr.import", handler: r.handleXMRImport, interactive: true,
+				usages: usages("<address> [--label text] [--restore-height n] [--view-key hex]", "Import a primary address and private view key as a view-only wallet (key prompted if omitted)"),
+				args: arguments("address", "primary address of the Monero wallet, not a subaddress", "--restore-height", "block to start scanning from, the wallet's creation height",
+					"--view-key", "private view key, prefer the hidden prompt"),
+				examples: []string{"xmr.import 4... --label cold --restore-height 3100000"}},
+			{name: "xmr.list", handler: r.handleXMRList, readOnly: true,
+				usages: usages("", "List the Monero view-only wallets")},
+			{name: "xmr.balance", handler: r.handleXMRBalance, readOnly: true,
+				usages:   usages("<address|label>", "Scan via monero-wallet-rpc and show the received total"),
+				examples: []string{"xmr.balance cold"}},
+			{name: "xmr.history", handler: r.handleXMRHistory, readOnly: true,
+				usages:   usages("<address|label> [--limit n]", "Scan via monero-wallet-rpc and list incoming payments"),
+				examples: []string{"xmr.history cold --limit 50"}},
+			{name: "xmr.remove", handler: r.handleXMRRemove,
+				usages: usages("<address|label>", "Forget a Monero view-only wallet")},
+		}},
+		{"PAYMENT REQUESTS", []command{
+			{name: "request.create", handler: r.handleRequestCreate, accountArgs: 2,
+				usages: usages(accountID+" <amount> [memo] [--svg <file>] [--content auto|address|uri|json]", "Create a payment URI (BIP21, EIP-681, Solana Pay) with QR code"),
+				args: arguments("--svg", "also write the QR code as an SVG image",
+					"--content", "what the QR code contains, default ui.qr_content; the URI carries the account label"),
+				examples: []string{`request.create savings 0.05 "invoice 42"`, `request.create savings 0.05 --svg invoice-42.svg`, `request.create savings 0.05 --content json`}},
+			{name: "request.list", handler: r.handleRequestList, readOnly: true,
+				usages: usages("[--all]", "List outstanding (or all) payment requests")},
+			{name: "request.decode", handler: r.handleRequestDecode, readOnly: true,
+				usages:   usages("<uri>", "Show the fields of a BIP21, EIP-681 or Solana Pay URI"),
+				examples: []string{`request.decode "bitcoin:bc1q...?amount=0.1&label=Coffee"`}},
+			{name: "qr.export", handler: r.handleQRExport, readOnly: true,
+				usages: usages("<text> [--out <file.png|file.svg>] [--size small|medium|large|print] [--fg <color>] [--bg <color>] [--logo <image>] [--level L|M|Q|H]",
+					"Show a QR code for an address or URI, or write it as a styled PNG or SVG"),
+				args: arguments(
+					"color", "a name (black, navy, ...), #RGB, #RRGGBB, or #RGBA / #RRGGBBAA with alpha; --bg transparent for a see-through PNG",
+					"--size", "256, 512 (default), 1024 or 2048 pixels wide",
+					"--logo", "PNG or JPEG placed in the center; raises error correction to H",
+					"--level", "error correction level, M by default"),
+				examples: []string{"qr.export bc1q...", "qr.export bitcoin:bc1q...?amount=0.1 --out pay.png --size large --fg '#1a3c6e' --bg transparent --logo logo.png"}},
+			{name: "qr.batch", handler: r.handleQRBatch,
+				usages: usages("--account <id> --count n --out <dir> [--start i] [--size small|medium|large|print] [--format png|svg] [--content auto|address|uri|json] [--template <file>]",
+					"Write a QR image for each of n receive addresses, deriving missing ones, plus a printable index.html"),
+				args: arguments(
+					"--start", "first address index, 0 by default",
+					"--content", "what each QR code contains, default ui.qr_content (auto is the plain address, as there is no amount)",
+					"--template", "html/template for index.html instead of the built-in card sheet; fields .Coin, .Label, .Cards (.Index, .Address, .Label, .Content, .File)"),
+				examples: []string{"qr.batch --account shop --count 20 --out deposit-cards", "qr.batch --account shop --count 20 --out deposit-cards --content uri"}},
+			{name: "share.create", handler: r.handleShareCreate, interactive: true, accountArgs: 1,
+				usages: usages(accountID+" [--ttl 24h] [--name <text>]", "Create a password-protected, expiring link showing the account's receive addresses and QR codes on the web server"),
+				args: arguments("accountID", accountIDArg,
+					"--ttl", "how long the link works, e.g. 90m, 12h or 7d (at most 30d), 24h by default",
+					"--name", "heading shown on the page"),
+				examples: []string{`share.create shop --ttl 7d --name "ACME Ltd deposits"`}},
+			{name: "share.list", handler: r.handleShareList, readOnly: true,
+				usages: usages("", "List share links and whether they are active, expired or revoked")},
+			// 吊销只改写 shares.json，不动钱包存储：serve 运行时可以用 --read-only 打开 REPL 吊销
+			{name: "share.revoke", handler: r.handleShareRevoke, readOnly: true,
+				usages: usages("<id>", "Revoke a share link; the page stops showing addresses (also works with --read-only while serve is running)")},
+			{name: "watch.start", handler: r.handleWatchStart,
+				usages: usages("[intervalSeconds]", "Watch receive addresses for incoming payments in the background")},
+			{name: "watch.stop", handler: r.handleWatchStop,
+				usages: usages("", "Stop the payment watcher")},
+			{name: "watch.status", handler: r.handleWatchStatus, readOnly: true,
+				usages: usages("", "Show watcher state and recent incoming payments")},
+			{name: "watch.import", handler: r.handleWatchImport,
+				usages: usages("<file.csv>", "Import external addresses (coin,address[,label]) as watch-only, not spendable")},
+			{name: "watch.list", handler: r.handleWatchList, readOnly: true,
+				usages: usages("[--balance]", "List watch-only addresses, optionally with balances")},
+			{name: "watch.remove", handler: r.handleWatchRemove,
+				usages: usages("<address>", "Stop watching a watch-only address")},
+			{name: "alert.add", handler: r.handleAlertAdd,
+				usages: usages("balance.below|tx.above <coin|"+accountID+"> <amount> | address.reuse [coin|"+accountID+"]",
+					"Add an alert rule evaluated by the payment watcher, firing alert.fired notifications"),
+				args: arguments(
+					"balance.below", "the balance of the coin or account drops below amount; fires again only after it recovers",
+					"tx.above", "a single outgoing transaction sends more than amount, fee excluded",
+					"address.reuse", "a receive address that was already paid receives another payment"),
+				examples: []string{"alert.add balance.below BTC 0.01", "alert.add tx.above savings 0.5", "alert.add address.reuse"}},
+			{name: "alert.list", handler: r.handleAlertList, readOnly: true,
+				usages: usages("", "List alert rules and whether they are firing")},
+			{name: "alert.remove", handler: r.handleAlertRemove,
+				usages: usages("<id>", "Remove an alert rule")},
+		}},
+		{"METADATA", []command{
+			{name: "label.set", handler: r.handleLabelSet,
+				usages: usages("<accountID|address> <label>", "Label an account or address")},
+			{name: "label.remove", handler: r.handleLabelRemove,
+				usages: usages("<accountID|address>", "Remove a label")},
+			{name: "label.list", handler: r.handleLabelList, readOnly: true,
+				usages: usages("", "List labels")},
+			{name: "tag.add", handler: r.handleTagAdd,
+				usages:   usages("<accountID|address> <tag>...", "Tag an account or address; tags are hierarchical, e.g. clients/acme"),
+				examples: []string{"tag.add savings personal/savings", "tag.add bc1q... clients/acme invoices"}},
+			{name: "tag.remove", handler: r.handleTagRemove,
+				usages: usages("<accountID|address> <tag>...", "Remove tags (tags below them are kept)")},
+			{name: "tag.list", handler: r.handleTagList, readOnly: true,
+				usages: usages("[tag]", "Show the tag tree with counts, or what is tagged with a tag or one below it")},
+			{name: "account.archive", handler: r.handleAccountArchive,
+				usages: usages(accountID, "Hide an account from account.list")},
+			{name: "account.unarchive", handler: r.handleAccountUnarchive,
+				usages: usages(accountID, "Restore an archived account")},
+			{name: "contact.add", handler: r.handleContactAdd,
+				usages: usages("<name> <address>", "Add a contact (usable as btc.send recipient)")},
+			{name: "contact.remove", handler: r.handleContactRemove,
+				usages: usages("<name>", "Remove a contact")},
+			{name: "contact.rename", handler: r.handleContactRename,
+				usages: usages("<name> <newName>", "Rename a contact")},
+			{name: "contact.list", handler: r.handleContactList, readOnly: true,
+				usages: usages("", "List contacts")},
+			{name: "name.resolve", handler: r.handleNameResolve, readOnly: true,
+				usages:   usages("<name> [coin]", "Resolve an ENS, SNS or Unstoppable Domains name without sending"),
+				args:     arguments("name", "e.g. vitalik.eth, bonfida.sol, brad.crypto", "coin", "address to look up, default ETH (SOL for .sol)"),
+				examples: []string{"name.resolve vitalik.eth", "name.resolve brad.crypto BTC"}},
+			{name: "name.lookup", handler: r.handleNameLookup, readOnly: true,
+				usages: usages("<address>", "Show the verified ENS primary name of an ETH address")},
+			{name: "alias.set", handler: r.handleAliasSet,
+				usages: usages("<alias> "+accountID, "Name an account, usable instead of its ID")},
+			{name: "alias.remove", handler: r.handleAliasRemove,
+				usages: usages("<alias>", "Remove an alias")},
+			{name: "alias.list", handler: r.handleAliasList, readOnly: true,
+				usages: usages("", "List aliases")},
+			{name: "undo", handler: r.handleUndo,
+				usages: usages("[command]", "Undo the last metadata change (of that command)",
+					"--list [n]", "Show recent metadata changes"),
+				examples: []string{"undo", "undo label.set", "undo --list 20"}},
+			{name: "metadata.compact", handler: r.handleMetadataCompact,
+				usages: usages("--before <YYYY-MM-DD>", "Fold metadata events before the date into a snapshot; earlier times can no longer be viewed")},
+			{name: "find", handler: r.handleFind, readOnly: true,
+				usages: usages("<query> [--tag <tag>] [--limit n]", "Search accounts, paths, coins, addresses, labels, tags and contacts"),
+				args:   arguments("--tag", "only results that, or whose account, have this tag or one below it")},
+		}},
+		{"INTEGRITY AND HARDENING", []command{
+			{name: "integrity.status", handler: r.handleIntegrityStatus, readOnly: true,
+				usages: usages("", "Check wallet files against the signed manifest (needs [integrity] enabled)")},
+			{name: "integrity.accept", handler: r.handleIntegrityAccept, interactive: true,
+				usages: usages("", "Accept verified external changes as the new baseline")},
+			{name: "security.status", handler: r.handleSecurityStatus, readOnly: true,
+				usages: usages("", "Show memory locking, core dump and ptrace protection in effect, and the startup security summary")},
+			{name: "security.report", handler: r.handleSecurityReport, readOnly: true,
+				usages: usages("[--json | --reencrypt]", "List the cipher, KDF and parameters of every encrypted record and flag those below the current encryption settings"),
+				args: arguments(
+					"--json", "print the report, per-scheme counts and re-encryption history as JSON",
+					"--reencrypt", "re-encrypt the records below the baseline with the current settings (needs an unlocked wallet; files are backed up and every record verified)"),
+				examples: []string{"security.report", "security.report --reencrypt"}},
+			{name: "storage.rebuild", handler: r.handleStorageRebuild,
+				usages: usages("[--accounts n] [--gap n] [--scan [--parallel n] [--restart]]", "Rebuild lost or damaged account records from the seed and surviving address files"),
+				args: arguments(
+					"--accounts", "account indexes tried per coin, purpose and path convention (default 20)",
+					"--gap", "with --scan, unused addresses in a row before an account's scan stops (default 20)",
+					"--scan", "also query balances to find accounts and addresses whose files are lost (uses the explorer); an interrupted scan continues where it stopped",
+					"--parallel", "with --scan, coins scanned at the same time (default 4)",
+					"--restart", "with --scan, ignore the progress of an earlier interrupted scan and start over"),
+				examples: []string{"storage.rebuild", "storage.rebuild --scan --accounts 5", "storage.rebuild --scan --parallel 8 --restart"}},
+		}},
+		{"TRASH", []command{
+			{name: "account.remove", handler: r.handleAccountRemove, interactive: true,
+				usages: usages(accountID, "Move an account and its addresses to the trash")},
+			{name: "address.remove", handler: r.handleAddressRemove, interactive: true,
+				usages: usages("<address>", "Move a derived address to the trash")},
+			{name: "trash.list", handler: r.handleTrashList, readOnly: true,
+				usages: usages("", "List removed accounts and addresses")},
+			{name: "trash.restore", handler: r.handleTrashRestore,
+				usages: usages("<trashID> [--accept-all]", "Restore a removed account or address, confirming each record that would overwrite a changed one")},
+			{name: "trash.purge", handler: r.handleTrashPurge, interactive: true,
+				usages: usages("<trashID>|--all", "Permanently delete trash entries (also done after [trash] retention_days)")},
+		}},
+		{"MOCKCHAIN", []command{
+			{name: "mockchain.status", handler: r.handleMockchainStatus, readOnly: true,
+				usages: usages("", "Show height, mempool and fee rates of the built-in mock chain")},
+			{name: "mockchain.mine", handler: r.handleMockchainMine,
+				usages: usages("[blocks]", "Mine blocks on the mock chain, confirming pending transactions")},
+		}},
+		{"BACKUP", []command{
+			{name: "backup.qr", handler: r.handleBackupQR, interactive: true,
+				usages:   usages("[--png <dir>] [--svg <dir>] [--fragment-size n] [--animate]", "Export the encrypted backup as a QR code sequence"),
+				examples: []string{"backup.qr", "backup.qr --svg backup-frames --png backup-frames"}},
+			{name: "backup.scan", handler: r.handleBackupScan, interactive: true,
+				usages: usages("[framesFile] [--accept-all]", "Reassemble scanned QR frames and restore the backup")},
+			{name: "backup.restore", handler: r.handleBackupRestore, interactive: true,
+				usages: usages("<file> [--identity <ageKeyFile>] [--accept-all]", "Restore a backup written by 'slowmade backup create'"),
+				args: arguments(
+					"file", "an .age file, or a bundle already decrypted with age -d or gpg -d",
+					"ageKeyFile", "age identity (AGE-SECRET-KEY-1...) for .age files",
+					"--accept-all", "overwrite existing records that differ from the backup without asking; the differences are still shown"),
+				examples: []string{"backup.restore slowmade-backup-20260101T000000Z.age --identity recovery-key.txt"}},
+			{name: "inherit.kit", handler: r.handleInheritKit, interactive: true,
+				usages: usages("[--recipient <key>]... [--shares k] [--note <file>] [--out <dir>]",
+					"Write an inheritance kit for each heir: instructions, xpubs and optionally a share of the recovery words"),
+				args: arguments(
+					"--recipient", "an heir's age public key (age1...); asks step by step when omitted",
+					"--shares", "split the recovery words so that any k heirs together can recover; left out by default",
+					"--note", "text file with instructions for the heirs, such as where the words or the cloak are kept",
+					"--out", "output directory, the current directory by default"),
+				examples: []string{"inherit.kit", "inherit.kit --recipient age1... --recipient ./bob.asc --recipient age1... --shares 2 --note heirs.txt --out ./kit"}},
+			{name: "inherit.combine", handler: r.handleInheritCombine, interactive: true, readOnly: true,
+				usages: usages("", "Combine recovery shares from inheritance kits into the recovery words (prompts for each share)")},
+		}},
+		{"MOBILE PAIRING", []command{
+			{name: "pair.new", handler: r.handlePairNew, interactive: true,
+				usages: usages("", "Pair a companion mobile app on the local network (needs pairing.enabled)")},
+			{name: "pair.list", handler: r.handlePairList, readOnly: true,
+				usages: usages("", "List paired devices")},
+			{name: "pair.remove", handler: r.handlePairRemove,
+				usages: usages("<device>", "Unpair a device; it can no longer connect"),
+				args:   arguments("device", "name or ID from pair.list")},
+			{name: "pair.send", handler: r.handlePairSend, interactive: true,
+				usages: usages("<device> <backup|watch-only>", "Send the encrypted backup or watch-only descriptors to a paired device over the LAN"),
+				args: arguments("device", "name or ID from pair.list",
+					"backup", "the bundle of backup.qr, encrypted with the wallet password",
+					"watch-only", "xpubs and descriptors of all accounts, no secrets"),
+				examples: []string{"pair.send pixel backup", "pair.send 3f9a01c2 watch-only"}},
+		}},
+		{"SYNC", []command{
+			{name: "sync.push", handler: r.handleSyncPush, interactive: true,
+				usages: usages("[--force]", "Push encrypted storage to the sync backend")},
+			{name: "sync.pull", handler: r.handleSyncPull, interactive: true,
+				usages: usages("[--force]", "Pull encrypted storage from the sync backend")},
+			{name: "sync.status", handler: r.handleSyncStatus, readOnly: true, interactive: true,
+				usages: usages("", "Compare local and remote revisions")},
+			{name: "sync.meta-push", handler: r.handleSyncMetaPush, interactive: true,
+				usages: usages("[--file path] [--prefer local|remote]", "Merge remote changes, then push labels, tags, contacts and aliases only, encrypted with a sync password"),
+				args: arguments("--file", "read and write this bundle file instead of the sync backend",
+					"--prefer", "resolve every conflict this way instead of asking"),
+				examples: []string{"sync.meta-push", "sync.meta-push --file /media/usb/metadata.bundle"}},
+			{name: "sync.meta-pull", handler: r.handleSyncMetaPull, interactive: true,
+				usages: usages("[--file path] [--prefer local|remote]", "Merge pushed labels, tags, contacts and aliases into this machine, asking on conflicts"),
+				args: arguments("--file", "read this bundle file instead of the sync backend",
+					"--prefer", "resolve every conflict this way instead of asking")},
+		}},
+	}
+}
+
+// registerCommands 按注册表注册所有命令和别名
+func (r *REPL) registerCommands() {
+	r.groups = r.commandGroups()
+	r.commands = make(map[string]CommandHandler)
+	r.readOnlyCommands = make(map[string]bool)
+	for _, group := range r.groups {
+		for _, c := range group.commands {
+			for _, name := range append([]string{c.name}, c.aliases...) {
+				r.commands[name] = c.handler
+				r.readOnlyCommands[name] = c.readOnly
+			}
+		}
+	}
+}
+
+// commandNames 补全用的命令名，只读模式下只有可用的命令
+func (r *REPL) commandNames() []string {
+	var names []string
+	for _, group := range r.groups {
+		for _, c := range group.commands {
+			for _, name := range append([]string{c.name}, c.aliases...) {
+				if !readOnlyMode() || c.readOnly {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// lookupCommand 按命令名或别名查找注册表中的命令
+func (r *REPL) lookupCommand(name string) (command, bool) {
+	name = strings.ToLower(name)
+	for _, group := range r.groups {
+		for _, c := range group.commands {
+			if c.name == name {
+				return c, true
+			}
+			for _, alias := range c.aliases {
+				if alias == name {
+					return c, true
+				}
+			}
+		}
+	}
+	return command{}, false
+}
+
+// unknownCommandError 未知命令的错误，附上拼写最接近的命令
+func (r *REPL) unknownCommandError(name string) error {
+	suggestions := r.suggestCommands(name)
+	if len(suggestions) == 0 {
+		return usageFailure(fmt.Sprintf("unknown command '%s'. Type 'help' for available commands", name))
+	}
+	return usageFailure(fmt.Sprintf("unknown command '%s', did you mean '%s'?", name, strings.Join(suggestions, "' or '")))
+}
+
+// suggestCommands 编辑距离最小且不超过命令名长度三分之一（至少 1、至多 3）的命令和别名，最多 3 个；
+// 只读模式下也在全部命令中查找，执行时再提示不可用
+func (r *REPL) suggestCommands(name string) []string {
+	name = strings.ToLower(name)
+	limit := min(max(len(name)/3, 1), 3)
+	best := limit
+	var matches []string
+	for _, group := range r.groups {
+		for _, c := range group.commands {
+			for _, candidate := range append([]string{c.name}, c.aliases...) {
+				distance := editDistance(name, candidate)
+				switch {
+				case distance > best:
+				case distance < best || matches == nil:
+					best, matches = distance, []string{candidate}
+				case len(matches) < 3:
+					matches = append(matches, candidate)
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// editDistance 两个字符串的 Levenshtein 距离，相邻字符交换也计为一次编辑（常见的打字错误）
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// rows[i][j] 为 s[:i] 与 t[:j] 的距离，交换需要再往前一行
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
+
+// help 命令的帮助信息
+func (c command) help() view.CommandHelp {
+	return view.CommandHelp{
+		Name:     c.name,
+		Aliases:  c.aliases,
+		Usages:   c.usages,
+		Args:     c.args,
+		Examples: c.examples,
+		ReadOnly: c.readOnly,
+	}
+}
+
+// helpGroups 帮助中显示的分组，只读模式下只列出可用的命令
+func (r *REPL) helpGroups() []view.HelpGroup {
+	var groups []view.HelpGroup
+	for _, group := range r.groups {
+		helpGroup := view.HelpGroup{Title: group.title}
+		for _, c := range group.commands {
+			if !readOnlyMode() || c.readOnly {
+				helpGroup.Commands = append(helpGroup.Commands, c.help())
+			}
+		}
+		if len(helpGroup.Commands) > 0 {
+			groups = append(groups, helpGroup)
+		}
+	}
+	return groups
+}
+
+// usageError 由注册表生成命令的用法提示
+func (r *REPL) usageError(name string) error {
+	c, ok := r.lookupCommand(name)
+	if !ok {
+		return usageFailure("usage: " + name)
+	}
+	return usageFailure("usage: " + strings.Join(c.help().UsageLines(), " | "))
+}
+
+// 帮助命令处理函数，不带参数时列出全部命令，help <command> 显示一条命令的详细用法
+func (r *REPL) handleHelp(args []string) error {
+	switch len(args) {
+	case 0:
+		fmt.Println(r.template.Help(r.helpGroups()))
+		return nil
+	case 1:
+		c, ok := r.lookupCommand(args[0])
+		if !ok {
+			return r.unknownCommandError(args[0])
+		}
+		fmt.Println(r.template.CommandHelp(c.help()))
+		return nil
+	default:
+		return r.usageError("help")
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/diagnostics"
+	"go.uber.org/zap"
+)
+
+// processSafely 执行一行输入，命令 panic 时恢复并提示生成诊断包，REPL 继续运行
+func (r *REPL) processSafely(input string) (err error) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		stack := debug.Stack()
+		command := redactLine(input)
+		r.logger.Error("Command panicked", zap.String("command", command), zap.Any("panic", p))
+		r.offerDiagnostics(fmt.Sprintf("panic: %v\ncommand: %s\n\n%s", p, command, stack))
+		err = fmt.Errorf("internal error: %v", p)
+	}()
+	return r.processInput(input)
+}
+
+// offerDiagnostics 询问是否在当前目录生成诊断包
+func (r *REPL) offerDiagnostics(panicInfo string) {
+	fmt.Println(r.template.Error("Slowmade hit an internal error. This is a bug."))
+	fmt.Println(r.template.Info("A diagnostics bundle contains " + diagnostics.Describe() + "."))
+	answer, err := r.line.Prompt("Create a diagnostics bundle for a bug report? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return
+	}
+
+	opts := r.diagnostics
+	if opts.BaseDir == "" {
+		opts.BaseDir = r.baseDir()
+	}
+	opts.Template = fmt.Sprintf("%T", r.template)
+	opts.Panic = panicInfo
+	path := diagnostics.DefaultName()
+	if _, err := diagnostics.Write(path, opts); err != nil {
+		fmt.Println(r.template.Error(fmt.Sprintf("Failed to create diagnostics bundle: %v", err)))
+		return
+	}
+	fmt.Println(r.template.Success("Diagnostics bundle written to " + path + "; review it before attaching it to a bug report"))
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+)
+
+// fullDiceRolls 达到 256 位熵需要的六面骰子次数
+const fullDiceRolls = 99
+
+// entropySources 解析 wallet.create 的熵参数，加上配置的硬件随机数设备；
+// 不认识的参数按命令行密码处理，避免密码留在历史记录中
+func (r *REPL) entropySources(args []string) ([]mnemonic.EntropySource, error) {
+	var sources []mnemonic.EntropySource
+	appConfig := config.GetAppConfig()
+	if device := appConfig.GetEntropyConfig().Device; device != "" {
+		sources = append(sources, &mnemonic.DeviceSource{Path: device})
+	}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--dice":
+			dice, err := r.readDice()
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, dice)
+		case args[i] == "--entropy-file" && i+1 < len(args):
+			i++
+			sources = append(sources, &mnemonic.FileSource{Path: args[i]})
+		case args[i] == "--device" && i+1 < len(args):
+			i++
+			sources = append(sources, &mnemonic.DeviceSource{Path: args[i]})
+		default:
+			return nil, passwordArgumentError("wallet.create")
+		}
+	}
+	return sources, nil
+}
+
+// readDice 逐行读取骰子结果，空行结束
+func (r *REPL) readDice() (*mnemonic.DiceSource, error) {
+	fmt.Println(r.template.Info(fmt.Sprintf("Roll a six-sided die and enter the results, %d rolls give 256 bits. Empty line to finish.", fullDiceRolls)))
+	var rolls strings.Builder
+	for {
+		line, err := r.line.Prompt(fmt.Sprintf("rolls [%d]> ", len(strings.Fields(rolls.String()))))
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if _, err := mnemonic.ParseDice(line); err != nil {
+			fmt.Println(r.template.Error(err.Error() + ", line ignored"))
+			continue
+		}
+		// 每次掷骰单独成词，便于计数
+		for _, c := range line {
+			if c >= '1' && c <= '6' {
+				rolls.WriteString(string(c) + " ")
+			}
+		}
+	}
+	dice, err := mnemonic.ParseDice(rolls.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(dice.Rolls) < fullDiceRolls {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d rolls are about %d bits; they are mixed with the system RNG, not used alone",
+			len(dice.Rolls), dice.Bits())))
+	}
+	return dice, nil
+}
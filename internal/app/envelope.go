@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// migrateEnvelopes 解锁后把旧格式的密文迁移为信封格式，已全部迁移时不输出任何内容；
+// 失败时保留备份并提醒，不影响本次解锁，两种格式都能正常解密
+func migrateEnvelopes(walletMgr core.WalletManager, password string, template view.DisplayTemplate) {
+	if readOnlyMode() {
+		return
+	}
+	progress := func(done, total int) {
+		if done == 1 {
+			fmt.Println(template.Info(fmt.Sprintf("Upgrading %d encrypted records to the versioned format. "+
+				"The wallet files are backed up first and each record is decrypted and compared before the old one is replaced", total)))
+		}
+		// 最多输出十行进度
+		if done == total || done*10/total != (done-1)*10/total {
+			fmt.Printf("  %d/%d\n", done, total)
+		}
+	}
+	result, err := walletMgr.MigrateEnvelopes(password, progress)
+	if err != nil {
+		message := fmt.Sprintf("Could not upgrade the encrypted records: %v. Nothing is lost, the wallet reads both formats "+
+			"and the upgrade is retried at the next unlock", err)
+		if result != nil && result.Backup != "" {
+			message += "; the files as they were before are in " + result.Backup
+		}
+		fmt.Println(template.Warning(message))
+		return
+	}
+	if result.Migrated > 0 {
+		fmt.Println(template.Success(fmt.Sprintf("Upgraded %d encrypted records, all verified; the backup was removed", result.Migrated)))
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Println(template.Warning(fmt.Sprintf("%d records do not decrypt with the wallet password and were left unchanged: %s",
+			len(result.Skipped), strings.Join(result.Skipped, ", "))))
+	}
+}
+
+// migrateEnvelopes 见同名函数，使用 REPL 的显示模板
+func (r *REPL) migrateEnvelopes(password string) {
+	migrateEnvelopes(r.walletMgr, password, r.template)
+}
+
+// MigrateEnvelopes 命令行解锁后调用，见 migrateEnvelopes
+func (c *Container) MigrateEnvelopes(password string) {
+	migrateEnvelopes(c.WalletMgr, password, view.NewTemplate())
+}
@@ -11,4 +11,6 @@ var (
 	ErrInvalidAddress    = errors.New("invalid address format")
 	ErrInsufficientFunds = errors.New("insufficient funds")
 	ErrInvalidPrivateKey = errors.New("invalid private key")
+	ErrAmbiguousAccount  = errors.New("ambiguous account")
+	ErrPasswordArgument  = errors.New("passwords are not accepted as command arguments")
 )
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/upgrade"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// 进程退出码，供脚本区分失败原因（doctor 命令使用自己的 0/1/2 约定）
+const (
+	ExitOK          = 0
+	ExitError       = 1 // 其他错误
+	ExitUsage       = 2 // 命令用法错误或未知命令
+	ExitConfig      = 3 // 配置文件或配置项无效
+	ExitLocked      = 4 // 钱包已锁定
+	ExitBadPassword = 5 // 密码错误
+	ExitStorage     = 6 // 存储目录不可用：读写失败、被其他进程占用、权限过宽或只读
+)
+
+// ErrUsage 命令参数不符合用法，或命令不存在
+var ErrUsage = errors.New("usage")
+
+// usageFailure 用法错误，消息原样显示，errors.Is(err, ErrUsage) 成立
+type usageFailure string
+
+func (e usageFailure) Error() string { return string(e) }
+
+func (e usageFailure) Is(target error) bool { return target == ErrUsage }
+
+// ExitCode 按错误类型返回退出码，err 为 nil 时返回 ExitOK
+func ExitCode(err error) int {
+	var pathErr *fs.PathError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrUsage):
+		return ExitUsage
+	case errors.Is(err, config.ErrInvalid):
+		return ExitConfig
+	case errors.Is(err, core.ErrWalletLocked):
+		return ExitLocked
+	case errors.Is(err, core.ErrInvalidPassword), errors.Is(err, crypto.ErrInvalidPassword):
+		return ExitBadPassword
+	case errors.Is(err, core.ErrDataDirLocked), errors.Is(err, core.ErrWorldAccessible),
+		errors.Is(err, core.ErrReadOnly), errors.Is(err, upgrade.ErrIncompatible), errors.As(err, &pathErr):
+		return ExitStorage
+	}
+	return ExitError
+}
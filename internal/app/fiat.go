@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/price"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// priceTimeout 单次汇率查询的超时
+const priceTimeout = 30 * time.Second
+
+// priceService 返回会话内共享的汇率服务，缓存在多次发送间复用
+func (r *REPL) priceService() (*price.Service, error) {
+	r.priceMu.Lock()
+	defer r.priceMu.Unlock()
+	if r.prices == nil {
+		appConfig := config.GetAppConfig()
+		prices, err := price.NewService(appConfig.GetPriceConfig())
+		if err != nil {
+			return nil, err
+		}
+		r.prices = prices
+	}
+	return r.prices, nil
+}
+
+// fiatAmount 按当前汇率把法币金额换算为 symbol 的最小单位，并显示汇率和查询时间
+func (r *REPL) fiatAmount(symbol string, fiat *big.Rat, currency string) (*big.Int, *price.Quote, error) {
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown coin %s", symbol)
+	}
+	prices, err := r.priceService()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), priceTimeout)
+	defer cancel()
+	quote, err := prices.Quote(ctx, symbol, currency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the %s/%s rate: %w", symbol, currency, err)
+	}
+	amount := quote.Convert(fiat, info.Decimal)
+	if amount.Sign() == 0 {
+		return nil, nil, fmt.Errorf("the %s amount is less than the smallest %s unit", currency, symbol)
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%s %s = %s at %s %s per %s (%s, %s)",
+		r.format().Decimal(fiat.FloatString(2)), currency, r.format().Amount(amount, info.Decimal, symbol),
+		r.format().Decimal(quote.Rate.FloatString(2)), currency, symbol, quote.Source, r.format().Date(quote.FetchedAt))))
+	return amount, quote, nil
+}
+
+// recheckRate 签名前重新查询汇率，变化超过 price.max_slippage_percent 时中止
+func (r *REPL) recheckRate(quote *price.Quote) error {
+	prices, err := r.priceService()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), priceTimeout)
+	defer cancel()
+	latest, err := prices.Fresh(ctx, quote.Coin, quote.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to re-check the %s/%s rate before signing: %w", quote.Coin, quote.Currency, err)
+	}
+	appConfig := config.GetAppConfig()
+	return quote.Check(latest, appConfig.GetPriceConfig().MaxSlippagePercent)
+}
@@ -2,52 +2,58 @@ package app
 
 import (
 	"fmt"
-	"syscall"
+	"strconv"
+	"strings"
 
 	"github.com/palagend/slowmade/internal/core"
-	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/palagend/slowmade/internal/walletstats"
 	"github.com/palagend/slowmade/pkg/coin"
 	"github.com/palagend/slowmade/pkg/logging"
-	"golang.org/x/term"
+	"github.com/palagend/slowmade/pkg/mnemonic"
 )
 
+// passwordArgumentError 拒绝命令行中的密码：参数会留在 REPL 和 shell 历史以及 ps 输出中
+func passwordArgumentError(command string) error {
+	return fmt.Errorf("%w: run %s without a password and enter it at the prompt, "+
+		"or for automation start slowmade with --password-file or --unlock-fd (unlock at startup) "+
+		"or --password-fd (answer prompts from a pipe)", ErrPasswordArgument, command)
+}
+
 // 钱包管理命令处理函数
 func (r *REPL) handleWalletCreate(args []string) error {
-	var password string
-	if len(args) > 1 {
-		return fmt.Errorf("usage: wallet.create [password]")
+	sources, err := r.entropySources(args)
+	if err != nil {
+		return err
 	}
-	// 如果没有提供密码参数，提示用户输入
-	if len(args) < 1 {
-		fmt.Print("Enter password: ")
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password: %v", err)
-		}
-		password = string(bytePassword)
-		fmt.Println() // 换行，因为ReadPassword不会自动换行
-	} else {
-		// 保持向后兼容，支持命令行参数方式（但不推荐）
-		password = args[0]
-		fmt.Println("Warning: Using password from command line arguments is not secure")
+	password, err := r.passwordPrompt().ReadNew("Wallet password: ")
+	if err != nil {
+		return err
 	}
 
 	// 显示创建中状态
 	fmt.Println(r.template.Info("Creating new HD wallet..."))
+	for _, source := range sources {
+		fmt.Println(r.template.Info("Mixing in entropy from " + source.Name()))
+	}
 
-	_, err := r.walletMgr.CreateNewWallet(password)
+	_, err = r.walletMgr.CreateNewWallet(password, sources...)
 	if err != nil {
 		return fmt.Errorf("failed to create wallet: %v", err)
 	}
+	r.resetIntegrity(password)
 
-	// 显示助记词（重要安全信息）
+	// 显示助记词（重要安全信息），只在终端的独立全屏视图中显示，不进入回滚记录
 	mnemonic, err := r.walletMgr.ExportMnemonic(password)
-	if err == nil && mnemonic != "" {
-		fmt.Printf("\n%s\n", view.Yellow("Mnemonic Phrase:"))
-		fmt.Printf("%s\n\n", view.Green(mnemonic))
-		fmt.Println(r.template.Warning("SAVE THIS MNEMONIC PHRASE IN A SECURE LOCATION!"))
-		fmt.Println(r.template.Warning("It can be used to restore your wallet."))
-		fmt.Println(r.template.Separator())
+	if err == nil {
+		err = r.showMnemonic(string(mnemonic.Bytes()))
+		mnemonic.Destroy()
+	}
+	if err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Mnemonic phrase not shown: %v", err)))
+		fmt.Println(r.template.Warning("Run wallet.mnemonic in a terminal and write it down before funding the wallet."))
 	}
 
 	fmt.Println(r.template.WalletCreated("locked"))
@@ -55,27 +61,53 @@ func (r *REPL) handleWalletCreate(args []string) error {
 }
 
 func (r *REPL) handleWalletRestore(args []string) error {
-	if len(args) < 2 {
-		return fmt.Errorf("usage: wallet.restore <mnemonic> <password>")
+	// 助记词可以跟在命令后面，也可以在不回显的提示中输入；密码只能在提示中输入
+	// 整句加引号或逐词输入都可以，多余的空白、换行、大小写和复制带来的不可见字符不影响
+	var words []string
+	confirmed := false
+	for _, arg := range args {
+		if arg == "--yes" {
+			confirmed = true
+			continue
+		}
+		words = append(words, arg)
+	}
+	phrase := strings.Join(words, " ")
+	if strings.TrimSpace(phrase) == "" {
+		var err error
+		if phrase, err = r.passwordPrompt().Read("Mnemonic: "); err != nil {
+			return err
+		}
+	}
+	phrase = mnemonic.Normalize(phrase)
+	if words := len(strings.Fields(phrase)); words%3 != 0 || words < 12 || words > 24 {
+		return fmt.Errorf("a mnemonic has 12, 15, 18, 21 or 24 words, got %d; %v",
+			words, passwordArgumentError("wallet.restore"))
+	}
+	password, err := r.passwordPrompt().ReadNew("Wallet password: ")
+	if err != nil {
+		return err
+	}
+	if err := r.previewRestore(phrase, password, confirmed); err != nil {
+		return err
 	}
-
-	mnemonic := args[0]
-	password := args[1]
 
 	fmt.Println(r.template.Info("Restoring wallet from mnemonic..."))
 
-	_, err := r.walletMgr.RestoreWalletFromMnemonic(mnemonic, password)
+	_, err = r.walletMgr.RestoreWalletFromMnemonic(phrase, password)
 	if err != nil {
 		return fmt.Errorf("failed to restore wallet: %v", err)
 	}
+	r.resetIntegrity(password)
 
 	fmt.Println(r.template.WalletRestored("locked"))
 	return nil
 }
 
 func (r *REPL) handleWalletUnlock(args []string) error {
-	var password string
-	var err error
+	if len(args) > 0 {
+		return passwordArgumentError("wallet.unlock")
+	}
 
 	// 如果已经解锁，提示用户
 	if !r.walletMgr.IsLocked() {
@@ -83,72 +115,185 @@ func (r *REPL) handleWalletUnlock(args []string) error {
 		return nil
 	}
 
-	// 如果没有提供密码参数，提示用户输入
-	if len(args) < 1 {
-		fmt.Print("Enter password: ")
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password: %v", err)
-		}
-		password = string(bytePassword)
-		fmt.Println() // 换行，因为ReadPassword不会自动换行
-	} else {
-		// 保持向后兼容，支持命令行参数方式（但不推荐）
-		password = args[0]
-		fmt.Println("Warning: Using password from command line arguments is not secure")
+	password, err := r.passwordPrompt().Read("Enter password: ")
+	if err != nil {
+		return err
 	}
 
 	err = r.walletMgr.UnlockWallet(password)
+	metrics.Inc(metrics.Unlocks, "source", "repl", "result", metrics.Result(err))
 	if err != nil {
 		return fmt.Errorf("failed to unlock wallet: %v", err)
 	}
 	r.passwordMgr.SetPassword(password)
+	if err := walletstats.RecordUnlock(r.baseDir(), "repl"); err != nil {
+		logging.Warnf("记录解锁时间失败: %v", err)
+	}
+	if err := r.buildSearchIndex(); err != nil {
+		logging.Warnf("构建查找索引失败: %v", err)
+	}
 	fmt.Println(r.template.WalletUnlocked())
+	if r.checkIntegrity(password) {
+		r.migrateEnvelopes(password)
+	}
 	return nil
 }
 
 func (r *REPL) handleWalletLock(args []string) error {
-	// 锁定钱包
-	r.walletMgr.LockWallet()
-	r.passwordMgr.Clear()
+	r.lockWallet()
 	fmt.Println(r.template.WalletLocked())
 	return nil
 }
 
-func (r *REPL) handleWalletStatus(args []string) error {
-	status := "locked"
-	if !r.walletMgr.IsLocked() {
-		status = "unlocked"
+// lockWallet 锁定钱包并清除会话中依赖解锁状态的数据，手动锁定和自动锁定共用
+func (r *REPL) lockWallet() {
+	r.stopWatch()
+	r.walletMgr.LockWallet()
+	r.passwordMgr.Clear()
+	r.searchIndex = nil
+	if r.integrity != nil {
+		r.integrity.Lock()
 	}
-	fmt.Println(r.template.WalletStatus(status))
-	return nil
 }
 
-// 简化的账户管理命令
+// 简化的账户管理命令：按派生路径创建账户，或按币种和地址派生约定（standard、ledger-live、mew）创建，
+// 用于找回其他钱包按各自路径派生的资金
 func (r *REPL) handleAccountCreate(args []string) error {
+	usage := fmt.Errorf("用法: account.create <派生路径> [--convention <约定>]，例如 m/44'/60'/0'/0/0 或账户级路径 m/84'/0'/0'；" +
+		"或 account.create <币种> [--format <地址格式>] [--convention <standard|ledger-live|mew>] [--index <账户索引>]")
+	formatName, args, err := formatOption(args)
+	if err != nil {
+		return err
+	}
 	if len(args) < 1 {
-		return fmt.Errorf("用法: account create  <派生路径>")
+		return usage
 	}
-
-	derivationPath, err := core.ParseDerivationPath(args[0])
+	var (
+		conventionName string
+		index          = -1
+	)
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		switch args[i] {
+		case "--convention":
+			conventionName = args[i+1]
+		case "--index":
+			value, err := strconv.ParseUint(args[i+1], 10, 31)
+			if err != nil {
+				return fmt.Errorf("无效的账户索引: %s", args[i+1])
+			}
+			index = int(value)
+		default:
+			return usage
+		}
+		i++
+	}
+	convention, err := core.ParsePathConvention(conventionName)
 	if err != nil {
 		return err
 	}
 
+	var derivationPath *core.DerivationPath
+	if strings.HasPrefix(args[0], "m/") {
+		if index >= 0 {
+			return fmt.Errorf("--index 只能和币种一起使用，派生路径中已经包含账户索引")
+		}
+		if formatName != "" {
+			return fmt.Errorf("--format 只能和币种一起使用，派生路径的 purpose 已经决定了地址格式")
+		}
+		if derivationPath, err = core.ParseDerivationPath(args[0]); err != nil {
+			return err
+		}
+	} else {
+		info, ok := coin.LookupSymbol(args[0])
+		if !ok {
+			return fmt.Errorf("不支持的币种: %s", args[0])
+		}
+		// BTC 的地址格式决定账户的 purpose（BIP44/49/84/86），其他币种的格式只影响显示
+		purpose := uint32(44)
+		format, ok, err := addressFormat(info.Symbol, formatName)
+		if err != nil {
+			return err
+		}
+		if ok && format.Script && format.Purpose != purpose {
+			if convention != core.ConventionStandard {
+				return fmt.Errorf("%s 地址只能使用 standard 约定", format.Name)
+			}
+			purpose = format.Purpose
+		}
+		if index < 0 {
+			if index, err = r.nextAccountIndex(info.Type|core.HardenedOffset, purpose, convention); err != nil {
+				return err
+			}
+		}
+		derivationPath = convention.AccountPath(info.Type, uint32(index))
+		if purpose != 44 {
+			components := derivationPath.Components()
+			components[0] = purpose | core.HardenedOffset
+			derivationPath = core.NewDerivationPath(components)
+		}
+		if err := core.PathRuleFor(info.Type).Validate(derivationPath); err != nil {
+			return err
+		}
+	}
+
 	// 创建新账户
-	account, err := r.accountMgr.CreateNewAccount(derivationPath)
+	account, err := r.accountMgr.CreateNewAccount(derivationPath, convention)
 	if err != nil {
 		return fmt.Errorf("创建账户失败: %v", err)
 	}
 
-	logging.Infof("账户创建成功: ID=%s, 币种=%s, 路径=%s",
-		account.ID, account.CoinSymbol, account.DerivationPath)
+	logging.Infof("账户创建成功: ID=%s, 币种=%s, 路径=%s, 约定=%s",
+		account.ID, account.CoinSymbol, account.DerivationPath, account.Convention())
+	r.setVariable(varLastAccount, account.ID, "account.create")
+	if addresses, err := r.accountMgr.GetAddresses(account.ID); err == nil && len(addresses) > 0 {
+		path := ""
+		if addressPath, err := account.AddressPath(0, 0); err == nil {
+			path = "，路径：" + addressPath.String()
+		}
+		display, err := r.addressDisplay(account, "")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s (地址索引: 0，币种：%s， 类型： 收款地址%s)\n", display(addresses[0].Address), account.CoinSymbol, path)
+	}
+	r.warnUnspendableFormat(account)
 	return nil
 }
 
+// nextAccountIndex 币种在该 purpose 和约定下第一个未使用的账户索引，找回 ledger-live 的资金时依次创建 0、1、2…
+func (r *REPL) nextAccountIndex(coinType, purpose uint32, convention core.PathConvention) (int, error) {
+	accounts, err := r.accountMgr.GetAccountsByCoin(coinType)
+	if err != nil {
+		return 0, err
+	}
+	next := 0
+	for _, account := range accounts {
+		path, err := account.Path()
+		if err != nil || account.Standalone || account.Convention() != convention || len(path.HardenedPrefix()) < 3 ||
+			path.Purpose&^core.HardenedOffset != purpose {
+			continue
+		}
+		if index := int(path.AccountIndex &^ core.HardenedOffset); index >= next {
+			next = index + 1
+		}
+	}
+	return next, nil
+}
+
 func (r *REPL) handleAccountList(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("用法: account list  <CoinSymbol>")
+	tag, args, err := r.tagOption(args)
+	if err != nil {
+		return err
+	}
+	order, args, err := sortOption(args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && args[1] != "--all") {
+		return r.usageError("account.list")
 	}
 	coinSymbol := args[0]
 	logging.Debugf("CoinSymbol is %s", coinSymbol)
@@ -156,6 +301,34 @@ func (r *REPL) handleAccountList(args []string) error {
 	if err != nil {
 		return err
 	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if tag != "" {
+		tagged := make([]*core.CoinAccount, 0, len(accountList))
+		for _, account := range accountList {
+			if store.HasTag(account.ID, tag) {
+				tagged = append(tagged, account)
+			}
+		}
+		accountList = tagged
+	}
+
+	// 已归档的账户只在 --all 时显示
+	if len(args) == 1 {
+		active := make([]*core.CoinAccount, 0, len(accountList))
+		for _, account := range accountList {
+			if _, archived := store.Get(metadata.Archived, account.ID); !archived {
+				active = append(active, account)
+			}
+		}
+		if hidden := len(accountList) - len(active); hidden > 0 {
+			fmt.Println(r.template.Info(fmt.Sprintf("%d archived accounts hidden, use --all to show them", hidden)))
+		}
+		accountList = active
+	}
+	core.OrderAccounts(accountList, order, labelOf(store))
 	fmt.Println(r.template.AccountList(accountList))
 	return nil
 }
@@ -167,11 +340,6 @@ func (r *REPL) handleExit(args []string) error {
 	return ErrExitRequested
 }
 
-func (r *REPL) handleHelp(args []string) error {
-	fmt.Println(r.template.Help())
-	return nil
-}
-
 func (r *REPL) handleClear(args []string) error {
 	fmt.Print("\033[H\033[2J")
 	return nil
@@ -179,6 +347,9 @@ func (r *REPL) handleClear(args []string) error {
 
 // 修改 handleHistory 函数使用会话历史记录
 func (r *REPL) handleHistory(args []string) error {
+	if len(args) > 0 && args[0] == "metadata" {
+		return r.handleMetadataHistory(args[1:])
+	}
 	limit := 50 // 默认显示最近50条记录
 
 	if len(args) > 0 {
@@ -211,19 +382,41 @@ func (r *REPL) handleHistory(args []string) error {
 }
 
 func (r *REPL) handleVersion(args []string) error {
-	fmt.Println(r.template.Version())
+	switch {
+	case len(args) == 0:
+		fmt.Println(r.template.Version())
+	case len(args) == 1 && args[0] == "--json":
+		fmt.Println(version.Get().JSON())
+	default:
+		return r.usageError("version")
+	}
 	return nil
 }
 
 func (r *REPL) handleAddressDerive(args []string) error {
+	formatName, args, err := formatOption(args)
+	if err != nil {
+		return err
+	}
 	if len(args) != 3 {
-		return fmt.Errorf("用法: address derive <账户ID> <找零地址/收款地址> [地址索引]")
+		return r.usageError("address.derive")
 	}
 
-	accountID := args[0]
-	changeType := uint32(1)
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	display, err := r.addressDisplay(account, formatName)
+	if err != nil {
+		return err
+	}
+	changeType := uint32(0)
 	if args[1] == "change" {
-		changeType = 0
+		changeType = 1
 	}
 	startIndex := uint32(0)
 	if len(args) > 2 {
@@ -240,7 +433,7 @@ func (r *REPL) handleAddressDerive(args []string) error {
 		return fmt.Errorf("钱包已锁定，请先解锁钱包")
 	}
 
-	fmt.Println(r.template.Info(fmt.Sprintf("正在从账户 %s... 派生地址...", accountID[5:13])))
+	fmt.Println(r.template.Info(fmt.Sprintf("正在从账户 %s... 派生地址...", core.ShortIDs([]string{accountID})[accountID])))
 
 	// 派生地址
 	addr, err := r.accountMgr.DeriveAddress(accountID, changeType, startIndex)
@@ -248,23 +441,66 @@ func (r *REPL) handleAddressDerive(args []string) error {
 		return fmt.Errorf("派生地址失败: %v", err)
 	}
 
+	// 链上已使用过的地址需要提示，严格模式下未确认时不显示
+	_, confirmed, err := r.checkReuse([]*core.AddressKey{addr})
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("地址已被使用，请改用新的地址索引")
+	}
+
+	// 手动指定索引不受限制，超出间隔时提示恢复钱包可能找不到
+	if changeType == 0 {
+		if lastUsed, err := r.lastUsedReceive(accountID); err == nil && checkGap(accountID, startIndex, lastUsed) != nil {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Index %d is more than address.gap_limit unused addresses after the last used one: "+
+				"wallets restoring this seed may not find funds sent here, prefer address.next", startIndex)))
+		}
+	}
+
+	r.setVariable(varLastAccount, accountID, "address.derive")
+	r.setVariable(varLastAddress, addr.Address, "address.derive")
+
 	// 显示派生结果
 	if addr.ChangeType == uint32(0) {
-		fmt.Printf("%s (地址索引: %d，币种：%s， 类型： 收款地址)\n", addr.Address, startIndex, addr.CoinSymbol)
+		fmt.Printf("%s (地址索引: %d，币种：%s， 类型： 收款地址)\n", display(addr.Address), startIndex, addr.CoinSymbol)
 	}
 	if addr.ChangeType == uint32(1) {
-		fmt.Printf("%s (地址索引: %d，币种：%s， 类型： 找零地址)\n", addr.Address, startIndex, addr.CoinSymbol)
+		fmt.Printf("%s (地址索引: %d，币种：%s， 类型： 找零地址)\n", display(addr.Address), startIndex, addr.CoinSymbol)
 	}
 
 	return nil
 }
 
 func (r *REPL) handleAddressList(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("用法: address list <账户ID> [显示数量]")
+	tag, args, err := r.tagOption(args)
+	if err != nil {
+		return err
+	}
+	formatName, args, err := formatOption(args)
+	if err != nil {
+		return err
+	}
+	order, args, err := sortOption(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return r.usageError("address.list")
 	}
 
-	accountID := args[0]
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	display, err := r.addressDisplay(account, formatName)
+	if err != nil {
+		return err
+	}
 
 	// 检查钱包是否已解锁
 	if r.walletMgr.IsLocked() {
@@ -283,8 +519,48 @@ func (r *REPL) handleAddressList(args []string) error {
 		fmt.Println("该账户尚未派生任何地址")
 		return nil
 	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if tag != "" {
+		tagged := make([]*core.AddressKey, 0, len(addresses))
+		for _, addr := range addresses {
+			if store.HasTag(addr.Address, tag) {
+				tagged = append(tagged, addr)
+			}
+		}
+		if len(tagged) == 0 {
+			fmt.Printf("No addresses of this account are tagged %s\n", tag)
+			return nil
+		}
+		addresses = tagged
+	}
 
-	// 显示地址列表
-	fmt.Println(r.template.AddressList(addresses))
+	// 严格模式下未确认时隐藏已使用的地址
+	used, confirmed, err := r.checkReuse(addresses)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fresh := make([]*core.AddressKey, 0, len(addresses))
+		for _, addr := range addresses {
+			if !used[addr.Address] {
+				fresh = append(fresh, addr)
+			}
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("Hiding %d already used addresses", len(used))))
+		addresses = fresh
+	}
+	core.OrderAddresses(addresses, order, labelOf(store))
+
+	// 显示地址列表，按选定的格式换写法时复制记录，不改动缓存中的地址
+	shown := make([]*core.AddressKey, len(addresses))
+	for i, addr := range addresses {
+		copied := *addr
+		copied.Address = display(addr.Address)
+		shown[i] = &copied
+	}
+	fmt.Println(r.template.AddressList(shown))
 	return nil
 }
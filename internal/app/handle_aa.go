@@ -0,0 +1,413 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/aa"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// weiDecimals ETH 的小数位数
+const weiDecimals = 18
+
+// aaFeeMargin 未指定手续费时，节点 gas 价格上浮的百分比，用作 maxFeePerGas 和 maxPriorityFeePerGas
+const aaFeeMargin = 25
+
+var errAADisabled = errors.New("smart accounts are experimental and disabled, set aa.enabled = true in the config to use them")
+
+// aaNode 智能账户需要的以太坊节点：合约调用、字节码、余额、gas 价格和链 ID
+type aaNode interface {
+	aa.Node
+	chain.TxPreparer
+}
+
+// aaSession 一次智能账户操作的上下文
+type aaSession struct {
+	config  config.AAConfig
+	node    aaNode
+	owner   *core.AddressKey
+	account *aa.Account
+}
+
+// aaOptions aa.send 和 aa.deploy 的公共选项
+type aaOptions struct {
+	salt                *big.Int
+	sponsored           bool
+	broadcast           bool
+	maxFee, priorityFee *big.Int
+	data                []byte
+}
+
+// parseAAOptions 解析 from 之后的选项，allowData 为 false 时不接受 --data
+func parseAAOptions(args []string, allowData bool) (*aaOptions, bool) {
+	opts := &aaOptions{salt: new(big.Int)}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--sponsored":
+			opts.sponsored = true
+			continue
+		case "--broadcast":
+			opts.broadcast = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, false
+		}
+		value := args[i+1]
+		var err error
+		switch {
+		case args[i] == "--salt":
+			var ok bool
+			if opts.salt, ok = new(big.Int).SetString(value, 10); !ok || opts.salt.Sign() < 0 {
+				err = errors.New("must be a non-negative integer")
+			}
+		case args[i] == "--max-fee":
+			opts.maxFee, err = coin.ParseUnits(value, gweiDecimals)
+		case args[i] == "--priority-fee":
+			opts.priorityFee, err = coin.ParseUnits(value, gweiDecimals)
+		case args[i] == "--data" && allowData:
+			opts.data, err = hexutil.Decode(value)
+		default:
+			return nil, false
+		}
+		if err != nil {
+			return nil, false
+		}
+		i++
+	}
+	if (opts.maxFee == nil) != (opts.priorityFee == nil) {
+		return nil, false
+	}
+	return opts, true
+}
+
+// openAA 检查实验开关，连接节点并计算所有者在 salt 下的智能账户地址
+func (r *REPL) openAA(ctx context.Context, ownerArg string, salt *big.Int) (*aaSession, error) {
+	appConfig := config.GetAppConfig()
+	aaConfig := appConfig.GetAAConfig()
+	if !aaConfig.Enabled {
+		return nil, errAADisabled
+	}
+	if !common.IsHexAddress(aaConfig.EntryPoint) || !common.IsHexAddress(aaConfig.Factory) {
+		return nil, fmt.Errorf("aa.entry_point and aa.factory must be contract addresses")
+	}
+	owner, err := r.coinAddress(ownerArg, "ETH")
+	if err != nil {
+		return nil, err
+	}
+	client, err := chain.ForCoin("ETH", appConfig)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := client.(aaNode)
+	if !ok {
+		return nil, fmt.Errorf("%w: smart accounts need an rpc.eth node, not %s", chain.ErrNotConfigured, client.Name())
+	}
+	account, err := aa.LoadAccount(ctx, node, common.HexToAddress(aaConfig.Factory), common.HexToAddress(aaConfig.EntryPoint),
+		common.HexToAddress(owner.Address), salt)
+	if err != nil {
+		return nil, err
+	}
+	return &aaSession{config: aaConfig, node: node, owner: owner, account: account}, nil
+}
+
+// 智能账户地址命令处理函数：显示 ETH 地址在 salt 下的 SimpleAccount 地址、是否已部署和余额
+func (r *REPL) handleAAAddress(args []string) error {
+	if len(args) != 1 && !(len(args) == 3 && args[1] == "--salt") {
+		return r.usageError("aa.address")
+	}
+	opts, ok := parseAAOptions(args[1:], false)
+	if !ok {
+		return r.usageError("aa.address")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	session, err := r.openAA(ctx, args[0], opts.salt)
+	if err != nil {
+		return err
+	}
+	deployed, err := session.account.Deployed(ctx, session.node)
+	if err != nil {
+		return fmt.Errorf("failed to fetch code from %s: %v", session.node.Name(), err)
+	}
+	balance, err := session.node.Balance(ctx, session.account.Address.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance from %s: %v", session.node.Name(), err)
+	}
+	fmt.Printf("  Owner:       %s\n", session.owner.Address)
+	fmt.Printf("  Salt:        %s\n", opts.salt)
+	fmt.Printf("  Account:     %s\n", session.account.Address.Hex())
+	if deployed {
+		fmt.Println("  Deployed:    yes")
+	} else {
+		fmt.Println("  Deployed:    no, the first user operation deploys it (aa.deploy or aa.send)")
+	}
+	fmt.Printf("  Balance:     %s ETH\n", r.format().Decimal(coin.FormatUnits(balance, weiDecimals)))
+	fmt.Printf("  Factory:     %s\n", session.account.Factory.Hex())
+	fmt.Printf("  Entry point: %s\n", session.account.EntryPoint.Hex())
+	return nil
+}
+
+// 智能账户转账命令处理函数：由 SimpleAccount.execute 转出 ETH 或调用合约
+func (r *REPL) handleAASend(args []string) error {
+	if len(args) < 3 {
+		return r.usageError("aa.send")
+	}
+	opts, ok := parseAAOptions(args[3:], true)
+	if !ok {
+		return r.usageError("aa.send")
+	}
+	recipient, err := r.resolveRecipient(args[1], "ETH")
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(recipient) {
+		return fmt.Errorf("invalid ETH address %q", recipient)
+	}
+	to := common.HexToAddress(recipient)
+	if to == (common.Address{}) {
+		return fmt.Errorf("refusing to send to the zero address")
+	}
+	value, err := coin.ParseUnits(args[2], weiDecimals)
+	if err != nil || value.Sign() < 0 {
+		return fmt.Errorf("invalid amount %q", args[2])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	session, err := r.openAA(ctx, args[0], opts.salt)
+	if err != nil {
+		return err
+	}
+	if to == session.account.Address {
+		return fmt.Errorf("refusing to send from the smart account to itself")
+	}
+	target := to.Hex()
+	if target != args[1] {
+		target = fmt.Sprintf("%s (%s)", target, args[1])
+	}
+	if _, own := r.accountMgr.IsMine(to.Hex()); own {
+		target += " [mine]"
+	}
+	summary := []string{
+		fmt.Sprintf("To:          %s", target),
+		fmt.Sprintf("Value:       %s ETH", r.format().Decimal(coin.FormatUnits(value, weiDecimals))),
+	}
+	if len(opts.data) > 0 {
+		summary = append(summary, fmt.Sprintf("Data:        %d bytes, selector 0x%x", len(opts.data), opts.data[:min(4, len(opts.data))]))
+	}
+	input := policy.Input{Destination: to.Hex(), Amount: new(big.Rat).SetFrac(value, big.NewInt(1e18))}
+	return r.submitUserOperation(ctx, "aa.send", session, aa.ExecuteData(to, value, opts.data), value, summary, input, opts)
+}
+
+// 智能账户部署命令处理函数：提交只带 initCode 的用户操作
+func (r *REPL) handleAADeploy(args []string) error {
+	if len(args) < 1 {
+		return r.usageError("aa.deploy")
+	}
+	opts, ok := parseAAOptions(args[1:], false)
+	if !ok {
+		return r.usageError("aa.deploy")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	session, err := r.openAA(ctx, args[0], opts.salt)
+	if err != nil {
+		return err
+	}
+	return r.submitUserOperation(ctx, "aa.deploy", session, nil, new(big.Int), nil, policy.Input{}, opts)
+}
+
+// submitUserOperation 构造用户操作：账户未部署时带上 initCode，经 bundler 估算 gas，按需请求 paymaster 代付，
+// 由所有者签名后在 --broadcast 且确认时提交给 bundler
+func (r *REPL) submitUserOperation(ctx context.Context, command string, session *aaSession, callData []byte, value *big.Int,
+	summary []string, target policy.Input, opts *aaOptions) error {
+	bundler, err := aa.NewBundler(session.config.BundlerURL)
+	if err != nil {
+		return err
+	}
+	var paymaster *aa.Paymaster
+	if opts.sponsored {
+		if paymaster, err = aa.NewPaymaster(session.config.PaymasterURL, session.config.PaymasterPolicy, session.config.GasToken); err != nil {
+			return err
+		}
+	}
+	account, node := session.account, session.node
+	if err := bundler.CheckEntryPoint(ctx, account.EntryPoint); err != nil {
+		return err
+	}
+	chainID, err := node.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch chain ID from %s: %v", node.Name(), err)
+	}
+	deployed, err := account.Deployed(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to fetch code from %s: %v", node.Name(), err)
+	}
+	var initCode []byte
+	nonce := new(big.Int)
+	if deployed {
+		if callData == nil {
+			return fmt.Errorf("smart account %s is already deployed", account.Address.Hex())
+		}
+		if nonce, err = account.Nonce(ctx, node); err != nil {
+			return err
+		}
+	} else {
+		initCode = account.InitCode()
+	}
+
+	op := aa.NewUserOperation(account.Address, nonce, initCode, callData)
+	maxFee, priorityFee := opts.maxFee, opts.priorityFee
+	if maxFee == nil {
+		gasPrice, err := node.GasPrice(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch gas price from %s: %v", node.Name(), err)
+		}
+		maxFee = gasPrice.Add(gasPrice, new(big.Int).Div(new(big.Int).Mul(gasPrice, big.NewInt(aaFeeMargin)), big.NewInt(100)))
+		priorityFee = maxFee
+	}
+	op.MaxFeePerGas, op.MaxPriorityFeePerGas = (*hexutil.Big)(maxFee), (*hexutil.Big)(priorityFee)
+	if err := bundler.EstimateGas(ctx, op, account.EntryPoint); err != nil {
+		return err
+	}
+	if paymaster != nil {
+		if err := paymaster.Sponsor(ctx, op, account.EntryPoint); err != nil {
+			return err
+		}
+	}
+	// 自付 gas 时账户余额要够预付最高费用和转出金额
+	balance, err := node.Balance(ctx, account.Address.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance from %s: %v", node.Name(), err)
+	}
+	needed := new(big.Int).Set(value)
+	if paymaster == nil {
+		needed.Add(needed, op.MaxCost())
+	}
+	if balance.Cmp(needed) < 0 {
+		hint := "fund it"
+		if paymaster == nil {
+			hint = "fund it or use --sponsored"
+		}
+		return fmt.Errorf("smart account %s holds %s ETH but needs up to %s ETH, %s", account.Address.Hex(),
+			coin.FormatUnits(balance, weiDecimals), coin.FormatUnits(needed, weiDecimals), hint)
+	}
+	hash := op.Hash(account.EntryPoint, chainID)
+
+	details := []string{
+		fmt.Sprintf("Account:     %s (owner %s)", account.Address.Hex(), session.owner.Address),
+	}
+	if initCode != nil {
+		details = append(details, "Deploy:      yes, this operation creates the account")
+	}
+	details = append(details, summary...)
+	details = append(details,
+		fmt.Sprintf("Nonce:       %s", nonce),
+		fmt.Sprintf("Gas:         call %s, verification %s, pre-verification %s", op.CallGasLimit.ToInt(), op.VerificationGasLimit.ToInt(), op.PreVerificationGas.ToInt()),
+		fmt.Sprintf("Max fee:     %s gwei (priority %s gwei)", coin.FormatUnits(maxFee, gweiDecimals), coin.FormatUnits(priorityFee, gweiDecimals)))
+	if address, ok := op.PaymasterAddress(); ok {
+		payer := "sponsored by paymaster " + address.Hex()
+		if session.config.GasToken != "" {
+			payer = fmt.Sprintf("paid in %s through paymaster %s", session.config.GasToken, address.Hex())
+		}
+		details = append(details, "Gas payer:   "+payer)
+	} else {
+		details = append(details, fmt.Sprintf("Max cost:    %s ETH from the smart account", r.format().Decimal(coin.FormatUnits(op.MaxCost(), weiDecimals))))
+	}
+	details = append(details, fmt.Sprintf("Chain:       %s, entry point %s", chainID, account.EntryPoint.Hex()),
+		fmt.Sprintf("UserOp hash: %s", hash.Hex()))
+
+	fmt.Println(r.template.Warning("Smart accounts are experimental; test with small amounts first"))
+	s, err := r.newSigner()
+	if err != nil {
+		return err
+	}
+	signature, err := s.SignUserOperation(common.HexToAddress(session.owner.Address), hash.Bytes(), details, target)
+	if err != nil {
+		return err
+	}
+	op.Signature = signature
+	if !opts.broadcast {
+		encoded, err := json.MarshalIndent(op, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+		fmt.Println(r.template.Info("User operation signed but not sent, rerun with --broadcast to submit it through the bundler"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := bundler.Send(ctx, op, account.EntryPoint)
+	if err != nil {
+		logger.Record("repl", command, hash.Hex(), err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", command, sent.Hex(), "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Sent user operation %s to %s, check it with aa.status", sent.Hex(), bundler.Name())))
+	r.setVariable(varLastTxID, sent.Hex(), command)
+	return nil
+}
+
+// 用户操作状态命令处理函数：查询 bundler 的回执
+func (r *REPL) handleAAStatus(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("aa.status")
+	}
+	raw, err := hexutil.Decode(args[0])
+	if err != nil || len(raw) != common.HashLength {
+		return fmt.Errorf("invalid user operation hash %q", args[0])
+	}
+	appConfig := config.GetAppConfig()
+	aaConfig := appConfig.GetAAConfig()
+	if !aaConfig.Enabled {
+		return errAADisabled
+	}
+	bundler, err := aa.NewBundler(aaConfig.BundlerURL)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	receipt, err := bundler.Receipt(ctx, common.BytesToHash(raw))
+	if err != nil {
+		return fmt.Errorf("failed to fetch the receipt from %s: %v", bundler.Name(), err)
+	}
+	if receipt == nil {
+		fmt.Println(r.template.Info("Not included yet, or unknown to this bundler"))
+		return nil
+	}
+	if receipt.Success {
+		fmt.Println(r.template.Success("Executed"))
+	} else {
+		fmt.Println(r.template.Error("Reverted"))
+	}
+	fmt.Printf("  Sender:      %s\n", receipt.Sender)
+	fmt.Printf("  Transaction: %s\n", receipt.Receipt.TransactionHash)
+	if block, err := hexutil.DecodeBig(receipt.Receipt.BlockNumber); err == nil {
+		fmt.Printf("  Block:       %s\n", block)
+	}
+	fmt.Printf("  Gas cost:    %s ETH\n", r.format().Decimal(coin.FormatUnits(receipt.GasCost(), weiDecimals)))
+	if receipt.Reason != "" && receipt.Reason != "0x" {
+		fmt.Printf("  Reason:      %s\n", receipt.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/addrformat"
+)
+
+// formatOption 取出参数中的 --format <格式>，返回格式名和其余参数
+func formatOption(args []string) (string, []string, error) {
+	for i, arg := range args {
+		if arg != "--format" {
+			continue
+		}
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("--format needs a format name")
+		}
+		rest := append(append([]string(nil), args[:i]...), args[i+2:]...)
+		return args[i+1], rest, nil
+	}
+	return "", args, nil
+}
+
+// addressFormat 币种选定的地址格式：--format 优先，其次是 [address.formats] 的配置；都没有时 ok 为 false
+func addressFormat(symbol, flag string) (format addrformat.Format, ok bool, err error) {
+	if flag != "" {
+		format, err = addrformat.Lookup(symbol, flag)
+		return format, err == nil, err
+	}
+	name := config.GetAppConfig().Address.Formats[strings.ToLower(symbol)]
+	if name == "" {
+		return addrformat.Format{}, false, nil
+	}
+	if format, err = addrformat.Lookup(symbol, name); err != nil {
+		return format, false, fmt.Errorf("address.formats.%s: %w", strings.ToLower(symbol), err)
+	}
+	return format, true, nil
+}
+
+// addressDisplay 返回显示账户地址的函数：BCH 和 ETH 按选定的格式换一种写法；
+// BTC 的格式是账户的脚本类型，--format 与账户不同时返回错误，配置只决定新账户的类型
+func (r *REPL) addressDisplay(account *core.CoinAccount, flag string) (func(string) string, error) {
+	same := func(address string) string { return address }
+	format, ok, err := addressFormat(account.CoinSymbol, flag)
+	if err != nil || !ok {
+		return same, err
+	}
+	if format.Script {
+		if flag != "" && format.Name != account.ScriptFormat() {
+			return nil, fmt.Errorf("this account has %s addresses; a %s address of the same key is a different address, "+
+				"create an account for it with account.create %s --format %s", account.ScriptFormat(), format.Name, account.CoinSymbol, format.Name)
+		}
+		return same, nil
+	}
+	return func(address string) string {
+		if converted, err := addrformat.Convert(account.CoinSymbol, address, format.Name, nil); err == nil {
+			return converted
+		}
+		return address
+	}, nil
+}
+
+// warnUnspendableFormat 钱包的 BTC 签名只花费 P2PKH 和 P2WPKH 输入，其他脚本类型的地址收到的资金暂时只能在别的钱包中花费
+func (r *REPL) warnUnspendableFormat(account *core.CoinAccount) {
+	switch account.ScriptFormat() {
+	case addrformat.P2SH, addrformat.Bech32m:
+		fmt.Println(r.template.Warning(fmt.Sprintf("btc.send cannot spend from %s addresses yet; "+
+			"funds received here can be spent with another wallet that imports the same mnemonic (path %s)",
+			account.ScriptFormat(), account.DerivationPath)))
+	}
+}
+
+// 地址格式转换命令处理函数：不指定格式时列出地址的所有格式。本钱包的地址用记录中的公钥，
+// BTC 可以换成任何脚本类型；其他地址的 BTC 转换只能在同一公钥哈希的 legacy、p2sh 和 bech32 之间
+func (r *REPL) handleAddressConvert(args []string) error {
+	symbol := ""
+	positional := make([]string, 0, 2)
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--coin" && i+1 < len(args) {
+			symbol = strings.ToUpper(args[i+1])
+			i++
+			continue
+		}
+		positional = append(positional, args[i])
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return r.usageError("address.convert")
+	}
+	address := positional[0]
+
+	owned, publicKey := r.ownedAddress(symbol, address)
+	if symbol == "" {
+		if owned != nil {
+			symbol = owned.CoinSymbol
+		} else {
+			symbol = guessFormatCoin(address)
+		}
+	}
+	source, err := addrformat.Detect(symbol, address)
+	if err != nil {
+		return err
+	}
+
+	targets := addrformat.Formats(symbol)
+	if len(positional) == 2 {
+		target, err := addrformat.Lookup(symbol, positional[1])
+		if err != nil {
+			return err
+		}
+		targets = []addrformat.Format{target}
+	}
+
+	fmt.Printf("%s %s address (%s)\n", symbol, source, sourceOwner(owned))
+	script := false
+	for _, format := range targets {
+		converted, err := addrformat.Convert(symbol, address, format.Name, publicKey)
+		if err != nil {
+			if !errors.Is(err, addrformat.ErrNeedsPublicKey) {
+				return err
+			}
+			converted = "(needs the public key, only for addresses of this wallet)"
+		}
+		marker := " "
+		if format.Name == source {
+			marker = "*"
+		}
+		fmt.Printf("%s %-10s %s\n", marker, format.Name, converted)
+		script = script || (format.Script && format.Name != source)
+	}
+	if script {
+		fmt.Println(r.template.Warning("Each BTC format is a different address with its own script: coins sent to one are not found under another. " +
+			"This wallet only watches the format of the account that derived the address."))
+	}
+	if owned == nil && symbol == "BTC" && strings.HasPrefix(address, "1") {
+		fmt.Println(r.template.Info("Legacy 1... addresses are also BCH addresses; use --coin BCH to convert to CashAddr"))
+	}
+	return nil
+}
+
+// ownedAddress 本钱包派生的地址记录和公钥，BCH 和 ETH 按默认写法再查一次
+func (r *REPL) ownedAddress(symbol, address string) (*core.AddressKey, []byte) {
+	lookup := []string{address}
+	for _, candidate := range []string{symbol, "BCH", "ETH"} {
+		if formats := addrformat.Formats(candidate); candidate != "BTC" && formats != nil {
+			if canonical, err := addrformat.Convert(candidate, address, formats[0].Name, nil); err == nil {
+				lookup = append(lookup, canonical)
+			}
+		}
+	}
+	for _, candidate := range lookup {
+		addr, ok := r.accountMgr.IsMine(candidate)
+		if !ok || (symbol != "" && addr.CoinSymbol != symbol) {
+			continue
+		}
+		publicKey, err := hex.DecodeString(addr.PublicKey)
+		if err != nil {
+			return addr, nil
+		}
+		return addr, publicKey
+	}
+	return nil, nil
+}
+
+// guessFormatCoin 按前缀猜测地址的币种，1... 和 3... 按 BTC 处理
+func guessFormatCoin(address string) string {
+	lower := strings.ToLower(address)
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		return "ETH"
+	case strings.HasPrefix(lower, "bitcoincash:"), strings.HasPrefix(lower, "q"), strings.HasPrefix(lower, "p"):
+		return "BCH"
+	default:
+		return "BTC"
+	}
+}
+
+// sourceOwner 地址归属的说明
+func sourceOwner(owned *core.AddressKey) string {
+	if owned == nil {
+		return "not derived by this wallet"
+	}
+	return fmt.Sprintf("account %s, index %d", core.ShortIDs([]string{owned.AccountID})[owned.AccountID], owned.AddressIndex)
+}
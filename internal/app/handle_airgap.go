@@ -0,0 +1,466 @@
+package app
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/airgap"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/qr"
+	"github.com/palagend/slowmade/pkg/ur"
+)
+
+// airgapMethods airgap.request 的请求类型对应的签名方法
+var airgapMethods = map[string]string{
+	"eth":     airgap.MethodETHTransaction,
+	"message": airgap.MethodPersonalSign,
+	"btc":     airgap.MethodBTCTransaction,
+}
+
+// airgapOptions 签名请求和响应的输出方式
+type airgapOptions struct {
+	out  string
+	qr   bool
+	memo string
+}
+
+// parseAirgapArgs 解析 --out、--qr 和 --memo，返回其余的位置参数；allowMemo 为 false 时 --memo 视为用法错误
+func parseAirgapArgs(args []string, allowMemo bool) ([]string, *airgapOptions, bool) {
+	opts := &airgapOptions{}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--qr":
+			opts.qr = true
+		case args[i] == "--out" && i+1 < len(args):
+			i++
+			opts.out = args[i]
+		case allowMemo && args[i] == "--memo" && i+1 < len(args):
+			i++
+			opts.memo = args[i]
+		case strings.HasPrefix(args[i], "--"):
+			return nil, nil, false
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest, opts, true
+}
+
+func (r *REPL) airgapLedger() (*airgap.Ledger, error) {
+	return airgap.LoadLedger(filepath.Join(r.baseDir(), airgap.LedgerFileName))
+}
+
+// 签名请求命令处理函数，在联网的观察实例上准备待离线签名的请求
+func (r *REPL) handleAirgapRequest(args []string) error {
+	usage := r.usageError("airgap.request")
+	rest, opts, ok := parseAirgapArgs(args, true)
+	if !ok || len(rest) != 2 {
+		return usage
+	}
+	method, ok := airgapMethods[rest[0]]
+	if !ok {
+		return usage
+	}
+	payload, err := os.ReadFile(rest[1])
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	if !json.Valid(payload) {
+		return fmt.Errorf("%s is not valid JSON", rest[1])
+	}
+
+	appConfig := config.GetAppConfig()
+	airgapConfig := appConfig.GetAirgapConfig()
+	ttl := time.Duration(max(airgapConfig.RequestTTLMinutes, 1)) * time.Minute
+	req, err := airgap.NewRequest(method, payload, ttl)
+	if err != nil {
+		return err
+	}
+	if opts.memo != "" {
+		req.Metadata = map[string]string{"memo": opts.memo}
+	}
+	// 观察实例知道签名地址的派生路径时一并写入，签名端据此核对
+	signers, err := req.Signers()
+	if err != nil {
+		return err
+	}
+	for _, address := range signers {
+		if addr, ok := r.accountMgr.IsMine(address); ok {
+			path, err := r.addressPath(addr)
+			if err != nil {
+				return err
+			}
+			if req.Paths == nil {
+				req.Paths = make(map[string]string)
+			}
+			req.Paths[address] = path
+		}
+	}
+	if !r.walletMgr.IsLocked() {
+		if req.Wallet, err = r.accountMgr.MasterFingerprint(); err != nil {
+			return err
+		}
+	}
+
+	data, err := req.Encode()
+	if err != nil {
+		return err
+	}
+	ledger, err := r.airgapLedger()
+	if err != nil {
+		return err
+	}
+	if err := ledger.Issue(req); err != nil {
+		return err
+	}
+	if opts.out == "" {
+		opts.out = "sign-request-" + req.ID[:8] + ".json"
+	}
+	if err := r.writeAirgapMessage(data, opts); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Signing request %s (%s) expires %s", req.ID[:8], req.Method, r.format().Date(req.ExpiresAt))))
+	fmt.Println(r.template.Info("Sign it with airgap.sign on the offline instance, then load the response with airgap.accept"))
+	return nil
+}
+
+// 离线签名命令处理函数，校验请求的有效期、来源钱包和派生路径，签名后生成响应
+func (r *REPL) handleAirgapSign(args []string) error {
+	rest, opts, ok := parseAirgapArgs(args, false)
+	if !ok || len(rest) > 1 {
+		return r.usageError("airgap.sign")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	data, err := r.readAirgapMessage(rest)
+	if err != nil {
+		return err
+	}
+	req, err := airgap.ParseRequest(data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(r.template.Info(fmt.Sprintf("Signing request %s: %s", req.ID[:8], req.Method)))
+	fmt.Printf("  Created:   %s\n", r.format().Date(req.CreatedAt))
+	fmt.Printf("  Expires:   %s\n", r.format().Date(req.ExpiresAt))
+	keys := make([]string, 0, len(req.Metadata))
+	for key := range req.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  %-10s %s\n", key+":", req.Metadata[key])
+	}
+	r.printSnapshotHeader()
+	if req.Wallet != "" {
+		fingerprint, err := r.accountMgr.MasterFingerprint()
+		if err != nil {
+			return err
+		}
+		if fingerprint != req.Wallet {
+			return fmt.Errorf("%w: request %s, this wallet %s", airgap.ErrWrongWallet, req.Wallet, fingerprint)
+		}
+	}
+	if err := r.checkAirgapPaths(req); err != nil {
+		return err
+	}
+
+	ledger, err := r.airgapLedger()
+	if err != nil {
+		return err
+	}
+	if err := ledger.Consume(req, time.Now()); err != nil {
+		return err
+	}
+
+	logger := audit.ForDir(r.baseDir())
+	result, txid, err := r.signAirgapRequest(req)
+	var resp *airgap.Response
+	switch {
+	case errors.Is(err, signer.ErrRejected), errors.Is(err, policy.ErrDenied):
+		logger.Record("repl", "airgap.sign", req.ID, "rejected")
+		resp, err = airgap.RejectResponse(req, err.Error())
+	case err != nil:
+		logger.Record("repl", "airgap.sign", req.ID, err.Error())
+		return fmt.Errorf("%w (request %s cannot be signed again, create a new one)", err, req.ID[:8])
+	default:
+		logger.Record("repl", "airgap.sign", req.ID, "ok")
+		resp, err = airgap.NewResponse(req, result, txid)
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := resp.Encode()
+	if err != nil {
+		return err
+	}
+	if opts.out == "" {
+		opts.out = "sign-response-" + req.ID[:8] + ".json"
+	}
+	if err := r.writeAirgapMessage(out, opts); err != nil {
+		return err
+	}
+	if resp.Status == airgap.StatusRejected {
+		fmt.Println(r.template.Warning("Request rejected, the response tells the online instance"))
+		return nil
+	}
+	fmt.Println(r.template.Success("Request signed, load the response with airgap.accept on the online instance"))
+	return nil
+}
+
+// signAirgapRequest 按请求的方法签名，用户拒绝时返回 signer.ErrRejected，策略拒绝时返回 policy.ErrDenied
+func (r *REPL) signAirgapRequest(req *airgap.Request) (string, string, error) {
+	switch req.Method {
+	case airgap.MethodETHTransaction:
+		args, err := req.ETHTransaction()
+		if err != nil {
+			return "", "", err
+		}
+		r.checkSnapshotETH(args)
+		s, err := r.newSigner()
+		if err != nil {
+			return "", "", err
+		}
+		raw, err := s.SignTransaction(args)
+		if err != nil {
+			return "", "", err
+		}
+		return hexutil.Encode(raw), "", nil
+	case airgap.MethodPersonalSign:
+		payload, err := req.Message()
+		if err != nil {
+			return "", "", err
+		}
+		s, err := r.newSigner()
+		if err != nil {
+			return "", "", err
+		}
+		sig, err := s.SignMessage(payload.Account, payload.Message)
+		if err != nil {
+			return "", "", err
+		}
+		return hexutil.Encode(sig), "", nil
+	case airgap.MethodBTCTransaction:
+		payload, err := req.BTCTransaction()
+		if err != nil {
+			return "", "", err
+		}
+		raw, txid, err := r.signAirgapBTC(payload)
+		if err != nil {
+			return "", "", err
+		}
+		return hex.EncodeToString(raw), txid, nil
+	}
+	return "", "", fmt.Errorf("%w: %q", airgap.ErrUnknownMethod, req.Method)
+}
+
+// signAirgapBTC 展示输入、输出和手续费，确认后用输入地址的私钥签名
+func (r *REPL) signAirgapBTC(payload *airgap.BTCPayload) ([]byte, string, error) {
+	var addresses []*core.AddressKey
+	var in, out, sent int64
+	fmt.Println(r.template.Info("btc_signTransaction"))
+	for _, input := range payload.Inputs {
+		addr, ok := r.accountMgr.IsMine(input.Address)
+		if !ok {
+			return nil, "", fmt.Errorf("input address %s does not belong to this wallet", input.Address)
+		}
+		addresses = append(addresses, addr)
+		in += input.Value
+		fmt.Printf("  Input:     %s (%s BTC)\n", input.Outpoint(), r.format().Decimal(btc.FormatBTC(input.Value)))
+	}
+	outputs := make([]btc.TxOut, 0, len(payload.Outputs))
+	for _, output := range payload.Outputs {
+		script, err := btc.AddressScript(output.Address)
+		if err != nil {
+			return nil, "", fmt.Errorf("output %s: %v", output.Address, err)
+		}
+		outputs = append(outputs, btc.TxOut{Value: output.Value, Script: script})
+		out += output.Value
+		label := output.Address
+		if owner, ok := r.addressOwner(output.Address); ok {
+			label += " (own: " + owner + ")"
+		} else {
+			sent += output.Value
+		}
+		fmt.Printf("  Output:    %s BTC -> %s\n", r.format().Decimal(btc.FormatBTC(output.Value)), label)
+	}
+	fmt.Printf("  Fee:       %s BTC\n", r.format().Decimal(btc.FormatBTC(in-out)))
+	r.checkSnapshotBTC(payload.Inputs, outputs, sent, in-out)
+
+	answer, err := r.line.Prompt("Sign? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return nil, "", signer.ErrRejected
+	}
+	return r.signBTC(payload.Inputs, outputs, addresses)
+}
+
+// checkAirgapPaths 请求中给出的派生路径必须与本钱包派生签名地址的路径一致
+func (r *REPL) checkAirgapPaths(req *airgap.Request) error {
+	for address, path := range req.Paths {
+		addr, ok := r.accountMgr.IsMine(address)
+		if !ok {
+			return fmt.Errorf("address %s does not belong to this wallet", address)
+		}
+		local, err := r.addressPath(addr)
+		if err != nil {
+			return err
+		}
+		if local != path {
+			return fmt.Errorf("address %s is derived at %s here, the request says %s", address, local, path)
+		}
+	}
+	return nil
+}
+
+// addressPath 地址的完整派生路径
+func (r *REPL) addressPath(addr *core.AddressKey) (string, error) {
+	account, err := r.findAccount(addr.AccountID)
+	if err != nil {
+		return "", err
+	}
+	path, err := account.AddressPath(addr.ChangeType, addr.AddressIndex)
+	if err != nil {
+		return "", err
+	}
+	return path.String(), nil
+}
+
+// 接收签名响应命令处理函数，核对响应对应本实例发出的请求后显示签名结果
+func (r *REPL) handleAirgapAccept(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && strings.HasPrefix(args[0], "--")) {
+		return r.usageError("airgap.accept")
+	}
+	data, err := r.readAirgapMessage(args)
+	if err != nil {
+		return err
+	}
+	resp, err := airgap.ParseResponse(data)
+	if err != nil {
+		return err
+	}
+	ledger, err := r.airgapLedger()
+	if err != nil {
+		return err
+	}
+	issued, err := ledger.Accept(resp)
+	if err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "airgap.accept", resp.RequestID, resp.Status)
+
+	if issued.Status == airgap.IssuedRejected {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Request %s was rejected by the signer: %s", resp.RequestID[:8], resp.Error)))
+		return nil
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Request %s signed %s", resp.RequestID[:8], r.format().Date(resp.SignedAt))))
+	if resp.TxID != "" {
+		fmt.Printf("Txid:   %s\n", resp.TxID)
+	}
+	fmt.Printf("Result: %s\n", resp.Result)
+	return nil
+}
+
+// 签名请求列表命令处理函数，默认只显示等待响应的请求
+func (r *REPL) handleAirgapList(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "--all") {
+		return r.usageError("airgap.list")
+	}
+	ledger, err := r.airgapLedger()
+	if err != nil {
+		return err
+	}
+	requests := ledger.List(len(args) == 1)
+	if len(requests) == 0 {
+		fmt.Println("No outstanding signing requests")
+		return nil
+	}
+	now := time.Now()
+	for _, issued := range requests {
+		status := issued.Status
+		if issued.Expired(now) {
+			status = "expired"
+		}
+		req := issued.Request
+		fmt.Printf("%s  %-8s %-20s %s  expires %s\n", req.ID[:8], status, req.Method, r.format().Date(req.CreatedAt), r.format().Date(req.ExpiresAt))
+		if memo := req.Metadata["memo"]; memo != "" {
+			fmt.Printf("          memo: %s\n", memo)
+		}
+		if issued.Status == airgap.IssuedRejected && issued.Error != "" {
+			fmt.Printf("          rejected: %s\n", issued.Error)
+		}
+	}
+	return nil
+}
+
+// readAirgapMessage 读取签名请求或响应：文件可以是 JSON，也可以是每行一个 ur:bytes 分片的扫码结果；
+// 没有给出文件时逐行粘贴扫码结果
+func (r *REPL) readAirgapMessage(args []string) ([]byte, error) {
+	decoder := ur.NewDecoder()
+	if len(args) == 1 {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(string(data))), "ur:") {
+			return data, nil
+		}
+		if err := r.scanFramesFromFile(decoder, args[0]); err != nil {
+			return nil, err
+		}
+	} else if err := r.scanFramesInteractive(decoder); err != nil {
+		return nil, err
+	}
+	if decoder.Type() != ur.BytesType {
+		return nil, fmt.Errorf("unexpected UR type %q", decoder.Type())
+	}
+	message, err := decoder.Message()
+	if err != nil {
+		return nil, err
+	}
+	return ur.DecodeBytes(message)
+}
+
+// writeAirgapMessage 把请求或响应写入文件（权限 0600），需要时再逐帧显示 ur:bytes 二维码
+func (r *REPL) writeAirgapMessage(data []byte, opts *airgapOptions) error {
+	if err := os.WriteFile(opts.out, data, 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", opts.out, err)
+	}
+	fmt.Printf("Wrote %s\n", opts.out)
+	if !opts.qr {
+		return nil
+	}
+	parts := ur.EncodeBytes(data, defaultQRFragmentSize)
+	for i, part := range parts {
+		frame, err := qr.Encode([]byte(strings.ToUpper(part)), qr.LevelM)
+		if err != nil {
+			return fmt.Errorf("frame %d: %v", i+1, err)
+		}
+		fmt.Printf("Frame %d/%d\n%s", i+1, len(parts), view.QR(frame))
+		if i == len(parts)-1 {
+			break
+		}
+		answer, err := r.line.Prompt("Enter for next frame, q to stop: ")
+		if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/alert"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 告警规则命令处理函数，规则由收款监控（watch.start 或 slowmade watch）每轮查询后求值
+func (r *REPL) handleAlertAdd(args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return r.usageError("alert.add")
+	}
+	kind, scope, amount := args[0], "", ""
+	if len(args) > 1 {
+		scope = args[1]
+	}
+	if len(args) > 2 {
+		amount = args[2]
+	}
+	// 范围可以是币种，也可以是账户 ID 或别名（规则的币种取账户的币种）
+	symbol, accountID := "", ""
+	if scope != "" {
+		if _, ok := coin.LookupSymbol(scope); ok {
+			symbol = scope
+		} else {
+			id, err := r.resolveAccountID(scope)
+			if err != nil {
+				return err
+			}
+			account, err := r.findAccount(id)
+			if err != nil {
+				return err
+			}
+			symbol, accountID = account.CoinSymbol, account.ID
+		}
+	}
+	rule, err := alert.NewRule(kind, symbol, accountID, amount)
+	if err != nil {
+		return err
+	}
+	store, err := alert.Load(filepath.Join(r.baseDir(), alert.FileName))
+	if err != nil {
+		return err
+	}
+	if err := store.Add(rule); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Alert %s: %s", rule.ID, rule.Describe())))
+	if r.watchCancel == nil {
+		fmt.Println(r.template.Info("Alerts are evaluated by the payment watcher, start it with watch.start or slowmade watch"))
+	}
+	return nil
+}
+
+func (r *REPL) handleAlertList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("alert.list")
+	}
+	store, err := alert.Load(filepath.Join(r.baseDir(), alert.FileName))
+	if err != nil {
+		return err
+	}
+	rules := store.List()
+	if len(rules) == 0 {
+		fmt.Println("No alert rules")
+		return nil
+	}
+	for _, rule := range rules {
+		state := ""
+		if rule.Firing {
+			state = "  " + view.Yellow("firing")
+		}
+		fmt.Printf("%s  %s  %s%s\n", rule.ID, r.format().Date(rule.CreatedAt), rule.Describe(), state)
+	}
+	return nil
+}
+
+func (r *REPL) handleAlertRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("alert.remove")
+	}
+	store, err := alert.Load(filepath.Join(r.baseDir(), alert.FileName))
+	if err != nil {
+		return err
+	}
+	if err := store.Remove(args[0]); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success("Alert " + args[0] + " removed"))
+	return nil
+}
@@ -0,0 +1,300 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/qr"
+	"github.com/palagend/slowmade/pkg/ur"
+)
+
+const (
+	defaultQRFragmentSize = 200
+	qrFrameInterval       = 400 * time.Millisecond
+)
+
+// 备份二维码命令处理函数，将加密备份包导出为 UR 分片二维码序列
+func (r *REPL) handleBackupQR(args []string) error {
+	usage := r.usageError("backup.qr")
+	var (
+		pngDir       string
+		svgDir       string
+		animate      bool
+		fragmentSize = defaultQRFragmentSize
+	)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--animate":
+			animate = true
+		case args[i] == "--png" && i+1 < len(args):
+			i++
+			pngDir = args[i]
+		case args[i] == "--svg" && i+1 < len(args):
+			i++
+			svgDir = args[i]
+		case args[i] == "--fragment-size" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 10 || n > 1000 {
+				return fmt.Errorf("invalid fragment size %q (10-1000 bytes)", args[i])
+			}
+			fragmentSize = n
+		default:
+			return usage
+		}
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+
+	bundle, err := backup.Collect(r.baseDir())
+	if err != nil {
+		return err
+	}
+	password, err := security.Password()
+	if err != nil {
+		return err
+	}
+	sealed, err := bundle.Seal(string(password))
+	security.WipeSensitiveData(password)
+	if err != nil {
+		return err
+	}
+
+	// 大写后可使用二维码字母数字模式，容量更大
+	parts := ur.EncodeBytes(sealed, fragmentSize)
+	frames := make([]*qr.Code, len(parts))
+	for i, part := range parts {
+		if frames[i], err = qr.Encode([]byte(strings.ToUpper(part)), qr.LevelM); err != nil {
+			return fmt.Errorf("frame %d: %v", i+1, err)
+		}
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Backup bundle: %d files, %s encrypted, %d QR frames (version %d)",
+		len(bundle.Files), r.format().Size(int64(len(sealed))), len(frames), frames[0].Version)))
+	fmt.Println(r.template.Warning("The bundle is encrypted with your current wallet password; you will need it to restore"))
+
+	switch {
+	case pngDir != "" || svgDir != "":
+		if pngDir != "" {
+			if err := r.writeQRFrames(pngDir, "png", frames); err != nil {
+				return err
+			}
+		}
+		if svgDir != "" {
+			if err := r.writeQRFrames(svgDir, "svg", frames); err != nil {
+				return err
+			}
+		}
+	case animate:
+		if err := r.animateQRFrames(frames); err != nil {
+			return err
+		}
+	default:
+		for i, frame := range frames {
+			fmt.Printf("Frame %d/%d\n%s", i+1, len(frames), view.QR(frame))
+			if i == len(frames)-1 {
+				break
+			}
+			// 没有看完全部帧不算完成备份
+			answer, err := r.line.Prompt("Enter for next frame, q to stop: ")
+			if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+				return nil
+			}
+		}
+	}
+	r.recordBackup("backup.qr")
+	return nil
+}
+
+// recordBackup 记录备份完成的时间，供安全概况提示；记录失败只警告
+func (r *REPL) recordBackup(method string) {
+	if err := backup.RecordBackup(r.baseDir(), method); err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Failed to record the backup time: %v", err)))
+	}
+}
+
+// writeQRFrames 将每一帧写为图片文件，format 为 png 或 svg
+func (r *REPL) writeQRFrames(dir, format string, frames []*qr.Code) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	for i, frame := range frames {
+		name := filepath.Join(dir, fmt.Sprintf("backup-%03d-of-%03d.%s", i+1, len(frames), format))
+		if err := writeQRImage(name, format, frame, 8, qr.Style{}); err != nil {
+			return err
+		}
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Wrote %d %s frames to %s", len(frames), strings.ToUpper(format), dir)))
+	return nil
+}
+
+// animateQRFrames 在终端循环播放所有帧，直到按下 Ctrl+C
+func (r *REPL) animateQRFrames(frames []*qr.Code) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(qrFrameInterval)
+	defer ticker.Stop()
+
+	for i := 0; ; i = (i + 1) % len(frames) {
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Frame %d/%d (Ctrl+C to stop)\n%s", i+1, len(frames), frames[i].ASCII())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// 扫描二维码命令处理函数，重组 UR 分片并从备份包恢复存储文件
+func (r *REPL) handleBackupScan(args []string) error {
+	args, acceptAll := acceptAllFlag(args)
+	if len(args) > 1 {
+		return r.usageError("backup.scan")
+	}
+	// 恢复会覆盖存储文件，解锁状态下内存中的钱包数据会与磁盘不一致
+	if !r.walletMgr.IsLocked() {
+		return fmt.Errorf("lock the wallet before restoring a backup")
+	}
+
+	decoder := ur.NewDecoder()
+	var err error
+	if len(args) == 1 {
+		err = r.scanFramesFromFile(decoder, args[0])
+	} else {
+		err = r.scanFramesInteractive(decoder)
+	}
+	if err != nil {
+		return err
+	}
+	if decoder.Type() != ur.BytesType {
+		return fmt.Errorf("unexpected UR type %q", decoder.Type())
+	}
+	message, err := decoder.Message()
+	if err != nil {
+		return err
+	}
+	sealed, err := ur.DecodeBytes(message)
+	if err != nil {
+		return err
+	}
+
+	password, err := r.passwordPrompt().Read("Backup password: ")
+	if err != nil {
+		return err
+	}
+	bundle, err := backup.Open(sealed, password)
+	if err != nil {
+		return err
+	}
+	return r.restoreBundle(bundle, acceptAll)
+}
+
+// 从文件恢复命令处理函数，读取 backup create 写入的公钥加密备份，或已在外部解密的备份包
+func (r *REPL) handleBackupRestore(args []string) error {
+	args, acceptAll := acceptAllFlag(args)
+	var file, identityFile string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--identity" && i+1 < len(args):
+			i++
+			identityFile = args[i]
+		case file == "" && !strings.HasPrefix(args[i], "--"):
+			file = args[i]
+		default:
+			return r.usageError("backup.restore")
+		}
+	}
+	if file == "" {
+		return r.usageError("backup.restore")
+	}
+	if !r.walletMgr.IsLocked() {
+		return fmt.Errorf("lock the wallet before restoring a backup")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	var identity []byte
+	if identityFile != "" {
+		if identity, err = os.ReadFile(identityFile); err != nil {
+			return fmt.Errorf("读取文件失败: %w", err)
+		}
+		defer security.WipeSensitiveData(identity)
+	}
+	bundle, err := backup.OpenFile(data, string(identity))
+	if err != nil {
+		return err
+	}
+	return r.restoreBundle(bundle, acceptAll)
+}
+
+// restoreBundle 列出备份包中的文件，确认后与已有记录合并并写回存储目录；
+// 内容不同的已有记录逐条显示差异并确认，acceptAll 时全部覆盖
+func (r *REPL) restoreBundle(bundle *backup.Bundle, acceptAll bool) error {
+	fmt.Printf("Backup created %s:\n", r.format().Date(time.Unix(bundle.CreatedAt, 0)))
+	for _, name := range bundle.Names() {
+		fmt.Printf("  %s\n", name)
+	}
+	answer, err := r.line.Prompt(fmt.Sprintf("Restore %d files into %s? [y/N]: ", len(bundle.Files), r.baseDir()))
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("restore cancelled")
+	}
+	overwritten, err := bundle.Reconcile(r.baseDir(), r.conflictResolver(acceptAll))
+	if err != nil {
+		return fmt.Errorf("restore failed: %v", err)
+	}
+	if err := bundle.Restore(r.baseDir()); err != nil {
+		return fmt.Errorf("restore failed: %v", err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Restored %d files, %d existing records overwritten", len(bundle.Files), overwritten)))
+	fmt.Println(r.template.Warning("Restart slowmade to reload the restored wallet"))
+	return nil
+}
+
+// scanFramesFromFile 从扫码结果文件（每行一个 UR）读取分片
+func (r *REPL) scanFramesFromFile(decoder *ur.Decoder, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := decoder.Receive(line); err != nil {
+			fmt.Println(r.template.Warning(fmt.Sprintf("line %d skipped: %v", n+1, err)))
+		}
+	}
+	if !decoder.Complete() {
+		received, total := decoder.Progress()
+		return fmt.Errorf("%w: %d/%d parts", ur.ErrIncomplete, received, total)
+	}
+	return nil
+}
+
+// scanFramesInteractive 逐行粘贴扫码结果，直到收齐全部分片
+func (r *REPL) scanFramesInteractive(decoder *ur.Decoder) error {
+	fmt.Println(r.template.Info("Paste scanned UR frames one per line, in any order; empty line to abort"))
+	for !decoder.Complete() {
+		received, total := decoder.Progress()
+		line, err := r.line.Prompt(fmt.Sprintf("frame [%d/%d]> ", received, total))
+		if err != nil || strings.TrimSpace(line) == "" {
+			return fmt.Errorf("scan aborted")
+		}
+		if err := decoder.Receive(line); err != nil {
+			fmt.Println(r.template.Error(err.Error()))
+		}
+	}
+	return nil
+}
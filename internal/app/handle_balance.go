@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// 余额查询命令处理函数，通过配置的区块浏览器查询账户下所有地址的余额
+func (r *REPL) handleAccountBalance(args []string) error {
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && args[1] != "--refresh") {
+		return r.usageError("account.balance")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	addresses, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("该账户尚未派生任何地址")
+	}
+	symbol := addresses[0].CoinSymbol
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return fmt.Errorf("unknown coin %s", symbol)
+	}
+
+	appConfig := config.GetAppConfig()
+	explorerConfig := appConfig.GetExplorerConfig()
+	client, err := chain.ForCoin(symbol, appConfig)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(explorerConfig.CacheTTL) * time.Second
+	cached := r.cachedClient(client, symbol, ttl)
+	if len(args) == 2 {
+		cached.SkipCache()
+	}
+
+	// 第三方浏览器会看到全部查询地址和来源 IP，可以据此把这些地址关联到同一个钱包
+	if client.ThirdParty() {
+		fmt.Println(r.template.Warning(fmt.Sprintf(
+			"Privacy: %s will see all %d addresses of this account and your IP address, "+
+				"and can link them together. Use your own node or Tor to avoid this.", client.Name(), len(addresses))))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	total := new(big.Int)
+	var (
+		used, lines []string
+		asOf        time.Time // 使用缓存的余额中最早的查询时间
+		fetchErr    error
+	)
+	for _, addr := range addresses {
+		balance, fetchedAt, err := cached.BalanceAt(ctx, addr.Address)
+		if balance == nil {
+			lines = append(lines, fmt.Sprintf("  %-62s %s", addr.Address, r.template.Error(err.Error())))
+			continue
+		}
+		note := ""
+		if err != nil {
+			note = fmt.Sprintf("  (as of %s)", r.format().Date(fetchedAt))
+			if asOf.IsZero() || fetchedAt.Before(asOf) {
+				asOf = fetchedAt
+			}
+			fetchErr = err
+		} else if time.Since(fetchedAt) > time.Minute {
+			note = fmt.Sprintf("  (cached %s)", r.format().Date(fetchedAt))
+		}
+		lines = append(lines, fmt.Sprintf("  %-62s %s%s", addr.Address, r.format().Amount(balance, info.Decimal, symbol), note))
+		total.Add(total, balance)
+		if balance.Sign() > 0 {
+			used = append(used, addr.Address)
+		}
+	}
+	if fetchErr != nil {
+		r.offlineBanner(client.Name(), asOf, fetchErr)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	r.recordUsage("account.balance", used)
+	fmt.Printf("Total: %s\n", r.format().Amount(total, info.Decimal, symbol))
+	return nil
+}
+
+// offlineCache 打开加密的链上数据缓存，钱包锁定时返回 nil；缓存属于另一个钱包时重新开始，
+// 旧版本的明文余额缓存并入后删除
+func (r *REPL) offlineCache() *chain.OfflineCache {
+	if r.walletMgr.IsLocked() {
+		return nil
+	}
+	seed, err := r.walletMgr.Seed()
+	if err != nil {
+		return nil
+	}
+	key, err := chain.OfflineCacheKey(seed.Bytes())
+	seed.Destroy()
+	if err != nil {
+		return nil
+	}
+	path := filepath.Join(r.baseDir(), chain.OfflineCacheFileName)
+	cache, err := chain.OpenOfflineCache(path, key)
+	if errors.Is(err, chain.ErrOfflineCacheKey) {
+		logging.Warnf("Discarding %s: %v", chain.OfflineCacheFileName, err)
+		if err = os.Remove(path); err == nil {
+			cache, err = chain.OpenOfflineCache(path, key)
+		}
+	}
+	if err != nil {
+		logging.Warnf("Chain data cache unavailable: %v", err)
+		return nil
+	}
+	if err := cache.ImportPlain(filepath.Join(r.baseDir(), chain.CacheFileName), backendSymbols()); err != nil {
+		logging.Warnf("Failed to move %s into the encrypted cache: %v", chain.CacheFileName, err)
+	}
+	return cache
+}
+
+// backendSymbols 按当前配置由后端名称找出币种，用于并入旧的明文缓存；多个币种共用同一后端时无法判断
+func backendSymbols() func(backend string) string {
+	appConfig := config.GetAppConfig()
+	symbols := make(map[string]string)
+	for _, info := range coin.GetAllCoins() {
+		client, err := chain.ForCoin(info.Symbol, appConfig)
+		if err != nil {
+			continue
+		}
+		if _, shared := symbols[client.Name()]; shared {
+			symbols[client.Name()] = ""
+		} else {
+			symbols[client.Name()] = info.Symbol
+		}
+	}
+	return func(backend string) string { return symbols[backend] }
+}
+
+// cachedClient 带缓存的余额查询：解锁时保存在加密缓存中，离线时显示最后一次查询的余额；锁定时只缓存在内存中
+func (r *REPL) cachedClient(client chain.ChainClient, symbol string, ttl time.Duration) *chain.CachedClient {
+	return chain.NewCachedClient(client, "", ttl).Offline(r.offlineCache(), symbol)
+}
+
+// offlineBanner 查询失败、改为显示缓存数据时的提示，下次查询成功时缓存自动更新
+func (r *REPL) offlineBanner(source string, asOf time.Time, err error) {
+	fmt.Println(r.template.Warning(fmt.Sprintf("Offline: %s is unreachable (%v)", source, err)))
+	fmt.Println(r.template.Warning(fmt.Sprintf("Showing last-known data as of %s, it refreshes the next time %s answers",
+		r.format().Date(asOf), source)))
+}
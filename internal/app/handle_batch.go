@@ -0,0 +1,658 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/addrcheck"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/payout"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// ethTransferGas 普通 ETH 转账消耗的 gas
+const ethTransferGas = 21000
+
+// errInvalidPayouts CSV 中有无效的行，整批不签名
+var errInvalidPayouts = errors.New("the payout file has invalid rows, nothing was signed")
+
+// batchOptions send.batch 的参数
+type batchOptions struct {
+	froms                         []string
+	feeRate                       int64
+	gasPrice, maxFee, priorityFee *big.Int
+	nonce                         *uint64
+	chainID                       *big.Int
+	report                        string
+	broadcast                     bool
+}
+
+// batchSource 一个币种的付款账户、它的付款和组合好的交易
+type batchSource struct {
+	coin      string
+	accountID string
+	rows      []*payout.Row
+	total     *big.Int
+
+	// BTC：一笔多输出交易
+	addresses     []*core.AddressKey
+	store         *btc.UTXOStore
+	backend       btc.Backend
+	feeRate       int64
+	selection     *btc.Selection
+	outputs       []btc.TxOut
+	changeScript  []byte
+	changeAddress *core.AddressKey
+
+	// ETH：每笔付款一笔交易，nonce 依次递增
+	from     *core.AddressKey
+	client   chain.ChainClient
+	nonce    uint64
+	gasPrice *big.Int // 旧式交易，或 EIP-1559 交易的最高单价
+	eip1559  bool
+	tip      *big.Int
+	chainID  *big.Int
+}
+
+// fee 整批的手续费（最小单位），ETH 按最高单价计算
+func (s *batchSource) fee() *big.Int {
+	if s.coin == "BTC" {
+		return big.NewInt(s.selection.Fee)
+	}
+	perTx := new(big.Int).Mul(s.gasPrice, big.NewInt(ethTransferGas))
+	return perTx.Mul(perTx, big.NewInt(int64(len(s.rows))))
+}
+
+// decimals 币种的小数位数
+func (s *batchSource) decimals() int {
+	if s.coin == "BTC" {
+		return 8
+	}
+	return weiDecimals
+}
+
+// payoutApprover 用户已确认整批付款：只批准付款地址向批内收款地址的转账，策略和签名习惯要求的额外确认仍逐笔提示
+type payoutApprover struct {
+	r          *REPL
+	from       common.Address
+	recipients map[string]bool
+}
+
+func (a *payoutApprover) Approve(req *signer.ApprovalRequest) bool {
+	return req.Method == "eth_signTransaction" && req.Account == a.from && a.recipients[req.To]
+}
+
+func (a *payoutApprover) Confirm(req *signer.ApprovalRequest) bool {
+	return a.r.confirmPolicy(req.Policy, req.Anomalies, req.Fee)
+}
+
+// 批量付款命令处理函数：先校验全部行，有错误时整批不签名；BTC 合并为一笔交易，ETH 每笔付款一笔交易，
+// 确认汇总后签名，并把每一行的结果写入报告
+func (r *REPL) handleSendBatch(args []string) error {
+	usage := r.usageError("send.batch")
+	file, opts, err := parseBatchArgs(args)
+	if err != nil {
+		return err
+	}
+	if file == "" || len(opts.froms) == 0 {
+		return usage
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	rows, err := payout.ParseCSV(in)
+	in.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	sources, err := r.batchSources(opts.froms)
+	if err != nil {
+		return err
+	}
+	if err := r.validatePayouts(rows, sources); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	var active []*batchSource
+	for _, source := range sources {
+		if len(source.rows) == 0 {
+			fmt.Println(r.template.Warning(fmt.Sprintf("No %s payments in %s, --from %s is not used", source.coin, file, source.accountID)))
+			continue
+		}
+		if source.coin == "BTC" {
+			err = r.prepareBTCBatch(ctx, source, opts)
+		} else {
+			err = r.prepareETHBatch(ctx, source, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", source.coin, err)
+		}
+		active = append(active, source)
+	}
+
+	r.printBatch(active)
+	answer, err := r.line.Prompt(fmt.Sprintf("Type the number of payments (%d) to sign them: ", len(rows)))
+	if err != nil || strings.TrimSpace(answer) != strconv.Itoa(len(rows)) {
+		return fmt.Errorf("batch cancelled")
+	}
+
+	results := make(map[*payout.Row]*payout.Result, len(rows))
+	for _, source := range active {
+		if source.coin == "BTC" {
+			r.sendBTCBatch(ctx, source, opts.broadcast, results)
+		} else {
+			r.sendETHBatch(ctx, source, opts.broadcast, results)
+		}
+	}
+
+	report := opts.report
+	if report == "" {
+		report = strings.TrimSuffix(file, ".csv") + "-results.csv"
+	}
+	ordered := make([]*payout.Result, len(rows))
+	failed := 0
+	for i, row := range rows {
+		ordered[i] = results[row]
+		if ordered[i].Status == payout.StatusFailed || ordered[i].Status == payout.StatusSkipped {
+			failed++
+		}
+	}
+	if err := payout.WriteReport(report, ordered); err != nil {
+		return fmt.Errorf("failed to write the report: %v", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d payments were not completed, see %s", failed, len(rows), report)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("%d payments done, report written to %s", len(rows), report)))
+	return nil
+}
+
+// parseBatchArgs 解析 send.batch 的参数，第一个位置参数为 CSV 文件
+func parseBatchArgs(args []string) (string, batchOptions, error) {
+	var (
+		file string
+		opts batchOptions
+	)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			if file != "" {
+				return "", opts, fmt.Errorf("unexpected argument %q", args[i])
+			}
+			file = args[i]
+			continue
+		}
+		if args[i] == "--broadcast" {
+			opts.broadcast = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return "", opts, fmt.Errorf("%s needs a value", args[i])
+		}
+		value := args[i+1]
+		var err error
+		switch args[i] {
+		case "--from":
+			opts.froms = append(opts.froms, value)
+		case "--report":
+			opts.report = value
+		case "--fee-rate":
+			if opts.feeRate, err = strconv.ParseInt(value, 10, 64); err == nil && opts.feeRate <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "--nonce":
+			var n uint64
+			if n, err = strconv.ParseUint(value, 10, 64); err == nil {
+				opts.nonce = &n
+			}
+		case "--chain-id":
+			var ok bool
+			if opts.chainID, ok = new(big.Int).SetString(value, 10); !ok || opts.chainID.Sign() <= 0 {
+				err = errors.New("must be a positive integer")
+			}
+		case "--gas-price":
+			opts.gasPrice, err = coin.ParseUnits(value, gweiDecimals)
+		case "--max-fee":
+			opts.maxFee, err = coin.ParseUnits(value, gweiDecimals)
+		case "--priority-fee":
+			opts.priorityFee, err = coin.ParseUnits(value, gweiDecimals)
+		default:
+			return "", opts, fmt.Errorf("unknown option %s", args[i])
+		}
+		if err != nil {
+			return "", opts, fmt.Errorf("无效的参数 %s: %s", args[i], value)
+		}
+		i++
+	}
+	if opts.gasPrice != nil && (opts.maxFee != nil || opts.priorityFee != nil) {
+		return "", opts, fmt.Errorf("use either --gas-price or --max-fee/--priority-fee")
+	}
+	if (opts.maxFee == nil) != (opts.priorityFee == nil) {
+		return "", opts, fmt.Errorf("--max-fee and --priority-fee must be given together")
+	}
+	return file, opts, nil
+}
+
+// batchSources 解析 --from：BTC 为账户，ETH 为账户或地址；每个币种只能有一个付款来源
+func (r *REPL) batchSources(froms []string) ([]*batchSource, error) {
+	if r.walletMgr.IsLocked() {
+		return nil, fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	var sources []*batchSource
+	seen := make(map[string]string)
+	for _, from := range froms {
+		symbol := ""
+		if addr, ok := r.accountMgr.IsMine(from); ok {
+			symbol = addr.CoinSymbol
+		} else {
+			accountID, err := r.resolveAccountID(from)
+			if err != nil {
+				return nil, err
+			}
+			addresses, err := r.accountMgr.GetAddresses(accountID)
+			if err != nil {
+				return nil, fmt.Errorf("获取地址列表失败: %v", err)
+			}
+			if len(addresses) == 0 {
+				return nil, fmt.Errorf("account %s has no addresses", accountID)
+			}
+			symbol = addresses[0].CoinSymbol
+		}
+		if previous, ok := seen[symbol]; ok {
+			return nil, fmt.Errorf("--from %s and --from %s are both %s, use one source per coin", previous, from, symbol)
+		}
+		seen[symbol] = from
+
+		source := &batchSource{coin: symbol, total: new(big.Int)}
+		switch symbol {
+		case "BTC":
+			addresses, err := r.btcAccountAddresses(from)
+			if err != nil {
+				return nil, err
+			}
+			source.addresses, source.accountID = addresses, addresses[0].AccountID
+		case "ETH":
+			addr, err := r.coinAddress(from, "ETH")
+			if err != nil {
+				return nil, err
+			}
+			source.from, source.accountID = addr, addr.AccountID
+		default:
+			return nil, fmt.Errorf("send.batch supports BTC and ETH, --from %s is %s", from, symbol)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// validatePayouts 校验每一行的币种、收款地址和金额并分配到付款来源，列出所有错误后整批失败
+func (r *REPL) validatePayouts(rows []*payout.Row, sources []*batchSource) error {
+	appConfig := config.GetAppConfig()
+	network := appConfig.GetRPCConfig().CurrentNetwork()
+	bySymbol := make(map[string]*batchSource, len(sources))
+	for _, source := range sources {
+		bySymbol[source.coin] = source
+	}
+	var problems []string
+	seen := make(map[string]int)
+	for _, row := range rows {
+		source, err := r.payoutSource(row, sources, bySymbol, network)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", row.Line, err))
+			continue
+		}
+		result, err := addrcheck.Validate(source.coin, r.resolveContact(row.Address), network)
+		if err == nil && !result.Valid {
+			err = errors.New(result.Error)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s address %s: %v", row.Line, source.coin, row.Address, err))
+			continue
+		}
+		if source.coin == "ETH" && common.HexToAddress(result.Normalized) == (common.Address{}) {
+			problems = append(problems, fmt.Sprintf("line %d: refusing to send to the zero address", row.Line))
+			continue
+		}
+		row.Coin, row.Recipient = source.coin, result.Normalized
+		if row.Value, err = coin.ParseUnits(row.Amount, source.decimals()); err != nil || row.Value.Sign() <= 0 {
+			problems = append(problems, fmt.Sprintf("line %d: invalid amount %q", row.Line, row.Amount))
+			continue
+		}
+		key := source.coin + ":" + row.Recipient
+		if line, ok := seen[key]; ok {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Line %d pays %s again (line %d), check it is not a duplicate", row.Line, row.Recipient, line)))
+		} else {
+			seen[key] = row.Line
+		}
+		source.rows = append(source.rows, row)
+		source.total.Add(source.total, row.Value)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Println(r.template.Error(problem))
+	}
+	return fmt.Errorf("%w (%d of %d rows)", errInvalidPayouts, len(problems), len(rows))
+}
+
+// payoutSource 行的付款来源：coin 列优先，只有一个来源时用它，否则按地址格式判断
+func (r *REPL) payoutSource(row *payout.Row, sources []*batchSource, bySymbol map[string]*batchSource, network string) (*batchSource, error) {
+	if row.Coin != "" {
+		source, ok := bySymbol[row.Coin]
+		if !ok {
+			return nil, fmt.Errorf("no --from for %s", row.Coin)
+		}
+		return source, nil
+	}
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+	var match *batchSource
+	for _, source := range sources {
+		if result, err := addrcheck.Validate(source.coin, r.resolveContact(row.Address), network); err == nil && result.Valid {
+			if match != nil {
+				return nil, fmt.Errorf("%s is valid for both %s and %s, fill in the coin column", row.Address, match.coin, source.coin)
+			}
+			match = source
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%s is not an address of any --from coin", row.Address)
+	}
+	return match, nil
+}
+
+// prepareBTCBatch 选择输入并组合一笔包含全部付款的交易，找零方式与 btc.send 相同
+func (r *REPL) prepareBTCBatch(ctx context.Context, source *batchSource, opts batchOptions) error {
+	store, backend, err := r.loadUTXOs(source.addresses, true)
+	if errors.Is(err, btc.ErrNotConfigured) {
+		store, backend, err = r.loadUTXOs(source.addresses, false)
+		fmt.Println(r.template.Warning("Bitcoin backend not configured, using cached UTXOs"))
+	}
+	if err != nil {
+		return err
+	}
+	if opts.broadcast && backend == nil {
+		return btc.ErrNotConfigured
+	}
+	source.store, source.backend, source.feeRate = store, backend, opts.feeRate
+	if source.feeRate == 0 {
+		if backend == nil {
+			return fmt.Errorf("--fee-rate is required without a bitcoin backend")
+		}
+		if source.feeRate, err = backend.EstimateFeeRate(ctx, 6); err != nil {
+			return fmt.Errorf("failed to estimate fee rate: %v, pass --fee-rate", err)
+		}
+	}
+
+	payments := make([]btc.TxOut, len(source.rows))
+	scripts := make([][]byte, len(source.rows))
+	for i, row := range source.rows {
+		script, err := btc.AddressScript(row.Recipient)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", row.Line, err)
+		}
+		payments[i], scripts[i] = btc.TxOut{Value: row.Value.Int64(), Script: script}, script
+	}
+	policy, err := r.changePolicy(source.accountID)
+	if err != nil {
+		return err
+	}
+	estimateScript, err := changeTemplate(policy, source.addresses)
+	if err != nil {
+		return err
+	}
+	source.selection, err = btc.SelectCoins(store.Unspent(addressStrings(source.addresses)), btc.SelectionParams{
+		Target:       source.total.Int64(),
+		FeeRate:      source.feeRate,
+		Outputs:      scripts,
+		ChangeScript: estimateScript,
+		Strategy:     btc.StrategyBranchAndBound,
+	})
+	if err != nil {
+		return err
+	}
+	if source.selection.Change > 0 {
+		if source.changeAddress, err = r.changeAddress(source.accountID, policy, source.addresses, source.selection.Inputs); err != nil {
+			return err
+		}
+		if source.changeScript, err = btc.AddressScript(source.changeAddress.Address); err != nil {
+			return err
+		}
+	}
+	source.outputs, err = btc.BuildOutputs(payments, source.changeScript, source.selection.Change)
+	return err
+}
+
+// prepareETHBatch 确定起始 nonce、手续费和链 ID；有节点时检查余额是否足够支付全部付款和最高手续费
+func (r *REPL) prepareETHBatch(ctx context.Context, source *batchSource, opts batchOptions) error {
+	appConfig := config.GetAppConfig()
+	client, err := chain.ForCoin("ETH", appConfig)
+	if err != nil && !errors.Is(err, chain.ErrNotConfigured) {
+		return err
+	}
+	source.client = client
+	preparer, _ := client.(chain.TxPreparer)
+	if opts.broadcast {
+		if _, ok := client.(chain.TxSender); !ok {
+			return fmt.Errorf("--broadcast needs an rpc.eth node")
+		}
+	}
+
+	switch {
+	case opts.nonce != nil:
+		source.nonce = *opts.nonce
+	case preparer == nil:
+		return fmt.Errorf("--nonce is required without an rpc.eth node")
+	default:
+		if source.nonce, err = preparer.Nonce(ctx, source.from.Address); err != nil {
+			return fmt.Errorf("failed to fetch nonce from %s: %v", client.Name(), err)
+		}
+	}
+	switch {
+	case opts.maxFee != nil:
+		source.eip1559, source.gasPrice, source.tip = true, opts.maxFee, opts.priorityFee
+	case opts.gasPrice != nil:
+		source.gasPrice = opts.gasPrice
+	case preparer == nil:
+		return fmt.Errorf("--gas-price or --max-fee/--priority-fee is required without an rpc.eth node")
+	default:
+		if source.gasPrice, err = preparer.GasPrice(ctx); err != nil {
+			return fmt.Errorf("failed to fetch gas price from %s: %v", client.Name(), err)
+		}
+	}
+	source.chainID = opts.chainID
+	if source.chainID == nil {
+		source.chainID = big.NewInt(1)
+		if preparer != nil {
+			if source.chainID, err = preparer.ChainID(ctx); err != nil {
+				return fmt.Errorf("failed to fetch chain ID from %s: %v", client.Name(), err)
+			}
+		}
+	}
+
+	if client != nil {
+		balance, err := client.Balance(ctx, source.from.Address)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the balance of %s: %v", source.from.Address, err)
+		}
+		needed := new(big.Int).Add(source.total, source.fee())
+		if balance.Cmp(needed) < 0 {
+			return fmt.Errorf("%s holds %s ETH, the batch needs up to %s ETH", source.from.Address,
+				coin.FormatUnits(balance, weiDecimals), coin.FormatUnits(needed, weiDecimals))
+		}
+	}
+	return nil
+}
+
+// printBatch 显示每个币种的付款来源、笔数、合计和手续费，以及每一行
+func (r *REPL) printBatch(sources []*batchSource) {
+	for _, source := range sources {
+		format := func(value *big.Int) string {
+			return r.format().Decimal(coin.FormatUnits(value, source.decimals())) + " " + source.coin
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("%s: %d payments, %s", source.coin, len(source.rows), format(source.total))))
+		if source.coin == "BTC" {
+			fmt.Printf("  From:      account %s, %d inputs\n", source.accountID, len(source.selection.Inputs))
+			fmt.Printf("  Fee:       %s (%d sat/vB, %d vB, one transaction)\n", format(source.fee()), source.feeRate, source.selection.VSize)
+			if source.changeAddress != nil {
+				fmt.Printf("  Change:    %s -> %s\n", format(big.NewInt(source.selection.Change)), source.changeAddress.Address)
+			}
+		} else {
+			fmt.Printf("  From:      %s\n", source.from.Address)
+			fmt.Printf("  Fee:       up to %s (%d transactions, nonce %d-%d, chain %s)\n", format(source.fee()),
+				len(source.rows), source.nonce, source.nonce+uint64(len(source.rows))-1, source.chainID)
+		}
+		for _, row := range source.rows {
+			to := row.Recipient
+			if to != row.Address {
+				to = fmt.Sprintf("%s (%s)", to, row.Address)
+			}
+			if _, own := r.accountMgr.IsMine(row.Recipient); own {
+				to += " [mine]"
+			}
+			memo := ""
+			if row.Memo != "" {
+				memo = "  " + row.Memo
+			}
+			fmt.Printf("  %4d  %20s  %s%s\n", row.Line, format(row.Value), to, memo)
+		}
+	}
+}
+
+// sendBTCBatch 签名合并的交易，--broadcast 时确认后广播；所有行共用同一个结果
+func (r *REPL) sendBTCBatch(ctx context.Context, source *batchSource, broadcast bool, results map[*payout.Row]*payout.Result) {
+	fail := func(err error) {
+		for _, row := range source.rows {
+			results[row] = &payout.Result{Row: row, Status: payout.StatusFailed, Error: err.Error()}
+		}
+	}
+	raw, txid, err := r.signBTC(source.selection.Inputs, source.outputs, source.addresses)
+	if err != nil {
+		fail(err)
+		return
+	}
+	fmt.Printf("  Txid:      %s\n", txid)
+	status, errText, rawHex := payout.StatusSigned, "", hex.EncodeToString(raw)
+	if broadcast {
+		recipients := make([]string, len(source.rows))
+		for i, row := range source.rows {
+			recipients[i] = row.Recipient
+		}
+		pending := btc.NewPendingTx(source.selection, source.outputs, source.changeScript)
+		err := r.broadcastBTC(ctx, "send.batch", source.backend, source.store, pending, raw, txid, watch.Spend{
+			AccountID: source.accountID, To: strings.Join(recipients, ","), Amount: source.total.String(), Fee: strconv.FormatInt(source.selection.Fee, 10)})
+		if err != nil {
+			status, errText = payout.StatusFailed, err.Error()
+		} else {
+			status, rawHex = payout.StatusBroadcast, ""
+			if source.changeAddress != nil {
+				r.recordUsage("send.batch", []string{source.changeAddress.Address})
+			}
+		}
+	}
+	for i, row := range source.rows {
+		result := &payout.Result{Row: row, Status: status, TxID: txid, Raw: rawHex, Error: errText}
+		if i == 0 {
+			result.Fee = strconv.FormatInt(source.selection.Fee, 10)
+		}
+		results[row] = result
+	}
+}
+
+// sendETHBatch 按 nonce 顺序逐笔签名，--broadcast 时确认后依次广播；
+// 一笔签名或广播失败后，后面的交易因 nonce 不连续而不再签名或广播
+func (r *REPL) sendETHBatch(ctx context.Context, source *batchSource, broadcast bool, results map[*payout.Row]*payout.Result) {
+	approver := &payoutApprover{r: r, from: common.HexToAddress(source.from.Address), recipients: make(map[string]bool, len(source.rows))}
+	for _, row := range source.rows {
+		approver.recipients[common.HexToAddress(row.Recipient).Hex()] = true
+	}
+	s, err := r.newSignerWith(approver)
+	if err != nil {
+		for _, row := range source.rows {
+			results[row] = &payout.Result{Row: row, Status: payout.StatusFailed, Error: err.Error()}
+		}
+		return
+	}
+	gas := uint64(ethTransferGas)
+	fee := new(big.Int).Mul(source.gasPrice, big.NewInt(ethTransferGas)).String()
+	var signed [][]byte
+	for i, row := range source.rows {
+		if err != nil {
+			results[row] = &payout.Result{Row: row, Status: payout.StatusSkipped, Error: "an earlier transaction of the batch was not signed"}
+			continue
+		}
+		to := common.HexToAddress(row.Recipient)
+		nonce := source.nonce + uint64(i)
+		txArgs := &signer.TransactionArgs{From: approver.from, To: &to, Value: (*hexutil.Big)(row.Value),
+			Nonce: (*hexutil.Uint64)(&nonce), Gas: (*hexutil.Uint64)(&gas), ChainID: (*hexutil.Big)(source.chainID)}
+		if source.eip1559 {
+			txArgs.MaxFeePerGas, txArgs.MaxPriorityFeePerGas = (*hexutil.Big)(source.gasPrice), (*hexutil.Big)(source.tip)
+		} else {
+			txArgs.GasPrice = (*hexutil.Big)(source.gasPrice)
+		}
+		var raw []byte
+		if raw, err = s.SignTransaction(txArgs); err != nil {
+			results[row] = &payout.Result{Row: row, Status: payout.StatusFailed, Error: err.Error()}
+			continue
+		}
+		signed = append(signed, raw)
+		hash := fmt.Sprintf("0x%x", crypto.Keccak256(raw))
+		fmt.Printf("  %4d  nonce %d  %s\n", row.Line, nonce, hash)
+		results[row] = &payout.Result{Row: row, Status: payout.StatusSigned, TxID: hash, Fee: fee, Raw: fmt.Sprintf("0x%x", raw)}
+	}
+	if !broadcast || len(signed) == 0 {
+		return
+	}
+
+	answer, err := r.line.Prompt(fmt.Sprintf("Broadcast %d ETH transactions? [y/N]: ", len(signed)))
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		fmt.Println(r.template.Warning("ETH broadcast cancelled, the signed transactions are in the report"))
+		return
+	}
+	sender := source.client.(chain.TxSender)
+	logger := audit.ForDir(r.baseDir())
+	for i, raw := range signed {
+		row := source.rows[i]
+		result := results[row]
+		sent, err := sender.SendRawTransaction(ctx, raw)
+		if err != nil {
+			logger.Record("repl", "send.batch", result.TxID, err.Error())
+			result.Status, result.Error = payout.StatusFailed, fmt.Sprintf("broadcast failed: %v", err)
+			for _, rest := range source.rows[i+1 : len(signed)] {
+				results[rest].Status = payout.StatusSkipped
+				results[rest].Error = fmt.Sprintf("not broadcast after line %d failed", row.Line)
+			}
+			return
+		}
+		logger.Record("repl", "send.batch", sent, "ok")
+		fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+		r.setVariable(varLastTxID, sent, "send.batch")
+		result.Status, result.TxID, result.Raw = payout.StatusBroadcast, sent, ""
+		if err := r.recordSpend(watch.Spend{Coin: "ETH", AccountID: source.accountID, TxID: sent, To: row.Recipient,
+			Amount: row.Value.String(), Fee: result.Fee, SentAt: time.Now().UTC(), Command: "send.batch"}); err != nil {
+			logging.Warnf("Failed to record spend %s: %v", sent, err)
+		}
+	}
+}
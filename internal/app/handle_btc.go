@@ -0,0 +1,867 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/price"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// changeBranch BIP44 内部链（找零地址）
+const changeBranch = 1
+
+// btcAccountAddresses 返回 BTC 账户下已派生的全部地址
+func (r *REPL) btcAccountAddresses(accountID string) ([]*core.AddressKey, error) {
+	if r.walletMgr.IsLocked() {
+		return nil, fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("该账户尚未派生任何地址")
+	}
+	for _, addr := range addresses {
+		if addr.CoinSymbol != "BTC" {
+			return nil, fmt.Errorf("account %s is not a BTC account", accountID)
+		}
+	}
+	return addresses, nil
+}
+
+// loadUTXOs 加载 UTXO 缓存，配置了后端时先刷新账户地址的 UTXO
+func (r *REPL) loadUTXOs(addresses []*core.AddressKey, refresh bool) (*btc.UTXOStore, btc.Backend, error) {
+	store, err := btc.LoadUTXOStore(filepath.Join(r.baseDir(), btc.UTXOFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	appConfig := config.GetAppConfig()
+	backend, err := btc.NewBackend(appConfig.GetBitcoinConfig())
+	if errors.Is(err, btc.ErrNotConfigured) {
+		if refresh {
+			return nil, nil, err
+		}
+		return store, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if refresh {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := store.Refresh(ctx, backend, addressStrings(addresses)); err != nil {
+			return nil, nil, fmt.Errorf("failed to refresh UTXOs from %s: %v", backend.Name(), err)
+		}
+		r.recordUsage("btc.utxos", store.UsedAddresses(addressStrings(addresses)))
+	}
+	return store, backend, nil
+}
+
+func addressStrings(addresses []*core.AddressKey) []string {
+	result := make([]string, len(addresses))
+	for i, addr := range addresses {
+		result[i] = addr.Address
+	}
+	return result
+}
+
+// UTXO 列表命令处理函数
+func (r *REPL) handleBTCUTXOs(args []string) error {
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && args[1] != "--refresh") {
+		return r.usageError("btc.utxos")
+	}
+	addresses, err := r.btcAccountAddresses(args[0])
+	if err != nil {
+		return err
+	}
+	store, _, err := r.loadUTXOs(addresses, len(args) == 2)
+	if err != nil && !errors.Is(err, btc.ErrNotConfigured) && len(args) == 2 {
+		// 后端不可用时显示本地记录的 UTXO
+		store, _, refreshErr := r.loadUTXOs(addresses, false)
+		if refreshErr != nil {
+			return err
+		}
+		appConfig := config.GetAppConfig()
+		r.offlineBanner("the "+appConfig.GetBitcoinConfig().Backend+" backend", store.Updated, err)
+		return r.printUTXOs(store, addresses)
+	}
+	if err != nil {
+		return err
+	}
+	return r.printUTXOs(store, addresses)
+}
+
+// printUTXOs 列出账户地址的 UTXO 和合计
+func (r *REPL) printUTXOs(store *btc.UTXOStore, addresses []*core.AddressKey) error {
+	utxos := store.Unspent(addressStrings(addresses))
+	if len(utxos) == 0 {
+		fmt.Println("No UTXOs known for this account, run btc.utxos <accountID> --refresh")
+		return nil
+	}
+	total := int64(0)
+	fmt.Printf("  %-70s %16s  %-8s %s\n", "OUTPOINT", "BTC", "HEIGHT", "ADDRESS")
+	for _, u := range utxos {
+		height := "pending"
+		if u.Height > 0 {
+			height = strconv.FormatInt(u.Height, 10)
+		}
+		fmt.Printf("  %-70s %16s  %-8s %s\n", u.Outpoint(), r.format().Decimal(btc.FormatBTC(u.Value)), height, u.Address)
+		total += u.Value
+	}
+	fmt.Printf("Total: %s BTC in %d UTXOs", r.format().Decimal(btc.FormatBTC(total)), len(utxos))
+	if !store.Updated.IsZero() {
+		fmt.Printf(" (updated %s)", r.format().Date(store.Updated))
+	}
+	fmt.Println()
+	return nil
+}
+
+// 余额命令处理函数，后端支持地址订阅时显示已确认和未确认余额，否则汇总 UTXO
+func (r *REPL) handleBTCBalance(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("btc.balance")
+	}
+	addresses, err := r.btcAccountAddresses(args[0])
+	if err != nil {
+		return err
+	}
+	appConfig := config.GetAppConfig()
+	backend, err := btc.NewBackend(appConfig.GetBitcoinConfig())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	accountID := addresses[0].AccountID
+	offline := r.offlineCache()
+	var confirmed, unconfirmed int64
+	if sub, ok := backend.(btc.AddressBackend); ok {
+		balances, err := sub.Balances(ctx, addressStrings(addresses))
+		if err != nil {
+			var asOf time.Time
+			if offline != nil {
+				asOf, ok = offline.Get(chain.SectionBTCBalance, accountID, &balances)
+			}
+			if offline == nil || !ok {
+				return fmt.Errorf("failed to fetch balances from %s: %v", backend.Name(), err)
+			}
+			r.offlineBanner(backend.Name(), asOf, err)
+		} else if offline != nil {
+			if err := offline.Put(chain.SectionBTCBalance, accountID, balances, time.Now()); err != nil {
+				logging.Warnf("Failed to cache balances: %v", err)
+			}
+		}
+		var used []string
+		for _, addr := range addresses {
+			balance := balances[addr.Address]
+			if balance.Confirmed == 0 && balance.Unconfirmed == 0 {
+				continue
+			}
+			used = append(used, addr.Address)
+			pending := r.format().Decimal(btc.FormatBTC(balance.Unconfirmed))
+			if balance.Unconfirmed > 0 {
+				pending = "+" + pending
+			}
+			fmt.Printf("  %-62s %16s  %s pending\n", addr.Address, r.format().Decimal(btc.FormatBTC(balance.Confirmed)), pending)
+			confirmed += balance.Confirmed
+			unconfirmed += balance.Unconfirmed
+		}
+		r.recordUsage("btc.balance", used)
+	} else {
+		store, err := btc.LoadUTXOStore(filepath.Join(r.baseDir(), btc.UTXOFileName))
+		if err != nil {
+			return err
+		}
+		if err := store.Refresh(ctx, backend, addressStrings(addresses)); err != nil {
+			if store.Updated.IsZero() {
+				return fmt.Errorf("failed to refresh UTXOs from %s: %v", backend.Name(), err)
+			}
+			r.offlineBanner(backend.Name(), store.Updated, err)
+		}
+		r.recordUsage("btc.balance", store.UsedAddresses(addressStrings(addresses)))
+		for _, u := range store.Unspent(addressStrings(addresses)) {
+			if u.Height > 0 {
+				confirmed += u.Value
+			} else {
+				unconfirmed += u.Value
+			}
+		}
+	}
+	fmt.Printf("Confirmed:   %s BTC\n", r.format().Decimal(btc.FormatBTC(confirmed)))
+	fmt.Printf("Unconfirmed: %s BTC\n", r.format().Decimal(btc.FormatBTC(unconfirmed)))
+	return nil
+}
+
+// 交易历史命令处理函数，需要支持地址订阅的后端
+func (r *REPL) handleBTCHistory(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("btc.history")
+	}
+	addresses, err := r.btcAccountAddresses(args[0])
+	if err != nil {
+		return err
+	}
+	appConfig := config.GetAppConfig()
+	backend, err := btc.NewBackend(appConfig.GetBitcoinConfig())
+	if err != nil {
+		return err
+	}
+	sub, ok := backend.(btc.AddressBackend)
+	if !ok {
+		return fmt.Errorf("backend %s does not support address history, use the electrum backend", backend.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	accountID := addresses[0].AccountID
+	offline := r.offlineCache()
+	history, err := sub.History(ctx, addressStrings(addresses))
+	if err != nil {
+		var asOf time.Time
+		if offline != nil {
+			asOf, ok = offline.Get(chain.SectionBTCHistory, accountID, &history)
+		}
+		if offline == nil || !ok {
+			return fmt.Errorf("failed to fetch history from %s: %v", backend.Name(), err)
+		}
+		r.offlineBanner(backend.Name(), asOf, err)
+	} else if offline != nil {
+		if err := offline.Put(chain.SectionBTCHistory, accountID, history, time.Now()); err != nil {
+			logging.Warnf("Failed to cache history: %v", err)
+		}
+	}
+	if len(history) == 0 {
+		fmt.Println("No transactions found for this account")
+		return nil
+	}
+	used := make([]string, len(history))
+	for i, item := range history {
+		used[i] = item.Address
+	}
+	r.recordUsage("btc.history", used)
+	// 按高度排序，未确认的排在最后
+	sort.SliceStable(history, func(i, j int) bool {
+		hi, hj := history[i].Height, history[j].Height
+		if hi <= 0 || hj <= 0 {
+			return hi > 0 && hj <= 0
+		}
+		return hi < hj
+	})
+	for _, item := range history {
+		height := "pending"
+		if item.Height > 0 {
+			height = strconv.FormatInt(item.Height, 10)
+		}
+		fmt.Printf("  %-8s %s  %s\n", height, item.TxID, item.Address)
+	}
+	return nil
+}
+
+// BTC 发送命令处理函数
+func (r *REPL) handleBTCSend(args []string) error {
+	usage := r.usageError("btc.send")
+	if len(args) == 0 {
+		return r.sendInteractive()
+	}
+	if len(args) < 2 {
+		return usage
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	// 收款方可以是 BIP21 URI，URI 中的金额在省略金额参数时使用
+	target, rest := args[1], args[2:]
+	var payment *payreq.Payment
+	if payreq.IsURI(target) {
+		if payment, err = payreq.ParseURI(target); err != nil {
+			return err
+		}
+		if payment.Coin != "BTC" {
+			return fmt.Errorf("%w: got a %s URI, btc.send only sends BTC", payreq.ErrCoinMismatch, payment.Coin)
+		}
+		r.printPayment(payment)
+		target = payment.Address
+	}
+	recipient, err := r.resolveRecipient(target, "BTC")
+	if err != nil {
+		return err
+	}
+	var (
+		amount int64
+		quote  *price.Quote // 按法币金额发送时的汇率，签名前重新检查
+	)
+	switch {
+	case len(rest) > 0 && !strings.HasPrefix(rest[0], "--"):
+		if fiat, currency, ok := price.ParseFiat(rest[0]); ok {
+			var sats *big.Int
+			if sats, quote, err = r.fiatAmount("BTC", fiat, currency); err != nil {
+				return err
+			}
+			amount = sats.Int64()
+		} else if amount, err = btc.ParseBTC(rest[0]); err != nil {
+			return err
+		}
+		rest = rest[1:]
+		if payment != nil && payment.Amount != nil && payment.Amount.Int64() != amount {
+			return fmt.Errorf("%w: URI asks for %s BTC, got %s BTC", payreq.ErrAmountMismatch,
+				btc.FormatBTC(payment.Amount.Int64()), btc.FormatBTC(amount))
+		}
+	case payment != nil && payment.Amount != nil:
+		amount = payment.Amount.Int64()
+	default:
+		return usage
+	}
+	recipientScript, err := btc.AddressScript(recipient)
+	if err != nil {
+		return err
+	}
+
+	var (
+		feeRate   int64
+		strategy  = btc.StrategyBranchAndBound
+		fromUTXOs []string
+		broadcast bool
+	)
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case "--broadcast":
+			broadcast = true
+		case "--fee-rate", "--strategy", "--from-utxo":
+			if i+1 >= len(rest) {
+				return usage
+			}
+			value := rest[i+1]
+			switch rest[i] {
+			case "--fee-rate":
+				if feeRate, err = strconv.ParseInt(value, 10, 64); err != nil || feeRate <= 0 {
+					return fmt.Errorf("invalid fee rate %q", value)
+				}
+			case "--strategy":
+				strategy = btc.Strategy(value)
+				if strategy != btc.StrategyBranchAndBound && strategy != btc.StrategyLargestFirst {
+					return fmt.Errorf("unknown strategy %q, use bnb or largest", value)
+				}
+			case "--from-utxo":
+				fromUTXOs = append(fromUTXOs, value)
+			}
+			i++
+		default:
+			return usage
+		}
+	}
+
+	addresses, err := r.btcAccountAddresses(accountID)
+	if err != nil {
+		return err
+	}
+	store, backend, err := r.loadUTXOs(addresses, true)
+	if errors.Is(err, btc.ErrNotConfigured) {
+		// 未配置后端时使用本地缓存，仅生成签名交易
+		store, backend, err = r.loadUTXOs(addresses, false)
+		fmt.Println(r.template.Warning("Bitcoin backend not configured, using cached UTXOs"))
+	}
+	if err != nil {
+		return err
+	}
+	if broadcast && backend == nil {
+		return btc.ErrNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if feeRate == 0 {
+		if backend == nil {
+			return fmt.Errorf("--fee-rate is required without a bitcoin backend")
+		}
+		if feeRate, err = backend.EstimateFeeRate(ctx, 6); err != nil {
+			return fmt.Errorf("failed to estimate fee rate: %v, pass --fee-rate", err)
+		}
+	}
+
+	// 先按找零策略的地址类型估算找零成本，确实需要找零时再选择或派生找零地址
+	policy, err := r.changePolicy(accountID)
+	if err != nil {
+		return err
+	}
+	estimateScript, err := changeTemplate(policy, addresses)
+	if err != nil {
+		return err
+	}
+	params := btc.SelectionParams{
+		Target:       amount,
+		FeeRate:      feeRate,
+		Outputs:      [][]byte{recipientScript},
+		ChangeScript: estimateScript,
+		Strategy:     strategy,
+	}
+
+	utxos := store.Unspent(addressStrings(addresses))
+	var selection *btc.Selection
+	if len(fromUTXOs) > 0 {
+		inputs, err := pickUTXOs(utxos, fromUTXOs)
+		if err != nil {
+			return err
+		}
+		selection, err = btc.FundWith(inputs, params)
+		if err != nil {
+			return err
+		}
+	} else if selection, err = btc.SelectCoins(utxos, params); err != nil {
+		return err
+	}
+
+	var changeAddress *core.AddressKey
+	if selection.Change > 0 {
+		if changeAddress, err = r.changeAddress(accountID, policy, addresses, selection.Inputs); err != nil {
+			return err
+		}
+	}
+	var changeScript []byte
+	if changeAddress != nil {
+		if changeScript, err = btc.AddressScript(changeAddress.Address); err != nil {
+			return err
+		}
+	}
+	outputs, err := btc.BuildOutputs([]btc.TxOut{{Value: amount, Script: recipientScript}}, changeScript, selection.Change)
+	if err != nil {
+		return err
+	}
+
+	if quote != nil {
+		if err := r.recheckRate(quote); err != nil {
+			return err
+		}
+	}
+	raw, txid, err := r.signBTC(selection.Inputs, outputs, addresses)
+	if err != nil {
+		return err
+	}
+
+	to := recipient
+	if recipient != target {
+		to = fmt.Sprintf("%s (%s)", recipient, target)
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s BTC to %s", r.format().Decimal(btc.FormatBTC(amount)), to)))
+	if payment != nil {
+		if payment.Label != "" {
+			fmt.Printf("  Label:     %s\n", payment.Label)
+		}
+		if payment.Message != "" {
+			fmt.Printf("  Message:   %s\n", payment.Message)
+		}
+	}
+	fmt.Printf("  Strategy:  %s\n", selection.Strategy)
+	for _, u := range selection.Inputs {
+		fmt.Printf("  Input:     %s (%s BTC)\n", u.Outpoint(), r.format().Decimal(btc.FormatBTC(u.Value)))
+	}
+	if changeAddress != nil {
+		fmt.Printf("  Change:    %s BTC -> %s (index %d, %s)\n", r.format().Decimal(btc.FormatBTC(selection.Change)), changeAddress.Address, changeAddress.AddressIndex, policy.Mode)
+	} else {
+		fmt.Printf("  Change:    none\n")
+	}
+	fmt.Printf("  Fee:       %s BTC (%d sat/vB, %d vB)\n", r.format().Decimal(btc.FormatBTC(selection.Fee)), feeRate, selection.VSize)
+	fmt.Printf("  Txid:      %s\n", txid)
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(raw))
+
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it"))
+		return nil
+	}
+	if err := r.broadcastBTC(ctx, "btc.send", backend, store, btc.NewPendingTx(selection, outputs, changeScript), raw, txid, watch.Spend{
+		AccountID: accountID, To: recipient, Amount: strconv.FormatInt(amount, 10), Fee: strconv.FormatInt(selection.Fee, 10)}); err != nil {
+		return err
+	}
+	if changeAddress != nil {
+		r.recordUsage("btc.send", []string{changeAddress.Address})
+	}
+	return nil
+}
+
+// signBTC 执行签名策略、对比签名历史后用账户地址的私钥签名交易，输入必须属于给定的地址
+func (r *REPL) signBTC(inputs []btc.UTXO, outputs []btc.TxOut, addresses []*core.AddressKey) ([]byte, string, error) {
+	accountID := ""
+	if len(addresses) > 0 {
+		accountID = addresses[0].AccountID
+	}
+	stats, usage, anomalies := r.checkBTCUsage(accountID, outputs)
+	estimate, err := r.estimateBTC(inputs, outputs)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, line := range estimate.Lines() {
+		fmt.Println("  " + line)
+	}
+	if err := r.checkBTCPolicy(outputs, anomalies, estimate.Warning()); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", "btc_signTransaction", "", err.Error())
+		return nil, "", err
+	}
+	byAddress := make(map[string]*core.AddressKey, len(addresses))
+	for _, addr := range addresses {
+		byAddress[addr.Address] = addr
+	}
+	appConfig := config.GetAppConfig()
+	rbf := appConfig.GetBitcoinConfig().RBF
+	raw, txid, err := btc.SignTransaction(inputs, outputs, func(u btc.UTXO) ([]byte, error) {
+		addr, ok := byAddress[u.Address]
+		if !ok {
+			return nil, fmt.Errorf("address %s does not belong to this account", u.Address)
+		}
+		key, err := r.accountMgr.AddressPrivateKey(addr)
+		if err != nil {
+			return nil, err
+		}
+		defer key.Destroy()
+		// SignTransaction 签名后会清除这份副本
+		return append([]byte(nil), key.Bytes()...), nil
+	}, rbf)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", "btc_signTransaction")
+	if stats != nil {
+		if err := stats.Record(usage); err != nil {
+			logging.Warnf("Failed to record signing statistics: %v", err)
+		}
+	}
+	return raw, txid, nil
+}
+
+// estimateBTC 签名前估算交易大小和手续费；转出金额不含转回本钱包的输出，全部转给自己时为所有输出之和
+func (r *REPL) estimateBTC(inputs []btc.UTXO, outputs []btc.TxOut) (*signer.FeeEstimate, error) {
+	var external, total int64
+	for _, output := range outputs {
+		total += output.Value
+		if _, own := r.accountMgr.IsMine(decoder.ScriptAddress(output.Script)); !own {
+			external += output.Value
+		}
+	}
+	if external == 0 {
+		external = total
+	}
+	return signer.EstimateBTC(inputs, outputs, external)
+}
+
+// broadcastBTC 确认后广播已签名的交易，记录审计日志，把输入标记为已花费，并把支出写入交易记录供 report spending 使用；
+// pending 保存到已广播交易记录，供 tx.bump、tx.cancel 构造替换交易
+func (r *REPL) broadcastBTC(ctx context.Context, command string, backend btc.Backend, store *btc.UTXOStore, pending *btc.PendingTx, raw []byte, txid string, spend watch.Spend) error {
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+
+	logger := audit.ForDir(r.baseDir())
+	sent, err := backend.Broadcast(ctx, raw)
+	if err != nil {
+		logger.Record("repl", command, txid, err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", command, sent, "ok")
+	if err := store.MarkSpent(pending.Inputs); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, command)
+	spend.Coin, spend.TxID, spend.SentAt, spend.Command = "BTC", sent, time.Now().UTC(), command
+	pending.TxID, pending.AccountID, pending.Command, pending.SentAt = sent, spend.AccountID, command, spend.SentAt
+	appConfig := config.GetAppConfig()
+	pending.RBF = appConfig.GetBitcoinConfig().RBF
+	if err := r.recordPending(pending, spend.Replaces); err != nil {
+		logging.Warnf("Failed to record pending transaction %s: %v", sent, err)
+	}
+	if err := r.recordSpend(spend); err != nil {
+		// 交易已经发出，记录失败只影响报表
+		logging.Warnf("Failed to record spend %s: %v", sent, err)
+	}
+	return nil
+}
+
+// recordPending 把已广播的交易写入已广播交易记录，replaces 为被替换的交易
+func (r *REPL) recordPending(pending *btc.PendingTx, replaces string) error {
+	pendingStore, err := btc.LoadPendingStore(filepath.Join(r.baseDir(), btc.PendingFileName))
+	if err != nil {
+		return err
+	}
+	return pendingStore.Add(pending, replaces)
+}
+
+// recordSpend 把本钱包广播的支出写入交易记录，配置了 tax.currency 时同时记录当前汇率
+func (r *REPL) recordSpend(spend watch.Spend) error {
+	appConfig := config.GetAppConfig()
+	if currency := appConfig.GetTaxConfig().Currency; currency != "" {
+		if prices, err := r.priceService(); err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), priceTimeout)
+			if quote, err := prices.Quote(ctx, spend.Coin, currency); err == nil {
+				spend.Fiat = watch.FiatFromQuote(quote)
+			} else {
+				logging.Warnf("Failed to record the %s/%s rate of %s, run tax.lots --backfill: %v", spend.Coin, currency, spend.TxID, err)
+			}
+			cancel()
+		}
+	}
+	txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	if err := txStore.RecordSpend(spend); err != nil {
+		return err
+	}
+	r.bus.Publish(events.Event{Type: mirror.TxRecorded, Data: spend.Mirror()})
+	return nil
+}
+
+// pickUTXOs 按 txid:vout 查找手动指定的 UTXO
+func pickUTXOs(utxos []btc.UTXO, outpoints []string) ([]btc.UTXO, error) {
+	byOutpoint := make(map[string]btc.UTXO, len(utxos))
+	for _, u := range utxos {
+		byOutpoint[u.Outpoint()] = u
+	}
+	var picked []btc.UTXO
+	seen := make(map[string]bool)
+	for _, outpoint := range outpoints {
+		u, ok := byOutpoint[outpoint]
+		if !ok {
+			return nil, fmt.Errorf("UTXO %s not found in this account, run btc.utxos <accountID> --refresh", outpoint)
+		}
+		if !seen[outpoint] {
+			seen[outpoint] = true
+			picked = append(picked, u)
+		}
+	}
+	return picked, nil
+}
+
+// changePolicy 账户的找零策略，未设置时为 fresh
+func (r *REPL) changePolicy(accountID string) (btc.ChangePolicy, error) {
+	store, err := r.metadataStore()
+	if err != nil {
+		return btc.ChangePolicy{}, err
+	}
+	value, _ := store.Get(metadata.ChangePolicies, accountID)
+	return btc.ParseChangePolicy(value)
+}
+
+// changeTemplate 用于估算找零成本的脚本：fixed 策略用固定地址，否则用账户已有地址（账户内地址类型一致）
+func changeTemplate(policy btc.ChangePolicy, addresses []*core.AddressKey) ([]byte, error) {
+	address := addresses[0].Address
+	if policy.Mode == btc.ChangeFixed {
+		address = policy.Address
+	}
+	script, err := btc.AddressScript(address)
+	if err != nil {
+		return nil, fmt.Errorf("change address %s: %v", address, err)
+	}
+	return script, nil
+}
+
+// changeAddress 按找零策略选择找零地址：sender 用金额最大的输入的地址，fixed 用固定地址，fresh 派生新的找零地址
+func (r *REPL) changeAddress(accountID string, policy btc.ChangePolicy, addresses []*core.AddressKey, inputs []btc.UTXO) (*core.AddressKey, error) {
+	switch policy.Mode {
+	case btc.ChangeSender:
+		if address, ok := btc.SenderInput(inputs); ok {
+			for _, addr := range addresses {
+				if addr.Address == address {
+					return addr, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("no input address of account %s for sender change", accountID)
+	case btc.ChangeFixed:
+		addr, ok := r.accountMgr.IsMine(policy.Address)
+		if !ok {
+			return nil, fmt.Errorf("fixed change address %s no longer belongs to this wallet, run account.set %s change-policy fresh", policy.Address, accountID)
+		}
+		return addr, nil
+	}
+	return r.nextChangeAddress(accountID, addresses)
+}
+
+// nextChangeAddress 在内部链上派生下一个找零地址
+func (r *REPL) nextChangeAddress(accountID string, addresses []*core.AddressKey) (*core.AddressKey, error) {
+	next := uint32(0)
+	for _, addr := range addresses {
+		if addr.ChangeType == changeBranch && addr.AddressIndex >= next {
+			next = addr.AddressIndex + 1
+		}
+	}
+	addr, err := r.accountMgr.DeriveAddress(accountID, changeBranch, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive change address: %v", err)
+	}
+	return addr, nil
+}
+
+// 小额 UTXO 合并命令处理函数：在费率较低时把多个小额 UTXO 合并到一个新的找零地址，
+// 显示现在的手续费和日后节省的手续费，确认后签名并广播
+func (r *REPL) handleBTCConsolidate(args []string) error {
+	usage := r.usageError("btc.consolidate")
+	var (
+		accountID              string
+		feeRate, futureFeeRate int64
+		below                  = int64(100000)
+		err                    error
+	)
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--account":
+			if accountID, err = r.resolveAccountID(value); err != nil {
+				return err
+			}
+		case "--fee-rate":
+			if feeRate, err = strconv.ParseInt(value, 10, 64); err != nil || feeRate <= 0 {
+				return fmt.Errorf("invalid fee rate %q", value)
+			}
+		case "--future-fee-rate":
+			if futureFeeRate, err = strconv.ParseInt(value, 10, 64); err != nil || futureFeeRate <= 0 {
+				return fmt.Errorf("invalid fee rate %q", value)
+			}
+		case "--below":
+			if below, err = btc.ParseBTC(value); err != nil {
+				return err
+			}
+		default:
+			return usage
+		}
+		i++
+	}
+	if accountID == "" {
+		return usage
+	}
+
+	addresses, err := r.btcAccountAddresses(accountID)
+	if err != nil {
+		return err
+	}
+	store, backend, err := r.loadUTXOs(addresses, true)
+	if errors.Is(err, btc.ErrNotConfigured) {
+		store, backend, err = r.loadUTXOs(addresses, false)
+		fmt.Println(r.template.Warning("Bitcoin backend not configured, using cached UTXOs"))
+	}
+	if err != nil {
+		return err
+	}
+
+	// 默认按低优先级（约一天内确认）合并，按正常优先级估算日后花费的费率
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if feeRate == 0 || futureFeeRate == 0 {
+		if backend == nil {
+			return fmt.Errorf("--fee-rate and --future-fee-rate are required without a bitcoin backend")
+		}
+		if feeRate == 0 {
+			if feeRate, err = backend.EstimateFeeRate(ctx, 144); err != nil {
+				return fmt.Errorf("failed to estimate fee rate: %v, pass --fee-rate", err)
+			}
+		}
+		if futureFeeRate == 0 {
+			if futureFeeRate, err = backend.EstimateFeeRate(ctx, 6); err != nil {
+				return fmt.Errorf("failed to estimate fee rate: %v, pass --future-fee-rate", err)
+			}
+		}
+	}
+
+	// 先按找零策略的地址类型估算，确认合并后再选择或派生合并目标地址；sender 策略合并到新地址
+	policy, err := r.changePolicy(accountID)
+	if err != nil {
+		return err
+	}
+	if policy.Mode == btc.ChangeSender {
+		policy = btc.ChangePolicy{Mode: btc.ChangeFresh}
+	}
+	estimateScript, err := changeTemplate(policy, addresses)
+	if err != nil {
+		return err
+	}
+	plan, err := btc.PlanConsolidation(store.Unspent(addressStrings(addresses)), estimateScript, feeRate, futureFeeRate, below)
+	if err != nil {
+		return err
+	}
+
+	total := plan.Amount + plan.Fee
+	fmt.Println(r.template.Info(fmt.Sprintf("Consolidate %d UTXOs below %s BTC (%s BTC)",
+		len(plan.Inputs), r.format().Decimal(btc.FormatBTC(below)), r.format().Decimal(btc.FormatBTC(total)))))
+	for _, u := range plan.Inputs {
+		fmt.Printf("  Input:     %s (%s BTC) %s\n", u.Outpoint(), r.format().Decimal(btc.FormatBTC(u.Value)), u.Address)
+	}
+	if plan.Skipped > 0 {
+		fmt.Printf("  Skipped:   %d UTXOs worth less than their spending cost at %d sat/vB\n", plan.Skipped, feeRate)
+	}
+	fmt.Printf("  Fee now:   %s BTC (%d sat/vB, %d vB)\n", r.format().Decimal(btc.FormatBTC(plan.Fee)), feeRate, plan.VSize)
+	fmt.Printf("  Saving:    %s BTC when spending later at %d sat/vB\n", r.format().Decimal(btc.FormatBTC(plan.Saving)), futureFeeRate)
+	fmt.Printf("  Benefit:   %s BTC\n", r.format().Decimal(btc.FormatBTC(plan.Benefit())))
+	if feeRate >= futureFeeRate {
+		fmt.Println(r.template.Warning("Fees are not lower than usual right now, consider waiting"))
+	}
+	if plan.Benefit() <= 0 {
+		fmt.Println(r.template.Warning("Consolidating now costs more than it saves later"))
+	}
+	fmt.Println(r.template.Warning("Consolidation links these addresses together on chain"))
+
+	answer, err := r.line.Prompt("Sign consolidation transaction? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("consolidation cancelled")
+	}
+	target, err := r.changeAddress(accountID, policy, addresses, plan.Inputs)
+	if err != nil {
+		return err
+	}
+	script, err := btc.AddressScript(target.Address)
+	if err != nil {
+		return err
+	}
+	selection, amount, err := btc.SweepAll(plan.Inputs, script, feeRate)
+	if err != nil {
+		return err
+	}
+	outputs := []btc.TxOut{{Value: amount, Script: script}}
+	raw, txid, err := r.signBTC(selection.Inputs, outputs, addresses)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Output:    %s BTC -> %s (index %d)\n", r.format().Decimal(btc.FormatBTC(amount)), target.Address, target.AddressIndex)
+	fmt.Printf("  Txid:      %s\n", txid)
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(raw))
+	if backend == nil {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, no bitcoin backend configured"))
+		return nil
+	}
+	if err := r.broadcastBTC(ctx, "btc.consolidate", backend, store, btc.NewPendingTx(selection, outputs, script), raw, txid, watch.Spend{
+		AccountID: accountID, To: target.Address, Amount: strconv.FormatInt(amount, 10), Fee: strconv.FormatInt(selection.Fee, 10)}); err != nil {
+		return err
+	}
+	r.recordUsage("btc.consolidate", []string{target.Address})
+	return nil
+}
@@ -0,0 +1,443 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/ceremony"
+	"github.com/palagend/slowmade/internal/inherit"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// shareLineWidth 显示分享时十六进制部分每行的字符数，输入时换行会被忽略
+const shareLineWidth = 32
+
+// 密钥仪式命令处理函数：在离线机器上由两名以上操作员逐步确认生成新钱包，可选把助记词分成 Shamir 分享
+// 交给各保管人，最后写出证明文件和 JSON 记录，并把记录追加到审计日志
+func (r *REPL) handleCeremonyStart(args []string) (err error) {
+	usage := r.usageError("ceremony.start")
+	var (
+		threshold, total              int
+		org, purpose, outDir, tmplArg string
+		allowNetwork                  bool
+		entropyArgs                   []string
+	)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--allow-network":
+			allowNetwork = true
+		case args[i] == "--dice":
+			entropyArgs = append(entropyArgs, args[i])
+		case (args[i] == "--entropy-file" || args[i] == "--device") && i+1 < len(args):
+			entropyArgs = append(entropyArgs, args[i], args[i+1])
+			i++
+		case args[i] == "--shares" && i+1 < len(args):
+			i++
+			if threshold, total, err = parseShareScheme(args[i]); err != nil {
+				return err
+			}
+		case args[i] == "--org" && i+1 < len(args):
+			i++
+			org = args[i]
+		case args[i] == "--purpose" && i+1 < len(args):
+			i++
+			purpose = args[i]
+		case args[i] == "--out" && i+1 < len(args):
+			i++
+			outDir = args[i]
+		case args[i] == "--template" && i+1 < len(args):
+			i++
+			tmplArg = args[i]
+		default:
+			return usage
+		}
+	}
+	if outDir == "" {
+		outDir = "."
+	}
+
+	// 开始前检查所有前提，避免操作员确认到一半才失败
+	if err := view.CheckSecretTerminal(); err != nil {
+		return err
+	}
+	if _, _, err := r.walletMgr.Timestamps(); err == nil {
+		return fmt.Errorf("a wallet already exists in %s, a key ceremony creates a new one: start slowmade with an empty --data-dir", r.baseDir())
+	}
+	tmpl, err := r.attestationTemplate(tmplArg)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(outDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("output directory %s does not exist", outDir)
+	}
+	airGap, err := ceremony.CheckAirGap()
+	if err != nil {
+		return err
+	}
+	if !airGap.Verified && !allowNetwork {
+		return fmt.Errorf("%w (%s)", ceremony.ErrNotAirGapped, strings.Join(airGap.Interfaces, ", "))
+	}
+	sources, err := r.entropySources(entropyArgs)
+	if err != nil {
+		return err
+	}
+
+	record, err := ceremony.New(org, purpose, version.Get().GitVersion)
+	if err != nil {
+		return err
+	}
+	record.AirGap = airGap
+	record.Cloaked = r.cloaked
+	for _, source := range sources {
+		record.EntropySources = append(record.EntropySources, source.Name())
+	}
+	if airGap.Verified {
+		record.Step("air gap checked, no active network interfaces")
+	} else {
+		record.Step("network interfaces up, continued with --allow-network: %s", strings.Join(airGap.Interfaces, ", "))
+		fmt.Println(r.template.Warning("Network interfaces are up: " + strings.Join(airGap.Interfaces, ", ") + ". The attestation records this."))
+	}
+
+	fmt.Println(r.template.Info(fmt.Sprintf("Key ceremony %s. Every operator confirms each step by typing their own name.", record.ID)))
+	if err := record.AddOperators(r.readCeremonyOperators()); err != nil {
+		return err
+	}
+	record.Step("operators registered: %s", strings.Join(record.Operators, ", "))
+
+	// 登记操作员之后、记录写入审计日志之前的任何失败也写入审计日志，仪式中断同样留下记录
+	var created, recorded bool
+	defer func() {
+		if err != nil && created && !recorded {
+			fmt.Println(r.template.Warning("The wallet was created but the ceremony did not complete. Delete the data directory and hold a new ceremony."))
+		}
+		if err != nil && !recorded {
+			record.Step("aborted: %v", err)
+			record.CompletedAt = time.Now().UTC()
+			if data, marshalErr := canonjson.Marshal(record); marshalErr == nil {
+				audit.ForDir(r.baseDir()).RecordData("repl", ceremony.AuditAction, record.ID, "aborted", data)
+			}
+		}
+	}()
+
+	fmt.Println(r.template.Separator())
+	fmt.Printf("Host:           %s\n", record.Host)
+	fmt.Printf("Software:       slowmade %s\n", record.Version)
+	if record.BinarySHA256 != "" {
+		fmt.Printf("Binary SHA-256: %s\n", record.BinarySHA256)
+	}
+	fmt.Printf("Entropy:        system RNG%s\n", joinPrefixed(record.EntropySources, " + "))
+	fmt.Printf("Operators:      %s\n", strings.Join(record.Operators, ", "))
+	if threshold > 0 {
+		fmt.Printf("Shares:         any %d of %d recover the wallet\n", threshold, total)
+	}
+	fmt.Println(r.template.Separator())
+	if err := record.Confirm("environment checked", r.ceremonyConfirm); err != nil {
+		return err
+	}
+
+	password, err := r.passwordPrompt().ReadNew("Wallet password: ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Info("Generating the seed..."))
+	if _, err := r.walletMgr.CreateNewWallet(password, sources...); err != nil {
+		return fmt.Errorf("failed to create wallet: %v", err)
+	}
+	created = true
+	r.resetIntegrity(password)
+	if err := r.walletMgr.UnlockWallet(password); err != nil {
+		return err
+	}
+	r.passwordMgr.SetPassword(password)
+	defer r.lockWallet()
+	if record.MasterFingerprint, err = r.accountMgr.MasterFingerprint(); err != nil {
+		return err
+	}
+	record.Step("seed generated, master fingerprint %s", record.MasterFingerprint)
+	fmt.Printf("Master fingerprint: %s\n", view.Green(record.MasterFingerprint))
+	fmt.Println(r.template.Info("Write the fingerprint down; it identifies the wallet without revealing it."))
+	if err := record.Confirm("seed generated", r.ceremonyConfirm); err != nil {
+		return err
+	}
+
+	secret, err := r.walletMgr.ExportMnemonic(password)
+	if err != nil {
+		return err
+	}
+	defer secret.Destroy()
+	mnemonic := string(secret.Bytes())
+	if threshold > 0 {
+		if err := r.distributeCeremonyShares(record, mnemonic, threshold, total); err != nil {
+			return err
+		}
+		if err := record.Confirm("shares distributed", r.ceremonyConfirm); err != nil {
+			return err
+		}
+	} else {
+		if err := r.showMnemonic(mnemonic); err != nil {
+			return err
+		}
+		record.Step("recovery words shown")
+		if err := record.Confirm("recovery words written down", r.ceremonyConfirm); err != nil {
+			return err
+		}
+	}
+
+	record.CompletedAt = time.Now().UTC()
+	record.Step("ceremony completed")
+	data, err := canonjson.Marshal(record)
+	if err != nil {
+		return err
+	}
+	auditHash, err := audit.ForDir(r.baseDir()).RecordData("repl", ceremony.AuditAction, record.ID, "ok", data)
+	if err != nil {
+		return err
+	}
+	recorded = true
+
+	// JSON 记录按规范格式原样写出，文件的 SHA-256 就是证明文件上的记录摘要
+	base := filepath.Join(outDir, "ceremony-"+record.ID)
+	if err := os.WriteFile(base+".json", data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	file, err := os.OpenFile(base+".txt", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	defer file.Close()
+	if err := ceremony.Render(file, tmpl, record, auditHash); err != nil {
+		return fmt.Errorf("template failed: %v", err)
+	}
+
+	fmt.Println(r.template.Success(fmt.Sprintf("Key ceremony %s completed for wallet %s", record.ID, record.MasterFingerprint)))
+	fmt.Printf("  %s.txt\n  %s.json\n", base, base)
+	fmt.Printf("Record SHA-256: %s\n", record.Digest())
+	fmt.Println(r.template.Info("Print the attestation and have every operator and custodian sign it. The wallet is locked."))
+	return nil
+}
+
+// 密钥仪式列表命令处理函数，从审计日志读取
+func (r *REPL) handleCeremonyList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("ceremony.list")
+	}
+	entries, err := audit.ReadAll(filepath.Join(r.baseDir(), audit.FileName))
+	if err != nil {
+		return err
+	}
+	format := r.format()
+	found := false
+	for _, entry := range entries {
+		record, ok := ceremonyRecord(entry)
+		if !ok {
+			continue
+		}
+		if !found {
+			fmt.Printf("%-12s  %-20s  %-8s  %-8s  %s\n", "ID", "COMPLETED", "RESULT", "WALLET", "OPERATORS")
+			found = true
+		}
+		fmt.Printf("%-12s  %-20s  %-8s  %-8s  %s\n", record.ID, format.Date(record.CompletedAt), entry.Result,
+			record.MasterFingerprint, strings.Join(record.Operators, ", "))
+	}
+	if !found {
+		fmt.Println("No key ceremonies in the audit log")
+	}
+	return nil
+}
+
+// 密钥仪式详情命令处理函数：校验审计日志哈希链，按证明文件模板重新输出记录
+func (r *REPL) handleCeremonyShow(args []string) error {
+	var id, tmplArg string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--template" && i+1 < len(args):
+			i++
+			tmplArg = args[i]
+		case id == "":
+			id = args[i]
+		default:
+			return r.usageError("ceremony.show")
+		}
+	}
+	if id == "" {
+		return r.usageError("ceremony.show")
+	}
+	tmpl, err := r.attestationTemplate(tmplArg)
+	if err != nil {
+		return err
+	}
+	entries, err := audit.ReadAll(filepath.Join(r.baseDir(), audit.FileName))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record, ok := ceremonyRecord(entry)
+		if !ok || record.ID != id {
+			continue
+		}
+		if broken := audit.Verify(entries); broken != 0 {
+			fmt.Println(r.template.Warning(fmt.Sprintf("The audit log hash chain is broken at entry %d, the record may have been altered", broken)))
+		} else {
+			fmt.Println(r.template.Success("Audit log hash chain intact"))
+		}
+		if entry.Result != "ok" {
+			fmt.Println(r.template.Warning("This ceremony did not complete: " + entry.Result))
+		}
+		fmt.Println(r.template.Separator())
+		return ceremony.Render(os.Stdout, tmpl, record, entry.Hash)
+	}
+	return fmt.Errorf("key ceremony %s not found in the audit log", id)
+}
+
+// attestationTemplate 读取 --template 指定的证明文件模板，为空时使用默认模板
+func (r *REPL) attestationTemplate(path string) (*template.Template, error) {
+	text := ""
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := ceremony.Template(text, r.format().FuncMap())
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// readCeremonyOperators 逐个输入操作员名字，空行结束
+func (r *REPL) readCeremonyOperators() []string {
+	var names []string
+	for {
+		prompt := fmt.Sprintf("Operator %d name: ", len(names)+1)
+		if len(names) >= 2 {
+			prompt = fmt.Sprintf("Operator %d name (empty to finish): ", len(names)+1)
+		}
+		name, err := r.line.Prompt(prompt)
+		if err != nil {
+			return names
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			if len(names) >= 2 {
+				return names
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+}
+
+// ceremonyConfirm 操作员输入自己的名字确认检查点，code 是记录摘要的前 8 位，抄在纸上以便事后对照
+func (r *REPL) ceremonyConfirm(checkpoint, code, operator string) (bool, error) {
+	answer, err := r.line.Prompt(fmt.Sprintf("%s, type your name to confirm %q [%s]: ", operator, checkpoint, code))
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(answer), operator), nil
+}
+
+// distributeCeremonyShares 把助记词分成分享，逐个只向对应保管人显示；保管人输入分享末尾的校验码确认已抄写
+func (r *REPL) distributeCeremonyShares(record *ceremony.Record, mnemonic string, threshold, total int) error {
+	shares, err := inherit.SplitMnemonic(mnemonic, record.MasterFingerprint, total, threshold)
+	if err != nil {
+		return err
+	}
+	record.Shares = &ceremony.ShareScheme{Set: shares[0].Set, Threshold: threshold, Total: total}
+	fmt.Println(r.template.Info(fmt.Sprintf("Each share is shown only to its custodian; any %d together recover the wallet. Everyone else looks away.", threshold)))
+	for _, share := range shares {
+		var custodian string
+		for custodian == "" {
+			name, err := r.line.Prompt(fmt.Sprintf("Custodian of share %d of %d: ", share.X, total))
+			if err != nil {
+				return err
+			}
+			custodian = strings.TrimSpace(name)
+		}
+		text := share.String()
+		checksum := text[strings.LastIndex(text, "-")+1:]
+		for confirmed := false; !confirmed; {
+			err := view.ShowSecret(fmt.Sprintf("Recovery share %d of %d for %s", share.X, total, custodian), shareLines(text), []string{
+				"Write the share on paper exactly as shown; line breaks do not matter.",
+				fmt.Sprintf("Any %d shares recover the wallet with inherit.combine. Never photograph or copy it.", threshold),
+			}, mnemonicClearAfter())
+			if errors.Is(err, view.ErrRevealCancelled) {
+				return fmt.Errorf("key ceremony cancelled while showing share %d", share.X)
+			}
+			if err != nil {
+				return err
+			}
+			answer, err := r.line.Prompt(fmt.Sprintf("%s, type the last %d characters of your share: ", custodian, len(checksum)))
+			if err != nil {
+				return err
+			}
+			if confirmed = strings.EqualFold(strings.TrimSpace(answer), checksum); !confirmed {
+				fmt.Println(r.template.Error("That does not match, the share is shown again"))
+			}
+		}
+		record.Shares.Custody = append(record.Shares.Custody, ceremony.ShareCustody{
+			Index:       int(share.X),
+			Custodian:   custodian,
+			Fingerprint: ceremony.ShareFingerprint(text),
+			ConfirmedAt: time.Now().UTC(),
+		})
+		record.Step("share %d handed to %s", share.X, custodian)
+	}
+	return nil
+}
+
+// ceremonyRecord 解码审计条目中的仪式记录
+func ceremonyRecord(entry audit.Entry) (*ceremony.Record, bool) {
+	if entry.Action != ceremony.AuditAction || len(entry.Data) == 0 {
+		return nil, false
+	}
+	var record ceremony.Record
+	if err := json.Unmarshal(entry.Data, &record); err != nil {
+		return nil, false
+	}
+	return &record, true
+}
+
+// parseShareScheme 解析 k-of-n 形式的分享方案，如 2-of-3
+func parseShareScheme(s string) (int, int, error) {
+	k, n, ok := strings.Cut(strings.ReplaceAll(strings.ToLower(s), "-", ""), "of")
+	threshold, err1 := strconv.Atoi(k)
+	total, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || threshold < 2 || threshold > total || total > 16 {
+		return 0, 0, fmt.Errorf("invalid share scheme %q, expected k-of-n such as 2-of-3 (2 <= k <= n <= 16)", s)
+	}
+	return threshold, total, nil
+}
+
+// shareLines 分享按抄写习惯分行：前缀和编号一行，十六进制每行 shareLineWidth 个字符，校验码单独一行
+func shareLines(text string) []string {
+	end := strings.LastIndex(text, "-")
+	start := strings.LastIndex(text[:end], "-") + 1
+	lines := []string{text[:start]}
+	hex := text[start:end]
+	for len(hex) > shareLineWidth {
+		lines = append(lines, hex[:shareLineWidth])
+		hex = hex[shareLineWidth:]
+	}
+	return append(lines, hex, text[end:])
+}
+
+// joinPrefixed 每项前加 sep 后拼接
+func joinPrefixed(items []string, sep string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(sep + item)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 币种注册命令处理函数
+func (r *REPL) handleCoinRegister(args []string) error {
+	usage := r.usageError("coin.register")
+	if len(args) != 3 && len(args) != 5 {
+		return usage
+	}
+
+	coinType, err := strconv.ParseUint(args[1], 10, 31)
+	if err != nil {
+		return fmt.Errorf("invalid coin type %q: %v", args[1], err)
+	}
+	decimals, err := strconv.Atoi(args[2])
+	if err != nil {
+		return fmt.Errorf("invalid decimals %q: %v", args[2], err)
+	}
+
+	curve := coin.CurveSecp256k1
+	if len(args) == 5 {
+		if args[3] != "--curve" {
+			return usage
+		}
+		curve = args[4]
+	}
+
+	info := coin.CoinInfo{
+		Symbol:  args[0],
+		Type:    uint32(coinType),
+		Decimal: decimals,
+		Curve:   curve,
+	}
+	if err := coin.AddCustomCoin(filepath.Join(r.baseDir(), coin.CustomCoinsFileName), info); err != nil {
+		return fmt.Errorf("failed to register coin: %v", err)
+	}
+
+	fmt.Println(r.template.Success(fmt.Sprintf("Registered %s (coin type %d, %d decimals, %s)",
+		args[0], coinType, decimals, curve)))
+	return nil
+}
+
+// 币种列表命令处理函数，插件币种同时显示默认手续费
+func (r *REPL) handleCoinList(args []string) error {
+	fmt.Printf("  %-8s %-10s %-9s %-10s %-9s %s\n", "SYMBOL", "COIN TYPE", "DECIMALS", "CURVE", "SOURCE", "DEFAULT FEE")
+	for _, info := range coin.GetAllCoins() {
+		source, fee := "built-in", "-"
+		if plugin, ok := core.CoinPluginFor(info.Type); ok {
+			fees := plugin.Fees()
+			source, fee = "plugin", fmt.Sprintf("%d %s", fees.Rate, fees.Unit)
+		}
+		if info.Custom {
+			source, fee = "custom", "-"
+		}
+		fmt.Printf("  %-8s %-10d %-9d %-10s %-9s %s\n", info.Symbol, info.Type, info.Decimal, info.Curve, source, fee)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/cosmos"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// cosmosChainFlag 取出 --chain 参数，返回其余参数和链配置
+func cosmosChainFlag(args []string) ([]string, string, config.CosmosChainConfig, error) {
+	name := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--chain" && i+1 < len(args) {
+			i++
+			name = args[i]
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	appConfig := config.GetAppConfig()
+	name, chain, err := appConfig.GetCosmosConfig().Chain(name)
+	return rest, name, chain, err
+}
+
+// Cosmos 链列表命令处理函数
+func (r *REPL) handleCosmosChains(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("cosmos.chains")
+	}
+	appConfig := config.GetAppConfig()
+	cosmosConfig := appConfig.GetCosmosConfig()
+	if len(cosmosConfig.Chains) == 0 {
+		fmt.Println("No Cosmos chains configured, add [cosmos.chains.<name>] sections to the config file")
+		return nil
+	}
+	fmt.Printf("  %-12s %-14s %-8s %-8s %-10s %s\n", "NAME", "CHAIN ID", "PREFIX", "DENOM", "GAS PRICE", "LCD")
+	for _, name := range cosmosConfig.Names() {
+		chain := cosmosConfig.Chains[name]
+		marker := " "
+		if name == strings.ToLower(cosmosConfig.DefaultChain) {
+			marker = "*"
+		}
+		fmt.Printf("%s %-12s %-14s %-8s %-8s %-10s %s\n", marker, name, chain.ChainID, chain.HRP, chain.Denom, chain.GasPrice, chain.LCDURL)
+	}
+	return nil
+}
+
+// Cosmos 地址命令处理函数：按链的前缀显示账户的收款地址
+func (r *REPL) handleCosmosAddress(args []string) error {
+	args, name, chain, err := cosmosChainFlag(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return r.usageError("cosmos.address")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	addresses, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	fmt.Printf("%s (%s)\n", name, chain.ChainID)
+	for _, addr := range addresses {
+		if addr.CoinSymbol != "ATOM" {
+			return fmt.Errorf("account %s is not a Cosmos account", accountID)
+		}
+		if addr.ChangeType != 0 {
+			continue
+		}
+		converted, err := cosmos.ConvertAddress(addr.Address, chain.HRP)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-4d %s\n", addr.AddressIndex, converted)
+	}
+	return nil
+}
+
+// Cosmos 余额命令处理函数
+func (r *REPL) handleCosmosBalance(args []string) error {
+	args, name, chain, err := cosmosChainFlag(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return r.usageError("cosmos.balance")
+	}
+	var address string
+	if _, _, err := cosmos.ParseAddress(args[0]); err == nil {
+		// 任意前缀的地址都可以查询，例如只读监控的地址
+		address = args[0]
+	} else {
+		addr, err := r.coinAddress(args[0], "ATOM")
+		if err != nil {
+			return err
+		}
+		address = addr.Address
+	}
+	if address, err = cosmos.ConvertAddress(address, chain.HRP); err != nil {
+		return err
+	}
+	lcd := cosmos.NewLCD(chain.LCDURL, chain.HRP, chain.Denom)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	balance, err := lcd.Balance(ctx, address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance from %s: %v", lcd.Name(), err)
+	}
+	fmt.Printf("%s (%s)\n", address, name)
+	fmt.Printf("  %s: %s\n", chain.Symbol, r.format().Decimal(coin.FormatUnits(balance, chain.Decimals)))
+	return nil
+}
+
+// Cosmos 发送命令处理函数：查询账户编号和序号，在本地构造 bank MsgSend 并签名，--broadcast 时经 LCD 广播
+func (r *REPL) handleCosmosSend(args []string) error {
+	usage := r.usageError("cosmos.send")
+	args, name, chain, err := cosmosChainFlag(args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 3 {
+		return usage
+	}
+	var (
+		memo      string
+		gasLimit  = chain.GasLimit
+		mode      = cosmos.SignModeDirect
+		broadcast bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == "--broadcast":
+			broadcast = true
+		case args[i] == "--amino":
+			mode = cosmos.SignModeAminoJSON
+		case args[i] == "--memo" && i+1 < len(args):
+			i++
+			memo = args[i]
+		case args[i] == "--gas" && i+1 < len(args):
+			i++
+			if gasLimit, err = strconv.ParseUint(args[i], 10, 64); err != nil || gasLimit == 0 {
+				return fmt.Errorf("invalid gas limit %q", args[i])
+			}
+		default:
+			return usage
+		}
+	}
+
+	from, err := r.coinAddress(args[0], "ATOM")
+	if err != nil {
+		return err
+	}
+	fromAddress, err := cosmos.ConvertAddress(from.Address, chain.HRP)
+	if err != nil {
+		return err
+	}
+	recipient, err := r.resolveRecipient(args[1], "ATOM")
+	if err != nil {
+		return err
+	}
+	hrp, _, err := cosmos.ParseAddress(recipient)
+	if err != nil {
+		return err
+	}
+	if hrp != chain.HRP {
+		return fmt.Errorf("%s is not a %s address (prefix %s), use --chain to pick another chain", recipient, name, chain.HRP)
+	}
+	to := strings.ToLower(recipient)
+	amount, err := coin.ParseUnits(args[2], chain.Decimals)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() <= 0 {
+		return cosmos.ErrInvalidAmount
+	}
+	fee := cosmos.Fee(chain.GasPriceRat(), gasLimit)
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(chain.Decimals)), nil)
+	if err := r.checkTransfer("cosmos_signTransaction", fromAddress, chain.Symbol, to, new(big.Rat).SetFrac(amount, scale)); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", "cosmos_signTransaction", "", err.Error())
+		return err
+	}
+	lcd := cosmos.NewLCD(chain.LCDURL, chain.HRP, chain.Denom)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	accountNumber, sequence, err := lcd.Account(ctx, fromAddress)
+	if err != nil {
+		return fmt.Errorf("failed to fetch account from %s: %v", lcd.Name(), err)
+	}
+	send := &cosmos.Send{
+		ChainID: chain.ChainID, AccountNumber: accountNumber, Sequence: sequence,
+		From: fromAddress, To: to, Amount: cosmos.Coin{Denom: chain.Denom, Amount: amount},
+		Fee: cosmos.Coin{Denom: chain.Denom, Amount: fee}, GasLimit: gasLimit, Memo: memo, Mode: mode,
+	}
+	signed, err := r.signCosmos(send, from)
+	if err != nil {
+		return err
+	}
+
+	target := to
+	if to != args[1] {
+		target = fmt.Sprintf("%s (%s)", to, args[1])
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s %s to %s", r.format().Decimal(coin.FormatUnits(amount, chain.Decimals)), chain.Symbol, target)))
+	fmt.Printf("  From:     %s\n", fromAddress)
+	fmt.Printf("  Chain:    %s (%s), account %d, sequence %d\n", name, chain.ChainID, accountNumber, sequence)
+	fmt.Printf("  Fee:      %s %s (gas limit %d)\n", r.format().Decimal(coin.FormatUnits(fee, chain.Decimals)), chain.Symbol, gasLimit)
+	if memo != "" {
+		fmt.Printf("  Memo:     %s\n", memo)
+	}
+	if mode == cosmos.SignModeAminoJSON {
+		fmt.Printf("  Signing:  amino JSON\n")
+	}
+	fmt.Printf("  Hash:     %s\n", signed.Hash)
+	fmt.Printf("  Raw:      %s\n", hex.EncodeToString(signed.Bytes))
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := lcd.Broadcast(ctx, signed)
+	if err != nil {
+		logger.Record("repl", "cosmos.send", signed.Hash, err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "cosmos.send", sent, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, "cosmos.send")
+	if err := r.recordSpend(watch.Spend{Coin: chain.Symbol, AccountID: from.AccountID, TxID: sent, To: to, Amount: amount.String(),
+		Fee: fee.String(), SentAt: time.Now().UTC(), Command: "cosmos.send"}); err != nil {
+		logging.Warnf("Failed to record spend %s: %v", sent, err)
+	}
+	return nil
+}
+
+// signCosmos 用地址私钥签名转账
+func (r *REPL) signCosmos(send *cosmos.Send, from *core.AddressKey) (*cosmos.Signed, error) {
+	key, err := r.accountMgr.AddressPrivateKey(from)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Destroy()
+	// Sign 签名后会清除这份副本
+	signed, err := send.Sign(append([]byte(nil), key.Bytes()...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", "cosmos_signTransaction")
+	return signed, nil
+}
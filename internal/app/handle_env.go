@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableName 会话变量名：字母或下划线开头，之后是字母、数字或下划线
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// 命令自动设置的会话变量
+const (
+	varLastAccount = "LAST_ACCOUNT" // 最近创建或派生地址的账户 ID
+	varLastAddress = "LAST_ADDRESS" // 最近派生的地址
+	varLastTxID    = "LAST_TXID"    // 最近广播的交易 ID
+)
+
+// sessionVariable 会话变量的值，source 为自动设置它的命令，set 设置的为空
+type sessionVariable struct {
+	value  string
+	source string
+}
+
+// setVariable 由命令自动设置会话变量
+func (r *REPL) setVariable(name, value, source string) {
+	if r.variables == nil {
+		r.variables = make(map[string]sessionVariable)
+	}
+	r.variables[name] = sessionVariable{value: value, source: source}
+}
+
+// expandVariables 展开词中的 $NAME 和 ${NAME}，未定义的变量返回错误；展开后的值不会再被当作操作符或变量
+func (r *REPL) expandVariables(tokens []token) ([]token, error) {
+	result := make([]token, len(tokens))
+	for i, t := range tokens {
+		result[i] = token{text: t.text, operator: t.operator}
+		if len(t.dollars) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, at := range t.dollars {
+			if at < last {
+				continue
+			}
+			name, end := t.text[at+1:], at+1
+			braced := strings.HasPrefix(name, "{")
+			if braced {
+				closing := strings.IndexByte(name, '}')
+				if closing < 0 {
+					return nil, fmt.Errorf("missing } in %q", t.text)
+				}
+				name, end = name[1:closing], at+closing+2
+				if name == "" || variableName.FindString(name) != name {
+					return nil, fmt.Errorf("invalid variable name ${%s}", name)
+				}
+			} else {
+				name = variableName.FindString(name)
+				end += len(name)
+			}
+			if name == "" {
+				// 单独的 $，如金额或正则中的 $，原样保留
+				continue
+			}
+			variable, ok := r.variables[name]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable $%s, see env", name)
+			}
+			b.WriteString(t.text[last:at])
+			b.WriteString(variable.value)
+			last = end
+		}
+		b.WriteString(t.text[last:])
+		result[i].text = b.String()
+	}
+	return result, nil
+}
+
+// 设置会话变量命令处理函数，值可以是多个词，以空格连接
+func (r *REPL) handleSet(args []string) error {
+	if len(args) < 2 {
+		return r.usageError("set")
+	}
+	name := args[0]
+	if variableName.FindString(name) != name {
+		return fmt.Errorf("invalid variable name %q: use letters, digits and _, not starting with a digit", name)
+	}
+	r.setVariable(name, strings.Join(args[1:], " "), "")
+	return nil
+}
+
+// 删除会话变量命令处理函数
+func (r *REPL) handleUnset(args []string) error {
+	if len(args) == 0 {
+		return r.usageError("unset")
+	}
+	for _, name := range args {
+		if _, ok := r.variables[name]; !ok {
+			return fmt.Errorf("undefined variable %s", name)
+		}
+		delete(r.variables, name)
+	}
+	return nil
+}
+
+// 列出会话变量命令处理函数
+func (r *REPL) handleEnv(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("env")
+	}
+	if len(r.variables) == 0 {
+		fmt.Println(r.template.Info("No variables, define one with set <name> <value>"))
+		return nil
+	}
+	names := make([]string, 0, len(r.variables))
+	for name := range r.variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		variable := r.variables[name]
+		if variable.source != "" {
+			fmt.Printf("%s=%s  (set by %s)\n", name, variable.value, variable.source)
+		} else {
+			fmt.Printf("%s=%s\n", name, variable.value)
+		}
+	}
+	return nil
+}
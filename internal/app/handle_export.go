@@ -0,0 +1,117 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/passprompt"
+	"github.com/palagend/slowmade/internal/pubexport"
+	"github.com/palagend/slowmade/internal/watch"
+)
+
+// 单账户导出命令处理函数，导出文件用单独的导出密码加密，不包含根种子
+func (r *REPL) handleAccountExport(args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[1] != "--encrypt-to-password" {
+		return r.usageError("account.export")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	file := fmt.Sprintf("account-%s.json", accountID[:min(len(accountID), 13)])
+	if len(args) == 3 {
+		file = args[2]
+	}
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("%s already exists", file)
+	}
+	// 冻结的账户由 ExportAccount 拒绝，这里提前检查，免得先输入导出密码
+	if account, err := r.findAccount(accountID); err == nil && account.Frozen {
+		return fmt.Errorf("导出账户失败: %w: %s", core.ErrAccountFrozen, accountID)
+	}
+
+	exportPassword, err := r.passwordPrompt().ReadNew("Export password: ")
+	if err != nil {
+		return err
+	}
+
+	data, err := r.accountMgr.ExportAccount(accountID, exportPassword)
+	if err != nil {
+		return fmt.Errorf("导出账户失败: %v", err)
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", file, err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Exported account %s to %s", accountID, file)))
+	fmt.Println(r.template.Warning("Anyone with this file and its password controls every address of the account"))
+	return nil
+}
+
+// 单账户导入命令处理函数，导入的账户以当前钱包密码重新加密
+func (r *REPL) handleAccountImport(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.import")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	exportPassword, err := r.passwordPrompt().Read("Export password: ")
+	if err != nil {
+		return err
+	}
+
+	account, err := r.accountMgr.ImportAccount(data, exportPassword)
+	if err != nil {
+		return fmt.Errorf("导入账户失败: %v", err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Imported standalone %s account %s (%s)",
+		account.CoinSymbol, account.ID, account.DerivationPath)))
+	return nil
+}
+
+// passwordPrompt 密码输入组件，输入不是终端时通过 liner 读取，和命令共用同一个缓冲；
+// 输出被捕获时不从标准输入读取
+func (r *REPL) passwordPrompt() *passprompt.Prompt {
+	p := passprompt.New()
+	p.Lines = r.line.Prompt
+	if r.line.capturing {
+		p.Refuse = errPromptCaptured
+	}
+	p.Waited = func(waited time.Duration) { r.line.waited += waited }
+	return p
+}
+
+// 公开数据导出命令处理函数，只导出扩展公钥、描述符、地址和交易记录
+func (r *REPL) handleExportPublic(args []string) error {
+	if len(args) != 2 || args[0] != "--out" {
+		return r.usageError("export.public")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	summary, err := pubexport.Export(r.accountMgr, txStore, args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Exported %d accounts, %d addresses and %d transactions to %s",
+		summary.Accounts, summary.Addresses, summary.Transactions, args[1])))
+	for _, file := range summary.Files {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Println(r.template.Warning("xpubs reveal every past and future address of an account, share them only with people you trust"))
+	return nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/search"
+)
+
+// searchEvents 会改变索引内容的存储变更：无论哪条命令派生了地址（收款、找零）或增删了账户，
+// 存储都会发布这些事件，查找前据此重建索引
+var searchEvents = []string{mirror.AccountUpserted, mirror.AccountRemoved, mirror.AddressAdded, mirror.AddressRemoved}
+
+// buildSearchIndex 从存储和元数据构建查找索引，解锁钱包时调用
+func (r *REPL) buildSearchIndex() error {
+	modified := r.metadataModified()
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	index, err := search.Build(r.accountMgr, store)
+	if err != nil {
+		return err
+	}
+	r.searchIndex, r.searchMetaTime = index, modified
+	r.searchStale.Store(false)
+	return nil
+}
+
+// metadataModified 元数据文件的修改时间，标签、标记、联系人和别名的修改都会写入该文件
+func (r *REPL) metadataModified() time.Time {
+	info, err := os.Stat(filepath.Join(r.baseDir(), metadata.FileName))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// markSearchStale 事件总线订阅者，账户或地址变化后标记索引过期；可能在后台协程中调用
+func (r *REPL) markSearchStale(event events.Event) {
+	r.searchStale.Store(true)
+}
+
+// searchCurrent 索引存在，且构建之后存储和元数据都没有变化
+func (r *REPL) searchCurrent() bool {
+	return r.searchIndex != nil && !r.searchStale.Load() && r.metadataModified().Equal(r.searchMetaTime)
+}
+
+// externalWrites 绕过账户管理器直接写入存储目录的命令，不会发布存储变更，执行后重建地址归属索引和查找索引
+var externalWrites = map[string]bool{"wallet.restore": true, "backup.scan": true, "sync.pull": true}
+
+// reloadExternalWrites 在直接写入存储目录的命令之后重新加载依赖存储内容的索引
+func (r *REPL) reloadExternalWrites(command string) {
+	if externalWrites[command] {
+		r.searchStale.Store(true)
+		r.accountMgr.ReloadOwnership()
+	}
+}
+
+// 查找命令处理函数，按账户 ID、派生路径、币种、地址、标签、标记和联系人查找，--tag 只返回有该标记的结果
+func (r *REPL) handleFind(args []string) error {
+	tag, args, err := r.tagOption(args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return r.usageError("find")
+	}
+	limit := 50
+	if len(args) >= 3 && args[len(args)-2] == "--limit" {
+		n, err := strconv.Atoi(args[len(args)-1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid limit %q", args[len(args)-1])
+		}
+		limit, args = n, args[:len(args)-2]
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	if !r.searchCurrent() {
+		if err := r.buildSearchIndex(); err != nil {
+			return fmt.Errorf("构建索引失败: %v", err)
+		}
+	}
+
+	results, err := r.searchIndex.FindTagged(strings.Join(args, " "), tag, limit)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("No matches")
+		return nil
+	}
+	for _, result := range results {
+		line := fmt.Sprintf("  %-8s %s", result.Kind, result.Key)
+		if result.Value != result.Key {
+			line += fmt.Sprintf("  (%s: %s)", result.Field, result.Value)
+		}
+		if result.Kind == search.KindAddress {
+			line += fmt.Sprintf("  [%s %s]", result.Coin, result.AccountID)
+		} else if result.Coin != "" {
+			line += fmt.Sprintf("  [%s]", result.Coin)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// 冻结账户命令处理函数：怀疑设备被入侵、资金尚未迁移时使用，之后签名、派生新地址和导出私钥都会失败
+func (r *REPL) handleAccountFreeze(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.freeze")
+	}
+	account, err := r.freezeTarget(args[0])
+	if err != nil {
+		return err
+	}
+	if account.Frozen {
+		return fmt.Errorf("account %s is already frozen (since %s)", account.ID, r.format().Date(account.FrozenAt()))
+	}
+	if err := r.accountMgr.FreezeAccount(account.ID); err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "account.freeze", account.ID, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Froze %s account %s", account.CoinSymbol, account.ID)))
+	fmt.Println(r.template.Info("Signing, deriving new addresses and exporting its keys now fail; balances and existing addresses stay visible. Use account.unfreeze to lift it"))
+	return nil
+}
+
+// 解除冻结命令处理函数，即使钱包已解锁也要重新输入密码
+func (r *REPL) handleAccountUnfreeze(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.unfreeze")
+	}
+	account, err := r.freezeTarget(args[0])
+	if err != nil {
+		return err
+	}
+	if !account.Frozen {
+		return fmt.Errorf("account %s is not frozen", account.ID)
+	}
+	password, err := r.passwordPrompt().Read("Wallet password: ")
+	if err != nil {
+		return err
+	}
+	logger := audit.ForDir(r.baseDir())
+	if err := r.accountMgr.UnfreezeAccount(account.ID, password); err != nil {
+		if errors.Is(err, core.ErrInvalidPassword) {
+			logger.Record("repl", "account.unfreeze", account.ID, "denied: invalid password")
+		}
+		return err
+	}
+	logger.Record("repl", "account.unfreeze", account.ID, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Unfroze %s account %s, it can sign again", account.CoinSymbol, account.ID)))
+	return nil
+}
+
+// freezeTarget 解析冻结命令的账户参数
+func (r *REPL) freezeTarget(arg string) (*core.CoinAccount, error) {
+	if r.walletMgr.IsLocked() {
+		return nil, fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(arg)
+	if err != nil {
+		return nil, err
+	}
+	return r.findAccount(accountID)
+}
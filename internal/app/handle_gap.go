@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/usage"
+)
+
+// errGapLimit 再分配收款地址会超过 address.gap_limit，用同一助记词在其他钱包中恢复时扫描不到后面的地址
+var errGapLimit = errors.New("receive address gap limit reached")
+
+// 下一个收款地址命令处理函数：分配最小的链上未使用且没有被未支付请求占用的收款地址，没有时派生下一个；
+// 不会分配超过 address.gap_limit 的地址
+func (r *REPL) handleAddressNext(args []string) error {
+	formatName, args, err := formatOption(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return r.usageError("address.next")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	display, err := r.addressDisplay(account, formatName)
+	if err != nil {
+		return err
+	}
+	store, err := payreq.Load(filepath.Join(r.baseDir(), payreq.FileName))
+	if err != nil {
+		return err
+	}
+	addr, err := r.requestAddress(account, store.OpenAddresses())
+	if err != nil {
+		return err
+	}
+
+	r.setVariable(varLastAccount, accountID, "address.next")
+	r.setVariable(varLastAddress, addr.Address, "address.next")
+	fmt.Printf("%s (index %d, %s)\n", display(addr.Address), addr.AddressIndex, addr.CoinSymbol)
+	if limit := config.GetAppConfig().Address.GapLimit; limit > 0 {
+		lastUsed, err := r.lastUsedReceive(accountID)
+		if err != nil {
+			return err
+		}
+		if left := lastUsed + int64(limit) - int64(addr.AddressIndex); left < int64(limit)/4 {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Only %d more unused receive addresses can be handed out before the gap limit (%d), "+
+				"they become available again as addresses receive funds", left, limit)))
+		}
+	}
+	return nil
+}
+
+// lastUsedReceive 账户中链上已使用的收款地址的最大索引，没有时为 -1
+func (r *REPL) lastUsedReceive(accountID string) (int64, error) {
+	addresses, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return 0, fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	tracker, err := usage.Load(filepath.Join(r.baseDir(), usage.FileName))
+	if err != nil {
+		return 0, err
+	}
+	return lastUsedIndex(addresses, tracker), nil
+}
+
+// lastUsedIndex 已使用的收款地址的最大索引，没有时为 -1
+func lastUsedIndex(addresses []*core.AddressKey, tracker *usage.Tracker) int64 {
+	last := int64(-1)
+	for _, addr := range addresses {
+		if addr.ChangeType != 0 {
+			continue
+		}
+		if _, used := tracker.Used(addr.Address); used && int64(addr.AddressIndex) > last {
+			last = int64(addr.AddressIndex)
+		}
+	}
+	return last
+}
+
+// checkGap 收款地址索引是否在最后一个已使用地址之后的 address.gap_limit 个地址内
+func checkGap(accountID string, index uint32, lastUsed int64) error {
+	limit := config.GetAppConfig().Address.GapLimit
+	if limit <= 0 || int64(index) <= lastUsed+int64(limit) {
+		return nil
+	}
+	after := "the start of the account"
+	if lastUsed >= 0 {
+		after = fmt.Sprintf("index %d, the last one that received funds", lastUsed)
+	}
+	return fmt.Errorf("%w: account %s already has %d unused receive addresses after %s; "+
+		"wait for incoming funds or raise address.gap_limit, wallets restoring this seed stop scanning after %d unused addresses",
+		errGapLimit, core.ShortIDs([]string{accountID})[accountID], limit, after, limit)
+}
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/inherit"
+	"github.com/palagend/slowmade/internal/pubexport"
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// 继承包命令处理函数：没有 --recipient 时逐项引导输入，确认风险后为每位继承人写一个加密文件
+func (r *REPL) handleInheritKit(args []string) error {
+	usage := r.usageError("inherit.kit")
+	var (
+		specs     []string
+		threshold int
+		noteFile  string
+		outDir    string
+	)
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		switch args[i] {
+		case "--recipient":
+			specs = append(specs, args[i+1])
+		case "--shares":
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return fmt.Errorf("invalid share threshold %q", args[i+1])
+			}
+			threshold = n
+		case "--note":
+			noteFile = args[i+1]
+		case "--out":
+			outDir = args[i+1]
+		default:
+			return usage
+		}
+		i++
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	if len(specs) == 0 {
+		var err error
+		if specs, threshold, noteFile, outDir, err = r.inheritKitGuide(); err != nil {
+			return err
+		}
+	}
+	if outDir == "" {
+		outDir = "."
+	}
+
+	recipients, err := backup.ParseRecipients(specs)
+	if err != nil {
+		return err
+	}
+	heirs := recipients.Count()
+	if threshold != 0 && (threshold < 2 || threshold > heirs) {
+		return fmt.Errorf("--shares must be between 2 and the number of recipients (%d)", heirs)
+	}
+	kit := &inherit.Kit{CreatedAt: time.Now(), Cloaked: r.cloaked, Threshold: threshold}
+	if noteFile != "" {
+		note, err := os.ReadFile(noteFile)
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %w", err)
+		}
+		kit.Note = string(note)
+	}
+	if kit.MasterFingerprint, err = r.accountMgr.MasterFingerprint(); err != nil {
+		return err
+	}
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].CoinSymbol != accounts[j].CoinSymbol {
+			return accounts[i].CoinSymbol < accounts[j].CoinSymbol
+		}
+		return accounts[i].DerivationPath < accounts[j].DerivationPath
+	})
+	for _, account := range accounts {
+		described, err := pubexport.Describe(r.accountMgr, account)
+		if err != nil {
+			return err
+		}
+		kit.Accounts = append(kit.Accounts, described)
+	}
+
+	r.printInheritWarnings(kit, heirs)
+	answer, err := r.line.Prompt(`Type "confirm" to write the kit: `)
+	if err != nil || strings.TrimSpace(answer) != "confirm" {
+		return fmt.Errorf("inheritance kit cancelled")
+	}
+	mnemonic := ""
+	if threshold > 0 {
+		// 助记词只在写入分享时解密，需要再次输入钱包密码
+		password, err := r.passwordPrompt().Read("Wallet password: ")
+		if err != nil {
+			return err
+		}
+		secret, err := r.walletMgr.ExportMnemonic(password)
+		if err != nil {
+			return err
+		}
+		defer secret.Destroy()
+		mnemonic = string(secret.Bytes())
+	}
+	paths, err := kit.Write(outDir, recipients, mnemonic)
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "inherit.kit", outDir, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Wrote %d inheritance kit files, give each heir only their own file", len(paths))))
+	fmt.Println(r.template.Info("Heirs open their file with age -d -i <their key file>; it is plain text"))
+	return nil
+}
+
+// inheritKitGuide 逐项询问继承人公钥、分享门限、给继承人的说明和输出目录
+func (r *REPL) inheritKitGuide() (specs []string, threshold int, noteFile, outDir string, err error) {
+	fmt.Println(r.template.Info("An inheritance kit tells your heirs how to recover this wallet. Each heir gets a"))
+	fmt.Println(r.template.Info("file encrypted to their own age public key (age-keygen creates one)."))
+	for {
+		spec, err := r.line.Prompt(fmt.Sprintf("Heir %d age public key (age1..., empty to finish): ", len(specs)+1))
+		if err != nil {
+			return nil, 0, "", "", err
+		}
+		if spec = strings.TrimSpace(spec); spec == "" {
+			break
+		}
+		if _, err := backup.ParseRecipients([]string{spec}); err != nil {
+			fmt.Println(r.template.Error(err.Error()))
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, 0, "", "", backup.ErrNoRecipients
+	}
+	if len(specs) >= 2 {
+		fmt.Println(r.template.Info("The recovery words can be split into shares, one per heir, so that a number of heirs"))
+		fmt.Println(r.template.Info("together can recover the wallet but fewer learn nothing."))
+		answer, err := r.line.Prompt(fmt.Sprintf("How many heirs together can recover (2-%d, empty to leave the words out): ", len(specs)))
+		if err != nil {
+			return nil, 0, "", "", err
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			if threshold, err = strconv.Atoi(answer); err != nil {
+				return nil, 0, "", "", fmt.Errorf("invalid share threshold %q", answer)
+			}
+		}
+	}
+	if noteFile, err = r.line.Prompt("File with a note for your heirs (empty for none): "); err != nil {
+		return nil, 0, "", "", err
+	}
+	if outDir, err = r.line.Prompt("Output directory [.]: "); err != nil {
+		return nil, 0, "", "", err
+	}
+	return specs, threshold, strings.TrimSpace(noteFile), strings.TrimSpace(outDir), nil
+}
+
+// printInheritWarnings 写入前说明继承包包含什么、谁能动用资金
+func (r *REPL) printInheritWarnings(kit *inherit.Kit, heirs int) {
+	fmt.Println(r.template.Separator())
+	fmt.Printf("Wallet %s, %d accounts, %d heirs\n", kit.MasterFingerprint, len(kit.Accounts), heirs)
+	if kit.Threshold > 0 {
+		fmt.Println(view.Red(fmt.Sprintf("Any %d of these %d heirs together can take ALL funds, without you and at any time.", kit.Threshold, heirs)))
+		fmt.Println(r.template.Warning("Never let one person hold enough shares, and choose heirs who will not collude."))
+		if lost := heirs - kit.Threshold; lost > 0 {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Up to %d heirs may lose their key or share; one more and the funds are lost for good.", lost)))
+		} else {
+			fmt.Println(r.template.Warning("Every heir is needed: if a single heir loses their key or share, the funds are lost for good."))
+		}
+	} else {
+		fmt.Println(r.template.Warning("The kit does not contain the recovery words. Say in your note where the heirs find them."))
+	}
+	if kit.Cloaked {
+		fmt.Println(r.template.Warning("The cloak is not in the kit. Without it the heirs restore an empty wallet."))
+	}
+	fmt.Println(r.template.Warning("The kit lists account xpubs, which reveal your whole transaction history to every heir."))
+	fmt.Println(r.template.Warning("Write a new kit when you add accounts, and have an heir test opening their file."))
+	fmt.Println(r.template.Separator())
+}
+
+// 分享合并命令处理函数：在不回显的提示中逐个输入分享，达到门限后在全屏视图中显示助记词
+func (r *REPL) handleInheritCombine(args []string) error {
+	if len(args) > 0 {
+		return r.usageError("inherit.combine")
+	}
+	// 先检查终端，避免输入分享后无法显示
+	if err := view.CheckSecretTerminal(); err != nil {
+		return err
+	}
+	var shares []*inherit.Share
+	for {
+		prompt := fmt.Sprintf("Share %d (empty to finish): ", len(shares)+1)
+		if len(shares) > 0 {
+			prompt = fmt.Sprintf("Share %d of %d needed (empty to finish): ", len(shares)+1, shares[0].Threshold)
+		}
+		text, err := r.passwordPrompt().Read(prompt)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			break
+		}
+		share, err := inherit.ParseShare(text)
+		if err != nil {
+			fmt.Println(r.template.Error(err.Error()))
+			continue
+		}
+		fmt.Printf("Share %d of %d for wallet %s, %d needed\n", share.X, share.Total, share.Wallet, share.Threshold)
+		shares = append(shares, share)
+		if len(shares) == share.Threshold {
+			break
+		}
+	}
+	mnemonic, err := inherit.CombineShares(shares)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Info("Recovered the recovery words of wallet " + shares[0].Wallet))
+	err = r.showMnemonic(mnemonic)
+	if errors.Is(err, view.ErrRevealCancelled) {
+		fmt.Println(r.template.Info("Recovery words not shown"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Warning("Anyone who sees these words can take the funds. Restore with wallet.restore on this"))
+	fmt.Println(r.template.Warning("offline computer, then move the funds or keep the words as securely as the owner did."))
+	return nil
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/integrity"
+)
+
+// checkIntegrity 解锁后校验存储目录，发现不是 slowmade 写入的修改时提醒；返回是否没有未确认的修改
+func (r *REPL) checkIntegrity(password string) bool {
+	if r.integrity == nil {
+		return true
+	}
+	report, err := r.integrity.Unlock(password)
+	if err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Integrity check failed: %v", err)))
+		return false
+	}
+	r.printIntegrityReport(report)
+	return report.Created || report.Clean()
+}
+
+// resetIntegrity 创建或恢复钱包后按新密码重建清单
+func (r *REPL) resetIntegrity(password string) {
+	if r.integrity == nil {
+		return
+	}
+	if err := r.integrity.Reset(password); err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Failed to create integrity manifest: %v", err)))
+	}
+}
+
+func (r *REPL) printIntegrityReport(report *integrity.Report) {
+	switch {
+	case report.Created:
+		fmt.Println(r.template.Info("Integrity manifest created for the current wallet files"))
+	case report.Clean():
+		fmt.Println(r.template.Success(fmt.Sprintf("Wallet files unchanged since %s", r.format().Date(report.UpdatedAt))))
+	default:
+		fmt.Println(r.template.Warning("WALLET FILES WERE CHANGED OUTSIDE SLOWMADE since " + r.format().Date(report.UpdatedAt)))
+		for _, line := range report.Problems() {
+			fmt.Println("  " + line)
+		}
+		fmt.Println(r.template.Warning("This can be malware, a sync conflict or a restored backup. Check before using the wallet; " +
+			"run integrity.accept once you have verified the changes"))
+	}
+}
+
+// 完整性状态命令处理函数，立即重新校验存储目录
+func (r *REPL) handleIntegrityStatus(args []string) error {
+	if r.integrity == nil {
+		return fmt.Errorf("integrity checking is disabled, set [integrity] enabled = true in the config")
+	}
+	report, err := r.integrity.Verify()
+	if err != nil {
+		return err
+	}
+	r.printIntegrityReport(report)
+	return nil
+}
+
+// 接受当前文件为新的基线，用于确认过的外部修改（如同步或恢复备份）
+func (r *REPL) handleIntegrityAccept(args []string) error {
+	if r.integrity == nil {
+		return fmt.Errorf("integrity checking is disabled, set [integrity] enabled = true in the config")
+	}
+	report, err := r.integrity.Verify()
+	if err != nil {
+		return err
+	}
+	if report.Clean() {
+		fmt.Println(r.template.Info("Nothing to accept, wallet files match the manifest"))
+		return nil
+	}
+	for _, line := range report.Problems() {
+		fmt.Println("  " + line)
+	}
+	answer, err := r.line.Prompt("Accept these changes as the new baseline? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("cancelled")
+	}
+	if err := r.integrity.Accept(); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success("Integrity manifest updated"))
+	return nil
+}
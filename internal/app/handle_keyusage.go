@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/keyusage"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// keyUsagePath 签名统计文件，anomaly.enabled 关闭时为空
+func keyUsagePath(baseDir string) (string, keyusage.Thresholds) {
+	appConfig := config.GetAppConfig()
+	anomalyConfig := appConfig.GetAnomalyConfig()
+	if !anomalyConfig.Enabled {
+		return "", keyusage.Thresholds{}
+	}
+	return filepath.Join(baseDir, keyusage.FileName), keyusage.Thresholds{
+		AmountFactor:  anomalyConfig.AmountFactor,
+		MinSignatures: anomalyConfig.MinSignatures,
+	}
+}
+
+// checkBTCUsage 对比 BTC 交易和账户的签名历史，返回统计、本次签名和异常原因；
+// 有异常时写入审计日志并发布 keyusage.Detected。统计关闭或无法加载时 stats 为 nil
+func (r *REPL) checkBTCUsage(accountID string, outputs []btc.TxOut) (*keyusage.Stats, keyusage.Input, []string) {
+	usage := keyusage.Input{Key: accountID, Coin: "BTC", At: time.Now()}
+	path, thresholds := keyUsagePath(r.baseDir())
+	if path == "" || accountID == "" {
+		return nil, usage, nil
+	}
+	stats, err := keyusage.Load(path, thresholds)
+	if err != nil {
+		logging.Warnf("Failed to load signing statistics: %v", err)
+		return nil, usage, nil
+	}
+	// 找零和转给本钱包的输出不计入金额和收款方
+	var sent int64
+	for _, output := range outputs {
+		address := decoder.ScriptAddress(output.Script)
+		if _, own := r.accountMgr.IsMine(address); own {
+			continue
+		}
+		sent += output.Value
+		usage.Destinations = append(usage.Destinations, address)
+	}
+	usage.Amount = new(big.Rat).SetFrac64(sent, 1e8)
+	anomalies := stats.Check(usage)
+	if len(anomalies) > 0 {
+		audit.ForDir(r.baseDir()).Record("repl", "btc_signTransaction", accountID, "anomaly: "+strings.Join(anomalies, "; "))
+		r.bus.Publish(events.Event{Type: keyusage.Detected, Data: &keyusage.Anomaly{
+			Key: accountID, Coin: "BTC", Method: "btc_signTransaction", Reasons: anomalies, At: usage.At.UTC()}})
+	}
+	return stats, usage, anomalies
+}
+
+// checkTransfer 单一收款方转账的签名前检查：对比 key 的签名历史，再执行签名策略，
+// 有异常或策略要求确认时询问用户，拒绝时返回 signer.ErrRejected；通过后记录本次签名
+func (r *REPL) checkTransfer(method, key, symbol, to string, amount *big.Rat) error {
+	_, own := r.accountMgr.IsMine(to)
+	usage := keyusage.Input{Key: key, Coin: symbol, Amount: new(big.Rat), At: time.Now()}
+	if !own {
+		usage.Amount, usage.Destinations = amount, []string{to}
+	}
+	var (
+		stats     *keyusage.Stats
+		anomalies []string
+	)
+	if path, thresholds := keyUsagePath(r.baseDir()); path != "" {
+		var err error
+		if stats, err = keyusage.Load(path, thresholds); err != nil {
+			logging.Warnf("Failed to load signing statistics: %v", err)
+		} else if anomalies = stats.Check(usage); len(anomalies) > 0 {
+			audit.ForDir(r.baseDir()).Record("repl", method, key, "anomaly: "+strings.Join(anomalies, "; "))
+			r.bus.Publish(events.Event{Type: keyusage.Detected, Data: &keyusage.Anomaly{
+				Key: key, Coin: symbol, Method: method, Reasons: anomalies, At: usage.At.UTC()}})
+		}
+	}
+
+	result, err := policy.Check(r.policyDir(), policy.Input{Method: method, Coin: symbol, Destination: to, Amount: amount, Own: own})
+	if err != nil {
+		return err
+	}
+	reason := ""
+	if result.Decision == policy.Confirm {
+		reason = result.Reason()
+	}
+	if (reason != "" || len(anomalies) > 0) && !r.confirmPolicy(reason, anomalies, "") {
+		return signer.ErrRejected
+	}
+	if stats != nil {
+		if err := stats.Record(usage); err != nil {
+			logging.Warnf("Failed to record signing statistics: %v", err)
+		}
+	}
+	return nil
+}
+
+// 签名统计命令处理函数，列出每个密钥的签名次数、常见金额、签名时段和收款方数量
+func (r *REPL) handleSigningStats(args []string) error {
+	if len(args) > 1 {
+		return r.usageError("signing.stats")
+	}
+	path, thresholds := keyUsagePath(r.baseDir())
+	if path == "" {
+		fmt.Println(r.template.Info("Signing statistics are disabled (anomaly.enabled = false)"))
+		return nil
+	}
+	stats, err := keyusage.Load(path, thresholds)
+	if err != nil {
+		return err
+	}
+	filter := ""
+	if len(args) == 1 {
+		if filter, err = r.resolveAccountID(args[0]); err != nil {
+			return err
+		}
+	}
+	keys := stats.Sorted()
+	shortIDs := core.ShortIDs(keys)
+	shown := 0
+	for _, key := range keys {
+		if filter != "" && !strings.EqualFold(key, filter) {
+			continue
+		}
+		p := stats.Keys[key]
+		shown++
+		label := key
+		if strings.HasPrefix(key, core.AccountIDPrefix) {
+			label = shortIDs[key]
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("%s (%s)", label, p.Coin)))
+		fmt.Printf("  Signatures:   %d, %s to %s\n", p.Count, r.format().Date(p.First), r.format().Date(p.Last))
+		if typical := p.TypicalAmount(); typical != nil {
+			fmt.Printf("  Typical:      %s %s\n", r.format().Decimal(typical.FloatString(8)), p.Coin)
+		}
+		fmt.Printf("  Hours:        %s\n", p.ActiveHours())
+		fmt.Printf("  Destinations: %d\n", len(p.Destinations))
+		if p.Count < thresholds.MinSignatures {
+			fmt.Printf("  Checks start after %d signatures\n", thresholds.MinSignatures)
+		}
+	}
+	if shown == 0 {
+		fmt.Println(r.template.Info("No signatures recorded"))
+	}
+	return nil
+}
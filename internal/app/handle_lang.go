@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/i18n"
+)
+
+// handleLangList 列出内置和外部目录中的语言、翻译完整度和来源，* 标记当前语言
+func (r *REPL) handleLangList(args []string) error {
+	if len(args) > 0 {
+		return r.usageError("lang.list")
+	}
+	locales := i18n.Locales()
+	if len(locales) == 0 {
+		return fmt.Errorf("translations are not loaded")
+	}
+	current := i18n.Language()
+	fmt.Printf("  %-10s %8s  %-30s %s\n", "LANGUAGE", "COMPLETE", "SOURCE", "NAME")
+	for _, locale := range locales {
+		marker := " "
+		if locale.Tag == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %-10s %7.1f%%  %-30s %s\n", marker, locale.Tag, locale.Completeness(),
+			strings.Join(locale.Sources, " + "), locale.Name)
+	}
+	if dir := i18n.Dir(); dir != "" {
+		fmt.Println(r.template.Info(fmt.Sprintf("Add or override languages with <language>.json, .toml or .yaml files in %s "+
+			"(ui.locales_dir) and select one with ui.lang or --lang", dir)))
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+)
+
+// ABIDirName 存放合约 ABI 文件的子目录，用于解码调用数据
+const ABIDirName = "abi"
+
+// replApprover 在 REPL 中展示解码后的签名预览并等待确认
+type replApprover struct {
+	r *REPL
+}
+
+func (a *replApprover) Approve(req *signer.ApprovalRequest) bool {
+	fmt.Println(a.r.template.Info(fmt.Sprintf("%s request for %s", req.Method, req.Account.Hex())))
+	for _, line := range req.Details {
+		fmt.Printf("  %s\n", line)
+	}
+	if req.To != "" {
+		if name := a.r.reverseName(req.To); name != "" {
+			fmt.Printf("  ENS name: %s (primary name of %s)\n", name, req.To)
+		}
+	}
+	answer, err := a.r.line.Prompt("Sign? [y/N]: ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func (a *replApprover) Confirm(req *signer.ApprovalRequest) bool {
+	return a.r.confirmPolicy(req.Policy, req.Anomalies, req.Fee)
+}
+
+// batchApprover 用户已确认整批签名：只批准批内地址的消息签名，策略和签名习惯要求的额外确认仍逐个提示
+type batchApprover struct {
+	r        *REPL
+	accounts map[common.Address]bool
+}
+
+func (a *batchApprover) Approve(req *signer.ApprovalRequest) bool {
+	return req.Method == "personal_sign" && a.accounts[req.Account]
+}
+
+func (a *batchApprover) Confirm(req *signer.ApprovalRequest) bool {
+	return a.r.confirmPolicy(req.Policy, req.Anomalies, req.Fee)
+}
+
+// newSigner 创建带解码预览的签名器，ABI 文件从数据目录的 abi/ 下加载
+func (r *REPL) newSigner() (*signer.Signer, error) {
+	return r.newSignerWith(&replApprover{r: r})
+}
+
+// newSignerWith 与 newSigner 相同，使用指定的确认方式
+func (r *REPL) newSignerWith(approver signer.Approver) (*signer.Signer, error) {
+	dec := decoder.NewDecoder()
+	if err := dec.LoadABIDir(filepath.Join(r.baseDir(), ABIDirName)); err != nil {
+		return nil, err
+	}
+	path, thresholds := keyUsagePath(r.baseDir())
+	return signer.NewSigner(r.accountMgr, approver, nil).
+		Preview(dec).
+		Audit(audit.ForDir(r.baseDir())).
+		Policies(filepath.Join(r.baseDir(), policy.DirName)).
+		Usage(path, thresholds, r.bus), nil
+}
+
+// 消息签名命令处理函数
+func (r *REPL) handleMessageSign(args []string) error {
+	usage := r.usageError("message.sign")
+	if len(args) < 2 {
+		return usage
+	}
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("invalid ETH address %q", args[0])
+	}
+	account := common.HexToAddress(args[0])
+
+	s, err := r.newSigner()
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	switch args[1] {
+	case "--typed":
+		if len(args) != 3 {
+			return usage
+		}
+		data, err := os.ReadFile(args[2])
+		if err != nil {
+			return err
+		}
+		td, err := signer.ParseTypedData(json.RawMessage(data))
+		if err != nil {
+			return err
+		}
+		sig, err = s.SignTypedData(account, td)
+		if err != nil {
+			return err
+		}
+	case "--hex":
+		if len(args) != 3 {
+			return usage
+		}
+		data, err := hexutil.Decode(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid hex message: %v", err)
+		}
+		sig, err = s.SignMessage(account, data)
+		if err != nil {
+			return err
+		}
+	default:
+		sig, err = s.SignMessage(account, []byte(strings.Join(args[1:], " ")))
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(r.template.Success("Message signed"))
+	fmt.Printf("Signature: %s\n", hexutil.Encode(sig))
+	return nil
+}
+
+// 地址所有权证明命令处理函数：签名包含时间、地址、附言和派生路径哈希的声明，写成 JSON 文件交给对方核验
+func (r *REPL) handleAddressProve(args []string) error {
+	usage := r.usageError("address.prove")
+	if len(args) < 1 {
+		return usage
+	}
+	message, out := "", ""
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--message" && i+1 < len(args):
+			i++
+			message = args[i]
+		case args[i] == "--out" && i+1 < len(args):
+			i++
+			out = args[i]
+		default:
+			return usage
+		}
+	}
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("only ETH addresses can be proven, message signing uses personal_sign: %q", args[0])
+	}
+	s, err := r.newSigner()
+	if err != nil {
+		return err
+	}
+	proof, err := s.Prove(common.HexToAddress(args[0]), message)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(proof, "", "  ")
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("%s already exists", out)
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Ownership proof for %s written to %s", proof.Address, out)))
+	return nil
+}
+
+// 核验地址所有权证明，不需要钱包；--path 同时核对对方出示的派生路径
+func (r *REPL) handleAddressVerifyProof(args []string) error {
+	usage := r.usageError("address.verify-proof")
+	if len(args) != 1 && !(len(args) == 3 && args[1] == "--path") {
+		return usage
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	proof, err := signer.ParseProof(data)
+	if err != nil {
+		return err
+	}
+	if err := proof.Verify(); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Valid proof: the holder of %s signed it", proof.Address)))
+	fmt.Printf("  Coin:      %s\n", proof.Coin)
+	fmt.Printf("  Address:   %s\n", proof.Address)
+	fmt.Printf("  Signed at: %s (%s ago, as stated by the signer)\n",
+		r.format().Date(proof.Timestamp), time.Since(proof.Timestamp).Round(time.Minute))
+	fmt.Printf("  Message:   %s\n", proof.Message)
+	fmt.Printf("  Path hash: %s\n", proof.PathHash)
+	if len(args) == 3 {
+		if signer.PathHash(args[2]) != proof.PathHash {
+			return fmt.Errorf("derivation path %s does not match the path hash in the proof", args[2])
+		}
+		fmt.Printf("  Path:      %s (matches)\n", args[2])
+	}
+	return nil
+}
+
+// whitelistLimit account.export-whitelist 一次最多签名的地址数
+const whitelistLimit = 1000
+
+// 提币白名单导出命令处理函数：为账户收款链上的地址逐个签名所有权证明，按 --out 的扩展名写成 CSV 或 JSON；
+// 确认一次后整批签名，策略要求的额外确认仍逐个提示
+func (r *REPL) handleAccountExportWhitelist(args []string) error {
+	usage := r.usageError("account.export-whitelist")
+	if len(args) < 1 {
+		return usage
+	}
+	var (
+		out, message string
+		count, start int
+	)
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		var err error
+		switch args[i] {
+		case "--count":
+			count, err = strconv.Atoi(args[i+1])
+		case "--start":
+			start, err = strconv.Atoi(args[i+1])
+		case "--out":
+			out = args[i+1]
+		case "--message":
+			message = args[i+1]
+		default:
+			return usage
+		}
+		if err != nil {
+			return fmt.Errorf("无效的参数 %s: %s", args[i], args[i+1])
+		}
+		i++
+	}
+	if out == "" || count <= 0 || start < 0 {
+		return usage
+	}
+	if count > whitelistLimit {
+		return fmt.Errorf("--count is limited to %d addresses", whitelistLimit)
+	}
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("%s already exists", out)
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account.CoinSymbol != "ETH" {
+		return fmt.Errorf("only ETH accounts can be exported, ownership signatures use personal_sign: %s is a %s account", args[0], account.CoinSymbol)
+	}
+	addresses, derived, err := r.batchAddresses(accountID, uint32(start), uint32(count))
+	if err != nil {
+		return err
+	}
+	if derived > 0 {
+		fmt.Println(r.template.Info(fmt.Sprintf("Derived %d new receive addresses", derived)))
+	}
+
+	fmt.Printf("Sign ownership proofs for %d addresses of %s (index %d to %d)\n", len(addresses), account.ID, start, start+count-1)
+	if message != "" {
+		fmt.Printf("  Message: %s\n", message)
+	}
+	answer, err := r.line.Prompt("Sign all? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("cancelled, nothing was signed")
+	}
+	approver := &batchApprover{r: r, accounts: make(map[common.Address]bool, len(addresses))}
+	for _, addr := range addresses {
+		approver.accounts[common.HexToAddress(addr.Address)] = true
+	}
+	s, err := r.newSignerWith(approver)
+	if err != nil {
+		return err
+	}
+	whitelist := signer.NewWhitelist(account.ID, account.CoinSymbol)
+	for _, addr := range addresses {
+		proof, err := s.Prove(common.HexToAddress(addr.Address), message)
+		if err != nil {
+			return fmt.Errorf("%s: %w", addr.Address, err)
+		}
+		whitelist.Add(addr.AddressIndex, proof)
+	}
+
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(out), ".csv") {
+		err = whitelist.WriteCSV(&buf)
+	} else {
+		err = whitelist.WriteJSON(&buf)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Wrote %d signed addresses to %s", len(whitelist.Addresses), out)))
+	return nil
+}
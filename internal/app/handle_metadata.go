@@ -0,0 +1,403 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+)
+
+// metadataStore 加载标签、归档、联系人和别名
+func (r *REPL) metadataStore() (*metadata.Store, error) {
+	return metadata.Load(filepath.Join(r.baseDir(), metadata.FileName))
+}
+
+// resolveAccountID 将别名、短 ID（账户 ID 去掉前缀后至少 4 个字符的前缀）或账户标签解析为账户 ID；
+// 匹配多个账户时返回 ErrAmbiguousAccount 并列出候选，没有匹配时原样返回，由调用方按地址或完整 ID 处理
+func (r *REPL) resolveAccountID(arg string) (string, error) {
+	store, err := r.metadataStore()
+	if err != nil {
+		store = nil
+	}
+	if store != nil {
+		if accountID, ok := store.Get(metadata.Aliases, arg); ok {
+			return accountID, nil
+		}
+	}
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		// 钱包锁定时由调用方给出提示
+		return arg, nil
+	}
+
+	query := strings.ToLower(strings.TrimPrefix(arg, core.AccountIDPrefix))
+	var matches []*core.CoinAccount
+	for _, account := range accounts {
+		if account.ID == arg {
+			return arg, nil
+		}
+		label := ""
+		if store != nil {
+			label, _ = store.Get(metadata.Labels, account.ID)
+		}
+		if (len(query) >= 4 && strings.HasPrefix(strings.TrimPrefix(account.ID, core.AccountIDPrefix), query)) ||
+			(label != "" && strings.EqualFold(label, arg)) {
+			matches = append(matches, account)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return arg, nil
+	case 1:
+		return matches[0].ID, nil
+	}
+
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	short := core.ShortIDs(ids)
+	var candidates strings.Builder
+	for _, account := range matches {
+		fmt.Fprintf(&candidates, "\n  %-12s %-5s %s", short[account.ID], account.CoinSymbol, account.DerivationPath)
+		if label, ok := store.Get(metadata.Labels, account.ID); ok {
+			fmt.Fprintf(&candidates, "  %q", label)
+		}
+	}
+	return "", fmt.Errorf("%w %q, candidates:%s", ErrAmbiguousAccount, arg, candidates.String())
+}
+
+// coinAddress 解析本钱包中 symbol 币种的地址：可以是地址本身，或账户 ID、别名、标签（使用账户的第一个收款地址）
+func (r *REPL) coinAddress(arg, symbol string) (*core.AddressKey, error) {
+	if r.walletMgr.IsLocked() {
+		return nil, fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	if addr, ok := r.accountMgr.IsMine(arg); ok {
+		if addr.CoinSymbol != symbol {
+			return nil, fmt.Errorf("%s is a %s address, not %s", arg, addr.CoinSymbol, symbol)
+		}
+		return addr, nil
+	}
+	accountID, err := r.resolveAccountID(arg)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	var first *core.AddressKey
+	for _, addr := range addresses {
+		if addr.CoinSymbol != symbol {
+			return nil, fmt.Errorf("account %s is not a %s account", accountID, symbol)
+		}
+		if addr.ChangeType == 0 && (first == nil || addr.AddressIndex < first.AddressIndex) {
+			first = addr
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("该账户尚未派生任何地址")
+	}
+	return first, nil
+}
+
+// resolveContact 将联系人名称解析为地址，不是联系人时原样返回
+func (r *REPL) resolveContact(arg string) string {
+	store, err := r.metadataStore()
+	if err != nil {
+		return arg
+	}
+	if address, ok := store.Get(metadata.Contacts, arg); ok {
+		fmt.Println(r.template.Info(fmt.Sprintf("Contact %s -> %s", arg, address)))
+		return address
+	}
+	return arg
+}
+
+// 标签命令处理函数，标签可以设置在账户 ID 或地址上
+func (r *REPL) handleLabelSet(args []string) error {
+	if len(args) < 2 {
+		return r.usageError("label.set")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	target, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := store.Set("label.set", metadata.Labels, target, strings.Join(args[1:], " ")); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Labelled %s", target)))
+	return nil
+}
+
+func (r *REPL) handleLabelRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("label.remove")
+	}
+	target, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	return r.removeMetadata("label.remove", metadata.Labels, target)
+}
+
+func (r *REPL) handleLabelList(args []string) error {
+	return r.listMetadata(metadata.Labels, "No labels")
+}
+
+// 账户归档命令处理函数，归档的账户默认不在 account.list 中显示
+func (r *REPL) handleAccountArchive(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.archive")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	if _, ok := store.Get(metadata.Archived, accountID); ok {
+		return fmt.Errorf("account %s is already archived", accountID)
+	}
+	if err := store.Set("account.archive", metadata.Archived, accountID, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Archived %s", accountID)))
+	return nil
+}
+
+func (r *REPL) handleAccountUnarchive(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.unarchive")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	return r.removeMetadata("account.unarchive", metadata.Archived, accountID)
+}
+
+// 账户设置命令处理函数，目前支持 BTC 账户的找零策略；不带值时显示当前设置
+func (r *REPL) handleAccountSet(args []string) error {
+	if len(args) < 2 || args[1] != "change-policy" || len(args) > 4 {
+		return r.usageError("account.set")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account.CoinSymbol != "BTC" {
+		return fmt.Errorf("change-policy only applies to BTC accounts, %s is %s", accountID, account.CoinSymbol)
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	current, _ := store.Get(metadata.ChangePolicies, accountID)
+	if len(args) == 2 {
+		policy, err := btc.ParseChangePolicy(current)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("change-policy: %s\n", policy)
+		return nil
+	}
+
+	policy, err := btc.ParseChangePolicy(strings.Join(args[2:], ":"))
+	if err != nil {
+		return err
+	}
+	if policy.Mode == btc.ChangeFixed {
+		owner, ok := r.accountMgr.IsMine(policy.Address)
+		if !ok {
+			return fmt.Errorf("fixed change address %s does not belong to this wallet", policy.Address)
+		}
+		if owner.CoinSymbol != "BTC" {
+			return fmt.Errorf("fixed change address %s is not a BTC address", policy.Address)
+		}
+	}
+	switch {
+	case policy.Mode == btc.ChangeFresh && current != "":
+		err = store.Delete("account.set", metadata.ChangePolicies, accountID)
+	case policy.Mode != btc.ChangeFresh && policy.String() != current:
+		err = store.Set("account.set", metadata.ChangePolicies, accountID, policy.String())
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Change policy of %s set to %s", accountID, policy)))
+	if policy.Mode != btc.ChangeFresh {
+		fmt.Println(r.template.Warning("Sending change to a known address links your transactions together on chain"))
+	}
+	return nil
+}
+
+// 联系人命令处理函数
+func (r *REPL) handleContactAdd(args []string) error {
+	if len(args) != 2 {
+		return r.usageError("contact.add")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if _, exists := store.Get(metadata.Contacts, args[0]); exists {
+		return fmt.Errorf("contact %s already exists, remove it first", args[0])
+	}
+	if err := store.Set("contact.add", metadata.Contacts, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Added contact %s", args[0])))
+	return nil
+}
+
+func (r *REPL) handleContactRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("contact.remove")
+	}
+	return r.removeMetadata("contact.remove", metadata.Contacts, args[0])
+}
+
+func (r *REPL) handleContactRename(args []string) error {
+	if len(args) != 2 {
+		return r.usageError("contact.rename")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Rename("contact.rename", metadata.Contacts, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Renamed contact %s to %s", args[0], args[1])))
+	return nil
+}
+
+func (r *REPL) handleContactList(args []string) error {
+	return r.listMetadata(metadata.Contacts, "No contacts")
+}
+
+// 账户别名命令处理函数，别名可以代替账户 ID 用在账户相关命令中
+func (r *REPL) handleAliasSet(args []string) error {
+	if len(args) != 2 {
+		return r.usageError("alias.set")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	accountID, err := r.resolveAccountID(args[1])
+	if err != nil {
+		return err
+	}
+	if err := store.Set("alias.set", metadata.Aliases, args[0], accountID); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Alias %s set", args[0])))
+	return nil
+}
+
+func (r *REPL) handleAliasRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("alias.remove")
+	}
+	return r.removeMetadata("alias.remove", metadata.Aliases, args[0])
+}
+
+func (r *REPL) handleAliasList(args []string) error {
+	return r.listMetadata(metadata.Aliases, "No aliases")
+}
+
+// 撤销命令处理函数：undo 撤销最近一次元数据修改，undo <command> 撤销该命令最近一次的修改
+func (r *REPL) handleUndo(args []string) error {
+	if len(args) > 2 || (len(args) == 2 && args[0] != "--list") {
+		return r.usageError("undo")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 && args[0] == "--list" {
+		return r.printJournal(store, 20)
+	}
+	if len(args) == 2 && args[0] == "--list" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+		return r.printJournal(store, n)
+	}
+
+	command := ""
+	if len(args) == 1 {
+		command = args[0]
+	}
+	op, err := store.Undo(command)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Undid #%d %s", op.ID, op.Command)))
+	for _, c := range op.Changes {
+		fmt.Printf("  %s\n", c)
+	}
+	return nil
+}
+
+func (r *REPL) printJournal(store *metadata.Store, n int) error {
+	history := store.History(n)
+	if len(history) == 0 {
+		fmt.Println("No metadata changes to undo")
+		return nil
+	}
+	for _, op := range history {
+		fmt.Printf("#%-4d %s  %s\n", op.ID, r.format().Date(op.Time), op.Command)
+		for _, c := range op.Changes {
+			fmt.Printf("        %s\n", c)
+		}
+	}
+	return nil
+}
+
+func (r *REPL) removeMetadata(command string, kind metadata.Kind, key string) error {
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(command, kind, key); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Removed %s, run undo to restore", key)))
+	return nil
+}
+
+func (r *REPL) listMetadata(kind metadata.Kind, empty string) error {
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	keys := store.Keys(kind)
+	if len(keys) == 0 {
+		fmt.Println(empty)
+		return nil
+	}
+	for _, key := range keys {
+		value, _ := store.Get(kind, key)
+		fmt.Printf("  %-40s %s\n", key, value)
+	}
+	return nil
+}
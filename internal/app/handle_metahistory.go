@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// parseHistoryTime 解析 YYYY-MM-DD（当天结束时）或 YYYY-MM-DDTHH:MM，按界面时区
+func parseHistoryTime(value string) (time.Time, error) {
+	if at, err := time.ParseInLocation("2006-01-02T15:04", value, view.Location()); err == nil {
+		return at, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, view.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or YYYY-MM-DDTHH:MM", value)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// 元数据历史命令处理函数：列出事件日志中的修改，--at 显示某一时刻的全部条目，--verify 校验哈希链
+func (r *REPL) handleMetadataHistory(args []string) error {
+	var (
+		at         *time.Time
+		kind, key  string
+		limit      = 20
+		verifyOnly bool
+	)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--at" && i+1 < len(args):
+			i++
+			t, err := parseHistoryTime(args[i])
+			if err != nil {
+				return err
+			}
+			at = &t
+		case args[i] == "--kind" && i+1 < len(args):
+			i++
+			kind = args[i]
+		case args[i] == "--key" && i+1 < len(args):
+			i++
+			key = args[i]
+		case args[i] == "--limit" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid limit %q", args[i])
+			}
+			limit = n
+		case args[i] == "--verify":
+			verifyOnly = true
+		default:
+			return r.usageError("history")
+		}
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+
+	if verifyOnly {
+		n, err := store.Verify()
+		if err != nil {
+			return err
+		}
+		fmt.Println(r.template.Success(fmt.Sprintf("Metadata event log intact (%d events)", n)))
+		return nil
+	}
+	if at != nil {
+		entries, err := store.At(*at)
+		if err != nil {
+			return err
+		}
+		return r.printEntriesAt(entries, *at, metadata.Kind(kind), key)
+	}
+
+	events, err := store.Events()
+	if err != nil {
+		return err
+	}
+	shown := 0
+	for i := len(events) - 1; i >= 0 && shown < limit; i-- {
+		event := events[i]
+		var changes []metadata.Change
+		for _, c := range event.Changes {
+			if (kind == "" || string(c.Kind) == kind) && (key == "" || c.Key == key) {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		shown++
+		undoes := ""
+		if event.Undoes > 0 {
+			undoes = fmt.Sprintf(" (undoes #%d)", event.Undoes)
+		}
+		fmt.Printf("#%-4d %s  %s%s\n", event.ID, r.format().Date(event.Time), event.Command, undoes)
+		for _, c := range changes {
+			fmt.Printf("        %s\n", c)
+		}
+	}
+	if shown == 0 {
+		fmt.Println("No matching metadata events")
+	}
+	return nil
+}
+
+// printEntriesAt 按类别列出某一时刻的条目
+func (r *REPL) printEntriesAt(entries map[metadata.Kind]map[string]string, at time.Time, kind metadata.Kind, key string) error {
+	fmt.Printf("Metadata as of %s\n", r.format().Date(at))
+	kinds := make([]string, 0, len(entries))
+	for k := range entries {
+		if kind == "" || k == kind {
+			kinds = append(kinds, string(k))
+		}
+	}
+	sort.Strings(kinds)
+	shown := 0
+	for _, k := range kinds {
+		values := entries[metadata.Kind(k)]
+		keys := make([]string, 0, len(values))
+		for entryKey := range values {
+			if key == "" || entryKey == key {
+				keys = append(keys, entryKey)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		fmt.Printf("%s:\n", k)
+		for _, entryKey := range keys {
+			fmt.Printf("  %-40s %s\n", entryKey, values[entryKey])
+		}
+		shown += len(keys)
+	}
+	if shown == 0 {
+		fmt.Println("No matching entries")
+	}
+	return nil
+}
+
+// 元数据压缩命令处理函数：把某日期之前的事件并入快照，之后不能再查看该日期之前的时刻
+func (r *REPL) handleMetadataCompact(args []string) error {
+	if len(args) != 2 || args[0] != "--before" {
+		return r.usageError("metadata.compact")
+	}
+	before, err := time.ParseInLocation("2006-01-02", args[1], view.Location())
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", args[1])
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if _, err := store.Verify(); err != nil {
+		return fmt.Errorf("refusing to compact: %w", err)
+	}
+	result, err := store.Compact(before)
+	if err != nil {
+		return err
+	}
+	if result.Removed == 0 {
+		fmt.Printf("No metadata events before %s, nothing to compact\n", args[1])
+		return nil
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Folded %d events into a snapshot, %d remain", result.Removed, result.Remaining)))
+	fmt.Printf("Point-in-time views now start at %s\n", r.format().Date(result.Since))
+	return nil
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/cloudsync"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/metasync"
+)
+
+// metaSyncArgs sync.meta-push、sync.meta-pull 的参数
+type metaSyncArgs struct {
+	file   string // 为空时使用云同步后端
+	prefer string // 冲突时的默认选择：local、remote，为空时逐个询问
+}
+
+func parseMetaSyncArgs(args []string, usage error) (*metaSyncArgs, error) {
+	parsed := &metaSyncArgs{}
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return nil, usage
+		}
+		switch args[i] {
+		case "--file":
+			parsed.file = args[i+1]
+		case "--prefer":
+			parsed.prefer = args[i+1]
+			if parsed.prefer != "local" && parsed.prefer != "remote" {
+				return nil, usage
+			}
+		default:
+			return nil, usage
+		}
+		i++
+	}
+	return parsed, nil
+}
+
+// 推送元数据命令处理函数：合并远端的修改后，把标签、标记、联系人和别名加密上传，不包含任何密钥文件
+func (r *REPL) handleSyncMetaPush(args []string) error {
+	parsed, err := parseMetaSyncArgs(args, r.usageError("sync.meta-push"))
+	if err != nil {
+		return err
+	}
+	return r.syncMetadata("sync.meta-push", parsed)
+}
+
+// 拉取元数据命令处理函数：把远端的标签、标记、联系人和别名合并到本机，冲突逐个询问
+func (r *REPL) handleSyncMetaPull(args []string) error {
+	parsed, err := parseMetaSyncArgs(args, r.usageError("sync.meta-pull"))
+	if err != nil {
+		return err
+	}
+	return r.syncMetadata("sync.meta-pull", parsed)
+}
+
+// syncMetadata 读取远端元数据包并三方合并到本机，推送时再把合并结果加密写回
+func (r *REPL) syncMetadata(command string, parsed *metaSyncArgs) error {
+	push := command == "sync.meta-push"
+	var backend cloudsync.Backend
+	if parsed.file == "" {
+		appConfig := config.GetAppConfig()
+		var err error
+		if backend, err = cloudsync.NewBackend(appConfig.GetSyncConfig()); err != nil {
+			return fmt.Errorf("%w, or use --file", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	sealed, err := r.readMetadataBundle(ctx, backend, parsed.file)
+	if err != nil {
+		return err
+	}
+	if sealed == nil && !push {
+		return fmt.Errorf("no metadata bundle found, run sync.meta-push on the other machine first")
+	}
+
+	// 第一次推送时确认新设置的同步密码
+	var password string
+	if sealed == nil {
+		password, err = r.passwordPrompt().ReadNew("Sync password: ")
+	} else {
+		password, err = r.passwordPrompt().Read("Sync password: ")
+	}
+	if err != nil {
+		return err
+	}
+
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	basePath := filepath.Join(r.baseDir(), metasync.BaseFileName)
+	var remote *metasync.Bundle
+	if sealed != nil {
+		remote, err = metasync.Open(sealed, password)
+		if err != nil {
+			return err
+		}
+		base, err := metasync.LoadBase(basePath)
+		if err != nil {
+			return err
+		}
+		merge := metasync.Plan(base, store.Export(metasync.Kinds...), remote.Entries)
+		for _, conflict := range merge.Conflicts {
+			takeRemote, err := r.resolveMetaConflict(conflict, parsed.prefer)
+			if err != nil {
+				return err
+			}
+			merge.Resolve(conflict, takeRemote)
+		}
+		if err := store.Merge(command, merge.Updates); err != nil {
+			return err
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("Merged bundle from %s: %d changes applied, %d conflicts", r.format().Date(remote.CreatedAt),
+			len(merge.Updates), len(merge.Conflicts))))
+		if len(merge.Updates) > 0 {
+			fmt.Println(r.template.Info("Run undo to revert the merged changes"))
+		}
+	}
+
+	bundle := metasync.Collect(store)
+	if push {
+		data, err := bundle.Seal(password)
+		if err != nil {
+			return err
+		}
+		if backend != nil {
+			err = backend.Put(ctx, metasync.ObjectName, data)
+		} else {
+			err = os.WriteFile(parsed.file, data, 0600)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write metadata bundle: %w", err)
+		}
+	}
+	// 基线是两端共同的状态：推送后为写出的内容，拉取后为远端的内容，本机未推送的修改下次推送时仍会带上
+	base := bundle.Entries
+	if !push {
+		base = remote.Entries
+	}
+	if err := metasync.SaveBase(basePath, base); err != nil {
+		return err
+	}
+	if push {
+		fmt.Println(r.template.Success(fmt.Sprintf("Pushed %d labels, tags, contacts and aliases (no key material)", bundle.Count())))
+	} else {
+		fmt.Println(r.template.Success(fmt.Sprintf("Metadata up to date, %d labels, tags, contacts and aliases", bundle.Count())))
+	}
+	return nil
+}
+
+// readMetadataBundle 读取远端或文件中的元数据包，不存在时返回 nil
+func (r *REPL) readMetadataBundle(ctx context.Context, backend cloudsync.Backend, file string) ([]byte, error) {
+	if backend == nil {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return data, err
+	}
+	data, err := backend.Get(ctx, metasync.ObjectName)
+	if errors.Is(err, cloudsync.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata bundle from %s: %w", backend.Name(), err)
+	}
+	return data, nil
+}
+
+// resolveMetaConflict 显示冲突条目两端的值，按 prefer 或用户输入决定是否采用远端的值
+func (r *REPL) resolveMetaConflict(conflict metasync.Conflict, prefer string) (bool, error) {
+	show := func(value *string) string {
+		if value == nil {
+			return "(deleted)"
+		}
+		return strconv.Quote(*value)
+	}
+	fmt.Println(r.template.Warning(fmt.Sprintf("Conflict in %s %s: local %s, remote %s", conflict.Kind, conflict.Key, show(conflict.Local), show(conflict.Remote))))
+	if prefer != "" {
+		return prefer == "remote", nil
+	}
+	answer, err := r.line.Prompt("Keep [l]ocal or take [r]emote? [L/r]: ")
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(answer), "r"), nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// mnemonicColumns 助记词每行显示的单词数
+const mnemonicColumns = 4
+
+// mnemonicClearAfter 助记词显示后自动清屏的时间
+func mnemonicClearAfter() time.Duration {
+	appConfig := config.GetAppConfig()
+	seconds := appConfig.GetWalletConfig().MnemonicClearSeconds
+	if seconds <= 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// showMnemonic 在独立的全屏视图中显示带编号的助记词，按键后才显示，倒计时结束后清屏，不进入终端回滚记录
+func (r *REPL) showMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	var lines []string
+	for i := 0; i < len(words); i += mnemonicColumns {
+		var line strings.Builder
+		for j := i; j < i+mnemonicColumns && j < len(words); j++ {
+			fmt.Fprintf(&line, "%2d. %-10s", j+1, words[j])
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+	return view.ShowSecret("Mnemonic Phrase", lines, []string{
+		"SAVE THIS MNEMONIC PHRASE IN A SECURE LOCATION!",
+		"It can be used to restore your wallet. Write it on paper, never photograph or copy it.",
+	}, mnemonicClearAfter())
+}
+
+// handleWalletMnemonic 输入钱包密码后再次显示助记词
+func (r *REPL) handleWalletMnemonic(args []string) error {
+	if len(args) > 0 {
+		return r.usageError("wallet.mnemonic")
+	}
+	// 先检查终端，避免在无法显示时还要求输入密码
+	if err := view.CheckSecretTerminal(); err != nil {
+		return err
+	}
+	password, err := r.passwordPrompt().Read("Wallet password: ")
+	if err != nil {
+		return err
+	}
+	mnemonic, err := r.walletMgr.ExportMnemonic(password)
+	if err != nil {
+		return err
+	}
+	err = r.showMnemonic(string(mnemonic.Bytes()))
+	mnemonic.Destroy()
+	if errors.Is(err, view.ErrRevealCancelled) {
+		fmt.Println(r.template.Info("Mnemonic not shown"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "wallet.mnemonic", "", "shown")
+	fmt.Println(r.template.Success("Mnemonic cleared from the screen"))
+	return nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/palagend/slowmade/internal/mockchain"
+)
+
+// 模拟链状态命令处理函数
+func (r *REPL) handleMockchainStatus(args []string) error {
+	chain := mockchain.Default()
+	height, err := chain.Height()
+	if err != nil {
+		return err
+	}
+	mempool, err := chain.Mempool()
+	if err != nil {
+		return err
+	}
+	fast, err := chain.FeeRate(1)
+	if err != nil {
+		return err
+	}
+	slow, err := chain.FeeRate(25)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Height:   %d\n", height)
+	fmt.Printf("Mempool:  %d transaction(s)\n", mempool)
+	fmt.Printf("Fee rate: %d sat/vB (next block), %d sat/vB (25 blocks)\n", fast, slow)
+	fmt.Printf("State:    %s\n", chain.Path())
+	return nil
+}
+
+// 模拟链出块命令处理函数，内存池中的交易在下一个区块确认
+func (r *REPL) handleMockchainMine(args []string) error {
+	blocks := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return r.usageError("mockchain.mine")
+		}
+		blocks = n
+	}
+	height, err := mockchain.Default().Mine(blocks)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Mined %d block(s), height is now %d", blocks, height)))
+	return nil
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/monero"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// moneroWallets 加载门罗币只读钱包列表
+func (r *REPL) moneroWallets() (*monero.ViewWallets, error) {
+	return monero.LoadViewWallets(filepath.Join(r.baseDir(), monero.ViewWalletsFileName))
+}
+
+// 门罗币只读导入命令处理函数：保存主地址和私有查看密钥（用钱包密码加密），只能查看收款，不能花费
+func (r *REPL) handleXMRImport(args []string) error {
+	usage := r.usageError("xmr.import")
+	if len(args) < 1 {
+		return usage
+	}
+	wallet := monero.ViewWallet{Address: args[0]}
+	keyText := ""
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--label" && i+1 < len(args):
+			i++
+			wallet.Label = args[i]
+		case args[i] == "--restore-height" && i+1 < len(args):
+			i++
+			height, err := strconv.ParseUint(args[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid restore height %q", args[i])
+			}
+			wallet.RestoreHeight = height
+		case args[i] == "--view-key" && i+1 < len(args):
+			i++
+			keyText = args[i]
+		default:
+			return usage
+		}
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	address, err := monero.ParseAddress(wallet.Address)
+	if err != nil {
+		return err
+	}
+	if address.Kind != monero.KindStandard {
+		return fmt.Errorf("%s is a %s, import the wallet's primary address (starting with 4 on mainnet)", wallet.Address, address.Kind)
+	}
+	wallet.Network = address.Network
+
+	if keyText == "" {
+		if keyText, err = r.passwordPrompt().Read("Private view key: "); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(r.template.Warning("The view key is in this session's command history, prefer entering it at the hidden prompt"))
+	}
+	viewKey, err := monero.ParseViewKey(keyText)
+	if err != nil {
+		return err
+	}
+	defer security.WipeSensitiveData(viewKey)
+	if err := monero.CheckViewKey(address, viewKey); err != nil {
+		return err
+	}
+	password, err := r.walletMgr.Password()
+	if err != nil {
+		return err
+	}
+	defer security.WipeSensitiveData(password)
+	if wallet.ViewKey, err = crypto.EncryptData(viewKey, string(password)); err != nil {
+		return fmt.Errorf("failed to encrypt view key: %v", err)
+	}
+	wallet.AddedAt = time.Now().UTC()
+
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return err
+	}
+	if err := wallets.Add(wallet); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Imported %s view-only wallet %s", wallet.Network, wallet.Address)))
+	fmt.Println(r.template.Warning("Watch-only: this wallet has no Monero spend key and cannot send XMR. " +
+		"A view-only wallet does not see spends, so its balance is everything ever received"))
+	return nil
+}
+
+// 门罗币只读钱包列表命令处理函数
+func (r *REPL) handleXMRList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("xmr.list")
+	}
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return err
+	}
+	list := wallets.List()
+	if len(list) == 0 {
+		fmt.Println("No Monero view-only wallets, import one with xmr.import <address>")
+		return nil
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%d Monero view-only wallets (watch-only, not spendable)", len(list))))
+	fmt.Printf("  %-16s %-9s %-14s %s\n", "LABEL", "NETWORK", "RESTORE FROM", "ADDRESS")
+	for _, wallet := range list {
+		fmt.Printf("  %-16s %-9s %-14d %s\n", wallet.Label, wallet.Network, wallet.RestoreHeight, wallet.Address)
+	}
+	return nil
+}
+
+// withMoneroWallet 在 wallet-rpc 中打开只读钱包（首次使用时创建）并扫描到最新区块后调用 fn
+func (r *REPL) withMoneroWallet(ref string, fn func(ctx context.Context, wallet monero.ViewWallet, rpc *monero.WalletRPC) error) error {
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return err
+	}
+	wallet, err := wallets.Lookup(ref)
+	if err != nil {
+		return err
+	}
+	password, err := r.walletMgr.Password()
+	if err != nil {
+		return err
+	}
+	viewKey, err := security.Decrypt(wallet.ViewKey, string(password))
+	security.WipeSensitiveData(password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt view key: %v", err)
+	}
+	defer viewKey.Destroy()
+
+	appConfig := config.GetAppConfig()
+	moneroConfig := appConfig.GetMoneroConfig()
+	rpc := monero.NewWalletRPC(moneroConfig.WalletRPCURL, moneroConfig.RPCUsername, moneroConfig.RPCPassword)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	if err := rpc.Open(ctx, wallet.WalletFile(), monero.FilePassword(viewKey.Bytes()), wallet.Address, viewKey.Bytes(), wallet.RestoreHeight); err != nil {
+		return fmt.Errorf("failed to open the view-only wallet on %s: %v", rpc.Name(), err)
+	}
+	defer rpc.Close(ctx)
+	if moneroConfig.DaemonURL != "" {
+		if err := rpc.SetDaemon(ctx, moneroConfig.DaemonURL); err != nil {
+			return fmt.Errorf("failed to set daemon %s: %v", moneroConfig.DaemonURL, err)
+		}
+	}
+	fmt.Println(r.template.Info("Scanning for incoming outputs, the first scan of an old wallet can take a while..."))
+	if _, err := rpc.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to scan via %s: %v", rpc.Name(), err)
+	}
+	return fn(ctx, wallet, rpc)
+}
+
+// 门罗币余额命令处理函数
+func (r *REPL) handleXMRBalance(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("xmr.balance")
+	}
+	return r.withMoneroWallet(args[0], func(ctx context.Context, wallet monero.ViewWallet, rpc *monero.WalletRPC) error {
+		balance, unlocked, err := rpc.Balance(ctx)
+		if err != nil {
+			return err
+		}
+		height, err := rpc.Height(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s (watch-only, scanned to block %d)\n", wallet.Address, height)
+		fmt.Printf("  Received: %s\n", r.format().Amount(balance, monero.Decimals, "XMR"))
+		if unlocked.Cmp(balance) != 0 {
+			fmt.Printf("  Unlocked: %s (outputs unlock after 10 blocks)\n", r.format().Amount(unlocked, monero.Decimals, "XMR"))
+		}
+		fmt.Println(r.template.Warning("Spends are not visible to a view-only wallet, the received total may exceed the real balance"))
+		return nil
+	})
+}
+
+// 门罗币收款记录命令处理函数
+func (r *REPL) handleXMRHistory(args []string) error {
+	usage := r.usageError("xmr.history")
+	if len(args) < 1 {
+		return usage
+	}
+	limit := 20
+	for i := 1; i < len(args); i++ {
+		if args[i] != "--limit" || i+1 >= len(args) {
+			return usage
+		}
+		i++
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid limit %q", args[i])
+		}
+		limit = n
+	}
+	return r.withMoneroWallet(args[0], func(ctx context.Context, wallet monero.ViewWallet, rpc *monero.WalletRPC) error {
+		transfers, err := rpc.Incoming(ctx)
+		if err != nil {
+			return err
+		}
+		if len(transfers) == 0 {
+			fmt.Printf("No incoming payments to %s since block %d\n", wallet.Address, wallet.RestoreHeight)
+			return nil
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("%d incoming payments (watch-only, outgoing transfers are not visible)", len(transfers))))
+		for _, transfer := range transfers[max(0, len(transfers)-limit):] {
+			amount, ok := new(big.Int).SetString(transfer.Amount.String(), 10)
+			if !ok {
+				amount = new(big.Int)
+			}
+			status := fmt.Sprintf("%d confirmations", transfer.Confirmations)
+			if transfer.Pending {
+				status = "in mempool"
+			} else if transfer.Locked {
+				status += ", locked"
+			}
+			line := fmt.Sprintf("  %s  %s  %s (%s)", r.format().Date(time.Unix(transfer.Timestamp, 0)),
+				r.format().Amount(amount, monero.Decimals, "XMR"), transfer.TxID, status)
+			if transfer.PaymentID != "" && strings.Trim(transfer.PaymentID, "0") != "" {
+				line += " payment id " + transfer.PaymentID
+			}
+			fmt.Println(line)
+		}
+		return nil
+	})
+}
+
+// 门罗币只读钱包删除命令处理函数，wallet-rpc 中的钱包文件保留
+func (r *REPL) handleXMRRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("xmr.remove")
+	}
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return err
+	}
+	wallet, err := wallets.Remove(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Removed view-only wallet %s", wallet.Address)))
+	fmt.Printf("The wallet file %s stays in monero-wallet-rpc's --wallet-dir, delete it there if no longer needed\n", wallet.WalletFile())
+	return nil
+}
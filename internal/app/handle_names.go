@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// nameTimeout 单次名称解析的超时
+const nameTimeout = 30 * time.Second
+
+// nameService 按配置创建名称解析服务，结果缓存在数据目录中
+func (r *REPL) nameService() *chain.NameService {
+	return chain.NewNameService(config.GetAppConfig(), filepath.Join(r.baseDir(), chain.NamesCacheFileName))
+}
+
+// resolveRecipient 将联系人名称或 ENS、SNS、Unstoppable Domains 名称解析为 coin 的地址，
+// 同时显示名称和解析出的地址；都不是时原样返回
+func (r *REPL) resolveRecipient(arg, coin string) (string, error) {
+	if address := r.resolveContact(arg); address != arg || !chain.LooksLikeName(arg) {
+		return address, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nameTimeout)
+	defer cancel()
+	result, err := r.nameService().Resolve(ctx, arg, coin)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %w", arg, err)
+	}
+	r.printResolution(result)
+	return result.Address, nil
+}
+
+func (r *REPL) printResolution(result *chain.Resolution) {
+	source := result.Resolver
+	if result.Cached {
+		source += ", cached " + r.format().Date(result.FetchedAt)
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%s -> %s (%s)", result.Name, result.Address, source)))
+	fmt.Println(r.template.Warning("Check the resolved address, names can be transferred or point to a new address at any time"))
+	if result.ThirdParty && !result.Cached {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Privacy: %s saw this name and your IP address", result.Resolver)))
+	}
+}
+
+// reverseName 签名确认时显示的 ETH 地址主名称，未启用、没有主名称或查询失败时返回空字符串
+func (r *REPL) reverseName(address string) string {
+	appConfig := config.GetAppConfig()
+	if !appConfig.GetNamesConfig().ReverseLookup {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nameTimeout)
+	defer cancel()
+	name, err := r.nameService().Lookup(ctx, "ETH", address)
+	if err != nil {
+		logging.Debugf("reverse lookup of %s failed: %v", address, err)
+		return ""
+	}
+	return name
+}
+
+// 名称解析命令处理函数，只显示结果，不发送
+func (r *REPL) handleNameResolve(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return r.usageError("name.resolve")
+	}
+	coin := "ETH"
+	switch {
+	case len(args) == 2:
+		coin = strings.ToUpper(args[1])
+	case strings.HasSuffix(strings.ToLower(args[0]), ".sol"):
+		coin = "SOL"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nameTimeout)
+	defer cancel()
+	result, err := r.nameService().Resolve(ctx, args[0], coin)
+	if err != nil {
+		return err
+	}
+	r.printResolution(result)
+	return nil
+}
+
+// 反查命令处理函数，显示 ETH 地址经正向验证的 ENS 主名称
+func (r *REPL) handleNameLookup(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("name.lookup")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nameTimeout)
+	defer cancel()
+	name, err := r.nameService().Lookup(ctx, "ETH", args[0])
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Printf("%s has no verified primary name\n", args[0])
+		return nil
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%s <- %s (ENS primary name, verified forward)", name, args[0])))
+	return nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// 网络统计命令处理函数，显示本次运行中各外部端点的调用、失败和熔断情况
+func (r *REPL) handleNetStats(args []string) error {
+	stats := resilience.Snapshot()
+	if len(stats) == 0 {
+		fmt.Println("No external calls yet")
+		return nil
+	}
+	appConfig := config.GetAppConfig()
+	threshold := appConfig.GetNetworkConfig().BreakerThreshold
+
+	fmt.Printf("  %-40s %7s %8s %7s %8s  %s\n", "ENDPOINT", "CALLS", "FAILURES", "RETRIES", "REJECTED", "STATE")
+	for _, s := range stats {
+		state := "ok"
+		if threshold > 0 && s.Consecutive >= threshold {
+			state = r.template.Error("open")
+		} else if s.Consecutive > 0 {
+			state = fmt.Sprintf("%d consecutive failures", s.Consecutive)
+		}
+		fmt.Printf("  %-40s %7d %8d %7d %8d  %s\n", s.Endpoint, s.Calls, s.Failures, s.Retries, s.Rejected, state)
+		if s.LastError != "" {
+			fmt.Printf("      last error %s: %s\n", r.format().Date(s.LastFailure), s.LastError)
+		}
+	}
+	return nil
+}
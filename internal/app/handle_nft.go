@@ -0,0 +1,348 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/nft"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// nftGasMargin 估算的 gas 用量上浮的百分比，接收方是合约时 onERC721Received 的开销可能有出入
+const nftGasMargin = 20
+
+// nftFetcher 按 [nft] 配置创建元数据读取器，fetch_metadata 关闭时返回 nil
+func nftFetcher() *nft.Fetcher {
+	appConfig := config.GetAppConfig()
+	nftConfig := appConfig.GetNFTConfig()
+	if !nftConfig.FetchMetadata {
+		return nil
+	}
+	return nft.NewFetcher(nftConfig.IPFSGateway)
+}
+
+// NFT 列表命令处理函数：从 ETH 后端的转账记录中找出收到过的代币，在链上核对仍持有的
+func (r *REPL) handleNFTList(args []string) error {
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "--no-metadata") {
+		return r.usageError("nft.list")
+	}
+	owner, err := r.coinAddress(args[0], "ETH")
+	if err != nil {
+		return err
+	}
+	appConfig := config.GetAppConfig()
+	source, err := chain.NewNFTSource(appConfig)
+	if err != nil {
+		return err
+	}
+	fetcher := nftFetcher()
+	if len(args) == 2 {
+		fetcher = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	transfers, err := source.NFTTransfers(ctx, owner.Address)
+	if err != nil {
+		return fmt.Errorf("failed to list NFT transfers from %s: %v", source.Name(), err)
+	}
+	tokens, err := nft.Inventory(ctx, source, owner.Address, nft.Candidates(owner.Address, transfers), fetcher)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		fmt.Printf("No NFTs held by %s\n", owner.Address)
+		return nil
+	}
+	fmt.Printf("%s holds %d NFT(s):\n", owner.Address, len(tokens))
+	for _, token := range tokens {
+		line := fmt.Sprintf("  %-42s %-8s #%s  %s", token.Contract, token.Standard, token.TokenID, token.DisplayName())
+		if token.Standard == nft.ERC1155 {
+			line += fmt.Sprintf("  x%s", token.Balance)
+		}
+		fmt.Println(line)
+		if token.Metadata != nil && token.Metadata.Image != "" {
+			fmt.Printf("  %-42s image: %s\n", "", token.Metadata.Image)
+		}
+	}
+	return nil
+}
+
+// NFT 详情命令处理函数：标准、集合名称、元数据链接、名称、描述和图片
+func (r *REPL) handleNFTShow(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return r.usageError("nft.show")
+	}
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("invalid contract address %q", args[0])
+	}
+	id, err := nft.ParseTokenID(args[1])
+	if err != nil {
+		return err
+	}
+	appConfig := config.GetAppConfig()
+	source, err := chain.NewNFTSource(appConfig)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	token, err := r.describeNFT(ctx, source, common.HexToAddress(args[0]).Hex(), id)
+	if err != nil {
+		return err
+	}
+	r.printNFT(token)
+	if len(args) == 3 {
+		owner, err := r.coinAddress(args[2], "ETH")
+		if err != nil {
+			return err
+		}
+		balance, err := nft.Balance(ctx, source, token.Standard, token.Contract, id, owner.Address)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  Held:       %s by %s\n", balance, owner.Address)
+	}
+	return nil
+}
+
+// describeNFT 识别合约标准并读取集合名称和元数据
+func (r *REPL) describeNFT(ctx context.Context, source chain.NFTSource, contract string, id *big.Int) (*nft.Token, error) {
+	standard, err := nft.Detect(ctx, source, contract)
+	if err != nil {
+		return nil, err
+	}
+	token := &nft.Token{Contract: contract, TokenID: id, Standard: standard, Collection: nft.CollectionName(ctx, source, contract)}
+	if fetcher := nftFetcher(); fetcher != nil {
+		nft.Describe(ctx, source, token, fetcher)
+	}
+	return token, nil
+}
+
+func (r *REPL) printNFT(token *nft.Token) {
+	collection := token.Contract
+	if token.Collection != "" {
+		collection = fmt.Sprintf("%s (%s)", token.Collection, token.Contract)
+	}
+	fmt.Printf("  Collection: %s\n", collection)
+	fmt.Printf("  Standard:   %s\n", token.Standard)
+	fmt.Printf("  Token ID:   %s\n", token.TokenID)
+	if token.Metadata != nil {
+		if token.Metadata.Name != "" {
+			fmt.Printf("  Name:       %s\n", token.Metadata.Name)
+		}
+		if token.Metadata.Description != "" {
+			about, _, _ := strings.Cut(token.Metadata.Description, "\n")
+			if runes := []rune(about); len(runes) > 120 {
+				about = string(runes[:120]) + "..."
+			}
+			fmt.Printf("  About:      %s\n", about)
+		}
+		if token.Metadata.Image != "" {
+			fmt.Printf("  Image:      %s\n", token.Metadata.Image)
+		}
+	}
+	if token.URI != "" && !strings.HasPrefix(token.URI, "data:") {
+		fmt.Printf("  Metadata:   %s\n", token.URI)
+	}
+}
+
+// NFT 转账命令处理函数：核对发送方持有该代币，展示集合和代币 ID 并要求输入代币 ID 确认后，
+// 签名 safeTransferFrom 交易；与其他 ETH 交易一样只签名不广播
+func (r *REPL) handleNFTSend(args []string) error {
+	usage := r.usageError("nft.send")
+	if len(args) < 4 {
+		return usage
+	}
+	amount := big.NewInt(1)
+	var (
+		nonce, gas                    *uint64
+		gasPrice, maxFee, priorityFee *big.Int
+		chainID                       *big.Int
+	)
+	for i := 4; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		value := args[i+1]
+		var err error
+		switch args[i] {
+		case "--amount":
+			var ok bool
+			if amount, ok = new(big.Int).SetString(value, 10); !ok || amount.Sign() <= 0 {
+				err = errors.New("must be a positive integer")
+			}
+		case "--nonce", "--gas":
+			var n uint64
+			if n, err = strconv.ParseUint(value, 10, 64); err == nil {
+				if args[i] == "--nonce" {
+					nonce = &n
+				} else {
+					gas = &n
+				}
+			}
+		case "--chain-id":
+			var ok bool
+			if chainID, ok = new(big.Int).SetString(value, 10); !ok || chainID.Sign() <= 0 {
+				err = errors.New("must be a positive integer")
+			}
+		case "--gas-price":
+			gasPrice, err = coin.ParseUnits(value, gweiDecimals)
+		case "--max-fee":
+			maxFee, err = coin.ParseUnits(value, gweiDecimals)
+		case "--priority-fee":
+			priorityFee, err = coin.ParseUnits(value, gweiDecimals)
+		default:
+			return usage
+		}
+		if err != nil {
+			return fmt.Errorf("无效的参数 %s: %s", args[i], value)
+		}
+		i++
+	}
+	if gasPrice != nil && (maxFee != nil || priorityFee != nil) {
+		return fmt.Errorf("use either --gas-price or --max-fee/--priority-fee")
+	}
+	if (maxFee == nil) != (priorityFee == nil) {
+		return fmt.Errorf("--max-fee and --priority-fee must be given together")
+	}
+
+	from, err := r.coinAddress(args[0], "ETH")
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(args[1]) {
+		return fmt.Errorf("invalid contract address %q", args[1])
+	}
+	contract := common.HexToAddress(args[1])
+	id, err := nft.ParseTokenID(args[2])
+	if err != nil {
+		return err
+	}
+	recipient, err := r.resolveRecipient(args[3], "ETH")
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(recipient) {
+		return fmt.Errorf("invalid ETH address %q", recipient)
+	}
+	to := common.HexToAddress(recipient)
+	if to == (common.Address{}) {
+		return fmt.Errorf("refusing to send to the zero address")
+	}
+
+	appConfig := config.GetAppConfig()
+	source, err := chain.NewNFTSource(appConfig)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	token, err := r.describeNFT(ctx, source, contract.Hex(), id)
+	if err != nil {
+		return err
+	}
+	data, err := nft.TransferData(token.Standard, common.HexToAddress(from.Address), to, id, amount)
+	if err != nil {
+		return err
+	}
+	balance, err := nft.Balance(ctx, source, token.Standard, token.Contract, id, from.Address)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: %s holds %s of token %s", nft.ErrNotOwner, from.Address, balance, id)
+	}
+
+	// 使用节点时补全未指定的 nonce、gas、手续费和链 ID
+	preparer, _ := source.(chain.TxPreparer)
+	if nonce == nil {
+		if preparer == nil {
+			return fmt.Errorf("--nonce is required without an rpc.eth node")
+		}
+		n, err := preparer.Nonce(ctx, from.Address)
+		if err != nil {
+			return fmt.Errorf("failed to fetch nonce from %s: %v", source.Name(), err)
+		}
+		nonce = &n
+	}
+	if gas == nil {
+		if preparer == nil {
+			return fmt.Errorf("--gas is required without an rpc.eth node")
+		}
+		estimate, err := preparer.EstimateGas(ctx, from.Address, contract.Hex(), data)
+		if err != nil {
+			return fmt.Errorf("failed to estimate gas with %s: %v", source.Name(), err)
+		}
+		estimate += estimate * nftGasMargin / 100
+		gas = &estimate
+	}
+	if gasPrice == nil && maxFee == nil {
+		if preparer == nil {
+			return fmt.Errorf("--gas-price or --max-fee/--priority-fee is required without an rpc.eth node")
+		}
+		if gasPrice, err = preparer.GasPrice(ctx); err != nil {
+			return fmt.Errorf("failed to fetch gas price from %s: %v", source.Name(), err)
+		}
+	}
+	if chainID == nil {
+		chainID = big.NewInt(1)
+		if preparer != nil {
+			if chainID, err = preparer.ChainID(ctx); err != nil {
+				return fmt.Errorf("failed to fetch chain ID from %s: %v", source.Name(), err)
+			}
+		}
+	}
+
+	// 额外确认：NFT 无法按金额核对，转错代币或集合的代价更高
+	fmt.Println(r.template.Info(fmt.Sprintf("Transfer %s", token.DisplayName())))
+	r.printNFT(token)
+	if token.Standard == nft.ERC1155 {
+		fmt.Printf("  Amount:     %s of %s held\n", amount, balance)
+	}
+	fmt.Printf("  From:       %s\n", from.Address)
+	target := to.Hex()
+	if target != args[3] {
+		target = fmt.Sprintf("%s (%s)", target, args[3])
+	}
+	if _, own := r.accountMgr.IsMine(to.Hex()); own {
+		target += " [mine]"
+	}
+	fmt.Printf("  To:         %s\n", target)
+	answer, err := r.line.Prompt(fmt.Sprintf("Type the token ID (%s) to confirm: ", id))
+	if err != nil || strings.TrimSpace(answer) != id.String() {
+		return fmt.Errorf("transfer cancelled")
+	}
+
+	input := hexutil.Bytes(data)
+	txArgs := &signer.TransactionArgs{From: common.HexToAddress(from.Address), To: &contract, Data: &input,
+		Nonce: (*hexutil.Uint64)(nonce), Gas: (*hexutil.Uint64)(gas), ChainID: (*hexutil.Big)(chainID)}
+	if gasPrice != nil {
+		txArgs.GasPrice = (*hexutil.Big)(gasPrice)
+	} else {
+		txArgs.MaxFeePerGas, txArgs.MaxPriorityFeePerGas = (*hexutil.Big)(maxFee), (*hexutil.Big)(priorityFee)
+	}
+	s, err := r.newSigner()
+	if err != nil {
+		return err
+	}
+	signed, err := s.SignTransaction(txArgs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Tx hash:    0x%x\n", crypto.Keccak256(signed))
+	fmt.Printf("  Raw:        0x%x\n", signed)
+	fmt.Println(r.template.Info("Transaction signed, broadcast the raw transaction with your node"))
+	return nil
+}
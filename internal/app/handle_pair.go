@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/lanpair"
+	"github.com/palagend/slowmade/internal/pubexport"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/qr"
+)
+
+var errPairingDisabled = errors.New("LAN pairing is disabled, set pairing.enabled = true in the config to use it")
+
+// watchOnlyExport pair.send watch-only 发送的内容
+type watchOnlyExport struct {
+	Wallet   string               `json:"wallet"`
+	Created  time.Time            `json:"created_at"`
+	Accounts []*pubexport.Account `json:"accounts"`
+}
+
+// pairingSession 检查开关，返回等待手机连接的上下文：超过 pairing.timeout 或按下 Ctrl+C 时结束
+func (r *REPL) pairingSession() (config.PairingConfig, context.Context, context.CancelFunc, error) {
+	pairingConfig := config.GetAppConfig().Pairing
+	if !pairingConfig.Enabled {
+		return pairingConfig, nil, nil, errPairingDisabled
+	}
+	if r.walletMgr.IsLocked() {
+		return pairingConfig, nil, nil, fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	timeout := time.Duration(max(pairingConfig.Timeout, 10)) * time.Second
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return pairingConfig, ctx, func() { cancel(); stop() }, nil
+}
+
+// showOffer 显示会话二维码和手动输入用的地址与指纹
+func (r *REPL) showOffer(offer lanpair.Offer, timeout int) error {
+	code, err := qr.Encode([]byte(offer.URI()), qr.LevelM)
+	if err != nil {
+		return err
+	}
+	fmt.Print(view.QR(code))
+	fmt.Printf("Address:     %s\n", offer.Address)
+	fmt.Printf("Fingerprint: %s\n", offer.Fingerprint)
+	fmt.Printf("Or paste:    %s\n", offer.URI())
+	fmt.Println(r.template.Info(fmt.Sprintf("Scan with the companion app on the same network; waiting up to %d seconds, Ctrl+C to cancel", max(timeout, 10))))
+	return nil
+}
+
+// 配对命令处理函数：显示带临时证书指纹和一次性密钥的二维码，手机连接后比对确认码再登记
+func (r *REPL) handlePairNew(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("pair.new")
+	}
+	pairingConfig, ctx, cancel, err := r.pairingSession()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	devices, err := lanpair.LoadDevices(r.baseDir())
+	if err != nil {
+		return err
+	}
+
+	pairing, err := lanpair.NewPairing(pairingConfig.Listen, devices)
+	if err != nil {
+		return err
+	}
+	defer pairing.Close()
+	if err := r.showOffer(pairing.Offer(), pairingConfig.Timeout); err != nil {
+		return err
+	}
+
+	device, err := pairing.Wait(ctx, func(name, code string) bool {
+		fmt.Printf("Device %q connected, confirmation code: %s\n", name, code)
+		answer, err := r.line.Prompt("Does the phone show the same code? [y/N]: ")
+		return err == nil && strings.EqualFold(strings.TrimSpace(answer), "y")
+	})
+	if err != nil {
+		return fmt.Errorf("pairing failed: %w", err)
+	}
+	if err := lanpair.SaveDevices(r.baseDir(), append(devices, *device)); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Paired %s (%s)", device.Name, device.ID())))
+	return nil
+}
+
+// 配对设备列表命令处理函数
+func (r *REPL) handlePairList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("pair.list")
+	}
+	devices, err := lanpair.LoadDevices(r.baseDir())
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Println("No paired devices, pair one with pair.new")
+		return nil
+	}
+	format := r.format()
+	fmt.Printf("%-8s  %-24s  %-20s  %s\n", "ID", "NAME", "PAIRED", "LAST SENT")
+	for _, device := range devices {
+		lastSent := "-"
+		if device.LastSent != nil {
+			lastSent = fmt.Sprintf("%s (%s)", format.Date(*device.LastSent), device.LastKind)
+		}
+		fmt.Printf("%-8s  %-24s  %-20s  %s\n", device.ID(), device.Name, format.Date(device.PairedAt), lastSent)
+	}
+	return nil
+}
+
+// 取消配对命令处理函数，之后该设备的证书不能再连接
+func (r *REPL) handlePairRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("pair.remove")
+	}
+	devices, err := lanpair.LoadDevices(r.baseDir())
+	if err != nil {
+		return err
+	}
+	i, err := lanpair.FindDevice(devices, args[0])
+	if err != nil {
+		return err
+	}
+	removed := devices[i]
+	if err := lanpair.SaveDevices(r.baseDir(), append(devices[:i], devices[i+1:]...)); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Removed %s (%s)", removed.Name, removed.ID())))
+	return nil
+}
+
+// 发送命令处理函数：向配对过的设备发送加密备份包或只读描述符，设备扫描二维码后直接连接取走
+func (r *REPL) handlePairSend(args []string) error {
+	if len(args) != 2 {
+		return r.usageError("pair.send")
+	}
+	kind := lanpair.Kind(args[1])
+	if kind != lanpair.KindBackup && kind != lanpair.KindWatchOnly {
+		return r.usageError("pair.send")
+	}
+	pairingConfig, ctx, cancel, err := r.pairingSession()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	devices, err := lanpair.LoadDevices(r.baseDir())
+	if err != nil {
+		return err
+	}
+	i, err := lanpair.FindDevice(devices, args[0])
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	contentType := "application/json"
+	if kind == lanpair.KindBackup {
+		if payload, err = r.sealedBackup(); err != nil {
+			return err
+		}
+		contentType = "application/octet-stream"
+		fmt.Println(r.template.Warning("The bundle is encrypted with your current wallet password; you will need it to restore"))
+	} else if payload, err = r.watchOnlyPayload(); err != nil {
+		return err
+	}
+
+	transfer, err := lanpair.NewTransfer(pairingConfig.Listen, devices[i], kind, payload, contentType)
+	if err != nil {
+		return err
+	}
+	defer transfer.Close()
+	fmt.Printf("Sending %s (%s) to %s\n", kind, r.format().Size(int64(len(payload))), devices[i].Name)
+	if err := r.showOffer(transfer.Offer(), pairingConfig.Timeout); err != nil {
+		return err
+	}
+	if err := transfer.Wait(ctx); err != nil {
+		return fmt.Errorf("sending failed: %w", err)
+	}
+
+	now := time.Now().UTC()
+	devices[i].LastSent, devices[i].LastKind = &now, kind
+	if err := lanpair.SaveDevices(r.baseDir(), devices); err != nil {
+		return err
+	}
+	if kind == lanpair.KindBackup {
+		r.recordBackup("pair.send")
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Sent %s to %s", kind, devices[i].Name)))
+	return nil
+}
+
+// sealedBackup 与 backup.qr 相同的加密备份包
+func (r *REPL) sealedBackup() ([]byte, error) {
+	bundle, err := backup.Collect(r.baseDir())
+	if err != nil {
+		return nil, err
+	}
+	password, err := security.Password()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(password)
+	return bundle.Seal(string(password))
+}
+
+// watchOnlyPayload 全部账户的扩展公钥和描述符，发送前用导出扫描器检查
+func (r *REPL) watchOnlyPayload() ([]byte, error) {
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].CoinSymbol != accounts[j].CoinSymbol {
+			return accounts[i].CoinSymbol < accounts[j].CoinSymbol
+		}
+		return accounts[i].DerivationPath < accounts[j].DerivationPath
+	})
+	export := watchOnlyExport{Wallet: "slowmade", Created: time.Now().UTC()}
+	for _, account := range accounts {
+		described, err := pubexport.Describe(r.accountMgr, account)
+		if err != nil {
+			return nil, err
+		}
+		export.Accounts = append(export.Accounts, described)
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if findings := pubexport.Scan("watch-only.json", data, nil); len(findings) > 0 {
+		return nil, fmt.Errorf("%w, nothing was sent: %s", pubexport.ErrSecretFound, findings[0])
+	}
+	return data, nil
+}
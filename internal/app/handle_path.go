@@ -0,0 +1,136 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// purposeNames 常见 purpose 对应的标准
+var purposeNames = map[uint32]string{
+	44: "BIP44 (legacy P2PKH / account-based chains)",
+	49: "BIP49 (P2SH-wrapped SegWit)",
+	84: "BIP84 (native SegWit)",
+	86: "BIP86 (Taproot)",
+}
+
+// handlePathExplain 解析派生路径的每个组成部分，并关联已存储的账户和地址
+func (r *REPL) handlePathExplain(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("path.explain")
+	}
+
+	dp, err := core.ParseDerivationPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	purpose := dp.Purpose &^ coin.HardenedBit
+	purposeName, ok := purposeNames[purpose]
+	if !ok {
+		purposeName = "non-standard purpose"
+	}
+	symbol := coin.CoinSymbol(dp.CoinType)
+	if symbol == "" {
+		symbol = "unregistered"
+	}
+
+	fmt.Println(r.template.Info(fmt.Sprintf("Derivation path %s (depth %d)", dp.String(), dp.Depth())))
+	fmt.Printf("  %-10s %-8s %-9s %s\n", "LEVEL", "VALUE", "HARDENED", "MEANING")
+	for i, component := range dp.Components() {
+		switch i {
+		case 0:
+			printPathComponent("purpose", component, purposeName)
+		case 1:
+			printPathComponent("coin_type", component, symbol)
+		case 2:
+			printPathComponent("account", component, fmt.Sprintf("account #%d", component&^coin.HardenedBit))
+		case 3:
+			if dp.Depth() == 4 && core.PathRuleFor(dp.CoinType).FlatIndex {
+				printPathComponent("index", component, fmt.Sprintf("address #%d (mew convention, no change level)", component&^coin.HardenedBit))
+				break
+			}
+			branch := "receive (external chain)"
+			if component&^coin.HardenedBit == 1 {
+				branch = "change (internal chain)"
+			}
+			printPathComponent("change", component, branch)
+		case 4:
+			printPathComponent("index", component, fmt.Sprintf("address #%d", component&^coin.HardenedBit))
+		default:
+			printPathComponent(fmt.Sprintf("level %d", i), component, "non-BIP44 level")
+		}
+	}
+
+	// 路径在账户层级（m/purpose'/coin'/account'）对应存储中的账户
+	accountPath := dp.MaskSuffix().String()
+	accountID := r.accountMgr.IDString(accountPath)
+	fmt.Printf("\n  Account path: %s\n", accountPath)
+	fmt.Printf("  Account ID:   %s\n", accountID)
+
+	if r.walletMgr.IsLocked() {
+		fmt.Println(r.template.Warning("Wallet is locked, unlock it to look up stored accounts"))
+		return nil
+	}
+
+	accounts, err := r.accountMgr.GetAccountsByCoin(dp.CoinType)
+	if err != nil {
+		return err
+	}
+	var found *core.CoinAccount
+	for _, account := range accounts {
+		if account.ID == accountID {
+			found = account
+			break
+		}
+	}
+	// 按 ledger-live 或 mew 约定创建的账户 ID 不同，按账户密钥路径和约定的地址层级匹配
+	if found == nil {
+		found = conventionAccount(accounts, dp)
+	}
+	if found == nil {
+		fmt.Println(r.template.Warning("No stored account matches this path, create it with account.create"))
+		return nil
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Falls under stored %s account %s (%s convention)", found.CoinSymbol, found.ID, found.Convention())))
+
+	addresses, err := r.accountMgr.GetAddresses(found.ID)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addresses {
+		if addressPath, err := found.AddressPath(addr.ChangeType, addr.AddressIndex); err == nil && addressPath.String() == dp.String() {
+			fmt.Println(r.template.Success(fmt.Sprintf("Address already derived: %s", addr.Address)))
+			return nil
+		}
+	}
+	fmt.Println(r.template.Info("Address at this index has not been derived yet"))
+	return nil
+}
+
+func printPathComponent(level string, value uint32, meaning string) {
+	hardened := "no"
+	if coin.IsHardened(value) {
+		hardened = "yes"
+	}
+	fmt.Printf("  %-10s %-8d %-9s %s\n", level, value&^coin.HardenedBit, hardened, meaning)
+}
+
+// conventionAccount 返回账户密钥路径相同、且约定的地址层级数与路径相符的非标准约定账户
+func conventionAccount(accounts []*core.CoinAccount, dp *core.DerivationPath) *core.CoinAccount {
+	prefix := core.NewDerivationPath(dp.HardenedPrefix()).String()
+	for _, account := range accounts {
+		if account.Convention() == core.ConventionStandard {
+			continue
+		}
+		path, err := account.Path()
+		if err != nil || core.NewDerivationPath(path.HardenedPrefix()).String() != prefix {
+			continue
+		}
+		if first, err := account.AddressPath(0, 0); err == nil && (first.Depth() == dp.Depth() || dp.Depth() == len(path.HardenedPrefix())) {
+			return account
+		}
+	}
+	return nil
+}
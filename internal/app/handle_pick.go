@@ -0,0 +1,165 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// pick 用方向键选择列表项，标准输入不是终端时改为输入编号
+func (r *REPL) pick(title string, items []string) (int, error) {
+	start := time.Now()
+	index, err := view.Pick(title, items)
+	r.line.waited += time.Since(start)
+	if !errors.Is(err, view.ErrNotTerminal) {
+		return index, err
+	}
+	fmt.Println(title)
+	for i, item := range items {
+		fmt.Printf("  %2d) %s\n", i+1, item)
+	}
+	answer, err := r.line.Prompt(fmt.Sprintf("Select 1-%d: ", len(items)))
+	if err != nil {
+		return -1, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(items) {
+		return -1, view.ErrPickCancelled
+	}
+	return n - 1, nil
+}
+
+// accountName 返回账户的别名或标签，都没有时返回缩短的账户 ID
+func accountName(store *metadata.Store, accountID string) string {
+	for _, alias := range store.Keys(metadata.Aliases) {
+		if target, _ := store.Get(metadata.Aliases, alias); target == accountID {
+			return alias
+		}
+	}
+	if label, ok := store.Get(metadata.Labels, accountID); ok {
+		return label
+	}
+	return core.ShortIDs([]string{accountID})[accountID]
+}
+
+// sendInteractive 引导式发送：选择来源账户和地址（显示缓存的余额），再输入收款方和金额，
+// 最后按选择结果调用 btc.send
+func (r *REPL) sendInteractive() error {
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accounts, err := r.accountMgr.GetAccountsByCoin(coin.CoinTypeBTC | coin.HardenedBit)
+	if err != nil {
+		return err
+	}
+	meta, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	utxoStore, err := btc.LoadUTXOStore(filepath.Join(r.baseDir(), btc.UTXOFileName))
+	if err != nil {
+		return err
+	}
+
+	var (
+		candidates []*core.CoinAccount
+		addressMap = make(map[string][]*core.AddressKey)
+		items      []string
+	)
+	for _, account := range accounts {
+		if _, archived := meta.Get(metadata.Archived, account.ID); archived {
+			continue
+		}
+		addresses, err := r.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return err
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+		total := int64(0)
+		for _, u := range utxoStore.Unspent(addressStrings(addresses)) {
+			total += u.Value
+		}
+		candidates = append(candidates, account)
+		addressMap[account.ID] = addresses
+		items = append(items, fmt.Sprintf("%-16s %-16s %16s BTC", accountName(meta, account.ID), account.DerivationPath,
+			r.format().Decimal(btc.FormatBTC(total))))
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no BTC account with derived addresses")
+	}
+	index, err := r.pick("Send from which account? (cached balances)", items)
+	if err != nil {
+		return err
+	}
+	account := candidates[index]
+	addresses := addressMap[account.ID]
+
+	// 第二步：自动币选择，或只花费某个地址上的 UTXO
+	byAddress := make(map[string][]btc.UTXO)
+	var funded []string
+	for _, u := range utxoStore.Unspent(addressStrings(addresses)) {
+		if len(byAddress[u.Address]) == 0 {
+			funded = append(funded, u.Address)
+		}
+		byAddress[u.Address] = append(byAddress[u.Address], u)
+	}
+	items = []string{"All addresses (automatic coin selection)"}
+	for _, address := range funded {
+		total := int64(0)
+		for _, u := range byAddress[address] {
+			total += u.Value
+		}
+		label, _ := meta.Get(metadata.Labels, address)
+		items = append(items, fmt.Sprintf("%-62s %16s BTC  %d UTXOs  %s", address, r.format().Decimal(btc.FormatBTC(total)), len(byAddress[address]), label))
+	}
+	var fromUTXOs []string
+	if len(items) > 1 {
+		if index, err = r.pick("Spend from which addresses?", items); err != nil {
+			return err
+		}
+		if index > 0 {
+			for _, u := range byAddress[funded[index-1]] {
+				fromUTXOs = append(fromUTXOs, "--from-utxo", u.Outpoint())
+			}
+		}
+	}
+
+	recipient, err := r.line.Prompt("Recipient address, contact, name or bitcoin: URI: ")
+	if err != nil {
+		return err
+	}
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return fmt.Errorf("send cancelled")
+	}
+	args := []string{account.ID, recipient}
+	// URI 已经给出金额时不再询问
+	if payment, err := payreq.ParseURI(recipient); err != nil || payment.Amount == nil {
+		amount, err := r.line.Prompt("Amount (BTC): ")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(amount) == "" {
+			return fmt.Errorf("send cancelled")
+		}
+		args = append(args, strings.TrimSpace(amount))
+	}
+	args = append(args, fromUTXOs...)
+	answer, err := r.line.Prompt("Broadcast after signing? [y/N]: ")
+	if err == nil && strings.EqualFold(strings.TrimSpace(answer), "y") {
+		args = append(args, "--broadcast")
+	}
+	return r.handleBTCSend(args)
+}
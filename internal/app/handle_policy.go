@@ -0,0 +1,160 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+)
+
+// policyDir 签名策略脚本目录
+func (r *REPL) policyDir() string {
+	return filepath.Join(r.baseDir(), policy.DirName)
+}
+
+// confirmPolicy 策略要求额外确认、签名请求偏离历史习惯或手续费离谱时，需要输入 confirm
+func (r *REPL) confirmPolicy(reason string, anomalies []string, fee string) bool {
+	if reason != "" {
+		fmt.Println(r.template.Warning("Signing policy requires confirmation: " + reason))
+	}
+	for _, anomaly := range anomalies {
+		fmt.Println(r.template.Warning("Unusual signing request: " + anomaly))
+	}
+	if fee != "" {
+		fmt.Println(r.template.Warning("Check the fee: " + fee))
+	}
+	answer, err := r.line.Prompt(`Type "confirm" to sign: `)
+	return err == nil && strings.TrimSpace(answer) == "confirm"
+}
+
+// checkBTCPolicy 对交易的每个输出执行签名策略，被拒绝时返回 policy.ErrDenied；
+// 策略要求额外确认、anomalies 或 fee 非空时未通过确认返回 signer.ErrRejected
+func (r *REPL) checkBTCPolicy(outputs []btc.TxOut, anomalies []string, fee string) error {
+	inputs := make([]policy.Input, 0, len(outputs))
+	for _, output := range outputs {
+		address := decoder.ScriptAddress(output.Script)
+		_, own := r.accountMgr.IsMine(address)
+		inputs = append(inputs, policy.Input{
+			Method:      "btc_signTransaction",
+			Coin:        "BTC",
+			Destination: address,
+			Amount:      new(big.Rat).SetFrac64(output.Value, 1e8),
+			Own:         own,
+		})
+	}
+	result, err := policy.Check(r.policyDir(), inputs...)
+	if err != nil {
+		return err
+	}
+	reason := ""
+	if result.Decision == policy.Confirm {
+		reason = result.Reason()
+	}
+	if (reason != "" || len(anomalies) > 0 || fee != "") && !r.confirmPolicy(reason, anomalies, fee) {
+		return signer.ErrRejected
+	}
+	return nil
+}
+
+// 策略列表命令处理函数，按求值顺序列出规则，脚本有语法错误时报告位置
+func (r *REPL) handlePolicyList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("policy.list")
+	}
+	engine, err := policy.Load(r.policyDir())
+	if err != nil {
+		return err
+	}
+	if len(engine.Rules) == 0 {
+		fmt.Printf("No policy rules in %s, every request goes through the normal confirmation\n", r.policyDir())
+		fmt.Println("Rules are lines of `allow|confirm|deny <expression>` in *.policy files; variables:")
+		for _, v := range policy.Variables {
+			fmt.Printf("  %-12s %s\n", v.Name, v.Description)
+		}
+		return nil
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%d rules from %s, first match wins", len(engine.Rules), r.policyDir())))
+	for _, rule := range engine.Rules {
+		fmt.Printf("  %-20s %-8s %s\n", rule.Source, rule.Action, rule.Text)
+	}
+	return nil
+}
+
+// 策略测试命令处理函数，用给定的请求内容求值策略，不签名
+func (r *REPL) handlePolicyTest(args []string) error {
+	usage := r.usageError("policy.test")
+	var positional []string
+	in := policy.Input{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--method" && i+1 < len(args):
+			i++
+			in.Method = args[i]
+		case args[i] == "--origin" && i+1 < len(args):
+			i++
+			in.Origin = args[i]
+		case args[i] == "--at" && i+1 < len(args):
+			i++
+			at, err := time.ParseInLocation("2006-01-02T15:04", args[i], time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid time %q, expected YYYY-MM-DDTHH:MM", args[i])
+			}
+			in.Time = at
+		case strings.HasPrefix(args[i], "--"):
+			return usage
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 3 {
+		return usage
+	}
+	in.Coin = strings.ToUpper(positional[0])
+	in.Destination = positional[1]
+	amount, ok := new(big.Rat).SetString(positional[2])
+	if !ok || amount.Sign() < 0 {
+		return fmt.Errorf("invalid amount %q", positional[2])
+	}
+	in.Amount = amount
+	if in.Method == "" {
+		switch in.Coin {
+		case "BTC":
+			in.Method = "btc_signTransaction"
+		case "TRX":
+			in.Method = "trx_signTransaction"
+		case "DOT", "KSM", "XLM", "XRP":
+			in.Method = strings.ToLower(in.Coin) + "_signTransaction"
+		default:
+			in.Method = "eth_signTransaction"
+			appConfig := config.GetAppConfig()
+			for _, chain := range appConfig.GetCosmosConfig().Chains {
+				if strings.EqualFold(chain.Symbol, in.Coin) {
+					in.Method = "cosmos_signTransaction"
+				}
+			}
+		}
+	}
+	_, in.Own = r.accountMgr.IsMine(in.Destination)
+
+	engine, err := policy.Load(r.policyDir())
+	if err != nil {
+		return err
+	}
+	result := engine.Evaluate(in)
+	switch result.Decision {
+	case policy.Deny:
+		fmt.Println(r.template.Error("deny: " + result.Reason()))
+	case policy.Confirm:
+		fmt.Println(r.template.Warning("confirm: " + result.Reason()))
+	default:
+		fmt.Println(r.template.Success("allow: " + result.Reason()))
+	}
+	return nil
+}
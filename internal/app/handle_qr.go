@@ -0,0 +1,407 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/qr"
+)
+
+// qrLevels 纠错等级参数
+var qrLevels = map[string]qr.Level{"L": qr.LevelL, "M": qr.LevelM, "Q": qr.LevelQ, "H": qr.LevelH}
+
+// 二维码导出命令处理函数，将任意文本（地址、支付 URI 等）编码为二维码，
+// 写入 PNG 或 SVG 文件时可以指定颜色、透明背景、中心标志和尺寸预设
+func (r *REPL) handleQRExport(args []string) error {
+	usage := r.usageError("qr.export")
+	var (
+		text, out, logoFile string
+		size                = "medium"
+		level               = qr.LevelM
+		style               qr.Style
+	)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			if text != "" {
+				return usage
+			}
+			text = args[i]
+			continue
+		}
+		if i+1 >= len(args) {
+			return usage
+		}
+		i++
+		switch args[i-1] {
+		case "--out":
+			out = args[i]
+		case "--fg", "--bg":
+			c, err := qr.ParseColor(args[i])
+			if err != nil {
+				return err
+			}
+			if args[i-1] == "--fg" {
+				style.Foreground = c
+			} else {
+				style.Background = c
+			}
+		case "--logo":
+			logoFile = args[i]
+		case "--size":
+			size = args[i]
+		case "--level":
+			l, ok := qrLevels[strings.ToUpper(args[i])]
+			if !ok {
+				return fmt.Errorf("invalid error correction level %q (L, M, Q or H)", args[i])
+			}
+			level = l
+		default:
+			return usage
+		}
+	}
+	if text == "" {
+		return usage
+	}
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(out), "."))
+	if out == "" && (logoFile != "" || style.Foreground != nil || style.Background != nil) {
+		return fmt.Errorf("--fg, --bg and --logo need --out <file.png|file.svg>")
+	}
+	if out != "" && format != "png" && format != "svg" {
+		return fmt.Errorf("unsupported output %q, use a .png or .svg file", out)
+	}
+	if err := style.Check(); err != nil {
+		return err
+	}
+	if logoFile != "" {
+		logo, err := loadImage(logoFile)
+		if err != nil {
+			return err
+		}
+		style.Logo = logo
+	}
+	bumped := qr.LevelFor(level, style.Logo != nil)
+
+	code, err := qr.Encode([]byte(text), bumped)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		fmt.Print(view.QR(code))
+		return nil
+	}
+	scale, err := code.ScaleFor(size)
+	if err != nil {
+		return err
+	}
+	if err := writeQRImage(out, format, code, scale, style); err != nil {
+		return err
+	}
+	side := (code.Size + 2*qr.QuietZone) * scale
+	fmt.Println(r.template.Success(fmt.Sprintf("Wrote %s (%dx%d, version %d)", out, side, side, code.Version)))
+	if bumped != level {
+		fmt.Println(r.template.Info("Used error correction level H so the code stays readable under the logo"))
+	}
+	return nil
+}
+
+// loadImage 读取 PNG 或 JPEG 图片
+func loadImage(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("读取图片 %s 失败: %w", name, err)
+	}
+	return img, nil
+}
+
+// qrBatchLimit qr.batch 一次最多生成的地址数
+const qrBatchLimit = 1000
+
+// qrContentMode 收款二维码的内容格式：--content 参数，省略时使用 ui.qr_content
+func qrContentMode(flag string) (string, error) {
+	mode := strings.ToLower(flag)
+	if mode == "" {
+		appConfig := config.GetAppConfig()
+		mode = strings.ToLower(appConfig.GetUIConfig().QRContent)
+	}
+	if mode == "" {
+		mode = payreq.ContentAuto
+	}
+	return mode, payreq.CheckContent(mode)
+}
+
+// qrCard 收款卡片页面中的一张卡片
+type qrCard struct {
+	Index   uint32
+	Address string
+	Label   string
+	Content string // 二维码中编码的文本
+	File    string // 相对 index.html 的图片文件名
+}
+
+// qrSheet 收款卡片页面模板的数据
+type qrSheet struct {
+	AccountID string
+	Label     string
+	Coin      string
+	Generated time.Time
+	Cards     []qrCard
+}
+
+// qrSheetTemplate 默认的收款卡片页面，每张卡片包含二维码、标签和地址，适合直接打印
+const qrSheetTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Coin}} deposit addresses{{with .Label}} - {{.}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 1cm; }
+.sheet { display: grid; grid-template-columns: repeat(auto-fill, minmax(6cm, 1fr)); gap: 0.5cm; }
+.card { border: 1px dashed #999; padding: 0.4cm; text-align: center; break-inside: avoid; }
+.card img { width: 5cm; height: 5cm; }
+.label { font-weight: bold; margin-top: 0.2cm; }
+.address { font-family: monospace; font-size: 8pt; word-break: break-all; }
+@media print { h1, .meta { display: none; } .card { border-color: #ccc; } }
+</style>
+</head>
+<body>
+<h1>{{.Coin}} deposit addresses{{with .Label}} - {{.}}{{end}}</h1>
+<p class="meta">Account {{.AccountID}}, {{len .Cards}} addresses, generated {{date .Generated}}</p>
+<div class="sheet">
+{{range .Cards}}<div class="card">
+<img src="{{.File}}" alt="{{.Address}}">
+<div class="label">{{with .Label}}{{.}}{{else}}#{{.Index}}{{end}}</div>
+<div class="address">{{.Address}}</div>
+</div>
+{{end}}</div>
+</body>
+</html>
+`
+
+// 批量二维码命令处理函数，为账户的前 N 个收款地址各生成一个二维码图片，并生成可打印的 index.html
+func (r *REPL) handleQRBatch(args []string) error {
+	usage := r.usageError("qr.batch")
+	var (
+		accountArg, out, templateFile string
+		contentFlag                   string
+		count, start                  int
+		size                          = "medium"
+		format                        = "png"
+	)
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		var err error
+		switch args[i] {
+		case "--account":
+			accountArg = args[i+1]
+		case "--count":
+			count, err = strconv.Atoi(args[i+1])
+		case "--start":
+			start, err = strconv.Atoi(args[i+1])
+		case "--out":
+			out = args[i+1]
+		case "--size":
+			size = args[i+1]
+		case "--format":
+			format = strings.ToLower(args[i+1])
+		case "--template":
+			templateFile = args[i+1]
+		case "--content":
+			contentFlag = args[i+1]
+		default:
+			return usage
+		}
+		if err != nil {
+			return fmt.Errorf("无效的参数 %s: %s", args[i], args[i+1])
+		}
+		i++
+	}
+	if accountArg == "" || out == "" || count <= 0 || start < 0 {
+		return usage
+	}
+	if count > qrBatchLimit {
+		return fmt.Errorf("--count is limited to %d addresses", qrBatchLimit)
+	}
+	if format != "png" && format != "svg" {
+		return fmt.Errorf("unsupported format %q, use png or svg", format)
+	}
+	if _, ok := qr.Sizes[strings.ToLower(size)]; !ok {
+		return fmt.Errorf("%w %q, expected one of %s", qr.ErrUnknownSize, size, strings.Join(qr.SizeNames, ", "))
+	}
+	mode, err := qrContentMode(contentFlag)
+	if err != nil {
+		return err
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(accountArg)
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if mode == payreq.ContentURI && !payreq.Supported(account.CoinSymbol) {
+		return fmt.Errorf("%w %s", payreq.ErrUnsupportedCoin, account.CoinSymbol)
+	}
+
+	sheetTemplate := qrSheetTemplate
+	if templateFile != "" {
+		text, err := os.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		sheetTemplate = string(text)
+	}
+	tmpl, err := htmltemplate.New("index.html").Funcs(htmltemplate.FuncMap(r.format().FuncMap())).Parse(sheetTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+
+	addresses, derived, err := r.batchAddresses(accountID, uint32(start), uint32(count))
+	if err != nil {
+		return err
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0700); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	accountLabel, _ := store.Get(metadata.Labels, account.ID)
+	sheet := qrSheet{AccountID: account.ID, Label: accountLabel, Coin: account.CoinSymbol, Generated: time.Now()}
+	for _, addr := range addresses {
+		label, _ := store.Get(metadata.Labels, addr.Address)
+		card := qrCard{Index: addr.AddressIndex, Address: addr.Address, Label: label,
+			File: qrCardFileName(addr.AddressIndex, label, format)}
+		receive := &payreq.Receive{Coin: account.CoinSymbol, Address: addr.Address, Label: label}
+		if card.Content, err = receive.Content(mode); err != nil {
+			return err
+		}
+		code, err := qr.Encode([]byte(card.Content), qr.LevelM)
+		if err != nil {
+			return err
+		}
+		scale, err := code.ScaleFor(size)
+		if err != nil {
+			return err
+		}
+		if err := writeQRImage(filepath.Join(out, card.File), format, code, scale, qr.Style{}); err != nil {
+			return err
+		}
+		sheet.Cards = append(sheet.Cards, card)
+	}
+
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, sheet); err != nil {
+		return fmt.Errorf("template failed: %v", err)
+	}
+	index := filepath.Join(out, "index.html")
+	if err := os.WriteFile(index, page.Bytes(), 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", index, err)
+	}
+	if derived > 0 {
+		fmt.Println(r.template.Info(fmt.Sprintf("Derived %d new receive addresses", derived)))
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Wrote %d QR codes and %s", len(sheet.Cards), index)))
+	return nil
+}
+
+// batchAddresses 返回收款链上 start 起 count 个地址，已保存的直接使用，缺少的在一次事务中派生
+func (r *REPL) batchAddresses(accountID string, start, count uint32) ([]*core.AddressKey, int, error) {
+	existing, err := r.accountMgr.GetAddresses(accountID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	byIndex := make(map[uint32]*core.AddressKey)
+	for _, addr := range existing {
+		if addr.ChangeType == 0 {
+			byIndex[addr.AddressIndex] = addr
+		}
+	}
+	first, last := -1, -1
+	for index := start; index < start+count; index++ {
+		if byIndex[index] == nil {
+			if first < 0 {
+				first = int(index)
+			}
+			last = int(index)
+		}
+	}
+	derived := 0
+	if first >= 0 {
+		keys, err := r.accountMgr.DeriveAddresses(accountID, 0, uint32(first), uint32(last-first+1))
+		if err != nil {
+			return nil, 0, fmt.Errorf("派生地址失败: %v", err)
+		}
+		for _, addr := range keys {
+			if byIndex[addr.AddressIndex] == nil {
+				derived++
+			}
+			byIndex[addr.AddressIndex] = addr
+		}
+	}
+	addresses := make([]*core.AddressKey, 0, count)
+	for index := start; index < start+count; index++ {
+		addresses = append(addresses, byIndex[index])
+	}
+	return addresses, derived, nil
+}
+
+// qrCardFileName 卡片图片的文件名：地址索引，有标签时附加只含字母数字的标签
+func qrCardFileName(index uint32, label, format string) string {
+	name := fmt.Sprintf("%04d", index)
+	slug := strings.Join(strings.FieldsFunc(strings.ToLower(label), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}), "-")
+	if slug != "" {
+		name += "-" + slug
+	}
+	return name + "." + format
+}
+
+// writeQRImage 将二维码按 style 写为 PNG 或 SVG 文件
+func writeQRImage(name, format string, code *qr.Code, scale int, style qr.Style) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	if format == "svg" {
+		err = code.StyledSVG(f, scale, style)
+	} else {
+		err = code.StyledPNG(f, scale, style)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return nil
+}
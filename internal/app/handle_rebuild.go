@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// 重建存储命令处理函数：账户列表丢失或损坏时，由根种子按地址文件找回账户并重新派生地址，
+// --scan 时再按币种并行查询候选账户的地址余额，补上链上用过的账户和地址；扫描进度保存在检查点中，
+// 按 Ctrl+C 中断或查询出错后再次执行从中断处继续。写入前备份，结束后重建索引并报告结果
+func (r *REPL) handleStorageRebuild(args []string) error {
+	usage := r.usageError("storage.rebuild")
+	opts := core.RebuildOptions{Accounts: core.DefaultRebuildAccounts, Gap: core.DefaultRebuildGap, Parallel: core.DefaultRebuildParallel}
+	scan := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--scan":
+			scan = true
+		case args[i] == "--restart":
+			opts.Restart = true
+		case (args[i] == "--accounts" || args[i] == "--gap") && i+1 < len(args):
+			n, err := strconv.ParseUint(args[i+1], 10, 32)
+			if err != nil || n < 1 || n > 1000 {
+				return fmt.Errorf("invalid %s value %q", args[i], args[i+1])
+			}
+			if args[i] == "--accounts" {
+				opts.Accounts = uint32(n)
+			} else {
+				opts.Gap = uint32(n)
+			}
+			i++
+		case args[i] == "--parallel" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 || n > 32 {
+				return fmt.Errorf("invalid --parallel value %q", args[i+1])
+			}
+			opts.Parallel = n
+			i++
+		default:
+			return usage
+		}
+	}
+	if opts.Restart && !scan {
+		return usage
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	if scan {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		opts.Context = ctx
+		opts.Checkpoint = filepath.Join(r.baseDir(), core.RebuildCheckpointFileName)
+		opts.Probe = r.balanceProbe(ctx)
+		opts.Progress = r.scanProgress()
+		fmt.Println(r.template.Info(fmt.Sprintf("Scanning up to %d coins at a time, stopping after %d unused addresses in a row; "+
+			"Ctrl+C stops the scan and storage.rebuild --scan continues it", opts.Parallel, opts.Gap)))
+	}
+
+	report, err := r.accountMgr.RebuildStorage(opts)
+	if err != nil {
+		if errors.Is(err, core.ErrScanInterrupted) {
+			return fmt.Errorf("%w; run storage.rebuild --scan with the same --accounts and --gap to continue where it stopped", err)
+		}
+		if report != nil && report.Backup != "" {
+			return fmt.Errorf("%w; the files as they were before are in %s", err, report.Backup)
+		}
+		return err
+	}
+
+	fmt.Printf("Backup of the files before the rebuild: %s\n", report.Backup)
+	fmt.Printf("Accounts kept from accounts.json: %d\n", report.Kept)
+	if report.Resumed > 0 {
+		fmt.Printf("Candidate accounts already scanned in an earlier run: %d\n", report.Resumed)
+	}
+	if len(report.Rebuilt) > 0 {
+		fmt.Println(r.template.Success(fmt.Sprintf("Rebuilt %d accounts:", len(report.Rebuilt))))
+		fmt.Printf("  %-5s %-18s %-12s %-14s %9s %6s  %s\n", "COIN", "PATH", "CONVENTION", "SOURCE", "ADDRESSES", "NEW", "ID")
+		for _, account := range report.Rebuilt {
+			fmt.Printf("  %-5s %-18s %-12s %-14s %9d %6d  %s\n", account.CoinSymbol, account.Path, account.Convention,
+				account.Source, account.Addresses, account.Added, account.ID)
+		}
+	} else {
+		fmt.Println("Nothing needed rebuilding")
+	}
+	if len(report.Mismatched) > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d stored addresses do not match what the seed derives and were removed:", len(report.Mismatched))))
+		for _, line := range report.Mismatched {
+			fmt.Println("  " + line)
+		}
+	}
+	if len(report.Unrecovered) > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d items could not be recovered:", len(report.Unrecovered))))
+		for _, line := range report.Unrecovered {
+			fmt.Println("  " + line)
+		}
+	}
+	if len(report.ScanErrors) > 0 {
+		symbols := make([]string, 0, len(report.ScanErrors))
+		for symbol := range report.ScanErrors {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		fmt.Println(r.template.Warning("Not scanned:"))
+		for _, symbol := range symbols {
+			fmt.Printf("  %-5s %v\n", symbol, report.ScanErrors[symbol])
+		}
+		fmt.Println(r.template.Info("storage.rebuild --scan again retries these coins from where they stopped and skips the finished ones"))
+	}
+	if !scan {
+		fmt.Println(r.template.Info("Accounts whose address files are also lost can only be found on chain: run storage.rebuild --scan"))
+	}
+	fmt.Println(r.template.Info("Labels, tags and other metadata are keyed by account ID and apply again to recovered accounts; " +
+		"account creation times are not recoverable"))
+	return nil
+}
+
+// scanProgress 逐个币种显示扫描进度：每扫完约四分之一的候选账户显示一行，结束或出错时再显示一行
+func (r *REPL) scanProgress() func(core.ScanProgress) {
+	return func(p core.ScanProgress) {
+		switch {
+		case p.Err != nil:
+			fmt.Printf("  %-5s %4d/%-4d %s\n", p.Coin, p.Done, p.Total, r.template.Warning(fmt.Sprintf("stopped: %v", p.Err)))
+		case p.Finished:
+			fmt.Printf("  %-5s %4d/%-4d %s\n", p.Coin, p.Done, p.Total, r.template.Success(fmt.Sprintf("done, %d accounts used", p.Found)))
+		case p.Done*4/p.Total != (p.Done-1)*4/p.Total:
+			fmt.Printf("  %-5s %4d/%-4d %d accounts used so far\n", p.Coin, p.Done, p.Total, p.Found)
+		}
+	}
+}
+
+// balanceProbe 返回按余额判断地址是否用过的查询函数，可以被多个币种同时调用；每个币种创建一次客户端，
+// 第三方浏览器在首次查询前提醒。ctx 取消时正在进行的查询也停止。余额已转空的地址看起来未使用
+func (r *REPL) balanceProbe(ctx context.Context) func(symbol, address string) (bool, error) {
+	appConfig := config.GetAppConfig()
+	var mu sync.Mutex
+	clients := make(map[string]chain.ChainClient)
+	return func(symbol, address string) (bool, error) {
+		mu.Lock()
+		client, ok := clients[symbol]
+		if !ok {
+			var err error
+			if client, err = chain.ForCoin(symbol, appConfig); err != nil {
+				mu.Unlock()
+				return false, err
+			}
+			clients[symbol] = client
+			if client.ThirdParty() {
+				fmt.Println(r.template.Warning(fmt.Sprintf("Privacy: %s will see every scanned %s address and your IP address, "+
+					"and can link them together. Use your own node or Tor to avoid this.", client.Name(), strings.ToUpper(symbol))))
+			}
+		}
+		mu.Unlock()
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		balance, err := client.Balance(ctx, address)
+		if err != nil {
+			return false, err
+		}
+		return balance.Sign() > 0, nil
+	}
+}
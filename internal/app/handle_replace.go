@@ -0,0 +1,275 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/decoder"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// gweiDecimals gwei 相对 wei 的精度
+const gweiDecimals = 9
+
+// replaceArgs tx.bump、tx.cancel 的参数
+type replaceArgs struct {
+	target      string // BTC 交易 ID，或已签名的 ETH 原始交易（十六进制或文件）
+	feeRate     int64  // BTC，sat/vB
+	gasPrice    *big.Int
+	maxFee      *big.Int
+	priorityFee *big.Int
+	broadcast   bool
+}
+
+func (r *REPL) parseReplaceArgs(command string, args []string) (*replaceArgs, error) {
+	usage := r.usageError(command)
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		return nil, usage
+	}
+	parsed := &replaceArgs{target: args[0]}
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--broadcast" {
+			parsed.broadcast = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, usage
+		}
+		value := args[i+1]
+		var err error
+		switch args[i] {
+		case "--fee-rate":
+			if parsed.feeRate, err = strconv.ParseInt(value, 10, 64); err == nil && parsed.feeRate <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "--gas-price":
+			parsed.gasPrice, err = coin.ParseUnits(value, gweiDecimals)
+		case "--max-fee":
+			parsed.maxFee, err = coin.ParseUnits(value, gweiDecimals)
+		case "--priority-fee":
+			parsed.priorityFee, err = coin.ParseUnits(value, gweiDecimals)
+		default:
+			return nil, usage
+		}
+		if err != nil {
+			return nil, fmt.Errorf("无效的参数 %s: %s", args[i], value)
+		}
+		i++
+	}
+	return parsed, nil
+}
+
+// 提高手续费命令处理函数：以更高的手续费重新签名本钱包广播的 BTC 交易，或已签名的 ETH 交易（相同 nonce）
+func (r *REPL) handleTxBump(args []string) error {
+	return r.replaceTx("tx.bump", args)
+}
+
+// 取消交易命令处理函数：用花费相同输入（BTC）或相同 nonce（ETH）、转回本钱包的交易替换原交易
+func (r *REPL) handleTxCancel(args []string) error {
+	return r.replaceTx("tx.cancel", args)
+}
+
+func (r *REPL) replaceTx(command string, args []string) error {
+	parsed, err := r.parseReplaceArgs(command, args)
+	if err != nil {
+		return err
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	pendingStore, err := btc.LoadPendingStore(filepath.Join(r.baseDir(), btc.PendingFileName))
+	if err != nil {
+		return err
+	}
+	pending, err := pendingStore.Get(strings.TrimPrefix(parsed.target, "0x"))
+	if err == nil {
+		if parsed.gasPrice != nil || parsed.maxFee != nil || parsed.priorityFee != nil {
+			return fmt.Errorf("--gas-price, --max-fee and --priority-fee only apply to ETH transactions")
+		}
+		return r.replaceBTC(command, pending, parsed)
+	}
+	// 64 位十六进制是 BTC 交易 ID，不是 ETH 原始交易
+	if _, hexErr := hex.DecodeString(parsed.target); hexErr == nil && len(parsed.target) == 64 {
+		return err
+	}
+	if parsed.feeRate != 0 {
+		return fmt.Errorf("--fee-rate only applies to BTC transactions, use --gas-price or --max-fee/--priority-fee")
+	}
+	return r.replaceETH(command, parsed)
+}
+
+// replaceBTC 为本钱包广播的 BTC 交易构造替换交易：tx.bump 从找零中多付手续费，tx.cancel 把输入全部转回本钱包
+func (r *REPL) replaceBTC(command string, pending *btc.PendingTx, parsed *replaceArgs) error {
+	addresses, err := r.btcAccountAddresses(pending.AccountID)
+	if err != nil {
+		return err
+	}
+	store, backend, err := r.loadUTXOs(addresses, true)
+	if err != nil {
+		return err
+	}
+	// 原交易已确认时它的输出会出现在已确认的 UTXO 中
+	for _, u := range store.Unspent(addressStrings(addresses)) {
+		if u.TxID == pending.TxID && u.Height > 0 {
+			return fmt.Errorf("transaction %s is already confirmed at height %d", pending.TxID, u.Height)
+		}
+	}
+
+	spend := watch.Spend{AccountID: pending.AccountID, Replaces: pending.TxID}
+	var plan func(feeRate int64) (*btc.Replacement, error)
+	if command == "tx.bump" {
+		plan = func(feeRate int64) (*btc.Replacement, error) { return btc.PlanBump(pending, feeRate) }
+		spend.To, spend.Amount = r.replacedSpend(pending)
+	} else {
+		policy, err := r.changePolicy(pending.AccountID)
+		if err != nil {
+			return err
+		}
+		if policy.Mode == btc.ChangeSender {
+			policy.Mode = btc.ChangeFresh
+		}
+		target, err := r.changeAddress(pending.AccountID, policy, addresses, pending.Inputs)
+		if err != nil {
+			return err
+		}
+		script, err := btc.AddressScript(target.Address)
+		if err != nil {
+			return err
+		}
+		plan = func(feeRate int64) (*btc.Replacement, error) { return btc.PlanCancel(pending, script, feeRate) }
+		spend.To = target.Address
+	}
+
+	// 未指定费率时取后端估算和 BIP125 最低费率中较高的一个
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	replacement, err := plan(parsed.feeRate)
+	if err != nil {
+		return err
+	}
+	if parsed.feeRate == 0 {
+		estimate, err := backend.EstimateFeeRate(ctx, 2)
+		if err != nil {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Failed to estimate fee rate, using the minimum of %d sat/vB: %v", replacement.FeeRate, err)))
+		} else if estimate > replacement.FeeRate {
+			if replacement, err = plan(estimate); err != nil {
+				return err
+			}
+		}
+	}
+	if command == "tx.cancel" {
+		spend.Amount = strconv.FormatInt(replacement.Outputs[0].Value, 10)
+	}
+	spend.Fee = strconv.FormatInt(replacement.Fee, 10)
+
+	raw, txid, err := r.signBTC(replacement.Inputs, replacement.Outputs, addresses)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Replace %s", pending.TxID)))
+	for _, out := range replacement.Outputs {
+		fmt.Printf("  Output:    %s BTC -> %s\n", r.format().Decimal(btc.FormatBTC(out.Value)), decoder.ScriptAddress(out.Script))
+	}
+	fmt.Printf("  Fee:       %s BTC (%d sat/vB, was %s BTC at %d sat/vB)\n", r.format().Decimal(btc.FormatBTC(replacement.Fee)), replacement.FeeRate,
+		r.format().Decimal(btc.FormatBTC(pending.Fee)), pending.FeeRate())
+	fmt.Printf("  Txid:      %s\n", txid)
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(raw))
+	if !parsed.broadcast {
+		fmt.Println(r.template.Info(fmt.Sprintf("Replacement signed but not broadcast, rerun %s with --broadcast to send it", command)))
+		return nil
+	}
+	return r.broadcastBTC(ctx, command, backend, store, replacement.Pending(), raw, txid, spend)
+}
+
+// replacedSpend 被替换交易的收款方和金额，优先沿用交易记录，否则取第一个非找零输出
+func (r *REPL) replacedSpend(pending *btc.PendingTx) (string, string) {
+	if txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName)); err == nil {
+		for _, spend := range txStore.Spends() {
+			if spend.TxID == pending.TxID {
+				return spend.To, spend.Amount
+			}
+		}
+	}
+	for _, out := range pending.Outputs {
+		if !out.Change {
+			script, _ := hex.DecodeString(out.Script)
+			return decoder.ScriptAddress(script), strconv.FormatInt(out.Value, 10)
+		}
+	}
+	return "", "0"
+}
+
+// replaceETH 以相同 nonce 重新签名已签名的 ETH 交易：tx.bump 只提高手续费，tx.cancel 改为向自己转 0 ETH。
+// 钱包不广播 ETH 交易，输出的原始交易需要用其他工具广播
+func (r *REPL) replaceETH(command string, parsed *replaceArgs) error {
+	raw, err := decoder.ReadTxInput(parsed.target)
+	if err != nil {
+		return err
+	}
+	original, from, err := signer.DecodeTransaction(raw)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.accountMgr.IsMine(from.Hex()); !ok {
+		return fmt.Errorf("sender %s does not belong to this wallet", from.Hex())
+	}
+	if parsed.broadcast {
+		return fmt.Errorf("ETH replacements are only signed, broadcast the raw transaction with your node")
+	}
+
+	next := original.Bumped(signer.DefaultBumpPercent)
+	if command == "tx.cancel" {
+		next = original.Cancellation(from, signer.DefaultBumpPercent)
+	}
+	if original.Type == signer.LegacyTxType {
+		if parsed.maxFee != nil || parsed.priorityFee != nil {
+			return fmt.Errorf("legacy transaction, use --gas-price")
+		}
+		if parsed.gasPrice != nil {
+			next.GasPrice = parsed.gasPrice
+		}
+	} else {
+		if parsed.gasPrice != nil {
+			return fmt.Errorf("EIP-1559 transaction, use --max-fee and --priority-fee")
+		}
+		if parsed.maxFee != nil {
+			next.MaxFeePerGas = parsed.maxFee
+		}
+		if parsed.priorityFee != nil {
+			next.MaxPriorityFeePerGas = parsed.priorityFee
+		}
+	}
+	if err := original.CheckReplacement(next); err != nil {
+		return err
+	}
+
+	s, err := r.newSigner()
+	if err != nil {
+		return err
+	}
+	signed, err := s.SignTransaction(next.Args(from))
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Replace nonce %d of %s (original 0x%x)", original.Nonce, from.Hex(), crypto.Keccak256(raw))))
+	if original.Type == signer.LegacyTxType {
+		fmt.Printf("  Gas price: %s gwei (was %s gwei)\n", coin.FormatUnits(next.GasPrice, gweiDecimals), coin.FormatUnits(original.GasPrice, gweiDecimals))
+	} else {
+		fmt.Printf("  Max fee:   %s gwei (was %s gwei)\n", coin.FormatUnits(next.MaxFeePerGas, gweiDecimals), coin.FormatUnits(original.MaxFeePerGas, gweiDecimals))
+		fmt.Printf("  Priority:  %s gwei (was %s gwei)\n", coin.FormatUnits(next.MaxPriorityFeePerGas, gweiDecimals), coin.FormatUnits(original.MaxPriorityFeePerGas, gweiDecimals))
+	}
+	fmt.Printf("  Tx hash:   0x%x\n", crypto.Keccak256(signed))
+	fmt.Printf("  Raw:       0x%x\n", signed)
+	return nil
+}
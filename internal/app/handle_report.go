@@ -0,0 +1,155 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/report"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/watch"
+)
+
+// 收支报表命令处理函数：汇总交易记录中账户（--tag 时为每个有该标记的账户）在一个期间内的入账、支出、交易对手和手续费，
+// 用显示模板或 --template 指定的 text/template 输出，--csv 导出每笔交易供报税使用
+func (r *REPL) handleReportSpending(args []string) error {
+	usage := r.usageError("report.spending")
+	var accountArg, tagArg, periodArg, csvFile, templateFile string
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		switch args[i] {
+		case "--account":
+			accountArg = args[i+1]
+		case "--tag":
+			tagArg = args[i+1]
+		case "--period":
+			periodArg = args[i+1]
+		case "--csv":
+			csvFile = args[i+1]
+		case "--template":
+			templateFile = args[i+1]
+		default:
+			return usage
+		}
+		i++
+	}
+	if (accountArg == "") == (tagArg == "") || periodArg == "" {
+		return usage
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	period, err := report.ParsePeriod(periodArg, view.Location())
+	if err != nil {
+		return err
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	accounts, err := r.reportAccounts(store, accountArg, tagArg)
+	if err != nil {
+		return err
+	}
+	if csvFile != "" && len(accounts) > 1 {
+		return fmt.Errorf("%d accounts are tagged %s, --csv needs a single account", len(accounts), tagArg)
+	}
+	txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if templateFile != "" {
+		text, err := os.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		tmpl, err = template.New(filepath.Base(templateFile)).Funcs(r.format().FuncMap()).Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+	}
+
+	for _, account := range accounts {
+		accountLabel, _ := store.Get(metadata.Labels, account.ID)
+		spending := report.Build(account.ID, accountLabel, account.CoinSymbol, period, txStore.Payments(), txStore.Spends(),
+			r.ownAddress, counterpartyLabeler(store))
+
+		if csvFile != "" {
+			var data bytes.Buffer
+			if err := spending.WriteCSV(&data); err != nil {
+				return err
+			}
+			if err := os.WriteFile(csvFile, data.Bytes(), 0600); err != nil {
+				return err
+			}
+		}
+		if tmpl != nil {
+			if err := tmpl.Execute(os.Stdout, spending); err != nil {
+				return fmt.Errorf("template failed: %v", err)
+			}
+		} else {
+			fmt.Println(r.template.SpendingReport(spending))
+		}
+		if csvFile != "" {
+			fmt.Println(r.template.Success(fmt.Sprintf("%d transactions written to %s", len(spending.Entries), csvFile)))
+		}
+	}
+	return nil
+}
+
+// reportAccounts 报表的账户：--account 指定的一个账户，或有 --tag 标记（含下级标记）的全部账户
+func (r *REPL) reportAccounts(store *metadata.Store, accountArg, tagArg string) ([]*core.CoinAccount, error) {
+	if accountArg != "" {
+		accountID, err := r.resolveAccountID(accountArg)
+		if err != nil {
+			return nil, err
+		}
+		account, err := r.findAccount(accountID)
+		if err != nil {
+			return nil, err
+		}
+		return []*core.CoinAccount{account}, nil
+	}
+	tag, err := metadata.NormalizeTag(tagArg)
+	if err != nil {
+		return nil, err
+	}
+	all, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var accounts []*core.CoinAccount
+	for _, account := range all {
+		if store.HasTag(account.ID, tag) {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts are tagged %s", tag)
+	}
+	return accounts, nil
+}
+
+// counterpartyLabeler 地址的显示名称：联系人名称优先，其次是地址标签
+func counterpartyLabeler(store *metadata.Store) report.Labeler {
+	contacts := make(map[string]string)
+	for _, name := range store.Keys(metadata.Contacts) {
+		if address, ok := store.Get(metadata.Contacts, name); ok {
+			contacts[address] = name
+		}
+	}
+	return func(address string) string {
+		if name, ok := contacts[address]; ok {
+			return name
+		}
+		label, _ := store.Get(metadata.Labels, address)
+		return label
+	}
+}
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/usage"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/qr"
+)
+
+// 收款请求命令处理函数，为账户分配一个未使用的收款地址并生成支付 URI 和二维码；
+// 账户标签作为 URI 的 label，二维码内容按 --content 或 ui.qr_content 选择
+func (r *REPL) handleRequestCreate(args []string) error {
+	var svgFile, contentFlag string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if (args[i] == "--svg" || args[i] == "--content") && i+1 < len(args) {
+			if args[i] == "--svg" {
+				svgFile = args[i+1]
+			} else {
+				contentFlag = args[i+1]
+			}
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	args = rest
+	if len(args) < 2 {
+		return r.usageError("request.create")
+	}
+	mode, err := qrContentMode(contentFlag)
+	if err != nil {
+		return err
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if !payreq.Supported(account.CoinSymbol) {
+		return fmt.Errorf("%w %s", payreq.ErrUnsupportedCoin, account.CoinSymbol)
+	}
+	info, ok := coin.LookupSymbol(account.CoinSymbol)
+	if !ok {
+		return fmt.Errorf("unknown coin %s", account.CoinSymbol)
+	}
+	amount, err := coin.ParseUnits(args[1], info.Decimal)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() == 0 {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	memo := strings.Join(args[2:], " ")
+
+	store, err := payreq.Load(filepath.Join(r.baseDir(), payreq.FileName))
+	if err != nil {
+		return err
+	}
+	addr, err := r.requestAddress(account, store.OpenAddresses())
+	if err != nil {
+		return err
+	}
+	metaStore, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	label, _ := metaStore.Get(metadata.Labels, account.ID)
+	receive := &payreq.Receive{Coin: account.CoinSymbol, Address: addr.Address, Amount: amount, Decimals: info.Decimal, Label: label, Memo: memo}
+	uri, err := receive.Content(payreq.ContentURI)
+	if err != nil {
+		return err
+	}
+	text, err := receive.Content(mode)
+	if err != nil {
+		return err
+	}
+	req := &payreq.Request{
+		AccountID: account.ID,
+		Coin:      account.CoinSymbol,
+		Address:   addr.Address,
+		Amount:    amount.String(),
+		Memo:      memo,
+		URI:       uri,
+	}
+	if err := store.Add(req); err != nil {
+		return err
+	}
+
+	code, err := qr.Encode([]byte(text), qr.LevelM)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Payment request %s: %s to %s (index %d)",
+		req.ID, r.format().Amount(amount, info.Decimal, account.CoinSymbol), addr.Address, addr.AddressIndex)))
+	fmt.Println(uri)
+	if text != uri {
+		fmt.Println(r.template.Info(fmt.Sprintf("QR code content (%s): %s", mode, text)))
+	}
+	fmt.Print(view.QR(code))
+	if svgFile != "" {
+		if err := writeQRImage(svgFile, "svg", code, 8, qr.Style{}); err != nil {
+			return err
+		}
+		fmt.Println(r.template.Success("QR code written to " + svgFile))
+	}
+	return nil
+}
+
+// requestAddress 选择链上未使用且没有被未支付请求占用的收款地址，没有时派生下一个；
+// 地址超出最后一个已使用地址之后的 address.gap_limit 时返回 errGapLimit
+func (r *REPL) requestAddress(account *core.CoinAccount, reserved map[string]bool) (*core.AddressKey, error) {
+	addresses, err := r.accountMgr.GetAddresses(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	tracker, err := usage.Load(filepath.Join(r.baseDir(), usage.FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidate *core.AddressKey
+	next := uint32(0)
+	for _, addr := range addresses {
+		if addr.ChangeType != 0 {
+			continue
+		}
+		if addr.AddressIndex >= next {
+			next = addr.AddressIndex + 1
+		}
+		if _, used := tracker.Used(addr.Address); used || reserved[addr.Address] {
+			continue
+		}
+		if candidate == nil || addr.AddressIndex < candidate.AddressIndex {
+			candidate = addr
+		}
+	}
+	index := next
+	if candidate != nil {
+		index = candidate.AddressIndex
+	}
+	if err := checkGap(account.ID, index, lastUsedIndex(addresses, tracker)); err != nil {
+		return nil, err
+	}
+	if candidate != nil {
+		return candidate, nil
+	}
+	addr, err := r.accountMgr.DeriveAddress(account.ID, 0, next)
+	if err != nil {
+		return nil, fmt.Errorf("派生地址失败: %v", err)
+	}
+	return addr, nil
+}
+
+// findAccount 按 ID 查找账户
+func (r *REPL) findAccount(accountID string) (*core.CoinAccount, error) {
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.ID == accountID {
+			return account, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", core.ErrAccountNotFound, accountID)
+}
+
+// 收款请求列表命令处理函数，默认只显示未支付的请求
+func (r *REPL) handleRequestList(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "--all") {
+		return r.usageError("request.list")
+	}
+	store, err := payreq.Load(filepath.Join(r.baseDir(), payreq.FileName))
+	if err != nil {
+		return err
+	}
+	requests := store.List(len(args) == 0)
+	if len(requests) == 0 {
+		fmt.Println("No outstanding payment requests")
+		return nil
+	}
+	for _, req := range requests {
+		amount := req.Amount
+		if value, ok := new(big.Int).SetString(req.Amount, 10); ok {
+			if info, ok := coin.LookupSymbol(req.Coin); ok {
+				amount = r.format().Amount(value, info.Decimal, req.Coin)
+			}
+		}
+		fmt.Printf("%s  %-5s %s  %s  %s\n", req.ID, req.Status, r.format().Date(req.CreatedAt), amount, req.Address)
+		if req.Memo != "" {
+			fmt.Printf("          memo: %s\n", req.Memo)
+		}
+		if req.Status == payreq.StatusPaid {
+			fmt.Printf("          paid %s in %s\n", r.format().Date(req.PaidAt), req.PaidTxID)
+		}
+		fmt.Printf("          %s\n", req.URI)
+	}
+	return nil
+}
+
+// printPayment 显示支付 URI 解码出的字段，发送前让用户核对
+func (r *REPL) printPayment(p *payreq.Payment) {
+	fmt.Println(r.template.Info(fmt.Sprintf("%s payment URI", p.Coin)))
+	fmt.Printf("  Address:   %s\n", p.Address)
+	if p.Amount != nil {
+		amount := p.Amount.String()
+		if info, ok := coin.LookupSymbol(p.Coin); ok {
+			amount = r.format().Amount(p.Amount, info.Decimal, p.Coin)
+		}
+		fmt.Printf("  Amount:    %s\n", amount)
+	}
+	if p.Token != "" {
+		fmt.Printf("  Token:     %s\n", p.Token)
+		if p.TokenAmount != "" {
+			fmt.Printf("  Tokens:    %s\n", p.TokenAmount)
+		}
+	}
+	if p.ChainID != nil {
+		fmt.Printf("  Chain ID:  %s\n", p.ChainID)
+	}
+	if p.Label != "" {
+		fmt.Printf("  Label:     %s\n", p.Label)
+	}
+	if p.Message != "" {
+		fmt.Printf("  Message:   %s\n", p.Message)
+	}
+	keys := make([]string, 0, len(p.Extra))
+	for key := range p.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  %-10s %s\n", key+":", p.Extra[key])
+	}
+}
+
+// 支付 URI 解码命令处理函数，只显示字段，不发送
+func (r *REPL) handleRequestDecode(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("request.decode")
+	}
+	payment, err := payreq.ParseURI(args[0])
+	if err != nil {
+		return err
+	}
+	r.printPayment(payment)
+	return nil
+}
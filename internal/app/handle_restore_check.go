@@ -0,0 +1,369 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+)
+
+// scratchWallet 在临时目录中恢复的钱包，只用于比较
+type scratchWallet struct {
+	dir        string
+	storage    *core.FileStorage
+	walletMgr  core.WalletManager
+	accountMgr core.AccountManager
+	passwords  *security.PasswordManager
+}
+
+// newScratchWallet 在内存文件系统（可用时）上创建临时目录并打开空的存储
+func newScratchWallet(cloak string) (*scratchWallet, error) {
+	parent := ""
+	if runtime.GOOS == "linux" {
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			parent = "/dev/shm"
+		}
+	}
+	dir, err := os.MkdirTemp(parent, "slowmade-restore-check-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	storageConfig.BaseDir, storageConfig.ReadOnly = dir, false
+	storage, err := core.NewFileStorage(storageConfig)
+	if err != nil {
+		security.WipeDir(dir)
+		return nil, err
+	}
+	// 独立的密码管理器，不影响当前钱包的解锁状态
+	passwords := security.NewPasswordManager()
+	walletMgr := core.NewDefaultWalletManager(storage, cloak).UsePasswords(passwords)
+	return &scratchWallet{
+		dir:        dir,
+		storage:    storage,
+		walletMgr:  walletMgr,
+		accountMgr: core.NewDefaultAccountManager(walletMgr, storage, filepath.Join(dir, core.DerivedCacheFileName)),
+		passwords:  passwords,
+	}, nil
+}
+
+// unlock 解锁临时钱包并保存密码供派生使用
+func (s *scratchWallet) unlock(password string) error {
+	if err := s.walletMgr.UnlockWallet(password); err != nil {
+		return err
+	}
+	return s.passwords.SetPassword(password)
+}
+
+// wipe 锁定钱包，覆盖并删除临时目录
+func (s *scratchWallet) wipe() error {
+	s.walletMgr.LockWallet()
+	s.passwords.Clear()
+	s.storage.Close()
+	return security.WipeDir(s.dir)
+}
+
+// restorePreview 恢复前预览的账户：主要币种 account.create 的默认路径，比特币另加其他钱包常用的原生隔离见证路径
+var restorePreview = []string{"m/44'/0'/0'", "m/84'/0'/0'", "m/44'/60'/0'", "m/44'/2'/0'"}
+
+// previewRestore 在临时目录中恢复，显示主密钥指纹和主要币种的第一个收款地址，确认后才写入钱包目录；
+// 取消时当前钱包目录保持不变。与 wallet.restore 一样种子由助记词和钱包密码生成，与 cloak 无关
+func (r *REPL) previewRestore(phrase, password string, confirmed bool) error {
+	scratch, err := newScratchWallet("")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := scratch.wipe(); err != nil {
+			logging.Warnf("Failed to wipe %s: %v", scratch.dir, err)
+		}
+	}()
+	if _, err := scratch.walletMgr.RestoreWalletFromMnemonic(phrase, password); err != nil {
+		return fmt.Errorf("failed to restore wallet: %v", err)
+	}
+	if err := scratch.unlock(password); err != nil {
+		return err
+	}
+	fingerprint, err := scratch.accountMgr.MasterFingerprint()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("This mnemonic restores the wallet with master fingerprint %s. First receive addresses:\n", fingerprint)
+	for _, preview := range restorePreview {
+		path, err := core.ParseDerivationPath(preview)
+		if err != nil {
+			return err
+		}
+		account, err := scratch.accountMgr.CreateNewAccount(path, core.ConventionStandard)
+		if err != nil {
+			return fmt.Errorf("%s: %w", preview, err)
+		}
+		address, err := scratch.accountMgr.DeriveAddress(account.ID, 0, 0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", preview, err)
+		}
+		addressPath := preview + "/0/0"
+		if p, err := account.AddressPath(0, 0); err == nil {
+			addressPath = p.String()
+		}
+		fmt.Printf("  %-5s %-22s %s\n", account.CoinSymbol, addressPath, address.Address)
+	}
+	fmt.Println(r.template.Info("Compare them with addresses you know are yours before continuing"))
+
+	if _, _, err := r.walletMgr.Timestamps(); err == nil {
+		fmt.Println(r.template.Warning("This replaces the wallet in " + r.baseDir() + ", whose mnemonic is then needed to get it back"))
+	}
+	if confirmed {
+		return nil
+	}
+	answer, err := r.line.Prompt("Restore this wallet? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("restore cancelled, nothing was written")
+	}
+	return nil
+}
+
+// 恢复演练命令处理函数：在临时目录中用备份文件或助记词恢复钱包，比较主密钥指纹、每个账户的扩展公钥
+// 和前几个收款地址，结束后覆盖并删除临时数据；不修改当前钱包
+func (r *REPL) handleWalletTestRestore(args []string) error {
+	usage := r.usageError("wallet.test-restore")
+	var (
+		words        []string
+		identityFile string
+		count        = 3
+	)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--identity" && i+1 < len(args):
+			i++
+			identityFile = args[i]
+		case args[i] == "--count" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 || n > 100 {
+				return fmt.Errorf("invalid address count %q", args[i])
+			}
+			count = n
+		case strings.HasPrefix(args[i], "--"):
+			return usage
+		default:
+			words = append(words, args[i])
+		}
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+
+	// 一个参数且文件存在时是备份文件，否则是助记词
+	var backupFile, phrase string
+	if len(words) == 1 {
+		if _, err := os.Stat(words[0]); err == nil {
+			backupFile = words[0]
+		}
+	}
+	if backupFile == "" {
+		if identityFile != "" {
+			return usage
+		}
+		phrase = mnemonic.Normalize(strings.Join(words, " "))
+		if phrase == "" {
+			var err error
+			if phrase, err = r.passwordPrompt().Read("Mnemonic: "); err != nil {
+				return err
+			}
+			phrase = mnemonic.Normalize(phrase)
+		}
+		if n := len(strings.Fields(phrase)); n%3 != 0 || n < 12 || n > 24 {
+			return fmt.Errorf("a mnemonic has 12, 15, 18, 21 or 24 words, got %d", n)
+		}
+	}
+	cloak := ""
+	if r.cloaked {
+		var err error
+		if cloak, err = r.passwordPrompt().Read("Cloak passphrase: "); err != nil {
+			return err
+		}
+	}
+
+	scratch, err := newScratchWallet(cloak)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := scratch.wipe(); err != nil {
+			logging.Warnf("Failed to wipe %s: %v", scratch.dir, err)
+		}
+	}()
+	fmt.Println(r.template.Info("Restoring into " + scratch.dir + ", the live wallet is not touched"))
+	if backupFile != "" {
+		err = r.restoreScratchBackup(scratch, backupFile, identityFile)
+	} else {
+		err = r.restoreScratchMnemonic(scratch, phrase)
+	}
+	if err != nil {
+		return err
+	}
+	return r.compareScratch(scratch, backupFile != "", uint32(count))
+}
+
+// restoreScratchBackup 把备份包写入临时目录，用备份时的钱包密码解锁
+func (r *REPL) restoreScratchBackup(scratch *scratchWallet, file, identityFile string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	var identity []byte
+	if identityFile != "" {
+		if identity, err = os.ReadFile(identityFile); err != nil {
+			return fmt.Errorf("读取文件失败: %w", err)
+		}
+		defer security.WipeSensitiveData(identity)
+	}
+	bundle, err := backup.OpenFile(data, string(identity))
+	if err != nil {
+		return err
+	}
+	if err := bundle.Restore(scratch.dir); err != nil {
+		return fmt.Errorf("restore failed: %v", err)
+	}
+	password, err := r.passwordPrompt().Read("Wallet password at the time of the backup: ")
+	if err != nil {
+		return err
+	}
+	if err := scratch.unlock(password); err != nil {
+		return fmt.Errorf("the backup does not unlock: %w", err)
+	}
+	return nil
+}
+
+// restoreScratchMnemonic 与 wallet.restore 相同，用当前钱包的密码从助记词恢复，
+// 这样比较的就是现在执行恢复会得到的钱包
+func (r *REPL) restoreScratchMnemonic(scratch *scratchWallet, mnemonic string) error {
+	password, err := r.walletMgr.Password()
+	if err != nil {
+		return err
+	}
+	defer security.WipeSensitiveData(password)
+	if _, err := scratch.walletMgr.RestoreWalletFromMnemonic(mnemonic, string(password)); err != nil {
+		return fmt.Errorf("failed to restore wallet: %v", err)
+	}
+	return scratch.unlock(string(password))
+}
+
+// compareScratch 逐个比较当前钱包与恢复结果，任何不一致都返回错误
+func (r *REPL) compareScratch(scratch *scratchWallet, fromBackup bool, count uint32) error {
+	liveFingerprint, err := r.accountMgr.MasterFingerprint()
+	if err != nil {
+		return err
+	}
+	restoredFingerprint, err := scratch.accountMgr.MasterFingerprint()
+	if err != nil {
+		return err
+	}
+	failures := 0
+	if liveFingerprint != restoredFingerprint {
+		fmt.Println(r.template.Error(fmt.Sprintf("Master fingerprint %s does not match the live wallet's %s", restoredFingerprint, liveFingerprint)))
+		return fmt.Errorf("restore check failed: different wallet")
+	}
+	fmt.Printf("Master fingerprint %s matches\n", liveFingerprint)
+
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	restoredAccounts, err := scratch.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	inBackup := make(map[string]bool, len(restoredAccounts))
+	for _, account := range restoredAccounts {
+		inBackup[account.ID] = true
+	}
+	for _, account := range accounts {
+		convention := account.PathConvention
+		if convention == "" {
+			convention = core.ConventionStandard
+		}
+		name := fmt.Sprintf("%-5s %-18s %-11s", account.CoinSymbol, account.DerivationPath, convention)
+		switch {
+		case fromBackup && !inBackup[account.ID]:
+			fmt.Printf("  %s %s\n", name, r.template.Warning("not in the backup (created after it?)"))
+			failures++
+			continue
+		case !fromBackup && account.Standalone:
+			fmt.Printf("  %s %s\n", name, r.template.Warning("skipped, imported account is not derived from the mnemonic"))
+			continue
+		case !fromBackup:
+			path, err := core.ParseDerivationPath(account.DerivationPath)
+			if err != nil {
+				return err
+			}
+			if _, err := scratch.accountMgr.CreateNewAccount(path, convention); err != nil {
+				return fmt.Errorf("%s: %w", account.DerivationPath, err)
+			}
+		}
+		problem, checked, err := r.compareAccount(scratch, account, count)
+		if err != nil {
+			return fmt.Errorf("%s: %w", account.DerivationPath, err)
+		}
+		if problem != "" {
+			fmt.Printf("  %s %s\n", name, r.template.Error(problem))
+			failures++
+			continue
+		}
+		if checked == 0 {
+			fmt.Printf("  %s %s\n", name, r.template.Success("ok (xpub, no receive addresses derived yet)"))
+			continue
+		}
+		fmt.Printf("  %s %s\n", name, r.template.Success(fmt.Sprintf("ok (xpub, %d addresses)", checked)))
+	}
+	if failures > 0 {
+		return fmt.Errorf("restore check failed for %d accounts", failures)
+	}
+	fmt.Println(r.template.Success("The backup restores this wallet"))
+	return nil
+}
+
+// compareAccount 比较扩展公钥和当前钱包已派生的前 count 个收款地址，返回不一致的说明和比较的地址数
+func (r *REPL) compareAccount(scratch *scratchWallet, account *core.CoinAccount, count uint32) (string, int, error) {
+	liveXPub, err := r.accountMgr.AccountPublicKey(account.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	restoredXPub, err := scratch.accountMgr.AccountPublicKey(account.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	if liveXPub != restoredXPub {
+		return "account public key differs", 0, nil
+	}
+	live, err := r.accountMgr.GetAddresses(account.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	checked := 0
+	for _, want := range live {
+		if want.ChangeType != 0 || want.AddressIndex >= count {
+			continue
+		}
+		got, err := scratch.accountMgr.DeriveAddress(account.ID, 0, want.AddressIndex)
+		if err != nil {
+			return "", checked, err
+		}
+		if got.Address != want.Address {
+			return fmt.Sprintf("address %d differs: %s, live %s", want.AddressIndex, got.Address, want.Address), checked, nil
+		}
+		checked++
+	}
+	return "", checked, nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/usage"
+)
+
+// recordUsage 记录链上查询发现的已使用地址，记录失败不影响查询结果；只读模式下不记录
+func (r *REPL) recordUsage(source string, addresses []string) {
+	if len(addresses) == 0 || readOnlyMode() {
+		return
+	}
+	tracker, err := usage.Load(filepath.Join(r.baseDir(), usage.FileName))
+	if err == nil {
+		err = tracker.MarkUsed(source, addresses...)
+	}
+	if err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("failed to record address usage: %v", err)))
+	}
+}
+
+// checkReuse 对已使用的地址打印警告并返回这些地址；
+// 严格模式下要求确认，未确认时 confirmed 为 false
+func (r *REPL) checkReuse(addresses []*core.AddressKey) (used map[string]bool, confirmed bool, err error) {
+	tracker, err := usage.Load(filepath.Join(r.baseDir(), usage.FileName))
+	if err != nil {
+		return nil, false, err
+	}
+	used = make(map[string]bool)
+	for _, addr := range addresses {
+		record, ok := tracker.Used(addr.Address)
+		if !ok {
+			continue
+		}
+		used[addr.Address] = true
+		fmt.Println(r.template.Warning(fmt.Sprintf("ADDRESS ALREADY USED: %s (seen by %s on %s)",
+			addr.Address, record.Source, r.format().Date(record.FirstSeen))))
+	}
+	if len(used) == 0 {
+		return used, true, nil
+	}
+	fmt.Println(r.template.Warning("Reusing addresses links your payments together on-chain; derive a fresh address instead"))
+
+	appConfig := config.GetAppConfig()
+	if !appConfig.GetPrivacyConfig().StrictAddressReuse {
+		return used, true, nil
+	}
+	if r.line.capturing {
+		return used, false, nil
+	}
+	answer, err := r.line.Prompt("Show already used addresses anyway? [y/N]: ")
+	return used, err == nil && strings.EqualFold(strings.TrimSpace(answer), "y"), nil
+}
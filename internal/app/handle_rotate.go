@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 账户轮换命令处理函数：在下一个账户索引派生后继账户，生成把旧地址资金转到新地址的清扫计划，
+// 旧账户归档保留历史。BTC 账户直接签名清扫交易，其他币种列出需要手动转出的金额
+func (r *REPL) handleAccountRotate(args []string) error {
+	usage := r.usageError("account.rotate")
+	if len(args) < 1 {
+		return usage
+	}
+	var (
+		feeRate   int64
+		broadcast bool
+		err       error
+	)
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--broadcast":
+			broadcast = true
+		case "--fee-rate":
+			if i+1 >= len(args) {
+				return usage
+			}
+			if feeRate, err = strconv.ParseInt(args[i+1], 10, 64); err != nil || feeRate <= 0 {
+				return fmt.Errorf("invalid fee rate %q", args[i+1])
+			}
+			i++
+		default:
+			return usage
+		}
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account.Standalone {
+		return fmt.Errorf("account %s was imported as a standalone account and cannot derive a successor, create a new account instead", account.ID)
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	successorID, rotated := store.Get(metadata.Rotations, account.ID)
+	if !rotated {
+		successor, err := r.successorPath(account)
+		if err != nil {
+			return err
+		}
+		fmt.Println(r.template.Info(fmt.Sprintf("Rotate %s (%s)", account.ID, account.DerivationPath)))
+		fmt.Printf("  Successor: %s\n", successor)
+		fmt.Printf("  Funds on %s addresses will be moved to the successor, %s is archived afterwards\n", account.CoinSymbol, account.ID)
+		answer, err := r.line.Prompt("Create the successor account? [y/N]: ")
+		if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			return fmt.Errorf("rotation cancelled")
+		}
+		created, err := r.accountMgr.CreateNewAccount(successor, account.Convention())
+		if err != nil {
+			return fmt.Errorf("创建账户失败: %v", err)
+		}
+		successorID = created.ID
+		if err := store.Set("account.rotate", metadata.Rotations, account.ID, successorID); err != nil {
+			return err
+		}
+		if _, archived := store.Get(metadata.Archived, account.ID); !archived {
+			if err := store.Set("account.rotate", metadata.Archived, account.ID, time.Now().UTC().Format(time.RFC3339)); err != nil {
+				return err
+			}
+		}
+		fmt.Println(r.template.Success(fmt.Sprintf("Created successor %s, archived %s", successorID, account.ID)))
+	} else {
+		// 已经轮换过的账户重新执行时只重新生成清扫计划，用于补扫后来收到的资金
+		fmt.Println(r.template.Info(fmt.Sprintf("%s was already rotated to %s, rebuilding the sweep plan", account.ID, successorID)))
+	}
+
+	target, err := r.requestAddress(&core.CoinAccount{ID: successorID}, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Sweep to:  %s (%s index %d)\n", target.Address, successorID, target.AddressIndex)
+
+	addresses, err := r.accountMgr.GetAddresses(account.ID)
+	if err != nil {
+		return fmt.Errorf("获取地址列表失败: %v", err)
+	}
+	if len(addresses) == 0 {
+		fmt.Println("No addresses derived on the old account, nothing to sweep")
+		return nil
+	}
+	if account.CoinSymbol == "BTC" {
+		return r.sweepBTCAccount(addresses, target, feeRate, broadcast)
+	}
+	if broadcast || feeRate != 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("--fee-rate and --broadcast only apply to BTC, %s transfers must be sent manually", account.CoinSymbol)))
+	}
+	return r.printSweepPlan(account.CoinSymbol, addresses, target)
+}
+
+// successorPath 返回同一用途和币种下第一个未使用的账户索引对应的路径
+func (r *REPL) successorPath(account *core.CoinAccount) (*core.DerivationPath, error) {
+	path, err := account.Path()
+	if err != nil {
+		return nil, err
+	}
+	if len(path.HardenedPrefix()) < 3 {
+		return nil, fmt.Errorf("account path %s has no hardened account level", account.DerivationPath)
+	}
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	next := path.AccountIndex &^ core.HardenedOffset
+	for _, other := range accounts {
+		otherPath, err := other.Path()
+		if err != nil || other.Standalone || otherPath.Purpose != path.Purpose ||
+			otherPath.CoinType != path.CoinType || otherPath.Depth() != path.Depth() {
+			continue
+		}
+		if index := otherPath.AccountIndex &^ core.HardenedOffset; index >= next {
+			next = index + 1
+		}
+	}
+	if next >= core.HardenedOffset {
+		return nil, fmt.Errorf("no account index left after %s", account.DerivationPath)
+	}
+	return path.WithAccountIndex(next), nil
+}
+
+// sweepBTCAccount 把旧账户的全部 UTXO 合并为一笔交易发送到后继账户的地址
+func (r *REPL) sweepBTCAccount(addresses []*core.AddressKey, target *core.AddressKey, feeRate int64, broadcast bool) error {
+	store, backend, err := r.loadUTXOs(addresses, true)
+	if errors.Is(err, btc.ErrNotConfigured) {
+		store, backend, err = r.loadUTXOs(addresses, false)
+		fmt.Println(r.template.Warning("Bitcoin backend not configured, using cached UTXOs"))
+	}
+	if err != nil {
+		return err
+	}
+	if broadcast && backend == nil {
+		return btc.ErrNotConfigured
+	}
+	utxos := store.Unspent(addressStrings(addresses))
+	if len(utxos) == 0 {
+		fmt.Println("No UTXOs left on the old account, nothing to sweep")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if feeRate == 0 {
+		if backend == nil {
+			return fmt.Errorf("--fee-rate is required without a bitcoin backend")
+		}
+		if feeRate, err = backend.EstimateFeeRate(ctx, 6); err != nil {
+			return fmt.Errorf("failed to estimate fee rate: %v, pass --fee-rate", err)
+		}
+	}
+	script, err := btc.AddressScript(target.Address)
+	if err != nil {
+		return err
+	}
+	selection, amount, err := btc.SweepAll(utxos, script, feeRate)
+	if err != nil {
+		return err
+	}
+	outputs := []btc.TxOut{{Value: amount, Script: script}}
+	raw, txid, err := r.signBTC(selection.Inputs, outputs, addresses)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range selection.Inputs {
+		fmt.Printf("  Input:     %s (%s BTC) %s\n", u.Outpoint(), r.format().Decimal(btc.FormatBTC(u.Value)), u.Address)
+	}
+	fmt.Printf("  Amount:    %s BTC\n", r.format().Decimal(btc.FormatBTC(amount)))
+	fmt.Printf("  Fee:       %s BTC (%d sat/vB, %d vB)\n", r.format().Decimal(btc.FormatBTC(selection.Fee)), feeRate, selection.VSize)
+	fmt.Printf("  Txid:      %s\n", txid)
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(raw))
+
+	if !broadcast {
+		fmt.Println(r.template.Info("Sweep signed but not broadcast, rerun account.rotate with --broadcast to send it"))
+		return nil
+	}
+	if err := r.broadcastBTC(ctx, "account.rotate", backend, store, btc.NewPendingTx(selection, outputs, script), raw, txid, watch.Spend{
+		AccountID: addresses[0].AccountID, To: target.Address, Amount: strconv.FormatInt(amount, 10), Fee: strconv.FormatInt(selection.Fee, 10)}); err != nil {
+		return err
+	}
+	r.recordUsage("account.rotate", []string{target.Address})
+	return nil
+}
+
+// printSweepPlan 查询旧账户各地址的余额，列出需要转到新地址的金额
+func (r *REPL) printSweepPlan(symbol string, addresses []*core.AddressKey, target *core.AddressKey) error {
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return fmt.Errorf("unknown coin %s", symbol)
+	}
+	appConfig := config.GetAppConfig()
+	client, err := chain.ForCoin(symbol, appConfig)
+	if err != nil {
+		return err
+	}
+	cached := r.cachedClient(client, symbol, 0).SkipCache()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	total := new(big.Int)
+	moves := 0
+	for _, addr := range addresses {
+		balance, err := cached.Balance(ctx, addr.Address)
+		if err != nil {
+			fmt.Printf("  %-62s %s\n", addr.Address, r.template.Error(err.Error()))
+			continue
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		fmt.Printf("  Move:      %s %s -> %s\n", r.format().Amount(balance, info.Decimal, symbol), addr.Address, target.Address)
+		total.Add(total, balance)
+		moves++
+	}
+	if moves == 0 {
+		fmt.Println("No funds found on the old account, nothing to sweep")
+		return nil
+	}
+	fmt.Printf("Total: %s in %d transfers, less network fees\n", r.format().Amount(total, info.Decimal, symbol), moves)
+	fmt.Println(r.template.Info(fmt.Sprintf("Signing %s transfers is not supported in the REPL, send them from a wallet holding these keys", symbol)))
+	return nil
+}
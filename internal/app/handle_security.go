@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/hardening"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+)
+
+// 进程加固状态命令处理函数，重新读取当前实际生效的保护，并显示与启动时相同的安全概况
+func (r *REPL) handleSecurityStatus(args []string) error {
+	status := hardening.Current()
+	level := status.Level()
+	switch level {
+	case "full":
+		fmt.Println(r.template.Success("Hardening level: " + level))
+	case "partial":
+		fmt.Println(r.template.Warning("Hardening level: " + level))
+	default:
+		fmt.Println(r.template.Error("Hardening level: " + level))
+	}
+	for _, p := range status.Protections {
+		state := r.template.Success("on ")
+		if !p.Enabled {
+			state = r.template.Warning("off")
+		}
+		fmt.Printf("  %-22s %s  %s\n", p.Name, state, p.Detail)
+	}
+	if startup := hardening.Startup(); startup != nil {
+		for _, p := range startup.Protections {
+			if !p.Enabled {
+				fmt.Printf("  at startup %s: %s\n", p.Name, p.Detail)
+			}
+		}
+	}
+	if err := mnemonic.CheckRNG(); err != nil {
+		fmt.Printf("  %-22s %s  %v\n", "RNG self-test", r.template.Error("fail"), err)
+	} else {
+		fmt.Printf("  %-22s %s\n", "RNG self-test", r.template.Success("pass"))
+	}
+	fmt.Println()
+	r.printSecuritySummary()
+	return nil
+}
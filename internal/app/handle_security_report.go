@@ -0,0 +1,314 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// reencryptAction 重新加密在审计日志中的操作名，security.report 从中读取历次的参数变化
+const reencryptAction = "security.reencrypt"
+
+// cryptoArtifact 一个加密字段的格式和参数，与基线比较的结果
+type cryptoArtifact struct {
+	Name          string `json:"name"`
+	Version       int    `json:"version"` // 0 为旧格式
+	Cipher        string `json:"cipher,omitempty"`
+	KDF           string `json:"kdf,omitempty"`
+	BelowBaseline string `json:"below_baseline,omitempty"` // 低于基线的原因
+	Error         string `json:"error,omitempty"`
+}
+
+// cryptoGroup 同一格式、算法和参数的字段数
+type cryptoGroup struct {
+	Scheme string `json:"scheme"`
+	Count  int    `json:"count"`
+}
+
+// reencryptRecord 一次重新加密的记录，保存在审计日志的附加数据中
+type reencryptRecord struct {
+	Time     string         `json:"time,omitempty"`
+	Baseline string         `json:"baseline"`
+	From     map[string]int `json:"from"` // 重新加密前的算法和参数及字段数
+	Migrated int            `json:"migrated"`
+	Skipped  int            `json:"skipped,omitempty"`
+}
+
+// cryptoReport security.report 的结果，--json 原样输出
+type cryptoReport struct {
+	Baseline  string            `json:"baseline"`
+	Weakness  string            `json:"weakness,omitempty"` // 基线本身低于推荐强度的原因
+	Artifacts []cryptoArtifact  `json:"artifacts"`
+	Groups    []cryptoGroup     `json:"groups"`
+	Below     int               `json:"below_baseline"`
+	History   []reencryptRecord `json:"history"`
+}
+
+// 加密参数报告命令处理函数：按字段列出算法、KDF 和参数（从信封头部读取，不需要密码），
+// 标出低于当前加密配置的字段；--reencrypt 用当前配置重新加密这些字段
+func (r *REPL) handleSecurityReport(args []string) error {
+	asJSON, reencrypt := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--reencrypt":
+			reencrypt = true
+		default:
+			return r.usageError("security.report")
+		}
+	}
+	if reencrypt && asJSON {
+		return r.usageError("security.report")
+	}
+	report, err := r.cryptoReport()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	r.printCryptoReport(report)
+	if !reencrypt {
+		if report.Below > 0 {
+			fmt.Println(r.template.Info("Run security.report --reencrypt to re-encrypt them with the baseline"))
+		}
+		return nil
+	}
+	if report.Below == 0 {
+		fmt.Println(r.template.Success("Nothing to re-encrypt, everything meets the baseline"))
+		return nil
+	}
+	return r.reencryptBelowBaseline(report)
+}
+
+// cryptoReport 读取钱包、账户、地址和门罗币查看密钥的加密参数
+func (r *REPL) cryptoReport() (*cryptoReport, error) {
+	baseline := crypto.CurrentEnvelope()
+	report := &cryptoReport{Baseline: scheme(baseline), Weakness: crypto.CurrentKDFWeakness()}
+	ciphertexts, err := r.walletMgr.Ciphertexts()
+	if err != nil {
+		return nil, err
+	}
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return nil, err
+	}
+	for _, wallet := range wallets.List() {
+		info, err := crypto.InspectCiphertext(wallet.ViewKey)
+		ciphertexts = append(ciphertexts, core.Ciphertext{Name: "xmr view key " + wallet.Address[:12], Info: info, Err: err})
+	}
+
+	counts := make(map[string]int)
+	for _, c := range ciphertexts {
+		artifact := cryptoArtifact{Name: c.Name, Version: c.Info.Version, Cipher: c.Info.Cipher, KDF: c.Info.KDF}
+		if c.Err != nil {
+			artifact.Error = c.Err.Error()
+		} else {
+			artifact.BelowBaseline = c.Info.BelowBaseline()
+			counts[scheme(c.Info)]++
+		}
+		if artifact.BelowBaseline != "" {
+			report.Below++
+		}
+		report.Artifacts = append(report.Artifacts, artifact)
+	}
+	for s, n := range counts {
+		report.Groups = append(report.Groups, cryptoGroup{Scheme: s, Count: n})
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Count != report.Groups[j].Count {
+			return report.Groups[i].Count > report.Groups[j].Count
+		}
+		return report.Groups[i].Scheme < report.Groups[j].Scheme
+	})
+
+	entries, err := audit.ReadAll(filepath.Join(r.baseDir(), audit.FileName))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		var record reencryptRecord
+		if entry.Action != reencryptAction || json.Unmarshal(entry.Data, &record) != nil {
+			continue
+		}
+		record.Time = entry.Time
+		report.History = append(report.History, record)
+	}
+	return report, nil
+}
+
+func (r *REPL) printCryptoReport(report *cryptoReport) {
+	fmt.Printf("Baseline: %s\n", report.Baseline)
+	if report.Weakness != "" {
+		fmt.Println(r.template.Warning("The baseline itself is weak: " + report.Weakness))
+	}
+	fmt.Println()
+	fmt.Printf("  %-34s %-7s %-18s %-36s %s\n", "ARTIFACT", "FORMAT", "CIPHER", "KDF", "POLICY")
+	for _, a := range report.Artifacts {
+		format, cipher, kdf := fmt.Sprintf("v%d", a.Version), a.Cipher, a.KDF
+		if a.Version == 0 {
+			format, cipher, kdf = "legacy", "-", "-"
+		}
+		policy := r.template.Success("ok")
+		switch {
+		case a.Error != "":
+			policy = r.template.Error(a.Error)
+		case a.BelowBaseline != "":
+			policy = r.template.Warning(a.BelowBaseline)
+		}
+		fmt.Printf("  %-34s %-7s %-18s %-36s %s\n", a.Name, format, cipher, kdf, policy)
+	}
+
+	fmt.Println()
+	fmt.Println("By scheme:")
+	for _, g := range report.Groups {
+		fmt.Printf("  %6d  %s\n", g.Count, g.Scheme)
+	}
+	if len(report.History) > 0 {
+		fmt.Println()
+		fmt.Println("Re-encryption history:")
+		for _, h := range report.History {
+			at := h.Time
+			if t, err := time.Parse(time.RFC3339, h.Time); err == nil {
+				at = r.format().Date(t)
+			}
+			fmt.Printf("  %s  %d re-encrypted to %s (from %s)\n", at, h.Migrated, h.Baseline, formatSchemes(h.From))
+		}
+	}
+	fmt.Println()
+	if report.Below == 0 {
+		fmt.Println(r.template.Success(fmt.Sprintf("All %d encrypted records meet the baseline", len(report.Artifacts))))
+	} else {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d of %d encrypted records are below the baseline", report.Below, len(report.Artifacts))))
+	}
+}
+
+// reencryptBelowBaseline 用当前加密配置重新加密低于基线的字段：钱包文件先备份，每个字段重新加密后核对，
+// 门罗币查看密钥逐个替换；结果写入审计日志，供之后的报告显示
+func (r *REPL) reencryptBelowBaseline(report *cryptoReport) error {
+	if readOnlyMode() {
+		return core.ErrReadOnly
+	}
+	password, err := r.walletMgr.Password()
+	if err != nil {
+		return err
+	}
+	defer security.WipeSensitiveData(password)
+
+	record := reencryptRecord{Baseline: report.Baseline, From: make(map[string]int)}
+	for _, a := range report.Artifacts {
+		if a.BelowBaseline != "" {
+			from := "legacy"
+			if a.Version != 0 {
+				from = a.Cipher + ", " + a.KDF
+			}
+			record.From[from]++
+		}
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Re-encrypting %d records with %s. "+
+		"The wallet files are backed up first and each record is decrypted and compared before the old one is replaced", report.Below, report.Baseline)))
+	progress := func(done, total int) {
+		if done == total || done*10/total != (done-1)*10/total {
+			fmt.Printf("  %d/%d\n", done, total)
+		}
+	}
+	result, err := r.walletMgr.UpgradeCiphertexts(string(password), progress)
+	if result != nil {
+		record.Migrated, record.Skipped = result.Migrated, len(result.Skipped)
+	}
+	if err == nil {
+		var viewKeys, skipped int
+		viewKeys, skipped, err = r.reencryptViewKeys(string(password))
+		record.Migrated += viewKeys
+		record.Skipped += skipped
+	}
+
+	logger := audit.ForDir(r.baseDir())
+	data, _ := json.Marshal(record)
+	if err != nil {
+		logger.RecordData("repl", reencryptAction, "", err.Error(), data)
+		message := fmt.Sprintf("Re-encryption stopped: %v. Nothing is lost, records keep working with their old parameters", err)
+		if result != nil && result.Backup != "" {
+			message += "; the files as they were before are in " + result.Backup
+		}
+		return errors.New(message)
+	}
+	if _, err := logger.RecordData("repl", reencryptAction, "", "ok", data); err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Failed to record the re-encryption in the audit log: %v", err)))
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Re-encrypted %d records with %s, all verified", record.Migrated, report.Baseline)))
+	if result != nil && len(result.Skipped) > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d records do not decrypt with the wallet password and were left unchanged: %s",
+			len(result.Skipped), strings.Join(result.Skipped, ", "))))
+	}
+	return nil
+}
+
+// reencryptViewKeys 重新加密低于基线的门罗币查看密钥，返回重新加密和无法解密的数量
+func (r *REPL) reencryptViewKeys(password string) (int, int, error) {
+	wallets, err := r.moneroWallets()
+	if err != nil {
+		return 0, 0, err
+	}
+	migrated, skipped := 0, 0
+	for _, wallet := range wallets.List() {
+		if !crypto.NeedsReencryption(wallet.ViewKey) {
+			continue
+		}
+		sealed, err := core.Reencrypt(wallet.ViewKey, password)
+		if errors.Is(err, crypto.ErrDecryptionFailed) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return migrated, skipped, fmt.Errorf("xmr view key %s: %w", wallet.Address[:12], err)
+		}
+		if err := wallets.SetViewKey(wallet.Address, sealed); err != nil {
+			return migrated, skipped, err
+		}
+		migrated++
+	}
+	return migrated, skipped, nil
+}
+
+// scheme 格式、算法和 KDF 参数的简短描述
+func scheme(info crypto.EnvelopeInfo) string {
+	if info.Legacy() {
+		return "legacy"
+	}
+	return info.Cipher + ", " + info.KDF
+}
+
+// formatSchemes 按字段数从多到少列出算法和参数
+func formatSchemes(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	return strings.Join(parts, "; ")
+}
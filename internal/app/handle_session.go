@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/redact"
+	"github.com/palagend/slowmade/internal/transcript"
+)
+
+// secretArguments 参数里可能带密码或助记词的命令，记录时整段参数都会被遮盖
+var secretArguments = map[string]bool{
+	"wallet.create": true, "wallet.restore": true, "wallet.unlock": true,
+}
+
+// redactArguments 返回写入会话记录的命令行
+func redactArguments(parts []string) string {
+	if secretArguments[strings.ToLower(parts[0])] && len(parts) > 1 {
+		return parts[0] + " " + redact.Mask
+	}
+	return strings.Join(parts, " ")
+}
+
+// keepInHistory 带参数的密码和助记词命令不写入历史，即使参数随后被拒绝；链式命令中任何一条带有时都不写入
+func keepInHistory(input string) bool {
+	tokens, err := tokenize(input)
+	if err != nil {
+		parts := strings.Fields(input)
+		return len(parts) < 2 || !secretArguments[strings.ToLower(parts[0])]
+	}
+	commands, _ := splitChain(tokens)
+	for _, command := range commands {
+		if len(command) > 1 && secretArguments[strings.ToLower(command[0].text)] {
+			return false
+		}
+	}
+	return true
+}
+
+// 会话记录命令处理函数，把之后的命令和输出记录到文件，助记词、私钥和密码会被遮盖
+func (r *REPL) handleSessionRecord(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("session.record")
+	}
+	if r.transcript != nil {
+		return fmt.Errorf("already recording to %s, run session.stop first", r.transcript.Path())
+	}
+	path, err := r.confirmOutputFile(args[0], false)
+	if err != nil {
+		return err
+	}
+	recorder, err := transcript.Start(path)
+	if err != nil {
+		return fmt.Errorf("failed to start recording: %w", err)
+	}
+	r.transcript = recorder
+	fmt.Println(r.template.Success(fmt.Sprintf("Recording session to %s", path)))
+	fmt.Println(r.template.Info("Mnemonics, private keys, 32-byte hex strings and passwords are redacted; review the file before sharing it"))
+	return nil
+}
+
+// 停止会话记录命令处理函数
+func (r *REPL) handleSessionStop(args []string) error {
+	if r.transcript == nil {
+		return fmt.Errorf("no session is being recorded")
+	}
+	path := r.transcript.Path()
+	count, err := r.transcript.Close()
+	r.transcript = nil
+	if err != nil {
+		return fmt.Errorf("failed to close transcript: %w", err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Recorded %d commands to %s", count, path)))
+	return nil
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/web"
+)
+
+// 分享链接的默认和最长有效期
+const (
+	defaultShareTTL = 24 * time.Hour
+	maxShareTTL     = 30 * 24 * time.Hour
+)
+
+// 分享链接命令处理函数：为账户生成带访问密码的只读链接，对方在 serve 启动的 Web 服务器上查看收款地址和二维码
+func (r *REPL) handleShareCreate(args []string) error {
+	ttl := defaultShareTTL
+	var name string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--ttl" && i+1 < len(args):
+			i++
+			d, err := parseShareTTL(args[i])
+			if err != nil {
+				return err
+			}
+			ttl = d
+		case args[i] == "--name" && i+1 < len(args):
+			i++
+			name = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) != 1 {
+		return r.usageError("share.create")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(rest[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	password, err := r.passwordPrompt().ReadNew("Share link password: ")
+	if err != nil {
+		return err
+	}
+	share, secret, err := web.NewShareStore(r.baseDir()).Create(name, account.ID, account.CoinSymbol, password, ttl)
+	if err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "share.create", share.ID, "ok")
+
+	fmt.Println(r.template.Success(fmt.Sprintf("Created share link %s for %s account %s", share.ID, account.CoinSymbol, account.ID)))
+	fmt.Printf("Link:    %s\n", shareLink(share.ID, secret))
+	fmt.Printf("Expires: %s\n", r.format().Date(time.Unix(share.ExpiresAt, 0)))
+	fmt.Println(r.template.Info("The link is shown only once. Send the password through a different channel; the page is served by `slowmade serve`"))
+	return nil
+}
+
+// 分享链接列表命令处理函数
+func (r *REPL) handleShareList(args []string) error {
+	if len(args) != 0 {
+		return r.usageError("share.list")
+	}
+	shares, err := web.NewShareStore(r.baseDir()).List()
+	if err != nil {
+		return err
+	}
+	if len(shares) == 0 {
+		fmt.Println("No share links, create one with share.create")
+		return nil
+	}
+	format := r.format()
+	now := time.Now()
+	fmt.Printf("%-8s  %-5s  %-8s  %-20s  %-20s  %s\n", "ID", "COIN", "STATUS", "CREATED", "EXPIRES", "NAME / ACCOUNT")
+	for _, share := range shares {
+		status := "active"
+		switch {
+		case share.Revoked:
+			status = "revoked"
+		case share.Expired(now):
+			status = "expired"
+		}
+		label := share.AccountID
+		if share.Name != "" {
+			label = share.Name + " / " + share.AccountID
+		}
+		fmt.Printf("%-8s  %-5s  %-8s  %-20s  %-20s  %s\n", share.ID, share.Coin, status,
+			format.Date(time.Unix(share.CreatedAt, 0)), format.Date(time.Unix(share.ExpiresAt, 0)), label)
+	}
+	return nil
+}
+
+// 吊销分享链接命令处理函数，对方再打开或刷新页面时不再显示地址
+func (r *REPL) handleShareRevoke(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("share.revoke")
+	}
+	if err := web.NewShareStore(r.baseDir()).Revoke(args[0]); err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "share.revoke", args[0], "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Revoked share link %s", args[0])))
+	return nil
+}
+
+// parseShareTTL 解析有效期：Go 时长（90m、12h）或天数（7d），最长 30 天
+func parseShareTTL(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d < time.Minute || d > maxShareTTL {
+		return 0, fmt.Errorf("invalid ttl %q (1m to 30d, e.g. 12h or 7d)", s)
+	}
+	return d, nil
+}
+
+// shareLink 对方打开的链接，密钥在查询参数中，服务器日志只记录路径
+func shareLink(id, secret string) string {
+	webConfig := config.GetAppConfig().Web
+	base := strings.TrimSuffix(webConfig.PublicURL, "/")
+	if base == "" {
+		base = fmt.Sprintf("http://%s:%d", webConfig.Host, webConfig.Port)
+	}
+	return base + "/share/" + url.PathEscape(id) + "?key=" + url.QueryEscape(secret)
+}
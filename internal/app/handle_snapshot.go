@@ -0,0 +1,295 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/airgap"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// snapshotCoins 行情快照记录价格的币种，即离线签名支持的币种
+var snapshotCoins = []string{"BTC", "ETH"}
+
+// snapshotFeeTargets 行情快照记录的 BTC 确认目标（区块数）
+var snapshotFeeTargets = []int{2, 6}
+
+// snapshotFeeFactor 手续费高于快照的这个倍数、或低于快照的这个分之一时提醒
+const snapshotFeeFactor = 2
+
+// 行情快照命令处理函数，在联网实例上导出签名的手续费和汇率，供离线签名端核对
+func (r *REPL) handleAirgapSnapshot(args []string) error {
+	appConfig := config.GetAppConfig()
+	currency := appConfig.GetTaxConfig().Currency
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--currency" && i+1 < len(args) {
+			currency = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	positional, opts, ok := parseAirgapArgs(rest, false)
+	if !ok || len(positional) != 0 {
+		return r.usageError("airgap.snapshot")
+	}
+	if currency == "" {
+		currency = "USD"
+	}
+	currency = strings.ToUpper(currency)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	snapshot := airgap.NewSnapshot(currency)
+	if backend, err := btc.NewBackend(appConfig.GetBitcoinConfig()); err == nil {
+		for _, blocks := range snapshotFeeTargets {
+			rate, err := backend.EstimateFeeRate(ctx, blocks)
+			if err != nil {
+				fmt.Println(r.template.Warning(fmt.Sprintf("BTC fee rate for %d blocks unavailable: %v", blocks, err)))
+				continue
+			}
+			snapshot.BTCFeeRates[strconv.Itoa(blocks)] = rate
+		}
+	} else if !errors.Is(err, btc.ErrNotConfigured) {
+		return err
+	}
+	if client, err := chain.ForCoin("ETH", appConfig); err == nil {
+		if pricer, ok := client.(chain.GasPricer); ok {
+			if gasPrice, err := pricer.GasPrice(ctx); err == nil {
+				snapshot.ETHGasPrice = gasPrice.String()
+			} else {
+				fmt.Println(r.template.Warning(fmt.Sprintf("ETH gas price unavailable: %v", err)))
+			}
+		}
+	}
+	if prices, err := r.priceService(); err == nil {
+		for _, symbol := range snapshotCoins {
+			quote, err := prices.Quote(ctx, symbol, currency)
+			if err != nil {
+				fmt.Println(r.template.Warning(fmt.Sprintf("%s/%s rate unavailable: %v", symbol, currency, err)))
+				continue
+			}
+			snapshot.Prices[symbol] = quote.Rate.FloatString(8)
+		}
+	} else {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Prices unavailable: %v", err)))
+	}
+	if len(snapshot.BTCFeeRates) == 0 && snapshot.ETHGasPrice == "" && len(snapshot.Prices) == 0 {
+		return fmt.Errorf("no fee rates or prices available, configure [bitcoin], rpc.eth or [price]")
+	}
+
+	key, created, err := airgap.LoadSnapshotKey(filepath.Join(r.baseDir(), airgap.SnapshotKeyFileName))
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Sign(key); err != nil {
+		return err
+	}
+	data, err := snapshot.Encode()
+	if err != nil {
+		return err
+	}
+	if opts.out == "" {
+		opts.out = "market-snapshot-" + snapshot.CreatedAt.Format("20060102-1504") + ".json"
+	}
+	r.printSnapshot(snapshot)
+	if err := r.writeAirgapMessage(data, opts); err != nil {
+		return err
+	}
+	if created {
+		fmt.Println(r.template.Info("Created a new snapshot signing key"))
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Market snapshot signed by key %s, import it with airgap.import-snapshot on the offline signer", snapshot.Fingerprint())))
+	return nil
+}
+
+// 导入行情快照命令处理函数，在离线签名端校验签名和签名公钥后保存，之后 airgap.sign 据此核对手续费
+func (r *REPL) handleAirgapImportSnapshot(args []string) error {
+	trustNew := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--trust-new-key" {
+			trustNew = true
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			return r.usageError("airgap.import-snapshot")
+		}
+		rest = append(rest, arg)
+	}
+	if len(rest) > 1 {
+		return r.usageError("airgap.import-snapshot")
+	}
+	data, err := r.readAirgapMessage(rest)
+	if err != nil {
+		return err
+	}
+	snapshot, err := airgap.ParseSnapshot(data)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(r.baseDir(), airgap.SnapshotFileName)
+	current, err := airgap.LoadSnapshot(path)
+	if err != nil {
+		logging.Warnf("Ignoring the imported market snapshot: %v", err)
+		current = nil
+	}
+	if err := snapshot.Trust(current, trustNew); err != nil {
+		if errors.Is(err, airgap.ErrSnapshotKey) {
+			return fmt.Errorf("%w, rerun with --trust-new-key if the online instance changed its key", err)
+		}
+		return err
+	}
+	if err := airgap.SaveSnapshot(path, snapshot); err != nil {
+		return err
+	}
+	audit.ForDir(r.baseDir()).Record("repl", "airgap.import-snapshot", snapshot.Fingerprint(), "ok")
+
+	r.printSnapshot(snapshot)
+	if current == nil || current.PublicKey != snapshot.PublicKey {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Trusting snapshot key %s from now on, check it matches the key shown by airgap.snapshot", snapshot.Fingerprint())))
+	}
+	r.warnStaleSnapshot(snapshot)
+	fmt.Println(r.template.Success("Market snapshot imported, airgap.sign will compare fees against it"))
+	return nil
+}
+
+// printSnapshot 显示快照的时间、手续费和汇率
+func (r *REPL) printSnapshot(snapshot *airgap.Snapshot) {
+	fmt.Println(r.template.Info(fmt.Sprintf("Market snapshot %s (%s ago)", r.format().Date(snapshot.CreatedAt),
+		time.Since(snapshot.CreatedAt).Round(time.Minute))))
+	for _, blocks := range snapshotFeeTargets {
+		if rate, ok := snapshot.BTCFeeRates[strconv.Itoa(blocks)]; ok {
+			fmt.Printf("  BTC fee:   %d sat/vB within %d blocks\n", rate, blocks)
+		}
+	}
+	if gasPrice, ok := snapshot.GasPrice(); ok {
+		fmt.Printf("  ETH gas:   %s gwei\n", r.format().Decimal(coin.FormatUnits(gasPrice, gweiDecimals)))
+	}
+	for _, symbol := range snapshotCoins {
+		if rate, ok := snapshot.Price(symbol); ok {
+			fmt.Printf("  %-3s price: %s %s\n", symbol, r.format().Decimal(rate.FloatString(2)), snapshot.Currency)
+		}
+	}
+}
+
+// warnStaleSnapshot 快照超过 airgap.snapshot_max_age_minutes 时提醒
+func (r *REPL) warnStaleSnapshot(snapshot *airgap.Snapshot) {
+	appConfig := config.GetAppConfig()
+	maxAge := time.Duration(appConfig.GetAirgapConfig().SnapshotMaxAgeMinutes) * time.Minute
+	if age := time.Since(snapshot.CreatedAt); maxAge > 0 && age > maxAge {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Market snapshot is %s old (limit %s), fees and prices may have moved, export a new one with airgap.snapshot",
+			age.Round(time.Minute), maxAge)))
+	}
+}
+
+// marketSnapshot 签名端已导入的快照，没有导入或无法读取时返回 nil
+func (r *REPL) marketSnapshot() *airgap.Snapshot {
+	snapshot, err := airgap.LoadSnapshot(filepath.Join(r.baseDir(), airgap.SnapshotFileName))
+	if err != nil {
+		logging.Warnf("Failed to load the market snapshot: %v", err)
+		return nil
+	}
+	return snapshot
+}
+
+// printSnapshotHeader 签名前显示快照的时间和是否过期
+func (r *REPL) printSnapshotHeader() {
+	snapshot := r.marketSnapshot()
+	if snapshot == nil {
+		fmt.Println(r.template.Info("No market snapshot, import one with airgap.import-snapshot to check fees against current rates"))
+		return
+	}
+	fmt.Printf("  Snapshot:  %s (%s ago, key %s)\n", r.format().Date(snapshot.CreatedAt), time.Since(snapshot.CreatedAt).Round(time.Minute), snapshot.Fingerprint())
+	r.warnStaleSnapshot(snapshot)
+}
+
+// fiatValue 按快照价格换算金额，amount 为最小单位
+func fiatValue(rate *big.Rat, amount *big.Int, decimals int) string {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value := new(big.Rat).SetFrac(amount, unit)
+	return value.Mul(value, rate).FloatString(2)
+}
+
+// checkSnapshotBTC 按快照核对 BTC 交易的费率，显示发送金额和手续费的法币价值
+func (r *REPL) checkSnapshotBTC(inputs []btc.UTXO, outputs []btc.TxOut, sent, fee int64) {
+	snapshot := r.marketSnapshot()
+	if snapshot == nil {
+		return
+	}
+	scripts := make([][]byte, len(outputs))
+	for i, out := range outputs {
+		scripts[i] = out.Script
+	}
+	if size, err := btc.EstimateVSize(inputs, scripts); err == nil && size > 0 && len(snapshot.BTCFeeRates) > 0 {
+		rate := fee / int64(size)
+		low, high := int64(0), int64(0)
+		var targets []string
+		for _, blocks := range snapshotFeeTargets {
+			if snapshotRate, ok := snapshot.BTCFeeRates[strconv.Itoa(blocks)]; ok {
+				targets = append(targets, fmt.Sprintf("%d sat/vB within %d blocks", snapshotRate, blocks))
+				if low == 0 || snapshotRate < low {
+					low = snapshotRate
+				}
+				high = max(high, snapshotRate)
+			}
+		}
+		fmt.Printf("  Fee rate:  ~%d sat/vB (snapshot: %s)\n", rate, strings.Join(targets, ", "))
+		switch {
+		case rate > high*snapshotFeeFactor:
+			fmt.Println(r.template.Warning(fmt.Sprintf("Fee rate is more than %dx the snapshot's fastest rate, check the fee", snapshotFeeFactor)))
+		case rate*snapshotFeeFactor < low:
+			fmt.Println(r.template.Warning(fmt.Sprintf("Fee rate is under 1/%d of the snapshot's slowest rate, the transaction may take long to confirm", snapshotFeeFactor)))
+		}
+	}
+	if price, ok := snapshot.Price("BTC"); ok {
+		fmt.Printf("  Value:     %s %s sent, %s %s fee (snapshot price)\n",
+			r.format().Decimal(fiatValue(price, big.NewInt(sent), 8)), snapshot.Currency,
+			r.format().Decimal(fiatValue(price, big.NewInt(fee), 8)), snapshot.Currency)
+	}
+}
+
+// checkSnapshotETH 按快照核对 ETH 交易的 gas 价格，显示金额和最高手续费的法币价值
+func (r *REPL) checkSnapshotETH(args *signer.TransactionArgs) {
+	snapshot := r.marketSnapshot()
+	if snapshot == nil {
+		return
+	}
+	tx, err := args.ToTransaction(nil)
+	if err != nil {
+		return
+	}
+	gasPrice := tx.GasPrice
+	if tx.Type != signer.LegacyTxType {
+		gasPrice = tx.MaxFeePerGas
+	}
+	if reference, ok := snapshot.GasPrice(); ok && reference.Sign() > 0 {
+		fmt.Printf("  Gas price: %s gwei (snapshot: %s gwei)\n",
+			r.format().Decimal(coin.FormatUnits(gasPrice, gweiDecimals)), r.format().Decimal(coin.FormatUnits(reference, gweiDecimals)))
+		switch {
+		case gasPrice.Cmp(new(big.Int).Mul(reference, big.NewInt(snapshotFeeFactor))) > 0:
+			fmt.Println(r.template.Warning(fmt.Sprintf("Gas price is more than %dx the snapshot's, check the fee", snapshotFeeFactor)))
+		case new(big.Int).Mul(gasPrice, big.NewInt(snapshotFeeFactor)).Cmp(reference) < 0:
+			fmt.Println(r.template.Warning(fmt.Sprintf("Gas price is under 1/%d of the snapshot's, the transaction may not be mined", snapshotFeeFactor)))
+		}
+	}
+	if price, ok := snapshot.Price("ETH"); ok {
+		maxFee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(tx.Gas))
+		fmt.Printf("  Value:     %s %s sent, up to %s %s fee (snapshot price)\n",
+			r.format().Decimal(fiatValue(price, tx.Value, 18)), snapshot.Currency,
+			r.format().Decimal(fiatValue(price, maxFee, 18)), snapshot.Currency)
+	}
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/walletstats"
+)
+
+// 会话统计命令处理函数，汇总本次运行执行的命令、失败、耗时、解锁尝试和签名；--prometheus 输出导出格式，
+// stats wallet 显示钱包概况
+func (r *REPL) handleStats(args []string) error {
+	if len(args) > 0 && args[0] == "wallet" {
+		return r.handleWalletStats(args[1:])
+	}
+	prometheus := false
+	for _, arg := range args {
+		switch arg {
+		case "--prometheus":
+			prometheus = true
+		default:
+			return r.usageError("stats")
+		}
+	}
+	if prometheus {
+		return metrics.WriteText(os.Stdout)
+	}
+
+	type commandStats struct {
+		calls, failures uint64
+		timing          metrics.Timing
+	}
+	commands := make(map[string]*commandStats)
+	unlocks := make(map[string]uint64)
+	signatures := make(map[string]uint64)
+	var total, failures, derived uint64
+	for _, sample := range metrics.Snapshot() {
+		switch sample.Name {
+		case metrics.Commands:
+			name := sample.Get("command")
+			if commands[name] == nil {
+				commands[name] = &commandStats{}
+			}
+			commands[name].calls += sample.Value
+			total += sample.Value
+			if sample.Get("result") != "ok" {
+				commands[name].failures += sample.Value
+				failures += sample.Value
+			}
+		case metrics.Unlocks:
+			unlocks[sample.Get("result")] += sample.Value
+		case metrics.Signatures:
+			signatures[sample.Get("method")] += sample.Value
+		case metrics.Derivations:
+			derived += sample.Value
+		}
+	}
+
+	for _, timing := range metrics.Timings() {
+		if stats := commands[timing.Get("command")]; timing.Name == metrics.CommandSeconds && stats != nil {
+			stats.timing = timing
+		}
+	}
+
+	elapsed := time.Since(metrics.Started())
+	fmt.Printf("Session started %s (%s ago)\n", r.format().Date(metrics.Started()), elapsed.Round(time.Second))
+	fmt.Printf("Commands:          %d (%d failed, %.1f/min)\n", total, failures, float64(total)/max(elapsed.Minutes(), 1))
+	fmt.Printf("Unlock attempts:   %d (%d failed)\n", unlocks["ok"]+unlocks["error"], unlocks["error"])
+	fmt.Printf("Addresses derived: %d\n", derived)
+	var signed uint64
+	for _, n := range signatures {
+		signed += n
+	}
+	fmt.Printf("Signatures:        %d\n", signed)
+	for _, method := range sortedKeys(signatures) {
+		fmt.Printf("  %-28s %6d\n", method, signatures[method])
+	}
+
+	if len(commands) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	// 调用最多的命令在前
+	sort.Slice(names, func(i, j int) bool {
+		if commands[names[i]].calls != commands[names[j]].calls {
+			return commands[names[i]].calls > commands[names[j]].calls
+		}
+		return names[i] < names[j]
+	})
+	fmt.Println()
+	// 耗时不含等待输入的时间；只读模式下被拒绝的命令没有耗时
+	fmt.Printf("  %-28s %6s %8s %9s %9s\n", "COMMAND", "CALLS", "FAILURES", "AVG", "MAX")
+	for _, name := range names {
+		stats := commands[name]
+		fmt.Printf("  %-28s %6d %8d %9s %9s\n", name, stats.calls, stats.failures,
+			formatElapsed(stats.timing.Mean()), formatElapsed(stats.timing.Max))
+	}
+	return nil
+}
+
+// handleWalletStats 显示各币种的账户数、每个账户的地址数、存储占用、最近一次备份和解锁以及加密参数；--json 输出与 /api/v1/stats 相同的结构
+func (r *REPL) handleWalletStats(args []string) error {
+	asJSON := false
+	for _, arg := range args {
+		if arg != "--json" {
+			return r.usageError("stats")
+		}
+		asJSON = true
+	}
+	stats, err := walletstats.Collect(r.baseDir(), r.walletMgr, r.accountMgr)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	date := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s (%s ago)", r.format().Date(*t), time.Since(*t).Round(time.Second))
+	}
+	fmt.Printf("Created:       %s\n", date(stats.Created))
+	fmt.Printf("Modified:      %s\n", date(stats.Modified))
+	fmt.Printf("Storage:       %s in %d files\n", formatSize(stats.StorageBytes), stats.StorageFiles)
+	if last := stats.LastBackup; last != nil {
+		fmt.Printf("Last backup:   %s via %s\n", date(&last.At), last.Method)
+	} else {
+		fmt.Println("Last backup:   none recorded")
+	}
+	if last := stats.LastUnlock; last != nil {
+		fmt.Printf("Last unlock:   %s from %s\n", date(&last.At), last.Source)
+	} else {
+		fmt.Println("Last unlock:   none recorded")
+	}
+	fmt.Printf("Encryption:    %s\n", stats.Encryption)
+	fmt.Printf("KDF:           %s\n", stats.KDF)
+	if stats.Locked {
+		fmt.Println(r.template.Info("Unlock the wallet to count accounts and addresses"))
+		return nil
+	}
+
+	fmt.Printf("Accounts:      %d\n", len(stats.Accounts))
+	coins := make([]string, 0, len(stats.Coins))
+	for coin := range stats.Coins {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	for _, coin := range coins {
+		fmt.Printf("  %-28s %6d\n", coin, stats.Coins[coin])
+	}
+	fmt.Printf("Addresses:     %d\n", stats.Addresses)
+	if len(stats.Accounts) == 0 {
+		return nil
+	}
+	ids := make([]string, len(stats.Accounts))
+	for i, account := range stats.Accounts {
+		ids[i] = account.ID
+	}
+	shortIDs := core.ShortIDs(ids)
+	fmt.Println()
+	fmt.Printf("  %-10s %-6s %-18s %9s  %s\n", "ACCOUNT", "COIN", "PATH", "ADDRESSES", "CREATED")
+	for _, account := range stats.Accounts {
+		created := "-"
+		if account.Created != nil {
+			created = r.format().Date(*account.Created)
+		}
+		fmt.Printf("  %-10s %-6s %-18s %9d  %s\n", shortIDs[account.ID], account.Coin, account.Path, account.Addresses, created)
+	}
+	return nil
+}
+
+// formatSize 以 1024 为进制显示字节数
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatElapsed 命令耗时，一秒以内显示毫秒
+func formatElapsed(elapsed time.Duration) string {
+	switch {
+	case elapsed == 0:
+		return "-"
+	case elapsed < time.Millisecond:
+		return elapsed.Round(time.Microsecond).String()
+	case elapsed < time.Second:
+		return elapsed.Round(time.Millisecond).String()
+	}
+	return elapsed.Round(10 * time.Millisecond).String()
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
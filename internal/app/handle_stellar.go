@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/stellar"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// minCreateBalance 新账户至少需要两倍基础储备，即 1 XLM
+const minCreateBalance = 10_000_000
+
+// horizon 按 [stellar] 配置创建 Horizon 客户端
+func horizon() *stellar.Horizon {
+	appConfig := config.GetAppConfig()
+	return stellar.NewHorizon(appConfig.GetStellarConfig().HorizonURL)
+}
+
+// confirmUntagged 收款方不是本钱包时，没有备注或目标标签的转账需要确认：交易所按它入账，缺少时资金可能无法找回
+func (r *REPL) confirmUntagged(field, to string) error {
+	if _, own := r.accountMgr.IsMine(to); own {
+		return nil
+	}
+	fmt.Println(r.template.Warning(fmt.Sprintf("No %s: exchanges and custodial wallets credit deposits by %s, "+
+		"sending to one without it can lose the funds", field, field)))
+	answer, err := r.line.Prompt(fmt.Sprintf("Send without a %s? [y/N]: ", field))
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("cancelled, add the recipient's %s", field)
+	}
+	return nil
+}
+
+// XLM 余额命令处理函数
+func (r *REPL) handleXLMBalance(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("xlm.balance")
+	}
+	addr, err := r.coinAddress(args[0], "XLM")
+	if err != nil {
+		return err
+	}
+	client := horizon()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	account, err := client.Account(ctx, addr.Address)
+	if errors.Is(err, stellar.ErrAccountNotFound) {
+		fmt.Printf("%s\n  XLM: 0 (not funded yet, the first payment must be at least 1 XLM)\n", addr.Address)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch account from %s: %v", client.Name(), err)
+	}
+	fmt.Printf("%s\n", addr.Address)
+	fmt.Printf("  XLM: %s\n", r.format().Decimal(coin.FormatUnits(account.Balance, stellar.Decimals)))
+	return nil
+}
+
+// XLM 发送命令处理函数：收款账户未激活时改用 create_account，收款方按 SEP-29 要求备注时必须带 --memo 或 --memo-id
+func (r *REPL) handleXLMSend(args []string) error {
+	usage := r.usageError("xlm.send")
+	if len(args) < 3 {
+		return usage
+	}
+	var (
+		memo      stellar.Memo
+		broadcast bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == "--broadcast":
+			broadcast = true
+		case args[i] == "--memo" && i+1 < len(args) && memo.Empty():
+			i++
+			if len(args[i]) > stellar.MaxMemoText {
+				return fmt.Errorf("memo text is longer than %d bytes, use --memo-id for numeric memos", stellar.MaxMemoText)
+			}
+			memo.Text = args[i]
+		case args[i] == "--memo-id" && i+1 < len(args) && memo.Empty():
+			i++
+			id, err := strconv.ParseUint(args[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid memo id %q", args[i])
+			}
+			memo.ID, memo.IsID = id, true
+		default:
+			return usage
+		}
+	}
+
+	from, err := r.coinAddress(args[0], "XLM")
+	if err != nil {
+		return err
+	}
+	source, err := stellar.DecodeAccount(from.Address)
+	if err != nil {
+		return err
+	}
+	recipient, err := r.resolveRecipient(args[1], "XLM")
+	if err != nil {
+		return err
+	}
+	destination, err := stellar.DecodeAccount(recipient)
+	if err != nil {
+		return err
+	}
+	to, _ := stellar.EncodeAccount(destination)
+	amount, err := coin.ParseUnits(args[2], stellar.Decimals)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() <= 0 || !amount.IsInt64() {
+		return stellar.ErrInvalidAmount
+	}
+
+	client := horizon()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	sender, err := client.Account(ctx, from.Address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %v", from.Address, client.Name(), err)
+	}
+	createAccount := false
+	receiver, err := client.Account(ctx, to)
+	switch {
+	case errors.Is(err, stellar.ErrAccountNotFound):
+		if amount.Int64() < minCreateBalance {
+			return fmt.Errorf("%s is not funded yet, the first payment must be at least 1 XLM", to)
+		}
+		createAccount = true
+	case err != nil:
+		return fmt.Errorf("failed to fetch %s from %s: %v", to, client.Name(), err)
+	case receiver.MemoRequired && memo.Empty():
+		return fmt.Errorf("%s requires a memo (SEP-29), add the --memo or --memo-id the recipient gave you", to)
+	}
+	if memo.Empty() {
+		if err := r.confirmUntagged("memo", to); err != nil {
+			return err
+		}
+	}
+	fee, err := client.BaseFee(ctx)
+	if err != nil {
+		logging.Warnf("Failed to fetch base fee from %s: %v", client.Name(), err)
+	}
+	fee = max(fee, 100)
+
+	value := new(big.Rat).SetFrac(amount, big.NewInt(10_000_000))
+	if err := r.checkTransfer("xlm_signTransaction", from.Address, "XLM", to, value); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", "xlm_signTransaction", "", err.Error())
+		return err
+	}
+	appConfig := config.GetAppConfig()
+	expires := time.Now().Add(5 * time.Minute)
+	tx := &stellar.Payment{Source: source, Destination: destination, Amount: amount.Int64(), Fee: fee,
+		Sequence: sender.Sequence + 1, MaxTime: uint64(expires.Unix()), Memo: memo, CreateAccount: createAccount,
+		Network: appConfig.GetStellarConfig().NetworkPassphrase}
+	if err := r.signStellar(tx, from); err != nil {
+		return err
+	}
+	encoded, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+
+	target := to
+	if to != args[1] {
+		target = fmt.Sprintf("%s (%s)", to, args[1])
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s XLM to %s", r.format().Decimal(coin.FormatUnits(amount, stellar.Decimals)), target)))
+	fmt.Printf("  From:    %s\n", from.Address)
+	if createAccount {
+		fmt.Printf("  Note:    %s is not funded yet, this payment creates the account\n", to)
+	}
+	if !memo.Empty() {
+		fmt.Printf("  Memo:    %s\n", memo)
+	}
+	fmt.Printf("  Fee:     %d stroops\n", fee)
+	fmt.Printf("  Expires: %s\n", r.format().Date(expires))
+	fmt.Printf("  Hash:    %s\n", tx.Hash())
+	fmt.Printf("  XDR:     %s\n", base64.StdEncoding.EncodeToString(encoded))
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it before it expires"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := client.Submit(ctx, tx)
+	if err != nil {
+		logger.Record("repl", "xlm.send", tx.Hash(), err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "xlm.send", sent, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, "xlm.send")
+	if err := r.recordSpend(watch.Spend{Coin: "XLM", AccountID: from.AccountID, TxID: sent, To: to, Amount: amount.String(),
+		Fee: strconv.FormatUint(uint64(fee), 10), SentAt: time.Now().UTC(), Command: "xlm.send"}); err != nil {
+		logging.Warnf("Failed to record spend %s: %v", sent, err)
+	}
+	return nil
+}
+
+// signStellar 以地址私钥为 ed25519 种子签名交易
+func (r *REPL) signStellar(tx *stellar.Payment, from *core.AddressKey) error {
+	key, err := r.accountMgr.AddressPrivateKey(from)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	// Sign 签名后会清除这份副本
+	if err := tx.Sign(append([]byte(nil), key.Bytes()...)); err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", "xlm_signTransaction")
+	return nil
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/substrate"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// substrateAddress 解析本钱包的 DOT 或 KSM 地址（地址本身或账户），返回地址和节点配置
+func (r *REPL) substrateAddress(arg string) (*core.AddressKey, config.SubstrateNetworkConfig, error) {
+	symbol := ""
+	if addr, ok := r.accountMgr.IsMine(arg); ok {
+		symbol = addr.CoinSymbol
+	} else if accountID, err := r.resolveAccountID(arg); err != nil {
+		return nil, config.SubstrateNetworkConfig{}, err
+	} else if account, err := r.findAccount(accountID); err == nil {
+		symbol = account.CoinSymbol
+	}
+	appConfig := config.GetAppConfig()
+	network, ok := appConfig.GetSubstrateConfig(symbol)
+	if symbol != "" && !ok {
+		return nil, network, fmt.Errorf("%s is not a Substrate coin, or substrate.%s.rpc_url is not set", symbol, strings.ToLower(symbol))
+	}
+	addr, err := r.coinAddress(arg, symbol)
+	return addr, network, err
+}
+
+// Substrate 余额命令处理函数
+func (r *REPL) handleSubstrateBalance(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("substrate.balance")
+	}
+	addr, network, err := r.substrateAddress(args[0])
+	if err != nil {
+		return err
+	}
+	client := substrate.NewClient(network.RPCURL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	balance, err := client.Balance(ctx, addr.Address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance from %s: %v", client.Name(), err)
+	}
+	info, _ := coin.LookupSymbol(addr.CoinSymbol)
+	fmt.Printf("%s\n", addr.Address)
+	fmt.Printf("  %s: %s (free)\n", addr.CoinSymbol, r.format().Decimal(coin.FormatUnits(balance, info.Decimal)))
+	return nil
+}
+
+// Substrate 发送命令处理函数：用 transfer_keep_alive 转账，转出后账户仍保留存在性押金
+func (r *REPL) handleSubstrateSend(args []string) error {
+	usage := r.usageError("substrate.send")
+	if len(args) < 3 {
+		return usage
+	}
+	var (
+		tip       = new(big.Int)
+		broadcast bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == "--broadcast":
+			broadcast = true
+		case args[i] == "--tip" && i+1 < len(args):
+			i++
+			if _, ok := tip.SetString(args[i], 10); !ok || tip.Sign() < 0 {
+				return fmt.Errorf("invalid tip %q, expected planck", args[i])
+			}
+		default:
+			return usage
+		}
+	}
+
+	from, network, err := r.substrateAddress(args[0])
+	if err != nil {
+		return err
+	}
+	symbol := from.CoinSymbol
+	plugin, ok := core.LookupCoinPlugin(symbol)
+	if !ok {
+		return fmt.Errorf("%s is not a Substrate coin", symbol)
+	}
+	decimals := plugin.Info().Decimal
+	recipient, err := r.resolveRecipient(args[1], symbol)
+	if err != nil {
+		return err
+	}
+	to, err := plugin.NormalizeAddress(recipient)
+	if err != nil {
+		return err
+	}
+	_, toID, err := substrate.Decode(to)
+	if err != nil {
+		return err
+	}
+	fromID, err := hex.DecodeString(from.PublicKey)
+	if err != nil || len(fromID) != 32 {
+		return fmt.Errorf("address %s has no ed25519 public key, derive it again", from.Address)
+	}
+	amount, err := coin.ParseUnits(args[2], decimals)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() <= 0 {
+		return substrate.ErrInvalidAmount
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	method := strings.ToLower(symbol) + "_signTransaction"
+	if err := r.checkTransfer(method, from.Address, symbol, to, new(big.Rat).SetFrac(amount, scale)); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", method, "", err.Error())
+		return err
+	}
+	client := substrate.NewClient(network.RPCURL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	runtime, err := client.Runtime(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch runtime from %s: %v", client.Name(), err)
+	}
+	runtime.MetadataHash = network.MetadataHash
+	nonce, err := client.Nonce(ctx, from.Address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch nonce from %s: %v", client.Name(), err)
+	}
+	tx := &substrate.Transfer{Pallet: byte(network.BalancesPallet), Call: byte(network.TransferCall),
+		From: fromID, To: toID, Amount: amount, Nonce: nonce, Tip: tip, Runtime: runtime}
+	if err := r.signSubstrate(tx, from, method); err != nil {
+		return err
+	}
+	encoded, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+	hash, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+
+	target := to
+	if to != args[1] {
+		target = fmt.Sprintf("%s (%s)", to, args[1])
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s %s to %s", r.format().Decimal(coin.FormatUnits(amount, decimals)), symbol, target)))
+	fmt.Printf("  From:     %s\n", from.Address)
+	fmt.Printf("  Nonce:    %d, runtime %d, valid for 64 blocks after #%d\n", nonce, runtime.SpecVersion, runtime.BlockNumber)
+	if tip.Sign() > 0 {
+		fmt.Printf("  Tip:      %s %s\n", r.format().Decimal(coin.FormatUnits(tip, decimals)), symbol)
+	}
+	fmt.Printf("  Hash:     %s\n", hash)
+	fmt.Printf("  Raw:      0x%s\n", hex.EncodeToString(encoded))
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it before it expires"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := client.Submit(ctx, tx)
+	if err != nil {
+		logger.Record("repl", "substrate.send", hash, err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "substrate.send", sent, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, "substrate.send")
+	// 手续费由运行时在打包时扣除，这里只记录小费
+	if err := r.recordSpend(watch.Spend{Coin: symbol, AccountID: from.AccountID, TxID: sent, To: to, Amount: amount.String(),
+		Fee: tip.String(), SentAt: time.Now().UTC(), Command: "substrate.send"}); err != nil {
+		logging.Warnf("Failed to record spend %s: %v", sent, err)
+	}
+	return nil
+}
+
+// signSubstrate 以地址私钥为 ed25519 种子签名转账
+func (r *REPL) signSubstrate(tx *substrate.Transfer, from *core.AddressKey, method string) error {
+	key, err := r.accountMgr.AddressPrivateKey(from)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	// Sign 签名后会清除这份副本
+	if err := tx.Sign(append([]byte(nil), key.Bytes()...)); err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", method)
+	return nil
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/signer"
+)
+
+// 清扫命令处理函数：临时使用外部私钥，把它控制的全部资金扣除手续费后转到本钱包的地址。
+// 私钥只保存在内存中，签名后立即清除；省略私钥参数时从隐藏输入读取，避免留在命令历史中
+func (r *REPL) handleSweep(args []string) error {
+	usage := r.usageError("sweep")
+	if len(args) < 3 {
+		return usage
+	}
+	if !strings.EqualFold(args[0], "BTC") {
+		return fmt.Errorf("sweeping %s keys is not supported, only BTC", strings.ToUpper(args[0]))
+	}
+
+	var (
+		keyFormat, keyText, to string
+		feeRate                int64
+		broadcast              bool
+		err                    error
+	)
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--broadcast":
+			broadcast = true
+		case "--wif", "--hex":
+			keyFormat = args[i]
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				keyText = args[i+1]
+				i++
+			}
+		case "--to", "--fee-rate":
+			if i+1 >= len(args) {
+				return usage
+			}
+			if args[i] == "--to" {
+				to = args[i+1]
+			} else if feeRate, err = strconv.ParseInt(args[i+1], 10, 64); err != nil || feeRate <= 0 {
+				return fmt.Errorf("invalid fee rate %q", args[i+1])
+			}
+			i++
+		default:
+			return usage
+		}
+	}
+	if keyFormat == "" || to == "" {
+		return usage
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	target, err := r.sweepTarget(to)
+	if err != nil {
+		return err
+	}
+
+	if keyText == "" {
+		if keyText, err = r.passwordPrompt().Read("Private key: "); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(r.template.Warning("The key is in this session's command history, prefer entering it at the hidden prompt"))
+	}
+	var key []byte
+	if keyFormat == "--wif" {
+		key, err = btc.ParseWIF(keyText)
+	} else {
+		key, err = btc.ParseHexKey(keyText)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+	sources, err := btc.KeyAddresses(key)
+	if err != nil {
+		return err
+	}
+
+	appConfig := config.GetAppConfig()
+	backend, err := btc.NewBackend(appConfig.GetBitcoinConfig())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	utxos, err := backend.ListUnspent(ctx, sources)
+	if err != nil {
+		return fmt.Errorf("failed to list UTXOs from %s: %v", backend.Name(), err)
+	}
+	if len(utxos) == 0 {
+		fmt.Printf("No funds found on %s\n", strings.Join(sources, ", "))
+		return nil
+	}
+	if feeRate == 0 {
+		if feeRate, err = backend.EstimateFeeRate(ctx, 6); err != nil {
+			return fmt.Errorf("failed to estimate fee rate: %v, pass --fee-rate", err)
+		}
+	}
+
+	script, err := btc.AddressScript(target.Address)
+	if err != nil {
+		return err
+	}
+	selection, amount, err := btc.SweepAll(utxos, script, feeRate)
+	if err != nil {
+		return err
+	}
+	outputs := []btc.TxOut{{Value: amount, Script: script}}
+	estimate, err := r.estimateBTC(selection.Inputs, outputs)
+	if err != nil {
+		return err
+	}
+	for _, line := range estimate.Lines() {
+		fmt.Println("  " + line)
+	}
+	if warning := estimate.Warning(); warning != "" && !r.confirmPolicy("", nil, warning) {
+		return signer.ErrRejected
+	}
+	raw, txid, err := btc.SignTransaction(selection.Inputs, outputs, func(btc.UTXO) ([]byte, error) {
+		return append([]byte(nil), key...), nil
+	}, appConfig.GetBitcoinConfig().RBF)
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	fmt.Println(r.template.Info(fmt.Sprintf("Sweep %s BTC to %s (%s index %d)",
+		r.format().Decimal(btc.FormatBTC(amount)), target.Address, target.AccountID, target.AddressIndex)))
+	for _, u := range selection.Inputs {
+		fmt.Printf("  Input:     %s (%s BTC) %s\n", u.Outpoint(), r.format().Decimal(btc.FormatBTC(u.Value)), u.Address)
+	}
+	fmt.Printf("  Fee:       %s BTC (%d sat/vB, %d vB)\n", r.format().Decimal(btc.FormatBTC(selection.Fee)), feeRate, selection.VSize)
+	fmt.Printf("  Txid:      %s\n", txid)
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(raw))
+
+	if !broadcast {
+		fmt.Println(r.template.Info("Sweep signed but not broadcast, rerun with --broadcast to send it"))
+		return nil
+	}
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := backend.Broadcast(ctx, raw)
+	if err != nil {
+		logger.Record("repl", "sweep", txid, err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "sweep", sent, "ok")
+	r.recordUsage("sweep", []string{target.Address})
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	return nil
+}
+
+// sweepTarget 解析清扫的目标：账户 ID 或别名时选择一个未使用的收款地址，
+// 否则必须是本钱包已派生的 BTC 地址
+func (r *REPL) sweepTarget(to string) (*core.AddressKey, error) {
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	accountID, err := r.resolveAccountID(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.CoinSymbol != "BTC" {
+			continue
+		}
+		if account.ID == accountID {
+			return r.requestAddress(account, nil)
+		}
+		addresses, err := r.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addresses {
+			if addr.Address == to {
+				return addr, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is neither a BTC account nor an address of this wallet", to)
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/cloudsync"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+)
+
+// 云同步命令处理函数
+func (r *REPL) newSyncer(ctx context.Context) (*cloudsync.Syncer, error) {
+	appConfig := config.GetAppConfig()
+	backend, err := cloudsync.NewBackend(appConfig.GetSyncConfig())
+	if err != nil {
+		return nil, err
+	}
+	key, err := r.syncKey(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+	return cloudsync.NewSyncer(backend, r.baseDir(), key), nil
+}
+
+// syncKey 由钱包种子派生同步密钥：解锁时直接使用种子，锁定时询问钱包密码并解密根钱包文件；
+// 本机还没有钱包时解密远端的根钱包文件，在新设备上第一次拉取
+func (r *REPL) syncKey(ctx context.Context, backend cloudsync.Backend) (*cloudsync.Key, error) {
+	if !r.walletMgr.IsLocked() {
+		seed, err := r.walletMgr.Seed()
+		if err != nil {
+			return nil, err
+		}
+		defer seed.Destroy()
+		return cloudsync.DeriveKey(seed.Bytes())
+	}
+	data, err := os.ReadFile(filepath.Join(r.baseDir(), filepath.FromSlash(cloudsync.WalletFile)))
+	if os.IsNotExist(err) {
+		data, err = cloudsync.FetchWallet(ctx, backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var wallet core.HDRootWallet
+	if err := json.Unmarshal(data, &wallet); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", cloudsync.WalletFile, err)
+	}
+	password, err := r.passwordPrompt().Read("Wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	// 与 WalletManager.Seed 解密同一字段，解锁与否派生出的密钥相同
+	seed, err := security.Decrypt(wallet.EncryptedMnemonic, password)
+	if err != nil {
+		return nil, core.ErrInvalidPassword
+	}
+	defer seed.Destroy()
+	return cloudsync.DeriveKey(seed.Bytes())
+}
+
+func (r *REPL) handleSyncStatus(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	syncer, err := r.newSyncer(ctx)
+	if err != nil {
+		return err
+	}
+
+	status, err := syncer.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get sync status: %v", err)
+	}
+
+	lastSynced := "never"
+	if !status.LastSyncedAt.IsZero() {
+		lastSynced = r.format().Date(status.LastSyncedAt)
+	}
+	fmt.Printf("Backend:          %s\n", status.Backend)
+	fmt.Printf("Local revision:   %d\n", status.LocalRevision)
+	fmt.Printf("Remote revision:  %d\n", status.RemoteRevision)
+	fmt.Printf("Last synced:      %s\n", lastSynced)
+	if len(status.LocalChanged) > 0 {
+		fmt.Printf("Local changes:    %s\n", strings.Join(status.LocalChanged, ", "))
+	}
+
+	switch {
+	case status.Conflict():
+		fmt.Println(r.template.Warning("Conflict: both local and remote changed since last sync"))
+	case status.RemoteChanged:
+		fmt.Println(r.template.Info("Remote is ahead, run sync.pull"))
+	case len(status.LocalChanged) > 0:
+		fmt.Println(r.template.Info("Local is ahead, run sync.push"))
+	default:
+		fmt.Println(r.template.Success("Up to date"))
+	}
+	return nil
+}
+
+func (r *REPL) handleSyncPush(args []string) error {
+	force, err := parseForceFlag(args, r.usageError("sync.push"))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	syncer, err := r.newSyncer(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := syncer.Push(ctx, force)
+	if err != nil {
+		return fmt.Errorf("sync push failed: %v", err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Pushed %d encrypted files (revision %d)", len(manifest.Files), manifest.Revision)))
+	r.recordBackup("sync.push")
+	return nil
+}
+
+func (r *REPL) handleSyncPull(args []string) error {
+	force, err := parseForceFlag(args, r.usageError("sync.pull"))
+	if err != nil {
+		return err
+	}
+	// 拉取会覆盖存储文件，解锁状态下内存中的钱包数据会与磁盘不一致
+	if !r.walletMgr.IsLocked() {
+		return fmt.Errorf("lock the wallet before pulling remote changes")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	syncer, err := r.newSyncer(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := syncer.Pull(ctx, force)
+	if err != nil {
+		return fmt.Errorf("sync pull failed: %v", err)
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Pulled revision %d (%d files)", manifest.Revision, len(manifest.Files))))
+	fmt.Println(r.template.Warning("Restart slowmade to reload the synchronized wallet"))
+	return nil
+}
+
+// parseForceFlag 解析只接受可选 --force 标志的命令参数
+func parseForceFlag(args []string, usage error) (bool, error) {
+	switch {
+	case len(args) == 0:
+		return false, nil
+	case len(args) == 1 && args[0] == "--force":
+		return true, nil
+	default:
+		return false, usage
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/metadata"
+)
+
+// 标记命令处理函数：账户和地址可以有多个层级标记（如 clients/acme），按上级标记过滤时包含全部下级
+func (r *REPL) handleTagAdd(args []string) error {
+	if len(args) < 2 {
+		return r.usageError("tag.add")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	target, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	tags, err := store.AddTags("tag.add", target, args[1:]...)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Tagged %s: %s", target, strings.Join(tags, ", "))))
+	return nil
+}
+
+func (r *REPL) handleTagRemove(args []string) error {
+	if len(args) < 2 {
+		return r.usageError("tag.remove")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	target, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	tags, err := store.RemoveTags("tag.remove", target, args[1:]...)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		fmt.Println(r.template.Success(fmt.Sprintf("Removed all tags of %s, run undo to restore", target)))
+		return nil
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Tags of %s: %s, run undo to restore", target, strings.Join(tags, ", "))))
+	return nil
+}
+
+// handleTagList 不带参数时以树形列出全部标记和使用次数，带标记时列出该标记及其下级标记的账户和地址
+func (r *REPL) handleTagList(args []string) error {
+	if len(args) > 1 {
+		return r.usageError("tag.list")
+	}
+	store, err := r.metadataStore()
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		filter, err := metadata.NormalizeTag(args[0])
+		if err != nil {
+			return err
+		}
+		targets := store.Tagged(filter)
+		if len(targets) == 0 {
+			fmt.Printf("Nothing tagged %s\n", filter)
+			return nil
+		}
+		for _, target := range targets {
+			line := fmt.Sprintf("  %-66s %s", target, strings.Join(store.Tags(target), ", "))
+			if label, ok := store.Get(metadata.Labels, target); ok {
+				line += fmt.Sprintf("  %q", label)
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	counts := store.TagCounts()
+	if len(counts) == 0 {
+		fmt.Println("No tags")
+		return nil
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		depth := strings.Count(tag, metadata.TagSeparator)
+		name := tag[strings.LastIndex(tag, metadata.TagSeparator)+1:]
+		fmt.Printf("  %s%s (%d)\n", strings.Repeat("  ", depth), name, counts[tag])
+	}
+	return nil
+}
+
+// tagOption 从参数中取出 --tag <tag>，返回规范化的标记和其余参数；没有 --tag 时标记为空
+func (r *REPL) tagOption(args []string) (string, []string, error) {
+	for i, arg := range args {
+		if arg != "--tag" {
+			continue
+		}
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("--tag needs a tag")
+		}
+		filter, err := metadata.NormalizeTag(args[i+1])
+		if err != nil {
+			return "", nil, err
+		}
+		rest := append(append([]string(nil), args[:i]...), args[i+2:]...)
+		return filter, rest, nil
+	}
+	return "", args, nil
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/report"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// taxCurrency 配置的记价法币，未配置时提示
+func taxCurrency() (string, error) {
+	appConfig := config.GetAppConfig()
+	currency := strings.ToUpper(appConfig.GetTaxConfig().Currency)
+	if currency == "" {
+		return "", fmt.Errorf("no tax currency configured, set [tax] currency (e.g. \"USD\")")
+	}
+	return currency, nil
+}
+
+// 持仓批次命令处理函数：列出币种仍有余额的入账批次和成本；--backfill 先按交易当天的历史汇率补记缺少的价格
+func (r *REPL) handleTaxLots(args []string) error {
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && args[1] != "--backfill") {
+		return r.usageError("tax.lots")
+	}
+	symbol := strings.ToUpper(args[0])
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return fmt.Errorf("不支持的币种: %s", args[0])
+	}
+	currency, err := taxCurrency()
+	if err != nil {
+		return err
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		if err := r.backfillPrices(txStore, currency); err != nil {
+			return err
+		}
+	}
+
+	// 处理全部支出后剩下的批次
+	all := report.Period{Name: "all", Start: time.Time{}, End: time.Now().AddDate(100, 0, 0)}
+	appConfig := config.GetAppConfig()
+	basis, err := report.BuildCostBasis(symbol, currency, appConfig.GetTaxConfig().Method, all, txStore.Payments(), txStore.Spends(), r.ownAddress)
+	if err != nil {
+		return err
+	}
+	if len(basis.Lots) == 0 {
+		fmt.Printf("No open %s lots recorded\n", symbol)
+		return nil
+	}
+	format := r.format()
+	fmt.Printf("Open %s lots (%s matching), cost in %s:\n", symbol, basis.Method, currency)
+	fmt.Printf("  %-19s %-10s %18s %18s %14s %14s\n", "ACQUIRED", "TXID", "RECEIVED", "REMAINING", "RATE", "COST")
+	total, missing := new(big.Rat), 0
+	for _, lot := range basis.Lots {
+		rate, cost := "-", "-"
+		if lot.Rate != nil {
+			value := new(big.Rat).Mul(new(big.Rat).SetFrac(lot.Remaining, pow10(info.Decimal)), lot.Rate)
+			total.Add(total, value)
+			rate, cost = format.Decimal(lot.Rate.FloatString(2)), format.Decimal(value.FloatString(2))
+		} else {
+			missing++
+		}
+		fmt.Printf("  %-19s %-10s %18s %18s %14s %14s\n", format.Date(lot.Acquired), shortTxID(lot.TxID),
+			format.Amount(lot.Amount, info.Decimal, ""), format.Amount(lot.Remaining, info.Decimal, ""), rate, cost)
+	}
+	fmt.Printf("  Total cost basis: %s %s\n", format.Decimal(total.FloatString(2)), currency)
+	if missing > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d lots have no %s price, run tax.lots %s --backfill", missing, currency, symbol)))
+	}
+	return nil
+}
+
+// backfillPrices 按交易当天的历史汇率补记缺少价格的入账和支出，同一天只查询一次
+func (r *REPL) backfillPrices(txStore *watch.TxStore, currency string) error {
+	prices, err := r.priceService()
+	if err != nil {
+		return err
+	}
+	cache := make(map[string]*watch.Fiat)
+	filled, err := txStore.FillPrices(currency, func(symbol string, at time.Time) (*watch.Fiat, error) {
+		key := symbol + "/" + at.UTC().Format("2006-01-02")
+		if fiat, ok := cache[key]; ok {
+			return fiat, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), priceTimeout)
+		defer cancel()
+		quote, err := prices.Historical(ctx, symbol, currency, at)
+		if err != nil {
+			return nil, err
+		}
+		fiat := watch.FiatFromQuote(quote)
+		cache[key] = fiat
+		return fiat, nil
+	})
+	if filled > 0 {
+		fmt.Println(r.template.Success(fmt.Sprintf("Recorded %d historical %s prices", filled, currency)))
+	}
+	if err != nil {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Some prices could not be fetched: %v", err)))
+	}
+	return nil
+}
+
+// 成本基础报表命令处理函数：按 FIFO 或 LIFO 把期间内的支出与入账批次配对，计算收入、成本和收益，
+// --csv 以报税软件常用的 Form 8949 格式导出
+func (r *REPL) handleTaxReport(args []string) error {
+	usage := r.usageError("tax.report")
+	if len(args) < 1 {
+		return usage
+	}
+	appConfig := config.GetAppConfig()
+	symbol := strings.ToUpper(args[0])
+	periodArg, method, csvFile := "", appConfig.GetTaxConfig().Method, ""
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		switch args[i] {
+		case "--period":
+			periodArg = args[i+1]
+		case "--method":
+			method = args[i+1]
+		case "--csv":
+			csvFile = args[i+1]
+		default:
+			return usage
+		}
+		i++
+	}
+	if periodArg == "" {
+		return usage
+	}
+	if _, ok := coin.LookupSymbol(symbol); !ok {
+		return fmt.Errorf("不支持的币种: %s", args[0])
+	}
+	currency, err := taxCurrency()
+	if err != nil {
+		return err
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	period, err := report.ParsePeriod(periodArg, view.Location())
+	if err != nil {
+		return err
+	}
+	txStore, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	basis, err := report.BuildCostBasis(symbol, currency, method, period, txStore.Payments(), txStore.Spends(), r.ownAddress)
+	if err != nil {
+		return err
+	}
+
+	format := r.format()
+	money := func(value *big.Rat) string {
+		if value == nil {
+			return "?"
+		}
+		return format.Decimal(value.FloatString(2))
+	}
+	fmt.Printf("%s cost basis %s (%s), amounts in %s\n", symbol, period.Name, strings.ToUpper(basis.Method), currency)
+	if len(basis.Disposals) == 0 {
+		fmt.Println("No disposals recorded in this period")
+	} else {
+		fmt.Printf("  %-10s %-10s %18s %14s %14s %14s %-5s\n", "ACQUIRED", "SOLD", "QUANTITY", "PROCEEDS", "COST", "GAIN", "TERM")
+		for _, d := range basis.Disposals {
+			acquired, term := format.Day(d.Acquired), "short"
+			if d.Unmatched {
+				acquired, term = "unknown", "-"
+			} else if d.LongTerm() {
+				term = "long"
+			}
+			quantity := format.Amount(d.Quantity, basis.Decimals, "")
+			if d.Internal {
+				quantity += " fee"
+			}
+			fmt.Printf("  %-10s %-10s %18s %14s %14s %14s %-5s\n", acquired, format.Day(d.Sold), quantity,
+				money(d.Proceeds), money(d.Cost), money(d.Gain()), term)
+		}
+	}
+	fmt.Printf("  Proceeds: %s  Cost basis: %s  Gain: %s\n", money(basis.Proceeds), money(basis.Cost), money(basis.Gain))
+	if basis.Missing > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d disposals lack a %s price, totals are incomplete; run tax.lots %s --backfill",
+			basis.Missing, currency, symbol)))
+	}
+	if basis.Unmatched > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("%d disposals exceed the recorded lots (funds received before slowmade recorded them), their cost basis is unknown",
+			basis.Unmatched)))
+	}
+	if csvFile != "" {
+		var data bytes.Buffer
+		if err := basis.WriteCSV(&data); err != nil {
+			return err
+		}
+		if err := os.WriteFile(csvFile, data.Bytes(), 0600); err != nil {
+			return err
+		}
+		fmt.Println(r.template.Success(fmt.Sprintf("%d rows written to %s", len(basis.Disposals), csvFile)))
+	}
+	return nil
+}
+
+// ownAddress 地址是否由本钱包派生
+func (r *REPL) ownAddress(address string) bool {
+	_, ok := r.accountMgr.IsMine(address)
+	return ok
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func shortTxID(txid string) string {
+	if len(txid) > 10 {
+		return txid[:8] + ".."
+	}
+	if txid == "" {
+		return "-"
+	}
+	return txid
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// 删除账户命令处理函数，账户和它的地址移到回收站，可以用 trash.restore 恢复
+func (r *REPL) handleAccountRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("account.remove")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accountID, err := r.resolveAccountID(args[0])
+	if err != nil {
+		return err
+	}
+	account, err := r.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	addresses, err := r.accountMgr.GetAddresses(account.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Warning(fmt.Sprintf("Remove %s %s (%s) and its %d addresses?", account.ID, account.CoinSymbol, account.DerivationPath, len(addresses))))
+	fmt.Println("  Funds stay on chain; the account can be restored from the trash or derived again from the mnemonic")
+	answer, err := r.line.Prompt("Move to trash? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("removal cancelled")
+	}
+	entry, err := r.accountMgr.RemoveAccount(account.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Moved to trash as %s (trash.restore %s to undo)", entry.ID, entry.ID)))
+	return nil
+}
+
+// 删除地址命令处理函数
+func (r *REPL) handleAddressRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("address.remove")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		addresses, err := r.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addresses {
+			if addr.Address != args[0] {
+				continue
+			}
+			entry, err := r.accountMgr.RemoveAddress(addr)
+			if err != nil {
+				return err
+			}
+			fmt.Println(r.template.Success(fmt.Sprintf("Moved %s to trash as %s", addr.Address, entry.ID)))
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", core.ErrAddressNotFound, args[0])
+}
+
+// 回收站列表命令处理函数
+func (r *REPL) handleTrashList(args []string) error {
+	entries, err := r.accountMgr.Trash()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Trash is empty")
+		return nil
+	}
+	appConfig := config.GetAppConfig()
+	retention := appConfig.GetTrashConfig().RetentionDays
+	for _, entry := range entries {
+		line := fmt.Sprintf("  %s  %s  %s", entry.ID, r.format().Date(entry.DeletedAt), entry.Describe())
+		if retention > 0 {
+			line += fmt.Sprintf("  (purged after %s)", r.format().Day(entry.DeletedAt.AddDate(0, 0, retention)))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// 回收站恢复命令处理函数，同一账户或地址已重新创建且内容不同时逐条确认是否覆盖
+func (r *REPL) handleTrashRestore(args []string) error {
+	args, acceptAll := acceptAllFlag(args)
+	if len(args) != 1 {
+		return r.usageError("trash.restore")
+	}
+	entry, kept, err := r.accountMgr.RestoreTrash(args[0], r.conflictResolver(acceptAll))
+	if err != nil {
+		return err
+	}
+	if kept > 0 {
+		fmt.Println(r.template.Warning(fmt.Sprintf("Kept %d existing records, %s stays in the trash", kept, entry.ID)))
+		return nil
+	}
+	fmt.Println(r.template.Success("Restored " + entry.Describe()))
+	return nil
+}
+
+// 回收站清除命令处理函数，永久删除
+func (r *REPL) handleTrashPurge(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("trash.purge")
+	}
+	entries, err := r.accountMgr.Trash()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	if args[0] == "--all" {
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+	} else {
+		ids = []string{args[0]}
+	}
+	if len(ids) == 0 {
+		fmt.Println("Trash is empty")
+		return nil
+	}
+	answer, err := r.line.Prompt(fmt.Sprintf("Permanently delete %d trash entries? [y/N]: ", len(ids)))
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("purge cancelled")
+	}
+	for _, id := range ids {
+		if err := r.accountMgr.PurgeTrash(id); err != nil {
+			return err
+		}
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Purged %d trash entries", len(ids))))
+	return nil
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/tron"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// tronGrid 按 [tron] 配置创建 TronGrid 客户端
+func tronGrid() *tron.Grid {
+	appConfig := config.GetAppConfig()
+	tronConfig := appConfig.GetTronConfig()
+	return tron.NewGrid(tronConfig.GridURL, tronConfig.APIKey)
+}
+
+// TRX 余额命令处理函数，指定 --token 时同时显示 TRC-20 代币余额
+func (r *REPL) handleTRXBalance(args []string) error {
+	usage := r.usageError("trx.balance")
+	if len(args) != 1 && (len(args) != 3 || args[1] != "--token") {
+		return usage
+	}
+	addr, err := r.coinAddress(args[0], "TRX")
+	if err != nil {
+		return err
+	}
+	grid := tronGrid()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	sun, err := grid.Balance(ctx, addr.Address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance from %s: %v", grid.Name(), err)
+	}
+	fmt.Printf("%s\n", addr.Address)
+	fmt.Printf("  TRX:   %s\n", r.format().Decimal(coin.FormatUnits(sun, tron.Decimals)))
+	if len(args) == 3 {
+		contract, err := tron.NormalizeAddress(args[2])
+		if err != nil {
+			return err
+		}
+		symbol, decimals, err := grid.Token(ctx, contract)
+		if err != nil {
+			return err
+		}
+		balance, err := grid.TokenBalance(ctx, contract, addr.Address)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-6s %s\n", symbol+":", r.format().Decimal(coin.FormatUnits(balance, decimals)))
+	}
+	return nil
+}
+
+// TRX 发送命令处理函数：在本地构造 TRX 或 TRC-20 转账并签名，--broadcast 时经 TronGrid 广播
+func (r *REPL) handleTRXSend(args []string) error {
+	usage := r.usageError("trx.send")
+	if len(args) < 3 {
+		return usage
+	}
+	appConfig := config.GetAppConfig()
+	var (
+		contract  string
+		feeLimit  = appConfig.GetTronConfig().FeeLimit
+		broadcast bool
+		err       error
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == "--broadcast":
+			broadcast = true
+		case args[i] == "--token" && i+1 < len(args):
+			i++
+			if contract, err = tron.NormalizeAddress(args[i]); err != nil {
+				return fmt.Errorf("invalid token contract: %w", err)
+			}
+		case args[i] == "--fee-limit" && i+1 < len(args):
+			i++
+			if feeLimit, err = strconv.ParseInt(args[i], 10, 64); err != nil || feeLimit <= 0 {
+				return fmt.Errorf("invalid fee limit %q", args[i])
+			}
+		default:
+			return usage
+		}
+	}
+
+	from, err := r.coinAddress(args[0], "TRX")
+	if err != nil {
+		return err
+	}
+	recipient, err := r.resolveRecipient(args[1], "TRX")
+	if err != nil {
+		return err
+	}
+	to, err := tron.NormalizeAddress(recipient)
+	if err != nil {
+		return err
+	}
+
+	grid := tronGrid()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	symbol, decimals := "TRX", tron.Decimals
+	if contract != "" {
+		if symbol, decimals, err = grid.Token(ctx, contract); err != nil {
+			return fmt.Errorf("failed to read token %s: %v", contract, err)
+		}
+	}
+	amount, err := coin.ParseUnits(args[2], decimals)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() <= 0 {
+		return tron.ErrInvalidAmount
+	}
+
+	// 代币转账按“地址:代币”单独统计，金额不与 TRX 混在一起
+	usageKey := from.Address
+	if contract != "" {
+		usageKey += ":" + symbol
+	}
+	value := new(big.Rat).SetFrac(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	if err := r.checkTransfer("trx_signTransaction", usageKey, symbol, to, value); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", "trx_signTransaction", "", err.Error())
+		return err
+	}
+	// 参考区块在确认之后获取，交易的有效期从这里开始计算
+	block, err := grid.NowBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the latest block from %s: %v", grid.Name(), err)
+	}
+	var tx *tron.Transaction
+	if contract != "" {
+		tx, err = tron.NewTRC20Transfer(from.Address, contract, to, amount, feeLimit*1_000_000, block)
+	} else if amount.IsInt64() {
+		tx, err = tron.NewTransfer(from.Address, to, amount.Int64(), block)
+	} else {
+		err = fmt.Errorf("amount %s TRX is too large", args[2])
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.signTron(tx, from); err != nil {
+		return err
+	}
+	encoded, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+
+	target := to
+	if to != args[1] {
+		target = fmt.Sprintf("%s (%s)", to, args[1])
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s %s to %s", r.format().Decimal(coin.FormatUnits(amount, decimals)), symbol, target)))
+	fmt.Printf("  From:      %s\n", from.Address)
+	if contract != "" {
+		fmt.Printf("  Token:     %s (TRC-20 %s)\n", symbol, contract)
+		fmt.Printf("  Fee limit: %d TRX\n", feeLimit)
+	}
+	fmt.Printf("  Expires:   %s\n", r.format().Date(tx.Expires()))
+	fmt.Printf("  Txid:      %s\n", tx.ID())
+	fmt.Printf("  Raw:       %s\n", hex.EncodeToString(encoded))
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it before it expires"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := grid.Broadcast(ctx, tx)
+	if err != nil {
+		logger.Record("repl", "trx.send", tx.ID(), err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "trx.send", sent, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, "trx.send")
+	if contract == "" {
+		// 代币转账的金额不是 TRX，不计入支出记录
+		if err := r.recordSpend(watch.Spend{Coin: "TRX", AccountID: from.AccountID, TxID: sent, To: to, Amount: amount.String(),
+			Fee: "0", SentAt: time.Now().UTC(), Command: "trx.send"}); err != nil {
+			logging.Warnf("Failed to record spend %s: %v", sent, err)
+		}
+	}
+	return nil
+}
+
+// signTron 用地址私钥签名交易
+func (r *REPL) signTron(tx *tron.Transaction, from *core.AddressKey) error {
+	key, err := r.accountMgr.AddressPrivateKey(from)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	// Sign 签名后会清除这份副本
+	if err := tx.Sign(append([]byte(nil), key.Bytes()...)); err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", "trx_signTransaction")
+	return nil
+}
@@ -0,0 +1,367 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/mockchain"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+)
+
+// errTutorialQuit 用户在步骤之间选择退出教程
+var errTutorialQuit = errors.New("tutorial stopped")
+
+// 教程使用的测试网和金额
+var (
+	tutorialChainID  = big.NewInt(11155111) // Sepolia
+	tutorialReceive  = big.NewInt(500_000_000_000_000_000)
+	tutorialSend     = big.NewInt(100_000_000_000_000_000)
+	tutorialGasPrice = big.NewInt(2_000_000_000)
+)
+
+const (
+	tutorialPath      = "m/44'/60'/0'"
+	tutorialGas       = 21000
+	tutorialQuizWords = 3
+	tutorialAttempts  = 3
+)
+
+// tutorial 一次教程的状态：内存盘上的临时钱包、独立的模拟链和各步骤的结果
+type tutorial struct {
+	r        *REPL
+	scratch  *scratchWallet
+	chain    *mockchain.Chain
+	password string
+	account  *core.CoinAccount
+	address  *core.AddressKey
+	unit     *big.Int
+}
+
+// tutorialApprover 教程中的签名确认，只显示请求并询问，不经过策略脚本、签名统计和 ENS 查询
+type tutorialApprover struct {
+	r *REPL
+}
+
+func (a *tutorialApprover) Approve(req *signer.ApprovalRequest) bool {
+	fmt.Println(a.r.template.Info(fmt.Sprintf("%s request for %s", req.Method, req.Account.Hex())))
+	for _, line := range req.Details {
+		fmt.Printf("  %s\n", line)
+	}
+	answer, err := a.r.line.Prompt("Sign? [y/N]: ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// 教程命令处理函数：在临时目录中用测试网和模拟链走一遍创建钱包、备份助记词、创建账户、
+// 派生地址、收款和签名交易，每一步都检查结果；不读写当前钱包，结束或退出时删除临时目录
+func (r *REPL) handleTutorial(args []string) error {
+	if len(args) > 0 {
+		return r.usageError("tutorial")
+	}
+	scratch, err := newScratchWallet("")
+	if err != nil {
+		return err
+	}
+	defer scratch.wipe()
+	t := &tutorial{
+		r:       r,
+		scratch: scratch,
+		chain:   mockchain.New("tutorial", 0, filepath.Join(scratch.dir, mockchain.StateFileName)),
+		unit:    big.NewInt(1_000_000_000_000_000_000),
+	}
+
+	fmt.Println(r.template.Info("This tutorial runs in a temporary sandbox: a new practice wallet, the Sepolia testnet " +
+		"and a private mock chain. Your own wallet is not opened or changed, and the sandbox is deleted when the tutorial ends."))
+	steps := []struct {
+		title, text string
+		run         func() error
+	}{
+		{"Create a wallet", "A wallet starts from 256 bits of randomness, written as 24 words (the mnemonic). Every key " +
+			"and address is derived from it. On disk the mnemonic is encrypted with your password, which is stretched " +
+			"with a deliberately slow key derivation function so guessing it is expensive.\n\n" +
+			"Choose a practice password; the real command is wallet.create.", t.createWallet},
+		{"Back up the mnemonic", "The mnemonic is the only backup that matters: with it anyone can restore every account, " +
+			"without it nobody can, including you. Write the words on paper in order and keep them offline. Never type them " +
+			"into a website, photograph them or store them in the cloud.\n\n" +
+			"The words are shown next, then you are asked for a few of them to check your copy. " +
+			"The real commands are wallet.mnemonic to show them and wallet.test-restore to rehearse a restore.", t.backupMnemonic},
+		{"Create an account", "Accounts separate coins and purposes. Each one has a BIP44 path: m/44'/60'/0' means purpose 44, " +
+			"coin type 60 (Ethereum) and account 0. The apostrophe marks hardened levels, whose keys cannot be linked " +
+			"to the parent public key.\n\n" +
+			"The real command is account.create " + tutorialPath + ".", t.createAccount},
+		{"Derive a receive address", "Addresses hang below the account: chain 0 is for receiving, chain 1 for change, " +
+			"followed by an index. The same mnemonic and path always give the same address, which is why restoring " +
+			"from the words brings every address back.\n\n" +
+			"The real command is address.derive <accountID> receive 0.", t.deriveAddress},
+		{"Receive funds", "Someone sends to your address; the transaction waits in the mempool (pending) until a miner " +
+			"includes it in a block (confirmed). The sandbox mock chain plays the sender and the miner, so no real coins " +
+			"are involved. It also gives about half of all addresses a starting balance, which is why yours may not begin at zero.\n\n" +
+			"The real commands are mockchain.mine for the mock chain, and eth.balance or the watch commands on a real network.", t.receiveFunds},
+		{"Sign a test transaction", "Sending means signing: the wallet shows what you are about to approve (recipient, amount, " +
+			"nonce, fee and chain ID), and only after you agree does it decrypt the key and sign. Always compare the " +
+			"recipient with the one you expect; the chain ID stops a testnet transaction from being replayed on mainnet.\n\n" +
+			"Here you send " + t.ether(tutorialSend) + " ETH to your second receive address.", t.signTransaction},
+	}
+	for i, step := range steps {
+		fmt.Println(r.template.TutorialStep(i+1, len(steps), step.title, step.text))
+		if err := t.next(); err != nil {
+			return t.stopped(i+1, len(steps))
+		}
+		if err := step.run(); err != nil {
+			if errors.Is(err, errTutorialQuit) {
+				return t.stopped(i+1, len(steps))
+			}
+			return fmt.Errorf("tutorial step %d (%s): %w", i+1, step.title, err)
+		}
+	}
+	fmt.Println(r.template.Success("Tutorial complete. The practice wallet and mock chain are being deleted."))
+	fmt.Println(r.template.Info("To start for real: wallet.create, then wallet.mnemonic to write down the words, " +
+		"account.create and address.derive. Type help for everything else."))
+	return nil
+}
+
+// next 等待用户继续，输入 q 退出
+func (t *tutorial) next() error {
+	answer, err := t.r.line.Prompt("Press Enter to continue, or q to quit: ")
+	if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+		return errTutorialQuit
+	}
+	return nil
+}
+
+func (t *tutorial) stopped(step, total int) error {
+	fmt.Println(t.r.template.Info(fmt.Sprintf("Tutorial stopped at step %d of %d, the sandbox was deleted. Run tutorial to start again.", step, total)))
+	return nil
+}
+
+// ether 把 wei 格式化为 ETH
+func (t *tutorial) ether(wei *big.Int) string {
+	return new(big.Rat).SetFrac(wei, big.NewInt(1_000_000_000_000_000_000)).FloatString(6)
+}
+
+func (t *tutorial) createWallet() error {
+	password, err := t.r.passwordPrompt().ReadNew("Practice password: ")
+	if err != nil {
+		return err
+	}
+	if _, err := t.scratch.walletMgr.CreateNewWallet(password); err != nil {
+		return err
+	}
+	// 检查：新钱包能用刚设置的密码解锁
+	if err := t.scratch.unlock(password); err != nil {
+		return fmt.Errorf("the new wallet does not unlock with the password: %w", err)
+	}
+	t.password = password
+	fmt.Println(t.r.template.Success("Practice wallet created and unlocked with your password."))
+	return nil
+}
+
+func (t *tutorial) backupMnemonic() error {
+	secret, err := t.scratch.walletMgr.ExportMnemonic(t.password)
+	if err != nil {
+		return err
+	}
+	defer secret.Destroy()
+	phrase := string(secret.Bytes())
+	if err := t.r.showMnemonic(phrase); err != nil {
+		// 没有终端时无法使用独立的全屏视图；练习用的助记词直接显示，真实钱包不会这样做
+		fmt.Println(t.r.template.Warning("No terminal for the private mnemonic view, showing the practice words inline. " +
+			"The real wallet.mnemonic refuses to do this."))
+		for i, word := range strings.Fields(phrase) {
+			fmt.Printf("%2d. %s\n", i+1, word)
+		}
+	}
+
+	// 检查：随机抽几个位置，让用户按抄写的内容回答
+	words := strings.Fields(phrase)
+	positions := rand.Perm(len(words))[:tutorialQuizWords]
+	sort.Ints(positions)
+	corrected := 0
+	for _, position := range positions {
+		for attempt := 1; ; attempt++ {
+			answer, err := t.r.line.Prompt(fmt.Sprintf("Word #%d of your copy (q to quit): ", position+1))
+			if err != nil {
+				return errTutorialQuit
+			}
+			answer = mnemonic.Normalize(answer)
+			if answer == "q" {
+				return errTutorialQuit
+			}
+			if answer == words[position] {
+				break
+			}
+			if attempt >= tutorialAttempts {
+				corrected++
+				fmt.Println(t.r.template.Warning(fmt.Sprintf("Word #%d is %q. A wrong word makes the backup useless, "+
+					"so with a real wallet check the whole copy again before receiving funds.", position+1, words[position])))
+				break
+			}
+			fmt.Println(t.r.template.Warning("That does not match, check your copy and the word number."))
+		}
+	}
+	if corrected > 0 {
+		fmt.Println(t.r.template.Warning(fmt.Sprintf("%d of %d words did not match your copy. For practice that is fine; "+
+			"for a real wallet fix the copy and run wallet.test-restore before receiving funds.", corrected, len(positions))))
+		return nil
+	}
+	fmt.Println(t.r.template.Success("Backup checked. wallet.test-restore does the full check for a real wallet."))
+	return nil
+}
+
+func (t *tutorial) createAccount() error {
+	path, err := core.ParseDerivationPath(tutorialPath)
+	if err != nil {
+		return err
+	}
+	account, err := t.scratch.accountMgr.CreateNewAccount(path, core.ConventionStandard)
+	if err != nil {
+		return err
+	}
+	// 检查：账户已保存，币种和路径与请求的一致
+	accounts, err := t.scratch.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	if len(accounts) != 1 || accounts[0].ID != account.ID || accounts[0].CoinSymbol != "ETH" || accounts[0].DerivationPath != tutorialPath {
+		return fmt.Errorf("account %s was not saved as an ETH account at %s", account.ID, tutorialPath)
+	}
+	t.account = account
+	fmt.Println(t.r.template.Success(fmt.Sprintf("Created %s account %s at %s.", account.CoinSymbol,
+		core.ShortIDs([]string{account.ID})[account.ID], account.DerivationPath)))
+	return nil
+}
+
+func (t *tutorial) deriveAddress() error {
+	address, err := t.scratch.accountMgr.DeriveAddress(t.account.ID, 0, 0)
+	if err != nil {
+		return err
+	}
+	// 检查：地址格式正确，并且被识别为本钱包的地址
+	if !common.IsHexAddress(address.Address) {
+		return fmt.Errorf("derived %q is not an ETH address", address.Address)
+	}
+	if _, own := t.scratch.accountMgr.IsMine(address.Address); !own {
+		return fmt.Errorf("derived address %s is not recognised as the wallet's own", address.Address)
+	}
+	t.address = address
+	fmt.Println(t.r.template.Success(fmt.Sprintf("Receive address 0: %s", address.Address)))
+	fmt.Println(t.r.template.Info("The mixed upper and lower case is a checksum (EIP-55): a mistyped address is rejected instead of losing the funds."))
+	return nil
+}
+
+// balance 显示并返回地址在模拟链上已确认和待确认的余额
+func (t *tutorial) balance(label string) (*big.Int, *big.Int, error) {
+	confirmed, pending, err := t.chain.Balance("ETH", t.address.Address, t.unit)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Printf("  %-20s confirmed %s ETH, pending %s ETH\n", label, t.ether(confirmed), t.ether(pending))
+	return confirmed, pending, nil
+}
+
+func (t *tutorial) receiveFunds() error {
+	before, _, err := t.balance("Before:")
+	if err != nil {
+		return err
+	}
+	txid, err := t.chain.Faucet("ETH", t.address.Address, tutorialReceive)
+	if err != nil {
+		return err
+	}
+	fmt.Println(t.r.template.Info(fmt.Sprintf("Incoming transaction %s...", txid[:16])))
+	if _, pending, err := t.balance("In the mempool:"); err != nil {
+		return err
+	} else if pending.Cmp(tutorialReceive) != 0 {
+		return fmt.Errorf("expected %s ETH pending, got %s", t.ether(tutorialReceive), t.ether(pending))
+	}
+	height, err := t.chain.Mine(1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(t.r.template.Info(fmt.Sprintf("Block %d mined.", height)))
+	// 检查：出块后金额计入已确认余额
+	after, _, err := t.balance("After one block:")
+	if err != nil {
+		return err
+	}
+	if received := new(big.Int).Sub(after, before); received.Cmp(tutorialReceive) != 0 {
+		return fmt.Errorf("expected %s ETH confirmed, got %s", t.ether(tutorialReceive), t.ether(received))
+	}
+	fmt.Println(t.r.template.Success(fmt.Sprintf("Received %s ETH with one confirmation.", t.ether(tutorialReceive))))
+	return nil
+}
+
+func (t *tutorial) signTransaction() error {
+	recipient, err := t.scratch.accountMgr.DeriveAddress(t.account.ID, 0, 1)
+	if err != nil {
+		return err
+	}
+	nonce, err := t.chain.Nonce("ETH", t.address.Address)
+	if err != nil {
+		return err
+	}
+	from, to := common.HexToAddress(t.address.Address), common.HexToAddress(recipient.Address)
+	gas, txNonce := hexutil.Uint64(tutorialGas), hexutil.Uint64(nonce)
+	args := &signer.TransactionArgs{
+		From:     from,
+		To:       &to,
+		Gas:      &gas,
+		GasPrice: (*hexutil.Big)(tutorialGasPrice),
+		Value:    (*hexutil.Big)(tutorialSend),
+		Nonce:    &txNonce,
+	}
+	for {
+		raw, err := signer.NewSigner(t.scratch.accountMgr, &tutorialApprover{r: t.r}, tutorialChainID).SignTransaction(args)
+		if errors.Is(err, signer.ErrRejected) {
+			fmt.Println(t.r.template.Info("Rejected, nothing was signed. That is the right answer whenever a request looks wrong; " +
+				"here it is safe to approve."))
+			if err := t.next(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// 检查：解码签名后的交易，恢复出的付款地址、收款地址、金额和链 ID 与请求一致
+		tx, sender, err := signer.DecodeTransaction(raw)
+		if err != nil {
+			return err
+		}
+		if sender != from || tx.To == nil || *tx.To != to || tx.Value.Cmp(tutorialSend) != 0 || tx.ChainID.Cmp(tutorialChainID) != 0 {
+			return fmt.Errorf("the signed transaction does not match the request")
+		}
+		fmt.Println(t.r.template.Success(fmt.Sprintf("Signed %d bytes; the signature recovers to %s.", len(raw), sender.Hex())))
+		fmt.Printf("  Raw transaction: %s\n", hexutil.Encode(raw))
+		break
+	}
+
+	fee := new(big.Int).Mul(tutorialGasPrice, big.NewInt(tutorialGas))
+	txid, err := t.chain.Transfer("ETH", t.address.Address, recipient.Address, tutorialSend, fee, nonce, t.unit)
+	if err != nil {
+		return err
+	}
+	if _, err := t.chain.Mine(1); err != nil {
+		return err
+	}
+	fmt.Println(t.r.template.Info(fmt.Sprintf("Broadcast to the mock chain as %s... and confirmed in the next block.", txid[:16])))
+	if _, _, err := t.balance("Sender now:"); err != nil {
+		return err
+	}
+	fmt.Println(t.r.template.Info(fmt.Sprintf("The sender paid %s ETH plus a fee of %s ETH (21000 gas at 2 gwei).",
+		t.ether(tutorialSend), t.ether(fee))))
+	return nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/decoder"
+)
+
+// addressOwner 地址属于本钱包时返回账户短 ID、链和索引
+func (r *REPL) addressOwner(address string) (string, bool) {
+	addr, ok := r.accountMgr.IsMine(address)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s %d/%d", core.ShortIDs([]string{addr.AccountID})[addr.AccountID], addr.ChangeType, addr.AddressIndex), true
+}
+
+// 交易解码命令处理函数，仅离线解析，不需要解锁钱包
+func (r *REPL) handleTxDecode(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("tx.decode")
+	}
+	raw, err := decoder.ReadTxInput(args[0])
+	if err != nil {
+		return err
+	}
+
+	dec := decoder.NewDecoder()
+	if err := dec.LoadABIDir(filepath.Join(r.baseDir(), ABIDirName)); err != nil {
+		return err
+	}
+	dec.SetOwner(r.addressOwner)
+	report, err := dec.DecodeTx(raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(r.template.Info(fmt.Sprintf("%s transaction, %s, %d bytes", report.Chain, report.Format, len(raw))))
+	for _, section := range report.Sections {
+		fmt.Printf("\n%s\n", section.Title)
+		for _, field := range section.Fields {
+			if field.Label == "" {
+				fmt.Printf("  %s\n", field.Value)
+				continue
+			}
+			fmt.Printf("  %-18s %s\n", field.Label+":", field.Value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// workContext use 设置的会话默认值，像数据库命令行的 use 一样，之后的命令可以省略账户或币种参数
+type workContext struct {
+	coin    string // 币种符号
+	account string // 账户 ID
+	name    string // 显示用的账户名：设置时输入的别名或标签，输入 ID 时为缩短的 ID
+}
+
+// String 提示符中显示的上下文，如 ETH/savings
+func (c workContext) String() string {
+	if c.account != "" {
+		return c.coin + "/" + c.name
+	}
+	return c.coin
+}
+
+// use 命令处理函数：use coin <symbol>、use account <account>、use none，不带参数时显示当前设置
+func (r *REPL) handleUse(args []string) error {
+	switch {
+	case len(args) == 0:
+		if r.context.coin == "" {
+			fmt.Println("No session defaults, set them with use coin <symbol> or use account <account>")
+			return nil
+		}
+		fmt.Printf("Coin:    %s\n", r.context.coin)
+		if r.context.account != "" {
+			fmt.Printf("Account: %s (%s)\n", r.context.account, r.context.name)
+		}
+		return nil
+	case len(args) == 1 && strings.EqualFold(args[0], "none"):
+		r.context = workContext{}
+		fmt.Println(r.template.Success("Cleared the session defaults"))
+		return nil
+	case len(args) == 2 && strings.EqualFold(args[0], "coin"):
+		info, ok := coin.LookupSymbol(args[1])
+		if !ok {
+			return fmt.Errorf("unknown coin %q, see coin.list", args[1])
+		}
+		if r.context.account != "" && r.context.coin != info.Symbol {
+			fmt.Println(r.template.Info(fmt.Sprintf("Account %s is %s, no longer used by default", r.context.name, r.context.coin)))
+			r.context.account, r.context.name = "", ""
+		}
+		r.context.coin = info.Symbol
+		fmt.Println(r.template.Success(fmt.Sprintf("Using %s; commands that take a coin first can leave it out", info.Symbol)))
+		return nil
+	case len(args) == 2 && strings.EqualFold(args[0], "account"):
+		if r.walletMgr.IsLocked() {
+			return fmt.Errorf("钱包已锁定，请先解锁钱包")
+		}
+		accountID, err := r.resolveAccountID(args[1])
+		if err != nil {
+			return err
+		}
+		account, err := r.findAccount(accountID)
+		if err != nil {
+			return err
+		}
+		name := args[1]
+		if name == account.ID {
+			name = strings.TrimPrefix(account.ID, core.AccountIDPrefix)
+			if len(name) > 8 {
+				name = name[:8]
+			}
+		}
+		r.context = workContext{coin: account.CoinSymbol, account: account.ID, name: name}
+		fmt.Println(r.template.Success(fmt.Sprintf("Using %s account %s; commands that take an account first can leave it out", account.CoinSymbol, account.ID)))
+		return nil
+	}
+	return r.usageError("use")
+}
+
+// withContext 命令省略了开头的账户或币种参数时补上 use 设置的默认值。只看第一个选项之前的参数：
+// 比命令需要的少一个时视为省略；以币种开头的命令（btc.send）只补同一币种的账户
+func (r *REPL) withContext(c command, args []string) []string {
+	positional := 0
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			break
+		}
+		positional++
+	}
+	switch {
+	case c.accountArgs > 0 && r.context.account != "" && positional == c.accountArgs-1:
+		prefix, _, _ := strings.Cut(c.name, ".")
+		if info, ok := coin.LookupSymbol(prefix); ok && info.Symbol != r.context.coin {
+			return args
+		}
+		return append([]string{r.context.account}, args...)
+	case c.coinArgs > 0 && r.context.coin != "" && positional == c.coinArgs-1:
+		return append([]string{r.context.coin}, args...)
+	}
+	return args
+}
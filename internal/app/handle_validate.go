@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/palagend/slowmade/internal/addrcheck"
+	"github.com/palagend/slowmade/internal/config"
+)
+
+// errAddressNotValid 地址无效时命令失败，脚本可以只看退出码
+var errAddressNotValid = errors.New("the address is not valid")
+
+// 地址校验命令处理函数：不需要解锁钱包，地址无效时输出原因并返回错误；--onchain 查询 ETH 和 BNB 地址是否为合约
+func (r *REPL) handleValidate(args []string) error {
+	asJSON, onchain := false, false
+	positional := make([]string, 0, 2)
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--onchain":
+			onchain = true
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return r.usageError("validate")
+	}
+
+	appConfig := config.GetAppConfig()
+	result, err := addrcheck.Validate(positional[0], positional[1], appConfig.GetRPCConfig().CurrentNetwork())
+	if err != nil {
+		return err
+	}
+	if onchain && result.Valid {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := addrcheck.CheckContract(ctx, result, appConfig); err != nil {
+			return err
+		}
+	}
+
+	if !asJSON {
+		if !result.Valid {
+			return fmt.Errorf("%w: %s", errAddressNotValid, result.Error)
+		}
+		r.printValidation(result)
+		return nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	if !result.Valid {
+		return errAddressNotValid
+	}
+	return nil
+}
+
+// printValidation 逐行显示有效地址的校验结果，没有的字段不显示
+func (r *REPL) printValidation(result *addrcheck.Result) {
+	fmt.Println(r.template.Success(fmt.Sprintf("%s address %s is valid", result.Coin, result.Address)))
+	for _, field := range []struct{ name, value string }{
+		{"Normalized", result.Normalized}, {"Network", result.Network}, {"Type", result.Type},
+		{"Format", result.Format}, {"Checksum", result.Checksum},
+	} {
+		if field.value != "" {
+			fmt.Printf("  %-11s %s\n", field.name+":", field.value)
+		}
+	}
+	for _, note := range result.Notes {
+		fmt.Println(r.template.Info(note))
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/devseed"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// rpcProbeTimeout 检查单个节点连通性的超时
+const rpcProbeTimeout = 3 * time.Second
+
+// 钱包状态命令处理函数，显示指纹、时间、账户和地址数、存储、加密、自动锁定和节点连通性；
+// --offline 不检查节点
+func (r *REPL) handleWalletStatus(args []string) error {
+	offline := false
+	for _, arg := range args {
+		if arg != "--offline" {
+			return r.usageError("wallet.status")
+		}
+		offline = true
+	}
+
+	info := &view.WalletStatusInfo{
+		Status:     "locked",
+		Encryption: crypto.GetCurrentAlgorithm(),
+		KDF:        crypto.GetCurrentKDF(),
+	}
+	if !r.walletMgr.IsLocked() {
+		info.Status = "unlocked"
+		if fingerprint, err := r.accountMgr.MasterFingerprint(); err == nil {
+			info.Fingerprint = fingerprint
+		}
+	}
+	// 钱包不存在时没有时间
+	if created, modified, err := r.walletMgr.Timestamps(); err == nil {
+		info.Created, info.Modified = created, modified
+	}
+
+	// 锁定时账户列表不可见，账户和地址数留空
+	if info.Status == "unlocked" {
+		if err := r.countAccounts(info); err != nil {
+			return err
+		}
+	}
+
+	info.Storage = "file " + r.baseDir()
+	if readOnlyMode() {
+		info.Storage += " (read-only)"
+	}
+
+	switch timeout := autoLockTimeout(); {
+	case timeout <= 0:
+		info.AutoLock = "disabled"
+	case info.Status == "locked":
+		info.AutoLock = fmt.Sprintf("after %s idle", timeout)
+	default:
+		// 计时在每条命令结束后重新开始
+		info.AutoLock = fmt.Sprintf("in %s if idle", timeout)
+	}
+
+	if !offline {
+		info.RPC = probeNodes()
+	}
+	fmt.Println(r.template.WalletStatus(info))
+	if devseed.IsDevDir(r.baseDir()) {
+		fmt.Println(r.template.Warning(devseed.Watermark))
+	}
+	return nil
+}
+
+// countAccounts 按币种统计账户数和已派生的地址数
+func (r *REPL) countAccounts(info *view.WalletStatusInfo) error {
+	accounts, err := r.accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	info.Accounts = make(map[string]int)
+	for _, account := range accounts {
+		info.Accounts[account.CoinSymbol]++
+		addresses, err := r.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return err
+		}
+		info.Addresses += len(addresses)
+	}
+	return nil
+}
+
+// probeNodes 并发检查当前网络上配置的全部节点
+func probeNodes() []view.RPCStatus {
+	appConfig := config.GetAppConfig()
+	rpcConfig := appConfig.GetRPCConfig()
+	var endpoints []string
+	statuses := []view.RPCStatus{}
+	for _, symbol := range rpcConfig.Configured() {
+		if node, ok := rpcConfig.Node(symbol); ok {
+			endpoints = append(endpoints, node.Endpoint)
+			statuses = append(statuses, view.RPCStatus{Coin: symbol, Endpoint: chain.EndpointName(node.Endpoint)})
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range statuses {
+		wg.Add(1)
+		go func(status *view.RPCStatus, endpoint string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), rpcProbeTimeout)
+			defer cancel()
+			status.Latency, status.Err = chain.Probe(ctx, endpoint)
+		}(&statuses[i], endpoints[i])
+	}
+	wg.Wait()
+	return statuses
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 收款监控命令处理函数，在后台轮询收款地址，检测到入账时在下一次提示符前显示
+func (r *REPL) handleWatchStart(args []string) error {
+	if len(args) > 1 {
+		return r.usageError("watch.start")
+	}
+	if r.walletMgr.IsLocked() {
+		return fmt.Errorf("钱包已锁定，请先解锁钱包")
+	}
+	if r.watchCancel != nil {
+		return fmt.Errorf("watcher is already running, use watch.stop first")
+	}
+	appConfig := config.GetAppConfig()
+	watchConfig := appConfig.GetWatchConfig()
+	interval := watchConfig.Interval
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid interval %q", args[0])
+		}
+		interval = n
+	}
+	if interval < 10 {
+		interval = 10
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.watchCancel = cancel
+	go watch.NewWatcher(r.accountMgr, r.baseDir(), r.bus).Run(ctx, time.Duration(interval)*time.Second)
+
+	fmt.Println(r.template.Success(fmt.Sprintf("Watching receive addresses every %ds", interval)))
+	fmt.Println(r.template.Warning("Polling reveals the watched addresses to the configured backends"))
+	return nil
+}
+
+func (r *REPL) handleWatchStop(args []string) error {
+	if r.watchCancel == nil {
+		fmt.Println("Watcher is not running")
+		return nil
+	}
+	r.stopWatch()
+	fmt.Println(r.template.Success("Watcher stopped"))
+	return nil
+}
+
+// stopWatch 停止后台收款监控，锁定钱包时也会调用
+func (r *REPL) stopWatch() {
+	if r.watchCancel != nil {
+		r.watchCancel()
+		r.watchCancel = nil
+	}
+}
+
+func (r *REPL) handleWatchStatus(args []string) error {
+	status := "stopped"
+	if r.watchCancel != nil {
+		status = "running"
+	}
+	fmt.Printf("Watcher: %s\n", status)
+
+	store, err := watch.LoadTxStore(filepath.Join(r.baseDir(), watch.TxFileName))
+	if err != nil {
+		return err
+	}
+	payments := store.Payments()
+	fmt.Printf("Recorded incoming payments: %d\n", len(payments))
+	for i := max(0, len(payments)-10); i < len(payments); i++ {
+		fmt.Println("  " + r.describePayment(payments[i]))
+	}
+	return nil
+}
+
+// queueNotice 事件总线订阅者，把事件转换为提示信息，在下一次提示符前显示
+func (r *REPL) queueNotice(event events.Event) {
+	var notice string
+	switch data := event.Data.(type) {
+	case watch.Payment:
+		notice = r.template.Success("Received " + r.describePayment(data))
+	case *payreq.Request:
+		notice = r.template.Success(fmt.Sprintf("Payment request %s paid (%s)", data.ID, data.URI))
+	default:
+		return
+	}
+	r.noticeMu.Lock()
+	r.notices = append(r.notices, notice)
+	r.noticeMu.Unlock()
+}
+
+// flushNotices 显示后台产生的提示信息
+func (r *REPL) flushNotices() {
+	r.noticeMu.Lock()
+	notices := r.notices
+	r.notices = nil
+	r.noticeMu.Unlock()
+	for _, notice := range notices {
+		fmt.Println(notice)
+	}
+}
+
+func (r *REPL) describePayment(p watch.Payment) string {
+	amount := p.Amount + " " + p.Coin
+	if value, ok := new(big.Int).SetString(p.Amount, 10); ok {
+		if info, ok := coin.LookupSymbol(p.Coin); ok {
+			amount = r.format().Amount(value, info.Decimal, p.Coin)
+		}
+	}
+	text := fmt.Sprintf("%s  %s on %s", r.format().Date(p.DetectedAt), amount, p.Address)
+	if p.TxID != "" {
+		text += fmt.Sprintf(" (%s:%d)", p.TxID, p.Vout)
+	}
+	if p.RequestID != "" {
+		text += " for request " + p.RequestID
+	}
+	if p.WatchOnly {
+		text += " (watch-only, not spendable)"
+	}
+	return text
+}
+
+// 只读地址导入命令处理函数：从 CSV（coin,address[,label]）导入外部地址，收款监控和 watch.list 会跟踪它们，
+// 但本钱包没有私钥，不能花费
+func (r *REPL) handleWatchImport(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("watch.import")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	list, err := watch.LoadWatchList(filepath.Join(r.baseDir(), watch.WatchOnlyFileName))
+	if err != nil {
+		return err
+	}
+	result, err := list.ImportCSV(file, func(address string) bool {
+		_, mine := r.accountMgr.IsMine(address)
+		return mine
+	})
+	if err != nil {
+		return fmt.Errorf("import failed, nothing was added: %w", err)
+	}
+
+	for _, problem := range result.Invalid {
+		fmt.Println(r.template.Warning("Skipped " + problem))
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Imported %d watch-only addresses (%d duplicates, %d owned by this wallet, %d invalid)",
+		len(result.Added), result.Duplicates, result.Owned, len(result.Invalid))))
+	if len(result.Added) > 0 {
+		fmt.Println(r.template.Info("Watch-only addresses are tracked by watch.start and watch.list --balance but cannot be spent from this wallet"))
+	}
+	return nil
+}
+
+// 只读地址列表命令处理函数，--balance 通过配置的区块浏览器查询余额
+func (r *REPL) handleWatchList(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "--balance") {
+		return r.usageError("watch.list")
+	}
+	list, err := watch.LoadWatchList(filepath.Join(r.baseDir(), watch.WatchOnlyFileName))
+	if err != nil {
+		return err
+	}
+	entries := list.List()
+	if len(entries) == 0 {
+		fmt.Println("No watch-only addresses, import some with watch.import <file.csv>")
+		return nil
+	}
+	balances := func(string, string) string { return "" }
+	if len(args) == 1 {
+		balances = r.watchOnlyBalances(entries)
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("%d watch-only addresses (not spendable)", len(entries))))
+	fmt.Printf("  %-5s %-62s %-20s %s\n", "COIN", "ADDRESS", "LABEL", "BALANCE")
+	for _, entry := range entries {
+		fmt.Printf("  %-5s %-62s %-20s %s\n", entry.Coin, entry.Address, entry.Label, balances(entry.Coin, entry.Address))
+	}
+	return nil
+}
+
+// watchOnlyBalances 查询全部只读地址的余额，返回按币种和地址取格式化余额的函数
+func (r *REPL) watchOnlyBalances(entries []watch.Entry) func(symbol, address string) string {
+	appConfig := config.GetAppConfig()
+	ttl := time.Duration(appConfig.GetExplorerConfig().CacheTTL) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	results := make(map[string]string, len(entries))
+	clients := make(map[string]*chain.CachedClient)
+	clientErrs := make(map[string]error) // 币种没有可用的浏览器
+	offline := r.offlineCache()
+	for _, entry := range entries {
+		key := entry.Coin + ":" + entry.Address
+		info, _ := coin.LookupSymbol(entry.Coin)
+		cached, ok := clients[entry.Coin]
+		if !ok && clientErrs[entry.Coin] == nil {
+			client, err := chain.ForCoin(entry.Coin, appConfig)
+			if err != nil {
+				clientErrs[entry.Coin] = err
+			} else {
+				cached = chain.NewCachedClient(client, "", ttl).Offline(offline, entry.Coin)
+				clients[entry.Coin] = cached
+			}
+		}
+		if err := clientErrs[entry.Coin]; err != nil {
+			results[key] = r.template.Error(err.Error())
+			continue
+		}
+		balance, _, err := cached.BalanceAt(ctx, entry.Address)
+		if balance == nil {
+			results[key] = r.template.Error(err.Error())
+			continue
+		}
+		results[key] = r.format().Amount(balance, info.Decimal, entry.Coin)
+	}
+	return func(symbol, address string) string {
+		return results[symbol+":"+address]
+	}
+}
+
+// 只读地址删除命令处理函数
+func (r *REPL) handleWatchRemove(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("watch.remove")
+	}
+	list, err := watch.LoadWatchList(filepath.Join(r.baseDir(), watch.WatchOnlyFileName))
+	if err != nil {
+		return err
+	}
+	removed, err := list.Remove(args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%s is not a watch-only address", args[0])
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("Stopped watching %s", args[0])))
+	return nil
+}
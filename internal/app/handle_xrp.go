@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/internal/xrp"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// ledgerWindow 交易在这么多个账本之后失效，约一分钟
+const ledgerWindow = 20
+
+// rippled 按 [xrp] 配置创建 rippled 客户端
+func rippled() *xrp.Client {
+	appConfig := config.GetAppConfig()
+	return xrp.NewClient(appConfig.GetXRPConfig().RPCURL)
+}
+
+// XRP 余额命令处理函数
+func (r *REPL) handleXRPBalance(args []string) error {
+	if len(args) != 1 {
+		return r.usageError("xrp.balance")
+	}
+	addr, err := r.coinAddress(args[0], "XRP")
+	if err != nil {
+		return err
+	}
+	client := rippled()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	account, err := client.Account(ctx, addr.Address)
+	if errors.Is(err, xrp.ErrAccountNotFound) {
+		fmt.Printf("%s\n  XRP: 0 (not activated yet, the first payment must cover the account reserve)\n", addr.Address)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch account from %s: %v", client.Name(), err)
+	}
+	fmt.Printf("%s\n", addr.Address)
+	fmt.Printf("  XRP: %s\n", r.format().Decimal(coin.FormatUnits(account.Balance, xrp.Decimals)))
+	return nil
+}
+
+// XRP 发送命令处理函数：收款方可以是经典地址加 --tag，或带标签的 X-address；
+// 收款账户设置了 RequireDest 时必须带目标标签
+func (r *REPL) handleXRPSend(args []string) error {
+	usage := r.usageError("xrp.send")
+	if len(args) < 3 {
+		return usage
+	}
+	var (
+		tag       uint32
+		hasTag    bool
+		broadcast bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == "--broadcast":
+			broadcast = true
+		case args[i] == "--tag" && i+1 < len(args):
+			i++
+			value, err := strconv.ParseUint(args[i], 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid destination tag %q", args[i])
+			}
+			tag, hasTag = uint32(value), true
+		default:
+			return usage
+		}
+	}
+
+	from, err := r.coinAddress(args[0], "XRP")
+	if err != nil {
+		return err
+	}
+	sender, err := xrp.ParseAddress(from.Address)
+	if err != nil {
+		return err
+	}
+	recipient, err := r.resolveRecipient(args[1], "XRP")
+	if err != nil {
+		return err
+	}
+	destination, err := xrp.ParseAddress(recipient)
+	if err != nil {
+		return err
+	}
+	if destination.Testnet {
+		return fmt.Errorf("%s is a testnet X-address", recipient)
+	}
+	if destination.HasTag {
+		if hasTag && tag != destination.Tag {
+			return fmt.Errorf("--tag %d differs from the tag %d in the X-address", tag, destination.Tag)
+		}
+		tag, hasTag = destination.Tag, true
+	}
+	to := destination.Classic()
+	amount, err := coin.ParseUnits(args[2], xrp.Decimals)
+	if err != nil {
+		return err
+	}
+	if amount.Sign() <= 0 || !amount.IsUint64() {
+		return xrp.ErrInvalidAmount
+	}
+
+	client := rippled()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	account, err := client.Account(ctx, from.Address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %v", from.Address, client.Name(), err)
+	}
+	receiver, err := client.Account(ctx, to)
+	switch {
+	case errors.Is(err, xrp.ErrAccountNotFound):
+		reserve, err := client.BaseReserve(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the account reserve from %s: %v", client.Name(), err)
+		}
+		if amount.Uint64() < reserve {
+			return fmt.Errorf("%s is not activated yet, the first payment must be at least the %s XRP reserve",
+				to, coin.FormatUnits(new(big.Int).SetUint64(reserve), xrp.Decimals))
+		}
+	case err != nil:
+		return fmt.Errorf("failed to fetch %s from %s: %v", to, client.Name(), err)
+	case receiver.RequiresDestinationTag() && !hasTag:
+		return fmt.Errorf("%s requires a destination tag, add the --tag the recipient gave you", to)
+	}
+	if !hasTag {
+		if err := r.confirmUntagged("destination tag", to); err != nil {
+			return err
+		}
+	}
+	appConfig := config.GetAppConfig()
+	maxFee := appConfig.GetXRPConfig().MaxFee
+	fee, err := client.Fee(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the fee from %s: %v", client.Name(), err)
+	}
+	fee = max(fee, 12)
+	if fee > maxFee {
+		return fmt.Errorf("the network fee is %d drops, above xrp.max_fee %d", fee, maxFee)
+	}
+	ledger, err := client.LedgerIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the current ledger from %s: %v", client.Name(), err)
+	}
+
+	value := new(big.Rat).SetFrac(amount, big.NewInt(1_000_000))
+	if err := r.checkTransfer("xrp_signTransaction", from.Address, "XRP", to, value); err != nil {
+		audit.ForDir(r.baseDir()).Record("repl", "xrp_signTransaction", "", err.Error())
+		return err
+	}
+	tx := &xrp.Payment{Account: sender.AccountID, Destination: destination.AccountID, DestinationTag: tag, HasDestinationTag: hasTag,
+		Amount: amount.Uint64(), Fee: fee, Sequence: account.Sequence, LastLedgerSequence: ledger + ledgerWindow}
+	if err := r.signXRP(tx, from); err != nil {
+		return err
+	}
+	encoded, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+	hash, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+
+	target := to
+	if to != args[1] {
+		target = fmt.Sprintf("%s (%s)", to, args[1])
+	}
+	fmt.Println(r.template.Info(fmt.Sprintf("Send %s XRP to %s", r.format().Decimal(coin.FormatUnits(amount, xrp.Decimals)), target)))
+	fmt.Printf("  From:     %s\n", from.Address)
+	if hasTag {
+		fmt.Printf("  Tag:      %d\n", tag)
+	}
+	fmt.Printf("  Fee:      %d drops\n", fee)
+	fmt.Printf("  Expires:  after ledger %d\n", ledger+ledgerWindow)
+	fmt.Printf("  Hash:     %s\n", hash)
+	fmt.Printf("  Raw:      %s\n", strings.ToUpper(hex.EncodeToString(encoded)))
+	if !broadcast {
+		fmt.Println(r.template.Info("Transaction signed but not broadcast, rerun with --broadcast to send it before it expires"))
+		return nil
+	}
+
+	answer, err := r.line.Prompt("Broadcast? [y/N]: ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Errorf("broadcast cancelled")
+	}
+	logger := audit.ForDir(r.baseDir())
+	sent, err := client.Submit(ctx, tx)
+	if err != nil {
+		logger.Record("repl", "xrp.send", hash, err.Error())
+		return fmt.Errorf("broadcast failed: %v", err)
+	}
+	logger.Record("repl", "xrp.send", sent, "ok")
+	fmt.Println(r.template.Success(fmt.Sprintf("Broadcast %s", sent)))
+	r.setVariable(varLastTxID, sent, "xrp.send")
+	if err := r.recordSpend(watch.Spend{Coin: "XRP", AccountID: from.AccountID, TxID: sent, To: to, Amount: amount.String(),
+		Fee: strconv.FormatUint(fee, 10), SentAt: time.Now().UTC(), Command: "xrp.send"}); err != nil {
+		logging.Warnf("Failed to record spend %s: %v", sent, err)
+	}
+	return nil
+}
+
+// signXRP 用地址私钥签名交易
+func (r *REPL) signXRP(tx *xrp.Payment, from *core.AddressKey) error {
+	key, err := r.accountMgr.AddressPrivateKey(from)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	// Sign 签名后会清除这份副本
+	if err := tx.Sign(append([]byte(nil), key.Bytes()...)); err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	metrics.Inc(metrics.Signatures, "method", "xrp_signTransaction")
+	return nil
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+)
+
+// sortOption 取出 --sort created|index|label，返回排序方式和其余参数；没有时按索引
+func sortOption(args []string) (core.ListOrder, []string, error) {
+	for i, arg := range args {
+		if arg != "--sort" {
+			continue
+		}
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("--sort needs created, index or label")
+		}
+		order, err := core.ParseListOrder(args[i+1])
+		if err != nil {
+			return "", nil, err
+		}
+		rest := append(append([]string(nil), args[:i]...), args[i+2:]...)
+		return order, rest, nil
+	}
+	return core.OrderIndex, args, nil
+}
+
+// labelOf 按 --sort label 排序用的标签查询
+func labelOf(store *metadata.Store) func(key string) string {
+	return func(key string) string {
+		label, _ := store.Get(metadata.Labels, key)
+		return label
+	}
+}
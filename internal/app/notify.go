@@ -0,0 +1,51 @@
+package app
+
+import (
+	"github.com/palagend/slowmade/internal/alert"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/keyusage"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// notifiable 未配置 notify.events 时通知的事件，索引用的变更事件太频繁，不作为通知
+var notifiable = []string{events.PaymentReceived, events.RequestPaid, events.WalletLocked, alert.Fired, keyusage.Detected}
+
+// newEventBus 创建事件总线，按配置订阅 webhook、索引器 webhook、桌面通知和 Telegram 机器人
+func newEventBus() *events.Bus {
+	appConfig := config.GetAppConfig()
+	watchConfig := appConfig.GetWatchConfig()
+	notifyConfig := appConfig.GetNotifyConfig()
+	indexConfig := appConfig.GetIndexConfig()
+	notifyEvents := notifyConfig.Events
+	if len(notifyEvents) == 0 {
+		notifyEvents = notifiable
+	}
+
+	bus := events.NewBus()
+	if len(watchConfig.Webhooks) > 0 {
+		// webhook 只接收收款相关的事件和告警
+		bus.Subscribe(events.Only([]string{events.PaymentReceived, events.RequestPaid, alert.Fired}, events.Webhook(watchConfig.Webhooks)))
+	}
+	if len(indexConfig.Webhooks) > 0 {
+		bus.Subscribe(events.Only(mirror.Events, events.Webhook(indexConfig.Webhooks)))
+	}
+	if notifyConfig.Desktop {
+		bus.Subscribe(events.Only(notifyEvents, events.Desktop()))
+	}
+	if telegram := notifyConfig.Telegram; telegram.Enabled {
+		handler, err := events.Telegram(events.TelegramOptions{
+			APIURL:   telegram.APIURL,
+			BotToken: telegram.BotToken,
+			ChatID:   telegram.ChatID,
+			Template: telegram.Template,
+		})
+		if err != nil {
+			logging.Warnf("Telegram notifications disabled: %v", err)
+		} else {
+			bus.Subscribe(events.Only(notifyEvents, handler))
+		}
+	}
+	return bus
+}
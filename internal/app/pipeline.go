@@ -0,0 +1,315 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ansiEscape 终端颜色和光标控制序列，写入文件或交给过滤器前去掉
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// errPromptCaptured 输出被捕获时命令要求输入，提示不会显示在终端上，直接失败而不是等待
+var errPromptCaptured = errors.New("this command prompts for input and cannot be piped or redirected")
+
+// pipeline 一行输入拆分后的结果：第一段是 REPL 命令，之后每段是一个过滤器，
+// target 非空时把最终输出写入文件
+type pipeline struct {
+	command []string
+	filters [][]string
+	target  string
+	append  bool
+}
+
+// parsePipeline 按不加引号的 "|"、">"、">>" 操作符拆分输入，没有这些符号时返回 nil；
+// 重定向只能出现在最后
+func parsePipeline(tokens []token) (*pipeline, error) {
+	var (
+		p      = &pipeline{}
+		stage  []string
+		stages [][]string
+		found  bool
+		parts  = words(tokens)
+	)
+	for i := 0; i < len(parts); i++ {
+		if !tokens[i].operator {
+			if p.target != "" {
+				return nil, fmt.Errorf("redirection must come last")
+			}
+			stage = append(stage, parts[i])
+			continue
+		}
+		switch parts[i] {
+		case "|":
+			if len(stage) == 0 {
+				return nil, fmt.Errorf("empty command before '|'")
+			}
+			stages, stage, found = append(stages, stage), nil, true
+		case ">", ">>":
+			if len(stage) == 0 {
+				return nil, fmt.Errorf("empty command before '%s'", parts[i])
+			}
+			if i != len(parts)-2 {
+				return nil, usageFailure(fmt.Sprintf("usage: <command> [| filter ...] %s <file>", parts[i]))
+			}
+			if tokens[i+1].operator {
+				return nil, fmt.Errorf("missing file after '%s'", parts[i])
+			}
+			p.target, p.append = parts[i+1], parts[i] == ">>"
+			i++
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	if len(stage) == 0 {
+		return nil, fmt.Errorf("empty command after '|'")
+	}
+	stages = append(stages, stage)
+	p.command, p.filters = stages[0], stages[1:]
+	return p, nil
+}
+
+// runPipeline 执行命令并捕获输出，依次经过过滤器，最后写入文件或打印
+func (r *REPL) runPipeline(p *pipeline) error {
+	command := strings.ToLower(p.command[0])
+	if c, ok := r.lookupCommand(command); ok && c.interactive {
+		return fmt.Errorf("%s prompts for input or shows secrets and cannot be piped or redirected", command)
+	}
+	filters := make([]func([]string) []string, 0, len(p.filters))
+	for _, args := range p.filters {
+		filter, err := newFilter(args)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+
+	var target string
+	if p.target != "" {
+		var err error
+		if target, err = r.confirmOutputFile(p.target, p.append); err != nil {
+			return err
+		}
+	}
+
+	output, err := r.capture(func() error { return r.execute(p.command) }, false)
+	if err != nil {
+		// 命令失败时照常显示已有的输出，不写文件
+		fmt.Print(output)
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if output == "" {
+		lines = nil
+	}
+	for _, filter := range filters {
+		lines = filter(lines)
+	}
+	text := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		text += "\n"
+	}
+
+	if target == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := writeRedirect(target, text, p.append); err != nil {
+		return err
+	}
+	fmt.Println(r.template.Success(fmt.Sprintf("%d lines written to %s", len(lines), target)))
+	return nil
+}
+
+// capture 把 fn 执行期间写到标准输出的内容收集起来，去掉终端控制序列后返回；
+// echo 为 true 时同时照常显示在终端上，命令仍然可以提示输入
+func (r *REPL) capture(fn func() error, echo bool) (string, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	var buf strings.Builder
+	var sink io.Writer = &buf
+	if echo {
+		sink = io.MultiWriter(stdout, &buf)
+	}
+	done := make(chan struct{})
+	go func() {
+		io.Copy(sink, reader)
+		close(done)
+	}()
+
+	os.Stdout = writer
+	r.line.capturing = !echo
+	func() {
+		defer func() {
+			os.Stdout = stdout
+			r.line.capturing = false
+		}()
+		err = fn()
+	}()
+	writer.Close()
+	<-done
+	reader.Close()
+	return ansiEscape.ReplaceAllString(buf.String(), ""), err
+}
+
+// confirmOutputFile 检查重定向或会话记录的目标文件：不允许写入钱包存储目录，覆盖已有文件前需要确认
+func (r *REPL) confirmOutputFile(name string, appendMode bool) (string, error) {
+	target, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	if base, err := filepath.Abs(r.baseDir()); err == nil {
+		if rel, err := filepath.Rel(base, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("refusing to write into the wallet storage directory %s", base)
+		}
+	}
+	info, err := os.Stat(target)
+	switch {
+	case os.IsNotExist(err):
+		return target, nil
+	case err != nil:
+		return "", err
+	case info.IsDir():
+		return "", fmt.Errorf("%s is a directory", target)
+	case appendMode:
+		return target, nil
+	}
+	answer, err := r.line.Prompt(fmt.Sprintf("%s already exists. Overwrite? [y/N]: ", target))
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return "", fmt.Errorf("cancelled, %s was not changed", target)
+	}
+	return target, nil
+}
+
+// writeRedirect 追加写入，或先写临时文件再重命名，避免留下写了一半的文件
+func writeRedirect(target, text string, appendMode bool) error {
+	if appendMode {
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteString(text); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// newFilter 解析管道中的过滤器：grep、head、tail、sort、uniq、wc
+func newFilter(args []string) (func([]string) []string, error) {
+	name, args := args[0], args[1:]
+	switch name {
+	case "grep":
+		var invert, fold bool
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") && len(args[0]) > 1 {
+			for _, flag := range args[0][1:] {
+				switch flag {
+				case 'v':
+					invert = true
+				case 'i':
+					fold = true
+				default:
+					return nil, usageFailure(fmt.Sprintf("grep: unknown flag -%c", flag))
+				}
+			}
+			args = args[1:]
+		}
+		if len(args) != 1 {
+			return nil, usageFailure("usage: grep [-i] [-v] <pattern>")
+		}
+		pattern := args[0]
+		if fold {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("grep: %w", err)
+		}
+		return func(lines []string) []string {
+			var out []string
+			for _, line := range lines {
+				if re.MatchString(line) != invert {
+					out = append(out, line)
+				}
+			}
+			return out
+		}, nil
+	case "head", "tail":
+		n := 10
+		switch {
+		case len(args) == 2 && args[0] == "-n":
+			args = args[1:]
+			fallthrough
+		case len(args) == 1:
+			v, err := strconv.Atoi(strings.TrimPrefix(args[0], "-"))
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("%s: invalid line count %q", name, args[0])
+			}
+			n = v
+		case len(args) != 0:
+			return nil, usageFailure(fmt.Sprintf("usage: %s [-n N]", name))
+		}
+		return func(lines []string) []string {
+			if len(lines) <= n {
+				return lines
+			}
+			if name == "head" {
+				return lines[:n]
+			}
+			return lines[len(lines)-n:]
+		}, nil
+	case "sort":
+		if len(args) > 1 || (len(args) == 1 && args[0] != "-r") {
+			return nil, usageFailure("usage: sort [-r]")
+		}
+		reverse := len(args) == 1
+		return func(lines []string) []string {
+			out := append([]string(nil), lines...)
+			sort.SliceStable(out, func(i, j int) bool {
+				if reverse {
+					return out[i] > out[j]
+				}
+				return out[i] < out[j]
+			})
+			return out
+		}, nil
+	case "uniq":
+		if len(args) != 0 {
+			return nil, usageFailure("usage: uniq")
+		}
+		return func(lines []string) []string {
+			var out []string
+			for i, line := range lines {
+				if i == 0 || line != lines[i-1] {
+					out = append(out, line)
+				}
+			}
+			return out
+		}, nil
+	case "wc":
+		if len(args) > 1 || (len(args) == 1 && args[0] != "-l") {
+			return nil, usageFailure("usage: wc [-l]")
+		}
+		return func(lines []string) []string {
+			return []string{strconv.Itoa(len(lines))}
+		}, nil
+	}
+	return nil, usageFailure(fmt.Sprintf("unknown filter %q (available: grep, head, tail, sort, uniq, wc)", name))
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// staleBackupAge 超过这个时间没有备份时提示
+const staleBackupAge = 90 * 24 * time.Hour
+
+// postureItem 安全概况中的一项，weak 时给出修正建议
+type postureItem struct {
+	name   string
+	detail string
+	weak   bool
+	fix    string
+}
+
+// securityPosture 检查存储目录权限、cloak、KDF 强度、自动锁定和备份情况
+func (r *REPL) securityPosture() []postureItem {
+	return []postureItem{
+		r.permissionPosture(),
+		r.cloakPosture(),
+		kdfPosture(),
+		autoLockPosture(),
+		r.backupPosture(),
+	}
+}
+
+func (r *REPL) permissionPosture() postureItem {
+	item := postureItem{name: "Data directory"}
+	loose, err := core.LoosePermissions(r.baseDir())
+	switch {
+	case err != nil:
+		item.detail, item.weak = fmt.Sprintf("cannot check permissions: %v", err), true
+		item.fix = "check that " + r.baseDir() + " exists and is readable"
+	case len(loose) > 0:
+		item.detail = fmt.Sprintf("%d entries accessible by other users, e.g. %s", len(loose), loose[0].Path)
+		item.weak, item.fix = true, fmt.Sprintf("chmod -R go-rwx %s, or set storage.permissions = \"repair\"", r.baseDir())
+	default:
+		item.detail = "private (no group or other access)"
+	}
+	return item
+}
+
+func (r *REPL) cloakPosture() postureItem {
+	if r.cloaked {
+		return postureItem{name: "Cloak", detail: "in use; the same cloak is needed to see these accounts again"}
+	}
+	// cloak 是可选的附加口令，不使用不算薄弱项
+	return postureItem{name: "Cloak", detail: "not in use (optional, see --cloak)"}
+}
+
+func kdfPosture() postureItem {
+	item := postureItem{name: "Key derivation", detail: crypto.GetCurrentKDF()}
+	if weakness := crypto.CurrentKDFWeakness(); weakness != "" {
+		item.detail, item.weak = weakness, true
+		item.fix = "raise the KDF parameters, then change the wallet password to re-encrypt"
+	}
+	return item
+}
+
+func autoLockPosture() postureItem {
+	if timeout := autoLockTimeout(); timeout > 0 {
+		return postureItem{name: "Auto-lock", detail: fmt.Sprintf("after %s idle", timeout)}
+	}
+	return postureItem{name: "Auto-lock", detail: "disabled", weak: true,
+		fix: "set wallet.auto_lock_minutes in the config file"}
+}
+
+func (r *REPL) backupPosture() postureItem {
+	item := postureItem{name: "Backup"}
+	if _, _, err := r.walletMgr.Timestamps(); errors.Is(err, core.ErrWalletNotCreated) {
+		item.detail = "no wallet yet"
+		return item
+	}
+	last, err := backup.LastBackup(r.baseDir())
+	if err != nil {
+		item.detail, item.weak, item.fix = fmt.Sprintf("cannot read the backup record: %v", err), true, "run backup.qr, sync.push or slowmade backup create"
+		return item
+	}
+	if last == nil {
+		item.detail, item.weak = "none recorded", true
+		item.fix = "write down the mnemonic, then run backup.qr, sync.push or slowmade backup create"
+		return item
+	}
+	item.detail = fmt.Sprintf("last %s via %s", r.format().Date(last.At), last.Method)
+	if changed, err := backup.ChangedSince(r.baseDir(), last.At); err == nil && changed {
+		item.detail += ", accounts changed since"
+		item.weak, item.fix = true, "run backup.qr, sync.push or slowmade backup create again"
+	} else if time.Since(last.At) > staleBackupAge {
+		item.weak, item.fix = true, "verify the backup still restores, or take a new one"
+	}
+	return item
+}
+
+// printSecuritySummary 在欢迎信息后显示安全概况，有薄弱项时给出修正建议
+func (r *REPL) printSecuritySummary() {
+	items := r.securityPosture()
+	weak := 0
+	fmt.Println("Security summary:")
+	for _, item := range items {
+		line := fmt.Sprintf("%-15s %s", item.name, item.detail)
+		if !item.weak {
+			fmt.Println("  " + r.template.Success(line))
+			continue
+		}
+		weak++
+		fmt.Println("  " + r.template.Warning(line))
+		fmt.Printf("      fix: %s\n", item.fix)
+	}
+	if weak > 0 {
+		fmt.Println(r.template.Info(fmt.Sprintf("%d item(s) need attention; security.status shows this summary again", weak)))
+	}
+	fmt.Println()
+}
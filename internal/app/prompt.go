@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/view"
+)
+
+// promptCache 解析后的 ui.prompt 模板，配置改变时重新解析；模板出错时只提示一次
+type promptCache struct {
+	text        string
+	tmpl        *template.Template
+	failed      string // 已提示过错误的模板文本
+	fingerprint string // 主密钥指纹，解锁期间缓存，解密种子较慢
+}
+
+// getPrompt 生成提示符：配置了 ui.prompt 时按模板渲染，模板无效或执行出错时回退到内置提示符
+func (r *REPL) getPrompt() string {
+	appConfig := config.GetAppConfig()
+	text := appConfig.GetUIConfig().Prompt
+	if text != "" {
+		prompt, err := r.customPrompt(text)
+		if err == nil {
+			return prompt
+		}
+		if r.prompt.failed != text {
+			r.prompt.failed = text
+			fmt.Println(r.template.Warning(fmt.Sprintf("ui.prompt: %v, using the built-in prompt", err)))
+		}
+	}
+	return r.template.Prompt(r.walletMgr.IsLocked(), r.context.String())
+}
+
+// customPrompt 按 ui.prompt 模板渲染提示符，只计算模板用到的较慢的变量
+func (r *REPL) customPrompt(text string) (string, error) {
+	if r.prompt.text != text || r.prompt.tmpl == nil {
+		tmpl, err := view.PromptTemplate(text, r.format().FuncMap())
+		if err != nil {
+			return "", err
+		}
+		r.prompt.text, r.prompt.tmpl = text, tmpl
+	}
+
+	appConfig := config.GetAppConfig()
+	locked := r.walletMgr.IsLocked()
+	data := view.PromptData{
+		Locked:   locked,
+		ReadOnly: readOnlyMode(),
+		Network:  appConfig.GetRPCConfig().Network,
+		DataDir:  r.baseDir(),
+		Time:     time.Now(),
+		Vars:     make(map[string]string, len(r.variables)),
+	}
+	data.State, data.Icon = view.PromptState(locked)
+	for name, variable := range r.variables {
+		data.Vars[name] = variable.value
+	}
+	data.Context, data.Coin = r.context.String(), r.context.coin
+	data.Account = r.context.account
+	if data.Account == "" {
+		data.Account = data.Vars["ACC"]
+	}
+	if data.Account == "" {
+		data.Account = data.Vars[varLastAccount]
+	}
+
+	if locked {
+		r.prompt.fingerprint = ""
+	} else if strings.Contains(text, ".Fingerprint") {
+		if r.prompt.fingerprint == "" {
+			r.prompt.fingerprint, _ = r.accountMgr.MasterFingerprint()
+		}
+		data.Fingerprint = r.prompt.fingerprint
+	}
+	if !locked && data.Coin == "" && data.Account != "" && strings.Contains(text, ".Coin") {
+		if accountID, err := r.resolveAccountID(data.Account); err == nil {
+			if account, err := r.findAccount(accountID); err == nil {
+				data.Coin = account.CoinSymbol
+			}
+		}
+	}
+	return view.RenderPrompt(r.prompt.tmpl, data)
+}
+
+// inputPrompt 行编辑器按字符数计算光标位置：多行提示符的前几行直接输出，输入所在的最后一行去掉颜色
+func inputPrompt(prompt string) string {
+	if i := strings.LastIndexByte(prompt, '\n'); i >= 0 {
+		fmt.Print(prompt[:i+1])
+		prompt = prompt[i+1:]
+	}
+	return ansiEscape.ReplaceAllString(prompt, "")
+}
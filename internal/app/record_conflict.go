@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// acceptAllFlag 从参数中移除 --accept-all，返回剩余参数和是否指定
+func acceptAllFlag(args []string) ([]string, bool) {
+	i := slices.Index(args, "--accept-all")
+	if i < 0 {
+		return args, false
+	}
+	return slices.Delete(slices.Clone(args), i, i+1), true
+}
+
+// conflictResolver 显示导入记录与本机记录的逐字段差异，逐条询问是否覆盖；acceptAll 时只显示差异并全部覆盖
+func (r *REPL) conflictResolver(acceptAll bool) core.ConflictResolver {
+	return func(conflict *core.RecordConflict) (bool, error) {
+		key := conflict.Key
+		if conflict.Kind == core.RecordAccount {
+			key = core.ShortIDs([]string{key})[key]
+		}
+		fmt.Println(r.template.Warning(fmt.Sprintf("Existing %s %s differs:", conflict.Kind, key)))
+		for _, change := range conflict.Changes {
+			switch change.Type() {
+			case "added":
+				fmt.Printf("  + %s: %s\n", change.Field, change.After)
+			case "removed":
+				fmt.Printf("  - %s: %s\n", change.Field, change.Before)
+			default:
+				fmt.Printf("  ~ %s: %s -> %s\n", change.Field, change.Before, change.After)
+			}
+		}
+		if acceptAll {
+			return true, nil
+		}
+		answer, err := r.line.Prompt(fmt.Sprintf("Overwrite the existing %s? [y/N]: ", conflict.Kind))
+		if err != nil {
+			return false, err
+		}
+		return strings.EqualFold(strings.TrimSpace(answer), "y"), nil
+	}
+}
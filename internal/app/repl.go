@@ -2,30 +2,66 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/palagend/slowmade/internal/config"
 	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/diagnostics"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/integrity"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/price"
+	"github.com/palagend/slowmade/internal/search"
 	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/tracing"
+	"github.com/palagend/slowmade/internal/transcript"
 	"github.com/palagend/slowmade/internal/view"
 	"github.com/palagend/slowmade/pkg/logging"
 	"github.com/peterh/liner"
 	"go.uber.org/zap"
+	"golang.org/x/term"
 )
 
 // REPL 表示一个交互式读取-求值-打印循环环境
 type REPL struct {
-	line           *liner.State
-	running        bool
-	commands       map[string]CommandHandler
-	logger         *zap.Logger
-	walletMgr      core.WalletManager
-	accountMgr     core.AccountManager
-	template       view.DisplayTemplate
-	cachedPassword []byte
-	passwordMgr    *security.PasswordManager
-	sessionHistory []string // 当前会话的历史记录
+	line             *inputLine
+	running          bool
+	commands         map[string]CommandHandler
+	groups           []commandGroup  // 命令注册表，生成分发、补全和帮助
+	readOnlyCommands map[string]bool // 只读模式下可用的命令
+	logger           *zap.Logger
+	walletMgr        core.WalletManager
+	accountMgr       core.AccountManager
+	template         view.DisplayTemplate
+	cachedPassword   []byte
+	passwordMgr      *security.PasswordManager
+	sessionHistory   []string           // 当前会话的历史记录
+	searchIndex      *search.Index      // 解锁后构建的查找索引，锁定时清除
+	searchMetaTime   time.Time          // 构建查找索引时元数据文件的修改时间
+	searchStale      atomic.Bool        // 构建查找索引之后账户或地址有变化
+	watchCancel      context.CancelFunc // 后台收款监控，未运行时为 nil
+	bus              *events.Bus        // 收款和自动锁定事件，转发到提示信息和配置的通知
+	priceMu          sync.Mutex
+	prices           *price.Service // 法币金额换算用的汇率服务，首次使用时创建
+	noticeMu         sync.Mutex
+	notices          []string             // 后台事件的提示，在下一次提示符前显示
+	transcript       *transcript.Recorder // session.record 开启的会话记录，未记录时为 nil
+	diagnostics      diagnostics.Options  // 崩溃时生成诊断包用的存储信息
+	integrity        *integrity.Guard     // 存储目录防篡改，未启用时为 nil
+	cloaked          bool                 // 钱包使用了 --cloak 附加口令
+	activityMu       sync.Mutex
+	busy             bool                       // 正在执行命令，此时不自动锁定
+	lastActivity     time.Time                  // 上一条命令结束的时间
+	variables        map[string]sessionVariable // set 设置和命令自动设置的会话变量
+	prompt           promptCache                // ui.prompt 模板和它用到的缓存值
+	context          workContext                // use 设置的默认币种和账户
 }
 
 // CommandHandler 定义命令处理函数类型
@@ -33,7 +69,7 @@ type CommandHandler func(args []string) error
 
 // NewREPL 创建并初始化一个新的 REPL 实例
 func NewREPL(walletMgr core.WalletManager, accountMgr core.AccountManager) (*REPL, error) {
-	return NewREPLWithTemplate(walletMgr, accountMgr, view.NewDefaultTemplate())
+	return NewREPLWithTemplate(walletMgr, accountMgr, view.NewTemplate())
 }
 
 // NewREPLWithTemplate 使用自定义模板创建 REPL 实例
@@ -42,70 +78,63 @@ func NewREPLWithTemplate(walletMgr core.WalletManager, accountMgr core.AccountMa
 	line.SetCtrlCAborts(true)
 	line.SetTabCompletionStyle(liner.TabCircular)
 
-	// 简化的命令补全
+	// 命令补全，候选由命令注册表生成
+	var repl *REPL
 	line.SetCompleter(func(line string) []string {
-		return []string{
-			"exit", "quit", "help", "clear", "history", "version",
-			"wallet.create", "wallet.restore", "wallet.unlock", "wallet.lock", "wallet.status",
-			"account.create", "account.list", "address.derive", "address.list",
-		}
+		return repl.commandNames()
 	})
 
-	repl := &REPL{
-		line:        line,
+	repl = &REPL{
+		line:        &inputLine{State: line},
 		running:     true,
 		logger:      logging.Get(),
-		commands:    make(map[string]CommandHandler),
 		walletMgr:   walletMgr,
 		accountMgr:  accountMgr,
 		template:    template,
 		passwordMgr: security.GetPasswordManager(),
+		bus:         newEventBus(),
 	}
+	repl.bus.Subscribe(repl.queueNotice)
+	repl.bus.Subscribe(events.Only(searchEvents, repl.markSearchStale))
 
 	repl.registerCommands()
 	return repl, nil
 }
 
-// registerCommands 注册所有命令
-func (r *REPL) registerCommands() {
-	r.commands = map[string]CommandHandler{
-		// 基础命令
-		"exit":    r.handleExit,
-		"quit":    r.handleExit,
-		"help":    r.handleHelp,
-		"clear":   r.handleClear,
-		"history": r.handleHistory,
-		"version": r.handleVersion,
-
-		// 钱包管理命令
-		"wallet.create":  r.handleWalletCreate,
-		"wallet.restore": r.handleWalletRestore,
-		"wallet.unlock":  r.handleWalletUnlock,
-		"wallet.lock":    r.handleWalletLock,
-		"wallet.status":  r.handleWalletStatus,
+// readOnlyMode 存储目录是否以只读方式打开
+func readOnlyMode() bool {
+	appConfig := config.GetAppConfig()
+	return appConfig.GetStorageConfig().ReadOnly
+}
 
-		// 账户管理命令（简化参数）
-		"account.create": r.handleAccountCreate,
-		"account.list":   r.handleAccountList,
-		"address.derive": r.handleAddressDerive,
-		"address.list":   r.handleAddressList,
-	}
+// baseDir 返回配置的存储根目录
+func (r *REPL) baseDir() string {
+	appConfig := config.GetAppConfig()
+	return appConfig.GetStorageConfig().BaseDir
 }
 
-// getPrompt 使用模板生成提示符
-func (r *REPL) getPrompt() string {
-	return r.template.Prompt(r.walletMgr.IsLocked())
+// format 返回当前语言的日期和数字格式化器
+func (r *REPL) format() *view.Formatter {
+	return view.CurrentFormatter()
 }
 
 // printWelcome 显示欢迎信息
 func (r *REPL) printWelcome() {
 	fmt.Println(r.template.Welcome())
+	if readOnlyMode() {
+		fmt.Println(r.template.Info("Read-only mode: only commands that do not change the data directory are available"))
+	}
+	r.printSecuritySummary()
 }
 
-// Run 启动 REPL 主循环
-func (r *REPL) Run() {
+// Run 启动 REPL 主循环。标准输入不是终端（脚本通过管道输入命令）时返回最后一个失败命令的错误，
+// 交互使用时总是返回 nil
+func (r *REPL) Run() error {
 	defer r.Close()
+	var failed error
 	r.printWelcome()
+	stopAutoLock := r.startAutoLock()
+	defer stopAutoLock()
 
 	for r.running {
 		input, err := r.readInput()
@@ -147,16 +176,36 @@ func (r *REPL) Run() {
 		}
 
 		// 添加到历史记录（liner会自动处理）
-		r.line.AppendHistory(input)
+		if keepInHistory(input) {
+			r.line.AppendHistory(input)
+		}
 
 		// 处理输入
-		if err := r.processInput(input); err != nil {
+		r.markActivity(true)
+		err = r.processSafely(input)
+		r.markActivity(false)
+		if err != nil {
 			if err == ErrExitRequested {
 				break
 			}
 			fmt.Println(r.template.Error(err.Error()))
+			failed = err
 		}
 	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return failed
+}
+
+// Exec 非交互地执行一行命令，与 REPL 使用相同的分词、&& 链、管道和重定向规则；exit 和 quit 视为成功
+func (r *REPL) Exec(input string) error {
+	err := r.processSafely(input)
+	r.flushNotices()
+	if err != nil && err != ErrExitRequested {
+		return err
+	}
+	return nil
 }
 
 // readInputWithFallback 使用回退提示符读取输入
@@ -182,28 +231,95 @@ func (r *REPL) processInput(input string) error {
 	}
 
 	// 添加到会话历史记录（去重）
-	if len(r.sessionHistory) == 0 || r.sessionHistory[len(r.sessionHistory)-1] != input {
+	if keepInHistory(input) && (len(r.sessionHistory) == 0 || r.sessionHistory[len(r.sessionHistory)-1] != input) {
 		r.sessionHistory = append(r.sessionHistory, input)
 	}
 
-	parts := strings.Fields(input)
-	if len(parts) == 0 {
-		return nil
+	tokens, err := tokenize(input)
+	if err != nil {
+		return err
 	}
+	commands, err := splitChain(tokens)
+	if err != nil {
+		return err
+	}
+	// 用 && 连接的命令依次执行，一条失败后不再执行后面的命令；变量在执行前展开，可以使用前面的命令设置的值
+	for i, command := range commands {
+		command, err := r.expandVariables(command)
+		if err == nil {
+			err = r.runCommand(command)
+		}
+		if err != nil {
+			if rest := len(commands) - i - 1; rest > 0 {
+				fmt.Println(r.template.Warning(fmt.Sprintf("Skipped %d remaining chained command(s)", rest)))
+			}
+			return err
+		}
+	}
+	return nil
+}
 
+// runCommand 执行一条命令，正在记录会话时同时记录命令和输出
+func (r *REPL) runCommand(tokens []token) error {
+	parts := words(tokens)
+	if r.transcript != nil && !strings.HasPrefix(strings.ToLower(parts[0]), "session.") {
+		output, err := r.capture(func() error { return r.dispatch(tokens) }, true)
+		if recordErr := r.transcript.Record(redactArguments(parts), output, err); recordErr != nil {
+			fmt.Println(r.template.Warning(fmt.Sprintf("Session recording failed: %v", recordErr)))
+		}
+		return err
+	}
+	return r.dispatch(tokens)
+}
+
+// dispatch 执行一条命令，含管道或重定向时交给 runPipeline
+func (r *REPL) dispatch(tokens []token) error {
+	pipe, err := parsePipeline(tokens)
+	if err != nil {
+		return err
+	}
+	if pipe != nil {
+		return r.runPipeline(pipe)
+	}
+	return r.execute(words(tokens))
+}
+
+// execute 查找并执行一条命令
+func (r *REPL) execute(parts []string) error {
 	command := strings.ToLower(parts[0])
 	args := parts[1:]
 
 	if handler, exists := r.commands[command]; exists {
-		return handler(args)
+		// <command> --help 与 help <command> 相同，不执行命令
+		if len(args) == 1 && args[0] == "--help" {
+			return r.handleHelp([]string{command})
+		}
+		if readOnlyMode() && !r.readOnlyCommands[command] {
+			metrics.Inc(metrics.Commands, "command", command, "result", "error")
+			return fmt.Errorf("%s is not available in read-only mode", command)
+		}
+		if c, ok := r.lookupCommand(command); ok {
+			args = r.withContext(c, args)
+		}
+		timer := r.startTimer()
+		span := tracing.Command(command, "command", command)
+		err := handler(args)
+		span.End(err)
+		r.stopTimer(command, timer)
+		r.reloadExternalWrites(command)
+		metrics.Inc(metrics.Commands, "command", command, "result", metrics.Result(err))
+		return err
 	}
 
-	return fmt.Errorf("unknown command: %s. Type 'help' for available commands", command)
+	// 未知命令统一计为 unknown，避免任意输入产生新的标签值
+	metrics.Inc(metrics.Commands, "command", "unknown", "result", "error")
+	return r.unknownCommandError(command)
 }
 
 // readInput 读取用户输入
 func (r *REPL) readInput() (string, error) {
-	prompt := r.getPrompt()
+	r.flushNotices()
+	prompt := inputPrompt(r.getPrompt())
 
 	line, err := r.line.Prompt(prompt)
 	if err == liner.ErrPromptAborted || err == io.EOF {
@@ -221,6 +337,10 @@ func (r *REPL) readInput() (string, error) {
 
 // Close 清理资源
 func (r *REPL) Close() {
+	if r.transcript != nil {
+		r.transcript.Close()
+		r.transcript = nil
+	}
 	if r.line != nil {
 		r.line.Close()
 	}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peterh/liner"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/resilience"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// kdfCommands 需要用密码派生密钥的命令，耗时主要来自有意放慢的 KDF
+var kdfCommands = map[string]bool{
+	"wallet.create":       true,
+	"wallet.restore":      true,
+	"wallet.unlock":       true,
+	"wallet.mnemonic":     true,
+	"wallet.test-restore": true,
+	"backup.restore":      true,
+	"security.report":     true,
+	"tutorial":            true,
+}
+
+// inputLine 在 liner 之上记录等待用户输入的时间，命令耗时中扣除这部分
+type inputLine struct {
+	*liner.State
+	waited    time.Duration
+	capturing bool // 输出正在被管道或重定向捕获，此时不能提示输入
+}
+
+// Prompt 读取一行输入并累计等待时间；输出被捕获时提示看不到，返回 errPromptCaptured
+func (l *inputLine) Prompt(prompt string) (string, error) {
+	if l.capturing {
+		return "", errPromptCaptured
+	}
+	start := time.Now()
+	defer func() { l.waited += time.Since(start) }()
+	return l.State.Prompt(prompt)
+}
+
+// commandTimer 一条命令的计时：墙钟时间扣除等待输入的时间，同时记下外部调用的数量
+type commandTimer struct {
+	start  time.Time
+	waited time.Duration
+	calls  map[string]resilience.Stats
+}
+
+func (r *REPL) startTimer() *commandTimer {
+	return &commandTimer{start: time.Now(), waited: r.line.waited, calls: endpointStats()}
+}
+
+func endpointStats() map[string]resilience.Stats {
+	stats := make(map[string]resilience.Stats)
+	for _, s := range resilience.Snapshot() {
+		stats[s.Endpoint] = s
+	}
+	return stats
+}
+
+// stopTimer 记录命令耗时，超过 ui.slow_hint 秒时在下一次提示符前说明原因
+func (r *REPL) stopTimer(command string, timer *commandTimer) {
+	elapsed := time.Since(timer.start) - (r.line.waited - timer.waited)
+	metrics.Observe(metrics.CommandSeconds, elapsed, "command", command)
+
+	appConfig := config.GetAppConfig()
+	threshold := time.Duration(appConfig.GetUIConfig().SlowHint) * time.Second
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	hint := fmt.Sprintf("%s took %s.", command, elapsed.Round(100*time.Millisecond))
+	if kdfCommands[command] {
+		hint += fmt.Sprintf(" Most of it is key derivation: the password is stretched with %s, which is deliberately slow "+
+			"and memory-hard so that guessing it is expensive, and it runs again for the integrity and cache keys. "+
+			"`slowmade bench storage --crypto-samples 5` measures it on this machine; expect several seconds on "+
+			"slow or memory-constrained hardware.", crypto.GetCurrentKDF())
+	} else if network := networkHint(timer.calls); network != "" {
+		hint += " " + network
+	}
+	hint += fmt.Sprintf(" Set ui.slow_hint to change when this hint appears (now %ds, 0 turns it off).", appConfig.GetUIConfig().SlowHint)
+
+	r.noticeMu.Lock()
+	r.notices = append(r.notices, r.template.Info(hint))
+	r.noticeMu.Unlock()
+}
+
+// networkHint 比较命令前后的端点统计，命令访问过外部端点时说明调用、重试和失败的情况
+func networkHint(before map[string]resilience.Stats) string {
+	var calls, retries, failures int64
+	var endpoints, failing []string
+	for _, s := range resilience.Snapshot() {
+		previous := before[s.Endpoint]
+		if s.Calls == previous.Calls && s.Rejected == previous.Rejected {
+			continue
+		}
+		endpoints = append(endpoints, s.Endpoint)
+		calls += s.Calls - previous.Calls
+		retries += s.Retries - previous.Retries
+		failures += s.Failures - previous.Failures
+		if s.Failures > previous.Failures {
+			failing = append(failing, s.Endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return ""
+	}
+	hint := fmt.Sprintf("It made %d network call(s) to %s", calls, strings.Join(endpoints, ", "))
+	if retries > 0 || failures > 0 {
+		hint += fmt.Sprintf(" with %d retries and %d failed attempts (%s)", retries, failures, strings.Join(failing, ", "))
+	}
+	return hint + "; net.stats shows the state of each endpoint. Configure a closer or faster node in [rpc], " +
+		"or lower rpc.timeout and network.retries to fail faster."
+}
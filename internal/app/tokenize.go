@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operators 命令行中的操作符，只有不加引号的独立词才是操作符
+var operators = map[string]bool{"&&": true, "|": true, ">": true, ">>": true}
+
+// token 输入中的一个词，operator 为不加引号的 &&、|、>、>>；
+// dollars 为可以展开会话变量的 $ 在 text 中的位置，单引号内和转义的 $ 不展开
+type token struct {
+	text     string
+	operator bool
+	dollars  []int
+}
+
+// tokenize 按空白拆分一行输入。单引号内的内容原样保留；双引号内的空白保留，反斜杠只转义 "、\ 和 $，
+// 其他字符前的反斜杠原样保留（与 shell 相同）；引号外的反斜杠转义下一个字符。
+// 加了引号或转义的词不会被当作操作符。引号未闭合时返回错误。
+// 变量在执行每条链式命令前展开，见 expandVariables
+func tokenize(input string) ([]token, error) {
+	var (
+		tokens  []token
+		current strings.Builder
+		inWord  bool // 当前词已开始，"" 也是一个词
+		quoted  bool // 当前词含有引号或转义
+		quote   rune // 当前所在的引号，0 表示不在引号内
+		escaped bool
+		dollars []int
+	)
+	flush := func() {
+		if inWord {
+			text := current.String()
+			tokens = append(tokens, token{text: text, operator: !quoted && operators[text], dollars: dollars})
+		}
+		current.Reset()
+		inWord, quoted, dollars = false, false, nil
+	}
+	for _, c := range input {
+		switch {
+		case escaped:
+			if quote == '"' && c != '"' && c != '\\' && c != '$' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\\' && quote != '\'':
+			escaped, inWord, quoted = true, true, true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				if c == '$' {
+					dollars = append(dollars, current.Len())
+				}
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord, quoted = c, true, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		default:
+			if c == '$' {
+				dollars = append(dollars, current.Len())
+			}
+			current.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	flush()
+	return tokens, nil
+}
+
+// splitChain 按 && 拆分为依次执行的命令，&& 两侧都必须有命令
+func splitChain(tokens []token) ([][]token, error) {
+	var (
+		commands [][]token
+		current  []token
+	)
+	for _, t := range tokens {
+		if t.operator && t.text == "&&" {
+			if len(current) == 0 {
+				return nil, fmt.Errorf("empty command before '&&'")
+			}
+			commands, current = append(commands, current), nil
+			continue
+		}
+		current = append(current, t)
+	}
+	if len(current) == 0 {
+		if len(commands) > 0 {
+			return nil, fmt.Errorf("empty command after '&&'")
+		}
+		return nil, nil
+	}
+	return append(commands, current), nil
+}
+
+// words 返回词的文本
+func words(tokens []token) []string {
+	result := make([]string, len(tokens))
+	for i, t := range tokens {
+		result[i] = t.text
+	}
+	return result
+}
+
+// redactLine 返回可以写入日志的一行输入，每条链式命令的密码和助记词参数都被遮盖
+func redactLine(input string) string {
+	tokens, err := tokenize(input)
+	if err != nil {
+		tokens = nil
+		for _, field := range strings.Fields(input) {
+			tokens = append(tokens, token{text: field})
+		}
+	}
+	commands, err := splitChain(tokens)
+	if err != nil || len(commands) == 0 {
+		if fields := strings.Fields(input); len(fields) > 0 {
+			return redactArguments(fields)
+		}
+		return ""
+	}
+	parts := make([]string, len(commands))
+	for i, command := range commands {
+		parts[i] = redactArguments(words(command))
+	}
+	return strings.Join(parts, " && ")
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	phrase12 := strings.Repeat("abandon ", 11) + "about"
+	phrase24 := strings.Repeat("abandon ", 23) + "art"
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty input", "", nil},
+		{"only whitespace", " \t\r\n ", nil},
+		{"plain words", "account.list  BTC\tETH", []string{"account.list", "BTC", "ETH"}},
+		{"double quoted 12-word phrase", `wallet.restore "` + phrase12 + `"`, []string{"wallet.restore", phrase12}},
+		{"single quoted 24-word phrase", `wallet.restore '` + phrase24 + `'`, []string{"wallet.restore", phrase24}},
+		{"phrase with escaped spaces", `wallet.restore abandon\ abandon\ about`, []string{"wallet.restore", "abandon abandon about"}},
+		{"quotes inside a word", `label.set acc my" "savings`, []string{"label.set", "acc", "my savings"}},
+		{"path with spaces", `backup.restore "/home/alice/My Backups/wallet.age"`, []string{"backup.restore", "/home/alice/My Backups/wallet.age"}},
+		{"path with escaped spaces", `backup.restore /home/alice/My\ Backups/wallet.age`, []string{"backup.restore", "/home/alice/My Backups/wallet.age"}},
+		{"windows path in double quotes", `backup.restore "C:\Users\Alice\My Backups\wallet.age"`, []string{"backup.restore", `C:\Users\Alice\My Backups\wallet.age`}},
+		{"windows path in single quotes", `backup.restore 'C:\Program Files\slowmade\wallet.age'`, []string{"backup.restore", `C:\Program Files\slowmade\wallet.age`}},
+		{"windows path with doubled backslashes", `backup.restore C:\\Users\\Alice\\wallet.age`, []string{"backup.restore", `C:\Users\Alice\wallet.age`}},
+		{"escapes inside double quotes", `say "a \"b\" \\ \$HOME \n"`, []string{"say", `a "b" \ $HOME \n`}},
+		{"backslash inside single quotes", `say 'a\'`, []string{"say", `a\`}},
+		{"empty double quoted word", `label.set acc ""`, []string{"label.set", "acc", ""}},
+		{"empty single quoted word", `label.set '' x`, []string{"label.set", "", "x"}},
+		{"operators", `account.list BTC && find x | grep y >> out.txt`, []string{"account.list", "BTC", "&&", "find", "x", "|", "grep", "y", ">>", "out.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize(%q) error: %v", tt.input, err)
+			}
+			var got []string
+			if len(tokens) > 0 {
+				got = words(tokens)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unterminated double quote", `wallet.restore "abandon abandon about`, `unterminated " quote`},
+		{"unterminated single quote", `wallet.restore 'abandon abandon about`, `unterminated ' quote`},
+		{"escaped closing quote", `say "abc\"`, `unterminated " quote`},
+		{"trailing backslash", `say abc\`, "trailing backslash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tokenize(tt.input)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("tokenize(%q) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator bool
+	}{
+		{"&&", true},
+		{"|", true},
+		{">", true},
+		{">>", true},
+		{`"&&"`, false},
+		{`'|'`, false},
+		{`\>`, false},
+		{"a&&b", false},
+	}
+	for _, tt := range tests {
+		tokens, err := tokenize(tt.input)
+		if err != nil || len(tokens) != 1 {
+			t.Fatalf("tokenize(%q) = %v, %v", tt.input, tokens, err)
+		}
+		if tokens[0].operator != tt.operator {
+			t.Errorf("tokenize(%q) operator = %v, want %v", tt.input, tokens[0].operator, tt.operator)
+		}
+	}
+}
+
+func TestTokenizeDollars(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{"$ACC", []int{0}},
+		{`"$ACC/$IDX"`, []int{0, 5}},
+		{"'$ACC'", nil},
+		{`\$ACC`, nil},
+		{`"\$ACC $IDX"`, []int{5}},
+	}
+	for _, tt := range tests {
+		tokens, err := tokenize(tt.input)
+		if err != nil || len(tokens) != 1 {
+			t.Fatalf("tokenize(%q) = %v, %v", tt.input, tokens, err)
+		}
+		if !reflect.DeepEqual(tokens[0].dollars, tt.want) {
+			t.Errorf("tokenize(%q) dollars = %v, want %v", tt.input, tokens[0].dollars, tt.want)
+		}
+	}
+}
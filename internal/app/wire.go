@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/approval"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/coinplugin"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/diagnostics"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/integrity"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/internal/web"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/palagend/slowmade/pkg/wallet"
+)
+
+// Container 组合根：按配置一次性构建存储和管理器，CLI 的每个命令都从这里取得依赖，
+// 不再各自创建存储或管理器
+type Container struct {
+	BaseDir    string
+	Storage    core.StorageHandler
+	WalletMgr  core.WalletManager
+	AccountMgr core.AccountManager
+	Integrity  *integrity.Guard // 未启用防篡改时为 nil
+	Cloaked    bool             // 启动时给出了 --cloak
+
+	bus *events.Bus // 进程内共享的事件总线，存储的变更也发布到这里
+}
+
+// Wire 根据已加载的配置构建依赖，cloak 为可选的附加口令
+func Wire(cloak string) (*Container, error) {
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	var guard *integrity.Guard
+	if appConfig.GetIntegrityConfig().Enabled {
+		guard = integrity.NewGuard(storageConfig.BaseDir)
+	}
+	opened, err := wallet.Open(wallet.Options{
+		Dir:             storageConfig.BaseDir,
+		ReadOnly:        storageConfig.ReadOnly,
+		Permissions:     storageConfig.Permissions,
+		CacheEntries:    storageConfig.CacheEntries,
+		Cloak:           cloak,
+		SharedPasswords: true,
+		OnWrite: func() error {
+			// 锁定期间或存在未确认的外部修改时不更新清单，留到下次解锁时报告
+			if guard == nil {
+				return nil
+			}
+			if err := guard.Seal(); err != nil && !errors.Is(err, integrity.ErrLocked) && !errors.Is(err, integrity.ErrUnverified) {
+				return err
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("初始化存储失败: %w", err)
+	}
+	// 注册内置币种插件，再加载用户运行时注册的币种
+	coinplugin.RegisterBuiltin()
+	if err := coin.LoadCustomCoins(filepath.Join(storageConfig.BaseDir, coin.CustomCoinsFileName)); err != nil {
+		logging.Warnf("Failed to load custom coins: %v", err)
+	}
+	engine := opened.Engine()
+	stor, walletMgr, accountMgr := engine.Storage, engine.WalletMgr, engine.AccountMgr
+	bus := newEventBus()
+	stor.OnChange(mirror.Publisher(bus))
+	retention := time.Duration(appConfig.GetTrashConfig().RetentionDays) * 24 * time.Hour
+	if purged, err := accountMgr.PurgeExpiredTrash(retention); err != nil {
+		logging.Warnf("Failed to purge expired trash: %v", err)
+	} else if purged > 0 {
+		logging.Infof("Purged %d trash entries older than %d days", purged, appConfig.GetTrashConfig().RetentionDays)
+	}
+	return &Container{
+		BaseDir:    storageConfig.BaseDir,
+		Storage:    stor,
+		WalletMgr:  walletMgr,
+		AccountMgr: accountMgr,
+		Integrity:  guard,
+		Cloaked:    cloak != "",
+		bus:        bus,
+	}, nil
+}
+
+// REPL 创建交互式环境
+func (c *Container) REPL() (*REPL, error) {
+	r, err := NewREPL(c.WalletMgr, c.AccountMgr)
+	if err != nil {
+		return nil, err
+	}
+	r.diagnostics = c.Diagnostics()
+	r.integrity = c.Integrity
+	r.cloaked = c.Cloaked
+	// 和组合根共用事件总线，存储变更和收款事件经同一组订阅者转发
+	r.bus = c.bus
+	r.bus.Subscribe(r.queueNotice)
+	r.bus.Subscribe(events.Only(searchEvents, r.markSearchStale))
+	return r, nil
+}
+
+// Diagnostics 返回生成诊断包所需的存储目录和健康检查
+func (c *Container) Diagnostics() diagnostics.Options {
+	opts := diagnostics.Options{BaseDir: c.BaseDir}
+	if checker, ok := c.Storage.(interface{ CheckStorageHealth() error }); ok {
+		opts.Health = checker.CheckStorageHealth
+	}
+	return opts
+}
+
+// WebServer 创建带钱包 API、鉴权密钥和审计日志的 Web 服务器，用户的命名空间按需打开；
+// 审批队列加载失败时不提供审批接口
+func (c *Container) WebServer() *web.Server {
+	server := web.NewServer().
+		Wallet(c.WalletMgr, c.AccountMgr).
+		DataDir(c.BaseDir).
+		Keys(c.KeyStore()).
+		Users(c.UserStore(), c.Tenant).
+		Shares(c.ShareStore()).
+		Audit(c.Audit())
+
+	appConfig := config.GetAppConfig()
+	approvalConfig := appConfig.GetApprovalConfig()
+	store, err := approval.Load(filepath.Join(c.BaseDir, approval.FileName))
+	if err != nil {
+		logging.Warnf("Failed to load approval queue: %v", err)
+		return server
+	}
+	chainID := big.NewInt(approvalConfig.ChainID)
+	return server.Approvals(store, c.Signer(signer.PreApproved{}, chainID), approvalConfig.RequiredApprovals,
+		time.Duration(approvalConfig.ExpiryMinutes)*time.Minute, chainID)
+}
+
+// Tenant 打开用户命名空间下的存储和管理器，和默认命名空间及其他用户不共享任何文件
+func (c *Container) Tenant(user string) (*web.Tenant, error) {
+	dir := web.UserDir(c.BaseDir, user)
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	// 每个用户的解锁密码保存在自己的密码管理器中，不经过全局的密码管理器
+	opened, err := wallet.Open(wallet.Options{
+		Dir:          dir,
+		Permissions:  storageConfig.Permissions,
+		CacheEntries: storageConfig.CacheEntries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("初始化用户 %s 的存储失败: %w", user, err)
+	}
+	engine := opened.Engine()
+	return &web.Tenant{WalletMgr: engine.WalletMgr, AccountMgr: engine.AccountMgr, Passwords: engine.Passwords, DataDir: dir}, nil
+}
+
+// Signer 创建签名器，预览解码器由调用方设置
+func (c *Container) Signer(approver signer.Approver, chainID *big.Int) *signer.Signer {
+	s := signer.NewSigner(c.AccountMgr, approver, chainID).
+		Audit(c.Audit()).
+		Policies(filepath.Join(c.BaseDir, policy.DirName))
+	// 审批队列中的请求已由其他人批准，不再按签名历史要求确认
+	if _, ok := approver.(signer.PreApproved); !ok {
+		path, thresholds := keyUsagePath(c.BaseDir)
+		s.Usage(path, thresholds, c.bus)
+	}
+	return s
+}
+
+// Watcher 创建收款监控
+func (c *Container) Watcher(bus *events.Bus) *watch.Watcher {
+	return watch.NewWatcher(c.AccountMgr, c.BaseDir, bus)
+}
+
+// EventBus 返回按配置转发到 webhook 和通知的事件总线
+func (c *Container) EventBus() *events.Bus {
+	return c.bus
+}
+
+// KeyStore 返回 API 密钥存储
+func (c *Container) KeyStore() *web.KeyStore {
+	return web.NewKeyStore(c.BaseDir)
+}
+
+// ShareStore 返回只读分享链接的存储
+func (c *Container) ShareStore() *web.ShareStore {
+	return web.NewShareStore(c.BaseDir)
+}
+
+// UserStore 返回 serve 模式的用户存储
+func (c *Container) UserStore() *web.UserStore {
+	return web.NewUserStore(c.BaseDir)
+}
+
+// Audit 返回审计日志
+func (c *Container) Audit() *audit.Logger {
+	return audit.ForDir(c.BaseDir)
+}
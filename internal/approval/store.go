@@ -0,0 +1,255 @@
+// Package approval 团队使用时的签名审批队列（双人规则）：请求者提交的交易先进入待审批状态，
+// 获得足够多的其他审批者批准后才会签名，超过有效期未完成的请求自动过期
+package approval
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// FileName 审批队列在数据目录中的文件名
+const FileName = "approvals.json"
+
+// 请求状态
+const (
+	StatusPending  = "pending"  // 等待审批
+	StatusApproved = "approved" // 批准数已够，等待签名
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+	StatusSigned   = "signed"
+	StatusFailed   = "failed" // 批准后签名失败，如钱包已锁定
+)
+
+// 错误定义
+var (
+	ErrRequestNotFound = errors.New("approval request not found")
+	ErrNotPending      = errors.New("approval request is not pending")
+	ErrSelfApproval    = errors.New("requesters cannot approve their own request")
+	ErrAlreadyApproved = errors.New("already approved by this user")
+)
+
+// Approval 一次批准
+type Approval struct {
+	User string    `json:"user"`
+	At   time.Time `json:"at"`
+}
+
+// Request 一条待签名的交易请求
+type Request struct {
+	ID        string                  `json:"id"`
+	Requester string                  `json:"requester"`
+	Note      string                  `json:"note,omitempty"`
+	Tx        *signer.TransactionArgs `json:"tx"`
+	Required  int                     `json:"required"` // 需要的批准数，不含请求者本人
+	Approvals []Approval              `json:"approvals"`
+	Status    string                  `json:"status"`
+	CreatedAt time.Time               `json:"created_at"`
+	ExpiresAt time.Time               `json:"expires_at"`
+	DecidedBy string                  `json:"decided_by,omitempty"` // 拒绝者
+	Reason    string                  `json:"reason,omitempty"`
+	RawTx     string                  `json:"raw_tx,omitempty"` // 签名后的原始交易（0x 十六进制）
+	Error     string                  `json:"error,omitempty"`
+	SignedAt  time.Time               `json:"signed_at,omitempty"`
+}
+
+// Store 审批队列存储
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	Requests []*Request `json:"requests"`
+}
+
+// Load 加载审批队列，文件不存在时返回空队列
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解码审批队列失败: %w", err)
+	}
+	return s, nil
+}
+
+// Create 提交新的待审批请求，ttl 后仍未完成审批时过期
+func (s *Store) Create(requester string, tx *signer.TransactionArgs, note string, required int, ttl time.Duration) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	req := &Request{
+		ID:        hex.EncodeToString(id),
+		Requester: requester,
+		Note:      note,
+		Tx:        tx,
+		Required:  max(required, 1),
+		Approvals: []Approval{},
+		Status:    StatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	s.Requests = append(s.Requests, req)
+	if err := s.save(); err != nil {
+		s.Requests = s.Requests[:len(s.Requests)-1]
+		return nil, err
+	}
+	return req, nil
+}
+
+// Get 返回指定请求
+func (s *Store) Get(id string) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(id)
+}
+
+// List 返回请求，按创建时间从新到旧排序；status 为空时返回全部
+func (s *Store) List(status string) []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*Request
+	for _, req := range s.Requests {
+		if status == "" || req.Status == status {
+			result = append(result, req)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Approve 记录一次批准，请求者本人和重复批准不计入；批准数达到要求时状态变为 approved
+func (s *Store) Approve(id, user string) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == req.Requester {
+		return nil, ErrSelfApproval
+	}
+	for _, approval := range req.Approvals {
+		if approval.User == user {
+			return nil, ErrAlreadyApproved
+		}
+	}
+	req.Approvals = append(req.Approvals, Approval{User: user, At: time.Now().UTC()})
+	if len(req.Approvals) >= req.Required {
+		req.Status = StatusApproved
+	}
+	return req, s.save()
+}
+
+// Reject 拒绝待审批的请求
+func (s *Store) Reject(id, user, reason string) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	req.Status, req.DecidedBy, req.Reason = StatusRejected, user, reason
+	return req, s.save()
+}
+
+// Finish 记录批准后的签名结果
+func (s *Store) Finish(id string, rawTx string, signErr error) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != StatusApproved {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotPending, id, req.Status)
+	}
+	if signErr != nil {
+		req.Status, req.Error = StatusFailed, signErr.Error()
+	} else {
+		req.Status, req.RawTx, req.SignedAt = StatusSigned, rawTx, time.Now().UTC()
+	}
+	return req, s.save()
+}
+
+// Expire 将超过有效期仍未签名的待审批请求标记为过期，返回这些请求
+func (s *Store) Expire(now time.Time) ([]*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*Request
+	for _, req := range s.Requests {
+		if req.Status == StatusPending && now.After(req.ExpiresAt) {
+			req.Status = StatusExpired
+			expired = append(expired, req)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	return expired, s.save()
+}
+
+func (s *Store) find(id string) (*Request, error) {
+	for _, req := range s.Requests {
+		if req.ID == id {
+			return req, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrRequestNotFound, id)
+}
+
+// pending 返回仍在等待审批且未过期的请求
+func (s *Store) pending(id string) (*Request, error) {
+	req, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status == StatusPending && time.Now().After(req.ExpiresAt) {
+		req.Status = StatusExpired
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	if req.Status != StatusPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotPending, id, req.Status)
+	}
+	return req, nil
+}
+
+func (s *Store) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入审批队列失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名审批队列失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// internal/audit/audit.go
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// Entry 审计日志条目，绝不记录私钥、助记词或明文密码
+// 每条记录包含上一条记录的哈希，形成哈希链，便于发现篡改
+type Entry struct {
+	Time   string `json:"time"`
+	Actor  string `json:"actor"`  // 操作者，如 repl、apikey:<id>
+	Action string `json:"action"` // 操作类型，如 wallet.unlock
+	Target string `json:"target,omitempty"`
+	Result string `json:"result"` // ok / denied / error 信息
+	// Data 附加的机器可读记录（如密钥仪式记录），非空时计入哈希
+	Data json.RawMessage `json:"data,omitempty"`
+	Prev string          `json:"prev"`
+	Hash string          `json:"hash"`
+}
+
+// Logger 追加写入的审计日志
+type Logger struct {
+	mu       sync.Mutex
+	path     string
+	lastHash string
+	loaded   bool
+}
+
+// NewLogger 创建审计日志记录器
+func NewLogger(path string) *Logger {
+	return &Logger{path: path}
+}
+
+// FileName 审计日志在存储目录中的文件名
+const FileName = "audit.log"
+
+// maxEntrySize 一条审计记录的最大长度
+const maxEntrySize = 1 << 20
+
+// ForDir 返回存储目录下的审计日志记录器
+func ForDir(baseDir string) *Logger {
+	return NewLogger(filepath.Join(baseDir, FileName))
+}
+
+// Record 追加一条审计记录
+func (l *Logger) Record(actor, action, target, result string) error {
+	_, err := l.RecordData(actor, action, target, result, nil)
+	return err
+}
+
+// RecordData 追加一条带 JSON 附加数据的审计记录，返回条目哈希
+func (l *Logger) RecordData(actor, action, target, result string, data []byte) (string, error) {
+	// 写入前转为规范编码，与整行的 canonjson 编码一致，读回后哈希不变
+	var canonical []byte
+	if len(data) > 0 {
+		var err error
+		if canonical, err = canonjson.Marshal(json.RawMessage(data)); err != nil {
+			return "", fmt.Errorf("审计附加数据不是有效的 JSON: %w", err)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded {
+		last, err := lastHash(l.path)
+		if err != nil {
+			return "", err
+		}
+		l.lastHash = last
+		l.loaded = true
+	}
+
+	entry := Entry{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Result: result,
+		Prev:   l.lastHash,
+	}
+	if len(canonical) > 0 {
+		entry.Data = canonical
+	}
+	entry.Hash = entry.computeHash()
+
+	line, err := canonjson.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return "", fmt.Errorf("写入审计日志失败: %w", err)
+	}
+
+	l.lastHash = entry.Hash
+	return entry.Hash, nil
+}
+
+// computeHash 计算条目哈希（不含 Hash 字段本身），没有 Data 的条目与旧格式相同
+func (e Entry) computeHash() string {
+	content := e.Prev + "|" + e.Time + "|" + e.Actor + "|" + e.Action + "|" + e.Target + "|" + e.Result
+	if len(e.Data) > 0 {
+		content += "|" + string(e.Data)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadAll 读取全部审计记录
+func ReadAll(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	// 带附加数据的条目可能超过默认的 64KB 行长度
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntrySize)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("解码审计日志失败: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Verify 校验哈希链，返回第一条被篡改记录的序号（从 1 开始），0 表示完整
+func Verify(entries []Entry) int {
+	prev := ""
+	for i, e := range entries {
+		if e.Prev != prev || e.computeHash() != e.Hash {
+			return i + 1
+		}
+		prev = e.Hash
+	}
+	return 0
+}
+
+func lastHash(path string) (string, error) {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	return entries[len(entries)-1].Hash, nil
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordDataChainSurvivesReadBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	logger := NewLogger(path)
+
+	// 旧版本用 json.Marshal 写出的记录：字段按结构体顺序，附加数据中的 < 被转义
+	legacy := Entry{Time: "2024-01-01T00:00:00Z", Actor: "repl", Action: "ceremony", Result: "ok", Data: json.RawMessage(`{"z":1,"a":"\u003cx\u003e"}`)}
+	legacy.Hash = legacy.computeHash()
+	line, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(line, '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := logger.Record("repl", "wallet.unlock", "", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := logger.RecordData("apikey:k1", "ceremony", "m/84'/0'/0'", "ok", []byte(`{ "z": 1.50, "a": "<x>", "n": [3, 1e2] }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logger.RecordData("repl", "ceremony", "", "ok", []byte(`{"a":`)); err == nil {
+		t.Error("RecordData accepted invalid JSON")
+	}
+
+	entries, err := ReadAll(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("ReadAll returned %d entries, want 3", len(entries))
+	}
+	if broken := Verify(entries); broken != 0 {
+		t.Fatalf("Verify = %d, want an intact chain", broken)
+	}
+	last := entries[2]
+	if last.Hash != hash || string(last.Data) != `{"a":"<x>","n":[3,100],"z":1.5}` {
+		t.Errorf("last entry = %s %s, want hash %s and canonical data", last.Hash, last.Data, hash)
+	}
+
+	// 附加数据被改动时链断开
+	last.Data = json.RawMessage(`{"a":"<y>","n":[3,100],"z":1.5}`)
+	entries[2] = last
+	if broken := Verify(entries); broken != 3 {
+		t.Errorf("Verify after editing data = %d, want 3", broken)
+	}
+}
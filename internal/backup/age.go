@@ -0,0 +1,317 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/bech32"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// age v1 文件格式（https://age-encryption.org/v1）的 X25519 接收方加密和解密，
+// 生成的文件可以用 age -d -i key.txt 解密，只支持 X25519 接收方
+
+const (
+	ageIntro        = "age-encryption.org/v1\n"
+	ageRecipientHRP = "age"
+	ageIdentityHRP  = "age-secret-key-"
+	ageX25519Label  = "age-encryption.org/v1/X25519"
+	ageChunkSize    = 64 * 1024
+	ageFileKeySize  = 16
+	ageColumns      = 64 // 头部 stanza 正文每行的 base64 字符数
+)
+
+var (
+	ErrAgeNoIdentity = errors.New("no identity matches any recipient of the age file")
+	ErrAgeMalformed  = errors.New("malformed age file")
+)
+
+var b64 = base64.RawStdEncoding
+
+// ageRecipient age 的 X25519 公钥（age1...）
+type ageRecipient struct {
+	publicKey []byte
+	text      string
+}
+
+// parseAgeRecipient 解析 age1 开头的接收方
+func parseAgeRecipient(s string) (*ageRecipient, error) {
+	hrp, key, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient %q: %v", s, err)
+	}
+	if hrp != ageRecipientHRP || len(key) != curve25519.PointSize {
+		return nil, fmt.Errorf("invalid age recipient %q", s)
+	}
+	return &ageRecipient{publicKey: key, text: s}, nil
+}
+
+// ParseAgeIdentity 解析 age-keygen 生成的私钥（AGE-SECRET-KEY-1...），
+// 输入可以是整个密钥文件，# 开头的注释行被忽略
+func ParseAgeIdentity(text string) ([]byte, error) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hrp, key, err := bech32.Decode(line)
+		if err != nil || hrp != ageIdentityHRP || len(key) != curve25519.ScalarSize {
+			return nil, errors.New("invalid age identity, expected AGE-SECRET-KEY-1...")
+		}
+		return key, nil
+	}
+	return nil, errors.New("no age identity found")
+}
+
+// ageEncrypt 为每个接收方包装随机文件密钥，再以 STREAM 方式分块加密明文
+func ageEncrypt(plaintext []byte, recipients []*ageRecipient) ([]byte, error) {
+	fileKey := make([]byte, ageFileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	var header bytes.Buffer
+	header.WriteString(ageIntro)
+	for _, recipient := range recipients {
+		share, body, err := wrapX25519(fileKey, recipient.publicKey)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&header, "-> X25519 %s\n", b64.EncodeToString(share))
+		writeStanzaBody(&header, body)
+	}
+	header.WriteString("---")
+	mac, err := ageHeaderMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&header, " %s\n", b64.EncodeToString(mac))
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	payload, err := ageStream(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(header.Bytes(), nonce...)
+	for offset := 0; ; offset += ageChunkSize {
+		end := min(offset+ageChunkSize, len(plaintext))
+		last := end == len(plaintext)
+		out = payload.Seal(out, ageChunkNonce(uint64(offset/ageChunkSize), last), plaintext[offset:end], nil)
+		if last {
+			break
+		}
+	}
+	return out, nil
+}
+
+// ageDecrypt 用 X25519 私钥解开文件密钥，校验头部 MAC 后解密全部分块
+func ageDecrypt(data []byte, identity []byte) ([]byte, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	intro, err := reader.ReadString('\n')
+	if err != nil || intro != ageIntro {
+		return nil, ErrAgeMalformed
+	}
+	header := bytes.NewBufferString(intro)
+
+	var fileKey []byte
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, ErrAgeMalformed
+		}
+		if strings.HasPrefix(line, "--- ") {
+			header.WriteString("---")
+			mac, err := b64.DecodeString(strings.TrimSuffix(line[4:], "\n"))
+			if err != nil {
+				return nil, ErrAgeMalformed
+			}
+			if fileKey == nil {
+				return nil, ErrAgeNoIdentity
+			}
+			expected, err := ageHeaderMAC(fileKey, header.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			if !hmac.Equal(mac, expected) {
+				return nil, fmt.Errorf("%w: header MAC mismatch", ErrAgeMalformed)
+			}
+			break
+		}
+		header.WriteString(line)
+		args := strings.Fields(strings.TrimPrefix(line, "-> "))
+		if !strings.HasPrefix(line, "-> ") || len(args) == 0 {
+			return nil, ErrAgeMalformed
+		}
+		body, err := readStanzaBody(reader, header)
+		if err != nil {
+			return nil, err
+		}
+		if args[0] != "X25519" || len(args) != 2 || fileKey != nil {
+			continue
+		}
+		share, err := b64.DecodeString(args[1])
+		if err != nil {
+			return nil, ErrAgeMalformed
+		}
+		// 不是发给这个私钥的 stanza 解包失败，继续尝试下一个
+		if key, err := unwrapX25519(share, body, identity); err == nil {
+			fileKey = key
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(reader, nonce); err != nil {
+		return nil, ErrAgeMalformed
+	}
+	payload, err := ageStream(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var plaintext []byte
+	sealedChunk := ageChunkSize + payload.Overhead()
+	for counter := uint64(0); ; counter++ {
+		end := min(sealedChunk, len(ciphertext))
+		last := end == len(ciphertext)
+		plaintext, err = payload.Open(plaintext, ageChunkNonce(counter, last), ciphertext[:end], nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload authentication failed", ErrAgeMalformed)
+		}
+		if last {
+			return plaintext, nil
+		}
+		ciphertext = ciphertext[end:]
+	}
+}
+
+// wrapX25519 生成临时密钥对，用与接收方的共享密钥加密文件密钥
+func wrapX25519(fileKey, publicKey []byte) (share, body []byte, err error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, nil, err
+	}
+	share, err = curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := x25519WrapKey(shared, share, publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return share, aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil), nil
+}
+
+func unwrapX25519(share, body, identity []byte) ([]byte, error) {
+	publicKey, err := curve25519.X25519(identity, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(identity, share)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := x25519WrapKey(shared, share, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+}
+
+func x25519WrapKey(shared, share, publicKey []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, share...), publicKey...)
+	key, err := hkdfKey(shared, salt, ageX25519Label)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+func ageHeaderMAC(fileKey, header []byte) ([]byte, error) {
+	key, err := hkdfKey(fileKey, nil, "header")
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+func ageStream(fileKey, nonce []byte) (cipher.AEAD, error) {
+	key, err := hkdfKey(fileKey, nonce, "payload")
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// ageChunkNonce 11 字节大端计数器加 1 字节结束标记
+func ageChunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func hkdfKey(secret, salt []byte, info string) ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeStanzaBody 正文按 64 列换行，最后一行必须短于 64 列（长度恰好整除时追加空行）
+func writeStanzaBody(w *bytes.Buffer, body []byte) {
+	encoded := b64.EncodeToString(body)
+	for len(encoded) >= ageColumns {
+		w.WriteString(encoded[:ageColumns] + "\n")
+		encoded = encoded[ageColumns:]
+	}
+	w.WriteString(encoded + "\n")
+}
+
+func readStanzaBody(reader *bufio.Reader, header *bytes.Buffer) ([]byte, error) {
+	var encoded strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, ErrAgeMalformed
+		}
+		header.WriteString(line)
+		line = strings.TrimSuffix(line, "\n")
+		encoded.WriteString(line)
+		if len(line) < ageColumns {
+			break
+		}
+	}
+	body, err := b64.DecodeString(encoded.String())
+	if err != nil {
+		return nil, ErrAgeMalformed
+	}
+	return body, nil
+}
@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/palagend/slowmade/pkg/bech32"
+	"golang.org/x/crypto/curve25519"
+)
+
+// C2SP age 测试套件使用的身份，私钥为 32 个 0x42
+const (
+	testkitIdentity  = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	testkitRecipient = "age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj"
+)
+
+// ageVector 按 age v1 规范用标准库独立构造的文件：发给 testkitRecipient，临时私钥为 32 个 0x07，
+// 文件密钥为 YELLOW SUBMARINE，payload nonce 为 16 个 0x10，明文 slowmade age known answer\n
+var ageVector = append([]byte("age-encryption.org/v1\n"+
+	"-> X25519 E75P6uryBMf9M1j8nAByGIHRdCeBKCJ+xnTzf3/pe20\n"+
+	"jAW2TT6DlvvK3queYqSX8i91cQ9ctKAyKhzAXp8v3YY\n"+
+	"--- BN0UMG0v77c7nOxJ2jQpym2BLNND+pGyTRYXiioMlD8\n"),
+	mustHex("1010101010101010101010101010101023d16165c197c78b97a99cb34eac8912d41f63e24f1c1d8c4f1911c64235874acf54af7a63e3a9fbea28")...)
+
+func mustHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// newAgeKey 生成随机 X25519 身份和对应的接收方
+func newAgeKey(t *testing.T) ([]byte, *ageRecipient) {
+	t.Helper()
+	identity := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(identity); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := curve25519.X25519(identity, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := bech32.Encode(ageRecipientHRP, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := parseAgeRecipient(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity, recipient
+}
+
+func TestAgeDecryptKnownAnswer(t *testing.T) {
+	identity, err := ParseAgeIdentity("# created: test\n# public key: " + testkitRecipient + "\n" + testkitIdentity + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := parseAgeRecipient(testkitRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicKey, _ := curve25519.X25519(identity, curve25519.Basepoint); !bytes.Equal(publicKey, recipient.publicKey) {
+		t.Fatalf("identity public key %x does not match %s", publicKey, testkitRecipient)
+	}
+	plaintext, err := ageDecrypt(ageVector, identity)
+	if err != nil || string(plaintext) != "slowmade age known answer\n" {
+		t.Errorf("ageDecrypt = %q, %v, want the known plaintext", plaintext, err)
+	}
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	alice, aliceRecipient := newAgeKey(t)
+	bob, bobRecipient := newAgeKey(t)
+	stranger, _ := newAgeKey(t)
+	// 空明文、恰好一个分块、跨分块边界
+	for _, size := range []int{0, 1, ageChunkSize, ageChunkSize + 1, 3*ageChunkSize - 7} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+		file, err := ageEncrypt(plaintext, []*ageRecipient{aliceRecipient, bobRecipient})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, identity := range [][]byte{alice, bob} {
+			got, err := ageDecrypt(file, identity)
+			if err != nil || !bytes.Equal(got, plaintext) {
+				t.Errorf("%d bytes: ageDecrypt = %d bytes, %v, want the plaintext", size, len(got), err)
+			}
+		}
+		if _, err := ageDecrypt(file, stranger); !errors.Is(err, ErrAgeNoIdentity) {
+			t.Errorf("%d bytes: ageDecrypt with another identity error = %v, want ErrAgeNoIdentity", size, err)
+		}
+	}
+}
+
+func TestAgeRejectsTampering(t *testing.T) {
+	identity, recipient := newAgeKey(t)
+	plaintext := make([]byte, ageChunkSize+100)
+	file, err := ageEncrypt(plaintext, []*ageRecipient{recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	headerEnd := bytes.Index(file, []byte("\n--- ")) + 1
+	macLine := headerEnd + bytes.IndexByte(file[headerEnd:], '\n') + 1
+
+	flips := map[string]int{
+		"stanza":  len(ageIntro) + len("-> X25519 ") + 2,
+		"mac":     headerEnd + len("--- ") + 1,
+		"nonce":   macLine + 3,
+		"payload": macLine + 16 + 10,
+		"tag":     len(file) - 1,
+	}
+	for name, offset := range flips {
+		tampered := append([]byte(nil), file...)
+		tampered[offset] ^= 0x01
+		if _, err := ageDecrypt(tampered, identity); err == nil {
+			t.Errorf("ageDecrypt with tampered %s succeeded", name)
+		}
+	}
+
+	// 截掉最后一个分块后，前一个分块没有结束标记
+	sealedChunk := ageChunkSize + 16
+	truncations := map[string]int{
+		"header":      headerEnd,
+		"nonce":       macLine + 8,
+		"final chunk": macLine + 16 + sealedChunk,
+		"tag":         len(file) - 1,
+	}
+	for name, size := range truncations {
+		if _, err := ageDecrypt(file[:size], identity); !errors.Is(err, ErrAgeMalformed) {
+			t.Errorf("ageDecrypt truncated in %s error = %v, want ErrAgeMalformed", name, err)
+		}
+	}
+}
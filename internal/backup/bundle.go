@@ -0,0 +1,256 @@
+// Package backup 将存储目录打包为加密的备份包，并从备份包恢复
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+const bundleVersion = 1
+
+// backupDirs 备份的存储子目录，与云同步的范围一致
+var backupDirs = []string{"wallets", "accounts", "addresses"}
+
+// 错误定义
+var (
+	ErrEmptyBundle        = errors.New("no wallet files to back up")
+	ErrUnsupportedVersion = errors.New("unsupported backup bundle version")
+	ErrInvalidPath        = errors.New("invalid file path in backup bundle")
+)
+
+// Bundle 备份包，Files 以 "子目录/文件名" 为键
+type Bundle struct {
+	Version   int               `json:"version"`
+	CreatedAt int64             `json:"created_at"`
+	Files     map[string][]byte `json:"files"`
+}
+
+// Collect 读取存储目录中的钱包、账户和地址文件
+func Collect(baseDir string) (*Bundle, error) {
+	b := &Bundle{Version: bundleVersion, CreatedAt: time.Now().Unix(), Files: make(map[string][]byte)}
+	for _, dir := range backupDirs {
+		entries, err := os.ReadDir(filepath.Join(baseDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(baseDir, dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			b.Files[dir+"/"+entry.Name()] = data
+		}
+	}
+	if len(b.Files) == 0 {
+		return nil, ErrEmptyBundle
+	}
+	return b, nil
+}
+
+// Seal 压缩后用密码整体加密，返回二进制密文
+func (b *Bundle) Seal(password string) ([]byte, error) {
+	compressed, err := b.compress()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptData(compressed, password)
+	if err != nil {
+		return nil, fmt.Errorf("加密备份包失败: %w", err)
+	}
+	return hex.DecodeString(encrypted)
+}
+
+// Open 解密并解压备份包
+func Open(sealed []byte, password string) (*Bundle, error) {
+	compressed, err := crypto.DecryptData(hex.EncodeToString(sealed), password)
+	if err != nil {
+		return nil, fmt.Errorf("解密备份包失败: %w", err)
+	}
+	return decompress(compressed)
+}
+
+// compress 规范编码后 gzip 压缩
+func (b *Bundle) compress() ([]byte, error) {
+	data, err := canonjson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// decompress 解压并校验备份包
+func decompress(compressed []byte) (*Bundle, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("解压备份包失败: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("解压备份包失败: %w", err)
+	}
+	return decode(data)
+}
+
+// decode 解码备份包并校验版本和文件路径
+func decode(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("解码备份包失败: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Version)
+	}
+	for name := range b.Files {
+		if !validPath(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, name)
+		}
+	}
+	return &b, nil
+}
+
+// Names 返回排序后的文件列表
+func (b *Bundle) Names() []string {
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Restore 将备份包中的文件写回存储目录，覆盖同名文件
+func (b *Bundle) Restore(baseDir string) error {
+	for _, name := range b.Names() {
+		if !validPath(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidPath, name)
+		}
+		target := filepath.Join(baseDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		tempFile := target + ".tmp"
+		if err := os.WriteFile(tempFile, b.Files[name], 0600); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", name, err)
+		}
+		if err := os.Rename(tempFile, target); err != nil {
+			return fmt.Errorf("重命名 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile 把备份包中的钱包、账户和地址文件与存储目录中已有的文件逐条合并：新记录直接恢复，
+// 内容不同的记录交给 resolve 决定是否覆盖，只在本机存在的记录保留。返回被覆盖的记录数
+func (b *Bundle) Reconcile(baseDir string, resolve core.ConflictResolver) (int, error) {
+	overwritten := 0
+	for _, name := range b.Names() {
+		if !validPath(name) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidPath, name)
+		}
+		local, err := os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		var n int
+		switch path.Dir(name) {
+		case "wallets":
+			n, err = b.reconcileWallet(name, local, resolve)
+		case "accounts":
+			n, err = reconcileFile(b, name, local, resolve, core.MergeAccounts)
+		case "addresses":
+			n, err = reconcileFile(b, name, local, resolve, core.MergeAddresses)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		overwritten += n
+	}
+	return overwritten, nil
+}
+
+// reconcileWallet 根钱包不可合并，保留本机的根钱包时不再写回该文件
+func (b *Bundle) reconcileWallet(name string, local []byte, resolve core.ConflictResolver) (int, error) {
+	var current, incoming core.HDRootWallet
+	if err := json.Unmarshal(local, &current); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(b.Files[name], &incoming); err != nil {
+		return 0, err
+	}
+	changes := core.DiffRecords(&current, &incoming)
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	overwrite, err := resolve(&core.RecordConflict{Kind: core.RecordWallet, Key: "root", Changes: changes})
+	if err != nil {
+		return 0, err
+	}
+	if !overwrite {
+		delete(b.Files, name)
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// reconcileFile 合并记录列表文件，用合并结果替换备份包中的文件内容
+func reconcileFile[T any](b *Bundle, name string, local []byte, resolve core.ConflictResolver,
+	merge func(current, incoming []T, resolve core.ConflictResolver) ([]T, int, error)) (int, error) {
+	var current, incoming []T
+	if err := json.Unmarshal(local, &current); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(b.Files[name], &incoming); err != nil {
+		return 0, err
+	}
+	merged, overwritten, err := merge(current, incoming, resolve)
+	if err != nil {
+		return 0, err
+	}
+	data, err := canonjson.MarshalIndent(merged, "  ")
+	if err != nil {
+		return 0, err
+	}
+	b.Files[name] = data
+	return overwritten, nil
+}
+
+// validPath 只允许备份目录下的一级 .json 文件，防止路径穿越
+func validPath(name string) bool {
+	dir, file := path.Split(name)
+	return slices.Contains(backupDirs, strings.TrimSuffix(dir, "/")) &&
+		file != "" && !strings.HasPrefix(file, ".") && !strings.ContainsAny(file, `/\`) &&
+		strings.HasSuffix(file, ".json")
+}
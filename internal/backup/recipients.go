@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 错误定义
+var (
+	ErrNoRecipients         = errors.New("no backup recipients")
+	ErrUnsupportedRecipient = errors.New("unsupported backup recipient, expected an age public key (age1...); OpenPGP keys are no longer supported, create an age key with age-keygen")
+	ErrPGPBundle            = errors.New("OpenPGP-encrypted backup, decrypt it first with gpg -d")
+)
+
+// Recipients 备份包的公钥接收方，全部是 age X25519 公钥。
+// 加密不需要钱包密码，可以无人值守地创建备份
+type Recipients struct {
+	age []*ageRecipient
+}
+
+// ParseRecipients 解析 age1 开头的接收方列表。
+// 只支持 age：旧版本接受的 OpenPGP 公钥文件返回 ErrUnsupportedRecipient，之前写出的 OpenPGP 备份仍可用 gpg -d 解密后恢复
+func ParseRecipients(specs []string) (*Recipients, error) {
+	recipients := &Recipients{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		if !strings.HasPrefix(spec, ageRecipientHRP+"1") {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRecipient, spec)
+		}
+		recipient, err := parseAgeRecipient(spec)
+		if err != nil {
+			return nil, err
+		}
+		recipients.age = append(recipients.age, recipient)
+	}
+	if len(recipients.age) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return recipients, nil
+}
+
+// Extension 加密后备份文件的扩展名
+func (rs *Recipients) Extension() string {
+	return ".age"
+}
+
+// Count 接收方数量
+func (rs *Recipients) Count() int {
+	return len(rs.age)
+}
+
+// Each 把接收方拆成每个一组，分别加密不同内容时使用
+func (rs *Recipients) Each() []*Recipients {
+	each := make([]*Recipients, 0, rs.Count())
+	for _, recipient := range rs.age {
+		each = append(each, &Recipients{age: []*ageRecipient{recipient}})
+	}
+	return each
+}
+
+// Name 第一个接收方的显示名称，即 age 公钥原文
+func (rs *Recipients) Name() string {
+	if len(rs.age) > 0 {
+		return rs.age[0].text
+	}
+	return ""
+}
+
+// SealTo 压缩后加密给全部接收方，生成 age v1 文件（age -d -i key.txt 解密），
+// 解密结果是 gzip 压缩的备份包
+func (b *Bundle) SealTo(recipients *Recipients) ([]byte, error) {
+	compressed, err := b.compress()
+	if err != nil {
+		return nil, err
+	}
+	return recipients.Encrypt(compressed)
+}
+
+// Encrypt 把任意内容加密给全部接收方，格式与 SealTo 相同
+func (rs *Recipients) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(rs.age) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return ageEncrypt(plaintext, rs.age)
+}
+
+// OpenFile 打开公钥加密的备份文件：age 文件用 identity（age-keygen 生成的私钥文件内容）解密，
+// 已经用 age 或 gpg 在外部解密过的 gzip 备份包直接读取；旧版本写出的 OpenPGP 消息返回 ErrPGPBundle
+func OpenFile(data []byte, identity string) (*Bundle, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte(ageIntro)):
+		if identity == "" {
+			return nil, errors.New("age-encrypted backup, an identity file is required")
+		}
+		key, err := ParseAgeIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := ageDecrypt(data, key)
+		if err != nil {
+			return nil, fmt.Errorf("解密备份包失败: %w", err)
+		}
+		return decompress(compressed)
+	case bytes.HasPrefix(data, []byte("-----BEGIN PGP MESSAGE")):
+		return nil, ErrPGPBundle
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return decompress(data)
+	}
+	return nil, errors.New("unrecognized backup file: expected an age file or a decrypted backup bundle")
+}
+
+// WriteFile 收集 baseDir 中的钱包文件，加密给全部接收方后写入 dir（权限 0600），
+// 并记录这次备份；返回备份文件路径和包含的文件数
+func WriteFile(baseDir, dir string, recipients *Recipients, method string) (string, int, error) {
+	bundle, err := Collect(baseDir)
+	if err != nil {
+		return "", 0, err
+	}
+	sealed, err := bundle.SealTo(recipients)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", 0, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	name := "slowmade-backup-" + time.Now().UTC().Format("20060102T150405Z") + recipients.Extension()
+	path := filepath.Join(dir, name)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, sealed, 0600); err != nil {
+		return "", 0, fmt.Errorf("写入备份文件失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return "", 0, fmt.Errorf("重命名备份文件失败: %w", err)
+	}
+	if err := RecordBackup(baseDir, method); err != nil {
+		return path, len(bundle.Files), err
+	}
+	return path, len(bundle.Files), nil
+}
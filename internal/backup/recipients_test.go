@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/palagend/slowmade/pkg/bech32"
+)
+
+func TestParseRecipientsAgeOnly(t *testing.T) {
+	recipients, err := ParseRecipients([]string{" " + testkitRecipient, ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipients.Count() != 1 || recipients.Name() != testkitRecipient || recipients.Extension() != ".age" {
+		t.Errorf("recipients = %d, %q, %q, want the age recipient", recipients.Count(), recipients.Name(), recipients.Extension())
+	}
+
+	if _, err := ParseRecipients([]string{testkitRecipient, "/home/heir/pubkey.asc"}); !errors.Is(err, ErrUnsupportedRecipient) {
+		t.Errorf("ParseRecipients with an OpenPGP key file error = %v, want ErrUnsupportedRecipient", err)
+	}
+	if _, err := ParseRecipients([]string{" "}); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("ParseRecipients without recipients error = %v, want ErrNoRecipients", err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	identity, recipient := newAgeKey(t)
+	bundle := &Bundle{Version: bundleVersion, Files: map[string][]byte{"accounts/accounts.json": []byte("[]")}}
+	sealed, err := bundle.SealTo(&Recipients{age: []*ageRecipient{recipient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(sealed, ""); err == nil {
+		t.Error("OpenFile of an age file without an identity succeeded")
+	}
+	key, err := bech32.Encode(ageIdentityHRP, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenFile(sealed, strings.ToUpper(key))
+	if err != nil || string(opened.Files["accounts/accounts.json"]) != "[]" {
+		t.Errorf("OpenFile = %+v, %v, want the sealed bundle", opened, err)
+	}
+
+	// 旧版本写出的 OpenPGP 备份需要先用 gpg 解密
+	if _, err := OpenFile([]byte("-----BEGIN PGP MESSAGE-----\n"), ""); !errors.Is(err, ErrPGPBundle) {
+		t.Errorf("OpenFile of an OpenPGP message error = %v, want ErrPGPBundle", err)
+	}
+}
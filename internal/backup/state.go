@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// StateFileName 最近一次备份的记录在数据目录中的文件名
+const StateFileName = "backup_state.json"
+
+// State 最近一次备份的方式和时间
+type State struct {
+	Method string    `json:"method"` // 如 backup.qr、sync.push
+	At     time.Time `json:"at"`
+}
+
+// RecordBackup 记录一次成功的备份
+func RecordBackup(baseDir, method string) error {
+	data, err := canonjson.MarshalIndent(State{Method: method, At: time.Now().UTC()}, "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, StateFileName)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入备份记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名备份记录失败: %w", err)
+	}
+	return nil
+}
+
+// LastBackup 返回最近一次备份的记录，从未备份时返回 nil
+func LastBackup(baseDir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, StateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解码备份记录失败: %w", err)
+	}
+	return &state, nil
+}
+
+// ChangedSince 备份范围内的钱包、账户和地址文件在 t 之后是否有修改
+func ChangedSince(baseDir string, t time.Time) (bool, error) {
+	for _, dir := range backupDirs {
+		entries, err := os.ReadDir(filepath.Join(baseDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false, err
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return false, err
+			}
+			if !entry.IsDir() && info.ModTime().After(t) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
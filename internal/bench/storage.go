@@ -0,0 +1,296 @@
+// Package bench 存储层和加密层的负载测试：在临时目录中创建 N 个账户、每个账户 M 个地址，
+// 统计各存储后端的读写吞吐、p50/p99 延迟和文件大小，用于在接受存储重构之前验证性能
+package bench
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// ErrUnknownBackend 没有注册的存储后端
+var ErrUnknownBackend = errors.New("unknown storage backend")
+
+// Opener 在目录 dir 中创建一个空的存储后端
+type Opener func(dir string) (core.StorageHandler, error)
+
+// backends 可以测试的存储后端，新的后端（如 SQLite）实现 core.StorageHandler 后在这里注册
+var backends = map[string]Opener{
+	"file": func(dir string) (core.StorageHandler, error) {
+		return core.NewFileStorage(config.StorageConfig{BaseDir: dir})
+	},
+}
+
+// Backends 已注册的后端名称，按字母排序
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options 负载测试参数
+type Options struct {
+	Accounts      int      // 账户数 N
+	Addresses     int      // 每个账户的地址数 M
+	Backends      []string // 为空时测试全部已注册的后端
+	Dir           string   // 工作目录，为空时使用临时目录
+	Keep          bool     // 结束后保留生成的数据
+	CryptoSamples int      // 加密和解密的采样次数，0 表示不测试加密层
+}
+
+// Stats 一类操作的延迟统计
+type Stats struct {
+	Operation  string        `json:"operation"`
+	Count      int           `json:"count"`
+	Total      time.Duration `json:"total_ns"`
+	P50        time.Duration `json:"p50_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+	Throughput float64       `json:"ops_per_second"`
+}
+
+// Result 一个后端（或加密层）的测试结果
+type Result struct {
+	Backend string  `json:"backend"`
+	Stats   []Stats `json:"stats"`
+	Files   int     `json:"files,omitempty"`
+	Bytes   int64   `json:"bytes,omitempty"`
+}
+
+// Run 依次测试每个后端，每个后端使用单独的空目录
+func Run(opts Options) ([]Result, error) {
+	if opts.Accounts < 1 || opts.Addresses < 0 {
+		return nil, fmt.Errorf("accounts must be at least 1 and addresses must not be negative")
+	}
+	names := opts.Backends
+	if len(names) == 0 {
+		names = Backends()
+	}
+	for _, name := range names {
+		if _, ok := backends[name]; !ok {
+			return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownBackend, name, strings.Join(Backends(), ", "))
+		}
+	}
+
+	root := opts.Dir
+	if root == "" {
+		var err error
+		if root, err = os.MkdirTemp("", "slowmade-bench-"); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.Keep {
+		defer os.RemoveAll(root)
+	}
+
+	// 记录中的密文用真实的加密结果，文件大小才有参考意义
+	ciphertext, err := crypto.EncryptData(make([]byte, 32), "bench")
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, name := range names {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+		result, err := runBackend(name, dir, opts, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		results = append(results, result)
+	}
+	if opts.CryptoSamples > 0 {
+		result, err := runCrypto(opts.CryptoSamples)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func runBackend(name, dir string, opts Options, ciphertext string) (Result, error) {
+	storage, err := backends[name](dir)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Backend: name}
+
+	accounts := make([]*core.CoinAccount, opts.Accounts)
+	var saves []time.Duration
+	for i := range accounts {
+		accounts[i] = &core.CoinAccount{
+			ID:                         "file_" + randomHex(32),
+			CoinSymbol:                 "BTC",
+			DerivationPath:             fmt.Sprintf("m/84'/0'/%d'", i),
+			EncryptedAccountPrivateKey: ciphertext,
+		}
+		elapsed, err := timed(func() error { return storage.SaveAccount(accounts[i]) })
+		if err != nil {
+			return Result{}, err
+		}
+		saves = append(saves, elapsed)
+	}
+	result.Stats = append(result.Stats, summarize("save account", saves))
+
+	saves = saves[:0]
+	for _, account := range accounts {
+		for i := 0; i < opts.Addresses; i++ {
+			address := &core.AddressKey{
+				AccountID:           account.ID,
+				EncryptedPrivateKey: ciphertext,
+				PublicKey:           "02" + randomHex(32),
+				Address:             "bc1q" + randomHex(20),
+				AddressIndex:        uint32(i),
+				CoinSymbol:          account.CoinSymbol,
+			}
+			elapsed, err := timed(func() error { return storage.SaveAddress(address) })
+			if err != nil {
+				return Result{}, err
+			}
+			saves = append(saves, elapsed)
+		}
+	}
+	if opts.Addresses > 0 {
+		result.Stats = append(result.Stats, summarize("save address", saves))
+	}
+
+	var loads []time.Duration
+	for range accounts {
+		elapsed, err := timed(func() error {
+			loaded, err := storage.LoadAccounts()
+			if err == nil && len(loaded) != len(accounts) {
+				err = fmt.Errorf("loaded %d accounts, expected %d", len(loaded), len(accounts))
+			}
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		loads = append(loads, elapsed)
+	}
+	result.Stats = append(result.Stats, summarize("load accounts", loads))
+
+	loads = loads[:0]
+	for _, account := range accounts {
+		elapsed, err := timed(func() error {
+			loaded, err := storage.LoadAddresses(account.ID)
+			if err == nil && len(loaded) != opts.Addresses {
+				err = fmt.Errorf("loaded %d addresses, expected %d", len(loaded), opts.Addresses)
+			}
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		loads = append(loads, elapsed)
+	}
+	result.Stats = append(result.Stats, summarize("load addresses", loads))
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		result.Files++
+		result.Bytes += info.Size()
+		return nil
+	})
+	return result, err
+}
+
+// runCrypto 加密和解密一个 32 字节私钥的延迟，主要由密钥派生函数决定
+func runCrypto(samples int) (Result, error) {
+	result := Result{Backend: "crypto"}
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return Result{}, err
+	}
+	var ciphertext string
+	var encrypts, decrypts []time.Duration
+	for i := 0; i < samples; i++ {
+		elapsed, err := timed(func() (err error) {
+			ciphertext, err = crypto.EncryptData(plaintext, "bench")
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		encrypts = append(encrypts, elapsed)
+
+		elapsed, err = timed(func() error {
+			key, err := security.Decrypt(ciphertext, "bench")
+			if err != nil {
+				return err
+			}
+			key.Destroy()
+			return nil
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		decrypts = append(decrypts, elapsed)
+	}
+	result.Stats = append(result.Stats, summarize("encrypt key", encrypts), summarize("decrypt key", decrypts))
+	return result, nil
+}
+
+func timed(fn func() error) (time.Duration, error) {
+	start := time.Now()
+	err := fn()
+	return time.Since(start), err
+}
+
+func summarize(operation string, samples []time.Duration) Stats {
+	stats := Stats{Operation: operation, Count: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, d := range sorted {
+		stats.Total += d
+	}
+	stats.P50 = percentile(sorted, 50)
+	stats.P99 = percentile(sorted, 99)
+	stats.Max = sorted[len(sorted)-1]
+	if stats.Total > 0 {
+		stats.Throughput = float64(len(sorted)) / stats.Total.Seconds()
+	}
+	return stats
+}
+
+// percentile 最近秩法，sorted 必须已经升序排列
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,44 @@
+package btc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SatoshiPerBTC 1 BTC 对应的聪数
+const SatoshiPerBTC = 100_000_000
+
+// ParseBTC 将十进制 BTC 金额（如 0.015）精确转换为聪，避免浮点误差
+func ParseBTC(amount string) (int64, error) {
+	amount = strings.TrimSpace(amount)
+	integer, fraction, _ := strings.Cut(amount, ".")
+	if integer == "" && fraction == "" || strings.HasPrefix(integer, "-") || strings.HasPrefix(integer, "+") {
+		return 0, fmt.Errorf("invalid BTC amount %q", amount)
+	}
+	if len(fraction) > 8 {
+		return 0, fmt.Errorf("invalid BTC amount %q: more than 8 decimal places", amount)
+	}
+	fraction += strings.Repeat("0", 8-len(fraction))
+	if integer == "" {
+		integer = "0"
+	}
+	whole, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || whole > 21_000_000 {
+		return 0, fmt.Errorf("invalid BTC amount %q", amount)
+	}
+	sats, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid BTC amount %q", amount)
+	}
+	return whole*SatoshiPerBTC + sats, nil
+}
+
+// FormatBTC 将聪格式化为 BTC，保留 8 位小数
+func FormatBTC(sats int64) string {
+	sign := ""
+	if sats < 0 {
+		sign, sats = "-", -sats
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, sats/SatoshiPerBTC, sats%SatoshiPerBTC)
+}
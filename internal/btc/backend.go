@@ -0,0 +1,80 @@
+package btc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/mockchain"
+)
+
+// 错误定义
+var (
+	ErrNotConfigured  = errors.New("bitcoin backend not configured")
+	ErrUnknownBackend = errors.New("unknown bitcoin backend")
+)
+
+// UTXO 未花费的交易输出
+type UTXO struct {
+	TxID    string `json:"txid"`
+	Vout    uint32 `json:"vout"`
+	Value   int64  `json:"value"` // 金额（聪）
+	Address string `json:"address"`
+	Script  string `json:"script"` // 输出脚本（十六进制）
+	Height  int64  `json:"height"` // 所在区块高度，0 表示未确认
+}
+
+// Outpoint 返回 txid:vout 形式的输出标识
+func (u UTXO) Outpoint() string {
+	return fmt.Sprintf("%s:%d", u.TxID, u.Vout)
+}
+
+// Backend 比特币链上数据后端
+type Backend interface {
+	Name() string
+	ListUnspent(ctx context.Context, addresses []string) ([]UTXO, error)
+	EstimateFeeRate(ctx context.Context, blocks int) (int64, error) // 返回 sat/vB
+	Broadcast(ctx context.Context, rawTx []byte) (string, error)
+}
+
+// Balance 地址余额（聪）
+type Balance struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"` // 内存池中的变化，可能为负
+}
+
+// HistoryItem 地址相关的交易
+type HistoryItem struct {
+	TxID    string
+	Height  int64 // 0 或负数表示未确认
+	Address string
+}
+
+// AddressBackend 支持地址订阅的后端，可以查询余额和历史，并据订阅状态跳过未变化的地址
+type AddressBackend interface {
+	Backend
+	Subscribe(ctx context.Context, addresses []string) (map[string]string, error)
+	Balances(ctx context.Context, addresses []string) (map[string]Balance, error)
+	History(ctx context.Context, addresses []string) ([]HistoryItem, error)
+}
+
+// NewBackend 根据配置创建链上数据后端
+func NewBackend(cfg config.BitcoinConfig) (Backend, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, ErrNotConfigured
+	case "electrum":
+		return NewElectrumBackend(cfg.ElectrumServers, cfg.ElectrumSkipVerify)
+	case mockchain.BackendName:
+		return NewMockBackend(mockchain.Default()), nil
+	case "rpc":
+		if cfg.RPCURL == "" {
+			return nil, fmt.Errorf("bitcoin.rpc_url is required for rpc backend")
+		}
+		return NewRPCBackend(cfg.RPCURL, cfg.RPCUser, cfg.RPCPassword), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
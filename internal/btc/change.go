@@ -0,0 +1,68 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 找零策略
+const (
+	ChangeFresh  = "fresh"  // 每次在内部链派生新的找零地址（默认）
+	ChangeSender = "sender" // 找零回到金额最大的输入的地址
+	ChangeFixed  = "fixed"  // 找零到固定的本钱包地址，如合并用地址
+)
+
+// ChangeModes 全部找零策略
+var ChangeModes = []string{ChangeFresh, ChangeSender, ChangeFixed}
+
+// ErrInvalidChangePolicy 无法解析的找零策略
+var ErrInvalidChangePolicy = errors.New("invalid change policy")
+
+// ChangePolicy 账户的找零策略，Address 仅用于 fixed
+type ChangePolicy struct {
+	Mode    string
+	Address string
+}
+
+// ParseChangePolicy 解析 fresh、sender 或 fixed:<address>，空字符串为 fresh
+func ParseChangePolicy(s string) (ChangePolicy, error) {
+	mode, address, _ := strings.Cut(strings.TrimSpace(s), ":")
+	switch strings.ToLower(mode) {
+	case "", ChangeFresh:
+		return ChangePolicy{Mode: ChangeFresh}, nil
+	case ChangeSender:
+		return ChangePolicy{Mode: ChangeSender}, nil
+	case ChangeFixed:
+		if _, err := AddressScript(address); err != nil {
+			return ChangePolicy{}, fmt.Errorf("%w: fixed needs a bitcoin address: %v", ErrInvalidChangePolicy, err)
+		}
+		return ChangePolicy{Mode: ChangeFixed, Address: address}, nil
+	}
+	return ChangePolicy{}, fmt.Errorf("%w %q, expected one of %s", ErrInvalidChangePolicy, s, strings.Join(ChangeModes, ", "))
+}
+
+// String 返回可由 ParseChangePolicy 解析的形式
+func (p ChangePolicy) String() string {
+	if p.Mode == ChangeFixed {
+		return ChangeFixed + ":" + p.Address
+	}
+	if p.Mode == "" {
+		return ChangeFresh
+	}
+	return p.Mode
+}
+
+// SenderInput 返回 sender 策略的找零地址：金额最大的输入的地址
+func SenderInput(inputs []UTXO) (string, bool) {
+	if len(inputs) == 0 {
+		return "", false
+	}
+	largest := inputs[0]
+	for _, u := range inputs[1:] {
+		if u.Value > largest.Value {
+			largest = u
+		}
+	}
+	return largest.Address, true
+}
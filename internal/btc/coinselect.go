@@ -0,0 +1,285 @@
+package btc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Strategy 币选择策略
+type Strategy string
+
+const (
+	StrategyBranchAndBound Strategy = "bnb"     // 寻找无需找零的精确组合，失败时回退到最大优先
+	StrategyLargestFirst   Strategy = "largest" // 按金额从大到小选取，输入最少
+	StrategyManual         Strategy = "manual"  // 手动币控制，只花费指定的 UTXO
+)
+
+// bnbMaxTries 分支定界的最大搜索次数，与 Bitcoin Core 一致
+const bnbMaxTries = 100000
+
+// ErrInsufficientFunds 可用 UTXO 不足以支付金额和手续费
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// SelectionParams 币选择参数
+type SelectionParams struct {
+	Target       int64    // 支付给接收方的总金额（聪）
+	FeeRate      int64    // 费率（sat/vB）
+	Outputs      [][]byte // 接收方输出脚本，用于估算交易大小
+	ChangeScript []byte   // 找零输出脚本
+	Strategy     Strategy
+}
+
+// Selection 币选择结果
+type Selection struct {
+	Inputs   []UTXO
+	Change   int64 // 找零金额，0 表示无找零（金额过小时被并入手续费）
+	Fee      int64
+	VSize    int
+	Strategy Strategy // 实际采用的策略
+}
+
+// candidate 计入自身花费成本后的 UTXO
+type candidate struct {
+	utxo      UTXO
+	effective int64 // 有效金额 = 金额 - 花费该输入的手续费
+}
+
+// SelectCoins 按策略从可用 UTXO 中选择输入，有效金额不为正的 UTXO 不参与选择
+func SelectCoins(utxos []UTXO, p SelectionParams) (*Selection, error) {
+	if p.Target <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	var candidates []candidate
+	for _, u := range utxos {
+		weight, err := utxoInputWeight(u)
+		if err != nil {
+			continue
+		}
+		if effective := u.Value - p.FeeRate*int64(vsize(weight)); effective > 0 {
+			candidates = append(candidates, candidate{utxo: u, effective: effective})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].effective > candidates[j].effective })
+
+	// 交易骨架和接收方输出的手续费，按含隔离见证标记保守估算
+	baseWeight := txOverheadWeight + segwitFlagWeight
+	for _, script := range p.Outputs {
+		baseWeight += outputWeight(script)
+	}
+	target := p.Target + p.FeeRate*int64(vsize(baseWeight))
+
+	if p.Strategy == StrategyBranchAndBound || p.Strategy == "" {
+		// 找零的成本：找零输出本身的手续费 + 日后花费它的手续费
+		costOfChange := p.FeeRate * int64(vsize(outputWeight(p.ChangeScript)))
+		if weight, err := inputWeight(p.ChangeScript); err == nil {
+			costOfChange += p.FeeRate * int64(vsize(weight))
+		}
+		if inputs := branchAndBound(candidates, target, costOfChange); inputs != nil {
+			if selection, err := finalize(inputs, p, false); err == nil {
+				selection.Strategy = StrategyBranchAndBound
+				return selection, nil
+			}
+		}
+	}
+
+	var (
+		inputs []UTXO
+		sum    int64
+	)
+	for _, c := range candidates {
+		inputs = append(inputs, c.utxo)
+		sum += c.effective
+		if sum >= target {
+			break
+		}
+	}
+	if sum < target {
+		return nil, fmt.Errorf("%w: need %s BTC including fees", ErrInsufficientFunds, FormatBTC(target))
+	}
+	selection, err := finalize(inputs, p, true)
+	if err != nil {
+		return nil, err
+	}
+	selection.Strategy = StrategyLargestFirst
+	return selection, nil
+}
+
+// FundWith 手动币控制：花费全部指定的 UTXO，不足时报错而不是自动追加输入
+func FundWith(inputs []UTXO, p SelectionParams) (*Selection, error) {
+	if p.Target <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	for _, u := range inputs {
+		if _, err := utxoInputWeight(u); err != nil {
+			return nil, fmt.Errorf("%s: %w", u.Outpoint(), err)
+		}
+	}
+	selection, err := finalize(inputs, p, true)
+	if err != nil {
+		return nil, err
+	}
+	selection.Strategy = StrategyManual
+	return selection, nil
+}
+
+// branchAndBound 深度优先搜索有效金额之和落在 [target, target+costOfChange] 内的组合，
+// 返回超出部分最小的组合，这样的交易无需找零输出
+func branchAndBound(candidates []candidate, target, costOfChange int64) []UTXO {
+	remaining := int64(0)
+	for _, c := range candidates {
+		remaining += c.effective
+	}
+	if remaining < target {
+		return nil
+	}
+
+	var (
+		tries      int
+		selected   = make([]bool, len(candidates))
+		best       []bool
+		bestExcess = costOfChange + 1
+		search     func(i int, current, remaining int64)
+	)
+	search = func(i int, current, remaining int64) {
+		tries++
+		if tries > bnbMaxTries || bestExcess == 0 {
+			return
+		}
+		if current > target+costOfChange || current+remaining < target {
+			return
+		}
+		if current >= target {
+			if excess := current - target; excess < bestExcess {
+				bestExcess = excess
+				best = append(best[:0], selected...)
+			}
+			return
+		}
+		if i == len(candidates) {
+			return
+		}
+		value := candidates[i].effective
+		// 上一个金额相同的候选已被排除时，包含当前候选得到的组合已经搜索过
+		if i == 0 || selected[i-1] || candidates[i-1].effective != value {
+			selected[i] = true
+			search(i+1, current+value, remaining-value)
+			selected[i] = false
+		}
+		search(i+1, current, remaining-value)
+	}
+	search(0, 0, remaining)
+
+	if best == nil {
+		return nil
+	}
+	var inputs []UTXO
+	for i, ok := range best {
+		if ok {
+			inputs = append(inputs, candidates[i].utxo)
+		}
+	}
+	return inputs
+}
+
+// finalize 按实际输入精确计算手续费，并做找零裁剪：
+// 找零低于粉尘阈值或不足以覆盖日后花费它的成本时，直接并入手续费
+func finalize(inputs []UTXO, p SelectionParams, allowChange bool) (*Selection, error) {
+	total := int64(0)
+	for _, u := range inputs {
+		total += u.Value
+	}
+	weight, err := estimateWeight(inputs, p.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	fee := p.FeeRate * int64(vsize(weight))
+	if total < p.Target+fee {
+		return nil, fmt.Errorf("%w: have %s BTC, need %s BTC including fees",
+			ErrInsufficientFunds, FormatBTC(total), FormatBTC(p.Target+fee))
+	}
+	selection := &Selection{Inputs: inputs, Fee: total - p.Target, VSize: vsize(weight)}
+
+	if allowChange && len(p.ChangeScript) > 0 {
+		changeWeight := weight + outputWeight(p.ChangeScript)
+		changeFee := p.FeeRate * int64(vsize(changeWeight))
+		change := total - p.Target - changeFee
+		spendCost := int64(0)
+		if w, err := inputWeight(p.ChangeScript); err == nil {
+			spendCost = p.FeeRate * int64(vsize(w))
+		}
+		if change >= dustThreshold(p.ChangeScript) && change > spendCost {
+			selection.Change = change
+			selection.Fee = changeFee
+			selection.VSize = vsize(changeWeight)
+		}
+	}
+	return selection, nil
+}
+
+// EstimateVSize 估算签名后交易的虚拟大小（vB），outputs 为全部输出脚本
+func EstimateVSize(inputs []UTXO, outputs [][]byte) (int, error) {
+	weight, err := estimateWeight(inputs, outputs)
+	if err != nil {
+		return 0, err
+	}
+	return vsize(weight), nil
+}
+
+// estimateWeight 估算交易权重（不含找零输出）
+func estimateWeight(inputs []UTXO, outputs [][]byte) (int, error) {
+	weight := txOverheadWeight
+	segwit, legacy := false, 0
+	for _, u := range inputs {
+		w, err := utxoInputWeight(u)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", u.Outpoint(), err)
+		}
+		weight += w
+		if w == p2wpkhInputWeight {
+			segwit = true
+		} else {
+			legacy++
+		}
+	}
+	// 隔离见证交易中，非见证输入也要写入一个空的见证栈
+	if segwit {
+		weight += segwitFlagWeight + legacy
+	}
+	for _, script := range outputs {
+		weight += outputWeight(script)
+	}
+	return weight, nil
+}
+
+func utxoInputWeight(u UTXO) (int, error) {
+	script, err := hex.DecodeString(u.Script)
+	if err != nil {
+		return 0, fmt.Errorf("invalid script for %s: %w", u.Outpoint(), err)
+	}
+	return inputWeight(script)
+}
+
+// SweepAll 花费全部输入，扣除手续费后的金额全部发送到一个输出，不产生找零，
+// 返回选择结果和发送金额
+func SweepAll(inputs []UTXO, script []byte, feeRate int64) (*Selection, int64, error) {
+	if len(inputs) == 0 {
+		return nil, 0, fmt.Errorf("%w: no UTXOs to sweep", ErrInsufficientFunds)
+	}
+	total := int64(0)
+	for _, u := range inputs {
+		total += u.Value
+	}
+	weight, err := estimateWeight(inputs, [][]byte{script})
+	if err != nil {
+		return nil, 0, err
+	}
+	fee := feeRate * int64(vsize(weight))
+	amount := total - fee
+	if amount < dustThreshold(script) {
+		return nil, 0, fmt.Errorf("%w: %s BTC does not cover the %s BTC fee",
+			ErrInsufficientFunds, FormatBTC(total), FormatBTC(fee))
+	}
+	return &Selection{Inputs: inputs, Fee: fee, VSize: vsize(weight), Strategy: StrategyManual}, amount, nil
+}
@@ -0,0 +1,63 @@
+package btc
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNothingToConsolidate 符合条件的小额 UTXO 少于两个
+var ErrNothingToConsolidate = errors.New("fewer than two small UTXOs to consolidate")
+
+// ConsolidationPlan 把多个小额 UTXO 合并为一个输出的计划
+type ConsolidationPlan struct {
+	Inputs  []UTXO
+	Skipped int   // 金额不足以支付自身花费成本而被跳过的 UTXO 数量
+	Amount  int64 // 合并后的输出金额
+	Fee     int64 // 现在合并需要支付的手续费
+	VSize   int
+	Saving  int64 // 按未来费率，分别花费这些输入比花费一个合并输出多付的手续费
+}
+
+// Benefit 以未来费率计算的净收益，为负表示合并不划算
+func (p *ConsolidationPlan) Benefit() int64 {
+	return p.Saving - p.Fee
+}
+
+// PlanConsolidation 选出已确认且金额低于 below 的 UTXO，按当前费率合并到 script，
+// 并按 futureFeeRate 估算日后花费时节省的手续费；花费成本高于自身金额的 UTXO 不参与合并
+func PlanConsolidation(utxos []UTXO, script []byte, feeRate, futureFeeRate, below int64) (*ConsolidationPlan, error) {
+	plan := &ConsolidationPlan{}
+	var inputWeights int
+	for _, u := range utxos {
+		if u.Height <= 0 || u.Value >= below {
+			continue
+		}
+		weight, err := utxoInputWeight(u)
+		if err != nil {
+			continue
+		}
+		if u.Value <= feeRate*int64(vsize(weight)) {
+			plan.Skipped++
+			continue
+		}
+		plan.Inputs = append(plan.Inputs, u)
+		inputWeights += weight
+	}
+	if len(plan.Inputs) < 2 {
+		return nil, ErrNothingToConsolidate
+	}
+	sort.SliceStable(plan.Inputs, func(i, j int) bool { return plan.Inputs[i].Value < plan.Inputs[j].Value })
+
+	selection, amount, err := SweepAll(plan.Inputs, script, feeRate)
+	if err != nil {
+		return nil, err
+	}
+	plan.Amount, plan.Fee, plan.VSize = amount, selection.Fee, selection.VSize
+
+	merged, err := inputWeight(script)
+	if err != nil {
+		return nil, err
+	}
+	plan.Saving = futureFeeRate * int64(vsize(inputWeights)-vsize(merged))
+	return plan, nil
+}
@@ -0,0 +1,82 @@
+package btc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/addrformat"
+)
+
+// 输出描述符校验和字符集（BIP380）
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorFunctions 地址格式对应的描述符，%s 为密钥表达式
+var descriptorFunctions = map[string]string{
+	addrformat.Legacy:  "pkh(%s)",
+	addrformat.P2SH:    "sh(wpkh(%s))",
+	addrformat.Bech32:  "wpkh(%s)",
+	addrformat.Bech32m: "tr(%s)",
+}
+
+// AccountDescriptors 返回账户收款链和找零链的输出描述符（带校验和），
+// 按账户的地址格式（legacy、p2sh、bech32、bech32m）选择 pkh、sh(wpkh)、wpkh 或 tr，与钱包实际生成的地址类型一致
+func AccountDescriptors(xpub string, format string) ([]string, error) {
+	function, ok := descriptorFunctions[format]
+	if !ok {
+		return nil, fmt.Errorf("no descriptor for address format %q", format)
+	}
+	var descriptors []string
+	for branch := 0; branch <= 1; branch++ { // 0 收款链，1 找零链
+		descriptor, err := WithChecksum(fmt.Sprintf(function, fmt.Sprintf("%s/%d/*", xpub, branch)))
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	return descriptors, nil
+}
+
+// WithChecksum 为描述符追加 #checksum
+func WithChecksum(descriptor string) (string, error) {
+	c := uint64(1)
+	cls, count := 0, 0
+	for _, ch := range descriptor {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("invalid character %q in descriptor", ch)
+		}
+		c = descriptorPolymod(c, uint64(pos&31))
+		cls = cls*3 + pos>>5
+		if count++; count == 3 {
+			c = descriptorPolymod(c, uint64(cls))
+			cls, count = 0, 0
+		}
+	}
+	if count > 0 {
+		c = descriptorPolymod(c, uint64(cls))
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return descriptor + "#" + string(checksum), nil
+}
+
+func descriptorPolymod(c, value uint64) uint64 {
+	top := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ value
+	for i, generator := range []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+		if top>>i&1 == 1 {
+			c ^= generator
+		}
+	}
+	return c
+}
@@ -0,0 +1,362 @@
+package btc
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+	"github.com/palagend/slowmade/internal/tracing"
+)
+
+// electrumProtocol 请求的 Electrum 协议版本
+const electrumProtocol = "1.4"
+
+// electrumServer 服务器地址，格式与 Electrum 客户端一致：host:port:s（TLS）或 host:port:t（明文 TCP）
+type electrumServer struct {
+	addr string
+	tls  bool
+}
+
+func (s electrumServer) String() string {
+	if s.tls {
+		return s.addr + ":s"
+	}
+	return s.addr + ":t"
+}
+
+// ElectrumError 服务器返回的错误，如交易被拒绝，不会触发故障转移
+type ElectrumError struct {
+	Code    int
+	Message string
+}
+
+func (e *ElectrumError) Error() string {
+	return fmt.Sprintf("electrum: %s (code %d)", e.Message, e.Code)
+}
+
+// ElectrumBackend 基于 Electrum 服务器协议的轻量后端，按列表顺序连接服务器，失败时切换到下一个
+type ElectrumBackend struct {
+	servers    []electrumServer
+	skipVerify bool
+	timeout    time.Duration
+}
+
+// NewElectrumBackend 创建 Electrum 后端，skipVerify 用于自签名证书的私有服务器
+func NewElectrumBackend(servers []string, skipVerify bool) (*ElectrumBackend, error) {
+	if len(servers) == 0 {
+		return nil, errors.New("at least one electrum server is required")
+	}
+	b := &ElectrumBackend{skipVerify: skipVerify, timeout: 30 * time.Second}
+	for _, server := range servers {
+		parsed, err := parseElectrumServer(server)
+		if err != nil {
+			return nil, err
+		}
+		b.servers = append(b.servers, parsed)
+	}
+	return b, nil
+}
+
+func parseElectrumServer(server string) (electrumServer, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err == nil {
+		// 未指定协议时默认 TLS
+		return electrumServer{addr: net.JoinHostPort(host, port), tls: true}, nil
+	}
+	i := strings.LastIndex(server, ":")
+	if i < 0 {
+		return electrumServer{}, fmt.Errorf("invalid electrum server %q, expected host:port[:s|t]", server)
+	}
+	addr, proto := server[:i], server[i+1:]
+	if _, _, err := net.SplitHostPort(addr); err != nil || (proto != "s" && proto != "t") {
+		return electrumServer{}, fmt.Errorf("invalid electrum server %q, expected host:port[:s|t]", server)
+	}
+	return electrumServer{addr: addr, tls: proto == "s"}, nil
+}
+
+func (b *ElectrumBackend) Name() string {
+	names := make([]string, len(b.servers))
+	for i, s := range b.servers {
+		names[i] = s.String()
+	}
+	return "electrum:" + strings.Join(names, ",")
+}
+
+// scriptHash Electrum 以输出脚本 SHA256 的逆序十六进制标识地址
+func scriptHash(address string) (string, []byte, error) {
+	script, err := AddressScript(address)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(script)
+	return hex.EncodeToString(reverseBytes(sum[:])), script, nil
+}
+
+// Subscribe 订阅地址并返回其状态哈希，状态不变说明地址历史没有变化，空字符串表示地址从未使用
+func (b *ElectrumBackend) Subscribe(ctx context.Context, addresses []string) (map[string]string, error) {
+	statuses := make(map[string]string, len(addresses))
+	err := b.session(ctx, func(c *electrumConn) error {
+		for _, address := range addresses {
+			hash, _, err := scriptHash(address)
+			if err != nil {
+				return err
+			}
+			var status *string
+			if err := c.call("blockchain.scripthash.subscribe", []interface{}{hash}, &status); err != nil {
+				return err
+			}
+			statuses[address] = ""
+			if status != nil {
+				statuses[address] = *status
+			}
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (b *ElectrumBackend) ListUnspent(ctx context.Context, addresses []string) ([]UTXO, error) {
+	var utxos []UTXO
+	err := b.session(ctx, func(c *electrumConn) error {
+		utxos = utxos[:0]
+		for _, address := range addresses {
+			hash, script, err := scriptHash(address)
+			if err != nil {
+				return err
+			}
+			var result []struct {
+				TxHash string `json:"tx_hash"`
+				TxPos  uint32 `json:"tx_pos"`
+				Height int64  `json:"height"`
+				Value  int64  `json:"value"`
+			}
+			if err := c.call("blockchain.scripthash.listunspent", []interface{}{hash}, &result); err != nil {
+				return err
+			}
+			for _, u := range result {
+				height := u.Height
+				if height < 0 {
+					height = 0
+				}
+				utxos = append(utxos, UTXO{
+					TxID:    u.TxHash,
+					Vout:    u.TxPos,
+					Value:   u.Value,
+					Address: address,
+					Script:  hex.EncodeToString(script),
+					Height:  height,
+				})
+			}
+		}
+		return nil
+	})
+	return utxos, err
+}
+
+// Balances 查询地址的已确认和未确认余额
+func (b *ElectrumBackend) Balances(ctx context.Context, addresses []string) (map[string]Balance, error) {
+	balances := make(map[string]Balance, len(addresses))
+	err := b.session(ctx, func(c *electrumConn) error {
+		for _, address := range addresses {
+			hash, _, err := scriptHash(address)
+			if err != nil {
+				return err
+			}
+			var result Balance
+			if err := c.call("blockchain.scripthash.get_balance", []interface{}{hash}, &result); err != nil {
+				return err
+			}
+			balances[address] = result
+		}
+		return nil
+	})
+	return balances, err
+}
+
+// History 查询地址的交易历史
+func (b *ElectrumBackend) History(ctx context.Context, addresses []string) ([]HistoryItem, error) {
+	var history []HistoryItem
+	err := b.session(ctx, func(c *electrumConn) error {
+		history = history[:0]
+		for _, address := range addresses {
+			hash, _, err := scriptHash(address)
+			if err != nil {
+				return err
+			}
+			var result []struct {
+				TxHash string `json:"tx_hash"`
+				Height int64  `json:"height"`
+			}
+			if err := c.call("blockchain.scripthash.get_history", []interface{}{hash}, &result); err != nil {
+				return err
+			}
+			for _, item := range result {
+				history = append(history, HistoryItem{TxID: item.TxHash, Height: item.Height, Address: address})
+			}
+		}
+		return nil
+	})
+	return history, err
+}
+
+func (b *ElectrumBackend) EstimateFeeRate(ctx context.Context, blocks int) (int64, error) {
+	var rate float64 // BTC/kB，-1 表示服务器无法估算
+	err := b.session(ctx, func(c *electrumConn) error {
+		return c.call("blockchain.estimatefee", []interface{}{blocks}, &rate)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if rate <= 0 {
+		return 0, errors.New("fee estimation unavailable")
+	}
+	return int64(math.Ceil(rate * SatoshiPerBTC / 1000)), nil
+}
+
+func (b *ElectrumBackend) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	var txid string
+	err := b.session(ctx, func(c *electrumConn) error {
+		return c.call("blockchain.transaction.broadcast", []interface{}{hex.EncodeToString(rawTx)}, &txid)
+	})
+	return txid, err
+}
+
+// session 依次尝试服务器直到 fn 成功，服务器返回的业务错误直接返回，不再切换。
+// 切换服务器即是重试，所以每个服务器只尝试一次；连续失败被熔断的服务器直接跳过
+func (b *ElectrumBackend) session(ctx context.Context, fn func(c *electrumConn) error) error {
+	var failures []string
+	for _, server := range b.servers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		policy := resilience.PolicyFor(server.addr, b.timeout)
+		policy.Attempts = 1
+		_, span := tracing.Start(ctx, tracing.Client, "electrum", "server.address", server.addr)
+		err := resilience.Retry(ctx, "electrum:"+server.addr, policy, func(ctx context.Context) error {
+			c, err := b.dial(ctx, server, policy.Timeout)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			err = fn(c)
+			if isServerError(err) {
+				return resilience.Permanent(err)
+			}
+			return err
+		})
+		span.End(err)
+		if err == nil || isServerError(err) {
+			return err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", server, err))
+	}
+	return fmt.Errorf("all electrum servers failed: %s", strings.Join(failures, "; "))
+}
+
+// isServerError 服务器正常响应的业务错误，不是连接问题
+func isServerError(err error) bool {
+	var serverErr *ElectrumError
+	return errors.As(err, &serverErr) || errors.Is(err, ErrInvalidAddress)
+}
+
+func (b *ElectrumBackend) dial(ctx context.Context, server electrumServer, timeout time.Duration) (*electrumConn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if server.tls {
+		host, _, _ := net.SplitHostPort(server.addr)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: b.skipVerify,
+			MinVersion:         tls.VersionTLS12,
+		}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", server.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", server.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	c := &electrumConn{conn: conn, reader: bufio.NewReader(conn)}
+	// 协议要求连接后首先协商版本
+	var version []string
+	if err := c.call("server.version", []interface{}{"slowmade", electrumProtocol}, &version); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// electrumConn 单个服务器连接，请求和响应均为换行分隔的 JSON-RPC
+type electrumConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID atomic.Uint64
+}
+
+func (c *electrumConn) call(method string, params []interface{}, result interface{}) error {
+	id := c.nextID.Add(1)
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(request, '\n')); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var response struct {
+			ID     *uint64         `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(line, &response); err != nil {
+			return fmt.Errorf("invalid electrum response: %w", err)
+		}
+		// 跳过订阅通知和其他请求的响应
+		if response.ID == nil || *response.ID != id {
+			continue
+		}
+		if response.Error != nil {
+			return &ElectrumError{Code: response.Error.Code, Message: response.Error.Message}
+		}
+		return json.Unmarshal(response.Result, result)
+	}
+}
+
+func (c *electrumConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,256 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/palagend/slowmade/internal/mockchain"
+)
+
+// satsPerBTC 一个比特币的聪数，模拟链按它生成初始资金
+var satsPerBTC = big.NewInt(100_000_000)
+
+// MockBackend 基于内置模拟链的后端，不访问网络；广播的交易会被解析，
+// 花费的输出和新建的输出都记录在模拟链中，之后的查询能看到找零和确认
+type MockBackend struct {
+	chain *mockchain.Chain
+}
+
+// NewMockBackend 创建模拟链后端
+func NewMockBackend(chain *mockchain.Chain) *MockBackend {
+	return &MockBackend{chain: chain}
+}
+
+func (b *MockBackend) Name() string { return mockchain.BackendName }
+
+func (b *MockBackend) ListUnspent(ctx context.Context, addresses []string) ([]UTXO, error) {
+	var utxos []UTXO
+	for _, address := range addresses {
+		script, err := AddressScript(address)
+		if err != nil {
+			return nil, err
+		}
+		coins, err := b.chain.Unspent("BTC", hex.EncodeToString(script), satsPerBTC)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range coins {
+			utxos = append(utxos, UTXO{
+				TxID: c.TxID, Vout: c.Vout, Value: c.Value.Int64(), Address: address,
+				Script: hex.EncodeToString(script), Height: c.Height,
+			})
+		}
+	}
+	return utxos, nil
+}
+
+func (b *MockBackend) EstimateFeeRate(ctx context.Context, blocks int) (int64, error) {
+	return b.chain.FeeRate(blocks)
+}
+
+func (b *MockBackend) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	tx, err := parseTx(rawTx)
+	if err != nil {
+		return "", fmt.Errorf("mockchain: %w", err)
+	}
+	outputs := make([]mockchain.Output, len(tx.outputs))
+	for i, out := range tx.outputs {
+		outputs[i] = mockchain.Output{Key: hex.EncodeToString(out.Script), Value: big.NewInt(out.Value)}
+	}
+	if err := b.chain.Submit("BTC", tx.txid, tx.spends, outputs, tx.owners, satsPerBTC, tx.rbf); err != nil {
+		return "", err
+	}
+	return tx.txid, nil
+}
+
+// Subscribe 状态为地址历史的摘要，历史变化时随之改变
+func (b *MockBackend) Subscribe(ctx context.Context, addresses []string) (map[string]string, error) {
+	statuses := make(map[string]string, len(addresses))
+	for _, address := range addresses {
+		history, err := b.History(ctx, []string{address})
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		for _, item := range history {
+			fmt.Fprintf(&buf, "%s:%d:", item.TxID, item.Height)
+		}
+		statuses[address] = ""
+		if buf.Len() > 0 {
+			statuses[address] = hex.EncodeToString(DoubleSHA256(buf.Bytes()))
+		}
+	}
+	return statuses, nil
+}
+
+func (b *MockBackend) Balances(ctx context.Context, addresses []string) (map[string]Balance, error) {
+	utxos, err := b.ListUnspent(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]Balance, len(addresses))
+	for _, address := range addresses {
+		balances[address] = Balance{}
+	}
+	for _, u := range utxos {
+		balance := balances[u.Address]
+		if u.Height > 0 {
+			balance.Confirmed += u.Value
+		} else {
+			balance.Unconfirmed += u.Value
+		}
+		balances[u.Address] = balance
+	}
+	return balances, nil
+}
+
+func (b *MockBackend) History(ctx context.Context, addresses []string) ([]HistoryItem, error) {
+	var history []HistoryItem
+	for _, address := range addresses {
+		script, err := AddressScript(address)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := b.chain.History("BTC", hex.EncodeToString(script), satsPerBTC)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			history = append(history, HistoryItem{TxID: e.TxID, Height: e.Height, Address: address})
+		}
+	}
+	return history, nil
+}
+
+// parsedTx 模拟链需要的交易内容
+type parsedTx struct {
+	txid    string
+	spends  []mockchain.Outpoint
+	owners  map[mockchain.Outpoint][]string // 由签名中的公钥推出的被花费输出脚本
+	outputs []TxOut
+	rbf     bool // 有输入的 sequence 声明了 RBF
+}
+
+var errTruncatedTx = errors.New("truncated transaction")
+
+// parseTx 解析原始交易（支持隔离见证格式），txid 按不含见证数据的序列化计算
+func parseTx(raw []byte) (*parsedTx, error) {
+	r := &txReader{data: raw}
+	version := r.read(4)
+	segwit := len(raw) > 6 && raw[4] == 0x00 && raw[5] == 0x01
+	if segwit {
+		r.read(2)
+	}
+	bodyStart := r.pos
+
+	tx := &parsedTx{owners: make(map[mockchain.Outpoint][]string)}
+	inputs := r.varInt()
+	if inputs > uint64(len(raw)) {
+		return nil, errTruncatedTx
+	}
+	scriptSigs := make([][]byte, inputs)
+	for i := uint64(0); i < inputs && r.err == nil; i++ {
+		hash := r.read(32)
+		vout := r.uint32()
+		scriptSigs[i] = r.read(int(r.varInt()))
+		if sequence := r.uint32(); SignalsRBF(sequence) {
+			tx.rbf = true
+		}
+		tx.spends = append(tx.spends, mockchain.Outpoint{TxID: hex.EncodeToString(reverseBytes(hash)), Vout: vout})
+	}
+	outputs := r.varInt()
+	for i := uint64(0); i < outputs && r.err == nil; i++ {
+		value := int64(binary.LittleEndian.Uint64(r.read(8)))
+		script := r.read(int(r.varInt()))
+		tx.outputs = append(tx.outputs, TxOut{Value: value, Script: append([]byte(nil), script...)})
+	}
+	bodyEnd := r.pos
+
+	witnesses := make([][][]byte, inputs)
+	if segwit {
+		for i := uint64(0); i < inputs && r.err == nil; i++ {
+			items := r.varInt()
+			for j := uint64(0); j < items && r.err == nil; j++ {
+				witnesses[i] = append(witnesses[i], r.read(int(r.varInt())))
+			}
+		}
+	}
+	locktime := r.read(4)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.pos != len(raw) {
+		return nil, fmt.Errorf("%d trailing bytes", len(raw)-r.pos)
+	}
+
+	var stripped bytes.Buffer
+	stripped.Write(version)
+	stripped.Write(raw[bodyStart:bodyEnd])
+	stripped.Write(locktime)
+	tx.txid = hex.EncodeToString(reverseBytes(DoubleSHA256(stripped.Bytes())))
+
+	for i, in := range tx.spends {
+		if pub := signingPubKey(scriptSigs[i], witnesses[i]); pub != nil {
+			hash := Hash160(pub)
+			tx.owners[in] = []string{
+				hex.EncodeToString(append([]byte{witnessV0, 0x14}, hash...)),
+				hex.EncodeToString(p2pkhScript(hash)),
+			}
+		}
+	}
+	return tx, nil
+}
+
+// signingPubKey P2WPKH 见证或 P2PKH 解锁脚本中的压缩公钥
+func signingPubKey(scriptSig []byte, witness [][]byte) []byte {
+	if len(witness) == 2 && len(witness[1]) == 33 {
+		return witness[1]
+	}
+	// scriptSig 为 <签名> <公钥> 两个直接压栈
+	if len(scriptSig) > 0 {
+		sigLen := int(scriptSig[0])
+		if len(scriptSig) == 1+sigLen+1+33 && scriptSig[1+sigLen] == 33 {
+			return scriptSig[2+sigLen:]
+		}
+	}
+	return nil
+}
+
+type txReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *txReader) read(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.err = errTruncatedTx
+		return make([]byte, max(n, 0))
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *txReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.read(4))
+}
+
+func (r *txReader) varInt() uint64 {
+	prefix := r.read(1)[0]
+	switch prefix {
+	case 0xfd:
+		return uint64(binary.LittleEndian.Uint16(r.read(2)))
+	case 0xfe:
+		return uint64(binary.LittleEndian.Uint32(r.read(4)))
+	case 0xff:
+		return binary.LittleEndian.Uint64(r.read(8))
+	default:
+		return uint64(prefix)
+	}
+}
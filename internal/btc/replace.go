@@ -0,0 +1,255 @@
+package btc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// PendingFileName 已广播交易的记录在数据目录中的文件名，用于替换交易
+const PendingFileName = "btc_pending.json"
+
+// pendingExpiry 超过该时间的记录在保存时删除，与 Bitcoin Core 内存池的默认过期时间一致
+const pendingExpiry = 14 * 24 * time.Hour
+
+// minRelayFeeRate 替换交易在原交易手续费之外至少再为自身大小支付的费率（BIP125 规则 4）
+const minRelayFeeRate = 1
+
+// 错误定义
+var (
+	ErrNotPending      = errors.New("transaction was not broadcast by this wallet")
+	ErrNotReplaceable  = errors.New("transaction does not signal replace-by-fee")
+	ErrAlreadyReplaced = errors.New("transaction was already replaced")
+	ErrNoChangeOutput  = errors.New("transaction has no change output to pay a higher fee from")
+	ErrFeeRateTooLow   = errors.New("fee rate too low to replace the transaction")
+)
+
+// PendingOutput 已广播交易的输出，Change 表示属于本钱包、提高手续费时可以从中扣除
+type PendingOutput struct {
+	Value  int64  `json:"value"`
+	Script string `json:"script"` // 十六进制
+	Change bool   `json:"change,omitempty"`
+}
+
+// PendingTx 本钱包广播的交易，保存输入和输出以便构造替换交易
+type PendingTx struct {
+	TxID       string          `json:"txid"`
+	AccountID  string          `json:"account_id"`
+	Command    string          `json:"command"`
+	Inputs     []UTXO          `json:"inputs"`
+	Outputs    []PendingOutput `json:"outputs"`
+	Fee        int64           `json:"fee"`
+	VSize      int             `json:"vsize"`
+	RBF        bool            `json:"rbf"`
+	SentAt     time.Time       `json:"sent_at"`
+	ReplacedBy string          `json:"replaced_by,omitempty"`
+}
+
+// NewPendingTx 由签名时的输入、输出和手续费构造记录，输出脚本等于 changeScript 的输出标记为找零
+func NewPendingTx(selection *Selection, outputs []TxOut, changeScript []byte) *PendingTx {
+	tx := &PendingTx{Inputs: selection.Inputs, Fee: selection.Fee, VSize: selection.VSize}
+	change := hex.EncodeToString(changeScript)
+	for _, out := range outputs {
+		script := hex.EncodeToString(out.Script)
+		tx.Outputs = append(tx.Outputs, PendingOutput{Value: out.Value, Script: script, Change: len(changeScript) > 0 && script == change})
+	}
+	return tx
+}
+
+// FeeRate 交易的费率（sat/vB），向上取整
+func (tx *PendingTx) FeeRate() int64 {
+	if tx.VSize <= 0 {
+		return 0
+	}
+	return (tx.Fee + int64(tx.VSize) - 1) / int64(tx.VSize)
+}
+
+// minReplacementFeeRate 大小为 size 的替换交易至少需要的费率：高于原费率，且多付的手续费覆盖自身的转发费用
+func (tx *PendingTx) minReplacementFeeRate(size int) int64 {
+	need := tx.Fee + minRelayFeeRate*int64(size)
+	return max(tx.FeeRate()+1, (need+int64(size)-1)/int64(size))
+}
+
+// Replacement 替换交易的输入、输出和手续费
+type Replacement struct {
+	Inputs  []UTXO
+	Outputs []TxOut
+	Fee     int64
+	VSize   int
+	FeeRate int64
+	change  []bool // 各输出是否为找零
+}
+
+// Pending 返回替换交易本身的记录，找零标记沿用原交易
+func (r *Replacement) Pending() *PendingTx {
+	tx := &PendingTx{Inputs: r.Inputs, Fee: r.Fee, VSize: r.VSize}
+	for i, out := range r.Outputs {
+		tx.Outputs = append(tx.Outputs, PendingOutput{Value: out.Value, Script: hex.EncodeToString(out.Script), Change: r.change[i]})
+	}
+	return tx
+}
+
+// checkReplaceable 检查原交易可以被替换
+func checkReplaceable(tx *PendingTx) error {
+	if tx.ReplacedBy != "" {
+		return fmt.Errorf("%w by %s", ErrAlreadyReplaced, tx.ReplacedBy)
+	}
+	if !tx.RBF {
+		return ErrNotReplaceable
+	}
+	return nil
+}
+
+// checkReplacementFee 检查替换交易满足 BIP125：费率更高，且手续费不少于原手续费加上自身大小的转发费用
+func checkReplacementFee(tx *PendingTx, fee int64, size int) error {
+	need := tx.Fee + minRelayFeeRate*int64(size)
+	if fee < need || fee*int64(tx.VSize) <= tx.Fee*int64(size) {
+		return fmt.Errorf("%w: pays %d sat, needs at least %d sat (original %d sat at %d sat/vB)",
+			ErrFeeRateTooLow, fee, need, tx.Fee, tx.FeeRate())
+	}
+	return nil
+}
+
+// PlanBump 保持输入和收款输出不变，以 feeRate 重新计算手续费，多出的手续费从找零输出中扣除；
+// feeRate 为 0 时使用 BIP125 允许的最低费率
+func PlanBump(tx *PendingTx, feeRate int64) (*Replacement, error) {
+	if err := checkReplaceable(tx); err != nil {
+		return nil, err
+	}
+	change := -1
+	outputs := make([]TxOut, len(tx.Outputs))
+	scripts := make([][]byte, len(tx.Outputs))
+	flags := make([]bool, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		flags[i] = out.Change
+		script, err := hex.DecodeString(out.Script)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output script: %w", err)
+		}
+		outputs[i], scripts[i] = TxOut{Value: out.Value, Script: script}, script
+		if out.Change && (change < 0 || out.Value > outputs[change].Value) {
+			change = i
+		}
+	}
+	if change < 0 {
+		return nil, ErrNoChangeOutput
+	}
+	weight, err := estimateWeight(tx.Inputs, scripts)
+	if err != nil {
+		return nil, err
+	}
+	size := vsize(weight)
+	if feeRate == 0 {
+		feeRate = tx.minReplacementFeeRate(size)
+	}
+	fee := feeRate * int64(size)
+	if err := checkReplacementFee(tx, fee, size); err != nil {
+		return nil, err
+	}
+	outputs[change].Value -= fee - tx.Fee
+	if outputs[change].Value < dustThreshold(outputs[change].Script) {
+		return nil, fmt.Errorf("%w: change of %s BTC cannot cover a %s BTC fee, use tx.cancel or a lower fee rate",
+			ErrInsufficientFunds, FormatBTC(tx.Outputs[change].Value), FormatBTC(fee))
+	}
+	return &Replacement{Inputs: tx.Inputs, Outputs: outputs, Fee: fee, VSize: size, FeeRate: feeRate, change: flags}, nil
+}
+
+// PlanCancel 花费同样的输入，扣除手续费后全部转到 script（本钱包地址），原交易的付款随之作废；
+// feeRate 为 0 时使用 BIP125 允许的最低费率
+func PlanCancel(tx *PendingTx, script []byte, feeRate int64) (*Replacement, error) {
+	if err := checkReplaceable(tx); err != nil {
+		return nil, err
+	}
+	if feeRate == 0 {
+		// 交易大小与费率无关，先按最低费率算出大小
+		probe, _, err := SweepAll(tx.Inputs, script, minRelayFeeRate)
+		if err != nil {
+			return nil, err
+		}
+		feeRate = tx.minReplacementFeeRate(probe.VSize)
+	}
+	selection, amount, err := SweepAll(tx.Inputs, script, feeRate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReplacementFee(tx, selection.Fee, selection.VSize); err != nil {
+		return nil, err
+	}
+	return &Replacement{Inputs: tx.Inputs, Outputs: []TxOut{{Value: amount, Script: script}},
+		Fee: selection.Fee, VSize: selection.VSize, FeeRate: feeRate, change: []bool{true}}, nil
+}
+
+// PendingStore 本钱包广播的交易记录
+type PendingStore struct {
+	mu   sync.Mutex
+	path string
+	Txs  map[string]*PendingTx `json:"txs"`
+}
+
+// LoadPendingStore 加载交易记录，文件不存在时返回空记录
+func LoadPendingStore(path string) (*PendingStore, error) {
+	store := &PendingStore{path: path, Txs: make(map[string]*PendingTx)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("解码交易记录失败: %w", err)
+	}
+	if store.Txs == nil {
+		store.Txs = make(map[string]*PendingTx)
+	}
+	return store, nil
+}
+
+// Get 查找交易记录
+func (s *PendingStore) Get(txid string) (*PendingTx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.Txs[txid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotPending, txid)
+	}
+	copied := *tx
+	return &copied, nil
+}
+
+// Add 保存新广播的交易，replaces 不为空时把被替换的交易标记为已替换；同时删除过期的记录
+func (s *PendingStore) Add(tx *PendingTx, replaces string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.Txs[replaces]; ok {
+		old.ReplacedBy = tx.TxID
+	}
+	s.Txs[tx.TxID] = tx
+	for txid, pending := range s.Txs {
+		if time.Since(pending.SentAt) > pendingExpiry {
+			delete(s.Txs, txid)
+		}
+	}
+	return s.save()
+}
+
+func (s *PendingStore) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入交易记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名交易记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// RPCBackend 基于 Bitcoin Core JSON-RPC 的后端，UTXO 通过 scantxoutset 查询，
+// 不依赖节点钱包，但只能看到已确认的输出
+type RPCBackend struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewRPCBackend 创建 Bitcoin Core RPC 后端
+func NewRPCBackend(url, user, password string) *RPCBackend {
+	return &RPCBackend{
+		url:      url,
+		user:     user,
+		password: password,
+		// scantxoutset 需要遍历整个 UTXO 集，耗时较长
+		client: resilience.NewHTTPClient(5 * time.Minute),
+	}
+}
+
+func (b *RPCBackend) Name() string {
+	return "rpc:" + b.url
+}
+
+func (b *RPCBackend) ListUnspent(ctx context.Context, addresses []string) ([]UTXO, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	// 以输出脚本反查地址，避免解析返回的描述符
+	byScript := make(map[string]string, len(addresses))
+	descriptors := make([]string, len(addresses))
+	for i, address := range addresses {
+		script, err := AddressScript(address)
+		if err != nil {
+			return nil, err
+		}
+		byScript[hex.EncodeToString(script)] = address
+		descriptors[i] = "addr(" + address + ")"
+	}
+
+	var result struct {
+		Success  bool `json:"success"`
+		Unspents []struct {
+			TxID         string      `json:"txid"`
+			Vout         uint32      `json:"vout"`
+			ScriptPubKey string      `json:"scriptPubKey"`
+			Amount       json.Number `json:"amount"`
+			Height       int64       `json:"height"`
+		} `json:"unspents"`
+	}
+	if err := b.call(ctx, "scantxoutset", []interface{}{"start", descriptors}, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("scantxoutset did not complete")
+	}
+
+	utxos := make([]UTXO, 0, len(result.Unspents))
+	for _, u := range result.Unspents {
+		value, err := ParseBTC(u.Amount.String())
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, UTXO{
+			TxID:    u.TxID,
+			Vout:    u.Vout,
+			Value:   value,
+			Address: byScript[u.ScriptPubKey],
+			Script:  u.ScriptPubKey,
+			Height:  u.Height,
+		})
+	}
+	return utxos, nil
+}
+
+func (b *RPCBackend) EstimateFeeRate(ctx context.Context, blocks int) (int64, error) {
+	var result struct {
+		FeeRate float64  `json:"feerate"` // BTC/kvB
+		Errors  []string `json:"errors"`
+	}
+	if err := b.call(ctx, "estimatesmartfee", []interface{}{blocks}, &result); err != nil {
+		return 0, err
+	}
+	if result.FeeRate <= 0 {
+		return 0, fmt.Errorf("fee estimation unavailable: %v", result.Errors)
+	}
+	return int64(math.Ceil(result.FeeRate * SatoshiPerBTC / 1000)), nil
+}
+
+func (b *RPCBackend) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	var txid string
+	if err := b.call(ctx, "sendrawtransaction", []interface{}{hex.EncodeToString(rawTx)}, &txid); err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+// call 发送 JSON-RPC 1.0 请求
+func (b *RPCBackend) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "1.0",
+		"id":      "slowmade",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.user != "" || b.password != "" {
+		req.SetBasicAuth(b.user, b.password)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Bitcoin Core 在 RPC 出错时同样返回 JSON 错误体（HTTP 500）
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("bitcoin rpc %s: %s", method, resp.Status)
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("bitcoin rpc %s: %s (code %d)", method, envelope.Error.Message, envelope.Error.Code)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
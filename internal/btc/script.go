@@ -0,0 +1,155 @@
+package btc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/bech32"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// 主网地址参数
+const (
+	mainnetHRP    = "bc"
+	p2pkhVersion  = 0x00
+	p2shVersion   = 0x05
+	witnessV0     = 0x00
+	opDup         = 0x76
+	opHash160     = 0xa9
+	opEqual       = 0x87
+	opEqualVerify = 0x88
+	opCheckSig    = 0xac
+	opOne         = 0x51
+)
+
+// ErrInvalidAddress 地址无法解析为主网输出脚本
+var ErrInvalidAddress = errors.New("invalid bitcoin address")
+
+// ScriptType 输出脚本类型
+type ScriptType int
+
+const (
+	ScriptUnknown ScriptType = iota
+	ScriptP2PKH
+	ScriptP2SH
+	ScriptP2WPKH
+	ScriptP2WSH
+	ScriptP2TR
+)
+
+func (t ScriptType) String() string {
+	switch t {
+	case ScriptP2PKH:
+		return "P2PKH"
+	case ScriptP2SH:
+		return "P2SH"
+	case ScriptP2WPKH:
+		return "P2WPKH"
+	case ScriptP2WSH:
+		return "P2WSH"
+	case ScriptP2TR:
+		return "P2TR"
+	default:
+		return "unknown"
+	}
+}
+
+// Hash160 即 RIPEMD160(SHA256(data))
+func Hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sum[:])
+	return hasher.Sum(nil)
+}
+
+// AddressScript 将主网地址（1.../3.../bc1...）解码为输出脚本
+func AddressScript(address string) ([]byte, error) {
+	if strings.HasPrefix(strings.ToLower(address), mainnetHRP+"1") {
+		version, program, err := bech32.DecodeSegwitAddress(mainnetHRP, address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+		}
+		op := byte(0x00)
+		if version > 0 {
+			op = opOne + version - 1
+		}
+		return append([]byte{op, byte(len(program))}, program...), nil
+	}
+
+	payload, err := base58.CheckDecode(address)
+	if err != nil || len(payload) != 21 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	switch payload[0] {
+	case p2pkhVersion:
+		return p2pkhScript(payload[1:]), nil
+	case p2shVersion:
+		return append(append([]byte{opHash160, 0x14}, payload[1:]...), opEqual), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported version byte 0x%02x", ErrInvalidAddress, payload[0])
+	}
+}
+
+func p2pkhScript(pubKeyHash []byte) []byte {
+	script := append([]byte{opDup, opHash160, 0x14}, pubKeyHash...)
+	return append(script, opEqualVerify, opCheckSig)
+}
+
+// ClassifyScript 识别标准输出脚本类型
+func ClassifyScript(script []byte) ScriptType {
+	switch {
+	case len(script) == 25 && script[0] == opDup && script[1] == opHash160 && script[2] == 0x14 &&
+		script[23] == opEqualVerify && script[24] == opCheckSig:
+		return ScriptP2PKH
+	case len(script) == 23 && script[0] == opHash160 && script[1] == 0x14 && script[22] == opEqual:
+		return ScriptP2SH
+	case len(script) == 22 && script[0] == witnessV0 && script[1] == 0x14:
+		return ScriptP2WPKH
+	case len(script) == 34 && script[0] == witnessV0 && script[1] == 0x20:
+		return ScriptP2WSH
+	case len(script) == 34 && script[0] == opOne && script[1] == 0x20:
+		return ScriptP2TR
+	default:
+		return ScriptUnknown
+	}
+}
+
+// 交易各部分的权重（weight units），虚拟大小 vsize = ceil(weight / 4)
+const (
+	txOverheadWeight  = (4 + 4 + 1 + 1) * 4 // version + locktime + 输入/输出个数
+	segwitFlagWeight  = 2                   // marker + flag
+	p2pkhInputWeight  = (32 + 4 + 1 + 107 + 4) * 4
+	p2wpkhInputWeight = (32+4+1+4)*4 + 1 + 1 + 72 + 1 + 33
+)
+
+// inputWeight 花费该类型输出所需的输入权重（按最大 DER 签名估算）
+func inputWeight(script []byte) (int, error) {
+	switch ClassifyScript(script) {
+	case ScriptP2PKH:
+		return p2pkhInputWeight, nil
+	case ScriptP2WPKH:
+		return p2wpkhInputWeight, nil
+	default:
+		return 0, fmt.Errorf("spending %s outputs is not supported", ClassifyScript(script))
+	}
+}
+
+func outputWeight(script []byte) int {
+	return (8 + 1 + len(script)) * 4
+}
+
+// dustThreshold 低于该金额的输出花费成本超过其价值，按 Bitcoin Core 的 3 sat/vB 计算
+func dustThreshold(script []byte) int64 {
+	spend := int64(148)
+	if len(script) > 0 && (script[0] == witnessV0 || script[0] == opOne) {
+		spend = 67
+	}
+	return 3 * (int64(len(script)) + 9 + spend)
+}
+
+func vsize(weight int) int {
+	return (weight + 3) / 4
+}
@@ -0,0 +1,311 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	txVersion     = 2
+	sighashAll    = 0x01
+	rbfSequence   = 0xfffffffd // 允许 RBF 手续费追加（BIP125）
+	finalSequence = 0xfffffffe // 不允许替换，locktime 仍然有效
+)
+
+// Sequence 输入的 nSequence，rbf 为 true 时按 BIP125 声明可替换
+func Sequence(rbf bool) uint32 {
+	if rbf {
+		return rbfSequence
+	}
+	return finalSequence
+}
+
+// SignalsRBF 按 BIP125 判断 nSequence 是否声明可替换
+func SignalsRBF(sequence uint32) bool {
+	return sequence < finalSequence
+}
+
+// TxOut 交易输出
+type TxOut struct {
+	Value  int64
+	Script []byte
+}
+
+// KeyFunc 返回花费指定 UTXO 所需的私钥，调用方负责在签名后清除
+type KeyFunc func(u UTXO) ([]byte, error)
+
+// BuildOutputs 组装接收方和找零输出，找零放在随机位置，避免暴露哪个是找零
+func BuildOutputs(payments []TxOut, changeScript []byte, change int64) ([]TxOut, error) {
+	outputs := append([]TxOut(nil), payments...)
+	if change <= 0 {
+		return outputs, nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(outputs)+1)))
+	if err != nil {
+		return nil, err
+	}
+	i := int(n.Int64())
+	outputs = append(outputs, TxOut{})
+	copy(outputs[i+1:], outputs[i:])
+	outputs[i] = TxOut{Value: change, Script: changeScript}
+	return outputs, nil
+}
+
+type txInput struct {
+	utxo      UTXO
+	sequence  uint32
+	hash      []byte // 前序交易哈希（内部字节序）
+	script    []byte // 前序输出脚本
+	scriptSig []byte
+	witness   [][]byte
+}
+
+// SignTransaction 构造并签名交易，支持 P2PKH 和 P2WPKH 输入，返回原始交易和 txid；
+// rbf 为 true 时所有输入声明可替换，之后可以用 tx.bump 或 tx.cancel 替换
+func SignTransaction(inputs []UTXO, outputs []TxOut, keyFor KeyFunc, rbf bool) ([]byte, string, error) {
+	if len(inputs) == 0 || len(outputs) == 0 {
+		return nil, "", errors.New("transaction needs at least one input and one output")
+	}
+	ins := make([]*txInput, len(inputs))
+	for i, u := range inputs {
+		hash, err := hex.DecodeString(u.TxID)
+		if err != nil || len(hash) != 32 {
+			return nil, "", fmt.Errorf("invalid txid %s", u.TxID)
+		}
+		script, err := hex.DecodeString(u.Script)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid script for %s: %w", u.Outpoint(), err)
+		}
+		ins[i] = &txInput{utxo: u, sequence: Sequence(rbf), hash: reverseBytes(hash), script: script}
+	}
+
+	for i, in := range ins {
+		key, err := keyFor(in.utxo)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", in.utxo.Outpoint(), err)
+		}
+		err = signInput(ins, outputs, i, key)
+		for j := range key {
+			key[j] = 0
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", in.utxo.Outpoint(), err)
+		}
+	}
+
+	raw := serialize(ins, outputs, true)
+	txid := reverseBytes(DoubleSHA256(serialize(ins, outputs, false)))
+	return raw, hex.EncodeToString(txid), nil
+}
+
+func signInput(ins []*txInput, outputs []TxOut, index int, privateKey []byte) error {
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return err
+	}
+	pub := crypto.CompressPubkey(&key.PublicKey)
+	pubKeyHash := Hash160(pub)
+	in := ins[index]
+
+	var digest []byte
+	switch ClassifyScript(in.script) {
+	case ScriptP2PKH:
+		if !bytes.Equal(in.script[3:23], pubKeyHash) {
+			return errors.New("key does not match the output script")
+		}
+		digest = legacySighash(ins, outputs, index)
+	case ScriptP2WPKH:
+		if !bytes.Equal(in.script[2:], pubKeyHash) {
+			return errors.New("key does not match the output script")
+		}
+		digest = witnessV0Sighash(ins, outputs, index, p2pkhScript(pubKeyHash))
+	default:
+		return fmt.Errorf("spending %s outputs is not supported", ClassifyScript(in.script))
+	}
+
+	// 签名结果为 R || S || V，libsecp256k1 生成的 S 已是低位形式（BIP62）
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return err
+	}
+	der := append(DERSignature(sig[:32], sig[32:64]), sighashAll)
+
+	if ClassifyScript(in.script) == ScriptP2WPKH {
+		in.witness = [][]byte{der, pub}
+	} else {
+		in.scriptSig = append(pushData(der), pushData(pub)...)
+	}
+	return nil
+}
+
+// legacySighash 传统签名摘要：当前输入的 scriptSig 替换为前序输出脚本，其余置空
+func legacySighash(ins []*txInput, outputs []TxOut, index int) []byte {
+	var buf bytes.Buffer
+	writeUint32(&buf, txVersion)
+	writeVarInt(&buf, uint64(len(ins)))
+	for i, in := range ins {
+		buf.Write(in.hash)
+		writeUint32(&buf, in.utxo.Vout)
+		if i == index {
+			writeVarBytes(&buf, in.script)
+		} else {
+			writeVarInt(&buf, 0)
+		}
+		writeUint32(&buf, in.sequence)
+	}
+	writeOutputs(&buf, outputs)
+	writeUint32(&buf, 0)
+	writeUint32(&buf, sighashAll)
+	return DoubleSHA256(buf.Bytes())
+}
+
+// witnessV0Sighash 隔离见证 v0 签名摘要（BIP143），签名覆盖输入金额
+func witnessV0Sighash(ins []*txInput, outputs []TxOut, index int, scriptCode []byte) []byte {
+	var prevouts, sequences, outs bytes.Buffer
+	for _, in := range ins {
+		prevouts.Write(in.hash)
+		writeUint32(&prevouts, in.utxo.Vout)
+		writeUint32(&sequences, in.sequence)
+	}
+	// BIP143 的 hashOutputs 不含输出个数
+	for _, out := range outputs {
+		writeOutput(&outs, out)
+	}
+	in := ins[index]
+
+	var buf bytes.Buffer
+	writeUint32(&buf, txVersion)
+	buf.Write(DoubleSHA256(prevouts.Bytes()))
+	buf.Write(DoubleSHA256(sequences.Bytes()))
+	buf.Write(in.hash)
+	writeUint32(&buf, in.utxo.Vout)
+	writeVarBytes(&buf, scriptCode)
+	writeUint64(&buf, uint64(in.utxo.Value))
+	writeUint32(&buf, in.sequence)
+	buf.Write(DoubleSHA256(outs.Bytes()))
+	writeUint32(&buf, 0)
+	writeUint32(&buf, sighashAll)
+	return DoubleSHA256(buf.Bytes())
+}
+
+// serialize 序列化交易，withWitness 为 false 时生成用于计算 txid 的格式
+func serialize(ins []*txInput, outputs []TxOut, withWitness bool) []byte {
+	segwit := false
+	for _, in := range ins {
+		if len(in.witness) > 0 {
+			segwit = true
+		}
+	}
+	withWitness = withWitness && segwit
+
+	var buf bytes.Buffer
+	writeUint32(&buf, txVersion)
+	if withWitness {
+		buf.Write([]byte{0x00, 0x01})
+	}
+	writeVarInt(&buf, uint64(len(ins)))
+	for _, in := range ins {
+		buf.Write(in.hash)
+		writeUint32(&buf, in.utxo.Vout)
+		writeVarBytes(&buf, in.scriptSig)
+		writeUint32(&buf, in.sequence)
+	}
+	writeOutputs(&buf, outputs)
+	if withWitness {
+		for _, in := range ins {
+			writeVarInt(&buf, uint64(len(in.witness)))
+			for _, item := range in.witness {
+				writeVarBytes(&buf, item)
+			}
+		}
+	}
+	writeUint32(&buf, 0) // locktime
+	return buf.Bytes()
+}
+
+func writeOutputs(buf *bytes.Buffer, outputs []TxOut) {
+	writeVarInt(buf, uint64(len(outputs)))
+	for _, out := range outputs {
+		writeOutput(buf, out)
+	}
+}
+
+func writeOutput(buf *bytes.Buffer, out TxOut) {
+	writeUint64(buf, uint64(out.Value))
+	writeVarBytes(buf, out.Script)
+}
+
+// DERSignature 将 R、S 编码为 DER 格式，比特币和 XRP 的签名共用
+func DERSignature(r, s []byte) []byte {
+	encode := func(v []byte) []byte {
+		v = bytes.TrimLeft(v, "\x00")
+		if len(v) == 0 || v[0]&0x80 != 0 {
+			v = append([]byte{0x00}, v...)
+		}
+		return append([]byte{0x02, byte(len(v))}, v...)
+	}
+	body := append(encode(r), encode(s)...)
+	return append([]byte{0x30, byte(len(body))}, body...)
+}
+
+func pushData(data []byte) []byte {
+	return append([]byte{byte(len(data))}, data...)
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeVarInt(buf *bytes.Buffer, v uint64) {
+	switch {
+	case v < 0xfd:
+		buf.WriteByte(byte(v))
+	case v <= 0xffff:
+		buf.WriteByte(0xfd)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(v))
+		buf.Write(b[:])
+	case v <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		writeUint32(buf, uint32(v))
+	default:
+		buf.WriteByte(0xff)
+		writeUint64(buf, v)
+	}
+}
+
+func writeVarBytes(buf *bytes.Buffer, data []byte) {
+	writeVarInt(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+// DoubleSHA256 两次 SHA-256，用于交易哈希和签名摘要
+func DoubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func reverseBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[len(b)-1-i]
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package btc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// UTXOFileName UTXO 缓存在数据目录中的文件名
+const UTXOFileName = "utxos.json"
+
+// UTXOStore 按地址跟踪 UTXO，缓存在本地文件中，离线时也可查看和做币选择
+type UTXOStore struct {
+	mu        sync.Mutex
+	path      string
+	Updated   time.Time         `json:"updated"`
+	Addresses map[string][]UTXO `json:"addresses"`
+	Statuses  map[string]string `json:"statuses,omitempty"` // 地址订阅状态，未变化的地址无需重新查询
+}
+
+// LoadUTXOStore 加载 UTXO 缓存，文件不存在时返回空缓存
+func LoadUTXOStore(path string) (*UTXOStore, error) {
+	store := &UTXOStore{path: path, Addresses: make(map[string][]UTXO)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("解码 UTXO 缓存失败: %w", err)
+	}
+	if store.Addresses == nil {
+		store.Addresses = make(map[string][]UTXO)
+	}
+	return store, nil
+}
+
+// Refresh 从后端重新查询指定地址的 UTXO 并保存，
+// 后端支持地址订阅时只查询状态发生变化的地址
+func (s *UTXOStore) Refresh(ctx context.Context, backend Backend, addresses []string) error {
+	changed := addresses
+	var statuses map[string]string
+	if sub, ok := backend.(AddressBackend); ok {
+		var err error
+		if statuses, err = sub.Subscribe(ctx, addresses); err != nil {
+			return err
+		}
+		changed = nil
+		s.mu.Lock()
+		for _, address := range addresses {
+			_, cached := s.Addresses[address]
+			if !cached || s.Statuses[address] != statuses[address] {
+				changed = append(changed, address)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	var utxos []UTXO
+	if len(changed) > 0 {
+		var err error
+		if utxos, err = backend.ListUnspent(ctx, changed); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, address := range changed {
+		s.Addresses[address] = []UTXO{}
+	}
+	for _, u := range utxos {
+		s.Addresses[u.Address] = append(s.Addresses[u.Address], u)
+	}
+	if statuses != nil {
+		if s.Statuses == nil {
+			s.Statuses = make(map[string]string)
+		}
+		for address, status := range statuses {
+			s.Statuses[address] = status
+		}
+	}
+	s.Updated = time.Now().UTC()
+	return s.save()
+}
+
+// Unspent 返回指定地址的 UTXO，按金额从大到小排序
+func (s *UTXOStore) Unspent(addresses []string) []UTXO {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []UTXO
+	for _, address := range addresses {
+		result = append(result, s.Addresses[address]...)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Value > result[j].Value })
+	return result
+}
+
+// UsedAddresses 返回有 UTXO 或订阅状态显示有历史交易的地址
+func (s *UTXOStore) UsedAddresses(addresses []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var used []string
+	for _, address := range addresses {
+		if len(s.Addresses[address]) > 0 || s.Statuses[address] != "" {
+			used = append(used, address)
+		}
+	}
+	return used
+}
+
+// MarkSpent 广播成功后移除已花费的 UTXO，避免下次重复选择
+func (s *UTXOStore) MarkSpent(spent []UTXO) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outpoints := make(map[string]bool, len(spent))
+	for _, u := range spent {
+		outpoints[u.Outpoint()] = true
+	}
+	for address, utxos := range s.Addresses {
+		kept := utxos[:0]
+		for _, u := range utxos {
+			if !outpoints[u.Outpoint()] {
+				kept = append(kept, u)
+			}
+		}
+		if len(kept) != len(utxos) {
+			// 地址状态已变化，下次刷新时重新查询
+			delete(s.Statuses, address)
+		}
+		s.Addresses[address] = kept
+	}
+	return s.save()
+}
+
+func (s *UTXOStore) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入 UTXO 缓存失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名 UTXO 缓存失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package btc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/bech32"
+)
+
+// wifVersion 主网 WIF 私钥的版本字节
+const wifVersion = 0x80
+
+// ErrInvalidKey 私钥无法解析
+var ErrInvalidKey = errors.New("invalid private key")
+
+// ParseWIF 解析主网 WIF 私钥，只支持压缩公钥格式（签名时总是使用压缩公钥）
+func ParseWIF(wif string) ([]byte, error) {
+	payload, err := base58.CheckDecode(strings.TrimSpace(wif))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(payload) == 0 || payload[0] != wifVersion {
+		return nil, fmt.Errorf("%w: not a mainnet WIF key", ErrInvalidKey)
+	}
+	switch len(payload) {
+	case 34:
+		if payload[33] != 0x01 {
+			return nil, fmt.Errorf("%w: bad compression flag", ErrInvalidKey)
+		}
+		return append([]byte(nil), payload[1:33]...), nil
+	case 33:
+		return nil, fmt.Errorf("%w: uncompressed WIF keys are not supported", ErrInvalidKey)
+	default:
+		return nil, fmt.Errorf("%w: unexpected WIF length %d", ErrInvalidKey, len(payload))
+	}
+}
+
+// ParseHexKey 解析 32 字节的十六进制私钥
+func ParseHexKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%w: expected 32 bytes of hex", ErrInvalidKey)
+	}
+	return key, nil
+}
+
+// KeyAddresses 返回私钥可以花费的主网地址（P2WPKH 和 P2PKH）
+func KeyAddresses(privateKey []byte) ([]string, error) {
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	pubKeyHash := Hash160(crypto.CompressPubkey(&key.PublicKey))
+	segwit, err := bech32.EncodeSegwitAddress(mainnetHRP, 0, pubKeyHash)
+	if err != nil {
+		return nil, err
+	}
+	legacy := base58.CheckEncode(append([]byte{p2pkhVersion}, pubKeyHash...))
+	return []string{segwit, legacy}, nil
+}
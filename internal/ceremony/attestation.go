@@ -0,0 +1,100 @@
+package ceremony
+
+import (
+	"io"
+	"text/template"
+)
+
+// Attestation 证明文件模板的数据，Digest 是最终记录的 SHA-256，与审计日志中的记录对照
+type Attestation struct {
+	*Record
+	Digest    string
+	AuditHash string
+}
+
+// Template 解析证明文件模板，text 为空时使用 DefaultTemplate；funcs 需要提供 date
+func Template(text string, funcs template.FuncMap) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("attestation").Funcs(funcs).Parse(text)
+}
+
+// Render 渲染证明文件，auditHash 为记录在审计日志中的条目哈希
+func Render(w io.Writer, tmpl *template.Template, record *Record, auditHash string) error {
+	return tmpl.Execute(w, Attestation{Record: record, Digest: record.Digest(), AuditHash: auditHash})
+}
+
+// DefaultTemplate 默认证明文件，打印后由全部操作员和保管人签字
+const DefaultTemplate = `SLOWMADE KEY CEREMONY ATTESTATION
+
+Ceremony:           {{.ID}}
+{{- with .Organization}}
+Organization:       {{.}}
+{{- end}}
+{{- with .Purpose}}
+Purpose:            {{.}}
+{{- end}}
+Started:            {{date .StartedAt}}
+Completed:          {{date .CompletedAt}}
+Host:               {{.Host}}
+Software:           slowmade {{.Version}}
+{{- with .BinarySHA256}}
+Binary SHA-256:     {{.}}
+{{- end}}
+Network:            {{if .AirGap.Verified}}no active interfaces (air-gapped){{else}}ACTIVE INTERFACES: {{range $i, $name := .AirGap.Interfaces}}{{if $i}}, {{end}}{{$name}}{{end}}{{end}}
+Entropy:            system RNG{{range .EntropySources}} + {{.}}{{end}}
+
+WALLET
+
+Master fingerprint: {{.MasterFingerprint}}
+{{- if .Cloaked}}
+A cloak passphrase is in use; it is not part of the recovery words or shares.
+{{- end}}
+{{- with .Shares}}
+
+RECOVERY SHARES (any {{.Threshold}} of {{.Total}} recover the wallet, set {{.Set}})
+
+{{- range .Custody}}
+  Share {{.Index}}  fingerprint {{.Fingerprint}}  custodian {{.Custodian}}  confirmed {{date .ConfirmedAt}}
+{{- end}}
+{{- else}}
+
+The recovery words were shown once and written down; they are not split into shares.
+{{- end}}
+
+CHECKPOINTS
+{{range .Checkpoints}}
+  {{.Name}} (code {{printf "%.8s" .Digest}})
+{{- range .Confirmation}}
+    confirmed by {{.Operator}} at {{date .At}}
+{{- end}}
+{{- end}}
+
+STEPS
+{{range .Steps}}
+  {{date .At}}  {{.Note}}
+{{- end}}
+
+RECORD
+
+Record SHA-256:     {{.Digest}}
+{{- with .AuditHash}}
+Audit log entry:    {{.}}
+{{- end}}
+
+The record is appended to the wallet's audit log (action ceremony.record).
+ceremony.show {{.ID}} checks the log's hash chain and prints the SHA-256 to compare.
+
+SIGNATURES
+
+By signing, each person attests that they were present for the whole ceremony,
+that the values above match what was shown on screen, and that they hold no copy
+of the recovery words or of any share other than their own.
+{{range .Operators}}
+Operator:  {{printf "%-30s" .}}  Signature: ______________________  Date: __________
+{{- end}}
+{{- with .Shares}}{{range .Custody}}
+Custodian: {{printf "%-30s" .Custodian}}  Signature: ______________________  Date: __________
+{{- end}}{{end}}
+`
@@ -0,0 +1,210 @@
+// Package ceremony 记录组织的密钥仪式：在离线机器上生成种子、两名以上操作员逐步确认、
+// 助记词按 Shamir 分享交给保管人，最后生成可打印的证明文件和写入审计日志的机器可读记录。
+// 记录中只有指纹、校验和与时间，不包含助记词、分享或密码
+package ceremony
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// 错误定义
+var (
+	ErrNotAirGapped      = errors.New("network interfaces are up, disconnect the machine or pass --allow-network")
+	ErrTooFewOperators   = errors.New("a key ceremony needs at least two operators")
+	ErrDuplicateOperator = errors.New("operator names must be different")
+	ErrNotConfirmed      = errors.New("operator did not confirm")
+)
+
+// AuditAction 仪式记录在审计日志中的操作名
+const AuditAction = "ceremony.record"
+
+// Record 一次密钥仪式的机器可读记录，按 JSON 写入审计日志和输出目录
+type Record struct {
+	ID                string       `json:"id"`
+	Organization      string       `json:"organization,omitempty"`
+	Purpose           string       `json:"purpose,omitempty"`
+	StartedAt         time.Time    `json:"started_at"`
+	CompletedAt       time.Time    `json:"completed_at"`
+	Host              string       `json:"host"`
+	Version           string       `json:"version"`
+	BinarySHA256      string       `json:"binary_sha256,omitempty"`
+	AirGap            AirGap       `json:"air_gap"`
+	Operators         []string     `json:"operators"`
+	EntropySources    []string     `json:"entropy_sources"`
+	MasterFingerprint string       `json:"master_fingerprint"`
+	Cloaked           bool         `json:"cloaked,omitempty"`
+	Shares            *ShareScheme `json:"shares,omitempty"`
+	Checkpoints       []Checkpoint `json:"checkpoints"`
+	Steps             []Step       `json:"steps"`
+}
+
+// AirGap 开始时的网络检查，Interfaces 为启用且有地址的非回环网卡
+type AirGap struct {
+	Verified   bool     `json:"verified"`
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// ShareScheme 助记词分享的方案和每份分享的去向；Fingerprint 是分享文本的 SHA-256 前 8 字节，
+// 保管人日后出示分享时可以核对，但从指纹得不到分享
+type ShareScheme struct {
+	Set       string         `json:"set"`
+	Threshold int            `json:"threshold"`
+	Total     int            `json:"total"`
+	Custody   []ShareCustody `json:"custody"`
+}
+
+// ShareCustody 一份分享交给了谁、何时确认抄写无误
+type ShareCustody struct {
+	Index       int       `json:"index"`
+	Custodian   string    `json:"custodian"`
+	Fingerprint string    `json:"fingerprint"`
+	ConfirmedAt time.Time `json:"confirmed_at"`
+}
+
+// Checkpoint 需要全部操作员确认的节点，Digest 是确认时记录内容的摘要
+type Checkpoint struct {
+	Name         string         `json:"name"`
+	Digest       string         `json:"digest"`
+	Confirmation []Confirmation `json:"confirmations"`
+}
+
+// Confirmation 一名操作员的确认
+type Confirmation struct {
+	Operator string    `json:"operator"`
+	At       time.Time `json:"at"`
+}
+
+// Step 按时间顺序的步骤日志
+type Step struct {
+	At   time.Time `json:"at"`
+	Note string    `json:"note"`
+}
+
+// New 开始一次仪式记录
+func New(organization, purpose, version string) (*Record, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	record := &Record{
+		ID:           hex.EncodeToString(id),
+		Organization: organization,
+		Purpose:      purpose,
+		StartedAt:    time.Now().UTC(),
+		Host:         host,
+		Version:      version,
+	}
+	if path, err := os.Executable(); err == nil {
+		record.BinarySHA256, _ = fileSHA256(path)
+	}
+	return record, nil
+}
+
+// Step 追加一条步骤日志
+func (r *Record) Step(format string, args ...any) {
+	r.Steps = append(r.Steps, Step{At: time.Now().UTC(), Note: fmt.Sprintf(format, args...)})
+}
+
+// AddOperators 登记操作员，至少两名且名字不同
+func (r *Record) AddOperators(names []string) error {
+	seen := make(map[string]bool)
+	var operators []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if seen[strings.ToLower(name)] {
+			return fmt.Errorf("%w: %s", ErrDuplicateOperator, name)
+		}
+		seen[strings.ToLower(name)] = true
+		operators = append(operators, name)
+	}
+	if len(operators) < 2 {
+		return ErrTooFewOperators
+	}
+	r.Operators = operators
+	return nil
+}
+
+// Digest 记录当前内容的 SHA-256（规范 JSON），打印在证明文件上，可与审计日志中的记录对照
+func (r *Record) Digest() string {
+	data, err := canonjson.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Confirm 由 confirm 依次询问每名操作员；任何一人不确认时返回 ErrNotConfirmed。
+// confirm 收到检查点名称、当前摘要的前 8 个字符（操作员抄到纸上）和操作员名字
+func (r *Record) Confirm(name string, confirm func(checkpoint, code, operator string) (bool, error)) error {
+	checkpoint := Checkpoint{Name: name, Digest: r.Digest()}
+	code := checkpoint.Digest[:8]
+	for _, operator := range r.Operators {
+		ok, err := confirm(name, code, operator)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			r.Step("%s declined checkpoint %q", operator, name)
+			return fmt.Errorf("%w: %s at %q", ErrNotConfirmed, operator, name)
+		}
+		checkpoint.Confirmation = append(checkpoint.Confirmation, Confirmation{Operator: operator, At: time.Now().UTC()})
+	}
+	r.Checkpoints = append(r.Checkpoints, checkpoint)
+	r.Step("checkpoint %q confirmed by %s", name, strings.Join(r.Operators, ", "))
+	return nil
+}
+
+// ShareFingerprint 分享文本的指纹
+func ShareFingerprint(share string) string {
+	sum := sha256.Sum256([]byte(share))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CheckAirGap 列出启用且有地址的非回环网卡，没有时视为已离线
+func CheckAirGap() (AirGap, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return AirGap{}, err
+	}
+	var up []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		up = append(up, iface.Name)
+	}
+	return AirGap{Verified: len(up) == 0, Interfaces: up}, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
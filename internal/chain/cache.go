@@ -0,0 +1,145 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// CacheFileName 余额缓存在数据目录中的文件名
+const CacheFileName = "balance_cache.json"
+
+type cacheEntry struct {
+	Balance   string    `json:"balance"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CachedClient 为客户端加上文件缓存：TTL 内直接使用缓存，减少对第三方服务的查询次数；
+// 查询失败时退回过期的缓存。设置 Offline 后保存在加密缓存中，path 为空时只缓存在内存中
+type CachedClient struct {
+	client    ChainClient
+	path      string
+	ttl       time.Duration
+	skipCache bool
+	offline   *OfflineCache
+	symbol    string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachedClient 创建带缓存的客户端
+func NewCachedClient(client ChainClient, path string, ttl time.Duration) *CachedClient {
+	return &CachedClient{client: client, path: path, ttl: ttl}
+}
+
+// SkipCache 忽略未过期的缓存，强制重新查询
+func (c *CachedClient) SkipCache() *CachedClient {
+	c.skipCache = true
+	return c
+}
+
+// Offline 使用加密缓存代替明文文件，按币种和地址保存，换用其他节点或浏览器后仍可显示
+func (c *CachedClient) Offline(cache *OfflineCache, symbol string) *CachedClient {
+	c.offline, c.symbol = cache, symbol
+	return c
+}
+
+func (c *CachedClient) Name() string     { return c.client.Name() }
+func (c *CachedClient) ThirdParty() bool { return c.client.ThirdParty() }
+
+func (c *CachedClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	balance, _, err := c.BalanceAt(ctx, address)
+	return balance, err
+}
+
+// BalanceAt 返回余额及其查询时间，使用过期缓存时同时返回查询错误
+func (c *CachedClient) BalanceAt(ctx context.Context, address string) (*big.Int, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	key := c.client.Name() + "|" + address
+	if c.offline != nil {
+		key = c.symbol + "|" + address
+	}
+	cached, ok := c.lookup(key)
+	if ok && !c.skipCache && time.Since(cached.FetchedAt) < c.ttl {
+		if balance, valid := new(big.Int).SetString(cached.Balance, 10); valid {
+			return balance, cached.FetchedAt, nil
+		}
+	}
+
+	balance, err := c.client.Balance(ctx, address)
+	if err != nil {
+		if stale, valid := new(big.Int).SetString(cached.Balance, 10); ok && valid {
+			return stale, cached.FetchedAt, err
+		}
+		return nil, time.Time{}, err
+	}
+	now := time.Now().UTC()
+	if c.offline != nil {
+		return balance, now, c.offline.Put(SectionBalance, key, balance.String(), now)
+	}
+	c.entries[key] = cacheEntry{Balance: balance.String(), FetchedAt: now}
+	return balance, now, c.save()
+}
+
+// lookup 读取 key 的缓存，使用加密缓存时从中读取
+func (c *CachedClient) lookup(key string) (cacheEntry, bool) {
+	if c.offline == nil {
+		entry, ok := c.entries[key]
+		return entry, ok
+	}
+	var entry cacheEntry
+	fetchedAt, ok := c.offline.Get(SectionBalance, key, &entry.Balance)
+	entry.FetchedAt = fetchedAt
+	return entry, ok
+}
+
+func (c *CachedClient) load() error {
+	if c.entries != nil {
+		return nil
+	}
+	c.entries = make(map[string]cacheEntry)
+	if c.path == "" || c.offline != nil {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return fmt.Errorf("解码余额缓存失败: %w", err)
+	}
+	return nil
+}
+
+func (c *CachedClient) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := canonjson.MarshalIndent(c.entries, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := c.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入余额缓存失败: %w", err)
+	}
+	if err := os.Rename(tempFile, c.path); err != nil {
+		return fmt.Errorf("重命名余额缓存失败: %w", err)
+	}
+	return nil
+}
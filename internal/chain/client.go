@@ -0,0 +1,135 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/cosmos"
+	"github.com/palagend/slowmade/internal/mockchain"
+	"github.com/palagend/slowmade/internal/resilience"
+	"github.com/palagend/slowmade/internal/stellar"
+	"github.com/palagend/slowmade/internal/substrate"
+	"github.com/palagend/slowmade/internal/tron"
+	"github.com/palagend/slowmade/internal/xrp"
+)
+
+// 错误定义
+var (
+	ErrNotConfigured  = errors.New("no chain backend configured for this coin")
+	ErrUnknownBackend = errors.New("unknown chain backend")
+	ErrRateLimited    = errors.New("rate limited by explorer")
+)
+
+// ChainClient 查询链上数据的客户端，金额均为最小单位（聪、wei、lamports）
+type ChainClient interface {
+	Name() string
+	// ThirdParty 为 true 表示查询会把地址和 IP 暴露给第三方服务
+	ThirdParty() bool
+	Balance(ctx context.Context, address string) (*big.Int, error)
+}
+
+// NewClient 根据币种的后端配置创建客户端
+func NewClient(symbol string, cfg config.ExplorerBackendConfig) (ChainClient, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, fmt.Errorf("%w: %s", ErrNotConfigured, symbol)
+	case "blockstream", "esplora":
+		return NewEsploraClient(cfg.URL), nil
+	case "etherscan":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("explorer.coins.%s.api_key is required for etherscan", strings.ToLower(symbol))
+		}
+		return NewEtherscanClient(cfg.URL, cfg.APIKey), nil
+	case "solscan":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("explorer.coins.%s.api_key is required for solscan", strings.ToLower(symbol))
+		}
+		return NewSolscanClient(cfg.URL, cfg.APIKey), nil
+	case "blockchair":
+		return NewBlockchairClient(symbol, cfg.URL, cfg.APIKey)
+	case "trongrid":
+		return tron.NewGrid(cfg.URL, cfg.APIKey), nil
+	case "cosmos":
+		return newCosmosClient(symbol, cfg.URL)
+	case "substrate":
+		if cfg.URL == "" {
+			appConfig := config.GetAppConfig()
+			network, ok := appConfig.GetSubstrateConfig(symbol)
+			if !ok {
+				return nil, fmt.Errorf("%w: substrate.%s.rpc_url", ErrNotConfigured, strings.ToLower(symbol))
+			}
+			cfg.URL = network.RPCURL
+		}
+		return substrate.NewClient(cfg.URL), nil
+	case "horizon":
+		if cfg.URL == "" {
+			appConfig := config.GetAppConfig()
+			cfg.URL = appConfig.GetStellarConfig().HorizonURL
+		}
+		return stellar.NewHorizon(cfg.URL), nil
+	case "rippled":
+		if cfg.URL == "" {
+			appConfig := config.GetAppConfig()
+			cfg.URL = appConfig.GetXRPConfig().RPCURL
+		}
+		return xrp.NewClient(cfg.URL), nil
+	case mockchain.BackendName:
+		return NewMockClient(symbol, mockchain.Default()), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// newCosmosClient 使用 [cosmos.chains] 中显示单位为 symbol 的链，url 非空时替换其 LCD 地址
+func newCosmosClient(symbol, url string) (ChainClient, error) {
+	appConfig := config.GetAppConfig()
+	cosmosConfig := appConfig.GetCosmosConfig()
+	for _, name := range cosmosConfig.Names() {
+		chain := cosmosConfig.Chains[name]
+		if !strings.EqualFold(chain.Symbol, symbol) {
+			continue
+		}
+		if url == "" {
+			url = chain.LCDURL
+		}
+		return cosmos.NewLCD(url, chain.HRP, chain.Denom), nil
+	}
+	return nil, fmt.Errorf("%w: no [cosmos.chains] entry with symbol %s", ErrNotConfigured, symbol)
+}
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// getJSON 发送 GET 请求并解码 JSON 响应
+func getJSON(ctx context.Context, url string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/base58"
+)
+
+// unstoppableTLDs Unstoppable Domains 的顶级域名
+var unstoppableTLDs = []string{
+	"crypto", "x", "nft", "wallet", "blockchain", "bitcoin", "dao", "888", "zil",
+	"polygon", "unstoppable", "klever", "hi", "kresus", "anime", "manga", "binanceus",
+	"go", "pudgy", "austin", "bitget", "pog", "clay", "witg", "ubu", "raiin", "fun",
+}
+
+// UnstoppableResolver 通过 Unstoppable Domains 的 Resolution API 查询 crypto.<币种>.address 记录
+type UnstoppableResolver struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewUnstoppableResolver 创建 Unstoppable Domains 解析器，baseURL 为空时使用官方 API
+func NewUnstoppableResolver(baseURL, apiKey string) *UnstoppableResolver {
+	if baseURL == "" {
+		baseURL = "https://api.unstoppabledomains.com"
+	}
+	return &UnstoppableResolver{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+func (r *UnstoppableResolver) Name() string     { return "Unstoppable Domains" }
+func (r *UnstoppableResolver) ThirdParty() bool { return true }
+
+func (r *UnstoppableResolver) Supports(name, coin string) bool {
+	tld := name[strings.LastIndex(name, ".")+1:]
+	for _, candidate := range unstoppableTLDs {
+		if tld == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UnstoppableResolver) Resolve(ctx context.Context, name, coin string) (string, error) {
+	if r.apiKey == "" {
+		return "", fmt.Errorf("%w: names.unstoppable_api_key is required", ErrNotConfigured)
+	}
+	var result struct {
+		Records map[string]string `json:"records"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + r.apiKey}
+	if err := getJSON(ctx, r.baseURL+"/resolve/domains/"+url.PathEscape(name), headers, &result); err != nil {
+		return "", err
+	}
+	address := result.Records["crypto."+coin+".address"]
+	if address == "" {
+		return "", ErrNameNotFound
+	}
+	return address, nil
+}
+
+// SNSResolver 通过 Bonfida 的 SNS 代理解析 .sol 名称的所有者地址
+type SNSResolver struct {
+	baseURL string
+}
+
+// NewSNSResolver 创建 SNS 解析器，baseURL 为空时使用 Bonfida 的公共代理
+func NewSNSResolver(baseURL string) *SNSResolver {
+	if baseURL == "" {
+		baseURL = "https://sns-sdk-proxy.bonfida.workers.dev"
+	}
+	return &SNSResolver{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (r *SNSResolver) Name() string     { return "SNS" }
+func (r *SNSResolver) ThirdParty() bool { return isThirdParty(r.baseURL) }
+
+func (r *SNSResolver) Supports(name, coin string) bool {
+	return coin == "SOL" && strings.HasSuffix(name, ".sol")
+}
+
+func (r *SNSResolver) Resolve(ctx context.Context, name, coin string) (string, error) {
+	var result struct {
+		Status string `json:"s"`
+		Result string `json:"result"`
+	}
+	domain := strings.TrimSuffix(name, ".sol")
+	if err := getJSON(ctx, r.baseURL+"/resolve/"+url.PathEscape(domain), nil, &result); err != nil {
+		return "", err
+	}
+	if result.Status != "ok" {
+		return "", fmt.Errorf("%w: %s", ErrNameNotFound, result.Result)
+	}
+	// 代理返回的是所有者公钥，确认是合法的 Solana 地址
+	if key, err := base58.Decode(result.Result); err != nil || len(key) != 32 {
+		return "", fmt.Errorf("sns: invalid address %q", result.Result)
+	}
+	return result.Result, nil
+}
@@ -0,0 +1,206 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/palagend/slowmade/internal/config"
+)
+
+// ensRegistry ENS 注册表合约，主网和测试网地址相同
+const ensRegistry = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+
+var (
+	selectorResolver = crypto.Keccak256([]byte("resolver(bytes32)"))[:4]
+	selectorAddr     = crypto.Keccak256([]byte("addr(bytes32)"))[:4]
+	selectorName     = crypto.Keccak256([]byte("name(bytes32)"))[:4]
+)
+
+// ethCaller 能执行只读合约调用的以太坊客户端
+type ethCaller interface {
+	ChainClient
+	Call(ctx context.Context, to string, data []byte) ([]byte, error)
+}
+
+// ENSResolver 通过以太坊节点或 Etherscan 的 eth_call 查询 ENS 注册表，只解析 ETH 地址
+type ENSResolver struct {
+	caller ethCaller
+	err    error // 没有可用的以太坊后端时的原因
+}
+
+// NewENSResolver 使用 ETH 的节点或浏览器后端（见 ForCoin）创建 ENS 解析器
+func NewENSResolver(appConfig config.AppConfig) *ENSResolver {
+	client, err := ForCoin("ETH", appConfig)
+	if err != nil {
+		return &ENSResolver{err: err}
+	}
+	caller, ok := client.(ethCaller)
+	if !ok {
+		return &ENSResolver{err: fmt.Errorf("%w: ENS needs an rpc.eth node or the etherscan backend, not %s", ErrNotConfigured, client.Name())}
+	}
+	return &ENSResolver{caller: caller}
+}
+
+func (r *ENSResolver) Name() string { return "ENS" }
+
+func (r *ENSResolver) ThirdParty() bool { return r.caller == nil || r.caller.ThirdParty() }
+
+func (r *ENSResolver) Supports(name, coin string) bool {
+	return coin == "ETH" && strings.HasSuffix(name, ".eth")
+}
+
+func (r *ENSResolver) Resolve(ctx context.Context, name, coin string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	node, err := namehash(name)
+	if err != nil {
+		return "", err
+	}
+	resolver, err := r.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	out, err := r.caller.Call(ctx, resolver, append(append([]byte{}, selectorAddr...), node...))
+	if err != nil {
+		return "", err
+	}
+	if len(out) < 32 || new(big.Int).SetBytes(out[:32]).Sign() == 0 {
+		return "", ErrNameNotFound
+	}
+	return common.BytesToAddress(out[12:32]).Hex(), nil
+}
+
+// Lookup 查询地址的反向记录（<地址>.addr.reverse），并确认该名称正向解析回同一地址，
+// 否则任何人都可以把自己的地址反向指向别人的名称
+func (r *ENSResolver) Lookup(ctx context.Context, coin, address string) (string, error) {
+	if coin != "ETH" || !common.IsHexAddress(address) {
+		return "", ErrNameNotFound
+	}
+	if r.err != nil {
+		return "", r.err
+	}
+	node, err := namehash(strings.ToLower(strings.TrimPrefix(common.HexToAddress(address).Hex(), "0x")) + ".addr.reverse")
+	if err != nil {
+		return "", err
+	}
+	resolver, err := r.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	out, err := r.caller.Call(ctx, resolver, append(append([]byte{}, selectorName...), node...))
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeABIString(out)
+	if err != nil || name == "" {
+		return "", ErrNameNotFound
+	}
+	forward, err := r.Resolve(ctx, strings.ToLower(name), coin)
+	if err != nil || !strings.EqualFold(forward, address) {
+		return "", ErrNameNotFound
+	}
+	return name, nil
+}
+
+// resolver 从注册表查询节点的解析器合约，未设置时返回 ErrNameNotFound
+func (r *ENSResolver) resolver(ctx context.Context, node []byte) (string, error) {
+	out, err := r.caller.Call(ctx, ensRegistry, append(append([]byte{}, selectorResolver...), node...))
+	if err != nil {
+		return "", err
+	}
+	if len(out) < 32 || new(big.Int).SetBytes(out[:32]).Sign() == 0 {
+		return "", ErrNameNotFound
+	}
+	return common.BytesToAddress(out[12:32]).Hex(), nil
+}
+
+// namehash ENS 名称哈希（EIP-137）。完整的 ENSIP-15 规范化需要 Unicode 映射表，
+// 这里只接受小写 ASCII 名称，其他名称请直接使用地址
+func namehash(name string) ([]byte, error) {
+	node := make([]byte, 32)
+	if name == "" {
+		return node, nil
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		if label == "" {
+			return nil, fmt.Errorf("invalid ENS name %q: empty label", name)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return nil, fmt.Errorf("ENS name %q: only lowercase ASCII names are supported, use the address", name)
+			}
+		}
+		node = crypto.Keccak256(node, crypto.Keccak256([]byte(label)))
+	}
+	return node, nil
+}
+
+// decodeABIString 解码 ABI 编码的单个 string 返回值
+func decodeABIString(out []byte) (string, error) {
+	if len(out) < 64 {
+		return "", fmt.Errorf("short ABI string")
+	}
+	offset := new(big.Int).SetBytes(out[:32])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+		return "", fmt.Errorf("invalid ABI string offset")
+	}
+	start := offset.Int64()
+	length := new(big.Int).SetBytes(out[start : start+32])
+	if !length.IsInt64() || start+32+length.Int64() > int64(len(out)) {
+		return "", fmt.Errorf("invalid ABI string length")
+	}
+	return string(bytes.TrimRight(out[start+32:start+32+length.Int64()], "\x00")), nil
+}
+
+// Call 执行只读合约调用 eth_call
+func (c *EthereumNodeClient) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	var result string
+	call := map[string]string{"to": to, "data": "0x" + hex.EncodeToString(data)}
+	if err := c.rpc.call(ctx, "eth_call", []interface{}{call, "latest"}, &result); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+// Call 通过 Etherscan 的 proxy 模块执行 eth_call
+func (c *EtherscanClient) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	query := url.Values{
+		"module": {"proxy"},
+		"action": {"eth_call"},
+		"to":     {to},
+		"data":   {"0x" + hex.EncodeToString(data)},
+		"tag":    {"latest"},
+		"apikey": {c.apiKey},
+	}
+	// 成功时是 JSON-RPC 格式，出错时是 status/message/result 格式
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := getJSON(ctx, c.baseURL+"?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var text string
+	if err := json.Unmarshal(result.Result, &text); err != nil || !strings.HasPrefix(text, "0x") {
+		if strings.Contains(strings.ToLower(string(result.Result)), "rate limit") {
+			return nil, ErrRateLimited
+		}
+		return nil, fmt.Errorf("etherscan: eth_call failed: %s", result.Result)
+	}
+	return hex.DecodeString(strings.TrimPrefix(text, "0x"))
+}
@@ -0,0 +1,190 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// EsploraClient Blockstream Esplora 接口（blockstream.info、mempool.space 等）
+type EsploraClient struct {
+	baseURL string
+}
+
+// NewEsploraClient 创建 Esplora 客户端，baseURL 为空时使用 blockstream.info
+func NewEsploraClient(baseURL string) *EsploraClient {
+	if baseURL == "" {
+		baseURL = "https://blockstream.info/api"
+	}
+	return &EsploraClient{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (c *EsploraClient) Name() string     { return "esplora:" + c.baseURL }
+func (c *EsploraClient) ThirdParty() bool { return true }
+
+// Balance 返回已确认余额加内存池中的变化
+func (c *EsploraClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	type stats struct {
+		Funded int64 `json:"funded_txo_sum"`
+		Spent  int64 `json:"spent_txo_sum"`
+	}
+	var result struct {
+		ChainStats   stats `json:"chain_stats"`
+		MempoolStats stats `json:"mempool_stats"`
+	}
+	if err := getJSON(ctx, c.baseURL+"/address/"+url.PathEscape(address), nil, &result); err != nil {
+		return nil, err
+	}
+	sats := result.ChainStats.Funded - result.ChainStats.Spent + result.MempoolStats.Funded - result.MempoolStats.Spent
+	return big.NewInt(sats), nil
+}
+
+// EtherscanClient Etherscan 兼容接口（Etherscan、BscScan、Polygonscan 等）
+type EtherscanClient struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewEtherscanClient 创建 Etherscan 兼容客户端，baseURL 为空时使用以太坊主网
+func NewEtherscanClient(baseURL, apiKey string) *EtherscanClient {
+	if baseURL == "" {
+		baseURL = "https://api.etherscan.io/api"
+	}
+	return &EtherscanClient{baseURL: baseURL, apiKey: apiKey}
+}
+
+func (c *EtherscanClient) Name() string     { return "etherscan:" + c.baseURL }
+func (c *EtherscanClient) ThirdParty() bool { return true }
+
+func (c *EtherscanClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	query := url.Values{
+		"module":  {"account"},
+		"action":  {"balance"},
+		"address": {address},
+		"tag":     {"latest"},
+		"apikey":  {c.apiKey},
+	}
+	var result struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Result  string `json:"result"`
+	}
+	if err := getJSON(ctx, c.baseURL+"?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	// 出错时 result 字段为错误说明
+	if result.Status != "1" {
+		if strings.Contains(strings.ToLower(result.Result), "rate limit") {
+			return nil, ErrRateLimited
+		}
+		return nil, fmt.Errorf("etherscan: %s: %s", result.Message, result.Result)
+	}
+	wei, ok := new(big.Int).SetString(result.Result, 10)
+	if !ok {
+		return nil, fmt.Errorf("etherscan: invalid balance %q", result.Result)
+	}
+	return wei, nil
+}
+
+// SolscanClient Solscan Pro API
+type SolscanClient struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewSolscanClient 创建 Solscan 客户端，baseURL 为空时使用 Pro API v2
+func NewSolscanClient(baseURL, apiKey string) *SolscanClient {
+	if baseURL == "" {
+		baseURL = "https://pro-api.solscan.io/v2.0"
+	}
+	return &SolscanClient{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+func (c *SolscanClient) Name() string     { return "solscan:" + c.baseURL }
+func (c *SolscanClient) ThirdParty() bool { return true }
+
+func (c *SolscanClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Lamports uint64 `json:"lamports"`
+		} `json:"data"`
+		Errors struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	endpoint := c.baseURL + "/account/detail?address=" + url.QueryEscape(address)
+	if err := getJSON(ctx, endpoint, map[string]string{"token": c.apiKey}, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("solscan: %s", result.Errors.Message)
+	}
+	return new(big.Int).SetUint64(result.Data.Lamports), nil
+}
+
+// blockchairChains Blockchair 支持的币种在 API 路径中的名称
+var blockchairChains = map[string]string{
+	"BTC":  "bitcoin",
+	"LTC":  "litecoin",
+	"DOGE": "dogecoin",
+	"BCH":  "bitcoin-cash",
+}
+
+// BlockchairClient Blockchair 接口，支持比特币及其分叉币
+type BlockchairClient struct {
+	baseURL string
+	chain   string
+	apiKey  string
+}
+
+// NewBlockchairClient 创建 Blockchair 客户端，baseURL 为空时使用公共 API，apiKey 可选
+func NewBlockchairClient(symbol, baseURL, apiKey string) (*BlockchairClient, error) {
+	chain, ok := blockchairChains[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("blockchair does not support %s", symbol)
+	}
+	if baseURL == "" {
+		baseURL = "https://api.blockchair.com"
+	}
+	return &BlockchairClient{baseURL: strings.TrimRight(baseURL, "/"), chain: chain, apiKey: apiKey}, nil
+}
+
+func (c *BlockchairClient) Name() string     { return "blockchair:" + c.chain }
+func (c *BlockchairClient) ThirdParty() bool { return true }
+
+// Balance 返回地址余额（含未确认），单位为聪
+func (c *BlockchairClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var result struct {
+		Data map[string]struct {
+			Address struct {
+				Balance json.Number `json:"balance"`
+			} `json:"address"`
+		} `json:"data"`
+		Context struct {
+			Error string `json:"error"`
+		} `json:"context"`
+	}
+	endpoint := c.baseURL + "/" + c.chain + "/dashboards/address/" + url.PathEscape(address)
+	if c.apiKey != "" {
+		endpoint += "?key=" + url.QueryEscape(c.apiKey)
+	}
+	if err := getJSON(ctx, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Context.Error != "" {
+		return nil, fmt.Errorf("blockchair: %s", result.Context.Error)
+	}
+	// 结果以查询的地址为键，BCH 地址可能去掉了前缀
+	for _, entry := range result.Data {
+		balance, ok := new(big.Int).SetString(entry.Address.Balance.String(), 10)
+		if !ok {
+			return nil, fmt.Errorf("blockchair: invalid balance %q", entry.Address.Balance)
+		}
+		return balance, nil
+	}
+	return nil, fmt.Errorf("blockchair: no data for %s", address)
+}
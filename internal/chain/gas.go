@@ -0,0 +1,115 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// GasPricer 能查询当前 gas 价格的以太坊客户端
+type GasPricer interface {
+	ChainClient
+	GasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// GasPrice 节点建议的 gas 价格（wei），即 eth_gasPrice
+func (c *EthereumNodeClient) GasPrice(ctx context.Context) (*big.Int, error) {
+	var result string
+	if err := c.rpc.call(ctx, "eth_gasPrice", []interface{}{}, &result); err != nil {
+		return nil, err
+	}
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("eth_gasPrice: invalid gas price %q", result)
+	}
+	return wei, nil
+}
+
+// TxPreparer 能补全交易 nonce、gas 和链 ID 的以太坊节点；使用 Etherscan 时需要手动指定
+type TxPreparer interface {
+	GasPricer
+	Nonce(ctx context.Context, address string) (uint64, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, from, to string, data []byte) (uint64, error)
+}
+
+// Nonce 地址的下一个 nonce，包含节点内存池中的交易
+func (c *EthereumNodeClient) Nonce(ctx context.Context, address string) (uint64, error) {
+	n, err := c.quantity(ctx, "eth_getTransactionCount", address, "pending")
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("eth_getTransactionCount: nonce out of range")
+	}
+	return n.Uint64(), nil
+}
+
+// ChainID 节点所在链的 ID
+func (c *EthereumNodeClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.quantity(ctx, "eth_chainId")
+}
+
+// EstimateGas 估算合约调用的 gas 用量
+func (c *EthereumNodeClient) EstimateGas(ctx context.Context, from, to string, data []byte) (uint64, error) {
+	call := map[string]string{"from": from, "to": to, "data": "0x" + hex.EncodeToString(data)}
+	n, err := c.quantity(ctx, "eth_estimateGas", call)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("eth_estimateGas: gas out of range")
+	}
+	return n.Uint64(), nil
+}
+
+// quantity 调用返回十六进制数量的方法
+func (c *EthereumNodeClient) quantity(ctx context.Context, method string, params ...interface{}) (*big.Int, error) {
+	var result string
+	if params == nil {
+		params = []interface{}{}
+	}
+	if err := c.rpc.call(ctx, method, params, &result); err != nil {
+		return nil, err
+	}
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid result %q", method, result)
+	}
+	return n, nil
+}
+
+// GasPrice 模拟链的 gas 价格：下一个区块的费率按 gwei 计
+func (c *MockClient) GasPrice(ctx context.Context) (*big.Int, error) {
+	rate, err := c.chain.FeeRate(1)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mul(big.NewInt(rate), big.NewInt(1e9)), nil
+}
+
+// Code 地址上部署的合约字节码，外部账户和未部署的地址为空
+func (c *EthereumNodeClient) Code(ctx context.Context, address string) ([]byte, error) {
+	var result string
+	if err := c.rpc.call(ctx, "eth_getCode", []interface{}{address, "latest"}, &result); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+// TxSender 能广播已签名交易的以太坊节点
+type TxSender interface {
+	ChainClient
+	SendRawTransaction(ctx context.Context, raw []byte) (string, error)
+}
+
+// SendRawTransaction 广播已签名的交易，返回交易哈希
+func (c *EthereumNodeClient) SendRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	var hash string
+	if err := c.rpc.call(ctx, "eth_sendRawTransaction", []interface{}{"0x" + hex.EncodeToString(raw)}, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
@@ -0,0 +1,48 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/mockchain"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// MockClient 查询内置模拟链，不访问网络；余额包含未确认的变化
+type MockClient struct {
+	symbol string
+	chain  *mockchain.Chain
+}
+
+// NewMockClient 创建模拟链客户端
+func NewMockClient(symbol string, chain *mockchain.Chain) *MockClient {
+	return &MockClient{symbol: strings.ToUpper(symbol), chain: chain}
+}
+
+func (c *MockClient) Name() string     { return mockchain.BackendName }
+func (c *MockClient) ThirdParty() bool { return false }
+
+// Balance BTC 按输出脚本统计 UTXO，账户类币种按地址记账
+func (c *MockClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	if c.symbol == "BTC" {
+		balances, err := btc.NewMockBackend(c.chain).Balances(ctx, []string{address})
+		if err != nil {
+			return nil, err
+		}
+		balance := balances[address]
+		return big.NewInt(balance.Confirmed + balance.Unconfirmed), nil
+	}
+	info, ok := coin.LookupSymbol(c.symbol)
+	if !ok {
+		return nil, fmt.Errorf("unknown coin %s", c.symbol)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(info.Decimal)), nil)
+	confirmed, pending, err := c.chain.Balance(c.symbol, address, unit)
+	if err != nil {
+		return nil, err
+	}
+	return confirmed.Add(confirmed, pending), nil
+}
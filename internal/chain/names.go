@@ -0,0 +1,192 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// NamesCacheFileName 名称解析缓存在数据目录中的文件名
+const NamesCacheFileName = "names_cache.json"
+
+// 名称解析错误
+var (
+	ErrNameNotFound  = errors.New("name has no address for this coin")
+	ErrNoNameService = errors.New("no name service handles this name")
+)
+
+// NameResolver 把可读名称解析为地址的服务（ENS、Unstoppable Domains、SNS）
+type NameResolver interface {
+	Name() string
+	// ThirdParty 为 true 表示查询会把名称和 IP 暴露给第三方服务
+	ThirdParty() bool
+	// Supports 是否能为 coin 解析 name，按顶级域名判断
+	Supports(name, coin string) bool
+	Resolve(ctx context.Context, name, coin string) (string, error)
+}
+
+// ReverseResolver 支持从地址反查主名称的服务，返回的名称已经正向解析验证
+type ReverseResolver interface {
+	Lookup(ctx context.Context, coin, address string) (string, error)
+}
+
+// LooksLikeName 参数是否是需要解析的名称：各币种的地址都不含点号
+func LooksLikeName(s string) bool {
+	return strings.Contains(s, ".") && !strings.ContainsAny(s, " :/")
+}
+
+// Resolution 一次名称解析的结果
+type Resolution struct {
+	Name       string
+	Coin       string
+	Address    string
+	Resolver   string
+	ThirdParty bool
+	Cached     bool
+	FetchedAt  time.Time
+}
+
+type nameEntry struct {
+	Value     string    `json:"value"`
+	Resolver  string    `json:"resolver"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// NameService 按顺序选择第一个支持该名称的解析服务，结果在 TTL 内缓存；解析失败不缓存
+type NameService struct {
+	resolvers []NameResolver
+	path      string
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	entries map[string]nameEntry
+}
+
+// NewNameService 按配置创建 ENS、Unstoppable Domains 和 SNS 解析服务，缓存写入 path
+func NewNameService(appConfig config.AppConfig, path string) *NameService {
+	namesConfig := appConfig.GetNamesConfig()
+	return &NameService{
+		resolvers: []NameResolver{
+			NewENSResolver(appConfig),
+			NewSNSResolver(namesConfig.SNSURL),
+			NewUnstoppableResolver(namesConfig.UnstoppableURL, namesConfig.UnstoppableAPIKey),
+		},
+		path: path,
+		ttl:  time.Duration(namesConfig.CacheTTL) * time.Second,
+	}
+}
+
+// Resolve 解析名称，名称不区分大小写
+func (s *NameService) Resolve(ctx context.Context, name, coin string) (*Resolution, error) {
+	name, coin = strings.ToLower(strings.TrimSuffix(name, ".")), strings.ToUpper(coin)
+	var resolver NameResolver
+	for _, candidate := range s.resolvers {
+		if candidate.Supports(name, coin) {
+			resolver = candidate
+			break
+		}
+	}
+	if resolver == nil {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrNoNameService, name, coin)
+	}
+	result := &Resolution{Name: name, Coin: coin, Resolver: resolver.Name(), ThirdParty: resolver.ThirdParty()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := resolver.Name() + "|" + coin + "|" + name
+	if entry, ok := s.cached(key); ok {
+		result.Address, result.Cached, result.FetchedAt = entry.Value, true, entry.FetchedAt
+		return result, nil
+	}
+	address, err := resolver.Resolve(ctx, name, coin)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resolver.Name(), err)
+	}
+	result.Address, result.FetchedAt = address, time.Now().UTC()
+	s.entries[key] = nameEntry{Value: address, Resolver: resolver.Name(), FetchedAt: result.FetchedAt}
+	return result, s.save()
+}
+
+// Lookup 反查地址的主名称，没有服务支持反查或没有设置主名称时返回空字符串
+func (s *NameService) Lookup(ctx context.Context, coin, address string) (string, error) {
+	coin = strings.ToUpper(coin)
+	for _, resolver := range s.resolvers {
+		reverse, ok := resolver.(ReverseResolver)
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		key := resolver.Name() + "|" + coin + "|reverse|" + strings.ToLower(address)
+		entry, ok := s.cached(key)
+		s.mu.Unlock()
+		if ok {
+			return entry.Value, nil
+		}
+		name, err := reverse.Lookup(ctx, coin, address)
+		if errors.Is(err, ErrNameNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", resolver.Name(), err)
+		}
+		s.mu.Lock()
+		s.entries[key] = nameEntry{Value: name, Resolver: resolver.Name(), FetchedAt: time.Now().UTC()}
+		err = s.save()
+		s.mu.Unlock()
+		return name, err
+	}
+	return "", nil
+}
+
+// cached 返回未过期的缓存，调用方持有锁
+func (s *NameService) cached(key string) (nameEntry, bool) {
+	if err := s.load(); err != nil {
+		return nameEntry{}, false
+	}
+	entry, ok := s.entries[key]
+	if !ok || time.Since(entry.FetchedAt) >= s.ttl {
+		return nameEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *NameService) load() error {
+	if s.entries != nil {
+		return nil
+	}
+	s.entries = make(map[string]nameEntry)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return fmt.Errorf("解码名称缓存失败: %w", err)
+	}
+	return nil
+}
+
+func (s *NameService) save() error {
+	data, err := canonjson.MarshalIndent(s.entries, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入名称缓存失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名名称缓存失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/nft"
+)
+
+// NFTSource 能列出地址的 NFT 转账记录并执行合约调用的以太坊后端
+type NFTSource interface {
+	ethCaller
+	NFTTransfers(ctx context.Context, owner string) ([]nft.Transfer, error)
+}
+
+// NewNFTSource 使用 ETH 的节点或浏览器后端（见 ForCoin）
+func NewNFTSource(appConfig config.AppConfig) (NFTSource, error) {
+	client, err := ForCoin("ETH", appConfig)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := client.(NFTSource)
+	if !ok {
+		return nil, fmt.Errorf("%w: NFTs need an rpc.eth node or the etherscan backend, not %s", ErrNotConfigured, client.Name())
+	}
+	return source, nil
+}
+
+// 转账事件的 topic0
+var (
+	topicTransfer       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	topicTransferSingle = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	topicTransferBatch  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// NFTTransfers 用 eth_getLogs 查询转入 owner 的 ERC-721 和 ERC-1155 转账事件，从 nft.from_block 开始；
+// 只需要转入记录来找候选代币，当前持有量由合约调用核对
+func (c *EthereumNodeClient) NFTTransfers(ctx context.Context, owner string) ([]nft.Transfer, error) {
+	appConfig := config.GetAppConfig()
+	fromBlock := fmt.Sprintf("0x%x", appConfig.GetNFTConfig().FromBlock)
+	ownerTopic := common.BytesToHash(common.HexToAddress(owner).Bytes()).Hex()
+	type logEntry struct {
+		Address string   `json:"address"`
+		Topics  []string `json:"topics"`
+		Data    string   `json:"data"`
+	}
+	query := func(topics []interface{}) ([]logEntry, error) {
+		filter := map[string]interface{}{"fromBlock": fromBlock, "toBlock": "latest", "topics": topics}
+		var logs []logEntry
+		if err := c.rpc.call(ctx, "eth_getLogs", []interface{}{filter}, &logs); err != nil {
+			return nil, fmt.Errorf("eth_getLogs: %w", err)
+		}
+		return logs, nil
+	}
+
+	var transfers []nft.Transfer
+	// ERC-20 的 Transfer 事件签名相同，但 tokenId 不是 indexed，只有 3 个 topic
+	logs, err := query([]interface{}{topicTransfer.Hex(), nil, ownerTopic})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range logs {
+		if len(entry.Topics) != 4 {
+			continue
+		}
+		transfers = append(transfers, nft.Transfer{Contract: common.HexToAddress(entry.Address).Hex(), Standard: nft.ERC721,
+			TokenID: new(big.Int).SetBytes(common.FromHex(entry.Topics[3])), Amount: big.NewInt(1),
+			From: topicAddress(entry.Topics[1]), To: topicAddress(entry.Topics[2])})
+	}
+
+	for _, topic := range []common.Hash{topicTransferSingle, topicTransferBatch} {
+		logs, err := query([]interface{}{topic.Hex(), nil, nil, ownerTopic})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range logs {
+			if len(entry.Topics) != 4 {
+				continue
+			}
+			ids, amounts, err := decodeTransferData(topic, common.FromHex(entry.Data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", entry.Address, err)
+			}
+			for i := range ids {
+				transfers = append(transfers, nft.Transfer{Contract: common.HexToAddress(entry.Address).Hex(), Standard: nft.ERC1155, TokenID: ids[i], Amount: amounts[i],
+					From: topicAddress(entry.Topics[2]), To: topicAddress(entry.Topics[3])})
+			}
+		}
+	}
+	return transfers, nil
+}
+
+// decodeTransferData 解码 TransferSingle（id, value）或 TransferBatch（uint256[] ids, uint256[] values）的数据
+func decodeTransferData(topic common.Hash, data []byte) ([]*big.Int, []*big.Int, error) {
+	if topic == topicTransferSingle {
+		if len(data) < 64 {
+			return nil, nil, fmt.Errorf("short TransferSingle data")
+		}
+		return []*big.Int{new(big.Int).SetBytes(data[:32])}, []*big.Int{new(big.Int).SetBytes(data[32:64])}, nil
+	}
+	array := func(head int) ([]*big.Int, error) {
+		if len(data) < head+32 {
+			return nil, fmt.Errorf("short TransferBatch data")
+		}
+		offset := new(big.Int).SetBytes(data[head : head+32])
+		if !offset.IsInt64() || offset.Int64()+32 > int64(len(data)) {
+			return nil, fmt.Errorf("invalid TransferBatch array offset")
+		}
+		start := int(offset.Int64())
+		length := new(big.Int).SetBytes(data[start : start+32])
+		if !length.IsInt64() || int64(start+32)+length.Int64()*32 > int64(len(data)) {
+			return nil, fmt.Errorf("invalid TransferBatch array length")
+		}
+		values := make([]*big.Int, length.Int64())
+		for i := range values {
+			values[i] = new(big.Int).SetBytes(data[start+32+i*32 : start+64+i*32])
+		}
+		return values, nil
+	}
+	ids, err := array(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	amounts, err := array(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ids) != len(amounts) {
+		return nil, nil, fmt.Errorf("TransferBatch ids and values differ in length")
+	}
+	return ids, amounts, nil
+}
+
+func topicAddress(topic string) string {
+	return common.HexToAddress(topic).Hex()
+}
+
+// NFTTransfers 用 Etherscan 的 tokennfttx（ERC-721）和 token1155tx（ERC-1155）列出地址的代币转账，最多各 10000 条
+func (c *EtherscanClient) NFTTransfers(ctx context.Context, owner string) ([]nft.Transfer, error) {
+	var transfers []nft.Transfer
+	for _, action := range []struct {
+		name     string
+		standard nft.Standard
+	}{{"tokennfttx", nft.ERC721}, {"token1155tx", nft.ERC1155}} {
+		query := url.Values{
+			"module":  {"account"},
+			"action":  {action.name},
+			"address": {owner},
+			"page":    {"1"},
+			"offset":  {"10000"},
+			"sort":    {"asc"},
+			"apikey":  {c.apiKey},
+		}
+		// 出错时 result 是错误说明字符串
+		var result struct {
+			Status  string          `json:"status"`
+			Message string          `json:"message"`
+			Result  json.RawMessage `json:"result"`
+		}
+		if err := getJSON(ctx, c.baseURL+"?"+query.Encode(), nil, &result); err != nil {
+			return nil, err
+		}
+		var entries []struct {
+			ContractAddress string `json:"contractAddress"`
+			From            string `json:"from"`
+			To              string `json:"to"`
+			TokenID         string `json:"tokenID"`
+			TokenValue      string `json:"tokenValue"`
+		}
+		if err := json.Unmarshal(result.Result, &entries); err != nil {
+			if strings.Contains(strings.ToLower(string(result.Result)), "rate limit") {
+				return nil, ErrRateLimited
+			}
+			return nil, fmt.Errorf("etherscan: %s: %s %s", action.name, result.Message, result.Result)
+		}
+		// 没有记录时 status 为 0、result 为空数组
+		for _, entry := range entries {
+			id, ok := new(big.Int).SetString(entry.TokenID, 10)
+			if !ok {
+				return nil, fmt.Errorf("etherscan: invalid token ID %q", entry.TokenID)
+			}
+			amount := big.NewInt(1)
+			if action.standard == nft.ERC1155 {
+				if amount, ok = new(big.Int).SetString(entry.TokenValue, 10); !ok {
+					return nil, fmt.Errorf("etherscan: invalid token value %q", entry.TokenValue)
+				}
+			}
+			transfers = append(transfers, nft.Transfer{Contract: common.HexToAddress(entry.ContractAddress).Hex(), TokenID: id,
+				Standard: action.standard, From: entry.From, To: entry.To, Amount: amount})
+		}
+	}
+	return transfers, nil
+}
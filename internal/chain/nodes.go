@@ -0,0 +1,254 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// ForCoin 按配置为币种选择客户端：当前网络上配置了 rpc.<币种>.<网络> 节点时使用节点，
+// 否则使用 explorer.coins.<币种> 的区块浏览器
+func ForCoin(symbol string, appConfig config.AppConfig) (ChainClient, error) {
+	if node, ok := appConfig.GetRPCConfig().Node(symbol); ok {
+		return NewNodeClient(symbol, node)
+	}
+	return NewClient(symbol, appConfig.GetExplorerConfig().Coins[strings.ToLower(symbol)])
+}
+
+// NewNodeClient 创建直接查询节点的客户端：BTC 使用 Electrum 或 Bitcoin Core，
+// ed25519 币种使用 Solana JSON-RPC，其他 secp256k1 币种使用以太坊 JSON-RPC
+func NewNodeClient(symbol string, node config.RPCEndpointConfig) (ChainClient, error) {
+	endpoints := append([]string{node.Endpoint}, node.Fallbacks...)
+	timeout := time.Duration(node.Timeout) * time.Second
+	if strings.EqualFold(symbol, "BTC") {
+		return newBitcoinNodeClient(node, endpoints, timeout)
+	}
+	if node.IsElectrum() {
+		return nil, fmt.Errorf("rpc.%s: electrum servers are only supported for BTC", strings.ToLower(symbol))
+	}
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return nil, fmt.Errorf("unknown coin %s", symbol)
+	}
+	rpc := &jsonRPC{endpoints: endpoints, timeout: timeout, username: node.Username, password: node.Password, token: node.Token}
+	if info.Curve == coin.CurveEd25519 {
+		return &SolanaNodeClient{rpc: rpc}, nil
+	}
+	return &EthereumNodeClient{rpc: rpc}, nil
+}
+
+// EthereumNodeClient 以太坊兼容节点（geth、erigon 或 RPC 服务商）
+type EthereumNodeClient struct {
+	rpc *jsonRPC
+}
+
+func (c *EthereumNodeClient) Name() string     { return "eth-rpc:" + c.rpc.name() }
+func (c *EthereumNodeClient) ThirdParty() bool { return c.rpc.thirdParty() }
+
+func (c *EthereumNodeClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var result string
+	if err := c.rpc.call(ctx, "eth_getBalance", []interface{}{address, "latest"}, &result); err != nil {
+		return nil, err
+	}
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("eth_getBalance: invalid balance %q", result)
+	}
+	return wei, nil
+}
+
+// SolanaNodeClient Solana JSON-RPC 节点
+type SolanaNodeClient struct {
+	rpc *jsonRPC
+}
+
+func (c *SolanaNodeClient) Name() string     { return "sol-rpc:" + c.rpc.name() }
+func (c *SolanaNodeClient) ThirdParty() bool { return c.rpc.thirdParty() }
+
+func (c *SolanaNodeClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var result struct {
+		Value uint64 `json:"value"`
+	}
+	if err := c.rpc.call(ctx, "getBalance", []interface{}{address}, &result); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(result.Value), nil
+}
+
+// BitcoinNodeClient 通过 btc 包的 Electrum 或 Bitcoin Core 后端查询余额
+type BitcoinNodeClient struct {
+	backend  btc.Backend
+	timeout  time.Duration
+	endpoint string
+}
+
+func newBitcoinNodeClient(node config.RPCEndpointConfig, endpoints []string, timeout time.Duration) (*BitcoinNodeClient, error) {
+	client := &BitcoinNodeClient{timeout: timeout, endpoint: node.Endpoint}
+	if node.IsElectrum() {
+		// ssl://host:port 转成 Electrum 的 host:port:s 写法
+		servers := make([]string, len(endpoints))
+		for i, endpoint := range endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				return nil, err
+			}
+			servers[i] = u.Host + ":" + u.Scheme[:1]
+		}
+		backend, err := btc.NewElectrumBackend(servers, false)
+		if err != nil {
+			return nil, err
+		}
+		client.backend = backend
+		return client, nil
+	}
+	// Bitcoin Core 不支持故障转移，只使用主端点
+	client.backend = btc.NewRPCBackend(node.Endpoint, node.Username, node.Password)
+	return client, nil
+}
+
+func (c *BitcoinNodeClient) Name() string     { return c.backend.Name() }
+func (c *BitcoinNodeClient) ThirdParty() bool { return isThirdParty(c.endpoint) }
+
+// Balance Electrum 返回确认和未确认余额之和；Bitcoin Core 只能看到已确认的输出
+func (c *BitcoinNodeClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if backend, ok := c.backend.(btc.AddressBackend); ok {
+		balances, err := backend.Balances(ctx, []string{address})
+		if err != nil {
+			return nil, err
+		}
+		balance := balances[address]
+		return big.NewInt(balance.Confirmed + balance.Unconfirmed), nil
+	}
+	utxos, err := c.backend.ListUnspent(ctx, []string{address})
+	if err != nil {
+		return nil, err
+	}
+	var sats int64
+	for _, utxo := range utxos {
+		sats += utxo.Value
+	}
+	return big.NewInt(sats), nil
+}
+
+// jsonRPC JSON-RPC 2.0 调用，按顺序尝试主端点和备用端点
+type jsonRPC struct {
+	endpoints []string
+	timeout   time.Duration
+	username  string
+	password  string
+	token     string
+}
+
+// rpcError 节点返回的错误，不切换到备用端点
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message) }
+
+func (r *jsonRPC) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	var lastErr error
+	for _, endpoint := range r.endpoints {
+		err := r.callEndpoint(ctx, endpoint, method, params, out)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*rpcError); ok || ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (r *jsonRPC) callEndpoint(ctx context.Context, endpoint, method string, params []interface{}, out interface{}) error {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case r.token != "":
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	case r.username != "":
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(text)))
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return json.Unmarshal(result.Result, out)
+}
+
+// name 主端点，去掉 URL 中的用户名密码和路径（服务商常把 API key 放在路径里）
+func (r *jsonRPC) name() string {
+	u, err := url.Parse(r.endpoints[0])
+	if err != nil {
+		return "invalid endpoint"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (r *jsonRPC) thirdParty() bool {
+	return isThirdParty(r.endpoints[0])
+}
+
+// isThirdParty 端点不在本机、局域网或 Tor 上时，查询的地址和 IP 会暴露给第三方
+func isThirdParty(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".onion") {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !(ip.IsLoopback() || ip.IsPrivate())
+}
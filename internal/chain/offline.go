@@ -0,0 +1,172 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// OfflineCacheFileName 加密的链上数据缓存在数据目录中的文件名
+const OfflineCacheFileName = "chain_cache.enc"
+
+// 缓存的分区
+const (
+	SectionBalance    = "balance"     // CachedClient 查询的地址余额，键为 币种|地址
+	SectionBTCBalance = "btc.balance" // BTC 账户各地址的已确认和未确认余额
+	SectionBTCHistory = "btc.history" // BTC 账户的交易历史
+)
+
+// offlineMagic 文件开头的标识和格式版本，随后是 nonce 和 ChaCha20-Poly1305 密文
+var offlineMagic = []byte("SMCC\x01")
+
+// ErrOfflineCacheKey 缓存无法用当前钱包的密钥解密，例如数据目录换了钱包
+var ErrOfflineCacheKey = errors.New("the chain data cache was written by another wallet")
+
+type offlineEntry struct {
+	Data      json.RawMessage `json:"data"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// OfflineCache 加密保存的余额和交易历史，节点或浏览器不可用时显示最后一次查询的结果。
+// 密钥由钱包种子派生，只有解锁后才能读写；地址和金额不以明文落盘
+type OfflineCache struct {
+	mu      sync.Mutex
+	path    string
+	key     []byte
+	entries map[string]offlineEntry
+}
+
+// OfflineCacheKey 由钱包种子派生缓存的加密密钥
+func OfflineCacheKey(seed []byte) ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, seed, nil, []byte("slowmade chain cache")), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// OpenOfflineCache 打开加密缓存，文件不存在时为空；无法用 key 解密时返回 ErrOfflineCacheKey
+func OpenOfflineCache(path string, key []byte) (*OfflineCache, error) {
+	c := &OfflineCache{path: path, key: key, entries: make(map[string]offlineEntry)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, offlineMagic) || len(data) < len(offlineMagic)+aead.NonceSize() {
+		return nil, fmt.Errorf("解码链上数据缓存失败: unknown format")
+	}
+	data = data[len(offlineMagic):]
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], offlineMagic)
+	if err != nil {
+		return nil, ErrOfflineCacheKey
+	}
+	if err := json.Unmarshal(plain, &c.entries); err != nil {
+		return nil, fmt.Errorf("解码链上数据缓存失败: %w", err)
+	}
+	return c, nil
+}
+
+// Get 读取 section 中 key 的数据到 v，返回查询时间；没有记录时返回 false
+func (c *OfflineCache) Get(section, key string, v interface{}) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[section+"|"+key]
+	if !ok || json.Unmarshal(entry.Data, v) != nil {
+		return time.Time{}, false
+	}
+	return entry.FetchedAt, true
+}
+
+// Put 保存 section 中 key 的数据，查询时间为 fetchedAt，并写回文件
+func (c *OfflineCache) Put(section, key string, v interface{}, fetchedAt time.Time) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[section+"|"+key] = offlineEntry{Data: data, FetchedAt: fetchedAt.UTC()}
+	return c.save()
+}
+
+// ImportPlain 把旧版本的明文余额缓存（CacheFileName）并入加密缓存后删除明文文件；
+// 旧缓存按后端名称保存，只能并入当时查询的后端，symbolOf 由后端名称给出币种，无法判断时跳过
+func (c *OfflineCache) ImportPlain(path string, symbolOf func(backend string) string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var plain map[string]cacheEntry
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return fmt.Errorf("解码余额缓存失败: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range plain {
+		backend, address, ok := strings.Cut(key, "|")
+		symbol := symbolOf(backend)
+		if !ok || symbol == "" {
+			continue
+		}
+		full := SectionBalance + "|" + symbol + "|" + address
+		if existing, ok := c.entries[full]; ok && !existing.FetchedAt.Before(entry.FetchedAt) {
+			continue
+		}
+		encoded, err := json.Marshal(entry.Balance)
+		if err != nil {
+			return err
+		}
+		c.entries[full] = offlineEntry{Data: encoded, FetchedAt: entry.FetchedAt}
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (c *OfflineCache) save() error {
+	plain, err := canonjson.MarshalIndent(c.entries, "  ")
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.New(c.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := append(append([]byte(nil), offlineMagic...), nonce...)
+	sealed = aead.Seal(sealed, nonce, plain, offlineMagic)
+	tempFile := c.path + ".tmp"
+	if err := os.WriteFile(tempFile, sealed, 0600); err != nil {
+		return fmt.Errorf("写入链上数据缓存失败: %w", err)
+	}
+	if err := os.Rename(tempFile, c.path); err != nil {
+		return fmt.Errorf("重命名链上数据缓存失败: %w", err)
+	}
+	return nil
+}
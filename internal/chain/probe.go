@@ -0,0 +1,39 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// defaultPorts 端点地址没有端口时按协议使用的默认端口
+var defaultPorts = map[string]string{"http": "80", "https": "443", "tcp": "50001", "ssl": "50002"}
+
+// Probe 只建立一次 TCP 连接检查节点是否可达，不发送任何请求，返回连接耗时
+func Probe(ctx context.Context, endpoint string) (time.Duration, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return 0, err
+	}
+	port := u.Port()
+	if port == "" {
+		if port = defaultPorts[u.Scheme]; port == "" {
+			return 0, fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+	}
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return time.Since(start), nil
+}
+
+// EndpointName 用于显示的端点，去掉用户名密码和路径（服务商常把 API key 放在路径里）
+func EndpointName(endpoint string) string {
+	return (&jsonRPC{endpoints: []string{endpoint}}).name()
+}
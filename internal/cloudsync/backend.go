@@ -0,0 +1,63 @@
+// internal/cloudsync/backend.go
+package cloudsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/config"
+)
+
+// 错误定义
+var (
+	ErrNotFound       = errors.New("remote object not found")
+	ErrConflict       = errors.New("sync conflict: local and remote both changed")
+	ErrNotConfigured  = errors.New("sync backend not configured")
+	ErrUnknownBackend = errors.New("unknown sync backend")
+	ErrInvalidPath    = errors.New("invalid sync path")
+	ErrTampered       = errors.New("remote data was modified or belongs to another wallet")
+	ErrRollback       = errors.New("remote manifest is older than the last synced revision")
+)
+
+// Backend 定义远端存储后端，只负责按名称读写字节
+type Backend interface {
+	Name() string
+	Get(ctx context.Context, name string) ([]byte, error)
+	Put(ctx context.Context, name string, data []byte) error
+}
+
+// NewBackend 根据配置创建同步后端
+func NewBackend(cfg config.SyncConfig) (Backend, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, ErrNotConfigured
+	case "dir":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("sync.dir is required for dir backend")
+		}
+		return NewDirBackend(cfg.Dir), nil
+	case "webdav":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("sync.url is required for webdav backend")
+		}
+		return NewWebDAVBackend(cfg.URL, cfg.Prefix, cfg.Username, cfg.Password), nil
+	case "s3":
+		if cfg.URL == "" || cfg.Bucket == "" {
+			return nil, fmt.Errorf("sync.url and sync.bucket are required for s3 backend")
+		}
+		return NewS3Backend(cfg.URL, cfg.Bucket, cfg.Region, cfg.Prefix, cfg.AccessKey, cfg.SecretKey), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// joinKey 拼接远端对象名
+func joinKey(prefix, name string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
@@ -0,0 +1,69 @@
+package cloudsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DirBackend 将数据写入本地目录，可配合 rsync、网盘客户端等工具使用
+type DirBackend struct {
+	root string
+}
+
+// NewDirBackend 创建目录后端
+func NewDirBackend(root string) *DirBackend {
+	return &DirBackend{root: root}
+}
+
+func (d *DirBackend) Name() string {
+	return "dir:" + d.root
+}
+
+func (d *DirBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	target, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *DirBackend) Put(ctx context.Context, name string, data []byte) error {
+	target, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return writeFileAtomic(target, data)
+}
+
+// path 对象名对应的文件，对象名不能离开后端目录
+func (d *DirBackend) path(name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+	return filepath.Join(d.root, local), nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，保证写入原子性
+func writeFileAtomic(filename string, data []byte) error {
+	tempFile := filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tempFile, filename); err != nil {
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cloudsync
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// sealedMagic 加密后上传的记录开头的标识和格式版本，随后是 nonce 和 ChaCha20-Poly1305 密文
+var sealedMagic = []byte("SMCS\x01")
+
+// Key 由钱包种子派生的同步密钥：mac 认证远端清单，enc 加密上传的账户和地址记录。
+// 只有持有同一钱包种子的设备能写出被接受的清单，远端无法替换或回滚其中的文件
+type Key struct {
+	mac []byte
+	enc []byte
+}
+
+// DeriveKey 由钱包种子派生同步密钥
+func DeriveKey(seed []byte) (*Key, error) {
+	material := make([]byte, 32+chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, seed, nil, []byte("slowmade cloud sync")), material); err != nil {
+		return nil, err
+	}
+	return &Key{mac: material[:32], enc: material[32:]}, nil
+}
+
+// seal 加密 rel 的内容，rel 作为附加数据，密文不能挪作其他文件
+func (k *Key) seal(rel string, plain []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(k.enc)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), sealedMagic...), nonce...)
+	return aead.Seal(out, nonce, plain, append(append([]byte(nil), sealedMagic...), rel...)), nil
+}
+
+// open 解密 seal 写出的内容
+func (k *Key) open(rel string, data []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(k.enc)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, sealedMagic) || len(data) < len(sealedMagic)+aead.NonceSize() {
+		return nil, fmt.Errorf("%w: %s is not encrypted", ErrTampered, rel)
+	}
+	data = data[len(sealedMagic):]
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], append(append([]byte(nil), sealedMagic...), rel...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decrypt %s", ErrTampered, rel)
+	}
+	return plain, nil
+}
+
+// sign 清单除 MAC 外全部字段的规范编码的 HMAC-SHA256
+func (k *Key) sign(m *Manifest) (string, error) {
+	unsigned := *m
+	unsigned.MAC = ""
+	data, err := canonjson.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, k.mac)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verify 清单的 MAC 是否由同一钱包的同步密钥写出
+func (k *Key) verify(m *Manifest) error {
+	expected, err := k.sign(m)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(m.MAC)) {
+		return fmt.Errorf("%w: manifest authentication failed", ErrTampered)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package cloudsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+const (
+	manifestName  = "manifest.json"
+	stateFileName = ".sync_state.json"
+
+	// WalletFile 根钱包文件的相对路径，原样上传：其中只有加密的助记词和种子，新设备用钱包密码解密种子后派生同步密钥
+	WalletFile = "wallets/root_wallet.json"
+)
+
+// syncedDirs 需要同步的存储子目录；wallets 之外的记录含有地址和公钥，上传前用同步密钥加密
+var syncedDirs = []string{"wallets", "accounts", "addresses"}
+
+// Manifest 远端清单，Revision 单调递增，用于冲突检测；MAC 由同步密钥计算，覆盖其余全部字段
+type Manifest struct {
+	Revision  uint64            `json:"revision"`
+	UpdatedAt int64             `json:"updated_at"`
+	Files     map[string]string `json:"files"` // 相对路径 -> 明文的 sha256
+	MAC       string            `json:"mac,omitempty"`
+}
+
+// State 本地同步状态，记录最近一次成功同步时的远端版本和文件摘要
+type State struct {
+	BaseRevision uint64            `json:"base_revision"`
+	SyncedAt     int64             `json:"synced_at"`
+	Files        map[string]string `json:"files"`
+}
+
+// Status 同步状态报告
+type Status struct {
+	Backend        string
+	LocalRevision  uint64
+	RemoteRevision uint64
+	LocalChanged   []string
+	RemoteChanged  bool
+	LastSyncedAt   time.Time
+}
+
+// Conflict 本地与远端是否同时发生了变化
+func (s *Status) Conflict() bool {
+	return len(s.LocalChanged) > 0 && s.RemoteChanged
+}
+
+// Syncer 负责在本地存储目录与远端后端之间推送/拉取加密文件
+type Syncer struct {
+	backend Backend
+	baseDir string
+	key     *Key
+}
+
+// NewSyncer 创建同步器，key 由 DeriveKey 从钱包种子派生
+func NewSyncer(backend Backend, baseDir string, key *Key) *Syncer {
+	return &Syncer{backend: backend, baseDir: baseDir, key: key}
+}
+
+// Status 比较本地文件、本地同步状态和远端清单
+func (s *Syncer) Status(ctx context.Context) (*Status, error) {
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := s.loadManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	local, err := s.localFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Backend:        s.backend.Name(),
+		LocalRevision:  state.BaseRevision,
+		RemoteRevision: remote.Revision,
+		LocalChanged:   diffFiles(state.Files, local),
+		RemoteChanged:  remote.Revision != state.BaseRevision,
+	}
+	if state.SyncedAt > 0 {
+		status.LastSyncedAt = time.Unix(state.SyncedAt, 0)
+	}
+	return status, nil
+}
+
+// Push 将本地文件推送到远端；远端在上次同步后被修改时返回 ErrConflict，除非 force。
+// 远端清单无法用同步密钥认证时返回 ErrTampered，force 时改为覆盖
+func (s *Syncer) Push(ctx context.Context, force bool) (*Manifest, error) {
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := s.loadManifest(ctx)
+	if errors.Is(err, ErrTampered) && force {
+		// 强制推送时用本机的文件替换无法认证的远端内容
+		remote, err = &Manifest{Files: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if remote.Revision != state.BaseRevision && !force {
+		return nil, fmt.Errorf("%w (remote revision %d, local base %d), run sync.pull first",
+			ErrConflict, remote.Revision, state.BaseRevision)
+	}
+
+	local, err := s.localFiles()
+	if err != nil {
+		return nil, err
+	}
+	for rel, sum := range local {
+		if remote.Files[rel] == sum {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.baseDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		if rel != WalletFile {
+			if data, err = s.key.seal(rel, data); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.backend.Put(ctx, "files/"+rel, data); err != nil {
+			return nil, fmt.Errorf("上传 %s 失败: %w", rel, err)
+		}
+	}
+
+	// 清单最后写入，保证远端清单引用的文件均已上传
+	next := &Manifest{
+		Revision:  remote.Revision + 1,
+		UpdatedAt: time.Now().Unix(),
+		Files:     local,
+	}
+	if err := s.saveManifest(ctx, next); err != nil {
+		return nil, err
+	}
+	return next, s.saveState(&State{BaseRevision: next.Revision, SyncedAt: next.UpdatedAt, Files: local})
+}
+
+// Pull 从远端拉取文件覆盖本地，删除远端已删除的文件；本地在上次同步后被修改时返回 ErrConflict，除非 force。
+// 远端清单比上次同步的版本旧时返回 ErrRollback，除非 force
+func (s *Syncer) Pull(ctx context.Context, force bool) (*Manifest, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Conflict() && !force {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, strings.Join(status.LocalChanged, ", "))
+	}
+	if status.RemoteRevision < status.LocalRevision && !force {
+		return nil, fmt.Errorf("%w (remote revision %d, local base %d)", ErrRollback, status.RemoteRevision, status.LocalRevision)
+	}
+
+	remote, err := s.loadManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+	local, err := s.localFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// 先下载并校验全部文件，任何一个被篡改时不改动本地
+	pulled := make(map[string][]byte)
+	for rel, sum := range remote.Files {
+		if local[rel] == sum {
+			continue
+		}
+		data, err := s.backend.Get(ctx, "files/"+rel)
+		if err != nil {
+			return nil, fmt.Errorf("下载 %s 失败: %w", rel, err)
+		}
+		if rel != WalletFile {
+			if data, err = s.key.open(rel, data); err != nil {
+				return nil, err
+			}
+		}
+		if sha256Hex(data) != sum {
+			return nil, fmt.Errorf("%w: %s does not match the manifest", ErrTampered, rel)
+		}
+		pulled[rel] = data
+	}
+	for rel, data := range pulled {
+		target := filepath.Join(s.baseDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(target, data); err != nil {
+			return nil, err
+		}
+	}
+	// 上次同步时存在、远端已删除的文件在本地也删除；force 时本地新增的文件同样删除
+	for rel := range local {
+		if _, ok := remote.Files[rel]; ok {
+			continue
+		}
+		if _, synced := state.Files[rel]; !synced && !force {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.baseDir, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return remote, s.saveState(&State{BaseRevision: remote.Revision, SyncedAt: time.Now().Unix(), Files: remote.Files})
+}
+
+// FetchWallet 下载远端的根钱包文件，本机还没有钱包时用它解密种子、派生同步密钥。
+// 此时清单还无法认证，文件能用钱包密码解密即说明来自同一钱包，拉取时再按认证后的清单校验
+func FetchWallet(ctx context.Context, backend Backend) ([]byte, error) {
+	data, err := backend.Get(ctx, "files/"+WalletFile)
+	if err != nil {
+		return nil, fmt.Errorf("下载 %s 失败: %w", WalletFile, err)
+	}
+	return data, nil
+}
+
+// localFiles 计算所有需要同步的本地文件摘要
+func (s *Syncer) localFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range syncedDirs {
+		entries, err := os.ReadDir(filepath.Join(s.baseDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(s.baseDir, dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(data)
+			files[dir+"/"+entry.Name()] = hex.EncodeToString(sum[:])
+		}
+	}
+	return files, nil
+}
+
+func (s *Syncer) loadManifest(ctx context.Context) (*Manifest, error) {
+	data, err := s.backend.Get(ctx, manifestName)
+	if err != nil {
+		if err == ErrNotFound {
+			return &Manifest{Files: map[string]string{}}, nil
+		}
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解码远端清单失败: %w", err)
+	}
+	if err := s.key.verify(&m); err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	for rel := range m.Files {
+		if err := checkPath(rel); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+func (s *Syncer) saveManifest(ctx context.Context, m *Manifest) error {
+	mac, err := s.key.sign(m)
+	if err != nil {
+		return err
+	}
+	m.MAC = mac
+	data, err := canonjson.MarshalIndent(m, "  ")
+	if err != nil {
+		return err
+	}
+	return s.backend.Put(ctx, manifestName, data)
+}
+
+func (s *Syncer) loadState() (*State, error) {
+	data, err := os.ReadFile(filepath.Join(s.baseDir, stateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{Files: map[string]string{}}, nil
+		}
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("解码同步状态失败: %w", err)
+	}
+	if st.Files == nil {
+		st.Files = map[string]string{}
+	}
+	return &st, nil
+}
+
+func (s *Syncer) saveState(st *State) error {
+	data, err := canonjson.MarshalIndent(st, "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.baseDir, stateFileName), data)
+}
+
+// checkPath 清单中的路径必须是 syncedDirs 某个目录下的 .json 文件，不能含有 .. 或绝对路径
+func checkPath(rel string) error {
+	dir, name := path.Split(rel)
+	if !filepath.IsLocal(filepath.FromSlash(rel)) || path.Clean(rel) != rel || !strings.HasSuffix(name, ".json") ||
+		!containsDir(strings.TrimSuffix(dir, "/")) {
+		return fmt.Errorf("%w in remote manifest: %q", ErrInvalidPath, rel)
+	}
+	return nil
+}
+
+func containsDir(dir string) bool {
+	for _, synced := range syncedDirs {
+		if dir == synced {
+			return true
+		}
+	}
+	return false
+}
+
+// diffFiles 返回相对于基线新增、修改或删除的文件
+func diffFiles(base, current map[string]string) []string {
+	var changed []string
+	for rel, sum := range current {
+		if base[rel] != sum {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range base {
+		if _, ok := current[rel]; !ok {
+			changed = append(changed, rel)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
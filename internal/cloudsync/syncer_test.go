@@ -0,0 +1,206 @@
+package cloudsync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testDevice 一个数据目录和它的同步器，所有设备共用同一个目录后端
+type testDevice struct {
+	dir    string
+	syncer *Syncer
+}
+
+func newTestDevice(t *testing.T, remote string, seed string) *testDevice {
+	t.Helper()
+	key, err := DeriveKey([]byte(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	return &testDevice{dir: dir, syncer: NewSyncer(NewDirBackend(remote), dir, key)}
+}
+
+func (d *testDevice) write(t *testing.T, rel, content string) {
+	t.Helper()
+	target := filepath.Join(d.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (d *testDevice) read(t *testing.T, rel string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(d.dir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestSyncRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	remote := t.TempDir()
+	laptop := newTestDevice(t, remote, "seed")
+	phone := newTestDevice(t, remote, "seed")
+
+	laptop.write(t, WalletFile, `{"EncryptedSeed":"00"}`)
+	laptop.write(t, "accounts/accounts.json", `[{"ID":"a"}]`)
+	laptop.write(t, "addresses/a_addresses.json", `[{"Address":"bc1qexample"}]`)
+	if _, err := laptop.syncer.Push(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// 账户和地址记录上传前加密，根钱包文件原样上传
+	uploaded, err := os.ReadFile(filepath.Join(remote, "files", "addresses", "a_addresses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(uploaded, []byte("bc1qexample")) || !bytes.HasPrefix(uploaded, sealedMagic) {
+		t.Errorf("address record uploaded in the clear: %q", uploaded)
+	}
+
+	if _, err := phone.syncer.Pull(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{WalletFile, "accounts/accounts.json", "addresses/a_addresses.json"} {
+		want, _ := laptop.read(t, rel)
+		if got, ok := phone.read(t, rel); !ok || got != want {
+			t.Errorf("%s after pull = %q, want %q", rel, got, want)
+		}
+	}
+
+	// 删除在下一次拉取时生效
+	if err := os.Remove(filepath.Join(laptop.dir, "addresses", "a_addresses.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := laptop.syncer.Push(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := phone.syncer.Pull(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := phone.read(t, "addresses/a_addresses.json"); ok {
+		t.Error("file deleted on the remote is still present after pull")
+	}
+}
+
+func TestSyncRejectsTampering(t *testing.T) {
+	ctx := context.Background()
+	remote := t.TempDir()
+	laptop := newTestDevice(t, remote, "seed")
+	laptop.write(t, "addresses/a_addresses.json", `[{"Address":"a"}]`)
+	laptop.write(t, "addresses/b_addresses.json", `[{"Address":"b"}]`)
+	if _, err := laptop.syncer.Push(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("another wallet", func(t *testing.T) {
+		other := newTestDevice(t, remote, "other seed")
+		if _, err := other.syncer.Pull(ctx, false); !errors.Is(err, ErrTampered) {
+			t.Errorf("Pull with another wallet's key error = %v, want ErrTampered", err)
+		}
+	})
+
+	t.Run("edited manifest", func(t *testing.T) {
+		path := filepath.Join(remote, manifestName)
+		original, _ := os.ReadFile(path)
+		defer os.WriteFile(path, original, 0600)
+		edited := bytes.Replace(original, []byte("addresses/b_addresses.json"), []byte("../../.ssh/authorized_keys"), 1)
+		if err := os.WriteFile(path, edited, 0600); err != nil {
+			t.Fatal(err)
+		}
+		phone := newTestDevice(t, remote, "seed")
+		if _, err := phone.syncer.Pull(ctx, false); !errors.Is(err, ErrTampered) {
+			t.Errorf("Pull with edited manifest error = %v, want ErrTampered", err)
+		}
+	})
+
+	t.Run("swapped files", func(t *testing.T) {
+		a := filepath.Join(remote, "files", "addresses", "a_addresses.json")
+		b := filepath.Join(remote, "files", "addresses", "b_addresses.json")
+		dataA, _ := os.ReadFile(a)
+		dataB, _ := os.ReadFile(b)
+		defer os.WriteFile(a, dataA, 0600)
+		defer os.WriteFile(b, dataB, 0600)
+		os.WriteFile(a, dataB, 0600)
+		os.WriteFile(b, dataA, 0600)
+		phone := newTestDevice(t, remote, "seed")
+		if _, err := phone.syncer.Pull(ctx, false); !errors.Is(err, ErrTampered) {
+			t.Errorf("Pull with swapped files error = %v, want ErrTampered", err)
+		}
+		if _, ok := phone.read(t, "addresses/a_addresses.json"); ok {
+			t.Error("Pull wrote files before detecting tampering")
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		phone := newTestDevice(t, remote, "seed")
+		if _, err := phone.syncer.Pull(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(remote, manifestName)
+		old, _ := os.ReadFile(path)
+		laptop.write(t, "accounts/accounts.json", `[]`)
+		if _, err := laptop.syncer.Push(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := phone.syncer.Pull(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, old, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := phone.syncer.Pull(ctx, false); !errors.Is(err, ErrRollback) {
+			t.Errorf("Pull of an older manifest error = %v, want ErrRollback", err)
+		}
+	})
+}
+
+func TestCheckPath(t *testing.T) {
+	valid := []string{"wallets/root_wallet.json", "accounts/accounts.json", "addresses/file_1_addresses.json"}
+	for _, rel := range valid {
+		if err := checkPath(rel); err != nil {
+			t.Errorf("checkPath(%q) = %v, want nil", rel, err)
+		}
+	}
+	invalid := []string{
+		"../../.ssh/authorized_keys",
+		"addresses/../../x.json",
+		"/etc/passwd",
+		"addresses/sub/x.json",
+		"addresses/x.txt",
+		"config.json",
+		"apikeys/x.json",
+		"addresses/./x.json",
+		"addresses\\..\\x.json",
+		"",
+	}
+	for _, rel := range invalid {
+		if err := checkPath(rel); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("checkPath(%q) = %v, want ErrInvalidPath", rel, err)
+		}
+	}
+}
+
+func TestDirBackendRejectsEscapingNames(t *testing.T) {
+	backend := NewDirBackend(t.TempDir())
+	for _, name := range []string{"../outside.json", "files/../../outside.json", "/tmp/outside.json"} {
+		if err := backend.Put(context.Background(), name, []byte("x")); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidPath", name, err)
+		}
+		if _, err := backend.Get(context.Background(), name); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Get(%q) error = %v, want ErrInvalidPath", name, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cloudsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// WebDAVBackend 基于 WebDAV 的同步后端
+type WebDAVBackend struct {
+	baseURL  string
+	prefix   string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVBackend 创建 WebDAV 后端
+func NewWebDAVBackend(baseURL, prefix, username, password string) *WebDAVBackend {
+	return &WebDAVBackend{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		prefix:   prefix,
+		username: username,
+		password: password,
+		client:   resilience.NewHTTPClient(30 * time.Second),
+	}
+}
+
+func (w *WebDAVBackend) Name() string {
+	return "webdav:" + w.baseURL
+}
+
+func (w *WebDAVBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := w.do(ctx, http.MethodGet, joinKey(w.prefix, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("webdav GET %s: %s", name, resp.Status)
+	}
+}
+
+func (w *WebDAVBackend) Put(ctx context.Context, name string, data []byte) error {
+	key := joinKey(w.prefix, name)
+	if err := w.ensureCollections(ctx, key); err != nil {
+		return err
+	}
+
+	resp, err := w.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webdav PUT %s: %s", name, resp.Status)
+	}
+	return nil
+}
+
+// ensureCollections 逐级创建父集合（MKCOL），已存在的集合返回 405 属于正常情况
+func (w *WebDAVBackend) ensureCollections(ctx context.Context, key string) error {
+	parts := strings.Split(key, "/")
+	for i := 1; i < len(parts); i++ {
+		resp, err := w.do(ctx, "MKCOL", strings.Join(parts[:i], "/")+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("webdav MKCOL %s: %s", strings.Join(parts[:i], "/"), resp.Status)
+		}
+	}
+	return nil
+}
+
+func (w *WebDAVBackend) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, w.baseURL+"/"+key, reader)
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return w.client.Do(req)
+}
@@ -0,0 +1,65 @@
+package coinplugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/cashaddr"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeBCH 比特币现金的 SLIP-44 币种类型
+const CoinTypeBCH uint32 = 145
+
+// BitcoinCash 比特币现金：BIP44 账户生成 CashAddr 地址，校验时也接受旧格式地址并转换为 CashAddr
+type BitcoinCash struct{}
+
+func (BitcoinCash) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "BCH", Type: CoinTypeBCH, Decimal: 8, Curve: coin.CurveSecp256k1}
+}
+
+func (BitcoinCash) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (BitcoinCash) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return cashAddrGenerator{}
+}
+
+func (BitcoinCash) NormalizeAddress(address string) (string, error) {
+	if !strings.Contains(address, ":") && (strings.HasPrefix(address, "1") || strings.HasPrefix(address, "3")) {
+		// 旧格式与比特币相同：0x00 为 P2PKH，0x05 为 P2SH
+		if err := checkBase58("BCH", address, 0x00, 0x05); err != nil {
+			return "", err
+		}
+		payload, _ := base58.CheckDecode(address)
+		addressType := cashaddr.P2PKH
+		if payload[0] == 0x05 {
+			addressType = cashaddr.P2SH
+		}
+		return cashaddr.Encode(addressType, payload[1:])
+	}
+	addressType, hash, err := cashaddr.Decode(address)
+	if err != nil {
+		return "", fmt.Errorf("%w for BCH: %v", ErrInvalidAddress, err)
+	}
+	return cashaddr.Encode(addressType, hash)
+}
+
+// Fees 比特币现金节点默认的最低转发费率
+func (BitcoinCash) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 1, Unit: "sat/B"}
+}
+
+// cashAddrGenerator 生成 P2PKH（bitcoincash:q...）地址
+type cashAddrGenerator struct{}
+
+func (cashAddrGenerator) GenerateAddress(publicKey []byte) (string, error) {
+	hash, err := pubKeyHash("BCH", publicKey)
+	if err != nil {
+		return "", err
+	}
+	return cashaddr.Encode(cashaddr.P2PKH, hash)
+}
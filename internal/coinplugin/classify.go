@@ -0,0 +1,159 @@
+package coinplugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/addrcheck"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/cosmos"
+	"github.com/palagend/slowmade/internal/substrate"
+	"github.com/palagend/slowmade/internal/tron"
+	"github.com/palagend/slowmade/internal/xrp"
+	"github.com/palagend/slowmade/pkg/cashaddr"
+)
+
+// 莱特币和狗狗币各网络的地址参数；钱包只生成主网地址，校验时也识别测试网地址以便给出明确的原因
+var (
+	litecoinParams = addrcheck.Params{
+		HRPs: map[string]string{ltcHRP: addrcheck.Mainnet, "tltc": addrcheck.Testnet, "rltc": addrcheck.Regtest},
+		Versions: map[byte]addrcheck.Base58Version{
+			ltcP2PKHVersion: {Network: addrcheck.Mainnet, Type: "p2pkh"}, ltcP2SHVersion: {Network: addrcheck.Mainnet, Type: "p2sh"},
+			ltcLegacyP2SH: {Network: addrcheck.Mainnet, Type: "p2sh"},
+			0x6f:          {Network: addrcheck.Testnet, Type: "p2pkh"}, 0x3a: {Network: addrcheck.Testnet, Type: "p2sh"},
+			0xc4: {Network: addrcheck.Testnet, Type: "p2sh"},
+		},
+	}
+	dogecoinParams = addrcheck.Params{
+		Versions: map[byte]addrcheck.Base58Version{
+			dogeP2PKHVersion: {Network: addrcheck.Mainnet, Type: "p2pkh"}, dogeP2SHVersion: {Network: addrcheck.Mainnet, Type: "p2sh"},
+			0x71: {Network: addrcheck.Testnet, Type: "p2pkh"}, 0xc4: {Network: addrcheck.Testnet, Type: "p2sh"},
+		},
+	}
+)
+
+// ClassifyAddress 识别 ltc1、L...、M... 和旧的 3... 地址，以及测试网地址
+func (Litecoin) ClassifyAddress(address string) (core.AddressInfo, error) {
+	info, err := litecoinParams.Classify(address)
+	if err == nil && strings.HasPrefix(address, "3") {
+		info.Note = "the old 3... form of a Litecoin P2SH address, some services only accept M..."
+	}
+	return info, err
+}
+
+// ClassifyAddress 识别 D... 和 9.../A... 地址，以及测试网地址
+func (Dogecoin) ClassifyAddress(address string) (core.AddressInfo, error) {
+	return dogecoinParams.Classify(address)
+}
+
+// ClassifyAddress 识别 CashAddr 和旧格式地址，规范形式为 CashAddr
+func (b BitcoinCash) ClassifyAddress(address string) (core.AddressInfo, error) {
+	normalized, err := b.NormalizeAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	addressType, _, _ := cashaddr.Decode(normalized)
+	info := core.AddressInfo{Normalized: normalized, Network: addrcheck.Mainnet, Type: "p2pkh", Format: "cashaddr", Checksum: "cashaddr"}
+	if addressType == cashaddr.P2SH {
+		info.Type = "p2sh"
+	}
+	if !strings.Contains(address, ":") && (strings.HasPrefix(address, "1") || strings.HasPrefix(address, "3")) {
+		info.Format, info.Checksum = "legacy", "base58check"
+		info.Note = "a legacy address is also a valid BTC address, give the CashAddr form so it is not paid on the wrong chain"
+	}
+	return info, nil
+}
+
+// ClassifyAddress 识别 T... 或 41 开头的十六进制地址
+func (Tron) ClassifyAddress(address string) (core.AddressInfo, error) {
+	normalized, err := tron.NormalizeAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	info := core.AddressInfo{Normalized: normalized, Network: addrcheck.Mainnet, Type: "account", Format: "base58", Checksum: "base58check"}
+	if normalized != address {
+		info.Format, info.Checksum = "hex", "none"
+	}
+	return info, nil
+}
+
+// ClassifyAddress 网络为地址前缀（cosmos、osmo 等）；20 字节为账户，32 字节为 CosmWasm 合约或模块账户
+func (Cosmos) ClassifyAddress(address string) (core.AddressInfo, error) {
+	hrp, data, err := cosmos.ParseAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	info := core.AddressInfo{Normalized: strings.ToLower(address), Network: hrp, Type: "account", Format: "bech32", Checksum: "bech32"}
+	if len(data) == 32 {
+		info.Type = "contract"
+		info.Note = "a CosmWasm contract or module account, not a key holder"
+	}
+	if hrp != cosmos.DefaultHRP {
+		notes := []string{fmt.Sprintf("an address of the %s chain, not the Cosmos Hub", hrp)}
+		if info.Note != "" {
+			notes = append([]string{info.Note}, notes...)
+		}
+		info.Note = strings.Join(notes, "; ")
+	}
+	return info, nil
+}
+
+// ClassifyAddress 网络由 SS58 前缀决定，通用前缀 42 的地址规范为本网络的形式
+func (s Substrate) ClassifyAddress(address string) (core.AddressInfo, error) {
+	prefix, _, err := substrate.Decode(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	normalized, err := s.NormalizeAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	info := core.AddressInfo{Normalized: normalized, Network: substrateNetwork(prefix), Type: "account", Format: "ss58", Checksum: "ss58"}
+	if prefix == substrate.PrefixGeneric {
+		info.Note = fmt.Sprintf("generic substrate prefix 42, the %s form is %s", s.Symbol, normalized)
+	}
+	return info, nil
+}
+
+// substrateNetwork SS58 前缀对应的网络名
+func substrateNetwork(prefix uint16) string {
+	switch prefix {
+	case substrate.PrefixPolkadot:
+		return "polkadot"
+	case substrate.PrefixKusama:
+		return "kusama"
+	case substrate.PrefixGeneric:
+		return "substrate"
+	}
+	return fmt.Sprintf("ss58-%d", prefix)
+}
+
+// ClassifyAddress 识别 G... 账户地址，各网络的地址相同
+func (s Stellar) ClassifyAddress(address string) (core.AddressInfo, error) {
+	normalized, err := s.NormalizeAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	return core.AddressInfo{Normalized: normalized, Type: "account", Format: "strkey", Checksum: "crc16"}, nil
+}
+
+// ClassifyAddress 识别经典地址和 X-address；X-address 带有网络和目标标签，规范形式为经典地址
+func (XRP) ClassifyAddress(address string) (core.AddressInfo, error) {
+	dest, err := xrp.ParseAddress(address)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+	info := core.AddressInfo{Normalized: dest.Classic(), Type: "account", Format: "classic", Checksum: "base58check"}
+	if strings.HasPrefix(address, "r") {
+		return info, nil
+	}
+	info.Format, info.Network = "x-address", addrcheck.Mainnet
+	if dest.Testnet {
+		info.Network = addrcheck.Testnet
+	}
+	info.Note = "no destination tag"
+	if dest.HasTag {
+		info.Note = fmt.Sprintf("destination tag %d", dest.Tag)
+	}
+	return info, nil
+}
@@ -0,0 +1,63 @@
+// Package coinplugin 通过 core.CoinPlugin 接入的内置插件：莱特币、狗狗币、比特币现金、波场、Cosmos SDK 链、Polkadot 和 Kusama、Stellar 以及 XRP。
+// 前三者与比特币使用相同的 secp256k1 密钥和 HASH160，只是地址编码不同
+package coinplugin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/base58"
+)
+
+// ErrInvalidAddress 地址不符合币种的格式
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Builtin 内置插件
+func Builtin() []core.CoinPlugin {
+	return []core.CoinPlugin{Litecoin{}, Dogecoin{}, BitcoinCash{}, Tron{}, Cosmos{}, Polkadot, Kusama, Stellar{}, XRP{}}
+}
+
+// RegisterBuiltin 注册全部内置插件
+func RegisterBuiltin() {
+	for _, plugin := range Builtin() {
+		core.RegisterCoinPlugin(plugin)
+	}
+}
+
+// pubKeyHash 压缩公钥的 HASH160
+func pubKeyHash(symbol string, publicKey []byte) ([]byte, error) {
+	if len(publicKey) != 33 {
+		return nil, fmt.Errorf("%s requires compressed public key (33 bytes)", symbol)
+	}
+	return btc.Hash160(publicKey), nil
+}
+
+// base58Generator 以 Base58Check 编码 P2PKH 地址
+type base58Generator struct {
+	symbol  string
+	version byte
+}
+
+func (g base58Generator) GenerateAddress(publicKey []byte) (string, error) {
+	hash, err := pubKeyHash(g.symbol, publicKey)
+	if err != nil {
+		return "", err
+	}
+	return base58.CheckEncode(append([]byte{g.version}, hash...)), nil
+}
+
+// checkBase58 校验 Base58Check 地址的版本字节和长度
+func checkBase58(symbol, address string, versions ...byte) error {
+	payload, err := base58.CheckDecode(address)
+	if err != nil || len(payload) != 21 {
+		return fmt.Errorf("%w for %s: %s", ErrInvalidAddress, symbol, address)
+	}
+	for _, version := range versions {
+		if payload[0] == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for %s: unsupported version byte 0x%02x", ErrInvalidAddress, symbol, payload[0])
+}
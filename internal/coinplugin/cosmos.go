@@ -0,0 +1,42 @@
+package coinplugin
+
+import (
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/cosmos"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeATOM Cosmos Hub 及大多数 Cosmos SDK 链共用的 SLIP-44 币种类型
+const CoinTypeATOM uint32 = 118
+
+// Cosmos Cosmos SDK 链族：地址以 cosmos 前缀保存，其他链（osmo、juno 等，见 [cosmos.chains]）使用同一个密钥，
+// 只是 bech32 前缀不同，由 cosmos.* 命令按 --chain 转换
+type Cosmos struct{}
+
+func (Cosmos) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "ATOM", Type: CoinTypeATOM, Decimal: 6, Curve: coin.CurveSecp256k1}
+}
+
+func (Cosmos) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (Cosmos) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return cosmosGenerator{}
+}
+
+// NormalizeAddress 接受任意前缀的 Cosmos 地址，监控其他链上的地址时不必先配置该链
+func (Cosmos) NormalizeAddress(address string) (string, error) {
+	return cosmos.NormalizeAddress(address)
+}
+
+// Fees Cosmos Hub 上一笔转账的默认手续费（0.025 uatom/gas × 200000 gas），实际按链的 gas_price 计算
+func (Cosmos) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 5000, Unit: "uatom/tx"}
+}
+
+type cosmosGenerator struct{}
+
+func (cosmosGenerator) GenerateAddress(publicKey []byte) (string, error) {
+	return cosmos.AddressFromPublicKey(cosmos.DefaultHRP, publicKey)
+}
@@ -0,0 +1,42 @@
+package coinplugin
+
+import (
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeDOGE 狗狗币的 SLIP-44 币种类型
+const CoinTypeDOGE uint32 = 3
+
+// 狗狗币主网地址参数
+const (
+	dogeP2PKHVersion = 0x1e // D...
+	dogeP2SHVersion  = 0x16 // 9... 或 A...
+)
+
+// Dogecoin 狗狗币：只有 BIP44 P2PKH 地址
+type Dogecoin struct{}
+
+func (Dogecoin) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "DOGE", Type: CoinTypeDOGE, Decimal: 8, Curve: coin.CurveSecp256k1}
+}
+
+func (Dogecoin) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (Dogecoin) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return base58Generator{symbol: "DOGE", version: dogeP2PKHVersion}
+}
+
+func (Dogecoin) NormalizeAddress(address string) (string, error) {
+	if err := checkBase58("DOGE", address, dogeP2PKHVersion, dogeP2SHVersion); err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// Fees 狗狗币推荐的 0.01 DOGE/kB
+func (Dogecoin) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 1000, Unit: "koinu/B"}
+}
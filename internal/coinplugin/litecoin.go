@@ -0,0 +1,69 @@
+package coinplugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/bech32"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeLTC 莱特币的 SLIP-44 币种类型
+const CoinTypeLTC uint32 = 2
+
+// 莱特币主网地址参数
+const (
+	ltcHRP          = "ltc"
+	ltcP2PKHVersion = 0x30 // L...
+	ltcP2SHVersion  = 0x32 // M...
+	ltcLegacyP2SH   = 0x05 // 旧版本钱包使用的 3...
+)
+
+// Litecoin 莱特币：BIP44 账户生成 L... 地址，BIP84 账户生成 ltc1 地址
+type Litecoin struct{}
+
+func (Litecoin) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "LTC", Type: CoinTypeLTC, Decimal: 8, Curve: coin.CurveSecp256k1}
+}
+
+func (Litecoin) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44, 84}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (Litecoin) AddressGenerator(account *core.CoinAccount) core.AddressGenerator {
+	if dp, _ := account.Path(); dp != nil && dp.Purpose == 84|core.HardenedOffset {
+		return ltcSegwitGenerator{}
+	}
+	return base58Generator{symbol: "LTC", version: ltcP2PKHVersion}
+}
+
+func (Litecoin) NormalizeAddress(address string) (string, error) {
+	if strings.HasPrefix(strings.ToLower(address), ltcHRP+"1") {
+		address = strings.ToLower(address)
+		if _, _, err := bech32.DecodeSegwitAddress(ltcHRP, address); err != nil {
+			return "", fmt.Errorf("%w for LTC: %v", ErrInvalidAddress, err)
+		}
+		return address, nil
+	}
+	if err := checkBase58("LTC", address, ltcP2PKHVersion, ltcP2SHVersion, ltcLegacyP2SH); err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// Fees 莱特币节点默认的最低转发费率
+func (Litecoin) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 10, Unit: "litoshi/vB"}
+}
+
+// ltcSegwitGenerator 生成 P2WPKH（ltc1q...）地址
+type ltcSegwitGenerator struct{}
+
+func (ltcSegwitGenerator) GenerateAddress(publicKey []byte) (string, error) {
+	hash, err := pubKeyHash("LTC", publicKey)
+	if err != nil {
+		return "", err
+	}
+	return bech32.EncodeSegwitAddress(ltcHRP, 0, hash)
+}
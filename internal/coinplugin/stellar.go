@@ -0,0 +1,51 @@
+package coinplugin
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/stellar"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeXLM Stellar 的 SLIP-44 币种类型
+const CoinTypeXLM uint32 = 148
+
+// Stellar 路径沿用 SEP-0005 的 m/44'/148'/x'，与 Polkadot 一样以派生出的地址私钥为 ed25519 种子，
+// 没有使用 SLIP-0010 派生，同一助记词在其他 Stellar 钱包中得到的账户不同
+type Stellar struct{}
+
+func (Stellar) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "XLM", Type: CoinTypeXLM, Decimal: stellar.Decimals, Curve: coin.CurveEd25519}
+}
+
+func (Stellar) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, AllHardened: true}
+}
+
+func (Stellar) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return stellarGenerator{}
+}
+
+func (Stellar) NormalizeAddress(address string) (string, error) {
+	publicKey, err := stellar.DecodeAccount(address)
+	if err != nil {
+		return "", fmt.Errorf("%w for XLM: %v", ErrInvalidAddress, err)
+	}
+	return stellar.EncodeAccount(publicKey)
+}
+
+// Fees 每个操作的基础手续费
+func (Stellar) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 100, Unit: "stroops/op"}
+}
+
+type stellarGenerator struct{}
+
+func (stellarGenerator) GenerateAddress([]byte) (string, error) {
+	return "", fmt.Errorf("XLM addresses use ed25519 keys and cannot be derived from a public key")
+}
+
+func (stellarGenerator) GenerateAddressFromSeed(seed []byte) (string, []byte, error) {
+	return stellar.AddressFromSeed(seed)
+}
@@ -0,0 +1,73 @@
+package coinplugin
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/substrate"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// Polkadot 和 Kusama 的 SLIP-44 币种类型
+const (
+	CoinTypeDOT uint32 = 354
+	CoinTypeKSM uint32 = 434
+)
+
+// Substrate 链：ed25519 密钥以派生出的地址私钥为种子，地址为带网络前缀的 SS58。
+// 密钥与 polkadot.js 等钱包默认的 sr25519 账户不同，不能互相导入
+type Substrate struct {
+	Symbol   string
+	CoinType uint32
+	Prefix   uint16 // SS58 网络前缀
+	Decimals int
+}
+
+// 内置的 Substrate 网络
+var (
+	Polkadot = Substrate{Symbol: "DOT", CoinType: CoinTypeDOT, Prefix: substrate.PrefixPolkadot, Decimals: 10}
+	Kusama   = Substrate{Symbol: "KSM", CoinType: CoinTypeKSM, Prefix: substrate.PrefixKusama, Decimals: 12}
+)
+
+func (s Substrate) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: s.Symbol, Type: s.CoinType, Decimal: s.Decimals, Curve: coin.CurveEd25519}
+}
+
+func (Substrate) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, AllHardened: true}
+}
+
+func (s Substrate) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return substrateGenerator{symbol: s.Symbol, prefix: s.Prefix}
+}
+
+// NormalizeAddress 接受本网络或通用前缀（42）的地址，返回本网络前缀的形式
+func (s Substrate) NormalizeAddress(address string) (string, error) {
+	prefix, accountID, err := substrate.Decode(address)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %v", ErrInvalidAddress, s.Symbol, err)
+	}
+	if prefix != s.Prefix && prefix != substrate.PrefixGeneric {
+		return "", fmt.Errorf("%w for %s: network prefix %d, expected %d", ErrInvalidAddress, s.Symbol, prefix, s.Prefix)
+	}
+	return substrate.Encode(s.Prefix, accountID)
+}
+
+// Fees 手续费由运行时按交易权重和长度计算，可以另加小费
+func (Substrate) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 0, Unit: "planck tip"}
+}
+
+// substrateGenerator 需要地址私钥作为 ed25519 种子，不能由 secp256k1 公钥生成
+type substrateGenerator struct {
+	symbol string
+	prefix uint16
+}
+
+func (g substrateGenerator) GenerateAddress([]byte) (string, error) {
+	return "", fmt.Errorf("%s addresses use ed25519 keys and cannot be derived from a public key", g.symbol)
+}
+
+func (g substrateGenerator) GenerateAddressFromSeed(seed []byte) (string, []byte, error) {
+	return substrate.AddressFromSeed(g.prefix, seed)
+}
@@ -0,0 +1,40 @@
+package coinplugin
+
+import (
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/tron"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeTRX 波场的 SLIP-44 币种类型
+const CoinTypeTRX uint32 = 195
+
+// Tron 波场：地址与以太坊使用同样的公钥哈希，以 0x41 为前缀 Base58Check 编码（T...）
+type Tron struct{}
+
+func (Tron) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "TRX", Type: CoinTypeTRX, Decimal: tron.Decimals, Curve: coin.CurveSecp256k1}
+}
+
+func (Tron) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (Tron) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return tronGenerator{}
+}
+
+func (Tron) NormalizeAddress(address string) (string, error) {
+	return tron.NormalizeAddress(address)
+}
+
+// Fees 带宽不足时每字节燃烧的 TRX；普通转账通常由每日免费带宽支付，TRC-20 转账另需能量，见 tron.fee_limit
+func (Tron) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 1000, Unit: "sun/B"}
+}
+
+type tronGenerator struct{}
+
+func (tronGenerator) GenerateAddress(publicKey []byte) (string, error) {
+	return tron.AddressFromPublicKey(publicKey)
+}
@@ -0,0 +1,51 @@
+package coinplugin
+
+import (
+	"fmt"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/xrp"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinTypeXRP XRP 的 SLIP-44 币种类型
+const CoinTypeXRP uint32 = 144
+
+// XRP secp256k1 密钥，路径与 Ledger、Xaman 相同（m/44'/144'/0'/0/0）；地址为公钥 HASH160 的经典地址（r...）
+type XRP struct{}
+
+func (XRP) Info() coin.CoinInfo {
+	return coin.CoinInfo{Symbol: "XRP", Type: CoinTypeXRP, Decimal: xrp.Decimals, Curve: coin.CurveSecp256k1}
+}
+
+func (XRP) PathRule() core.PathRule {
+	return core.PathRule{Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3}
+}
+
+func (XRP) AddressGenerator(*core.CoinAccount) core.AddressGenerator {
+	return xrpGenerator{}
+}
+
+// NormalizeAddress 只接受经典地址；X-address 带有目标标签，由 xrp.send 解析
+func (XRP) NormalizeAddress(address string) (string, error) {
+	normalized, err := xrp.NormalizeAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("%w for XRP: %v", ErrInvalidAddress, err)
+	}
+	return normalized, nil
+}
+
+// Fees 一笔交易的最低手续费，网络拥堵时按 open_ledger_fee 提高
+func (XRP) Fees() core.FeeDefaults {
+	return core.FeeDefaults{Rate: 12, Unit: "drops/tx"}
+}
+
+type xrpGenerator struct{}
+
+func (xrpGenerator) GenerateAddress(publicKey []byte) (string, error) {
+	accountID, err := xrp.AccountIDFromPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return xrp.EncodeClassic(accountID), nil
+}
@@ -2,46 +2,391 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 
+	"github.com/palagend/slowmade/internal/redact"
+	"github.com/palagend/slowmade/internal/tracing"
+	"github.com/palagend/slowmade/internal/version"
 	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/palagend/slowmade/pkg/otlp"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// ErrInvalid 配置文件无法解析或配置项取值无效
+var ErrInvalid = errors.New("invalid configuration")
+
 // AppConfig 完整的应用配置结构
 type AppConfig struct {
 	RPC     RPCConfig     `mapstructure:"rpc"`
 	Storage StorageConfig `mapstructure:"storage"`
 	Log     LogConfig     `mapstructure:"log"`
+	Tracing TracingConfig `mapstructure:"tracing"`
 	UI      UIConfig      `mapstructure:"ui"`
 	Web     WebConfig     `mapstructure:"web"`
+	Sync    SyncConfig    `mapstructure:"sync"`
+
+	WalletConnect WalletConnectConfig `mapstructure:"walletconnect"`
+	Bitcoin       BitcoinConfig       `mapstructure:"bitcoin"`
+	Tron          TronConfig          `mapstructure:"tron"`
+	Cosmos        CosmosConfig        `mapstructure:"cosmos"`
+	Substrate     SubstrateConfig     `mapstructure:"substrate"`
+	Monero        MoneroConfig        `mapstructure:"monero"`
+	Stellar       StellarConfig       `mapstructure:"stellar"`
+	XRP           XRPConfig           `mapstructure:"xrp"`
+	NFT           NFTConfig           `mapstructure:"nft"`
+	AA            AAConfig            `mapstructure:"aa"`
+	Explorer      ExplorerConfig      `mapstructure:"explorer"`
+	Privacy       PrivacyConfig       `mapstructure:"privacy"`
+	Watch         WatchConfig         `mapstructure:"watch"`
+	Network       NetworkConfig       `mapstructure:"network"`
+	Integrity     IntegrityConfig     `mapstructure:"integrity"`
+	Hardening     HardeningConfig     `mapstructure:"hardening"`
+	Entropy       EntropyConfig       `mapstructure:"entropy"`
+	Mockchain     MockchainConfig     `mapstructure:"mockchain"`
+	Trash         TrashConfig         `mapstructure:"trash"`
+	Approval      ApprovalConfig      `mapstructure:"approval"`
+	Wallet        WalletConfig        `mapstructure:"wallet"`
+	Backup        BackupConfig        `mapstructure:"backup"`
+	Airgap        AirgapConfig        `mapstructure:"airgap"`
+	Anomaly       AnomalyConfig       `mapstructure:"anomaly"`
+	Notify        NotifyConfig        `mapstructure:"notify"`
+	Names         NamesConfig         `mapstructure:"names"`
+	Price         PriceConfig         `mapstructure:"price"`
+	Tax           TaxConfig           `mapstructure:"tax"`
+	Index         IndexConfig         `mapstructure:"index"`
+	Address       AddressConfig       `mapstructure:"address"`
+	Pairing       PairingConfig       `mapstructure:"pairing"`
 }
 
+// RPCConfig 节点 RPC 配置，按 rpc.<币种>.<网络> 分层，如 rpc.eth.mainnet.endpoint
 type RPCConfig struct {
-	Endpoint string `mapstructure:"endpoint"`
-	Timeout  int    `mapstructure:"timeout"`
+	Network  string                                  `mapstructure:"network"`  // 当前使用的网络，默认 mainnet
+	Timeout  int                                     `mapstructure:"timeout"`  // 端点没有设置 timeout 时使用（秒）
+	Endpoint string                                  `mapstructure:"endpoint"` // 已废弃：旧的单一端点，按 rpc.eth.mainnet 处理
+	Coins    map[string]map[string]RPCEndpointConfig `mapstructure:",remain"`  // 小写币种符号 -> 网络 -> 节点
+}
+
+// RPCEndpointConfig 单个币种在单个网络上的节点
+type RPCEndpointConfig struct {
+	Endpoint  string   `mapstructure:"endpoint"`  // http(s):// 为 JSON-RPC，ssl:// 或 tcp:// 为 Electrum 服务器
+	Fallbacks []string `mapstructure:"fallbacks"` // 主端点失败后依次尝试的端点，协议必须相同
+	Timeout   int      `mapstructure:"timeout"`   // 单次请求超时（秒），0 表示使用 rpc.timeout
+	Username  string   `mapstructure:"username"`  // HTTP Basic 认证
+	Password  string   `mapstructure:"password"`
+	Token     string   `mapstructure:"token"` // 以 Authorization: Bearer 发送
 }
 
 type StorageConfig struct {
-	BaseDir string `mapstructure:"base_dir"`
+	BaseDir      string `mapstructure:"base_dir"`
+	ReadOnly     bool   `mapstructure:"read_only"`     // 不加实例锁、拒绝写入，用于另一个进程运行时查看数据
+	Permissions  string `mapstructure:"permissions"`   // 存储目录权限检查：warn（默认）、repair 或 strict
+	CacheEntries int    `mapstructure:"cache_entries"` // 读取缓存最多保存的文件数，0 表示不缓存
 }
 
 type LogConfig struct {
-	Level    string `mapstructure:"level"`
-	File     string `mapstructure:"file"`
-	Encoding string `mapstructure:"encoding"`
+	Level    string               `mapstructure:"level"`
+	File     string               `mapstructure:"file"`
+	Encoding string               `mapstructure:"encoding"`
+	Remote   logging.RemoteConfig `mapstructure:"remote"` // 远程日志，见 logging.RemoteConfig
+}
+
+// TracingConfig 链路追踪：命令、核心层、存储和节点 RPC 调用的 span 经 OTLP/gRPC 发送到 collector
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint"`     // https://host:4317，或 http://host:4317（明文 HTTP/2）
+	SampleRatio float64           `mapstructure:"sample_ratio"` // 被记录的命令和请求比例，0 到 1
+	CAFile      string            `mapstructure:"ca_file"`      // 校验 collector 证书的 CA，为空时使用系统证书
+	CertFile    string            `mapstructure:"cert_file"`    // 客户端证书（双向 TLS）
+	KeyFile     string            `mapstructure:"key_file"`
+	Headers     map[string]string `mapstructure:"headers"` // 随请求发送的 gRPC 元数据，如鉴权令牌
+	ServiceName string            `mapstructure:"service_name"`
+}
+
+// Validate 检查追踪配置，不连接 collector
+func (c TracingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch {
+	case !otlp.ValidAddress(c.Endpoint):
+		return fmt.Errorf("tracing.endpoint %q must be https://host:port or http://host:port", c.Endpoint)
+	case c.SampleRatio < 0 || c.SampleRatio > 1:
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1")
+	case (c.CertFile == "") != (c.KeyFile == ""):
+		return fmt.Errorf("tracing.cert_file and tracing.key_file must be set together")
+	}
+	return nil
 }
 
 type UIConfig struct {
-	Lang string `mapstructure:"lang"`
+	Lang       string `mapstructure:"lang"`
+	LocalesDir string `mapstructure:"locales_dir"` // 外部翻译文件目录，为空时使用配置文件所在目录下的 locales
+	Timezone   string `mapstructure:"timezone"`    // 显示时间用的时区（IANA 名称或 UTC），为空时使用系统时区
+	QR         string `mapstructure:"qr"`          // 终端二维码渲染方式：auto、ascii、sixel、kitty
+	QRContent  string `mapstructure:"qr_content"`  // 收款二维码的默认内容：auto、address、uri、json
+	Accessible bool   `mapstructure:"accessible"`  // 无障碍输出：纯文本逐行显示，不用框线、图标、颜色和多列布局
+	SlowHint   int    `mapstructure:"slow_hint"`   // 命令耗时超过该秒数时提示原因和调整方法，0 表示不提示
+	Prompt     string `mapstructure:"prompt"`      // REPL 提示符的 text/template 模板，为空时使用内置提示符
 }
 
 type WebConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Host      string `mapstructure:"host"`
+	Port      int    `mapstructure:"port"`
+	Mode      string `mapstructure:"mode"`
+	PublicURL string `mapstructure:"public_url"` // 对方访问服务器的地址，用于生成分享链接，为空时使用 http://host:port
+	// AdminSocket serve 管理接口（slowmade ctl）的 unix socket 路径，为空时为数据目录下的 admin.sock，off 表示不启用
+	AdminSocket string `mapstructure:"admin_socket"`
+}
+
+// SyncConfig 云同步配置，只同步加密后的存储文件
+type SyncConfig struct {
+	Backend   string `mapstructure:"backend"` // dir | webdav | s3，为空表示未启用
+	Dir       string `mapstructure:"dir"`     // dir 后端的目标目录（可被 rsync 等工具同步）
+	URL       string `mapstructure:"url"`     // webdav 或 s3 的服务地址
+	Bucket    string `mapstructure:"bucket"`
+	Region    string `mapstructure:"region"`
+	Prefix    string `mapstructure:"prefix"` // 远端路径前缀
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// WalletConnectConfig WalletConnect v2 中继配置
+type WalletConnectConfig struct {
+	ProjectID string `mapstructure:"project_id"` // cloud.walletconnect.com 申请的项目 ID
+	RelayURL  string `mapstructure:"relay_url"`
+}
+
+// BitcoinConfig 比特币链上数据后端配置，用于查询 UTXO、估算费率和广播交易
+type BitcoinConfig struct {
+	Backend     string `mapstructure:"backend"` // electrum | rpc，为空表示未启用
+	RPCURL      string `mapstructure:"rpc_url"` // Bitcoin Core JSON-RPC 地址
+	RPCUser     string `mapstructure:"rpc_user"`
+	RPCPassword string `mapstructure:"rpc_password"`
+
+	ElectrumServers    []string `mapstructure:"electrum_servers"`     // host:port:s（TLS）或 host:port:t，按顺序故障转移
+	ElectrumSkipVerify bool     `mapstructure:"electrum_skip_verify"` // 跳过证书校验，仅用于自签名的私有服务器
+
+	RBF bool `mapstructure:"rbf"` // 交易声明可替换（BIP125），之后可用 tx.bump、tx.cancel 提高手续费或取消
+}
+
+// TronConfig 波场后端配置，用于查询余额、获取参考区块和广播交易
+type TronConfig struct {
+	GridURL  string `mapstructure:"grid_url"`  // TronGrid 或自建 java-tron 节点的 HTTP 接口
+	APIKey   string `mapstructure:"api_key"`   // TronGrid 的 TRON-PRO-API-KEY，可选
+	FeeLimit int64  `mapstructure:"fee_limit"` // TRC-20 转账最多燃烧的 TRX
+}
+
+// SubstrateConfig Substrate 链的节点配置，键为小写币种符号（dot、ksm）
+type SubstrateConfig map[string]SubstrateNetworkConfig
+
+// SubstrateNetworkConfig 单个 Substrate 网络；模块和调用下标随运行时变化，升级后如有变动在这里修改
+type SubstrateNetworkConfig struct {
+	RPCURL         string `mapstructure:"rpc_url"`         // 节点 HTTP JSON-RPC 地址
+	BalancesPallet int    `mapstructure:"balances_pallet"` // Balances 模块在运行时中的下标
+	TransferCall   int    `mapstructure:"transfer_call"`   // transfer_keep_alive 在 Balances 模块中的下标
+	MetadataHash   bool   `mapstructure:"metadata_hash"`   // 运行时包含 CheckMetadataHash 签名扩展
+}
+
+// StellarConfig Stellar 后端配置
+type StellarConfig struct {
+	HorizonURL        string `mapstructure:"horizon_url"`        // Horizon 接口地址
+	NetworkPassphrase string `mapstructure:"network_passphrase"` // 签名时使用的网络口令，测试网为 "Test SDF Network ; September 2015"
+}
+
+// XRPConfig XRP Ledger 后端配置
+type XRPConfig struct {
+	RPCURL string `mapstructure:"rpc_url"` // rippled JSON-RPC 地址
+	MaxFee uint64 `mapstructure:"max_fee"` // 网络拥堵时愿意支付的最高手续费（drops）
+}
+
+// NFTConfig ETH 账户的 ERC-721/ERC-1155 代币：持有的代币由 ETH 后端（节点或 Etherscan）的转账记录找出，再在链上核对
+type NFTConfig struct {
+	IPFSGateway   string `mapstructure:"ipfs_gateway"`   // ipfs:// 元数据和图片链接改写到的网关
+	FetchMetadata bool   `mapstructure:"fetch_metadata"` // 读取代币的元数据 JSON，会向元数据所在的服务器暴露 IP
+	FromBlock     uint64 `mapstructure:"from_block"`     // 使用节点时从这个区块开始扫描转账日志
+}
+
+// AAConfig ERC-4337 智能账户（实验性）：钱包中的 ETH 地址作为 SimpleAccount 的所有者，
+// 用户操作经 bundler 提交，可由 paymaster 代付 gas
+type AAConfig struct {
+	Enabled         bool   `mapstructure:"enabled"`          // 实验功能开关，关闭时 aa.* 命令不可用
+	BundlerURL      string `mapstructure:"bundler_url"`      // 支持 eth_sendUserOperation 的 bundler
+	PaymasterURL    string `mapstructure:"paymaster_url"`    // 支持 pm_sponsorUserOperation 的 paymaster，--sponsored 时使用
+	PaymasterPolicy string `mapstructure:"paymaster_policy"` // 传给 paymaster 的 sponsorshipPolicyId
+	GasToken        string `mapstructure:"gas_token"`        // 用这个 ERC-20 代币向 paymaster 支付 gas，为空时完全代付
+	EntryPoint      string `mapstructure:"entry_point"`      // EntryPoint v0.6 合约
+	Factory         string `mapstructure:"factory"`          // SimpleAccountFactory 合约
+}
+
+// MoneroConfig 门罗币只读钱包配置：由 monero-wallet-rpc 用地址和私有查看密钥扫描收款
+type MoneroConfig struct {
+	WalletRPCURL string `mapstructure:"wallet_rpc_url"` // monero-wallet-rpc 地址
+	RPCUsername  string `mapstructure:"rpc_username"`   // wallet-rpc 的 --rpc-login 用户名，HTTP digest 认证
+	RPCPassword  string `mapstructure:"rpc_password"`
+	DaemonURL    string `mapstructure:"daemon_url"` // 让 wallet-rpc 连接的 monerod，为空时使用它启动时的 --daemon-address
+}
+
+// ExplorerConfig 区块浏览器余额查询配置，按币种选择后端
+type ExplorerConfig struct {
+	CacheTTL int                              `mapstructure:"cache_ttl"` // 余额缓存有效期（秒）
+	Coins    map[string]ExplorerBackendConfig `mapstructure:"coins"`     // 键为小写币种符号，如 btc、eth
+}
+
+// ExplorerBackendConfig 单个币种的浏览器后端
+type ExplorerBackendConfig struct {
+	Backend string `mapstructure:"backend"` // blockstream | etherscan | solscan | blockchair | trongrid | cosmos | substrate，为空表示未启用
+	URL     string `mapstructure:"url"`     // 为空时使用后端的公共地址
+	APIKey  string `mapstructure:"api_key"`
+}
+
+// PrivacyConfig 隐私相关配置
+type PrivacyConfig struct {
+	StrictAddressReuse bool `mapstructure:"strict_address_reuse"` // 派生或展示已使用的地址时要求确认
+}
+
+// WatchConfig 收款监控配置
+type WatchConfig struct {
+	Interval int      `mapstructure:"interval"` // 轮询间隔（秒）
+	Webhooks []string `mapstructure:"webhooks"` // 收到付款、收款请求已支付和告警触发时 POST JSON 的地址
+}
+
+// NotifyConfig 事件通知配置（收到付款、收款请求已支付、自动锁定、告警），默认全部关闭
+type NotifyConfig struct {
+	Desktop  bool           `mapstructure:"desktop"` // 系统桌面通知（Linux notify-send，macOS osascript）
+	Events   []string       `mapstructure:"events"`  // 通知的事件类型，为空表示收款、收款请求、自动锁定和告警
+	Telegram TelegramConfig `mapstructure:"telegram"`
+}
+
+// TelegramConfig Telegram 机器人通知
+type TelegramConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	BotToken string `mapstructure:"bot_token"` // BotFather 发放的令牌
+	ChatID   string `mapstructure:"chat_id"`   // 接收通知的聊天或频道 ID
+	Template string `mapstructure:"template"`  // Go text/template 消息模板，可用 .Type .Time .Title .Body .Data
+	APIURL   string `mapstructure:"api_url"`   // Bot API 地址，自建 Bot API 服务器时修改
+}
+
+// NamesConfig 发送时把 ENS、Unstoppable Domains 和 SNS 名称解析为地址
+type NamesConfig struct {
+	CacheTTL          int    `mapstructure:"cache_ttl"`           // 解析结果缓存有效期（秒）
+	ReverseLookup     bool   `mapstructure:"reverse_lookup"`      // 签名确认时显示 ETH 收款地址的 ENS 主名称
+	UnstoppableURL    string `mapstructure:"unstoppable_url"`     // Resolution API 地址
+	UnstoppableAPIKey string `mapstructure:"unstoppable_api_key"` // Unstoppable Domains 的 API 密钥
+	SNSURL            string `mapstructure:"sns_url"`             // SNS 代理地址
+}
+
+// PriceConfig 法币汇率来源，用于按法币金额发送
+type PriceConfig struct {
+	Source             string  `mapstructure:"source"`               // coingecko，为空表示不查询汇率
+	URL                string  `mapstructure:"url"`                  // 为空时使用公共接口
+	APIKey             string  `mapstructure:"api_key"`              // CoinGecko demo API key，可选
+	CacheTTL           int     `mapstructure:"cache_ttl"`            // 汇率缓存有效期（秒）
+	MaxSlippagePercent float64 `mapstructure:"max_slippage_percent"` // 签名前重新查询的汇率允许的最大变化
+}
+
+// TaxConfig 成本基础报表：入账和支出按 Currency 记录当时的汇率
+type TaxConfig struct {
+	Currency string `mapstructure:"currency"` // 记录价格使用的法币，为空表示不记录
+	Method   string `mapstructure:"method"`   // 默认的成本计算方法：fifo 或 lifo
+}
+
+// IndexConfig 外部索引器：账户、地址和交易记录的变更事件
+type IndexConfig struct {
+	Webhooks []string `mapstructure:"webhooks"` // 接收变更事件 JSON POST 的地址
+}
+
+// AddressConfig 地址格式配置
+type AddressConfig struct {
+	// 小写币种符号 -> 默认地址格式。BTC 决定新账户的脚本类型（legacy、p2sh、bech32、bech32m），
+	// BCH（cashaddr、legacy）和 ETH（checksum、lowercase）只影响显示
+	Formats map[string]string `mapstructure:"formats"`
+	// 最后一个已使用的收款地址之后最多分配多少个未使用的地址，与其他钱包恢复时的扫描间隔一致；0 表示不限制
+	GapLimit int `mapstructure:"gap_limit"`
+}
+
+// PairingConfig 局域网配对（默认关闭）：向配对过的手机发送加密备份或只读描述符，只在本地网络中直连
+type PairingConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Listen  string `mapstructure:"listen"`  // 监听地址，如 192.168.1.5:0，必须是私有网络地址；为空时使用第一个私有网络地址和随机端口
+	Timeout int    `mapstructure:"timeout"` // 等待手机连接的秒数
+}
+
+// NetworkConfig 外部调用（RPC、区块浏览器、同步、webhook）的重试、熔断和超时策略
+type NetworkConfig struct {
+	Retries          int      `mapstructure:"retries"`           // 失败后的重试次数
+	BackoffMs        int      `mapstructure:"backoff_ms"`        // 第一次重试前的最大等待（毫秒），之后指数增长并随机抖动
+	MaxBackoffMs     int      `mapstructure:"max_backoff_ms"`    // 单次等待上限（毫秒）
+	BreakerThreshold int      `mapstructure:"breaker_threshold"` // 同一端点连续失败多少次后熔断，0 表示不熔断
+	BreakerCooldown  int      `mapstructure:"breaker_cooldown"`  // 熔断后多久放行试探请求（秒）
+	Timeouts         []string `mapstructure:"timeouts"`          // 按端点覆盖单次超时，格式 host[:port]=秒
+}
+
+// IntegrityConfig 存储目录防篡改配置
+type IntegrityConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 每次写入后更新带 HMAC 的文件哈希清单，解锁时校验
+}
+
+// HardeningConfig 启动时的进程加固配置（仅 Linux）
+type HardeningConfig struct {
+	MlockAll         bool `mapstructure:"mlockall"`           // 锁定全部内存页，RLIMIT_MEMLOCK 不是 unlimited 时自动跳过
+	DisableCoreDumps bool `mapstructure:"disable_core_dumps"` // RLIMIT_CORE=0
+	NoDumpable       bool `mapstructure:"no_dumpable"`        // PR_SET_DUMPABLE=0，禁止 core dump 和同用户进程 ptrace 附加
+}
+
+// EntropyConfig 生成助记词时额外的熵来源
+type EntropyConfig struct {
+	Device string `mapstructure:"device"` // 硬件随机数设备（如 /dev/hwrng），每次生成助记词都与系统随机数异或，为空表示不使用
+}
+
+// WalletConfig REPL 中钱包的会话设置
+type WalletConfig struct {
+	AutoLockMinutes      int `mapstructure:"auto_lock_minutes"`      // 无操作多少分钟后自动锁定，0 表示不自动锁定
+	MnemonicClearSeconds int `mapstructure:"mnemonic_clear_seconds"` // 助记词显示多少秒后自动清屏
+}
+
+// MockchainConfig 内置模拟链配置，在 bitcoin.backend 或 explorer.coins.<币种>.backend 中设为 mockchain 时使用
+type MockchainConfig struct {
+	Seed          string `mapstructure:"seed"`           // 决定初始资金、手续费和交易 ID，相同的种子得到相同的链
+	BlockInterval int    `mapstructure:"block_interval"` // 自动出块间隔（秒），0 表示只在 mockchain.mine 时出块
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days"` // 删除的账户和地址保留天数，过期后启动时永久删除，0 表示不自动删除
+}
+
+// BackupConfig 公钥加密备份配置，不需要钱包密码，可以在 serve 模式下定时创建
+type BackupConfig struct {
+	Recipients    []string `mapstructure:"recipients"`     // age1... 公钥，不支持 OpenPGP
+	Dir           string   `mapstructure:"dir"`            // 备份文件写入的目录
+	IntervalHours int      `mapstructure:"interval_hours"` // serve 模式下定时备份的间隔（小时），0 表示不定时备份
+}
+
+// AirgapConfig 冷热分离签名请求的配置
+type AirgapConfig struct {
+	RequestTTLMinutes     int `mapstructure:"request_ttl_minutes"`      // 签名请求的有效期（分钟），过期后签名端拒绝签名
+	SnapshotMaxAgeMinutes int `mapstructure:"snapshot_max_age_minutes"` // 行情快照超过该时间后签名端提示已过期
+}
+
+// AnomalyConfig 按签名历史识别异常签名请求的配置
+type AnomalyConfig struct {
+	Enabled       bool  `mapstructure:"enabled"`
+	AmountFactor  int64 `mapstructure:"amount_factor"`  // 金额达到常见金额的这个倍数时视为异常
+	MinSignatures int   `mapstructure:"min_signatures"` // 密钥签名次数达到这个数之后才开始判断
+}
+
+// ApprovalConfig serve 模式下团队钱包的签名审批配置
+type ApprovalConfig struct {
+	RequiredApprovals int   `mapstructure:"required_approvals"` // 签名前需要的批准数，请求者本人不计入
+	ExpiryMinutes     int   `mapstructure:"expiry_minutes"`     // 待审批请求的有效期（分钟）
+	ChainID           int64 `mapstructure:"chain_id"`           // 交易未指定 chainId 时使用
 }
 
 // Load 加载配置并初始化日志
@@ -64,37 +409,277 @@ func Load() error {
 	v.AutomaticEnv()
 
 	// 6. 反序列化到结构体
-	if err := v.Unmarshal(&appConfig); err != nil {
-		return fmt.Errorf("unable to decode config into struct: %w", err)
+	next, legacyRPC, err := decode(v)
+	if err != nil {
+		return err
 	}
+	setAppConfig(next)
 
 	// 7. 初始化日志系统
-	if err := setupLogging(appConfig.Log); err != nil {
+	if err := setupLogging(next.Log); err != nil {
+		return err
+	}
+	if err := setupTracing(next.Tracing); err != nil {
 		return err
 	}
 
 	// 记录配置加载信息
 	logConfigSources(v)
+	if legacyRPC {
+		logging.Warnf("rpc.endpoint is deprecated, move it to [rpc.eth.mainnet] endpoint = %q", next.RPC.Endpoint)
+	}
 
 	return nil
 }
 
+// Reload 运行中重新读取配置文件（slowmade ctl reload），新配置无效时保留当前配置；
+// 数据目录、监听地址和链路追踪等启动时使用的配置需要重启才生效
+func Reload() error {
+	v := viper.GetViper()
+	if err := setupConfigFile(v); err != nil {
+		return err
+	}
+	next, _, err := decode(v)
+	if err != nil {
+		return err
+	}
+	if err := setupLogging(next.Log); err != nil {
+		return err
+	}
+	setAppConfig(next)
+	logConfigSources(v)
+	return nil
+}
+
+// decode 把 viper 中的配置反序列化并校验，返回是否使用了旧的 rpc.endpoint
+func decode(v *viper.Viper) (AppConfig, bool, error) {
+	var next AppConfig
+	if err := v.Unmarshal(&next); err != nil {
+		return AppConfig{}, false, fmt.Errorf("%w: unable to decode config into struct: %v", ErrInvalid, err)
+	}
+	legacyRPC := next.RPC.migrateLegacyEndpoint()
+	if err := next.RPC.Validate(); err != nil {
+		return AppConfig{}, false, fmt.Errorf("%w: rpc: %v", ErrInvalid, err)
+	}
+	if err := next.Cosmos.Validate(); err != nil {
+		return AppConfig{}, false, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := next.Log.Remote.Validate(); err != nil {
+		return AppConfig{}, false, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := next.Tracing.Validate(); err != nil {
+		return AppConfig{}, false, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return next, legacyRPC, nil
+}
+
 // setDefaults 设置所有配置的默认值
 func setDefaults(v *viper.Viper) {
 	// RPC 配置默认值
-	v.SetDefault("rpc.endpoint", "http://localhost:8545")
+	v.SetDefault("rpc.network", "mainnet")
 	v.SetDefault("rpc.timeout", 30)
 
 	// Keystore 配置默认值
 	v.SetDefault("keystore.path", "./keystore")
 
+	// 存储配置默认值
+	v.SetDefault("storage.permissions", "warn")
+	v.SetDefault("storage.cache_entries", 256)
+
 	// 日志配置默认值
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.encoding", "console")
 	v.SetDefault("log.file", "")
+	v.SetDefault("log.remote.protocol", "")
+	v.SetDefault("log.remote.address", "")
+	v.SetDefault("log.remote.level", "")
+	v.SetDefault("log.remote.batch_size", 100)
+	v.SetDefault("log.remote.flush_seconds", 2)
+	v.SetDefault("log.remote.queue_size", 10000)
+	v.SetDefault("log.remote.facility", "daemon")
+	v.SetDefault("log.remote.service_name", "slowmade")
+	v.SetDefault("log.remote.scrub_fields", []string{})
+
+	// 链路追踪默认值
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("tracing.service_name", "slowmade")
 
 	// UI 配置默认值
 	v.SetDefault("ui.lang", "en")
+	v.SetDefault("ui.locales_dir", "")
+	v.SetDefault("ui.timezone", "")
+	v.SetDefault("ui.qr", "auto")
+	v.SetDefault("ui.qr_content", "auto")
+	v.SetDefault("ui.accessible", false)
+	v.SetDefault("ui.slow_hint", 3)
+	v.SetDefault("ui.prompt", "")
+
+	// 同步配置默认值
+	v.SetDefault("sync.backend", "")
+	v.SetDefault("sync.region", "us-east-1")
+	v.SetDefault("sync.prefix", "slowmade")
+
+	// WalletConnect 配置默认值
+	v.SetDefault("walletconnect.relay_url", "wss://relay.walletconnect.com")
+
+	// 比特币后端配置默认值
+	v.SetDefault("bitcoin.backend", "electrum")
+	v.SetDefault("bitcoin.electrum_servers", []string{
+		"electrum.blockstream.info:50002:s",
+		"electrum.emzy.de:50002:s",
+		"electrum.bitaroo.net:50002:s",
+	})
+	v.SetDefault("bitcoin.rbf", true)
+
+	// 波场后端配置默认值
+	v.SetDefault("tron.grid_url", "https://api.trongrid.io")
+	v.SetDefault("tron.fee_limit", 30)
+
+	// Substrate 网络默认值
+	v.SetDefault("substrate.dot.rpc_url", "https://rpc.polkadot.io")
+	v.SetDefault("substrate.dot.balances_pallet", 5)
+	v.SetDefault("substrate.dot.transfer_call", 3)
+	v.SetDefault("substrate.dot.metadata_hash", true)
+	v.SetDefault("substrate.ksm.rpc_url", "https://kusama-rpc.polkadot.io")
+	v.SetDefault("substrate.ksm.balances_pallet", 4)
+	v.SetDefault("substrate.ksm.transfer_call", 3)
+	v.SetDefault("substrate.ksm.metadata_hash", true)
+
+	// Stellar 和 XRP Ledger 后端默认值
+	v.SetDefault("stellar.horizon_url", "https://horizon.stellar.org")
+	v.SetDefault("stellar.network_passphrase", "Public Global Stellar Network ; September 2015")
+	v.SetDefault("xrp.rpc_url", "https://s1.ripple.com:51234")
+	v.SetDefault("xrp.max_fee", 5000)
+
+	// NFT 默认值
+	v.SetDefault("nft.ipfs_gateway", "https://ipfs.io/ipfs/")
+	v.SetDefault("nft.fetch_metadata", true)
+
+	// ERC-4337 智能账户默认值，默认关闭
+	v.SetDefault("aa.enabled", false)
+	v.SetDefault("aa.entry_point", "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	v.SetDefault("aa.factory", "0x9406Cc6185a346906296840746125a0E44976454")
+
+	// 门罗币只读钱包默认值
+	v.SetDefault("monero.wallet_rpc_url", "http://127.0.0.1:18083")
+
+	// Cosmos SDK 链默认值，所有链都使用币种类型 118
+	v.SetDefault("cosmos.default_chain", "cosmoshub")
+	for name, chain := range map[string][6]string{
+		"cosmoshub": {"cosmoshub-4", "cosmos", "uatom", "ATOM", "https://cosmos-rest.publicnode.com", "0.025"},
+		"osmosis":   {"osmosis-1", "osmo", "uosmo", "OSMO", "https://osmosis-rest.publicnode.com", "0.0025"},
+		"juno":      {"juno-1", "juno", "ujuno", "JUNO", "https://juno-rest.publicnode.com", "0.075"},
+	} {
+		v.SetDefault("cosmos.chains."+name+".chain_id", chain[0])
+		v.SetDefault("cosmos.chains."+name+".hrp", chain[1])
+		v.SetDefault("cosmos.chains."+name+".denom", chain[2])
+		v.SetDefault("cosmos.chains."+name+".symbol", chain[3])
+		v.SetDefault("cosmos.chains."+name+".lcd_url", chain[4])
+		v.SetDefault("cosmos.chains."+name+".decimals", 6)
+		v.SetDefault("cosmos.chains."+name+".gas_price", chain[5])
+		v.SetDefault("cosmos.chains."+name+".gas_limit", 200000)
+	}
+
+	// 区块浏览器配置默认值
+	v.SetDefault("explorer.cache_ttl", 600)
+	v.SetDefault("explorer.coins.btc.backend", "blockstream")
+	v.SetDefault("explorer.coins.eth.backend", "etherscan")
+	v.SetDefault("explorer.coins.sol.backend", "solscan")
+	v.SetDefault("explorer.coins.ltc.backend", "blockchair")
+	v.SetDefault("explorer.coins.doge.backend", "blockchair")
+	v.SetDefault("explorer.coins.bch.backend", "blockchair")
+	v.SetDefault("explorer.coins.trx.backend", "trongrid")
+	v.SetDefault("explorer.coins.atom.backend", "cosmos")
+	v.SetDefault("explorer.coins.dot.backend", "substrate")
+	v.SetDefault("explorer.coins.ksm.backend", "substrate")
+	v.SetDefault("explorer.coins.xlm.backend", "horizon")
+	v.SetDefault("explorer.coins.xrp.backend", "rippled")
+	v.SetDefault("bitcoin.rpc_url", "http://127.0.0.1:8332")
+
+	// 隐私配置默认值
+	v.SetDefault("privacy.strict_address_reuse", false)
+
+	// 网络调用策略默认值
+	v.SetDefault("network.retries", 2)
+	v.SetDefault("network.backoff_ms", 500)
+	v.SetDefault("network.max_backoff_ms", 5000)
+	v.SetDefault("network.breaker_threshold", 5)
+	v.SetDefault("network.breaker_cooldown", 60)
+	v.SetDefault("network.timeouts", []string{})
+
+	// 收款监控配置默认值
+	v.SetDefault("watch.interval", 60)
+	v.SetDefault("watch.webhooks", []string{})
+
+	// 防篡改配置默认值
+	v.SetDefault("integrity.enabled", false)
+
+	// 进程加固配置默认值
+	v.SetDefault("hardening.mlockall", true)
+	v.SetDefault("hardening.disable_core_dumps", true)
+	v.SetDefault("hardening.no_dumpable", true)
+	v.SetDefault("entropy.device", "")
+	v.SetDefault("wallet.auto_lock_minutes", 0)
+	v.SetDefault("wallet.mnemonic_clear_seconds", 60)
+
+	// 模拟链配置默认值
+	v.SetDefault("mockchain.seed", "slowmade-mockchain")
+	v.SetDefault("mockchain.block_interval", 0)
+
+	// 回收站配置默认值
+	v.SetDefault("trash.retention_days", 30)
+
+	// 签名审批配置默认值
+	v.SetDefault("approval.required_approvals", 1)
+	v.SetDefault("approval.expiry_minutes", 60)
+	v.SetDefault("approval.chain_id", 1)
+
+	// 公钥加密备份配置默认值
+	v.SetDefault("backup.recipients", []string{})
+	v.SetDefault("backup.dir", "")
+	v.SetDefault("backup.interval_hours", 0)
+
+	// 冷热分离签名配置默认值
+	v.SetDefault("airgap.request_ttl_minutes", 60)
+	v.SetDefault("airgap.snapshot_max_age_minutes", 120)
+
+	// 异常签名识别默认值
+	v.SetDefault("anomaly.enabled", true)
+	v.SetDefault("anomaly.amount_factor", 10)
+	v.SetDefault("anomaly.min_signatures", 5)
+
+	// 名称解析配置默认值
+	v.SetDefault("names.cache_ttl", 3600)
+	v.SetDefault("names.reverse_lookup", true)
+	v.SetDefault("names.unstoppable_url", "https://api.unstoppabledomains.com")
+	v.SetDefault("names.unstoppable_api_key", "")
+	v.SetDefault("names.sns_url", "https://sns-sdk-proxy.bonfida.workers.dev")
+
+	// 汇率配置默认值
+	v.SetDefault("price.source", "coingecko")
+	v.SetDefault("price.url", "")
+	v.SetDefault("price.api_key", "")
+	v.SetDefault("price.cache_ttl", 60)
+	v.SetDefault("price.max_slippage_percent", 1.0)
+	v.SetDefault("tax.currency", "")
+	v.SetDefault("tax.method", "fifo")
+	v.SetDefault("index.webhooks", []string{})
+	v.SetDefault("address.formats", map[string]string{})
+	v.SetDefault("address.gap_limit", 20)
+	v.SetDefault("pairing.enabled", false)
+	v.SetDefault("pairing.listen", "")
+	v.SetDefault("pairing.timeout", 300)
+
+	// 事件通知配置默认值
+	v.SetDefault("notify.desktop", false)
+	v.SetDefault("notify.events", []string{})
+	v.SetDefault("notify.telegram.enabled", false)
+	v.SetDefault("notify.telegram.bot_token", "")
+	v.SetDefault("notify.telegram.chat_id", "")
+	v.SetDefault("notify.telegram.template", "{{.Title}}\n{{.Body}}")
+	v.SetDefault("notify.telegram.api_url", "https://api.telegram.org")
 }
 
 // bindEnvironmentVariables 绑定环境变量映射
@@ -106,13 +691,27 @@ func bindEnvironmentVariables(v *viper.Viper) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// 显式绑定关键环境变量（确保正确的映射关系）
-	v.BindEnv("rpc.endpoint")  // 对应 SLOWMADE_RPC_ENDPOINT
-	v.BindEnv("rpc.timeout")   // 对应 SLOWMADE_RPC_TIMEOUT
-	v.BindEnv("keystore.path") // 对应 SLOWMADE_KEYSTORE_PATH
-	v.BindEnv("log.level")     // 对应 SLOWMADE_LOG_LEVEL
-	v.BindEnv("log.file")      // 对应 SLOWMADE_LOG_FILE
-	v.BindEnv("log.encoding")  // 对应 SLOWMADE_LOG_ENCODING
-	v.BindEnv("ui.lang")       // 对应 SLOWMADE_UI_LANG
+	v.BindEnv("rpc.endpoint")               // 对应 SLOWMADE_RPC_ENDPOINT
+	v.BindEnv("rpc.timeout")                // 对应 SLOWMADE_RPC_TIMEOUT
+	v.BindEnv("rpc.network")                // 对应 SLOWMADE_RPC_NETWORK
+	v.BindEnv("keystore.path")              // 对应 SLOWMADE_KEYSTORE_PATH
+	v.BindEnv("log.level")                  // 对应 SLOWMADE_LOG_LEVEL
+	v.BindEnv("log.file")                   // 对应 SLOWMADE_LOG_FILE
+	v.BindEnv("log.encoding")               // 对应 SLOWMADE_LOG_ENCODING
+	v.BindEnv("ui.lang")                    // 对应 SLOWMADE_UI_LANG
+	v.BindEnv("ui.timezone")                // 对应 SLOWMADE_UI_TIMEZONE
+	v.BindEnv("sync.password")              // 对应 SLOWMADE_SYNC_PASSWORD
+	v.BindEnv("sync.access_key")            // 对应 SLOWMADE_SYNC_ACCESS_KEY
+	v.BindEnv("sync.secret_key")            // 对应 SLOWMADE_SYNC_SECRET_KEY
+	v.BindEnv("walletconnect.project_id")   // 对应 SLOWMADE_WALLETCONNECT_PROJECT_ID
+	v.BindEnv("bitcoin.rpc_password")       // 对应 SLOWMADE_BITCOIN_RPC_PASSWORD
+	v.BindEnv("tron.api_key")               // 对应 SLOWMADE_TRON_API_KEY
+	v.BindEnv("monero.rpc_password")        // 对应 SLOWMADE_MONERO_RPC_PASSWORD
+	v.BindEnv("explorer.coins.eth.api_key") // 对应 SLOWMADE_EXPLORER_COINS_ETH_API_KEY
+	v.BindEnv("explorer.coins.sol.api_key") // 对应 SLOWMADE_EXPLORER_COINS_SOL_API_KEY
+	v.BindEnv("notify.telegram.bot_token")  // 对应 SLOWMADE_NOTIFY_TELEGRAM_BOT_TOKEN
+	v.BindEnv("names.unstoppable_api_key")  // 对应 SLOWMADE_NAMES_UNSTOPPABLE_API_KEY
+	v.BindEnv("price.api_key")              // 对应 SLOWMADE_PRICE_API_KEY
 }
 
 // setupConfigFile 设置和读取配置文件
@@ -129,7 +728,7 @@ func setupConfigFile(v *viper.Viper) error {
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			// 如果是配置文件找到但解析错误，返回错误
-			return fmt.Errorf("config file found but unable to read: %w", err)
+			return fmt.Errorf("%w: config file found but unable to read: %v", ErrInvalid, err)
 		}
 		// 配置文件不存在是正常的，使用默认值+环境变量+命令行参数
 	}
@@ -139,10 +738,14 @@ func setupConfigFile(v *viper.Viper) error {
 
 // setupLogging 初始化日志系统
 func setupLogging(logConfig LogConfig) error {
+	remote := logConfig.Remote
+	remote.Version = version.Get().GitVersion
+	remote.Redact = redact.String
 	config := logging.Config{
 		Level:    logConfig.Level,
 		Encoding: logConfig.Encoding,
 		File:     logConfig.File,
+		Remote:   remote,
 	}
 
 	if err := logging.Init(config); err != nil {
@@ -152,6 +755,28 @@ func setupLogging(logConfig LogConfig) error {
 	return nil
 }
 
+// setupTracing 启用时开始发送链路追踪
+func setupTracing(tracingConfig TracingConfig) error {
+	options := tracing.Options{}
+	if tracingConfig.Enabled {
+		host, _ := os.Hostname()
+		options = tracing.Options{
+			Endpoint:    tracingConfig.Endpoint,
+			TLS:         otlp.TLSFiles{CAFile: tracingConfig.CAFile, CertFile: tracingConfig.CertFile, KeyFile: tracingConfig.KeyFile},
+			Headers:     tracingConfig.Headers,
+			SampleRatio: tracingConfig.SampleRatio,
+			ServiceName: tracingConfig.ServiceName,
+			Version:     version.Get().GitVersion,
+			Host:        host,
+			Redact:      redact.String,
+		}
+	}
+	if err := tracing.Init(options); err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	return nil
+}
+
 // logConfigSources 记录配置加载的来源信息
 func logConfigSources(v *viper.Viper) {
 	logger := logging.Get()
@@ -166,7 +791,8 @@ func logConfigSources(v *viper.Viper) {
 
 	// 记录重要的配置值（敏感信息需要脱敏）
 	logger.Debug("Configuration values",
-		zap.String("rpc.endpoint", v.GetString("rpc.endpoint")),
+		zap.String("rpc.network", v.GetString("rpc.network")),
+		zap.Strings("rpc.coins", GetAppConfig().RPC.Configured()),
 		zap.Int("rpc.timeout", v.GetInt("rpc.timeout")),
 		zap.String("log.level", v.GetString("log.level")),
 		zap.String("ui.lang", v.GetString("ui.lang")),
@@ -199,8 +825,170 @@ func (c *AppConfig) GetUIConfig() UIConfig {
 	return c.UI
 }
 
-var appConfig AppConfig
+// GetSyncConfig 返回云同步相关的配置
+func (c *AppConfig) GetSyncConfig() SyncConfig {
+	return c.Sync
+}
+
+// GetWalletConnectConfig 返回 WalletConnect 相关的配置
+func (c *AppConfig) GetWalletConnectConfig() WalletConnectConfig {
+	return c.WalletConnect
+}
+
+// GetBitcoinConfig 返回比特币后端相关的配置
+func (c *AppConfig) GetBitcoinConfig() BitcoinConfig {
+	return c.Bitcoin
+}
+
+// GetCosmosConfig 返回 Cosmos SDK 链的配置
+func (c *AppConfig) GetCosmosConfig() CosmosConfig {
+	return c.Cosmos
+}
+
+// GetSubstrateConfig 返回币种所在 Substrate 网络的节点配置
+func (c *AppConfig) GetSubstrateConfig(symbol string) (SubstrateNetworkConfig, bool) {
+	network, ok := c.Substrate[strings.ToLower(symbol)]
+	return network, ok && network.RPCURL != ""
+}
+
+// GetStellarConfig 返回 Stellar 后端相关的配置
+func (c *AppConfig) GetStellarConfig() StellarConfig {
+	return c.Stellar
+}
+
+// GetXRPConfig 返回 XRP Ledger 后端相关的配置
+func (c *AppConfig) GetXRPConfig() XRPConfig {
+	return c.XRP
+}
+
+// GetNFTConfig 返回 NFT 相关的配置
+func (c *AppConfig) GetNFTConfig() NFTConfig {
+	return c.NFT
+}
+
+// GetAAConfig 返回 ERC-4337 智能账户相关的配置
+func (c *AppConfig) GetAAConfig() AAConfig {
+	return c.AA
+}
+
+// GetMoneroConfig 返回门罗币只读钱包相关的配置
+func (c *AppConfig) GetMoneroConfig() MoneroConfig {
+	return c.Monero
+}
+
+// GetTronConfig 返回波场后端相关的配置
+func (c *AppConfig) GetTronConfig() TronConfig {
+	return c.Tron
+}
+
+// GetExplorerConfig 返回区块浏览器相关的配置
+func (c *AppConfig) GetExplorerConfig() ExplorerConfig {
+	return c.Explorer
+}
+
+// GetPrivacyConfig 返回隐私相关的配置
+func (c *AppConfig) GetPrivacyConfig() PrivacyConfig {
+	return c.Privacy
+}
+
+// GetWatchConfig 返回收款监控相关的配置
+func (c *AppConfig) GetWatchConfig() WatchConfig {
+	return c.Watch
+}
+
+// GetNetworkConfig 返回外部调用策略相关的配置
+func (c *AppConfig) GetNetworkConfig() NetworkConfig {
+	return c.Network
+}
+
+// GetIntegrityConfig 返回存储目录防篡改相关的配置
+func (c *AppConfig) GetIntegrityConfig() IntegrityConfig {
+	return c.Integrity
+}
+
+// GetHardeningConfig 返回进程加固相关的配置
+func (c *AppConfig) GetHardeningConfig() HardeningConfig {
+	return c.Hardening
+}
+
+// GetEntropyConfig 返回额外熵来源的配置
+func (c *AppConfig) GetEntropyConfig() EntropyConfig {
+	return c.Entropy
+}
+
+// GetWalletConfig 返回钱包会话相关的配置
+func (c *AppConfig) GetWalletConfig() WalletConfig {
+	return c.Wallet
+}
+
+// GetMockchainConfig 返回模拟链相关的配置
+func (c *AppConfig) GetMockchainConfig() MockchainConfig {
+	return c.Mockchain
+}
+
+// GetTrashConfig 返回回收站相关的配置
+func (c *AppConfig) GetTrashConfig() TrashConfig {
+	return c.Trash
+}
+
+// GetApprovalConfig 返回签名审批相关的配置
+func (c *AppConfig) GetApprovalConfig() ApprovalConfig {
+	return c.Approval
+}
+
+// GetBackupConfig 返回公钥加密备份相关的配置
+func (c *AppConfig) GetBackupConfig() BackupConfig {
+	return c.Backup
+}
+
+// GetPriceConfig 返回汇率相关的配置
+func (c *AppConfig) GetPriceConfig() PriceConfig {
+	return c.Price
+}
+
+// GetTaxConfig 返回成本基础报表相关的配置
+func (c *AppConfig) GetTaxConfig() TaxConfig {
+	return c.Tax
+}
+
+// GetIndexConfig 返回外部索引器相关的配置
+func (c *AppConfig) GetIndexConfig() IndexConfig {
+	return c.Index
+}
+
+// GetNamesConfig 返回名称解析相关的配置
+func (c *AppConfig) GetNamesConfig() NamesConfig {
+	return c.Names
+}
+
+// GetNotifyConfig 返回事件通知相关的配置
+func (c *AppConfig) GetNotifyConfig() NotifyConfig {
+	return c.Notify
+}
+
+// GetAirgapConfig 返回冷热分离签名相关的配置
+func (c *AppConfig) GetAirgapConfig() AirgapConfig {
+	return c.Airgap
+}
+
+// GetAnomalyConfig 返回异常签名识别相关的配置
+func (c *AppConfig) GetAnomalyConfig() AnomalyConfig {
+	return c.Anomaly
+}
+
+var (
+	appConfig   AppConfig
+	appConfigMu sync.RWMutex // Reload 可能与读取配置的请求并发
+)
 
 func GetAppConfig() AppConfig {
+	appConfigMu.RLock()
+	defer appConfigMu.RUnlock()
 	return appConfig
 }
+
+func setAppConfig(next AppConfig) {
+	appConfigMu.Lock()
+	defer appConfigMu.Unlock()
+	appConfig = next
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cosmosHRP bech32 人类可读前缀：小写字母和数字
+var cosmosHRP = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// CosmosConfig Cosmos SDK 链配置，同一个币种类型 118 的账户可以在每条链上使用
+type CosmosConfig struct {
+	DefaultChain string                       `mapstructure:"default_chain"` // 命令未指定 --chain 时使用的链
+	Chains       map[string]CosmosChainConfig `mapstructure:"chains"`        // 链名称（小写）-> 链参数
+}
+
+// CosmosChainConfig 单条 Cosmos SDK 链
+type CosmosChainConfig struct {
+	ChainID  string `mapstructure:"chain_id"`  // 签名使用的链 ID，如 cosmoshub-4
+	HRP      string `mapstructure:"hrp"`       // 地址前缀，如 cosmos、osmo
+	Denom    string `mapstructure:"denom"`     // 手续费和转账的最小单位，如 uatom
+	Symbol   string `mapstructure:"symbol"`    // 显示单位，如 ATOM
+	Decimals int    `mapstructure:"decimals"`  // Symbol 与 Denom 之间的精度
+	LCDURL   string `mapstructure:"lcd_url"`   // REST（LCD）接口，查询账户、余额和广播交易
+	GasPrice string `mapstructure:"gas_price"` // 每单位 gas 的最小单位数，可以是小数
+	GasLimit uint64 `mapstructure:"gas_limit"` // 转账默认的 gas 上限
+}
+
+// Chain 按名称查找链，名称为空时使用 default_chain
+func (c CosmosConfig) Chain(name string) (string, CosmosChainConfig, error) {
+	if name == "" {
+		name = c.DefaultChain
+	}
+	name = strings.ToLower(name)
+	chain, ok := c.Chains[name]
+	if !ok {
+		return "", CosmosChainConfig{}, fmt.Errorf("unknown cosmos chain %q, configured: %s", name, strings.Join(c.Names(), ", "))
+	}
+	return name, chain, nil
+}
+
+// ChainByHRP 按地址前缀查找链
+func (c CosmosConfig) ChainByHRP(hrp string) (string, CosmosChainConfig, bool) {
+	for _, name := range c.Names() {
+		if c.Chains[name].HRP == hrp {
+			return name, c.Chains[name], true
+		}
+	}
+	return "", CosmosChainConfig{}, false
+}
+
+// Names 已配置的链名称，按字母排序
+func (c CosmosConfig) Names() []string {
+	names := make([]string, 0, len(c.Chains))
+	for name := range c.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GasPriceRat gas 单价，配置无效时返回 nil（Validate 已经检查过）
+func (c CosmosChainConfig) GasPriceRat() *big.Rat {
+	price, ok := new(big.Rat).SetString(c.GasPrice)
+	if !ok {
+		return nil
+	}
+	return price
+}
+
+// Validate 检查每条链的前缀、单位和 gas 设置
+func (c CosmosConfig) Validate() error {
+	if c.DefaultChain != "" {
+		if _, ok := c.Chains[strings.ToLower(c.DefaultChain)]; !ok {
+			return fmt.Errorf("cosmos.default_chain %q is not configured", c.DefaultChain)
+		}
+	}
+	for name, chain := range c.Chains {
+		key := "cosmos.chains." + name
+		switch {
+		case chain.ChainID == "":
+			return fmt.Errorf("%s.chain_id is required", key)
+		case !cosmosHRP.MatchString(chain.HRP):
+			return fmt.Errorf("%s.hrp %q must be lowercase letters and digits", key, chain.HRP)
+		case chain.Denom == "":
+			return fmt.Errorf("%s.denom is required", key)
+		case chain.Decimals < 0 || chain.Decimals > 18:
+			return fmt.Errorf("%s.decimals must be between 0 and 18", key)
+		case chain.GasLimit == 0:
+			return fmt.Errorf("%s.gas_limit must be positive", key)
+		}
+		if price := chain.GasPriceRat(); price == nil || price.Sign() < 0 {
+			return fmt.Errorf("%s.gas_price %q is not a valid number", key, chain.GasPrice)
+		}
+	}
+	return nil
+}
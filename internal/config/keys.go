@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DeprecatedKeys 已废弃的配置键 -> 取代它的键
+var DeprecatedKeys = map[string]string{
+	"rpc.endpoint": "rpc.eth.mainnet.endpoint",
+}
+
+// FileKeys 配置文件中直接写出的全部键（不含默认值、环境变量和命令行参数），没有配置文件时返回空
+func FileKeys() ([]string, error) {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return nil, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// UnknownKeys 不对应任何设置的键，多半是拼写错误或已经删除的设置，读取配置时被忽略
+func UnknownKeys(keys []string) []string {
+	var unknown []string
+	for _, key := range keys {
+		if !knownKey(reflect.TypeOf(AppConfig{}), strings.Split(key, ".")) {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
+
+// knownKey 按 mapstructure 标签逐级匹配键；映射接受任意键名，remain 字段接受结构体中其余的键
+func knownKey(t reflect.Type, parts []string) bool {
+	if len(parts) == 0 {
+		return true
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var remain *reflect.StructField
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			switch {
+			case strings.Contains(options, "remain"):
+				remain = &field
+			case strings.Contains(options, "squash"):
+				if knownKey(field.Type, parts) {
+					return true
+				}
+			case strings.EqualFold(name, parts[0]):
+				return knownKey(field.Type, parts[1:])
+			}
+		}
+		return remain != nil && knownKey(remain.Type, parts)
+	case reflect.Map:
+		return knownKey(t.Elem(), parts[1:])
+	case reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// tomlTable 匹配 TOML 表头，如 [rpc] 或 [rpc.eth.mainnet]
+var tomlTable = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9_.\-]+)\s*\]`)
+
+// MigrateLegacyEndpoint 改写 TOML 配置文件：注释掉 [rpc] 中的 endpoint，把它写到 [rpc.eth.mainnet]；
+// 已有 rpc.eth.mainnet.endpoint 时旧键本来就被忽略，只注释掉。原文件保留为 <文件>.bak，返回该路径
+func MigrateLegacyEndpoint(file string) (string, error) {
+	if !strings.EqualFold(filepath.Ext(file), ".toml") {
+		return "", fmt.Errorf("%s: only TOML config files can be rewritten, move rpc.endpoint by hand", file)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	table, value := "", ""
+	mainnet, mainnetEndpoint := -1, false
+	for i, line := range lines {
+		if m := tomlTable.FindStringSubmatch(line); m != nil {
+			table = strings.ToLower(m[1])
+			if table == "rpc.eth.mainnet" {
+				mainnet = i
+			}
+			continue
+		}
+		key, rest, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.TrimSpace(key) != "endpoint" {
+			continue
+		}
+		switch table {
+		case "rpc":
+			value = strings.TrimSpace(rest)
+			lines[i] = "# " + line + " # moved to [rpc.eth.mainnet] by upgrade-check"
+		case "rpc.eth.mainnet":
+			mainnetEndpoint = true
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s: no endpoint in the [rpc] table", file)
+	}
+	switch {
+	case mainnetEndpoint:
+	case mainnet >= 0:
+		lines = append(lines[:mainnet+1], append([]string{"endpoint = " + value}, lines[mainnet+1:]...)...)
+	default:
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(lines, "", "[rpc.eth.mainnet]", "endpoint = "+value, "")
+	}
+
+	backup := file + ".bak"
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	tempFile := file + ".tmp"
+	if err := os.WriteFile(tempFile, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Rename(tempFile, file); err != nil {
+		return "", fmt.Errorf("重命名配置文件失败: %w", err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// rpcSchemes 支持的节点协议
+var rpcSchemes = map[string]bool{"http": true, "https": true, "ssl": true, "tcp": true}
+
+// rpcName 币种和网络名称：小写字母、数字和连字符
+var rpcName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Node 返回币种在当前网络（rpc.network）上的节点，timeout 已用 rpc.timeout 补全
+func (c RPCConfig) Node(symbol string) (RPCEndpointConfig, bool) {
+	node, ok := c.Coins[strings.ToLower(symbol)][c.CurrentNetwork()]
+	if !ok || node.Endpoint == "" {
+		return RPCEndpointConfig{}, false
+	}
+	if node.Timeout == 0 {
+		node.Timeout = c.Timeout
+	}
+	return node, true
+}
+
+// Configured 当前网络上配置了节点的币种，按字母排序
+func (c RPCConfig) Configured() []string {
+	var symbols []string
+	for symbol, networks := range c.Coins {
+		if _, ok := networks[c.CurrentNetwork()]; ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Validate 检查每个节点的地址、协议、超时和认证设置
+func (c RPCConfig) Validate() error {
+	if !rpcName.MatchString(c.CurrentNetwork()) {
+		return fmt.Errorf("rpc.network %q is not a valid network name", c.Network)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("rpc.timeout must not be negative")
+	}
+	for symbol, networks := range c.Coins {
+		if !rpcName.MatchString(symbol) {
+			return fmt.Errorf("rpc.%s: coin must be a lowercase symbol such as btc or eth", symbol)
+		}
+		for network, node := range networks {
+			key := "rpc." + symbol + "." + network
+			if !rpcName.MatchString(network) {
+				return fmt.Errorf("%s: %q is not a valid network name", key, network)
+			}
+			if err := node.validate(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n RPCEndpointConfig) validate(key string) error {
+	if n.Endpoint == "" {
+		return fmt.Errorf("%s.endpoint is required", key)
+	}
+	scheme, err := rpcScheme(n.Endpoint)
+	if err != nil {
+		return fmt.Errorf("%s.endpoint: %v", key, err)
+	}
+	for _, fallback := range n.Fallbacks {
+		other, err := rpcScheme(fallback)
+		if err != nil {
+			return fmt.Errorf("%s.fallbacks: %v", key, err)
+		}
+		if electrumScheme(other) != electrumScheme(scheme) {
+			return fmt.Errorf("%s.fallbacks: %s uses a different protocol than the endpoint", key, fallback)
+		}
+	}
+	if n.Timeout < 0 {
+		return fmt.Errorf("%s.timeout must not be negative", key)
+	}
+	if n.Password != "" && n.Username == "" {
+		return fmt.Errorf("%s.password is set without a username", key)
+	}
+	if n.Token != "" && n.Username != "" {
+		return fmt.Errorf("%s: use either username/password or token, not both", key)
+	}
+	if electrumScheme(scheme) && (n.Username != "" || n.Token != "") {
+		return fmt.Errorf("%s: electrum servers do not support authentication", key)
+	}
+	return nil
+}
+
+// IsElectrum 节点是 Electrum 服务器（ssl:// 或 tcp://）而不是 JSON-RPC
+func (n RPCEndpointConfig) IsElectrum() bool {
+	scheme, err := rpcScheme(n.Endpoint)
+	return err == nil && electrumScheme(scheme)
+}
+
+func rpcScheme(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !rpcSchemes[scheme] {
+		return "", fmt.Errorf("%q must start with http://, https://, ssl:// or tcp://", endpoint)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%q has no host", endpoint)
+	}
+	if electrumScheme(scheme) && u.Port() == "" {
+		return "", fmt.Errorf("%q needs a port for electrum", endpoint)
+	}
+	return scheme, nil
+}
+
+func electrumScheme(scheme string) bool {
+	return scheme == "ssl" || scheme == "tcp"
+}
+
+// CurrentNetwork 当前使用的网络，未设置时为 mainnet
+func (c RPCConfig) CurrentNetwork() string {
+	if c.Network == "" {
+		return "mainnet"
+	}
+	return strings.ToLower(c.Network)
+}
+
+// migrateLegacyEndpoint 旧配置的 rpc.endpoint 按以太坊主网节点处理，返回是否做了迁移
+func (c *RPCConfig) migrateLegacyEndpoint() bool {
+	if c.Endpoint == "" {
+		return false
+	}
+	if _, ok := c.Coins["eth"]["mainnet"]; ok {
+		return false
+	}
+	if c.Coins == nil {
+		c.Coins = make(map[string]map[string]RPCEndpointConfig)
+	}
+	if c.Coins["eth"] == nil {
+		c.Coins["eth"] = make(map[string]RPCEndpointConfig)
+	}
+	c.Coins["eth"]["mainnet"] = RPCEndpointConfig{Endpoint: c.Endpoint}
+	return true
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/crypto"
+	"github.com/tyler-smith/go-bip32"
+)
+
+const (
+	accountExportType    = "slowmade-account"
+	accountExportVersion = 1
+)
+
+// 错误定义
+var (
+	ErrAccountExists        = errors.New("account already exists")
+	ErrInvalidAccountExport = errors.New("invalid account export")
+)
+
+// accountExportFile 导出文件格式，账户数据整体用导出密码加密
+type accountExportFile struct {
+	Type       string `json:"type"`
+	Version    int    `json:"version"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// accountExport 导出的账户数据：账户层级私钥和地址元数据，地址私钥在导入时重新派生
+type accountExport struct {
+	CoinSymbol     string            `json:"coin_symbol"`
+	DerivationPath string            `json:"derivation_path"`
+	PathConvention PathConvention    `json:"path_convention,omitempty"`
+	AccountKey     string            `json:"account_key"` // BIP32 扩展私钥（xprv）
+	Addresses      []exportedAddress `json:"addresses"`
+}
+
+type exportedAddress struct {
+	ChangeType   uint32 `json:"change_type"`
+	AddressIndex uint32 `json:"address_index"`
+	Address      string `json:"address"`
+}
+
+// ExportAccount 导出单个账户，用 exportPassword 加密；导出内容不包含根种子
+func (am *DefaultAccountManager) ExportAccount(accountID, exportPassword string) ([]byte, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	account, err := am.findAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotFrozen(account); err != nil {
+		return nil, err
+	}
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(password)
+	accountKey, keyData, err := am.accountKey(account, string(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt account private key: %w", err)
+	}
+	defer keyData.Destroy()
+
+	addresses, err := am.storage.LoadAddresses(accountID)
+	if err != nil {
+		return nil, err
+	}
+	export := accountExport{
+		CoinSymbol:     account.CoinSymbol,
+		DerivationPath: account.DerivationPath,
+		PathConvention: account.PathConvention,
+		AccountKey:     accountKey.B58Serialize(),
+	}
+	for _, addr := range addresses {
+		export.Addresses = append(export.Addresses, exportedAddress{
+			ChangeType:   addr.ChangeType,
+			AddressIndex: addr.AddressIndex,
+			Address:      addr.Address,
+		})
+	}
+
+	plaintext, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(plaintext)
+	ciphertext, err := crypto.EncryptData(plaintext, exportPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt account export: %w", err)
+	}
+	return json.MarshalIndent(accountExportFile{
+		Type:       accountExportType,
+		Version:    accountExportVersion,
+		Ciphertext: ciphertext,
+	}, "", "  ")
+}
+
+// ImportAccount 导入 ExportAccount 生成的文件，作为独立账户以当前钱包密码重新加密保存
+func (am *DefaultAccountManager) ImportAccount(data []byte, exportPassword string) (*CoinAccount, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	var file accountExportFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Type != accountExportType {
+		return nil, ErrInvalidAccountExport
+	}
+	if file.Version != accountExportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidAccountExport, file.Version)
+	}
+	plaintext, err := security.Decrypt(file.Ciphertext, exportPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt account export: %w", err)
+	}
+	defer plaintext.Destroy()
+	var export accountExport
+	if err := json.Unmarshal(plaintext.Bytes(), &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccountExport, err)
+	}
+	accountKey, err := bip32.B58Deserialize(export.AccountKey)
+	if err != nil || !accountKey.IsPrivate {
+		return nil, fmt.Errorf("%w: bad account key", ErrInvalidAccountExport)
+	}
+	defer wipeKeys(accountKey)
+
+	// 独立账户的 ID 由账户公钥决定，避免与本钱包同路径的账户冲突
+	accountID := am.IDString("standalone:" + accountKey.PublicKey().B58Serialize())
+	if _, err := am.findAccount(accountID); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountExists, accountID)
+	}
+
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(password)
+	serializedKey, err := accountKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(serializedKey)
+	encryptedKey, err := crypto.EncryptData(serializedKey, string(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt account private key: %w", err)
+	}
+	account := &CoinAccount{
+		ID:                         accountID,
+		CoinSymbol:                 export.CoinSymbol,
+		DerivationPath:             export.DerivationPath,
+		EncryptedAccountPrivateKey: encryptedKey,
+		Standalone:                 true,
+		PathConvention:             export.PathConvention,
+
+		CreationTime: uint64(time.Now().Unix()),
+	}
+	if _, err := account.Path(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccountExport, err)
+	}
+	if _, err := ParsePathConvention(string(account.PathConvention)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccountExport, err)
+	}
+
+	// 先校验全部地址都能由账户密钥重新派生，再写入存储
+	addresses := make([]*AddressKey, 0, len(export.Addresses))
+	for _, exported := range export.Addresses {
+		addr, err := am.newAddressKey(account, accountKey, exported.ChangeType, exported.AddressIndex, string(password))
+		if err != nil {
+			return nil, err
+		}
+		if addr.Address != exported.Address {
+			return nil, fmt.Errorf("%w: address %s does not match the account key", ErrInvalidAccountExport, exported.Address)
+		}
+		addresses = append(addresses, addr)
+	}
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		if err := tx.SaveAccount(account); err != nil {
+			return fmt.Errorf("failed to save account: %w", err)
+		}
+		for _, addr := range addresses {
+			if err := tx.SaveAddress(addr); err != nil {
+				return fmt.Errorf("failed to save address: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	am.trackOwned(addresses...)
+	return account, nil
+}
+
+// findAccount 按 ID 查找账户
+func (am *DefaultAccountManager) findAccount(accountID string) (*CoinAccount, error) {
+	accounts, err := am.storage.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.ID == accountID {
+			return account, nil
+		}
+	}
+	return nil, ErrAccountNotFound
+}
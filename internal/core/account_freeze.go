@@ -0,0 +1,52 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAccountFrozen 账户已冻结，不能解密或派生私钥
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// FreezeAccount 冻结账户：之后解密地址私钥（签名）、派生新地址和导出账户私钥都会失败，直到 UnfreezeAccount；
+// 用于怀疑设备被入侵、资金尚未迁移时，因此不要求输入密码
+func (am *DefaultAccountManager) FreezeAccount(accountID string) error {
+	account, err := am.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account.Frozen {
+		return nil
+	}
+	account.Frozen = true
+	account.FrozenTime = uint64(time.Now().Unix())
+	return am.storage.SaveAccount(account)
+}
+
+// UnfreezeAccount 解除冻结，password 必须能解密账户私钥，已解锁的会话也要重新输入
+func (am *DefaultAccountManager) UnfreezeAccount(accountID, password string) error {
+	account, err := am.findAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if !account.Frozen {
+		return nil
+	}
+	_, keyData, err := am.accountKey(account, password)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+	keyData.Destroy()
+	account.Frozen = false
+	account.FrozenTime = 0
+	return am.storage.SaveAccount(account)
+}
+
+// checkNotFrozen 账户冻结时返回 ErrAccountFrozen
+func checkNotFrozen(account *CoinAccount) error {
+	if account.Frozen {
+		return fmt.Errorf("%w: %s", ErrAccountFrozen, account.ID)
+	}
+	return nil
+}
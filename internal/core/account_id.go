@@ -0,0 +1,33 @@
+package core
+
+import "strings"
+
+// AccountIDPrefix 账户 ID 的前缀，其后是派生路径的 SHA256 十六进制串
+const AccountIDPrefix = "file_"
+
+// ShortIDLength 短账户 ID 的最小长度
+const ShortIDLength = 8
+
+// ShortIDs 为一组账户 ID 生成短 ID：去掉前缀后取前 ShortIDLength 个字符，
+// 与其他 ID 冲突时逐位加长直到唯一
+func ShortIDs(ids []string) map[string]string {
+	result := make(map[string]string, len(ids))
+	for _, id := range ids {
+		hex := strings.TrimPrefix(id, AccountIDPrefix)
+		n := min(ShortIDLength, len(hex))
+		for n < len(hex) && sharesPrefix(ids, id, hex[:n]) {
+			n++
+		}
+		result[id] = hex[:n]
+	}
+	return result
+}
+
+func sharesPrefix(ids []string, self, prefix string) bool {
+	for _, other := range ids {
+		if other != self && strings.HasPrefix(strings.TrimPrefix(other, AccountIDPrefix), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/palagend/slowmade/pkg/addrformat"
+	"time"
 
+	"github.com/palagend/slowmade/internal/metrics"
 	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/tracing"
 	"github.com/palagend/slowmade/pkg/coin"
 	"github.com/palagend/slowmade/pkg/crypto"
 	"github.com/palagend/slowmade/pkg/logging"
@@ -18,6 +22,7 @@ var (
 	ErrInvalidPassword     = errors.New("invalid password")
 	ErrWalletAlreadyExists = errors.New("wallet already exists")
 	ErrWalletNotCreated    = errors.New("wallet not created")
+	ErrAccountNotFound     = errors.New("account not found")
 )
 
 // DefaultAccountManager 默认的账户管理器实现
@@ -25,62 +30,114 @@ type DefaultAccountManager struct {
 	walletManager WalletManager
 	storage       StorageHandler
 	maxLength     int // ID最大长度
+	owned         ownership
+	derived       *derivedCache // 为空时不缓存派生数据
 }
 
-// NewDefaultAccountManager 创建新的账户管理器
-func NewDefaultAccountManager(walletManager WalletManager, storage StorageHandler) AccountManager {
-	return &DefaultAccountManager{
+// NewDefaultAccountManager 创建新的账户管理器，derivedCachePath 为空时每次都重新派生账户公钥
+func NewDefaultAccountManager(walletManager WalletManager, storage StorageHandler, derivedCachePath string) AccountManager {
+	am := &DefaultAccountManager{
 		walletManager: walletManager,
 		storage:       storage,
 	}
+	if derivedCachePath != "" {
+		am.derived = &derivedCache{path: derivedCachePath}
+	}
+	return am
 }
 
-// CreateNewAccount 创建新账户
-func (am *DefaultAccountManager) CreateNewAccount(derivationPath *DerivationPath) (*CoinAccount, error) {
+// CreateNewAccount 创建新账户，账户和它的第一个收款地址在同一个事务中保存；
+// convention 决定账户下地址的派生方式，非标准约定只用于支持非硬化派生的币种
+func (am *DefaultAccountManager) CreateNewAccount(derivationPath *DerivationPath, convention PathConvention) (_ *CoinAccount, err error) {
+	coinSymbol := coin.CoinSymbol(derivationPath.CoinType)
+	span := tracing.Begin("account.create", "coin", coinSymbol)
+	defer func() { span.End(err) }()
 	if am.walletManager.IsLocked() {
 		return nil, ErrWalletLocked
 	}
 
-	coinSymbol := coin.CoinSymbol(derivationPath.CoinType)
 	if coinSymbol == "" {
 		return nil, fmt.Errorf("该币种（coin_type=%s）暂不支持", derivationPath.CoinTypeString())
 	}
+	if convention != ConventionStandard && PathRuleFor(derivationPath.CoinType).AllHardened {
+		return nil, fmt.Errorf("%s 只支持硬化派生，不能使用 %s 约定", coinSymbol, convention)
+	}
 	// 派生账户密钥
 	dp := derivationPath.MaskSuffix()
 	accountKey, err := am.deriveAccountKey(dp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive account key: %w", err)
 	}
+	defer wipeKeys(accountKey)
 
-	password, err := security.Password()
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(password)
+	account, err := am.newAccount(dp, convention, accountKey, string(password))
+	if err != nil {
+		return nil, err
+	}
+	account.CreationTime = uint64(time.Now().Unix())
+	span.Set("account", account.ID)
+	firstAddress, err := am.newAddressKey(account, accountKey, 0, 0, string(password))
 	if err != nil {
 		return nil, err
 	}
+
+	// 保存账户和第一个地址
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		if err := tx.SaveAccount(account); err != nil {
+			return fmt.Errorf("failed to save account: %w", err)
+		}
+		if err := tx.SaveAddress(firstAddress); err != nil {
+			return fmt.Errorf("failed to save address: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	am.trackOwned(firstAddress)
+
+	return account, nil
+}
+
+// newAccount 由账户密钥构造账户记录，账户私钥用 password 加密，不保存
+func (am *DefaultAccountManager) newAccount(dp *DerivationPath, convention PathConvention, accountKey *bip32.Key, password string) (*CoinAccount, error) {
 	serializedKey, err := accountKey.Serialize()
 	if err != nil {
 		return nil, err
 	}
+	defer security.WipeSensitiveData(serializedKey)
 	logging.Debugf("serializedKey len is %d", len(serializedKey))
-	encryptedPrivateKey, err := crypto.EncryptData(serializedKey, string(password))
+	encryptedPrivateKey, err := crypto.EncryptData(serializedKey, password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt account private key: %w", err)
 	}
 
 	account := &CoinAccount{
-		ID:                         am.IDString(dp.String()),
-		CoinSymbol:                 coinSymbol,
+		ID:                         am.IDString(convention.idInput(dp.String())),
+		CoinSymbol:                 coin.CoinSymbol(dp.CoinType),
 		DerivationPath:             dp.String(),
 		EncryptedAccountPrivateKey: encryptedPrivateKey,
 	}
-
-	// 保存账户
-	if err := am.storage.SaveAccount(account); err != nil {
-		return nil, fmt.Errorf("failed to save account: %w", err)
+	if convention != ConventionStandard {
+		account.PathConvention = convention
 	}
-
+	account.AddressFormat = scriptFormatFor(account.CoinSymbol, dp)
 	return account, nil
 }
 
+// scriptFormatFor 输出脚本不同的地址格式由 purpose 决定（BIP44/49/84/86），其他币种为空
+func scriptFormatFor(symbol string, dp *DerivationPath) string {
+	if format, ok := addrformat.ForPurpose(symbol, dp.Purpose&^HardenedOffset); ok && format.Script {
+		return format.Name
+	}
+	return ""
+}
+
 // GetAccountsByCoin 获取指定币种的所有账户
 func (am *DefaultAccountManager) GetAccountsByCoin(coinType uint32) ([]*CoinAccount, error) {
 	if am.walletManager.IsLocked() {
@@ -101,68 +158,117 @@ func (am *DefaultAccountManager) GetAccountsByCoin(coinType uint32) ([]*CoinAcco
 	return result, nil
 }
 
+// GetAccounts 获取所有币种的全部账户
+func (am *DefaultAccountManager) GetAccounts() ([]*CoinAccount, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	return am.storage.LoadAccounts()
+}
+
 // DeriveAddress 派生新地址
-func (am *DefaultAccountManager) DeriveAddress(accountID string, changeType uint32, addressIndex uint32) (*AddressKey, error) {
+func (am *DefaultAccountManager) DeriveAddress(accountID string, changeType uint32, addressIndex uint32) (_ *AddressKey, err error) {
+	span := tracing.Begin("account.derive_address", "account", accountID, "change", fmt.Sprint(changeType), "index", fmt.Sprint(addressIndex))
+	defer func() { span.End(err) }()
 	if am.walletManager.IsLocked() {
 		return nil, ErrWalletLocked
 	}
 
-	// 获取账户
-	accounts, err := am.storage.LoadAccounts()
+	targetAccount, err := am.findAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	span.Set("coin", targetAccount.CoinSymbol)
+	if err := checkNotFrozen(targetAccount); err != nil {
+		return nil, err
+	}
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(password)
+	accountKey, keyData, err := am.accountKey(targetAccount, string(password))
 	if err != nil {
 		return nil, err
 	}
+	defer keyData.Destroy()
 
-	var targetAccount *CoinAccount
-	for _, account := range accounts {
-		if account.ID == accountID {
-			targetAccount = account
-			break
-		}
+	// 派生地址密钥并生成地址
+	addressKeyObj, err := am.newAddressKey(targetAccount, accountKey, changeType, addressIndex, string(password))
+	if err != nil {
+		return nil, err
 	}
 
-	if targetAccount == nil {
-		return nil, errors.New("account not found")
+	// 保存地址
+	if err := am.storage.SaveAddress(addressKeyObj); err != nil {
+		return nil, fmt.Errorf("failed to save address: %w", err)
 	}
+	am.trackOwned(addressKeyObj)
+	metrics.Inc(metrics.Derivations, "coin", targetAccount.CoinSymbol)
 
-	// 派生地址密钥
-	addressKey, err := am.deriveAddressKey(targetAccount, changeType, addressIndex)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive address key: %w", err)
+	return addressKeyObj, nil
+}
+
+// DeriveAddresses 派生 start 起连续 count 个地址，在同一个事务中保存，
+// 批量派生时只解密一次账户私钥、只重写一次地址文件
+func (am *DefaultAccountManager) DeriveAddresses(accountID string, changeType uint32, start, count uint32) (_ []*AddressKey, err error) {
+	span := tracing.Begin("account.derive_addresses", "account", accountID, "change", fmt.Sprint(changeType), "start", fmt.Sprint(start), "count", fmt.Sprint(count))
+	defer func() { span.End(err) }()
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	if start+count-1 < start {
+		return nil, fmt.Errorf("address index range overflows: %d+%d", start, count)
 	}
 
-	// 生成地址（这里需要根据币种实现具体的地址生成逻辑）
-	address, publicKey, err := am.generateAddress(targetAccount.CoinType(), addressKey)
+	targetAccount, err := am.findAccount(accountID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate address: %w", err)
+		return nil, err
 	}
-
-	// 加密私钥（在实际应用中需要使用密码）
-	password, err := security.Password()
+	span.Set("coin", targetAccount.CoinSymbol)
+	if err := checkNotFrozen(targetAccount); err != nil {
+		return nil, err
+	}
+	password, err := am.walletManager.Password()
 	if err != nil {
 		return nil, err
 	}
-	encryptedPrivateKey, err := crypto.EncryptData(addressKey.Key, string(password))
+	defer security.WipeSensitiveData(password)
+	accountKey, keyData, err := am.accountKey(targetAccount, string(password))
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+		return nil, err
 	}
+	defer keyData.Destroy()
 
-	addressKeyObj := &AddressKey{
-		AccountID:           accountID,
-		ChangeType:          changeType,
-		AddressIndex:        addressIndex,
-		EncryptedPrivateKey: encryptedPrivateKey,
-		PublicKey:           hex.EncodeToString(publicKey),
-		Address:             address,
-		CoinSymbol:          coin.CoinSymbol(targetAccount.CoinType()),
+	addresses := make([]*AddressKey, 0, count)
+	for i := uint32(0); i < count; i++ {
+		addressKeyObj, err := am.newAddressKey(targetAccount, accountKey, changeType, start+i, string(password))
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, addressKeyObj)
 	}
 
-	// 保存地址
-	if err := am.storage.SaveAddress(addressKeyObj); err != nil {
-		return nil, fmt.Errorf("failed to save address: %w", err)
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		for _, addr := range addresses {
+			if err := tx.SaveAddress(addr); err != nil {
+				return fmt.Errorf("failed to save address: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	am.trackOwned(addresses...)
+	for range addresses {
+		metrics.Inc(metrics.Derivations, "coin", targetAccount.CoinSymbol)
 	}
 
-	return addressKeyObj, nil
+	return addresses, nil
 }
 
 // GetAddresses 获取指定账户的所有地址
@@ -170,77 +276,216 @@ func (am *DefaultAccountManager) GetAddresses(accountID string) ([]*AddressKey,
 	return am.storage.LoadAddresses(accountID)
 }
 
-// 派生账户密钥
-func (am *DefaultAccountManager) deriveAccountKey(derivationPath *DerivationPath) (*bip32.Key, error) {
-	if derivationPath == nil {
-		return nil, fmt.Errorf("derivationPath cannot be nil")
+// AddressPrivateKey 解密地址私钥，放在锁定内存中，调用方用完必须 Destroy；所有签名都经过这里，账户冻结时拒绝
+func (am *DefaultAccountManager) AddressPrivateKey(address *AddressKey) (*security.SecureBytes, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
 	}
-	// BIP44 路径：m/44'/coinType'/accountIndex'
-	seed, err := am.walletManager.Seed()
+	account, err := am.findAccount(address.AccountID)
 	if err != nil {
 		return nil, err
 	}
-	masterKey, err := bip32.NewMasterKey(seed)
+	if err := checkNotFrozen(account); err != nil {
+		return nil, err
+	}
+	password, err := am.walletManager.Password()
 	if err != nil {
 		return nil, err
 	}
+	defer security.WipeSensitiveData(password)
+	privateKey, err := security.Decrypt(address.EncryptedPrivateKey, string(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt address private key: %w", err)
+	}
+	return privateKey, nil
+}
 
-	// purpose: 44' (硬化派生)
-	purposeKey, err := masterKey.NewChildKey(derivationPath.Purpose)
+// AccountPublicKey 返回账户层级的 BIP32 扩展公钥（xpub），可用于只读地派生该账户的全部地址
+func (am *DefaultAccountManager) AccountPublicKey(accountID string) (string, error) {
+	entry, err := am.accountDerived(accountID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	return entry.XPub, nil
+}
 
-	// coinType 0'
-	coinTypeKey, err := purposeKey.NewChildKey(derivationPath.CoinType)
+// AccountFingerprint 返回账户公钥 HASH160 的前 4 字节（十六进制），用于在 PSBT 和描述符中识别账户
+func (am *DefaultAccountManager) AccountFingerprint(accountID string) (string, error) {
+	entry, err := am.accountDerived(accountID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	return entry.Fingerprint, nil
+}
 
-	// accountIndex 0'
-	accountKey, err := coinTypeKey.NewChildKey(derivationPath.AccountIndex)
+// MasterFingerprint 主密钥指纹（BIP32 的 HASH160 前 4 字节），用于区分钱包，需要钱包已解锁
+func (am *DefaultAccountManager) MasterFingerprint() (string, error) {
+	if am.walletManager.IsLocked() {
+		return "", ErrWalletLocked
+	}
+	seed, err := am.walletManager.Seed()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return accountKey, nil
+	defer seed.Destroy()
+	key, err := bip32.NewMasterKey(seed.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer wipeKeys(key)
+	return keyFingerprint(key.PublicKey().Key), nil
 }
 
-// 派生地址密钥
-func (am *DefaultAccountManager) deriveAddressKey(account *CoinAccount, changeType, addressIndex uint32) (*bip32.Key, error) {
-	password, err := security.Password()
+// accountDerived 账户的派生数据，缓存条目的输入未改变时直接使用，否则解密账户私钥重新派生并写回缓存
+func (am *DefaultAccountManager) accountDerived(accountID string) (derivedEntry, error) {
+	if am.walletManager.IsLocked() {
+		return derivedEntry{}, ErrWalletLocked
+	}
+	account, err := am.findAccount(accountID)
+	if err != nil {
+		return derivedEntry{}, err
+	}
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return derivedEntry{}, err
+	}
+	defer security.WipeSensitiveData(password)
+	if am.derived != nil {
+		if entry, ok := am.derived.get(account, string(password)); ok {
+			return entry, nil
+		}
+	}
+	accountKey, keyData, err := am.accountKey(account, string(password))
+	if err != nil {
+		return derivedEntry{}, fmt.Errorf("failed to decrypt account private key: %w", err)
+	}
+	defer keyData.Destroy()
+	publicKey := accountKey.PublicKey()
+	entry := derivedEntry{XPub: publicKey.B58Serialize(), Fingerprint: keyFingerprint(publicKey.Key)}
+	if am.derived != nil {
+		am.derived.put(account, entry, string(password))
+	}
+	return entry, nil
+}
+
+// 派生账户密钥
+func (am *DefaultAccountManager) deriveAccountKey(derivationPath *DerivationPath) (*bip32.Key, error) {
+	if derivationPath == nil {
+		return nil, fmt.Errorf("derivationPath cannot be nil")
+	}
+	seed, err := am.walletManager.Seed()
 	if err != nil {
 		return nil, err
 	}
-	accountPrivateKey, err := crypto.DecryptData(account.EncryptedAccountPrivateKey, string(password))
+	defer seed.Destroy()
+	master, err := bip32.NewMasterKey(seed.Bytes())
 	if err != nil {
 		return nil, err
 	}
+	defer wipeKeys(master)
+	return hardenedChild(master, derivationPath)
+}
+
+// hardenedChild 沿账户路径的硬化前缀逐级派生，BIP44 中即 m/44'/coinType'/accountIndex'；不修改 master
+func hardenedChild(master *bip32.Key, derivationPath *DerivationPath) (*bip32.Key, error) {
+	key := master
+	for _, component := range derivationPath.HardenedPrefix() {
+		child, err := key.NewChildKey(component)
+		if key != master {
+			wipeKeys(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	if key == master {
+		return nil, fmt.Errorf("derivation path %s has no hardened prefix", derivationPath)
+	}
+	return key, nil
+}
 
-	// 重新创建账户密钥
-	accountKey, err := bip32.Deserialize(accountPrivateKey)
+// accountKey 解密账户层级私钥。返回的密钥直接引用锁定内存中的数据，
+// 调用方用完密钥后必须 Destroy 返回的 SecureBytes，之后不能再使用该密钥
+func (am *DefaultAccountManager) accountKey(account *CoinAccount, password string) (*bip32.Key, *security.SecureBytes, error) {
+	accountPrivateKey, err := security.Decrypt(account.EncryptedAccountPrivateKey, password)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	key, err := bip32.Deserialize(accountPrivateKey.Bytes())
+	if err != nil {
+		accountPrivateKey.Destroy()
+		return nil, nil, err
+	}
+	return key, accountPrivateKey, nil
+}
 
-	// 派生 change 路径：changeType (0=外部, 1=找零)
-	changeKey, err := accountKey.NewChildKey(changeType)
+// wipeKeys 清除堆上派生出的 bip32 私钥和链码
+func wipeKeys(keys ...*bip32.Key) {
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		security.WipeSensitiveData(key.Key)
+		security.WipeSensitiveData(key.ChainCode)
+	}
+}
+
+// newAddressKey 由账户密钥派生地址，地址私钥用 password 加密
+func (am *DefaultAccountManager) newAddressKey(account *CoinAccount, accountKey *bip32.Key, changeType, addressIndex uint32, password string) (*AddressKey, error) {
+	addressKey, err := addressKeyAt(account, accountKey, changeType, addressIndex)
 	if err != nil {
 		return nil, err
 	}
+	defer wipeKeys(addressKey)
 
-	// 派生地址索引
-	addressKey, err := changeKey.NewChildKey(addressIndex)
+	address, publicKey, err := am.generateAddress(account, addressKey)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to generate address: %w", err)
 	}
+	encryptedPrivateKey, err := crypto.EncryptData(addressKey.Key, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	return &AddressKey{
+		AccountID:           account.ID,
+		ChangeType:          changeType,
+		AddressIndex:        addressIndex,
+		EncryptedPrivateKey: encryptedPrivateKey,
+		PublicKey:           hex.EncodeToString(publicKey),
+		Address:             address,
+		CoinSymbol:          coin.CoinSymbol(account.CoinType()),
+		CreationTime:        uint64(time.Now().Unix()),
+	}, nil
+}
 
+// addressKeyAt 按账户的约定由账户密钥派生地址密钥，标准约定为 changeType (0=外部, 1=找零) 和地址索引两级；
+// 调用方用完后 wipeKeys
+func addressKeyAt(account *CoinAccount, accountKey *bip32.Key, changeType, addressIndex uint32) (*bip32.Key, error) {
+	components, err := account.Convention().addressComponents(changeType, addressIndex)
+	if err != nil {
+		return nil, err
+	}
+	addressKey := accountKey
+	for _, component := range components {
+		child, err := addressKey.NewChildKey(component)
+		if addressKey != accountKey {
+			wipeKeys(addressKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive address key: %w", err)
+		}
+		addressKey = child
+	}
 	return addressKey, nil
 }
 
-func (am *DefaultAccountManager) generateAddress(coinType uint32, key *bip32.Key) (string, []byte, error) {
+func (am *DefaultAccountManager) generateAddress(account *CoinAccount, key *bip32.Key) (string, []byte, error) {
 	if key == nil {
 		return "", nil, errors.New("key cannot be nil")
 	}
+	coinType := account.CoinType()
 
 	publicKey := key.PublicKey().Key
 
@@ -250,7 +495,7 @@ func (am *DefaultAccountManager) generateAddress(coinType uint32, key *bip32.Key
 
 	switch coinType {
 	case coin.CoinTypeBTC | coin.HardenedBit:
-		generator = &BTCAddressGenerator{}
+		generator = &BTCAddressGenerator{Format: account.ScriptFormat()}
 		address, err = generator.GenerateAddress(publicKey)
 
 	case coin.CoinTypeETH | coin.HardenedBit:
@@ -270,7 +515,16 @@ func (am *DefaultAccountManager) generateAddress(coinType uint32, key *bip32.Key
 		address, err = generator.GenerateAddress(publicKey)
 
 	default:
-		return "", nil, fmt.Errorf("unsupported coin type: %d", coinType)
+		plugin, ok := CoinPluginFor(coinType)
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported coin type: %d", coinType)
+		}
+		generator = plugin.AddressGenerator(account)
+		if seeded, ok := generator.(SeedAddressGenerator); ok {
+			address, publicKey, err = seeded.GenerateAddressFromSeed(key.Key)
+		} else {
+			address, err = generator.GenerateAddress(publicKey)
+		}
 	}
 
 	if err != nil {
@@ -283,7 +537,7 @@ func (am *DefaultAccountManager) generateAddress(coinType uint32, key *bip32.Key
 func (am *DefaultAccountManager) IDString(derivationPath string) string {
 	// 添加前缀和哈希
 	hash := sha256.Sum256([]byte(derivationPath))
-	prefix := "file_"
+	prefix := AccountIDPrefix
 	hexString := hex.EncodeToString(hash[:])
 
 	filename := prefix + hexString
@@ -1,11 +1,13 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 
-	"golang.org/x/crypto/ripemd160" // 需要导入：go get golang.org/x/crypto/ripemd160
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/pkg/addrformat"
 )
 
 // 币种特定的地址生成器接口
@@ -13,41 +15,52 @@ type AddressGenerator interface {
 	GenerateAddress(publicKey []byte) (string, error)
 }
 
-// BTC地址生成器
-type BTCAddressGenerator struct{}
+// SeedAddressGenerator 使用其他曲线（如 ed25519）的币种：以派生出的 32 字节地址私钥为种子生成密钥，
+// 返回地址和该曲线的公钥；签名时同样以地址私钥为种子。这类地址不能由账户 xpub 只读派生
+type SeedAddressGenerator interface {
+	AddressGenerator
+	GenerateAddressFromSeed(seed []byte) (string, []byte, error)
+}
+
+// BTC地址生成器，Format 选择地址格式：legacy 为 P2PKH（1...，BIP44），p2sh 为 P2SH-P2WPKH（3...，BIP49），
+// bech32 为 P2WPKH（bc1q...，BIP84），bech32m 为 Taproot P2TR（bc1p...，BIP86）。
+// 为空时生成 legacy 地址，通常由 CoinAccount.ScriptFormat 按账户的 purpose 和地址格式设置
+type BTCAddressGenerator struct {
+	Format string
+}
 
 func (g *BTCAddressGenerator) GenerateAddress(publicKey []byte) (string, error) {
-	if len(publicKey) != 33 {
-		return "", errors.New("BTC requires compressed public key (33 bytes)")
+	format := g.Format
+	if format == "" {
+		format = addrformat.Legacy
 	}
-
-	// SHA256哈希
-	sha256Hash := sha256.Sum256(publicKey)
-
-	// RIPEMD160哈希
-	ripemd160Hasher := ripemd160.New()
-	ripemd160Hasher.Write(sha256Hash[:])
-	ripemd160Hash := ripemd160Hasher.Sum(nil)
-
-	// 返回十六进制格式的地址（简化版，实际应该进行Base58Check编码）
-	return "1" + hex.EncodeToString(ripemd160Hash)[:40], nil
+	return addrformat.BTCAddress(publicKey, format)
 }
 
 // ETH地址生成器
 type ETHAddressGenerator struct{}
 
 func (g *ETHAddressGenerator) GenerateAddress(publicKey []byte) (string, error) {
-	if len(publicKey) != 64 {
-		return "", errors.New("ETH requires 64-byte uncompressed public key")
+	var (
+		pub *ecdsa.PublicKey
+		err error
+	)
+	switch len(publicKey) {
+	case 33: // BIP32 派生出的压缩公钥
+		pub, err = crypto.DecompressPubkey(publicKey)
+	case 64:
+		pub, err = crypto.UnmarshalPubkey(append([]byte{0x04}, publicKey...))
+	case 65:
+		pub, err = crypto.UnmarshalPubkey(publicKey)
+	default:
+		return "", errors.New("ETH requires a 33, 64 or 65-byte secp256k1 public key")
+	}
+	if err != nil {
+		return "", err
 	}
 
-	// Keccak256哈希（这里用SHA256简化，实际应该用Keccak256）
-	hash := sha256.Sum256(publicKey)
-
-	// 取后20字节作为地址
-	addressBytes := hash[len(hash)-20:]
-
-	return "0x" + hex.EncodeToString(addressBytes), nil
+	// Keccak256(公钥) 的后20字节，EIP-55 校验和格式
+	return crypto.PubkeyToAddress(*pub).Hex(), nil
 }
 
 // SOL地址生成器
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// CoinPlugin 内置币种以外的链通过插件接入：提供币种信息、派生路径规则、地址生成和校验以及默认手续费。
+// 注册后即可创建账户、派生地址、添加只读监控地址
+type CoinPlugin interface {
+	Info() coin.CoinInfo
+	PathRule() PathRule
+	// AddressGenerator 返回账户使用的地址生成器，可按账户的 purpose 选择地址格式
+	AddressGenerator(account *CoinAccount) AddressGenerator
+	// NormalizeAddress 校验地址并返回规范形式
+	NormalizeAddress(address string) (string, error)
+	Fees() FeeDefaults
+}
+
+// AddressClassifier 插件可选实现：除校验外还能识别地址所属的网络和类型，供 validate 命令和 /api/v1/validate 使用；
+// 没有实现时只用 NormalizeAddress 判断是否有效
+type AddressClassifier interface {
+	ClassifyAddress(address string) (AddressInfo, error)
+}
+
+// AddressInfo 地址的规范形式、所属网络和类型
+type AddressInfo struct {
+	Normalized string
+	Network    string // mainnet、testnet 或 regtest；同一币种有多个网络时为网络名，如 Cosmos 的地址前缀
+	Type       string // 账户或输出脚本类型，如 p2pkh、p2wpkh、account、contract
+	Format     string // 编码格式，如 base58、bech32、cashaddr
+	Checksum   string // 校验方式，如 base58check、bech32、ss58；none 表示地址本身不含校验，输错也无法发现
+	Note       string // 补充说明
+}
+
+// FeeDefaults 默认手续费
+type FeeDefaults struct {
+	Rate int64  // 每字节（隔离见证为每虚拟字节）的最小单位数
+	Unit string // 显示单位，如 sat/vB
+}
+
+var (
+	coinPlugins      = map[uint32]CoinPlugin{}
+	coinPluginsMutex sync.RWMutex
+)
+
+// RegisterCoinPlugin 注册币种插件，同时注册币种信息和派生路径规则
+func RegisterCoinPlugin(plugin CoinPlugin) {
+	info := plugin.Info()
+	coin.Register(info)
+	RegisterPathRule(info.Type, plugin.PathRule())
+
+	coinPluginsMutex.Lock()
+	defer coinPluginsMutex.Unlock()
+	coinPlugins[info.Type&^HardenedOffset] = plugin
+}
+
+// CoinPluginFor 按币种类型查找插件
+func CoinPluginFor(coinType uint32) (CoinPlugin, bool) {
+	coinPluginsMutex.RLock()
+	defer coinPluginsMutex.RUnlock()
+	plugin, ok := coinPlugins[coinType&^HardenedOffset]
+	return plugin, ok
+}
+
+// LookupCoinPlugin 按币种符号查找插件
+func LookupCoinPlugin(symbol string) (CoinPlugin, bool) {
+	info, ok := coin.LookupSymbol(strings.ToUpper(symbol))
+	if !ok {
+		return nil, false
+	}
+	return CoinPluginFor(info.Type)
+}
+
+// CoinPlugins 已注册的插件，按币种类型排序
+func CoinPlugins() []CoinPlugin {
+	coinPluginsMutex.RLock()
+	defer coinPluginsMutex.RUnlock()
+	plugins := make([]CoinPlugin, 0, len(coinPlugins))
+	for _, plugin := range coinPlugins {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Info().Type < plugins[j].Info().Type })
+	return plugins
+}
@@ -6,100 +6,136 @@ import (
 	"strings"
 )
 
+// HardenedOffset BIP32 硬化派生偏移
+const HardenedOffset uint32 = 0x80000000
+
+// DerivationPath 派生路径，BIP44 字段始终可用，完整组件保存在 components 中以支持可变深度
 type DerivationPath struct {
 	Purpose      uint32
 	CoinType     uint32
 	AccountIndex uint32
 	Change       uint32
 	AddressIndex uint32
+
+	components []uint32 // 完整路径组件（含硬化位），为空时按 BIP44 五级路径处理
 }
 
+// ParseDerivationPath 解析派生路径，支持账户级（m/84'/0'/0'）和任意深度的路径，
+// 并按币种规则校验，未注册规则的币种使用 BIP44 模板
 func ParseDerivationPath(path string) (*DerivationPath, error) {
+	result, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := PathRuleFor(result.CoinType).Validate(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseDerivationPath 只做语法解析，不校验币种规则（用于读取已存储的路径）
+func parseDerivationPath(path string) (*DerivationPath, error) {
 	// 移除前缀 "m/" 如果存在
 	cleanPath := strings.TrimPrefix(path, "m/")
 	if cleanPath == path {
-		return nil, fmt.Errorf("invalid BIP44 path format, should start with 'm/'")
+		return nil, fmt.Errorf("invalid derivation path format, should start with 'm/'")
 	}
 
 	// 分割路径组件
-	components := strings.Split(cleanPath, "/")
-	if len(components) != 5 {
-		return nil, fmt.Errorf("BIP44 path should have exactly 5 components, got %d", len(components))
-	}
-
-	result := &DerivationPath{}
-
-	// 解析 purpose (带硬化标记)
-	purpose, err := parsePathComponent(components[0])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse purpose: %w", err)
+	parts := strings.Split(cleanPath, "/")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("derivation path should have at least 3 components (purpose/coin/account), got %d", len(parts))
 	}
-	result.Purpose = purpose
 
-	// 解析 coin type (带硬化标记)
-	coinType, err := parsePathComponent(components[1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse coin type: %w", err)
+	components := make([]uint32, len(parts))
+	for i, part := range parts {
+		value, err := parsePathComponent(part)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", componentName(i), err)
+		}
+		components[i] = value
 	}
-	result.CoinType = coinType
 
-	// 解析 account (带硬化标记)
-	account, err := parsePathComponent(components[2])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse account: %w", err)
-	}
-	result.AccountIndex = account
+	return NewDerivationPath(components), nil
+}
 
-	// 解析 change (不带硬化标记)
-	change, err := parsePathComponent(components[3])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse change: %w", err)
-	}
-	if change != 0 && change != 1 {
-		return nil, fmt.Errorf("change should be 0 or 1, got %d", change)
+// NewDerivationPath 由路径组件构造派生路径
+func NewDerivationPath(components []uint32) *DerivationPath {
+	dp := &DerivationPath{components: append([]uint32(nil), components...)}
+	fields := []*uint32{&dp.Purpose, &dp.CoinType, &dp.AccountIndex, &dp.Change, &dp.AddressIndex}
+	for i := 0; i < len(components) && i < len(fields); i++ {
+		*fields[i] = components[i]
 	}
-	result.Change = change
-
-	// 解析 address index (不带硬化标记)
-	addressIndex, err := parsePathComponent(components[4])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse address index: %w", err)
-	}
-	result.AddressIndex = addressIndex
-
-	return result, nil
+	return dp
 }
 
 // parsePathComponent 解析单个路径组件，处理硬化标记
 func parsePathComponent(component string) (uint32, error) {
-	// 检查是否是硬化标记（以'结尾）
-	isHardened := strings.HasSuffix(component, "'")
+	// 检查是否是硬化标记（以'或h结尾）
+	isHardened := strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h")
 	if isHardened {
-		component = strings.TrimSuffix(component, "'")
+		component = component[:len(component)-1]
 	}
 
 	// 转换为数字
-	value, err := strconv.ParseUint(component, 10, 32)
+	value, err := strconv.ParseUint(component, 10, 31)
 	if err != nil {
 		return 0, fmt.Errorf("invalid component '%s': %w", component, err)
 	}
 
 	// 对于硬化标记，设置最高位（BIP32规范）
 	if isHardened {
-		value |= 0x80000000
+		value |= uint64(HardenedOffset)
 	}
 
 	return uint32(value), nil
 }
 
-// FormatDerivationPath 将DerivationPath格式化为字符串
+// componentName 返回路径层级的名称
+func componentName(level int) string {
+	names := []string{"purpose", "coin type", "account", "change", "address index"}
+	if level < len(names) {
+		return names[level]
+	}
+	return fmt.Sprintf("level %d", level)
+}
+
+// Components 返回完整的路径组件
+func (p *DerivationPath) Components() []uint32 {
+	if len(p.components) > 0 {
+		return append([]uint32(nil), p.components...)
+	}
+	return []uint32{p.Purpose, p.CoinType, p.AccountIndex, p.Change, p.AddressIndex}
+}
+
+// Depth 返回路径深度
+func (p *DerivationPath) Depth() int {
+	return len(p.Components())
+}
+
+// HardenedPrefix 返回开头连续的硬化组件，即账户密钥所在的路径
+func (p *DerivationPath) HardenedPrefix() []uint32 {
+	var prefix []uint32
+	for _, c := range p.Components() {
+		if c&HardenedOffset == 0 {
+			break
+		}
+		prefix = append(prefix, c)
+	}
+	return prefix
+}
+
+// String 将DerivationPath格式化为字符串
 func (p *DerivationPath) String() string {
-	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d",
-		p.Purpose&0x7FFFFFFF, // 移除硬化标记位
-		p.CoinType&0x7FFFFFFF,
-		p.AccountIndex&0x7FFFFFFF,
-		p.Change,
-		p.AddressIndex)
+	var b strings.Builder
+	b.WriteString("m")
+	for _, c := range p.Components() {
+		b.WriteString(fmt.Sprintf("/%d", c&^HardenedOffset))
+		if c&HardenedOffset != 0 {
+			b.WriteString("'")
+		}
+	}
+	return b.String()
 }
 
 func (p *DerivationPath) PurposeString() string {
@@ -114,14 +150,19 @@ func (p *DerivationPath) AccountString() string {
 	return fmt.Sprintf("%d'", p.AccountIndex&0x7FFFFFFF)
 }
 
-// MaskSuffix 掩盖changeType和addressIndex
+// MaskSuffix 掩盖硬化前缀之后的非硬化组件（BIP44 中即 changeType 和 addressIndex），
+// 得到账户层级的路径，深度保持不变以兼容已有账户 ID
 func (p *DerivationPath) MaskSuffix() *DerivationPath {
-	dp := &DerivationPath{
-		Purpose:      p.Purpose,
-		CoinType:     p.CoinType,
-		AccountIndex: p.AccountIndex,
-		Change:       uint32(0),
-		AddressIndex: uint32(0),
+	components := p.Components()
+	for i := len(p.HardenedPrefix()); i < len(components); i++ {
+		components[i] = 0
 	}
-	return dp
+	return NewDerivationPath(components)
+}
+
+// WithAccountIndex 返回账户层级替换为 index（硬化）的新路径，其余组件不变
+func (p *DerivationPath) WithAccountIndex(index uint32) *DerivationPath {
+	components := p.Components()
+	components[2] = index | HardenedOffset
+	return NewDerivationPath(components)
 }
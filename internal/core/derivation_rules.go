@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// PathRule 币种的派生路径校验规则
+type PathRule struct {
+	Purposes       []uint32 // 允许的 purpose（不含硬化位），为空表示不限制
+	MinDepth       int
+	MaxDepth       int
+	HardenedPrefix int  // 开头必须硬化的组件数量
+	AllHardened    bool // 是否要求所有组件硬化（ed25519 曲线只支持硬化派生）
+	FlatIndex      bool // 四级路径的最后一级是地址索引而不是 change（mew 约定的 m/44'/60'/0'/x）
+}
+
+// BIP44Rule 默认的 BIP44 模板：m/purpose'/coin'/account'[/change/index]
+var BIP44Rule = PathRule{
+	MinDepth:       3,
+	MaxDepth:       5,
+	HardenedPrefix: 3,
+}
+
+var (
+	pathRules = map[uint32]PathRule{
+		coin.CoinTypeBTC: {Purposes: []uint32{44, 49, 84, 86}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3},
+		coin.CoinTypeETH: {Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, FlatIndex: true},
+		coin.CoinTypeBNB: {Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, FlatIndex: true},
+		coin.CoinTypeSOL: {Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 4, HardenedPrefix: 3, AllHardened: true},
+		coin.CoinTypeSUI: {Purposes: []uint32{44}, MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, AllHardened: true},
+	}
+	pathRulesMutex sync.RWMutex
+)
+
+// RegisterPathRule 注册币种的派生路径规则
+func RegisterPathRule(coinType uint32, rule PathRule) {
+	pathRulesMutex.Lock()
+	defer pathRulesMutex.Unlock()
+	pathRules[coinType&^HardenedOffset] = rule
+}
+
+// ed25519Rule ed25519 曲线只支持硬化派生
+var ed25519Rule = PathRule{MinDepth: 3, MaxDepth: 5, HardenedPrefix: 3, AllHardened: true}
+
+// PathRuleFor 返回币种的派生路径规则，未注册时按币种曲线选择模板
+func PathRuleFor(coinType uint32) PathRule {
+	pathRulesMutex.RLock()
+	rule, ok := pathRules[coinType&^HardenedOffset]
+	pathRulesMutex.RUnlock()
+	if ok {
+		return rule
+	}
+	if info, exists := coin.GetCoinInfo(coinType); exists && info.Curve == coin.CurveEd25519 {
+		return ed25519Rule
+	}
+	return BIP44Rule
+}
+
+// Validate 校验派生路径是否符合规则
+func (r PathRule) Validate(dp *DerivationPath) error {
+	components := dp.Components()
+	depth := len(components)
+	if depth < r.MinDepth || depth > r.MaxDepth {
+		return fmt.Errorf("path %s should have %d to %d components, got %d", dp.String(), r.MinDepth, r.MaxDepth, depth)
+	}
+
+	if len(r.Purposes) > 0 {
+		purpose := dp.Purpose &^ HardenedOffset
+		allowed := false
+		for _, p := range r.Purposes {
+			if p == purpose {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("purpose %d is not allowed for coin type %d, expected one of %v",
+				purpose, dp.CoinType&^HardenedOffset, r.Purposes)
+		}
+	}
+
+	for i, c := range components {
+		mustHarden := i < r.HardenedPrefix || r.AllHardened
+		if mustHarden && c&HardenedOffset == 0 {
+			return fmt.Errorf("%s must be hardened in %s", componentName(i), dp.String())
+		}
+	}
+
+	// BIP44 模板中 change 只能是 0（收款）或 1（找零）
+	if !r.AllHardened && depth > 3 && !(r.FlatIndex && depth == 4) {
+		if change := components[3]; change != 0 && change != 1 {
+			return fmt.Errorf("change should be 0 or 1, got %d", change)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+	"github.com/palagend/slowmade/pkg/logging"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// DerivedCacheFileName 派生数据缓存在存储目录中的文件名
+const DerivedCacheFileName = "derived_cache.json"
+
+// derivedCacheVersion 缓存格式版本，格式改变时旧缓存整体失效
+const derivedCacheVersion = 1
+
+// derivedEntry 一个账户的派生结果，Inputs 是派生输入（路径和加密的账户私钥）的哈希，
+// 输入改变时（重新导入、恢复备份）条目失效
+type derivedEntry struct {
+	Inputs      string `json:"inputs"`
+	XPub        string `json:"xpub"`
+	Fingerprint string `json:"fingerprint"` // 账户公钥 HASH160 的前 4 字节
+}
+
+// derivedCacheFile 缓存文件，MAC 用钱包密码派生的密钥计算，被改动的缓存（如替换 xpub）整体丢弃
+type derivedCacheFile struct {
+	Version  int                     `json:"version"`
+	Salt     string                  `json:"salt"`
+	Accounts map[string]derivedEntry `json:"accounts"`
+	MAC      string                  `json:"mac"`
+}
+
+// derivedCache 账户公钥等派生数据的持久化缓存，避免每次启动都解密账户私钥重新派生。
+// MAC 密钥在进程内第一次使用时派生一次，放在锁定内存中
+type derivedCache struct {
+	path    string
+	mu      sync.Mutex
+	loaded  bool
+	salt    []byte
+	key     *security.SecureBytes
+	entries map[string]derivedEntry
+}
+
+// derivedInputs 账户派生输入的哈希
+func derivedInputs(account *CoinAccount) string {
+	sum := sha256.Sum256([]byte(account.DerivationPath + "\x00" + account.EncryptedAccountPrivateKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// get 返回输入未改变的缓存条目
+func (c *derivedCache) get(account *CoinAccount, password string) (derivedEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(password); err != nil {
+		logging.Warnf("派生缓存不可用: %v", err)
+		return derivedEntry{}, false
+	}
+	entry, ok := c.entries[account.ID]
+	if !ok || entry.Inputs != derivedInputs(account) {
+		return derivedEntry{}, false
+	}
+	return entry, true
+}
+
+// put 保存条目并写回缓存文件，写入失败只影响下次启动的速度
+func (c *derivedCache) put(account *CoinAccount, entry derivedEntry, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(password); err != nil {
+		logging.Warnf("派生缓存不可用: %v", err)
+		return
+	}
+	entry.Inputs = derivedInputs(account)
+	c.entries[account.ID] = entry
+	if err := c.save(); err != nil {
+		logging.Warnf("保存派生缓存失败: %v", err)
+	}
+}
+
+// reset 丢弃内存中的缓存和密钥，下次使用时重新读取文件
+func (c *derivedCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.key.Destroy()
+	c.key = nil
+	c.loaded = false
+	c.entries = nil
+}
+
+// load 读取并校验缓存文件；文件不存在、版本不符或 MAC 不匹配时从空缓存开始
+func (c *derivedCache) load(password string) error {
+	if c.loaded {
+		return nil
+	}
+	var file derivedCacheFile
+	data, err := os.ReadFile(c.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			logging.Warnf("派生缓存已损坏，重新派生: %v", err)
+			file = derivedCacheFile{}
+		}
+	}
+
+	salt, _ := hex.DecodeString(file.Salt)
+	if file.Version != derivedCacheVersion || len(salt) == 0 {
+		file = derivedCacheFile{}
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	key, err := crypto.NewScryptKDF().DeriveKey("slowmade derived cache\x00"+password, salt)
+	if err != nil {
+		return err
+	}
+	c.salt = salt
+	c.key = security.NewSecureBytes(key)
+	c.entries = make(map[string]derivedEntry)
+	c.loaded = true
+
+	if file.Accounts != nil {
+		if hmac.Equal([]byte(c.mac(file.Accounts)), []byte(file.MAC)) {
+			c.entries = file.Accounts
+		} else {
+			logging.Warnf("派生缓存校验失败（被修改或属于其他钱包），重新派生")
+		}
+	}
+	return nil
+}
+
+func (c *derivedCache) save() error {
+	file := derivedCacheFile{
+		Version:  derivedCacheVersion,
+		Salt:     hex.EncodeToString(c.salt),
+		Accounts: c.entries,
+		MAC:      c.mac(c.entries),
+	}
+	data, err := canonjson.MarshalIndent(file, "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// mac 按账户 ID 排序后计算，与 JSON 字段顺序无关
+func (c *derivedCache) mac(entries map[string]derivedEntry) string {
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := hmac.New(sha256.New, c.key.Bytes())
+	fmt.Fprintf(h, "v%d\n", derivedCacheVersion)
+	for _, id := range ids {
+		e := entries[id]
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\n", id, e.Inputs, e.XPub, e.Fingerprint)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// keyFingerprint BIP32 密钥标识的前 4 字节（公钥的 HASH160）
+func keyFingerprint(publicKey []byte) string {
+	sha := sha256.Sum256(publicKey)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+	return hex.EncodeToString(hasher.Sum(nil)[:4])
+}
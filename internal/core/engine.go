@@ -0,0 +1,42 @@
+package core
+
+import (
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/security"
+)
+
+// Engine 一个数据目录的存储、钱包管理器和账户管理器，由 pkg/wallet 通过 OpenEngine 构建
+type Engine struct {
+	Storage    *FileStorage
+	WalletMgr  *DefaultWalletManager
+	AccountMgr AccountManager
+	Passwords  *security.PasswordManager // 钱包管理器使用的解锁密码
+}
+
+// EngineOptions 构建引擎的配置
+type EngineOptions struct {
+	Storage   config.StorageConfig
+	Cloak     string                    // 可选的附加口令，不同的值得到不同的地址
+	Passwords *security.PasswordManager // 保存解锁密码，为空时使用独立的一个
+	OnWrite   func() error              // 每次写入存储后调用，可为空
+}
+
+// OpenEngine 打开数据目录并构建存储和管理器，目录不存在时创建
+func OpenEngine(opts EngineOptions) (*Engine, error) {
+	storage, err := NewFileStorage(opts.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OnWrite != nil {
+		storage.OnWrite(opts.OnWrite)
+	}
+	passwords := opts.Passwords
+	if passwords == nil {
+		passwords = security.NewPasswordManager()
+	}
+	walletMgr := NewDefaultWalletManager(storage, opts.Cloak).UsePasswords(passwords)
+	accountMgr := NewDefaultAccountManager(walletMgr, storage, filepath.Join(opts.Storage.BaseDir, DerivedCacheFileName))
+	return &Engine{Storage: storage, WalletMgr: walletMgr, AccountMgr: accountMgr, Passwords: passwords}, nil
+}
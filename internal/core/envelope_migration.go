@@ -0,0 +1,281 @@
+package core
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// 错误定义
+var (
+	ErrMigrationVerify   = errors.New("re-encrypted data does not decrypt to the original")
+	ErrMigrationNoBackup = errors.New("storage cannot be backed up, legacy ciphertexts left unchanged")
+)
+
+// EnvelopeMigration 一次密文格式迁移的结果
+type EnvelopeMigration struct {
+	Migrated int
+	Skipped  []string // 无法用解锁密码解密、保持原样的字段，如 address 1a2b3c4d/0/5
+	Backup   string   // 迁移前的备份目录，验证通过后删除，此时为空
+}
+
+// legacyField 需要重新加密的一个密文字段
+type legacyField struct {
+	name  string // 进度和错误信息中的名称，如 account 1a2b3c4d
+	value *string
+}
+
+// legacyRecords 根钱包、账户和地址中需要重新加密的密文字段
+type legacyRecords struct {
+	root      *HDRootWallet
+	accounts  []*CoinAccount
+	addresses []*AddressKey
+	fields    []legacyField
+}
+
+// loadRecords 读取根钱包、账户和地址中 match 返回 true 的密文字段
+func loadRecords(storage StorageHandler, match func(ciphertext string) bool) (*legacyRecords, error) {
+	records := &legacyRecords{}
+	add := func(name string, value *string) bool {
+		if !match(*value) {
+			return false
+		}
+		records.fields = append(records.fields, legacyField{name: name, value: value})
+		return true
+	}
+
+	root, err := storage.LoadRootWallet()
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, ErrWalletNotCreated
+	}
+	legacyMnemonic := add("wallet mnemonic", &root.EncryptedMnemonic)
+	legacySeed := add("wallet seed", &root.EncryptedSeed)
+	if legacyMnemonic || legacySeed {
+		records.root = root
+	}
+
+	accounts, err := storage.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	shortIDs := ShortIDs(ids)
+	for _, account := range accounts {
+		id := shortIDs[account.ID]
+		if add("account "+id, &account.EncryptedAccountPrivateKey) {
+			records.accounts = append(records.accounts, account)
+		}
+		addresses, err := storage.LoadAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			if add(fmt.Sprintf("address %s/%d/%d", id, address.ChangeType, address.AddressIndex), &address.EncryptedPrivateKey) {
+				records.addresses = append(records.addresses, address)
+			}
+		}
+	}
+	return records, nil
+}
+
+// Ciphertext 存储中的一个密文字段及其头部记录的格式和参数
+type Ciphertext struct {
+	Name string // 如 wallet seed、account 1a2b3c4d、address 1a2b3c4d/0/5
+	Info crypto.EnvelopeInfo
+	Err  error // 无法解码，或由更新版本的程序写入（crypto.ErrUnsupportedEnvelope）
+}
+
+// Ciphertexts 列出根钱包、账户和地址中的全部密文字段及其格式，不需要密码
+func Ciphertexts(storage StorageHandler) ([]Ciphertext, error) {
+	records, err := loadRecords(storage, func(value string) bool { return value != "" })
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Ciphertext, len(records.fields))
+	for i, field := range records.fields {
+		list[i].Name = field.name
+		list[i].Info, list[i].Err = crypto.InspectCiphertext(*field.value)
+	}
+	return list, nil
+}
+
+// LegacyCiphertexts 统计根钱包、账户和地址中仍为旧格式（salt+nonce 十六进制）的密文数量，不需要密码
+func LegacyCiphertexts(storage StorageHandler) (int, error) {
+	records, err := loadRecords(storage, crypto.IsLegacyCiphertext)
+	if err != nil {
+		return 0, err
+	}
+	return len(records.fields), nil
+}
+
+// EnvelopeVersions 按信封版本统计根钱包、账户和地址的密文数量，0 为旧格式；
+// 包括本程序不支持的更新版本，无法解码的密文不计入，不需要密码
+func EnvelopeVersions(storage StorageHandler) (map[int]int, error) {
+	counts := make(map[int]int)
+	count := func(value string) {
+		if value == "" {
+			return
+		}
+		info, err := crypto.InspectCiphertext(value)
+		if err != nil && !errors.Is(err, crypto.ErrUnsupportedEnvelope) {
+			return
+		}
+		counts[info.Version]++
+	}
+	root, err := storage.LoadRootWallet()
+	if err != nil || root == nil {
+		return counts, err
+	}
+	count(root.EncryptedMnemonic)
+	count(root.EncryptedSeed)
+	accounts, err := storage.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		count(account.EncryptedAccountPrivateKey)
+		addresses, err := storage.LoadAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			count(address.EncryptedPrivateKey)
+		}
+	}
+	return counts, nil
+}
+
+// MigrateEnvelopes 把旧格式的密文重新加密为带版本和参数的信封格式。
+// 每个字段重新加密后立即解密并与原文比对，无法解密的字段保持原样并记入 Skipped；
+// 写入前备份钱包、账户和地址文件，全部写入并确认存储中的内容无误后才删除备份，
+// 任何一步失败都保留备份并返回错误。两种格式都能解密，中途失败的部分迁移不影响使用，下次解锁时继续
+func MigrateEnvelopes(storage StorageHandler, password string, progress func(done, total int)) (*EnvelopeMigration, error) {
+	return reencryptRecords(storage, password, progress, crypto.IsLegacyCiphertext, "envelope-migration-")
+}
+
+// UpgradeCiphertexts 把低于当前加密配置的密文（旧格式、pbkdf2、参数较低的 scrypt 或 argon2id）
+// 用当前配置重新加密，备份、核对和失败处理与 MigrateEnvelopes 相同
+func UpgradeCiphertexts(storage StorageHandler, password string, progress func(done, total int)) (*EnvelopeMigration, error) {
+	return reencryptRecords(storage, password, progress, crypto.NeedsReencryption, "reencrypt-")
+}
+
+// reencryptRecords 重新加密 match 选中的密文字段，backupPrefix 为备份目录名的前缀
+func reencryptRecords(storage StorageHandler, password string, progress func(done, total int),
+	match func(ciphertext string) bool, backupPrefix string) (*EnvelopeMigration, error) {
+	records, err := loadRecords(storage, match)
+	if err != nil {
+		return nil, err
+	}
+	result := &EnvelopeMigration{}
+	total := len(records.fields)
+	if total == 0 {
+		return result, nil
+	}
+
+	migrated := make([]string, total)
+	for i, field := range records.fields {
+		sealed, err := Reencrypt(*field.value, password)
+		switch {
+		case errors.Is(err, crypto.ErrDecryptionFailed):
+			result.Skipped = append(result.Skipped, field.name)
+		case err != nil:
+			return result, fmt.Errorf("%s: %w", field.name, err)
+		default:
+			migrated[i] = sealed
+		}
+		if progress != nil {
+			progress(i+1, total)
+		}
+	}
+	if len(result.Skipped) == total {
+		return result, nil
+	}
+
+	backuper, ok := storage.(interface {
+		Backup(name string) (string, error)
+	})
+	if !ok {
+		return result, ErrMigrationNoBackup
+	}
+	backup, err := backuper.Backup(backupPrefix + time.Now().UTC().Format("20060102T150405Z"))
+	if err != nil {
+		return result, fmt.Errorf("backup before migration failed: %w", err)
+	}
+	result.Backup = backup
+	for i, field := range records.fields {
+		if migrated[i] != "" {
+			*field.value = migrated[i]
+		}
+	}
+
+	err = storage.WithTransaction(func(tx StorageWriter) error {
+		for _, account := range records.accounts {
+			if err := tx.SaveAccount(account); err != nil {
+				return err
+			}
+		}
+		for _, address := range records.addresses {
+			if err := tx.SaveAddress(address); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if records.root != nil {
+		if err := storage.SaveRootWallet(records.root); err != nil {
+			return result, err
+		}
+	}
+
+	// 重新读取，确认存储中只剩下跳过的字段仍需要重新加密
+	stored, err := loadRecords(storage, match)
+	if err != nil {
+		return result, err
+	}
+	if len(stored.fields) != len(result.Skipped) {
+		return result, ErrMigrationVerify
+	}
+	result.Migrated = total - len(result.Skipped)
+	if err := os.RemoveAll(backup); err != nil {
+		return result, err
+	}
+	result.Backup = ""
+	return result, nil
+}
+
+// Reencrypt 解密密文，用当前加密服务重新加密，再解密新密文与原文比对；
+// 密码不对时返回 crypto.ErrDecryptionFailed
+func Reencrypt(legacy, password string) (string, error) {
+	plaintext, err := security.Decrypt(legacy, password)
+	if err != nil {
+		return "", err
+	}
+	defer plaintext.Destroy()
+	sealed, err := crypto.EncryptData(plaintext.Bytes(), password)
+	if err != nil {
+		return "", err
+	}
+	check, err := security.Decrypt(sealed, password)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMigrationVerify, err)
+	}
+	defer check.Destroy()
+	if subtle.ConstantTimeCompare(plaintext.Bytes(), check.Bytes()) != 1 {
+		return "", ErrMigrationVerify
+	}
+	return sealed, nil
+}
@@ -2,49 +2,139 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/tracing"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/logging"
 )
 
-// FileStorage 基于本地文件系统的存储实现
+// ErrInvalidID 账户或回收站 ID 不能用作文件名（含路径分隔符或 ..），拒绝访问存储目录之外的文件
+var ErrInvalidID = errors.New("invalid id")
+
+// StorageSchemaVersion 存储目录布局和记录格式的版本，格式改变时递增并在 upgrade 包中加入迁移
+const StorageSchemaVersion = 1
+
+// 存储变更类型
+const (
+	AccountSaved   = "account.saved"
+	AccountDeleted = "account.deleted" // Account 只有 ID，账户的地址随之删除
+	AddressSaved   = "address.saved"
+	AddressDeleted = "address.deleted"
+)
+
+// Change 一次已生效的账户或地址写入
+type Change struct {
+	Kind    string
+	Account *CoinAccount
+	Address *AddressKey
+}
+
+// FileStorage 基于本地文件系统的存储实现，所有文件都在 baseDir 之内；
+// 多用户模式下每个用户一个 FileStorage，按 ID 构造的路径不能离开自己的目录
 type FileStorage struct {
 	baseDir      string
 	walletsDir   string
 	accountsDir  string
 	addressesDir string
+	trashDir     string
 	mutex        sync.RWMutex
+	afterWrite   func() error   // 每次成功写入后调用，如更新完整性清单
+	onChange     func([]Change) // 账户和地址的写入生效后调用，此时已释放存储锁
+	readOnly     bool
+	permissions  string       // 存储目录权限策略，见 EnforcePermissions
+	cache        *recordCache // 读取缓存，storage.cache_entries 为 0 时为 nil
+	lock         *os.File     // 实例锁，随进程退出释放
 }
 
-// NewFileStorage 创建新的文件存储实例
+// NewFileStorage 创建新的文件存储实例。同一存储目录同时只能被一个进程以读写方式打开，
+// 已被占用时返回 ErrDataDirLocked；cfg.ReadOnly 时不加锁也不写入，用于在另一个进程运行时查看数据
 func NewFileStorage(cfg config.StorageConfig) (*FileStorage, error) {
+	permissions, err := ParsePermissionPolicy(cfg.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := newRecordCache(cfg.CacheEntries)
+	if err != nil {
+		return nil, fmt.Errorf("创建读取缓存失败: %w", err)
+	}
 	storage := &FileStorage{
 		baseDir:      cfg.BaseDir,
 		walletsDir:   filepath.Join(cfg.BaseDir, "wallets"),
 		accountsDir:  filepath.Join(cfg.BaseDir, "accounts"),
 		addressesDir: filepath.Join(cfg.BaseDir, "addresses"),
+		trashDir:     filepath.Join(cfg.BaseDir, "trash"),
+		readOnly:     cfg.ReadOnly,
+		permissions:  permissions,
+		cache:        cache,
 	}
 
+	if storage.readOnly {
+		// 另一个进程可能正在事务中途，未完成的事务留给它或下次读写打开时处理
+		if _, err := os.Stat(filepath.Join(cfg.BaseDir, stagingDirName)); err == nil {
+			logging.Warnf("存储目录有未完成的事务，只读模式下不处理: %s", cfg.BaseDir)
+		}
+		if err := storage.EnforcePermissions(); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return storage, nil
+	}
+
+	if err := os.MkdirAll(cfg.BaseDir, 0700); err != nil {
+		return nil, fmt.Errorf("创建目录失败 %s: %w", cfg.BaseDir, err)
+	}
+	// 恢复的备份或共享的机器上权限可能被放宽，先于加锁和读取检查
+	if err := storage.EnforcePermissions(); err != nil {
+		return nil, err
+	}
+	lock, err := lockInstance(cfg.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	storage.lock = lock
+
 	// 创建必要的目录结构
-	dirs := []string{storage.walletsDir, storage.accountsDir, storage.addressesDir}
+	dirs := []string{storage.walletsDir, storage.accountsDir, storage.addressesDir, storage.trashDir}
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0700); err != nil {
 			return nil, fmt.Errorf("创建目录失败 %s: %w", dir, err)
 		}
 	}
 
+	// 完成或丢弃上次中断的事务
+	if err := storage.recoverTransaction(); err != nil {
+		return nil, fmt.Errorf("恢复未完成的事务失败: %w", err)
+	}
+
 	return storage, nil
 }
 
+// Close 释放实例锁，用于进程内临时打开的存储目录；之后不能再使用这个存储
+func (fs *FileStorage) Close() error {
+	if fs.lock == nil {
+		return nil
+	}
+	err := fs.lock.Close()
+	fs.lock = nil
+	return err
+}
+
 // SaveRootWallet 保存根钱包数据到JSON文件
 func (fs *FileStorage) SaveRootWallet(wallet *HDRootWallet) error {
 	fs.mutex.Lock()
 	defer fs.mutex.Unlock()
 
 	walletFile := filepath.Join(fs.walletsDir, "root_wallet.json")
+	wallet.ModificationTime = uint64(time.Now().Unix())
 	return fs.saveToFile(walletFile, wallet)
 }
 
@@ -65,7 +155,8 @@ func (fs *FileStorage) LoadRootWallet() (*HDRootWallet, error) {
 }
 
 // SaveAccount 保存账户数据到JSON文件
-func (fs *FileStorage) SaveAccount(account *CoinAccount) error {
+func (fs *FileStorage) SaveAccount(account *CoinAccount) (err error) {
+	defer fs.changed(&err, Change{Kind: AccountSaved, Account: account})
 	fs.mutex.Lock()
 	defer fs.mutex.Unlock()
 
@@ -75,25 +166,25 @@ func (fs *FileStorage) SaveAccount(account *CoinAccount) error {
 		return err
 	}
 
-	// 检查账户是否已存在，更新或添加
-	found := false
+	// 保存更新后的账户列表
+	accounts = mergeAccount(accounts, account)
+	SortAccounts(accounts)
+	return fs.saveToFile(fs.accountsFile(), accounts)
+}
+
+// mergeAccount 账户已存在时更新，否则追加，并记录修改时间
+func mergeAccount(accounts []*CoinAccount, account *CoinAccount) []*CoinAccount {
+	account.ModificationTime = uint64(time.Now().Unix())
 	for i, acc := range accounts {
 		if acc.ID == account.ID {
 			accounts[i] = account
-			found = true
-			break
+			return accounts
 		}
 	}
-	if !found {
-		accounts = append(accounts, account)
-	}
-
-	// 保存更新后的账户列表
-	accountsFile := filepath.Join(fs.accountsDir, "accounts.json")
-	return fs.saveToFile(accountsFile, accounts)
+	return append(accounts, account)
 }
 
-// LoadAccounts 加载所有账户数据
+// LoadAccounts 加载所有账户数据，按 SortAccounts 的顺序
 func (fs *FileStorage) LoadAccounts() ([]*CoinAccount, error) {
 	fs.mutex.RLock()
 	defer fs.mutex.RUnlock()
@@ -103,53 +194,63 @@ func (fs *FileStorage) LoadAccounts() ([]*CoinAccount, error) {
 
 // loadAllAccounts 内部方法：加载所有账户
 func (fs *FileStorage) loadAllAccounts() ([]*CoinAccount, error) {
-	accountsFile := filepath.Join(fs.accountsDir, "accounts.json")
 	var accounts []*CoinAccount
-	if err := fs.loadFromFile(accountsFile, &accounts); err != nil {
+	if err := fs.loadFromFile(fs.accountsFile(), &accounts); err != nil {
 		if os.IsNotExist(err) {
 			return []*CoinAccount{}, nil // 文件不存在返回空列表
 		}
 		return nil, err
 	}
+	SortAccounts(accounts)
 	return accounts, nil
 }
 
 // SaveAddress 保存地址数据到对应账户的文件
-func (fs *FileStorage) SaveAddress(address *AddressKey) error {
+func (fs *FileStorage) SaveAddress(address *AddressKey) (err error) {
+	defer fs.changed(&err, Change{Kind: AddressSaved, Address: address})
 	fs.mutex.Lock()
 	defer fs.mutex.Unlock()
 
-	addressFile := filepath.Join(fs.addressesDir, fmt.Sprintf("%s_addresses.json", address.AccountID))
+	addressFile, err := fs.addressFile(address.AccountID)
+	if err != nil {
+		return err
+	}
 
 	var addresses []*AddressKey
 	if err := fs.loadFromFile(addressFile, &addresses); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
-	// 检查地址是否已存在，更新或添加
-	found := false
+	addresses = mergeAddress(addresses, address)
+	SortAddresses(addresses)
+	return fs.saveToFile(addressFile, addresses)
+}
+
+// mergeAddress 同一账户、链和索引的地址已存在时更新并保留首次派生时间，否则追加
+func mergeAddress(addresses []*AddressKey, address *AddressKey) []*AddressKey {
 	for i, addr := range addresses {
 		if addr.AccountID == address.AccountID &&
 			addr.ChangeType == address.ChangeType &&
 			addr.AddressIndex == address.AddressIndex {
+			if addr.CreationTime != 0 && (address.CreationTime == 0 || addr.CreationTime < address.CreationTime) {
+				address.CreationTime = addr.CreationTime
+			}
 			addresses[i] = address
-			found = true
-			break
+			return addresses
 		}
 	}
-	if !found {
-		addresses = append(addresses, address)
-	}
-
-	return fs.saveToFile(addressFile, addresses)
+	return append(addresses, address)
 }
 
-// LoadAddresses 加载指定账户的所有地址
+// LoadAddresses 加载指定账户的所有地址，按 SortAddresses 的顺序
 func (fs *FileStorage) LoadAddresses(accountID string) ([]*AddressKey, error) {
 	fs.mutex.RLock()
 	defer fs.mutex.RUnlock()
 
-	addressFile := filepath.Join(fs.addressesDir, fmt.Sprintf("%s_addresses.json", accountID))
+	addressFile, err := fs.addressFile(accountID)
+	if err != nil {
+		return nil, err
+	}
 	var addresses []*AddressKey
 	if err := fs.loadFromFile(addressFile, &addresses); err != nil {
 		if os.IsNotExist(err) {
@@ -157,63 +258,180 @@ func (fs *FileStorage) LoadAddresses(accountID string) ([]*AddressKey, error) {
 		}
 		return nil, err
 	}
+	SortAddresses(addresses)
 	return addresses, nil
 }
 
+func (fs *FileStorage) accountsFile() string {
+	return filepath.Join(fs.accountsDir, "accounts.json")
+}
+
+func (fs *FileStorage) addressFile(accountID string) (string, error) {
+	if err := checkID(accountID); err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.addressesDir, fmt.Sprintf("%s_addresses.json", accountID)), nil
+}
+
+func (fs *FileStorage) trashFile(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.trashDir, id+".json"), nil
+}
+
+// checkID ID 只能是单个文件名的一部分
+func checkID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") || strings.ContainsRune(id, 0) {
+		return fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// LoadTrash 加载回收站中的全部记录，按删除时间排序
+func (fs *FileStorage) LoadTrash() ([]*TrashEntry, error) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(fs.trashDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*TrashEntry, 0, len(files))
+	for _, file := range files {
+		var entry TrashEntry
+		if err := fs.loadFromFile(file, &entry); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		entries = append(entries, &entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeletedAt.Before(entries[j].DeletedAt) })
+	return entries, nil
+}
+
 // saveToFile 通用方法：保存数据到JSON文件
-func (fs *FileStorage) saveToFile(filename string, data interface{}) error {
+func (fs *FileStorage) saveToFile(filename string, data interface{}) (err error) {
+	span := tracing.Begin("storage.write", "file", filepath.Base(filename))
+	defer func() { span.End(err) }()
+	if fs.readOnly {
+		return ErrReadOnly
+	}
 	// 创建临时文件以确保写入原子性
 	tempFile := filename + ".tmp"
+	if err := writeJSONFile(tempFile, data); err != nil {
+		return err
+	}
 
-	file, err := os.Create(tempFile)
+	// 重命名临时文件为正式文件（原子操作）
+	fs.cache.invalidate(filename)
+	if err := os.Rename(tempFile, filename); err != nil {
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+
+	if fs.afterWrite != nil {
+		if err := fs.afterWrite(); err != nil {
+			logging.Warnf("写入后回调失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// writeJSONFile 写入 JSON 并同步到磁盘
+func writeJSONFile(filename string, data interface{}) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("创建临时文件失败: %w", err)
 	}
 	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ") // 美化JSON输出
-	if err := encoder.Encode(data); err != nil {
+	// 规范编码（键排序、缩进固定），同样的记录总是写出同样的字节
+	encoded, err := canonjson.MarshalIndent(data, "  ")
+	if err != nil {
 		return fmt.Errorf("编码JSON失败: %w", err)
 	}
+	if _, err := file.Write(encoded); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
 
 	// 确保数据写入磁盘
 	if err := file.Sync(); err != nil {
 		return fmt.Errorf("同步文件失败: %w", err)
 	}
+	return nil
+}
 
-	// 重命名临时文件为正式文件（原子操作）
-	if err := os.Rename(tempFile, filename); err != nil {
-		return fmt.Errorf("重命名文件失败: %w", err)
-	}
+// OnWrite 设置写入后回调，回调失败只记录日志，不影响写入结果
+func (fs *FileStorage) OnWrite(fn func() error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.afterWrite = fn
+}
 
-	return nil
+// OnChange 设置账户和地址变更的回调，用于把变更发布给外部索引器；回调中可以读取存储
+func (fs *FileStorage) OnChange(fn func([]Change)) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.onChange = fn
 }
 
-// loadFromFile 通用方法：从JSON文件加载数据
-func (fs *FileStorage) loadFromFile(filename string, v interface{}) error {
-	file, err := os.Open(filename)
+// changed 写入成功后通知变更，在存储锁释放之后调用
+func (fs *FileStorage) changed(err *error, changes ...Change) {
+	if *err != nil || len(changes) == 0 {
+		return
+	}
+	fs.mutex.RLock()
+	fn := fs.onChange
+	fs.mutex.RUnlock()
+	if fn != nil {
+		fn(changes)
+	}
+}
+
+// loadFromFile 通用方法：从JSON文件加载数据，文件未变化时使用读取缓存
+func (fs *FileStorage) loadFromFile(filename string, v interface{}) (err error) {
+	span := tracing.Begin("storage.read", "file", filepath.Base(filename))
+	defer func() {
+		// 文件不存在是正常情况（还没有账户或地址），不算失败
+		if os.IsNotExist(err) {
+			span.End(nil)
+		} else {
+			span.End(err)
+		}
+	}()
+	info, err := os.Stat(filename)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	data := fs.cache.get(filename, info)
+	if data == nil {
+		span.Set("cache", "miss")
+		if data, err = os.ReadFile(filename); err != nil {
+			return err
+		}
+		fs.cache.put(filename, info, data)
+	} else {
+		span.Set("cache", "hit")
+	}
+	defer security.WipeSensitiveData(data)
 
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(v); err != nil {
+	if err := json.Unmarshal(data, v); err != nil {
 		return fmt.Errorf("解码JSON失败: %w", err)
 	}
-
 	return nil
 }
 
 // CheckStorageHealth 检查存储系统健康状态
 func (fs *FileStorage) CheckStorageHealth() error {
 	// 检查目录权限
-	dirs := []string{fs.walletsDir, fs.accountsDir, fs.addressesDir}
+	dirs := []string{fs.walletsDir, fs.accountsDir, fs.addressesDir, fs.trashDir}
 	for _, dir := range dirs {
 		if _, err := os.Stat(dir); err != nil {
 			return fmt.Errorf("目录不可访问 %s: %w", dir, err)
 		}
+		// 只读模式下不写入
+		if fs.readOnly {
+			continue
+		}
 
 		// 测试写入权限
 		testFile := filepath.Join(dir, ".healthcheck")
@@ -224,3 +442,83 @@ func (fs *FileStorage) CheckStorageHealth() error {
 	}
 	return nil
 }
+
+// Backup 把钱包、账户和地址文件复制到存储目录下的 name 子目录，返回备份目录；
+// 用于批量改写记录之前保留一份原样的副本
+func (fs *FileStorage) Backup(name string) (string, error) {
+	if fs.readOnly {
+		return "", ErrReadOnly
+	}
+	if err := checkID(name); err != nil {
+		return "", err
+	}
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	target := filepath.Join(fs.baseDir, name)
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("备份目录已存在: %s", target)
+	}
+	for _, dir := range []string{fs.walletsDir, fs.accountsDir, fs.addressesDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", err
+		}
+		copyDir := filepath.Join(target, filepath.Base(dir))
+		if err := os.MkdirAll(copyDir, 0700); err != nil {
+			return "", fmt.Errorf("创建目录失败 %s: %w", copyDir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") || entry.Name() == ".healthcheck" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(filepath.Join(copyDir, entry.Name()), data, 0600); err != nil {
+				return "", fmt.Errorf("写入备份失败: %w", err)
+			}
+		}
+	}
+	return target, nil
+}
+
+// AddressFileIDs 地址目录中有地址文件的账户 ID，包括账户列表中已没有的账户
+func (fs *FileStorage) AddressFileIDs() ([]string, error) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(fs.addressesDir, "*_addresses.json"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(file), "_addresses.json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// RemoveUnreadable 删除无法解码的账户列表（accountID 为空时）或地址文件，供重建存储时从头写入；
+// 调用前应先 Backup
+func (fs *FileStorage) RemoveUnreadable(accountID string) error {
+	if fs.readOnly {
+		return ErrReadOnly
+	}
+	file := fs.accountsFile()
+	if accountID != "" {
+		var err error
+		if file, err = fs.addressFile(accountID); err != nil {
+			return err
+		}
+	}
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.cache.invalidate(file)
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+const (
+	stagingDirName  = ".staging" // 事务暂存目录，位于存储根目录下，不属于同步和完整性校验的目录
+	journalFileName = "COMMIT"   // 提交记录，存在即表示事务已提交
+)
+
+// stagedFile 暂存文件和它要替换的目标文件（相对存储根目录），Staged 为空表示删除目标文件
+type stagedFile struct {
+	Staged string `json:"staged,omitempty"`
+	Target string `json:"target"`
+}
+
+// fileTx 文件存储的事务，写入按顺序记录，提交时在内存中依次应用后一起落盘
+type fileTx struct {
+	ops []func(st *txState) error
+}
+
+func (tx *fileTx) SaveAccount(account *CoinAccount) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		accounts, err := st.loadAccounts()
+		if err != nil {
+			return err
+		}
+		st.accounts = mergeAccount(accounts, account)
+		st.changes = append(st.changes, Change{Kind: AccountSaved, Account: account})
+		return nil
+	})
+	return nil
+}
+
+func (tx *fileTx) SaveAddress(address *AddressKey) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		addresses, err := st.loadAddresses(address.AccountID)
+		if err != nil {
+			return err
+		}
+		st.addresses[address.AccountID] = mergeAddress(addresses, address)
+		st.changes = append(st.changes, Change{Kind: AddressSaved, Address: address})
+		return nil
+	})
+	return nil
+}
+
+func (tx *fileTx) DeleteAccount(accountID string) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		accounts, err := st.loadAccounts()
+		if err != nil {
+			return err
+		}
+		kept := accounts[:0:0]
+		for _, account := range accounts {
+			if account.ID != accountID {
+				kept = append(kept, account)
+			}
+		}
+		if len(kept) == len(accounts) {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+		}
+		st.accounts = kept
+		st.addresses[accountID] = nil
+		st.changes = append(st.changes, Change{Kind: AccountDeleted, Account: &CoinAccount{ID: accountID}})
+		return nil
+	})
+	return nil
+}
+
+func (tx *fileTx) DeleteAddress(address *AddressKey) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		addresses, err := st.loadAddresses(address.AccountID)
+		if err != nil {
+			return err
+		}
+		kept := addresses[:0:0]
+		for _, addr := range addresses {
+			if addr.ChangeType != address.ChangeType || addr.AddressIndex != address.AddressIndex {
+				kept = append(kept, addr)
+			}
+		}
+		if len(kept) == len(addresses) {
+			return fmt.Errorf("%w: %s", ErrAddressNotFound, address.Address)
+		}
+		st.addresses[address.AccountID] = kept
+		st.changes = append(st.changes, Change{Kind: AddressDeleted, Address: address})
+		return nil
+	})
+	return nil
+}
+
+func (tx *fileTx) SaveTrash(entry *TrashEntry) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		st.trash[entry.ID] = entry
+		return nil
+	})
+	return nil
+}
+
+func (tx *fileTx) DeleteTrash(id string) error {
+	tx.ops = append(tx.ops, func(st *txState) error {
+		file, err := st.fs.trashFile(id)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(file); err != nil && st.trash[id] == nil {
+			return fmt.Errorf("%w: %s", ErrTrashNotFound, id)
+		}
+		st.trash[id] = nil
+		return nil
+	})
+	return nil
+}
+
+// txState 事务应用过程中受影响文件的新内容
+type txState struct {
+	fs        *FileStorage
+	accounts  []*CoinAccount           // 为 nil 时尚未读取，账户列表未改变
+	addresses map[string][]*AddressKey // 账户 ID 到地址列表，值为 nil 表示删除地址文件
+	trash     map[string]*TrashEntry   // 回收站 ID 到记录，值为 nil 表示删除
+	changes   []Change                 // 提交后通知的账户和地址变更
+}
+
+func (st *txState) loadAccounts() ([]*CoinAccount, error) {
+	if st.accounts == nil {
+		accounts, err := st.fs.loadAllAccounts()
+		if err != nil {
+			return nil, err
+		}
+		st.accounts = accounts
+	}
+	return st.accounts, nil
+}
+
+func (st *txState) loadAddresses(accountID string) ([]*AddressKey, error) {
+	if addresses, ok := st.addresses[accountID]; ok {
+		return addresses, nil
+	}
+	file, err := st.fs.addressFile(accountID)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []*AddressKey
+	if err := st.fs.loadFromFile(file, &addresses); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// WithTransaction 提交时把受影响的文件完整写入暂存目录，写入提交记录后再逐个替换或删除正式文件；
+// 替换过程中断时，下次打开存储会按提交记录继续完成，没有提交记录的暂存目录直接丢弃
+func (fs *FileStorage) WithTransaction(fn func(tx StorageWriter) error) (err error) {
+	tx := &fileTx{}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+	if fs.readOnly {
+		return ErrReadOnly
+	}
+
+	var changes []Change
+	defer func() { fs.changed(&err, changes...) }()
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if changes, err = fs.commit(tx); err != nil {
+		return err
+	}
+	if fs.afterWrite != nil {
+		if err := fs.afterWrite(); err != nil {
+			logging.Warnf("写入后回调失败: %v", err)
+		}
+	}
+	return nil
+}
+
+func (fs *FileStorage) commit(tx *fileTx) ([]Change, error) {
+	st := &txState{fs: fs, addresses: make(map[string][]*AddressKey), trash: make(map[string]*TrashEntry)}
+	for _, op := range tx.ops {
+		if err := op(st); err != nil {
+			return nil, err
+		}
+	}
+
+	// 目标文件的新内容，nil 表示删除
+	files := make(map[string]interface{})
+	if st.accounts != nil {
+		SortAccounts(st.accounts)
+		files[fs.accountsFile()] = st.accounts
+	}
+	for accountID, addresses := range st.addresses {
+		file, err := fs.addressFile(accountID)
+		if err != nil {
+			return nil, err
+		}
+		if addresses == nil {
+			files[file] = nil
+		} else {
+			SortAddresses(addresses)
+			files[file] = addresses
+		}
+	}
+	for id, entry := range st.trash {
+		file, err := fs.trashFile(id)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			files[file] = nil
+		} else {
+			files[file] = entry
+		}
+	}
+
+	staging := filepath.Join(fs.baseDir, stagingDirName)
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, fmt.Errorf("清理暂存目录失败: %w", err)
+	}
+	if err := os.MkdirAll(staging, 0700); err != nil {
+		return nil, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+
+	targets := make([]string, 0, len(files))
+	for target := range files {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	journal := make([]stagedFile, len(targets))
+	for i, target := range targets {
+		rel, err := filepath.Rel(fs.baseDir, target)
+		if err != nil {
+			os.RemoveAll(staging)
+			return nil, err
+		}
+		journal[i] = stagedFile{Target: rel}
+		if files[target] == nil {
+			continue
+		}
+		journal[i].Staged = fmt.Sprintf("%d.json", i)
+		if err := writeJSONFile(filepath.Join(staging, journal[i].Staged), files[target]); err != nil {
+			os.RemoveAll(staging)
+			return nil, err
+		}
+	}
+
+	// 提交点：提交记录改名成功之后事务一定会完成
+	journalFile := filepath.Join(staging, journalFileName)
+	if err := writeJSONFile(journalFile+".tmp", journal); err != nil {
+		os.RemoveAll(staging)
+		return nil, err
+	}
+	if err := os.Rename(journalFile+".tmp", journalFile); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("写入提交记录失败: %w", err)
+	}
+	syncDir(staging)
+	return st.changes, fs.applyJournal(staging, journal)
+}
+
+// applyJournal 按提交记录替换或删除正式文件，已经处理过的（暂存文件或目标文件不存在）跳过
+func (fs *FileStorage) applyJournal(staging string, journal []stagedFile) error {
+	for _, file := range journal {
+		if !filepath.IsLocal(file.Target) || (file.Staged != "" && !filepath.IsLocal(file.Staged)) {
+			return fmt.Errorf("提交记录中的路径无效: %q", file.Target)
+		}
+		target := filepath.Join(fs.baseDir, file.Target)
+		fs.cache.invalidate(target)
+		if file.Staged == "" {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("删除 %s 失败: %w", file.Target, err)
+			}
+			continue
+		}
+		staged := filepath.Join(staging, file.Staged)
+		if _, err := os.Stat(staged); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(staged, target); err != nil {
+			return fmt.Errorf("替换 %s 失败: %w", file.Target, err)
+		}
+	}
+	return os.RemoveAll(staging)
+}
+
+// recoverTransaction 打开存储时处理上次中断的事务：已提交的继续完成，未提交的丢弃
+func (fs *FileStorage) recoverTransaction() error {
+	staging := filepath.Join(fs.baseDir, stagingDirName)
+	data, err := os.ReadFile(filepath.Join(staging, journalFileName))
+	if os.IsNotExist(err) {
+		return os.RemoveAll(staging)
+	}
+	if err != nil {
+		return err
+	}
+	var journal []stagedFile
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return fmt.Errorf("解析提交记录失败: %w", err)
+	}
+	for _, file := range journal {
+		target := filepath.Clean(file.Target)
+		if filepath.IsAbs(target) || target == ".." || strings.HasPrefix(target, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("提交记录中的路径无效: %s", file.Target)
+		}
+	}
+	logging.Warnf("完成上次中断的存储事务（%d 个文件）", len(journal))
+	return fs.applyJournal(staging, journal)
+}
+
+// syncDir 同步目录项，保证改名在断电后仍然有效；失败时只影响持久性，不影响正确性
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LockFileName 存储目录中的实例锁文件，持有锁的进程把自己的 pid 写在里面
+const LockFileName = "slowmade.lock"
+
+// 错误定义
+var (
+	ErrDataDirLocked = errors.New("data directory is in use by another slowmade process")
+	ErrReadOnly      = errors.New("storage is opened read-only")
+)
+
+// lockedError 获取实例锁失败时的提示，尽量带上持有锁的进程
+func lockedError(dir string, file *os.File) error {
+	holder := ""
+	if data, err := os.ReadFile(file.Name()); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			holder = fmt.Sprintf(" (pid %d)", pid)
+		}
+	}
+	return fmt.Errorf("%w: %s%s; stop it first, or pass --read-only to inspect the data concurrently", ErrDataDirLocked, dir, holder)
+}
+
+// writePID 取得锁后记录当前进程，供其他进程报错时显示
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
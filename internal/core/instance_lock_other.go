@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package core
+
+import "os"
+
+// lockInstance 当前平台没有可用的文件锁，不做限制
+func lockInstance(dir string) (*os.File, error) {
+	return nil, nil
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockInstance 对存储目录加排他的 flock，进程退出（包括崩溃）时由内核释放，不会留下失效的锁
+func lockInstance(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, LockFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		defer file.Close()
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, lockedError(dir, file)
+		}
+		return nil, err
+	}
+	if err := writePID(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
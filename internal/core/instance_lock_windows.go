@@ -0,0 +1,34 @@
+//go:build windows
+
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockInstance 对锁文件加排他的 LockFileEx 锁，进程退出时由系统释放
+func lockInstance(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, LockFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// 只锁 pid 之后的一个字节，其他进程仍能读出 pid
+	overlapped := &windows.Overlapped{Offset: 1 << 20}
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if err := windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, 1, 0, overlapped); err != nil {
+		defer file.Close()
+		if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+			return nil, lockedError(dir, file)
+		}
+		return nil, err
+	}
+	if err := writePID(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
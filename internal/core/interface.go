@@ -1,23 +1,54 @@
 package core
 
+import (
+	"time"
+
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+)
+
 // 定义了钱包生命周期管理的核心操作
 type WalletManager interface {
-	CreateNewWallet(password string) (*HDRootWallet, error)                     // 创建新钱包（生成助记词和种子）
-	ExportMnemonic(password string) (string, error)                             // 导出助记词
-	RestoreWalletFromMnemonic(mnemonic, password string) (*HDRootWallet, error) // 从助记词恢复钱包
-	UnlockWallet(password string) error                                         // 解锁钱包（解密根种子）
-	LockWallet()                                                                // 锁定钱包（清除内存中的敏感信息）
-	IsLocked() bool                                                             // 检查钱包当前是否已解锁
-	Seed() ([]byte, error)                                                      // 返回解密后的Seed
+	CreateNewWallet(password string, extra ...mnemonic.EntropySource) (*HDRootWallet, error)        // 创建新钱包（生成助记词和种子），extra 为额外的熵来源
+	ExportMnemonic(password string) (*security.SecureBytes, error)                                  // 导出助记词（用完必须 Destroy）
+	RestoreWalletFromMnemonic(mnemonic, password string) (*HDRootWallet, error)                     // 从助记词恢复钱包
+	UnlockWallet(password string) error                                                             // 解锁钱包（解密根种子）
+	LockWallet()                                                                                    // 锁定钱包（清除内存中的敏感信息）
+	IsLocked() bool                                                                                 // 检查钱包当前是否已解锁
+	Seed() (*security.SecureBytes, error)                                                           // 返回解密后的Seed（锁定内存，用完必须 Destroy）
+	Password() ([]byte, error)                                                                      // 解锁密码的副本，调用方用完必须清除
+	Timestamps() (created, modified time.Time, err error)                                           // 根钱包的创建和最后修改时间，未记录时为零值
+	MigrateEnvelopes(password string, progress func(done, total int)) (*EnvelopeMigration, error)   // 把旧格式密文重新加密为信封格式（先备份，逐个核对）
+	UpgradeCiphertexts(password string, progress func(done, total int)) (*EnvelopeMigration, error) // 把低于当前加密配置的密文重新加密（先备份，逐个核对）
+	Ciphertexts() ([]Ciphertext, error)                                                             // 全部密文字段的格式和 KDF 参数，不需要解锁
 }
 
 // AccountManager 定义了账户管理的操作
 type AccountManager interface {
-	CreateNewAccount(derivationPath *DerivationPath) (*CoinAccount, error)                       // 创建新币种账户
-	GetAccountsByCoin(coinType uint32) ([]*CoinAccount, error)                                   // 获取指定币种的所有账户
-	DeriveAddress(accountID string, changeType uint32, addressIndex uint32) (*AddressKey, error) // 为指定账户派生新地址
-	GetAddresses(accountID string) ([]*AddressKey, error)                                        // 获取指定账户下的所有地址
+	CreateNewAccount(derivationPath *DerivationPath, convention PathConvention) (*CoinAccount, error) // 按地址派生约定创建新币种账户
+	GetAccountsByCoin(coinType uint32) ([]*CoinAccount, error)                                        // 获取指定币种的所有账户
+	GetAccounts() ([]*CoinAccount, error)                                                             // 获取所有币种的全部账户
+	DeriveAddress(accountID string, changeType uint32, addressIndex uint32) (*AddressKey, error)      // 为指定账户派生新地址
+	DeriveAddresses(accountID string, changeType uint32, start, count uint32) ([]*AddressKey, error)  // 批量派生连续地址，一次保存
+	GetAddresses(accountID string) ([]*AddressKey, error)                                             // 获取指定账户下的所有地址
+	AddressPrivateKey(address *AddressKey) (*security.SecureBytes, error)                             // 解密地址私钥（需要钱包已解锁，用完必须 Destroy）
+	AccountPublicKey(accountID string) (string, error)                                                // 账户层级扩展公钥（xpub），不含私钥材料
+	AccountFingerprint(accountID string) (string, error)                                              // 账户公钥指纹（HASH160 前 4 字节）
+	MasterFingerprint() (string, error)                                                               // 主密钥指纹，需要钱包已解锁
 	IDString(derivationPath string) string
+	ExportAccount(accountID, exportPassword string) ([]byte, error)             // 导出单个账户（用导出密码加密，不含根种子）
+	ImportAccount(data []byte, exportPassword string) (*CoinAccount, error)     // 导入单个账户为独立账户
+	FreezeAccount(accountID string) error                                       // 冻结账户，禁止签名和派生私钥
+	UnfreezeAccount(accountID, password string) error                           // 校验密码后解除冻结
+	RemoveAccount(accountID string) (*TrashEntry, error)                        // 把账户连同它的地址移到回收站
+	RemoveAddress(address *AddressKey) (*TrashEntry, error)                     // 把单个地址移到回收站
+	Trash() ([]*TrashEntry, error)                                              // 回收站中的记录，按删除时间排序
+	RestoreTrash(id string, resolve ConflictResolver) (*TrashEntry, int, error) // 从回收站恢复记录
+	PurgeTrash(id string) error                                                 // 永久删除回收站中的记录
+	PurgeExpiredTrash(retention time.Duration) (int, error)                     // 永久删除超过保留期的记录，返回删除数
+	IsMine(address string) (*AddressKey, bool)                                  // 地址是否由本钱包派生（布隆过滤器加精确索引）
+	ReloadOwnership()                                                           // 存储被外部修改后重建地址索引
+	RebuildStorage(opts RebuildOptions) (*RebuildReport, error)                 // 账户列表丢失时由根种子、地址文件和链上扫描重建账户和地址
 }
 
 // StorageHandler 定义了数据持久化的操作，支持不同的后端（如文件系统、数据库）
@@ -28,4 +59,17 @@ type StorageHandler interface {
 	LoadAccounts() ([]*CoinAccount, error)
 	SaveAddress(address *AddressKey) error
 	LoadAddresses(accountID string) ([]*AddressKey, error)
+	LoadTrash() ([]*TrashEntry, error)
+	// WithTransaction fn 返回 nil 时其中的写入全部生效，返回错误或提交失败时全部不生效
+	WithTransaction(fn func(tx StorageWriter) error) error
+}
+
+// StorageWriter 事务中可用的写入操作，事务提交前的写入对读取不可见
+type StorageWriter interface {
+	SaveAccount(account *CoinAccount) error
+	SaveAddress(address *AddressKey) error
+	DeleteAccount(accountID string) error // 同时删除账户的全部地址
+	DeleteAddress(address *AddressKey) error
+	SaveTrash(entry *TrashEntry) error
+	DeleteTrash(id string) error
 }
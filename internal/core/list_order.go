@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ListOrder 列出账户和地址的顺序
+type ListOrder string
+
+const (
+	OrderIndex   ListOrder = "index"   // 默认：币种、账户索引，地址按链和地址索引
+	OrderCreated ListOrder = "created" // 创建时间，旧版本创建的没有记录，排在最前
+	OrderLabel   ListOrder = "label"   // 标签的字典序，没有标签的排在最后
+)
+
+// ParseListOrder 解析 --sort 的值，为空时为 OrderIndex
+func ParseListOrder(value string) (ListOrder, error) {
+	switch order := ListOrder(value); order {
+	case "":
+		return OrderIndex, nil
+	case OrderIndex, OrderCreated, OrderLabel:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown sort order %q, use created, index or label", value)
+}
+
+// SortAccounts 账户的稳定顺序：币种类型、账户索引、purpose、地址约定，最后按 ID，与文件中的顺序无关
+func SortAccounts(accounts []*CoinAccount) {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accountSortKey(accounts[i]), accountSortKey(accounts[j])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		if accounts[i].Convention() != accounts[j].Convention() {
+			return accounts[i].Convention() < accounts[j].Convention()
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+}
+
+// accountSortKey 去掉硬化位的币种类型、账户索引和 purpose，路径无法解析的排在最后
+func accountSortKey(account *CoinAccount) [3]uint64 {
+	path, err := account.Path()
+	if err != nil {
+		return [3]uint64{1 << 32, 0, 0}
+	}
+	return [3]uint64{uint64(path.CoinType &^ HardenedOffset), uint64(path.AccountIndex &^ HardenedOffset), uint64(path.Purpose &^ HardenedOffset)}
+}
+
+// SortAddresses 地址的稳定顺序：账户、收款地址在找零地址前，按地址索引
+func SortAddresses(addresses []*AddressKey) {
+	sort.SliceStable(addresses, func(i, j int) bool {
+		a, b := addresses[i], addresses[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.ChangeType != b.ChangeType {
+			return a.ChangeType < b.ChangeType
+		}
+		return a.AddressIndex < b.AddressIndex
+	})
+}
+
+// OrderAccounts 按 order 重排已按 SortAccounts 排好的账户，相同时保持原顺序；label 返回账户 ID 的标签
+func OrderAccounts(accounts []*CoinAccount, order ListOrder, label func(key string) string) {
+	switch order {
+	case OrderCreated:
+		sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].CreationTime < accounts[j].CreationTime })
+	case OrderLabel:
+		sort.SliceStable(accounts, func(i, j int) bool { return labelLess(label(accounts[i].ID), label(accounts[j].ID)) })
+	}
+}
+
+// OrderAddresses 按 order 重排已按 SortAddresses 排好的地址，相同时保持原顺序；label 返回地址的标签
+func OrderAddresses(addresses []*AddressKey, order ListOrder, label func(key string) string) {
+	switch order {
+	case OrderCreated:
+		sort.SliceStable(addresses, func(i, j int) bool { return addresses[i].CreationTime < addresses[j].CreationTime })
+	case OrderLabel:
+		sort.SliceStable(addresses, func(i, j int) bool { return labelLess(label(addresses[i].Address), label(addresses[j].Address)) })
+	}
+}
+
+// labelLess 有标签的在前，按字典序
+func labelLess(a, b string) bool {
+	if a == "" || b == "" {
+		return a != "" && b == ""
+	}
+	return a < b
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// bloomFalsePositive 布隆过滤器的目标误判率
+const bloomFalsePositive = 0.01
+
+// bloomFilter 只增不删的布隆过滤器，用双重哈希生成 k 个位置
+type bloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// newBloomFilter 按预计元素数 n 和目标误判率分配位数组
+func newBloomFilter(n int) *bloomFilter {
+	if n < 64 {
+		n = 64
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(bloomFalsePositive) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func (b *bloomFilter) positions(item string) (h1, h2 uint64) {
+	sum := sha256.Sum256([]byte(item))
+	return binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
+
+func (b *bloomFilter) add(item string) {
+	h1, h2 := b.positions(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) mayContain(item string) bool {
+	h1, h2 := b.positions(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ownershipIndex 全部派生地址的索引：布隆过滤器快速排除不属于钱包的地址，精确表确认并给出地址记录
+type ownershipIndex struct {
+	filter   *bloomFilter
+	capacity int
+	exact    map[string]*AddressKey
+}
+
+func newOwnershipIndex(addresses []*AddressKey) *ownershipIndex {
+	capacity := 2 * len(addresses)
+	idx := &ownershipIndex{filter: newBloomFilter(capacity), capacity: capacity, exact: make(map[string]*AddressKey, len(addresses))}
+	for _, addr := range addresses {
+		idx.add(addr)
+	}
+	return idx
+}
+
+// add 加入地址，超过容量时按两倍容量重建过滤器，保持误判率
+func (idx *ownershipIndex) add(addr *AddressKey) {
+	key := ownershipKey(addr.CoinSymbol, addr.Address)
+	idx.exact[key] = addr
+	if len(idx.exact) > idx.capacity {
+		idx.capacity = 2 * len(idx.exact)
+		idx.filter = newBloomFilter(idx.capacity)
+		for key := range idx.exact {
+			idx.filter.add(key)
+		}
+		return
+	}
+	idx.filter.add(key)
+}
+
+// lookup 查询时不知道地址属于哪个币种，依次尝试内置规则和每个接受该地址的插件给出的规范形式
+func (idx *ownershipIndex) lookup(address string) (*AddressKey, bool) {
+	for _, key := range lookupKeys(address) {
+		if !idx.filter.mayContain(key) {
+			continue
+		}
+		if addr, ok := idx.exact[key]; ok {
+			return addr, true
+		}
+	}
+	return nil, false
+}
+
+// ownershipKey 地址在索引中的键：由插件接入的币种用插件的规范形式（如 BCH 补上 bitcoincash: 前缀、
+// ltc1 和 Cosmos 地址转为小写），内置币种和插件不接受的地址按 foldAddress 处理
+func ownershipKey(symbol, address string) string {
+	if plugin, ok := LookupCoinPlugin(symbol); ok {
+		if normalized, err := plugin.NormalizeAddress(address); err == nil {
+			return normalized
+		}
+	}
+	return foldAddress(address)
+}
+
+// lookupKeys 待查地址可能对应的全部键，去掉重复
+func lookupKeys(address string) []string {
+	keys := []string{foldAddress(address)}
+	for _, plugin := range CoinPlugins() {
+		normalized, err := plugin.NormalizeAddress(address)
+		if err == nil && !slices.Contains(keys, normalized) {
+			keys = append(keys, normalized)
+		}
+	}
+	return keys
+}
+
+// foldAddress 内置币种的规则：十六进制（0x）和比特币 bech32 地址不区分大小写，base58 地址保持原样
+func foldAddress(address string) string {
+	lower := strings.ToLower(address)
+	if strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "bc1") || strings.HasPrefix(lower, "tb1") || strings.HasPrefix(lower, "bcrt1") {
+		return lower
+	}
+	return address
+}
+
+// ownership 账户管理器持有的索引，第一次查询时从存储构建
+type ownership struct {
+	mu    sync.Mutex
+	index *ownershipIndex
+}
+
+// IsMine 地址是否由本钱包派生，是时返回地址记录；不需要解锁钱包
+func (am *DefaultAccountManager) IsMine(address string) (*AddressKey, bool) {
+	am.owned.mu.Lock()
+	defer am.owned.mu.Unlock()
+	if am.owned.index == nil {
+		index, err := am.buildOwnershipIndex()
+		if err != nil {
+			return nil, false
+		}
+		am.owned.index = index
+	}
+	return am.owned.index.lookup(address)
+}
+
+// ReloadOwnership 存储被外部修改（同步、恢复备份）后丢弃索引，下次查询时重建
+func (am *DefaultAccountManager) ReloadOwnership() {
+	am.owned.mu.Lock()
+	defer am.owned.mu.Unlock()
+	am.owned.index = nil
+	if am.derived != nil {
+		am.derived.reset()
+	}
+}
+
+// trackOwned 新派生或导入的地址加入已构建的索引
+func (am *DefaultAccountManager) trackOwned(addresses ...*AddressKey) {
+	am.owned.mu.Lock()
+	defer am.owned.mu.Unlock()
+	if am.owned.index == nil {
+		return
+	}
+	for _, addr := range addresses {
+		am.owned.index.add(addr)
+	}
+}
+
+func (am *DefaultAccountManager) buildOwnershipIndex() (*ownershipIndex, error) {
+	accounts, err := am.storage.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var all []*AddressKey
+	for _, account := range accounts {
+		addresses, err := am.storage.LoadAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, addresses...)
+	}
+	return newOwnershipIndex(all), nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PathConvention 账户下地址的派生约定，用于找回其他钱包按非标准路径派生的资金
+type PathConvention string
+
+const (
+	ConventionStandard   PathConvention = "standard"    // m/44'/60'/0'/0/x，BIP44，MetaMask、Trezor 等
+	ConventionLedgerLive PathConvention = "ledger-live" // m/44'/60'/x'/0/0，每个账户只有一个地址
+	ConventionMEW        PathConvention = "mew"         // m/44'/60'/0'/x，MyEtherWallet 和 Ledger 旧版，没有 change 层级
+)
+
+// PathConventions 全部约定，按显示顺序
+var PathConventions = []PathConvention{ConventionStandard, ConventionLedgerLive, ConventionMEW}
+
+var (
+	ErrUnknownConvention = errors.New("unknown path convention, expected standard, ledger-live or mew")
+	ErrSingleAddress     = errors.New("ledger-live accounts have a single address at 0/0, create the next account instead")
+	ErrNoChangeLevel     = errors.New("mew accounts have no change level, only receive addresses")
+)
+
+// ParsePathConvention 解析约定名称，空字符串为 standard
+func ParsePathConvention(name string) (PathConvention, error) {
+	if name == "" {
+		return ConventionStandard, nil
+	}
+	for _, c := range PathConventions {
+		if strings.EqualFold(name, string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownConvention, name)
+}
+
+// Pattern 约定的路径模式，x 为递增的索引
+func (c PathConvention) Pattern(coinType uint32) string {
+	coinLevel := coinType &^ HardenedOffset
+	switch c {
+	case ConventionLedgerLive:
+		return fmt.Sprintf("m/44'/%d'/x'/0/0", coinLevel)
+	case ConventionMEW:
+		return fmt.Sprintf("m/44'/%d'/0'/x", coinLevel)
+	default:
+		return fmt.Sprintf("m/44'/%d'/0'/0/x", coinLevel)
+	}
+}
+
+// AccountPath 约定下第 index 个账户的账户级路径 m/44'/coin'/index'
+func (c PathConvention) AccountPath(coinType, index uint32) *DerivationPath {
+	return NewDerivationPath([]uint32{44 | HardenedOffset, coinType | HardenedOffset, index | HardenedOffset})
+}
+
+// addressComponents 地址相对账户密钥的路径组件
+func (c PathConvention) addressComponents(changeType, addressIndex uint32) ([]uint32, error) {
+	switch c {
+	case ConventionLedgerLive:
+		if changeType != 0 || addressIndex != 0 {
+			return nil, ErrSingleAddress
+		}
+		return []uint32{0, 0}, nil
+	case ConventionMEW:
+		if changeType != 0 {
+			return nil, ErrNoChangeLevel
+		}
+		return []uint32{addressIndex}, nil
+	default:
+		return []uint32{changeType, addressIndex}, nil
+	}
+}
+
+// idInput 计算账户 ID 的输入，非标准约定加上前缀，避免与同路径的标准账户冲突
+func (c PathConvention) idInput(accountPath string) string {
+	if c == ConventionStandard {
+		return accountPath
+	}
+	return string(c) + ":" + accountPath
+}
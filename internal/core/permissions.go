@@ -0,0 +1,119 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// 存储目录权限策略（storage.permissions）
+const (
+	PermissionsWarn   = "warn"   // 记录警告，默认
+	PermissionsRepair = "repair" // 自动改为 0700 目录、0600 文件
+	PermissionsStrict = "strict" // 其他用户可以访问时拒绝继续
+)
+
+// ErrWorldAccessible 严格模式下存储目录中有其他用户可以访问的目录或文件
+var ErrWorldAccessible = errors.New("data directory is accessible by other users")
+
+// LooseEntry 权限过宽的目录或文件
+type LooseEntry struct {
+	Path string // 相对存储目录的路径
+	Mode fs.FileMode
+}
+
+// World 其他用户（而不只是同组用户）可以访问
+func (e LooseEntry) World() bool {
+	return e.Mode.Perm()&0007 != 0
+}
+
+// ParsePermissionPolicy 校验权限策略，空字符串为 warn
+func ParsePermissionPolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return PermissionsWarn, nil
+	case PermissionsWarn, PermissionsRepair, PermissionsStrict:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%w: storage.permissions %q must be warn, repair or strict", config.ErrInvalid, policy)
+}
+
+// LoosePermissions 返回存储目录中组或其他用户可以访问的目录和文件，
+// 存储目录应为 0700、文件为 0600；Windows 上权限位没有意义，不检查
+func LoosePermissions(baseDir string) ([]LooseEntry, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	var loose []LooseEntry
+	err := filepath.WalkDir(baseDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// 符号链接自身的权限位没有意义
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			rel, err := filepath.Rel(baseDir, path)
+			if err != nil {
+				return err
+			}
+			loose = append(loose, LooseEntry{Path: rel, Mode: info.Mode()})
+		}
+		return nil
+	})
+	return loose, err
+}
+
+// RepairPermissions 将权限过宽的目录改为 0700、文件改为 0600，返回修改的数量
+func RepairPermissions(baseDir string, loose []LooseEntry) (int, error) {
+	repaired := 0
+	for _, entry := range loose {
+		mode := fs.FileMode(0600)
+		if entry.Mode.IsDir() {
+			mode = 0700
+		}
+		if err := os.Chmod(filepath.Join(baseDir, entry.Path), mode); err != nil {
+			return repaired, fmt.Errorf("修改权限失败 %s: %w", entry.Path, err)
+		}
+		repaired++
+	}
+	return repaired, nil
+}
+
+// EnforcePermissions 按策略检查存储目录权限：warn 记录警告，repair 自动修正（只读模式下不修改），
+// strict 在其他用户可以访问时返回 ErrWorldAccessible。启动时和每次解锁前调用
+func (fs *FileStorage) EnforcePermissions() error {
+	loose, err := LoosePermissions(fs.baseDir)
+	if err != nil || len(loose) == 0 {
+		return err
+	}
+	if fs.permissions == PermissionsRepair && !fs.readOnly {
+		repaired, err := RepairPermissions(fs.baseDir, loose)
+		if err != nil {
+			return err
+		}
+		logging.Infof("已修正存储目录中 %d 个目录和文件的权限: %s", repaired, fs.baseDir)
+		return nil
+	}
+	if fs.permissions == PermissionsStrict {
+		for _, entry := range loose {
+			if entry.World() {
+				return fmt.Errorf("%w: %s is %s, run chmod -R go-rwx %s", ErrWorldAccessible, entry.Path, entry.Mode.Perm(), fs.baseDir)
+			}
+		}
+	}
+	logging.Warnf("存储目录中 %d 个目录和文件可以被其他用户访问，例如 %s (%s)；运行 chmod -R go-rwx %s 或设置 storage.permissions = \"repair\"",
+		len(loose), loose[0].Path, loose[0].Mode.Perm(), fs.baseDir)
+	return nil
+}
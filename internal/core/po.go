@@ -1,19 +1,32 @@
 package core
 
-import "github.com/palagend/slowmade/pkg/logging"
+import (
+	"github.com/palagend/slowmade/pkg/addrformat"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
 
 // 根钱包
 type HDRootWallet struct {
 	EncryptedMnemonic string //加密后的助记词
 	EncryptedSeed     string //加密后的种子
 	CreationTime      uint64 //创建时间
+	ModificationTime  uint64 `json:",omitempty"` // 最后保存时间，旧版本保存的文件没有
 }
 
 type CoinAccount struct {
 	ID                         string
 	CoinSymbol                 string
-	DerivationPath             string // derivationPath的字符串表示
-	EncryptedAccountPrivateKey string // 加密的账户层级私钥
+	DerivationPath             string         // derivationPath的字符串表示
+	EncryptedAccountPrivateKey string         // 加密的账户层级私钥
+	Standalone                 bool           `json:",omitempty"` // 从其他实例导入的独立账户，不由本钱包根种子派生
+	PathConvention             PathConvention `json:",omitempty"` // 地址派生约定，为空表示 standard
+	AddressFormat              string         `json:",omitempty"` // 输出脚本不同的地址格式（BTC 的 legacy、p2sh、bech32、bech32m），为空时按旧版本的规则
+	CreationTime               uint64         `json:",omitempty"` // 创建或导入时间，旧版本创建的账户没有
+	ModificationTime           uint64         `json:",omitempty"` // 最后保存时间
+	Frozen                     bool           `json:",omitempty"` // 已冻结：不能签名、派生新地址或导出私钥
+	FrozenTime                 uint64         `json:",omitempty"` // 冻结时间
 
 	derivationPath *DerivationPath
 }
@@ -26,10 +39,90 @@ type AddressKey struct {
 	ChangeType          uint32 // 0-外部链（收款地址），1-内部链（找零地址）
 	AddressIndex        uint32
 	CoinSymbol          string
+	CreationTime        uint64 `json:",omitempty"` // 首次派生时间，旧版本派生的地址没有
+}
+
+// Created 创建时间，未记录时为零值
+func (w *HDRootWallet) Created() time.Time {
+	return unixTime(w.CreationTime)
+}
+
+// Modified 最后修改时间，未记录时为零值
+func (w *HDRootWallet) Modified() time.Time {
+	return unixTime(w.ModificationTime)
+}
+
+// Created 创建时间，未记录时为零值
+func (c *CoinAccount) Created() time.Time {
+	return unixTime(c.CreationTime)
+}
+
+// Modified 最后修改时间，未记录时为零值
+func (c *CoinAccount) Modified() time.Time {
+	return unixTime(c.ModificationTime)
+}
+
+// FrozenAt 冻结时间，未冻结时为零值
+func (c *CoinAccount) FrozenAt() time.Time {
+	return unixTime(c.FrozenTime)
+}
+
+func unixTime(seconds uint64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0)
 }
 
 func (c *CoinAccount) CoinType() uint32 {
-	logging.Debugf("Ignore possible parsing errors for %s.", c.DerivationPath)
-	dp, _ := ParseDerivationPath(c.DerivationPath)
+	dp, err := c.Path()
+	if err != nil {
+		logging.Debugf("Ignore parsing error for %s: %v", c.DerivationPath, err)
+		return 0
+	}
 	return dp.CoinType
 }
+
+// Convention 账户的地址派生约定
+func (c *CoinAccount) Convention() PathConvention {
+	if c.PathConvention == "" {
+		return ConventionStandard
+	}
+	return c.PathConvention
+}
+
+// ScriptFormat 账户生成的地址格式。未记录格式的旧账户按当时的规则：BTC 的 purpose 84 为 bech32，其余为 legacy
+func (c *CoinAccount) ScriptFormat() string {
+	if c.AddressFormat != "" {
+		return c.AddressFormat
+	}
+	if dp, err := c.Path(); err == nil && dp.Purpose == 84|HardenedOffset {
+		return addrformat.Bech32
+	}
+	return addrformat.Legacy
+}
+
+// AddressPath 账户下地址的完整派生路径
+func (c *CoinAccount) AddressPath(changeType, addressIndex uint32) (*DerivationPath, error) {
+	path, err := c.Path()
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := c.Convention().addressComponents(changeType, addressIndex)
+	if err != nil {
+		return nil, err
+	}
+	return NewDerivationPath(append(path.HardenedPrefix(), suffix...)), nil
+}
+
+// Path 返回账户的派生路径（已存储的路径只做语法解析）
+func (c *CoinAccount) Path() (*DerivationPath, error) {
+	if c.derivationPath == nil {
+		dp, err := parseDerivationPath(c.DerivationPath)
+		if err != nil {
+			return nil, err
+		}
+		c.derivationPath = dp
+	}
+	return c.derivationPath, nil
+}
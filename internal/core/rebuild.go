@@ -0,0 +1,397 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/tyler-smith/go-bip32"
+)
+
+// 错误定义
+var (
+	ErrRebuildUnsupported = errors.New("storage cannot be rebuilt: it cannot be backed up or list address files")
+	ErrScanInterrupted    = errors.New("scan interrupted, nothing was written")
+)
+
+// 重建存储时的默认范围
+const (
+	DefaultRebuildAccounts = 20   // 每个币种、purpose 和约定检查的账户索引数
+	DefaultRebuildGap      = 20   // 扫描时连续多少个未使用的地址后停止
+	DefaultRebuildParallel = 4    // 同时扫描的币种数
+	maxRebuildScan         = 1000 // 每条链最多扫描的地址数，防止查询后端把所有地址都报告为用过时无限扫描
+)
+
+// rebuildableStorage 可以重建的存储：先备份，列出全部地址文件，删除无法解码的文件后从头写入
+type rebuildableStorage interface {
+	Backup(name string) (string, error)
+	AddressFileIDs() ([]string, error)
+	RemoveUnreadable(accountID string) error
+}
+
+// RebuildOptions 重建存储的范围
+type RebuildOptions struct {
+	Accounts uint32 // 每个币种、purpose 和约定检查的账户索引数，从 0 开始
+	Gap      uint32 // 扫描时连续多少个未使用的地址后停止
+	// Probe 查询地址是否在链上用过，为空时不扫描；返回错误时跳过该币种的其余扫描
+	Probe    func(coinSymbol, address string) (bool, error)
+	Progress func(ScanProgress) // 各币种的扫描进度，调用不会并发
+	Parallel int                // 同时扫描的币种数，为 0 时为 DefaultRebuildParallel
+	// Checkpoint 扫描检查点文件，每扫完一个候选账户保存一次；中断或出错后再次扫描时跳过已扫描的候选账户。
+	// 为空时不保存，全部币种扫描成功并写入后删除
+	Checkpoint string
+	Restart    bool            // 忽略已有的检查点，从头扫描
+	Context    context.Context // 取消时停止扫描并返回 ErrScanInterrupted，不写入；检查点保留
+}
+
+// RebuiltAccount 重建时写入的一个账户
+type RebuiltAccount struct {
+	ID         string
+	CoinSymbol string
+	Path       string
+	Convention PathConvention
+	Source     string // address file：由孤立的地址文件找回；scan：链上扫描找到；accounts.json：已有账户补充了地址
+	Addresses  int    // 重建后的地址数
+	Added      int    // 新派生的地址数
+}
+
+// RebuildReport 重建的结果
+type RebuildReport struct {
+	Backup      string           // 重建前的备份目录
+	Kept        int              // 账户列表中完好保留的账户数
+	Rebuilt     []RebuiltAccount // 找回的账户和补充了地址的已有账户
+	Unrecovered []string         // 无法找回的数据及原因
+	Mismatched  []string         // 与重新派生的结果不符而删除的地址
+	Scanned     int              // 扫描的候选账户数，未扫描时为 0
+	Resumed     int              // 按检查点跳过的已扫描候选账户数
+	ScanErrors  map[string]error // 扫描出错而跳过的币种
+}
+
+// rebuildCandidate 可能由本钱包根种子派生的一个账户
+type rebuildCandidate struct {
+	path       *DerivationPath
+	convention PathConvention
+}
+
+// rebuildEntry 重建中的一个账户和要写入的地址
+type rebuildEntry struct {
+	account    *CoinAccount
+	save       bool // 账户不在账户列表中，需要写入
+	source     string
+	have       map[[2]uint32]bool
+	addresses  []*AddressKey // 要写入的地址
+	mismatched []*AddressKey // 要删除的地址
+	stored     int           // 已存储且原样保留的地址数
+	verified   int           // addresses 中与存储相符、重新派生的地址数
+}
+
+// RebuildStorage 账户列表丢失或损坏而根钱包完好时，用根种子重建账户和地址：
+// 地址文件按文件名中的账户 ID 与候选路径的 ID 比对，找回账户并重新派生其中每个地址，与存储的不符的删除；
+// 已有账户缺少地址文件时补上 0/0；opts.Probe 不为空时按币种并行扫描候选账户的地址，补上用过的账户和地址。
+// 写入前备份钱包、账户和地址文件，备份保留供比对
+func (am *DefaultAccountManager) RebuildStorage(opts RebuildOptions) (*RebuildReport, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	storage, ok := am.storage.(rebuildableStorage)
+	if !ok {
+		return nil, ErrRebuildUnsupported
+	}
+	if opts.Accounts == 0 {
+		opts.Accounts = DefaultRebuildAccounts
+	}
+	if opts.Gap == 0 {
+		opts.Gap = DefaultRebuildGap
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = DefaultRebuildParallel
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+
+	report := &RebuildReport{ScanErrors: make(map[string]error)}
+	backup, err := storage.Backup("rebuild-" + time.Now().UTC().Format("20060102T150405Z"))
+	if err != nil {
+		return nil, fmt.Errorf("backup before rebuild failed: %w", err)
+	}
+	report.Backup = backup
+
+	// 无法解码的文件在写入前删除，从头写入；原文件留在备份中
+	var unreadable []string
+	accounts, err := am.storage.LoadAccounts()
+	if err != nil {
+		report.Unrecovered = append(report.Unrecovered, fmt.Sprintf("accounts.json: %v (the unreadable file is kept in the backup)", err))
+		unreadable = append(unreadable, "")
+		accounts = nil
+	}
+	report.Kept = len(accounts)
+	fileIDs, err := storage.AddressFileIDs()
+	if err != nil {
+		return report, err
+	}
+
+	password, err := am.walletManager.Password()
+	if err != nil {
+		return report, err
+	}
+	defer security.WipeSensitiveData(password)
+	seed, err := am.walletManager.Seed()
+	if err != nil {
+		return report, err
+	}
+	defer seed.Destroy()
+	master, err := bip32.NewMasterKey(seed.Bytes())
+	if err != nil {
+		return report, err
+	}
+	defer wipeKeys(master)
+
+	candidates := rebuildCandidates(opts.Accounts)
+	byID := make(map[string]rebuildCandidate, len(candidates))
+	for _, candidate := range candidates {
+		byID[am.IDString(candidate.convention.idInput(candidate.path.String()))] = candidate
+	}
+	ids := append([]string(nil), fileIDs...)
+	for _, account := range accounts {
+		ids = append(ids, account.ID)
+	}
+	shortIDs := ShortIDs(ids)
+	entries := make(map[string]*rebuildEntry)
+
+	// 已有账户：缺少地址文件或地址文件无法解码时补上 0/0
+	present := make(map[string]*CoinAccount, len(accounts))
+	for _, account := range accounts {
+		present[account.ID] = account
+		stored, err := am.storage.LoadAddresses(account.ID)
+		if err != nil {
+			report.Unrecovered = append(report.Unrecovered, fmt.Sprintf("%s: unreadable address file: %v (kept in the backup, addresses derived again from 0/0)", shortIDs[account.ID], err))
+			unreadable = append(unreadable, account.ID)
+			stored = nil
+		}
+		entry := &rebuildEntry{account: account, source: "accounts.json", have: make(map[[2]uint32]bool), stored: len(stored)}
+		for _, address := range stored {
+			entry.have[[2]uint32{address.ChangeType, address.AddressIndex}] = true
+		}
+		entries[account.ID] = entry
+		if len(stored) > 0 {
+			continue
+		}
+		accountKey, keyData, err := am.accountKey(account, string(password))
+		if err != nil {
+			report.Unrecovered = append(report.Unrecovered, fmt.Sprintf("%s: cannot decrypt the account key: %v", shortIDs[account.ID], err))
+			continue
+		}
+		err = entry.derive(am, accountKey, 0, 0, string(password))
+		keyData.Destroy()
+		if err != nil {
+			return report, err
+		}
+	}
+
+	// 账户列表中没有的地址文件：按 ID 找到候选路径后重新派生其中的地址
+	for _, id := range fileIDs {
+		if present[id] != nil {
+			continue
+		}
+		stored, loadErr := am.storage.LoadAddresses(id)
+		candidate, ok := byID[id]
+		if !ok {
+			report.Unrecovered = append(report.Unrecovered, describeOrphan(shortIDs[id], stored, loadErr, opts.Accounts))
+			continue
+		}
+		if loadErr != nil {
+			report.Unrecovered = append(report.Unrecovered, fmt.Sprintf("%s: unreadable address file: %v (kept in the backup, addresses derived again from 0/0)", shortIDs[id], loadErr))
+			unreadable = append(unreadable, id)
+			stored = nil
+		}
+		entry, err := am.recoverAccount(master, candidate, stored, string(password), shortIDs[id], report)
+		if err != nil {
+			return report, err
+		}
+		if entry != nil {
+			entries[id] = entry
+		}
+	}
+
+	if opts.Probe != nil {
+		if err := am.scanCandidates(master, candidates, entries, opts, string(password), report); err != nil {
+			return report, err
+		}
+	}
+
+	for _, id := range unreadable {
+		if err := storage.RemoveUnreadable(id); err != nil {
+			return report, err
+		}
+	}
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		for _, entry := range entries {
+			if entry.save {
+				if err := tx.SaveAccount(entry.account); err != nil {
+					return err
+				}
+			}
+			for _, address := range entry.mismatched {
+				if err := tx.DeleteAddress(address); err != nil {
+					return err
+				}
+			}
+			for _, address := range entry.addresses {
+				if err := tx.SaveAddress(address); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	am.ReloadOwnership()
+	if opts.Probe != nil && opts.Checkpoint != "" && len(report.ScanErrors) == 0 {
+		if err := os.Remove(opts.Checkpoint); err != nil && !os.IsNotExist(err) {
+			logging.Warnf("删除扫描检查点失败: %v", err)
+		}
+	}
+
+	for _, entry := range entries {
+		if !entry.save && len(entry.addresses) == 0 {
+			continue
+		}
+		report.Rebuilt = append(report.Rebuilt, RebuiltAccount{
+			ID:         entry.account.ID,
+			CoinSymbol: entry.account.CoinSymbol,
+			Path:       entry.account.DerivationPath,
+			Convention: entry.account.Convention(),
+			Source:     entry.source,
+			Addresses:  entry.stored + len(entry.addresses),
+			Added:      len(entry.addresses) - entry.verified,
+		})
+	}
+	sort.Slice(report.Rebuilt, func(i, j int) bool {
+		if report.Rebuilt[i].CoinSymbol != report.Rebuilt[j].CoinSymbol {
+			return report.Rebuilt[i].CoinSymbol < report.Rebuilt[j].CoinSymbol
+		}
+		return report.Rebuilt[i].Path < report.Rebuilt[j].Path
+	})
+	return report, nil
+}
+
+// rebuildCandidates 每个已注册币种在各 purpose 和地址约定下的前 accounts 个账户路径
+func rebuildCandidates(accounts uint32) []rebuildCandidate {
+	var candidates []rebuildCandidate
+	for _, info := range coin.GetAllCoins() {
+		coinType := info.Type | HardenedOffset
+		rule := PathRuleFor(coinType)
+		purposes := rule.Purposes
+		if len(purposes) == 0 {
+			purposes = []uint32{44}
+		}
+		for index := uint32(0); index < accounts; index++ {
+			for _, purpose := range purposes {
+				path := NewDerivationPath([]uint32{purpose | HardenedOffset, coinType, index | HardenedOffset})
+				candidates = append(candidates, rebuildCandidate{path: path, convention: ConventionStandard})
+			}
+			if rule.AllHardened {
+				continue
+			}
+			for _, convention := range []PathConvention{ConventionLedgerLive, ConventionMEW} {
+				candidates = append(candidates, rebuildCandidate{path: convention.AccountPath(coinType, index), convention: convention})
+			}
+		}
+	}
+	return candidates
+}
+
+// recoverAccount 由根种子重建候选账户，重新派生地址文件中的每个地址并与存储的比对，不符的删除；
+// 地址文件为空时补上 0/0。没有一个地址相符时地址文件不属于本钱包（如同路径的独立账户），返回 nil 且不修改
+func (am *DefaultAccountManager) recoverAccount(master *bip32.Key, candidate rebuildCandidate, stored []*AddressKey, password, shortID string, report *RebuildReport) (*rebuildEntry, error) {
+	accountKey, err := hardenedChild(master, candidate.path)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeKeys(accountKey)
+	account, err := am.newAccount(candidate.path, candidate.convention, accountKey, password)
+	if err != nil {
+		return nil, err
+	}
+	entry, mismatches := am.verifyStored(account, accountKey, stored, password, shortID)
+	if format := account.AddressFormat; len(stored) > 0 && entry.verified == 0 && format != "" {
+		// 旧版本不记录地址格式，BIP49 和 BIP86 账户也生成 legacy 地址
+		account.AddressFormat = ""
+		if legacy, legacyMismatches := am.verifyStored(account, accountKey, stored, password, shortID); legacy.verified > 0 {
+			entry, mismatches = legacy, legacyMismatches
+		} else {
+			account.AddressFormat = format
+		}
+	}
+	if len(stored) > 0 && entry.verified == 0 {
+		report.Unrecovered = append(report.Unrecovered, fmt.Sprintf("%s: none of the %d %s address(es) match %s derived from this wallet's seed "+
+			"(an imported standalone account must be imported again with account.import)", shortID, len(stored), stored[0].CoinSymbol, candidate.path))
+		return nil, nil
+	}
+	report.Mismatched = append(report.Mismatched, mismatches...)
+	if len(stored) == 0 {
+		if err := entry.derive(am, accountKey, 0, 0, password); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// verifyStored 按账户当前的地址格式重新派生地址文件中的每个地址，返回相符的地址和不符的说明
+func (am *DefaultAccountManager) verifyStored(account *CoinAccount, accountKey *bip32.Key, stored []*AddressKey, password, shortID string) (*rebuildEntry, []string) {
+	entry := &rebuildEntry{account: account, save: true, source: "address file", have: make(map[[2]uint32]bool)}
+	var mismatches []string
+	for _, address := range stored {
+		fresh, err := am.newAddressKey(account, accountKey, address.ChangeType, address.AddressIndex, password)
+		if err == nil && fresh.Address == address.Address {
+			entry.have[[2]uint32{address.ChangeType, address.AddressIndex}] = true
+			entry.addresses = append(entry.addresses, fresh)
+			continue
+		}
+		derived := err
+		if err == nil {
+			derived = fmt.Errorf("derived %s", fresh.Address)
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%s %d/%d: stored %s, %v",
+			shortID, address.ChangeType, address.AddressIndex, address.Address, derived))
+		entry.mismatched = append(entry.mismatched, address)
+	}
+	entry.verified = len(entry.addresses)
+	return entry, mismatches
+}
+
+// derive 派生一个尚未存储的地址，加入要写入的地址
+func (e *rebuildEntry) derive(am *DefaultAccountManager, accountKey *bip32.Key, changeType, addressIndex uint32, password string) error {
+	key := [2]uint32{changeType, addressIndex}
+	if e.have[key] {
+		return nil
+	}
+	address, err := am.newAddressKey(e.account, accountKey, changeType, addressIndex, password)
+	if err != nil {
+		return err
+	}
+	e.have[key] = true
+	e.addresses = append(e.addresses, address)
+	return nil
+}
+
+// describeOrphan 说明一个无法找回账户的地址文件
+func describeOrphan(shortID string, stored []*AddressKey, err error, accounts uint32) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s: unreadable address file of an unknown account: %v", shortID, err)
+	case len(stored) == 0:
+		return fmt.Sprintf("%s: empty address file of an unknown account", shortID)
+	}
+	return fmt.Sprintf("%s: %d %s address(es) starting with %s are not derived from this wallet's seed within the first %d accounts "+
+		"(an imported standalone account must be imported again with account.import)", shortID, len(stored), stored[0].CoinSymbol, stored[0].Address, accounts)
+}
@@ -0,0 +1,338 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/tyler-smith/go-bip32"
+)
+
+// RebuildCheckpointFileName 存储目录中重建扫描检查点的文件名
+const RebuildCheckpointFileName = "rebuild_scan.json"
+
+// ScanProgress 一个币种的扫描进度，并行扫描时各币种的进度交替报告
+type ScanProgress struct {
+	Coin     string
+	Done     int   // 已扫描的候选账户数，含按检查点跳过的
+	Total    int   // 该币种的候选账户数
+	Found    int   // 有用过地址的候选账户数
+	Finished bool  // 该币种扫描结束
+	Err      error // 查询出错而停止，此时 Finished 也为 true
+}
+
+// scanCheckpoint 扫描检查点，只在钱包和扫描范围相同时使用
+type scanCheckpoint struct {
+	Fingerprint string                     `json:"fingerprint"` // 主密钥指纹
+	Accounts    uint32                     `json:"accounts"`
+	Gap         uint32                     `json:"gap"`
+	Coins       map[string]*coinCheckpoint `json:"coins"`
+}
+
+// coinCheckpoint 一个币种的扫描进度
+type coinCheckpoint struct {
+	Next  int       `json:"next"`            // 已扫描的候选账户数，按 rebuildCandidates 的顺序
+	Found []scanHit `json:"found,omitempty"` // 已找到的用过的地址，继续时直接派生，不再查询
+	Done  bool      `json:"done,omitempty"`
+}
+
+// scanHit 候选账户的一条链上最后一个用过的地址
+type scanHit struct {
+	Path       string         `json:"path"`
+	Convention PathConvention `json:"convention"`
+	Change     uint32         `json:"change"`
+	Last       uint32         `json:"last"`
+}
+
+// coinScan 一个币种的候选账户和属于该币种的重建条目，扫描期间只由该币种的协程访问
+type coinScan struct {
+	symbol     string
+	candidates []rebuildCandidate
+	entries    map[string]*rebuildEntry
+	state      *coinCheckpoint
+	found      map[string]bool // 有用过地址的候选账户
+	scanned    int
+	err        error
+}
+
+// scanTracker 各币种共享的检查点文件和进度回调
+type scanTracker struct {
+	mu         sync.Mutex
+	checkpoint *scanCheckpoint
+	path       string
+	progress   func(ScanProgress)
+	saveFailed bool
+}
+
+// scanCandidates 按币种并行扫描候选账户，同时扫描的币种数不超过 opts.Parallel；每个币种只访问自己的条目，
+// 全部结束后合并。opts.Context 取消时各币种在当前候选账户扫完后停止，返回 ErrScanInterrupted
+func (am *DefaultAccountManager) scanCandidates(master *bip32.Key, candidates []rebuildCandidate, entries map[string]*rebuildEntry,
+	opts RebuildOptions, password string, report *RebuildReport) error {
+	tracker := &scanTracker{
+		checkpoint: loadScanCheckpoint(opts, keyFingerprint(master.PublicKey().Key)),
+		path:       opts.Checkpoint,
+		progress:   opts.Progress,
+	}
+
+	var scans []*coinScan
+	bySymbol := make(map[string]*coinScan)
+	for _, candidate := range candidates {
+		symbol := coin.CoinSymbol(candidate.path.CoinType)
+		scan := bySymbol[symbol]
+		if scan == nil {
+			state := tracker.checkpoint.Coins[symbol]
+			if state == nil {
+				state = &coinCheckpoint{}
+				tracker.checkpoint.Coins[symbol] = state
+			}
+			scan = &coinScan{symbol: symbol, entries: make(map[string]*rebuildEntry), state: state, found: make(map[string]bool)}
+			bySymbol[symbol] = scan
+			scans = append(scans, scan)
+		}
+		scan.candidates = append(scan.candidates, candidate)
+	}
+	for id, entry := range entries {
+		if scan := bySymbol[entry.account.CoinSymbol]; scan != nil {
+			scan.entries[id] = entry
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, opts.Parallel)
+	for _, scan := range scans {
+		wg.Add(1)
+		go func(scan *coinScan) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if err := am.scanCoin(master, scan, opts, password, tracker); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
+			}
+		}(scan)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	for _, scan := range scans {
+		for id, entry := range scan.entries {
+			entries[id] = entry
+		}
+		report.Scanned += scan.scanned
+		report.Resumed += scan.state.Next - scan.scanned
+		if scan.err != nil {
+			report.ScanErrors[scan.symbol] = scan.err
+		}
+	}
+	if err := opts.Context.Err(); err != nil {
+		return ErrScanInterrupted
+	}
+	return nil
+}
+
+// scanCoin 扫描一个币种的候选账户：检查点中已找到的地址直接派生，从检查点记录的位置继续查询，
+// 每扫完一个候选账户保存检查点；查询出错时记录错误并停止该币种，检查点停在出错的候选账户
+func (am *DefaultAccountManager) scanCoin(master *bip32.Key, scan *coinScan, opts RebuildOptions, password string, tracker *scanTracker) error {
+	start := scan.state.Next
+	for _, hit := range scan.state.Found {
+		if err := am.applyHit(master, scan, hit, password); err != nil {
+			return err
+		}
+	}
+	if scan.state.Done || start >= len(scan.candidates) {
+		tracker.update(scan, nil, ScanProgress{Done: len(scan.candidates), Finished: true})
+		return nil
+	}
+
+	for i := start; i < len(scan.candidates); i++ {
+		if opts.Context.Err() != nil {
+			return nil
+		}
+		candidate := scan.candidates[i]
+		id := am.IDString(candidate.convention.idInput(candidate.path.String()))
+		var hits []scanHit
+		if entry := scan.entries[id]; entry == nil || !entry.account.Standalone { // 同路径的独立账户不由本钱包根种子派生
+			var err error
+			hits, err = am.scanCandidate(master, candidate, opts)
+			if err != nil {
+				if opts.Context.Err() != nil {
+					return nil
+				}
+				scan.err = err
+				tracker.update(scan, nil, ScanProgress{Done: i, Err: err, Finished: true})
+				return nil
+			}
+		}
+		for _, hit := range hits {
+			if err := am.applyHit(master, scan, hit, password); err != nil {
+				return err
+			}
+		}
+		scan.scanned++
+		next := i + 1
+		tracker.update(scan, func(state *coinCheckpoint) {
+			state.Next = next
+			state.Found = append(state.Found, hits...)
+			state.Done = next == len(scan.candidates)
+		}, ScanProgress{Done: next, Finished: next == len(scan.candidates)})
+	}
+	return nil
+}
+
+// scanCandidate 扫描一个候选账户的收款地址（标准约定的非 ed25519 币种也扫描找零地址），
+// 返回有用过地址的链和其上最后一个用过的地址
+func (am *DefaultAccountManager) scanCandidate(master *bip32.Key, candidate rebuildCandidate, opts RebuildOptions) ([]scanHit, error) {
+	symbol := coin.CoinSymbol(candidate.path.CoinType)
+	probe := &CoinAccount{ID: am.IDString(candidate.convention.idInput(candidate.path.String())), CoinSymbol: symbol,
+		DerivationPath: candidate.path.String(), AddressFormat: scriptFormatFor(symbol, candidate.path)}
+	if candidate.convention != ConventionStandard {
+		probe.PathConvention = candidate.convention
+	}
+	chains := []uint32{0}
+	if rule := PathRuleFor(candidate.path.CoinType); candidate.convention == ConventionStandard && !rule.FlatIndex && !rule.AllHardened {
+		chains = append(chains, 1)
+	}
+
+	accountKey, err := hardenedChild(master, candidate.path)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeKeys(accountKey)
+	var hits []scanHit
+	for _, changeType := range chains {
+		last, err := am.scanChain(probe, accountKey, changeType, opts)
+		if err != nil {
+			return nil, err
+		}
+		if last >= 0 {
+			hits = append(hits, scanHit{Path: candidate.path.String(), Convention: candidate.convention, Change: changeType, Last: uint32(last)})
+		}
+	}
+	return hits, nil
+}
+
+// applyHit 补上找到的账户和链上到最后一个用过的地址为止的全部地址
+func (am *DefaultAccountManager) applyHit(master *bip32.Key, scan *coinScan, hit scanHit, password string) error {
+	path, err := ParseDerivationPath(hit.Path)
+	if err != nil {
+		return err
+	}
+	accountKey, err := hardenedChild(master, path)
+	if err != nil {
+		return err
+	}
+	defer wipeKeys(accountKey)
+	id := am.IDString(hit.Convention.idInput(path.String()))
+	entry := scan.entries[id]
+	if entry == nil {
+		account, err := am.newAccount(path, hit.Convention, accountKey, password)
+		if err != nil {
+			return err
+		}
+		entry = &rebuildEntry{account: account, save: true, source: "scan", have: make(map[[2]uint32]bool)}
+		scan.entries[id] = entry
+	}
+	scan.found[id] = true
+	for index := uint32(0); index <= hit.Last; index++ {
+		if err := entry.derive(am, accountKey, hit.Change, index, password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanChain 返回一条链上最后一个用过的地址索引，没有时返回 -1；ledger-live 约定只有 0/0
+func (am *DefaultAccountManager) scanChain(account *CoinAccount, accountKey *bip32.Key, changeType uint32, opts RebuildOptions) (int64, error) {
+	last := int64(-1)
+	for index := uint32(0); int64(index) < last+1+int64(opts.Gap); index++ {
+		if account.Convention() == ConventionLedgerLive && index > 0 {
+			break
+		}
+		if index == maxRebuildScan {
+			return last, fmt.Errorf("stopped after %d addresses on chain %d of %s that all look used", maxRebuildScan, changeType, account.DerivationPath)
+		}
+		key, err := addressKeyAt(account, accountKey, changeType, index)
+		if err != nil {
+			return last, err
+		}
+		address, _, err := am.generateAddress(account, key)
+		wipeKeys(key)
+		if err != nil {
+			return last, err
+		}
+		used, err := opts.Probe(account.CoinSymbol, address)
+		if err != nil {
+			return last, err
+		}
+		if used {
+			last = int64(index)
+		}
+	}
+	return last, nil
+}
+
+// update 修改一个币种的检查点并保存，然后报告进度；保存失败只警告一次，扫描继续
+func (t *scanTracker) update(scan *coinScan, change func(state *coinCheckpoint), progress ScanProgress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if change != nil {
+		change(scan.state)
+		if t.path != "" && !t.saveFailed {
+			if err := t.checkpoint.save(t.path); err != nil {
+				t.saveFailed = true
+				logging.Warnf("保存扫描检查点失败，中断后需要从头扫描: %v", err)
+			}
+		}
+	}
+	if t.progress != nil {
+		progress.Coin, progress.Total, progress.Found = scan.symbol, len(scan.candidates), len(scan.found)
+		t.progress(progress)
+	}
+}
+
+// loadScanCheckpoint 读取检查点；文件不存在、无法解析或属于其他钱包和扫描范围时从头开始
+func loadScanCheckpoint(opts RebuildOptions, fingerprint string) *scanCheckpoint {
+	fresh := &scanCheckpoint{Fingerprint: fingerprint, Accounts: opts.Accounts, Gap: opts.Gap, Coins: make(map[string]*coinCheckpoint)}
+	if opts.Checkpoint == "" || opts.Restart {
+		return fresh
+	}
+	data, err := os.ReadFile(opts.Checkpoint)
+	if err != nil {
+		return fresh
+	}
+	var checkpoint scanCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		logging.Warnf("扫描检查点无法解析，从头扫描: %v", err)
+		return fresh
+	}
+	if checkpoint.Fingerprint != fingerprint || checkpoint.Accounts != opts.Accounts || checkpoint.Gap != opts.Gap || checkpoint.Coins == nil {
+		return fresh
+	}
+	return &checkpoint
+}
+
+// save 先写临时文件再替换，中断时不会留下写了一半的检查点
+func (c *scanCheckpoint) save(path string) error {
+	data, err := canonjson.MarshalIndent(c, "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
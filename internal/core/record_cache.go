@@ -0,0 +1,131 @@
+package core
+
+import (
+	"container/list"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/security"
+)
+
+// recordCache 存储读取的小型缓存，加速 Web API 反复列出账户和地址。
+// 记录以会话密钥（进程内随机生成，不落盘）加密保存，内存中不常驻明文；
+// 按文件路径索引，LRU 淘汰，写入时失效，文件大小或修改时间变化（如另一个进程写入）时视为过期
+type recordCache struct {
+	mu       sync.Mutex
+	aead     cipher.AEAD
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // 最近使用的在前
+}
+
+type cachedRecord struct {
+	path    string
+	size    int64
+	modTime time.Time
+	sealed  []byte // nonce || 密文
+}
+
+// newRecordCache 创建最多保存 capacity 条记录的缓存，capacity 不大于 0 时返回 nil（不缓存）
+func newRecordCache(capacity int) (*recordCache, error) {
+	if capacity <= 0 {
+		return nil, nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	defer security.WipeSensitiveData(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &recordCache{
+		aead:     aead,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}, nil
+}
+
+// get 返回文件的缓存内容，调用方用完后应清除；没有缓存或已过期时返回 nil
+func (c *recordCache) get(path string, info os.FileInfo) []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[path]
+	if !ok {
+		metrics.Inc(metrics.StorageCache, "result", "miss")
+		return nil
+	}
+	record := element.Value.(*cachedRecord)
+	if record.size != info.Size() || !record.modTime.Equal(info.ModTime()) {
+		c.removeElement(element)
+		metrics.Inc(metrics.StorageCache, "result", "stale")
+		return nil
+	}
+	nonceSize := c.aead.NonceSize()
+	data, err := c.aead.Open(nil, record.sealed[:nonceSize], record.sealed[nonceSize:], []byte(path))
+	if err != nil {
+		c.removeElement(element)
+		return nil
+	}
+	c.order.MoveToFront(element)
+	metrics.Inc(metrics.StorageCache, "result", "hit")
+	return data
+}
+
+// put 加密保存文件内容，超出容量时淘汰最久未使用的记录
+func (c *recordCache) put(path string, info os.FileInfo, data []byte) {
+	if c == nil {
+		return
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return
+	}
+	record := &cachedRecord{
+		path:    path,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		sealed:  c.aead.Seal(nonce, nonce, data, []byte(path)),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		c.removeElement(element)
+	}
+	c.entries[path] = c.order.PushFront(record)
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// invalidate 文件被写入或删除时丢弃它的缓存
+func (c *recordCache) invalidate(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		c.removeElement(element)
+	}
+}
+
+func (c *recordCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cachedRecord).path)
+}
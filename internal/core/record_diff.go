@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// 冲突记录的类型
+const (
+	RecordWallet  = "wallet"
+	RecordAccount = "account"
+	RecordAddress = "address"
+)
+
+// FieldChange 记录中一个字段的差异，Before 或 After 为空表示该字段新增或删除
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Type added、removed 或 changed
+func (c FieldChange) Type() string {
+	switch {
+	case c.Before == "":
+		return "added"
+	case c.After == "":
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// RecordConflict 导入或恢复的记录与本机已有的记录不同
+type RecordConflict struct {
+	Kind    string // RecordWallet、RecordAccount 或 RecordAddress
+	Key     string // 账户 ID 或地址
+	Changes []FieldChange
+}
+
+// ConflictResolver 决定是否用导入的记录覆盖本机记录，返回 false 时保留本机记录
+type ConflictResolver func(*RecordConflict) (bool, error)
+
+// DiffRecords 逐字段比较同类型的两条记录（结构体指针），忽略修改时间和未导出字段；
+// 加密字段只显示是否变化，不显示密文
+func DiffRecords(current, incoming interface{}) []FieldChange {
+	before, after := reflect.ValueOf(current).Elem(), reflect.ValueOf(incoming).Elem()
+	var changes []FieldChange
+	for i := 0; i < before.NumField(); i++ {
+		field := before.Type().Field(i)
+		if !field.IsExported() || field.Name == "ModificationTime" {
+			continue
+		}
+		b, a := before.Field(i), after.Field(i)
+		if reflect.DeepEqual(b.Interface(), a.Interface()) {
+			continue
+		}
+		change := FieldChange{Field: field.Name}
+		if !b.IsZero() {
+			change.Before = displayField(field.Name, b)
+		}
+		if !a.IsZero() {
+			change.After = displayField(field.Name, a)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func displayField(name string, v reflect.Value) string {
+	if strings.HasPrefix(name, "Encrypted") {
+		return fmt.Sprintf("(encrypted, %d chars)", len(v.String()))
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// MergeAccounts 把导入的账户合并到本机账户列表：新账户追加，相同的跳过，不同的交给 resolve 决定；
+// 只在本机存在的账户保留。返回合并后的列表和被覆盖的账户数
+func MergeAccounts(current, incoming []*CoinAccount, resolve ConflictResolver) ([]*CoinAccount, int, error) {
+	merged := append([]*CoinAccount(nil), current...)
+	overwritten := 0
+	for _, account := range incoming {
+		i := slices.IndexFunc(merged, func(a *CoinAccount) bool { return a.ID == account.ID })
+		if i < 0 {
+			merged = append(merged, account)
+			continue
+		}
+		ok, err := resolveRecord(RecordAccount, account.ID, merged[i], account, resolve)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			merged[i] = account
+			overwritten++
+		}
+	}
+	return merged, overwritten, nil
+}
+
+// MergeAddresses 与 MergeAccounts 相同，同一账户、链和索引视为同一地址
+func MergeAddresses(current, incoming []*AddressKey, resolve ConflictResolver) ([]*AddressKey, int, error) {
+	merged := append([]*AddressKey(nil), current...)
+	overwritten := 0
+	for _, address := range incoming {
+		i := slices.IndexFunc(merged, func(a *AddressKey) bool {
+			return a.AccountID == address.AccountID && a.ChangeType == address.ChangeType && a.AddressIndex == address.AddressIndex
+		})
+		if i < 0 {
+			merged = append(merged, address)
+			continue
+		}
+		ok, err := resolveRecord(RecordAddress, merged[i].Address, merged[i], address, resolve)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			merged[i] = address
+			overwritten++
+		}
+	}
+	return merged, overwritten, nil
+}
+
+// resolveRecord 两条记录有差异时询问 resolve，没有差异时不需要覆盖
+func resolveRecord(kind, key string, current, incoming interface{}, resolve ConflictResolver) (bool, error) {
+	changes := DiffRecords(current, incoming)
+	if len(changes) == 0 {
+		return false, nil
+	}
+	return resolve(&RecordConflict{Kind: kind, Key: key, Changes: changes})
+}
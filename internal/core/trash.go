@@ -0,0 +1,262 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// 错误定义
+var (
+	ErrAddressNotFound = errors.New("address not found")
+	ErrTrashNotFound   = errors.New("trash entry not found")
+)
+
+// 回收站记录类型
+const (
+	TrashAccount = "account"
+	TrashAddress = "address"
+)
+
+// TrashEntry 回收站中的一条记录：删除的账户（连同它的地址）或单个地址，密钥仍然是加密的
+type TrashEntry struct {
+	ID        string
+	Kind      string
+	DeletedAt time.Time
+	Account   *CoinAccount `json:",omitempty"`
+	Addresses []*AddressKey
+}
+
+// Describe 记录的简短说明
+func (e *TrashEntry) Describe() string {
+	if e.Kind == TrashAccount && e.Account != nil {
+		return fmt.Sprintf("account %s %s (%s, %d addresses)", e.Account.ID, e.Account.CoinSymbol, e.Account.DerivationPath, len(e.Addresses))
+	}
+	if len(e.Addresses) > 0 {
+		addr := e.Addresses[0]
+		return fmt.Sprintf("address %s (%s %d/%d)", addr.Address, addr.AccountID, addr.ChangeType, addr.AddressIndex)
+	}
+	return e.Kind
+}
+
+// RemoveAccount 把账户连同它的地址移到回收站
+func (am *DefaultAccountManager) RemoveAccount(accountID string) (*TrashEntry, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	account, err := am.findAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := am.storage.LoadAddresses(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	entry := &TrashEntry{ID: newTrashID(), Kind: TrashAccount, DeletedAt: time.Now().UTC(), Account: account, Addresses: addresses}
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		if err := tx.DeleteAccount(account.ID); err != nil {
+			return err
+		}
+		return tx.SaveTrash(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	am.ReloadOwnership()
+	return entry, nil
+}
+
+// RemoveAddress 把单个地址移到回收站
+func (am *DefaultAccountManager) RemoveAddress(address *AddressKey) (*TrashEntry, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	entry := &TrashEntry{ID: newTrashID(), Kind: TrashAddress, DeletedAt: time.Now().UTC(), Addresses: []*AddressKey{address}}
+	err := am.storage.WithTransaction(func(tx StorageWriter) error {
+		if err := tx.DeleteAddress(address); err != nil {
+			return err
+		}
+		return tx.SaveTrash(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	am.ReloadOwnership()
+	return entry, nil
+}
+
+// Trash 回收站中的记录
+func (am *DefaultAccountManager) Trash() ([]*TrashEntry, error) {
+	if am.walletManager.IsLocked() {
+		return nil, ErrWalletLocked
+	}
+	return am.storage.LoadTrash()
+}
+
+// RestoreTrash 恢复记录；地址所属的账户必须存在（先恢复账户）。
+// 同一账户或地址已重新创建且内容不同时交给 resolve 决定是否覆盖，保留了本机记录时回收站中的记录不删除
+func (am *DefaultAccountManager) RestoreTrash(id string, resolve ConflictResolver) (*TrashEntry, int, error) {
+	if am.walletManager.IsLocked() {
+		return nil, 0, ErrWalletLocked
+	}
+	entry, err := am.trashEntry(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entry.Kind == TrashAddress {
+		for _, addr := range entry.Addresses {
+			if _, err := am.findAccount(addr.AccountID); err != nil {
+				return nil, 0, fmt.Errorf("account %s of address %s is not present, restore the account first: %w", addr.AccountID, addr.Address, err)
+			}
+		}
+	}
+	account, addresses, kept, err := am.trashConflicts(entry, resolve)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		if account != nil {
+			if err := tx.SaveAccount(account); err != nil {
+				return err
+			}
+		}
+		for _, addr := range addresses {
+			if err := tx.SaveAddress(addr); err != nil {
+				return err
+			}
+		}
+		if kept > 0 {
+			return nil
+		}
+		return tx.DeleteTrash(entry.ID)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	am.trackOwned(addresses...)
+	return entry, kept, nil
+}
+
+// trashConflicts 对比回收站记录和本机已有的同一账户、地址，返回需要写入的记录和保留本机记录的数量；
+// 内容相同的记录照常写入
+func (am *DefaultAccountManager) trashConflicts(entry *TrashEntry, resolve ConflictResolver) (*CoinAccount, []*AddressKey, int, error) {
+	account, kept := entry.Account, 0
+	if account != nil {
+		if existing, err := am.findAccount(account.ID); err == nil {
+			changes := DiffRecords(existing, account)
+			if len(changes) > 0 {
+				ok, err := resolve(&RecordConflict{Kind: RecordAccount, Key: account.ID, Changes: changes})
+				if err != nil {
+					return nil, nil, 0, err
+				}
+				if !ok {
+					account = nil
+					kept++
+				}
+			}
+		}
+	}
+	var addresses []*AddressKey
+	for _, addr := range entry.Addresses {
+		existing, err := am.storage.LoadAddresses(addr.AccountID)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if i := slices.IndexFunc(existing, func(a *AddressKey) bool {
+			return a.ChangeType == addr.ChangeType && a.AddressIndex == addr.AddressIndex
+		}); i >= 0 {
+			if changes := DiffRecords(existing[i], addr); len(changes) > 0 {
+				ok, err := resolve(&RecordConflict{Kind: RecordAddress, Key: existing[i].Address, Changes: changes})
+				if err != nil {
+					return nil, nil, 0, err
+				}
+				if !ok {
+					kept++
+					continue
+				}
+			}
+		}
+		addresses = append(addresses, addr)
+	}
+	return account, addresses, kept, nil
+}
+
+// PurgeTrash 永久删除回收站中的记录
+func (am *DefaultAccountManager) PurgeTrash(id string) error {
+	if am.walletManager.IsLocked() {
+		return ErrWalletLocked
+	}
+	entry, err := am.trashEntry(id)
+	if err != nil {
+		return err
+	}
+	return am.storage.WithTransaction(func(tx StorageWriter) error {
+		return tx.DeleteTrash(entry.ID)
+	})
+}
+
+// PurgeExpiredTrash 永久删除超过保留期的记录，不需要解锁钱包；retention 不大于 0 时不删除
+func (am *DefaultAccountManager) PurgeExpiredTrash(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	entries, err := am.storage.LoadTrash()
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-retention)
+	var expired []string
+	for _, entry := range entries {
+		if entry.DeletedAt.Before(cutoff) {
+			expired = append(expired, entry.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	err = am.storage.WithTransaction(func(tx StorageWriter) error {
+		for _, id := range expired {
+			if err := tx.DeleteTrash(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// trashEntry 按 ID 或唯一的 ID 前缀查找记录
+func (am *DefaultAccountManager) trashEntry(id string) (*TrashEntry, error) {
+	entries, err := am.storage.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+	var match *TrashEntry
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+		if id != "" && len(id) < len(entry.ID) && entry.ID[:len(id)] == id {
+			if match != nil {
+				return nil, fmt.Errorf("trash id %s is ambiguous", id)
+			}
+			match = entry
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTrashNotFound, id)
+	}
+	return match, nil
+}
+
+func newTrashID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
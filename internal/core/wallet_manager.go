@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/tracing"
 	"github.com/palagend/slowmade/pkg/crypto"
 	"github.com/palagend/slowmade/pkg/logging"
 	"github.com/palagend/slowmade/pkg/mnemonic"
-	"github.com/tyler-smith/go-bip39"
 )
 
 // DefaultWalletManager 默认的钱包管理器实现
@@ -24,6 +24,7 @@ type DefaultWalletManager struct {
 	mutex      sync.RWMutex
 	once       sync.Once
 	cloak      string // A cloak is not a password! Any variation entered in future loads a valid wallet, but with different addresses.
+	passwords  *security.PasswordManager
 }
 
 // NewDefaultWalletManager 创建新的钱包管理器实例
@@ -33,22 +34,36 @@ func NewDefaultWalletManager(storage StorageHandler, cloak string) *DefaultWalle
 		mnemonicService: mnemonic.NewBIP39MnemonicService(),
 		isLocked:        true,
 		cloak:           cloak,
+		passwords:       security.GetPasswordManager(),
 	}
 }
-func (wm *DefaultWalletManager) Seed() ([]byte, error) {
-	password, err := security.Password()
-	if err != nil {
-		return nil, err
-	}
-	seed, err := crypto.DecryptData(wm.rootWallet.EncryptedMnemonic, string(password))
+
+// UsePasswords 改用独立的密码管理器保存解锁密码，多用户模式下每个命名空间一个，
+// 调用方解锁后把密码设置到这里而不是全局的密码管理器
+func (wm *DefaultWalletManager) UsePasswords(passwords *security.PasswordManager) *DefaultWalletManager {
+	wm.passwords = passwords
+	return wm
+}
+
+// Password 解锁密码的副本，调用方用完必须清除
+func (wm *DefaultWalletManager) Password() ([]byte, error) {
+	return wm.passwords.GetPassword()
+}
+
+// Seed 返回解密后的种子，放在锁定内存中，调用方用完必须 Destroy
+func (wm *DefaultWalletManager) Seed() (*security.SecureBytes, error) {
+	password, err := wm.Password()
 	if err != nil {
 		return nil, err
 	}
-	return seed, nil
+	defer security.WipeSensitiveData(password)
+	return security.Decrypt(wm.rootWallet.EncryptedMnemonic, string(password))
 }
 
-// CreateNewWallet 创建新钱包（生成助记词和种子）
-func (wm *DefaultWalletManager) CreateNewWallet(password string) (*HDRootWallet, error) {
+// CreateNewWallet 创建新钱包（生成助记词和种子），extra 中的熵来源与系统随机数异或
+func (wm *DefaultWalletManager) CreateNewWallet(password string, extra ...mnemonic.EntropySource) (_ *HDRootWallet, err error) {
+	span := tracing.Begin("wallet.create")
+	defer func() { span.End(err) }()
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
@@ -59,13 +74,14 @@ func (wm *DefaultWalletManager) CreateNewWallet(password string) (*HDRootWallet,
 	}
 	logging.Debug("Generating mnemonic...")
 	// 使用助记词服务生成助记词
-	mnemonic, err := wm.mnemonicService.GenerateMnemonic(256) // 256位强度
+	mnemonic, err := wm.mnemonicService.GenerateMnemonic(256, extra...) // 256位强度
 	if err != nil {
 		return nil, fmt.Errorf("生成助记词失败: %w", err)
 	}
 	logging.Debug("Generating seed...")
 	// 从助记词生成种子
 	seed := wm.mnemonicService.GenerateSeedFromMnemonic(mnemonic, wm.cloak)
+	defer security.WipeSensitiveData(seed)
 
 	logging.Debug("Encrypting mnemonic...")
 	// 使用加密服务加密敏感数据
@@ -96,33 +112,46 @@ func (wm *DefaultWalletManager) CreateNewWallet(password string) (*HDRootWallet,
 	return wallet, nil
 }
 
-// ExportMnemonic 导出助记词
-func (wm *DefaultWalletManager) ExportMnemonic(password string) (string, error) {
-	mne, err := crypto.DecryptData(wm.rootWallet.EncryptedMnemonic, password)
+// ExportMnemonic 导出助记词，调用方用完后调用 Destroy；需要字符串时（显示、拆分）在使用处复制
+func (wm *DefaultWalletManager) ExportMnemonic(password string) (*security.SecureBytes, error) {
+	wm.once.Do(func() {
+		if wm.rootWallet == nil {
+			wm.rootWallet, _ = wm.storage.LoadRootWallet()
+		}
+	})
+	if wm.rootWallet == nil {
+		return nil, ErrWalletNotCreated
+	}
+	mne, err := security.Decrypt(wm.rootWallet.EncryptedMnemonic, password)
 	if err != nil {
-		return "", fmt.Errorf("解密失败！")
+		return nil, fmt.Errorf("解密失败: %w", ErrInvalidPassword)
 	}
-	if mne != nil {
-		return string(mne), nil
+	if mne.Size() == 0 {
+		mne.Destroy()
+		return nil, fmt.Errorf("导出助记词失败！")
 	}
-	return "", fmt.Errorf("导出助记词失败！")
+	return mne, nil
 }
 
-// RestoreWalletFromMnemonic 从助记词恢复钱包
-func (wm *DefaultWalletManager) RestoreWalletFromMnemonic(mnemonic, password string) (*HDRootWallet, error) {
+// RestoreWalletFromMnemonic 从助记词恢复钱包，保存的是规范化后的助记词
+func (wm *DefaultWalletManager) RestoreWalletFromMnemonic(phrase, password string) (_ *HDRootWallet, err error) {
+	span := tracing.Begin("wallet.restore")
+	defer func() { span.End(err) }()
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
-	// 使用助记词服务验证助记词有效性
-	if !bip39.IsMnemonicValid(mnemonic) {
-		return nil, errors.New("无效的助记词")
+	// 规范化后验证助记词有效性，粘贴带来的空白、大小写和不可见字符不影响
+	phrase, err = mnemonic.Validate(phrase)
+	if err != nil {
+		return nil, fmt.Errorf("无效的助记词: %w", err)
 	}
 
 	// 从助记词生成种子
-	seed := wm.mnemonicService.GenerateSeedFromMnemonic(mnemonic, password)
+	seed := wm.mnemonicService.GenerateSeedFromMnemonic(phrase, password)
+	defer security.WipeSensitiveData(seed)
 
 	// 使用加密服务加密敏感数据
-	encryptedMnemonic, err := crypto.EncryptData([]byte(mnemonic), password)
+	encryptedMnemonic, err := crypto.EncryptData([]byte(phrase), password)
 	if err != nil {
 		return nil, fmt.Errorf("加密助记词失败: %w", err)
 	}
@@ -149,24 +178,75 @@ func (wm *DefaultWalletManager) RestoreWalletFromMnemonic(mnemonic, password str
 }
 
 // UnlockWallet 解锁钱包
-func (wm *DefaultWalletManager) UnlockWallet(password string) error {
+func (wm *DefaultWalletManager) UnlockWallet(password string) (err error) {
+	span := tracing.Begin("wallet.unlock")
+	defer func() { span.End(err) }()
 	wm.once.Do(func() {
 		if wm.rootWallet == nil {
 			wm.rootWallet, _ = wm.storage.LoadRootWallet()
 		}
 	})
 	if wm.rootWallet == nil {
-		return errors.New("钱包不存在")
+		return ErrWalletNotCreated
 	}
-	_, err := crypto.DecryptData(wm.rootWallet.EncryptedSeed, password)
+	// 运行期间权限可能被改动，解密前按策略再检查一次
+	if checker, ok := wm.storage.(interface{ EnforcePermissions() error }); ok {
+		if err := checker.EnforcePermissions(); err != nil {
+			return err
+		}
+	}
+	seed, err := security.Decrypt(wm.rootWallet.EncryptedSeed, password)
 	if err != nil {
-		return errors.New("密码错误")
+		return ErrInvalidPassword
 	}
+	seed.Destroy()
 
 	wm.isLocked = false
 	return nil
 }
 
+// MigrateEnvelopes 把存储中旧格式的密文迁移为信封格式，需要钱包已解锁；
+// 迁移后重新读取根钱包，内存中的记录与文件一致
+func (wm *DefaultWalletManager) MigrateEnvelopes(password string, progress func(done, total int)) (_ *EnvelopeMigration, err error) {
+	span := tracing.Begin("wallet.migrate_envelopes")
+	defer func() { span.End(err) }()
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	if wm.isLocked {
+		return nil, ErrWalletLocked
+	}
+	result, err := MigrateEnvelopes(wm.storage, password, progress)
+	if err == nil && result.Migrated > 0 {
+		if wallet, loadErr := wm.storage.LoadRootWallet(); loadErr == nil && wallet != nil {
+			wm.rootWallet = wallet
+		}
+	}
+	return result, err
+}
+
+// UpgradeCiphertexts 把低于当前加密配置的密文重新加密，需要钱包已解锁；完成后重新读取根钱包
+func (wm *DefaultWalletManager) UpgradeCiphertexts(password string, progress func(done, total int)) (_ *EnvelopeMigration, err error) {
+	span := tracing.Begin("wallet.upgrade_ciphertexts")
+	defer func() { span.End(err) }()
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	if wm.isLocked {
+		return nil, ErrWalletLocked
+	}
+	result, err := UpgradeCiphertexts(wm.storage, password, progress)
+	if err == nil && result.Migrated > 0 {
+		if wallet, loadErr := wm.storage.LoadRootWallet(); loadErr == nil && wallet != nil {
+			wm.rootWallet = wallet
+		}
+	}
+	return result, err
+}
+
+// Ciphertexts 列出存储中全部密文字段的格式和参数，不需要解锁
+func (wm *DefaultWalletManager) Ciphertexts() ([]Ciphertext, error) {
+	return Ciphertexts(wm.storage)
+}
+
 // LockWallet 锁定钱包，并安全地清除内存中的敏感信息。
 func (wm *DefaultWalletManager) LockWallet() {
 	wm.mutex.Lock()
@@ -184,6 +264,18 @@ func (wm *DefaultWalletManager) LockWallet() {
 	wm.rootWallet = nil // 考虑清空根引用，促进GC回收非敏感数据
 }
 
+// Timestamps 读取存储中的根钱包，不需要解锁
+func (wm *DefaultWalletManager) Timestamps() (time.Time, time.Time, error) {
+	wallet, err := wm.storage.LoadRootWallet()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if wallet == nil {
+		return time.Time{}, time.Time{}, ErrWalletNotCreated
+	}
+	return wallet.Created(), wallet.Modified(), nil
+}
+
 // IsUnlocked 检查钱包当前是否已解锁
 func (wm *DefaultWalletManager) IsLocked() bool {
 	wm.mutex.RLock()
@@ -0,0 +1,55 @@
+// Package cosmos Cosmos SDK 链的地址、bank MsgSend 交易的构造和签名，以及 LCD（REST）接口。
+// 各条链使用相同的密钥和地址哈希，只是 bech32 前缀不同，因此同一个账户可以在多条链上使用
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/pkg/bech32"
+)
+
+// DefaultHRP 账户地址保存时使用的前缀，其他链的地址由它转换而来
+const DefaultHRP = "cosmos"
+
+// ErrInvalidAddress 不是有效的 Cosmos 地址
+var ErrInvalidAddress = errors.New("invalid cosmos address")
+
+// AddressFromPublicKey 由 33 字节压缩公钥生成地址：公钥的 HASH160 以 hrp 为前缀 bech32 编码
+func AddressFromPublicKey(hrp string, publicKey []byte) (string, error) {
+	if len(publicKey) != 33 {
+		return "", errors.New("cosmos requires a compressed secp256k1 public key (33 bytes)")
+	}
+	return bech32.Encode(hrp, btc.Hash160(publicKey))
+}
+
+// ParseAddress 解码地址，返回前缀和地址字节（账户为 20 字节，合约和模块账户为 32 字节）
+func ParseAddress(address string) (string, []byte, error) {
+	hrp, data, err := bech32.Decode(address)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s: %v", ErrInvalidAddress, address, err)
+	}
+	if len(data) != 20 && len(data) != 32 {
+		return "", nil, fmt.Errorf("%w: %s has %d bytes", ErrInvalidAddress, address, len(data))
+	}
+	return hrp, data, nil
+}
+
+// NormalizeAddress 校验地址并返回小写形式，不限制前缀
+func NormalizeAddress(address string) (string, error) {
+	if _, _, err := ParseAddress(address); err != nil {
+		return "", err
+	}
+	return strings.ToLower(address), nil
+}
+
+// ConvertAddress 把地址换成另一条链的前缀
+func ConvertAddress(address, hrp string) (string, error) {
+	_, data, err := ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, data)
+}
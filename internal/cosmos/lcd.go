@@ -0,0 +1,164 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// 错误定义
+var (
+	ErrAccountNotFound = errors.New("account not found on chain, it has never received funds")
+	ErrBroadcast       = errors.New("transaction rejected")
+)
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// LCD 一条链的 REST（LCD）接口；地址在请求前转换为该链的前缀
+type LCD struct {
+	baseURL string
+	hrp     string
+	denom   string
+}
+
+// NewLCD 创建 LCD 客户端，Balance 查询 denom 的余额
+func NewLCD(baseURL, hrp, denom string) *LCD {
+	return &LCD{baseURL: strings.TrimRight(baseURL, "/"), hrp: hrp, denom: denom}
+}
+
+func (l *LCD) Name() string     { return "lcd:" + l.baseURL }
+func (l *LCD) ThirdParty() bool { return true }
+
+// Account 账户编号和下一个序号，签名时需要
+func (l *LCD) Account(ctx context.Context, address string) (uint64, uint64, error) {
+	address, err := ConvertAddress(address, l.hrp)
+	if err != nil {
+		return 0, 0, err
+	}
+	type baseAccount struct {
+		AccountNumber string `json:"account_number"`
+		Sequence      string `json:"sequence"`
+	}
+	var result struct {
+		Account struct {
+			baseAccount
+			// 锁仓账户把基础账户嵌套在 base_vesting_account 中
+			BaseVestingAccount struct {
+				BaseAccount baseAccount `json:"base_account"`
+			} `json:"base_vesting_account"`
+		} `json:"account"`
+	}
+	status, err := l.do(ctx, http.MethodGet, "/cosmos/auth/v1beta1/accounts/"+address, nil, &result)
+	if status == http.StatusNotFound {
+		return 0, 0, fmt.Errorf("%w: %s", ErrAccountNotFound, address)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	account := result.Account.baseAccount
+	if account.AccountNumber == "" {
+		account = result.Account.BaseVestingAccount.BaseAccount
+	}
+	number, err := strconv.ParseUint(account.AccountNumber, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("lcd: invalid account number %q", account.AccountNumber)
+	}
+	sequence, err := strconv.ParseUint(orZero(account.Sequence), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("lcd: invalid sequence %q", account.Sequence)
+	}
+	return number, sequence, nil
+}
+
+// Balance 余额（最小单位）
+func (l *LCD) Balance(ctx context.Context, address string) (*big.Int, error) {
+	address, err := ConvertAddress(address, l.hrp)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Balance struct {
+			Amount string `json:"amount"`
+		} `json:"balance"`
+	}
+	path := "/cosmos/bank/v1beta1/balances/" + address + "/by_denom?denom=" + url.QueryEscape(l.denom)
+	if _, err := l.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(orZero(result.Balance.Amount), 10)
+	if !ok {
+		return nil, fmt.Errorf("lcd: invalid balance %q", result.Balance.Amount)
+	}
+	return amount, nil
+}
+
+// Broadcast 同步广播交易，节点完成 CheckTx 后返回交易哈希
+func (l *LCD) Broadcast(ctx context.Context, tx *Signed) (string, error) {
+	request := map[string]string{"tx_bytes": base64.StdEncoding.EncodeToString(tx.Bytes), "mode": "BROADCAST_MODE_SYNC"}
+	var result struct {
+		TxResponse struct {
+			TxHash string `json:"txhash"`
+			Code   int    `json:"code"`
+			RawLog string `json:"raw_log"`
+		} `json:"tx_response"`
+	}
+	if _, err := l.do(ctx, http.MethodPost, "/cosmos/tx/v1beta1/txs", request, &result); err != nil {
+		return "", err
+	}
+	if result.TxResponse.Code != 0 {
+		return "", fmt.Errorf("%w: code %d: %s", ErrBroadcast, result.TxResponse.Code, result.TxResponse.RawLog)
+	}
+	if result.TxResponse.TxHash == "" {
+		return tx.Hash, nil
+	}
+	return result.TxResponse.TxHash, nil
+}
+
+// do 发送请求并解码 JSON 响应，返回 HTTP 状态码
+func (l *LCD) do(ctx context.Context, method, path string, request, out interface{}) (int, error) {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, l.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
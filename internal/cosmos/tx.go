@@ -0,0 +1,234 @@
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignMode 签名模式，见 cosmos.tx.signing.v1beta1.SignMode
+type SignMode int
+
+const (
+	// SignModeDirect 对 protobuf 编码的 SignDoc 签名，所有 SDK 版本都支持
+	SignModeDirect SignMode = 1
+	// SignModeAminoJSON 对按键排序的旧版 amino JSON 签名，部分硬件钱包和旧链只支持这种方式
+	SignModeAminoJSON SignMode = 127
+)
+
+// protobuf Any 的类型 URL
+const (
+	msgSendType = "/cosmos.bank.v1beta1.MsgSend"
+	pubKeyType  = "/cosmos.crypto.secp256k1.PubKey"
+)
+
+// ErrInvalidAmount 金额必须为正数
+var ErrInvalidAmount = errors.New("amount must be positive")
+
+// Coin 链上金额，Amount 为最小单位
+type Coin struct {
+	Denom  string
+	Amount *big.Int
+}
+
+// Send bank MsgSend 转账及其签名参数
+type Send struct {
+	ChainID       string
+	AccountNumber uint64
+	Sequence      uint64
+	From          string
+	To            string
+	Amount        Coin
+	Fee           Coin
+	GasLimit      uint64
+	Memo          string
+	Mode          SignMode
+}
+
+// Signed 已签名的交易
+type Signed struct {
+	Bytes []byte // TxRaw 的 protobuf 编码，用于广播
+	Hash  string // 大写十六进制的交易哈希
+}
+
+// Fee 按 gas 单价和上限计算手续费，向上取整
+func Fee(gasPrice *big.Rat, gasLimit uint64) *big.Int {
+	total := new(big.Rat).Mul(gasPrice, new(big.Rat).SetInt(new(big.Int).SetUint64(gasLimit)))
+	fee, remainder := new(big.Int).QuoRem(total.Num(), total.Denom(), new(big.Int))
+	if remainder.Sign() > 0 {
+		fee.Add(fee, big.NewInt(1))
+	}
+	return fee
+}
+
+// Sign 用 secp256k1 私钥签名，签名后清除 privateKey
+func (s *Send) Sign(privateKey []byte) (*Signed, error) {
+	defer func() {
+		for i := range privateKey {
+			privateKey[i] = 0
+		}
+	}()
+	if s.Amount.Amount == nil || s.Amount.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	body := s.body()
+	authInfo := s.authInfo(crypto.CompressPubkey(&key.PublicKey))
+	signBytes, err := s.signBytes(body, authInfo)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(signBytes)
+	signature, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cosmos 使用 64 字节的 r||s（低 S 形式），不带恢复标识
+	var raw protoBuffer
+	raw.bytes(1, body)
+	raw.bytes(2, authInfo)
+	raw.bytes(3, signature[:64])
+	txHash := sha256.Sum256(raw)
+	return &Signed{Bytes: raw, Hash: strings.ToUpper(hex.EncodeToString(txHash[:]))}, nil
+}
+
+// signBytes 按签名模式返回待签名的字节
+func (s *Send) signBytes(body, authInfo []byte) ([]byte, error) {
+	if s.Mode == SignModeAminoJSON {
+		return s.aminoSignDoc()
+	}
+	var doc protoBuffer
+	doc.bytes(1, body)
+	doc.bytes(2, authInfo)
+	doc.string(3, s.ChainID)
+	doc.varint(4, s.AccountNumber)
+	return doc, nil
+}
+
+// body TxBody：单条 MsgSend 和备注
+func (s *Send) body() []byte {
+	var msg protoBuffer
+	msg.string(1, s.From)
+	msg.string(2, s.To)
+	msg.bytes(3, encodeCoin(s.Amount))
+
+	var body protoBuffer
+	body.bytes(1, encodeAny(msgSendType, msg))
+	body.string(2, s.Memo)
+	return body
+}
+
+// authInfo AuthInfo：签名者的公钥、签名模式、序号以及手续费
+func (s *Send) authInfo(publicKey []byte) []byte {
+	var pubKey protoBuffer
+	pubKey.bytes(1, publicKey)
+	var single protoBuffer
+	single.varint(1, uint64(s.mode()))
+	var modeInfo protoBuffer
+	modeInfo.bytes(1, single)
+
+	var signer protoBuffer
+	signer.bytes(1, encodeAny(pubKeyType, pubKey))
+	signer.bytes(2, modeInfo)
+	signer.varint(3, s.Sequence)
+
+	var fee protoBuffer
+	if s.Fee.Amount != nil && s.Fee.Amount.Sign() > 0 {
+		fee.bytes(1, encodeCoin(s.Fee))
+	}
+	fee.varint(2, s.GasLimit)
+
+	var info protoBuffer
+	info.bytes(1, signer)
+	info.bytes(2, fee)
+	return info
+}
+
+func (s *Send) mode() SignMode {
+	if s.Mode == 0 {
+		return SignModeDirect
+	}
+	return s.Mode
+}
+
+// aminoSignDoc 旧版 StdSignDoc：键按字母排序、数字一律为字符串，与 SDK 的 MustSortJSON 输出一致
+func (s *Send) aminoSignDoc() ([]byte, error) {
+	fee := []interface{}{}
+	if s.Fee.Amount != nil && s.Fee.Amount.Sign() > 0 {
+		fee = append(fee, aminoCoin(s.Fee))
+	}
+	doc := map[string]interface{}{
+		"account_number": strconv.FormatUint(s.AccountNumber, 10),
+		"chain_id":       s.ChainID,
+		"fee":            map[string]interface{}{"amount": fee, "gas": strconv.FormatUint(s.GasLimit, 10)},
+		"memo":           s.Memo,
+		"msgs": []interface{}{map[string]interface{}{
+			"type": "cosmos-sdk/MsgSend",
+			"value": map[string]interface{}{
+				"amount":       []interface{}{aminoCoin(s.Amount)},
+				"from_address": s.From,
+				"to_address":   s.To,
+			},
+		}},
+		"sequence": strconv.FormatUint(s.Sequence, 10),
+	}
+	return json.Marshal(doc)
+}
+
+func aminoCoin(c Coin) map[string]interface{} {
+	return map[string]interface{}{"amount": c.Amount.String(), "denom": c.Denom}
+}
+
+func encodeCoin(c Coin) []byte {
+	var coin protoBuffer
+	coin.string(1, c.Denom)
+	coin.string(2, c.Amount.String())
+	return coin
+}
+
+func encodeAny(typeURL string, value []byte) []byte {
+	var wrapped protoBuffer
+	wrapped.string(1, typeURL)
+	wrapped.bytes(2, value)
+	return wrapped
+}
+
+// protoBuffer proto3 编码，与 SDK 一致省略零值标量，节点重建 SignDoc 时才能得到相同的字节
+type protoBuffer []byte
+
+func (b *protoBuffer) key(field int, wireType uint64) {
+	*b = binary.AppendUvarint(*b, uint64(field)<<3|wireType)
+}
+
+func (b *protoBuffer) varint(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	b.key(field, 0)
+	*b = binary.AppendUvarint(*b, value)
+}
+
+func (b *protoBuffer) string(field int, value string) {
+	if value == "" {
+		return
+	}
+	b.bytes(field, []byte(value))
+}
+
+// bytes 写入字节或嵌套消息；嵌套消息即使为空也要写入
+func (b *protoBuffer) bytes(field int, value []byte) {
+	b.key(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(value)))
+	*b = append(*b, value...)
+}
@@ -0,0 +1,223 @@
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 内置的常用函数签名（4byte 选择器），无需 ABI 文件即可解码
+var builtinSignatures = []string{
+	"transfer(address to,uint256 amount)",
+	"transferFrom(address from,address to,uint256 amount)",
+	"approve(address spender,uint256 amount)",
+	"increaseAllowance(address spender,uint256 addedValue)",
+	"decreaseAllowance(address spender,uint256 subtractedValue)",
+	"safeTransferFrom(address from,address to,uint256 tokenId)",
+	"safeTransferFrom(address from,address to,uint256 tokenId,bytes data)",
+	"safeTransferFrom(address from,address to,uint256 id,uint256 amount,bytes data)",
+	"setApprovalForAll(address operator,bool approved)",
+	"permit(address owner,address spender,uint256 value,uint256 deadline,uint8 v,bytes32 r,bytes32 s)",
+	"mint(address to,uint256 amount)",
+	"burn(uint256 amount)",
+	"deposit()",
+	"withdraw(uint256 amount)",
+	"multicall(bytes[] data)",
+}
+
+// Decoder 解码合约调用数据和 EIP-712 结构化数据，生成签名前的可读预览
+type Decoder struct {
+	methods map[[4]byte]abi.Method
+	owner   OwnerFunc
+}
+
+// OwnerFunc 判断地址是否属于本钱包，属于时返回显示在地址后的说明（如账户和索引）
+type OwnerFunc func(address string) (string, bool)
+
+// SetOwner 设置后，解码结果中属于本钱包的地址会被标记
+func (d *Decoder) SetOwner(owner OwnerFunc) {
+	d.owner = owner
+}
+
+// ownerNote 地址属于本钱包时返回标记，否则为空
+func (d *Decoder) ownerNote(address string) string {
+	if d.owner == nil || address == "" {
+		return ""
+	}
+	if note, ok := d.owner(address); ok {
+		return "  [mine: " + note + "]"
+	}
+	return ""
+}
+
+// NewDecoder 创建包含内置函数签名的解码器
+func NewDecoder() *Decoder {
+	d := &Decoder{methods: make(map[[4]byte]abi.Method)}
+	for _, signature := range builtinSignatures {
+		method, err := parseSignature(signature)
+		if err != nil {
+			panic(fmt.Sprintf("invalid builtin signature %s: %v", signature, err))
+		}
+		d.addMethod(method)
+	}
+	return d
+}
+
+// LoadABIFile 加载合约 ABI 文件，其中的函数会覆盖同选择器的内置签名
+func (d *Decoder) LoadABIFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid ABI file %s: %w", path, err)
+	}
+	for _, method := range parsed.Methods {
+		d.addMethod(method)
+	}
+	return nil
+}
+
+// LoadABIDir 加载目录下所有 *.json ABI 文件，目录不存在时忽略
+func (d *Decoder) LoadABIDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := d.LoadABIFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) addMethod(method abi.Method) {
+	var selector [4]byte
+	copy(selector[:], method.ID)
+	d.methods[selector] = method
+}
+
+// Call 解码后的合约调用
+type Call struct {
+	Signature string
+	Args      []Arg
+}
+
+// Arg 解码后的参数
+type Arg struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// DecodeCalldata 根据 4byte 选择器查找函数并解码参数
+func (d *Decoder) DecodeCalldata(data []byte) (*Call, error) {
+	if len(data) < 4 {
+		return nil, errors.New("calldata is shorter than a function selector")
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	method, ok := d.methods[selector]
+	if !ok {
+		return nil, fmt.Errorf("unknown function selector 0x%x", selector)
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s arguments: %w", method.Sig, err)
+	}
+	call := &Call{Signature: method.Sig}
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		call.Args = append(call.Args, Arg{Name: name, Type: input.Type.String(), Value: formatValue(values[i])})
+	}
+	return call, nil
+}
+
+// PreviewCalldata 实现 signer.Previewer，无法解码时退回原始数据摘要
+func (d *Decoder) PreviewCalldata(data []byte) []string {
+	call, err := d.DecodeCalldata(data)
+	if err != nil {
+		return []string{
+			fmt.Sprintf("Data:     %d bytes, %v", len(data), err),
+		}
+	}
+	lines := []string{fmt.Sprintf("Call:     %s", call.Signature)}
+	for _, arg := range call.Args {
+		lines = append(lines, fmt.Sprintf("  %s (%s): %s", arg.Name, arg.Type, arg.Value))
+	}
+	return lines
+}
+
+// parseSignature 将 name(type1 arg1,type2 arg2) 形式的签名解析为 ABI 方法（不支持元组）
+func parseSignature(signature string) (abi.Method, error) {
+	name, rest, ok := strings.Cut(signature, "(")
+	if !ok || !strings.HasSuffix(rest, ")") {
+		return abi.Method{}, fmt.Errorf("malformed signature")
+	}
+	var inputs abi.Arguments
+	if params := strings.TrimSuffix(rest, ")"); params != "" {
+		for _, param := range strings.Split(params, ",") {
+			typeName, argName, _ := strings.Cut(strings.TrimSpace(param), " ")
+			typ, err := abi.NewType(typeName, "", nil)
+			if err != nil {
+				return abi.Method{}, err
+			}
+			inputs = append(inputs, abi.Argument{Name: argName, Type: typ})
+		}
+	}
+	return abi.NewMethod(name, name, abi.Function, "nonpayable", false, false, inputs, nil), nil
+}
+
+// formatValue 将解码后的参数格式化为可读文本
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		// bytesN 解码为定长字节数组
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return fmt.Sprintf("0x%x", b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = formatValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Struct:
+		fields := make([]string, rv.NumField())
+		for i := range fields {
+			fields[i] = fmt.Sprintf("%s: %s", rv.Type().Field(i).Name, formatValue(rv.Field(i).Interface()))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,85 @@
+package decoder
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TxReport 原始交易的结构化解码结果
+type TxReport struct {
+	Chain    string // ETH | BTC | SOL
+	Format   string
+	Sections []Section
+}
+
+// Section 报告中的一组字段，如输入、输出
+type Section struct {
+	Title  string
+	Fields []Field
+}
+
+// Field 单个字段
+type Field struct {
+	Label string
+	Value string
+}
+
+func (s *Section) add(label, format string, args ...interface{}) {
+	s.Fields = append(s.Fields, Field{Label: label, Value: fmt.Sprintf(format, args...)})
+}
+
+// txDecoders 按顺序尝试的交易格式，解码需消费全部字节才视为匹配
+var txDecoders = []struct {
+	chain  string
+	decode func(d *Decoder, raw []byte) (*TxReport, error)
+}{
+	{"ETH", (*Decoder).decodeETHTx},
+	{"BTC", (*Decoder).decodeBTCTx},
+	{"SOL", (*Decoder).decodeSOLTx},
+}
+
+// DecodeTx 自动识别链格式并解码原始交易
+func (d *Decoder) DecodeTx(raw []byte) (*TxReport, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("empty transaction")
+	}
+	var errs []string
+	for _, candidate := range txDecoders {
+		report, err := candidate.decode(d, raw)
+		if err == nil {
+			return report, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", candidate.chain, err))
+	}
+	return nil, fmt.Errorf("unrecognized transaction format (%s)", strings.Join(errs, "; "))
+}
+
+// ReadTxInput 读取命令参数中的交易：十六进制字符串，或包含十六进制/base64/二进制内容的文件
+func ReadTxInput(arg string) ([]byte, error) {
+	content := []byte(arg)
+	fromFile := false
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		if content, err = os.ReadFile(arg); err != nil {
+			return nil, err
+		}
+		fromFile = true
+	}
+
+	text := strings.TrimSpace(string(content))
+	hexText := strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "0X")
+	if b, err := hex.DecodeString(hexText); err == nil && len(b) > 0 {
+		return b, nil
+	}
+	// Solana 工具通常输出 base64 编码的交易
+	if b, err := base64.StdEncoding.DecodeString(text); err == nil && len(b) > 0 {
+		return b, nil
+	}
+	if fromFile && len(content) > 0 {
+		return content, nil
+	}
+	return nil, fmt.Errorf("%q is neither a hex string nor a readable file", arg)
+}
@@ -0,0 +1,224 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/bech32"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// btcReader 按比特币序列化格式顺序读取字节
+type btcReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *btcReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = errors.New("unexpected end of transaction")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *btcReader) uint32() uint32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *btcReader) uint64() uint64 {
+	b := r.read(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// varInt 读取 CompactSize 编码的整数
+func (r *btcReader) varInt() uint64 {
+	b := r.read(1)
+	if b == nil {
+		return 0
+	}
+	switch b[0] {
+	case 0xfd:
+		v := r.read(2)
+		if v == nil {
+			return 0
+		}
+		return uint64(binary.LittleEndian.Uint16(v))
+	case 0xfe:
+		return uint64(r.uint32())
+	case 0xff:
+		return r.uint64()
+	default:
+		return uint64(b[0])
+	}
+}
+
+// count 读取元素个数并做合理性检查，防止恶意长度导致大量分配
+func (r *btcReader) count(minSize int) int {
+	n := r.varInt()
+	if r.err == nil && n > uint64(len(r.data)-r.pos)/uint64(minSize) {
+		r.err = fmt.Errorf("count %d exceeds transaction size", n)
+	}
+	return int(n)
+}
+
+func (d *Decoder) decodeBTCTx(raw []byte) (*TxReport, error) {
+	r := &btcReader{data: raw}
+	version := r.uint32()
+	if r.err == nil && (version < 1 || version > 3) {
+		return nil, fmt.Errorf("unexpected version %d", version)
+	}
+
+	segwit := false
+	if r.pos+2 <= len(raw) && raw[r.pos] == 0x00 && raw[r.pos+1] == 0x01 {
+		segwit = true
+		r.read(2)
+	}
+
+	// 记录非见证部分的位置，用于计算 txid
+	bodyStart := r.pos
+	inputCount := r.count(41)
+	if r.err == nil && inputCount == 0 {
+		return nil, errors.New("transaction has no inputs")
+	}
+	inputs := Section{Title: fmt.Sprintf("Inputs (%d)", inputCount)}
+	for i := 0; i < inputCount && r.err == nil; i++ {
+		prevHash := reverse(r.read(32))
+		vout := r.uint32()
+		scriptSig := r.read(r.count(1))
+		sequence := r.uint32()
+		label := fmt.Sprintf("#%d", i)
+		inputs.add(label, "%x:%d", prevHash, vout)
+		if len(scriptSig) > 0 {
+			inputs.add(label+" scriptSig", "%d bytes", len(scriptSig))
+		}
+		if sequence < 0xfffffffe {
+			inputs.add(label+" sequence", "0x%08x (RBF enabled)", sequence)
+		}
+	}
+
+	outputCount := r.count(9)
+	outputs := Section{Title: fmt.Sprintf("Outputs (%d)", outputCount)}
+	total := uint64(0)
+	for i := 0; i < outputCount && r.err == nil; i++ {
+		value := r.uint64()
+		script := r.read(r.count(1))
+		total += value
+		outputs.add(fmt.Sprintf("#%d", i), "%s BTC -> %s%s", coin.FormatUnits(new(big.Int).SetUint64(value), 8), describeScript(script), d.ownerNote(ScriptAddress(script)))
+	}
+	bodyEnd := r.pos
+
+	if segwit {
+		for i := 0; i < inputCount && r.err == nil; i++ {
+			items := r.count(1)
+			for j := 0; j < items && r.err == nil; j++ {
+				r.read(r.count(1))
+			}
+		}
+	}
+	lockTime := r.uint32()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.pos != len(raw) {
+		return nil, fmt.Errorf("%d trailing bytes", len(raw)-r.pos)
+	}
+
+	// txid 为不含见证数据的序列化的双 SHA256（字节逆序显示）
+	stripped := append([]byte{}, raw[:4]...)
+	stripped = append(stripped, raw[bodyStart:bodyEnd]...)
+	stripped = append(stripped, raw[len(raw)-4:]...)
+
+	overview := Section{Title: "Transaction"}
+	overview.add("Txid", "%x", reverse(btc.DoubleSHA256(stripped)))
+	if segwit {
+		overview.add("Wtxid", "%x", reverse(btc.DoubleSHA256(raw)))
+	}
+	overview.add("Version", "%d", version)
+	overview.add("SegWit", "%t", segwit)
+	// 虚拟大小：见证数据按 1/4 计入
+	weight := len(stripped)*4 + (len(raw) - len(stripped))
+	overview.add("Size", "%d bytes, %d vbytes", len(raw), (weight+3)/4)
+	if lockTime != 0 {
+		overview.add("Lock time", "%d", lockTime)
+	}
+	overview.add("Total output", "%s BTC", coin.FormatUnits(new(big.Int).SetUint64(total), 8))
+	overview.add("Fee", "unknown (input amounts are not part of the raw transaction)")
+
+	return &TxReport{
+		Chain:    "BTC",
+		Format:   map[bool]string{true: "SegWit", false: "Legacy"}[segwit],
+		Sections: []Section{overview, inputs, outputs},
+	}, nil
+}
+
+// describeScript 识别标准输出脚本并还原主网地址
+func describeScript(script []byte) string {
+	if address, kind := scriptAddressKind(script); address != "" {
+		return address + " (" + kind + ")"
+	}
+	if len(script) > 0 && script[0] == 0x6a {
+		return "OP_RETURN " + hex.EncodeToString(script[1:])
+	}
+	return "script " + hex.EncodeToString(script)
+}
+
+// ScriptAddress 标准输出脚本对应的主网地址，非标准脚本返回空
+func ScriptAddress(script []byte) string {
+	address, _ := scriptAddressKind(script)
+	return address
+}
+
+func scriptAddressKind(script []byte) (address, kind string) {
+	switch {
+	case len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac:
+		return base58.CheckEncode(append([]byte{0x00}, script[3:23]...)), "P2PKH"
+	case len(script) == 23 && script[0] == 0xa9 && script[1] == 0x14 && script[22] == 0x87:
+		return base58.CheckEncode(append([]byte{0x05}, script[2:22]...)), "P2SH"
+	case len(script) >= 4 && len(script) <= 42 && (script[0] == 0x00 || (script[0] >= 0x51 && script[0] <= 0x60)) && int(script[1]) == len(script)-2:
+		version := script[0]
+		if version != 0 {
+			version -= 0x50
+		}
+		address, err := bech32.EncodeSegwitAddress("bc", version, script[2:])
+		if err != nil {
+			return "", ""
+		}
+		kind := map[int]string{20: "P2WPKH", 32: "P2WSH"}[len(script)-2]
+		if version == 1 && len(script) == 34 {
+			kind = "P2TR"
+		}
+		if kind == "" {
+			kind = fmt.Sprintf("witness v%d", version)
+		}
+		return address, kind
+	}
+	return "", ""
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[len(b)-1-i]
+	}
+	return out
+}
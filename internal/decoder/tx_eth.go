@@ -0,0 +1,217 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// ethTxLayout 各交易类型的字段布局，sigStart 之后为 v/yParity, r, s
+var ethTxLayouts = map[byte]struct {
+	name     string
+	fields   []string
+	sigStart int
+}{
+	0x00: {"Legacy", []string{"nonce", "gasPrice", "gas", "to", "value", "data"}, 6},
+	0x01: {"EIP-2930 (type 1)", []string{"chainId", "nonce", "gasPrice", "gas", "to", "value", "data", "accessList"}, 8},
+	0x02: {"EIP-1559 (type 2)", []string{"chainId", "nonce", "maxPriorityFeePerGas", "maxFeePerGas", "gas", "to", "value", "data", "accessList"}, 9},
+	0x03: {"EIP-4844 (type 3)", []string{"chainId", "nonce", "maxPriorityFeePerGas", "maxFeePerGas", "gas", "to", "value", "data", "accessList", "maxFeePerBlobGas", "blobVersionedHashes"}, 11},
+}
+
+func (d *Decoder) decodeETHTx(raw []byte) (*TxReport, error) {
+	txType := byte(0x00)
+	payload := raw
+	if raw[0] < 0x7f {
+		txType, payload = raw[0], raw[1:]
+	}
+	layout, ok := ethTxLayouts[txType]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type %d", txType)
+	}
+	if len(payload) == 0 || payload[0] < 0xc0 {
+		return nil, errors.New("not an RLP list")
+	}
+
+	var items []interface{}
+	if err := rlp.DecodeBytes(payload, &items); err != nil {
+		return nil, err
+	}
+	signed := len(items) == layout.sigStart+3
+	if !signed && len(items) != layout.sigStart {
+		return nil, fmt.Errorf("expected %d or %d fields for %s, got %d", layout.sigStart, layout.sigStart+3, layout.name, len(items))
+	}
+	item := func(name string) interface{} {
+		for i, n := range layout.fields {
+			if n == name {
+				return items[i]
+			}
+		}
+		return nil
+	}
+	field := func(name string) []byte {
+		b, _ := item(name).([]byte)
+		return b
+	}
+	number := func(name string) *big.Int {
+		return new(big.Int).SetBytes(field(name))
+	}
+
+	report := &TxReport{Chain: "ETH", Format: layout.name}
+	overview := Section{Title: "Transaction"}
+
+	// legacy 交易的 chainId 编码在 v 中（EIP-155）
+	chainID := number("chainId")
+	var v *big.Int
+	if signed {
+		vBytes, _ := items[layout.sigStart].([]byte)
+		r, _ := items[layout.sigStart+1].([]byte)
+		s, _ := items[layout.sigStart+2].([]byte)
+		v = new(big.Int).SetBytes(vBytes)
+		// 未签名的 EIP-155 legacy 交易以 [chainId, 0, 0] 结尾
+		if txType == 0x00 && len(r) == 0 && len(s) == 0 {
+			signed = false
+			chainID = v
+		} else if txType == 0x00 {
+			if v.Cmp(big.NewInt(35)) >= 0 {
+				chainID = new(big.Int).Sub(v, big.NewInt(35))
+				chainID.Rsh(chainID, 1)
+			} else {
+				chainID = nil
+			}
+		}
+	}
+	if chainID != nil && chainID.Sign() > 0 {
+		overview.add("Chain ID", "%s", chainID)
+	} else {
+		overview.add("Chain ID", "none (pre-EIP-155, replayable on any chain)")
+	}
+
+	to := field("to")
+	switch len(to) {
+	case 0:
+		overview.add("To", "contract creation")
+	case common.AddressLength:
+		overview.add("To", "%s%s", common.BytesToAddress(to).Hex(), d.ownerNote(common.BytesToAddress(to).Hex()))
+	default:
+		return nil, fmt.Errorf("invalid recipient length %d", len(to))
+	}
+	overview.add("Value", "%s ETH", coin.FormatEther(number("value")))
+	overview.add("Nonce", "%s", number("nonce"))
+	overview.add("Gas limit", "%s", number("gas"))
+
+	gas := number("gas")
+	fees := Section{Title: "Fees"}
+	if txType <= 0x01 {
+		gasPrice := number("gasPrice")
+		fees.add("Gas price", "%s gwei", formatGwei(gasPrice))
+		fees.add("Max fee", "%s ETH", coin.FormatEther(new(big.Int).Mul(gas, gasPrice)))
+	} else {
+		maxFee := number("maxFeePerGas")
+		fees.add("Max fee per gas", "%s gwei", formatGwei(maxFee))
+		fees.add("Priority fee", "%s gwei", formatGwei(number("maxPriorityFeePerGas")))
+		fees.add("Max fee", "%s ETH", coin.FormatEther(new(big.Int).Mul(gas, maxFee)))
+	}
+	if txType == 0x03 {
+		hashes, _ := item("blobVersionedHashes").([]interface{})
+		fees.add("Max fee per blob gas", "%s gwei", formatGwei(number("maxFeePerBlobGas")))
+		fees.add("Blobs", "%d", len(hashes))
+	}
+
+	report.Sections = append(report.Sections, overview, fees)
+
+	if data := field("data"); len(data) > 0 {
+		call := Section{Title: "Call data"}
+		for _, line := range d.PreviewCalldata(data) {
+			call.Fields = append(call.Fields, Field{Value: line})
+		}
+		report.Sections = append(report.Sections, call)
+	}
+
+	if txType != 0x00 {
+		if list, _ := item("accessList").([]interface{}); len(list) > 0 {
+			access := Section{Title: "Access list"}
+			for _, entry := range list {
+				tuple, _ := entry.([]interface{})
+				if len(tuple) != 2 {
+					continue
+				}
+				address, _ := tuple[0].([]byte)
+				keys, _ := tuple[1].([]interface{})
+				access.add(common.BytesToAddress(address).Hex(), "%d storage keys", len(keys))
+			}
+			report.Sections = append(report.Sections, access)
+		}
+	}
+
+	signature := Section{Title: "Signature"}
+	if !signed {
+		signature.add("Status", "unsigned")
+	} else {
+		sender, err := recoverETHSender(txType, items, layout.sigStart, v, chainID)
+		if err != nil {
+			signature.add("Status", "invalid signature: %v", err)
+		} else {
+			signature.add("Status", "signed")
+			signature.add("From", "%s%s", sender.Hex(), d.ownerNote(sender.Hex()))
+		}
+		hash := crypto.Keccak256(raw)
+		signature.add("Tx hash", "0x%x", hash)
+	}
+	report.Sections = append(report.Sections, signature)
+	return report, nil
+}
+
+// recoverETHSender 重建签名摘要并恢复发送方地址
+func recoverETHSender(txType byte, items []interface{}, sigStart int, v, chainID *big.Int) (common.Address, error) {
+	r, _ := items[sigStart+1].([]byte)
+	s, _ := items[sigStart+2].([]byte)
+	if len(r) > 32 || len(s) > 32 {
+		return common.Address{}, errors.New("r or s too long")
+	}
+
+	unsigned := items[:sigStart]
+	var recoveryID byte
+	if txType == 0x00 {
+		switch {
+		case chainID != nil:
+			unsigned = append(append([]interface{}{}, unsigned...), chainID, uint(0), uint(0))
+			recoveryID = byte(new(big.Int).Sub(v, new(big.Int).Add(new(big.Int).Lsh(chainID, 1), big.NewInt(35))).Uint64())
+		case v.Uint64() == 27 || v.Uint64() == 28:
+			recoveryID = byte(v.Uint64() - 27)
+		default:
+			return common.Address{}, fmt.Errorf("invalid v %s", v)
+		}
+	} else {
+		if v.Uint64() > 1 {
+			return common.Address{}, fmt.Errorf("invalid yParity %s", v)
+		}
+		recoveryID = byte(v.Uint64())
+	}
+
+	payload, err := rlp.EncodeToBytes(unsigned)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if txType != 0x00 {
+		payload = append([]byte{txType}, payload...)
+	}
+
+	sig := make([]byte, 65)
+	copy(sig[32-len(r):32], r)
+	copy(sig[64-len(s):64], s)
+	sig[64] = recoveryID
+	pub, err := crypto.SigToPub(crypto.Keccak256(payload), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func formatGwei(wei *big.Int) string {
+	return coin.FormatUnits(wei, 9)
+}
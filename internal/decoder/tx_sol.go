@@ -0,0 +1,278 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// Solana 常用程序地址
+var solPrograms = map[string]string{
+	"11111111111111111111111111111111":             "System Program",
+	"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA":  "Token Program",
+	"TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb":  "Token-2022 Program",
+	"ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL": "Associated Token Program",
+	"ComputeBudget111111111111111111111111111111":  "Compute Budget Program",
+	"MemoSq4gqABAXKb96qWYbMG4GGm5HvQ5wV3bcvR4Z6W":  "Memo Program",
+}
+
+const (
+	solSystemProgram        = "11111111111111111111111111111111"
+	solComputeBudgetProgram = "ComputeBudget111111111111111111111111111111"
+)
+
+// solReader 按 Solana 消息格式顺序读取字节，长度前缀为 compact-u16
+type solReader struct {
+	btcReader
+}
+
+func (r *solReader) u8() byte {
+	b := r.read(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// compactU16 读取 Solana 的 compact-u16 编码
+func (r *solReader) compactU16() int {
+	value := 0
+	for i := 0; i < 3; i++ {
+		b := r.read(1)
+		if b == nil {
+			return 0
+		}
+		value |= int(b[0]&0x7f) << (7 * i)
+		if b[0]&0x80 == 0 {
+			return value
+		}
+	}
+	r.err = errors.New("invalid compact-u16 length")
+	return 0
+}
+
+// count 读取元素个数并检查剩余长度
+func (r *solReader) count(minSize int) int {
+	n := r.compactU16()
+	if r.err == nil && n > (len(r.data)-r.pos)/minSize {
+		r.err = fmt.Errorf("count %d exceeds transaction size", n)
+	}
+	return n
+}
+
+func (d *Decoder) decodeSOLTx(raw []byte) (*TxReport, error) {
+	// 先按完整交易（签名 + 消息）解析，失败时按单独的消息解析
+	report, err := d.decodeSOLMessage(raw, true)
+	if err != nil {
+		if bare, bareErr := d.decodeSOLMessage(raw, false); bareErr == nil {
+			return bare, nil
+		}
+	}
+	return report, err
+}
+
+func (d *Decoder) decodeSOLMessage(raw []byte, withSignatures bool) (*TxReport, error) {
+	r := &solReader{btcReader{data: raw}}
+
+	var signatures [][]byte
+	if withSignatures {
+		n := r.count(64)
+		if r.err == nil && n == 0 {
+			return nil, errors.New("transaction has no signatures")
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			signatures = append(signatures, r.read(64))
+		}
+	}
+
+	format := "Legacy message"
+	versioned := false
+	if r.pos < len(raw) && raw[r.pos]&0x80 != 0 {
+		version := r.u8() & 0x7f
+		if version != 0 {
+			return nil, fmt.Errorf("unsupported message version %d", version)
+		}
+		versioned = true
+		format = "Versioned message (v0)"
+	}
+
+	required := int(r.u8())
+	readonlySigned := int(r.u8())
+	readonlyUnsigned := int(r.u8())
+	keyCount := r.count(32)
+	keys := make([]string, 0, keyCount)
+	for i := 0; i < keyCount && r.err == nil; i++ {
+		keys = append(keys, base58.Encode(r.read(32)))
+	}
+	blockhash := r.read(32)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if required == 0 || required > keyCount || readonlySigned >= required || readonlyUnsigned > keyCount-required {
+		return nil, errors.New("invalid message header")
+	}
+	if withSignatures && len(signatures) != required {
+		return nil, fmt.Errorf("expected %d signatures, got %d", required, len(signatures))
+	}
+
+	type instruction struct {
+		program  int
+		accounts []byte
+		data     []byte
+	}
+	var instructions []instruction
+	ixCount := r.count(2)
+	for i := 0; i < ixCount && r.err == nil; i++ {
+		program := int(r.u8())
+		accounts := r.read(r.count(1))
+		data := r.read(r.count(1))
+		instructions = append(instructions, instruction{program, accounts, data})
+	}
+
+	lookups := Section{Title: "Address lookup tables"}
+	loaded := 0
+	if versioned {
+		tables := r.count(34)
+		for i := 0; i < tables && r.err == nil; i++ {
+			table := base58.Encode(r.read(32))
+			writable := r.read(r.count(1))
+			readonly := r.read(r.count(1))
+			loaded += len(writable) + len(readonly)
+			lookups.add(table, "%d writable, %d readonly", len(writable), len(readonly))
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.pos != len(raw) {
+		return nil, fmt.Errorf("%d trailing bytes", len(raw)-r.pos)
+	}
+
+	// 查找表加载的账户只有索引，无法离线还原地址
+	account := func(index int) string {
+		if index < len(keys) {
+			return keys[index]
+		}
+		if index < len(keys)+loaded {
+			return fmt.Sprintf("lookup #%d", index-len(keys))
+		}
+		return fmt.Sprintf("invalid #%d", index)
+	}
+
+	overview := Section{Title: "Transaction"}
+	if len(signatures) > 0 {
+		overview.add("Signature", "%s", base58.Encode(signatures[0]))
+	}
+	overview.add("Fee payer", "%s%s", keys[0], d.ownerNote(keys[0]))
+	overview.add("Signers", "%d", required)
+	overview.add("Recent blockhash", "%s", base58.Encode(blockhash))
+	if withSignatures {
+		unsigned := 0
+		for _, sig := range signatures {
+			if isZero(sig) {
+				unsigned++
+			}
+		}
+		if unsigned > 0 {
+			overview.add("Status", "%d of %d signatures missing", unsigned, len(signatures))
+		} else {
+			overview.add("Status", "signed")
+		}
+	} else {
+		overview.add("Status", "unsigned message")
+	}
+
+	accounts := Section{Title: fmt.Sprintf("Accounts (%d)", keyCount)}
+	for i, key := range keys {
+		var flags []string
+		if i < required {
+			flags = append(flags, "signer")
+		}
+		writable := i < required-readonlySigned || (i >= required && i < keyCount-readonlyUnsigned)
+		if writable {
+			flags = append(flags, "writable")
+		}
+		label := fmt.Sprintf("#%d", i)
+		if len(flags) > 0 {
+			accounts.add(label, "%s (%s)%s", key, strings.Join(flags, ", "), d.ownerNote(key))
+		} else {
+			accounts.add(label, "%s%s", key, d.ownerNote(key))
+		}
+	}
+
+	instrs := Section{Title: fmt.Sprintf("Instructions (%d)", len(instructions))}
+	// 手续费估算：Compute Budget 指令设置的计算单元上限和单价，转出金额为 System Program 转账之和
+	var (
+		unitLimit uint32
+		unitPrice uint64
+		budget    int
+	)
+	transferred := new(big.Int)
+	for i, ix := range instructions {
+		program := account(ix.program)
+		name := program
+		if known, ok := solPrograms[program]; ok {
+			name = known
+		}
+		label := fmt.Sprintf("#%d", i)
+		// System Program 转账：指令 2 + u64 lamports
+		if program == solSystemProgram && len(ix.data) == 12 && binary.LittleEndian.Uint32(ix.data) == 2 && len(ix.accounts) == 2 {
+			lamports := new(big.Int).SetUint64(binary.LittleEndian.Uint64(ix.data[4:]))
+			transferred.Add(transferred, lamports)
+			instrs.add(label, "Transfer %s SOL", coin.FormatUnits(lamports, 9))
+			instrs.add("  from", "%s", account(int(ix.accounts[0])))
+			instrs.add("  to", "%s", account(int(ix.accounts[1])))
+			continue
+		}
+		if program == solComputeBudgetProgram {
+			budget++
+			switch {
+			case len(ix.data) == 5 && ix.data[0] == 2:
+				unitLimit = binary.LittleEndian.Uint32(ix.data[1:])
+				instrs.add(label, "Set compute unit limit %d", unitLimit)
+				continue
+			case len(ix.data) == 9 && ix.data[0] == 3:
+				unitPrice = binary.LittleEndian.Uint64(ix.data[1:])
+				instrs.add(label, "Set compute unit price %d micro-lamports", unitPrice)
+				continue
+			}
+		}
+		instrs.add(label, "%s, %d accounts, %d bytes data", name, len(ix.accounts), len(ix.data))
+	}
+
+	size := len(raw)
+	if !withSignatures {
+		// 签名数量的 compact-u16 前缀加上每个签名 64 字节
+		size += 1 + 64*required
+	}
+	estimate := signer.EstimateSOL(required, len(instructions)-budget, unitLimit, unitPrice, size, transferred)
+	fees := Section{Title: "Fee estimate"}
+	for _, line := range estimate.Lines() {
+		label, value, _ := strings.Cut(line, ":")
+		fees.add(label, "%s", strings.TrimSpace(value))
+	}
+	if warning := estimate.Warning(); warning != "" {
+		fees.add("Warning", "%s", warning)
+	}
+
+	sections := []Section{overview, accounts, instrs, fees}
+	if len(lookups.Fields) > 0 {
+		sections = append(sections, lookups)
+	}
+	return &TxReport{Chain: "SOL", Format: format, Sections: sections}, nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/signer"
+)
+
+// 授权类结构化数据，签名后第三方可直接转走代币
+var approvalTypes = map[string]bool{
+	"Permit":                  true,
+	"PermitSingle":            true,
+	"PermitBatch":             true,
+	"PermitTransferFrom":      true,
+	"PermitBatchTransferFrom": true,
+}
+
+// 域字段的显示顺序
+var domainOrder = []string{"name", "version", "chainId", "verifyingContract", "salt"}
+
+// PreviewTypedData 实现 signer.Previewer，逐字段展示域和消息内容
+func (d *Decoder) PreviewTypedData(td *signer.TypedData) []string {
+	lines := []string{"Domain:"}
+	for _, name := range domainOrder {
+		if value, ok := td.Domain[name]; ok {
+			lines = append(lines, fmt.Sprintf("  %s: %v", name, value))
+		}
+	}
+
+	if td.PrimaryType != "EIP712Domain" {
+		lines = append(lines, fmt.Sprintf("Message (%s):", td.PrimaryType))
+		lines = append(lines, d.structLines(td, td.PrimaryType, td.Message, "  ")...)
+	}
+	if approvalTypes[td.PrimaryType] {
+		lines = append(lines, "Warning: this signature grants token spending approval without an on-chain transaction")
+	}
+	return lines
+}
+
+func (d *Decoder) structLines(td *signer.TypedData, typeName string, data map[string]interface{}, indent string) []string {
+	var lines []string
+	for _, field := range td.Types[typeName] {
+		lines = append(lines, d.valueLines(td, field.Name, field.Type, data[field.Name], indent)...)
+	}
+	return lines
+}
+
+func (d *Decoder) valueLines(td *signer.TypedData, name, typeName string, value interface{}, indent string) []string {
+	if strings.HasSuffix(typeName, "]") {
+		items, _ := value.([]interface{})
+		elemType := typeName[:strings.LastIndex(typeName, "[")]
+		lines := []string{fmt.Sprintf("%s%s (%s, %d items):", indent, name, typeName, len(items))}
+		for i, item := range items {
+			lines = append(lines, d.valueLines(td, fmt.Sprintf("[%d]", i), elemType, item, indent+"  ")...)
+		}
+		return lines
+	}
+
+	if _, ok := td.Types[typeName]; ok {
+		fields, _ := value.(map[string]interface{})
+		lines := []string{fmt.Sprintf("%s%s (%s):", indent, name, typeName)}
+		return append(lines, d.structLines(td, typeName, fields, indent+"  ")...)
+	}
+
+	return []string{fmt.Sprintf("%s%s: %s", indent, name, formatTypedValue(typeName, value))}
+}
+
+// formatTypedValue 整数统一以十进制显示，超大授权额度单独标注
+func formatTypedValue(typeName string, value interface{}) string {
+	if !strings.HasPrefix(typeName, "uint") && !strings.HasPrefix(typeName, "int") {
+		return fmt.Sprint(value)
+	}
+	n, ok := new(big.Int).SetString(fmt.Sprint(value), 0)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	// 2^255 以上通常是无限授权（type(uint256).max 等）
+	if n.BitLen() > 255 || (typeName == "uint160" && n.BitLen() == 160) {
+		return n.String() + " (unlimited)"
+	}
+	return n.String()
+}
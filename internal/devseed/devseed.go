@@ -0,0 +1,206 @@
+// Package devseed 从任意字符串确定性地生成开发用钱包，供集成测试和教程使用固定的地址。
+// 这些钱包没有任何安全性：知道字符串就能算出助记词，只能存放在带标记文件的开发目录中
+package devseed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// MarkerFileName 开发目录中的标记文件，存在时所有输出都标注 INSECURE
+	MarkerFileName = "DEV-INSECURE"
+	// PasswordFileName 开发钱包的密码文件，可以直接传给 --password-file
+	PasswordFileName = "dev-password"
+	// Password 所有开发钱包共用的固定密码
+	Password = "insecure-dev-wallet"
+	// Watermark 开发钱包的警告文字
+	Watermark = "INSECURE DEVELOPMENT WALLET - derived from a public string, never send real funds to it"
+)
+
+// 错误定义
+var (
+	ErrNotDevDir     = errors.New("directory already holds a wallet that is not a development wallet")
+	ErrConfiguredDir = errors.New("refusing to put a development wallet in the configured data directory")
+	ErrOtherSeed     = errors.New("directory holds a development wallet for a different string")
+	ErrEmptyInput    = errors.New("the seed string is empty")
+)
+
+// Result 生成的开发钱包
+type Result struct {
+	Dir               string
+	Mnemonic          string
+	MasterFingerprint string
+	Reused            bool
+	Accounts          []*Account
+}
+
+// Account 一个币种的账户和前几个收款地址
+type Account struct {
+	Coin      string
+	Path      string
+	Addresses []string
+}
+
+// Mnemonic 字符串对应的 24 个助记词：熵为带域分隔的 SHA-256
+func Mnemonic(input string) (string, error) {
+	if input == "" {
+		return "", ErrEmptyInput
+	}
+	entropy := sha256.Sum256([]byte("slowmade-dev-seed:" + input))
+	return bip39.NewMnemonic(entropy[:])
+}
+
+// ID 字符串的短标识，用作默认目录名和标记文件内容
+func ID(input string) string {
+	sum := sha256.Sum256([]byte("slowmade-dev-id:" + input))
+	return hex.EncodeToString(sum[:4])
+}
+
+// DefaultDir 默认的开发目录：系统临时目录下的 slowmade-dev/<标识>，同一台机器上多次生成位置不变
+func DefaultDir(input string) string {
+	return filepath.Join(os.TempDir(), "slowmade-dev", ID(input))
+}
+
+// IsDevDir 目录是否是开发目录
+func IsDevDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, MarkerFileName))
+	return err == nil
+}
+
+// Create 在 dir 中生成字符串对应的钱包，为每个币种创建账户 0 并派生 count 个收款地址。
+// 目录已经是同一字符串的开发目录时复用其中的钱包；拒绝配置的数据目录和已有普通钱包的目录
+func Create(dir, input string, coins []string, count int) (*Result, error) {
+	mnemonic, err := Mnemonic(input)
+	if err != nil {
+		return nil, err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDir(dir, input); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	// 先写标记，即使之后失败目录也不会被当成普通钱包
+	if err := os.WriteFile(filepath.Join(dir, MarkerFileName), []byte(marker(input)), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, PasswordFileName), []byte(Password+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	storageConfig.BaseDir, storageConfig.ReadOnly = dir, false
+	storage, err := core.NewFileStorage(storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer storage.Close()
+	// 独立的密码管理器，不影响其他钱包
+	passwords := security.NewPasswordManager()
+	defer passwords.Clear()
+	walletMgr := core.NewDefaultWalletManager(storage, "").UsePasswords(passwords)
+	accountMgr := core.NewDefaultAccountManager(walletMgr, storage, filepath.Join(dir, core.DerivedCacheFileName))
+
+	result := &Result{Dir: dir, Mnemonic: mnemonic}
+	if existing, _ := storage.LoadRootWallet(); existing != nil {
+		result.Reused = true
+	} else if _, err := walletMgr.RestoreWalletFromMnemonic(mnemonic, Password); err != nil {
+		return nil, err
+	}
+	if err := walletMgr.UnlockWallet(Password); err != nil {
+		return nil, err
+	}
+	defer walletMgr.LockWallet()
+	if err := passwords.SetPassword(Password); err != nil {
+		return nil, err
+	}
+	if result.MasterFingerprint, err = accountMgr.MasterFingerprint(); err != nil {
+		return nil, err
+	}
+
+	for _, symbol := range coins {
+		account, err := ensureAccount(accountMgr, symbol)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := accountMgr.DeriveAddresses(account.ID, 0, 0, uint32(count))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		entry := &Account{Coin: account.CoinSymbol, Path: account.DerivationPath}
+		for _, key := range keys {
+			entry.Addresses = append(entry.Addresses, key.Address)
+		}
+		result.Accounts = append(result.Accounts, entry)
+	}
+	return result, nil
+}
+
+// checkDir 目标目录必须是新目录、空目录或同一字符串的开发目录
+func checkDir(dir, input string) error {
+	appConfig := config.GetAppConfig()
+	if configured, err := filepath.Abs(appConfig.GetStorageConfig().BaseDir); err == nil && configured == dir {
+		return ErrConfiguredDir
+	}
+	if IsDevDir(dir) {
+		data, err := os.ReadFile(filepath.Join(dir, MarkerFileName))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(data, []byte(marker(input))) {
+			return fmt.Errorf("%w: %s", ErrOtherSeed, dir)
+		}
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%w: %s is not empty", ErrNotDevDir, dir)
+	}
+	return nil
+}
+
+func marker(input string) string {
+	return fmt.Sprintf("%s\nseed id: %s\n", Watermark, ID(input))
+}
+
+// ensureAccount 币种的账户 0，不存在时创建
+func ensureAccount(accountMgr core.AccountManager, symbol string) (*core.CoinAccount, error) {
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return nil, fmt.Errorf("不支持的币种: %s", symbol)
+	}
+	path := core.ConventionStandard.AccountPath(info.Type, 0)
+	accounts, err := accountMgr.GetAccountsByCoin(info.Type | core.HardenedOffset)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if !account.Standalone && account.Convention() == core.ConventionStandard && account.DerivationPath == path.String() {
+			return account, nil
+		}
+	}
+	return accountMgr.CreateNewAccount(path, core.ConventionStandard)
+}
@@ -0,0 +1,234 @@
+// Package diagnostics 生成附在问题报告里的诊断包（tar.gz）：版本、遮盖过的配置、存储目录状态、
+// 终端和模板状态、最近的日志和 goroutine 堆栈；存储文件只记录名称、大小和权限，不包含内容
+package diagnostics
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/redact"
+	"github.com/palagend/slowmade/internal/version"
+	"golang.org/x/term"
+)
+
+// LogLines 诊断包中保留的日志行数
+const LogLines = 500
+
+// sensitiveKey 配置中需要遮盖的字段名
+var sensitiveKey = regexp.MustCompile(`(?i)pass|secret|token|key|user|project`)
+
+// urlCredentials URL 中的 user:password@ 部分
+var urlCredentials = regexp.MustCompile(`://[^/@\s]+@`)
+
+// Options 诊断包的内容来源
+type Options struct {
+	BaseDir  string
+	Health   func() error // 存储健康检查，为空时跳过
+	Template string       // 当前使用的显示模板
+	Panic    string       // 崩溃时的 panic 信息和堆栈，为空表示不是崩溃报告
+}
+
+// entry 诊断包中的一个文件
+type entry struct {
+	name string
+	data []byte
+}
+
+// Write 生成诊断包写入 path，返回写入的文件列表；path 已存在时报错
+func Write(path string, opts Options) ([]string, error) {
+	files := []entry{
+		{"version.json", versionInfo()},
+		{"config.json", redactedConfig()},
+		{"storage.txt", storageReport(opts)},
+		{"terminal.txt", terminalReport(opts.Template)},
+		{"log.txt", recentLog()},
+		{"goroutines.txt", goroutineDump()},
+	}
+	if opts.Panic != "" {
+		files = append(files, entry{"panic.txt", []byte(redact.String(opts.Panic))})
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	var names []string
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.data)), ModTime: now}
+		if err = tw.WriteHeader(header); err != nil {
+			break
+		}
+		if _, err = tw.Write(f.data); err != nil {
+			break
+		}
+		names = append(names, f.name)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return names, nil
+}
+
+// DefaultName 诊断包的默认文件名
+func DefaultName() string {
+	return fmt.Sprintf("slowmade-diagnostics-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+}
+
+func versionInfo() []byte {
+	data, _ := json.MarshalIndent(version.Get(), "", "  ")
+	return data
+}
+
+// redactedConfig 导出当前配置，密码、密钥、令牌和用户名一律遮盖
+func redactedConfig() []byte {
+	data, err := json.Marshal(config.GetAppConfig())
+	if err != nil {
+		return []byte(err.Error())
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return []byte(err.Error())
+	}
+	maskSensitive(tree)
+	data, _ = json.MarshalIndent(tree, "", "  ")
+	return data
+}
+
+func maskSensitive(node interface{}) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok && s != "" {
+				if sensitiveKey.MatchString(key) {
+					v[key] = redact.Mask
+				} else {
+					v[key] = urlCredentials.ReplaceAllString(s, "://"+redact.Mask+"@")
+				}
+				continue
+			}
+			maskSensitive(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			maskSensitive(item)
+		}
+	}
+}
+
+// storageReport 列出存储目录中的文件名、大小、权限和修改时间，检查 JSON 文件能否解析
+func storageReport(opts Options) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "base dir: %s\n", opts.BaseDir)
+	if opts.Health != nil {
+		if err := opts.Health(); err != nil {
+			fmt.Fprintf(&b, "health: FAILED: %v\n", err)
+		} else {
+			b.WriteString("health: ok\n")
+		}
+	}
+	b.WriteString("\n")
+	err := filepath.WalkDir(opts.BaseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", path, err)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(opts.BaseDir, path)
+		status := ""
+		if !d.IsDir() && strings.HasSuffix(path, ".json") {
+			status = "json ok"
+			if data, err := os.ReadFile(path); err != nil {
+				status = "unreadable: " + err.Error()
+			} else if !json.Valid(data) {
+				status = "INVALID JSON"
+			}
+		}
+		fmt.Fprintf(&b, "%s %10d %s %s %s\n", info.Mode(), info.Size(), info.ModTime().UTC().Format(time.RFC3339), rel, status)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(&b, "walk failed: %v\n", err)
+	}
+	return b.Bytes()
+}
+
+func terminalReport(template string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "template: %s\n", template)
+	fmt.Fprintf(&b, "stdin terminal: %v\n", term.IsTerminal(int(os.Stdin.Fd())))
+	fmt.Fprintf(&b, "stdout terminal: %v\n", term.IsTerminal(int(os.Stdout.Fd())))
+	fmt.Fprintf(&b, "color profile: %s\n", [...]string{"truecolor", "ansi256", "ansi", "ascii"}[lipgloss.ColorProfile()])
+	appConfig := config.GetAppConfig()
+	fmt.Fprintf(&b, "ui.lang: %s\n", appConfig.UI.Lang)
+	for _, name := range []string{"TERM", "COLORTERM", "LANG", "LC_ALL", "NO_COLOR"} {
+		fmt.Fprintf(&b, "%s=%s\n", name, os.Getenv(name))
+	}
+	return b.Bytes()
+}
+
+// recentLog 读取配置的日志文件的最后 LogLines 行并遮盖私密内容
+func recentLog() []byte {
+	appConfig := config.GetAppConfig()
+	path := appConfig.Log.File
+	if path == "" {
+		return []byte("logging to console, no log file configured\n")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return []byte(fmt.Sprintf("%s: %v\n", path, err))
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > LogLines {
+			lines = lines[1:]
+		}
+	}
+	return []byte(redact.String(strings.Join(lines, "\n")) + "\n")
+}
+
+func goroutineDump() []byte {
+	var b bytes.Buffer
+	if profile := pprof.Lookup("goroutine"); profile != nil {
+		profile.WriteTo(&b, 2)
+	}
+	return b.Bytes()
+}
+
+// Describe 返回诊断包内容说明，供确认提示使用
+func Describe() string {
+	return fmt.Sprintf("version, config (secrets masked), storage file list (no contents), terminal status, last %d log lines (redacted) and a goroutine dump", LogLines)
+}
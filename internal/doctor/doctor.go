@@ -0,0 +1,371 @@
+// Package doctor 供脚本和监控使用的自检：存储、配置、显示模板、加密往返、助记词单词表和节点连通性，
+// 每项检查给出 ok、warn 或 fail，整体结果映射为退出码
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/crypto"
+	"github.com/palagend/slowmade/pkg/mnemonic"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+// Status 单项检查的结果
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Warn:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+// Result 一项检查的名称、结果和说明
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Options 检查的输入
+type Options struct {
+	ConfigErr    error         // 加载配置时的错误，不为空时配置检查失败
+	Offline      bool          // 不检查节点连通性
+	ProbeTimeout time.Duration // 单个节点的连接超时
+}
+
+// Run 依次执行全部检查
+func Run(opts Options) []Result {
+	results := []Result{
+		checkStorage(),
+		checkConfig(opts.ConfigErr),
+		CheckTemplates(),
+		checkCrypto(),
+		checkEntropy(),
+		checkWordList(),
+	}
+	if opts.Offline {
+		return append(results, Result{Name: "rpc", Status: OK, Detail: "skipped (--offline)"})
+	}
+	return append(results, checkRPC(opts.ProbeTimeout))
+}
+
+// ExitCode 按最差的结果返回退出码：0 全部通过，1 有警告，2 有失败（与 Nagios 插件的约定相同）
+func ExitCode(results []Result) int {
+	worst := OK
+	for _, result := range results {
+		worst = max(worst, result.Status)
+	}
+	return int(worst)
+}
+
+// checkStorage 打开存储目录，检查目录可写、钱包和账户文件可以解析；
+// 目录被另一个进程占用时以只读方式检查
+func checkStorage() Result {
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	result := Result{Name: "storage"}
+	stor, err := core.NewFileStorage(storageConfig)
+	shared := false
+	if errors.Is(err, core.ErrDataDirLocked) {
+		storageConfig.ReadOnly = true
+		stor, err = core.NewFileStorage(storageConfig)
+		shared = true
+	}
+	if err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	if err := stor.CheckStorageHealth(); err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	wallet, err := stor.LoadRootWallet()
+	if err != nil {
+		result.Status, result.Detail = Fail, fmt.Sprintf("wallet file: %v", err)
+		return result
+	}
+	accounts, err := stor.LoadAccounts()
+	if err != nil {
+		result.Status, result.Detail = Fail, fmt.Sprintf("accounts file: %v", err)
+		return result
+	}
+
+	result.Detail = storageConfig.BaseDir
+	if wallet == nil {
+		result.Status = Warn
+		result.Detail += ", no wallet created yet"
+	} else {
+		result.Detail += fmt.Sprintf(", %d accounts", len(accounts))
+	}
+	if storageConfig.ReadOnly {
+		result.Detail += ", checked read-only"
+		if shared {
+			result.Detail += " (in use by another process)"
+		}
+	}
+	return result
+}
+
+// checkConfig 检查配置文件可以加载，并校验 RPC 节点、时区、日志级别和数值范围
+func checkConfig(loadErr error) Result {
+	result := Result{Name: "config"}
+	if loadErr != nil {
+		result.Status, result.Detail = Fail, loadErr.Error()
+		return result
+	}
+	appConfig := config.GetAppConfig()
+	var problems []string
+	if err := appConfig.GetRPCConfig().Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if zone := strings.TrimSpace(appConfig.GetUIConfig().Timezone); zone != "" && !strings.EqualFold(zone, "local") {
+		if _, err := time.LoadLocation(zone); err != nil {
+			problems = append(problems, fmt.Sprintf("ui.timezone %q: %v", zone, err))
+		}
+	}
+	if mode := strings.ToLower(strings.TrimSpace(appConfig.GetUIConfig().QR)); mode != "" && !slices.Contains(view.GraphicsModes, mode) {
+		problems = append(problems, fmt.Sprintf("ui.qr %q must be one of %s", appConfig.GetUIConfig().QR, strings.Join(view.GraphicsModes, ", ")))
+	}
+	if err := payreq.CheckContent(appConfig.GetUIConfig().QRContent); err != nil {
+		problems = append(problems, "ui.qr_content: "+err.Error())
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(appConfig.GetLogConfig().Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level %q is not a valid level", appConfig.GetLogConfig().Level))
+	}
+	if port := appConfig.GetWebConfig().Port; port < 0 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("web.port %d is out of range", port))
+	}
+	if appConfig.GetWalletConfig().AutoLockMinutes < 0 {
+		problems = append(problems, "wallet.auto_lock_minutes must not be negative")
+	}
+	if appConfig.GetWalletConfig().MnemonicClearSeconds <= 0 {
+		problems = append(problems, "wallet.mnemonic_clear_seconds must be positive")
+	}
+	if _, err := core.ParsePermissionPolicy(appConfig.GetStorageConfig().Permissions); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if appConfig.GetStorageConfig().CacheEntries < 0 {
+		problems = append(problems, "storage.cache_entries must not be negative")
+	}
+	if appConfig.GetTrashConfig().RetentionDays < 0 {
+		problems = append(problems, "trash.retention_days must not be negative")
+	}
+	backupConfig := appConfig.GetBackupConfig()
+	if len(backupConfig.Recipients) > 0 {
+		if _, err := backup.ParseRecipients(backupConfig.Recipients); err != nil {
+			problems = append(problems, "backup.recipients: "+err.Error())
+		}
+	}
+	if backupConfig.IntervalHours < 0 {
+		problems = append(problems, "backup.interval_hours must not be negative")
+	}
+	if backupConfig.IntervalHours > 0 && (backupConfig.Dir == "" || len(backupConfig.Recipients) == 0) {
+		problems = append(problems, "backup.interval_hours needs backup.dir and backup.recipients")
+	}
+	if appConfig.GetPriceConfig().MaxSlippagePercent < 0 {
+		problems = append(problems, "price.max_slippage_percent must not be negative")
+	}
+	if telegram := appConfig.GetNotifyConfig().Telegram; telegram.Enabled {
+		if _, err := events.Telegram(events.TelegramOptions{BotToken: telegram.BotToken, ChatID: telegram.ChatID, Template: telegram.Template}); err != nil {
+			problems = append(problems, "notify.telegram: "+err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		result.Status, result.Detail = Fail, strings.Join(problems, "; ")
+		return result
+	}
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		result.Detail = file
+	} else {
+		result.Detail = "no config file, using defaults"
+	}
+	return result
+}
+
+// CheckTemplates 渲染内置显示模板，并用格式化函数解析执行一个自定义模板，升级检查也使用
+func CheckTemplates() (result Result) {
+	result = Result{Name: "templates"}
+	// 模板渲染出错时会 panic，作为检查失败报告
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status, result.Detail = Fail, fmt.Sprintf("rendering panicked: %v", r)
+		}
+	}()
+	helpCommand := view.CommandHelp{Name: "help", Usages: []view.HelpUsage{{Args: "[command]", Summary: "Show help"}}}
+	for _, tmpl := range []view.DisplayTemplate{view.NewDefaultTemplate(), view.NewAccessibleTemplate()} {
+		for name, output := range map[string]string{
+			"welcome":       tmpl.Welcome(),
+			"help":          tmpl.Help([]view.HelpGroup{{Title: "BASIC COMMANDS", Commands: []view.CommandHelp{helpCommand}}}),
+			"command help":  tmpl.CommandHelp(helpCommand),
+			"wallet status": tmpl.WalletStatus(&view.WalletStatusInfo{Status: "locked"}),
+		} {
+			if strings.TrimSpace(output) == "" {
+				result.Status, result.Detail = Fail, name+" rendered empty"
+				return result
+			}
+		}
+	}
+
+	formatter := view.CurrentFormatter()
+	custom, err := template.New("doctor").Funcs(formatter.FuncMap()).Parse(`{{date .Time}} {{number .Count}} {{size .Bytes}}`)
+	if err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	var out bytes.Buffer
+	data := struct {
+		Time         time.Time
+		Count, Bytes int64
+	}{time.Now(), 1234567, 1 << 20}
+	if err := custom.Execute(&out, data); err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	appConfig := config.GetAppConfig()
+	kind := "default"
+	if view.Accessible() {
+		kind = "accessible"
+	}
+	result.Detail = fmt.Sprintf("%s template, %s formatting in %s, %s QR codes", kind, appConfig.GetUIConfig().Lang, view.Location(), view.Graphics())
+	return result
+}
+
+// checkCrypto 用随机密码加密随机数据再解密，确认得到原文且错误的密码无法解密
+func checkCrypto() Result {
+	result := Result{Name: "crypto"}
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	plaintext, password := buf[:32], hex.EncodeToString(buf[32:])
+
+	service := crypto.GetDefaultCryptoService()
+	start := time.Now()
+	ciphertext, err := service.Encrypt(plaintext, password)
+	if err != nil {
+		result.Status, result.Detail = Fail, fmt.Sprintf("encrypt: %v", err)
+		return result
+	}
+	decrypted, err := service.Decrypt(ciphertext, password)
+	if err != nil {
+		result.Status, result.Detail = Fail, fmt.Sprintf("decrypt: %v", err)
+		return result
+	}
+	elapsed := time.Since(start)
+	if !bytes.Equal(decrypted, plaintext) {
+		result.Status, result.Detail = Fail, "decrypted data does not match the plaintext"
+		return result
+	}
+	if _, err := service.Decrypt(ciphertext, password+"x"); err == nil {
+		result.Status, result.Detail = Fail, "ciphertext decrypted with a wrong password"
+		return result
+	}
+	result.Detail = fmt.Sprintf("%s, %s, round trip %s", crypto.GetCurrentAlgorithm(), crypto.GetCurrentKDF(), elapsed.Round(time.Millisecond))
+	return result
+}
+
+// checkEntropy 检查系统随机数自检的结果，并从配置的硬件随机数设备读取一次
+func checkEntropy() Result {
+	result := Result{Name: "entropy"}
+	if err := mnemonic.CheckRNG(); err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	result.Detail = "system RNG self-test passed"
+	appConfig := config.GetAppConfig()
+	if device := appConfig.GetEntropyConfig().Device; device != "" {
+		source := &mnemonic.DeviceSource{Path: device}
+		if _, err := source.Entropy(32); err != nil {
+			result.Status, result.Detail = Fail, err.Error()
+			return result
+		}
+		result.Detail += ", " + device + " readable"
+	}
+	return result
+}
+
+// checkWordList 检查 BIP39 单词表完整
+func checkWordList() Result {
+	result := Result{Name: "wordlist"}
+	if err := mnemonic.NewBIP39MnemonicService().CheckWordList(); err != nil {
+		result.Status, result.Detail = Fail, err.Error()
+		return result
+	}
+	result.Detail = "BIP39 english, 2048 words"
+	return result
+}
+
+// checkRPC 并发连接当前网络上配置的全部节点，不可达的节点记为警告：钱包离线时仍可使用
+func checkRPC(timeout time.Duration) Result {
+	result := Result{Name: "rpc"}
+	appConfig := config.GetAppConfig()
+	rpcConfig := appConfig.GetRPCConfig()
+	symbols := rpcConfig.Configured()
+	if len(symbols) == 0 {
+		result.Detail = "no nodes configured"
+		return result
+	}
+
+	details := make([]string, len(symbols))
+	failed := make([]bool, len(symbols))
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		node, ok := rpcConfig.Node(symbol)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, symbol, endpoint string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			latency, err := chain.Probe(ctx, endpoint)
+			if err != nil {
+				details[i], failed[i] = fmt.Sprintf("%s %s unreachable: %v", symbol, chain.EndpointName(endpoint), err), true
+				return
+			}
+			details[i] = fmt.Sprintf("%s %s %.1f ms", symbol, chain.EndpointName(endpoint), float64(latency.Microseconds())/1000)
+		}(i, symbol, node.Endpoint)
+	}
+	wg.Wait()
+
+	for _, f := range failed {
+		if f {
+			result.Status = Warn
+		}
+	}
+	result.Detail = strings.Join(details, "; ")
+	return result
+}
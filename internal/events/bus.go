@@ -0,0 +1,96 @@
+// Package events 进程内事件总线，以及把事件转发到 webhook、桌面通知和 Telegram 的订阅者
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	PaymentReceived = "payment.received" // 监控的地址收到付款
+	RequestPaid     = "request.paid"     // 收款请求已支付
+	WalletLocked    = "wallet.locked"    // 钱包因空闲超时自动锁定
+)
+
+// Describer 事件数据提供给通知的标题和正文
+type Describer interface {
+	Describe() (title, body string)
+}
+
+// Locked wallet.locked 事件的数据
+type Locked struct {
+	Reason string `json:"reason"`
+}
+
+// Describe 实现 Describer
+func (l Locked) Describe() (string, string) {
+	return "Wallet locked", l.Reason
+}
+
+// Describe 返回事件的通知标题和正文，数据没有实现 Describer 时正文为 JSON
+func Describe(event Event) (title, body string) {
+	if d, ok := event.Data.(Describer); ok {
+		return d.Describe()
+	}
+	data, _ := json.Marshal(event.Data)
+	return event.Type, string(data)
+}
+
+// Event 一条事件，Data 会被编码为 JSON 发送给 webhook
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Handler 事件处理函数，应尽快返回
+type Handler func(Event)
+
+// Bus 事件总线，按订阅顺序同步调用处理函数
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 订阅全部事件
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，Time 为空时使用当前时间
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Only 只把 types 中的事件交给 handler，types 为空时不过滤
+func Only(types []string, handler Handler) Handler {
+	if len(types) == 0 {
+		return handler
+	}
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+	return func(event Event) {
+		if wanted[event.Type] {
+			handler(event)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// ErrDesktopUnsupported 当前系统不支持桌面通知
+var ErrDesktopUnsupported = errors.New("desktop notifications are only supported on Linux and macOS")
+
+// Desktop 返回以系统桌面通知显示事件的处理函数（Linux 使用 notify-send，macOS 使用 osascript），
+// 在后台发送，失败只在第一次记录日志
+func Desktop() Handler {
+	var once sync.Once
+	return func(event Event) {
+		title, body := Describe(event)
+		go func() {
+			if err := notifyDesktop(title, body); err != nil {
+				once.Do(func() { logging.Warnf("桌面通知发送失败: %v", err) })
+			}
+		}()
+	}
+}
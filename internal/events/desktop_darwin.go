@@ -0,0 +1,12 @@
+package events
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// notifyDesktop 通过 AppleScript 显示通知，标题和正文按 AppleScript 字符串字面量转义
+func notifyDesktop(title, body string) error {
+	script := "display notification " + strconv.Quote(body) + " with title " + strconv.Quote(title)
+	return exec.Command("osascript", "-e", script).Run()
+}
@@ -0,0 +1,7 @@
+package events
+
+import "os/exec"
+
+func notifyDesktop(title, body string) error {
+	return exec.Command("notify-send", "--app-name=slowmade", title, body).Run()
+}
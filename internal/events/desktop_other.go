@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package events
+
+func notifyDesktop(title, body string) error {
+	return ErrDesktopUnsupported
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// DefaultTelegramTemplate 默认的 Telegram 消息模板
+const DefaultTelegramTemplate = "{{.Title}}\n{{.Body}}"
+
+// TelegramOptions Telegram 机器人通知的设置
+type TelegramOptions struct {
+	APIURL   string // Bot API 地址，如 https://api.telegram.org
+	BotToken string
+	ChatID   string
+	Template string // text/template 模板，可用 .Type .Time .Title .Body .Data，为空时使用 DefaultTelegramTemplate
+}
+
+// Message 通知模板的数据
+type Message struct {
+	Type  string
+	Time  time.Time
+	Title string
+	Body  string
+	Data  interface{} // 事件原始数据，如 .Data.Amount
+}
+
+// Telegram 返回通过 Bot API sendMessage 把事件发送到聊天的处理函数，在后台发送，失败只记录日志
+func Telegram(opts TelegramOptions) (Handler, error) {
+	if opts.BotToken == "" || opts.ChatID == "" {
+		return nil, errors.New("telegram notifications need bot_token and chat_id")
+	}
+	text := opts.Template
+	if text == "" {
+		text = DefaultTelegramTemplate
+	}
+	tmpl, err := template.New("telegram").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("telegram template: %w", err)
+	}
+	endpoint := strings.TrimRight(opts.APIURL, "/") + "/bot" + opts.BotToken + "/sendMessage"
+
+	return func(event Event) {
+		title, body := Describe(event)
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, Message{Type: event.Type, Time: event.Time, Title: title, Body: body, Data: event.Data}); err != nil {
+			logging.Warnf("telegram 消息模板执行失败: %v", err)
+			return
+		}
+		payload, err := json.Marshal(map[string]string{"chat_id": opts.ChatID, "text": buf.String()})
+		if err != nil {
+			return
+		}
+		go func() {
+			if err := post(endpoint, payload); err != nil {
+				// 请求地址中含有机器人令牌，不能出现在日志中
+				var urlErr *url.Error
+				if errors.As(err, &urlErr) {
+					err = urlErr.Err
+				}
+				logging.Warnf("telegram 通知发送失败: %v", err)
+			}
+		}()
+	}, nil
+}
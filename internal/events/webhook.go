@@ -0,0 +1,43 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+var webhookClient = resilience.NewHTTPClient(10 * time.Second)
+
+// Webhook 返回把事件以 JSON POST 到各个 URL 的处理函数，在后台发送，失败只记录日志
+func Webhook(urls []string) Handler {
+	return func(event Event) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			logging.Warnf("编码事件失败: %v", err)
+			return
+		}
+		for _, url := range urls {
+			go func(url string) {
+				if err := post(url, body); err != nil {
+					logging.Warnf("webhook %s 发送失败: %v", url, err)
+				}
+			}(url)
+		}
+	}
+}
+
+func post(url string, body []byte) error {
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
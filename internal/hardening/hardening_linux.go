@@ -0,0 +1,146 @@
+package hardening
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// mlockallDone 启动时 mlockall 是否成功，内核没有提供查询接口
+var mlockallDone bool
+
+func apply(opts Options) *Status {
+	status := &Status{}
+
+	memory := Protection{Name: MemoryLock}
+	switch {
+	case !opts.MlockAll:
+		memory.Detail = "disabled in config, secret buffers are still locked by memguard"
+	case !memlockUnlimited():
+		// MCL_FUTURE 在额度不够时会让之后的内存分配失败，运行时会直接崩溃，所以额度有限时不尝试
+		memory.Detail = fmt.Sprintf("skipped, RLIMIT_MEMLOCK is %s (run with ulimit -l unlimited or CAP_IPC_LOCK); "+
+			"secret buffers are still locked by memguard", memlockLimit())
+	default:
+		if err := unix.Mlockall(unix.MCL_CURRENT | unix.MCL_FUTURE); err != nil {
+			memory.Detail = fmt.Sprintf("mlockall failed: %v", err)
+		} else {
+			mlockallDone = true
+			memory.Enabled = true
+			memory.Detail = "mlockall(MCL_CURRENT|MCL_FUTURE)"
+		}
+	}
+	status.Protections = append(status.Protections, memory)
+
+	core := Protection{Name: CoreDumps}
+	if !opts.DisableCoreDumps {
+		core.Detail = "disabled in config"
+	} else if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0}); err != nil {
+		core.Detail = fmt.Sprintf("setrlimit(RLIMIT_CORE) failed: %v", err)
+	} else {
+		core.Enabled = true
+		core.Detail = "RLIMIT_CORE=0"
+	}
+	status.Protections = append(status.Protections, core)
+
+	ptrace := Protection{Name: NoPtrace}
+	if !opts.NoDumpable {
+		ptrace.Detail = "disabled in config"
+	} else if err := unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		ptrace.Detail = fmt.Sprintf("prctl(PR_SET_DUMPABLE) failed: %v", err)
+	} else {
+		ptrace.Enabled = true
+		ptrace.Detail = "PR_SET_DUMPABLE=0" + ptraceScope()
+	}
+	status.Protections = append(status.Protections, ptrace)
+
+	return status
+}
+
+func current() *Status {
+	status := &Status{}
+
+	memory := Protection{Name: MemoryLock, Enabled: mlockallDone}
+	locked := procStatus("VmLck")
+	if mlockallDone {
+		memory.Detail = "mlockall active, locked " + locked
+	} else {
+		memory.Detail = "only memguard buffers are locked (" + locked + ")"
+	}
+	status.Protections = append(status.Protections, memory)
+
+	core := Protection{Name: CoreDumps}
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_CORE, &limit); err != nil {
+		core.Detail = fmt.Sprintf("getrlimit failed: %v", err)
+	} else {
+		core.Enabled = limit.Cur == 0
+		core.Detail = fmt.Sprintf("RLIMIT_CORE=%s", formatLimit(limit.Cur))
+	}
+	status.Protections = append(status.Protections, core)
+
+	ptrace := Protection{Name: NoPtrace}
+	if dumpable, err := unix.PrctlRetInt(unix.PR_GET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		ptrace.Detail = fmt.Sprintf("prctl(PR_GET_DUMPABLE) failed: %v", err)
+	} else {
+		ptrace.Enabled = dumpable == 0
+		ptrace.Detail = fmt.Sprintf("PR_GET_DUMPABLE=%d%s", dumpable, ptraceScope())
+	}
+	status.Protections = append(status.Protections, ptrace)
+
+	return status
+}
+
+// memlockUnlimited 能否安全地锁定全部内存：额度无限，或者以 root 运行（通常有 CAP_IPC_LOCK）
+func memlockUnlimited() bool {
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit); err != nil {
+		return false
+	}
+	return limit.Cur == unix.RLIM_INFINITY || os.Geteuid() == 0
+}
+
+func memlockLimit() string {
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit); err != nil {
+		return "unknown"
+	}
+	return formatLimit(limit.Cur)
+}
+
+func formatLimit(value uint64) string {
+	if value == unix.RLIM_INFINITY {
+		return "unlimited"
+	}
+	if value >= 1024 && value%1024 == 0 {
+		return fmt.Sprintf("%d KiB", value/1024)
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+// ptraceScope Yama 的 ptrace_scope 设置，没有 Yama 时为空
+func ptraceScope() string {
+	data, err := os.ReadFile("/proc/sys/kernel/yama/ptrace_scope")
+	if err != nil {
+		return ""
+	}
+	return ", yama ptrace_scope=" + strings.TrimSpace(string(data))
+}
+
+// procStatus 读取 /proc/self/status 中的一项
+func procStatus(key string) string {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return "unknown"
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if name, value, ok := strings.Cut(scanner.Text(), ":"); ok && name == key {
+			return strings.Join(strings.Fields(value), " ")
+		}
+	}
+	return "unknown"
+}
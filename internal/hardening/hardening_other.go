@@ -0,0 +1,19 @@
+//go:build !linux
+
+package hardening
+
+import "runtime"
+
+// 非 Linux 平台没有实现这些系统调用，只报告不可用；密钥缓冲区仍由 memguard 锁定
+func apply(opts Options) *Status {
+	return current()
+}
+
+func current() *Status {
+	detail := "not supported on " + runtime.GOOS
+	return &Status{Protections: []Protection{
+		{Name: MemoryLock, Detail: detail + ", secret buffers are still locked by memguard"},
+		{Name: CoreDumps, Detail: detail},
+		{Name: NoPtrace, Detail: detail},
+	}}
+}
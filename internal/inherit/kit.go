@@ -0,0 +1,135 @@
+// Package inherit 生成遗产继承包：给继承人的说明、钱包指纹、扩展公钥和描述符，
+// 以及可选的助记词 Shamir 分享，分别加密给每位继承人的公钥
+package inherit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/pubexport"
+)
+
+// Kit 继承包的内容，Threshold 为 0 时不包含助记词分享
+type Kit struct {
+	CreatedAt         time.Time
+	MasterFingerprint string
+	Cloaked           bool
+	Note              string
+	Accounts          []*pubexport.Account
+	Threshold         int
+}
+
+// Render 生成给一位继承人的纯文本说明，share 为空时不包含分享
+func (k *Kit) Render(recipient string, share *Share) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "SLOWMADE INHERITANCE KIT\n\n")
+	fmt.Fprintf(&b, "Prepared:           %s\n", k.CreatedAt.UTC().Format("2006-01-02"))
+	fmt.Fprintf(&b, "For:                %s\n", recipient)
+	fmt.Fprintf(&b, "Wallet fingerprint: %s\n\n", k.MasterFingerprint)
+	if note := strings.TrimSpace(k.Note); note != "" {
+		fmt.Fprintf(&b, "A NOTE FROM THE OWNER\n\n%s\n\n", note)
+	}
+
+	fmt.Fprintf(&b, "WHAT THIS IS\n\n")
+	fmt.Fprintf(&b, "This file was written by the owner of a slowmade cryptocurrency wallet so that\n")
+	fmt.Fprintf(&b, "the funds can be recovered if the owner can no longer do it. Keep it private.\n")
+	fmt.Fprintf(&b, "Be suspicious of anyone who contacts you offering to help recover the funds:\n")
+	fmt.Fprintf(&b, "nobody legitimate needs your recovery share or the recovered words.\n\n")
+
+	fmt.Fprintf(&b, "HOW TO RECOVER\n\n")
+	if share != nil {
+		fmt.Fprintf(&b, "1. Any %d of the %d heirs must each contribute their recovery share (below).\n", share.Threshold, share.Total)
+		fmt.Fprintf(&b, "   Fewer shares reveal nothing about the wallet.\n")
+		fmt.Fprintf(&b, "2. On an offline computer, install slowmade and run inherit.combine in its\n")
+		fmt.Fprintf(&b, "   shell. Enter the shares; it prints the 12-24 recovery words.\n")
+		fmt.Fprintf(&b, "3. Run wallet.restore with those words and choose a new password.\n")
+	} else {
+		fmt.Fprintf(&b, "1. This kit does not contain the recovery words. The owner's note above says\n")
+		fmt.Fprintf(&b, "   where they are kept.\n")
+		fmt.Fprintf(&b, "2. Install slowmade on an offline computer.\n")
+		fmt.Fprintf(&b, "3. Run wallet.restore in its shell with the recovery words and choose a new\n")
+		fmt.Fprintf(&b, "   password.\n")
+	}
+	if k.Cloaked {
+		fmt.Fprintf(&b, "   The wallet also uses a cloak (an extra passphrase, given with --cloak).\n")
+		fmt.Fprintf(&b, "   It is NOT in this kit; without it the restored wallet is empty.\n")
+	}
+	fmt.Fprintf(&b, "4. Check that wallet.status shows the fingerprint %s, then recreate the\n", k.MasterFingerprint)
+	fmt.Fprintf(&b, "   accounts listed below with account.create <path>, or the command shown.\n")
+	fmt.Fprintf(&b, "5. The extended public keys below show the balances without the words, for\n")
+	fmt.Fprintf(&b, "   example in a watch-only wallet, so you can check what to expect first.\n\n")
+
+	fmt.Fprintf(&b, "ACCOUNTS\n\n")
+	for _, account := range k.Accounts {
+		fmt.Fprintf(&b, "%s %s (fingerprint %s)\n", account.Coin, account.Path, account.Fingerprint)
+		if account.Convention != "" {
+			index := uint32(0)
+			if path, err := core.ParseDerivationPath(account.Path); err == nil {
+				index = path.AccountIndex &^ core.HardenedOffset
+			}
+			fmt.Fprintf(&b, "  addresses use the %s layout: account.create %s --convention %s --index %d\n",
+				account.Convention, account.Coin, account.Convention, index)
+		}
+		if account.Standalone {
+			fmt.Fprintf(&b, "  imported account, it is NOT recovered from the words\n")
+		}
+		fmt.Fprintf(&b, "  %s\n", account.XPub)
+		for _, descriptor := range account.Descriptors {
+			fmt.Fprintf(&b, "  %s\n", descriptor)
+		}
+	}
+	if len(k.Accounts) == 0 {
+		fmt.Fprintf(&b, "(no accounts)\n")
+	}
+
+	if share != nil {
+		fmt.Fprintf(&b, "\nYOUR RECOVERY SHARE (%d of %d, any %d recover the wallet)\n\n", share.X, share.Total, share.Threshold)
+		fmt.Fprintf(&b, "%s\n", share)
+	}
+	return b.Bytes()
+}
+
+// Write 为每位继承人写一个只有其本人能解密的继承包文件（权限 0600）。mnemonic 不为空时
+// 分成与继承人数相同的分享，每人一份；为空时全部继承人的文件内容相同。返回写入的文件路径
+func (k *Kit) Write(dir string, recipients *backup.Recipients, mnemonic string) ([]string, error) {
+	each := recipients.Each()
+	var shares []*Share
+	if mnemonic != "" {
+		var err error
+		if shares, err = SplitMnemonic(mnemonic, k.MasterFingerprint, len(each), k.Threshold); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	stamp := k.CreatedAt.UTC().Format("20060102")
+	var paths []string
+	for i, recipient := range each {
+		var share *Share
+		if shares != nil {
+			share = shares[i]
+		}
+		path := filepath.Join(dir, fmt.Sprintf("inheritance-kit-%s-%d%s", stamp, i+1, recipient.Extension()))
+		if _, err := os.Stat(path); err == nil {
+			return paths, fmt.Errorf("%s already exists", path)
+		}
+		plaintext := k.Render(recipient.Name(), share)
+		sealed, err := recipient.Encrypt(plaintext)
+		clear(plaintext)
+		if err != nil {
+			return paths, err
+		}
+		if err := os.WriteFile(path, sealed, 0600); err != nil {
+			return paths, fmt.Errorf("写入 %s 失败: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
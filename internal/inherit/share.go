@@ -0,0 +1,136 @@
+package inherit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/mnemonic"
+	"github.com/palagend/slowmade/pkg/shamir"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// sharePrefix 分享文本的前缀，带版本号
+const sharePrefix = "slowmade-share1"
+
+// 错误定义
+var (
+	ErrInvalidShare   = errors.New("invalid recovery share")
+	ErrMixedShares    = errors.New("shares belong to different kits")
+	ErrNotEnoughShare = errors.New("not enough shares")
+)
+
+// Share 一份助记词分享。Wallet 是钱包主密钥指纹，Set 是每次分割随机生成的编号，
+// 用来发现混在一起的其他钱包或其他继承包的分享
+type Share struct {
+	Wallet    string
+	Set       string
+	Threshold int
+	Total     int
+	shamir.Share
+}
+
+// String 编码为 slowmade-share1-<指纹>-<编号>-<门限>of<总数>-<序号>-<十六进制>-<校验>，校验为前面内容的 SHA-256 前 4 字节
+func (s *Share) String() string {
+	body := fmt.Sprintf("%s-%s-%s-%dof%d-%d-%s", sharePrefix, s.Wallet, s.Set, s.Threshold, s.Total, s.X, hex.EncodeToString(s.Y))
+	sum := sha256.Sum256([]byte(body))
+	return body + "-" + hex.EncodeToString(sum[:4])
+}
+
+// ParseShare 解析分享文本，允许前后空白和换行
+func ParseShare(text string) (*Share, error) {
+	text = strings.Join(strings.Fields(text), "")
+	body, checksum, ok := cutLast(text, "-")
+	if !ok || !strings.HasPrefix(body, sharePrefix+"-") {
+		return nil, fmt.Errorf("%w: expected %s-...", ErrInvalidShare, sharePrefix)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if expected, err := hex.DecodeString(checksum); err != nil || !bytes.Equal(expected, sum[:4]) {
+		return nil, fmt.Errorf("%w: checksum does not match, check for typos", ErrInvalidShare)
+	}
+	fields := strings.Split(strings.TrimPrefix(body, sharePrefix+"-"), "-")
+	if len(fields) != 5 {
+		return nil, ErrInvalidShare
+	}
+	threshold, total, ok := strings.Cut(fields[2], "of")
+	share := &Share{Wallet: fields[0], Set: fields[1]}
+	var err error
+	if share.Threshold, err = strconv.Atoi(threshold); err != nil || !ok {
+		return nil, ErrInvalidShare
+	}
+	if share.Total, err = strconv.Atoi(total); err != nil || share.Threshold < 2 || share.Threshold > share.Total {
+		return nil, ErrInvalidShare
+	}
+	x, err := strconv.ParseUint(fields[3], 10, 8)
+	if err != nil || x == 0 || int(x) > share.Total {
+		return nil, ErrInvalidShare
+	}
+	share.X = byte(x)
+	if share.Y, err = hex.DecodeString(fields[4]); err != nil || len(share.Y) == 0 {
+		return nil, ErrInvalidShare
+	}
+	return share, nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// SplitMnemonic 把助记词的熵分成 total 份，任意 threshold 份可以恢复
+func SplitMnemonic(phrase, wallet string, total, threshold int) ([]*Share, error) {
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic.Normalize(phrase))
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	defer clear(entropy)
+	parts, err := shamir.Split(entropy, total, threshold)
+	if err != nil {
+		return nil, err
+	}
+	set := make([]byte, 2)
+	if _, err := rand.Read(set); err != nil {
+		return nil, err
+	}
+	shares := make([]*Share, len(parts))
+	for i, part := range parts {
+		shares[i] = &Share{Wallet: wallet, Set: hex.EncodeToString(set), Threshold: threshold, Total: total, Share: part}
+	}
+	return shares, nil
+}
+
+// CombineShares 用至少门限数量的分享恢复助记词
+func CombineShares(shares []*Share) (string, error) {
+	if len(shares) == 0 {
+		return "", ErrNotEnoughShare
+	}
+	first := shares[0]
+	parts := make([]shamir.Share, 0, len(shares))
+	for _, share := range shares {
+		if share.Wallet != first.Wallet || share.Set != first.Set || share.Threshold != first.Threshold || share.Total != first.Total {
+			return "", ErrMixedShares
+		}
+		parts = append(parts, share.Share)
+	}
+	if len(shares) < first.Threshold {
+		return "", fmt.Errorf("%w: %d of %d", ErrNotEnoughShare, len(shares), first.Threshold)
+	}
+	entropy, err := shamir.Combine(parts)
+	if err != nil {
+		return "", err
+	}
+	defer clear(entropy)
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidShare, err)
+	}
+	return mnemonic, nil
+}
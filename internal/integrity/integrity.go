@@ -0,0 +1,343 @@
+// Package integrity 为存储目录维护文件哈希清单：每次写入后更新，清单用钱包密码派生的密钥做 HMAC，
+// 解锁时校验，发现不是 slowmade 写入的修改（恶意软件、同步冲突）时提醒用户
+package integrity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// ManifestFileName 清单在存储目录中的文件名
+const ManifestFileName = "integrity.json"
+
+// TrackedDirs 纳入清单的子目录，即钱包、账户和地址文件
+var TrackedDirs = []string{"wallets", "accounts", "addresses"}
+
+// 错误定义
+var (
+	ErrLocked     = errors.New("integrity key is not available, unlock the wallet first")
+	ErrNoManifest = errors.New("no integrity manifest")
+	ErrUnverified = errors.New("wallet files changed outside slowmade, accept or revert the changes first")
+)
+
+// Manifest 文件哈希清单
+type Manifest struct {
+	Version   int               `json:"version"`
+	Salt      string            `json:"salt"`  // 派生 HMAC 密钥用的盐（十六进制）
+	Files     map[string]string `json:"files"` // 相对路径 -> SHA-256
+	UpdatedAt time.Time         `json:"updated_at"`
+	MAC       string            `json:"mac"`
+}
+
+// Report 校验结果
+type Report struct {
+	Created   bool     // 之前没有清单，已用当前文件创建基线
+	BadMAC    bool     // 清单本身被改动或不是用这个钱包的密码生成的
+	Modified  []string // 内容改变的文件
+	Added     []string // 清单之外新出现的文件
+	Removed   []string // 清单中有但已不存在的文件
+	UpdatedAt time.Time
+}
+
+// Clean 没有发现任何异常
+func (r *Report) Clean() bool {
+	return !r.BadMAC && len(r.Modified) == 0 && len(r.Added) == 0 && len(r.Removed) == 0
+}
+
+// Problems 逐条列出发现的异常
+func (r *Report) Problems() []string {
+	var lines []string
+	if r.BadMAC {
+		lines = append(lines, "the manifest signature does not match (manifest edited or replaced)")
+	}
+	for _, name := range r.Modified {
+		lines = append(lines, "modified: "+name)
+	}
+	for _, name := range r.Added {
+		lines = append(lines, "added:    "+name)
+	}
+	for _, name := range r.Removed {
+		lines = append(lines, "removed:  "+name)
+	}
+	return lines
+}
+
+// Guard 持有解锁期间的 HMAC 密钥，锁定时清除
+type Guard struct {
+	baseDir string
+	mu      sync.Mutex
+	key     []byte
+	salt    []byte
+	pending bool // 解锁时发现了异常，确认前不再更新清单，避免把外部修改当成基线
+}
+
+// NewGuard 创建存储目录的防篡改守护
+func NewGuard(baseDir string) *Guard {
+	return &Guard{baseDir: baseDir}
+}
+
+// Unlock 用钱包密码派生密钥并校验清单；还没有清单时以当前文件为基线创建
+func (g *Guard) Unlock(password string) (*Report, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	manifest, err := g.load()
+	if errors.Is(err, ErrNoManifest) {
+		if err := g.derive(password, nil); err != nil {
+			return nil, err
+		}
+		if err := g.seal(); err != nil {
+			return nil, err
+		}
+		return &Report{Created: true, UpdatedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(manifest.Salt)
+	if err != nil || len(salt) == 0 {
+		salt = nil
+	}
+	if err := g.derive(password, salt); err != nil {
+		return nil, err
+	}
+	report, err := g.verify(manifest)
+	if err != nil {
+		return nil, err
+	}
+	g.pending = !report.Clean()
+	return report, nil
+}
+
+// Verify 用解锁时派生的密钥重新校验
+func (g *Guard) Verify() (*Report, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.key == nil {
+		return nil, ErrLocked
+	}
+	manifest, err := g.load()
+	if err != nil {
+		return nil, err
+	}
+	return g.verify(manifest)
+}
+
+// Seal 按当前文件重写清单，作为存储的写入钩子使用；未解锁时返回 ErrLocked，
+// 存在未确认的外部修改时返回 ErrUnverified
+func (g *Guard) Seal() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.key == nil {
+		return ErrLocked
+	}
+	if g.pending {
+		return ErrUnverified
+	}
+	return g.seal()
+}
+
+// Accept 用户确认外部修改后，以当前文件为新的基线
+func (g *Guard) Accept() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.key == nil {
+		return ErrLocked
+	}
+	if err := g.seal(); err != nil {
+		return err
+	}
+	g.pending = false
+	return nil
+}
+
+// Reset 用新的盐重建清单后立即清除密钥，用于创建或恢复钱包之后（此时钱包仍是锁定的）
+func (g *Guard) Reset(password string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.derive(password, nil); err != nil {
+		return err
+	}
+	defer g.wipe()
+	return g.seal()
+}
+
+// Lock 清除内存中的密钥
+func (g *Guard) Lock() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.wipe()
+}
+
+func (g *Guard) wipe() {
+	for i := range g.key {
+		g.key[i] = 0
+	}
+	g.key, g.pending = nil, false
+}
+
+// derive 派生 HMAC 密钥，salt 为空时生成新的盐
+func (g *Guard) derive(password string, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	key, err := crypto.NewScryptKDF().DeriveKey("slowmade-integrity:"+password, salt)
+	if err != nil {
+		return err
+	}
+	g.wipe()
+	g.key, g.salt = key, salt
+	return nil
+}
+
+func (g *Guard) verify(manifest *Manifest) (*Report, error) {
+	report := &Report{UpdatedAt: manifest.UpdatedAt}
+	expected := g.mac(manifest)
+	actual, err := hex.DecodeString(manifest.MAC)
+	if err != nil || !hmac.Equal(expected, actual) {
+		report.BadMAC = true
+	}
+	current, err := g.hashFiles()
+	if err != nil {
+		return nil, err
+	}
+	for name, sum := range current {
+		recorded, ok := manifest.Files[name]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, name)
+		case recorded != sum:
+			report.Modified = append(report.Modified, name)
+		}
+	}
+	for name := range manifest.Files {
+		if _, ok := current[name]; !ok {
+			report.Removed = append(report.Removed, name)
+		}
+	}
+	sort.Strings(report.Modified)
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	return report, nil
+}
+
+func (g *Guard) seal() error {
+	files, err := g.hashFiles()
+	if err != nil {
+		return err
+	}
+	manifest := &Manifest{
+		Version:   1,
+		Salt:      hex.EncodeToString(g.salt),
+		Files:     files,
+		UpdatedAt: time.Now().UTC(),
+	}
+	manifest.MAC = hex.EncodeToString(g.mac(manifest))
+
+	data, err := canonjson.MarshalIndent(manifest, "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(g.baseDir, ManifestFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (g *Guard) load() (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(g.baseDir, ManifestFileName))
+	if os.IsNotExist(err) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析完整性清单失败: %w", err)
+	}
+	return &manifest, nil
+}
+
+// mac 对清单的版本、盐、时间和排序后的文件列表计算 HMAC-SHA256
+func (g *Guard) mac(manifest *Manifest) []byte {
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := hmac.New(sha256.New, g.key)
+	fmt.Fprintf(h, "v%d\n%s\n%s\n", manifest.Version, manifest.Salt, manifest.UpdatedAt.UTC().Format(time.RFC3339Nano))
+	for _, name := range names {
+		fmt.Fprintf(h, "%s %s\n", name, manifest.Files[name])
+	}
+	return h.Sum(nil)
+}
+
+// hashFiles 计算受保护目录下所有文件的 SHA-256，跳过临时文件
+func (g *Guard) hashFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range TrackedDirs {
+		root := filepath.Join(g.baseDir, dir)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || strings.HasSuffix(path, ".tmp") || d.Name() == ".healthcheck" {
+				return nil
+			}
+			sum, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(g.baseDir, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = sum
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,231 @@
+// Package keyusage 按签名密钥统计签名次数、常见金额、时段和收款方。签名请求明显偏离历史习惯
+// （金额远超常见金额、平时不签名的时段、从未付过款的收款方）时给出原因，由调用方要求额外确认并记录异常事件
+package keyusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// FileName 签名统计在数据目录中的文件名
+const FileName = "key_usage.json"
+
+// Detected 签名请求偏离历史习惯时发布的事件类型
+const Detected = "signing.anomaly"
+
+// maxAmounts 每个密钥保留的最近金额数，常见金额取它们的中位数
+const maxAmounts = 50
+
+// Thresholds 判断异常的阈值
+type Thresholds struct {
+	AmountFactor  int64 // 金额达到常见金额的这个倍数时视为异常
+	MinSignatures int   // 签名次数达到这个数之前没有足够的历史，不做判断
+}
+
+// Input 一次签名：Key 为 ETH 地址或 BTC 账户 ID，消息签名没有金额和收款方
+type Input struct {
+	Key          string
+	Coin         string
+	Amount       *big.Rat // 不含找零和转给本钱包的部分
+	Destinations []string // 本钱包以外的收款地址
+	At           time.Time
+}
+
+// Profile 一个密钥的签名历史
+type Profile struct {
+	Coin         string         `json:"coin"`
+	Count        int            `json:"count"`
+	Amounts      []string       `json:"amounts,omitempty"` // 最近的非零金额，有理数字符串
+	Hours        [24]int        `json:"hours"`             // 按本地时间各小时的签名次数
+	Destinations map[string]int `json:"destinations,omitempty"`
+	First        time.Time      `json:"first"`
+	Last         time.Time      `json:"last"`
+}
+
+// TypicalAmount 最近金额的中位数，没有金额时返回 nil
+func (p *Profile) TypicalAmount() *big.Rat {
+	amounts := make([]*big.Rat, 0, len(p.Amounts))
+	for _, value := range p.Amounts {
+		if amount, ok := new(big.Rat).SetString(value); ok {
+			amounts = append(amounts, amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return nil
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].Cmp(amounts[j]) < 0 })
+	return amounts[len(amounts)/2]
+}
+
+// ActiveHours 有过签名的小时，如 "9-12, 20"
+func (p *Profile) ActiveHours() string {
+	var ranges []string
+	for start := 0; start < 24; start++ {
+		if p.Hours[start] == 0 {
+			continue
+		}
+		end := start
+		for end+1 < 24 && p.Hours[end+1] > 0 {
+			end++
+		}
+		if end == start {
+			ranges = append(ranges, fmt.Sprint(start))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", start, end))
+		}
+		start = end
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// usualHour 该小时或相邻小时签过名
+func (p *Profile) usualHour(hour int) bool {
+	for _, h := range []int{hour + 23, hour, hour + 1} {
+		if p.Hours[h%24] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Anomaly 偏离历史习惯的签名请求，作为 signing.anomaly 事件的数据
+type Anomaly struct {
+	Key     string    `json:"key"`
+	Coin    string    `json:"coin"`
+	Method  string    `json:"method"`
+	Reasons []string  `json:"reasons"`
+	At      time.Time `json:"at"`
+}
+
+// Describe 实现 events.Describer
+func (a *Anomaly) Describe() (string, string) {
+	return "Unusual signing request", fmt.Sprintf("%s %s for %s: %s", a.Coin, a.Method, a.Key, strings.Join(a.Reasons, "; "))
+}
+
+// Stats 全部密钥的签名统计
+type Stats struct {
+	mu         sync.Mutex
+	path       string
+	thresholds Thresholds
+	Keys       map[string]*Profile `json:"keys"`
+}
+
+// Load 加载签名统计，文件不存在时返回空统计
+func Load(path string, thresholds Thresholds) (*Stats, error) {
+	s := &Stats{path: path, thresholds: thresholds, Keys: make(map[string]*Profile)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解码签名统计失败: %w", err)
+	}
+	if s.Keys == nil {
+		s.Keys = make(map[string]*Profile)
+	}
+	return s, nil
+}
+
+// Check 对比签名请求和密钥的历史，返回异常原因；历史不足 MinSignatures 次时不判断
+func (s *Stats) Check(in Input) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.Keys[normalize(in.Key)]
+	if !ok || p.Count < s.thresholds.MinSignatures {
+		return nil
+	}
+	var reasons []string
+	if typical := p.TypicalAmount(); typical != nil && in.Amount != nil && s.thresholds.AmountFactor > 0 {
+		limit := new(big.Rat).Mul(typical, new(big.Rat).SetInt64(s.thresholds.AmountFactor))
+		if in.Amount.Cmp(limit) >= 0 {
+			reasons = append(reasons, fmt.Sprintf("amount %s %s is at least %dx the typical %s %s",
+				in.Amount.FloatString(8), in.Coin, s.thresholds.AmountFactor, typical.FloatString(8), in.Coin))
+		}
+	}
+	if hour := in.At.Local().Hour(); !p.usualHour(hour) {
+		reasons = append(reasons, fmt.Sprintf("signing at %02d:00, outside the hours this key usually signs (%s)", hour, p.ActiveHours()))
+	}
+	for _, destination := range in.Destinations {
+		if _, ok := p.Destinations[normalize(destination)]; !ok {
+			reasons = append(reasons, fmt.Sprintf("first payment to %s", destination))
+		}
+	}
+	return reasons
+}
+
+// Record 记录一次已批准的签名并保存
+func (s *Stats) Record(in Input) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := normalize(in.Key)
+	p, ok := s.Keys[key]
+	if !ok {
+		p = &Profile{Coin: in.Coin, First: in.At.UTC()}
+		s.Keys[key] = p
+	}
+	p.Count++
+	p.Last = in.At.UTC()
+	p.Hours[in.At.Local().Hour()]++
+	if in.Amount != nil && in.Amount.Sign() > 0 {
+		p.Amounts = append(p.Amounts, in.Amount.RatString())
+		if len(p.Amounts) > maxAmounts {
+			p.Amounts = p.Amounts[len(p.Amounts)-maxAmounts:]
+		}
+	}
+	for _, destination := range in.Destinations {
+		if p.Destinations == nil {
+			p.Destinations = make(map[string]int)
+		}
+		p.Destinations[normalize(destination)]++
+	}
+	return s.save()
+}
+
+// Sorted 按密钥排序的统计
+func (s *Stats) Sorted() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.Keys))
+	for key := range s.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Stats) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return fmt.Errorf("编码签名统计失败: %w", err)
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入签名统计失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名签名统计文件失败: %w", err)
+	}
+	return nil
+}
+
+// normalize ETH 地址不区分大小写
+func normalize(key string) string {
+	if strings.HasPrefix(key, "0x") || strings.HasPrefix(key, "0X") {
+		return strings.ToLower(key)
+	}
+	return key
+}
@@ -0,0 +1,92 @@
+package lanpair
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// DevicesFile 配对过的设备在数据目录中的文件名
+const DevicesFile = "paired_devices.json"
+
+// Device 配对过的设备，用它的客户端证书公钥识别
+type Device struct {
+	Name        string     `json:"name"`
+	Fingerprint string     `json:"fingerprint"` // 客户端证书 SubjectPublicKeyInfo 的 sha256，十六进制
+	PairedAt    time.Time  `json:"paired_at"`
+	LastSent    *time.Time `json:"last_sent,omitempty"`
+	LastKind    Kind       `json:"last_kind,omitempty"`
+}
+
+// ID 设备的短 ID，指纹的前 8 位
+func (d Device) ID() string {
+	return d.Fingerprint[:8]
+}
+
+// LoadDevices 读取配对过的设备，按配对时间排序；没有配对过时返回空列表
+func LoadDevices(baseDir string) ([]Device, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, DevicesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var devices []Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("解码配对设备失败: %w", err)
+	}
+	for _, device := range devices {
+		if len(device.Fingerprint) != 64 {
+			return nil, fmt.Errorf("%s: invalid fingerprint for device %q", DevicesFile, device.Name)
+		}
+	}
+	sort.SliceStable(devices, func(i, j int) bool { return devices[i].PairedAt.Before(devices[j].PairedAt) })
+	return devices, nil
+}
+
+// SaveDevices 写入配对过的设备
+func SaveDevices(baseDir string, devices []Device) error {
+	if devices == nil {
+		devices = []Device{}
+	}
+	data, err := canonjson.MarshalIndent(devices, "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, DevicesFile)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入配对设备失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名配对设备文件失败: %w", err)
+	}
+	return nil
+}
+
+// FindDevice 按名称（不区分大小写）或指纹前缀查找设备，返回它在列表中的位置
+func FindDevice(devices []Device, ref string) (int, error) {
+	found := -1
+	for i, device := range devices {
+		if strings.EqualFold(device.Name, ref) {
+			return i, nil
+		}
+		if len(ref) >= 4 && strings.HasPrefix(device.Fingerprint, strings.ToLower(ref)) {
+			if found >= 0 {
+				return -1, fmt.Errorf("%w: %s", ErrAmbiguousDevice, ref)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("%w: %s", ErrDeviceNotFound, ref)
+	}
+	return found, nil
+}
@@ -0,0 +1,475 @@
+// Package lanpair 与手机配套应用的局域网配对和传输：钱包在本地网络地址上临时监听，
+// 二维码带上临时证书的指纹和一次性密钥；双方用 TLS 1.3 互相认证（钱包证书按二维码中的指纹固定，
+// 手机证书在配对时登记），只接受私有网络、链路本地和回环地址的连接，数据不经过互联网
+package lanpair
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 协议常量
+const (
+	Version     = 1
+	PairScheme  = "slowmade-pair"
+	SendScheme  = "slowmade-send"
+	PairPath    = "/v1/pair"
+	PayloadPath = "/v1/payload"
+
+	certLifetime   = time.Hour
+	tokenSize      = 16
+	maxNameLength  = 64
+	maxRequestBody = 4096
+)
+
+// Kind 发送给设备的内容
+type Kind string
+
+const (
+	KindBackup    Kind = "backup"     // 与 backup.qr 相同的加密备份包，用钱包密码加密
+	KindWatchOnly Kind = "watch-only" // 账户的扩展公钥和描述符 JSON，不含私密材料
+)
+
+// 错误定义
+var (
+	ErrNotLocal        = errors.New("not a private, link-local or loopback address")
+	ErrNoLocalAddress  = errors.New("no private network address found, set pairing.listen")
+	ErrDeviceNotFound  = errors.New("paired device not found")
+	ErrAmbiguousDevice = errors.New("more than one paired device matches")
+	ErrDeviceExists    = errors.New("this device is already paired")
+	ErrWrongSecret     = errors.New("the device sent a wrong pairing secret")
+	ErrRejected        = errors.New("pairing rejected")
+	ErrTimeout         = errors.New("no device connected in time")
+)
+
+// Offer 二维码中的会话信息，手机扫描后连接 Address，并只接受指纹为 Fingerprint 的证书
+type Offer struct {
+	Scheme      string
+	Address     string
+	Fingerprint string // 钱包临时证书 SubjectPublicKeyInfo 的 sha256
+	Token       string // 一次性密钥，base64url
+	Kind        Kind   // 发送会话的内容
+}
+
+// URI 编入二维码的文本，如 slowmade-pair:?v=1&addr=192.168.1.5:40001&fp=...&token=...
+func (o Offer) URI() string {
+	query := url.Values{}
+	query.Set("v", strconv.Itoa(Version))
+	query.Set("addr", o.Address)
+	query.Set("fp", o.Fingerprint)
+	query.Set("token", o.Token)
+	if o.Kind != "" {
+		query.Set("kind", string(o.Kind))
+	}
+	return o.Scheme + ":?" + query.Encode()
+}
+
+// PairRequest 手机发送的配对请求
+type PairRequest struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+// PairResponse 配对成功后返回给手机
+type PairResponse struct {
+	Wallet   string `json:"wallet"`
+	DeviceID string `json:"device_id"`
+}
+
+// Fingerprint 证书 SubjectPublicKeyInfo 的 sha256，十六进制；手机更换证书但保留密钥时不变
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// Code 配对确认码：双方由两个证书指纹和一次性密钥算出同样的 6 位数字，由用户比对两个屏幕上的显示
+func Code(walletFingerprint, deviceFingerprint, token string) string {
+	sum := sha256.Sum256([]byte(PairScheme + "\x00" + walletFingerprint + "\x00" + deviceFingerprint + "\x00" + token))
+	n := binary.BigEndian.Uint32(sum[:4]) % 1000000
+	return fmt.Sprintf("%03d %03d", n/1000, n%1000)
+}
+
+// IsLocal 地址是否在本地网络中：私有网络、链路本地或回环
+func IsLocal(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLoopback()
+}
+
+// ListenAddress 检查配置的监听地址必须在本地网络中，为空时使用第一个私有网络 IPv4 地址和随机端口
+func ListenAddress(address string) (string, error) {
+	if address == "" {
+		interfaces, err := net.Interfaces()
+		if err != nil {
+			return "", err
+		}
+		for _, iface := range interfaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			addrs, err := iface.Addrs()
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && ipNet.IP.IsPrivate() {
+					return net.JoinHostPort(ipNet.IP.String(), "0"), nil
+				}
+			}
+		}
+		return "", ErrNoLocalAddress
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("pairing.listen: %w", err)
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsLocal(ip) {
+		return "", fmt.Errorf("pairing.listen %s: %w", host, ErrNotLocal)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// localListener 丢弃来自本地网络以外的连接
+type localListener struct {
+	net.Listener
+}
+
+func (l localListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && IsLocal(addr.IP) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// session 一次性的 TLS 监听：只处理一个请求，结果由 done 传回
+type session struct {
+	server   *http.Server
+	offer    Offer
+	once     sync.Once
+	done     chan error
+	shutdown sync.Once
+}
+
+// listen 用临时证书在本地网络地址上监听，verify 检查手机客户端证书的指纹
+func listen(address, scheme string, verify func(fingerprint string) error, handler http.Handler) (*session, error) {
+	address, err := ListenAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	cert, fingerprint, err := newCertificate()
+	if err != nil {
+		return nil, err
+	}
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	raw, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			client, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			return verify(Fingerprint(client))
+		},
+	}
+	s := &session{
+		// 握手失败（未配对的证书、扫描器）不打印到终端
+		server: &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second, ErrorLog: log.New(io.Discard, "", 0)},
+		offer: Offer{Scheme: scheme, Address: raw.Addr().String(), Fingerprint: fingerprint,
+			Token: base64.RawURLEncoding.EncodeToString(token)},
+		done: make(chan error, 1),
+	}
+	go s.server.Serve(tls.NewListener(localListener{raw}, tlsConfig))
+	return s, nil
+}
+
+// claim 第一个请求占用会话，之后的请求被拒绝
+func (s *session) claim() bool {
+	claimed := false
+	s.once.Do(func() { claimed = true })
+	return claimed
+}
+
+// finish 记录会话结果
+func (s *session) finish(err error) {
+	select {
+	case s.done <- err:
+	default:
+	}
+}
+
+// wait 等待会话结束，ctx 结束时返回 ErrTimeout 或 ctx 的错误
+func (s *session) wait(ctx context.Context) error {
+	select {
+	case err := <-s.done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrTimeout
+		}
+		return ctx.Err()
+	}
+}
+
+// Close 停止监听，等待正在写的响应发完
+func (s *session) Close() {
+	s.shutdown.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.server.Shutdown(ctx)
+	})
+}
+
+// Offer 二维码中的会话信息
+func (s *session) Offer() Offer {
+	return s.offer
+}
+
+// tokenMatches 常数时间比较一次性密钥
+func (s *session) tokenMatches(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.offer.Token)) == 1
+}
+
+// clientFingerprint 请求的客户端证书指纹
+func clientFingerprint(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	return Fingerprint(r.TLS.PeerCertificates[0])
+}
+
+// newCertificate 生成一小时有效的自签名 Ed25519 证书
+func newCertificate() (tls.Certificate, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "slowmade"},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(certLifetime),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, public, private)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: private, Leaf: leaf}, Fingerprint(leaf), nil
+}
+
+// Pairing 配对会话：手机扫描二维码后用自己的证书连接并发送名称和一次性密钥，
+// 用户在钱包中确认双方显示的确认码一致后登记该设备
+type Pairing struct {
+	*session
+	paired   map[string]bool
+	attempts chan *attempt
+	device   *Device
+}
+
+// attempt 等待用户确认的配对请求
+type attempt struct {
+	name, fingerprint, code string
+	reply                   chan bool
+}
+
+// NewPairing 开始配对会话，paired 中的设备不能重复配对
+func NewPairing(address string, paired []Device) (*Pairing, error) {
+	p := &Pairing{paired: make(map[string]bool), attempts: make(chan *attempt, 1)}
+	for _, device := range paired {
+		p.paired[device.Fingerprint] = true
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PairPath, p.handlePair)
+	s, err := listen(address, PairScheme, func(string) error { return nil }, mux)
+	if err != nil {
+		return nil, err
+	}
+	p.session = s
+	return p, nil
+}
+
+// Wait 等待手机连接，把设备名称和确认码交给 confirm；确认后返回新设备，调用方负责保存
+func (p *Pairing) Wait(ctx context.Context, confirm func(name, code string) bool) (*Device, error) {
+	for {
+		select {
+		case a := <-p.attempts:
+			a.reply <- confirm(a.name, a.code)
+		case err := <-p.done:
+			if err != nil {
+				return nil, err
+			}
+			return p.device, nil
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ErrTimeout
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *Pairing) handlePair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !p.claim() {
+		http.Error(w, "this pairing code was already used", http.StatusGone)
+		return
+	}
+	var request PairRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		p.finish(fmt.Errorf("invalid pairing request: %w", err))
+		return
+	}
+	if !p.tokenMatches(request.Token) {
+		http.Error(w, ErrWrongSecret.Error(), http.StatusForbidden)
+		p.finish(ErrWrongSecret)
+		return
+	}
+	fingerprint := clientFingerprint(r)
+	if p.paired[fingerprint] {
+		http.Error(w, ErrDeviceExists.Error(), http.StatusConflict)
+		p.finish(fmt.Errorf("%w (%s)", ErrDeviceExists, fingerprint[:8]))
+		return
+	}
+
+	a := &attempt{name: deviceName(request.Name, fingerprint), fingerprint: fingerprint,
+		code: Code(p.offer.Fingerprint, fingerprint, p.offer.Token), reply: make(chan bool, 1)}
+	p.attempts <- a
+	select {
+	case accepted := <-a.reply:
+		if !accepted {
+			http.Error(w, ErrRejected.Error(), http.StatusForbidden)
+			p.finish(ErrRejected)
+			return
+		}
+	case <-r.Context().Done():
+		p.finish(fmt.Errorf("the device disconnected before the pairing was confirmed"))
+		return
+	}
+	p.device = &Device{Name: a.name, Fingerprint: fingerprint, PairedAt: time.Now().UTC()}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PairResponse{Wallet: "slowmade", DeviceID: p.device.ID()})
+	p.finish(nil)
+}
+
+// deviceName 清理手机报告的名称：去掉控制字符，限制长度，为空时用设备 ID
+func deviceName(name, fingerprint string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	if name == "" {
+		name = "device-" + fingerprint[:8]
+	}
+	return name
+}
+
+// Transfer 发送会话：只有配对过的设备能完成 TLS 握手，凭二维码中的一次性密钥取走一次内容
+type Transfer struct {
+	*session
+	device      Device
+	payload     []byte
+	contentType string
+}
+
+// NewTransfer 开始向设备发送内容的会话
+func NewTransfer(address string, device Device, kind Kind, payload []byte, contentType string) (*Transfer, error) {
+	t := &Transfer{device: device, payload: payload, contentType: contentType}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PayloadPath, t.handlePayload)
+	verify := func(fingerprint string) error {
+		if fingerprint != device.Fingerprint {
+			return fmt.Errorf("%w: %s", ErrDeviceNotFound, fingerprint[:8])
+		}
+		return nil
+	}
+	s, err := listen(address, SendScheme, verify, mux)
+	if err != nil {
+		return nil, err
+	}
+	s.offer.Kind = kind
+	t.session = s
+	return t, nil
+}
+
+// Wait 等待设备取走内容
+func (t *Transfer) Wait(ctx context.Context) error {
+	return t.wait(ctx)
+}
+
+func (t *Transfer) handlePayload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !t.tokenMatches(r.URL.Query().Get("token")) {
+		http.Error(w, "wrong or expired session", http.StatusForbidden)
+		return
+	}
+	if !t.claim() {
+		http.Error(w, "already sent", http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", t.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(t.payload)))
+	w.Header().Set("X-Slowmade-Kind", string(t.offer.Kind))
+	if _, err := w.Write(t.payload); err != nil {
+		t.finish(fmt.Errorf("sending to %s: %w", t.device.Name, err))
+		return
+	}
+	t.finish(nil)
+}
@@ -0,0 +1,421 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// 事件日志和快照的文件名，与 metadata.json 在同一目录
+const (
+	EventsFileName    = "metadata_events.jsonl"   // 每行一个事件，只追加
+	SnapshotsFileName = "metadata_snapshots.json" // 定期保存的全部条目，回放从最近的快照开始
+)
+
+// snapshotInterval 每隔多少个事件保存一次快照
+const snapshotInterval = 100
+
+// 错误定义
+var (
+	ErrNoHistory     = errors.New("no metadata history recorded yet")
+	ErrBeforeHistory = errors.New("metadata history starts later")
+	ErrTampered      = errors.New("metadata event log was modified")
+)
+
+// Event 事件日志中的一条记录：一条命令产生的全部修改。Hash 覆盖前一条事件的 Hash，
+// 日志中任何一条被改动、删除或插入都会使之后的校验失败
+type Event struct {
+	Operation
+	Undoes int    `json:"undoes,omitempty"` // 撤销的操作 ID
+	Prev   string `json:"prev"`
+	Hash   string `json:"hash"`
+}
+
+// digest 事件内容和前一条事件哈希的 sha256
+func (e Event) digest() (string, error) {
+	unsigned := e
+	unsigned.Hash = ""
+	data, err := canonjson.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Snapshot 某个事件之后的全部条目
+type Snapshot struct {
+	After   int                        `json:"after"` // 已包含的最后一个事件 ID
+	Time    time.Time                  `json:"time"`
+	Hash    string                     `json:"hash"` // 该事件的哈希，之后的事件从这里接续
+	Entries map[Kind]map[string]string `json:"entries"`
+}
+
+func (s *Store) eventsPath() string {
+	return filepath.Join(filepath.Dir(s.path), EventsFileName)
+}
+
+func (s *Store) snapshotsPath() string {
+	return filepath.Join(filepath.Dir(s.path), SnapshotsFileName)
+}
+
+// ensureLog 第一次记录事件前把当前条目存为起点快照，之前的修改只保留结果
+func (s *Store) ensureLog() error {
+	if _, err := os.Stat(s.eventsPath()); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+	if _, err := os.Stat(s.snapshotsPath()); err == nil {
+		return nil
+	}
+	s.LastHash, s.EventsSize = "", 0
+	genesis := Snapshot{After: s.NextID - 1, Time: time.Now().UTC(), Entries: copyEntries(s.Entries)}
+	return writeJSON(s.snapshotsPath(), []Snapshot{genesis})
+}
+
+// record 把操作追加到事件日志并同步到磁盘，返回撤回这次追加的函数；内存中的存储不记录
+func (s *Store) record(op Operation, undoes int) (func(), error) {
+	if s.path == "" {
+		return func() {}, nil
+	}
+	if err := s.ensureLog(); err != nil {
+		return nil, err
+	}
+	event := Event{Operation: op, Undoes: undoes, Prev: s.LastHash}
+	hash, err := event.digest()
+	if err != nil {
+		return nil, err
+	}
+	event.Hash = hash
+	line, err := canonjson.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(s.eventsPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		os.Truncate(s.eventsPath(), size)
+		return nil, fmt.Errorf("写入元数据事件失败: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("写入元数据事件失败: %w", err)
+	}
+	previousHash, previousSize := s.LastHash, s.EventsSize
+	s.LastHash, s.EventsSize = hash, size+int64(len(line))+1
+	return func() {
+		os.Truncate(s.eventsPath(), size)
+		s.LastHash, s.EventsSize = previousHash, previousSize
+	}, nil
+}
+
+// maybeSnapshot 每 snapshotInterval 个事件保存一次快照；快照只用于加快回放，写入失败不影响已保存的修改
+func (s *Store) maybeSnapshot(op Operation) {
+	if s.path == "" || op.ID%snapshotInterval != 0 {
+		return
+	}
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return
+	}
+	snapshots = append(snapshots, Snapshot{After: op.ID, Time: op.Time, Hash: s.LastHash, Entries: copyEntries(s.Entries)})
+	writeJSON(s.snapshotsPath(), snapshots)
+}
+
+// catchUp 重放 metadata.json 保存之后追加的事件（例如写入事件后、保存前进程退出），只修改内存；
+// metadata.json 丢失时从最早的快照开始重放全部事件
+func (s *Store) catchUp() error {
+	file, err := os.Open(s.eventsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.Size() <= s.EventsSize {
+		return err
+	}
+	if s.EventsSize == 0 && s.NextID == 1 {
+		snapshots, err := s.Snapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) > 0 {
+			s.Entries = copyEntries(snapshots[0].Entries)
+			s.NextID, s.LastHash = snapshots[0].After+1, snapshots[0].Hash
+		}
+	}
+	if _, err := file.Seek(s.EventsSize, io.SeekStart); err != nil {
+		return err
+	}
+	events, err := readEvents(file)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.ID < s.NextID {
+			continue
+		}
+		for _, c := range event.Changes {
+			s.put(c.Kind, c.Key, c.After)
+		}
+		if event.Undoes > 0 {
+			for i, op := range s.Journal {
+				if op.ID == event.Undoes {
+					s.Journal = append(s.Journal[:i:i], s.Journal[i+1:]...)
+					break
+				}
+			}
+		} else {
+			s.Journal = append(s.Journal, event.Operation)
+		}
+		s.NextID, s.LastHash = event.ID+1, event.Hash
+	}
+	if len(s.Journal) > journalLimit {
+		s.Journal = s.Journal[len(s.Journal)-journalLimit:]
+	}
+	s.EventsSize = info.Size()
+	return nil
+}
+
+// Events 事件日志中的全部事件，最早的在前；没有日志时返回空列表
+func (s *Store) Events() ([]Event, error) {
+	file, err := os.Open(s.eventsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	return readEvents(file)
+}
+
+// Snapshots 保存的快照，最早的在前
+func (s *Store) Snapshots() ([]Snapshot, error) {
+	data, err := os.ReadFile(s.snapshotsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []Snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("解码元数据快照失败: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Verify 校验事件日志的哈希链从最早的快照接续到最后一条事件，返回事件数
+func (s *Store) Verify() (int, error) {
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return 0, err
+	}
+	if len(snapshots) == 0 {
+		return 0, ErrNoHistory
+	}
+	events, err := s.Events()
+	if err != nil {
+		return 0, err
+	}
+	previous, next := snapshots[0].Hash, snapshots[0].After+1
+	for _, event := range events {
+		hash, err := event.digest()
+		if err != nil {
+			return 0, err
+		}
+		if event.Prev != previous || event.Hash != hash || event.ID < next {
+			return 0, fmt.Errorf("%w at event #%d", ErrTampered, event.ID)
+		}
+		previous, next = event.Hash, event.ID+1
+	}
+	return len(events), nil
+}
+
+// At 某一时刻的全部条目：从该时刻之前最近的快照开始，重放到该时刻为止的事件
+func (s *Store) At(t time.Time) (map[Kind]map[string]string, error) {
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, ErrNoHistory
+	}
+	start := -1
+	for i, snapshot := range snapshots {
+		if !snapshot.Time.After(t) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("%w, at %s", ErrBeforeHistory, snapshots[0].Time.Format(time.RFC3339))
+	}
+	events, err := s.Events()
+	if err != nil {
+		return nil, err
+	}
+	view := copyEntries(snapshots[start].Entries)
+	for _, event := range events {
+		if event.ID <= snapshots[start].After {
+			continue
+		}
+		if event.Time.After(t) {
+			break
+		}
+		applyChanges(view, event.Changes)
+	}
+	return view, nil
+}
+
+// CompactResult 压缩的结果
+type CompactResult struct {
+	Removed   int       // 并入快照的事件数
+	Remaining int       // 日志中剩下的事件数
+	Snapshots int       // 剩下的快照数
+	Since     time.Time // 之后仍可查看任意时刻的条目
+}
+
+// Compact 把 before 之前的事件并入一个快照，从日志中删去，并删去更早的快照；
+// 之后的事件和哈希链不变，只是不能再查看 before 之前的时刻
+func (s *Store) Compact(before time.Time) (*CompactResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, ErrNoHistory
+	}
+	events, err := s.Events()
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for n < len(events) && events[n].Time.Before(before) {
+		n++
+	}
+	result := &CompactResult{Remaining: len(events) - n}
+	if n == 0 {
+		result.Snapshots, result.Since = len(snapshots), snapshots[0].Time
+		return result, nil
+	}
+
+	// 按 ID 而不是时间重放，同一时刻的后续事件不会混进快照
+	last := events[n-1]
+	view := copyEntries(snapshots[0].Entries)
+	for _, event := range events[:n] {
+		if event.ID > snapshots[0].After {
+			applyChanges(view, event.Changes)
+		}
+	}
+	kept := []Snapshot{{After: last.ID, Time: last.Time, Hash: last.Hash, Entries: view}}
+	for _, snapshot := range snapshots {
+		if snapshot.After > last.ID {
+			kept = append(kept, snapshot)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events[n:] {
+		line, err := canonjson.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := writeJSON(s.snapshotsPath(), kept); err != nil {
+		return nil, err
+	}
+	if err := writeFile(s.eventsPath(), buf.Bytes()); err != nil {
+		return nil, err
+	}
+	s.EventsSize = int64(buf.Len())
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	result.Removed, result.Snapshots, result.Since = n, len(kept), last.Time
+	return result, nil
+}
+
+// readEvents 逐行解码事件
+func readEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", EventsFileName, line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// applyChanges 把修改应用到条目副本
+func applyChanges(entries map[Kind]map[string]string, changes []Change) {
+	for _, c := range changes {
+		if c.After == nil {
+			delete(entries[c.Kind], c.Key)
+			continue
+		}
+		if entries[c.Kind] == nil {
+			entries[c.Kind] = make(map[string]string)
+		}
+		entries[c.Kind][c.Key] = *c.After
+	}
+}
+
+func copyEntries(entries map[Kind]map[string]string) map[Kind]map[string]string {
+	copied := make(map[Kind]map[string]string, len(entries))
+	for kind, values := range entries {
+		copied[kind] = make(map[string]string, len(values))
+		for key, value := range values {
+			copied[kind][key] = value
+		}
+	}
+	return copied
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := canonjson.MarshalIndent(v, "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+func writeFile(path string, data []byte) error {
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名 %s 失败: %w", filepath.Base(path), err)
+	}
+	return nil
+}
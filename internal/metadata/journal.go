@@ -0,0 +1,127 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// journalLimit 操作日志最多保留的条数
+const journalLimit = 200
+
+// 错误定义
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrUndoConflict  = errors.New("entry was changed by a later operation")
+)
+
+// Change 单个键的修改，Before/After 为 nil 表示修改前不存在或修改后被删除
+type Change struct {
+	Kind   Kind    `json:"kind"`
+	Key    string  `json:"key"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+// Operation 一条命令产生的全部修改
+type Operation struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+}
+
+// Undo 撤销最近一次操作；command 非空时撤销该命令最近一次的操作
+func (s *Store) Undo(command string) (*Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := -1
+	for i := len(s.Journal) - 1; i >= 0; i-- {
+		if command == "" || s.Journal[i].Command == command {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if command != "" {
+			return nil, fmt.Errorf("%w for %s", ErrNothingToUndo, command)
+		}
+		return nil, ErrNothingToUndo
+	}
+
+	// 撤销较早的操作前确认相关条目没有被之后的操作修改
+	op := s.Journal[index]
+	for _, c := range op.Changes {
+		current, ok := s.Entries[c.Kind][c.Key]
+		if ok != (c.After != nil) || (ok && current != *c.After) {
+			return nil, fmt.Errorf("%w: %s %s", ErrUndoConflict, c.Kind, c.Key)
+		}
+	}
+
+	// 撤销也是一个事件：按相反顺序把每个键改回操作前的值
+	undo := Operation{ID: s.NextID, Time: time.Now().UTC(), Command: "undo"}
+	for i := len(op.Changes) - 1; i >= 0; i-- {
+		c := op.Changes[i]
+		undo.Changes = append(undo.Changes, Change{Kind: c.Kind, Key: c.Key, Before: c.After, After: c.Before})
+	}
+	s.revert(op)
+	unrecord, err := s.record(undo, op.ID)
+	if err != nil {
+		s.redo(op)
+		return nil, err
+	}
+	journal := append(append([]Operation(nil), s.Journal[:index]...), s.Journal[index+1:]...)
+	previous := s.Journal
+	s.Journal = journal
+	s.NextID++
+	if err := s.save(); err != nil {
+		s.redo(op)
+		s.Journal = previous
+		s.NextID--
+		unrecord()
+		return nil, err
+	}
+	s.maybeSnapshot(undo)
+	return &op, nil
+}
+
+// redo 重新应用操作后的值
+func (s *Store) redo(op Operation) {
+	for _, c := range op.Changes {
+		s.put(c.Kind, c.Key, c.After)
+	}
+}
+
+// History 返回最近 n 条操作，最新的在前
+func (s *Store) History(n int) []Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Operation
+	for i := len(s.Journal) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, s.Journal[i])
+	}
+	return result
+}
+
+// revert 按相反顺序恢复操作前的值
+func (s *Store) revert(op Operation) {
+	for i := len(op.Changes) - 1; i >= 0; i-- {
+		c := op.Changes[i]
+		s.put(c.Kind, c.Key, c.Before)
+	}
+}
+
+// String 操作的简短描述
+func (c Change) String() string {
+	switch {
+	case c.Before == nil && c.After != nil:
+		return fmt.Sprintf("add %s %s = %q", c.Kind, c.Key, *c.After)
+	case c.Before != nil && c.After == nil:
+		return fmt.Sprintf("remove %s %s (was %q)", c.Kind, c.Key, *c.Before)
+	case c.Before != nil && c.After != nil:
+		return fmt.Sprintf("change %s %s %q -> %q", c.Kind, c.Key, *c.Before, *c.After)
+	default:
+		return fmt.Sprintf("%s %s", c.Kind, c.Key)
+	}
+}
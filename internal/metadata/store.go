@@ -0,0 +1,222 @@
+// Package metadata 管理标签、层级标记、归档、联系人和别名等非密码学元数据。所有修改先追加到只增的事件日志，
+// 再更新 metadata.json 中的当前条目和撤销用的操作日志；事件日志配合定期快照可以查看任意时刻的条目
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// FileName 元数据在数据目录中的文件名
+const FileName = "metadata.json"
+
+// Kind 元数据类别
+type Kind string
+
+const (
+	Labels   Kind = "labels"   // 账户 ID 或地址 -> 标签
+	Archived Kind = "archived" // 已归档的账户 ID -> 归档时间
+	Contacts Kind = "contacts" // 联系人名称 -> 地址
+	Aliases  Kind = "aliases"  // 别名 -> 账户 ID
+	Tags     Kind = "tags"     // 账户 ID 或地址 -> 逗号分隔的层级标签，见 tags.go
+
+	Rotations      Kind = "rotations"       // 已轮换的账户 ID -> 后继账户 ID
+	ChangePolicies Kind = "change_policies" // BTC 账户 ID -> 找零策略，见 btc.ParseChangePolicy
+)
+
+// 错误定义
+var (
+	ErrNotFound = errors.New("metadata entry not found")
+	ErrExists   = errors.New("metadata entry already exists")
+)
+
+// Store 元数据存储，当前条目保存在单个 JSON 文件中，修改历史见 events.go
+type Store struct {
+	mu         sync.Mutex
+	path       string
+	Entries    map[Kind]map[string]string `json:"entries"`
+	Journal    []Operation                `json:"journal"`
+	NextID     int                        `json:"next_id"`
+	LastHash   string                     `json:"last_hash,omitempty"`   // 最后一个已应用事件的哈希
+	EventsSize int64                      `json:"events_size,omitempty"` // 应用到该事件为止的事件日志大小，之后的事件在加载时重放
+}
+
+// Load 加载元数据并重放尚未应用的事件，文件不存在时返回空存储；path 为空时是不保存的内存存储
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Entries: make(map[Kind]map[string]string), NextID: 1}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("解码元数据失败: %w", err)
+		}
+	}
+	if s.Entries == nil {
+		s.Entries = make(map[Kind]map[string]string)
+	}
+	if err := s.catchUp(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 查询单个条目
+func (s *Store) Get(kind Kind, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.Entries[kind][key]
+	return value, ok
+}
+
+// Keys 返回某类别的全部键，按字典序排序
+func (s *Store) Keys(kind Kind) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.Entries[kind]))
+	for key := range s.Entries[kind] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set 新增或修改条目
+func (s *Store) Set(command string, kind Kind, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apply(command, []Change{s.change(kind, key, &value)})
+}
+
+// Delete 删除条目，条目不存在时返回 ErrNotFound
+func (s *Store) Delete(command string, kind Kind, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Entries[kind][key]; !ok {
+		return fmt.Errorf("%w: %s %s", ErrNotFound, kind, key)
+	}
+	return s.apply(command, []Change{s.change(kind, key, nil)})
+}
+
+// Rename 修改条目的键，作为一条操作记录，撤销时一并恢复
+func (s *Store) Rename(command string, kind Kind, oldKey, newKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.Entries[kind][oldKey]
+	if !ok {
+		return fmt.Errorf("%w: %s %s", ErrNotFound, kind, oldKey)
+	}
+	if _, exists := s.Entries[kind][newKey]; exists {
+		return fmt.Errorf("%w: %s %s", ErrExists, kind, newKey)
+	}
+	return s.apply(command, []Change{s.change(kind, oldKey, nil), s.change(kind, newKey, &value)})
+}
+
+// Export 复制若干类别的全部条目
+func (s *Store) Export(kinds ...Kind) map[Kind]map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[Kind]map[string]string, len(kinds))
+	for _, kind := range kinds {
+		entries := make(map[string]string, len(s.Entries[kind]))
+		for key, value := range s.Entries[kind] {
+			entries[key] = value
+		}
+		result[kind] = entries
+	}
+	return result
+}
+
+// Merge 把一组修改作为一条操作写入，只使用 Kind、Key 和 After（nil 表示删除），Before 按当前值记录
+func (s *Store) Merge(command string, updates []Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(updates) == 0 {
+		return nil
+	}
+	changes := make([]Change, 0, len(updates))
+	for _, u := range updates {
+		changes = append(changes, s.change(u.Kind, u.Key, u.After))
+	}
+	return s.apply(command, changes)
+}
+
+// change 记录键的修改前后值，after 为 nil 表示删除
+func (s *Store) change(kind Kind, key string, after *string) Change {
+	c := Change{Kind: kind, Key: key, After: after}
+	if before, ok := s.Entries[kind][key]; ok {
+		c.Before = &before
+	}
+	return c
+}
+
+// apply 执行修改，先追加到事件日志，再写入操作日志并保存；任何一步失败时回滚内存中的修改和追加的事件
+func (s *Store) apply(command string, changes []Change) error {
+	if s.path != "" {
+		if err := s.ensureLog(); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		s.put(c.Kind, c.Key, c.After)
+	}
+	op := Operation{ID: s.NextID, Time: time.Now().UTC(), Command: command, Changes: changes}
+	unrecord, err := s.record(op, 0)
+	if err != nil {
+		s.revert(op)
+		return err
+	}
+	previous := s.Journal
+	journal := append(append([]Operation(nil), s.Journal...), op)
+	if len(journal) > journalLimit {
+		journal = journal[len(journal)-journalLimit:]
+	}
+	s.Journal = journal
+	s.NextID++
+	if err := s.save(); err != nil {
+		s.revert(op)
+		s.Journal = previous
+		s.NextID--
+		unrecord()
+		return err
+	}
+	s.maybeSnapshot(op)
+	return nil
+}
+
+func (s *Store) put(kind Kind, key string, value *string) {
+	if value == nil {
+		delete(s.Entries[kind], key)
+		return
+	}
+	if s.Entries[kind] == nil {
+		s.Entries[kind] = make(map[string]string)
+	}
+	s.Entries[kind][key] = *value
+}
+
+func (s *Store) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入元数据失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名元数据失败: %w", err)
+	}
+	return nil
+}
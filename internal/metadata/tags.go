@@ -0,0 +1,159 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidTag 标记格式不正确
+var ErrInvalidTag = errors.New("invalid tag")
+
+// TagSeparator 层级标记的分隔符，如 clients/acme
+const TagSeparator = "/"
+
+// NormalizeTag 规范化标记：去掉首尾空白和分隔符并转为小写；各级不能为空，不能包含空白或逗号
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.Trim(strings.TrimSpace(tag), TagSeparator))
+	if normalized == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidTag)
+	}
+	for _, segment := range strings.Split(normalized, TagSeparator) {
+		if segment == "" || strings.ContainsAny(segment, ", \t\n") {
+			return "", fmt.Errorf("%w %q: levels are separated by a single / and cannot contain spaces or commas", ErrInvalidTag, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// MatchTag 标记是否等于 filter 或在 filter 之下，clients 匹配 clients 和 clients/acme，不匹配 clientsx
+func MatchTag(tag, filter string) bool {
+	return tag == filter || strings.HasPrefix(tag, filter+TagSeparator)
+}
+
+// Tags 返回账户 ID 或地址的标记，按字典序排序
+func (s *Store) Tags(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return splitTags(s.Entries[Tags][key])
+}
+
+// HasTag 账户 ID 或地址是否有匹配 filter 的标记，filter 应已规范化
+func (s *Store) HasTag(key, filter string) bool {
+	for _, tag := range s.Tags(key) {
+		if MatchTag(tag, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tagged 返回有匹配 filter 的标记的全部账户 ID 和地址，按字典序排序
+func (s *Store) Tagged(filter string) []string {
+	var keys []string
+	for _, key := range s.Keys(Tags) {
+		if s.HasTag(key, filter) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// TagCounts 每个标记及其各级上级被多少个账户和地址使用，clients/acme 同时计入 clients
+func (s *Store) TagCounts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, value := range s.Entries[Tags] {
+		seen := make(map[string]bool)
+		for _, tag := range splitTags(value) {
+			segments := strings.Split(tag, TagSeparator)
+			for i := range segments {
+				seen[strings.Join(segments[:i+1], TagSeparator)] = true
+			}
+		}
+		for tag := range seen {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// AddTags 为账户 ID 或地址添加标记，已有的标记忽略；返回规范化后的全部标记
+func (s *Store) AddTags(command, key string, tags ...string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := splitTags(s.Entries[Tags][key])
+	for _, tag := range tags {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, normalized)
+	}
+	result := joinTags(current)
+	if before, ok := s.Entries[Tags][key]; ok && before == result {
+		return splitTags(result), nil
+	}
+	if err := s.apply(command, []Change{s.change(Tags, key, &result)}); err != nil {
+		return nil, err
+	}
+	return splitTags(result), nil
+}
+
+// RemoveTags 删除账户 ID 或地址的标记（只删除完全相同的标记，不删除下级），删除最后一个标记时删除条目；
+// 一个都没有删除时返回 ErrNotFound
+func (s *Store) RemoveTags(command, key string, tags ...string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		remove[normalized] = true
+	}
+	var kept []string
+	current := splitTags(s.Entries[Tags][key])
+	for _, tag := range current {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == len(current) {
+		return nil, fmt.Errorf("%w: %s has no tag %s", ErrNotFound, key, strings.Join(tags, ", "))
+	}
+	var after *string
+	if len(kept) > 0 {
+		value := joinTags(kept)
+		after = &value
+	}
+	if err := s.apply(command, []Change{s.change(Tags, key, after)}); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// splitTags 解析保存的标记
+func splitTags(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// joinTags 去重、排序后保存为逗号分隔的字符串
+func joinTags(tags []string) string {
+	unique := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !unique[tag] {
+			unique[tag] = true
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
@@ -0,0 +1,206 @@
+// Package metasync 只同步标签、标记、联系人和别名：元数据打包后用同步密码加密，经云同步后端或文件在机器之间交换，
+// 不包含任何钱包、账户或地址文件。导入时与上次同步的基线做三方合并，两边都改过的条目交给调用方决定
+package metasync
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// ObjectName 元数据包在同步后端中的对象名
+const ObjectName = "metadata.bundle"
+
+// BaseFileName 上次同步后的元数据基线在数据目录中的文件名
+const BaseFileName = "metadata_sync.json"
+
+const bundleVersion = 1
+
+// Kinds 同步的元数据类别；归档、轮换和找零策略只对本机有意义，不同步
+var Kinds = []metadata.Kind{metadata.Labels, metadata.Tags, metadata.Contacts, metadata.Aliases}
+
+// 错误定义
+var (
+	ErrUnsupportedVersion = errors.New("unsupported metadata bundle version")
+	ErrWrongPassword      = errors.New("cannot decrypt metadata bundle, wrong sync password?")
+)
+
+// Bundle 元数据包
+type Bundle struct {
+	Version   int                                 `json:"version"`
+	CreatedAt time.Time                           `json:"created_at"`
+	Entries   map[metadata.Kind]map[string]string `json:"entries"`
+}
+
+// Collect 收集要同步的元数据
+func Collect(store *metadata.Store) *Bundle {
+	return &Bundle{Version: bundleVersion, CreatedAt: time.Now().UTC().Truncate(time.Second), Entries: store.Export(Kinds...)}
+}
+
+// Count 条目总数
+func (b *Bundle) Count() int {
+	n := 0
+	for _, entries := range b.Entries {
+		n += len(entries)
+	}
+	return n
+}
+
+// Seal 规范编码、压缩后用同步密码加密，返回二进制密文
+func (b *Bundle) Seal(password string) ([]byte, error) {
+	data, err := canonjson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptData(buf.Bytes(), password)
+	if err != nil {
+		return nil, fmt.Errorf("加密元数据包失败: %w", err)
+	}
+	return hex.DecodeString(encrypted)
+}
+
+// Open 解密并解压元数据包
+func Open(sealed []byte, password string) (*Bundle, error) {
+	plaintext, err := security.Decrypt(hex.EncodeToString(sealed), password)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	defer plaintext.Destroy()
+	zr, err := gzip.NewReader(bytes.NewReader(plaintext.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("解压元数据包失败: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("解压元数据包失败: %w", err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("解码元数据包失败: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Version)
+	}
+	if b.Entries == nil {
+		b.Entries = make(map[metadata.Kind]map[string]string)
+	}
+	return &b, nil
+}
+
+// Conflict 本机和远端在上次同步后都修改过的条目，值为 nil 表示该端已删除
+type Conflict struct {
+	Kind   metadata.Kind
+	Key    string
+	Local  *string
+	Remote *string
+}
+
+// Merge 三方合并的结果：Updates 是本机需要应用的远端修改，Conflicts 需要调用方逐个决定
+type Merge struct {
+	Updates   []metadata.Change
+	Conflicts []Conflict
+}
+
+// Resolve 按 takeRemote 决定冲突条目是否采用远端的值，采用的加入 Updates
+func (m *Merge) Resolve(c Conflict, takeRemote bool) {
+	if takeRemote {
+		m.Updates = append(m.Updates, metadata.Change{Kind: c.Kind, Key: c.Key, After: c.Remote})
+	}
+}
+
+// Plan 以上次同步的基线 base 为参照合并远端和本机的条目：只有一端改过的条目取改过的一端，
+// 两端改成相同值的不处理，两端改成不同值的作为冲突；base 为空（首次同步）时两端都有且不同即为冲突
+func Plan(base, local, remote map[metadata.Kind]map[string]string) *Merge {
+	m := &Merge{}
+	for _, kind := range Kinds {
+		keys := make(map[string]bool)
+		for _, side := range []map[string]string{base[kind], local[kind], remote[kind]} {
+			for key := range side {
+				keys[key] = true
+			}
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			b, l, r := lookup(base[kind], key), lookup(local[kind], key), lookup(remote[kind], key)
+			switch {
+			case equal(l, r), equal(r, b):
+				// 相同，或者只有本机改过
+			case equal(l, b):
+				m.Updates = append(m.Updates, metadata.Change{Kind: kind, Key: key, After: r})
+			default:
+				m.Conflicts = append(m.Conflicts, Conflict{Kind: kind, Key: key, Local: l, Remote: r})
+			}
+		}
+	}
+	return m
+}
+
+func lookup(entries map[string]string, key string) *string {
+	if value, ok := entries[key]; ok {
+		return &value
+	}
+	return nil
+}
+
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// LoadBase 读取上次同步后的基线，从未同步过时返回 nil
+func LoadBase(path string) (map[metadata.Kind]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var base map[metadata.Kind]map[string]string
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("解码元数据同步基线失败: %w", err)
+	}
+	return base, nil
+}
+
+// SaveBase 同步成功后保存基线
+func SaveBase(path string, entries map[metadata.Kind]map[string]string) error {
+	data, err := canonjson.MarshalIndent(entries, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入元数据同步基线失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名元数据同步基线失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package metrics 进程内的活动计数器（执行的命令、失败、解锁尝试、签名、派生）和命令耗时，
+// 以 Prometheus 文本格式在 serve 模式的 /metrics 导出，也供 REPL 的 stats 命令汇总本次会话
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 指标名称
+const (
+	Commands     = "slowmade_commands_total"          // REPL 命令，标签 command、result（ok/error）
+	HTTPRequests = "slowmade_http_requests_total"     // serve 模式的请求，标签 path、status
+	Unlocks      = "slowmade_unlock_attempts_total"   // 解锁尝试，标签 source（repl/cli/http）、result
+	Signatures   = "slowmade_signatures_total"        // 产生的签名，标签 method
+	Derivations  = "slowmade_addresses_derived_total" // 新派生的地址，标签 coin
+	StorageCache = "slowmade_storage_cache_total"     // 存储读取缓存的查询，标签 result（hit/miss/stale）
+
+	CommandSeconds = "slowmade_command_duration_seconds" // REPL 命令的耗时（不含等待输入），标签 command
+)
+
+var help = map[string]string{
+	Commands:     "REPL commands executed, by command and result.",
+	HTTPRequests: "HTTP API requests served, by route and status code.",
+	Unlocks:      "Wallet unlock attempts, by source and result.",
+	Signatures:   "Signatures produced, by method.",
+	Derivations:  "Addresses derived, by coin.",
+	StorageCache: "Storage read cache lookups, by result.",
+
+	CommandSeconds: "Time spent executing REPL commands, excluding time waiting for input, by command.",
+}
+
+// Sample 一个计数器的当前值
+type Sample struct {
+	Name   string
+	Labels []Label
+	Value  uint64
+}
+
+// Label 标签名和值
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Get 返回标签的值，不存在时为空
+func (s Sample) Get(name string) string {
+	for _, label := range s.Labels {
+		if label.Name == name {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// Timing 一个耗时指标的累计值，以 Prometheus summary 的 _sum 和 _count 导出
+type Timing struct {
+	Name   string
+	Labels []Label
+	Count  uint64
+	Sum    time.Duration
+	Max    time.Duration // 只在 stats 中显示，不导出
+}
+
+// Get 返回标签的值，不存在时为空
+func (t Timing) Get(name string) string {
+	return Sample{Labels: t.Labels}.Get(name)
+}
+
+// Mean 平均耗时
+func (t Timing) Mean() time.Duration {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Sum / time.Duration(t.Count)
+}
+
+var (
+	mu       sync.Mutex
+	counters = make(map[string]*Sample) // 指标名和标签序列化后的键
+	timings  = make(map[string]*Timing)
+	started  = time.Now()
+)
+
+// key 指标名和标签序列化后的键，同时检查标签成对
+func key(name string, labels []string) string {
+	if len(labels)%2 != 0 {
+		panic("metrics: labels must be name/value pairs")
+	}
+	return name + "{" + strings.Join(labels, "\x00") + "}"
+}
+
+func pairs(labels []string) []Label {
+	var result []Label
+	for i := 0; i < len(labels); i += 2 {
+		result = append(result, Label{Name: labels[i], Value: labels[i+1]})
+	}
+	return result
+}
+
+// Inc 计数器加一，labels 为交替的标签名和值
+func Inc(name string, labels ...string) {
+	k := key(name, labels)
+	mu.Lock()
+	defer mu.Unlock()
+	sample, ok := counters[k]
+	if !ok {
+		sample = &Sample{Name: name, Labels: pairs(labels)}
+		counters[k] = sample
+	}
+	sample.Value++
+}
+
+// Observe 记录一次耗时，labels 为交替的标签名和值
+func Observe(name string, elapsed time.Duration, labels ...string) {
+	k := key(name, labels)
+	mu.Lock()
+	defer mu.Unlock()
+	timing, ok := timings[k]
+	if !ok {
+		timing = &Timing{Name: name, Labels: pairs(labels)}
+		timings[k] = timing
+	}
+	timing.Count++
+	timing.Sum += elapsed
+	timing.Max = max(timing.Max, elapsed)
+}
+
+// Result 按错误返回 result 标签的值
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// Started 进程开始计数的时间
+func Started() time.Time {
+	return started
+}
+
+// Snapshot 返回全部计数器，按名称和标签排序
+func Snapshot() []Sample {
+	mu.Lock()
+	samples := make([]Sample, 0, len(counters))
+	for _, sample := range counters {
+		copied := *sample
+		copied.Labels = append([]Label(nil), sample.Labels...)
+		samples = append(samples, copied)
+	}
+	mu.Unlock()
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return formatLabels(samples[i].Labels) < formatLabels(samples[j].Labels)
+	})
+	return samples
+}
+
+// Timings 返回全部耗时指标，按名称和标签排序
+func Timings() []Timing {
+	mu.Lock()
+	result := make([]Timing, 0, len(timings))
+	for _, timing := range timings {
+		copied := *timing
+		copied.Labels = append([]Label(nil), timing.Labels...)
+		result = append(result, copied)
+	}
+	mu.Unlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return formatLabels(result[i].Labels) < formatLabels(result[j].Labels)
+	})
+	return result
+}
+
+// WriteText 以 Prometheus 文本格式（0.0.4）写出全部计数器和耗时
+func WriteText(w io.Writer) error {
+	last := ""
+	for _, sample := range Snapshot() {
+		if sample.Name != last {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", sample.Name, help[sample.Name], sample.Name); err != nil {
+				return err
+			}
+			last = sample.Name
+		}
+		if _, err := fmt.Fprintf(w, "%s%s %d\n", sample.Name, formatLabels(sample.Labels), sample.Value); err != nil {
+			return err
+		}
+	}
+	last = ""
+	for _, timing := range Timings() {
+		if timing.Name != last {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s summary\n", timing.Name, help[timing.Name], timing.Name); err != nil {
+				return err
+			}
+			last = timing.Name
+		}
+		labels := formatLabels(timing.Labels)
+		if _, err := fmt.Fprintf(w, "%s_sum%s %g\n%s_count%s %d\n", timing.Name, labels, timing.Sum.Seconds(), timing.Name, labels, timing.Count); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "# HELP slowmade_start_time_seconds Start time of the process since unix epoch in seconds.\n"+
+		"# TYPE slowmade_start_time_seconds gauge\nslowmade_start_time_seconds %d\n", started.Unix())
+	return err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, label.Name, labelEscaper.Replace(label.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,165 @@
+// Package mirror 钱包状态的变更事件：账户、地址和交易记录的增删以结构化数据发布到事件总线，
+// 外部索引器据此把钱包状态镜像到自己的数据库；Snapshot 按同样的格式输出当前的全部状态用于初始同步
+package mirror
+
+import (
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/events"
+)
+
+// 事件类型
+const (
+	AccountUpserted = "account.upserted" // 账户创建、导入、恢复或修改
+	AccountRemoved  = "account.removed"  // 账户连同它的地址被移到回收站
+	AddressAdded    = "address.added"    // 派生或恢复了地址，已存在的地址被重写时也会发布
+	AddressRemoved  = "address.removed"  // 地址被移到回收站
+	TxRecorded      = "tx.recorded"      // 交易记录中新增了入账或支出
+	SyncComplete    = "sync.complete"    // 初始同步的最后一条，之后的状态以变更事件为准
+)
+
+// Events 全部变更事件类型
+var Events = []string{AccountUpserted, AccountRemoved, AddressAdded, AddressRemoved, TxRecorded}
+
+// 交易方向
+const (
+	In  = "in"
+	Out = "out"
+)
+
+// Account 账户事件的数据，不含任何私钥材料
+type Account struct {
+	ID         string `json:"id"`
+	Coin       string `json:"coin,omitempty"`
+	Path       string `json:"derivation_path,omitempty"`
+	Convention string `json:"path_convention,omitempty"`
+	Standalone bool   `json:"standalone,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`  // RFC 3339 UTC，旧账户没有记录
+	ModifiedAt string `json:"modified_at,omitempty"` // RFC 3339 UTC
+}
+
+// Address 地址事件的数据，以账户 ID、链和索引唯一确定
+type Address struct {
+	AccountID string `json:"account_id"`
+	Coin      string `json:"coin"`
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+	Change    uint32 `json:"change"`
+	Index     uint32 `json:"index"`
+}
+
+// Tx 交易记录事件的数据；入账以 txid:vout 唯一确定（余额类后端没有 txid），支出以 txid 唯一确定
+type Tx struct {
+	Direction string    `json:"direction"`
+	Coin      string    `json:"coin"`
+	AccountID string    `json:"account_id,omitempty"` // 只读地址的入账为空
+	Address   string    `json:"address"`              // 入账为本钱包的收款地址，支出为对方地址
+	TxID      string    `json:"txid,omitempty"`
+	Vout      uint32    `json:"vout,omitempty"`
+	Amount    string    `json:"amount"`        // 最小单位
+	Fee       string    `json:"fee,omitempty"` // 支出的手续费，最小单位
+	Height    int64     `json:"height,omitempty"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	WatchOnly bool      `json:"watch_only,omitempty"`
+}
+
+// Summary sync.complete 事件的数据
+type Summary struct {
+	Accounts  int `json:"accounts"`
+	Addresses int `json:"addresses"`
+	Txs       int `json:"txs"`
+}
+
+// NewAccount 账户的事件数据
+func NewAccount(account *core.CoinAccount) Account {
+	result := Account{
+		ID:         account.ID,
+		Coin:       account.CoinSymbol,
+		Path:       account.DerivationPath,
+		Standalone: account.Standalone,
+		CreatedAt:  timestamp(account.Created()),
+		ModifiedAt: timestamp(account.Modified()),
+	}
+	if account.DerivationPath != "" {
+		result.Convention = string(account.Convention())
+	}
+	return result
+}
+
+// NewAddress 地址的事件数据
+func NewAddress(key *core.AddressKey) Address {
+	return Address{
+		AccountID: key.AccountID,
+		Coin:      key.CoinSymbol,
+		Address:   key.Address,
+		PublicKey: key.PublicKey,
+		Change:    key.ChangeType,
+		Index:     key.AddressIndex,
+	}
+}
+
+// Event 存储变更对应的事件
+func Event(change core.Change) events.Event {
+	switch change.Kind {
+	case core.AccountSaved:
+		return events.Event{Type: AccountUpserted, Data: NewAccount(change.Account)}
+	case core.AccountDeleted:
+		return events.Event{Type: AccountRemoved, Data: Account{ID: change.Account.ID}}
+	case core.AddressSaved:
+		return events.Event{Type: AddressAdded, Data: NewAddress(change.Address)}
+	default:
+		return events.Event{Type: AddressRemoved, Data: NewAddress(change.Address)}
+	}
+}
+
+// Publisher 返回把存储变更发布到事件总线的回调，用于 FileStorage.OnChange
+func Publisher(bus *events.Bus) func([]core.Change) {
+	return func(changes []core.Change) {
+		for _, change := range changes {
+			bus.Publish(Event(change))
+		}
+	}
+}
+
+// Snapshot 按变更事件的格式依次输出全部账户、每个账户的地址和交易记录，最后是 sync.complete；
+// emit 返回错误时停止
+func Snapshot(accountMgr core.AccountManager, txs []Tx, emit func(events.Event) error) error {
+	accounts, err := accountMgr.GetAccounts()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	summary := Summary{Accounts: len(accounts), Txs: len(txs)}
+	for _, account := range accounts {
+		if err := emit(events.Event{Type: AccountUpserted, Time: now, Data: NewAccount(account)}); err != nil {
+			return err
+		}
+	}
+	for _, account := range accounts {
+		addresses, err := accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return err
+		}
+		for _, key := range addresses {
+			if err := emit(events.Event{Type: AddressAdded, Time: now, Data: NewAddress(key)}); err != nil {
+				return err
+			}
+		}
+		summary.Addresses += len(addresses)
+	}
+	for _, tx := range txs {
+		if err := emit(events.Event{Type: TxRecorded, Time: now, Data: tx}); err != nil {
+			return err
+		}
+	}
+	return emit(events.Event{Type: SyncComplete, Time: now, Data: summary})
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
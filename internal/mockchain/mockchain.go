@@ -0,0 +1,497 @@
+// Package mockchain 内置的模拟链：余额、nonce、手续费和确认都由种子确定性地生成，
+// 状态保存在存储目录的 mockchain.json 中，用于在没有网络的 CI 和演示中跑通发送、签名、广播和跟踪流程
+package mockchain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// StateFileName 模拟链状态在存储目录中的文件名
+const StateFileName = "mockchain.json"
+
+// BackendName 在 bitcoin.backend 或 explorer.coins.<币种>.backend 中选择模拟链时使用的名称
+const BackendName = "mockchain"
+
+// 错误定义
+var (
+	ErrUnknownOutput     = errors.New("mockchain: input spends an unknown output")
+	ErrDoubleSpend       = errors.New("mockchain: input already spent")
+	ErrInsufficientFunds = errors.New("mockchain: insufficient balance")
+	ErrDuplicateTx       = errors.New("mockchain: transaction already broadcast")
+)
+
+// Outpoint UTXO 币种的输出引用
+type Outpoint struct {
+	TxID string `json:"txid"`
+	Vout uint32 `json:"vout"`
+}
+
+// Output 交易输出，Key 为 UTXO 币种的输出脚本（十六进制）或账户币种的地址
+type Output struct {
+	Key   string   `json:"key"`
+	Value *big.Int `json:"value"`
+}
+
+// Coin 未花费的输出
+type Coin struct {
+	TxID   string
+	Vout   uint32
+	Value  *big.Int
+	Height int64 // 0 表示在内存池中
+}
+
+// Entry 与某个地址相关的交易
+type Entry struct {
+	TxID   string
+	Height int64 // 0 表示在内存池中
+}
+
+// Tx 广播到模拟链的交易，在广播后的下一个区块确认
+type Tx struct {
+	Coin   string     `json:"coin"`
+	TxID   string     `json:"txid"`
+	At     int64      `json:"at"` // 广播时的区块高度
+	Spends []Outpoint `json:"spends,omitempty"`
+	From   string     `json:"from,omitempty"` // 账户币种的付款地址
+	Fee    *big.Int   `json:"fee,omitempty"`
+	Nonce  uint64     `json:"nonce,omitempty"`
+	Output []Output   `json:"outputs"`
+	// Replaceable UTXO 币种的交易声明了 RBF，确认前可以被花费相同输入的交易替换
+	Replaceable bool `json:"replaceable,omitempty"`
+}
+
+type state struct {
+	Seed      string    `json:"seed"`
+	CreatedAt time.Time `json:"created_at"`
+	Mined     int64     `json:"mined"` // mockchain.mine 手动挖出的区块数
+	Txs       []Tx      `json:"txs"`
+}
+
+// Chain 模拟链，每次操作都重新读取状态文件，多个进程（REPL 和 watch）可以共用
+type Chain struct {
+	seed     string
+	interval time.Duration // 自动出块间隔，0 表示只在 mockchain.mine 时出块
+	path     string
+	mu       sync.Mutex
+}
+
+// New 创建模拟链，状态保存在 path
+func New(seed string, interval time.Duration, path string) *Chain {
+	return &Chain{seed: seed, interval: interval, path: path}
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultChain *Chain
+)
+
+// Default 按 [mockchain] 配置返回进程内共用的模拟链，状态保存在存储目录中
+func Default() *Chain {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultChain == nil {
+		appConfig := config.GetAppConfig()
+		cfg := appConfig.GetMockchainConfig()
+		path := filepath.Join(appConfig.GetStorageConfig().BaseDir, StateFileName)
+		defaultChain = New(cfg.Seed, time.Duration(cfg.BlockInterval)*time.Second, path)
+	}
+	return defaultChain
+}
+
+// Height 当前区块高度：种子决定的起始高度加上手动和按时间挖出的区块
+func (c *Chain) Height() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	return c.height(st), nil
+}
+
+// Mine 挖出 n 个区块，内存池中的交易随之确认，返回新的高度
+func (c *Chain) Mine(n int) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	st.Mined += int64(n)
+	if err := c.save(st); err != nil {
+		return 0, err
+	}
+	return c.height(st), nil
+}
+
+// Mempool 尚未确认的交易数
+func (c *Chain) Mempool() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	height := c.height(st)
+	count := 0
+	for _, tx := range st.Txs {
+		if confirmedAt(tx, height) == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Path 状态文件位置
+func (c *Chain) Path() string { return c.path }
+
+// FeeRate 估算在 blocks 个区块内确认所需的费率（sat/vB），由种子和当前高度决定
+func (c *Chain) FeeRate(blocks int) (int64, error) {
+	height, err := c.Height()
+	if err != nil {
+		return 0, err
+	}
+	if blocks < 1 {
+		blocks = 1
+	}
+	base := int64(c.number("fee", fmt.Sprint(height))%40) + 2
+	rate := base * 6 / int64(blocks+5)
+	if rate < 1 {
+		rate = 1
+	}
+	return rate, nil
+}
+
+// Unspent UTXO 币种某个输出脚本的未花费输出，包括种子决定的初始资金；unit 为一个币的最小单位数
+func (c *Chain) Unspent(coin, key string, unit *big.Int) ([]Coin, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	height := c.height(st)
+	spent := make(map[Outpoint]bool)
+	for _, tx := range st.Txs {
+		for _, in := range tx.Spends {
+			spent[in] = true
+		}
+	}
+	var coins []Coin
+	if fund, ok := c.funding(coin, key, unit, st); ok && !spent[Outpoint{fund.TxID, fund.Vout}] {
+		coins = append(coins, fund)
+	}
+	for _, tx := range st.Txs {
+		if tx.Coin != coin {
+			continue
+		}
+		for vout, out := range tx.Output {
+			if out.Key == key && !spent[Outpoint{tx.TxID, uint32(vout)}] {
+				coins = append(coins, Coin{TxID: tx.TxID, Vout: uint32(vout), Value: out.Value, Height: confirmedAt(tx, height)})
+			}
+		}
+	}
+	return coins, nil
+}
+
+// Submit 广播 UTXO 币种的交易：检查每个输入都存在且未被花费。
+// 输入已被内存池中声明 RBF、且没有后续交易的交易花费时，替换（删除）这些交易，不检查手续费。
+// 初始资金不在状态中记录，owners 给出每个输入可能所属的输出脚本（由签名中的公钥推出），用来重新计算资金交易
+func (c *Chain) Submit(coin, txid string, spends []Outpoint, outputs []Output, owners map[Outpoint][]string, unit *big.Int, replaceable bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return err
+	}
+	height := c.height(st)
+	spentBy := make(map[Outpoint]int)
+	created := make(map[Outpoint]bool)
+	for i, tx := range st.Txs {
+		if tx.TxID == txid {
+			return ErrDuplicateTx
+		}
+		for _, in := range tx.Spends {
+			spentBy[in] = i
+		}
+		for vout := range tx.Output {
+			created[Outpoint{tx.TxID, uint32(vout)}] = true
+		}
+	}
+	replaced := make(map[int]bool)
+	for _, in := range spends {
+		if i, ok := spentBy[in]; ok {
+			if !c.replaceable(st, i, spentBy, height) {
+				return fmt.Errorf("%w: %s:%d", ErrDoubleSpend, in.TxID, in.Vout)
+			}
+			replaced[i] = true
+			continue
+		}
+		if created[in] {
+			continue
+		}
+		found := false
+		for _, key := range owners[in] {
+			if fund, ok := c.funding(coin, key, unit, st); ok && fund.TxID == in.TxID && fund.Vout == in.Vout {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s:%d", ErrUnknownOutput, in.TxID, in.Vout)
+		}
+	}
+	if len(replaced) > 0 {
+		kept := st.Txs[:0]
+		for i, tx := range st.Txs {
+			if !replaced[i] {
+				kept = append(kept, tx)
+			}
+		}
+		st.Txs = kept
+	}
+	st.Txs = append(st.Txs, Tx{Coin: coin, TxID: txid, At: height, Spends: spends, Output: outputs, Replaceable: replaceable})
+	return c.save(st)
+}
+
+// replaceable 第 i 笔交易声明了 RBF、仍在内存池中，且输出没有被其他交易花费
+func (c *Chain) replaceable(st *state, i int, spentBy map[Outpoint]int, height int64) bool {
+	tx := st.Txs[i]
+	if !tx.Replaceable || confirmedAt(tx, height) != 0 {
+		return false
+	}
+	for vout := range tx.Output {
+		if _, ok := spentBy[Outpoint{tx.TxID, uint32(vout)}]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Balance 账户币种的余额：确认部分和内存池中的变化
+func (c *Chain) Balance(coin, address string, unit *big.Int) (confirmed, pending *big.Int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	height := c.height(st)
+	confirmed, pending = new(big.Int), new(big.Int)
+	if fund, ok := c.funding(coin, address, unit, st); ok {
+		confirmed.Add(confirmed, fund.Value)
+	}
+	for _, tx := range st.Txs {
+		if tx.Coin != coin {
+			continue
+		}
+		target := confirmed
+		if confirmedAt(tx, height) == 0 {
+			target = pending
+		}
+		if tx.From == address {
+			target.Sub(target, tx.Fee)
+			for _, out := range tx.Output {
+				target.Sub(target, out.Value)
+			}
+		}
+		for _, out := range tx.Output {
+			if out.Key == address {
+				target.Add(target, out.Value)
+			}
+		}
+	}
+	return confirmed, pending, nil
+}
+
+// Nonce 账户币种地址已发送的交易数，即下一笔交易的 nonce
+func (c *Chain) Nonce(coin, address string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	return nonce(st, coin, address), nil
+}
+
+// Transfer 账户币种的转账，nonce 必须等于地址当前的 nonce，余额必须足够支付金额和手续费
+func (c *Chain) Transfer(coin, from, to string, amount, fee *big.Int, txNonce uint64, unit *big.Int) (string, error) {
+	confirmed, pending, err := c.Balance(coin, from, unit)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return "", err
+	}
+	if expected := nonce(st, coin, from); txNonce != expected {
+		return "", fmt.Errorf("mockchain: nonce %d, expected %d", txNonce, expected)
+	}
+	available := new(big.Int).Add(confirmed, pending)
+	if available.Cmp(new(big.Int).Add(amount, fee)) < 0 {
+		return "", ErrInsufficientFunds
+	}
+	txid := c.hash("transfer", coin, from, fmt.Sprint(txNonce))
+	st.Txs = append(st.Txs, Tx{Coin: coin, TxID: txid, At: c.height(st), From: from, Fee: fee, Nonce: txNonce,
+		Output: []Output{{Key: to, Value: amount}}})
+	return txid, c.save(st)
+}
+
+// Faucet 模拟从外部收到一笔账户币种的转账：交易没有付款地址，进入内存池，在下一个区块确认
+func (c *Chain) Faucet(coin, to string, amount *big.Int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return "", err
+	}
+	txid := c.hash("faucet", coin, to, fmt.Sprint(len(st.Txs)))
+	st.Txs = append(st.Txs, Tx{Coin: coin, TxID: txid, At: c.height(st), Output: []Output{{Key: to, Value: amount}}})
+	return txid, c.save(st)
+}
+
+// History 与某个输出脚本或地址相关的交易，包括初始资金
+func (c *Chain) History(coin, key string, unit *big.Int) ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	height := c.height(st)
+	var entries []Entry
+	// owned 属于这个脚本的输出，花费它们的交易也计入历史
+	owned := make(map[Outpoint]bool)
+	if fund, ok := c.funding(coin, key, unit, st); ok {
+		entries = append(entries, Entry{TxID: fund.TxID, Height: fund.Height})
+		owned[Outpoint{TxID: fund.TxID, Vout: fund.Vout}] = true
+	}
+	for _, tx := range st.Txs {
+		if tx.Coin != coin {
+			continue
+		}
+		related := tx.From == key
+		for _, in := range tx.Spends {
+			related = related || owned[in]
+		}
+		for i, out := range tx.Output {
+			if out.Key == key {
+				related = true
+				owned[Outpoint{TxID: tx.TxID, Vout: uint32(i)}] = true
+			}
+		}
+		if related {
+			entries = append(entries, Entry{TxID: tx.TxID, Height: confirmedAt(tx, height)})
+		}
+	}
+	return entries, nil
+}
+
+// genesis 种子决定的起始高度
+func (c *Chain) genesis() int64 {
+	return 800000 + int64(c.number("genesis")%10000)
+}
+
+func (c *Chain) height(st *state) int64 {
+	height := c.genesis() + st.Mined
+	if c.interval > 0 {
+		height += int64(time.Since(st.CreatedAt) / c.interval)
+	}
+	return height
+}
+
+// funding 种子决定的初始资金：约一半的地址在起始高度之前收到 0.01 到 5 个币
+func (c *Chain) funding(coin, key string, unit *big.Int, st *state) (Coin, bool) {
+	if key == "" || c.number("funded", coin, key)%2 == 1 {
+		return Coin{}, false
+	}
+	milli := int64(c.number("amount", coin, key)%4990) + 10
+	value := new(big.Int).Mul(unit, big.NewInt(milli))
+	value.Div(value, big.NewInt(1000))
+	return Coin{
+		TxID:   c.hash("fund", coin, key),
+		Vout:   0,
+		Value:  value,
+		Height: c.genesis() - int64(c.number("age", coin, key)%1000),
+	}, true
+}
+
+func confirmedAt(tx Tx, height int64) int64 {
+	if height > tx.At {
+		return tx.At + 1
+	}
+	return 0
+}
+
+func nonce(st *state, coin, address string) uint64 {
+	var n uint64
+	for _, tx := range st.Txs {
+		if tx.Coin == coin && tx.From == address {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *Chain) hash(parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(c.seed))
+	mac.Write([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Chain) number(parts ...string) uint64 {
+	sum, _ := hex.DecodeString(c.hash(parts...))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// load 读取状态；文件不存在或种子改变时从空状态开始
+func (c *Chain) load() (*state, error) {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return &state{Seed: c.seed, CreatedAt: time.Now().UTC()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("解析模拟链状态失败: %w", err)
+	}
+	if st.Seed != c.seed {
+		return &state{Seed: c.seed, CreatedAt: time.Now().UTC()}, nil
+	}
+	return &st, nil
+}
+
+func (c *Chain) save(st *state) error {
+	data, err := canonjson.MarshalIndent(st, "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
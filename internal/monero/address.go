@@ -0,0 +1,136 @@
+// Package monero 门罗币只读支持：地址和私有查看密钥的解析与校验，以及 monero-wallet-rpc 接口。
+// 本钱包不派生也不保存门罗币的花费密钥，只能查看收款，不能转账
+package monero
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 地址类型
+const (
+	KindStandard   = "standard"
+	KindIntegrated = "integrated"
+	KindSubaddress = "subaddress"
+)
+
+// ErrInvalidAddress 不是有效的门罗币地址
+var ErrInvalidAddress = errors.New("invalid monero address")
+
+// 各网络的地址前缀：标准地址、集成地址、子地址
+var networkPrefixes = map[string][3]uint64{
+	"mainnet":  {18, 19, 42},
+	"testnet":  {53, 54, 63},
+	"stagenet": {24, 25, 36},
+}
+
+// Address 解析后的地址
+type Address struct {
+	Network        string
+	Kind           string
+	PublicSpendKey []byte
+	PublicViewKey  []byte
+	PaymentID      []byte // 集成地址的 8 字节付款 ID
+}
+
+// ParseAddress 解析并校验地址：前缀、公钥和 Keccak-256 校验和
+func ParseAddress(address string) (*Address, error) {
+	data, err := decodeBase58(address)
+	if err != nil || len(data) < 1+64+4 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	body, check := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(crypto.Keccak256(body)[:4], check) {
+		return nil, fmt.Errorf("%w: %s has a bad checksum", ErrInvalidAddress, address)
+	}
+	prefix, n := uvarint(body)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	keys := body[n:]
+	for network, prefixes := range networkPrefixes {
+		for i, kind := range []string{KindStandard, KindIntegrated, KindSubaddress} {
+			if prefix != prefixes[i] {
+				continue
+			}
+			size := 64
+			if kind == KindIntegrated {
+				size += 8
+			}
+			if len(keys) != size {
+				return nil, fmt.Errorf("%w: %s has the wrong length", ErrInvalidAddress, address)
+			}
+			return &Address{Network: network, Kind: kind,
+				PublicSpendKey: keys[:32], PublicViewKey: keys[32:64], PaymentID: keys[64:]}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has unknown prefix %d", ErrInvalidAddress, address, prefix)
+}
+
+// uvarint 门罗币的前缀按 LEB128 编码
+func uvarint(data []byte) (uint64, int) {
+	var value uint64
+	for i, b := range data {
+		if i == 9 {
+			return 0, -1
+		}
+		value |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// encodedBlockSizes 按字节数索引的块编码长度：门罗币的 base58 把数据按 8 字节分块分别编码
+var encodedBlockSizes = []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+// decodeBase58 门罗币的分块 base58 解码
+func decodeBase58(s string) ([]byte, error) {
+	fullBlocks, rest := len(s)/11, len(s)%11
+	lastSize := -1
+	for size, encoded := range encodedBlockSizes {
+		if encoded == rest {
+			lastSize = size
+		}
+	}
+	if lastSize < 0 {
+		return nil, errors.New("invalid base58 length")
+	}
+	var out []byte
+	for i := 0; i <= fullBlocks; i++ {
+		block, size := s[i*11:min(len(s), i*11+11)], 8
+		if i == fullBlocks {
+			if rest == 0 {
+				break
+			}
+			size = lastSize
+		}
+		var value uint64
+		for _, c := range []byte(block) {
+			digit := bytes.IndexByte([]byte(base58Alphabet), c)
+			if digit < 0 {
+				return nil, fmt.Errorf("invalid base58 character %q", c)
+			}
+			hi, lo := bits.Mul64(value, 58)
+			lo, carry := bits.Add64(lo, uint64(digit), 0)
+			if hi != 0 || carry != 0 {
+				return nil, errors.New("base58 block overflow")
+			}
+			value = lo
+		}
+		if size < 8 && value>>(8*size) != 0 {
+			return nil, errors.New("base58 block overflow")
+		}
+		for j := size - 1; j >= 0; j-- {
+			out = append(out, byte(value>>(8*j)))
+		}
+	}
+	return out, nil
+}
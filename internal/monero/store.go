@@ -0,0 +1,149 @@
+package monero
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// ViewWalletsFileName 门罗币只读钱包在数据目录中的文件名
+const ViewWalletsFileName = "monero_view.json"
+
+// 错误定义
+var (
+	ErrNotFound  = errors.New("no such monero view-only wallet")
+	ErrDuplicate = errors.New("monero address is already imported")
+)
+
+// ViewWallet 导入的只读钱包：主地址和用钱包密码加密的私有查看密钥
+type ViewWallet struct {
+	Address       string    `json:"address"`
+	Label         string    `json:"label,omitempty"`
+	Network       string    `json:"network"`
+	ViewKey       string    `json:"view_key"`       // 加密的私有查看密钥
+	RestoreHeight uint64    `json:"restore_height"` // 从这个高度开始扫描，0 为从创世区块扫描
+	AddedAt       time.Time `json:"added_at"`
+}
+
+// WalletFile wallet-rpc 中的钱包文件名，由地址决定
+func (v ViewWallet) WalletFile() string {
+	return "slowmade-view-" + v.Address[:16]
+}
+
+// FilePassword wallet-rpc 钱包文件的密码，由私有查看密钥派生，不需要另外保存
+func FilePassword(viewKey []byte) string {
+	sum := sha256.Sum256(append([]byte("slowmade monero wallet file:"), viewKey...))
+	return hex.EncodeToString(sum[:])
+}
+
+// ViewWallets 只读钱包列表
+type ViewWallets struct {
+	mu      sync.Mutex
+	path    string
+	Wallets []ViewWallet `json:"wallets"`
+}
+
+// LoadViewWallets 加载只读钱包列表，文件不存在时返回空列表
+func LoadViewWallets(path string) (*ViewWallets, error) {
+	l := &ViewWallets{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("解码门罗币只读钱包失败: %w", err)
+	}
+	return l, nil
+}
+
+// List 返回全部只读钱包
+func (l *ViewWallets) List() []ViewWallet {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ViewWallet(nil), l.Wallets...)
+}
+
+// Lookup 按地址或标签查找
+func (l *ViewWallets) Lookup(ref string) (ViewWallet, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, wallet := range l.Wallets {
+		if wallet.Address == ref || (wallet.Label != "" && strings.EqualFold(wallet.Label, ref)) {
+			return wallet, nil
+		}
+	}
+	return ViewWallet{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Add 添加只读钱包并保存
+func (l *ViewWallets) Add(wallet ViewWallet) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, existing := range l.Wallets {
+		if existing.Address == wallet.Address {
+			return ErrDuplicate
+		}
+	}
+	l.Wallets = append(l.Wallets, wallet)
+	if err := l.save(); err != nil {
+		l.Wallets = l.Wallets[:len(l.Wallets)-1]
+		return err
+	}
+	return nil
+}
+
+// SetViewKey 替换地址对应的加密查看密钥并保存，用于用新的加密参数重新加密
+func (l *ViewWallets) SetViewKey(address, viewKey string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, wallet := range l.Wallets {
+		if wallet.Address == address {
+			l.Wallets[i].ViewKey = viewKey
+			if err := l.save(); err != nil {
+				l.Wallets[i].ViewKey = wallet.ViewKey
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, address)
+}
+
+// Remove 按地址或标签删除，返回删除的钱包
+func (l *ViewWallets) Remove(ref string) (ViewWallet, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, wallet := range l.Wallets {
+		if wallet.Address == ref || (wallet.Label != "" && strings.EqualFold(wallet.Label, ref)) {
+			l.Wallets = append(l.Wallets[:i], l.Wallets[i+1:]...)
+			return wallet, l.save()
+		}
+	}
+	return ViewWallet{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+func (l *ViewWallets) save() error {
+	data, err := canonjson.MarshalIndent(l, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := l.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入门罗币只读钱包失败: %w", err)
+	}
+	if err := os.Rename(tempFile, l.path); err != nil {
+		return fmt.Errorf("重命名门罗币只读钱包失败: %w", err)
+	}
+	return nil
+}
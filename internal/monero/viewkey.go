@@ -0,0 +1,84 @@
+package monero
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// ErrViewKeyMismatch 私有查看密钥与地址中的公开查看密钥不对应
+var ErrViewKeyMismatch = errors.New("private view key does not belong to this address")
+
+// ed25519 曲线参数：p = 2^255 - 19，d = -121665/121666，l 为基点的阶
+var (
+	fieldP    = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	curveD    = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), fieldP)), fieldP)
+	orderL, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	baseX, _  = new(big.Int).SetString("15112221349535400772501151409588531511454012693041857206046113283949847762202", 10)
+	baseY, _  = new(big.Int).SetString("46316835694926478169428394003475163141307993866256225615783033603165251855960", 10)
+)
+
+// ParseViewKey 解析 64 位十六进制的私有查看密钥（小端标量），必须小于 l
+func ParseViewKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("private view key must be 64 hex characters")
+	}
+	if leInt(key).Cmp(orderL) >= 0 {
+		return nil, errors.New("private view key is not a reduced scalar")
+	}
+	return key, nil
+}
+
+// CheckViewKey 校验 viewKey·G 等于地址的公开查看密钥
+func CheckViewKey(address *Address, viewKey []byte) error {
+	x, y := scalarBaseMult(leInt(viewKey))
+	// 压缩格式：小端 y，最高位为 x 的奇偶
+	public := make([]byte, 32)
+	yBytes := y.Bytes()
+	for i, b := range yBytes {
+		public[len(yBytes)-1-i] = b
+	}
+	public[31] |= byte(x.Bit(0)) << 7
+	if !bytes.Equal(public, address.PublicViewKey) {
+		return ErrViewKeyMismatch
+	}
+	return nil
+}
+
+// scalarBaseMult 用仿射坐标的倍加计算 k·G，只在导入时调用一次，不要求速度和常数时间
+func scalarBaseMult(k *big.Int) (*big.Int, *big.Int) {
+	x, y := big.NewInt(0), big.NewInt(1)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		x, y = pointAdd(x, y, x, y)
+		if k.Bit(i) == 1 {
+			x, y = pointAdd(x, y, baseX, baseY)
+		}
+	}
+	return x, y
+}
+
+// pointAdd 扭曲爱德华曲线 -x² + y² = 1 + d·x²·y² 上的点加
+func pointAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	x1y2 := new(big.Int).Mul(x1, y2)
+	y1x2 := new(big.Int).Mul(y1, x2)
+	x1x2 := new(big.Int).Mul(x1, x2)
+	y1y2 := new(big.Int).Mul(y1, y2)
+	t := new(big.Int).Mul(curveD, new(big.Int).Mul(x1x2, y1y2))
+	t.Mod(t, fieldP)
+	xDen := new(big.Int).Add(big.NewInt(1), t)
+	yDen := new(big.Int).Sub(big.NewInt(1), t)
+	x3 := new(big.Int).Mul(x1y2.Add(x1y2, y1x2), xDen.ModInverse(xDen.Mod(xDen, fieldP), fieldP))
+	y3 := new(big.Int).Mul(y1y2.Add(y1y2, x1x2), yDen.ModInverse(yDen.Mod(yDen, fieldP), fieldP))
+	return x3.Mod(x3, fieldP), y3.Mod(y3, fieldP)
+}
+
+func leInt(data []byte) *big.Int {
+	be := make([]byte, len(data))
+	for i, b := range data {
+		be[len(data)-1-i] = b
+	}
+	return new(big.Int).SetBytes(be)
+}
@@ -0,0 +1,216 @@
+package monero
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// Decimals 门罗币精度，1 XMR = 10^12 piconero
+const Decimals = 12
+
+// ErrRPC wallet-rpc 返回了 JSON-RPC 错误
+var ErrRPC = errors.New("monero wallet-rpc error")
+
+var httpClient = resilience.NewHTTPClient(2 * time.Minute)
+
+// WalletRPC monero-wallet-rpc 的 JSON-RPC 接口，同一时间只打开一个钱包文件
+type WalletRPC struct {
+	url      string
+	username string
+	password string
+}
+
+// NewWalletRPC 创建客户端；username 非空时使用 HTTP digest 认证（wallet-rpc 的 --rpc-login）
+func NewWalletRPC(url, username, password string) *WalletRPC {
+	return &WalletRPC{url: strings.TrimRight(url, "/") + "/json_rpc", username: username, password: password}
+}
+
+func (w *WalletRPC) Name() string {
+	return "monero-wallet-rpc:" + strings.TrimSuffix(w.url, "/json_rpc")
+}
+
+// Open 打开只读钱包文件，文件不存在时用地址和私有查看密钥创建，从 restoreHeight 开始扫描
+func (w *WalletRPC) Open(ctx context.Context, filename, filePassword, address string, viewKey []byte, restoreHeight uint64) error {
+	openErr := w.call(ctx, "open_wallet", map[string]interface{}{"filename": filename, "password": filePassword}, nil)
+	if openErr == nil {
+		return nil
+	}
+	err := w.call(ctx, "generate_from_keys", map[string]interface{}{
+		"filename": filename, "password": filePassword, "address": address,
+		"viewkey": hex.EncodeToString(viewKey), "restore_height": restoreHeight, "autosave_current": true,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("%v; creating it failed too: %v", openErr, err)
+	}
+	return nil
+}
+
+// SetDaemon 让 wallet-rpc 连接指定的 monerod
+func (w *WalletRPC) SetDaemon(ctx context.Context, daemonURL string) error {
+	return w.call(ctx, "set_daemon", map[string]interface{}{"address": daemonURL}, nil)
+}
+
+// Refresh 扫描新区块，返回扫描的区块数
+func (w *WalletRPC) Refresh(ctx context.Context) (uint64, error) {
+	var result struct {
+		BlocksFetched uint64 `json:"blocks_fetched"`
+	}
+	err := w.call(ctx, "refresh", map[string]interface{}{}, &result)
+	return result.BlocksFetched, err
+}
+
+// Height 钱包已扫描到的高度
+func (w *WalletRPC) Height(ctx context.Context) (uint64, error) {
+	var result struct {
+		Height uint64 `json:"height"`
+	}
+	err := w.call(ctx, "get_height", map[string]interface{}{}, &result)
+	return result.Height, err
+}
+
+// Balance 主账户的余额和已解锁余额（piconero）；只读钱包看不到花费，余额是全部收款之和
+func (w *WalletRPC) Balance(ctx context.Context) (*big.Int, *big.Int, error) {
+	var result struct {
+		Balance         json.Number `json:"balance"`
+		UnlockedBalance json.Number `json:"unlocked_balance"`
+	}
+	if err := w.call(ctx, "get_balance", map[string]interface{}{"account_index": 0}, &result); err != nil {
+		return nil, nil, err
+	}
+	balance, ok1 := new(big.Int).SetString(result.Balance.String(), 10)
+	unlocked, ok2 := new(big.Int).SetString(result.UnlockedBalance.String(), 10)
+	if !ok1 || !ok2 {
+		return nil, nil, fmt.Errorf("monero: unexpected balance %q", result.Balance)
+	}
+	return balance, unlocked, nil
+}
+
+// Transfer 收到的一笔付款
+type Transfer struct {
+	TxID          string      `json:"txid"`
+	Amount        json.Number `json:"amount"`
+	Height        uint64      `json:"height"`
+	Timestamp     int64       `json:"timestamp"`
+	Confirmations uint64      `json:"confirmations"`
+	Address       string      `json:"address"` // 收款的子地址
+	PaymentID     string      `json:"payment_id"`
+	Locked        bool        `json:"locked"`
+	Pending       bool        `json:"-"` // 还在交易池中
+}
+
+// Incoming 已确认和交易池中的收款，按高度排列，交易池的在最后
+func (w *WalletRPC) Incoming(ctx context.Context) ([]Transfer, error) {
+	var result struct {
+		In   []Transfer `json:"in"`
+		Pool []Transfer `json:"pool"`
+	}
+	if err := w.call(ctx, "get_transfers", map[string]interface{}{"in": true, "pool": true, "account_index": 0}, &result); err != nil {
+		return nil, err
+	}
+	for i := range result.Pool {
+		result.Pool[i].Pending = true
+	}
+	return append(result.In, result.Pool...), nil
+}
+
+// Close 保存并关闭当前钱包文件
+func (w *WalletRPC) Close(ctx context.Context) error {
+	return w.call(ctx, "close_wallet", map[string]interface{}{}, nil)
+}
+
+// call 发送 JSON-RPC 请求；服务端要求 digest 认证时按质询重发一次
+func (w *WalletRPC) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": "0", "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	resp, err := w.post(ctx, body, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && w.username != "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if resp, err = w.post(ctx, body, w.digest(challenge)); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", method, resp.Status, strings.TrimSpace(string(data)))
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return fmt.Errorf("%w: %s: %s", ErrRPC, method, result.Error.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Result, out)
+}
+
+func (w *WalletRPC) post(ctx context.Context, body []byte, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return httpClient.Do(req)
+}
+
+// digest 按 RFC 2617（MD5，qop=auth）回应质询
+func (w *WalletRPC) digest(challenge string) string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(challenge, "Digest "), ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	uri := "/json_rpc"
+	if parsed, err := url.Parse(w.url); err == nil {
+		uri = parsed.RequestURI()
+	}
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	cnonce := hex.EncodeToString(nonce)
+	md5hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := md5hex(w.username + ":" + params["realm"] + ":" + w.password)
+	ha2 := md5hex(http.MethodPost + ":" + uri)
+	response := md5hex(strings.Join([]string{ha1, params["nonce"], "00000001", cnonce, "auth", ha2}, ":"))
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=MD5, qop=auth, nc=00000001, cnonce="%s", response="%s"`,
+		w.username, params["realm"], params["nonce"], uri, cnonce, response)
+	if opaque, ok := params["opaque"]; ok {
+		header += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return header
+}
@@ -0,0 +1,95 @@
+package nft
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// Transfer 转账记录中的一次代币转移，用于找出可能仍持有的代币
+type Transfer struct {
+	Contract string
+	TokenID  *big.Int
+	Standard Standard
+	From     string
+	To       string
+	Amount   *big.Int
+}
+
+// Candidates owner 收到过的代币，按合约和代币 ID 去重并保持首次出现的顺序；
+// 转账记录可能不完整（如空投不触发事件），因此持有量要用 Inventory 在链上核对
+func Candidates(owner string, transfers []Transfer) []*Token {
+	seen := make(map[string]bool)
+	var tokens []*Token
+	for _, transfer := range transfers {
+		if !strings.EqualFold(transfer.To, owner) {
+			continue
+		}
+		token := &Token{Contract: transfer.Contract, TokenID: transfer.TokenID, Standard: transfer.Standard}
+		if seen[token.Key()] {
+			continue
+		}
+		seen[token.Key()] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Inventory 在链上核对候选代币的当前持有量，只返回仍持有的，并读取集合名称；
+// fetcher 非空时同时读取元数据，单个代币的元数据读取失败不影响结果
+func Inventory(ctx context.Context, c Caller, owner string, candidates []*Token, fetcher *Fetcher) ([]*Token, error) {
+	names := make(map[string]string)
+	var owned []*Token
+	for _, token := range candidates {
+		if token.Standard == "" {
+			standard, err := Detect(ctx, c, token.Contract)
+			if err != nil {
+				logging.Debugf("Skipping %s: %v", token.Key(), err)
+				continue
+			}
+			token.Standard = standard
+		}
+		balance, err := Balance(ctx, c, token.Standard, token.Contract, token.TokenID, owner)
+		if err != nil {
+			// 已销毁的 ERC-721 代币 ownerOf 会回滚
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logging.Debugf("Skipping %s: %v", token.Key(), err)
+			continue
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		token.Balance = balance
+		contract := strings.ToLower(token.Contract)
+		name, ok := names[contract]
+		if !ok {
+			name = CollectionName(ctx, c, token.Contract)
+			names[contract] = name
+		}
+		token.Collection = name
+		if fetcher != nil {
+			Describe(ctx, c, token, fetcher)
+		}
+		owned = append(owned, token)
+	}
+	return owned, nil
+}
+
+// Describe 读取代币的元数据链接和元数据，失败时保持为空
+func Describe(ctx context.Context, c Caller, token *Token, fetcher *Fetcher) {
+	uri, err := TokenURI(ctx, c, token.Standard, token.Contract, token.TokenID)
+	if err != nil || uri == "" {
+		return
+	}
+	token.URI = uri
+	meta, err := fetcher.Fetch(ctx, uri)
+	if err != nil {
+		logging.Debugf("Failed to fetch metadata of %s from %s: %v", token.Key(), uri, err)
+		return
+	}
+	token.Metadata = meta
+}
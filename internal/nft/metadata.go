@@ -0,0 +1,110 @@
+package nft
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// DefaultGateway ipfs:// 链接默认改写到的公共网关
+const DefaultGateway = "https://ipfs.io/ipfs/"
+
+// maxMetadataSize 元数据 JSON 的最大字节数
+const maxMetadataSize = 1 << 20
+
+var httpClient = resilience.NewHTTPClient(20 * time.Second)
+
+// Metadata 元数据 JSON 中显示用的字段（ERC-721 Metadata JSON Schema，ERC-1155 相同）
+type Metadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Image       string `json:"image"` // 已按网关改写的 http(s) 链接
+}
+
+// Fetcher 读取代币元数据，ipfs:// 和 ar:// 链接改写为 HTTP 网关
+type Fetcher struct {
+	gateway string
+}
+
+// NewFetcher 创建元数据读取器，gateway 为空时使用 ipfs.io
+func NewFetcher(gateway string) *Fetcher {
+	if gateway == "" {
+		gateway = DefaultGateway
+	}
+	return &Fetcher{gateway: strings.TrimRight(gateway, "/") + "/"}
+}
+
+// Resolve 把 ipfs:// 和 ar:// 链接改写为网关上的 https 链接，其他链接原样返回
+func (f *Fetcher) Resolve(uri string) string {
+	switch {
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(uri, "ipfs://")
+		return f.gateway + strings.TrimPrefix(path, "ipfs/")
+	case strings.HasPrefix(uri, "ar://"):
+		return "https://arweave.net/" + strings.TrimPrefix(uri, "ar://")
+	default:
+		return uri
+	}
+}
+
+// Fetch 读取元数据：支持 data:application/json（base64 或 URL 编码）和 http(s)、ipfs、ar 链接
+func (f *Fetcher) Fetch(ctx context.Context, uri string) (*Metadata, error) {
+	var data []byte
+	if strings.HasPrefix(uri, "data:") {
+		var err error
+		if data, err = decodeDataURI(uri); err != nil {
+			return nil, err
+		}
+	} else {
+		target := f.Resolve(uri)
+		if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+			return nil, fmt.Errorf("unsupported metadata link %q", uri)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize)); err != nil {
+			return nil, err
+		}
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid metadata JSON: %v", err)
+	}
+	meta.Image = f.Resolve(meta.Image)
+	return &meta, nil
+}
+
+// decodeDataURI 解码 data: 链接的内容，链上生成的元数据常用这种形式
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URI")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		return base64.StdEncoding.DecodeString(payload)
+	}
+	text, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
@@ -0,0 +1,219 @@
+// Package nft ERC-721 和 ERC-1155 代币：持有关系的链上核对、集合名称和元数据读取，以及 safeTransferFrom 的调用数据
+package nft
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Standard 代币标准
+type Standard string
+
+const (
+	ERC721  Standard = "ERC-721"
+	ERC1155 Standard = "ERC-1155"
+)
+
+// 错误定义
+var (
+	ErrNotNFT         = errors.New("contract implements neither ERC-721 nor ERC-1155")
+	ErrNotOwner       = errors.New("token is not owned by the sender")
+	ErrInvalidTokenID = errors.New("invalid token ID")
+)
+
+// ERC-165 接口 ID
+var (
+	interfaceERC721  = []byte{0x80, 0xac, 0x58, 0xcd}
+	interfaceERC1155 = []byte{0xd9, 0xb6, 0x7a, 0x26}
+)
+
+var (
+	selectorSupportsInterface = selector("supportsInterface(bytes4)")
+	selectorOwnerOf           = selector("ownerOf(uint256)")
+	selectorBalanceOf         = selector("balanceOf(address,uint256)")
+	selectorName              = selector("name()")
+	selectorTokenURI          = selector("tokenURI(uint256)")
+	selectorURI               = selector("uri(uint256)")
+	selectorTransfer721       = selector("safeTransferFrom(address,address,uint256)")
+	selectorTransfer1155      = selector("safeTransferFrom(address,address,uint256,uint256,bytes)")
+)
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// Caller 能执行只读合约调用（eth_call）的客户端
+type Caller interface {
+	Call(ctx context.Context, to string, data []byte) ([]byte, error)
+}
+
+// Token 一个代币；ERC-1155 的 Balance 可以大于 1
+type Token struct {
+	Contract   string
+	TokenID    *big.Int
+	Standard   Standard
+	Balance    *big.Int
+	Collection string    // 合约的 name()，没有时为空
+	URI        string    // tokenURI 或 uri，ERC-1155 的 {id} 已替换
+	Metadata   *Metadata // 未读取或读取失败时为 nil
+}
+
+// Key 合约和代币 ID，用于去重
+func (t *Token) Key() string {
+	return strings.ToLower(t.Contract) + "/" + t.TokenID.String()
+}
+
+// DisplayName 显示用的名称：元数据名称，否则为集合名称加代币 ID
+func (t *Token) DisplayName() string {
+	if t.Metadata != nil && t.Metadata.Name != "" {
+		return t.Metadata.Name
+	}
+	if t.Collection != "" {
+		return fmt.Sprintf("%s #%s", t.Collection, t.TokenID)
+	}
+	return "#" + t.TokenID.String()
+}
+
+// ParseTokenID 解析十进制或 0x 开头的十六进制代币 ID
+func ParseTokenID(s string) (*big.Int, error) {
+	id, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		id, ok = id.SetString(s[2:], 16)
+	} else {
+		id, ok = id.SetString(s, 10)
+	}
+	if !ok || id.Sign() < 0 || id.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTokenID, s)
+	}
+	return id, nil
+}
+
+// Detect 通过 ERC-165 supportsInterface 判断合约实现的标准
+func Detect(ctx context.Context, c Caller, contract string) (Standard, error) {
+	for _, candidate := range []struct {
+		standard Standard
+		id       []byte
+	}{{ERC721, interfaceERC721}, {ERC1155, interfaceERC1155}} {
+		out, err := c.Call(ctx, contract, append(append([]byte{}, selectorSupportsInterface...), common.RightPadBytes(candidate.id, 32)...))
+		if err != nil {
+			return "", fmt.Errorf("supportsInterface on %s: %w", contract, err)
+		}
+		if len(out) >= 32 && new(big.Int).SetBytes(out[:32]).Sign() != 0 {
+			return candidate.standard, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotNFT, contract)
+}
+
+// Balance owner 持有的数量：ERC-721 按 ownerOf 为 0 或 1，ERC-1155 为 balanceOf
+func Balance(ctx context.Context, c Caller, standard Standard, contract string, id *big.Int, owner string) (*big.Int, error) {
+	switch standard {
+	case ERC721:
+		out, err := c.Call(ctx, contract, append(append([]byte{}, selectorOwnerOf...), word(id)...))
+		if err != nil {
+			return nil, fmt.Errorf("ownerOf(%s) on %s: %w", id, contract, err)
+		}
+		if len(out) < 32 {
+			return nil, fmt.Errorf("ownerOf(%s) on %s: short result", id, contract)
+		}
+		if common.BytesToAddress(out[12:32]) == common.HexToAddress(owner) {
+			return big.NewInt(1), nil
+		}
+		return new(big.Int), nil
+	case ERC1155:
+		data := append(append([]byte{}, selectorBalanceOf...), common.LeftPadBytes(common.HexToAddress(owner).Bytes(), 32)...)
+		out, err := c.Call(ctx, contract, append(data, word(id)...))
+		if err != nil {
+			return nil, fmt.Errorf("balanceOf(%s) on %s: %w", id, contract, err)
+		}
+		if len(out) < 32 {
+			return nil, fmt.Errorf("balanceOf(%s) on %s: short result", id, contract)
+		}
+		return new(big.Int).SetBytes(out[:32]), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotNFT, contract)
+	}
+}
+
+// CollectionName 合约的 name()；ERC-1155 没有要求实现，失败时返回空
+func CollectionName(ctx context.Context, c Caller, contract string) string {
+	out, err := c.Call(ctx, contract, selectorName)
+	if err != nil {
+		return ""
+	}
+	name, err := decodeString(out)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// TokenURI 代币的元数据链接：ERC-721 的 tokenURI，ERC-1155 的 uri（{id} 替换为 64 位小写十六进制）
+func TokenURI(ctx context.Context, c Caller, standard Standard, contract string, id *big.Int) (string, error) {
+	method := selectorTokenURI
+	if standard == ERC1155 {
+		method = selectorURI
+	}
+	out, err := c.Call(ctx, contract, append(append([]byte{}, method...), word(id)...))
+	if err != nil {
+		return "", err
+	}
+	uri, err := decodeString(out)
+	if err != nil {
+		return "", err
+	}
+	if standard == ERC1155 {
+		uri = strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", id))
+	}
+	return uri, nil
+}
+
+// TransferData safeTransferFrom 的调用数据；ERC-721 只能转移 1 个，ERC-1155 的 data 参数为空
+func TransferData(standard Standard, from, to common.Address, id, amount *big.Int) ([]byte, error) {
+	if amount.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	address := func(a common.Address) []byte { return common.LeftPadBytes(a.Bytes(), 32) }
+	switch standard {
+	case ERC721:
+		if amount.Cmp(big.NewInt(1)) != 0 {
+			return nil, errors.New("an ERC-721 token can only be transferred as a whole, amount must be 1")
+		}
+		return bytes.Join([][]byte{selectorTransfer721, address(from), address(to), word(id)}, nil), nil
+	case ERC1155:
+		// bytes data：偏移量 5*32 指向长度为 0 的字节串
+		return bytes.Join([][]byte{selectorTransfer1155, address(from), address(to), word(id), word(amount),
+			word(big.NewInt(5 * 32)), word(new(big.Int))}, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown token standard %q", standard)
+	}
+}
+
+// word uint256 的 32 字节大端编码
+func word(n *big.Int) []byte {
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
+
+// decodeString 解码 ABI 编码的单个 string 返回值
+func decodeString(out []byte) (string, error) {
+	if len(out) < 64 {
+		return "", errors.New("short ABI string")
+	}
+	offset := new(big.Int).SetBytes(out[:32])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+		return "", errors.New("invalid ABI string offset")
+	}
+	start := offset.Int64()
+	length := new(big.Int).SetBytes(out[start : start+32])
+	if !length.IsInt64() || start+32+length.Int64() > int64(len(out)) {
+		return "", errors.New("invalid ABI string length")
+	}
+	return string(bytes.TrimRight(out[start+32:start+32+length.Int64()], "\x00")), nil
+}
@@ -0,0 +1,262 @@
+// Package passprompt 统一的密码输入：终端上不回显，确认模式要求输入两次并显示强度，
+// 非终端（管道、脚本）时逐行读取标准输入，或者从 --password-fd 指定的文件描述符读取；
+// 另外支持启动时从文件描述符或文件一次性读取解锁密码（systemd credentials、docker secrets）
+package passprompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// 错误定义
+var (
+	ErrMismatch = errors.New("passwords do not match")
+	ErrEmpty    = errors.New("password must not be empty")
+)
+
+// LineReader 非终端时读取一行输入，REPL 中由 liner 提供，避免和它的缓冲争抢标准输入
+type LineReader func(prompt string) (string, error)
+
+// StrengthFunc 密码强度提示钩子，返回显示在确认输入之前的说明
+type StrengthFunc func(password string) string
+
+// Prompt 密码输入组件
+type Prompt struct {
+	Input    *os.File            // 默认标准输入
+	Output   io.Writer           // 提示文本输出位置，默认标准输出
+	Lines    LineReader          // 输入不是终端时使用，为空时直接从 Input 读取一行
+	Strength StrengthFunc        // 确认模式下显示强度，为空时不显示
+	Waited   func(time.Duration) // 在终端上等待输入的时间，调用方从命令耗时中扣除，可为空
+	Refuse   error               // 非空时不从标准输入读取，直接返回该错误，用于提示无法显示的场合（如输出被捕获）
+}
+
+var (
+	sourceMu sync.Mutex
+	source   *os.File // --password-fd 打开的文件，保持唯一实例，避免被回收时关闭描述符
+)
+
+// SetFD 之后的密码都从这个文件描述符读取，每行一个，用于脚本和自动化
+func SetFD(fd int) error {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("password-fd-%d", fd))
+	if file == nil {
+		return fmt.Errorf("invalid password file descriptor %d", fd)
+	}
+	if _, err := file.Stat(); err != nil {
+		return fmt.Errorf("invalid password file descriptor %d: %w", fd, err)
+	}
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	source = file
+	return nil
+}
+
+// ReadFD 从文件描述符读取一次密码（第一行）后关闭描述符，调用方用完后清除返回的数据
+func ReadFD(fd int) ([]byte, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("unlock-fd-%d", fd))
+	if file == nil {
+		return nil, fmt.Errorf("invalid unlock file descriptor %d", fd)
+	}
+	defer file.Close()
+	password, err := readLine(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password from file descriptor %d: %v", fd, err)
+	}
+	return password, nil
+}
+
+// ReadFile 从文件读取一次密码（第一行），文件不能被其他用户访问；调用方用完后清除返回的数据
+func ReadFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid password file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("invalid password file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("password file %s is accessible by other users (mode %v), run chmod 600 on it", path, info.Mode().Perm())
+	}
+	password, err := readLine(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password file: %v", err)
+	}
+	return password, nil
+}
+
+// New 使用默认设置创建输入组件：设置了 --password-fd 时从该描述符读取，否则从标准输入
+func New() *Prompt {
+	p := &Prompt{Input: os.Stdin, Output: os.Stdout, Strength: DescribeStrength}
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	if source != nil {
+		p.Input = source
+	}
+	return p
+}
+
+// Read 读取一个已有的密码（解锁、导入），允许为空，由调用方校验
+func (p *Prompt) Read(label string) (string, error) {
+	password, err := p.readOne(label)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return password, nil
+}
+
+// ReadNew 设置新密码：不能为空，显示强度后要求再输入一次确认
+func (p *Prompt) ReadNew(label string) (string, error) {
+	password, err := p.Read(label)
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", ErrEmpty
+	}
+	if p.Strength != nil {
+		fmt.Fprintln(p.output(), p.Strength(password))
+	}
+	confirm, err := p.Read("Repeat " + lowerFirst(label))
+	if err != nil {
+		return "", err
+	}
+	if confirm != password {
+		return "", ErrMismatch
+	}
+	return password, nil
+}
+
+func (p *Prompt) readOne(label string) (string, error) {
+	input := p.input()
+	if p.Refuse != nil && input == os.Stdin {
+		return "", p.Refuse
+	}
+	if term.IsTerminal(int(input.Fd())) {
+		fmt.Fprint(p.output(), label)
+		start := time.Now()
+		password, err := term.ReadPassword(int(input.Fd()))
+		if p.Waited != nil {
+			p.Waited(time.Since(start))
+		}
+		fmt.Fprintln(p.output()) // 换行，因为ReadPassword不会自动换行
+		return string(password), err
+	}
+	if p.Lines != nil && input == os.Stdin {
+		return p.Lines(label)
+	}
+	line, err := readLine(input)
+	return string(line), err
+}
+
+func (p *Prompt) input() *os.File {
+	if p.Input == nil {
+		return os.Stdin
+	}
+	return p.Input
+}
+
+func (p *Prompt) output() io.Writer {
+	if p.Output == nil {
+		return os.Stdout
+	}
+	return p.Output
+}
+
+// readLine 逐字节读取一行，不做缓冲，之后的读取（下一个密码或 REPL 命令）不会丢数据
+func readLine(input io.Reader) ([]byte, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := input.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF {
+			if len(line) == 0 {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bytes.TrimSuffix(line, []byte("\r")), nil
+}
+
+// EstimateBits 按字符集大小和长度粗略估计密码熵（位），不考虑字典词
+func EstimateBits(password string) float64 {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+	// 重复字符不增加强度
+	unique := make(map[rune]bool)
+	for _, r := range password {
+		unique[r] = true
+	}
+	length := len([]rune(password))
+	effective := math.Min(float64(length), float64(len(unique))*1.5)
+	return effective * math.Log2(float64(pool))
+}
+
+// DescribeStrength 默认的强度提示
+func DescribeStrength(password string) string {
+	bits := EstimateBits(password)
+	label := "strong"
+	switch {
+	case bits < 40:
+		label = "weak, anyone who gets the wallet files can guess it"
+	case bits < 60:
+		label = "fair"
+	case bits < 80:
+		label = "good"
+	}
+	return fmt.Sprintf("Password strength: %s (~%.0f bits)", label, bits)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
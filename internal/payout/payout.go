@@ -0,0 +1,104 @@
+// Package payout 批量付款（send.batch）：读取收款 CSV，写出每一行的结果报告。
+// 校验、组合交易和签名在命令中完成：BTC 的全部付款合并为一笔多输出交易，ETH 每笔付款一笔交易，nonce 依次递增
+package payout
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 付款结果
+const (
+	StatusSigned    = "signed"    // 已签名，未广播
+	StatusBroadcast = "broadcast" // 已广播
+	StatusFailed    = "failed"    // 签名或广播失败
+	StatusSkipped   = "skipped"   // 同一批中之前的交易失败，没有签名或广播
+)
+
+// ErrEmpty CSV 中没有付款
+var ErrEmpty = errors.New("no payments in the file")
+
+// Row CSV 中的一笔付款，Coin 为空时由付款账户决定
+type Row struct {
+	Line    int
+	Address string
+	Amount  string
+	Memo    string
+	Coin    string
+
+	Recipient string   // 解析联系人并规范化后的地址
+	Value     *big.Int // 最小单位
+}
+
+// Result 一笔付款的结果，同一笔 BTC 交易的各行 TxID 相同
+type Result struct {
+	Row    *Row
+	Status string
+	TxID   string
+	Fee    string // 最小单位，BTC 交易的手续费只记在第一行
+	Raw    string // 未广播的已签名交易
+	Error  string
+}
+
+// ParseCSV 读取 address,amount[,memo[,coin]]，可以有标题行，# 开头的行为注释
+func ParseCSV(r io.Reader) ([]*Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rows []*Row
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if line == 1 && len(record) >= 2 && strings.EqualFold(record[0], "address") && strings.EqualFold(record[1], "amount") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("line %d: expected address,amount[,memo[,coin]], got %d fields", line, len(record))
+		}
+		row := &Row{Line: line, Address: strings.TrimSpace(record[0]), Amount: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			row.Memo = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			row.Coin = strings.ToUpper(strings.TrimSpace(record[3]))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmpty
+	}
+	return rows, nil
+}
+
+// WriteReport 按 CSV 写出每一行的结果，文件只对所有者可读（包含地址和金额）
+func WriteReport(path string, results []*Result) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	records := [][]string{{"line", "coin", "address", "amount", "memo", "status", "txid", "fee", "error", "raw"}}
+	for _, result := range results {
+		row := result.Row
+		records = append(records, []string{strconv.Itoa(row.Line), row.Coin, row.Recipient, row.Amount, row.Memo,
+			result.Status, result.TxID, result.Fee, result.Error, result.Raw})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,89 @@
+package payreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 收款二维码的内容格式
+const (
+	ContentAuto    = "auto"    // 有金额时用支付 URI，否则只有地址
+	ContentAddress = "address" // 只有地址，任何钱包都能扫描
+	ContentURI     = "uri"     // BIP21、EIP-681 或 Solana Pay URI，带金额和标签
+	ContentJSON    = "json"    // 供内部工具读取的 JSON
+)
+
+// ContentModes 可选的内容格式
+var ContentModes = []string{ContentAuto, ContentAddress, ContentURI, ContentJSON}
+
+// ErrUnknownContent 内容格式不是 ContentModes 之一
+var ErrUnknownContent = errors.New("unknown QR content")
+
+// CheckContent 检查内容格式名称
+func CheckContent(mode string) error {
+	for _, known := range ContentModes {
+		if mode == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q, expected one of %s", ErrUnknownContent, mode, strings.Join(ContentModes, ", "))
+}
+
+// Receive 收款二维码描述的收款信息
+type Receive struct {
+	Coin     string
+	Address  string
+	Amount   *big.Int // 最小单位，nil 表示不指定金额
+	Decimals int
+	Label    string // 收款方名称，BIP21 和 Solana Pay 的 label
+	Memo     string
+}
+
+// receivePayload json 格式的字段，金额为十进制整币，uri 只在币种有支付 URI 格式时给出
+type receivePayload struct {
+	Coin    string `json:"coin"`
+	Address string `json:"address"`
+	Amount  string `json:"amount,omitempty"`
+	Label   string `json:"label,omitempty"`
+	Memo    string `json:"memo,omitempty"`
+	URI     string `json:"uri,omitempty"`
+}
+
+// Content 按格式生成二维码中编码的文本；uri 格式要求币种有支付 URI，auto 在没有时退回地址
+func (r *Receive) Content(mode string) (string, error) {
+	switch mode {
+	case ContentAddress:
+		return r.Address, nil
+	case ContentAuto:
+		if r.Amount == nil || !Supported(r.Coin) {
+			return r.Address, nil
+		}
+		return URI(r.Coin, r.Address, r.Amount, r.Decimals, r.Label, r.Memo)
+	case ContentURI:
+		return URI(r.Coin, r.Address, r.Amount, r.Decimals, r.Label, r.Memo)
+	case ContentJSON:
+		payload := receivePayload{Coin: strings.ToUpper(r.Coin), Address: r.Address, Label: r.Label, Memo: r.Memo}
+		if r.Amount != nil {
+			payload.Amount = coin.FormatUnits(r.Amount, r.Decimals)
+		}
+		if Supported(r.Coin) {
+			payload.URI, _ = URI(r.Coin, r.Address, r.Amount, r.Decimals, r.Label, r.Memo)
+		}
+		// URI 中的 & 保持原样，不转义为 \u0026
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(payload); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	default:
+		return "", CheckContent(mode)
+	}
+}
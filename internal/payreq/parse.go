@@ -0,0 +1,223 @@
+package payreq
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 支付 URI 解析错误
+var (
+	ErrInvalidURI      = errors.New("invalid payment URI")
+	ErrRequiredParam   = errors.New("payment URI has a required parameter this wallet does not understand")
+	ErrUnsupportedCall = errors.New("unsupported EIP-681 function")
+	ErrCoinMismatch    = errors.New("payment URI is for a different coin")
+	ErrAmountMismatch  = errors.New("amount differs from the payment URI")
+	ErrDuplicateParam  = errors.New("payment URI repeats a parameter")
+)
+
+// schemes 支付 URI 的协议名及对应币种
+var schemes = map[string]string{
+	"bitcoin":  "BTC",
+	"ethereum": "ETH",
+	"solana":   "SOL",
+}
+
+// Payment 从支付 URI 解码出的字段
+type Payment struct {
+	URI         string
+	Coin        string
+	Address     string   // 收款地址；EIP-681 也可能是 ENS 名称
+	Amount      *big.Int // 原生币的最小单位金额，nil 表示 URI 未指定
+	Token       string   // 代币合约（EIP-681 transfer）或 SPL 代币 mint，为空表示原生币
+	TokenAmount string   // 代币金额原文：EIP-681 为最小单位整数，Solana Pay 为十进制
+	ChainID     *big.Int // EIP-681 的 @chain_id，nil 表示未指定
+	Label       string
+	Message     string
+	Extra       map[string]string // 其他可选参数，如 lightning、memo、gas
+}
+
+// IsURI 参数是否以支持的支付 URI 协议开头
+func IsURI(s string) bool {
+	scheme, _, ok := strings.Cut(s, ":")
+	_, known := schemes[strings.ToLower(scheme)]
+	return ok && known
+}
+
+// ParseURI 解析 BIP21、EIP-681 或 Solana Pay URI。BIP21 中不认识的 req- 参数、
+// 重复的参数和不是整数的金额都视为错误，避免按错误的金额或收款方发送
+func ParseURI(uri string) (*Payment, error) {
+	uri = strings.TrimSpace(uri)
+	scheme, rest, ok := strings.Cut(uri, ":")
+	symbol, known := schemes[strings.ToLower(scheme)]
+	if !ok || !known {
+		return nil, fmt.Errorf("%w: unknown scheme in %q", ErrInvalidURI, uri)
+	}
+	// bitcoin://addr 不是标准写法，但常见于二维码
+	rest = strings.TrimPrefix(rest, "//")
+	target, rawQuery, _ := strings.Cut(rest, "?")
+	params, err := parseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	p := &Payment{URI: uri, Coin: symbol, Extra: make(map[string]string)}
+	p.Label, p.Message = params["label"], params["message"]
+	delete(params, "label")
+	delete(params, "message")
+
+	switch symbol {
+	case "BTC":
+		err = p.parseBIP21(target, params)
+	case "ETH":
+		err = p.parseEIP681(target, params)
+	default:
+		err = p.parseSolanaPay(target, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Address == "" {
+		return nil, fmt.Errorf("%w: missing address", ErrInvalidURI)
+	}
+	return p, nil
+}
+
+func (p *Payment) parseBIP21(target string, params map[string]string) error {
+	p.Address = target
+	for key, value := range params {
+		switch {
+		case key == "amount":
+			amount, err := coin.ParseUnits(value, 8)
+			if err != nil {
+				return fmt.Errorf("%w: amount: %v", ErrInvalidURI, err)
+			}
+			p.Amount = amount
+		case strings.HasPrefix(key, "req-"):
+			return fmt.Errorf("%w: %s", ErrRequiredParam, key)
+		default:
+			p.Extra[key] = value
+		}
+	}
+	return nil
+}
+
+// parseEIP681 ethereum:[pay-]<地址>[@chain_id][/function]?params，只支持转账和 ERC-20 transfer
+func (p *Payment) parseEIP681(target string, params map[string]string) error {
+	target = strings.TrimPrefix(target, "pay-")
+	target, function, _ := strings.Cut(target, "/")
+	target, chainID, hasChain := strings.Cut(target, "@")
+	p.Address = target
+	if hasChain {
+		id, ok := new(big.Int).SetString(chainID, 10)
+		if !ok || id.Sign() <= 0 {
+			return fmt.Errorf("%w: chain id %q", ErrInvalidURI, chainID)
+		}
+		p.ChainID = id
+	}
+	switch function {
+	case "":
+		for key, value := range params {
+			if key == "value" {
+				amount, err := parseEIP681Number(value)
+				if err != nil {
+					return err
+				}
+				p.Amount = amount
+				continue
+			}
+			p.Extra[key] = value
+		}
+	case "transfer":
+		// 目标是代币合约，收款方和金额在参数中
+		p.Token, p.Address = target, params["address"]
+		if amount, ok := params["uint256"]; ok {
+			value, err := parseEIP681Number(amount)
+			if err != nil {
+				return err
+			}
+			p.TokenAmount = value.String()
+		}
+		for key, value := range params {
+			if key != "address" && key != "uint256" {
+				p.Extra[key] = value
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedCall, function)
+	}
+	return nil
+}
+
+// parseEIP681Number 解析 EIP-681 的数字，允许科学计数法（如 2.014e18），结果必须是非负整数
+func parseEIP681Number(s string) (*big.Int, error) {
+	mantissa, exponent, hasExp := strings.Cut(strings.ToLower(s), "e")
+	if mantissa == "" || strings.Trim(mantissa, "0123456789.") != "" {
+		return nil, fmt.Errorf("%w: number %q", ErrInvalidURI, s)
+	}
+	value, ok := new(big.Rat).SetString(mantissa)
+	if !ok {
+		return nil, fmt.Errorf("%w: number %q", ErrInvalidURI, s)
+	}
+	if hasExp {
+		exp, ok := new(big.Int).SetString(exponent, 10)
+		if !ok || exp.Sign() < 0 || exp.Cmp(big.NewInt(78)) > 0 {
+			return nil, fmt.Errorf("%w: number %q", ErrInvalidURI, s)
+		}
+		value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), exp, nil)))
+	}
+	if !value.IsInt() {
+		return nil, fmt.Errorf("%w: %q is not a whole number of units", ErrInvalidURI, s)
+	}
+	return new(big.Int).Set(value.Num()), nil
+}
+
+func (p *Payment) parseSolanaPay(target string, params map[string]string) error {
+	p.Address = target
+	p.Token = params["spl-token"]
+	delete(params, "spl-token")
+	for key, value := range params {
+		if key != "amount" {
+			p.Extra[key] = value
+			continue
+		}
+		if p.Token != "" {
+			// 代币精度需要查询链上的 mint，保留原文
+			p.TokenAmount = value
+			continue
+		}
+		amount, err := coin.ParseUnits(value, 9)
+		if err != nil {
+			return fmt.Errorf("%w: amount: %v", ErrInvalidURI, err)
+		}
+		p.Amount = amount
+	}
+	return nil
+}
+
+// parseQuery 解析查询参数，参数名不区分大小写，不允许重复
+func parseQuery(raw string) (map[string]string, error) {
+	params := make(map[string]string)
+	if raw == "" {
+		return params, nil
+	}
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.ToLower(key)
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %s: %v", ErrInvalidURI, key, err)
+		}
+		if _, dup := params[key]; dup {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateParam, key)
+		}
+		params[key] = decoded
+	}
+	return params, nil
+}
@@ -0,0 +1,152 @@
+// Package payreq 管理收款请求：生成支付 URI，并保存请求以便之后匹配收到的付款
+package payreq
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// FileName 收款请求在数据目录中的文件名
+const FileName = "payment_requests.json"
+
+// 请求状态
+const (
+	StatusOpen = "open"
+	StatusPaid = "paid"
+)
+
+// ErrRequestNotFound 收款请求不存在
+var ErrRequestNotFound = errors.New("payment request not found")
+
+// Request 一条收款请求，Amount 为最小单位金额的十进制字符串
+type Request struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	Coin      string    `json:"coin"`
+	Address   string    `json:"address"`
+	Amount    string    `json:"amount"`
+	Memo      string    `json:"memo,omitempty"`
+	URI       string    `json:"uri"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	PaidTxID  string    `json:"paid_txid,omitempty"`
+	PaidAt    time.Time `json:"paid_at,omitempty"`
+}
+
+// Describe 通知的标题和正文
+func (r *Request) Describe() (string, string) {
+	body := fmt.Sprintf("Request %s paid: %s", r.ID, r.URI)
+	if r.Memo != "" {
+		body += " (" + r.Memo + ")"
+	}
+	return "Payment request paid", body
+}
+
+// Store 收款请求存储
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	Requests []*Request `json:"requests"`
+}
+
+// Load 加载收款请求，文件不存在时返回空存储
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解码收款请求失败: %w", err)
+	}
+	return s, nil
+}
+
+// Add 保存新的收款请求，分配 ID 和创建时间
+func (s *Store) Add(req *Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	req.ID = hex.EncodeToString(id)
+	req.CreatedAt = time.Now().UTC()
+	req.Status = StatusOpen
+	s.Requests = append(s.Requests, req)
+	if err := s.save(); err != nil {
+		s.Requests = s.Requests[:len(s.Requests)-1]
+		return err
+	}
+	return nil
+}
+
+// List 返回收款请求，按创建时间从新到旧排序；openOnly 时只返回未支付的请求
+func (s *Store) List(openOnly bool) []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*Request
+	for _, req := range s.Requests {
+		if !openOnly || req.Status == StatusOpen {
+			result = append(result, req)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// OpenAddresses 返回未支付请求占用的地址
+func (s *Store) OpenAddresses() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]bool)
+	for _, req := range s.Requests {
+		if req.Status == StatusOpen {
+			result[req.Address] = true
+		}
+	}
+	return result
+}
+
+// MarkPaid 将请求标记为已支付
+func (s *Store) MarkPaid(id, txid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, req := range s.Requests {
+		if req.ID == id {
+			req.Status, req.PaidTxID, req.PaidAt = StatusPaid, txid, time.Now().UTC()
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrRequestNotFound, id)
+}
+
+func (s *Store) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入收款请求失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名收款请求失败: %w", err)
+	}
+	return nil
+}
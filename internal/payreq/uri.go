@@ -0,0 +1,73 @@
+package payreq
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// ErrUnsupportedCoin 币种没有标准的支付 URI 格式
+var ErrUnsupportedCoin = errors.New("no payment URI scheme for coin")
+
+// Supported 币种是否有支付 URI 格式
+func Supported(symbol string) bool {
+	switch strings.ToUpper(symbol) {
+	case "BTC", "ETH", "SOL":
+		return true
+	}
+	return false
+}
+
+// URI 生成支付 URI：BTC 使用 BIP21，ETH 使用 EIP-681，SOL 使用 Solana Pay；amount 为最小单位金额，
+// nil 时不带金额。EIP-681 没有标签和备注字段，label 和 memo 只保存在本地
+func URI(symbol, address string, amount *big.Int, decimals int, label, memo string) (string, error) {
+	var params []string
+	switch strings.ToUpper(symbol) {
+	case "BTC":
+		if amount != nil {
+			params = append(params, "amount="+coin.FormatUnits(amount, decimals))
+		}
+		if label != "" {
+			params = append(params, "label="+escape(label))
+		}
+		if memo != "" {
+			params = append(params, "message="+escape(memo))
+		}
+		return "bitcoin:" + address + query(params), nil
+	case "ETH":
+		// value 为 wei 整数
+		if amount != nil {
+			params = append(params, "value="+amount.String())
+		}
+		return "ethereum:" + address + query(params), nil
+	case "SOL":
+		if amount != nil {
+			params = append(params, "amount="+coin.FormatUnits(amount, decimals))
+		}
+		if label != "" {
+			params = append(params, "label="+escape(label))
+		}
+		if memo != "" {
+			params = append(params, "message="+escape(memo), "memo="+escape(memo))
+		}
+		return "solana:" + address + query(params), nil
+	default:
+		return "", fmt.Errorf("%w %s", ErrUnsupportedCoin, symbol)
+	}
+}
+
+func query(params []string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(params, "&")
+}
+
+// escape 百分号编码，空格编码为 %20 而不是 +（BIP21 要求）
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,470 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 策略表达式是 CEL 风格的小型语言：
+//
+//	字面量    123  0.5  "text"  true  false  ["a", "b"]
+//	变量      见 Variables
+//	运算符    ||  &&  !  ==  !=  <  <=  >  >=  in  (...)
+//	函数      lower(s)  startsWith(s, prefix)  endsWith(s, suffix)  contains(s, sub)
+//
+// 数字是精确的有理数，不会有浮点误差；字符串只支持 == 和 !=，大小写敏感
+
+// ErrSyntax 表达式语法错误
+var ErrSyntax = errors.New("policy syntax error")
+
+// value 表达式的值：bool、string、*big.Rat 或 []value
+type value interface{}
+
+type node interface {
+	eval(env map[string]value) (value, error)
+}
+
+// parseExpr 解析完整的表达式，变量必须在 known 中
+func parseExpr(src string, known map[string]bool) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, known: known}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrSyntax, p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrSyntax)
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid string %s", ErrSyntax, src[i:end+1])
+			}
+			tokens = append(tokens, token{tokString, text})
+			i = end + 1
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(src) && (src[end] >= '0' && src[end] <= '9' || src[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokNumber, src[i:end]})
+			i = end
+		case c == '_' || unicode.IsLetter(c):
+			end := i
+			for end < len(src) && (src[end] == '_' || unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end]))) {
+				end++
+			}
+			tokens = append(tokens, token{tokIdent, src[i:end]})
+			i = end
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{tokOp, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w: unexpected character %q", ErrSyntax, c)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	known  map[string]bool
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind != tokString && p.tokens[p.pos].text == op
+}
+
+func (p *parser) expect(op string) error {
+	if !p.peek(op) {
+		return fmt.Errorf("%w: expected %q", ErrSyntax, op)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary ! 作用于整个比较，!coin == "BTC" 等价于 !(coin == "BTC")
+func (p *parser) unary() (node, error) {
+	if p.peek("!") {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{operand: operand}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.peek(op) {
+			p.pos++
+			right, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			return &compare{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) primary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokString:
+		return &literal{v: tok.text}, nil
+	case tokNumber:
+		n, ok := new(big.Rat).SetString(tok.text)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid number %q", ErrSyntax, tok.text)
+		}
+		return &literal{v: n}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literal{v: true}, nil
+		case "false":
+			return &literal{v: false}, nil
+		}
+		if p.peek("(") {
+			return p.call(tok.text)
+		}
+		if !p.known[tok.text] {
+			return nil, fmt.Errorf("%w: unknown variable %q", ErrSyntax, tok.text)
+		}
+		return &variable{name: tok.text}, nil
+	}
+	switch tok.text {
+	case "(":
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	case "[":
+		items := &list{}
+		for !p.peek("]") {
+			if len(items.items) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			item, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			items.items = append(items.items, item)
+		}
+		p.pos++
+		return items, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", ErrSyntax, tok.text)
+}
+
+func (p *parser) call(name string) (node, error) {
+	arity, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q", ErrSyntax, name)
+	}
+	p.pos++ // (
+	c := &call{name: name}
+	for !p.peek(")") {
+		if len(c.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+	}
+	p.pos++
+	if len(c.args) != arity {
+		return nil, fmt.Errorf("%w: %s takes %d argument(s)", ErrSyntax, name, arity)
+	}
+	return c, nil
+}
+
+type literal struct{ v value }
+
+func (n *literal) eval(map[string]value) (value, error) { return n.v, nil }
+
+type variable struct{ name string }
+
+func (n *variable) eval(env map[string]value) (value, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("variable %q is not set", n.name)
+	}
+	return v, nil
+}
+
+type list struct{ items []node }
+
+func (n *list) eval(env map[string]value) (value, error) {
+	values := make([]value, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+type not struct{ operand node }
+
+func (n *not) eval(env map[string]value) (value, error) {
+	v, err := evalBool(n.operand, env)
+	if err != nil {
+		return nil, err
+	}
+	return !v, nil
+}
+
+// logical && 和 ||，左侧已决定结果时不再求值右侧
+type logical struct {
+	or          bool
+	left, right node
+}
+
+func (n *logical) eval(env map[string]value) (value, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	if left == n.or {
+		return left, nil
+	}
+	return evalBool(n.right, env)
+}
+
+type compare struct {
+	op          string
+	left, right node
+}
+
+func (n *compare) eval(env map[string]value) (value, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "in":
+		items, ok := right.([]value)
+		if !ok {
+			return nil, fmt.Errorf("right side of in must be a list, got %s", typeName(right))
+		}
+		for _, item := range items {
+			if equal, err := equals(left, item); err == nil && equal {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "==", "!=":
+		equal, err := equals(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return equal == (n.op == "=="), nil
+	}
+	a, aok := left.(*big.Rat)
+	b, bok := right.(*big.Rat)
+	if !aok || !bok {
+		return nil, fmt.Errorf("%s needs numbers, got %s and %s", n.op, typeName(left), typeName(right))
+	}
+	cmp := a.Cmp(b)
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// functions 内置函数及其参数个数，参数都是字符串
+var functions = map[string]int{
+	"lower":      1,
+	"startsWith": 2,
+	"endsWith":   2,
+	"contains":   2,
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n *call) eval(env map[string]value) (value, error) {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s needs strings, got %s", n.name, typeName(v))
+		}
+		args[i] = s
+	}
+	switch n.name {
+	case "lower":
+		return strings.ToLower(args[0]), nil
+	case "startsWith":
+		return strings.HasPrefix(args[0], args[1]), nil
+	case "endsWith":
+		return strings.HasSuffix(args[0], args[1]), nil
+	default:
+		return strings.Contains(args[0], args[1]), nil
+	}
+}
+
+func evalBool(n node, env map[string]value) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+func equals(a, b value) (bool, error) {
+	switch x := a.(type) {
+	case bool:
+		if y, ok := b.(bool); ok {
+			return x == y, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return x == y, nil
+		}
+	case *big.Rat:
+		if y, ok := b.(*big.Rat); ok {
+			return x.Cmp(y) == 0, nil
+		}
+	}
+	return false, fmt.Errorf("cannot compare %s with %s", typeName(a), typeName(b))
+}
+
+func typeName(v value) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case *big.Rat:
+		return "number"
+	case []value:
+		return "list"
+	}
+	return fmt.Sprintf("%T", v)
+}
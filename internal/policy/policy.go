@@ -0,0 +1,236 @@
+// Package policy 签名前执行的策略脚本：数据目录 policies/ 下的 *.policy 文件，每行一条规则
+//
+//	<allow|confirm|deny> <表达式>
+//
+// 规则按文件名和行号顺序求值，第一条表达式为真的规则决定结果：allow 按正常流程确认，
+// confirm 在正常确认之外要求额外确认，deny 直接拒绝；没有规则匹配时为 allow。
+// # 开头的行和空行被忽略。表达式语法见 expr.go，可用的变量见 Variables。
+// 策略文件无法解析或规则求值出错时拒绝签名
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName 策略脚本在数据目录中的子目录
+const DirName = "policies"
+
+// fileExt 策略脚本的扩展名
+const fileExt = ".policy"
+
+// ErrDenied 签名请求被策略拒绝
+var ErrDenied = errors.New("denied by signing policy")
+
+// Variables 表达式中可用的变量及说明
+var Variables = []struct{ Name, Description string }{
+	{"method", `signing method: "eth_signTransaction", "personal_sign", "eth_signTypedData", "eth_signUserOperation" or "btc_signTransaction"`},
+	{"coin", `"ETH" or "BTC"`},
+	{"destination", "recipient address (checksummed for ETH), empty for messages and contract creation"},
+	{"amount", "amount sent to destination in whole coins, 0 for messages"},
+	{"own", "destination is an address of this wallet"},
+	{"origin", "requesting dApp or client, empty for the local REPL"},
+	{"hour", "local hour of day, 0-23"},
+	{"weekday", `local day of week: "mon" ... "sun"`},
+}
+
+// Decision 策略的结果，数值越大越严格
+type Decision int
+
+const (
+	Allow Decision = iota
+	Confirm
+	Deny
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Confirm:
+		return "confirm"
+	case Deny:
+		return "deny"
+	}
+	return "allow"
+}
+
+// Input 一次签名请求中交给策略判断的内容；一笔交易有多个输出时每个输出一份
+type Input struct {
+	Method      string
+	Coin        string
+	Destination string
+	Amount      *big.Rat // 整币单位，nil 视为 0
+	Own         bool
+	Origin      string
+	Time        time.Time // 零值表示当前时间
+}
+
+func (in Input) env() map[string]value {
+	amount := in.Amount
+	if amount == nil {
+		amount = new(big.Rat)
+	}
+	at := in.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return map[string]value{
+		"method":      in.Method,
+		"coin":        in.Coin,
+		"destination": in.Destination,
+		"amount":      amount,
+		"own":         in.Own,
+		"origin":      in.Origin,
+		"hour":        new(big.Rat).SetInt64(int64(at.Hour())),
+		"weekday":     strings.ToLower(at.Weekday().String()[:3]),
+	}
+}
+
+// Rule 一条策略规则
+type Rule struct {
+	Action Decision
+	Source string // 文件名:行号
+	Text   string // 表达式原文
+	expr   node
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s: %s %s", r.Source, r.Action, r.Text)
+}
+
+// Result 策略判断结果，Rule 为 nil 表示没有规则匹配
+type Result struct {
+	Decision Decision
+	Rule     *Rule
+	Err      error // 规则求值出错，此时 Decision 为 Deny
+}
+
+// Reason 结果的说明，用于错误信息和确认提示
+func (r Result) Reason() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", r.Rule, r.Err)
+	case r.Rule != nil:
+		return r.Rule.String()
+	}
+	return "no rule matched"
+}
+
+// Engine 按顺序求值的规则集合
+type Engine struct {
+	Rules []*Rule
+}
+
+// Load 加载 dir 下全部 *.policy 文件，目录不存在时返回空规则集
+func Load(dir string) (*Engine, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Engine{}, nil
+		}
+		return nil, fmt.Errorf("读取策略目录失败: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), fileExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	engine := &Engine{}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("读取策略文件失败: %w", err)
+		}
+		rules, err := Parse(name, string(data))
+		if err != nil {
+			return nil, err
+		}
+		engine.Rules = append(engine.Rules, rules...)
+	}
+	return engine, nil
+}
+
+// Parse 解析一个策略文件的内容，name 用于规则来源和错误信息
+func Parse(name, src string) ([]*Rule, error) {
+	known := make(map[string]bool, len(Variables))
+	for _, v := range Variables {
+		known[v.Name] = true
+	}
+	var rules []*Rule
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		source := fmt.Sprintf("%s:%d", name, i+1)
+		action, text, _ := strings.Cut(line, " ")
+		rule := &Rule{Source: source, Text: strings.TrimSpace(text)}
+		switch action {
+		case "allow":
+			rule.Action = Allow
+		case "confirm":
+			rule.Action = Confirm
+		case "deny":
+			rule.Action = Deny
+		default:
+			return nil, fmt.Errorf("%s: %w: rule must start with allow, confirm or deny", source, ErrSyntax)
+		}
+		if rule.Text == "" {
+			return nil, fmt.Errorf("%s: %w: missing expression", source, ErrSyntax)
+		}
+		expr, err := parseExpr(rule.Text, known)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		rule.expr = expr
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Evaluate 返回第一条匹配规则的结果；求值出错的规则按拒绝处理
+func (e *Engine) Evaluate(in Input) Result {
+	env := in.env()
+	for _, rule := range e.Rules {
+		matched, err := evalBool(rule.expr, env)
+		if err != nil {
+			return Result{Decision: Deny, Rule: rule, Err: err}
+		}
+		if matched {
+			return Result{Decision: rule.Action, Rule: rule}
+		}
+	}
+	return Result{Decision: Allow}
+}
+
+// EvaluateAll 分别判断每份输入，返回最严格的结果
+func (e *Engine) EvaluateAll(inputs []Input) Result {
+	var strictest Result
+	for _, in := range inputs {
+		if result := e.Evaluate(in); result.Decision > strictest.Decision {
+			strictest = result
+		}
+	}
+	return strictest
+}
+
+// Check 加载 dir 下的策略并判断输入：被拒绝或策略无法加载时返回 ErrDenied，否则返回结果
+func Check(dir string, inputs ...Input) (Result, error) {
+	engine, err := Load(dir)
+	if err != nil {
+		return Result{Decision: Deny}, fmt.Errorf("%w: %v", ErrDenied, err)
+	}
+	result := engine.EvaluateAll(inputs)
+	if result.Decision == Deny {
+		return result, fmt.Errorf("%w (%s)", ErrDenied, result.Reason())
+	}
+	return result, nil
+}
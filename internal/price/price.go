@@ -0,0 +1,229 @@
+// Package price 查询币种的法币汇率，用于按法币金额发送（如 50usd）
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// 错误定义
+var (
+	ErrNotConfigured = errors.New("no price source configured, set [price] source")
+	ErrUnknownCoin   = errors.New("no price available for coin")
+	ErrSlippage      = errors.New("exchange rate moved more than the allowed slippage")
+)
+
+// coinIDs 币种符号对应的 CoinGecko ID
+var coinIDs = map[string]string{
+	"BTC": "bitcoin",
+	"ETH": "ethereum",
+	"SOL": "solana",
+}
+
+// Quote 一次汇率查询结果：1 个币等于 Rate 个法币单位
+type Quote struct {
+	Coin      string
+	Currency  string
+	Rate      *big.Rat
+	Source    string
+	FetchedAt time.Time
+}
+
+// Convert 把法币金额换算为币的最小单位，向下取整
+func (q *Quote) Convert(fiat *big.Rat, decimals int) *big.Int {
+	units := new(big.Rat).Quo(fiat, q.Rate)
+	units.Mul(units, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	return new(big.Int).Quo(units.Num(), units.Denom())
+}
+
+// Check 确认新的报价与 q 相差不超过 maxPercent%，否则返回 ErrSlippage
+func (q *Quote) Check(latest *Quote, maxPercent float64) error {
+	diff := new(big.Rat).Sub(latest.Rate, q.Rate)
+	diff.Abs(diff).Quo(diff, q.Rate).Mul(diff, big.NewRat(100, 1))
+	limit := new(big.Rat)
+	limit.SetFloat64(maxPercent)
+	if diff.Cmp(limit) > 0 {
+		percent, _ := diff.Float64()
+		return fmt.Errorf("%w: %s/%s %s -> %s (%.2f%%, limit %.2f%%)", ErrSlippage, q.Coin, q.Currency,
+			q.Rate.FloatString(2), latest.Rate.FloatString(2), percent, maxPercent)
+	}
+	return nil
+}
+
+// ParseFiat 解析带法币代码后缀的金额，如 50usd、12.5EUR；不是法币金额时 ok 为 false
+func ParseFiat(s string) (amount *big.Rat, currency string, ok bool) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] >= 'a' && s[i-1] <= 'z' || s[i-1] >= 'A' && s[i-1] <= 'Z') {
+		i--
+	}
+	number, code := s[:i], strings.ToUpper(s[i:])
+	if len(code) != 3 || number == "" || strings.Trim(number, "0123456789.") != "" || strings.Count(number, ".") > 1 {
+		return nil, "", false
+	}
+	amount, ok = new(big.Rat).SetString(number)
+	if !ok || amount.Sign() <= 0 {
+		return nil, "", false
+	}
+	return amount, code, true
+}
+
+// Service 带内存缓存的汇率服务
+type Service struct {
+	baseURL string
+	apiKey  string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu     sync.Mutex
+	quotes map[string]*Quote
+}
+
+// NewService 按配置创建汇率服务，目前支持 CoinGecko 兼容接口
+func NewService(cfg config.PriceConfig) (*Service, error) {
+	switch strings.ToLower(cfg.Source) {
+	case "":
+		return nil, ErrNotConfigured
+	case "coingecko":
+	default:
+		return nil, fmt.Errorf("unknown price source %q", cfg.Source)
+	}
+	baseURL := cfg.URL
+	if baseURL == "" {
+		baseURL = "https://api.coingecko.com/api/v3"
+	}
+	return &Service{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  cfg.APIKey,
+		ttl:     time.Duration(cfg.CacheTTL) * time.Second,
+		client:  resilience.NewHTTPClient(30 * time.Second),
+		quotes:  make(map[string]*Quote),
+	}, nil
+}
+
+// Name 汇率来源，显示在换算结果中
+func (s *Service) Name() string {
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return "coingecko"
+	}
+	return "coingecko:" + u.Host
+}
+
+// Quote 返回汇率，TTL 内使用缓存
+func (s *Service) Quote(ctx context.Context, symbol, currency string) (*Quote, error) {
+	key := strings.ToUpper(symbol) + "/" + strings.ToUpper(currency)
+	s.mu.Lock()
+	cached, ok := s.quotes[key]
+	s.mu.Unlock()
+	if ok && time.Since(cached.FetchedAt) < s.ttl {
+		return cached, nil
+	}
+	return s.Fresh(ctx, symbol, currency)
+}
+
+// Fresh 忽略缓存重新查询汇率，签名前的滑点检查使用
+func (s *Service) Fresh(ctx context.Context, symbol, currency string) (*Quote, error) {
+	symbol, currency = strings.ToUpper(symbol), strings.ToUpper(currency)
+	id, ok := coinIDs[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownCoin, symbol)
+	}
+	vs := strings.ToLower(currency)
+	query := url.Values{"ids": {id}, "vs_currencies": {vs}, "precision": {"full"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var result map[string]map[string]json.Number
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("解码汇率失败: %w", err)
+	}
+	number, ok := result[id][vs]
+	if !ok {
+		return nil, fmt.Errorf("%w %s in %s", ErrUnknownCoin, symbol, currency)
+	}
+	rate, ok := new(big.Rat).SetString(number.String())
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q for %s/%s", number, symbol, currency)
+	}
+	quote := &Quote{Coin: symbol, Currency: currency, Rate: rate, Source: s.Name(), FetchedAt: time.Now().UTC()}
+	s.mu.Lock()
+	s.quotes[symbol+"/"+currency] = quote
+	s.mu.Unlock()
+	return quote, nil
+}
+
+// Historical 查询某一天（UTC）的收盘汇率，用于补记交易发生时的价格；不使用缓存
+func (s *Service) Historical(ctx context.Context, symbol, currency string, day time.Time) (*Quote, error) {
+	symbol, currency = strings.ToUpper(symbol), strings.ToUpper(currency)
+	id, ok := coinIDs[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownCoin, symbol)
+	}
+	vs := strings.ToLower(currency)
+	query := url.Values{"date": {day.UTC().Format("02-01-2006")}, "localization": {"false"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/coins/"+id+"/history?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		MarketData struct {
+			CurrentPrice map[string]json.Number `json:"current_price"`
+		} `json:"market_data"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("解码汇率失败: %w", err)
+	}
+	number, ok := result.MarketData.CurrentPrice[vs]
+	if !ok {
+		return nil, fmt.Errorf("%w %s in %s on %s", ErrUnknownCoin, symbol, currency, day.UTC().Format("2006-01-02"))
+	}
+	rate, ok := new(big.Rat).SetString(number.String())
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q for %s/%s", number, symbol, currency)
+	}
+	return &Quote{Coin: symbol, Currency: currency, Rate: rate, Source: s.Name() + " daily", FetchedAt: time.Now().UTC()}, nil
+}
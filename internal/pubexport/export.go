@@ -0,0 +1,203 @@
+// Package pubexport 导出不含任何私密材料的公开数据（扩展公钥、描述符、地址和交易记录），
+// 供会计或区块浏览器导入；写入前用内置扫描器检查，发现疑似私密内容时拒绝导出
+package pubexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// ErrOutputNotEmpty 输出目录已存在且不为空
+var ErrOutputNotEmpty = errors.New("output directory is not empty")
+
+// 导出目录中的文件名
+const (
+	AccountsFile     = "accounts.json"
+	AddressesFile    = "addresses.csv"
+	DescriptorsFile  = "descriptors.txt"
+	TransactionsFile = "transactions.csv"
+)
+
+// Account 账户的公开信息
+type Account struct {
+	ID          string   `json:"id"`
+	Coin        string   `json:"coin"`
+	Path        string   `json:"path"`
+	XPub        string   `json:"xpub"`
+	Fingerprint string   `json:"fingerprint"`
+	Descriptors []string `json:"descriptors,omitempty"`
+	Convention  string   `json:"convention,omitempty"`
+	Standalone  bool     `json:"standalone,omitempty"`
+}
+
+// Summary 导出结果
+type Summary struct {
+	Accounts     int
+	Addresses    int
+	Transactions int
+	Files        []string
+}
+
+// Export 收集全部账户的公开数据，扫描通过后写入 dir；txStore 为空时不导出交易记录。
+// 任何一个文件扫描不通过时都不写入任何文件
+func Export(accountMgr core.AccountManager, txStore *watch.TxStore, dir string) (*Summary, error) {
+	accounts, err := accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].CoinSymbol != accounts[j].CoinSymbol {
+			return accounts[i].CoinSymbol < accounts[j].CoinSymbol
+		}
+		return accounts[i].DerivationPath < accounts[j].DerivationPath
+	})
+
+	summary := &Summary{Accounts: len(accounts)}
+	var (
+		exported    []Account
+		descriptors bytes.Buffer
+		addresses   bytes.Buffer
+	)
+	addressCSV := csv.NewWriter(&addresses)
+	addressCSV.Write([]string{"account_id", "coin", "path", "change", "index", "address"})
+	for _, account := range accounts {
+		entry, err := Describe(accountMgr, account)
+		if err != nil {
+			return nil, err
+		}
+		for _, descriptor := range entry.Descriptors {
+			descriptors.WriteString(descriptor + "\n")
+		}
+		exported = append(exported, *entry)
+
+		keys, err := accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].ChangeType != keys[j].ChangeType {
+				return keys[i].ChangeType < keys[j].ChangeType
+			}
+			return keys[i].AddressIndex < keys[j].AddressIndex
+		})
+		for _, key := range keys {
+			addressPath, err := account.AddressPath(key.ChangeType, key.AddressIndex)
+			if err != nil {
+				return nil, err
+			}
+			addressCSV.Write([]string{account.ID, account.CoinSymbol, addressPath.String(),
+				strconv.FormatUint(uint64(key.ChangeType), 10), strconv.FormatUint(uint64(key.AddressIndex), 10), key.Address})
+			summary.Addresses++
+		}
+	}
+	addressCSV.Flush()
+
+	accountsJSON, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{
+		AccountsFile:  accountsJSON,
+		AddressesFile: addresses.Bytes(),
+	}
+	if descriptors.Len() > 0 {
+		files[DescriptorsFile] = descriptors.Bytes()
+	}
+
+	// 交易 ID 是唯一允许出现的 32 字节十六进制串
+	allowed := make(map[string]bool)
+	if txStore != nil {
+		var transactions bytes.Buffer
+		txCSV := csv.NewWriter(&transactions)
+		txCSV.Write([]string{"date", "coin", "account_id", "address", "txid", "vout", "amount", "height"})
+		for _, payment := range txStore.Payments() {
+			amount := payment.Amount
+			if value, ok := new(big.Int).SetString(payment.Amount, 10); ok {
+				if info, ok := coin.LookupSymbol(payment.Coin); ok {
+					amount = coin.FormatUnits(value, info.Decimal)
+				}
+			}
+			txCSV.Write([]string{payment.DetectedAt.UTC().Format(time.RFC3339), payment.Coin, payment.AccountID, payment.Address,
+				payment.TxID, strconv.FormatUint(uint64(payment.Vout), 10), amount, strconv.FormatInt(payment.Height, 10)})
+			if payment.TxID != "" {
+				allowed[strings.ToLower(payment.TxID)] = true
+			}
+			summary.Transactions++
+		}
+		txCSV.Flush()
+		files[TransactionsFile] = transactions.Bytes()
+	}
+
+	var findings []string
+	for name, data := range files {
+		for _, finding := range Scan(name, data, allowed) {
+			findings = append(findings, finding.String())
+		}
+	}
+	if len(findings) > 0 {
+		sort.Strings(findings)
+		return nil, fmt.Errorf("%w, nothing was written:\n  %s", ErrSecretFound, strings.Join(findings, "\n  "))
+	}
+
+	if err := prepareDir(dir); err != nil {
+		return nil, err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return nil, fmt.Errorf("写入 %s 失败: %w", name, err)
+		}
+		summary.Files = append(summary.Files, name)
+	}
+	sort.Strings(summary.Files)
+	return summary, nil
+}
+
+// Describe 账户的公开信息：扩展公钥、指纹和非标准的路径约定，BTC 账户还有输出描述符
+func Describe(accountMgr core.AccountManager, account *core.CoinAccount) (*Account, error) {
+	xpub, err := accountMgr.AccountPublicKey(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", account.ID, err)
+	}
+	fingerprint, err := accountMgr.AccountFingerprint(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", account.ID, err)
+	}
+	entry := &Account{ID: account.ID, Coin: account.CoinSymbol, Path: account.DerivationPath, XPub: xpub, Fingerprint: fingerprint,
+		Convention: string(account.PathConvention), Standalone: account.Standalone}
+	if account.CoinSymbol == "BTC" {
+		if entry.Descriptors, err = btc.AccountDescriptors(xpub, account.ScriptFormat()); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// prepareDir 创建输出目录，已存在的目录必须为空，避免和其他文件混在一起交给第三方
+func prepareDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("%w: %s", ErrOutputNotEmpty, dir)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	return os.MkdirAll(dir, 0755)
+}
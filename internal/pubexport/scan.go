@@ -0,0 +1,87 @@
+package pubexport
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// ErrSecretFound 导出内容中发现疑似私密材料
+var ErrSecretFound = errors.New("export contains secret-looking data")
+
+// mnemonicRun 连续出现多少个 BIP39 单词时视为助记词
+const mnemonicRun = 12
+
+var (
+	extendedPrivateKey = regexp.MustCompile(`[xyztuvYZUV]prv[1-9A-HJ-NP-Za-km-z]{100,}`)
+	wifCandidate       = regexp.MustCompile(`\b[5KLc9][1-9A-HJ-NP-Za-km-z]{50,51}\b`)
+	hexKeyCandidate    = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{64}\b`)
+	secretFieldName    = regexp.MustCompile(`(?i)"[a-z_]*(?:priv|secret|seed|mnemonic|encrypted|password)[a-z_]*"\s*:`)
+	wordSplitter       = regexp.MustCompile(`[^a-z]+`)
+)
+
+// Finding 扫描发现的一处疑似私密材料，Sample 只保留开头几个字符
+type Finding struct {
+	File   string
+	Reason string
+	Sample string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s...)", f.File, f.Reason, f.Sample)
+}
+
+// Scan 检查一个导出文件的内容：扩展私钥、WIF 私钥、不属于已知交易 ID 的 32 字节十六进制串、
+// 私钥相关的 JSON 字段名以及连续的助记词单词；allowed 是允许出现的十六进制串（交易 ID）
+func Scan(file string, data []byte, allowed map[string]bool) []Finding {
+	text := string(data)
+	var findings []Finding
+	add := func(reason, match string) {
+		findings = append(findings, Finding{File: file, Reason: reason, Sample: match[:min(len(match), 8)]})
+	}
+
+	for _, match := range extendedPrivateKey.FindAllString(text, -1) {
+		add("extended private key", match)
+	}
+	for _, match := range wifCandidate.FindAllString(text, -1) {
+		if payload, err := base58.CheckDecode(match); err == nil && len(payload) > 0 && (payload[0] == 0x80 || payload[0] == 0xef) {
+			add("WIF private key", match)
+		}
+	}
+	for _, match := range hexKeyCandidate.FindAllString(text, -1) {
+		if !allowed[strings.ToLower(strings.TrimPrefix(match, "0x"))] {
+			add("32-byte hex string that is not a known transaction id", match)
+		}
+	}
+	for _, match := range secretFieldName.FindAllString(text, -1) {
+		add("secret field name", match)
+	}
+	if run := longestMnemonicRun(text); run != "" {
+		add(fmt.Sprintf("%d or more consecutive BIP39 words", mnemonicRun), run)
+	}
+	return findings
+}
+
+// longestMnemonicRun 返回第一段至少 mnemonicRun 个连续 BIP39 单词的开头
+func longestMnemonicRun(text string) string {
+	words := make(map[string]bool, 2048)
+	for _, word := range bip39.GetWordList() {
+		words[word] = true
+	}
+	run := 0
+	fields := wordSplitter.Split(strings.ToLower(text), -1)
+	for i, field := range fields {
+		if !words[field] {
+			run = 0
+			continue
+		}
+		if run++; run >= mnemonicRun {
+			return strings.Join(fields[i-run+1:i+1], " ")
+		}
+	}
+	return ""
+}
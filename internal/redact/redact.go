@@ -0,0 +1,81 @@
+// Package redact 从会话记录、日志等文本中去掉助记词、私钥和密码，
+// 只做替换不做判断，宁可多遮盖（交易 ID 和私钥一样是 32 字节十六进制串，也会被遮盖）
+package redact
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Mask 替换私密内容的占位符
+const Mask = "[REDACTED]"
+
+// mnemonicRun 连续出现多少个 BIP39 单词时视为助记词
+const mnemonicRun = 12
+
+var (
+	extendedPrivateKey = regexp.MustCompile(`[xyztuvYZUV]prv[1-9A-HJ-NP-Za-km-z]{100,}`)
+	wifCandidate       = regexp.MustCompile(`\b[5KLc9][1-9A-HJ-NP-Za-km-z]{50,51}\b`)
+	hexKey             = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{64}\b`)
+	secretAssignment   = regexp.MustCompile(`(?i)((?:password|passphrase|secret|mnemonic|private_?key|token)["']?\s*[:=]\s*)("[^"]*"|\S+)`)
+	word               = regexp.MustCompile(`[A-Za-z]+`)
+	wordGap            = regexp.MustCompile(`^[\s\d.):,-]*$`)
+)
+
+// String 遮盖文本中的扩展私钥、WIF 私钥、32 字节十六进制串、助记词以及 password=... 形式的赋值
+func String(text string) string {
+	text = extendedPrivateKey.ReplaceAllString(text, Mask)
+	text = wifCandidate.ReplaceAllStringFunc(text, func(match string) string {
+		if payload, err := base58.CheckDecode(match); err == nil && len(payload) > 0 && (payload[0] == 0x80 || payload[0] == 0xef) {
+			return Mask
+		}
+		return match
+	})
+	text = hexKey.ReplaceAllString(text, Mask)
+	text = secretAssignment.ReplaceAllString(text, "${1}"+Mask)
+	return mnemonics(text)
+}
+
+// mnemonics 把至少 mnemonicRun 个连续的 BIP39 单词（中间只允许空白、编号和标点）整段替换掉
+func mnemonics(text string) string {
+	words := make(map[string]bool, 2048)
+	for _, w := range bip39.GetWordList() {
+		words[w] = true
+	}
+
+	var (
+		b         strings.Builder
+		last      int
+		runStart  = -1
+		runEnd    int
+		runLength int
+	)
+	flush := func() {
+		if runLength >= mnemonicRun {
+			b.WriteString(text[last:runStart])
+			b.WriteString(Mask)
+			last = runEnd
+		}
+		runStart, runLength = -1, 0
+	}
+	for _, loc := range word.FindAllStringIndex(text, -1) {
+		switch {
+		case !words[strings.ToLower(text[loc[0]:loc[1]])]:
+			flush()
+			continue
+		case runStart >= 0 && !wordGap.MatchString(text[runEnd:loc[0]]):
+			flush()
+		}
+		if runStart < 0 {
+			runStart = loc[0]
+		}
+		runEnd = loc[1]
+		runLength++
+	}
+	flush()
+	b.WriteString(text[last:])
+	return b.String()
+}
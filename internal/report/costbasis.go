@@ -0,0 +1,308 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 成本计算方法
+const (
+	FIFO = "fifo" // 先买入的先卖出
+	LIFO = "lifo" // 后买入的先卖出
+)
+
+// ErrUnknownMethod 不支持的成本计算方法
+var ErrUnknownMethod = errors.New("unknown cost basis method, use fifo or lifo")
+
+// Lot 一笔入账形成的持仓批次；Rate 为入账时 1 个币的法币价格，没有记录时为 nil
+type Lot struct {
+	Coin      string
+	AccountID string
+	TxID      string
+	Acquired  time.Time
+	Amount    *big.Int
+	Remaining *big.Int
+	Rate      *big.Rat
+}
+
+// Disposal 一笔支出中对应一个批次的部分。Unmatched 表示找不到足够的批次（资金在开始记录之前就已持有），
+// Cost 为 nil 表示成本未知，Proceeds 为 nil 表示支出时没有记录价格
+type Disposal struct {
+	TxID      string
+	LotTxID   string
+	Acquired  time.Time
+	Sold      time.Time
+	Quantity  *big.Int
+	Proceeds  *big.Rat
+	Cost      *big.Rat
+	Internal  bool // 转到本钱包自己的地址，只处置手续费
+	Unmatched bool
+}
+
+// Gain 收益（亏损为负），收入或成本未知时为 nil
+func (d *Disposal) Gain() *big.Rat {
+	if d.Proceeds == nil || d.Cost == nil {
+		return nil
+	}
+	return new(big.Rat).Sub(d.Proceeds, d.Cost)
+}
+
+// LongTerm 持有超过一年
+func (d *Disposal) LongTerm() bool {
+	return !d.Unmatched && d.Sold.After(d.Acquired.AddDate(1, 0, 0))
+}
+
+// CostBasis 期间内的处置及其成本基础，金额为 Currency 法币
+type CostBasis struct {
+	Coin      string
+	Currency  string
+	Method    string
+	Decimals  int
+	Period    Period
+	Disposals []*Disposal
+	Lots      []*Lot // 期间结束时仍有余额的批次
+	Proceeds  *big.Rat
+	Cost      *big.Rat
+	Gain      *big.Rat
+	Missing   int // 缺少价格、收入或成本未知的处置数
+	Unmatched int // 找不到批次的处置数
+}
+
+// event 按时间排序的入账或支出
+type event struct {
+	at    time.Time
+	lot   *Lot
+	spend *watch.Spend
+}
+
+// BuildCostBasis 按 method 把币种的全部支出与之前的入账批次配对，返回期间内的处置。
+// 入账按 currency 的价格计成本；本钱包广播的交易的找零和内部转账不形成批次，
+// 内部转账只处置手续费（收入为零）。own 判断地址是否属于本钱包
+func BuildCostBasis(coinSymbol, currency, method string, period Period, payments []watch.Payment, spends []watch.Spend,
+	own func(address string) bool) (*CostBasis, error) {
+	method = strings.ToLower(method)
+	if method != FIFO && method != LIFO {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMethod, method)
+	}
+	result := &CostBasis{
+		Coin:     coinSymbol,
+		Currency: currency,
+		Method:   method,
+		Period:   period,
+		Proceeds: new(big.Rat),
+		Cost:     new(big.Rat),
+		Gain:     new(big.Rat),
+	}
+	if info, ok := coin.LookupSymbol(coinSymbol); ok {
+		result.Decimals = info.Decimal
+	}
+
+	var events []event
+	for _, lot := range Lots(coinSymbol, currency, payments, spends) {
+		events = append(events, event{at: lot.Acquired, lot: lot})
+	}
+	for i := range spends {
+		if spends[i].Coin == coinSymbol {
+			events = append(events, event{at: spends[i].SentAt, spend: &spends[i]})
+		}
+	}
+	// 同一时刻先入账后支出
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].lot != nil && events[j].lot == nil
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	var open []*Lot
+	for _, e := range events {
+		if !e.at.Before(period.End) {
+			break
+		}
+		if e.lot != nil {
+			open = append(open, e.lot)
+			continue
+		}
+		disposals := result.dispose(e.spend, open, method, own(e.spend.To))
+		open = withBalance(open)
+		if !period.Contains(e.at) {
+			continue
+		}
+		for _, d := range disposals {
+			result.Disposals = append(result.Disposals, d)
+			if d.Unmatched {
+				result.Unmatched++
+			} else if d.Gain() == nil {
+				result.Missing++
+			}
+			if d.Proceeds != nil {
+				result.Proceeds.Add(result.Proceeds, d.Proceeds)
+			}
+			if d.Cost != nil {
+				result.Cost.Add(result.Cost, d.Cost)
+			}
+			if gain := d.Gain(); gain != nil {
+				result.Gain.Add(result.Gain, gain)
+			}
+		}
+	}
+	result.Lots = open
+	return result, nil
+}
+
+// Lots 币种的全部入账批次，按入账时间排序；只读地址和本钱包交易的找零不计入
+func Lots(coinSymbol, currency string, payments []watch.Payment, spends []watch.Spend) []*Lot {
+	ours := make(map[string]bool)
+	for _, spend := range spends {
+		ours[spend.TxID] = true
+	}
+	var lots []*Lot
+	for _, payment := range payments {
+		if payment.Coin != coinSymbol || payment.WatchOnly || (payment.TxID != "" && ours[payment.TxID]) {
+			continue
+		}
+		amount := parseAmount(payment.Amount)
+		lots = append(lots, &Lot{
+			Coin:      payment.Coin,
+			AccountID: payment.AccountID,
+			TxID:      payment.TxID,
+			Acquired:  payment.DetectedAt,
+			Amount:    amount,
+			Remaining: new(big.Int).Set(amount),
+			Rate:      fiatRate(payment.Fiat, currency),
+		})
+	}
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].Acquired.Before(lots[j].Acquired)
+	})
+	return lots
+}
+
+// dispose 从批次中扣除一笔支出处置的数量：外部支出为金额加手续费，内部转账只有手续费
+func (c *CostBasis) dispose(spend *watch.Spend, open []*Lot, method string, internal bool) []*Disposal {
+	amount, fee := parseAmount(spend.Amount), parseAmount(spend.Fee)
+	quantity := new(big.Int).Set(fee)
+	var proceeds *big.Rat
+	rate := fiatRate(spend.Fiat, c.Currency)
+	if internal {
+		proceeds = new(big.Rat)
+	} else {
+		quantity.Add(quantity, amount)
+		if rate != nil {
+			// 手续费视为减少收入
+			proceeds = new(big.Rat).Mul(c.coins(amount), rate)
+		}
+	}
+	if quantity.Sign() == 0 {
+		return nil
+	}
+
+	order := make([]*Lot, len(open))
+	copy(order, open)
+	if method == LIFO {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+	var result []*Disposal
+	remaining := new(big.Int).Set(quantity)
+	share := func(part *big.Int) *big.Rat {
+		if proceeds == nil {
+			return nil
+		}
+		ratio := new(big.Rat).SetFrac(part, quantity)
+		return ratio.Mul(ratio, proceeds)
+	}
+	for _, lot := range order {
+		if remaining.Sign() == 0 {
+			break
+		}
+		if lot.Remaining.Sign() == 0 {
+			continue
+		}
+		part := new(big.Int).Set(remaining)
+		if part.Cmp(lot.Remaining) > 0 {
+			part.Set(lot.Remaining)
+		}
+		lot.Remaining.Sub(lot.Remaining, part)
+		remaining.Sub(remaining, part)
+		d := &Disposal{TxID: spend.TxID, LotTxID: lot.TxID, Acquired: lot.Acquired, Sold: spend.SentAt,
+			Quantity: part, Proceeds: share(part), Internal: internal}
+		if lot.Rate != nil {
+			d.Cost = new(big.Rat).Mul(c.coins(part), lot.Rate)
+		}
+		result = append(result, d)
+	}
+	if remaining.Sign() > 0 {
+		result = append(result, &Disposal{TxID: spend.TxID, Sold: spend.SentAt, Quantity: remaining,
+			Proceeds: share(remaining), Internal: internal, Unmatched: true})
+	}
+	return result
+}
+
+// coins 最小单位换算为整币
+func (c *CostBasis) coins(units *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(units, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.Decimals)), nil))
+}
+
+// WriteCSV 按常见报税软件（Form 8949 格式）导入的列输出：Description, Date Acquired, Date Sold,
+// Proceeds, Cost Basis, Gain or Loss, Term；日期为 MM/DD/YYYY，找不到批次时取得日期写 VARIOUS，未知的金额留空
+func (c *CostBasis) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"Description", "Date Acquired", "Date Sold", "Proceeds", "Cost Basis", "Gain or Loss", "Term", "Transaction ID"})
+	for _, d := range c.Disposals {
+		description := coin.FormatUnits(d.Quantity, c.Decimals) + " " + c.Coin
+		if d.Internal {
+			description += " (network fee)"
+		}
+		acquired, term := d.Acquired.UTC().Format("01/02/2006"), "Short"
+		if d.Unmatched {
+			acquired, term = "VARIOUS", ""
+		} else if d.LongTerm() {
+			term = "Long"
+		}
+		out.Write([]string{description, acquired, d.Sold.UTC().Format("01/02/2006"),
+			money(d.Proceeds), money(d.Cost), money(d.Gain()), term, d.TxID})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// money 保留两位小数，nil 为空
+func money(value *big.Rat) string {
+	if value == nil {
+		return ""
+	}
+	return value.FloatString(2)
+}
+
+// fiatRate 记录中 currency 的价格，没有记录或币种不同时为 nil
+func fiatRate(fiat *watch.Fiat, currency string) *big.Rat {
+	if fiat == nil || !strings.EqualFold(fiat.Currency, currency) {
+		return nil
+	}
+	rate, ok := new(big.Rat).SetString(fiat.Rate)
+	if !ok {
+		return nil
+	}
+	return rate
+}
+
+func withBalance(lots []*Lot) []*Lot {
+	result := lots[:0]
+	for _, lot := range lots {
+		if lot.Remaining.Sign() > 0 {
+			result = append(result, lot)
+		}
+	}
+	return result
+}
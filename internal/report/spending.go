@@ -0,0 +1,252 @@
+// Package report 根据交易记录生成账户报表
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/watch"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 方向
+const (
+	In       = "in"
+	Out      = "out"
+	Internal = "internal" // 转到本钱包自己的地址，只计手续费
+)
+
+// ErrInvalidPeriod 无法识别的报表期间
+var ErrInvalidPeriod = errors.New("invalid period, use YYYY, YYYYQ1-4 or YYYY-MM")
+
+// Period 报表期间 [Start, End)
+type Period struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+var periodPattern = regexp.MustCompile(`^(\d{4})(?:[Qq]([1-4])|-(\d{2}))?$`)
+
+// ParsePeriod 解析 2024、2024Q4 或 2024-11，按 loc 时区的日历计算
+func ParsePeriod(text string, loc *time.Location) (Period, error) {
+	m := periodPattern.FindStringSubmatch(text)
+	if m == nil {
+		return Period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, text)
+	}
+	year, _ := strconv.Atoi(m[1])
+	start, months := time.Date(year, 1, 1, 0, 0, 0, 0, loc), 12
+	switch {
+	case m[2] != "":
+		quarter, _ := strconv.Atoi(m[2])
+		start, months = start.AddDate(0, 3*(quarter-1), 0), 3
+	case m[3] != "":
+		month, _ := strconv.Atoi(m[3])
+		if month < 1 || month > 12 {
+			return Period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, text)
+		}
+		start, months = start.AddDate(0, month-1, 0), 1
+	}
+	return Period{Name: text, Start: start, End: start.AddDate(0, months, 0)}, nil
+}
+
+// Contains 时间是否在期间内
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Entry 一笔交易
+type Entry struct {
+	Time         time.Time
+	Direction    string
+	Counterparty string
+	Address      string // 入账为本钱包的收款地址，支出为对方地址
+	TxID         string
+	Amount       *big.Int
+	Fee          *big.Int
+}
+
+// Month 一个月的汇总
+type Month struct {
+	Month time.Time
+	In    *big.Int
+	Out   *big.Int
+	Fees  *big.Int
+	Count int
+}
+
+// Counterparty 一个交易对手的汇总
+type Counterparty struct {
+	Name  string
+	In    *big.Int
+	Out   *big.Int
+	Count int
+}
+
+// Spending 账户在一个期间内的收支报表，金额为最小单位
+type Spending struct {
+	AccountID      string
+	AccountLabel   string
+	Coin           string
+	Decimals       int
+	Period         Period
+	In             *big.Int
+	Out            *big.Int
+	Fees           *big.Int
+	Months         []*Month
+	Counterparties []*Counterparty
+	Entries        []*Entry
+}
+
+// Labeler 返回地址的显示名称（联系人或标签），没有时返回空
+type Labeler func(address string) string
+
+// Build 汇总账户在期间内的入账和支出。本钱包广播的交易的找零不算入账；
+// 支出到本钱包自己的地址 (own) 记为内部转账，只计手续费
+func Build(accountID, accountLabel, coinSymbol string, period Period, payments []watch.Payment, spends []watch.Spend,
+	own func(address string) bool, label Labeler) *Spending {
+	report := &Spending{
+		AccountID:    accountID,
+		AccountLabel: accountLabel,
+		Coin:         coinSymbol,
+		Period:       period,
+		In:           new(big.Int),
+		Out:          new(big.Int),
+		Fees:         new(big.Int),
+	}
+	if info, ok := coin.LookupSymbol(coinSymbol); ok {
+		report.Decimals = info.Decimal
+	}
+
+	// 本钱包广播的交易，入账与其 txid 相同时是找零或内部转账
+	sentBy := make(map[string]string)
+	for _, spend := range spends {
+		sentBy[spend.TxID] = spend.AccountID
+	}
+	for _, spend := range spends {
+		if spend.AccountID != accountID || !period.Contains(spend.SentAt) {
+			continue
+		}
+		entry := &Entry{Time: spend.SentAt, Direction: Out, Address: spend.To, TxID: spend.TxID,
+			Amount: parseAmount(spend.Amount), Fee: parseAmount(spend.Fee)}
+		entry.Counterparty = label(spend.To)
+		if own(spend.To) {
+			entry.Direction = Internal
+			if entry.Counterparty == "" {
+				entry.Counterparty = "own address"
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	for _, payment := range payments {
+		if payment.AccountID != accountID || !period.Contains(payment.DetectedAt) {
+			continue
+		}
+		from, ours := sentBy[payment.TxID]
+		if ours && from == accountID {
+			continue
+		}
+		// 入账看不到付款方，用收款地址的标签或收款请求区分
+		entry := &Entry{Time: payment.DetectedAt, Direction: In, Address: payment.Address, TxID: payment.TxID,
+			Amount: parseAmount(payment.Amount), Fee: new(big.Int)}
+		switch {
+		case ours:
+			entry.Counterparty = "own account " + from
+		case label(payment.Address) != "":
+			entry.Counterparty = label(payment.Address)
+		case payment.RequestID != "":
+			entry.Counterparty = "request " + payment.RequestID
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return report.Entries[i].Time.Before(report.Entries[j].Time)
+	})
+
+	for month := period.Start; month.Before(period.End); month = month.AddDate(0, 1, 0) {
+		report.Months = append(report.Months, &Month{Month: month, In: new(big.Int), Out: new(big.Int), Fees: new(big.Int)})
+	}
+	byName := make(map[string]*Counterparty)
+	for _, entry := range report.Entries {
+		month := report.Months[monthIndex(period.Start, entry.Time.In(period.Start.Location()))]
+		month.Count++
+		month.Fees.Add(month.Fees, entry.Fee)
+		report.Fees.Add(report.Fees, entry.Fee)
+		if entry.Direction == Internal {
+			continue
+		}
+		name := entry.Counterparty
+		if name == "" {
+			name = "unlabeled"
+		}
+		party := byName[name]
+		if party == nil {
+			party = &Counterparty{Name: name, In: new(big.Int), Out: new(big.Int)}
+			byName[name] = party
+			report.Counterparties = append(report.Counterparties, party)
+		}
+		party.Count++
+		if entry.Direction == In {
+			month.In.Add(month.In, entry.Amount)
+			party.In.Add(party.In, entry.Amount)
+			report.In.Add(report.In, entry.Amount)
+		} else {
+			month.Out.Add(month.Out, entry.Amount)
+			party.Out.Add(party.Out, entry.Amount)
+			report.Out.Add(report.Out, entry.Amount)
+		}
+	}
+	// 按往来总额从大到小
+	sort.SliceStable(report.Counterparties, func(i, j int) bool {
+		a := new(big.Int).Add(report.Counterparties[i].In, report.Counterparties[i].Out)
+		b := new(big.Int).Add(report.Counterparties[j].In, report.Counterparties[j].Out)
+		return a.Cmp(b) > 0
+	})
+	return report
+}
+
+// Net 期间内的净变化：入账减去支出和手续费
+func (s *Spending) Net() *big.Int {
+	net := new(big.Int).Sub(s.In, s.Out)
+	return net.Sub(net, s.Fees)
+}
+
+// WriteCSV 每笔交易一行，金额为带小数点的整币单位，不做本地化，便于报税软件导入
+func (s *Spending) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"date", "direction", "counterparty", "address", "txid", "amount", "fee", "coin", "account_id"})
+	for _, entry := range s.Entries {
+		out.Write([]string{
+			entry.Time.UTC().Format(time.RFC3339),
+			entry.Direction,
+			entry.Counterparty,
+			entry.Address,
+			entry.TxID,
+			coin.FormatUnits(entry.Amount, s.Decimals),
+			coin.FormatUnits(entry.Fee, s.Decimals),
+			s.Coin,
+			s.AccountID,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func monthIndex(start, t time.Time) int {
+	return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+}
+
+func parseAmount(text string) *big.Int {
+	value, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 端点连续失败后被熔断，冷却期内不再发起请求
+var ErrCircuitOpen = errors.New("circuit breaker open: endpoint failing, retry later")
+
+// Breaker 单个端点的熔断器：连续失败达到阈值后打开，冷却期过后放行一次试探请求（半开），
+// 试探成功则关闭，失败则重新计时
+type Breaker struct {
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*Breaker)
+)
+
+// BreakerFor 返回端点的熔断器，同一端点在进程内共享
+func BreakerFor(endpoint string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[endpoint]
+	if !ok {
+		b = &Breaker{}
+		breakers[endpoint] = b
+	}
+	return b
+}
+
+// Allow 是否允许发起请求
+func (b *Breaker) Allow(policy Policy) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if policy.BreakerThreshold <= 0 || b.failures < policy.BreakerThreshold {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < policy.BreakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success 记录成功，关闭熔断器
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.probing = 0, false
+}
+
+// Failure 记录失败，试探失败时重新开始冷却
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.openedAt = time.Now()
+	b.probing = false
+}
+
+// Failures 当前连续失败的次数
+func (b *Breaker) Failures() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures
+}
+
+// Open 熔断器当前是否打开
+func (b *Breaker) Open(policy Policy) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return policy.BreakerThreshold > 0 && b.failures >= policy.BreakerThreshold
+}
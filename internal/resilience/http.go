@@ -0,0 +1,122 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/tracing"
+)
+
+// Transport 为 http.Client 加上重试和熔断，按请求的 host:port 区分端点。
+// 连接错误和 429、502、503、504 会重试，其他状态码原样返回给调用方
+type Transport struct {
+	Base    http.RoundTripper
+	Timeout time.Duration // 单次尝试的默认超时
+}
+
+// NewHTTPClient 创建带重试和熔断的 HTTP 客户端，timeout 为单次尝试的默认超时
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: &Transport{Timeout: timeout}}
+}
+
+// statusError 可重试的 HTTP 状态码
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string { return e.status }
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	// 只记录端点，路径和查询参数中可能有地址或 API 密钥
+	_, span := tracing.Start(req.Context(), tracing.Client, "HTTP "+req.Method, "http.method", req.Method, "server.address", req.URL.Host)
+	defer func() {
+		if resp != nil {
+			span.Set("http.status_code", strconv.Itoa(resp.StatusCode))
+		}
+		span.End(err)
+	}()
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	endpoint := req.URL.Host
+	policy := PolicyFor(endpoint, t.Timeout)
+	timeout := policy.Timeout
+	// 响应体在返回后才被读取，单次超时由这里管理，不交给 Retry
+	policy.Timeout = 0
+
+	var last *http.Response
+	attempt := 0
+	err = Retry(req.Context(), endpoint, policy, func(ctx context.Context) error {
+		if last != nil {
+			last.Body.Close()
+			last = nil
+		}
+		attempt++
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		attemptReq := req.Clone(attemptCtx)
+		if req.Body != nil && req.Body != http.NoBody && attempt > 1 {
+			if req.GetBody == nil {
+				cancel()
+				return Permanent(errors.New("request body cannot be replayed"))
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return Permanent(err)
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := base.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			return err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		last = resp
+		if retryableStatus(resp.StatusCode) {
+			return &statusError{status: fmt.Sprintf("%s: %s", endpoint, resp.Status)}
+		}
+		return nil
+	})
+
+	span.Set("attempts", strconv.Itoa(attempt))
+	var se *statusError
+	if err == nil || (errors.As(err, &se) && last != nil) {
+		// 重试用尽后把最后一个响应交给调用方，由调用方解释状态码
+		return last, nil
+	}
+	if last != nil {
+		last.Body.Close()
+	}
+	return nil, err
+}
+
+// cancelBody 关闭响应体时释放单次尝试的超时 context
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,57 @@
+package resilience
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats 单个端点的调用统计
+type Stats struct {
+	Endpoint    string    `json:"endpoint"`
+	Calls       int64     `json:"calls"`    // 调用次数，重试不重复计数
+	Failures    int64     `json:"failures"` // 失败的尝试，包括之后重试成功的
+	Retries     int64     `json:"retries"`
+	Rejected    int64     `json:"rejected"` // 被熔断拒绝的调用
+	Consecutive int       `json:"consecutive_failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = make(map[string]*Stats)
+)
+
+func record(endpoint string, update func(s *Stats)) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	s, ok := stats[endpoint]
+	if !ok {
+		s = &Stats{Endpoint: endpoint}
+		stats[endpoint] = s
+	}
+	update(s)
+}
+
+func recordFailure(endpoint string, err error) {
+	record(endpoint, func(s *Stats) {
+		s.Failures++
+		s.LastError = err.Error()
+		s.LastFailure = time.Now().UTC()
+	})
+}
+
+// Snapshot 返回所有端点的统计，按端点名排序
+func Snapshot() []Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	result := make([]Stats, 0, len(stats))
+	for _, s := range stats {
+		snapshot := *s
+		snapshot.Consecutive = BreakerFor(s.Endpoint).Failures()
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Endpoint < result[j].Endpoint })
+	return result
+}
@@ -0,0 +1,41 @@
+package resilience
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+)
+
+// PolicyFor 根据 [network] 配置返回端点的策略，timeout 为调用方的默认单次超时，
+// 可以被 network.timeouts 中的 host 或 host:port 覆盖
+func PolicyFor(endpoint string, timeout time.Duration) Policy {
+	appConfig := config.GetAppConfig()
+	networkConfig := appConfig.GetNetworkConfig()
+	policy := Policy{
+		Attempts:         networkConfig.Retries + 1,
+		BaseDelay:        time.Duration(networkConfig.BackoffMs) * time.Millisecond,
+		MaxDelay:         time.Duration(networkConfig.MaxBackoffMs) * time.Millisecond,
+		Timeout:          timeout,
+		BreakerThreshold: networkConfig.BreakerThreshold,
+		BreakerCooldown:  time.Duration(networkConfig.BreakerCooldown) * time.Second,
+	}
+
+	host := endpoint
+	if h, _, err := net.SplitHostPort(endpoint); err == nil {
+		host = h
+	}
+	for _, entry := range networkConfig.Timeouts {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || (key != endpoint && key != host) {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds > 0 {
+			policy.Timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	return policy
+}
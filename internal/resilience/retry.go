@@ -0,0 +1,110 @@
+// Package resilience 外部调用的重试、熔断和超时策略，以及按端点统计的失败指标
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy 一个端点的调用策略
+type Policy struct {
+	Attempts         int           // 最多尝试次数（含第一次）
+	BaseDelay        time.Duration // 第一次重试前的最大等待，之后指数增长
+	MaxDelay         time.Duration // 单次等待上限
+	Timeout          time.Duration // 单次尝试的超时，0 表示不限制
+	BreakerThreshold int           // 连续失败多少次后熔断，0 表示不熔断
+	BreakerCooldown  time.Duration // 熔断后多久放行一次试探请求
+}
+
+// permanentError 不应重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记错误不需要重试，如参数错误或服务端明确拒绝
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 错误是否被标记为不需要重试
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Retry 按策略调用 fn，失败时以带抖动的指数退避重试；
+// 端点熔断时直接返回 ErrCircuitOpen，Permanent 错误不重试也不计入熔断
+func Retry(ctx context.Context, endpoint string, policy Policy, fn func(ctx context.Context) error) error {
+	breaker := BreakerFor(endpoint)
+	record(endpoint, func(s *Stats) { s.Calls++ })
+	attempts := max(policy.Attempts, 1)
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			record(endpoint, func(s *Stats) { s.Retries++ })
+			if err := sleep(ctx, backoff(policy, attempt)); err != nil {
+				return err
+			}
+		}
+		if !breaker.Allow(policy) {
+			record(endpoint, func(s *Stats) { s.Rejected++ })
+			return ErrCircuitOpen
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if policy.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		}
+		err = fn(attemptCtx)
+		cancel()
+
+		switch {
+		case err == nil:
+			breaker.Success()
+			return nil
+		case IsPermanent(err):
+			breaker.Success()
+			return errors.Unwrap(err)
+		}
+		breaker.Failure()
+		recordFailure(endpoint, err)
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff 第 attempt 次重试前的等待时间：[0, min(MaxDelay, BaseDelay*2^(attempt-1))) 内随机（full jitter）
+func backoff(policy Policy, attempt int) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+	delay := policy.BaseDelay << (attempt - 1)
+	if delay <= 0 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(delay) + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+// Package search 在内存中索引账户、地址、标签、标记和联系人，支持前缀和子串查找，可以按标记过滤
+package search
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+)
+
+// ErrEmptyQuery 查询为空
+var ErrEmptyQuery = errors.New("empty search query")
+
+// Kind 结果类型
+type Kind string
+
+const (
+	KindAccount Kind = "account"
+	KindAddress Kind = "address"
+	KindLabel   Kind = "label"
+	KindContact Kind = "contact"
+	KindTag     Kind = "tag"
+)
+
+// kindOrder 同等匹配程度时结果的排列顺序
+var kindOrder = map[Kind]int{KindAccount: 0, KindAddress: 1, KindLabel: 2, KindTag: 3, KindContact: 4}
+
+// Result 一条查找结果，Field 是命中的字段，Value 是该字段的值
+type Result struct {
+	Kind      Kind   `json:"kind"`
+	Key       string `json:"key"` // 账户 ID、地址、加了标签或标记的账户 ID 或地址、联系人名称
+	Field     string `json:"field"`
+	Value     string `json:"value"`
+	AccountID string `json:"account_id,omitempty"`
+	Coin      string `json:"coin,omitempty"`
+	rank      int    // 0 完全匹配，1 前缀匹配，2 子串匹配
+}
+
+type entry struct {
+	Result
+	lower string
+}
+
+// Index 只读的内存索引，数据变化后需要重新构建
+type Index struct {
+	entries []entry
+	tags    map[string][]string // 账户 ID 或地址 -> 标记
+}
+
+// Build 从账户管理器和元数据构建索引，需要钱包已解锁
+func Build(accountMgr core.AccountManager, meta *metadata.Store) (*Index, error) {
+	accounts, err := accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{tags: make(map[string][]string)}
+	for _, account := range accounts {
+		idx.add(KindAccount, account.ID, "id", account.ID, account.ID, account.CoinSymbol)
+		idx.add(KindAccount, account.ID, "path", account.DerivationPath, account.ID, account.CoinSymbol)
+		idx.add(KindAccount, account.ID, "coin", account.CoinSymbol, account.ID, account.CoinSymbol)
+
+		addresses, err := accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addresses {
+			idx.add(KindAddress, addr.Address, "address", addr.Address, addr.AccountID, addr.CoinSymbol)
+		}
+	}
+
+	if meta != nil {
+		for _, alias := range meta.Keys(metadata.Aliases) {
+			accountID, _ := meta.Get(metadata.Aliases, alias)
+			idx.add(KindAccount, accountID, "alias", alias, accountID, "")
+		}
+		for _, target := range meta.Keys(metadata.Labels) {
+			label, _ := meta.Get(metadata.Labels, target)
+			idx.add(KindLabel, target, "label", label, "", "")
+		}
+		for _, target := range meta.Keys(metadata.Tags) {
+			tags := meta.Tags(target)
+			idx.tags[target] = tags
+			for _, tag := range tags {
+				idx.add(KindTag, target, "tag", tag, "", "")
+			}
+		}
+		for _, name := range meta.Keys(metadata.Contacts) {
+			address, _ := meta.Get(metadata.Contacts, name)
+			idx.add(KindContact, name, "contact", name, "", "")
+			idx.add(KindContact, name, "address", address, "", "")
+		}
+	}
+	return idx, nil
+}
+
+func (idx *Index) add(kind Kind, key, field, value, accountID, coin string) {
+	if value == "" {
+		return
+	}
+	idx.entries = append(idx.entries, entry{
+		Result: Result{Kind: kind, Key: key, Field: field, Value: value, AccountID: accountID, Coin: coin},
+		lower:  strings.ToLower(value),
+	})
+}
+
+// Find 不区分大小写地查找，完全匹配优先于前缀匹配，前缀匹配优先于子串匹配；
+// 同一对象命中多个字段时只返回最好的一条，limit <= 0 表示不限制数量
+func (idx *Index) Find(query string, limit int) ([]Result, error) {
+	return idx.FindTagged(query, "", limit)
+}
+
+// FindTagged 同 Find，tag 非空时只返回本身或所属账户有该标记（含下级标记）的结果，tag 应已规范化
+func (idx *Index) FindTagged(query, tag string, limit int) ([]Result, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	best := make(map[string]Result)
+	for _, e := range idx.entries {
+		if tag != "" && !idx.hasTag(e.Key, tag) && !idx.hasTag(e.AccountID, tag) {
+			continue
+		}
+		rank := 2
+		switch {
+		case e.lower == query:
+			rank = 0
+		case strings.HasPrefix(e.lower, query):
+			rank = 1
+		case !strings.Contains(e.lower, query):
+			continue
+		}
+		id := string(e.Kind) + "\x00" + e.Key
+		if current, ok := best[id]; ok && current.rank <= rank {
+			continue
+		}
+		result := e.Result
+		result.rank = rank
+		best[id] = result
+	}
+
+	results := make([]Result, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		return a.Key < b.Key
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// hasTag 账户 ID 或地址是否有匹配的标记
+func (idx *Index) hasTag(key, tag string) bool {
+	for _, t := range idx.tags[key] {
+		if metadata.MatchTag(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len 索引的条目数
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
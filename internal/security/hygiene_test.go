@@ -0,0 +1,247 @@
+package security
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// secretAPIs 返回解密出的私密材料（种子、助记词、账户和地址私钥）的函数、方法和接口方法，按所在目录列出；
+// 它们的结果中必须有 *SecureBytes，调用方拿到后必须 Destroy 或原样返回
+var secretAPIs = map[string][]string{
+	"internal/security": {"Decrypt"},
+	"internal/core":     {"Seed", "AddressPrivateKey", "accountKey", "ExportMnemonic"},
+}
+
+// rawDecryptAllowed 可以直接调用 crypto.DecryptData 的目录：
+// backup 解密的是整份加密备份包，内容本身仍是加密的存储文件
+var rawDecryptAllowed = map[string]bool{"internal/security": true, "internal/backup": true}
+
+// sourceFile 模块中的一个非测试源文件
+type sourceFile struct {
+	dir  string // 相对模块根目录的目录
+	file *ast.File
+}
+
+// TestSecretHygiene 检查解密出的私密材料不以普通 []byte 或字符串的形式流出：
+// 只有本包调用 crypto.DecryptData，secretAPIs 返回 *SecureBytes，每个调用方都 Destroy 了拿到的值
+func TestSecretHygiene(t *testing.T) {
+	fset := token.NewFileSet()
+	files := parseModule(t, fset, filepath.Join("..", ".."))
+
+	// 规则 1：crypto.DecryptData 只能在 security 包中调用
+	for _, f := range files {
+		if rawDecryptAllowed[f.dir] {
+			continue
+		}
+		ast.Inspect(f.file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && isSelector(call.Fun, "crypto", "DecryptData") {
+				t.Errorf("%s: crypto.DecryptData outside internal/security, use security.Decrypt instead", fset.Position(call.Pos()))
+			}
+			return true
+		})
+	}
+
+	// 规则 2：secretAPIs 的声明（包括接口中的方法）返回 *SecureBytes，记下它在结果中的位置；
+	// 包级函数按 包名.函数名 识别调用，方法按方法名识别
+	results := make(map[string]int)
+	check := func(dir, name string, method bool, ftype *ast.FuncType, pos token.Pos) {
+		if !contains(secretAPIs[dir], name) {
+			return
+		}
+		index := secureResult(ftype)
+		if index < 0 {
+			t.Errorf("%s: %s returns decrypted secrets and must return *security.SecureBytes", fset.Position(pos), name)
+			return
+		}
+		if !method {
+			name = path.Base(dir) + "." + name
+		}
+		results[name] = index
+	}
+	for _, f := range files {
+		ast.Inspect(f.file, func(n ast.Node) bool {
+			switch decl := n.(type) {
+			case *ast.FuncDecl:
+				check(f.dir, decl.Name.Name, decl.Recv != nil, decl.Type, decl.Pos())
+			case *ast.InterfaceType:
+				for _, method := range decl.Methods.List {
+					if ftype, ok := method.Type.(*ast.FuncType); ok && len(method.Names) == 1 {
+						check(f.dir, method.Names[0].Name, true, ftype, method.Pos())
+					}
+				}
+			}
+			return true
+		})
+	}
+	for dir, names := range secretAPIs {
+		for _, name := range names {
+			_, method := results[name]
+			_, function := results[path.Base(dir)+"."+name]
+			if !method && !function {
+				t.Errorf("%s.%s not found; update secretAPIs when renaming or moving it", dir, name)
+			}
+		}
+	}
+
+	// 规则 3：调用 secretAPIs 得到的 SecureBytes 在之后被 Destroy，或者原样返回给调用方
+	for _, f := range files {
+		for _, decl := range f.file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				assign, ok := n.(*ast.AssignStmt)
+				if !ok || len(assign.Rhs) != 1 {
+					return true
+				}
+				call, ok := assign.Rhs[0].(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				name, index, ok := secretCall(results, call, path.Base(f.dir))
+				if !ok || index >= len(assign.Lhs) {
+					return true
+				}
+				owner, ok := assign.Lhs[index].(*ast.Ident)
+				switch {
+				case !ok:
+					return true
+				case owner.Name == "_":
+					t.Errorf("%s: the *SecureBytes returned by %s is discarded without Destroy", fset.Position(assign.Pos()), name)
+				case !released(fn.Body, owner.Name, assign.End()):
+					t.Errorf("%s: %s from %s is never destroyed or returned", fset.Position(assign.Pos()), owner.Name, name)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// parseModule 解析 root 之下的全部非测试 Go 文件，跳过隐藏目录和 testdata
+func parseModule(t *testing.T, fset *token.FileSet, root string) []sourceFile {
+	t.Helper()
+	var files []sourceFile
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filename != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(filename, ".go") || strings.HasSuffix(filename, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			return err
+		}
+		dir, err := filepath.Rel(root, filepath.Dir(filename))
+		if err != nil {
+			return err
+		}
+		files = append(files, sourceFile{dir: filepath.ToSlash(dir), file: file})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no source files found")
+	}
+	return files
+}
+
+// secureResult *SecureBytes 在结果中的位置，没有时返回 -1
+func secureResult(ftype *ast.FuncType) int {
+	if ftype.Results == nil {
+		return -1
+	}
+	index := 0
+	for _, field := range ftype.Results.List {
+		count := max(len(field.Names), 1)
+		if star, ok := field.Type.(*ast.StarExpr); ok {
+			if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "SecureBytes" {
+				return index
+			}
+			if isSelector(star.X, "security", "SecureBytes") {
+				return index
+			}
+		}
+		index += count
+	}
+	return -1
+}
+
+// released 在 after 之后的函数体中，name 被调用了 Destroy（包括 defer），或者作为返回值交给调用方
+func released(body *ast.BlockStmt, name string, after token.Pos) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found || n == nil || n.Pos() < after {
+			return !found
+		}
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if isSelector(node.Fun, name, "Destroy") {
+				found = true
+			}
+		case *ast.ReturnStmt:
+			for _, result := range node.Results {
+				if ident, ok := result.(*ast.Ident); ok && ident.Name == name {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// secretCall 调用的是否为 secretAPIs 之一，返回它的名称和 *SecureBytes 在结果中的位置；
+// 包级函数按 包名.函数名 匹配（pkg 中的直接调用补上 pkg），其他选择器按方法名匹配
+func secretCall(results map[string]int, call *ast.CallExpr, pkg string) (string, int, bool) {
+	var names []string
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		names = []string{pkg + "." + fun.Name}
+	case *ast.SelectorExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			names = append(names, ident.Name+"."+fun.Sel.Name)
+		}
+		names = append(names, fun.Sel.Name)
+	}
+	for _, name := range names {
+		if index, ok := results[name]; ok {
+			return name, index, true
+		}
+	}
+	return "", 0, false
+}
+
+// isSelector expr 是否为 x.sel
+func isSelector(expr ast.Expr, x, sel string) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != sel {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == x
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -22,13 +22,18 @@ type PasswordManager struct {
 // GetPasswordManager 获取密码管理器单例实例
 func GetPasswordManager() *PasswordManager {
 	once.Do(func() {
-		instance = &PasswordManager{
-			isSealed: true, // 初始状态为已锁定
-		}
+		instance = NewPasswordManager()
 	})
 	return instance
 }
 
+// NewPasswordManager 创建独立的密码管理器，多用户模式下每个钱包命名空间一个，互不可见
+func NewPasswordManager() *PasswordManager {
+	return &PasswordManager{
+		isSealed: true, // 初始状态为已锁定
+	}
+}
+
 // ResetPasswordManagerInstance 重置单例实例（主要用于测试）
 func ResetPasswordManagerInstance() {
 	instance = nil
@@ -0,0 +1,53 @@
+package security
+
+import (
+	"github.com/awnumar/memguard"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// SecureBytes 解密得到的敏感数据（种子、序列化的 bip32 密钥、助记词、地址私钥），
+// 存放在 memguard 锁定且只读的内存中，不会被换出或留在 GC 堆上。
+// 持有者必须在用完后调用 Destroy，通常紧跟在获取之后 defer
+type SecureBytes struct {
+	buf *memguard.LockedBuffer
+}
+
+// NewSecureBytes 把 data 移入锁定内存，data 本身会被清零
+func NewSecureBytes(data []byte) *SecureBytes {
+	return &SecureBytes{buf: memguard.NewBufferFromBytes(data)}
+}
+
+// Decrypt 解密并直接放入锁定内存，解密过程中的明文副本会被清零；
+// 除了本包，其他代码不应直接调用 crypto.DecryptData（见 hygiene_test.go）
+func Decrypt(ciphertext, password string) (*SecureBytes, error) {
+	plaintext, err := crypto.DecryptData(ciphertext, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecureBytes(plaintext), nil
+}
+
+// Bytes 返回锁定内存中的数据，只读，Destroy 之后不能再访问。
+// 需要长期保存或交给会修改数据的代码时，复制一份并负责清除
+func (s *SecureBytes) Bytes() []byte {
+	if s == nil || s.buf == nil {
+		return nil
+	}
+	return s.buf.Bytes()
+}
+
+// Size 数据长度
+func (s *SecureBytes) Size() int {
+	if s == nil || s.buf == nil {
+		return 0
+	}
+	return s.buf.Size()
+}
+
+// Destroy 清零并释放锁定内存，可以重复调用
+func (s *SecureBytes) Destroy() {
+	if s == nil || s.buf == nil {
+		return
+	}
+	s.buf.Destroy()
+}
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WipeDir 用零覆盖目录中每个文件的内容并同步到磁盘，然后删除整个目录。
+// SSD 和写时复制文件系统不保证覆盖原来的物理位置，存放敏感数据的临时目录应优先放在内存文件系统上
+func WipeDir(dir string) error {
+	var firstErr error
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		if err := overwriteFile(path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+func overwriteFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	zeros := make([]byte, 32*1024)
+	for remaining := info.Size(); remaining > 0; {
+		n := int64(len(zeros))
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := file.Write(zeros[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return file.Sync()
+}
@@ -0,0 +1,91 @@
+package signer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TerminalApprover 在终端逐个提示用户确认请求，并发请求排队处理
+type TerminalApprover struct {
+	mu     sync.Mutex
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// NewTerminalApprover 创建终端确认器
+func NewTerminalApprover(in io.Reader, out io.Writer) *TerminalApprover {
+	return &TerminalApprover{
+		reader: bufio.NewReader(in),
+		out:    out,
+	}
+}
+
+// Approve 显示请求摘要并等待 y/N 输入，默认拒绝
+func (a *TerminalApprover) Approve(req *ApprovalRequest) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if req.Account == (common.Address{}) {
+		fmt.Fprintf(a.out, "\n--- %s request ---\n", req.Method)
+	} else {
+		fmt.Fprintf(a.out, "\n--- %s request for %s ---\n", req.Method, req.Account.Hex())
+	}
+	if req.Origin != "" {
+		fmt.Fprintf(a.out, "  From: %s\n", req.Origin)
+	}
+	for _, line := range req.Details {
+		fmt.Fprintf(a.out, "  %s\n", line)
+	}
+	fmt.Fprint(a.out, "Approve? [y/N]: ")
+
+	answer, err := a.reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(a.out)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	approved := answer == "y" || answer == "yes"
+	if approved {
+		fmt.Fprintln(a.out, "Approved")
+	} else {
+		fmt.Fprintln(a.out, "Rejected")
+	}
+	return approved
+}
+
+// Confirm 策略要求额外确认、请求偏离历史习惯或手续费离谱时，需要输入 confirm
+func (a *TerminalApprover) Confirm(req *ApprovalRequest) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if req.Policy != "" {
+		fmt.Fprintf(a.out, "Signing policy requires confirmation: %s\n", req.Policy)
+	}
+	for _, reason := range req.Anomalies {
+		fmt.Fprintf(a.out, "Unusual signing request: %s\n", reason)
+	}
+	if req.Fee != "" {
+		fmt.Fprintf(a.out, "Check the fee: %s\n", req.Fee)
+	}
+	fmt.Fprint(a.out, `Type "confirm" to sign: `)
+	answer, err := a.reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(a.out)
+		return false
+	}
+	return strings.TrimSpace(answer) == "confirm"
+}
+
+// PreApproved 请求已经在审批队列中获得批准，签名时不再交互确认
+type PreApproved struct{}
+
+// Approve 总是批准
+func (PreApproved) Approve(req *ApprovalRequest) bool { return true }
+
+// Confirm 审批队列中其他审批者的批准即是额外确认
+func (PreApproved) Confirm(req *ApprovalRequest) bool { return true }
@@ -0,0 +1,330 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TypedDataField EIP-712 结构体字段
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData EIP-712 结构化数据
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+const domainType = "EIP712Domain"
+
+// domainFields EIP712Domain 允许的字段及顺序，types 中未声明时据此推断
+var domainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+var (
+	arrayTypeRegexp = regexp.MustCompile(`^(.+)\[(\d*)\]$`)
+	intTypeRegexp   = regexp.MustCompile(`^(u?)int(\d*)$`)
+	bytesTypeRegexp = regexp.MustCompile(`^bytes(\d+)$`)
+)
+
+// ParseTypedData 解析结构化数据，数字保留为 json.Number 以免丢失精度
+// 部分客户端（如 ethers 的 eth_signTypedData_v4）会把整个对象编码为 JSON 字符串
+func ParseTypedData(raw json.RawMessage) (*TypedData, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var td TypedData
+	if err := decoder.Decode(&td); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	if td.PrimaryType == "" {
+		return nil, errors.New("typed data is missing primaryType")
+	}
+	if td.Types == nil {
+		td.Types = make(map[string][]TypedDataField)
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok && td.PrimaryType != domainType {
+		return nil, fmt.Errorf("primary type %s is not defined", td.PrimaryType)
+	}
+	if _, ok := td.Types[domainType]; !ok {
+		var fields []TypedDataField
+		for _, field := range domainFields {
+			if _, exists := td.Domain[field.Name]; exists {
+				fields = append(fields, field)
+			}
+		}
+		td.Types[domainType] = fields
+	}
+	return &td, nil
+}
+
+// DomainSeparator 计算 hashStruct(domain)
+func (td *TypedData) DomainSeparator() ([]byte, error) {
+	return td.HashStruct(domainType, td.Domain)
+}
+
+// Hash 计算待签名的 EIP-712 摘要：keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (td *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := td.DomainSeparator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	rawData := []byte{0x19, 0x01}
+	rawData = append(rawData, domainSeparator...)
+	// primaryType 为 EIP712Domain 时只对域签名
+	if td.PrimaryType != domainType {
+		messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash message: %w", err)
+		}
+		rawData = append(rawData, messageHash...)
+	}
+	return crypto.Keccak256(rawData), nil
+}
+
+// HashStruct 计算 keccak256(typeHash ‖ encodeData(data))
+func (td *TypedData) HashStruct(primaryType string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := td.encodeData(primaryType, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// EncodeType 返回类型签名，依赖的结构体按名称排序追加在主类型之后
+func (td *TypedData) EncodeType(primaryType string) string {
+	deps := td.dependencies(primaryType, nil)
+	sort.Strings(deps[1:])
+
+	var buf strings.Builder
+	for _, dep := range deps {
+		buf.WriteString(dep)
+		buf.WriteString("(")
+		for i, field := range td.Types[dep] {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(field.Type)
+			buf.WriteString(" ")
+			buf.WriteString(field.Name)
+		}
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// dependencies 返回类型自身及其引用的所有结构体类型，自身在首位
+func (td *TypedData) dependencies(typeName string, found []string) []string {
+	typeName = baseType(typeName)
+	for _, name := range found {
+		if name == typeName {
+			return found
+		}
+	}
+	if _, ok := td.Types[typeName]; !ok {
+		return found
+	}
+	found = append(found, typeName)
+	for _, field := range td.Types[typeName] {
+		found = td.dependencies(field.Type, found)
+	}
+	return found
+}
+
+func (td *TypedData) encodeData(primaryType string, data map[string]interface{}) ([]byte, error) {
+	fields, ok := td.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("type %s is not defined", primaryType)
+	}
+	buf := crypto.Keccak256([]byte(td.EncodeType(primaryType)))
+	for _, field := range fields {
+		value, exists := data[field.Name]
+		if !exists {
+			return nil, fmt.Errorf("%s.%s is missing", primaryType, field.Name)
+		}
+		encoded, err := td.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", primaryType, field.Name, err)
+		}
+		buf = append(buf, encoded...)
+	}
+	return buf, nil
+}
+
+// encodeValue 将单个字段编码为 32 字节
+func (td *TypedData) encodeValue(typeName string, value interface{}) ([]byte, error) {
+	if match := arrayTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array for %s", typeName)
+		}
+		if match[2] != "" {
+			if length, _ := strconv.Atoi(match[2]); length != len(items) {
+				return nil, fmt.Errorf("expected %d items for %s, got %d", length, typeName, len(items))
+			}
+		}
+		var buf []byte
+		for _, item := range items {
+			encoded, err := td.encodeValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, encoded...)
+		}
+		return crypto.Keccak256(buf), nil
+	}
+
+	if _, ok := td.Types[typeName]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for %s", typeName)
+		}
+		return td.HashStruct(typeName, fields)
+	}
+
+	switch typeName {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("expected string")
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case "bytes":
+		b, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("expected bool")
+		}
+		if b {
+			return math.PaddedBigBytes(big.NewInt(1), 32), nil
+		}
+		return make([]byte, 32), nil
+	case "address":
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(s).Bytes(), 32), nil
+	}
+
+	if match := bytesTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		if size < 1 || size > 32 {
+			return nil, fmt.Errorf("invalid type %s", typeName)
+		}
+		b, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != size {
+			return nil, fmt.Errorf("expected %d bytes for %s, got %d", size, typeName, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+	}
+
+	if match := intTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		bits := 256
+		if match[2] != "" {
+			bits, _ = strconv.Atoi(match[2])
+		}
+		if bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("invalid type %s", typeName)
+		}
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if match[1] == "u" {
+			if n.Sign() < 0 || n.BitLen() > bits {
+				return nil, fmt.Errorf("%s out of range for %s", n, typeName)
+			}
+		} else {
+			limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%s out of range for %s", n, typeName)
+			}
+		}
+		// 负数按 256 位补码编码
+		return math.U256Bytes(new(big.Int).Set(n)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typeName)
+}
+
+func baseType(typeName string) string {
+	for {
+		match := arrayTypeRegexp.FindStringSubmatch(typeName)
+		if match == nil {
+			return typeName
+		}
+		typeName = match[1]
+	}
+}
+
+func parseBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.New("expected hex string")
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %w", s, err)
+	}
+	return b, nil
+}
+
+// parseInteger 支持 JSON 数字、十进制字符串及 0x 十六进制字符串
+func parseInteger(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("expected integer, got %v", value)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	n, ok := math.ParseBig256(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", value)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
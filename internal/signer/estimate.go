@@ -0,0 +1,214 @@
+package signer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 判断手续费是否离谱的阈值：超过转出金额的 maxFeePercent，或费率超过该币种的上限
+const (
+	maxFeePercent = 10
+	maxBTCFeeRate = 1000       // sat/vB
+	maxETHGasGwei = 1000       // gwei/gas
+	maxSOLCUPrice = 10_000_000 // micro-lamports/CU
+)
+
+// 以太坊固有 gas（黄皮书、EIP-2028、EIP-2930、EIP-3860）
+const (
+	txGas               = 21000
+	txCreateGas         = 53000
+	txDataZeroGas       = 4
+	txDataNonZeroGas    = 16
+	txAccessListAddress = 2400
+	txAccessListStorage = 1900
+	txInitCodeWordGas   = 2
+)
+
+// Solana 手续费
+const (
+	solSignatureLamports  = 5000
+	solDefaultUnits       = 200_000 // 未设置计算单元上限时每条指令的默认值
+	solMaxUnits           = 1_400_000
+	solMicroLamportsPerLP = 1_000_000
+)
+
+// FeeEstimate 签名前估算的交易大小和手续费。大小按链的计费单位：BTC 为虚拟字节，ETH 为 gas 上限，SOL 为计算单元上限
+type FeeEstimate struct {
+	Coin     string
+	Decimals int
+	Size     uint64
+	Unit     string   // vB、gas、CU
+	Minimum  uint64   // 交易至少消耗的单位（ETH 的固有 gas），0 表示不适用
+	Bytes    int      // 签名后序列化的字节数，0 表示未知
+	Fee      *big.Int // 手续费，最小单位
+	AtMost   bool     // Fee 是上限（ETH 按 gas 上限和最高费率计算），实际通常更少
+	Rate     string   // 选定的费率，如 12 sat/vB
+	Amount   *big.Int // 转出的金额，用于判断手续费是否离谱，可为 nil
+	highRate string   // 超过该币种上限的费率，为空表示正常
+}
+
+// Lines 确认界面中显示的估算，每行一项
+func (e *FeeEstimate) Lines() []string {
+	size := fmt.Sprintf("%d %s", e.Size, e.Unit)
+	if e.Minimum > 0 && e.Minimum < e.Size {
+		size += fmt.Sprintf(" (at least %d)", e.Minimum)
+	}
+	if e.Bytes > 0 {
+		size += fmt.Sprintf(", %d bytes", e.Bytes)
+	}
+	fee := coin.FormatUnits(e.Fee, e.Decimals) + " " + e.Coin
+	if e.AtMost {
+		fee = "up to " + fee
+	}
+	lines := []string{
+		fmt.Sprintf("Size:     %s", size),
+		fmt.Sprintf("Fee:      %s at %s", fee, e.Rate),
+	}
+	if e.Minimum > e.Size {
+		lines = append(lines, fmt.Sprintf("Warning:  the %s limit is below the %d the transaction needs, it will fail", e.Unit, e.Minimum))
+	}
+	return lines
+}
+
+// Warning 手续费离谱时返回原因：超过转出金额的 10%，或费率超过该币种的合理上限
+func (e *FeeEstimate) Warning() string {
+	if e.highRate != "" {
+		return fmt.Sprintf("fee rate %s is unusually high", e.highRate)
+	}
+	if e.Amount != nil && e.Amount.Sign() > 0 {
+		limit := new(big.Int).Mul(e.Amount, big.NewInt(maxFeePercent))
+		if new(big.Int).Mul(e.Fee, big.NewInt(100)).Cmp(limit) > 0 {
+			return fmt.Sprintf("fee of %s %s is more than %d%% of the %s %s sent", coin.FormatUnits(e.Fee, e.Decimals), e.Coin,
+				maxFeePercent, coin.FormatUnits(e.Amount, e.Decimals), e.Coin)
+		}
+	}
+	return ""
+}
+
+// EstimateETH 估算以太坊交易的 gas、签名后的大小和最高手续费（gas 上限 × gasPrice 或 maxFeePerGas）
+func EstimateETH(tx *Transaction) (*FeeEstimate, error) {
+	rate := tx.GasPrice
+	if tx.Type == DynamicFeeTxType {
+		rate = tx.MaxFeePerGas
+	}
+	// 用最大长度的签名值编码，得到签名后大小的上限
+	sig := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	var (
+		payload []byte
+		err     error
+	)
+	if tx.Type == LegacyTxType {
+		v := new(big.Int).Add(new(big.Int).Mul(tx.ChainID, big.NewInt(2)), big.NewInt(36))
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			tx.Nonce, tx.GasPrice, tx.Gas, tx.toField(), tx.Value, tx.Data, v, sig, sig,
+		})
+	} else {
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			tx.ChainID, tx.Nonce, tx.MaxPriorityFeePerGas, tx.MaxFeePerGas, tx.Gas,
+			tx.toField(), tx.Value, tx.Data, tx.accessListField(), uint64(1), sig, sig,
+		})
+		payload = append([]byte{DynamicFeeTxType}, payload...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	gwei := new(big.Rat).SetFrac(rate, big.NewInt(1e9))
+	estimate := &FeeEstimate{
+		Coin:     "ETH",
+		Decimals: 18,
+		Size:     tx.Gas,
+		Unit:     "gas",
+		Minimum:  intrinsicGas(tx),
+		Bytes:    len(payload),
+		Fee:      new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas), rate),
+		AtMost:   true,
+		Rate:     gwei.FloatString(2) + " gwei/gas",
+		Amount:   tx.Value,
+	}
+	if gwei.Cmp(big.NewRat(maxETHGasGwei, 1)) > 0 {
+		estimate.highRate = estimate.Rate
+	}
+	return estimate, nil
+}
+
+// intrinsicGas 交易在执行任何代码之前消耗的 gas
+func intrinsicGas(tx *Transaction) uint64 {
+	gas := uint64(txGas)
+	if tx.To == nil {
+		gas = txCreateGas + uint64(len(tx.Data)+31)/32*txInitCodeWordGas
+	}
+	for _, b := range tx.Data {
+		if b == 0 {
+			gas += txDataZeroGas
+		} else {
+			gas += txDataNonZeroGas
+		}
+	}
+	for _, tuple := range tx.AccessList {
+		gas += txAccessListAddress + uint64(len(tuple.StorageKeys))*txAccessListStorage
+	}
+	return gas
+}
+
+// EstimateBTC 估算比特币交易签名后的虚拟大小，手续费为输入减输出；
+// amount 为转给他人的金额，全部转给自己时传所有输出之和
+func EstimateBTC(inputs []btc.UTXO, outputs []btc.TxOut, amount int64) (*FeeEstimate, error) {
+	scripts := make([][]byte, len(outputs))
+	fee := int64(0)
+	for _, u := range inputs {
+		fee += u.Value
+	}
+	for i, out := range outputs {
+		scripts[i] = out.Script
+		fee -= out.Value
+	}
+	vsize, err := btc.EstimateVSize(inputs, scripts)
+	if err != nil {
+		return nil, err
+	}
+	rate := float64(fee) / float64(max(vsize, 1))
+	estimate := &FeeEstimate{
+		Coin:     "BTC",
+		Decimals: 8,
+		Size:     uint64(vsize),
+		Unit:     "vB",
+		Fee:      big.NewInt(fee),
+		Rate:     fmt.Sprintf("%.1f sat/vB", rate),
+		Amount:   big.NewInt(amount),
+	}
+	if rate > maxBTCFeeRate {
+		estimate.highRate = estimate.Rate
+	}
+	return estimate, nil
+}
+
+// EstimateSOL 估算 Solana 交易的计算单元和手续费：每个签名 5000 lamports，加上计算单元上限 × 单价（micro-lamports）的优先费。
+// unitLimit 为 0 时按每条非 Compute Budget 指令 200000 计算
+func EstimateSOL(signatures, instructions int, unitLimit uint32, unitPrice uint64, bytes int, amount *big.Int) *FeeEstimate {
+	units := uint64(unitLimit)
+	if units == 0 {
+		units = min(uint64(instructions)*solDefaultUnits, solMaxUnits)
+	}
+	priority := new(big.Int).Mul(new(big.Int).SetUint64(units), new(big.Int).SetUint64(unitPrice))
+	priority.Add(priority, big.NewInt(solMicroLamportsPerLP-1))
+	priority.Div(priority, big.NewInt(solMicroLamportsPerLP))
+	fee := new(big.Int).Add(big.NewInt(int64(signatures)*solSignatureLamports), priority)
+	estimate := &FeeEstimate{
+		Coin:     "SOL",
+		Decimals: 9,
+		Size:     units,
+		Unit:     "CU",
+		Bytes:    bytes,
+		Fee:      fee,
+		Rate:     fmt.Sprintf("%d lamports/signature + %d micro-lamports/CU", solSignatureLamports, unitPrice),
+		Amount:   amount,
+	}
+	if unitPrice > maxSOLCUPrice {
+		estimate.highRate = fmt.Sprintf("%d micro-lamports/CU", unitPrice)
+	}
+	return estimate
+}
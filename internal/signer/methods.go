@@ -0,0 +1,94 @@
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrMethodNotSupported 不支持的 JSON-RPC 方法
+var ErrMethodNotSupported = errors.New("method not supported")
+
+// Handle 按以太坊 JSON-RPC 约定分发签名方法，供 HTTP 代理和 WalletConnect 共用
+func (s *Signer) Handle(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eth_accounts":
+		return s.Accounts()
+	case "eth_signTransaction":
+		return s.handleSignTransaction(params)
+	case "eth_signTypedData", "eth_signTypedData_v4":
+		return s.handleSignTypedData(params)
+	case "personal_sign":
+		return s.handlePersonalSign(params, 1, 0)
+	case "eth_sign":
+		return s.handlePersonalSign(params, 0, 1)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrMethodNotSupported, method)
+	}
+}
+
+func (s *Signer) handleSignTransaction(params []json.RawMessage) (interface{}, error) {
+	if len(params) != 1 {
+		return nil, &paramsError{"expected [transaction]"}
+	}
+	var args TransactionArgs
+	if err := json.Unmarshal(params[0], &args); err != nil {
+		return nil, &paramsError{err.Error()}
+	}
+	raw, err := s.SignTransaction(&args)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(raw), nil
+}
+
+func (s *Signer) handleSignTypedData(params []json.RawMessage) (interface{}, error) {
+	if len(params) != 2 {
+		return nil, &paramsError{"expected [address, typedData]"}
+	}
+	var account common.Address
+	if err := json.Unmarshal(params[0], &account); err != nil {
+		return nil, &paramsError{err.Error()}
+	}
+	td, err := ParseTypedData(params[1])
+	if err != nil {
+		return nil, &paramsError{err.Error()}
+	}
+	sig, err := s.SignTypedData(account, td)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(sig), nil
+}
+
+// handlePersonalSign personal_sign 为 [data, address]，eth_sign 为 [address, data]
+func (s *Signer) handlePersonalSign(params []json.RawMessage, accountIndex, dataIndex int) (interface{}, error) {
+	if len(params) < 2 {
+		return nil, &paramsError{"expected address and data"}
+	}
+	var account common.Address
+	if err := json.Unmarshal(params[accountIndex], &account); err != nil {
+		return nil, &paramsError{err.Error()}
+	}
+	var data hexutil.Bytes
+	if err := json.Unmarshal(params[dataIndex], &data); err != nil {
+		return nil, &paramsError{err.Error()}
+	}
+	sig, err := s.SignMessage(account, data)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(sig), nil
+}
+
+// paramsError 请求参数无法解析
+type paramsError struct {
+	message string
+}
+
+func (e *paramsError) Error() string {
+	return e.message
+}
@@ -0,0 +1,134 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/core"
+)
+
+// ProofType 地址所有权证明文件的类型标识，带版本号
+const ProofType = "slowmade-address-proof/1"
+
+// 错误定义
+var (
+	ErrInvalidProof  = errors.New("invalid ownership proof")
+	ErrProofMismatch = errors.New("signature was not made by the address in the proof")
+)
+
+// Proof 地址所有权证明：用地址的私钥按 personal_sign 签名 Statement 给出的声明，
+// 声明包含时间、地址、附言和派生路径的哈希，任何一项被改动签名都不再有效
+type Proof struct {
+	Type      string    `json:"type"`
+	Coin      string    `json:"coin"`
+	Address   string    `json:"address"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	PathHash  string    `json:"path_hash"` // SHA-256(派生路径)，证明方之后可以出示路径供审计核对，不直接公开路径
+	Signature string    `json:"signature"`
+}
+
+// Statement 被签名的声明文本
+func (p *Proof) Statement() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n", ProofType)
+	fmt.Fprintf(&b, "I control the %s address %s.\n", p.Coin, p.Address)
+	fmt.Fprintf(&b, "Time: %s\n", p.Timestamp.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Path hash: %s\n", p.PathHash)
+	fmt.Fprintf(&b, "Message: %s", p.Message)
+	return b.Bytes()
+}
+
+// PathHash 派生路径的哈希
+func PathHash(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prove 为钱包中的 ETH 地址生成所有权证明，签名经过与 message.sign 相同的确认、策略和审计
+func (s *Signer) Prove(account common.Address, message string) (*Proof, error) {
+	key, err := s.findAddress(account)
+	if err != nil {
+		return nil, err
+	}
+	path, err := s.addressPath(key)
+	if err != nil {
+		return nil, err
+	}
+	proof := &Proof{
+		Type:      ProofType,
+		Coin:      "ETH",
+		Address:   account.Hex(),
+		Message:   message,
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+		PathHash:  PathHash(path),
+	}
+	sig, err := s.SignMessage(account, proof.Statement())
+	if err != nil {
+		return nil, err
+	}
+	proof.Signature = hexutil.Encode(sig)
+	return proof, nil
+}
+
+// addressPath 地址的完整派生路径
+func (s *Signer) addressPath(key *core.AddressKey) (string, error) {
+	accounts, err := s.accountMgr.GetAccounts()
+	if err != nil {
+		return "", err
+	}
+	for _, account := range accounts {
+		if account.ID != key.AccountID {
+			continue
+		}
+		path, err := account.AddressPath(key.ChangeType, key.AddressIndex)
+		if err != nil {
+			return "", err
+		}
+		return path.String(), nil
+	}
+	return "", fmt.Errorf("%w %s", ErrUnknownAccount, key.Address)
+}
+
+// ParseProof 解析证明文件
+func ParseProof(data []byte) (*Proof, error) {
+	var proof Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if proof.Type != ProofType {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidProof, proof.Type)
+	}
+	if proof.Coin != "ETH" || !common.IsHexAddress(proof.Address) {
+		return nil, fmt.Errorf("%w: unsupported address %s %q", ErrInvalidProof, proof.Coin, proof.Address)
+	}
+	return &proof, nil
+}
+
+// Verify 检查签名由证明中的地址做出，不需要钱包
+func (p *Proof) Verify() error {
+	sig, err := hexutil.Decode(p.Signature)
+	if err != nil || len(sig) != 65 {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidProof)
+	}
+	sig = bytes.Clone(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := crypto.SigToPub(textHash(p.Statement()), sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(p.Address) {
+		return ErrProofMismatch
+	}
+	return nil
+}
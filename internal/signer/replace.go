@@ -0,0 +1,191 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MinBumpPercent 节点接受相同 nonce 的替换交易要求的最低涨幅（geth txpool.pricebump 的默认值）
+const MinBumpPercent = 10
+
+// DefaultBumpPercent 未指定手续费时替换交易的涨幅
+const DefaultBumpPercent = 25
+
+// cancelGas 取消交易（向自己转 0 ETH）的 gas
+const cancelGas = 21000
+
+// ErrFeeBumpTooLow 替换交易的手续费涨幅不足，节点会拒绝
+var ErrFeeBumpTooLow = fmt.Errorf("replacement fees must be at least %d%% higher than the original", MinBumpPercent)
+
+// legacyTx legacy 交易的 RLP 字段
+type legacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       []byte
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// dynamicFeeTx EIP-1559 交易的 RLP 字段
+type dynamicFeeTx struct {
+	ChainID              *big.Int
+	Nonce                uint64
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	Gas                  uint64
+	To                   []byte
+	Value                *big.Int
+	Data                 []byte
+	AccessList           []AccessTuple
+	V, R, S              *big.Int
+}
+
+// DecodeTransaction 解析已签名的 EIP-155 legacy 或 EIP-1559 交易，并由签名恢复发送方地址
+func DecodeTransaction(raw []byte) (*Transaction, common.Address, error) {
+	if len(raw) == 0 {
+		return nil, common.Address{}, errors.New("empty transaction")
+	}
+	var (
+		tx      *Transaction
+		v, r, s *big.Int
+	)
+	switch {
+	case raw[0] == DynamicFeeTxType:
+		var dec dynamicFeeTx
+		if err := rlp.DecodeBytes(raw[1:], &dec); err != nil {
+			return nil, common.Address{}, fmt.Errorf("invalid EIP-1559 transaction: %v", err)
+		}
+		tx = &Transaction{Type: DynamicFeeTxType, ChainID: dec.ChainID, Nonce: dec.Nonce,
+			MaxFeePerGas: dec.MaxFeePerGas, MaxPriorityFeePerGas: dec.MaxPriorityFeePerGas,
+			Gas: dec.Gas, Value: dec.Value, Data: dec.Data, AccessList: dec.AccessList}
+		if err := tx.setTo(dec.To); err != nil {
+			return nil, common.Address{}, err
+		}
+		v, r, s = dec.V, dec.R, dec.S
+	case raw[0] >= 0xc0:
+		var dec legacyTx
+		if err := rlp.DecodeBytes(raw, &dec); err != nil {
+			return nil, common.Address{}, fmt.Errorf("invalid legacy transaction: %v", err)
+		}
+		if dec.V.Cmp(big.NewInt(35)) < 0 {
+			return nil, common.Address{}, errors.New("legacy transaction without EIP-155 chain id")
+		}
+		chainID := new(big.Int).Sub(dec.V, big.NewInt(35))
+		chainID.Rsh(chainID, 1)
+		tx = &Transaction{Type: LegacyTxType, ChainID: chainID, Nonce: dec.Nonce, GasPrice: dec.GasPrice,
+			Gas: dec.Gas, Value: dec.Value, Data: dec.Data}
+		if err := tx.setTo(dec.To); err != nil {
+			return nil, common.Address{}, err
+		}
+		v = new(big.Int).Sub(dec.V, new(big.Int).Add(new(big.Int).Lsh(chainID, 1), big.NewInt(35)))
+		r, s = dec.R, dec.S
+	default:
+		return nil, common.Address{}, fmt.Errorf("unsupported transaction type %d", raw[0])
+	}
+	if v.Sign() < 0 || v.Cmp(big.NewInt(1)) > 0 || r.BitLen() > 256 || s.BitLen() > 256 {
+		return nil, common.Address{}, errors.New("invalid signature values")
+	}
+
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(v.Uint64())
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid signature: %v", err)
+	}
+	return tx, crypto.PubkeyToAddress(*pub), nil
+}
+
+func (tx *Transaction) setTo(to []byte) error {
+	switch len(to) {
+	case 0:
+	case common.AddressLength:
+		address := common.BytesToAddress(to)
+		tx.To = &address
+	default:
+		return fmt.Errorf("invalid recipient length %d", len(to))
+	}
+	return nil
+}
+
+// BumpFee 按百分比提高手续费，向上取整到 wei
+func BumpFee(fee *big.Int, percent int64) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// Bumped 返回手续费按 percent 提高后的副本，nonce、收款方和数据不变
+func (tx *Transaction) Bumped(percent int64) *Transaction {
+	next := *tx
+	if tx.Type == LegacyTxType {
+		next.GasPrice = BumpFee(tx.GasPrice, percent)
+	} else {
+		next.MaxFeePerGas = BumpFee(tx.MaxFeePerGas, percent)
+		next.MaxPriorityFeePerGas = BumpFee(tx.MaxPriorityFeePerGas, percent)
+	}
+	return &next
+}
+
+// Cancellation 返回取消交易：相同 nonce、向 from 转 0 ETH，手续费按 percent 提高
+func (tx *Transaction) Cancellation(from common.Address, percent int64) *Transaction {
+	next := tx.Bumped(percent)
+	next.To, next.Value, next.Data, next.AccessList, next.Gas = &from, new(big.Int), nil, nil, cancelGas
+	return next
+}
+
+// CheckReplacement 检查 next 的手续费比 tx 至少高 MinBumpPercent，EIP-1559 交易的两项费用都要提高
+func (tx *Transaction) CheckReplacement(next *Transaction) error {
+	if next.Nonce != tx.Nonce || next.Type != tx.Type {
+		return errors.New("replacement must keep the nonce and transaction type")
+	}
+	enough := func(old, fee *big.Int) bool { return fee != nil && fee.Cmp(BumpFee(old, MinBumpPercent)) >= 0 }
+	if tx.Type == LegacyTxType {
+		if !enough(tx.GasPrice, next.GasPrice) {
+			return fmt.Errorf("%w: gas price %s wei, needs at least %s wei", ErrFeeBumpTooLow, next.GasPrice, BumpFee(tx.GasPrice, MinBumpPercent))
+		}
+		return nil
+	}
+	if !enough(tx.MaxFeePerGas, next.MaxFeePerGas) || !enough(tx.MaxPriorityFeePerGas, next.MaxPriorityFeePerGas) {
+		return fmt.Errorf("%w: max fee %s / priority %s wei, needs at least %s / %s wei", ErrFeeBumpTooLow,
+			next.MaxFeePerGas, next.MaxPriorityFeePerGas,
+			BumpFee(tx.MaxFeePerGas, MinBumpPercent), BumpFee(tx.MaxPriorityFeePerGas, MinBumpPercent))
+	}
+	return nil
+}
+
+// Args 转换为 SignTransaction 的参数，用于重新签名
+func (tx *Transaction) Args(from common.Address) *TransactionArgs {
+	nonce, gas := hexutil.Uint64(tx.Nonce), hexutil.Uint64(tx.Gas)
+	data := hexutil.Bytes(tx.Data)
+	args := &TransactionArgs{
+		From:       from,
+		To:         tx.To,
+		Gas:        &gas,
+		Value:      (*hexutil.Big)(tx.Value),
+		Nonce:      &nonce,
+		Input:      &data,
+		ChainID:    (*hexutil.Big)(tx.ChainID),
+		AccessList: tx.AccessList,
+	}
+	if tx.Type == LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice)
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.MaxFeePerGas)
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.MaxPriorityFeePerGas)
+	}
+	return args
+}
@@ -0,0 +1,124 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// JSON-RPC 2.0 错误码
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeInternal       = -32603
+	errCodeRejected       = 4001 // EIP-1193 用户拒绝
+	errCodeUnauthorized   = 4100 // EIP-1193 未授权（账户未知或钱包已锁定）
+)
+
+const maxRequestSize = 1 << 20
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// RPCServer 以太坊 JSON-RPC 签名接口，供 Hardhat、Foundry 等工具作为外部签名器使用
+type RPCServer struct {
+	signer *Signer
+}
+
+// NewRPCServer 创建 JSON-RPC 处理器
+func NewRPCServer(signer *Signer) *RPCServer {
+	return &RPCServer{signer: signer}
+}
+
+// ServeHTTP 处理单个或批量 JSON-RPC 请求
+func (s *RPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []rpcRequest
+		if err := json.Unmarshal(body, &requests); err != nil {
+			json.NewEncoder(w).Encode(errorResponse(nil, errCodeParse, err.Error()))
+			return
+		}
+		responses := make([]*rpcResponse, 0, len(requests))
+		for i := range requests {
+			responses = append(responses, s.handle(&requests[i]))
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var request rpcRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		json.NewEncoder(w).Encode(errorResponse(nil, errCodeParse, err.Error()))
+		return
+	}
+	json.NewEncoder(w).Encode(s.handle(&request))
+}
+
+func (s *RPCServer) handle(req *rpcRequest) *rpcResponse {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, errCodeInvalidRequest, "invalid JSON-RPC 2.0 request")
+	}
+	logging.Debugf("signer request %s", req.Method)
+
+	result, err := s.signer.Handle(req.Method, req.Params)
+	if err != nil {
+		return errorResponse(req.ID, errorCode(err), err.Error())
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func errorCode(err error) int {
+	var pe *paramsError
+	switch {
+	case errors.Is(err, ErrMethodNotSupported):
+		return errCodeMethodNotFound
+	case errors.As(err, &pe):
+		return errCodeInvalidParams
+	case errors.Is(err, ErrRejected), errors.Is(err, policy.ErrDenied):
+		return errCodeRejected
+	case errors.Is(err, ErrUnknownAccount), errors.Is(err, core.ErrWalletLocked):
+		return errCodeUnauthorized
+	default:
+		return errCodeInternal
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
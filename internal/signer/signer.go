@@ -0,0 +1,360 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/keyusage"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+var (
+	ErrRejected       = errors.New("request rejected by user")
+	ErrUnknownAccount = errors.New("unknown account")
+)
+
+// ApprovalRequest 需要用户确认的签名请求
+type ApprovalRequest struct {
+	Method  string
+	Origin  string // 请求来源，如 WalletConnect 会话的 dApp，为空表示本地客户端
+	Account common.Address
+	Details []string // 展示给用户的请求摘要，每行一项
+	To      string   // 交易的收款地址，消息签名和部署合约时为空
+	Policy  string   // 要求额外确认的策略规则，为空表示不需要
+	Fee     string   // 手续费离谱的原因，见 FeeEstimate.Warning，非空时同样要求额外确认
+	// Anomalies 请求偏离该密钥历史签名习惯的原因，非空时同样要求额外确认
+	Anomalies []string
+}
+
+// Approver 对每个签名请求进行交互式确认
+type Approver interface {
+	Approve(req *ApprovalRequest) bool
+}
+
+// Confirmer 策略规则要求额外确认、请求偏离历史习惯或手续费离谱时，在 Approve 批准后再次确认；未实现的 Approver 视为不确认
+type Confirmer interface {
+	Confirm(req *ApprovalRequest) bool
+}
+
+// Previewer 将待签名内容解码为可读的预览，每行一项
+type Previewer interface {
+	PreviewCalldata(data []byte) []string
+	PreviewTypedData(td *TypedData) []string
+}
+
+// Signer 使用钱包中已派生的 ETH 地址签名
+type Signer struct {
+	accountMgr core.AccountManager
+	approver   Approver
+	chainID    *big.Int
+	origin     string
+	previewer  Previewer
+	auditLog   *audit.Logger
+	policyDir  string
+	usagePath  string
+	usageLimit keyusage.Thresholds
+	bus        *events.Bus
+}
+
+// NewSigner 创建签名器，chainID 为交易未指定 chainId 时的默认值
+func NewSigner(accountMgr core.AccountManager, approver Approver, chainID *big.Int) *Signer {
+	return &Signer{
+		accountMgr: accountMgr,
+		approver:   approver,
+		chainID:    chainID,
+	}
+}
+
+// Audit 设置审计日志
+func (s *Signer) Audit(logger *audit.Logger) *Signer {
+	s.auditLog = logger
+	return s
+}
+
+// Policies 设置签名前执行的策略脚本目录
+func (s *Signer) Policies(dir string) *Signer {
+	s.policyDir = dir
+	return s
+}
+
+// Usage 设置签名统计文件：请求偏离历史习惯时要求额外确认，并在 bus（可为 nil）上发布 keyusage.Detected
+func (s *Signer) Usage(path string, thresholds keyusage.Thresholds, bus *events.Bus) *Signer {
+	s.usagePath, s.usageLimit, s.bus = path, thresholds, bus
+	return s
+}
+
+// Preview 设置签名前的解码预览
+func (s *Signer) Preview(previewer Previewer) *Signer {
+	s.previewer = previewer
+	return s
+}
+
+// For 返回绑定请求来源和默认链的签名器副本
+func (s *Signer) For(origin string, chainID *big.Int) *Signer {
+	clone := *s
+	clone.origin = origin
+	if chainID != nil {
+		clone.chainID = chainID
+	}
+	return &clone
+}
+
+// Accounts 返回所有已派生的 ETH 地址
+func (s *Signer) Accounts() ([]common.Address, error) {
+	keys, err := s.addressKeys()
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]common.Address, 0, len(keys))
+	for _, key := range keys {
+		accounts = append(accounts, common.HexToAddress(key.Address))
+	}
+	return accounts, nil
+}
+
+// SignTransaction 签名交易，返回原始交易编码
+func (s *Signer) SignTransaction(args *TransactionArgs) ([]byte, error) {
+	tx, err := args.ToTransaction(s.chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := "contract creation"
+	if tx.To != nil {
+		to = tx.To.Hex()
+	}
+	details := []string{
+		fmt.Sprintf("Chain ID: %s", tx.ChainID),
+		fmt.Sprintf("To:       %s", to),
+		fmt.Sprintf("Value:    %s ETH", coin.FormatEther(tx.Value)),
+		fmt.Sprintf("Nonce:    %d", tx.Nonce),
+		fmt.Sprintf("Gas:      %d", tx.Gas),
+	}
+	if tx.Type == LegacyTxType {
+		details = append(details, fmt.Sprintf("Gas price: %s wei", tx.GasPrice))
+	} else {
+		details = append(details,
+			fmt.Sprintf("Max fee:  %s wei", tx.MaxFeePerGas),
+			fmt.Sprintf("Priority: %s wei", tx.MaxPriorityFeePerGas))
+	}
+	if len(tx.Data) > 0 {
+		if s.previewer != nil {
+			details = append(details, s.previewer.PreviewCalldata(tx.Data)...)
+		} else {
+			details = append(details, fmt.Sprintf("Data:     %d bytes (selector 0x%x)", len(tx.Data), tx.Data[:min(4, len(tx.Data))]))
+		}
+	}
+
+	estimate, err := EstimateETH(tx)
+	if err != nil {
+		return nil, err
+	}
+	details = append(details, estimate.Lines()...)
+
+	target := policy.Input{Amount: new(big.Rat).SetFrac(tx.Value, big.NewInt(1e18))}
+	if tx.To != nil {
+		target.Destination = tx.To.Hex()
+	}
+	key, err := s.approve("eth_signTransaction", args.From, details, target, estimate.Warning())
+	if err != nil {
+		return nil, err
+	}
+	return tx.Sign(key)
+}
+
+// SignTypedData 按 EIP-712 签名结构化数据，返回 65 字节签名（v 为 27/28）
+func (s *Signer) SignTypedData(account common.Address, td *TypedData) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	var details []string
+	if s.previewer != nil {
+		details = s.previewer.PreviewTypedData(td)
+	} else {
+		details = append(details, fmt.Sprintf("Primary type: %s", td.PrimaryType))
+		for _, field := range domainFields {
+			if value, ok := td.Domain[field.Name]; ok {
+				details = append(details, fmt.Sprintf("Domain %s: %v", field.Name, value))
+			}
+		}
+	}
+	details = append(details, fmt.Sprintf("Digest: 0x%x", hash))
+
+	key, err := s.approve("eth_signTypedData", account, details, policy.Input{}, "")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignMessage 按 EIP-191（personal_sign）签名消息，返回 65 字节签名（v 为 27/28）
+func (s *Signer) SignMessage(account common.Address, data []byte) ([]byte, error) {
+	hash := textHash(data)
+
+	message := fmt.Sprintf("0x%x", data)
+	if utf8.Valid(data) {
+		message = string(data)
+	}
+	details := []string{
+		fmt.Sprintf("Message: %s", message),
+		fmt.Sprintf("Digest:  0x%x", hash),
+	}
+
+	key, err := s.approve("personal_sign", account, details, policy.Input{}, "")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// approve 执行签名策略、请求用户确认并返回账户私钥，结果写入审计日志；
+// target 给出交易的接收方和金额，消息签名为零值；fee 为手续费离谱的原因，为空表示正常或不适用
+func (s *Signer) approve(method string, account common.Address, details []string, target policy.Input, fee string) (*ecdsa.PrivateKey, error) {
+	addressKey, err := s.findAddress(account)
+	if err != nil {
+		s.record(method, account, "error")
+		return nil, err
+	}
+
+	req := &ApprovalRequest{Method: method, Origin: s.origin, Account: account, Details: details, To: target.Destination, Fee: fee}
+	if target.Destination != "" {
+		_, target.Own = s.accountMgr.IsMine(target.Destination)
+	}
+	if s.policyDir != "" {
+		target.Method, target.Coin, target.Origin = method, "ETH", s.origin
+		result, err := policy.Check(s.policyDir, target)
+		if err != nil {
+			s.record(method, account, "denied")
+			return nil, err
+		}
+		if result.Decision == policy.Confirm {
+			req.Policy = result.Reason()
+		}
+	}
+
+	var stats *keyusage.Stats
+	// 转给本钱包的金额和地址不计入常见金额和收款方
+	usage := keyusage.Input{Key: account.Hex(), Coin: "ETH", At: time.Now()}
+	if target.Destination != "" && !target.Own {
+		usage.Amount, usage.Destinations = target.Amount, []string{target.Destination}
+	}
+	if s.usagePath != "" {
+		if stats, err = keyusage.Load(s.usagePath, s.usageLimit); err != nil {
+			logging.Warnf("Failed to load signing statistics: %v", err)
+		} else if req.Anomalies = stats.Check(usage); len(req.Anomalies) > 0 {
+			s.record(method, account, "anomaly: "+strings.Join(req.Anomalies, "; "))
+			if s.bus != nil {
+				s.bus.Publish(events.Event{Type: keyusage.Detected, Data: &keyusage.Anomaly{
+					Key: usage.Key, Coin: usage.Coin, Method: method, Reasons: req.Anomalies, At: usage.At.UTC()}})
+			}
+		}
+	}
+
+	if !s.approver.Approve(req) {
+		s.record(method, account, "rejected")
+		return nil, ErrRejected
+	}
+	if req.Policy != "" || len(req.Anomalies) > 0 || req.Fee != "" {
+		confirmer, ok := s.approver.(Confirmer)
+		if !ok || !confirmer.Confirm(req) {
+			s.record(method, account, "rejected")
+			return nil, ErrRejected
+		}
+	}
+
+	privateKey, err := s.accountMgr.AddressPrivateKey(addressKey)
+	if err != nil {
+		s.record(method, account, "error")
+		return nil, err
+	}
+	defer privateKey.Destroy()
+	key, err := crypto.ToECDSA(privateKey.Bytes())
+	if err != nil {
+		s.record(method, account, "error")
+		return nil, err
+	}
+	s.record(method, account, "approved")
+	if stats != nil {
+		if err := stats.Record(usage); err != nil {
+			logging.Warnf("Failed to record signing statistics: %v", err)
+		}
+	}
+	// 批准后签名本身不会因用户输入失败，在此按方法计数
+	metrics.Inc(metrics.Signatures, "method", method)
+	return key, nil
+}
+
+func (s *Signer) record(method string, account common.Address, result string) {
+	if s.auditLog == nil {
+		return
+	}
+	actor := "signer"
+	if s.origin != "" {
+		actor = "signer:" + s.origin
+	}
+	s.auditLog.Record(actor, method, account.Hex(), result)
+}
+
+func (s *Signer) findAddress(account common.Address) (*core.AddressKey, error) {
+	keys, err := s.addressKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key.Address, account.Hex()) {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnknownAccount, account.Hex())
+}
+
+func (s *Signer) addressKeys() ([]*core.AddressKey, error) {
+	accounts, err := s.accountMgr.GetAccountsByCoin(coin.CoinTypeETH | coin.HardenedBit)
+	if err != nil {
+		return nil, err
+	}
+	var result []*core.AddressKey
+	for _, account := range accounts {
+		addresses, err := s.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addresses {
+			if common.IsHexAddress(addr.Address) {
+				result = append(result, addr)
+			}
+		}
+	}
+	return result, nil
+}
+
+// textHash 计算 keccak256("\x19Ethereum Signed Message:\n" ‖ len(data) ‖ data)
+func textHash(data []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data))
+	return crypto.Keccak256([]byte(prefix), data)
+}
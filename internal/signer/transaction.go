@@ -0,0 +1,190 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// 交易类型
+const (
+	LegacyTxType     = 0
+	DynamicFeeTxType = 2
+)
+
+// AccessTuple EIP-2930 访问列表项
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// TransactionArgs eth_signTransaction 的参数，字段与以太坊 JSON-RPC 一致
+type TransactionArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                *hexutil.Uint64 `json:"nonce"`
+	Data                 *hexutil.Bytes  `json:"data"`
+	Input                *hexutil.Bytes  `json:"input"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	AccessList           []AccessTuple   `json:"accessList"`
+}
+
+// Transaction 待签名的交易，由 TransactionArgs 补全默认值后得到
+type Transaction struct {
+	Type                 int
+	ChainID              *big.Int
+	Nonce                uint64
+	GasPrice             *big.Int // 仅 legacy 交易
+	MaxFeePerGas         *big.Int // 仅 EIP-1559 交易
+	MaxPriorityFeePerGas *big.Int
+	Gas                  uint64
+	To                   *common.Address // nil 表示合约创建
+	Value                *big.Int
+	Data                 []byte
+	AccessList           []AccessTuple
+}
+
+// ToTransaction 校验参数并构造交易，未指定 chainId 时使用 defaultChainID
+// 签名端不连接节点，因此 nonce 和 gas 必须由调用方提供
+func (args *TransactionArgs) ToTransaction(defaultChainID *big.Int) (*Transaction, error) {
+	if args.Nonce == nil {
+		return nil, errors.New("nonce must be specified")
+	}
+	if args.Gas == nil {
+		return nil, errors.New("gas must be specified")
+	}
+	if args.Data != nil && args.Input != nil && string(*args.Data) != string(*args.Input) {
+		return nil, errors.New("both data and input are set and not equal")
+	}
+
+	tx := &Transaction{
+		ChainID:    defaultChainID,
+		Nonce:      uint64(*args.Nonce),
+		Gas:        uint64(*args.Gas),
+		To:         args.To,
+		Value:      new(big.Int),
+		AccessList: args.AccessList,
+	}
+	if args.ChainID != nil {
+		tx.ChainID = args.ChainID.ToInt()
+	}
+	if tx.ChainID == nil || tx.ChainID.Sign() <= 0 {
+		return nil, errors.New("chainId must be specified")
+	}
+	if args.Value != nil {
+		tx.Value = args.Value.ToInt()
+	}
+	if args.Input != nil {
+		tx.Data = *args.Input
+	} else if args.Data != nil {
+		tx.Data = *args.Data
+	}
+	if tx.To == nil && len(tx.Data) == 0 {
+		return nil, errors.New("contract creation without data")
+	}
+
+	switch {
+	case args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil):
+		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	case args.GasPrice != nil:
+		tx.Type = LegacyTxType
+		tx.GasPrice = args.GasPrice.ToInt()
+	case args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas != nil:
+		tx.Type = DynamicFeeTxType
+		tx.MaxFeePerGas = args.MaxFeePerGas.ToInt()
+		tx.MaxPriorityFeePerGas = args.MaxPriorityFeePerGas.ToInt()
+		if tx.MaxPriorityFeePerGas.Cmp(tx.MaxFeePerGas) > 0 {
+			return nil, errors.New("maxPriorityFeePerGas is higher than maxFeePerGas")
+		}
+	default:
+		return nil, errors.New("gasPrice or maxFeePerGas and maxPriorityFeePerGas must be specified")
+	}
+	return tx, nil
+}
+
+// toField 合约创建时 to 编码为空字节串
+func (tx *Transaction) toField() []byte {
+	if tx.To == nil {
+		return []byte{}
+	}
+	return tx.To.Bytes()
+}
+
+func (tx *Transaction) accessListField() []AccessTuple {
+	if tx.AccessList == nil {
+		return []AccessTuple{}
+	}
+	return tx.AccessList
+}
+
+// SigningHash 返回交易签名摘要（legacy 交易使用 EIP-155）
+func (tx *Transaction) SigningHash() ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+	switch tx.Type {
+	case LegacyTxType:
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			tx.Nonce, tx.GasPrice, tx.Gas, tx.toField(), tx.Value, tx.Data,
+			tx.ChainID, uint(0), uint(0),
+		})
+	case DynamicFeeTxType:
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			tx.ChainID, tx.Nonce, tx.MaxPriorityFeePerGas, tx.MaxFeePerGas, tx.Gas,
+			tx.toField(), tx.Value, tx.Data, tx.accessListField(),
+		})
+		payload = append([]byte{DynamicFeeTxType}, payload...)
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(payload), nil
+}
+
+// Sign 签名交易并返回可广播的原始交易编码
+func (tx *Transaction) Sign(key *ecdsa.PrivateKey) ([]byte, error) {
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	recoveryID := uint64(sig[64])
+
+	switch tx.Type {
+	case LegacyTxType:
+		// EIP-155: v = recoveryID + chainId*2 + 35
+		v := new(big.Int).Mul(tx.ChainID, big.NewInt(2))
+		v.Add(v, new(big.Int).SetUint64(recoveryID+35))
+		return rlp.EncodeToBytes([]interface{}{
+			tx.Nonce, tx.GasPrice, tx.Gas, tx.toField(), tx.Value, tx.Data, v, r, s,
+		})
+	default:
+		payload, err := rlp.EncodeToBytes([]interface{}{
+			tx.ChainID, tx.Nonce, tx.MaxPriorityFeePerGas, tx.MaxFeePerGas, tx.Gas,
+			tx.toField(), tx.Value, tx.Data, tx.accessListField(), recoveryID, r, s,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{DynamicFeeTxType}, payload...), nil
+	}
+}
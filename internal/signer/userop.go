@@ -0,0 +1,30 @@
+package signer
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/policy"
+)
+
+// MethodUserOperation ERC-4337 UserOperation 签名在策略、审计和统计中使用的方法名
+const MethodUserOperation = "eth_signUserOperation"
+
+// SignUserOperation 用智能账户的所有者密钥签名 userOpHash：SimpleAccount 按 EIP-191 校验，与 personal_sign 相同。
+// details 是调用方整理的操作摘要；target 给出智能账户实际转账的接收方和金额，供策略和签名统计使用
+func (s *Signer) SignUserOperation(owner common.Address, userOpHash []byte, details []string, target policy.Input) ([]byte, error) {
+	if len(userOpHash) != 32 {
+		return nil, errors.New("userOpHash must be 32 bytes")
+	}
+	key, err := s.approve(MethodUserOperation, owner, details, target, "")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(textHash(userOpHash), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
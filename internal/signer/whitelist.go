@@ -0,0 +1,61 @@
+package signer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WhitelistType 签名地址清单文件的类型标识，带版本号
+const WhitelistType = "slowmade-address-whitelist/1"
+
+// Whitelist 交给交易所登记提币地址的清单：同一账户的一组地址，每个都附带所有权证明
+type Whitelist struct {
+	Type      string           `json:"type"`
+	Account   string           `json:"account"`
+	Coin      string           `json:"coin"`
+	Generated time.Time        `json:"generated"`
+	Addresses []WhitelistEntry `json:"addresses"`
+}
+
+// WhitelistEntry 清单中的一个地址：地址索引、所有权证明和被签名的声明原文，
+// 对方不使用本工具也能按 personal_sign 核验
+type WhitelistEntry struct {
+	Index uint32 `json:"index"`
+	*Proof
+	Statement string `json:"statement"`
+}
+
+// NewWhitelist 创建空清单
+func NewWhitelist(account, coin string) *Whitelist {
+	return &Whitelist{Type: WhitelistType, Account: account, Coin: coin, Generated: time.Now().UTC().Truncate(time.Second)}
+}
+
+// Add 加入一个地址的证明
+func (w *Whitelist) Add(index uint32, proof *Proof) {
+	w.Addresses = append(w.Addresses, WhitelistEntry{Index: index, Proof: proof, Statement: string(proof.Statement())})
+}
+
+// WriteJSON 写出 JSON 格式的清单
+func (w *Whitelist) WriteJSON(out io.Writer) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+// WriteCSV 写出 CSV 格式的清单，每个地址一行，声明原文含换行，按 CSV 规则加引号
+func (w *Whitelist) WriteCSV(out io.Writer) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"index", "coin", "address", "timestamp", "message", "path_hash", "signature", "statement"})
+	for _, entry := range w.Addresses {
+		writer.Write([]string{strconv.FormatUint(uint64(entry.Index), 10), entry.Coin, entry.Address,
+			entry.Timestamp.UTC().Format(time.RFC3339), entry.Message, entry.PathHash, entry.Signature, entry.Statement})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,165 @@
+package stellar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// DefaultHorizonURL SDF 运营的主网 Horizon
+const DefaultHorizonURL = "https://horizon.stellar.org"
+
+// 错误定义
+var (
+	ErrAccountNotFound = errors.New("account not found, it has never been funded")
+	ErrRejected        = errors.New("transaction rejected")
+)
+
+// memoRequiredValue SEP-29：账户数据 config.memo_required 为 "1"（base64 MQ==）时收款必须带备注
+const memoRequiredValue = "MQ=="
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// Horizon Stellar 的 Horizon REST 接口
+type Horizon struct {
+	baseURL string
+}
+
+// NewHorizon 创建 Horizon 客户端，baseURL 为空时使用主网公共接口
+func NewHorizon(baseURL string) *Horizon {
+	if baseURL == "" {
+		baseURL = DefaultHorizonURL
+	}
+	return &Horizon{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (h *Horizon) Name() string     { return "horizon:" + h.baseURL }
+func (h *Horizon) ThirdParty() bool { return true }
+
+// Account 账户状态
+type Account struct {
+	Sequence     int64
+	Balance      *big.Int // XLM 余额（stroops）
+	MemoRequired bool     // 按 SEP-29 要求收款带备注
+}
+
+// Account 查询账户，未激活的账户返回 ErrAccountNotFound
+func (h *Horizon) Account(ctx context.Context, address string) (*Account, error) {
+	var result struct {
+		Sequence string `json:"sequence"`
+		Balances []struct {
+			AssetType string `json:"asset_type"`
+			Balance   string `json:"balance"`
+		} `json:"balances"`
+		Data map[string]string `json:"data"`
+	}
+	if err := h.get(ctx, "/accounts/"+url.PathEscape(address), &result); err != nil {
+		return nil, err
+	}
+	sequence, err := strconv.ParseInt(result.Sequence, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("stellar: invalid sequence %q", result.Sequence)
+	}
+	account := &Account{Sequence: sequence, Balance: new(big.Int), MemoRequired: result.Data["config.memo_required"] == memoRequiredValue}
+	for _, balance := range result.Balances {
+		if balance.AssetType == "native" {
+			if account.Balance, err = coin.ParseUnits(balance.Balance, Decimals); err != nil {
+				return nil, fmt.Errorf("stellar: invalid balance %q", balance.Balance)
+			}
+		}
+	}
+	return account, nil
+}
+
+// Balance XLM 余额（stroops），未激活的账户为 0
+func (h *Horizon) Balance(ctx context.Context, address string) (*big.Int, error) {
+	account, err := h.Account(ctx, address)
+	if errors.Is(err, ErrAccountNotFound) {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account.Balance, nil
+}
+
+// BaseFee 最近一个账本的每操作基础手续费（stroops）
+func (h *Horizon) BaseFee(ctx context.Context) (uint32, error) {
+	var result struct {
+		LastLedgerBaseFee string `json:"last_ledger_base_fee"`
+	}
+	if err := h.get(ctx, "/fee_stats", &result); err != nil {
+		return 0, err
+	}
+	fee, err := strconv.ParseUint(result.LastLedgerBaseFee, 10, 32)
+	return uint32(fee), err
+}
+
+// Submit 提交已签名的交易，返回交易哈希
+func (h *Horizon) Submit(ctx context.Context, tx *Payment) (string, error) {
+	envelope, err := tx.EnvelopeBase64()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+"/transactions",
+		strings.NewReader(url.Values{"tx": {envelope}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Hash   string `json:"hash"`
+		Title  string `json:"title"`
+		Extras struct {
+			ResultCodes struct {
+				Transaction string   `json:"transaction"`
+				Operations  []string `json:"operations"`
+			} `json:"result_codes"`
+		} `json:"extras"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		codes := result.Extras.ResultCodes
+		return "", fmt.Errorf("%w: %s %s %s", ErrRejected, result.Title, codes.Transaction, strings.Join(codes.Operations, ","))
+	}
+	return result.Hash, nil
+}
+
+func (h *Horizon) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && strings.HasPrefix(path, "/accounts/") {
+		return ErrAccountNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,69 @@
+// Package stellar Stellar 的 StrKey 地址、付款交易的 XDR 编码和 ed25519 签名，以及 Horizon 接口
+package stellar
+
+import (
+	"crypto/ed25519"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidAddress 不是有效的 Stellar 账户地址
+var ErrInvalidAddress = errors.New("invalid stellar address")
+
+// versionAccountID 账户公钥（G...）的版本字节
+const versionAccountID = 6 << 3
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeAccount 把 32 字节 ed25519 公钥编码为 G... 地址：版本字节、公钥和小端 CRC16-XModem 校验和
+func EncodeAccount(publicKey []byte) (string, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("stellar: public key must be 32 bytes, got %d", len(publicKey))
+	}
+	data := append([]byte{versionAccountID}, publicKey...)
+	data = binary.LittleEndian.AppendUint16(data, crc16(data))
+	return encoding.EncodeToString(data), nil
+}
+
+// DecodeAccount 解码 G... 地址，返回公钥
+func DecodeAccount(address string) ([]byte, error) {
+	data, err := encoding.DecodeString(address)
+	if err != nil || len(data) != 35 || data[0] != versionAccountID {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	if binary.LittleEndian.Uint16(data[33:]) != crc16(data[:33]) {
+		return nil, fmt.Errorf("%w: %s has a bad checksum", ErrInvalidAddress, address)
+	}
+	return data[1:33], nil
+}
+
+// AddressFromSeed 以 32 字节种子生成 ed25519 密钥，返回地址和公钥
+func AddressFromSeed(seed []byte) (string, []byte, error) {
+	if len(seed) != ed25519.SeedSize {
+		return "", nil, fmt.Errorf("ed25519 seed must be %d bytes", ed25519.SeedSize)
+	}
+	publicKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	address, err := EncodeAccount(publicKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return address, publicKey, nil
+}
+
+// crc16 CRC16-XModem（多项式 0x1021，初值 0）
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,195 @@
+package stellar
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Decimals XLM 精度，1 XLM = 10^7 stroops
+const Decimals = 7
+
+// 网络口令，交易签名时区分网络
+const (
+	PublicNetwork  = "Public Global Stellar Network ; September 2015"
+	TestnetNetwork = "Test SDF Network ; September 2015"
+)
+
+// MaxMemoText 文本备注的最大字节数
+const MaxMemoText = 28
+
+// 错误定义
+var (
+	ErrInvalidAmount = errors.New("amount must be positive")
+	ErrUnsigned      = errors.New("transaction is not signed")
+)
+
+// XDR 枚举值
+const (
+	envelopeTypeTx   = 2
+	keyTypeEd25519   = 0
+	preconditionTime = 1
+	memoNone         = 0
+	memoText         = 1
+	memoID           = 2
+	opCreateAccount  = 0
+	opPayment        = 1
+	assetTypeNative  = 0
+)
+
+// Memo 交易备注：文本或 64 位 ID，交易所用它区分充值用户
+type Memo struct {
+	Text string
+	ID   uint64
+	IsID bool
+}
+
+// Empty 没有备注
+func (m Memo) Empty() bool {
+	return !m.IsID && m.Text == ""
+}
+
+// String 显示用的备注
+func (m Memo) String() string {
+	if m.IsID {
+		return fmt.Sprintf("id %d", m.ID)
+	}
+	return fmt.Sprintf("text %q", m.Text)
+}
+
+// Payment XLM 付款；CreateAccount 为 true 时改用 create_account 操作激活新账户
+type Payment struct {
+	Source        []byte // 付款账户公钥
+	Destination   []byte
+	Amount        int64 // stroops
+	Fee           uint32
+	Sequence      int64
+	MaxTime       uint64 // 交易失效的 Unix 时间
+	Memo          Memo
+	CreateAccount bool
+	Network       string // 网络口令
+	signature     []byte
+}
+
+// transaction Transaction 结构的 XDR 编码
+func (p *Payment) transaction() []byte {
+	var buf bytes.Buffer
+	putUint32(&buf, keyTypeEd25519) // sourceAccount: MuxedAccount
+	buf.Write(p.Source)
+	putUint32(&buf, p.Fee)
+	putUint64(&buf, uint64(p.Sequence))
+	putUint32(&buf, preconditionTime) // cond: TimeBounds{minTime, maxTime}
+	putUint64(&buf, 0)
+	putUint64(&buf, p.MaxTime)
+	switch {
+	case p.Memo.IsID:
+		putUint32(&buf, memoID)
+		putUint64(&buf, p.Memo.ID)
+	case p.Memo.Text != "":
+		putUint32(&buf, memoText)
+		putOpaque(&buf, []byte(p.Memo.Text))
+	default:
+		putUint32(&buf, memoNone)
+	}
+	putUint32(&buf, 1) // operations<100>
+	putUint32(&buf, 0) // 操作的 sourceAccount 为空
+	if p.CreateAccount {
+		putUint32(&buf, opCreateAccount)
+		putUint32(&buf, keyTypeEd25519) // destination: AccountID
+		buf.Write(p.Destination)
+		putUint64(&buf, uint64(p.Amount))
+	} else {
+		putUint32(&buf, opPayment)
+		putUint32(&buf, keyTypeEd25519) // destination: MuxedAccount
+		buf.Write(p.Destination)
+		putUint32(&buf, assetTypeNative)
+		putUint64(&buf, uint64(p.Amount))
+	}
+	putUint32(&buf, 0) // ext
+	return buf.Bytes()
+}
+
+// hash 签名的哈希：sha256(网络 ID ++ ENVELOPE_TYPE_TX ++ 交易)，也是交易哈希
+func (p *Payment) hash() []byte {
+	networkID := sha256.Sum256([]byte(p.Network))
+	payload := append(networkID[:], binary.BigEndian.AppendUint32(nil, envelopeTypeTx)...)
+	sum := sha256.Sum256(append(payload, p.transaction()...))
+	return sum[:]
+}
+
+// Sign 以 32 字节种子生成 ed25519 密钥并签名，签名后清除 seed；公钥必须与 Source 一致
+func (p *Payment) Sign(seed []byte) error {
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	if p.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if len(p.Memo.Text) > MaxMemoText {
+		return fmt.Errorf("memo text is longer than %d bytes", MaxMemoText)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return errors.New("ed25519 seed must be 32 bytes")
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+	if !key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(p.Source)) {
+		return errors.New("signing key does not match the sender")
+	}
+	p.signature = ed25519.Sign(key, p.hash())
+	return nil
+}
+
+// Encode 已签名的 TransactionEnvelope，base64 编码后提交给 Horizon
+func (p *Payment) Encode() ([]byte, error) {
+	if p.signature == nil {
+		return nil, ErrUnsigned
+	}
+	var buf bytes.Buffer
+	putUint32(&buf, envelopeTypeTx)
+	buf.Write(p.transaction())
+	putUint32(&buf, 1)       // signatures<20>
+	buf.Write(p.Source[28:]) // hint：公钥的最后 4 字节
+	putOpaque(&buf, p.signature)
+	return buf.Bytes(), nil
+}
+
+// EnvelopeBase64 base64 编码的交易信封
+func (p *Payment) EnvelopeBase64() (string, error) {
+	encoded, err := p.Encode()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// Hash 交易哈希（十六进制）
+func (p *Payment) Hash() string {
+	return hex.EncodeToString(p.hash())
+}
+
+func putUint32(buf *bytes.Buffer, v uint32) {
+	buf.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func putUint64(buf *bytes.Buffer, v uint64) {
+	buf.Write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+// putOpaque 变长数据：长度、内容，补零到 4 字节对齐
+func putOpaque(buf *bytes.Buffer, data []byte) {
+	putUint32(buf, uint32(len(data)))
+	buf.Write(data)
+	buf.Write(make([]byte, (4-len(data)%4)%4))
+}
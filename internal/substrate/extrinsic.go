@@ -0,0 +1,185 @@
+package substrate
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// 交易格式：第 4 版 extrinsic，最高位表示已签名
+const (
+	extrinsicVersion = 4
+	signedBit        = 0x80
+)
+
+// MultiAddress::Id 和 MultiSignature::Ed25519 的枚举下标
+const (
+	multiAddressID   = 0x00
+	multiSigEd25519  = 0x00
+	mortalPeriod     = 64 // 交易在参考区块之后的有效区块数，约 6 分钟
+	maxPayloadLength = 256
+)
+
+// 错误定义
+var (
+	ErrInvalidAmount = errors.New("amount must be positive")
+	ErrUnsigned      = errors.New("extrinsic is not signed")
+)
+
+// Runtime 签名需要的链状态：运行时版本、创世区块和参考区块
+type Runtime struct {
+	SpecVersion        uint32
+	TransactionVersion uint32
+	GenesisHash        []byte
+	BlockHash          []byte // 参考区块（最新的最终确定区块）
+	BlockNumber        uint64
+	MetadataHash       bool // 运行时包含 CheckMetadataHash 扩展（Polkadot、Kusama 自 2024 年起）
+}
+
+// Transfer balances.transfer_keep_alive 转账：转出后账户保留存在性押金，不会被清除
+type Transfer struct {
+	Pallet    byte // Balances 模块在运行时中的下标
+	Call      byte // transfer_keep_alive 在模块中的下标
+	From      []byte
+	To        []byte
+	Amount    *big.Int // planck
+	Nonce     uint64
+	Tip       *big.Int
+	Runtime   Runtime
+	signature []byte
+}
+
+// call 编码的调用：模块和调用下标、MultiAddress::Id(收款账户)、Compact<u128> 金额
+func (t *Transfer) call() []byte {
+	call := []byte{t.Pallet, t.Call, multiAddressID}
+	call = append(call, t.To...)
+	return appendCompact(call, t.Amount)
+}
+
+// extra 随交易发送的签名扩展：mortality、nonce、tip，以及 CheckMetadataHash 的 mode
+func (t *Transfer) extra() []byte {
+	extra := mortalEra(t.Runtime.BlockNumber)
+	extra = appendCompact(extra, new(big.Int).SetUint64(t.Nonce))
+	extra = appendCompact(extra, t.tip())
+	if t.Runtime.MetadataHash {
+		extra = append(extra, 0) // mode = Disabled
+	}
+	return extra
+}
+
+// additional 只参与签名不随交易发送的数据
+func (t *Transfer) additional() []byte {
+	additional := binary.LittleEndian.AppendUint32(nil, t.Runtime.SpecVersion)
+	additional = binary.LittleEndian.AppendUint32(additional, t.Runtime.TransactionVersion)
+	additional = append(additional, t.Runtime.GenesisHash...)
+	additional = append(additional, t.Runtime.BlockHash...)
+	if t.Runtime.MetadataHash {
+		additional = append(additional, 0) // Option<Hash>::None
+	}
+	return additional
+}
+
+// payload 签名的数据，超过 256 字节时改为签名其 blake2b-256
+func (t *Transfer) payload() []byte {
+	payload := append(t.call(), t.extra()...)
+	payload = append(payload, t.additional()...)
+	if len(payload) > maxPayloadLength {
+		hash := blake2b.Sum256(payload)
+		return hash[:]
+	}
+	return payload
+}
+
+func (t *Transfer) tip() *big.Int {
+	if t.Tip == nil {
+		return new(big.Int)
+	}
+	return t.Tip
+}
+
+// Sign 以 32 字节种子生成 ed25519 密钥并签名，签名后清除 seed；公钥必须与 From 一致
+func (t *Transfer) Sign(seed []byte) error {
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	if t.Amount == nil || t.Amount.Sign() <= 0 {
+		return ErrInvalidAmount
+	}
+	if len(seed) != ed25519.SeedSize {
+		return errors.New("ed25519 seed must be 32 bytes")
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+	if !key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(t.From)) {
+		return errors.New("signing key does not match the sender")
+	}
+	t.signature = ed25519.Sign(key, t.payload())
+	return nil
+}
+
+// Encode 已签名交易的 SCALE 编码（带长度前缀），用于 author_submitExtrinsic
+func (t *Transfer) Encode() ([]byte, error) {
+	if t.signature == nil {
+		return nil, ErrUnsigned
+	}
+	body := []byte{signedBit | extrinsicVersion, multiAddressID}
+	body = append(body, t.From...)
+	body = append(body, multiSigEd25519)
+	body = append(body, t.signature...)
+	body = append(body, t.extra()...)
+	body = append(body, t.call()...)
+	return append(appendCompact(nil, big.NewInt(int64(len(body)))), body...), nil
+}
+
+// Hash 交易哈希，即编码后交易的 blake2b-256
+func (t *Transfer) Hash() (string, error) {
+	encoded, err := t.Encode()
+	if err != nil {
+		return "", err
+	}
+	hash := blake2b.Sum256(encoded)
+	return "0x" + hex.EncodeToString(hash[:]), nil
+}
+
+// mortalEra 以 blockNumber 为起点、有效期 mortalPeriod 个区块的 Era 编码
+func mortalEra(blockNumber uint64) []byte {
+	period := uint64(mortalPeriod)
+	phase := blockNumber % period
+	quantizeFactor := max(period>>12, 1)
+	encoded := uint16(min(15, max(1, bits.TrailingZeros64(period)-1))) | uint16(phase/quantizeFactor)<<4
+	return binary.LittleEndian.AppendUint16(nil, encoded)
+}
+
+// appendCompact SCALE 紧凑整数编码
+func appendCompact(buf []byte, n *big.Int) []byte {
+	switch {
+	case n.IsUint64() && n.Uint64() < 1<<6:
+		return append(buf, byte(n.Uint64()<<2))
+	case n.IsUint64() && n.Uint64() < 1<<14:
+		return binary.LittleEndian.AppendUint16(buf, uint16(n.Uint64()<<2|0b01))
+	case n.IsUint64() && n.Uint64() < 1<<30:
+		return binary.LittleEndian.AppendUint32(buf, uint32(n.Uint64()<<2|0b10))
+	}
+	// 大整数模式：首字节高 6 位为字节数减 4，之后是小端字节
+	be := n.Bytes()
+	le := make([]byte, len(be))
+	for i, b := range be {
+		le[len(be)-1-i] = b
+	}
+	for len(le) < 4 {
+		le = append(le, 0)
+	}
+	buf = append(buf, byte(len(le)-4)<<2|0b11)
+	return append(buf, le...)
+}
@@ -0,0 +1,175 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrRPC 节点返回了 JSON-RPC 错误
+var ErrRPC = errors.New("substrate rpc error")
+
+// systemAccountPrefix twox128("System") ++ twox128("Account")，System.Account 存储项的键前缀
+var systemAccountPrefix, _ = hex.DecodeString("26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9")
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// Client Substrate 节点的 HTTP JSON-RPC 接口
+type Client struct {
+	url string
+	id  atomic.Int64
+}
+
+// NewClient 创建 JSON-RPC 客户端
+func NewClient(url string) *Client {
+	return &Client{url: url}
+}
+
+func (c *Client) Name() string     { return "substrate:" + c.url }
+func (c *Client) ThirdParty() bool { return true }
+
+// Balance 账户的可用余额（free，planck），不存在的账户为 0
+func (c *Client) Balance(ctx context.Context, address string) (*big.Int, error) {
+	_, accountID, err := Decode(address)
+	if err != nil {
+		return nil, err
+	}
+	// 键：前缀 ++ blake2_128(账户 ID) ++ 账户 ID
+	hash, _ := blake2b.New(16, nil)
+	hash.Write(accountID)
+	key := append(append(append([]byte(nil), systemAccountPrefix...), hash.Sum(nil)...), accountID...)
+	var value *string
+	if err := c.call(ctx, "state_getStorage", []interface{}{"0x" + hex.EncodeToString(key)}, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return new(big.Int), nil
+	}
+	// AccountInfo：nonce、consumers、providers、sufficients 各 u32，之后 AccountData 以 free: u128 开头
+	data, err := decodeHex(*value)
+	if err != nil || len(data) < 32 {
+		return nil, fmt.Errorf("substrate: unexpected account data %q", *value)
+	}
+	return leUint(data[16:32]), nil
+}
+
+// Nonce 账户的下一个交易序号，包含交易池中未打包的交易
+func (c *Client) Nonce(ctx context.Context, address string) (uint64, error) {
+	var nonce uint64
+	err := c.call(ctx, "system_accountNextIndex", []interface{}{address}, &nonce)
+	return nonce, err
+}
+
+// Runtime 查询运行时版本、创世区块和最新的最终确定区块
+func (c *Client) Runtime(ctx context.Context) (Runtime, error) {
+	var version struct {
+		SpecVersion        uint32 `json:"specVersion"`
+		TransactionVersion uint32 `json:"transactionVersion"`
+	}
+	if err := c.call(ctx, "state_getRuntimeVersion", []interface{}{}, &version); err != nil {
+		return Runtime{}, err
+	}
+	var genesis, head string
+	if err := c.call(ctx, "chain_getBlockHash", []interface{}{0}, &genesis); err != nil {
+		return Runtime{}, err
+	}
+	if err := c.call(ctx, "chain_getFinalizedHead", []interface{}{}, &head); err != nil {
+		return Runtime{}, err
+	}
+	var header struct {
+		Number string `json:"number"`
+	}
+	if err := c.call(ctx, "chain_getHeader", []interface{}{head}, &header); err != nil {
+		return Runtime{}, err
+	}
+	number, err := strconv.ParseUint(strings.TrimPrefix(header.Number, "0x"), 16, 64)
+	if err != nil {
+		return Runtime{}, fmt.Errorf("substrate: invalid block number %q", header.Number)
+	}
+	genesisHash, err := decodeHex(genesis)
+	if err != nil || len(genesisHash) != 32 {
+		return Runtime{}, fmt.Errorf("substrate: invalid genesis hash %q", genesis)
+	}
+	blockHash, err := decodeHex(head)
+	if err != nil || len(blockHash) != 32 {
+		return Runtime{}, fmt.Errorf("substrate: invalid block hash %q", head)
+	}
+	return Runtime{SpecVersion: version.SpecVersion, TransactionVersion: version.TransactionVersion,
+		GenesisHash: genesisHash, BlockHash: blockHash, BlockNumber: number}, nil
+}
+
+// Submit 提交已签名的交易，返回交易哈希
+func (c *Client) Submit(ctx context.Context, tx *Transfer) (string, error) {
+	encoded, err := tx.Encode()
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if err := c.call(ctx, "author_submitExtrinsic", []interface{}{"0x" + hex.EncodeToString(encoded)}, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// call 发送 JSON-RPC 请求并解码结果
+func (c *Client) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": c.id.Add(1), "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return fmt.Errorf("%w: %s: %s %s", ErrRPC, method, result.Error.Message, strings.Trim(string(result.Error.Data), `"`))
+	}
+	return json.Unmarshal(result.Result, out)
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// leUint 小端无符号整数
+func leUint(data []byte) *big.Int {
+	be := make([]byte, len(data))
+	for i, b := range data {
+		be[len(data)-1-i] = b
+	}
+	return new(big.Int).SetBytes(be)
+}
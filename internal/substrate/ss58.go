@@ -0,0 +1,96 @@
+// Package substrate Polkadot、Kusama 等 Substrate 链的 SS58 地址、SCALE 编码、余额转账交易（extrinsic）的
+// 构造和签名，以及节点 JSON-RPC 接口。密钥使用 ed25519（MultiSignature::Ed25519）
+package substrate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/palagend/slowmade/pkg/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+// 网络的 SS58 地址前缀
+const (
+	PrefixPolkadot uint16 = 0
+	PrefixKusama   uint16 = 2
+	PrefixGeneric  uint16 = 42
+)
+
+// ErrInvalidAddress 不是有效的 SS58 地址
+var ErrInvalidAddress = errors.New("invalid ss58 address")
+
+var ss58Prefix = []byte("SS58PRE")
+
+// Encode 按 SS58 编码 32 字节账户 ID：前缀、账户 ID 和 blake2b-512 校验和的前 2 字节
+func Encode(prefix uint16, accountID []byte) (string, error) {
+	if len(accountID) != 32 {
+		return "", fmt.Errorf("ss58: account id must be 32 bytes, got %d", len(accountID))
+	}
+	if prefix > 16383 {
+		return "", fmt.Errorf("ss58: prefix %d out of range", prefix)
+	}
+	data := append(encodePrefix(prefix), accountID...)
+	return base58.Encode(append(data, checksum(data)...)), nil
+}
+
+// Decode 解码 SS58 地址，返回网络前缀和 32 字节账户 ID
+func Decode(address string) (uint16, []byte, error) {
+	data, err := base58.Decode(address)
+	if err != nil || len(data) < 35 {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	prefixLen := 1
+	prefix := uint16(data[0])
+	if data[0]&0x40 != 0 {
+		// 两字节前缀：低 6 位和高 8 位分散在两个字节中
+		prefixLen = 2
+		prefix = uint16(data[0]&0x3f)<<2 | uint16(data[1])>>6 | uint16(data[1]&0x3f)<<8
+	}
+	if len(data) != prefixLen+32+2 {
+		return 0, nil, fmt.Errorf("%w: %s is not a 32-byte account", ErrInvalidAddress, address)
+	}
+	body := data[:prefixLen+32]
+	if !bytes.Equal(checksum(body), data[prefixLen+32:]) {
+		return 0, nil, fmt.Errorf("%w: %s has a bad checksum", ErrInvalidAddress, address)
+	}
+	return prefix, append([]byte(nil), data[prefixLen:prefixLen+32]...), nil
+}
+
+// Convert 把地址换成另一个网络的前缀
+func Convert(address string, prefix uint16) (string, error) {
+	_, accountID, err := Decode(address)
+	if err != nil {
+		return "", err
+	}
+	return Encode(prefix, accountID)
+}
+
+// AddressFromSeed 以 32 字节种子生成 ed25519 密钥，返回地址和公钥（即账户 ID）
+func AddressFromSeed(prefix uint16, seed []byte) (string, []byte, error) {
+	if len(seed) != ed25519.SeedSize {
+		return "", nil, fmt.Errorf("ed25519 seed must be %d bytes", ed25519.SeedSize)
+	}
+	publicKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	address, err := Encode(prefix, publicKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return address, publicKey, nil
+}
+
+func encodePrefix(prefix uint16) []byte {
+	if prefix < 64 {
+		return []byte{byte(prefix)}
+	}
+	return []byte{byte(prefix&0xfc)>>2 | 0x40, byte(prefix>>8) | byte(prefix&0x03)<<6}
+}
+
+func checksum(data []byte) []byte {
+	hash, _ := blake2b.New512(nil)
+	hash.Write(ss58Prefix)
+	hash.Write(data)
+	return hash.Sum(nil)[:2]
+}
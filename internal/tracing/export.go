@@ -0,0 +1,194 @@
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/otlp"
+)
+
+// Options 追踪配置，Endpoint 为空时不启用
+type Options struct {
+	Endpoint    string            // collector 地址：https://host:4317，或 http://host:4317（明文 HTTP/2）
+	TLS         otlp.TLSFiles     // 服务器 CA 和双向 TLS 的客户端证书
+	Headers     map[string]string // 随请求发送的 gRPC 元数据，如鉴权令牌
+	SampleRatio float64           // 被记录的命令比例，0 到 1
+	ServiceName string
+	Version     string
+	Host        string
+	Redact      func(string) string // 遮盖错误信息中的私密内容
+}
+
+// 一批最多发送的 span 数、等待发送的最大 span 数和不满一批时的发送间隔
+const (
+	batchSize     = 256
+	queueSize     = 4096
+	flushInterval = 2 * time.Second
+)
+
+// Init 按配置启用追踪；重复调用时替换之前的配置，之前排队的 span 被丢弃
+func Init(options Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+	exporter, active = nil, nil
+	if options.Endpoint == "" {
+		return nil
+	}
+	client, err := otlp.NewClient(options.Endpoint, options.TLS, options.Headers)
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	if options.ServiceName == "" {
+		options.ServiceName = "slowmade"
+	}
+	exporter = newSpanExporter(client, otlp.Resource(options.ServiceName, options.Version, options.Host))
+	ratio = options.SampleRatio
+	if options.Redact != nil {
+		redact = options.Redact
+	}
+	return nil
+}
+
+// Shutdown 发送队列中剩下的 span，最多等待 5 秒
+func Shutdown() {
+	mu.Lock()
+	e := exporter
+	mu.Unlock()
+	if e != nil {
+		e.flush()
+	}
+}
+
+// spanExporter 后台按批发送结束的 span；队列满或发送失败时丢弃，并每分钟最多在标准错误输出提示一次
+type spanExporter struct {
+	client   *otlp.Client
+	resource []byte
+	queue    chan *Span
+	flushes  chan chan struct{}
+	dropped  atomic.Int64
+	reported time.Time
+}
+
+func newSpanExporter(client *otlp.Client, resource []byte) *spanExporter {
+	e := &spanExporter{
+		client:   client,
+		resource: resource,
+		queue:    make(chan *Span, queueSize),
+		flushes:  make(chan chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// enqueue 不阻塞调用方
+func (e *spanExporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *spanExporter) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, batchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.client.Export(otlp.TracesExportPath, e.encode(batch)); err != nil {
+			e.dropped.Add(int64(len(batch)))
+			e.report(err)
+		}
+		batch = batch[:0]
+	}
+	add := func(span *Span) {
+		batch = append(batch, span)
+		if len(batch) >= batchSize {
+			send()
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			add(span)
+		case <-ticker.C:
+			send()
+		case done := <-e.flushes:
+			for drained := false; !drained; {
+				select {
+				case span := <-e.queue:
+					add(span)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(done)
+		}
+	}
+}
+
+// report 不写日志（远程日志可能发往同一个 collector），直接写标准错误
+func (e *spanExporter) report(err error) {
+	if time.Since(e.reported) < time.Minute {
+		return
+	}
+	e.reported = time.Now()
+	fmt.Fprintf(os.Stderr, "tracing: %v (%d spans dropped so far)\n", err, e.dropped.Load())
+}
+
+func (e *spanExporter) flush() {
+	done := make(chan struct{})
+	select {
+	case e.flushes <- done:
+	case <-time.After(5 * time.Second):
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+	}
+}
+
+// encode 编码 ExportTraceServiceRequest；span 已经结束，字段不再改变，读取时不需要加锁
+func (e *spanExporter) encode(spans []*Span) []byte {
+	var scope []byte
+	scope = otlp.AppendMessage(scope, 1, otlp.Scope("github.com/palagend/slowmade/internal/tracing"))
+	for _, span := range spans {
+		scope = otlp.AppendMessage(scope, 2, encodeSpan(span))
+	}
+	var resourceSpans []byte
+	resourceSpans = otlp.AppendMessage(resourceSpans, 1, e.resource)
+	resourceSpans = otlp.AppendMessage(resourceSpans, 2, scope)
+	return otlp.AppendMessage(nil, 1, resourceSpans)
+}
+
+// encodeSpan 编码 opentelemetry.proto.trace.v1.Span
+func encodeSpan(span *Span) []byte {
+	var b []byte
+	b = otlp.AppendMessage(b, 1, span.traceID[:])
+	b = otlp.AppendMessage(b, 2, span.spanID[:])
+	if span.parentID != [8]byte{} {
+		b = otlp.AppendMessage(b, 4, span.parentID[:])
+	}
+	b = otlp.AppendString(b, 5, span.name)
+	b = otlp.AppendVarintField(b, 6, uint64(span.kind))
+	b = otlp.AppendFixed64(b, 7, uint64(span.start.UnixNano()))
+	b = otlp.AppendFixed64(b, 8, uint64(span.end.UnixNano()))
+	for _, attr := range span.attrs {
+		b = otlp.AppendMessage(b, 9, otlp.KeyValue(attr[0], attr[1]))
+	}
+	// Status：code 1 为 OK，2 为 ERROR
+	var status []byte
+	if span.err != "" {
+		status = otlp.AppendString(status, 2, span.err)
+		status = otlp.AppendVarintField(status, 3, 2)
+	} else {
+		status = otlp.AppendVarintField(status, 3, 1)
+	}
+	return otlp.AppendMessage(b, 15, status)
+}
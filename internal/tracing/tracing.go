@@ -0,0 +1,260 @@
+// Package tracing 可选的链路追踪：每条 REPL 命令和 HTTP 请求是一个 trace 的根 span，钱包、账户、存储和
+// 节点 RPC 调用产生子 span，结束后经 OTLP/gRPC 发送到 collector。没有启用时所有函数都是空操作。
+//
+// 核心层的调用不传递 context，Begin 把 span 挂在当前正在执行的命令下；同时有多个命令在执行时
+// （serve 模式下重叠的请求）无法判断归属，这些 span 各自成为一个 trace
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind span 的类型，对应 OTLP 的 SpanKind
+type Kind int
+
+const (
+	Internal Kind = 1 // 进程内的调用
+	Server   Kind = 2 // 处理收到的请求
+	Client   Kind = 3 // 调用外部节点或服务
+)
+
+// Span 一次被计时的调用，nil 表示没有启用追踪，方法都可以在 nil 上调用
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	kind     Kind
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    [][2]string
+	err      string
+
+	root    *Span   // 所属命令的根 span
+	stack   []*Span // 只用于根 span：Begin 打开、尚未结束的 span
+	dropped bool    // 只用于根 span：未被采样，它和子 span 都不发送
+	ended   bool
+}
+
+var (
+	mu       sync.Mutex
+	exporter *spanExporter
+	ratio    float64
+	redact   = func(s string) string { return s }
+	active   []*Span // 正在执行的命令
+)
+
+// urlQuery URL 的查询参数，其中可能有 API 密钥，错误信息中去掉
+var urlQuery = regexp.MustCompile(`(\w+://[^\s?"']+)\?[^\s"']*`)
+
+// Enabled 是否启用了追踪
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return exporter != nil
+}
+
+type contextKey struct{}
+
+// FromContext 返回 ctx 中的 span
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// remoteParent 从 traceparent 头解析出的上游 span，只用作父 span
+type remoteParent struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+type remoteKey struct{}
+
+// Extract 解析 W3C traceparent 头（00-<trace-id>-<parent-id>-<flags>），让 Root 接上调用方的 trace；格式不对时忽略
+func Extract(ctx context.Context, traceparent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx
+	}
+	var parent remoteParent
+	_, traceErr := hex.Decode(parent.traceID[:], []byte(parts[1]))
+	_, spanErr := hex.Decode(parent.spanID[:], []byte(parts[2]))
+	flags, flagsErr := hex.DecodeString(parts[3])
+	if traceErr != nil || spanErr != nil || flagsErr != nil || parent.traceID == [16]byte{} || parent.spanID == [8]byte{} {
+		return ctx
+	}
+	parent.sampled = flags[0]&1 == 1
+	return context.WithValue(ctx, remoteKey{}, parent)
+}
+
+// Root 开始一条命令或一个请求的根 span，结束前 Begin 创建的 span 都挂在它下面。未被采样的命令
+// 同样登记为正在执行，以免别的命令认领它的 span。attrs 为交替的属性名和值
+func Root(ctx context.Context, kind Kind, name string, attrs ...string) (context.Context, *Span) {
+	mu.Lock()
+	defer mu.Unlock()
+	return root(ctx, kind, name, attrs)
+}
+
+func root(ctx context.Context, kind Kind, name string, attrs []string) (context.Context, *Span) {
+	if exporter == nil {
+		return ctx, nil
+	}
+	span := &Span{kind: kind, name: name, start: time.Now(), attrs: pairs(attrs)}
+	if parent, ok := ctx.Value(remoteKey{}).(remoteParent); ok {
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+		span.dropped = !parent.sampled
+	} else {
+		rand.Read(span.traceID[:])
+		span.dropped = mathrand.Float64() >= ratio
+	}
+	rand.Read(span.spanID[:])
+	span.root = span
+	active = append(active, span)
+	return context.WithValue(ctx, contextKey{}, span), span
+}
+
+// Command 开始一条 REPL 命令的 span：在另一条命令中执行（脚本、管道）时是它的子 span，否则与 Root 相同
+func Command(name string, attrs ...string) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	if parent := current(); parent != nil {
+		return begin(parent, name, attrs)
+	}
+	_, span := root(context.Background(), Internal, name, attrs)
+	return span
+}
+
+// Start 开始一个子 span：父 span 取自 ctx，ctx 中没有时与 Begin 相同。attrs 为交替的属性名和值
+func Start(ctx context.Context, kind Kind, name string, attrs ...string) (context.Context, *Span) {
+	mu.Lock()
+	defer mu.Unlock()
+	parent := FromContext(ctx)
+	if parent == nil {
+		parent = current()
+	}
+	span := child(parent, kind, name, attrs)
+	if span == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, contextKey{}, span), span
+}
+
+// Begin 在没有 context 的代码（核心层、存储）中开始一个子 span，父 span 是当前命令中最内层未结束的 span；
+// 没有正在执行的命令或有多个时不记录
+func Begin(name string, attrs ...string) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	return begin(current(), name, attrs)
+}
+
+func begin(parent *Span, name string, attrs []string) *Span {
+	span := child(parent, Internal, name, attrs)
+	if span != nil {
+		span.root.stack = append(span.root.stack, span)
+	}
+	return span
+}
+
+// current 唯一正在执行的命令中最内层的 span，调用方持有 mu
+func current() *Span {
+	if len(active) != 1 {
+		return nil
+	}
+	root := active[0]
+	if len(root.stack) > 0 {
+		return root.stack[len(root.stack)-1]
+	}
+	return root
+}
+
+func child(parent *Span, kind Kind, name string, attrs []string) *Span {
+	if exporter == nil || parent == nil || parent.root.ended || parent.root.dropped {
+		return nil
+	}
+	span := &Span{traceID: parent.traceID, parentID: parent.spanID, kind: kind, name: name, start: time.Now(), attrs: pairs(attrs), root: parent.root}
+	rand.Read(span.spanID[:])
+	return span
+}
+
+func pairs(attrs []string) [][2]string {
+	if len(attrs)%2 != 0 {
+		panic("tracing: attributes must be name/value pairs")
+	}
+	result := make([][2]string, 0, len(attrs)/2)
+	for i := 0; i < len(attrs); i += 2 {
+		result = append(result, [2]string{attrs[i], attrs[i+1]})
+	}
+	return result
+}
+
+// Set 添加一个属性
+func (s *Span) Set(key, value string) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	s.attrs = append(s.attrs, [2]string{key, value})
+}
+
+// Setf 添加一个按格式生成值的属性
+func (s *Span) Setf(key, format string, args ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.Set(key, fmt.Sprintf(format, args...))
+}
+
+// End 结束 span 并排队发送，err 不为 nil 时标记为失败
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if err != nil {
+		s.err = redact(urlQuery.ReplaceAllString(err.Error(), "$1"))
+	}
+	if s.root == s {
+		active = remove(active, s)
+	} else {
+		s.root.stack = remove(s.root.stack, s)
+	}
+	if exporter != nil && !s.root.dropped {
+		exporter.enqueue(s)
+	}
+}
+
+// TraceID 十六进制的 trace ID，写进日志后可以在 collector 中找到对应的 trace；没有记录时为空
+func (s *Span) TraceID() string {
+	if s == nil || s.root.dropped {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+func remove(spans []*Span, span *Span) []*Span {
+	for i := range spans {
+		if spans[i] == span {
+			return append(spans[:i], spans[i+1:]...)
+		}
+	}
+	return spans
+}
@@ -0,0 +1,83 @@
+// Package transcript 把 REPL 会话的命令和输出记录到文件，写入前经过 redact 遮盖私密内容，
+// 用于审计留档或附在问题报告里
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/redact"
+)
+
+// ErrClosed 记录已经停止
+var ErrClosed = errors.New("transcript is closed")
+
+// Recorder 会话记录器
+type Recorder struct {
+	mu       sync.Mutex
+	file     *os.File
+	path     string
+	started  time.Time
+	commands int
+}
+
+// Start 创建（或清空）记录文件并写入文件头，文件只有当前用户可读
+func Start(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{file: file, path: path, started: time.Now()}
+	if _, err := fmt.Fprintf(file, "# slowmade session transcript, started %s\n# mnemonics, private keys and passwords are replaced with %s\n\n",
+		r.started.UTC().Format(time.RFC3339), redact.Mask); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path 记录文件路径
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Record 追加一条命令及其输出，command 应该已经去掉了参数中的密码
+func (r *Recorder) Record(command, output string, cmdErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ErrClosed
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] > %s\n", time.Now().UTC().Format(time.RFC3339), command)
+	if output != "" {
+		b.WriteString(strings.TrimRight(output, "\n") + "\n")
+	}
+	if cmdErr != nil {
+		fmt.Fprintf(&b, "error: %s\n", cmdErr)
+	}
+	b.WriteString("\n")
+	if _, err := r.file.WriteString(redact.String(b.String())); err != nil {
+		return err
+	}
+	r.commands++
+	return r.file.Sync()
+}
+
+// Close 写入文件尾并关闭，返回记录的命令数
+func (r *Recorder) Close() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return r.commands, ErrClosed
+	}
+	fmt.Fprintf(r.file, "# stopped %s, %d commands recorded\n", time.Now().UTC().Format(time.RFC3339), r.commands)
+	err := r.file.Close()
+	r.file = nil
+	return r.commands, err
+}
@@ -0,0 +1,78 @@
+// Package tron 波场（TRX）地址、交易构造和签名，以及 TronGrid 接口。
+// 交易在本地按 protobuf 编码构造并签名，TronGrid 只提供最新区块和广播
+package tron
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/pkg/base58"
+)
+
+// AddressPrefix 主网地址的版本字节，Base58Check 编码后以 T 开头
+const AddressPrefix = 0x41
+
+// Decimals TRX 的精度，最小单位为 sun
+const Decimals = 6
+
+// ErrInvalidAddress 不是有效的波场地址
+var ErrInvalidAddress = errors.New("invalid tron address")
+
+// AddressFromPublicKey 由 secp256k1 公钥（33 字节压缩或 65 字节未压缩）生成地址：
+// 与以太坊相同取 Keccak256 的后 20 字节，加上 0x41 前缀后 Base58Check 编码
+func AddressFromPublicKey(publicKey []byte) (string, error) {
+	var uncompressed []byte
+	switch len(publicKey) {
+	case 33:
+		pub, err := crypto.DecompressPubkey(publicKey)
+		if err != nil {
+			return "", err
+		}
+		uncompressed = crypto.FromECDSAPub(pub)
+	case 65:
+		uncompressed = publicKey
+	default:
+		return "", errors.New("TRX requires a 33 or 65-byte secp256k1 public key")
+	}
+	hash := crypto.Keccak256(uncompressed[1:])
+	return EncodeAddress(append([]byte{AddressPrefix}, hash[12:]...)), nil
+}
+
+// EncodeAddress 把 21 字节的地址编码为 T... 形式
+func EncodeAddress(raw []byte) string {
+	return base58.CheckEncode(raw)
+}
+
+// ParseAddress 解析 T... 或 41 开头的十六进制地址，返回 21 字节的地址
+func ParseAddress(address string) ([]byte, error) {
+	var raw []byte
+	if len(address) == 42 && strings.HasPrefix(address, "41") {
+		decoded, err := hex.DecodeString(address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+		}
+		raw = decoded
+	} else {
+		decoded, err := base58.CheckDecode(address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+		}
+		raw = decoded
+	}
+	if len(raw) != 21 || raw[0] != AddressPrefix {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	return raw, nil
+}
+
+// NormalizeAddress 校验地址并返回 T... 形式
+func NormalizeAddress(address string) (string, error) {
+	raw, err := ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return EncodeAddress(raw), nil
+}
@@ -0,0 +1,203 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// DefaultGridURL TronGrid 主网公共接口
+const DefaultGridURL = "https://api.trongrid.io"
+
+// ErrBroadcast 节点拒绝了交易
+var ErrBroadcast = errors.New("transaction rejected")
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// Grid TronGrid（或任何 java-tron 全节点）的 HTTP 接口
+type Grid struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewGrid 创建 TronGrid 客户端，baseURL 为空时使用主网公共接口；apiKey 可选，以 TRON-PRO-API-KEY 发送
+func NewGrid(baseURL, apiKey string) *Grid {
+	if baseURL == "" {
+		baseURL = DefaultGridURL
+	}
+	return &Grid{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+func (g *Grid) Name() string     { return "trongrid:" + g.baseURL }
+func (g *Grid) ThirdParty() bool { return true }
+
+// NowBlock 最新区块，用作交易的参考区块
+func (g *Grid) NowBlock(ctx context.Context) (Block, error) {
+	var result struct {
+		BlockID     string `json:"blockID"`
+		BlockHeader struct {
+			RawData struct {
+				Number    int64 `json:"number"`
+				Timestamp int64 `json:"timestamp"`
+			} `json:"raw_data"`
+		} `json:"block_header"`
+	}
+	if err := g.post(ctx, "/wallet/getnowblock", struct{}{}, &result); err != nil {
+		return Block{}, err
+	}
+	id, err := hex.DecodeString(result.BlockID)
+	if err != nil || len(id) != 32 {
+		return Block{}, fmt.Errorf("trongrid: invalid block id %q", result.BlockID)
+	}
+	raw := result.BlockHeader.RawData
+	return Block{Number: raw.Number, ID: id, Timestamp: raw.Timestamp}, nil
+}
+
+// Balance TRX 余额（sun），未激活的账户为 0
+func (g *Grid) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var result struct {
+		Balance int64 `json:"balance"`
+	}
+	if err := g.post(ctx, "/wallet/getaccount", map[string]interface{}{"address": address, "visible": true}, &result); err != nil {
+		return nil, err
+	}
+	return big.NewInt(result.Balance), nil
+}
+
+// TokenBalance TRC-20 代币余额（代币最小单位）
+func (g *Grid) TokenBalance(ctx context.Context, contract, address string) (*big.Int, error) {
+	raw, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	parameter := hex.EncodeToString(append(make([]byte, 12), raw[1:]...))
+	result, err := g.call(ctx, address, contract, "balanceOf(address)", parameter)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// Token TRC-20 代币的符号和精度
+func (g *Grid) Token(ctx context.Context, contract string) (string, int, error) {
+	// 只读调用也需要调用方地址，用合约自身即可
+	decimals, err := g.call(ctx, contract, contract, "decimals()", "")
+	if err != nil {
+		return "", 0, err
+	}
+	symbol, err := g.call(ctx, contract, contract, "symbol()", "")
+	if err != nil {
+		return "", 0, err
+	}
+	n := new(big.Int).SetBytes(decimals)
+	if !n.IsInt64() || n.Int64() > 36 {
+		return "", 0, fmt.Errorf("trongrid: invalid decimals for %s", contract)
+	}
+	return decodeABIString(symbol), int(n.Int64()), nil
+}
+
+// call 调用合约的只读方法，返回 ABI 编码的结果
+func (g *Grid) call(ctx context.Context, owner, contract, selector, parameter string) ([]byte, error) {
+	var result struct {
+		ConstantResult []string `json:"constant_result"`
+		Result         struct {
+			Result  bool   `json:"result"`
+			Message string `json:"message"`
+		} `json:"result"`
+	}
+	request := map[string]interface{}{
+		"owner_address":     owner,
+		"contract_address":  contract,
+		"function_selector": selector,
+		"parameter":         parameter,
+		"visible":           true,
+	}
+	if err := g.post(ctx, "/wallet/triggerconstantcontract", request, &result); err != nil {
+		return nil, err
+	}
+	if !result.Result.Result || len(result.ConstantResult) == 0 {
+		return nil, fmt.Errorf("trongrid: %s on %s failed: %s", selector, contract, decodeMessage(result.Result.Message))
+	}
+	return hex.DecodeString(result.ConstantResult[0])
+}
+
+// Broadcast 广播已签名的交易，返回交易 ID
+func (g *Grid) Broadcast(ctx context.Context, tx *Transaction) (string, error) {
+	encoded, err := tx.Encode()
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Result  bool   `json:"result"`
+		TxID    string `json:"txid"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := g.post(ctx, "/wallet/broadcasthex", map[string]string{"transaction": hex.EncodeToString(encoded)}, &result); err != nil {
+		return "", err
+	}
+	if !result.Result {
+		return "", fmt.Errorf("%w: %s %s", ErrBroadcast, result.Code, decodeMessage(result.Message))
+	}
+	if result.TxID == "" {
+		return tx.ID(), nil
+	}
+	return result.TxID, nil
+}
+
+// post 发送 JSON 请求并解码响应
+func (g *Grid) post(ctx context.Context, path string, request, out interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("TRON-PRO-API-KEY", g.apiKey)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// decodeMessage 节点的错误消息通常是十六进制编码的文本
+func decodeMessage(message string) string {
+	if decoded, err := hex.DecodeString(message); err == nil {
+		return string(decoded)
+	}
+	return message
+}
+
+// decodeABIString 解码 ABI 编码的 string 返回值，格式不符时返回空
+func decodeABIString(data []byte) string {
+	if len(data) < 64 {
+		return ""
+	}
+	length := new(big.Int).SetBytes(data[32:64])
+	if !length.IsInt64() || 64+length.Int64() > int64(len(data)) {
+		return ""
+	}
+	return string(data[64 : 64+length.Int64()])
+}
@@ -0,0 +1,216 @@
+package tron
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 合约类型，见 java-tron 的 Transaction.Contract.ContractType
+const (
+	TransferContract     = 1
+	TriggerSmartContract = 31
+)
+
+// expiration 交易在参考区块之后的有效期，留出签名后确认广播的时间
+const expiration = 10 * time.Minute
+
+// transferSelector TRC-20 transfer(address,uint256) 的函数选择器
+var transferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+// 错误定义
+var (
+	ErrInvalidAmount = errors.New("amount must be positive")
+	ErrUnsigned      = errors.New("transaction is not signed")
+)
+
+// Block 交易引用的区块，交易只在该区块之后一段时间内有效
+type Block struct {
+	Number    int64
+	ID        []byte // 32 字节区块 ID，前 8 字节为区块高度
+	Timestamp int64  // 毫秒
+}
+
+// Transaction 单个合约调用的交易
+type Transaction struct {
+	refBlockBytes []byte
+	refBlockHash  []byte
+	expiration    int64
+	timestamp     int64
+	feeLimit      int64
+	contractType  int
+	typeURL       string
+	parameter     []byte
+	signature     []byte
+
+	// 以下用于显示
+	Owner    string
+	To       string
+	Contract string   // TRC-20 合约地址，TRX 转账为空
+	Amount   *big.Int // sun 或代币最小单位
+	FeeLimit int64    // sun，TRX 转账为 0
+}
+
+// NewTransfer 构造 TRX 转账，amount 单位为 sun
+func NewTransfer(owner, to string, amount int64, block Block) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	ownerRaw, toRaw, err := parsePair(owner, to)
+	if err != nil {
+		return nil, err
+	}
+	var param protoBuffer
+	param.bytes(1, ownerRaw)
+	param.bytes(2, toRaw)
+	param.varint(3, uint64(amount))
+	tx := newTransaction(block, TransferContract, "TransferContract", param)
+	tx.Owner, tx.To, tx.Amount = owner, to, big.NewInt(amount)
+	return tx, nil
+}
+
+// NewTRC20Transfer 构造 TRC-20 代币转账，amount 为代币最小单位，feeLimit 为愿意燃烧的 TRX 上限（sun）
+func NewTRC20Transfer(owner, contract, to string, amount *big.Int, feeLimit int64, block Block) (*Transaction, error) {
+	if amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	ownerRaw, toRaw, err := parsePair(owner, to)
+	if err != nil {
+		return nil, err
+	}
+	contractRaw, err := ParseAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+	if amount.BitLen() > 256 {
+		return nil, fmt.Errorf("amount %s does not fit in uint256", amount)
+	}
+	// ABI 编码：地址去掉 0x41 前缀后左补零到 32 字节，金额为 32 字节大端整数
+	data := append([]byte(nil), transferSelector...)
+	data = append(data, make([]byte, 12)...)
+	data = append(data, toRaw[1:]...)
+	data = append(data, amount.FillBytes(make([]byte, 32))...)
+
+	var param protoBuffer
+	param.bytes(1, ownerRaw)
+	param.bytes(2, contractRaw)
+	param.bytes(4, data)
+	tx := newTransaction(block, TriggerSmartContract, "TriggerSmartContract", param)
+	tx.feeLimit = feeLimit
+	tx.Owner, tx.To, tx.Contract, tx.Amount, tx.FeeLimit = owner, to, contract, amount, feeLimit
+	return tx, nil
+}
+
+func parsePair(owner, to string) ([]byte, []byte, error) {
+	ownerRaw, err := ParseAddress(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	toRaw, err := ParseAddress(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ownerRaw, toRaw, nil
+}
+
+func newTransaction(block Block, contractType int, name string, param protoBuffer) *Transaction {
+	// 参考区块：高度的第 7、8 字节和区块 ID 的第 9 到 16 字节
+	height := make([]byte, 8)
+	binary.BigEndian.PutUint64(height, uint64(block.Number))
+	return &Transaction{
+		refBlockBytes: height[6:8],
+		refBlockHash:  append([]byte(nil), block.ID[8:16]...),
+		expiration:    block.Timestamp + expiration.Milliseconds(),
+		timestamp:     time.Now().UnixMilli(),
+		contractType:  contractType,
+		typeURL:       "type.googleapis.com/protocol." + name,
+		parameter:     param,
+	}
+}
+
+// rawData Transaction.raw 的 protobuf 编码，交易 ID 和签名都基于它
+func (tx *Transaction) rawData() []byte {
+	var parameter protoBuffer
+	parameter.bytes(1, []byte(tx.typeURL))
+	parameter.bytes(2, tx.parameter)
+	var contract protoBuffer
+	contract.varint(1, uint64(tx.contractType))
+	contract.bytes(2, parameter)
+
+	var raw protoBuffer
+	raw.bytes(1, tx.refBlockBytes)
+	raw.bytes(4, tx.refBlockHash)
+	raw.varint(8, uint64(tx.expiration))
+	raw.bytes(11, contract)
+	raw.varint(14, uint64(tx.timestamp))
+	if tx.feeLimit > 0 {
+		raw.varint(18, uint64(tx.feeLimit))
+	}
+	return raw
+}
+
+// ID 交易 ID，即 raw_data 的 SHA-256
+func (tx *Transaction) ID() string {
+	hash := sha256.Sum256(tx.rawData())
+	return hex.EncodeToString(hash[:])
+}
+
+// Expires 交易过期时间
+func (tx *Transaction) Expires() time.Time {
+	return time.UnixMilli(tx.expiration)
+}
+
+// Sign 用 secp256k1 私钥签名交易 ID，签名后清除 privateKey
+func (tx *Transaction) Sign(privateKey []byte) error {
+	defer func() {
+		for i := range privateKey {
+			privateKey[i] = 0
+		}
+	}()
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(tx.rawData())
+	signature, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return err
+	}
+	tx.signature = signature
+	return nil
+}
+
+// Encode 已签名交易的 protobuf 编码，用于 broadcasthex
+func (tx *Transaction) Encode() ([]byte, error) {
+	if tx.signature == nil {
+		return nil, ErrUnsigned
+	}
+	var encoded protoBuffer
+	encoded.bytes(1, tx.rawData())
+	encoded.bytes(2, tx.signature)
+	return encoded, nil
+}
+
+// protoBuffer 只包含本包用到的 varint 和 length-delimited 两种 protobuf 字段
+type protoBuffer []byte
+
+func (b *protoBuffer) key(field int, wireType uint64) {
+	*b = binary.AppendUvarint(*b, uint64(field)<<3|wireType)
+}
+
+func (b *protoBuffer) varint(field int, value uint64) {
+	b.key(field, 0)
+	*b = binary.AppendUvarint(*b, value)
+}
+
+func (b *protoBuffer) bytes(field int, value []byte) {
+	b.key(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(value)))
+	*b = append(*b, value...)
+}
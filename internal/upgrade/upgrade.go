@@ -0,0 +1,281 @@
+// Package upgrade 换用新版本后检查数据目录与程序的兼容性：存储格式版本、密文信封版本、配置键和显示模板，
+// 列出需要的迁移。检查结果记录在数据目录中，版本不变时启动不再检查；会改写用户文件的迁移只在明确同意时执行
+package upgrade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/doctor"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+	"github.com/spf13/viper"
+)
+
+// StateFileName 上次检查通过的版本在数据目录中的文件名
+const StateFileName = "upgrade_state.json"
+
+// 错误定义
+var (
+	ErrIncompatible = errors.New("data directory is not compatible with this version of slowmade")
+	ErrNeedsConsent = errors.New("some migrations rewrite your files and need consent")
+)
+
+// State 上次检查通过时的程序版本和存储格式版本
+type State struct {
+	Version   string    `json:"version"`
+	Schema    int       `json:"schema"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LoadState 读取检查记录，从未检查过时返回 nil
+func LoadState(baseDir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, StateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解码 %s 失败: %w", StateFileName, err)
+	}
+	return &state, nil
+}
+
+// Current 当前程序的版本是否已经检查过
+func (s *State) Current() bool {
+	return s != nil && s.Version == version.Get().GitVersion && s.Schema == core.StorageSchemaVersion
+}
+
+func saveState(baseDir string, state State) error {
+	data, err := canonjson.MarshalIndent(state, "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, StateFileName)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", StateFileName, err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名 %s 失败: %w", StateFileName, err)
+	}
+	return nil
+}
+
+// Migration 一项需要的迁移。apply 为空的迁移不由 upgrade-check 执行，Deferred 说明何时完成
+type Migration struct {
+	Name        string
+	Detail      string
+	Destructive bool   // 会改写用户的文件，只在明确同意时执行
+	Deferred    string // 如 on next unlock 或 manual
+	apply       func() (string, error)
+}
+
+// Automatic upgrade-check --apply 是否可以执行这项迁移
+func (m Migration) Automatic() bool {
+	return m.apply != nil
+}
+
+// Report 一次检查的结果
+type Report struct {
+	From       string // 上次检查通过的版本，从未检查过时为空
+	To         string
+	HasWallet  bool
+	Results    []doctor.Result
+	Migrations []Migration
+	baseDir    string
+	readOnly   bool
+}
+
+// Options 检查的输入
+type Options struct {
+	BaseDir   string
+	ConfigErr error // 加载配置时的错误
+}
+
+// Check 检查数据目录和配置，不修改任何文件，不需要解锁钱包
+func Check(opts Options) (*Report, error) {
+	state, err := LoadState(opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	appConfig := config.GetAppConfig()
+	report := &Report{To: version.Get().GitVersion, baseDir: opts.BaseDir, readOnly: appConfig.GetStorageConfig().ReadOnly}
+	if state != nil {
+		report.From = state.Version
+	}
+	report.Results = []doctor.Result{
+		report.checkStorage(state),
+		report.checkConfig(opts.ConfigErr),
+		doctor.CheckTemplates(),
+	}
+	return report, nil
+}
+
+// checkStorage 比较存储格式版本，并统计各版本的密文信封
+func (r *Report) checkStorage(state *State) doctor.Result {
+	result := doctor.Result{Name: "storage"}
+	if state != nil && state.Schema > core.StorageSchemaVersion {
+		result.Status = doctor.Fail
+		result.Detail = fmt.Sprintf("written by slowmade %s with storage schema %d, this version reads schema %d; "+
+			"install %s or newer, or restore a backup made with this version", state.Version, state.Schema, core.StorageSchemaVersion, state.Version)
+		return result
+	}
+	appConfig := config.GetAppConfig()
+	storageConfig := appConfig.GetStorageConfig()
+	storageConfig.ReadOnly = true
+	stor, err := core.NewFileStorage(storageConfig)
+	if err != nil {
+		result.Status, result.Detail = doctor.Fail, err.Error()
+		return result
+	}
+	wallet, err := stor.LoadRootWallet()
+	if err != nil {
+		result.Status, result.Detail = doctor.Fail, fmt.Sprintf("wallet file: %v", err)
+		return result
+	}
+	r.HasWallet = wallet != nil
+	counts, err := core.EnvelopeVersions(stor)
+	if err != nil {
+		result.Status, result.Detail = doctor.Fail, err.Error()
+		return result
+	}
+
+	result.Detail = fmt.Sprintf("schema %d", core.StorageSchemaVersion)
+	if state == nil && r.HasWallet {
+		result.Detail += " (not recorded before, assumed)"
+	}
+	versions := make([]int, 0, len(counts))
+	total := 0
+	for v, n := range counts {
+		versions = append(versions, v)
+		total += n
+	}
+	sort.Ints(versions)
+	var described []string
+	for _, v := range versions {
+		switch {
+		case v > crypto.EnvelopeVersion:
+			result.Status = doctor.Fail
+			described = append(described, fmt.Sprintf("%d in envelope v%d, newer than this version reads (v%d)", counts[v], v, crypto.EnvelopeVersion))
+		case v == 0:
+			result.Status = max(result.Status, doctor.Warn)
+			described = append(described, fmt.Sprintf("%d in the legacy format", counts[v]))
+			r.Migrations = append(r.Migrations, Migration{Name: "envelopes", Deferred: "on next unlock",
+				Detail: fmt.Sprintf("re-encrypt %d legacy ciphertexts as envelope v%d; runs on the next unlock, "+
+					"after a backup, and every record is verified before the backup is removed", counts[v], crypto.EnvelopeVersion)})
+		default:
+			described = append(described, fmt.Sprintf("%d in envelope v%d", counts[v], v))
+		}
+	}
+	if total > 0 {
+		result.Detail += fmt.Sprintf(", %d ciphertexts: %s", total, strings.Join(described, ", "))
+	}
+	return result
+}
+
+// checkConfig 找出配置文件中废弃和无法识别的键
+func (r *Report) checkConfig(loadErr error) doctor.Result {
+	result := doctor.Result{Name: "config"}
+	if loadErr != nil {
+		result.Status, result.Detail = doctor.Fail, loadErr.Error()
+		return result
+	}
+	file := viper.ConfigFileUsed()
+	keys, err := config.FileKeys()
+	if err != nil {
+		result.Status, result.Detail = doctor.Fail, err.Error()
+		return result
+	}
+	if file == "" {
+		result.Detail = "no config file, using defaults"
+		return result
+	}
+
+	var problems []string
+	for _, key := range keys {
+		replacement, ok := config.DeprecatedKeys[key]
+		if !ok {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s is deprecated, use %s", key, replacement))
+		migration := Migration{Name: "config " + key, Deferred: "manual", Detail: fmt.Sprintf("rename %s to %s in %s by hand", key, replacement, file)}
+		if key == "rpc.endpoint" && strings.EqualFold(filepath.Ext(file), ".toml") {
+			migration.Destructive = true
+			migration.Detail = fmt.Sprintf("move %s to %s in %s (the original is kept as %s.bak)", key, replacement, file, filepath.Base(file))
+			migration.apply = func() (string, error) {
+				backup, err := config.MigrateLegacyEndpoint(file)
+				if err != nil {
+					return "", err
+				}
+				return "original saved as " + backup, nil
+			}
+		}
+		r.Migrations = append(r.Migrations, migration)
+	}
+	if unknown := config.UnknownKeys(keys); len(unknown) > 0 {
+		problems = append(problems, "unknown keys, ignored: "+strings.Join(unknown, ", "))
+	}
+	result.Detail = file
+	if len(problems) > 0 {
+		result.Status = doctor.Warn
+		result.Detail += ": " + strings.Join(problems, "; ")
+	}
+	return result
+}
+
+// Blocked 是否有检查失败，此时不能用这个版本打开数据目录
+func (r *Report) Blocked() bool {
+	return doctor.ExitCode(r.Results) == int(doctor.Fail)
+}
+
+// Clean 没有失败、警告和需要的迁移
+func (r *Report) Clean() bool {
+	return doctor.ExitCode(r.Results) == int(doctor.OK) && len(r.Migrations) == 0
+}
+
+// Apply 执行 upgrade-check 可以执行的迁移，会改写文件的迁移只在 allowDestructive 时执行；
+// 全部执行完后记录当前版本，之后启动不再检查。返回每项已执行迁移的说明
+func (r *Report) Apply(allowDestructive bool) ([]string, error) {
+	if r.Blocked() {
+		return nil, ErrIncompatible
+	}
+	if r.readOnly {
+		return nil, core.ErrReadOnly
+	}
+	var applied []string
+	pending := false
+	for _, migration := range r.Migrations {
+		if !migration.Automatic() {
+			continue
+		}
+		if migration.Destructive && !allowDestructive {
+			pending = true
+			continue
+		}
+		detail, err := migration.apply()
+		if err != nil {
+			return applied, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		applied = append(applied, fmt.Sprintf("%s: %s", migration.Name, detail))
+	}
+	if pending {
+		return applied, ErrNeedsConsent
+	}
+	return applied, saveState(r.baseDir, State{Version: r.To, Schema: core.StorageSchemaVersion, CheckedAt: time.Now().UTC()})
+}
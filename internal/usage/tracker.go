@@ -0,0 +1,100 @@
+// Package usage 记录地址的链上使用情况，用于在派生和展示地址时提示地址复用
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// FileName 地址使用记录在数据目录中的文件名
+const FileName = "address_usage.json"
+
+// Record 地址首次被发现已使用的时间和来源
+type Record struct {
+	FirstSeen time.Time `json:"first_seen"`
+	Source    string    `json:"source"` // 发现来源的命令，如 btc.utxos、account.balance
+}
+
+// Tracker 地址使用记录，只增不减：地址一旦在链上出现过，即使余额归零也视为已使用
+type Tracker struct {
+	mu        sync.Mutex
+	path      string
+	Addresses map[string]Record `json:"addresses"`
+}
+
+// Load 加载使用记录，文件不存在时返回空记录
+func Load(path string) (*Tracker, error) {
+	t := &Tracker{path: path, Addresses: make(map[string]Record)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("解码地址使用记录失败: %w", err)
+	}
+	if t.Addresses == nil {
+		t.Addresses = make(map[string]Record)
+	}
+	return t, nil
+}
+
+// MarkUsed 记录已使用的地址，已有记录的地址保持首次发现的时间和来源
+func (t *Tracker) MarkUsed(source string, addresses ...string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := false
+	now := time.Now().UTC()
+	for _, address := range addresses {
+		key := normalize(address)
+		if _, ok := t.Addresses[key]; ok || key == "" {
+			continue
+		}
+		t.Addresses[key] = Record{FirstSeen: now, Source: source}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return t.save()
+}
+
+// Used 查询地址是否已使用
+func (t *Tracker) Used(address string) (Record, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record, ok := t.Addresses[normalize(address)]
+	return record, ok
+}
+
+// normalize 十六进制地址不区分大小写（EIP-55 校验和只影响大小写）
+func normalize(address string) string {
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+func (t *Tracker) save() error {
+	data, err := canonjson.MarshalIndent(t, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := t.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入地址使用记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, t.path); err != nil {
+		return fmt.Errorf("重命名地址使用记录失败: %w", err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 )
@@ -37,6 +38,24 @@ func (i Info) String() string {
 	)
 }
 
+// Short 返回一行的版本说明，如 v1.2.0 (abc1234, built 2024-01-01T00:00:00Z)
+func (i Info) Short() string {
+	commit := i.GitCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if i.GitTreeState == "dirty" {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("%s (%s, built %s, %s)", i.GitVersion, commit, i.BuildDate, i.GoVersion)
+}
+
+// JSON 返回缩进的 JSON，供 version --json 使用
+func (i Info) JSON() string {
+	data, _ := json.MarshalIndent(i, "", "  ")
+	return string(data)
+}
+
 // Get 返回填充好的 Info 结构体
 func Get() Info {
 	return Info{
@@ -0,0 +1,327 @@
+package view
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/report"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/spf13/viper"
+)
+
+// Accessible 是否使用无障碍输出：由 ui.accessible 或 --accessible 打开，TERM=dumb 时自动打开
+func Accessible() bool {
+	return viper.GetBool("ui.accessible") || os.Getenv("TERM") == "dumb"
+}
+
+// NewTemplate 按当前设置创建显示模板，无障碍模式下使用 AccessibleTemplate 并关闭所有颜色
+func NewTemplate() DisplayTemplate {
+	if Accessible() {
+		color.NoColor = true
+		return NewAccessibleTemplate()
+	}
+	return NewDefaultTemplate()
+}
+
+// render 按样式渲染，无障碍模式下原样返回，不输出颜色和加粗等转义序列
+func render(style lipgloss.Style, text string) string {
+	if Accessible() {
+		return text
+	}
+	return style.Render(text)
+}
+
+// AccessibleTemplate 供读屏软件和简易终端使用的模板：没有框线、图标、颜色和多列对齐，
+// 每条信息单独一行并以文字标明类型和字段名
+type AccessibleTemplate struct{}
+
+// NewAccessibleTemplate 创建无障碍模板
+func NewAccessibleTemplate() *AccessibleTemplate {
+	return &AccessibleTemplate{}
+}
+
+// heading 标题行，前后没有装饰字符
+func (t *AccessibleTemplate) heading(title string) string {
+	return title + ":"
+}
+
+// field 一行 "名称: 值"，值为空时写 none
+func field(b *strings.Builder, name, value string) {
+	if value == "" {
+		value = "none"
+	}
+	fmt.Fprintf(b, "%s: %s\n", name, value)
+}
+
+func (t *AccessibleTemplate) Welcome() string {
+	return "Slowmade wallet, accessible mode. Type help for available commands, or exit to quit."
+}
+
+func (t *AccessibleTemplate) Prompt(isLocked bool, context string) string {
+	state := "unlocked"
+	if isLocked {
+		state = "locked"
+	}
+	if viper.GetBool("storage.read_only") {
+		state += ", read-only"
+	}
+	if context != "" {
+		state += ", " + context
+	}
+	return fmt.Sprintf("slowmade %s> ", state)
+}
+
+func (t *AccessibleTemplate) WalletCreated(status string) string {
+	return fmt.Sprintf(`Success: wallet created. Status: %s.
+Important: save your mnemonic phrase in a secure location.
+Important: never share your private keys or mnemonic phrase.
+Important: back up your wallet regularly.`, status)
+}
+
+func (t *AccessibleTemplate) AccountList(accounts []*core.CoinAccount) string {
+	if len(accounts) == 0 {
+		return "No accounts found."
+	}
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	shortIDs := core.ShortIDs(ids)
+	format := CurrentFormatter()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s accounts found.\n", format.Number(int64(len(accounts))))
+	for i, account := range accounts {
+		fmt.Fprintf(&b, "\nAccount %d of %d.\n", i+1, len(accounts))
+		field(&b, "Short ID", shortIDs[account.ID])
+		field(&b, "Coin", account.CoinSymbol)
+		field(&b, "Path", account.DerivationPath)
+		field(&b, "Created", format.Date(account.Created()))
+		field(&b, "Modified", format.Date(account.Modified()))
+		field(&b, "Full ID", account.ID)
+		if account.Standalone {
+			field(&b, "Type", "standalone, imported and not derived from this wallet's seed")
+		}
+		if convention := account.Convention(); convention != core.ConventionStandard {
+			field(&b, "Paths", fmt.Sprintf("%s, %s", convention, convention.Pattern(account.CoinType())))
+		}
+	}
+	b.WriteString("\nCommands accept the short ID, an alias or a label.")
+	return b.String()
+}
+
+func (t *AccessibleTemplate) AddressList(addrs []*core.AddressKey) string {
+	if len(addrs) == 0 {
+		return "No addresses found."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s addresses found.\n", CurrentFormatter().Number(int64(len(addrs))))
+	for i, addr := range addrs {
+		chain := "receive"
+		if addr.ChangeType == 1 {
+			chain = "change"
+		}
+		fmt.Fprintf(&b, "\nAddress %d of %d.\n", i+1, len(addrs))
+		field(&b, "Address", addr.Address)
+		field(&b, "Coin", addr.CoinSymbol)
+		field(&b, "Index", fmt.Sprintf("%d, %s chain", addr.AddressIndex, chain))
+		field(&b, "Account", addr.AccountID)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (t *AccessibleTemplate) WalletRestored(status string) string {
+	return fmt.Sprintf("Success: wallet restored from mnemonic. Status: %s.", status)
+}
+
+func (t *AccessibleTemplate) WalletUnlocked() string {
+	return "Success: wallet unlocked. You can now perform account operations."
+}
+
+func (t *AccessibleTemplate) WalletLocked() string {
+	return "Success: wallet locked. All sensitive data has been cleared from memory."
+}
+
+func (t *AccessibleTemplate) WalletStatus(info *WalletStatusInfo) string {
+	var b strings.Builder
+	format := CurrentFormatter()
+	field(&b, "Wallet status", info.Status)
+	hidden := "hidden, unlock to show"
+	fingerprint := info.Fingerprint
+	if fingerprint == "" && info.Status == "locked" {
+		fingerprint = hidden
+	}
+	field(&b, "Fingerprint", fingerprint)
+	field(&b, "Created", format.Date(info.Created))
+	field(&b, "Modified", format.Date(info.Modified))
+
+	if info.Accounts == nil {
+		field(&b, "Accounts", hidden)
+		field(&b, "Addresses", hidden)
+	} else {
+		coins := make([]string, 0, len(info.Accounts))
+		total := 0
+		for symbol, n := range info.Accounts {
+			coins = append(coins, symbol)
+			total += n
+		}
+		sort.Strings(coins)
+		accounts := format.Number(int64(total)) + " in total"
+		for _, symbol := range coins {
+			accounts += fmt.Sprintf(", %s %s", symbol, format.Number(int64(info.Accounts[symbol])))
+		}
+		field(&b, "Accounts", accounts)
+		field(&b, "Addresses", format.Number(int64(info.Addresses)))
+	}
+	field(&b, "Storage", info.Storage)
+	field(&b, "Encryption", info.Encryption)
+	field(&b, "KDF", info.KDF)
+	field(&b, "Auto-lock", info.AutoLock)
+
+	switch {
+	case info.RPC == nil:
+		field(&b, "RPC", "not checked")
+	case len(info.RPC) == 0:
+		field(&b, "RPC", "no nodes configured, block explorers are used")
+	default:
+		for _, node := range info.RPC {
+			state := fmt.Sprintf("reachable in %.1f milliseconds", float64(node.Latency.Microseconds())/1000)
+			if node.Err != nil {
+				state = "unreachable, " + node.Err.Error()
+			}
+			field(&b, "RPC "+node.Coin, fmt.Sprintf("%s, %s", node.Endpoint, state))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// SpendingReport 收支报表，每个月份和交易对手各占一段，不使用表格列
+func (t *AccessibleTemplate) SpendingReport(spending *report.Spending) string {
+	var b strings.Builder
+	format := CurrentFormatter()
+	amount := func(value *big.Int) string {
+		return format.Amount(value, spending.Decimals, "")
+	}
+	account := spending.AccountID
+	if spending.AccountLabel != "" {
+		account = fmt.Sprintf("%s, %s", spending.AccountLabel, spending.AccountID)
+	}
+	fmt.Fprintf(&b, "Spending report %s, %s.\n", spending.Period.Name, spending.Coin)
+	field(&b, "Account", account)
+	field(&b, "Period", fmt.Sprintf("%s to %s", format.Day(spending.Period.Start), format.Day(spending.Period.End.AddDate(0, 0, -1))))
+	field(&b, "Inflows", amount(spending.In))
+	field(&b, "Outflows", amount(spending.Out))
+	field(&b, "Fees paid", amount(spending.Fees))
+	field(&b, "Net", amount(spending.Net()))
+
+	for _, month := range spending.Months {
+		fmt.Fprintf(&b, "\nMonth %s: in %s, out %s, fees %s, %d transactions.\n", month.Month.Format("2006-01"),
+			amount(month.In), amount(month.Out), amount(month.Fees), month.Count)
+	}
+	if len(spending.Counterparties) > 0 {
+		b.WriteString("\n" + t.heading("Counterparties") + "\n")
+		for _, party := range spending.Counterparties {
+			fmt.Fprintf(&b, "%s: in %s, out %s, %d transactions.\n", party.Name, amount(party.In), amount(party.Out), party.Count)
+		}
+	}
+	if len(spending.Entries) == 0 {
+		b.WriteString("\nNo transactions recorded in this period.\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Help 每条命令用法单独一行，用冒号连接说明，不按列对齐
+func (t *AccessibleTemplate) Help(groups []HelpGroup) string {
+	var b strings.Builder
+	b.WriteString("Available commands, by group. Type help followed by a command name for details.\n")
+	for _, group := range groups {
+		b.WriteString("\n" + t.heading(group.Title) + "\n")
+		for _, command := range group.Commands {
+			for i, line := range command.UsageLines() {
+				fmt.Fprintf(&b, "%s: %s\n", line, command.Usages[i].Summary)
+			}
+			if len(command.Aliases) > 0 {
+				fmt.Fprintf(&b, "%s also has the aliases %s.\n", command.Name, strings.Join(command.Aliases, ", "))
+			}
+		}
+	}
+	b.WriteString("\n" + t.heading("Pipes and redirection") + "\n")
+	b.WriteString("Follow a command with a vertical bar and grep, head, tail, sort, uniq or wc to filter its output.\n")
+	b.WriteString("Follow a command with a greater-than sign and a file name to write its output to the file, or two greater-than signs to append.\n")
+	b.WriteString("Join commands with two ampersands to run them in order, stopping at the first failure.\n")
+	b.WriteString("Quote arguments to keep spaces and special characters inside one argument.\n")
+	b.WriteString("\n" + t.heading("Shortcuts") + "\n")
+	b.WriteString("Control D or Control C exits. Tab completes command names.")
+	return b.String()
+}
+
+func (t *AccessibleTemplate) CommandHelp(command CommandHelp) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Help for %s.\n", command.Name)
+	for i, line := range command.UsageLines() {
+		fmt.Fprintf(&b, "Usage: %s\n", line)
+		if summary := command.Usages[i].Summary; summary != "" {
+			fmt.Fprintf(&b, "Description: %s\n", summary)
+		}
+	}
+	for _, arg := range command.Args {
+		fmt.Fprintf(&b, "Argument %s: %s\n", arg.Name, arg.Description)
+	}
+	for _, example := range command.Examples {
+		fmt.Fprintf(&b, "Example: %s\n", example)
+	}
+	if len(command.Aliases) > 0 {
+		fmt.Fprintf(&b, "Aliases: %s\n", strings.Join(command.Aliases, ", "))
+	}
+	if command.ReadOnly {
+		b.WriteString("Available in read-only mode.\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (t *AccessibleTemplate) Goodbye() string {
+	return "Goodbye, thank you for using Slowmade."
+}
+
+func (t *AccessibleTemplate) Error(message string) string {
+	return "Error: " + message
+}
+
+func (t *AccessibleTemplate) Info(message string) string {
+	return "Note: " + message
+}
+
+func (t *AccessibleTemplate) Success(message string) string {
+	return "Success: " + message
+}
+
+func (t *AccessibleTemplate) Warning(message string) string {
+	return "Warning: " + message
+}
+
+func (t *AccessibleTemplate) HistoryHeader() string {
+	return "Command history:"
+}
+
+func (t *AccessibleTemplate) HistoryItem(index int, command string) string {
+	return fmt.Sprintf("%d: %s", index+1, command)
+}
+
+func (t *AccessibleTemplate) Version() string {
+	return "Slowmade REPL " + version.Get().Short() + ", BIP44 HD wallet management"
+}
+
+func (t *AccessibleTemplate) Separator() string {
+	return ""
+}
+
+// TutorialStep 教程步骤：一行编号和标题，随后是说明原文
+func (t *AccessibleTemplate) TutorialStep(step, total int, title, text string) string {
+	return fmt.Sprintf("\nStep %d of %d: %s.\n%s", step, total, title, text)
+}
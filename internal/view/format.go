@@ -0,0 +1,174 @@
+package view
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/coin"
+	"github.com/palagend/slowmade/pkg/logging"
+	"github.com/spf13/viper"
+)
+
+// locale 一种语言的数字和日期格式
+type locale struct {
+	group   string // 千位分隔符
+	decimal string // 小数点
+	date    string // time.Format 日期时间格式
+	day     string // 只有日期
+}
+
+var locales = map[string]locale{
+	"en": {group: ",", decimal: ".", date: "2006-01-02 15:04:05", day: "2006-01-02"},
+	"zh": {group: ",", decimal: ".", date: "2006年01月02日 15:04:05", day: "2006年01月02日"},
+	"ja": {group: ",", decimal: ".", date: "2006/01/02 15:04:05", day: "2006/01/02"},
+	"de": {group: ".", decimal: ",", date: "02.01.2006 15:04:05", day: "02.01.2006"},
+	"es": {group: ".", decimal: ",", date: "02/01/2006 15:04:05", day: "02/01/2006"},
+	"fr": {group: " ", decimal: ",", date: "02/01/2006 15:04:05", day: "02/01/2006"},
+	"ru": {group: " ", decimal: ",", date: "02.01.2006 15:04:05", day: "02.01.2006"},
+}
+
+// Formatter 按 ui.lang 格式化日期、数字、币种金额和文件大小，日期按 ui.timezone 的时区显示
+type Formatter struct {
+	locale locale
+	loc    *time.Location
+}
+
+// badZones 已经警告过的无效时区，避免每次格式化都打印
+var badZones sync.Map
+
+// Location 返回 ui.timezone 配置的时区：IANA 名称（如 Asia/Shanghai）、UTC，
+// 为空或 Local 时使用系统时区，无效时警告一次并使用系统时区
+func Location() *time.Location {
+	name := strings.TrimSpace(viper.GetString("ui.timezone"))
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if _, warned := badZones.LoadOrStore(name, true); !warned {
+			logging.Warnf("无效的时区 %q，使用系统时区: %v", name, err)
+		}
+		return time.Local
+	}
+	return loc
+}
+
+// Timestamp JSON 等机器可读输出使用的 ISO-8601（RFC 3339）时间，带 ui.timezone 的偏移，零值为空字符串
+func Timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location()).Format(time.RFC3339)
+}
+
+// NewFormatter 创建格式化器，lang 可以带地区后缀（如 zh_CN、de-AT），未知语言使用英文格式
+func NewFormatter(lang string) *Formatter {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	l, ok := locales[lang]
+	if !ok {
+		l = locales["en"]
+	}
+	return &Formatter{locale: l, loc: time.Local}
+}
+
+// In 返回在指定时区显示日期的格式化器
+func (f *Formatter) In(loc *time.Location) *Formatter {
+	copied := *f
+	copied.loc = loc
+	return &copied
+}
+
+// CurrentFormatter 返回当前配置语言和时区的格式化器
+func CurrentFormatter() *Formatter {
+	return NewFormatter(viper.GetString("ui.lang")).In(Location())
+}
+
+// Date 格式化日期和时间，零值显示为 -
+func (f *Formatter) Date(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(f.loc).Format(f.locale.date)
+}
+
+// Day 只格式化日期，零值显示为 -
+func (f *Formatter) Day(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(f.loc).Format(f.locale.day)
+}
+
+// Number 带千位分隔符的整数
+func (f *Formatter) Number(n int64) string {
+	return f.Decimal(strconv.FormatInt(n, 10))
+}
+
+// Decimal 本地化十进制数字字符串（如 coin.FormatUnits 的结果），整数部分加千位分隔符
+func (f *Formatter) Decimal(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(f.locale.group)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString(f.locale.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// Amount 按币种精度格式化最小单位金额，symbol 非空时附在后面
+func (f *Formatter) Amount(value *big.Int, decimals int, symbol string) string {
+	if value == nil {
+		value = new(big.Int)
+	}
+	amount := f.Decimal(coin.FormatUnits(value, decimals))
+	if symbol == "" {
+		return amount
+	}
+	return amount + " " + symbol
+}
+
+// Size 以 1024 为进制格式化字节数
+func (f *Formatter) Size(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%s B", f.Number(bytes))
+	}
+	value, exp := float64(bytes)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%s %ciB", f.Decimal(strconv.FormatFloat(value, 'f', 1, 64)), "KMGTP"[exp])
+}
+
+// FuncMap 供 text/template 自定义模板使用的格式化函数：date、day、number、decimal、amount、size
+func (f *Formatter) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"date":    f.Date,
+		"day":     f.Day,
+		"number":  f.Number,
+		"decimal": f.Decimal,
+		"amount":  f.Amount,
+		"size":    f.Size,
+	}
+}
@@ -0,0 +1,79 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/qr"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// 终端二维码的渲染方式
+const (
+	GraphicsAuto  = "auto"  // 按终端能力自动选择
+	GraphicsASCII = "ascii" // 半块字符，所有终端可用
+	GraphicsSixel = "sixel" // DEC sixel 图形
+	GraphicsKitty = "kitty" // kitty 图形协议
+)
+
+// GraphicsModes ui.qr 可选的值
+var GraphicsModes = []string{GraphicsAuto, GraphicsASCII, GraphicsSixel, GraphicsKitty}
+
+// qrScale 图形渲染时每个模块的像素边长
+const qrScale = 4
+
+var (
+	detectOnce sync.Once
+	detected   string
+)
+
+// Graphics 返回终端二维码的渲染方式：ui.qr 不是 auto 时直接使用配置，
+// 否则标准输出不是终端时用 ASCII，是终端时按环境变量识别支持图形的终端
+func Graphics() string {
+	mode := strings.ToLower(strings.TrimSpace(viper.GetString("ui.qr")))
+	switch mode {
+	case GraphicsASCII, GraphicsSixel, GraphicsKitty:
+		return mode
+	}
+	detectOnce.Do(func() { detected = detectGraphics() })
+	return detected
+}
+
+// detectGraphics 按环境变量识别终端的图形能力；tmux、screen 会吞掉图形转义序列，一律用 ASCII
+func detectGraphics() string {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return GraphicsASCII
+	}
+	termName := strings.ToLower(os.Getenv("TERM"))
+	program := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(termName, "screen") || strings.HasPrefix(termName, "tmux") {
+		return GraphicsASCII
+	}
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", termName == "xterm-kitty", termName == "xterm-ghostty",
+		program == "wezterm", program == "ghostty":
+		return GraphicsKitty
+	case strings.Contains(termName, "sixel"), strings.HasPrefix(termName, "foot"), termName == "mlterm",
+		program == "mintty", program == "iterm.app", os.Getenv("KONSOLE_VERSION") != "":
+		return GraphicsSixel
+	}
+	return GraphicsASCII
+}
+
+// QR 按终端能力渲染二维码，图形输出末尾带换行，可直接打印；无障碍模式下只输出一行说明
+func QR(code *qr.Code) string {
+	if Accessible() {
+		return fmt.Sprintf("QR code not shown in accessible mode (version %d); qr.export with --out writes it as an image.\n", code.Version)
+	}
+	switch Graphics() {
+	case GraphicsKitty:
+		return code.Kitty(qrScale)
+	case GraphicsSixel:
+		return code.Sixel(qrScale) + "\n"
+	default:
+		return code.ASCII()
+	}
+}
@@ -0,0 +1,126 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HelpUsage 命令的一种用法：命令名之后的参数和这种用法的说明
+type HelpUsage struct {
+	Args    string
+	Summary string
+}
+
+// HelpArg 一个参数或标志的说明
+type HelpArg struct {
+	Name        string
+	Description string
+}
+
+// CommandHelp 一条命令的帮助信息，由 REPL 的命令注册表生成
+type CommandHelp struct {
+	Name     string
+	Aliases  []string
+	Usages   []HelpUsage
+	Args     []HelpArg
+	Examples []string
+	ReadOnly bool // 只读模式下可用
+}
+
+// UsageLines 每种用法一行，含命令名
+func (c CommandHelp) UsageLines() []string {
+	lines := make([]string, len(c.Usages))
+	for i, usage := range c.Usages {
+		lines[i] = strings.TrimSpace(c.Name + " " + usage.Args)
+	}
+	return lines
+}
+
+// HelpGroup 帮助中的一组命令
+type HelpGroup struct {
+	Title    string
+	Commands []CommandHelp
+}
+
+// Help 按分组列出全部命令的用法和说明
+func (t *DefaultTemplate) Help(groups []HelpGroup) string {
+	var b strings.Builder
+	b.WriteString(t.banner("AVAILABLE COMMANDS") + "\n\n")
+
+	for _, group := range groups {
+		b.WriteString(t.styles.Header.Render(group.Title) + "\n")
+		for _, command := range group.Commands {
+			for i, line := range command.UsageLines() {
+				if i == 0 && len(command.Aliases) > 0 {
+					line = strings.Join(append([]string{line}, command.Aliases...), ", ")
+				}
+				fmt.Fprintf(&b, "  %s %s %s\n", t.styles.Highlight.Render(line), IconArrow, command.Usages[i].Summary)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(t.styles.Header.Render("PIPES AND REDIRECTION") + "\n")
+	for _, item := range [][2]string{
+		{"<command> | grep [-i] [-v] <pattern>", "Filter output (also head, tail, sort, uniq, wc; chain with |)"},
+		{"<command> > <file>", "Write output to a file (asks before overwriting)"},
+		{"<command> >> <file>", "Append output to a file"},
+		{"<command> && <command> ...", "Run commands in order, stopping at the first failure"},
+		{"\"quoted args\" or 'quoted'", "Keep spaces, |, > and && inside one argument (\\ escapes a character)"},
+	} {
+		fmt.Fprintf(&b, "  %s %s %s\n", t.styles.Highlight.Render(item[0]), IconArrow, item[1])
+	}
+	b.WriteString("\n")
+
+	// 添加快捷键说明
+	b.WriteString(t.styles.Header.Render("SHORTCUTS") + "\n")
+	fmt.Fprintf(&b, "  Ctrl+D, Ctrl+C  %s Exit immediately\n", IconArrow)
+	fmt.Fprintf(&b, "  Tab            %s Auto-completion\n", IconArrow)
+	fmt.Fprintf(&b, "  help <command> %s Show usage, arguments and examples of one command\n", IconArrow)
+
+	return b.String()
+}
+
+// CommandHelp 显示一条命令的用法、参数说明和示例
+func (t *DefaultTemplate) CommandHelp(command CommandHelp) string {
+	var b strings.Builder
+	b.WriteString(t.styles.Title.Render(command.Name) + "\n\n")
+
+	b.WriteString(t.styles.Header.Render("USAGE") + "\n")
+	for i, line := range command.UsageLines() {
+		fmt.Fprintf(&b, "  %s\n", t.styles.Highlight.Render(line))
+		if summary := command.Usages[i].Summary; summary != "" {
+			fmt.Fprintf(&b, "      %s\n", summary)
+		}
+	}
+
+	if len(command.Args) > 0 {
+		width := 0
+		for _, arg := range command.Args {
+			width = max(width, len(arg.Name))
+		}
+		b.WriteString("\n" + t.styles.Header.Render("ARGUMENTS") + "\n")
+		for _, arg := range command.Args {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, arg.Name, arg.Description)
+		}
+	}
+
+	if len(command.Examples) > 0 {
+		b.WriteString("\n" + t.styles.Header.Render("EXAMPLES") + "\n")
+		for _, example := range command.Examples {
+			fmt.Fprintf(&b, "  %s\n", example)
+		}
+	}
+
+	var notes []string
+	if len(command.Aliases) > 0 {
+		notes = append(notes, "Aliases: "+strings.Join(command.Aliases, ", "))
+	}
+	if command.ReadOnly {
+		notes = append(notes, "Available in read-only mode")
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n" + t.styles.Muted.Render(strings.Join(notes, "; ")) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
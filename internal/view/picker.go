@@ -0,0 +1,99 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// 错误定义
+var (
+	ErrPickCancelled = errors.New("selection cancelled")
+	ErrNotTerminal   = errors.New("stdin is not a terminal")
+)
+
+var (
+	pickCursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Bold(true)
+	pickHintStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Faint(true)
+)
+
+// Pick 在终端中显示列表，用方向键（或 j/k）移动、回车选择，Esc、q 或 Ctrl+C 取消，返回选中项的下标。
+// 标准输入不是终端或处于无障碍模式（读屏软件无法跟随重绘的列表）时返回 ErrNotTerminal，调用方应改用编号输入
+func Pick(title string, items []string) (int, error) {
+	if len(items) == 0 {
+		return -1, errors.New("nothing to select")
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || Accessible() {
+		return -1, ErrNotTerminal
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return -1, err
+	}
+	defer term.Restore(fd, state)
+
+	p := &picker{items: items}
+	fmt.Print(title + "\r\n")
+	p.render(false)
+	buf := make([]byte, 3)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return -1, err
+		}
+		switch {
+		case n == 1 && (buf[0] == '\r' || buf[0] == '\n'):
+			p.render(true)
+			return p.selected, nil
+		case n == 1 && (buf[0] == 3 || buf[0] == 27 || buf[0] == 'q'):
+			p.selected = -1
+			p.render(true)
+			return -1, ErrPickCancelled
+		case (n == 3 && buf[0] == 27 && buf[2] == 'A') || (n == 1 && buf[0] == 'k'):
+			p.selected = (p.selected + len(items) - 1) % len(items)
+		case (n == 3 && buf[0] == 27 && buf[2] == 'B') || (n == 1 && buf[0] == 'j'):
+			p.selected = (p.selected + 1) % len(items)
+		default:
+			continue
+		}
+		p.render(false)
+	}
+}
+
+// picker 列表状态，lines 是上一次绘制的行数，重绘前把光标移回列表开头
+type picker struct {
+	items    []string
+	selected int
+	lines    int
+}
+
+// render 重绘列表，done 为 true 时只保留选中的一行
+func (p *picker) render(done bool) {
+	var b strings.Builder
+	if p.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", p.lines)
+	}
+	b.WriteString("\x1b[J")
+	switch {
+	case done && p.selected >= 0:
+		b.WriteString(render(pickCursorStyle, "> "+p.items[p.selected]) + "\r\n")
+	case done:
+		b.WriteString(render(pickHintStyle, "  (cancelled)") + "\r\n")
+	default:
+		for i, item := range p.items {
+			if i == p.selected {
+				b.WriteString(render(pickCursorStyle, "> "+item) + "\r\n")
+			} else {
+				b.WriteString("  " + item + "\r\n")
+			}
+		}
+		b.WriteString(render(pickHintStyle, "  up/down to move, enter to select, esc to cancel") + "\r\n")
+	}
+	p.lines = strings.Count(b.String(), "\r\n")
+	fmt.Print(b.String())
+}
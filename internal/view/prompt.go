@@ -0,0 +1,70 @@
+package view
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// PromptData 自定义提示符模板（ui.prompt）可用的变量，取不到的值为空字符串，
+// 模板中可以用 {{or .Fingerprint "-"}} 给出替代文字
+type PromptData struct {
+	Fingerprint string            // 主密钥指纹，钱包锁定时为空
+	Context     string            // use 设置的默认值，如 ETH/savings，与内置提示符中显示的相同
+	Account     string            // 当前账户：use 设置的账户，其次是会话变量 ACC，再次是最近创建或派生地址的账户
+	Coin        string            // use 设置的币种，或当前账户的币种；钱包锁定或账户不存在时为空
+	Locked      bool              // 钱包是否锁定
+	State       string            // locked 或 unlocked
+	Icon        string            // 锁定状态图标，与内置提示符相同
+	ReadOnly    bool              // 存储目录以只读方式打开
+	Network     string            // rpc.network，如 mainnet、testnet
+	DataDir     string            // 存储目录
+	Time        time.Time         // 当前时间，用 {{date .Time}} 或 {{.Time.Format "15:04"}} 显示
+	Vars        map[string]string // 全部会话变量，如 {{.Vars.LAST_TXID}}
+}
+
+// promptColors 提示符模板中的颜色函数，无障碍模式或设置了 NO_COLOR 时原样输出
+var promptColors = template.FuncMap{
+	"red":     color.New(color.FgRed).SprintFunc(),
+	"green":   color.New(color.FgGreen).SprintFunc(),
+	"yellow":  color.New(color.FgYellow).SprintFunc(),
+	"blue":    color.New(color.FgBlue).SprintFunc(),
+	"magenta": color.New(color.FgMagenta).SprintFunc(),
+	"cyan":    color.New(color.FgCyan).SprintFunc(),
+	"gray":    color.New(color.FgHiBlack).SprintFunc(),
+	"bold":    color.New(color.Bold).SprintFunc(),
+	"short":   shortText,
+}
+
+// PromptTemplate 解析 ui.prompt 模板，funcs 为额外的函数（如日期和数字格式化）
+func PromptTemplate(text string, funcs template.FuncMap) (*template.Template, error) {
+	// 不存在的会话变量显示为空，而不是 <no value>
+	return template.New("prompt").Option("missingkey=zero").Funcs(funcs).Funcs(promptColors).Parse(text)
+}
+
+// RenderPrompt 按模板生成提示符
+func RenderPrompt(tmpl *template.Template, data PromptData) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// PromptState 锁定状态的文字和图标
+func PromptState(isLocked bool) (string, string) {
+	if isLocked {
+		return "locked", IconLock
+	}
+	return "unlocked", IconOpen
+}
+
+// shortText 截取前 n 个字符，用于较长的账户 ID
+func shortText(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// 错误定义
+var (
+	ErrOutputNotTerminal = errors.New("stdout is not a terminal, refusing to display secret")
+	ErrRevealCancelled   = errors.New("reveal cancelled")
+)
+
+var (
+	secretTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	secretBodyStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+)
+
+// ShowSecret 在备用屏幕缓冲区中显示机密内容（如助记词），内容不会进入终端的回滚记录：
+// 先显示提示，按任意键后才显示内容，clearAfter 倒计时结束或再按任意键后清屏并恢复原屏幕；
+// 在显示提示时按 Esc、q 或 Ctrl+C 取消，返回 ErrRevealCancelled。
+// 标准输出不是终端时返回 ErrOutputNotTerminal，标准输入不是终端时返回 ErrNotTerminal，两种情况都不输出任何内容
+func ShowSecret(title string, lines []string, warnings []string, clearAfter time.Duration) error {
+	if err := CheckSecretTerminal(); err != nil {
+		return err
+	}
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// 进入备用屏幕并隐藏光标，退出时先清屏再切回，保证内容不留在任何缓冲区里
+	fmt.Print("\x1b[?1049h\x1b[?25l\x1b[2J\x1b[H")
+	defer fmt.Print("\x1b[2J\x1b[3J\x1b[H\x1b[?25h\x1b[?1049l")
+
+	var b strings.Builder
+	b.WriteString(render(secretTitleStyle, title) + "\r\n\r\n")
+	for _, warning := range warnings {
+		b.WriteString(Yellow(warning) + "\r\n")
+	}
+	b.WriteString("\r\n" + render(pickHintStyle, "Make sure nobody can see your screen. Press any key to reveal, esc to cancel") + "\r\n")
+	fmt.Print(b.String())
+
+	key, _, err := readKey(fd, 0)
+	if err != nil {
+		return err
+	}
+	if key == 3 || key == 27 || key == 'q' {
+		return ErrRevealCancelled
+	}
+
+	b.Reset()
+	b.WriteString("\x1b[2J\x1b[H" + render(secretTitleStyle, title) + "\r\n\r\n")
+	for _, line := range lines {
+		b.WriteString("  " + render(secretBodyStyle, line) + "\r\n")
+	}
+	b.WriteString("\r\n")
+	fmt.Print(b.String())
+
+	deadline := time.Now().Add(clearAfter)
+	for {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return nil
+		}
+		seconds := int((left + time.Second - 1) / time.Second)
+		fmt.Printf("\r\x1b[K%s", render(pickHintStyle, fmt.Sprintf("Screen clears in %ds, press any key to clear now", seconds)))
+		// 等到下一个整秒刷新倒计时
+		if _, pressed, err := readKey(fd, left-time.Duration(seconds-1)*time.Second); err != nil || pressed {
+			return err
+		}
+	}
+}
+
+// CheckSecretTerminal 检查能否用 ShowSecret 显示机密内容：标准输入和标准输出都必须是终端
+func CheckSecretTerminal() error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ErrOutputNotTerminal
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return ErrNotTerminal
+	}
+	return nil
+}
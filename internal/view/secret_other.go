@@ -0,0 +1,33 @@
+//go:build !unix
+
+package view
+
+import (
+	"os"
+	"time"
+)
+
+// readKey 读取一个按键，timeout 为 0 时一直等待；超时返回 pressed=false。
+// 当前平台没有 poll，超时后后台的读取仍在等待，下一次按键会被它丢弃
+func readKey(fd int, timeout time.Duration) (key byte, pressed bool, err error) {
+	type result struct {
+		key byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		buf := make([]byte, 8)
+		_, err := os.Stdin.Read(buf)
+		done <- result{buf[0], err}
+	}()
+	if timeout <= 0 {
+		r := <-done
+		return r.key, r.err == nil, r.err
+	}
+	select {
+	case r := <-done:
+		return r.key, r.err == nil, r.err
+	case <-time.After(timeout):
+		return 0, false, nil
+	}
+}
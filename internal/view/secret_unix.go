@@ -0,0 +1,42 @@
+//go:build unix
+
+package view
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// readKey 读取一个按键，timeout 为 0 时一直等待；超时返回 pressed=false。
+// 用 poll 等待而不是后台 goroutine，超时后不会有残留的读取吞掉之后的输入
+func readKey(fd int, timeout time.Duration) (key byte, pressed bool, err error) {
+	if timeout > 0 {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		deadline := time.Now().Add(timeout)
+		for {
+			ms := int(time.Until(deadline).Milliseconds())
+			if ms <= 0 {
+				return 0, false, nil
+			}
+			n, err := unix.Poll(fds, ms)
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			if err != nil {
+				return 0, false, err
+			}
+			if n == 0 {
+				return 0, false, nil
+			}
+			break
+		}
+	}
+	buf := make([]byte, 8)
+	if _, err := os.Stdin.Read(buf); err != nil {
+		return 0, false, err
+	}
+	return buf[0], true, nil
+}
@@ -2,25 +2,32 @@ package view
 
 import (
 	"fmt"
+	"math/big"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/report"
+	"github.com/palagend/slowmade/internal/version"
 	"github.com/spf13/viper"
 )
 
 // DisplayTemplate 定义显示模板接口
 type DisplayTemplate interface {
 	Welcome() string
-	Prompt(isLocked bool) string
+	Prompt(isLocked bool, context string) string // context 为 use 设置的默认币种和账户，未设置时为空
 	WalletCreated(status string) string
 	AccountList(accounts []*core.CoinAccount) string
 	AddressList(addrs []*core.AddressKey) string
 	WalletRestored(status string) string
 	WalletUnlocked() string
 	WalletLocked() string
-	WalletStatus(status string) string
-	Help() string
+	WalletStatus(info *WalletStatusInfo) string
+	SpendingReport(spending *report.Spending) string
+	Help(groups []HelpGroup) string
+	CommandHelp(command CommandHelp) string
 	Goodbye() string
 	Error(message string) string
 	Info(message string) string
@@ -30,6 +37,7 @@ type DisplayTemplate interface {
 	HistoryItem(index int, command string) string
 	Version() string
 	Separator() string
+	TutorialStep(step, total int, title, text string) string
 }
 
 // DefaultTemplate 使用 lipgloss 的现代化模板
@@ -70,6 +78,11 @@ const (
 	IconTriangle = "▶"
 )
 
+// Formatter 返回当前语言的格式化器，自定义模板也可以直接使用 CurrentFormatter 或其 FuncMap
+func (t *DefaultTemplate) Formatter() *Formatter {
+	return CurrentFormatter()
+}
+
 // NewDefaultTemplate 创建新的模板实例
 func NewDefaultTemplate() *DefaultTemplate {
 	return &DefaultTemplate{
@@ -187,13 +200,19 @@ Type '%s' for available commands, '%s' to quit.`,
 	)
 }
 
-func (t *DefaultTemplate) Prompt(isLocked bool) string {
+func (t *DefaultTemplate) Prompt(isLocked bool, context string) string {
 	statusIcon := IconLock
 	if !isLocked {
 		statusIcon = IconOpen
 	}
-	return fmt.Sprintf("%s(%s) > ", statusIcon, viper.GetString("storage.base_dir"))
-
+	location := viper.GetString("storage.base_dir")
+	if viper.GetBool("storage.read_only") {
+		location += ", read-only"
+	}
+	if context != "" {
+		location += ", " + context
+	}
+	return fmt.Sprintf("%s(%s) > ", statusIcon, location)
 }
 
 func (t *DefaultTemplate) WalletCreated(status string) string {
@@ -227,7 +246,14 @@ func (t *DefaultTemplate) AccountList(accounts []*core.CoinAccount) string {
 	var accountList strings.Builder
 	accountList.WriteString(fmt.Sprintf("%s Found %s accounts:\n\n",
 		IconSuccess,
-		t.styles.Highlight.Render(fmt.Sprintf("%d", len(accounts)))))
+		t.styles.Highlight.Render(t.Formatter().Number(int64(len(accounts))))))
+
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	shortIDs := core.ShortIDs(ids)
+	format := t.Formatter()
 
 	for i, account := range accounts {
 		keyPreview := "[ENCRYPTED]"
@@ -238,19 +264,35 @@ func (t *DefaultTemplate) AccountList(accounts []*core.CoinAccount) string {
 
 		accountList.WriteString(fmt.Sprintf(`%s Account #%d
   %s ID:       %s
+  %s Short ID: %s
   %s Coin:     %s
   %s Path:     %s
   %s Key:      %s
+  %s Created:  %s
+  %s Modified: %s
 `,
 			IconSquare, i+1,
 			IconArrow, account.ID,
+			IconArrow, t.styles.Highlight.Render(shortIDs[account.ID]),
 			IconArrow, t.styles.Highlight.Render(account.CoinSymbol),
 			IconArrow, account.DerivationPath,
 			IconArrow, t.styles.Muted.Render(keyPreview),
+			IconArrow, format.Date(account.Created()),
+			IconArrow, format.Date(account.Modified()),
 		))
+		if account.Frozen {
+			accountList.WriteString(fmt.Sprintf("  %s Status:   %s since %s (no signing or key derivation, see account.unfreeze)\n",
+				IconArrow, t.styles.Warning.Render("FROZEN"), format.Date(account.FrozenAt())))
+		}
+		if account.Standalone {
+			accountList.WriteString(fmt.Sprintf("  %s Type:     standalone (imported, not derived from this wallet's seed)\n", IconArrow))
+		}
+		if convention := account.Convention(); convention != core.ConventionStandard {
+			accountList.WriteString(fmt.Sprintf("  %s Paths:    %s (%s)\n", IconArrow, convention, convention.Pattern(account.CoinType())))
+		}
 	}
 
-	return fmt.Sprintf("%s\n\n%s\n\n%s Each account has a unique derivation path",
+	return fmt.Sprintf("%s\n\n%s\n\n%s Each account has a unique derivation path; commands accept the short ID, an alias or a label",
 		t.banner("ACCOUNT LIST"),
 		accountList.String(),
 		IconInfo,
@@ -291,63 +333,133 @@ func (t *DefaultTemplate) WalletLocked() string {
 	)
 }
 
-func (t *DefaultTemplate) WalletStatus(status string) string {
-	return fmt.Sprintf("Wallet Status: %s %s",
-		t.statusStyle(status).Render(status),
-		t.statusIcon(status))
-}
-
-func (t *DefaultTemplate) Help() string {
-	commands := map[string][]string{
-		"WALLET MANAGEMENT": {
-			"wallet.create [password]        " + IconArrow + " Create a new HD wallet",
-			"wallet.restore <mnemonic> <password> " + IconArrow + " Restore wallet from mnemonic",
-			"wallet.unlock <password>        " + IconArrow + " Unlock wallet with password",
-			"wallet.lock                   " + IconArrow + " Lock wallet",
-			"wallet.status                 " + IconArrow + " Check wallet status",
-		},
-		"ACCOUNT MANAGEMENT": {
-			"account.create <derivationPath> " + IconArrow + " Create new account",
-			"account.list <CoinSymbol>       " + IconArrow + " List accounts",
-			"address.derive <accountID> <password> " + IconArrow + " Derive new address",
-			"address.list <accountID>        " + IconArrow + " List addresses",
-		},
-		"BASIC COMMANDS": {
-			"exit, quit    " + IconArrow + " Exit the REPL",
-			"help        " + IconArrow + " Show help",
-			"clear       " + IconArrow + " Clear screen",
-			"history     " + IconArrow + " Show history",
-			"version     " + IconArrow + " Show version",
-		},
+// WalletStatusInfo wallet.status 显示的钱包详情，未知的项为空
+type WalletStatusInfo struct {
+	Status      string // locked / unlocked
+	Fingerprint string // 主密钥指纹，锁定时为空
+	Created     time.Time
+	Modified    time.Time
+	Accounts    map[string]int // 币种 -> 账户数，锁定时为 nil
+	Addresses   int            // 已派生的地址数
+	Storage     string         // 存储后端和路径
+	Encryption  string         // 加密算法
+	KDF         string         // 密钥派生函数和参数
+	AutoLock    string         // 自动锁定设置或倒计时
+	RPC         []RPCStatus    // 已配置节点的连通性，未检查时为 nil
+}
+
+// RPCStatus 一个节点的连通性
+type RPCStatus struct {
+	Coin     string
+	Endpoint string
+	Latency  time.Duration
+	Err      error
+}
+
+func (t *DefaultTemplate) WalletStatus(info *WalletStatusInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Wallet Status: %s %s\n",
+		t.statusStyle(info.Status).Render(info.Status),
+		t.statusIcon(info.Status))
+	format := t.Formatter()
+	line := func(name, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(&b, "  %s %-12s %s\n", IconArrow, name+":", value)
 	}
 
-	var helpText strings.Builder
-	helpText.WriteString(t.banner("AVAILABLE COMMANDS") + "\n\n")
-
-	for category, cmds := range commands {
-		helpText.WriteString(t.styles.Header.Render(category) + "\n")
-		for _, cmd := range cmds {
-			// 分割命令和描述
-			parts := strings.SplitN(cmd, IconArrow, 2)
-			if len(parts) == 2 {
-				helpText.WriteString(fmt.Sprintf("  %s %s %s\n",
-					t.styles.Highlight.Render(strings.TrimSpace(parts[0])),
-					IconArrow,
-					strings.TrimSpace(parts[1]),
-				))
+	hidden := t.styles.Muted.Render("(unlock to show)")
+	fingerprint := info.Fingerprint
+	if fingerprint == "" && info.Status == "locked" {
+		fingerprint = hidden
+	}
+	line("Fingerprint", fingerprint)
+	line("Created", format.Date(info.Created))
+	line("Modified", format.Date(info.Modified))
+
+	coins := make([]string, 0, len(info.Accounts))
+	total := 0
+	for symbol, n := range info.Accounts {
+		coins = append(coins, symbol)
+		total += n
+	}
+	sort.Strings(coins)
+	perCoin := make([]string, len(coins))
+	for i, symbol := range coins {
+		perCoin[i] = fmt.Sprintf("%s %s", symbol, format.Number(int64(info.Accounts[symbol])))
+	}
+	accounts := format.Number(int64(total))
+	if len(perCoin) > 0 {
+		accounts += " (" + strings.Join(perCoin, ", ") + ")"
+	}
+	addresses := format.Number(int64(info.Addresses))
+	if info.Accounts == nil {
+		accounts, addresses = hidden, hidden
+	}
+	line("Accounts", accounts)
+	line("Addresses", addresses)
+	line("Storage", info.Storage)
+	line("Encryption", info.Encryption)
+	line("KDF", info.KDF)
+	line("Auto-lock", info.AutoLock)
+
+	switch {
+	case info.RPC == nil:
+		line("RPC", t.styles.Muted.Render("not checked"))
+	case len(info.RPC) == 0:
+		line("RPC", "no nodes configured (block explorers are used)")
+	default:
+		for i, node := range info.RPC {
+			state := t.styles.Success.Render(fmt.Sprintf("reachable (%.1f ms)", float64(node.Latency.Microseconds())/1000))
+			if node.Err != nil {
+				state = t.styles.Error.Render("unreachable: " + node.Err.Error())
+			}
+			value := fmt.Sprintf("%-4s %s  %s", node.Coin, node.Endpoint, state)
+			if i == 0 {
+				line("RPC", value)
 			} else {
-				helpText.WriteString("  " + cmd + "\n")
+				fmt.Fprintf(&b, "     %-12s %s\n", "", value)
 			}
 		}
-		helpText.WriteString("\n")
 	}
+	return strings.TrimRight(b.String(), "\n")
+}
 
-	// 添加快捷键说明
-	helpText.WriteString(t.styles.Header.Render("SHORTCUTS") + "\n")
-	helpText.WriteString(fmt.Sprintf("  Ctrl+D, Ctrl+C  %s Exit immediately\n", IconArrow))
-	helpText.WriteString(fmt.Sprintf("  Tab            %s Auto-completion\n", IconArrow))
-
-	return helpText.String()
+// SpendingReport 收支报表：按月汇总、交易对手和手续费
+func (t *DefaultTemplate) SpendingReport(spending *report.Spending) string {
+	var b strings.Builder
+	format := t.Formatter()
+	amount := func(value *big.Int) string {
+		return format.Amount(value, spending.Decimals, "")
+	}
+	account := spending.AccountID
+	if spending.AccountLabel != "" {
+		account = fmt.Sprintf("%s (%s)", spending.AccountLabel, spending.AccountID)
+	}
+	fmt.Fprintf(&b, "%s\n", t.styles.Title.Render(fmt.Sprintf("Spending report %s, %s", spending.Period.Name, spending.Coin)))
+	fmt.Fprintf(&b, "  %s %-12s %s\n", IconArrow, "Account:", account)
+	fmt.Fprintf(&b, "  %s %-12s %s - %s\n", IconArrow, "Period:", format.Day(spending.Period.Start), format.Day(spending.Period.End.AddDate(0, 0, -1)))
+	fmt.Fprintf(&b, "  %s %-12s %s\n", IconArrow, "Inflows:", amount(spending.In))
+	fmt.Fprintf(&b, "  %s %-12s %s\n", IconArrow, "Outflows:", amount(spending.Out))
+	fmt.Fprintf(&b, "  %s %-12s %s\n", IconArrow, "Fees paid:", amount(spending.Fees))
+	fmt.Fprintf(&b, "  %s %-12s %s\n\n", IconArrow, "Net:", amount(spending.Net()))
+
+	fmt.Fprintf(&b, "%s\n", t.styles.Header.Render(fmt.Sprintf("  %-8s %18s %18s %14s %4s", "MONTH", "IN", "OUT", "FEES", "TXS")))
+	for _, month := range spending.Months {
+		fmt.Fprintf(&b, "  %-8s %18s %18s %14s %4d\n", month.Month.Format("2006-01"),
+			amount(month.In), amount(month.Out), amount(month.Fees), month.Count)
+	}
+	if len(spending.Counterparties) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", t.styles.Header.Render(fmt.Sprintf("  %-24s %18s %18s %4s", "COUNTERPARTY", "IN", "OUT", "TXS")))
+		for _, party := range spending.Counterparties {
+			fmt.Fprintf(&b, "  %-24s %18s %18s %4d\n", party.Name, amount(party.In), amount(party.Out), party.Count)
+		}
+	}
+	if len(spending.Entries) == 0 {
+		fmt.Fprintf(&b, "\n%s\n", t.styles.Muted.Render("No transactions recorded in this period"))
+	}
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // 简化通用消息方法
@@ -384,13 +496,27 @@ func (t *DefaultTemplate) HistoryItem(index int, command string) string {
 }
 
 func (t *DefaultTemplate) Version() string {
-	return t.styles.Info.Render("Slowmade REPL v1.1.0 - BIP44 HD Wallet Management")
+	return t.styles.Info.Render("Slowmade REPL " + version.Get().Short() + " - BIP44 HD Wallet Management")
 }
 
 func (t *DefaultTemplate) Separator() string {
 	return t.styles.Border.Render(strings.Repeat("-", 60))
 }
 
+// TutorialStep 教程步骤：编号和标题，分隔线下是缩进的说明，空行分段
+func (t *DefaultTemplate) TutorialStep(step, total int, title, text string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n%s\n", t.styles.Title.Render(fmt.Sprintf("STEP %d/%d  %s", step, total, title)), t.Separator())
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
 func (t *DefaultTemplate) AddressList(addrs []*core.AddressKey) string {
 	if len(addrs) == 0 {
 		return fmt.Sprintf("%s\n\n%s No addresses found",
@@ -402,7 +528,7 @@ func (t *DefaultTemplate) AddressList(addrs []*core.AddressKey) string {
 	var addressList strings.Builder
 	addressList.WriteString(fmt.Sprintf("%s Found %s addresses:\n\n",
 		IconSuccess,
-		t.styles.Highlight.Render(fmt.Sprintf("%d", len(addrs)))))
+		t.styles.Highlight.Render(t.Formatter().Number(int64(len(addrs))))))
 
 	for i, addr := range addrs {
 		// 格式化公钥预览
@@ -0,0 +1,60 @@
+package walletconnect
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/base58"
+)
+
+// ed25519 公钥的 multicodec 前缀
+var multicodecEd25519 = []byte{0xed, 0x01}
+
+// didKey 按 did:key 规范编码 ed25519 公钥
+func didKey(pub ed25519.PublicKey) string {
+	return "did:key:z" + base58.Encode(append(append([]byte{}, multicodecEd25519...), pub...))
+}
+
+// relayAuthToken 生成中继鉴权用的 EdDSA JWT
+func relayAuthToken(key ed25519.PrivateKey, audience string, ttl time.Duration) (string, error) {
+	subject := make([]byte, 32)
+	if _, err := rand.Read(subject); err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": didKey(key.Public().(ed25519.PublicKey)),
+		"sub": hex.EncodeToString(subject),
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	signature := ed25519.Sign(key, []byte(signingInput))
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// relayURL 拼接中继连接地址
+func relayURL(base, projectID, token, userAgent string) string {
+	query := url.Values{}
+	query.Set("auth", token)
+	query.Set("projectId", projectID)
+	query.Set("ua", userAgent)
+	return strings.TrimRight(base, "/") + "/?" + query.Encode()
+}
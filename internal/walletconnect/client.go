@@ -0,0 +1,522 @@
+package walletconnect
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/policy"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// DefaultRelayURL WalletConnect 官方中继
+const DefaultRelayURL = "wss://relay.walletconnect.com"
+
+const sessionTTL = 7 * 24 * time.Hour
+
+// 会话请求支持的方法，实际签名由 signer 完成
+var supportedMethods = []string{
+	"eth_accounts", "eth_signTransaction", "eth_signTypedData", "eth_signTypedData_v4", "personal_sign", "eth_sign",
+}
+
+// 协议方法对应的消息 tag 及有效期，响应的 tag 为请求 tag + 1
+var methodTags = map[string]struct {
+	tag int
+	ttl time.Duration
+}{
+	"wc_pairingDelete":  {1000, 24 * time.Hour},
+	"wc_pairingPing":    {1002, 30 * time.Second},
+	"wc_sessionPropose": {1100, 5 * time.Minute},
+	"wc_sessionSettle":  {1102, 5 * time.Minute},
+	"wc_sessionUpdate":  {1104, 24 * time.Hour},
+	"wc_sessionExtend":  {1106, 24 * time.Hour},
+	"wc_sessionRequest": {1108, 5 * time.Minute},
+	"wc_sessionEvent":   {1110, 5 * time.Minute},
+	"wc_sessionDelete":  {1112, 24 * time.Hour},
+	"wc_sessionPing":    {1114, 30 * time.Second},
+}
+
+// WalletConnect SDK 错误码
+const (
+	errCodeUserRejected       = 5000
+	errCodeUnsupportedChains  = 5100
+	errCodeUnsupportedNSKey   = 5104
+	errCodeUserDisconnected   = 6000
+	errCodeUnsupportedMethod  = 4200
+	errCodeUnauthorized       = 4100
+	errCodeInvalidParams      = -32602
+	errCodeInternal           = -32603
+	errCodeMethodNotAvailable = -32601
+)
+
+// RPCError WalletConnect JSON-RPC 错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Metadata 应用元数据
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// Namespace CAIP-25 命名空间
+type Namespace struct {
+	Chains   []string `json:"chains,omitempty"`
+	Accounts []string `json:"accounts,omitempty"`
+	Methods  []string `json:"methods"`
+	Events   []string `json:"events"`
+}
+
+// Session 已建立的会话
+type Session struct {
+	Topic  string
+	Peer   Metadata
+	Chains []string
+	Expiry time.Time
+}
+
+// Config 客户端配置
+type Config struct {
+	ProjectID string
+	RelayURL  string
+	Metadata  Metadata
+}
+
+type wcMessage struct {
+	ID      uint64          `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type proposalParams struct {
+	ID                 uint64               `json:"id"`
+	RequiredNamespaces map[string]Namespace `json:"requiredNamespaces"`
+	OptionalNamespaces map[string]Namespace `json:"optionalNamespaces"`
+	Relays             []map[string]string  `json:"relays"`
+	Proposer           struct {
+		PublicKey string   `json:"publicKey"`
+		Metadata  Metadata `json:"metadata"`
+	} `json:"proposer"`
+}
+
+type sessionRequestParams struct {
+	Request struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	} `json:"request"`
+	ChainID string `json:"chainId"`
+}
+
+// Client WalletConnect v2 钱包端，处理配对、会话提案和签名请求
+type Client struct {
+	cfg      Config
+	signer   *signer.Signer
+	approver signer.Approver
+	identity ed25519.PrivateKey
+	relay    *relay
+
+	mu       sync.Mutex
+	keys     map[string][]byte // topic -> 对称密钥
+	sessions map[string]*Session
+}
+
+// NewClient 创建客户端，签名请求交给 signer，会话提案由 approver 确认
+func NewClient(cfg Config, s *signer.Signer, approver signer.Approver) (*Client, error) {
+	if cfg.ProjectID == "" {
+		return nil, errors.New("walletconnect.project_id is not configured")
+	}
+	if cfg.RelayURL == "" {
+		cfg.RelayURL = DefaultRelayURL
+	}
+	_, identity, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		cfg:      cfg,
+		signer:   s,
+		approver: approver,
+		identity: identity,
+		keys:     make(map[string][]byte),
+		sessions: make(map[string]*Session),
+	}, nil
+}
+
+// Connect 连接中继服务器
+func (c *Client) Connect(ctx context.Context) error {
+	token, err := relayAuthToken(c.identity, c.cfg.RelayURL, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	userAgent := fmt.Sprintf("wc-2/go-slowmade-%s/%s", version.Get().GitVersion, runtime.GOOS)
+	c.relay, err = connectRelay(ctx, relayURL(c.cfg.RelayURL, c.cfg.ProjectID, token, userAgent), c.handleMessage)
+	return err
+}
+
+// Done 中继连接断开时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.relay.Done()
+}
+
+// Err 返回中继断开的原因
+func (c *Client) Err() error {
+	return c.relay.Err()
+}
+
+// Pair 使用 dApp 提供的 URI 配对，之后等待会话提案
+func (c *Client) Pair(ctx context.Context, uri *PairingURI) error {
+	if uri.Expired() {
+		return errors.New("pairing URI has expired, request a new one from the dApp")
+	}
+	if uri.RelayProtocol != "irn" {
+		return fmt.Errorf("unsupported relay protocol %s", uri.RelayProtocol)
+	}
+	c.setKey(uri.Topic, uri.SymKey)
+	return c.relay.Subscribe(ctx, uri.Topic)
+}
+
+// Sessions 返回当前会话
+func (c *Client) Sessions() []*Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// Disconnect 通知 dApp 断开所有会话并关闭中继连接
+func (c *Client) Disconnect(ctx context.Context) error {
+	for _, session := range c.Sessions() {
+		params := RPCError{Code: errCodeUserDisconnected, Message: "User disconnected."}
+		if err := c.request(ctx, session.Topic, "wc_sessionDelete", params); err != nil {
+			logging.Debugf("failed to delete session %s: %v", session.Topic, err)
+		}
+	}
+	return c.relay.Close()
+}
+
+func (c *Client) handleMessage(topic, message string) {
+	symKey := c.key(topic)
+	if symKey == nil {
+		logging.Debugf("ignore message for unknown topic %s", topic)
+		return
+	}
+	plaintext, err := decrypt(symKey, message)
+	if err != nil {
+		logging.Warnf("failed to decrypt WalletConnect message: %v", err)
+		return
+	}
+	var msg wcMessage
+	if err := json.Unmarshal(plaintext, &msg); err != nil {
+		logging.Warnf("malformed WalletConnect message: %v", err)
+		return
+	}
+	if msg.Method == "" {
+		if msg.Error != nil {
+			logging.Warnf("dApp returned error for request %d: %v", msg.ID, msg.Error)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	var (
+		result interface{}
+		rpcErr *RPCError
+	)
+	switch msg.Method {
+	case "wc_sessionPropose":
+		result, rpcErr = c.handleProposal(ctx, topic, msg.Params)
+	case "wc_sessionRequest":
+		result, rpcErr = c.handleRequest(topic, msg.Params)
+	case "wc_sessionDelete":
+		c.removeSession(topic)
+		result = true
+	case "wc_pairingDelete", "wc_sessionPing", "wc_pairingPing", "wc_sessionEvent", "wc_sessionExtend", "wc_sessionUpdate":
+		result = true
+	default:
+		rpcErr = &RPCError{Code: errCodeMethodNotAvailable, Message: fmt.Sprintf("method %s is not supported", msg.Method)}
+	}
+
+	if err := c.respond(ctx, topic, msg.Method, msg.ID, result, rpcErr); err != nil {
+		logging.Warnf("failed to respond to %s: %v", msg.Method, err)
+	}
+}
+
+// handleProposal 展示会话提案，确认后协商会话密钥并下发 settle
+func (c *Client) handleProposal(ctx context.Context, pairingTopic string, raw json.RawMessage) (interface{}, *RPCError) {
+	var proposal proposalParams
+	if err := json.Unmarshal(raw, &proposal); err != nil {
+		return nil, &RPCError{Code: errCodeInvalidParams, Message: err.Error()}
+	}
+	for key := range proposal.RequiredNamespaces {
+		if namespaceKey(key) != "eip155" {
+			return nil, &RPCError{Code: errCodeUnsupportedNSKey, Message: fmt.Sprintf("namespace %s is not supported", key)}
+		}
+	}
+
+	chains, methods, events := mergeNamespaces(proposal.RequiredNamespaces, proposal.OptionalNamespaces)
+	if len(chains) == 0 {
+		return nil, &RPCError{Code: errCodeUnsupportedChains, Message: "no eip155 chains requested"}
+	}
+	accounts, err := c.signer.Accounts()
+	if err != nil {
+		return nil, &RPCError{Code: errCodeUnauthorized, Message: err.Error()}
+	}
+
+	peer := proposal.Proposer.Metadata
+	details := []string{
+		fmt.Sprintf("dApp:     %s", peer.Name),
+		fmt.Sprintf("URL:      %s", peer.URL),
+		fmt.Sprintf("Chains:   %s", strings.Join(chains, ", ")),
+		fmt.Sprintf("Methods:  %s", strings.Join(methods, ", ")),
+		fmt.Sprintf("Accounts: %d ETH address(es) will be shared", len(accounts)),
+	}
+	if peer.Description != "" {
+		details = append(details, fmt.Sprintf("About:    %s", peer.Description))
+	}
+	if !c.approver.Approve(&signer.ApprovalRequest{Method: "wc_sessionPropose", Details: details}) {
+		return nil, &RPCError{Code: errCodeUserRejected, Message: "User rejected."}
+	}
+
+	private, err := generateKeyPair()
+	if err != nil {
+		return nil, &RPCError{Code: errCodeInternal, Message: err.Error()}
+	}
+	symKey, err := deriveSymKey(private, proposal.Proposer.PublicKey)
+	if err != nil {
+		return nil, &RPCError{Code: errCodeInvalidParams, Message: err.Error()}
+	}
+	session := &Session{
+		Topic:  topicForKey(symKey),
+		Peer:   peer,
+		Chains: chains,
+		Expiry: time.Now().Add(sessionTTL),
+	}
+	c.setKey(session.Topic, symKey)
+	if err := c.relay.Subscribe(ctx, session.Topic); err != nil {
+		return nil, &RPCError{Code: errCodeInternal, Message: err.Error()}
+	}
+
+	// 与官方 SDK 一致，先在会话话题上下发 settle，再响应提案
+	var caipAccounts []string
+	for _, chain := range chains {
+		for _, account := range accounts {
+			caipAccounts = append(caipAccounts, chain+":"+account.Hex())
+		}
+	}
+	settle := map[string]interface{}{
+		"relay": map[string]string{"protocol": "irn"},
+		"namespaces": map[string]Namespace{
+			"eip155": {Chains: chains, Accounts: caipAccounts, Methods: methods, Events: events},
+		},
+		"controller": map[string]interface{}{
+			"publicKey": hex.EncodeToString(private.PublicKey().Bytes()),
+			"metadata":  c.cfg.Metadata,
+		},
+		"expiry": session.Expiry.Unix(),
+	}
+	if err := c.request(ctx, session.Topic, "wc_sessionSettle", settle); err != nil {
+		return nil, &RPCError{Code: errCodeInternal, Message: err.Error()}
+	}
+	c.mu.Lock()
+	c.sessions[session.Topic] = session
+	c.mu.Unlock()
+	fmt.Printf("Session established with %s (%s)\n", peer.Name, peer.URL)
+
+	return map[string]interface{}{
+		"relay":              map[string]string{"protocol": "irn"},
+		"responderPublicKey": hex.EncodeToString(private.PublicKey().Bytes()),
+	}, nil
+}
+
+// handleRequest 将会话中的签名请求转交给 signer
+func (c *Client) handleRequest(topic string, raw json.RawMessage) (interface{}, *RPCError) {
+	c.mu.Lock()
+	session, ok := c.sessions[topic]
+	c.mu.Unlock()
+	if !ok {
+		return nil, &RPCError{Code: errCodeUnauthorized, Message: "no session for topic"}
+	}
+
+	var params sessionRequestParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &RPCError{Code: errCodeInvalidParams, Message: err.Error()}
+	}
+	chainID, err := parseChainID(params.ChainID)
+	if err != nil {
+		return nil, &RPCError{Code: errCodeInvalidParams, Message: err.Error()}
+	}
+
+	origin := fmt.Sprintf("%s (%s)", session.Peer.Name, session.Peer.URL)
+	result, err := c.signer.For(origin, chainID).Handle(params.Request.Method, params.Request.Params)
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	return result, nil
+}
+
+func (c *Client) request(ctx context.Context, topic, method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	msg := wcMessage{ID: payloadID(), JSONRPC: "2.0", Method: method, Params: raw}
+	tag := methodTags[method]
+	return c.publish(ctx, topic, msg, tag.tag, tag.ttl)
+}
+
+func (c *Client) respond(ctx context.Context, topic, method string, id uint64, result interface{}, rpcErr *RPCError) error {
+	msg := wcMessage{ID: id, JSONRPC: "2.0", Error: rpcErr}
+	if rpcErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = raw
+	}
+	tag, ok := methodTags[method]
+	if !ok {
+		tag = methodTags["wc_sessionRequest"]
+	}
+	return c.publish(ctx, topic, msg, tag.tag+1, tag.ttl)
+}
+
+func (c *Client) publish(ctx context.Context, topic string, msg wcMessage, tag int, ttl time.Duration) error {
+	symKey := c.key(topic)
+	if symKey == nil {
+		return fmt.Errorf("no key for topic %s", topic)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	envelope, err := encrypt(symKey, data)
+	if err != nil {
+		return err
+	}
+	return c.relay.Publish(ctx, topic, envelope, ttl, tag)
+}
+
+func (c *Client) key(topic string) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keys[topic]
+}
+
+func (c *Client) setKey(topic string, symKey []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[topic] = symKey
+}
+
+func (c *Client) removeSession(topic string) {
+	c.mu.Lock()
+	session, ok := c.sessions[topic]
+	delete(c.sessions, topic)
+	c.mu.Unlock()
+	if ok {
+		fmt.Printf("Session with %s was closed by the dApp\n", session.Peer.Name)
+	}
+}
+
+// namespaceKey 命名空间键可以是 "eip155" 或具体链 "eip155:1"
+func namespaceKey(key string) string {
+	ns, _, _ := strings.Cut(key, ":")
+	return ns
+}
+
+// mergeNamespaces 合并必需和可选的 eip155 命名空间，方法中补充本钱包支持的签名方法
+func mergeNamespaces(required, optional map[string]Namespace) (chains, methods, events []string) {
+	chainSet := make(map[string]bool)
+	methodSet := make(map[string]bool)
+	eventSet := make(map[string]bool)
+	for _, namespaces := range []map[string]Namespace{required, optional} {
+		for key, ns := range namespaces {
+			if namespaceKey(key) != "eip155" {
+				continue
+			}
+			if strings.Contains(key, ":") {
+				chainSet[key] = true
+			}
+			for _, chain := range ns.Chains {
+				chainSet[chain] = true
+			}
+			for _, method := range ns.Methods {
+				methodSet[method] = true
+			}
+			for _, event := range ns.Events {
+				eventSet[event] = true
+			}
+		}
+	}
+	// dApp 常要求 eth_sendTransaction 等方法，会话中声明但请求时返回不支持
+	for _, method := range supportedMethods {
+		methodSet[method] = true
+	}
+	return sortedKeys(chainSet), sortedKeys(methodSet), sortedKeys(eventSet)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseChainID 解析 CAIP-2 链标识 eip155:<id>
+func parseChainID(caip string) (*big.Int, error) {
+	ns, reference, ok := strings.Cut(caip, ":")
+	if !ok || ns != "eip155" {
+		return nil, fmt.Errorf("unsupported chain %q", caip)
+	}
+	id, ok := new(big.Int).SetString(reference, 10)
+	if !ok || id.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid chain id %q", caip)
+	}
+	return id, nil
+}
+
+func toRPCError(err error) *RPCError {
+	code := errCodeInternal
+	switch {
+	case errors.Is(err, signer.ErrRejected):
+		return &RPCError{Code: errCodeUserRejected, Message: "User rejected."}
+	case errors.Is(err, policy.ErrDenied):
+		return &RPCError{Code: errCodeUserRejected, Message: err.Error()}
+	case errors.Is(err, signer.ErrMethodNotSupported):
+		code = errCodeUnsupportedMethod
+	case errors.Is(err, signer.ErrUnknownAccount), errors.Is(err, core.ErrWalletLocked):
+		code = errCodeUnauthorized
+	}
+	return &RPCError{Code: code, Message: err.Error()}
+}
@@ -0,0 +1,85 @@
+package walletconnect
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// 信封类型 0：base64(0x00 ‖ iv ‖ ChaCha20-Poly1305 密文)
+const envelopeType0 = 0x00
+
+// topicForKey 话题为对称密钥的 sha256
+func topicForKey(symKey []byte) string {
+	sum := sha256.Sum256(symKey)
+	return hex.EncodeToString(sum[:])
+}
+
+// encrypt 使用对称密钥封装消息
+func encrypt(symKey, plaintext []byte) (string, error) {
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	envelope := append([]byte{envelopeType0}, iv...)
+	envelope = aead.Seal(envelope, iv, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// decrypt 解开类型 0 信封
+func decrypt(symKey []byte, message string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope encoding: %w", err)
+	}
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < 1+aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("envelope too short")
+	}
+	if envelope[0] != envelopeType0 {
+		return nil, fmt.Errorf("unsupported envelope type %d", envelope[0])
+	}
+	iv := envelope[1 : 1+aead.NonceSize()]
+	return aead.Open(nil, iv, envelope[1+aead.NonceSize():], nil)
+}
+
+// generateKeyPair 生成会话协商用的 X25519 密钥对
+func generateKeyPair() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// deriveSymKey X25519 共享密钥经 HKDF-SHA256 派生会话对称密钥
+func deriveSymKey(private *ecdh.PrivateKey, peerPublicHex string) ([]byte, error) {
+	peerBytes, err := hex.DecodeString(peerPublicHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(peerBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+	shared, err := private.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	symKey := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, nil), symKey); err != nil {
+		return nil, err
+	}
+	return symKey, nil
+}
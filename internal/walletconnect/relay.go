@@ -0,0 +1,186 @@
+package walletconnect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// relayRequest 中继的 JSON-RPC 请求（irn_* 方法）
+type relayRequest struct {
+	ID      uint64      `json:"id"`
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type relayMessage struct {
+	ID      uint64          `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// subscriptionParams irn_subscription 推送的消息
+type subscriptionParams struct {
+	ID   string `json:"id"`
+	Data struct {
+		Topic       string `json:"topic"`
+		Message     string `json:"message"`
+		PublishedAt int64  `json:"publishedAt"`
+		Tag         int    `json:"tag"`
+	} `json:"data"`
+}
+
+// relay 与 WalletConnect 中继服务器的连接
+type relay struct {
+	conn      *wsConn
+	onMessage func(topic, message string)
+
+	mu      sync.Mutex
+	pending map[uint64]chan *relayMessage
+	closed  chan struct{}
+	err     error
+}
+
+func connectRelay(ctx context.Context, url string, onMessage func(topic, message string)) (*relay, error) {
+	conn, err := dialWebSocket(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to relay: %w", err)
+	}
+	r := &relay{
+		conn:      conn,
+		onMessage: onMessage,
+		pending:   make(map[uint64]chan *relayMessage),
+		closed:    make(chan struct{}),
+	}
+	go r.readLoop()
+	return r, nil
+}
+
+// Subscribe 订阅话题
+func (r *relay) Subscribe(ctx context.Context, topic string) error {
+	_, err := r.call(ctx, "irn_subscribe", map[string]string{"topic": topic})
+	return err
+}
+
+// Publish 向话题发布加密后的消息
+func (r *relay) Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error {
+	_, err := r.call(ctx, "irn_publish", map[string]interface{}{
+		"topic":   topic,
+		"message": message,
+		"ttl":     int64(ttl.Seconds()),
+		"tag":     tag,
+		"prompt":  false,
+	})
+	return err
+}
+
+// Done 连接断开时关闭
+func (r *relay) Done() <-chan struct{} {
+	return r.closed
+}
+
+// Err 返回导致连接断开的错误
+func (r *relay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *relay) Close() error {
+	return r.conn.Close()
+}
+
+func (r *relay) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := payloadID()
+	data, err := json.Marshal(relayRequest{ID: id, JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *relayMessage, 1)
+	r.mu.Lock()
+	r.pending[id] = ch
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	if err := r.conn.WriteText(data); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s failed: %w", method, resp.Error)
+		}
+		return resp.Result, nil
+	case <-r.closed:
+		return nil, errors.New("relay connection closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *relay) readLoop() {
+	defer close(r.closed)
+	for {
+		data, err := r.conn.ReadMessage()
+		if err != nil {
+			r.mu.Lock()
+			r.err = err
+			r.mu.Unlock()
+			return
+		}
+
+		var msg relayMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			logging.Debugf("ignore malformed relay message: %v", err)
+			continue
+		}
+
+		if msg.Method == "" {
+			r.mu.Lock()
+			ch, ok := r.pending[msg.ID]
+			r.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+			continue
+		}
+
+		if msg.Method != "irn_subscription" {
+			continue
+		}
+		// 中继要求对推送消息应答，否则会重复投递
+		ack, _ := json.Marshal(map[string]interface{}{"id": msg.ID, "jsonrpc": "2.0", "result": true})
+		if err := r.conn.WriteText(ack); err != nil {
+			logging.Debugf("failed to acknowledge relay message: %v", err)
+		}
+
+		var params subscriptionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			logging.Debugf("ignore malformed subscription: %v", err)
+			continue
+		}
+		go r.onMessage(params.Data.Topic, params.Data.Message)
+	}
+}
+
+// payloadID 生成 JSON-RPC id：毫秒时间戳后追加 3 位随机数
+func payloadID() uint64 {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1000))
+	return uint64(time.Now().UnixMilli())*1000 + n.Uint64()
+}
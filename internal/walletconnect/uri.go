@@ -0,0 +1,74 @@
+package walletconnect
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PairingURI dApp 展示的配对 URI（通常编码在二维码中）
+// 格式：wc:<topic>@2?relay-protocol=irn&symKey=<hex>[&expiryTimestamp=<unix>]
+type PairingURI struct {
+	Topic         string
+	Version       int
+	RelayProtocol string
+	SymKey        []byte
+	Expiry        time.Time
+}
+
+// ParseURI 解析 WalletConnect v2 配对 URI
+func ParseURI(raw string) (*PairingURI, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "wc:") {
+		return nil, fmt.Errorf("not a WalletConnect URI: %q", raw)
+	}
+	body := strings.TrimPrefix(raw, "wc:")
+	body = strings.TrimPrefix(body, "//")
+
+	path, rawQuery, _ := strings.Cut(body, "?")
+	topic, version, ok := strings.Cut(path, "@")
+	if !ok || topic == "" {
+		return nil, fmt.Errorf("invalid WalletConnect URI: missing topic or version")
+	}
+	if version != "2" {
+		return nil, fmt.Errorf("unsupported WalletConnect version %s, only v2 is supported", version)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WalletConnect URI query: %w", err)
+	}
+	symKey, err := hex.DecodeString(query.Get("symKey"))
+	if err != nil || len(symKey) != 32 {
+		return nil, fmt.Errorf("invalid WalletConnect URI: symKey must be 32 bytes of hex")
+	}
+
+	uri := &PairingURI{
+		Topic:         topic,
+		Version:       2,
+		RelayProtocol: query.Get("relay-protocol"),
+		SymKey:        symKey,
+	}
+	if uri.RelayProtocol == "" {
+		uri.RelayProtocol = "irn"
+	}
+	if expiry := query.Get("expiryTimestamp"); expiry != "" {
+		seconds, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiryTimestamp %q", expiry)
+		}
+		uri.Expiry = time.Unix(seconds, 0)
+	}
+	if topicForKey(symKey) != topic {
+		return nil, fmt.Errorf("invalid WalletConnect URI: topic does not match symKey")
+	}
+	return uri, nil
+}
+
+// Expired 检查配对 URI 是否已过期
+func (u *PairingURI) Expired() bool {
+	return !u.Expiry.IsZero() && time.Now().After(u.Expiry)
+}
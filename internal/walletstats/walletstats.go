@@ -0,0 +1,152 @@
+// Package walletstats 钱包概况：各币种的账户数、每个账户的地址数、存储目录占用、钱包和账户的年龄、
+// 最近一次备份和解锁的时间以及加密参数，供 stats wallet 命令和 /api/v1/stats 使用
+package walletstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/palagend/slowmade/internal/backup"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// UnlockStateFileName 最近一次成功解锁的记录在数据目录中的文件名
+const UnlockStateFileName = "unlock_state.json"
+
+// Unlock 最近一次成功解锁的来源和时间
+type Unlock struct {
+	Source string    `json:"source"` // repl、cli 或 http
+	At     time.Time `json:"at"`
+}
+
+// Account 一个账户的地址数和创建时间
+type Account struct {
+	ID        string     `json:"id"`
+	Coin      string     `json:"coin"`
+	Path      string     `json:"path"`
+	Addresses int        `json:"addresses"`
+	Created   *time.Time `json:"created_at,omitempty"`
+}
+
+// Stats 钱包概况。锁定时账户列表不可见，Coins 和 Accounts 为空
+type Stats struct {
+	Locked       bool           `json:"locked"`
+	Created      *time.Time     `json:"created_at,omitempty"`
+	Modified     *time.Time     `json:"modified_at,omitempty"`
+	Coins        map[string]int `json:"accounts_per_coin,omitempty"`
+	Accounts     []Account      `json:"accounts,omitempty"`
+	Addresses    int            `json:"addresses"`
+	StorageBytes int64          `json:"storage_bytes"`
+	StorageFiles int            `json:"storage_files"`
+	LastBackup   *backup.State  `json:"last_backup,omitempty"`
+	LastUnlock   *Unlock        `json:"last_unlock,omitempty"`
+	Encryption   string         `json:"encryption"`
+	KDF          string         `json:"kdf"`
+}
+
+// Collect 汇总 baseDir 中钱包的概况；钱包不存在时没有时间，锁定时不统计账户和地址
+func Collect(baseDir string, walletMgr core.WalletManager, accountMgr core.AccountManager) (*Stats, error) {
+	stats := &Stats{
+		Locked:     walletMgr.IsLocked(),
+		Encryption: crypto.GetCurrentAlgorithm(),
+		KDF:        crypto.GetCurrentKDF(),
+	}
+	if created, modified, err := walletMgr.Timestamps(); err == nil {
+		stats.Created, stats.Modified = optionalTime(created), optionalTime(modified)
+	}
+
+	if !stats.Locked {
+		accounts, err := accountMgr.GetAccounts()
+		if err != nil {
+			return nil, err
+		}
+		stats.Coins = make(map[string]int)
+		for _, account := range accounts {
+			addresses, err := accountMgr.GetAddresses(account.ID)
+			if err != nil {
+				return nil, err
+			}
+			stats.Coins[account.CoinSymbol]++
+			stats.Addresses += len(addresses)
+			stats.Accounts = append(stats.Accounts, Account{ID: account.ID, Coin: account.CoinSymbol,
+				Path: account.DerivationPath, Addresses: len(addresses), Created: optionalTime(account.Created())})
+		}
+		sort.Slice(stats.Accounts, func(i, j int) bool {
+			if stats.Accounts[i].Coin != stats.Accounts[j].Coin {
+				return stats.Accounts[i].Coin < stats.Accounts[j].Coin
+			}
+			return stats.Accounts[i].Path < stats.Accounts[j].Path
+		})
+	}
+
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stats.StorageBytes += info.Size()
+		stats.StorageFiles++
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if stats.LastBackup, err = backup.LastBackup(baseDir); err != nil {
+		return nil, err
+	}
+	if stats.LastUnlock, err = LastUnlock(baseDir); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// RecordUnlock 记录一次成功的解锁
+func RecordUnlock(baseDir, source string) error {
+	data, err := canonjson.MarshalIndent(Unlock{Source: source, At: time.Now().UTC()}, "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, UnlockStateFileName)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入解锁记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名解锁记录失败: %w", err)
+	}
+	return nil
+}
+
+// LastUnlock 返回最近一次成功解锁的记录，从未记录时返回 nil
+func LastUnlock(baseDir string) (*Unlock, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, UnlockStateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var unlock Unlock
+	if err := json.Unmarshal(data, &unlock); err != nil {
+		return nil, fmt.Errorf("解码解锁记录失败: %w", err)
+	}
+	return &unlock, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
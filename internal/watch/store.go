@@ -0,0 +1,265 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/price"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// TxFileName 交易记录在数据目录中的文件名
+const TxFileName = "transactions.json"
+
+// Payment 检测到的一笔入账；余额类后端无法得知交易 ID，TxID 为空，Amount 为余额增量
+type Payment struct {
+	Coin       string    `json:"coin"`
+	AccountID  string    `json:"account_id"`
+	Address    string    `json:"address"`
+	TxID       string    `json:"txid,omitempty"`
+	Vout       uint32    `json:"vout,omitempty"`
+	Amount     string    `json:"amount"` // 最小单位
+	Height     int64     `json:"height,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+	RequestID  string    `json:"request_id,omitempty"` // 匹配到的收款请求
+	WatchOnly  bool      `json:"watch_only,omitempty"` // 导入的只读地址，本钱包不能花费
+	Fiat       *Fiat     `json:"fiat,omitempty"`       // 入账时的汇率，作为成本基础
+}
+
+// Fiat 交易发生时 1 个币的法币价格
+type Fiat struct {
+	Currency string    `json:"currency"`
+	Rate     string    `json:"rate"` // 十进制字符串，避免浮点误差
+	Source   string    `json:"source"`
+	PricedAt time.Time `json:"priced_at"`
+}
+
+// Describe 通知的标题和正文，金额按币种精度格式化
+func (p Payment) Describe() (string, string) {
+	amount := p.Amount + " " + p.Coin
+	if value, ok := new(big.Int).SetString(p.Amount, 10); ok {
+		if info, ok := coin.LookupSymbol(p.Coin); ok {
+			amount = coin.FormatUnits(value, info.Decimal) + " " + p.Coin
+		}
+	}
+	body := fmt.Sprintf("%s on %s", amount, p.Address)
+	if p.RequestID != "" {
+		body += " for request " + p.RequestID
+	}
+	if p.WatchOnly {
+		body += " (watch-only)"
+	}
+	return "Payment received", body
+}
+
+// Mirror tx.recorded 事件的数据
+func (p Payment) Mirror() mirror.Tx {
+	return mirror.Tx{Direction: mirror.In, Coin: p.Coin, AccountID: p.AccountID, Address: p.Address, TxID: p.TxID, Vout: p.Vout,
+		Amount: p.Amount, Height: p.Height, Time: p.DetectedAt, RequestID: p.RequestID, WatchOnly: p.WatchOnly}
+}
+
+// FiatFromQuote 把汇率查询结果转换为交易记录中的价格
+func FiatFromQuote(quote *price.Quote) *Fiat {
+	rate := strings.TrimRight(strings.TrimRight(quote.Rate.FloatString(12), "0"), ".")
+	return &Fiat{Currency: quote.Currency, Rate: rate, Source: quote.Source, PricedAt: quote.FetchedAt}
+}
+
+// Spend 本钱包广播的一笔支出；To 为收款地址，转到本钱包自己的地址（合并、轮换）时也会记录
+type Spend struct {
+	Coin      string    `json:"coin"`
+	AccountID string    `json:"account_id"`
+	TxID      string    `json:"txid"`
+	To        string    `json:"to"`
+	Amount    string    `json:"amount"` // 最小单位，不含手续费
+	Fee       string    `json:"fee"`    // 最小单位
+	SentAt    time.Time `json:"sent_at"`
+	Command   string    `json:"command,omitempty"`
+	Fiat      *Fiat     `json:"fiat,omitempty"`     // 发送时的汇率，用于计算处置收入
+	Replaces  string    `json:"replaces,omitempty"` // 被这笔交易替换（RBF）的交易
+}
+
+// Mirror tx.recorded 事件的数据
+func (s Spend) Mirror() mirror.Tx {
+	return mirror.Tx{Direction: mirror.Out, Coin: s.Coin, AccountID: s.AccountID, Address: s.To, TxID: s.TxID,
+		Amount: s.Amount, Fee: s.Fee, Time: s.SentAt}
+}
+
+// TxStore 交易记录：已检测到的入账、本钱包广播的支出、已见过的输出和各地址上次的余额
+type TxStore struct {
+	mu       sync.Mutex
+	path     string
+	Incoming []Payment         `json:"incoming"`
+	Outgoing []Spend           `json:"outgoing,omitempty"`
+	Seen     map[string]bool   `json:"seen"`     // 已记录的 txid:vout
+	Balances map[string]string `json:"balances"` // 地址 -> 上次查询的余额
+}
+
+// LoadTxStore 加载交易记录，文件不存在时返回空记录
+func LoadTxStore(path string) (*TxStore, error) {
+	s := &TxStore{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("解码交易记录失败: %w", err)
+		}
+	}
+	if s.Seen == nil {
+		s.Seen = make(map[string]bool)
+	}
+	if s.Balances == nil {
+		s.Balances = make(map[string]string)
+	}
+	return s, nil
+}
+
+// seen 输出是否已记录
+func (s *TxStore) seen(outpoint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Seen[outpoint]
+}
+
+// balance 返回地址上次的余额，没有记录时 ok 为 false
+func (s *TxStore) balance(address string) (*big.Int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.Balances[address]
+	if !ok {
+		return nil, false
+	}
+	balance, ok := new(big.Int).SetString(value, 10)
+	return balance, ok
+}
+
+// received 地址是否已有入账记录
+func (s *TxStore) received(address string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.Incoming {
+		if p.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// record 保存一轮查询的结果
+func (s *TxStore) record(payments []Payment, outpoints []string, balances map[string]*big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Incoming = append(s.Incoming, payments...)
+	for _, outpoint := range outpoints {
+		s.Seen[outpoint] = true
+	}
+	for address, balance := range balances {
+		s.Balances[address] = balance.String()
+	}
+	return s.save()
+}
+
+// Payments 返回全部入账记录
+func (s *TxStore) Payments() []Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Payment(nil), s.Incoming...)
+}
+
+// RecordSpend 保存一笔已广播的支出
+func (s *TxStore) RecordSpend(spend Spend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if spend.Replaces != "" {
+		s.Outgoing = slices.DeleteFunc(s.Outgoing, func(old Spend) bool { return old.TxID == spend.Replaces })
+	}
+	s.Outgoing = append(s.Outgoing, spend)
+	return s.save()
+}
+
+// Spends 返回全部支出记录
+func (s *TxStore) Spends() []Spend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Spend(nil), s.Outgoing...)
+}
+
+// Mirror 全部入账和支出的 tx.recorded 事件数据，按时间排序
+func (s *TxStore) Mirror() []mirror.Tx {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txs := make([]mirror.Tx, 0, len(s.Incoming)+len(s.Outgoing))
+	for _, p := range s.Incoming {
+		txs = append(txs, p.Mirror())
+	}
+	for _, spend := range s.Outgoing {
+		txs = append(txs, spend.Mirror())
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Time.Before(txs[j].Time)
+	})
+	return txs
+}
+
+// FillPrices 为缺少 currency 汇率的入账和支出补记价格，price 按币种和交易时间返回汇率，
+// 单笔失败时跳过；返回补记的条数和遇到的第一个错误
+func (s *TxStore) FillPrices(currency string, price func(coin string, at time.Time) (*Fiat, error)) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filled := 0
+	var firstErr error
+	fill := func(current **Fiat, coin string, at time.Time) {
+		if *current != nil && (*current).Currency == currency {
+			return
+		}
+		fiat, err := price(coin, at)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		*current = fiat
+		filled++
+	}
+	for i := range s.Incoming {
+		if !s.Incoming[i].WatchOnly {
+			fill(&s.Incoming[i].Fiat, s.Incoming[i].Coin, s.Incoming[i].DetectedAt)
+		}
+	}
+	for i := range s.Outgoing {
+		fill(&s.Outgoing[i].Fiat, s.Outgoing[i].Coin, s.Outgoing[i].SentAt)
+	}
+	if filled == 0 {
+		return 0, firstErr
+	}
+	if err := s.save(); err != nil {
+		return 0, err
+	}
+	return filled, firstErr
+}
+
+func (s *TxStore) save() error {
+	data, err := canonjson.MarshalIndent(s, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入交易记录失败: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		return fmt.Errorf("重命名交易记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+// Package watch 轮询链上数据，检测派生收款地址和导入的只读地址的入账，记录到交易记录、匹配收款请求并发布事件
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/alert"
+	"github.com/palagend/slowmade/internal/audit"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/price"
+	"github.com/palagend/slowmade/pkg/logging"
+)
+
+// Watcher 收款监控，只监控收款地址（找零地址的入账是自己的交易）
+type Watcher struct {
+	accountMgr core.AccountManager
+	dataDir    string
+	bus        *events.Bus
+	skipped    map[string]bool // 没有可用后端的币种，只提示一次
+}
+
+// NewWatcher 创建收款监控，需要钱包保持解锁
+func NewWatcher(accountMgr core.AccountManager, dataDir string, bus *events.Bus) *Watcher {
+	return &Watcher{accountMgr: accountMgr, dataDir: dataDir, bus: bus, skipped: make(map[string]bool)}
+}
+
+// Run 按间隔轮询直到 ctx 取消，单轮失败只记录日志
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Poll(ctx); err != nil && ctx.Err() == nil {
+			logging.Warnf("收款监控查询失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 查询一轮，返回新检测到的入账。地址第一次被查询时已有的资金只记录不通知，
+// 但仍会用来匹配收款请求
+func (w *Watcher) Poll(ctx context.Context) ([]Payment, error) {
+	accounts, err := w.accountMgr.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	store, err := LoadTxStore(filepath.Join(w.dataDir, TxFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []Payment
+	var outpoints []string
+	balances := make(map[string]*big.Int)
+	baseline := make(map[string]bool)
+	var accountBalances []alert.Balance
+	var errs []error
+	// collect 合并一组地址的查询结果，current 为 nil 表示币种没有可用的后端
+	collect := func(found []Payment, seen []string, current map[string]*big.Int, addresses []string) {
+		if current == nil {
+			return
+		}
+		for _, address := range addresses {
+			if _, ok := store.balance(address); !ok {
+				baseline[address] = true
+			}
+			if _, ok := current[address]; !ok {
+				current[address] = new(big.Int)
+			}
+		}
+		payments = append(payments, found...)
+		outpoints = append(outpoints, seen...)
+		for address, balance := range current {
+			balances[address] = balance
+		}
+	}
+	for _, account := range accounts {
+		addresses, err := w.accountMgr.GetAddresses(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		var receive []string
+		for _, addr := range addresses {
+			if addr.ChangeType == 0 {
+				receive = append(receive, addr.Address)
+			}
+		}
+		if len(receive) == 0 {
+			continue
+		}
+		mine := func(address string) bool {
+			_, ok := w.accountMgr.IsMine(address)
+			return ok
+		}
+		found, seen, current, err := w.pollAddresses(ctx, store, account.CoinSymbol, account.ID, receive, mine)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", account.ID, err))
+			continue
+		}
+		collect(found, seen, current, receive)
+		if current != nil {
+			total := new(big.Int)
+			for _, address := range receive {
+				total.Add(total, current[address])
+			}
+			accountBalances = append(accountBalances, alert.Balance{Coin: account.CoinSymbol, AccountID: account.ID, Amount: total})
+		}
+	}
+
+	// 只读地址按币种查询，入账标记为 watch-only
+	watchList, err := LoadWatchList(filepath.Join(w.dataDir, WatchOnlyFileName))
+	if err != nil {
+		return nil, err
+	}
+	for symbol, addresses := range watchList.addressesByCoin() {
+		listed := make(map[string]bool, len(addresses))
+		for _, address := range addresses {
+			listed[address] = true
+		}
+		found, seen, current, err := w.pollAddresses(ctx, store, symbol, "", addresses, func(address string) bool { return listed[address] })
+		if err != nil {
+			errs = append(errs, fmt.Errorf("watch-only %s: %w", symbol, err))
+			continue
+		}
+		for i := range found {
+			found[i].WatchOnly = true
+		}
+		collect(found, seen, current, addresses)
+	}
+
+	requests, err := payreq.Load(filepath.Join(w.dataDir, payreq.FileName))
+	if err != nil {
+		return nil, err
+	}
+	var paid []*payreq.Request
+	for i := range payments {
+		if req := match(requests, &payments[i]); req != nil {
+			paid = append(paid, req)
+		}
+	}
+	w.price(ctx, payments)
+	reuses := reused(store, payments, baseline)
+	if err := store.record(payments, outpoints, balances); err != nil {
+		return nil, err
+	}
+
+	var fresh []Payment
+	for _, p := range payments {
+		w.bus.Publish(events.Event{Type: mirror.TxRecorded, Data: p.Mirror()})
+		if !baseline[p.Address] {
+			fresh = append(fresh, p)
+			w.bus.Publish(events.Event{Type: events.PaymentReceived, Data: p})
+		}
+	}
+	for _, req := range paid {
+		w.bus.Publish(events.Event{Type: events.RequestPaid, Data: req})
+	}
+	w.alert(store, accountBalances, reuses)
+	return fresh, errors.Join(errs...)
+}
+
+// reused 本轮付款中发往已收过款的收款地址的付款；地址第一次被查询时已有的资金和只读地址不算
+func reused(store *TxStore, payments []Payment, baseline map[string]bool) []alert.Reuse {
+	var result []alert.Reuse
+	paid := make(map[string]bool)
+	for _, p := range payments {
+		if p.WatchOnly || baseline[p.Address] {
+			continue
+		}
+		if paid[p.Address] || store.received(p.Address) {
+			result = append(result, alert.Reuse{Coin: p.Coin, AccountID: p.AccountID, Address: p.Address, TxID: p.TxID})
+		}
+		paid[p.Address] = true
+	}
+	return result
+}
+
+// alert 对告警规则求值，触发的告警发布到事件总线并写入审计日志；失败只记录日志，不影响收款监控
+func (w *Watcher) alert(store *TxStore, balances []alert.Balance, reuses []alert.Reuse) {
+	rules, err := alert.Load(filepath.Join(w.dataDir, alert.FileName))
+	if err != nil {
+		logging.Warnf("加载告警规则失败: %v", err)
+		return
+	}
+	if len(rules.List()) == 0 {
+		return
+	}
+	var spends []alert.Spend
+	for _, spend := range store.Spends() {
+		amount, ok := new(big.Int).SetString(spend.Amount, 10)
+		if !ok {
+			continue
+		}
+		spends = append(spends, alert.Spend{Coin: spend.Coin, AccountID: spend.AccountID, TxID: spend.TxID,
+			To: spend.To, Amount: amount, SentAt: spend.SentAt})
+	}
+	alerts, err := rules.Evaluate(alert.Snapshot{Balances: balances, Spends: spends, Reuses: reuses})
+	if err != nil {
+		logging.Warnf("保存告警规则状态失败: %v", err)
+	}
+	logger := audit.ForDir(w.dataDir)
+	for _, a := range alerts {
+		w.bus.Publish(events.Event{Type: alert.Fired, Data: a})
+		if err := logger.Record("watch", alert.Fired, a.RuleID, a.Kind+": "+a.Message); err != nil {
+			logging.Warnf("写入审计日志失败: %v", err)
+		}
+	}
+}
+
+// price 配置了 tax.currency 时为新入账记录当前汇率作为成本基础；查询失败只记录日志，之后可以用 tax.lots --backfill 补记
+func (w *Watcher) price(ctx context.Context, payments []Payment) {
+	appConfig := config.GetAppConfig()
+	currency := appConfig.GetTaxConfig().Currency
+	if currency == "" || len(payments) == 0 {
+		return
+	}
+	service, err := price.NewService(appConfig.GetPriceConfig())
+	if err != nil {
+		logging.Warnf("无法记录入账汇率: %v", err)
+		return
+	}
+	for i := range payments {
+		if payments[i].WatchOnly {
+			continue
+		}
+		quote, err := service.Quote(ctx, payments[i].Coin, currency)
+		if err != nil {
+			logging.Warnf("无法记录 %s 入账汇率: %v", payments[i].Coin, err)
+			continue
+		}
+		payments[i].Fiat = FiatFromQuote(quote)
+	}
+}
+
+// pollAddresses 查询一组同币种的地址，accountID 为空表示只读地址；BTC 优先使用 UTXO 后端以获得交易 ID，
+// 其他币种比较余额。owned 过滤后端返回的输出。币种没有可用后端时返回的余额为 nil
+func (w *Watcher) pollAddresses(ctx context.Context, store *TxStore, symbol, accountID string, addresses []string, owned func(address string) bool) ([]Payment, []string, map[string]*big.Int, error) {
+	appConfig := config.GetAppConfig()
+	balances := make(map[string]*big.Int)
+	now := time.Now().UTC()
+
+	if symbol == "BTC" {
+		backend, err := btc.NewBackend(appConfig.GetBitcoinConfig())
+		if err == nil {
+			utxos, err := backend.ListUnspent(ctx, addresses)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			var payments []Payment
+			var outpoints []string
+			for _, u := range utxos {
+				// 只记录查询的地址上的输出，后端返回的其他地址忽略
+				if !owned(u.Address) {
+					logging.Warnf("收款监控忽略不属于本钱包的输出 %s (%s)", u.Outpoint(), u.Address)
+					continue
+				}
+				total, ok := balances[u.Address]
+				if !ok {
+					total = new(big.Int)
+					balances[u.Address] = total
+				}
+				total.Add(total, big.NewInt(u.Value))
+				if store.seen(u.Outpoint()) {
+					continue
+				}
+				outpoints = append(outpoints, u.Outpoint())
+				payments = append(payments, Payment{
+					Coin: symbol, AccountID: accountID, Address: u.Address,
+					TxID: u.TxID, Vout: u.Vout, Amount: big.NewInt(u.Value).String(), Height: u.Height, DetectedAt: now,
+				})
+			}
+			return payments, outpoints, balances, nil
+		}
+		if !errors.Is(err, btc.ErrNotConfigured) {
+			return nil, nil, nil, err
+		}
+	}
+
+	client, err := chain.ForCoin(symbol, appConfig)
+	if errors.Is(err, chain.ErrNotConfigured) || errors.Is(err, chain.ErrUnknownBackend) {
+		if !w.skipped[symbol] {
+			w.skipped[symbol] = true
+			logging.Warnf("收款监控跳过 %s: %v", symbol, err)
+		}
+		return nil, nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var payments []Payment
+	for _, address := range addresses {
+		balance, err := client.Balance(ctx, address)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		balances[address] = balance
+		previous, ok := store.balance(address)
+		if !ok {
+			previous = new(big.Int)
+		}
+		if delta := new(big.Int).Sub(balance, previous); delta.Sign() > 0 {
+			payments = append(payments, Payment{
+				Coin: symbol, AccountID: accountID, Address: address,
+				Amount: delta.String(), DetectedAt: now,
+			})
+		}
+	}
+	return payments, nil, balances, nil
+}
+
+// match 把入账匹配到同一地址上金额不超过入账金额的最早的未支付请求
+func match(requests *payreq.Store, p *Payment) *payreq.Request {
+	amount, ok := new(big.Int).SetString(p.Amount, 10)
+	if !ok {
+		return nil
+	}
+	open := requests.List(true)
+	for i := len(open) - 1; i >= 0; i-- {
+		req := open[i]
+		if req.Address != p.Address {
+			continue
+		}
+		requested, ok := new(big.Int).SetString(req.Amount, 10)
+		if !ok || amount.Cmp(requested) < 0 {
+			continue
+		}
+		if err := requests.MarkPaid(req.ID, p.TxID); err != nil {
+			logging.Warnf("更新收款请求 %s 失败: %v", req.ID, err)
+			return nil
+		}
+		p.RequestID = req.ID
+		return req
+	}
+	return nil
+}
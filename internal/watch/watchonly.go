@@ -0,0 +1,238 @@
+package watch
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// WatchOnlyFileName 只读监控地址在数据目录中的文件名
+const WatchOnlyFileName = "watch_only.json"
+
+// ErrInvalidAddress 地址不符合币种的格式
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Entry 导入的外部地址：收款监控和余额查询会跟踪它，但本钱包没有它的私钥，不能花费
+type Entry struct {
+	Coin    string    `json:"coin"`
+	Address string    `json:"address"`
+	Label   string    `json:"label,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// WatchList 只读监控地址列表
+type WatchList struct {
+	mu      sync.Mutex
+	path    string
+	Entries []Entry `json:"entries"`
+}
+
+// LoadWatchList 加载只读监控地址，文件不存在时返回空列表
+func LoadWatchList(path string) (*WatchList, error) {
+	l := &WatchList{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("解码只读监控地址失败: %w", err)
+	}
+	return l, nil
+}
+
+// List 返回全部地址
+func (l *WatchList) List() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.Entries...)
+}
+
+// Lookup 返回地址的记录
+func (l *WatchList) Lookup(address string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range l.Entries {
+		if entry.Address == address {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Remove 删除地址，返回是否存在
+func (l *WatchList) Remove(address string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, entry := range l.Entries {
+		if strings.EqualFold(entry.Address, address) {
+			l.Entries = append(l.Entries[:i], l.Entries[i+1:]...)
+			return true, l.save()
+		}
+	}
+	return false, nil
+}
+
+// ImportResult 导入的结果
+type ImportResult struct {
+	Added      []Entry
+	Duplicates int      // 已在列表中或文件中重复出现
+	Owned      int      // 本钱包派生的地址，不需要只读监控
+	Invalid    []string // 无法导入的行及原因
+}
+
+// ImportCSV 从 CSV（coin,address[,label]，可以有标题行）导入地址。地址按币种校验并规范化，
+// 重复的和本钱包自己的地址跳过，无效的行记录原因后跳过，其余的一次性保存
+func (l *WatchList) ImportCSV(r io.Reader, isMine func(address string) bool) (*ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	known := make(map[string]bool, len(l.Entries))
+	for _, entry := range l.Entries {
+		known[entry.Coin+":"+entry.Address] = true
+	}
+
+	result := &ImportResult{}
+	now := time.Now().UTC()
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if line == 1 && len(record) >= 2 && strings.EqualFold(record[0], "coin") && strings.EqualFold(record[1], "address") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("line %d: expected coin,address[,label]", line))
+			continue
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(record[0]))
+		address, err := NormalizeAddress(symbol, strings.TrimSpace(record[1]))
+		if err != nil {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		if known[symbol+":"+address] {
+			result.Duplicates++
+			continue
+		}
+		if isMine != nil && isMine(address) {
+			result.Owned++
+			continue
+		}
+		known[symbol+":"+address] = true
+		entry := Entry{Coin: symbol, Address: address, AddedAt: now}
+		if len(record) == 3 {
+			entry.Label = strings.TrimSpace(record[2])
+		}
+		result.Added = append(result.Added, entry)
+	}
+
+	if len(result.Added) == 0 {
+		return result, nil
+	}
+	l.Entries = append(l.Entries, result.Added...)
+	if err := l.save(); err != nil {
+		l.Entries = l.Entries[:len(l.Entries)-len(result.Added)]
+		return nil, err
+	}
+	return result, nil
+}
+
+// addressesByCoin 按币种分组的地址
+func (l *WatchList) addressesByCoin() map[string][]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	groups := make(map[string][]string)
+	for _, entry := range l.Entries {
+		groups[entry.Coin] = append(groups[entry.Coin], entry.Address)
+	}
+	return groups
+}
+
+func (l *WatchList) save() error {
+	data, err := canonjson.MarshalIndent(l, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := l.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入只读监控地址失败: %w", err)
+	}
+	if err := os.Rename(tempFile, l.path); err != nil {
+		return fmt.Errorf("重命名只读监控地址失败: %w", err)
+	}
+	return nil
+}
+
+// NormalizeAddress 按币种校验地址并返回规范形式：ETH/BNB 为 EIP-55 校验和格式，
+// BTC 的 bech32 地址为小写；不支持的币种返回错误
+func NormalizeAddress(symbol, address string) (string, error) {
+	if _, ok := coin.LookupSymbol(symbol); !ok {
+		return "", fmt.Errorf("unknown coin %q", symbol)
+	}
+	switch symbol {
+	case "BTC":
+		if strings.HasPrefix(strings.ToLower(address), "bc1") {
+			address = strings.ToLower(address)
+		}
+		if _, err := btc.AddressScript(address); err != nil {
+			return "", fmt.Errorf("%w for BTC: %s", ErrInvalidAddress, address)
+		}
+		return address, nil
+	case "ETH", "BNB":
+		if !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x") {
+			return "", fmt.Errorf("%w for %s: %s", ErrInvalidAddress, symbol, address)
+		}
+		// 大小写混合的地址带有校验和，必须正确
+		normalized := common.HexToAddress(address).Hex()
+		if address != strings.ToLower(address) && address != "0x"+strings.ToUpper(address[2:]) && address != normalized {
+			return "", fmt.Errorf("%w for %s: bad EIP-55 checksum in %s", ErrInvalidAddress, symbol, address)
+		}
+		return normalized, nil
+	case "SOL":
+		if key, err := base58.Decode(address); err != nil || len(key) != 32 {
+			return "", fmt.Errorf("%w for SOL: %s", ErrInvalidAddress, address)
+		}
+		return address, nil
+	case "SUI":
+		raw := strings.ToLower(address)
+		if key, err := hex.DecodeString(strings.TrimPrefix(raw, "0x")); err != nil || len(key) != 32 || !strings.HasPrefix(raw, "0x") {
+			return "", fmt.Errorf("%w for SUI: %s", ErrInvalidAddress, address)
+		}
+		return raw, nil
+	default:
+		plugin, ok := core.LookupCoinPlugin(symbol)
+		if !ok {
+			return "", fmt.Errorf("watch-only addresses are not supported for %s", symbol)
+		}
+		normalized, err := plugin.NormalizeAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("%w for %s: %s", ErrInvalidAddress, symbol, address)
+		}
+		return normalized, nil
+	}
+}
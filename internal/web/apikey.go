@@ -0,0 +1,200 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// Scope API 密钥权限范围，按 read < derive < sign < admin 逐级包含
+type Scope string
+
+const (
+	ScopeRead   Scope = "read"   // 查询账户、地址、余额
+	ScopeDerive Scope = "derive" // 派生新地址、修改标记
+	ScopeSign   Scope = "sign"   // 签名（要求钱包已解锁）
+	ScopeAdmin  Scope = "admin"  // 解锁和锁定钱包，钱包锁定时也可用
+)
+
+// 错误定义
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrKeyNotFound   = errors.New("api key not found")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+const (
+	apiKeysFileName = "apikeys.json"
+	apiKeyPrefix    = "sm"
+)
+
+var scopeLevels = map[Scope]int{
+	ScopeRead:   1,
+	ScopeDerive: 2,
+	ScopeSign:   3,
+	ScopeAdmin:  4,
+}
+
+// ParseScope 解析权限范围字符串
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := scopeLevels[scope]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidScope, s)
+	}
+	return scope, nil
+}
+
+// APIKey 持久化的 API 密钥，只保存密钥摘要
+type APIKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Scope      Scope  `json:"scope"`
+	User       string `json:"user,omitempty"` // 绑定的用户，为空时访问默认命名空间
+	SecretHash string `json:"secret_hash"`
+	CreatedAt  int64  `json:"created_at"`
+	Revoked    bool   `json:"revoked"`
+}
+
+// Allows 判断密钥是否拥有所需权限
+func (k *APIKey) Allows(required Scope) bool {
+	return !k.Revoked && scopeLevels[k.Scope] >= scopeLevels[required]
+}
+
+// KeyStore 基于文件的 API 密钥存储
+type KeyStore struct {
+	path  string
+	mutex sync.RWMutex
+}
+
+// NewKeyStore 创建存储目录下的密钥存储
+func NewKeyStore(baseDir string) *KeyStore {
+	return &KeyStore{path: filepath.Join(baseDir, apiKeysFileName)}
+}
+
+// Create 创建新密钥，user 不为空时密钥只能访问该用户的命名空间；返回的明文令牌只在此时可见
+func (ks *KeyStore) Create(name string, scope Scope, user string) (*APIKey, string, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	keys, err := ks.load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	idBytes := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	key := &APIKey{
+		ID:         hex.EncodeToString(idBytes),
+		Name:       name,
+		Scope:      scope,
+		User:       user,
+		SecretHash: hashSecret(hex.EncodeToString(secret)),
+		CreatedAt:  time.Now().Unix(),
+	}
+	keys = append(keys, key)
+	if err := ks.save(keys); err != nil {
+		return nil, "", err
+	}
+
+	token := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, key.ID, hex.EncodeToString(secret))
+	return key, token, nil
+}
+
+// List 列出所有密钥
+func (ks *KeyStore) List() ([]*APIKey, error) {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	return ks.load()
+}
+
+// Revoke 吊销密钥
+func (ks *KeyStore) Revoke(id string) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	keys, err := ks.load()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.ID == id {
+			key.Revoked = true
+			return ks.save(keys)
+		}
+	}
+	return ErrKeyNotFound
+}
+
+// Authenticate 校验令牌并返回对应密钥
+func (ks *KeyStore) Authenticate(token string) (*APIKey, error) {
+	parts := strings.Split(token, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrInvalidAPIKey
+	}
+
+	keys, err := ks.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.ID != parts[1] {
+			continue
+		}
+		if key.Revoked || subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(parts[2]))) != 1 {
+			return nil, ErrInvalidAPIKey
+		}
+		return key, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func (ks *KeyStore) load() ([]*APIKey, error) {
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*APIKey{}, nil
+		}
+		return nil, err
+	}
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("解码 API 密钥失败: %w", err)
+	}
+	return keys, nil
+}
+
+func (ks *KeyStore) save(keys []*APIKey) error {
+	data, err := canonjson.MarshalIndent(keys, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := ks.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入 API 密钥失败: %w", err)
+	}
+	return os.Rename(tempFile, ks.path)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,236 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/approval"
+	"github.com/palagend/slowmade/internal/signer"
+	"go.uber.org/zap"
+)
+
+// approvalQueue 默认（团队）钱包的签名审批队列
+type approvalQueue struct {
+	store    *approval.Store
+	signer   *signer.Signer
+	required int
+	ttl      time.Duration
+	chainID  *big.Int
+}
+
+type createApprovalRequest struct {
+	Tx   *signer.TransactionArgs `json:"tx"`
+	Note string                  `json:"note"`
+}
+
+type decideApprovalRequest struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// Approvals 启用默认钱包的签名审批队列：requester 提交交易，其他 approver 批准的数量达到 required 后
+// 才用 sign 签名；ttl 内未完成审批的请求过期
+func (s *Server) Approvals(store *approval.Store, sign *signer.Signer, required int, ttl time.Duration, chainID *big.Int) *Server {
+	s.approvals = &approvalQueue{store: store, signer: sign, required: required, ttl: ttl, chainID: chainID}
+	return s
+}
+
+// approvalsHandler GET 列出请求（?status= 过滤，?id= 查看单个），POST 提交新请求
+func (s *Server) approvalsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.approvalUser(w, r)
+	if !ok {
+		return
+	}
+	s.sweepApprovals()
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := r.URL.Query().Get("id"); id != "" {
+			req, err := s.approvals.store.Get(id)
+			if err != nil {
+				writeApprovalError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, req)
+			return
+		}
+		requests := s.approvals.store.List(r.URL.Query().Get("status"))
+		if requests == nil {
+			requests = []*approval.Request{}
+		}
+		writeJSON(w, http.StatusOK, requests)
+	case http.MethodPost:
+		if !user.HasRole(RoleRequester) {
+			s.audit("user:"+user.Name, r, "denied: not a requester")
+			writeError(w, http.StatusForbidden, "requester role required")
+			return
+		}
+		var body createApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Tx == nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		// 提交时就校验交易参数，避免批准后才发现无法签名
+		if _, err := body.Tx.ToTransaction(s.approvals.chainID); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req, err := s.approvals.store.Create(user.Name, body.Tx, body.Note, s.approvals.required, s.approvals.ttl)
+		if err != nil {
+			writeApprovalError(w, err)
+			return
+		}
+		s.recordApproval("user:"+user.Name, "approval.create", req, "ok")
+		writeJSON(w, http.StatusCreated, req)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// approveHandler 批准请求，批准数达到要求且钱包已解锁时立即签名
+func (s *Server) approveHandler(w http.ResponseWriter, r *http.Request) {
+	user, body, ok := s.approvalDecision(w, r)
+	if !ok {
+		return
+	}
+	if !user.HasRole(RoleApprover) {
+		s.audit("user:"+user.Name, r, "denied: not an approver")
+		writeError(w, http.StatusForbidden, "approver role required")
+		return
+	}
+	req, err := s.approvals.store.Approve(body.ID, user.Name)
+	if err != nil {
+		s.recordApproval("user:"+user.Name, "approval.approve", &approval.Request{ID: body.ID}, "denied: "+err.Error())
+		writeApprovalError(w, err)
+		return
+	}
+	s.recordApproval("user:"+user.Name, "approval.approve", req, "ok")
+	s.sweepApprovals()
+	if req, err = s.approvals.store.Get(body.ID); err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+// rejectHandler 审批者拒绝请求，或请求者撤回自己的请求
+func (s *Server) rejectHandler(w http.ResponseWriter, r *http.Request) {
+	user, body, ok := s.approvalDecision(w, r)
+	if !ok {
+		return
+	}
+	if !user.HasRole(RoleApprover) {
+		req, err := s.approvals.store.Get(body.ID)
+		if err != nil {
+			writeApprovalError(w, err)
+			return
+		}
+		if req.Requester != user.Name {
+			s.audit("user:"+user.Name, r, "denied: not an approver")
+			writeError(w, http.StatusForbidden, "approver role required")
+			return
+		}
+	}
+	req, err := s.approvals.store.Reject(body.ID, user.Name, body.Reason)
+	if err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	s.recordApproval("user:"+user.Name, "approval.reject", req, "ok")
+	writeJSON(w, http.StatusOK, req)
+}
+
+// approvalDecision 批准和拒绝共用的前置检查
+func (s *Server) approvalDecision(w http.ResponseWriter, r *http.Request) (*User, *decideApprovalRequest, bool) {
+	user, ok := s.approvalUser(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	s.sweepApprovals()
+	var body decideApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID == "" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return nil, nil, false
+	}
+	return user, &body, true
+}
+
+// approvalUser 审批接口只接受绑定了用户且拥有审批角色的密钥，每个人用自己的密钥操作
+func (s *Server) approvalUser(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	if s.approvals == nil || s.users == nil {
+		writeError(w, http.StatusServiceUnavailable, "approval queue not configured")
+		return nil, false
+	}
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok || key.User == "" {
+		writeError(w, http.StatusForbidden, "approval queue requires a user-bound api key")
+		return nil, false
+	}
+	user, err := s.users.Get(key.User)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return nil, false
+	}
+	if !user.HasRole(RoleRequester) && !user.HasRole(RoleApprover) {
+		writeError(w, http.StatusForbidden, "requester or approver role required")
+		return nil, false
+	}
+	return user, true
+}
+
+// sweepApprovals 标记过期的请求，并在默认钱包已解锁时签名已获批准的请求；
+// 钱包锁定期间批准的请求保持 approved，解锁后的下一次审批操作时签名
+func (s *Server) sweepApprovals() {
+	expired, err := s.approvals.store.Expire(time.Now())
+	if err != nil {
+		s.logger.Warn("Failed to expire approval requests", zap.Error(err))
+	}
+	for _, req := range expired {
+		s.recordApproval("system", "approval.expire", req, "ok")
+	}
+
+	if s.root.WalletMgr == nil || s.root.WalletMgr.IsLocked() {
+		return
+	}
+	for _, req := range s.approvals.store.List(approval.StatusApproved) {
+		raw, signErr := s.approvals.signer.For("approval:"+req.ID, s.approvals.chainID).SignTransaction(req.Tx)
+		rawTx := ""
+		if signErr == nil {
+			rawTx = hexutil.Encode(raw)
+		}
+		if _, err := s.approvals.store.Finish(req.ID, rawTx, signErr); err != nil {
+			s.logger.Warn("Failed to record signed approval request", zap.Error(err))
+			continue
+		}
+		result := "ok"
+		if signErr != nil {
+			result = "error: " + signErr.Error()
+		}
+		s.recordApproval("system", "approval.sign", req, result)
+	}
+}
+
+// recordApproval 审批队列的每次状态变化都写入审计日志
+func (s *Server) recordApproval(actor, action string, req *approval.Request, result string) {
+	if s.auditLog == nil {
+		return
+	}
+	if err := s.auditLog.Record(actor, action, req.ID, result); err != nil {
+		s.logger.Warn("Failed to write audit log", zap.Error(err))
+	}
+}
+
+func writeApprovalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, approval.ErrRequestNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, approval.ErrNotPending), errors.Is(err, approval.ErrSelfApproval), errors.Is(err, approval.ErrAlreadyApproved):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,10 +1,16 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -12,20 +18,33 @@ import (
 func (s *Server) LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		route := s.routeLabel(r)
+		// 调用方带有 traceparent 头时接上它的 trace
+		ctx := tracing.Extract(r.Context(), r.Header.Get("traceparent"))
+		ctx, span := tracing.Root(ctx, tracing.Server, r.Method+" "+route, "http.method", r.Method, "http.route", route)
 
 		// 包装 ResponseWriter 来捕获状态码
-		wrappedWriter := &responseWriter{w, http.StatusOK}
+		wrappedWriter := &responseWriter{ResponseWriter: w, status: http.StatusOK}
 
-		next.ServeHTTP(wrappedWriter, r)
+		next.ServeHTTP(wrappedWriter, r.WithContext(ctx))
 
 		duration := time.Since(start)
+		span.Set("http.status_code", strconv.Itoa(wrappedWriter.status))
+		if wrappedWriter.status >= http.StatusInternalServerError {
+			span.End(errors.New(http.StatusText(wrappedWriter.status)))
+		} else {
+			span.End(nil)
+		}
+		metrics.Inc(metrics.HTTPRequests, "path", route, "status", strconv.Itoa(wrappedWriter.status))
 		s.logger.Info("HTTP request",
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
 			zap.String("remote_addr", r.RemoteAddr),
 			zap.Int("status", wrappedWriter.status),
 			zap.Duration("duration", duration),
-			zap.String("user_agent", r.UserAgent()))
+			zap.String("api_key", wrappedWriter.apiKey),
+			zap.String("user_agent", r.UserAgent()),
+			zap.String("trace_id", span.TraceID()))
 	})
 }
 
@@ -63,13 +82,117 @@ func (s *Server) RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AuthMiddleware API 密钥鉴权中间件，按路由所需权限校验密钥
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required, scoped := s.routeScope(r)
+		if !scoped {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.keys == nil {
+			writeError(w, http.StatusServiceUnavailable, "api keys not configured")
+			return
+		}
+
+		key, err := s.keys.Authenticate(extractToken(r))
+		if err != nil {
+			s.audit("anonymous", r, "denied: "+err.Error())
+			writeError(w, http.StatusUnauthorized, "invalid or missing api key")
+			return
+		}
+
+		actor := "apikey:" + key.ID
+		if key.User != "" {
+			actor = "user:" + key.User + "/" + actor
+		}
+		if rw, ok := w.(*responseWriter); ok {
+			rw.apiKey = key.ID
+		}
+
+		// 绑定用户的密钥只能访问该用户的命名空间，用户被停用时拒绝
+		tenant := s.root
+		if key.User != "" {
+			if s.users == nil {
+				s.audit(actor, r, "denied: multi-user mode not configured")
+				writeError(w, http.StatusServiceUnavailable, "multi-user mode not configured")
+				return
+			}
+			if tenant, err = s.userTenant(key.User); err != nil {
+				s.audit(actor, r, "denied: "+err.Error())
+				if errors.Is(err, ErrUserDisabled) || errors.Is(err, ErrUserNotFound) {
+					writeError(w, http.StatusForbidden, err.Error())
+				} else {
+					writeError(w, http.StatusInternalServerError, err.Error())
+				}
+				return
+			}
+		}
+
+		if !key.Allows(required) {
+			s.audit(actor, r, "denied: scope "+string(key.Scope))
+			writeError(w, http.StatusForbidden, fmt.Sprintf("api key scope %q does not allow %q", key.Scope, required))
+			return
+		}
+
+		// 签名权限额外要求钱包处于解锁状态
+		if required == ScopeSign && (tenant.WalletMgr == nil || tenant.WalletMgr.IsLocked()) {
+			s.audit(actor, r, "denied: wallet locked")
+			writeError(w, http.StatusLocked, "wallet is locked")
+			return
+		}
+
+		s.audit(actor, r, "ok")
+		ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, tenantContextKey, tenant)))
+	})
+}
+
+// audit 记录按密钥归属的审计日志
+func (s *Server) audit(actor string, r *http.Request, result string) {
+	if s.auditLog == nil {
+		return
+	}
+	if err := s.auditLog.Record(actor, "http "+r.Method, r.URL.Path, result); err != nil {
+		s.logger.Warn("Failed to write audit log", zap.Error(err))
+	}
+}
+
+// extractToken 从 Authorization: Bearer 或 X-API-Key 头中提取令牌
+func extractToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+type contextKey int
+
+const (
+	apiKeyContextKey contextKey = iota
+	tenantContextKey
+)
+
+// APIKeyFromContext 返回已通过鉴权的 API 密钥
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey).(*APIKey)
+	return key, ok
+}
+
 // responseWriter 包装 http.ResponseWriter 来捕获状态码
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	apiKey string // 鉴权通过的密钥 ID，供日志归属
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap 让 http.ResponseController 能找到底层连接，流式接口需要 Flush 和延长写超时
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,204 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/palagend/slowmade/internal/chain"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/nft"
+	"github.com/palagend/slowmade/internal/signer"
+)
+
+// nftView 代币的对外表示，代币 ID 和数量可能超出 JSON 数字的精度，以十进制字符串表示
+type nftView struct {
+	Contract    string `json:"contract"`
+	TokenID     string `json:"token_id"`
+	Standard    string `json:"standard"`
+	Balance     string `json:"balance"`
+	Collection  string `json:"collection,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	URI         string `json:"uri,omitempty"`
+}
+
+func newNFTView(token *nft.Token) nftView {
+	v := nftView{Contract: token.Contract, TokenID: token.TokenID.String(), Standard: string(token.Standard),
+		Balance: token.Balance.String(), Collection: token.Collection, URI: token.URI}
+	if token.Metadata != nil {
+		v.Name, v.Description, v.Image = token.Metadata.Name, token.Metadata.Description, token.Metadata.Image
+	}
+	return v
+}
+
+// nftTransferRequest 提交 NFT 转账审批：tx 给出 from、nonce、gas、手续费和 chainId，to 和 data 由服务端生成
+type nftTransferRequest struct {
+	Tx       *signer.TransactionArgs `json:"tx"`
+	Contract string                  `json:"contract"`
+	TokenID  string                  `json:"token_id"`
+	To       string                  `json:"to"`
+	Amount   string                  `json:"amount"` // ERC-1155 的数量，默认 1
+	Note     string                  `json:"note"`
+}
+
+// nftsHandler 列出本命名空间钱包中一个 ETH 地址持有的 NFT，?metadata=false 时不读取元数据
+func (s *Server) nftsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, "missing address parameter")
+		return
+	}
+	if addr, ok := tenant.AccountMgr.IsMine(address); !ok || addr.CoinSymbol != "ETH" {
+		writeError(w, http.StatusNotFound, "not an ETH address of this wallet")
+		return
+	}
+	appConfig := config.GetAppConfig()
+	source, err := chain.NewNFTSource(appConfig)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	var fetcher *nft.Fetcher
+	if nftConfig := appConfig.GetNFTConfig(); nftConfig.FetchMetadata && r.URL.Query().Get("metadata") != "false" {
+		fetcher = nft.NewFetcher(nftConfig.IPFSGateway)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	transfers, err := source.NFTTransfers(ctx, address)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	tokens, err := nft.Inventory(ctx, source, address, nft.Candidates(address, transfers), fetcher)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	views := make([]nftView, 0, len(tokens))
+	for _, token := range tokens {
+		views = append(views, newNFTView(token))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// nftTransferHandler 核对发送方持有代币后，把 safeTransferFrom 交易提交到签名审批队列；
+// 审批说明中写明集合和代币 ID，审批者据此确认
+func (s *Server) nftTransferHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.approvalUser(w, r)
+	if !ok {
+		return
+	}
+	if !user.HasRole(RoleRequester) {
+		s.audit("user:"+user.Name, r, "denied: not a requester")
+		writeError(w, http.StatusForbidden, "requester role required")
+		return
+	}
+	var body nftTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Tx == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !common.IsHexAddress(body.Contract) || !common.IsHexAddress(body.To) {
+		writeError(w, http.StatusBadRequest, "contract and to must be ETH addresses")
+		return
+	}
+	to := common.HexToAddress(body.To)
+	if to == (common.Address{}) {
+		writeError(w, http.StatusBadRequest, "refusing to send to the zero address")
+		return
+	}
+	id, err := nft.ParseTokenID(body.TokenID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	amount := big.NewInt(1)
+	if body.Amount != "" {
+		if amount, ok = new(big.Int).SetString(body.Amount, 10); !ok || amount.Sign() <= 0 {
+			writeError(w, http.StatusBadRequest, "amount must be a positive integer")
+			return
+		}
+	}
+	from := body.Tx.From
+	if addr, ok := s.root.AccountMgr.IsMine(from.Hex()); !ok || addr.CoinSymbol != "ETH" {
+		writeError(w, http.StatusBadRequest, "tx.from is not an ETH address of the shared wallet")
+		return
+	}
+
+	appConfig := config.GetAppConfig()
+	source, err := chain.NewNFTSource(appConfig)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	contract := common.HexToAddress(body.Contract)
+	standard, err := nft.Detect(ctx, source, contract.Hex())
+	if err != nil {
+		writeNFTError(w, err)
+		return
+	}
+	data, err := nft.TransferData(standard, from, to, id, amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	balance, err := nft.Balance(ctx, source, standard, contract.Hex(), id, from.Hex())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if balance.Cmp(amount) < 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%v: %s holds %s of token %s", nft.ErrNotOwner, from.Hex(), balance, id))
+		return
+	}
+	input := hexutil.Bytes(data)
+	body.Tx.To, body.Tx.Data, body.Tx.Input, body.Tx.Value = &contract, &input, nil, nil
+	if _, err := body.Tx.ToTransaction(s.approvals.chainID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	collection := contract.Hex()
+	if name := nft.CollectionName(ctx, source, contract.Hex()); name != "" {
+		collection = fmt.Sprintf("%s (%s)", name, contract.Hex())
+	}
+	note := fmt.Sprintf("NFT transfer: %s token %s of %s to %s", standard, id, collection, to.Hex())
+	if standard == nft.ERC1155 {
+		note = fmt.Sprintf("NFT transfer: %s x%s of token %s of %s to %s", standard, amount, id, collection, to.Hex())
+	}
+	if body.Note != "" {
+		note += "; " + body.Note
+	}
+	s.sweepApprovals()
+	req, err := s.approvals.store.Create(user.Name, body.Tx, note, s.approvals.required, s.approvals.ttl)
+	if err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	s.recordApproval("user:"+user.Name, "approval.create", req, "ok")
+	writeJSON(w, http.StatusCreated, req)
+}
+
+func writeNFTError(w http.ResponseWriter, err error) {
+	if errors.Is(err, nft.ErrNotNFT) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadGateway, err.Error())
+}
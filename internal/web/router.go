@@ -0,0 +1,99 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group 共享路径前缀、中间件和权限的一组路由。路径支持 {name} 参数（http.ServeMux 的模式语法），
+// 处理函数通过 r.PathValue("name") 读取；{name...} 匹配剩余路径，末尾的 {$} 只匹配路径本身
+type Group struct {
+	server      *Server
+	prefix      string
+	middlewares []Middleware
+	scope       Scope // 非空时组内路由需要该权限的 API 密钥
+}
+
+// Handle 注册路由，method 为空时匹配所有方法。与已注册的路由冲突时 panic（同 http.ServeMux）
+func (s *Server) Handle(method, path string, handler http.Handler) {
+	s.routes.Handle(method, path, handler)
+}
+
+// HandleFunc 以函数注册路由
+func (s *Server) HandleFunc(method, path string, handler http.HandlerFunc) {
+	s.routes.Handle(method, path, handler)
+}
+
+// Group 创建带路径前缀和中间件的路由组
+func (s *Server) Group(prefix string, middlewares ...Middleware) *Group {
+	return s.routes.Group(prefix, middlewares...)
+}
+
+// Group 创建子路由组，继承前缀、中间件和权限
+func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	child := &Group{
+		server:      g.server,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(append([]Middleware(nil), g.middlewares...), middlewares...),
+		scope:       g.scope,
+	}
+	return child
+}
+
+// Use 添加组内中间件，只影响之后注册的路由
+func (g *Group) Use(middleware Middleware) *Group {
+	g.middlewares = append(g.middlewares, middleware)
+	return g
+}
+
+// Scoped 创建需要指定权限的子路由组，由 AuthMiddleware 校验 API 密钥
+func (g *Group) Scoped(scope Scope) *Group {
+	child := g.Group("")
+	child.scope = scope
+	return child
+}
+
+// Handle 注册路由，组中间件按添加顺序由外到内包裹处理函数，在全局中间件之后执行
+func (g *Group) Handle(method, path string, handler http.Handler) {
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		handler = g.middlewares[i](handler)
+	}
+	pattern := g.prefix + path
+	if method != "" {
+		pattern = method + " " + pattern
+	}
+	g.server.mux.Handle(pattern, handler)
+	if g.scope != "" {
+		g.server.routeScopes[pattern] = g.scope
+	}
+}
+
+// HandleFunc 以函数注册路由
+func (g *Group) HandleFunc(method, path string, handler http.HandlerFunc) {
+	g.Handle(method, path, handler)
+}
+
+// route 请求匹配到的路由模式，未匹配时为空
+func (s *Server) route(r *http.Request) string {
+	_, pattern := s.mux.Handler(r)
+	return pattern
+}
+
+// routeScope 请求的路由所需的权限
+func (s *Server) routeScope(r *http.Request) (Scope, bool) {
+	scope, ok := s.routeScopes[s.route(r)]
+	return scope, ok
+}
+
+// routeLabel 请求计数器的 path 标签：匹配到的路由模式（不含方法），路径参数不展开，
+// 未注册的路径统一为 other，避免任意路径产生新的标签值
+func (s *Server) routeLabel(r *http.Request) string {
+	pattern := s.route(r)
+	if pattern == "" {
+		return "other"
+	}
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+	return strings.TrimSuffix(pattern, "{$}")
+}
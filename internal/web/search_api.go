@@ -0,0 +1,52 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/palagend/slowmade/internal/search"
+)
+
+func (s *Server) findHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit parameter")
+			return
+		}
+		limit = n
+	}
+
+	tag, ok := tagParam(w, r)
+	if !ok {
+		return
+	}
+	index, err := tenant.searchIndex()
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	results, err := index.FindTagged(query, tag, limit)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeManagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
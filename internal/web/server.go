@@ -6,10 +6,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/palagend/slowmade/internal/audit"
 	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/version"
+	"github.com/palagend/slowmade/internal/view"
 	"github.com/palagend/slowmade/pkg/logging"
 	"go.uber.org/zap"
 )
@@ -17,9 +24,22 @@ import (
 // Server 表示 Web 服务器实例
 type Server struct {
 	config      *config.WebConfig
-	httpServer  *http.ServeMux
+	mux         *http.ServeMux
+	routes      *Group // 根路由组，无前缀和中间件
 	logger      *zap.Logger
 	middlewares []Middleware
+	root        *Tenant // 默认命名空间，未绑定用户的 API 密钥使用
+	keys        *KeyStore
+	auditLog    *audit.Logger
+	routeScopes map[string]Scope // 需要鉴权的路由模式及其所需权限
+
+	users      *UserStore // 为空时不支持绑定用户的 API 密钥
+	openTenant TenantOpener
+	tenantsMu  sync.Mutex
+	tenants    map[string]*Tenant
+
+	approvals *approvalQueue // 为空时不提供签名审批接口
+	shares    *ShareStore    // 为空时不提供分享链接
 }
 
 // Middleware 定义中间件函数类型
@@ -28,12 +48,43 @@ type Middleware func(http.Handler) http.Handler
 // NewServer 创建新的 Web 服务器实例
 func NewServer() *Server {
 	webConfig := config.GetAppConfig().Web
-	return &Server{
+	s := &Server{
 		config:      &webConfig,
-		httpServer:  http.NewServeMux(),
+		mux:         http.NewServeMux(),
 		logger:      logging.Get(),
 		middlewares: make([]Middleware, 0),
+		root:        &Tenant{},
+		routeScopes: make(map[string]Scope),
+		tenants:     make(map[string]*Tenant),
 	}
+	s.routes = &Group{server: s}
+	return s
+}
+
+// Wallet 设置默认命名空间的钱包管理器，解锁密码保存在全局的密码管理器中
+func (s *Server) Wallet(walletMgr core.WalletManager, accountMgr core.AccountManager) *Server {
+	s.root.WalletMgr = walletMgr
+	s.root.AccountMgr = accountMgr
+	s.root.Passwords = security.GetPasswordManager()
+	return s
+}
+
+// Keys 设置 API 密钥存储，启用后钱包 API 需要鉴权
+func (s *Server) Keys(keys *KeyStore) *Server {
+	s.keys = keys
+	return s
+}
+
+// Audit 设置审计日志，鉴权通过的请求会按密钥记录
+func (s *Server) Audit(logger *audit.Logger) *Server {
+	s.auditLog = logger
+	return s
+}
+
+// DataDir 设置默认命名空间的数据目录，查找接口从中读取元数据
+func (s *Server) DataDir(dir string) *Server {
+	s.root.DataDir = dir
+	return s
 }
 
 // Host 设置服务器主机
@@ -54,6 +105,11 @@ func (s *Server) Mode(mode string) *Server {
 	return s
 }
 
+// Addr 返回监听地址
+func (s *Server) Addr() string {
+	return fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+}
+
 // Use 添加中间件
 func (s *Server) Use(middleware Middleware) *Server {
 	s.middlewares = append(s.middlewares, middleware)
@@ -66,10 +122,10 @@ func (s *Server) Start() error {
 	s.setupRoutes()
 
 	// 应用中间件
-	handler := s.applyMiddlewares(s.httpServer)
+	handler := s.applyMiddlewares(s.mux)
 
 	// 创建 HTTP 服务器
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	addr := s.Addr()
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      handler,
@@ -110,13 +166,59 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// setupRoutes 设置路由处理
+// setupRoutes 注册内置路由，嵌入方在 Start 之前通过 Handle 和 Group 注册的路由一并生效
 func (s *Server) setupRoutes() {
 	// 健康检查端点
-	s.httpServer.HandleFunc("/health", s.healthHandler)
-	s.httpServer.HandleFunc("/api/v1/status", s.statusHandler)
-	s.httpServer.HandleFunc("/api/v1/info", s.infoHandler)
-	s.httpServer.HandleFunc("/", s.indexHandler)
+	s.HandleFunc(http.MethodGet, "/health", s.healthHandler)
+	s.HandleFunc(http.MethodGet, "/{$}", s.indexHandler)
+
+	api := s.Group("/api/v1")
+	api.HandleFunc(http.MethodGet, "/status", s.statusHandler)
+	api.HandleFunc("", "/info", s.infoHandler)
+
+	// 钱包 API（需要 API 密钥）
+	read, derive, admin := api.Scoped(ScopeRead), api.Scoped(ScopeDerive), api.Scoped(ScopeAdmin)
+	read.HandleFunc(http.MethodGet, "/accounts", s.accountsHandler)
+	read.HandleFunc(http.MethodGet, "/addresses", s.addressesHandler)
+	derive.HandleFunc(http.MethodPost, "/addresses/derive", s.deriveAddressHandler)
+	derive.HandleFunc(http.MethodPost, "/addresses/stream", s.streamAddressesHandler)
+	read.HandleFunc(http.MethodGet, "/find", s.findHandler)
+	read.HandleFunc(http.MethodGet, "/sync", s.syncHandler)
+	read.HandleFunc(http.MethodGet, "/tags", s.tagsHandler)
+	derive.HandleFunc(http.MethodPost, "/tags", s.tagHandler)
+	read.HandleFunc(http.MethodGet, "/wallet/status", s.walletStatusHandler)
+	read.HandleFunc(http.MethodGet, "/stats", s.walletStatsHandler)
+	admin.HandleFunc(http.MethodPost, "/wallet/unlock", s.unlockHandler)
+	admin.HandleFunc(http.MethodPost, "/wallet/lock", s.lockHandler)
+	read.HandleFunc(http.MethodGet, "/nfts", s.nftsHandler)
+	read.HandleFunc(http.MethodGet, "/validate", s.validateHandler)
+
+	// 默认钱包的签名审批队列，按用户角色授权
+	approvals := read.Group("/approvals")
+	approvals.HandleFunc(http.MethodGet, "", s.approvalsHandler)
+	approvals.HandleFunc(http.MethodPost, "", s.approvalsHandler)
+	approvals.HandleFunc(http.MethodPost, "/approve", s.approveHandler)
+	approvals.HandleFunc(http.MethodPost, "/reject", s.rejectHandler)
+	approvals.HandleFunc(http.MethodPost, "/nft-transfer", s.nftTransferHandler)
+
+	// 只读分享链接，凭链接密钥和访问密码查看默认钱包一个账户的收款地址，不需要 API 密钥
+	s.HandleFunc(http.MethodGet, "/share/{id}", s.shareHandler)
+	s.HandleFunc(http.MethodPost, "/share/{id}", s.shareHandler)
+
+	// Prometheus 文本格式的活动计数器
+	s.Group("").Scoped(ScopeRead).HandleFunc(http.MethodGet, "/metrics", s.metricsHandler)
+}
+
+// metricsHandler 导出全进程的计数器，只对未绑定用户的密钥开放，命名空间用户看不到其他人的活动
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if key, ok := APIKeyFromContext(r.Context()); ok && key.User != "" {
+		writeError(w, http.StatusForbidden, "metrics are only available to operator api keys")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WriteText(w); err != nil {
+		s.logger.Warn("Failed to write metrics", zap.Error(err))
+	}
 }
 
 // applyMiddlewares 应用中间件栈
@@ -129,53 +231,58 @@ func (s *Server) applyMiddlewares(handler http.Handler) http.Handler {
 
 // 路由处理函数
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"status": "healthy", "timestamp": "%s", "service": "slowmade"}`,
-		time.Now().Format(time.RFC3339))
+		view.Timestamp(time.Now()))
 }
 
 func (s *Server) statusHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{
-        "status": "ok",
-        "version": "1.0.0",
-        "timestamp": "%s",
-        "service": "slowmade",
-        "mode": "%s"
-    }`, time.Now().Format(time.RFC3339), s.config.Mode)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "ok",
+		"version":   version.Get().GitVersion,
+		"build":     version.Get(),
+		"timestamp": view.Timestamp(time.Now()),
+		"service":   "slowmade",
+		"mode":      s.config.Mode,
+	})
 }
 
 func (s *Server) infoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{
         "name": "Slowmade Web Server",
-        "version": "1.0.0",
+        "version": "%s",
         "description": "A secure cryptocurrency wallet service",
         "endpoints": [
             {"path": "/health", "method": "GET", "description": "Health check"},
             {"path": "/api/v1/status", "method": "GET", "description": "Service status"},
-            {"path": "/api/v1/info", "method": "GET", "description": "Service information"}
+            {"path": "/api/v1/info", "method": "GET", "description": "Service information"},
+            {"path": "/api/v1/accounts", "method": "GET", "scope": "read", "description": "List accounts by coin, optionally only those with a tag; ?sort=index (default), created or label"},
+            {"path": "/api/v1/addresses", "method": "GET", "scope": "read", "description": "List addresses of an account, optionally only those with a tag; ?sort=index (default), created or label"},
+            {"path": "/api/v1/addresses/derive", "method": "POST", "scope": "derive", "description": "Derive a new address"},
+            {"path": "/api/v1/find", "method": "GET", "scope": "read", "description": "Search accounts, addresses, labels, tags and contacts"},
+            {"path": "/api/v1/tags", "method": "GET", "scope": "read", "description": "Tag tree with counts, or what is tagged with ?tag= or a tag below it"},
+            {"path": "/api/v1/tags", "method": "POST", "scope": "derive", "description": "Add or remove tags of an account or address"},
+            {"path": "/api/v1/sync", "method": "GET", "scope": "read", "description": "Stream all accounts, addresses and transactions as NDJSON change events for an initial index load"},
+            {"path": "/api/v1/wallet/status", "method": "GET", "scope": "read", "description": "Whether the wallet of the key's namespace is unlocked"},
+            {"path": "/api/v1/stats", "method": "GET", "scope": "read", "description": "Accounts per coin, addresses per account, storage size, last backup and unlock times and KDF parameters of the wallet"},
+            {"path": "/api/v1/wallet/unlock", "method": "POST", "scope": "admin", "description": "Unlock the wallet of the key's namespace with its password; wrong passwords back off and lock out (429 with Retry-After)"},
+            {"path": "/api/v1/wallet/lock", "method": "POST", "scope": "admin", "description": "Lock the wallet of the key's namespace"},
+            {"path": "/api/v1/nfts", "method": "GET", "scope": "read", "description": "NFTs held by an ETH address of the wallet, with name and image from their metadata"},
+            {"path": "/api/v1/validate", "method": "GET", "scope": "read", "description": "Check ?address= for ?coin=: checksum, network and address type; ?onchain=true also asks the node whether an ETH or BNB address is a contract"},
+            {"path": "/api/v1/approvals", "method": "GET", "role": "requester|approver", "description": "List signing requests of the shared wallet"},
+            {"path": "/api/v1/approvals", "method": "POST", "role": "requester", "description": "Submit a transaction for approval"},
+            {"path": "/api/v1/approvals/approve", "method": "POST", "role": "approver", "description": "Approve a pending request, it is signed once enough approvers agree"},
+            {"path": "/api/v1/approvals/reject", "method": "POST", "role": "approver", "description": "Reject a pending request, or withdraw your own"},
+            {"path": "/api/v1/approvals/nft-transfer", "method": "POST", "role": "requester", "description": "Submit an ERC-721/1155 safeTransferFrom for approval, naming the collection and token ID"},
+            {"path": "/share/{id}", "method": "GET|POST", "description": "Password-protected page with the receive addresses of one account, from a link created with share.create"},
+            {"path": "/metrics", "method": "GET", "scope": "read", "description": "Activity counters in Prometheus text format (operator keys only)"}
         ]
-    }`)
+    }`, version.Get().GitVersion)
 }
 
 func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
-
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	fmt.Fprintf(w, `
 <!DOCTYPE html>
@@ -188,7 +295,7 @@ func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
         * { margin: 0; padding: 0; box-sizing: border-box; }
         body { 
             font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; 
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%);
             min-height: 100vh;
             display: flex;
             align-items: center;
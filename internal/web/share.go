@@ -0,0 +1,217 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+	"github.com/palagend/slowmade/pkg/crypto"
+)
+
+// 错误定义
+var (
+	ErrShareNotFound = errors.New("share link not found")
+	ErrShareInvalid  = errors.New("share link is invalid, expired or revoked")
+	ErrShareLimited  = errors.New("too many wrong passwords, try again later")
+	ErrSharePassword = errors.New("wrong password")
+)
+
+const (
+	sharesFileName = "shares.json"
+
+	// 同一链接在 shareLockout 内输错 shareMaxFailures 次密码后暂停校验，避免在线猜测
+	shareMaxFailures = 5
+	shareLockout     = 15 * time.Minute
+)
+
+// Share 持久化的分享链接：只保存链接密钥的摘要和访问密码的 argon2id 哈希
+type Share struct {
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+	AccountID    string `json:"account_id"`
+	Coin         string `json:"coin"`
+	SecretHash   string `json:"secret_hash"`
+	PasswordHash string `json:"password_hash"`
+	PasswordSalt string `json:"password_salt"`
+	CreatedAt    int64  `json:"created_at"`
+	ExpiresAt    int64  `json:"expires_at"`
+	Revoked      bool   `json:"revoked"`
+}
+
+// Expired 链接是否已过期
+func (s *Share) Expired(now time.Time) bool {
+	return now.Unix() >= s.ExpiresAt
+}
+
+// Active 链接是否仍可访问
+func (s *Share) Active(now time.Time) bool {
+	return !s.Revoked && !s.Expired(now)
+}
+
+// ShareStore 基于文件的分享链接存储；REPL 创建和吊销，Web 服务器每次访问时重新读取，不需要重启
+type ShareStore struct {
+	path  string
+	mutex sync.RWMutex
+
+	failuresMu sync.Mutex
+	failures   map[string][]time.Time // 每个链接最近输错密码的时间，只保存在内存中
+}
+
+// NewShareStore 创建存储目录下的分享链接存储
+func NewShareStore(baseDir string) *ShareStore {
+	return &ShareStore{path: filepath.Join(baseDir, sharesFileName), failures: make(map[string][]time.Time)}
+}
+
+// Create 为账户创建分享链接，返回的明文密钥只在此时可见，拼在链接中交给对方
+func (ss *ShareStore) Create(name, accountID, coin, password string, ttl time.Duration) (*Share, string, error) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	shares, err := ss.load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	idBytes := make([]byte, 4)
+	secret := make([]byte, 32)
+	salt := make([]byte, 16)
+	for _, b := range [][]byte{idBytes, secret, salt} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := time.Now()
+	share := &Share{
+		ID:           hex.EncodeToString(idBytes),
+		Name:         name,
+		AccountID:    accountID,
+		Coin:         coin,
+		SecretHash:   hashSecret(hex.EncodeToString(secret)),
+		PasswordHash: hashSharePassword(password, salt),
+		PasswordSalt: hex.EncodeToString(salt),
+		CreatedAt:    now.Unix(),
+		ExpiresAt:    now.Add(ttl).Unix(),
+	}
+	shares = append(shares, share)
+	if err := ss.save(shares); err != nil {
+		return nil, "", err
+	}
+	return share, hex.EncodeToString(secret), nil
+}
+
+// List 列出所有分享链接
+func (ss *ShareStore) List() ([]*Share, error) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return ss.load()
+}
+
+// Revoke 吊销分享链接，已打开的页面刷新后不再显示地址
+func (ss *ShareStore) Revoke(id string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	shares, err := ss.load()
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if share.ID == id {
+			share.Revoked = true
+			return ss.save(shares)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrShareNotFound, id)
+}
+
+// Lookup 校验链接 ID 和密钥，返回仍有效的链接；不区分不存在、密钥错误、过期和吊销
+func (ss *ShareStore) Lookup(id, secret string) (*Share, error) {
+	shares, err := ss.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		if share.ID != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(share.SecretHash), []byte(hashSecret(secret))) != 1 || !share.Active(time.Now()) {
+			return nil, ErrShareInvalid
+		}
+		return share, nil
+	}
+	return nil, ErrShareInvalid
+}
+
+// CheckPassword 校验访问密码，链接在 shareLockout 内输错 shareMaxFailures 次后直接拒绝；
+// 校验前先记一次失败，并发的请求也不能超过次数，密码正确时清空
+func (ss *ShareStore) CheckPassword(share *Share, password string) error {
+	ss.failuresMu.Lock()
+	now := time.Now()
+	var recent []time.Time
+	for _, at := range ss.failures[share.ID] {
+		if now.Sub(at) < shareLockout {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= shareMaxFailures {
+		ss.failures[share.ID] = recent
+		ss.failuresMu.Unlock()
+		return ErrShareLimited
+	}
+	ss.failures[share.ID] = append(recent, now)
+	ss.failuresMu.Unlock()
+
+	salt, err := hex.DecodeString(share.PasswordSalt)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(share.PasswordHash), []byte(hashSharePassword(password, salt))) != 1 {
+		return ErrSharePassword
+	}
+	ss.failuresMu.Lock()
+	delete(ss.failures, share.ID)
+	ss.failuresMu.Unlock()
+	return nil
+}
+
+func (ss *ShareStore) load() ([]*Share, error) {
+	data, err := os.ReadFile(ss.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Share{}, nil
+		}
+		return nil, err
+	}
+	var shares []*Share
+	if err := json.Unmarshal(data, &shares); err != nil {
+		return nil, fmt.Errorf("解码分享链接失败: %w", err)
+	}
+	return shares, nil
+}
+
+func (ss *ShareStore) save(shares []*Share) error {
+	data, err := canonjson.MarshalIndent(shares, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := ss.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入分享链接失败: %w", err)
+	}
+	return os.Rename(tempFile, ss.path)
+}
+
+func hashSharePassword(password string, salt []byte) string {
+	key, _ := crypto.NewArgon2KDF().DeriveKey(password, salt)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,199 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/payreq"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/pkg/qr"
+	"go.uber.org/zap"
+)
+
+// Shares 设置分享链接存储，未设置时 /share/ 下的链接都返回 404
+func (s *Server) Shares(store *ShareStore) *Server {
+	s.shares = store
+	return s
+}
+
+// sharePage 分享页面的数据，Opened 为 false 时显示密码表单
+type sharePage struct {
+	ID        string
+	Key       string
+	Name      string
+	Coin      string
+	Expires   string
+	Error     string
+	Gone      bool // 链接无效、过期或已吊销
+	Opened    bool // 密码正确
+	Addresses []shareAddress
+}
+
+type shareAddress struct {
+	Index   uint32
+	Address string
+	URI     string
+	QR      htmltemplate.HTML
+}
+
+// shareHandler GET 显示密码表单，POST 校验密码后显示账户的收款地址和二维码。链接密钥放在查询参数中，
+// 请求日志和审计日志只记录路径，不会记下密钥；页面只读取地址，不涉及余额和私钥，钱包锁定时也可以访问
+func (s *Server) shareHandler(w http.ResponseWriter, r *http.Request) {
+	// 页面不缓存、不被搜索引擎收录，离开页面时不带 Referer，不加载任何外部资源
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+
+	id := r.PathValue("id")
+	page := sharePage{ID: id}
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, 4096)
+		if err := r.ParseForm(); err != nil {
+			s.renderShare(w, http.StatusBadRequest, page)
+			return
+		}
+		page.Key = r.PostForm.Get("key")
+	} else {
+		page.Key = r.URL.Query().Get("key")
+	}
+
+	actor := "share:" + id
+	if s.shares == nil {
+		page.Gone = true
+		s.renderShare(w, http.StatusNotFound, page)
+		return
+	}
+	share, err := s.shares.Lookup(id, page.Key)
+	if err != nil {
+		s.audit(actor, r, "denied: "+err.Error())
+		page.Gone = true
+		s.renderShare(w, http.StatusNotFound, page)
+		return
+	}
+	page.Name, page.Coin = share.Name, share.Coin
+	page.Expires = view.Timestamp(time.Unix(share.ExpiresAt, 0))
+	if r.Method != http.MethodPost {
+		s.renderShare(w, http.StatusOK, page)
+		return
+	}
+
+	if err := s.shares.CheckPassword(share, r.PostForm.Get("password")); err != nil {
+		s.audit(actor, r, "denied: "+err.Error())
+		page.Error = err.Error()
+		status := http.StatusUnauthorized
+		if errors.Is(err, ErrShareLimited) {
+			status = http.StatusTooManyRequests
+		} else if !errors.Is(err, ErrSharePassword) {
+			status = http.StatusInternalServerError
+		}
+		s.renderShare(w, status, page)
+		return
+	}
+
+	page.Opened = true
+	if s.root.AccountMgr == nil {
+		page.Error = "wallet not available"
+		s.renderShare(w, http.StatusServiceUnavailable, page)
+		return
+	}
+	addresses, err := s.root.AccountMgr.GetAddresses(share.AccountID)
+	if err != nil {
+		s.logger.Warn("Failed to load shared addresses", zap.String("share", id), zap.Error(err))
+		page.Error = "addresses not available"
+		s.renderShare(w, http.StatusInternalServerError, page)
+		return
+	}
+	for _, address := range addresses {
+		if address.ChangeType != 0 {
+			continue
+		}
+		entry := shareAddress{Index: address.AddressIndex, Address: address.Address, URI: address.Address}
+		if payreq.Supported(share.Coin) {
+			if uri, err := payreq.URI(share.Coin, address.Address, nil, 0, "", ""); err == nil {
+				entry.URI = uri
+			}
+		}
+		if code, err := qr.Encode([]byte(entry.URI), qr.LevelM); err == nil {
+			var svg bytes.Buffer
+			if code.SVG(&svg, 4) == nil {
+				// 内嵌到 HTML 中，去掉 XML 声明
+				_, element, _ := strings.Cut(svg.String(), "<svg")
+				entry.QR = htmltemplate.HTML("<svg" + element)
+			}
+		}
+		page.Addresses = append(page.Addresses, entry)
+	}
+	sort.Slice(page.Addresses, func(i, j int) bool { return page.Addresses[i].Index < page.Addresses[j].Index })
+	s.audit(actor, r, "ok")
+	s.renderShare(w, http.StatusOK, page)
+}
+
+func (s *Server) renderShare(w http.ResponseWriter, status int, page sharePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := shareTemplate.Execute(w, page); err != nil {
+		s.logger.Warn("Failed to render share page", zap.Error(err))
+	}
+}
+
+var shareTemplate = htmltemplate.Must(htmltemplate.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex, nofollow">
+    <title>Deposit details</title>
+    <style>
+        body { font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background: #f4f5f7; color: #333; margin: 0; padding: 2rem 1rem; }
+        .container { background: white; max-width: 720px; margin: 0 auto; padding: 2rem; border-radius: 12px; box-shadow: 0 10px 30px rgba(0,0,0,0.08); }
+        h1 { font-size: 1.6rem; margin-bottom: 0.5rem; }
+        .meta { color: #666; margin-bottom: 1.5rem; }
+        .error { color: #b00020; margin-bottom: 1rem; }
+        .address { display: flex; gap: 1rem; align-items: center; border-top: 1px solid #eee; padding: 1rem 0; }
+        .address code { word-break: break-all; font-size: 0.95rem; }
+        .address svg { flex: none; width: 148px; height: 148px; }
+        input[type=password] { padding: 0.5rem; font-size: 1rem; width: 16rem; }
+        button { padding: 0.5rem 1rem; font-size: 1rem; }
+    </style>
+</head>
+<body>
+    <div class="container">
+{{- if .Gone}}
+        <h1>Link not available</h1>
+        <p class="meta">This share link is invalid, has expired or was revoked. Ask the sender for a new one.</p>
+{{- else}}
+        <h1>{{if .Name}}{{.Name}}{{else}}Deposit details{{end}}</h1>
+        <p class="meta">{{.Coin}} receive addresses · link expires {{.Expires}}</p>
+    {{- if .Error}}
+        <p class="error">{{.Error}}</p>
+    {{- end}}
+    {{- if .Opened}}
+        {{- range .Addresses}}
+        <div class="address">
+            {{.QR}}
+            <div><strong>#{{.Index}}</strong><br><code>{{.Address}}</code></div>
+        </div>
+        {{- else}}
+        {{- if not .Error}}
+        <p class="meta">No receive addresses yet.</p>
+        {{- end}}
+        {{- end}}
+    {{- else}}
+        <form method="post" action="/share/{{.ID}}">
+            <input type="hidden" name="key" value="{{.Key}}">
+            <p><label>Password <input type="password" name="password" autocomplete="off" autofocus></label></p>
+            <button type="submit">Show addresses</button>
+        </form>
+    {{- end}}
+{{- end}}
+    </div>
+</body>
+</html>
+`))
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	streamBatchSize    = 100              // 每批派生并在一个事务中保存的地址数
+	streamMaxCount     = 1000000          // 单次请求最多派生的地址数
+	streamWriteTimeout = 15 * time.Second // 每批的写超时，客户端停止读取超过这个时间即断开
+)
+
+type streamRequest struct {
+	AccountID string `json:"account_id"`
+	Change    uint32 `json:"change"`
+	Start     uint32 `json:"start"`
+	Count     uint32 `json:"count"`
+}
+
+// streamAddressesHandler 派生 start 起连续 count 个地址，以 NDJSON 逐行返回。
+// 按批派生和保存，每批写出后 Flush；写入阻塞时不再派生，客户端断开时停止。
+// 已经开始输出后出错时，最后一行为 {"error": "..."}，此前输出的地址都已保存
+func (s *Server) streamAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	var req streamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AccountID == "" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Change > 1 {
+		writeError(w, http.StatusBadRequest, "change must be 0 or 1")
+		return
+	}
+	if req.Count == 0 || req.Count > streamMaxCount {
+		writeError(w, http.StatusBadRequest, "count must be between 1 and 1000000")
+		return
+	}
+	if req.Start+req.Count-1 < req.Start {
+		writeError(w, http.StatusBadRequest, "address index range overflows")
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	started := false
+	for done := uint32(0); done < req.Count; {
+		if r.Context().Err() != nil {
+			s.logger.Info("Address stream cancelled by client")
+			return
+		}
+		batch := min(req.Count-done, streamBatchSize)
+		addresses, err := tenant.AccountMgr.DeriveAddresses(req.AccountID, req.Change, req.Start+done, batch)
+		if err != nil {
+			if !started {
+				writeManagerError(w, err)
+				return
+			}
+			encoder.Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		tenant.invalidateSearch()
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		for _, addr := range addresses {
+			if err := encoder.Encode(toAddressView(addr)); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+		done += batch
+	}
+}
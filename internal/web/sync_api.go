@@ -0,0 +1,66 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/palagend/slowmade/internal/events"
+	"github.com/palagend/slowmade/internal/mirror"
+	"github.com/palagend/slowmade/internal/watch"
+)
+
+const syncFlushEvery = 100 // 每输出多少条 Flush 一次
+
+// syncHandler 外部索引器的初始同步：以 NDJSON 逐行输出命名空间当前的全部账户、地址和交易记录，
+// 格式与 index.webhooks 收到的变更事件相同，最后一行为 sync.complete。
+// 已经开始输出后出错时，最后一行为 {"error": "..."}
+func (s *Server) syncHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	var txs []mirror.Tx
+	if tenant.DataDir != "" {
+		store, err := watch.LoadTxStore(filepath.Join(tenant.DataDir, watch.TxFileName))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		txs = store.Mirror()
+	}
+
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	written := 0
+	err := mirror.Snapshot(tenant.AccountMgr, txs, func(event events.Event) error {
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+		if written == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		if written%syncFlushEvery == 0 {
+			controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		written++
+		if written%syncFlushEvery == 0 || event.Type == mirror.SyncComplete {
+			return controller.Flush()
+		}
+		return nil
+	})
+	if err == nil || r.Context().Err() != nil {
+		return
+	}
+	if written == 0 {
+		writeManagerError(w, err)
+		return
+	}
+	encoder.Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+)
+
+// tagCount 标记树中的一个节点，Count 包含全部下级标记
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// taggedView 有标记的账户 ID 或地址
+type taggedView struct {
+	Target string   `json:"target"`
+	Tags   []string `json:"tags"`
+	Label  string   `json:"label,omitempty"`
+}
+
+type tagRequest struct {
+	Target string   `json:"target"` // 账户 ID 或地址
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// tagsHandler 不带 tag 参数时返回全部标记及使用次数（上级包含下级），
+// 带 tag 参数时返回有该标记或其下级标记的账户和地址
+func (s *Server) tagsHandler(w http.ResponseWriter, r *http.Request) {
+	store, err := s.tenant(r).metadata()
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	if filter := r.URL.Query().Get("tag"); filter != "" {
+		tag, err := metadata.NormalizeTag(filter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		views := make([]taggedView, 0)
+		for _, target := range store.Tagged(tag) {
+			label, _ := store.Get(metadata.Labels, target)
+			views = append(views, taggedView{Target: target, Tags: store.Tags(target), Label: label})
+		}
+		writeJSON(w, http.StatusOK, views)
+		return
+	}
+	counts := store.TagCounts()
+	views := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		views = append(views, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Tag < views[j].Tag })
+	writeJSON(w, http.StatusOK, views)
+}
+
+// tagHandler 为账户 ID 或地址添加和删除标记，修改记入元数据操作日志，可以在 REPL 中撤销
+func (s *Server) tagHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.DataDir == "" {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	var req tagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Target == "" || len(req.Add)+len(req.Remove) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	appConfig := config.GetAppConfig()
+	if appConfig.GetStorageConfig().ReadOnly {
+		writeManagerError(w, core.ErrReadOnly)
+		return
+	}
+	store, err := tenant.metadata()
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	tags := store.Tags(req.Target)
+	if len(req.Add) > 0 {
+		if tags, err = store.AddTags("api.tag", req.Target, req.Add...); err != nil {
+			writeTagError(w, err)
+			return
+		}
+	}
+	if len(req.Remove) > 0 {
+		if tags, err = store.RemoveTags("api.tag", req.Target, req.Remove...); err != nil {
+			writeTagError(w, err)
+			return
+		}
+	}
+	tenant.invalidateSearch()
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, http.StatusOK, taggedView{Target: req.Target, Tags: tags})
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, metadata.ErrInvalidTag):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, metadata.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeManagerError(w, err)
+	}
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/search"
+	"github.com/palagend/slowmade/internal/security"
+)
+
+// ErrUnlockLimited 输错钱包密码的次数过多，暂停解锁
+var ErrUnlockLimited = errors.New("too many wrong passwords, try again later")
+
+const (
+	// 同一命名空间在 unlockLockout 内输错 unlockMaxFailures 次钱包密码后暂停解锁，每次输错后的等待从 unlockBackoff 起加倍
+	unlockMaxFailures = 5
+	unlockLockout     = 15 * time.Minute
+	unlockBackoff     = time.Second
+)
+
+// Tenant 一个钱包命名空间：默认命名空间是存储根目录，多用户模式下每个用户一个，
+// 各自使用独立的存储、管理器和查找索引
+type Tenant struct {
+	WalletMgr  core.WalletManager
+	AccountMgr core.AccountManager
+	Passwords  *security.PasswordManager // 解锁密码，和钱包管理器使用的是同一个
+	DataDir    string                    // 数据目录，用于读取标签、联系人等元数据
+
+	searchMu      sync.Mutex
+	index         *search.Index
+	indexMetaTime time.Time // 构建索引时元数据文件的修改时间
+
+	unlockMu       sync.Mutex
+	unlockFailures []time.Time // 最近输错钱包密码的时间，只保存在内存中
+}
+
+// beginUnlock 检查是否允许尝试解锁，不允许时返回还需等待的时间：每次输错后等待 unlockBackoff 加倍，
+// unlockLockout 内输错 unlockMaxFailures 次后暂停到最早的一次失败过期。校验前先记一次失败，并发的请求也不能超过次数
+func (t *Tenant) beginUnlock(now time.Time) (time.Duration, bool) {
+	t.unlockMu.Lock()
+	defer t.unlockMu.Unlock()
+	var recent []time.Time
+	for _, at := range t.unlockFailures {
+		if now.Sub(at) < unlockLockout {
+			recent = append(recent, at)
+		}
+	}
+	t.unlockFailures = recent
+	if len(recent) >= unlockMaxFailures {
+		return recent[0].Add(unlockLockout).Sub(now), false
+	}
+	if n := len(recent); n > 0 {
+		if wait := recent[n-1].Add(unlockBackoff << (n - 1)).Sub(now); wait > 0 {
+			return wait, false
+		}
+	}
+	t.unlockFailures = append(recent, now)
+	return 0, true
+}
+
+// unlocked 密码正确时清空失败记录
+func (t *Tenant) unlocked() {
+	t.unlockMu.Lock()
+	t.unlockFailures = nil
+	t.unlockMu.Unlock()
+}
+
+// TenantOpener 打开用户的命名空间，由组合根提供
+type TenantOpener func(user string) (*Tenant, error)
+
+// Users 启用多用户模式：绑定用户的 API 密钥只能访问该用户的命名空间
+func (s *Server) Users(store *UserStore, open TenantOpener) *Server {
+	s.users = store
+	s.openTenant = open
+	return s
+}
+
+// userTenant 返回用户的命名空间，首次访问时打开并缓存
+func (s *Server) userTenant(name string) (*Tenant, error) {
+	if _, err := s.users.Get(name); err != nil {
+		return nil, err
+	}
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+	if tenant, ok := s.tenants[name]; ok {
+		return tenant, nil
+	}
+	tenant, err := s.openTenant(name)
+	if err != nil {
+		return nil, err
+	}
+	s.tenants[name] = tenant
+	return tenant, nil
+}
+
+// tenant 请求所属的命名空间，由鉴权中间件放入上下文；未鉴权的路由使用默认命名空间
+func (s *Server) tenant(r *http.Request) *Tenant {
+	if tenant, ok := r.Context().Value(tenantContextKey).(*Tenant); ok {
+		return tenant
+	}
+	return s.root
+}
+
+// searchIndex 返回查找索引，首次使用、派生新地址或元数据文件变化后重新构建
+func (t *Tenant) searchIndex() (*search.Index, error) {
+	t.searchMu.Lock()
+	defer t.searchMu.Unlock()
+
+	var path string
+	var modified time.Time
+	if t.DataDir != "" {
+		path = filepath.Join(t.DataDir, metadata.FileName)
+		if info, err := os.Stat(path); err == nil {
+			modified = info.ModTime()
+		}
+	}
+	if t.index != nil && modified.Equal(t.indexMetaTime) {
+		return t.index, nil
+	}
+
+	var meta *metadata.Store
+	if path != "" {
+		store, err := metadata.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		meta = store
+	}
+	index, err := search.Build(t.AccountMgr, meta)
+	if err != nil {
+		return nil, err
+	}
+	t.index, t.indexMetaTime = index, modified
+	return index, nil
+}
+
+// metadata 加载命名空间的标签、标记等元数据，没有数据目录时返回空的内存存储
+func (t *Tenant) metadata() (*metadata.Store, error) {
+	if t.DataDir == "" {
+		return metadata.Load("")
+	}
+	return metadata.Load(filepath.Join(t.DataDir, metadata.FileName))
+}
+
+// invalidateSearch 丢弃查找索引
+func (t *Tenant) invalidateSearch() {
+	t.searchMu.Lock()
+	t.index = nil
+	t.searchMu.Unlock()
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBeginUnlockBacksOffAndLocksOut(t *testing.T) {
+	tenant := &Tenant{}
+	now := time.Unix(1_700_000_000, 0)
+
+	// 第一次尝试不需要等待，之后每次输错的等待加倍
+	for i := 0; i < unlockMaxFailures; i++ {
+		if wait, ok := tenant.beginUnlock(now); !ok {
+			t.Fatalf("attempt %d refused, wait %v", i+1, wait)
+		}
+		backoff := unlockBackoff << i
+		if i < unlockMaxFailures-1 {
+			if wait, ok := tenant.beginUnlock(now.Add(backoff - time.Millisecond)); ok || wait != time.Millisecond {
+				t.Fatalf("attempt %d within backoff = %v, %v, want refused for 1ms", i+2, wait, ok)
+			}
+		}
+		now = now.Add(backoff)
+	}
+
+	// 达到次数后暂停到最早的一次失败过期
+	first := time.Unix(1_700_000_000, 0)
+	wait, ok := tenant.beginUnlock(now)
+	if ok || wait != first.Add(unlockLockout).Sub(now) {
+		t.Fatalf("attempt after %d failures = %v, %v, want refused until the first failure expires", unlockMaxFailures, wait, ok)
+	}
+	if _, ok := tenant.beginUnlock(first.Add(unlockLockout)); !ok {
+		t.Error("attempt after the first failure expired was refused")
+	}
+
+	// 成功解锁后清空记录
+	tenant.unlocked()
+	if _, ok := tenant.beginUnlock(now); !ok {
+		t.Error("attempt after a successful unlock was refused")
+	}
+}
+
+func TestUnlockRoutesRequireAdminScope(t *testing.T) {
+	s := NewServer()
+	s.setupRoutes()
+	for _, pattern := range []string{"POST /api/v1/wallet/unlock", "POST /api/v1/wallet/lock"} {
+		if scope := s.routeScopes[pattern]; scope != ScopeAdmin {
+			t.Errorf("%s scope = %q, want %q", pattern, scope, ScopeAdmin)
+		}
+	}
+	for _, scope := range []Scope{ScopeRead, ScopeDerive, ScopeSign} {
+		if (&APIKey{Scope: scope}).Allows(ScopeAdmin) {
+			t.Errorf("%s key allows admin routes", scope)
+		}
+	}
+}
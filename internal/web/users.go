@@ -0,0 +1,206 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// 错误定义
+var (
+	ErrUserExists      = errors.New("user already exists")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserDisabled    = errors.New("user is disabled")
+	ErrInvalidUserName = errors.New("invalid user name")
+	ErrInvalidRole     = errors.New("invalid role")
+)
+
+// Role 用户在默认（团队）钱包审批队列中的角色
+type Role string
+
+const (
+	RoleRequester Role = "requester" // 提交待签名的交易
+	RoleApprover  Role = "approver"  // 批准或拒绝其他人提交的交易
+)
+
+// ParseRoles 解析逗号分隔的角色列表，空字符串表示没有角色
+func ParseRoles(s string) ([]Role, error) {
+	var roles []Role
+	for _, part := range strings.Split(s, ",") {
+		role := Role(strings.ToLower(strings.TrimSpace(part)))
+		switch role {
+		case "":
+			continue
+		case RoleRequester, RoleApprover:
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, part)
+		}
+	}
+	return roles, nil
+}
+
+const (
+	usersFileName = "users.json"
+	// UsersDirName 存储目录下存放用户命名空间的子目录
+	UsersDirName = "users"
+)
+
+// userName 用户名同时用作目录名：小写字母开头，只含小写字母、数字、- 和 _
+var userName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// User serve 模式的用户，由管理员通过命令行创建，每个用户在 users/<name> 下有独立的钱包
+type User struct {
+	Name      string `json:"name"`
+	Roles     []Role `json:"roles,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	Disabled  bool   `json:"disabled"`
+}
+
+// HasRole 判断用户是否拥有角色
+func (u *User) HasRole(role Role) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// UserDir 用户命名空间的存储目录
+func UserDir(baseDir, name string) string {
+	return filepath.Join(baseDir, UsersDirName, name)
+}
+
+// UserStore 基于文件的用户存储
+type UserStore struct {
+	baseDir string
+	path    string
+	mutex   sync.RWMutex
+}
+
+// NewUserStore 创建存储目录下的用户存储
+func NewUserStore(baseDir string) *UserStore {
+	return &UserStore{baseDir: baseDir, path: filepath.Join(baseDir, usersFileName)}
+}
+
+// Add 创建用户和它的命名空间目录
+func (us *UserStore) Add(name string, roles []Role) (*User, error) {
+	if !userName.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidUserName, name)
+	}
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	users, err := us.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.Name == name {
+			return nil, fmt.Errorf("%w: %s", ErrUserExists, name)
+		}
+	}
+	if err := os.MkdirAll(UserDir(us.baseDir, name), 0700); err != nil {
+		return nil, err
+	}
+	user := &User{Name: name, Roles: roles, CreatedAt: time.Now().Unix()}
+	if err := us.save(append(users, user)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// List 列出所有用户
+func (us *UserStore) List() ([]*User, error) {
+	us.mutex.RLock()
+	defer us.mutex.RUnlock()
+
+	return us.load()
+}
+
+// Get 返回启用中的用户
+func (us *UserStore) Get(name string) (*User, error) {
+	users, err := us.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.Name == name {
+			if user.Disabled {
+				return nil, fmt.Errorf("%w: %s", ErrUserDisabled, name)
+			}
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+}
+
+// SetDisabled 停用或重新启用用户，停用后该用户的全部 API 密钥都无法通过鉴权，数据保留
+func (us *UserStore) SetDisabled(name string, disabled bool) error {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	users, err := us.load()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Name == name {
+			user.Disabled = disabled
+			return us.save(users)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+}
+
+// SetRoles 替换用户的角色
+func (us *UserStore) SetRoles(name string, roles []Role) error {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	users, err := us.load()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Name == name {
+			user.Roles = roles
+			return us.save(users)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+}
+
+func (us *UserStore) load() ([]*User, error) {
+	data, err := os.ReadFile(us.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*User{}, nil
+		}
+		return nil, err
+	}
+	var users []*User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("解码用户列表失败: %w", err)
+	}
+	return users, nil
+}
+
+func (us *UserStore) save(users []*User) error {
+	data, err := canonjson.MarshalIndent(users, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := us.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入用户列表失败: %w", err)
+	}
+	return os.Rename(tempFile, us.path)
+}
@@ -0,0 +1,55 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/addrcheck"
+	"github.com/palagend/slowmade/internal/config"
+)
+
+// validateHandler 校验外部地址，不需要钱包；地址无效也返回 200，由 valid 和 error 说明，
+// ?onchain=true 时通过节点查询 ETH 和 BNB 地址是否为合约
+func (s *Server) validateHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbol, address := query.Get("coin"), query.Get("address")
+	if symbol == "" || address == "" {
+		writeError(w, http.StatusBadRequest, "missing coin or address parameter")
+		return
+	}
+	onchain := false
+	if value := query.Get("onchain"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "onchain must be true or false")
+			return
+		}
+		onchain = parsed
+	}
+
+	appConfig := config.GetAppConfig()
+	result, err := addrcheck.Validate(symbol, address, appConfig.GetRPCConfig().CurrentNetwork())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if onchain && result.Valid {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		if err := addrcheck.CheckContract(ctx, result, appConfig); err != nil {
+			status := http.StatusBadGateway
+			switch {
+			case errors.Is(err, addrcheck.ErrNoContracts):
+				status = http.StatusBadRequest
+			case errors.Is(err, addrcheck.ErrNoNode):
+				status = http.StatusServiceUnavailable
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
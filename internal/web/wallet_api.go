@@ -0,0 +1,318 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/metadata"
+	"github.com/palagend/slowmade/internal/metrics"
+	"github.com/palagend/slowmade/internal/view"
+	"github.com/palagend/slowmade/internal/walletstats"
+	"github.com/palagend/slowmade/pkg/coin"
+	"go.uber.org/zap"
+)
+
+// accountView 账户的对外表示，不包含加密私钥
+type accountView struct {
+	ID             string   `json:"id"`
+	CoinSymbol     string   `json:"coin"`
+	DerivationPath string   `json:"derivation_path"`
+	PathConvention string   `json:"path_convention"`       // standard、ledger-live 或 mew
+	CreatedAt      string   `json:"created_at,omitempty"`  // ISO-8601，旧账户没有记录
+	ModifiedAt     string   `json:"modified_at,omitempty"` // ISO-8601
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// addressView 地址的对外表示，不包含加密私钥
+type addressView struct {
+	AccountID    string   `json:"account_id"`
+	Address      string   `json:"address"`
+	PublicKey    string   `json:"public_key"`
+	ChangeType   uint32   `json:"change"`
+	AddressIndex uint32   `json:"index"`
+	CoinSymbol   string   `json:"coin"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+type deriveRequest struct {
+	AccountID string `json:"account_id"`
+	Change    uint32 `json:"change"`
+	Index     uint32 `json:"index"`
+}
+
+func (s *Server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	symbol := r.URL.Query().Get("coin")
+	if symbol == "" {
+		writeError(w, http.StatusBadRequest, "missing coin parameter")
+		return
+	}
+
+	tag, ok := tagParam(w, r)
+	if !ok {
+		return
+	}
+	order, ok := sortParam(w, r)
+	if !ok {
+		return
+	}
+	accounts, err := tenant.AccountMgr.GetAccountsByCoin(coin.CoinType(symbol, true))
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	meta, err := tenant.metadata()
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+
+	core.OrderAccounts(accounts, order, metaLabel(meta))
+	views := make([]accountView, 0, len(accounts))
+	for _, account := range accounts {
+		if tag != "" && !meta.HasTag(account.ID, tag) {
+			continue
+		}
+		views = append(views, accountView{
+			ID:             account.ID,
+			CoinSymbol:     account.CoinSymbol,
+			DerivationPath: account.DerivationPath,
+			PathConvention: string(account.Convention()),
+			CreatedAt:      view.Timestamp(account.Created()),
+			ModifiedAt:     view.Timestamp(account.Modified()),
+			Tags:           meta.Tags(account.ID),
+		})
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) addressesHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	accountID := r.URL.Query().Get("account")
+	if accountID == "" {
+		writeError(w, http.StatusBadRequest, "missing account parameter")
+		return
+	}
+
+	tag, ok := tagParam(w, r)
+	if !ok {
+		return
+	}
+	order, ok := sortParam(w, r)
+	if !ok {
+		return
+	}
+	addresses, err := tenant.AccountMgr.GetAddresses(accountID)
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	meta, err := tenant.metadata()
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+
+	core.OrderAddresses(addresses, order, metaLabel(meta))
+	views := make([]addressView, 0, len(addresses))
+	for _, addr := range addresses {
+		if tag != "" && !meta.HasTag(addr.Address, tag) {
+			continue
+		}
+		addrView := toAddressView(addr)
+		addrView.Tags = meta.Tags(addr.Address)
+		views = append(views, addrView)
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) deriveAddressHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.AccountMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	var req deriveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AccountID == "" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Change > 1 {
+		writeError(w, http.StatusBadRequest, "change must be 0 or 1")
+		return
+	}
+
+	addr, err := tenant.AccountMgr.DeriveAddress(req.AccountID, req.Change, req.Index)
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	tenant.invalidateSearch()
+	writeJSON(w, http.StatusCreated, toAddressView(addr))
+}
+
+type unlockRequest struct {
+	Password string `json:"password"`
+}
+
+func (s *Server) walletStatusHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.WalletMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	status := map[string]interface{}{"locked": tenant.WalletMgr.IsLocked()}
+	if created, modified, err := tenant.WalletMgr.Timestamps(); err == nil {
+		status["created_at"] = view.Timestamp(created)
+		status["modified_at"] = view.Timestamp(modified)
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// walletStatsHandler 钱包概况，见 walletstats.Collect；锁定时不含账户和地址数
+func (s *Server) walletStatsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.WalletMgr == nil || tenant.AccountMgr == nil || tenant.DataDir == "" {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	stats, err := walletstats.Collect(tenant.DataDir, tenant.WalletMgr, tenant.AccountMgr)
+	if err != nil {
+		writeManagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// unlockHandler 用钱包密码解锁密钥所属命名空间的钱包，密码只保存在该命名空间的密码管理器中；
+// 输错密码后按 beginUnlock 限制重试，返回 429 和 Retry-After
+func (s *Server) unlockHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.WalletMgr == nil || tenant.Passwords == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+
+	var req unlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if wait, ok := tenant.beginUnlock(time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, ErrUnlockLimited.Error())
+		return
+	}
+	err := tenant.WalletMgr.UnlockWallet(req.Password)
+	metrics.Inc(metrics.Unlocks, "source", "http", "result", metrics.Result(err))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "failed to unlock wallet")
+		return
+	}
+	tenant.unlocked()
+	tenant.Passwords.SetPassword(req.Password)
+	if tenant.DataDir != "" {
+		if err := walletstats.RecordUnlock(tenant.DataDir, "http"); err != nil {
+			s.logger.Warn("Failed to record unlock", zap.Error(err))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"locked": false})
+}
+
+func (s *Server) lockHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := s.tenant(r)
+	if tenant.WalletMgr == nil {
+		writeError(w, http.StatusServiceUnavailable, "wallet not configured")
+		return
+	}
+	tenant.WalletMgr.LockWallet()
+	if tenant.Passwords != nil {
+		tenant.Passwords.Clear()
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"locked": true})
+}
+
+// tagParam 读取并规范化 tag 查询参数（按标记及其下级标记过滤），格式错误时写入 400 并返回 false
+func tagParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	value := r.URL.Query().Get("tag")
+	if value == "" {
+		return "", true
+	}
+	tag, err := metadata.NormalizeTag(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return tag, true
+}
+
+// sortParam 解析 sort 查询参数（created、index 或 label），无效时写入 400 响应并返回 false
+func sortParam(w http.ResponseWriter, r *http.Request) (core.ListOrder, bool) {
+	order, err := core.ParseListOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return order, true
+}
+
+// metaLabel 按标签排序用的查询
+func metaLabel(meta *metadata.Store) func(key string) string {
+	return func(key string) string {
+		label, _ := meta.Get(metadata.Labels, key)
+		return label
+	}
+}
+
+func toAddressView(addr *core.AddressKey) addressView {
+	return addressView{
+		AccountID:    addr.AccountID,
+		Address:      addr.Address,
+		PublicKey:    addr.PublicKey,
+		ChangeType:   addr.ChangeType,
+		AddressIndex: addr.AddressIndex,
+		CoinSymbol:   addr.CoinSymbol,
+	}
+}
+
+// writeManagerError 将核心层错误映射为 HTTP 状态码
+func writeManagerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, core.ErrWalletLocked):
+		writeError(w, http.StatusLocked, err.Error())
+		return
+	case errors.Is(err, core.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, core.ErrReadOnly):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
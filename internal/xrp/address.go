@@ -0,0 +1,121 @@
+// Package xrp XRP Ledger 的地址（经典地址和 X-address）、Payment 交易的二进制序列化和 secp256k1 签名，
+// 以及 rippled 的 JSON-RPC 接口
+package xrp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/internal/btc"
+	"github.com/palagend/slowmade/pkg/base58"
+)
+
+// ErrInvalidAddress 不是有效的 XRP 地址
+var ErrInvalidAddress = errors.New("invalid xrp address")
+
+// XRP Ledger 的 base58 字母表与比特币的字符顺序不同，编解码时逐字符替换后复用 Base58Check
+const (
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// X-address 的网络前缀
+var (
+	xAddressMainnet = []byte{0x05, 0x44}
+	xAddressTestnet = []byte{0x04, 0x93}
+)
+
+// Destination 解析后的收款方：账户 ID 和可选的目标标签
+type Destination struct {
+	AccountID []byte
+	Tag       uint32
+	HasTag    bool
+	Testnet   bool // X-address 属于测试网
+}
+
+// Classic 经典地址（r...）
+func (d Destination) Classic() string {
+	return EncodeClassic(d.AccountID)
+}
+
+// AccountIDFromPublicKey 33 字节压缩公钥的账户 ID：RIPEMD160(SHA256(公钥))
+func AccountIDFromPublicKey(publicKey []byte) ([]byte, error) {
+	if len(publicKey) != 33 {
+		return nil, errors.New("XRP requires a 33-byte compressed public key")
+	}
+	return btc.Hash160(publicKey), nil
+}
+
+// EncodeClassic 以类型前缀 0x00 编码经典地址
+func EncodeClassic(accountID []byte) string {
+	return translate(base58.CheckEncode(append([]byte{0x00}, accountID...)), bitcoinAlphabet, rippleAlphabet)
+}
+
+// EncodeXAddress 编码 X-address：网络前缀、账户 ID、标签标志和 4 字节小端标签，最后 4 字节保留为 0
+func EncodeXAddress(accountID []byte, tag uint32, hasTag, testnet bool) string {
+	payload := append([]byte(nil), xAddressMainnet...)
+	if testnet {
+		payload = append([]byte(nil), xAddressTestnet...)
+	}
+	payload = append(payload, accountID...)
+	flag := byte(0)
+	if hasTag {
+		flag = 1
+	}
+	payload = append(payload, flag)
+	payload = binary.LittleEndian.AppendUint32(payload, tag)
+	payload = append(payload, 0, 0, 0, 0)
+	return translate(base58.CheckEncode(payload), bitcoinAlphabet, rippleAlphabet)
+}
+
+// ParseAddress 解析经典地址或 X-address
+func ParseAddress(address string) (*Destination, error) {
+	payload, err := base58.CheckDecode(translate(address, rippleAlphabet, bitcoinAlphabet))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	switch {
+	case strings.HasPrefix(address, "r") && len(payload) == 21 && payload[0] == 0x00:
+		return &Destination{AccountID: payload[1:]}, nil
+	case strings.HasPrefix(address, "X") || strings.HasPrefix(address, "T"):
+		if len(payload) != 31 || payload[22] > 1 || !bytes.Equal(payload[27:], []byte{0, 0, 0, 0}) {
+			return nil, fmt.Errorf("%w: %s is not a 32-bit tag X-address", ErrInvalidAddress, address)
+		}
+		testnet := bytes.Equal(payload[:2], xAddressTestnet)
+		if !testnet && !bytes.Equal(payload[:2], xAddressMainnet) {
+			return nil, fmt.Errorf("%w: %s has an unknown prefix", ErrInvalidAddress, address)
+		}
+		return &Destination{AccountID: payload[2:22], Tag: binary.LittleEndian.Uint32(payload[23:27]),
+			HasTag: payload[22] == 1, Testnet: testnet}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+}
+
+// NormalizeAddress 校验经典地址并原样返回；X-address 带有标签，不在这里接受
+func NormalizeAddress(address string) (string, error) {
+	dest, err := ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(address, "r") {
+		return "", fmt.Errorf("%w: expected a classic r... address", ErrInvalidAddress)
+	}
+	return dest.Classic(), nil
+}
+
+func translate(s, from, to string) string {
+	out := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		index := strings.IndexByte(from, s[i])
+		if index < 0 {
+			// 不在字母表中的字符原样保留，解码时报错
+			out[i] = s[i]
+			continue
+		}
+		out[i] = to[index]
+	}
+	return string(out)
+}
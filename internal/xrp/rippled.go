@@ -0,0 +1,191 @@
+package xrp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palagend/slowmade/internal/resilience"
+)
+
+// DefaultRPCURL Ripple 运营的公共 rippled 节点
+const DefaultRPCURL = "https://s1.ripple.com:51234"
+
+// lsfRequireDestTag 账户要求收款必须带目标标签，交易所的收款账户通常设置它
+const lsfRequireDestTag = 0x00020000
+
+// 错误定义
+var (
+	ErrAccountNotFound = errors.New("account not found, it has never been funded")
+	ErrRejected        = errors.New("transaction rejected")
+)
+
+var httpClient = resilience.NewHTTPClient(30 * time.Second)
+
+// Client rippled 的 JSON-RPC 接口
+type Client struct {
+	url string
+}
+
+// NewClient 创建客户端，url 为空时使用公共节点
+func NewClient(url string) *Client {
+	if url == "" {
+		url = DefaultRPCURL
+	}
+	return &Client{url: url}
+}
+
+func (c *Client) Name() string     { return "rippled:" + c.url }
+func (c *Client) ThirdParty() bool { return true }
+
+// Account 账户状态
+type Account struct {
+	Balance  *big.Int // drops
+	Sequence uint32
+	Flags    uint32
+}
+
+// RequiresDestinationTag 账户设置了 RequireDest，没有目标标签的付款会被拒绝
+func (a *Account) RequiresDestinationTag() bool {
+	return a.Flags&lsfRequireDestTag != 0
+}
+
+// Account 查询当前账本中的账户，未激活的账户返回 ErrAccountNotFound
+func (c *Client) Account(ctx context.Context, address string) (*Account, error) {
+	var result struct {
+		AccountData struct {
+			Balance  string `json:"Balance"`
+			Sequence uint32 `json:"Sequence"`
+			Flags    uint32 `json:"Flags"`
+		} `json:"account_data"`
+	}
+	if err := c.call(ctx, "account_info", map[string]interface{}{"account": address, "ledger_index": "current"}, &result); err != nil {
+		return nil, err
+	}
+	balance, ok := new(big.Int).SetString(result.AccountData.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("xrp: unexpected balance %q", result.AccountData.Balance)
+	}
+	return &Account{Balance: balance, Sequence: result.AccountData.Sequence, Flags: result.AccountData.Flags}, nil
+}
+
+// Balance 账户余额（drops），未激活的账户为 0
+func (c *Client) Balance(ctx context.Context, address string) (*big.Int, error) {
+	account, err := c.Account(ctx, address)
+	if errors.Is(err, ErrAccountNotFound) {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account.Balance, nil
+}
+
+// LedgerIndex 当前未关闭账本的序号
+func (c *Client) LedgerIndex(ctx context.Context) (uint32, error) {
+	var result struct {
+		LedgerCurrentIndex uint32 `json:"ledger_current_index"`
+	}
+	err := c.call(ctx, "ledger_current", map[string]interface{}{}, &result)
+	return result.LedgerCurrentIndex, err
+}
+
+// Fee 进入当前账本所需的手续费（drops）
+func (c *Client) Fee(ctx context.Context) (uint64, error) {
+	var result struct {
+		Drops struct {
+			OpenLedgerFee string `json:"open_ledger_fee"`
+		} `json:"drops"`
+	}
+	if err := c.call(ctx, "fee", map[string]interface{}{}, &result); err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(result.Drops.OpenLedgerFee, 10, 64)
+}
+
+// BaseReserve 激活账户需要的最低余额（drops）
+func (c *Client) BaseReserve(ctx context.Context) (uint64, error) {
+	var result struct {
+		State struct {
+			ValidatedLedger struct {
+				ReserveBase uint64 `json:"reserve_base"`
+			} `json:"validated_ledger"`
+		} `json:"state"`
+	}
+	err := c.call(ctx, "server_state", map[string]interface{}{}, &result)
+	return result.State.ValidatedLedger.ReserveBase, err
+}
+
+// Submit 提交已签名的交易，返回交易哈希；排队等待的交易（terQUEUED）也视为成功
+func (c *Client) Submit(ctx context.Context, tx *Payment) (string, error) {
+	encoded, err := tx.Encode()
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		EngineResult        string `json:"engine_result"`
+		EngineResultMessage string `json:"engine_result_message"`
+		TxJSON              struct {
+			Hash string `json:"hash"`
+		} `json:"tx_json"`
+	}
+	if err := c.call(ctx, "submit", map[string]interface{}{"tx_blob": strings.ToUpper(hex.EncodeToString(encoded))}, &result); err != nil {
+		return "", err
+	}
+	if result.EngineResult != "tesSUCCESS" && result.EngineResult != "terQUEUED" {
+		return "", fmt.Errorf("%w: %s %s", ErrRejected, result.EngineResult, result.EngineResultMessage)
+	}
+	return result.TxJSON.Hash, nil
+}
+
+// call 发送 rippled JSON-RPC 请求，错误在 result 中以 status: error 返回
+func (c *Client) call(ctx context.Context, method string, params map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"method": method, "params": []interface{}{params}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	var status struct {
+		Status       string `json:"status"`
+		Error        string `json:"error"`
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := json.Unmarshal(envelope.Result, &status); err != nil {
+		return err
+	}
+	if status.Status == "error" {
+		if status.Error == "actNotFound" {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("rippled %s: %s %s", method, status.Error, status.ErrorMessage)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
@@ -0,0 +1,137 @@
+package xrp
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/internal/btc"
+)
+
+// Decimals XRP 精度，1 XRP = 10^6 drops
+const Decimals = 6
+
+// 错误定义
+var (
+	ErrInvalidAmount = errors.New("amount must be positive")
+	ErrUnsigned      = errors.New("transaction is not signed")
+)
+
+// 签名和交易哈希的前缀：STX\0、TXN\0
+var (
+	prefixSign = []byte{0x53, 0x54, 0x58, 0x00}
+	prefixTxID = []byte{0x54, 0x58, 0x4e, 0x00}
+)
+
+// maxDrops XRP 金额字段能表示的最大值 10^17
+const maxDrops = 100_000_000_000_000_000
+
+// Payment XRP 转账
+type Payment struct {
+	Account            []byte // 付款账户 ID
+	Destination        []byte
+	DestinationTag     uint32
+	HasDestinationTag  bool
+	Amount             uint64 // drops
+	Fee                uint64 // drops
+	Sequence           uint32
+	LastLedgerSequence uint32 // 超过这个账本序号未被打包时交易失效
+	signingPubKey      []byte
+	signature          []byte
+}
+
+// serialize 规范的二进制序列化，字段按（类型码，字段码）排序；withSignature 为 false 时用于签名
+func (p *Payment) serialize(withSignature bool) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x12, 0x00, 0x00}) // TransactionType = Payment
+	buf.WriteByte(0x24)                 // Sequence
+	buf.Write(binary.BigEndian.AppendUint32(nil, p.Sequence))
+	if p.HasDestinationTag {
+		buf.WriteByte(0x2e)
+		buf.Write(binary.BigEndian.AppendUint32(nil, p.DestinationTag))
+	}
+	buf.Write([]byte{0x20, 0x1b}) // LastLedgerSequence
+	buf.Write(binary.BigEndian.AppendUint32(nil, p.LastLedgerSequence))
+	buf.WriteByte(0x61) // Amount
+	buf.Write(nativeAmount(p.Amount))
+	buf.WriteByte(0x68) // Fee
+	buf.Write(nativeAmount(p.Fee))
+	buf.WriteByte(0x73) // SigningPubKey
+	buf.WriteByte(byte(len(p.signingPubKey)))
+	buf.Write(p.signingPubKey)
+	if withSignature {
+		buf.WriteByte(0x74) // TxnSignature
+		buf.WriteByte(byte(len(p.signature)))
+		buf.Write(p.signature)
+	}
+	buf.Write([]byte{0x81, 0x14}) // Account
+	buf.Write(p.Account)
+	buf.Write([]byte{0x83, 0x14}) // Destination
+	buf.Write(p.Destination)
+	return buf.Bytes()
+}
+
+// nativeAmount XRP 金额：最高位 0 表示 XRP，次高位 1 表示正数，其余为 drops
+func nativeAmount(drops uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, drops|0x4000000000000000)
+}
+
+// Sign 用 secp256k1 私钥签名，签名后清除 privateKey；公钥必须对应 Account
+func (p *Payment) Sign(privateKey []byte) error {
+	defer func() {
+		for i := range privateKey {
+			privateKey[i] = 0
+		}
+	}()
+	if p.Amount == 0 || p.Amount >= maxDrops {
+		return ErrInvalidAmount
+	}
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return err
+	}
+	publicKey := crypto.CompressPubkey(&key.PublicKey)
+	accountID, err := AccountIDFromPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(accountID, p.Account) {
+		return errors.New("signing key does not match the sender")
+	}
+	p.signingPubKey = publicKey
+	digest := sha512Half(prefixSign, p.serialize(false))
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return err
+	}
+	// crypto.Sign 给出的 s 已是低位形式，符合规范签名要求
+	p.signature = btc.DERSignature(sig[:32], sig[32:64])
+	return nil
+}
+
+// Encode 已签名交易的二进制形式，即 submit 的 tx_blob
+func (p *Payment) Encode() ([]byte, error) {
+	if p.signature == nil {
+		return nil, ErrUnsigned
+	}
+	return p.serialize(true), nil
+}
+
+// Hash 交易哈希（大写十六进制）
+func (p *Payment) Hash() (string, error) {
+	encoded, err := p.Encode()
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(sha512Half(prefixTxID, encoded))), nil
+}
+
+// sha512Half SHA-512 的前 32 字节
+func sha512Half(prefix, data []byte) []byte {
+	sum := sha512.Sum512(append(append([]byte(nil), prefix...), data...))
+	return sum[:32]
+}
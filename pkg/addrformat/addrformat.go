@@ -0,0 +1,321 @@
+// Package addrformat 地址格式：BTC 的 legacy、p2sh、bech32 和 bech32m，BCH 的 cashaddr 和 legacy，
+// ETH 的 checksum 和 lowercase。由公钥生成指定格式的地址，并在同一币种的格式之间转换
+package addrformat
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/pkg/base58"
+	"github.com/palagend/slowmade/pkg/bech32"
+	"github.com/palagend/slowmade/pkg/cashaddr"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// 格式名称
+const (
+	Legacy    = "legacy"    // BTC P2PKH（1...）；BCH 的旧格式（1... 或 3...）
+	P2SH      = "p2sh"      // BTC P2SH 包装的隔离见证 P2SH-P2WPKH（3...），BIP49
+	Bech32    = "bech32"    // BTC 原生隔离见证 P2WPKH（bc1q...），BIP84
+	Bech32m   = "bech32m"   // BTC Taproot P2TR（bc1p...），BIP86 只有内部密钥的花费路径
+	CashAddr  = "cashaddr"  // BCH bitcoincash:q...
+	Checksum  = "checksum"  // ETH EIP-55 大小写校验
+	Lowercase = "lowercase" // ETH 全小写，没有校验
+)
+
+// 主网参数
+const (
+	btcHRP       = "bc"
+	p2pkhVersion = 0x00
+	p2shVersion  = 0x05
+)
+
+// 错误定义
+var (
+	ErrUnknownFormat  = errors.New("unknown address format")
+	ErrNoFormats      = errors.New("this coin has a single address format")
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrNeedsPublicKey = errors.New("the public key is needed for this conversion, which only works for addresses of this wallet")
+	ErrKeyMismatch    = errors.New("the address does not belong to the public key")
+)
+
+// Format 一种地址格式
+type Format struct {
+	Name        string
+	Description string
+	Script      bool   // 与同币种其他格式的输出脚本不同：是另一个地址，而不只是同一地址的另一种写法
+	Purpose     uint32 // BIP 为该格式规定的 purpose，0 表示不限
+}
+
+var formats = map[string][]Format{
+	"BTC": {
+		{Name: Legacy, Description: "P2PKH, 1...", Script: true, Purpose: 44},
+		{Name: P2SH, Description: "P2SH-wrapped SegWit (P2SH-P2WPKH), 3...", Script: true, Purpose: 49},
+		{Name: Bech32, Description: "native SegWit (P2WPKH), bc1q...", Script: true, Purpose: 84},
+		{Name: Bech32m, Description: "Taproot (P2TR, key path only), bc1p...", Script: true, Purpose: 86},
+	},
+	"BCH": {
+		{Name: CashAddr, Description: "bitcoincash:q... or bitcoincash:p..."},
+		{Name: Legacy, Description: "1... or 3..., as used before CashAddr"},
+	},
+	"ETH": {
+		{Name: Checksum, Description: "EIP-55 mixed-case checksum"},
+		{Name: Lowercase, Description: "all lowercase, without checksum"},
+	},
+}
+
+// Formats 币种的地址格式，第一个为默认格式；只有一种格式的币种返回 nil
+func Formats(symbol string) []Format {
+	return formats[strings.ToUpper(symbol)]
+}
+
+// Lookup 查找币种的格式，名称不区分大小写
+func Lookup(symbol, name string) (Format, error) {
+	list := Formats(symbol)
+	if list == nil {
+		return Format{}, fmt.Errorf("%w: %s", ErrNoFormats, strings.ToUpper(symbol))
+	}
+	names := make([]string, len(list))
+	for i, format := range list {
+		if strings.EqualFold(format.Name, name) {
+			return format, nil
+		}
+		names[i] = format.Name
+	}
+	return Format{}, fmt.Errorf("%w %q for %s, expected %s", ErrUnknownFormat, name, strings.ToUpper(symbol), strings.Join(names, ", "))
+}
+
+// ForPurpose 币种中 BIP 规定使用该 purpose 的格式
+func ForPurpose(symbol string, purpose uint32) (Format, bool) {
+	for _, format := range Formats(symbol) {
+		if format.Purpose != 0 && format.Purpose == purpose {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// BTCAddress 由压缩公钥生成指定格式的比特币主网地址
+func BTCAddress(publicKey []byte, format string) (string, error) {
+	if len(publicKey) != 33 {
+		return "", errors.New("BTC requires compressed public key (33 bytes)")
+	}
+	switch format {
+	case Legacy, P2SH, Bech32:
+		return btcFromHash(hash160(publicKey), format)
+	case Bech32m:
+		outputKey, err := taprootOutputKey(publicKey)
+		if err != nil {
+			return "", err
+		}
+		return bech32.EncodeSegwitAddress(btcHRP, 1, outputKey)
+	default:
+		_, err := Lookup("BTC", format)
+		return "", err
+	}
+}
+
+// btcFromHash 由公钥哈希生成 legacy、p2sh 或 bech32 地址
+func btcFromHash(pubKeyHash []byte, format string) (string, error) {
+	switch format {
+	case Legacy:
+		return base58.CheckEncode(append([]byte{p2pkhVersion}, pubKeyHash...)), nil
+	case P2SH:
+		// 赎回脚本为 P2WPKH 见证程序 OP_0 <20 字节公钥哈希>
+		redeemScript := append([]byte{0x00, 0x14}, pubKeyHash...)
+		return base58.CheckEncode(append([]byte{p2shVersion}, hash160(redeemScript)...)), nil
+	case Bech32:
+		return bech32.EncodeSegwitAddress(btcHRP, 0, pubKeyHash)
+	default:
+		return "", ErrNeedsPublicKey
+	}
+}
+
+// Detect 返回地址的格式，地址无效时返回错误
+func Detect(symbol, address string) (string, error) {
+	switch strings.ToUpper(symbol) {
+	case "BTC":
+		format, _, err := parseBTC(address)
+		return format, err
+	case "BCH":
+		format, _, _, err := parseBCH(address)
+		return format, err
+	case "ETH":
+		if err := checkETH(address); err != nil {
+			return "", err
+		}
+		if address[2:] == strings.ToLower(address[2:]) {
+			return Lowercase, nil
+		}
+		return Checksum, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrNoFormats, strings.ToUpper(symbol))
+	}
+}
+
+// Convert 把地址转换为同币种的另一种格式。BCH 和 ETH 的格式只是同一地址的不同写法；
+// BTC 的格式对应不同的输出脚本，由 legacy 或 bech32 地址可以得到同一公钥哈希的 legacy、p2sh 和 bech32 地址，
+// 其余转换需要地址的公钥（publicKey 为空时返回 ErrNeedsPublicKey）
+func Convert(symbol, address, format string, publicKey []byte) (string, error) {
+	target, err := Lookup(symbol, format)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToUpper(symbol) {
+	case "BTC":
+		return convertBTC(address, target.Name, publicKey)
+	case "BCH":
+		_, addressType, hash, err := parseBCH(address)
+		if err != nil {
+			return "", err
+		}
+		if target.Name == CashAddr {
+			return cashaddr.Encode(addressType, hash)
+		}
+		version := byte(p2pkhVersion)
+		if addressType == cashaddr.P2SH {
+			version = p2shVersion
+		}
+		return base58.CheckEncode(append([]byte{version}, hash...)), nil
+	default: // ETH
+		if err := checkETH(address); err != nil {
+			return "", err
+		}
+		if target.Name == Lowercase {
+			return strings.ToLower(address), nil
+		}
+		return common.HexToAddress(address).Hex(), nil
+	}
+}
+
+func convertBTC(address, format string, publicKey []byte) (string, error) {
+	source, program, err := parseBTC(address)
+	if err != nil {
+		return "", err
+	}
+	if publicKey != nil {
+		own, err := BTCAddress(publicKey, source)
+		if err != nil {
+			return "", err
+		}
+		if own != normalizeBTC(address) {
+			return "", ErrKeyMismatch
+		}
+		return BTCAddress(publicKey, format)
+	}
+	if source == format {
+		return normalizeBTC(address), nil
+	}
+	if source == Legacy || source == Bech32 {
+		return btcFromHash(program, format)
+	}
+	return "", ErrNeedsPublicKey
+}
+
+// parseBTC 解析比特币主网地址，返回格式和公钥哈希、脚本哈希或 Taproot 输出密钥
+func parseBTC(address string) (string, []byte, error) {
+	if strings.HasPrefix(strings.ToLower(address), btcHRP+"1") {
+		version, program, err := bech32.DecodeSegwitAddress(btcHRP, address)
+		switch {
+		case err != nil:
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+		case version == 0 && len(program) == 20:
+			return Bech32, program, nil
+		case version == 1 && len(program) == 32:
+			return Bech32m, program, nil
+		default:
+			return "", nil, fmt.Errorf("%w: witness v%d program of %d bytes is not a single-key address", ErrInvalidAddress, version, len(program))
+		}
+	}
+	payload, err := base58.CheckDecode(address)
+	if err != nil || len(payload) != 21 {
+		return "", nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	switch payload[0] {
+	case p2pkhVersion:
+		return Legacy, payload[1:], nil
+	case p2shVersion:
+		return P2SH, payload[1:], nil
+	default:
+		return "", nil, fmt.Errorf("%w: unsupported version byte 0x%02x", ErrInvalidAddress, payload[0])
+	}
+}
+
+// normalizeBTC bech32 地址统一为小写，base58 地址保持原样
+func normalizeBTC(address string) string {
+	if strings.HasPrefix(strings.ToLower(address), btcHRP+"1") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// parseBCH 解析 CashAddr（可省略前缀）或旧格式地址，返回格式、地址类型和哈希
+func parseBCH(address string) (string, byte, []byte, error) {
+	if payload, err := base58.CheckDecode(address); err == nil && len(payload) == 21 {
+		switch payload[0] {
+		case p2pkhVersion:
+			return Legacy, cashaddr.P2PKH, payload[1:], nil
+		case p2shVersion:
+			return Legacy, cashaddr.P2SH, payload[1:], nil
+		}
+	}
+	addressType, hash, err := cashaddr.Decode(address)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+	return CashAddr, addressType, hash, nil
+}
+
+// checkETH 校验 0x 开头的 20 字节十六进制地址，大小写混合时必须符合 EIP-55 校验
+func checkETH(address string) error {
+	if !strings.HasPrefix(address, "0x") || !common.IsHexAddress(address) {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	body := address[2:]
+	if body != strings.ToLower(body) && body != strings.ToUpper(body) && common.HexToAddress(address).Hex() != address {
+		return fmt.Errorf("%w: EIP-55 checksum mismatch in %s", ErrInvalidAddress, address)
+	}
+	return nil
+}
+
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sum[:])
+	return hasher.Sum(nil)
+}
+
+// taprootOutputKey BIP86：取偶数 Y 的内部公钥 P，输出密钥 Q = P + H_TapTweak(P.x)·G，返回 Q 的 X 坐标
+func taprootOutputKey(publicKey []byte) ([]byte, error) {
+	pub, err := crypto.DecompressPubkey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	curve := crypto.S256()
+	params := curve.Params()
+	x, y := pub.X, pub.Y
+	if y.Bit(0) == 1 {
+		y = new(big.Int).Sub(params.P, y)
+	}
+	internalKey := x.FillBytes(make([]byte, 32))
+	tweak := taggedHash("TapTweak", internalKey)
+	if new(big.Int).SetBytes(tweak).Cmp(params.N) >= 0 {
+		return nil, errors.New("taproot tweak out of range")
+	}
+	tx, ty := curve.ScalarBaseMult(tweak)
+	qx, _ := curve.Add(x, y, tx, ty)
+	return qx.FillBytes(make([]byte, 32)), nil
+}
+
+// taggedHash BIP340 带标签的哈希 SHA256(SHA256(tag) || SHA256(tag) || data)
+func taggedHash(tag string, data []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	sum := sha256.Sum256(bytes.Join([][]byte{tagHash[:], tagHash[:], data}, nil))
+	return sum[:]
+}
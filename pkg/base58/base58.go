@@ -0,0 +1,96 @@
+// Package base58 实现比特币字母表的 Base58 与 Base58Check 编码
+package base58
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	ErrInvalidCharacter = errors.New("base58: invalid character")
+	ErrInvalidChecksum  = errors.New("base58: invalid checksum")
+)
+
+var decodeMap = func() [256]int {
+	var m [256]int
+	for i := range m {
+		m[i] = -1
+	}
+	for i, c := range alphabet {
+		m[c] = i
+	}
+	return m
+}()
+
+// Encode 编码字节，前导零字节编码为 '1'
+func Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Decode 解码 Base58 字符串
+func Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		value := decodeMap[s[i]]
+		if value < 0 {
+			return nil, ErrInvalidCharacter
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(value)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// CheckEncode 追加双 SHA256 校验和后编码
+func CheckEncode(payload []byte) string {
+	return Encode(append(append([]byte{}, payload...), checksum(payload)...))
+}
+
+// CheckDecode 解码并校验 Base58Check 字符串，返回去掉校验和的数据
+func CheckDecode(s string) ([]byte, error) {
+	data, err := Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, ErrInvalidChecksum
+	}
+	payload, sum := data[:len(data)-4], data[len(data)-4:]
+	if string(checksum(payload)) != string(sum) {
+		return nil, ErrInvalidChecksum
+	}
+	return payload, nil
+}
+
+func checksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
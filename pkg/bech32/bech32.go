@@ -0,0 +1,192 @@
+// Package bech32 实现 BIP173/BIP350 的 bech32 与 bech32m 编码及隔离见证地址
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// 校验和常量：bech32 用于 v0 见证程序，bech32m 用于 v1 及以上
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+	return result
+}
+
+func createChecksum(hrp string, data []byte, constant uint32) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ constant
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte((mod >> uint(5*(5-i))) & 31)
+	}
+	return checksum
+}
+
+// encode 编码 5 位分组的数据
+func encode(hrp string, data []byte, constant uint32) string {
+	combined := append(append([]byte{}, data...), createChecksum(hrp, data, constant)...)
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, b := range combined {
+		sb.WriteByte(charset[b])
+	}
+	return sb.String()
+}
+
+// decode 解码并返回 hrp、5 位分组数据及校验和常量
+func decode(s string) (string, []byte, uint32, error) {
+	if len(s) > 90 {
+		return "", nil, 0, errors.New("bech32: string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("bech32: mixed case")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, errors.New("bech32: invalid separator position")
+	}
+	hrp := s[:pos]
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		idx := strings.IndexByte(charset, s[i])
+		if idx < 0 {
+			return "", nil, 0, fmt.Errorf("bech32: invalid character %q", s[i])
+		}
+		data = append(data, byte(idx))
+	}
+	constant := polymod(append(hrpExpand(hrp), data...))
+	if constant != bech32Const && constant != bech32mConst {
+		return "", nil, 0, errors.New("bech32: invalid checksum")
+	}
+	return hrp, data[:len(data)-6], constant, nil
+}
+
+// ConvertBits 在不同位宽分组之间转换，CashAddr 也使用同样的分组
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<toBits - 1
+	var result []byte
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, errors.New("bech32: invalid data range")
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte((acc>>bits)&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			result = append(result, byte((acc<<(toBits-bits))&maxv))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxv != 0 {
+		return nil, errors.New("bech32: invalid padding")
+	}
+	return result, nil
+}
+
+// EncodeSegwitAddress 编码隔离见证地址，v0 使用 bech32，v1+ 使用 bech32m
+func EncodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	if version > 16 || len(program) < 2 || len(program) > 40 {
+		return "", errors.New("bech32: invalid witness program")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", errors.New("bech32: invalid v0 witness program length")
+	}
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	constant := uint32(bech32Const)
+	if version > 0 {
+		constant = bech32mConst
+	}
+	return encode(hrp, append([]byte{version}, data...), constant), nil
+}
+
+// DecodeSegwitAddress 解码隔离见证地址，返回见证版本和见证程序
+func DecodeSegwitAddress(hrp, address string) (byte, []byte, error) {
+	gotHRP, data, constant, err := decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != hrp {
+		return 0, nil, fmt.Errorf("bech32: expected prefix %s, got %s", hrp, gotHRP)
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return 0, nil, errors.New("bech32: invalid witness version")
+	}
+	version := data[0]
+	if (version == 0) != (constant == bech32Const) {
+		return 0, nil, errors.New("bech32: wrong checksum variant for witness version")
+	}
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return 0, nil, errors.New("bech32: invalid witness program length")
+	}
+	return version, program, nil
+}
+
+// Encode 以 bech32 编码任意字节（如 age 密钥），hrp 须为小写
+func Encode(hrp string, data []byte) (string, error) {
+	converted, err := ConvertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return encode(hrp, converted, bech32Const), nil
+}
+
+// Decode 解码 bech32（非 bech32m）字符串，返回小写的 hrp 和原始字节
+func Decode(s string) (string, []byte, error) {
+	hrp, data, constant, err := decode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if constant != bech32Const {
+		return "", nil, errors.New("bech32: expected bech32 checksum, got bech32m")
+	}
+	converted, err := ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, converted, nil
+}
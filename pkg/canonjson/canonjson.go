@@ -0,0 +1,142 @@
+// Package canonjson 生成确定性的 JSON：对象键按字节序排序、数字格式固定、不转义 HTML 字符，
+// 相同的值在不同版本间总是编码为相同的字节，供完整性清单和差异比较使用
+package canonjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal 返回 v 的紧凑规范编码
+func Marshal(v interface{}) ([]byte, error) {
+	return MarshalIndent(v, "")
+}
+
+// MarshalIndent 返回 v 的规范编码，indent 非空时每层缩进 indent 并以换行结尾
+func MarshalIndent(v interface{}, indent string) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeValue(&buf, value, indent, 0); err != nil {
+		return nil, err
+	}
+	if indent != "" {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func writeValue(buf *bytes.Buffer, value interface{}, indent string, depth int) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		number, err := formatNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case string:
+		writeString(buf, v)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(buf, indent, depth+1)
+			if err := writeValue(buf, element, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(buf, indent, depth)
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(buf, indent, depth+1)
+			writeString(buf, key)
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+			if err := writeValue(buf, v[key], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(buf, indent, depth)
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("canonjson: unexpected %T", value)
+	}
+	return nil
+}
+
+// formatNumber 整数原样保留（不经过 float64，避免大整数丢失精度），其余数字按 float64 的最短表示编码
+func formatNumber(n json.Number) (string, error) {
+	text := n.String()
+	if !strings.ContainsAny(text, ".eE") {
+		if text == "-0" {
+			return "0", nil
+		}
+		return text, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return "", fmt.Errorf("canonjson: %w", err)
+	}
+	if f == float64(int64(f)) && f > -1e15 && f < 1e15 {
+		return strconv.FormatInt(int64(f), 10), nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode 追加的换行
+}
+
+func newline(buf *bytes.Buffer, indent string, depth int) {
+	if indent == "" {
+		return
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(indent, depth))
+}
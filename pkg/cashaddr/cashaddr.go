@@ -0,0 +1,120 @@
+// Package cashaddr 实现 Bitcoin Cash 的 CashAddr 地址编码（bitcoincash:q...）
+package cashaddr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/palagend/slowmade/pkg/bech32"
+)
+
+// Prefix 主网地址前缀
+const Prefix = "bitcoincash"
+
+// 地址类型
+const (
+	P2PKH byte = 0
+	P2SH  byte = 1
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// 错误定义
+var (
+	ErrInvalidAddress  = errors.New("cashaddr: invalid address")
+	ErrInvalidChecksum = errors.New("cashaddr: invalid checksum")
+)
+
+// polymod 40 位 BCH 校验码
+func polymod(values []byte) uint64 {
+	c := uint64(1)
+	for _, d := range values {
+		c0 := byte(c >> 35)
+		c = (c&0x07ffffffff)<<5 ^ uint64(d)
+		if c0&0x01 != 0 {
+			c ^= 0x98f2bc8e61
+		}
+		if c0&0x02 != 0 {
+			c ^= 0x79b76d99e2
+		}
+		if c0&0x04 != 0 {
+			c ^= 0xf33e5fb3c4
+		}
+		if c0&0x08 != 0 {
+			c ^= 0xae2eabe2a8
+		}
+		if c0&0x10 != 0 {
+			c ^= 0x1e4f43e470
+		}
+	}
+	return c ^ 1
+}
+
+// prefixData 前缀每个字符的低 5 位，后接分隔用的 0
+func prefixData(prefix string) []byte {
+	data := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		data = append(data, prefix[i]&0x1f)
+	}
+	return append(data, 0)
+}
+
+// Encode 编码 20 字节的公钥或脚本哈希，返回带前缀的小写地址
+func Encode(addressType byte, hash []byte) (string, error) {
+	if len(hash) != 20 || addressType > P2SH {
+		return "", ErrInvalidAddress
+	}
+	// 版本字节：类型占高位，低 3 位为哈希长度编码，160 位为 0
+	payload, err := bech32.ConvertBits(append([]byte{addressType << 3}, hash...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	checksum := polymod(append(append(prefixData(Prefix), payload...), make([]byte, 8)...))
+	var b strings.Builder
+	b.WriteString(Prefix + ":")
+	for _, d := range payload {
+		b.WriteByte(charset[d])
+	}
+	for i := 0; i < 8; i++ {
+		b.WriteByte(charset[(checksum>>(5*(7-i)))&0x1f])
+	}
+	return b.String(), nil
+}
+
+// Decode 解码主网地址，前缀可以省略；返回地址类型和 20 字节哈希
+func Decode(address string) (byte, []byte, error) {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return 0, nil, fmt.Errorf("%w: mixed case", ErrInvalidAddress)
+	}
+	address = strings.ToLower(address)
+	prefix, body, found := strings.Cut(address, ":")
+	if !found {
+		prefix, body = Prefix, address
+	}
+	if prefix != Prefix {
+		return 0, nil, fmt.Errorf("%w: expected prefix %s, got %s", ErrInvalidAddress, Prefix, prefix)
+	}
+	if len(body) <= 8 {
+		return 0, nil, ErrInvalidAddress
+	}
+	data := make([]byte, len(body))
+	for i := 0; i < len(body); i++ {
+		d := strings.IndexByte(charset, body[i])
+		if d < 0 {
+			return 0, nil, fmt.Errorf("%w: invalid character %q", ErrInvalidAddress, body[i])
+		}
+		data[i] = byte(d)
+	}
+	if polymod(append(prefixData(prefix), data...)) != 0 {
+		return 0, nil, ErrInvalidChecksum
+	}
+	payload, err := bech32.ConvertBits(data[:len(data)-8], 5, 8, false)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+	if len(payload) != 21 || payload[0]&0x07 != 0 || payload[0]>>3 > P2SH {
+		return 0, nil, fmt.Errorf("%w: unsupported version byte 0x%02x", ErrInvalidAddress, payload[0])
+	}
+	return payload[0] >> 3, payload[1:], nil
+}
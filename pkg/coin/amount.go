@@ -0,0 +1,53 @@
+package coin
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatUnits 按精度将最小单位金额格式化为十进制字符串，去掉末尾多余的 0
+func FormatUnits(amount *big.Int, decimals int) string {
+	negative := amount.Sign() < 0
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals+1-len(digits)) + digits
+	}
+	integer, fraction := digits[:len(digits)-decimals], digits[len(digits)-decimals:]
+	for len(fraction) > 0 && fraction[len(fraction)-1] == '0' {
+		fraction = fraction[:len(fraction)-1]
+	}
+	result := integer
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatEther 将 wei 格式化为 ETH
+func FormatEther(wei *big.Int) string {
+	return FormatUnits(wei, 18)
+}
+
+// ParseUnits 将十进制金额精确转换为最小单位，小数位数不能超过精度，不接受负数
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	integer, fraction, _ := strings.Cut(amount, ".")
+	if integer == "" && fraction == "" {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("invalid amount %q: more than %d decimal places", amount, decimals)
+	}
+	digits := integer + fraction + strings.Repeat("0", decimals-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+	value, _ := new(big.Int).SetString(digits, 10)
+	return value, nil
+}
@@ -1,7 +1,10 @@
 package coin
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/palagend/slowmade/pkg/logging"
 )
@@ -18,22 +21,33 @@ const (
 	CoinTypeSUI uint32 = 784
 )
 
+// 椭圆曲线类型
+const (
+	CurveSecp256k1 = "secp256k1"
+	CurveEd25519   = "ed25519"
+)
+
 // CoinInfo 币种信息
 type CoinInfo struct {
-	Symbol  string
-	Type    uint32 //Coin Type
-	Decimal int    // 币种精度
+	Symbol  string `json:"symbol"`
+	Type    uint32 `json:"coin_type"` //Coin Type
+	Decimal int    `json:"decimals"`  // 币种精度
+	Curve   string `json:"curve"`     // 签名曲线
+	Custom  bool   `json:"-"`         // 是否为用户运行时注册
 }
 
 // coinRegistry 币种注册表
 var coinRegistry = map[uint32]CoinInfo{
-	CoinTypeBTC: {"BTC", CoinTypeBTC, 8},
-	CoinTypeETH: {"ETH", CoinTypeETH, 18},
-	CoinTypeSOL: {"SOL", CoinTypeSOL, 9},
-	CoinTypeBNB: {"BNB", CoinTypeBNB, 8},
-	CoinTypeSUI: {"SUI", CoinTypeSUI, 9},
+	CoinTypeBTC: {"BTC", CoinTypeBTC, 8, CurveSecp256k1, false},
+	CoinTypeETH: {"ETH", CoinTypeETH, 18, CurveSecp256k1, false},
+	CoinTypeSOL: {"SOL", CoinTypeSOL, 9, CurveEd25519, false},
+	CoinTypeBNB: {"BNB", CoinTypeBNB, 8, CurveSecp256k1, false},
+	CoinTypeSUI: {"SUI", CoinTypeSUI, 9, CurveEd25519, false},
 }
 
+// registryMutex 保护注册表的并发访问
+var registryMutex sync.RWMutex
+
 // symbolToType 符号到类型的映射
 var symbolToType = func() map[string]uint32 {
 	result := make(map[string]uint32)
@@ -45,6 +59,9 @@ var symbolToType = func() map[string]uint32 {
 
 // CoinSymbol 根据币种类型获取符号
 func CoinSymbol(coinType uint32) string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
 	// 清除硬化位
 	baseType := coinType &^ HardenedBit
 
@@ -56,7 +73,9 @@ func CoinSymbol(coinType uint32) string {
 
 // CoinType 根据币种符号获取类型
 func CoinType(coinSymbol string, hardened bool) uint32 {
+	registryMutex.RLock()
 	baseType, exists := symbolToType[strings.ToUpper(coinSymbol)]
+	registryMutex.RUnlock()
 	if !exists {
 		logging.Warnf("%s未注册，返回默认值0", coinSymbol)
 		return 0 // 默认返回0
@@ -70,28 +89,81 @@ func CoinType(coinSymbol string, hardened bool) uint32 {
 
 // GetCoinInfo 获取完整的币种信息
 func GetCoinInfo(coinType uint32) (CoinInfo, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
 	baseType := coinType &^ HardenedBit
 	coin, exists := coinRegistry[baseType]
 	return coin, exists
 }
 
-// GetAllCoins 获取所有已注册的币种
+// LookupSymbol 根据符号获取币种信息
+func LookupSymbol(symbol string) (CoinInfo, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	baseType, exists := symbolToType[strings.ToUpper(symbol)]
+	if !exists {
+		return CoinInfo{}, false
+	}
+	return coinRegistry[baseType], true
+}
+
+// GetAllCoins 获取所有已注册的币种，按币种类型排序
 func GetAllCoins() []CoinInfo {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
 	coins := make([]CoinInfo, 0, len(coinRegistry))
 	for _, coin := range coinRegistry {
 		coins = append(coins, coin)
 	}
+	sort.Slice(coins, func(i, j int) bool { return coins[i].Type < coins[j].Type })
 	return coins
 }
 
-// RegisterCoin 注册新币种（线程不安全，建议在init中调用）
+// RegisterCoin 注册新币种（默认 secp256k1 曲线）
 func RegisterCoin(coinType uint32, symbol string, decimal int) {
-	coinRegistry[coinType] = CoinInfo{
+	Register(CoinInfo{
 		Symbol:  symbol,
 		Type:    coinType,
 		Decimal: decimal,
+		Curve:   CurveSecp256k1,
+	})
+}
+
+// Register 注册或覆盖币种信息，并发安全
+func Register(info CoinInfo) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	info.Type &^= HardenedBit
+	info.Symbol = strings.ToUpper(info.Symbol)
+	if old, exists := coinRegistry[info.Type]; exists && old.Symbol != info.Symbol {
+		delete(symbolToType, old.Symbol)
+	}
+	coinRegistry[info.Type] = info
+	symbolToType[info.Symbol] = info.Type
+}
+
+// ValidateNew 校验用户注册的新币种不与已有币种冲突
+func ValidateNew(info CoinInfo) error {
+	if info.Symbol == "" {
+		return fmt.Errorf("coin symbol cannot be empty")
+	}
+	if info.Decimal < 0 || info.Decimal > 36 {
+		return fmt.Errorf("decimals must be between 0 and 36")
+	}
+	if info.Curve != CurveSecp256k1 && info.Curve != CurveEd25519 {
+		return fmt.Errorf("unsupported curve %q (secp256k1|ed25519)", info.Curve)
+	}
+	if existing, ok := GetCoinInfo(info.Type); ok {
+		return fmt.Errorf("coin type %d is already registered as %s", info.Type&^HardenedBit, existing.Symbol)
+	}
+	if existing, ok := LookupSymbol(info.Symbol); ok {
+		return fmt.Errorf("symbol %s is already registered with coin type %d", existing.Symbol, existing.Type)
 	}
-	symbolToType[symbol] = coinType
+	return nil
 }
 
 // IsHardened 检查是否为硬化类型
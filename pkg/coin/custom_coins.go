@@ -0,0 +1,79 @@
+package coin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/palagend/slowmade/pkg/canonjson"
+)
+
+// CustomCoinsFileName 用户注册币种在数据目录中的文件名
+const CustomCoinsFileName = "coins.json"
+
+// fileMutex 保护自定义币种文件的读写
+var fileMutex sync.Mutex
+
+// LoadCustomCoins 从文件加载用户注册的币种，文件不存在时不做任何操作
+func LoadCustomCoins(path string) error {
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	coins, err := readCustomCoins(path)
+	if err != nil {
+		return err
+	}
+	for _, info := range coins {
+		info.Custom = true
+		Register(info)
+	}
+	return nil
+}
+
+// AddCustomCoin 校验、注册并持久化新币种
+func AddCustomCoin(path string, info CoinInfo) error {
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	if err := ValidateNew(info); err != nil {
+		return err
+	}
+
+	coins, err := readCustomCoins(path)
+	if err != nil {
+		return err
+	}
+	coins = append(coins, info)
+
+	data, err := canonjson.MarshalIndent(coins, "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("写入币种文件失败: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		return fmt.Errorf("重命名币种文件失败: %w", err)
+	}
+
+	info.Custom = true
+	Register(info)
+	return nil
+}
+
+func readCustomCoins(path string) ([]CoinInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var coins []CoinInfo
+	if err := json.Unmarshal(data, &coins); err != nil {
+		return nil, fmt.Errorf("解码币种文件失败: %w", err)
+	}
+	return coins, nil
+}
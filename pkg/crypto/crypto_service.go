@@ -3,13 +3,12 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/chacha20poly1305"
@@ -136,50 +135,19 @@ func NewAESGCMService(kdf KDF) *AESGCMService {
 }
 
 func (a *AESGCMService) Encrypt(plaintext []byte, password string) (string, error) {
-	// 生成盐
-	salt := make([]byte, a.getSaltLen())
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-		return "", err
-	}
-
-	// 派生密钥
-	key, err := a.kdf.DeriveKey(password, salt)
-	if err != nil {
-		return "", err
-	}
-
-	// 创建AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	// 创建GCM模式
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	// 生成nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	// 加密
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-
-	// 组合结果: salt + ciphertext
-	result := append(salt, ciphertext...)
-	return hex.EncodeToString(result), nil
+	return sealEnvelope(cipherAESGCM, a.kdf, a.getSaltLen(), plaintext, password)
 }
 
+// Decrypt 解密信封格式的密文；旧格式（salt + nonce + 密文）按本服务的 KDF 解密
 func (a *AESGCMService) Decrypt(encodedCiphertext string, password string) ([]byte, error) {
 	// 解码hex
 	data, err := hex.DecodeString(encodedCiphertext)
 	if err != nil {
 		return nil, ErrInvalidCiphertext
 	}
+	if env, ok := parseEnvelope(data); ok {
+		return env.open(password)
+	}
 
 	saltLen := a.getSaltLen()
 	if len(data) < saltLen+a.nonceSize {
@@ -252,47 +220,22 @@ func NewChaCha20Poly1305Service(kdf KDF) *ChaCha20Poly1305Service {
 }
 
 func (c *ChaCha20Poly1305Service) Encrypt(plaintext []byte, password string) (string, error) {
-	// 生成盐
-	salt := make([]byte, c.getSaltLen())
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-		return "", err
-	}
-
-	// 派生密钥
-	key, err := c.kdf.DeriveKey(password, salt)
-	if err != nil {
-		return "", err
-	}
-
-	// 创建ChaCha20-Poly1305
-	aead, err := chacha20poly1305.New(key)
-	if err != nil {
-		return "", err
-	}
-
-	// 生成nonce
-	nonce := make([]byte, aead.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	// 加密
-	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
-
-	// 组合结果: salt + nonce + ciphertext
-	result := append(salt, nonce...)
-	result = append(result, ciphertext...)
-	return hex.EncodeToString(result), nil
+	return sealEnvelope(cipherChaCha20, c.kdf, c.getSaltLen(), plaintext, password)
 }
 
+// Decrypt 解密信封格式的密文；旧格式（salt + nonce + 密文）按本服务的 KDF 解密
 func (c *ChaCha20Poly1305Service) Decrypt(encodedCiphertext string, password string) ([]byte, error) {
 	data, err := hex.DecodeString(encodedCiphertext)
 	if err != nil {
 		return nil, ErrInvalidCiphertext
 	}
+	if env, ok := parseEnvelope(data); ok {
+		return env.open(password)
+	}
 
+	// 旧格式加密时使用 12 字节的 nonce
 	saltLen := c.getSaltLen()
-	nonceSize := chacha20poly1305.NonceSizeX
+	nonceSize := chacha20poly1305.NonceSize
 	if len(data) < saltLen+nonceSize {
 		return nil, ErrInvalidCiphertext
 	}
@@ -444,7 +387,7 @@ func (f *ConfigurableKDFFactory) CreateArgon2WithParams(time, memory uint32, thr
 
 // 全局单例实例
 var (
-	cryptoServiceInstance      CryptoService
+	cryptoServiceInstance      atomic.Pointer[serviceHolder]
 	cryptoServiceFactoryOnce   sync.Once
 	cryptoServiceFactory       *CryptoServiceFactory
 	configurableKDFFactory     *ConfigurableKDFFactory
@@ -453,6 +396,11 @@ var (
 	configurableKDFFactoryOnce sync.Once
 )
 
+// serviceHolder 包装接口值，使不同实现的服务都能原子替换
+type serviceHolder struct {
+	service CryptoService
+}
+
 // CryptoManager 加密管理器（单例）
 type CryptoManager struct {
 	factory *CryptoServiceFactory
@@ -482,22 +430,31 @@ func GetConfigurableKDFFactory() *ConfigurableKDFFactory {
 	return configurableKDFFactory
 }
 
-// GetDefaultCryptoService 获取默认加密服务单例
+// GetDefaultCryptoService 获取默认加密服务单例，可被多个 goroutine 并发调用
 func GetDefaultCryptoService() CryptoService {
-	if cryptoServiceInstance == nil {
-		cryptoServiceInstance = GetCryptoServiceFactory().CreateDefault()
+	if holder := cryptoServiceInstance.Load(); holder != nil {
+		return holder.service
+	}
+	// 并发初始化时只有一个实例会被保存，其余调用使用已保存的实例
+	holder := &serviceHolder{service: GetCryptoServiceFactory().CreateDefault()}
+	if cryptoServiceInstance.CompareAndSwap(nil, holder) {
+		return holder.service
 	}
-	return cryptoServiceInstance
+	return GetDefaultCryptoService()
 }
 
-// SetGlobalCryptoService 设置全局加密服务实例（用于测试或自定义配置）
+// SetGlobalCryptoService 原子地替换全局加密服务实例（用于测试或自定义配置），传入 nil 等同于重置
 func SetGlobalCryptoService(service CryptoService) {
-	cryptoServiceInstance = service
+	if service == nil {
+		cryptoServiceInstance.Store(nil)
+		return
+	}
+	cryptoServiceInstance.Store(&serviceHolder{service: service})
 }
 
-// ResetGlobalCryptoService 重置全局加密服务实例（主要用于测试）
+// ResetGlobalCryptoService 重置全局加密服务实例，下次使用时重新创建默认实例（主要用于测试）
 func ResetGlobalCryptoService() {
-	cryptoServiceInstance = nil
+	cryptoServiceInstance.Store(nil)
 }
 
 // ==================== 便捷函数 ====================
@@ -517,6 +474,48 @@ func GetCurrentAlgorithm() string {
 	return GetDefaultCryptoService().GetAlgorithm()
 }
 
+// currentKDF 当前加密服务使用的 KDF，无法识别的服务返回 nil
+func currentKDF() KDF {
+	switch service := GetDefaultCryptoService().(type) {
+	case *AESGCMService:
+		return service.kdf
+	case *ChaCha20Poly1305Service:
+		return service.kdf
+	}
+	return nil
+}
+
+// GetCurrentKDF 获取当前使用的 KDF 及其参数，如 scrypt (N=32768, r=8, p=1)
+func GetCurrentKDF() string {
+	kdf := currentKDF()
+	if kdf == nil {
+		return "unknown"
+	}
+	return describeKDF(kdf)
+}
+
+// CurrentKDFWeakness 当前 KDF 参数低于推荐强度时返回原因，否则返回空字符串
+func CurrentKDFWeakness() string {
+	kdf := currentKDF()
+	if kdf == nil {
+		return "unknown key derivation function"
+	}
+	switch k := kdf.(type) {
+	case *ScryptKDF:
+		if k.N < 32768 {
+			return fmt.Sprintf("scrypt N=%d is below the recommended 32768", k.N)
+		}
+	case *Argon2KDF:
+		if k.Memory < 64*1024 || k.Time < 1 {
+			return fmt.Sprintf("argon2id with %d MiB is below the recommended 64 MiB", k.Memory/1024)
+		}
+	case *PBKDF2SHA256:
+		// 迭代 SHA-256 不消耗内存，GPU 上破解很快
+		return "pbkdf2-sha256 is not memory-hard, use scrypt or argon2"
+	}
+	return ""
+}
+
 // CreateCustomCryptoService 创建自定义加密服务（非单例）
 func CreateCustomCryptoService(encType EncryptionType, kdfType KDFType) CryptoService {
 	return GetCryptoServiceFactory().CreateService(encType, kdfType)
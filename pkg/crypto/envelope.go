@@ -0,0 +1,318 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// EnvelopeVersion 当前密文信封格式的版本
+const EnvelopeVersion = 1
+
+// ErrUnsupportedEnvelope 信封头部的版本、算法或 KDF 参数无法识别或超出允许范围
+var ErrUnsupportedEnvelope = errors.New("unsupported ciphertext envelope")
+
+// envelopeMagic 信封开头的标识，随后一个字节是版本号。
+// 旧格式以随机盐开头，恰好与标识相同的概率可以忽略，解析失败时仍按旧格式处理
+var envelopeMagic = []byte("SMENV")
+
+// 信封中的算法编号
+const (
+	cipherAESGCM   byte = 1
+	cipherChaCha20 byte = 2
+
+	kdfIDScrypt byte = 1
+	kdfIDArgon2 byte = 2
+	kdfIDPBKDF2 byte = 3
+)
+
+// 解密时接受的 KDF 参数上限，防止伪造的头部让派生耗尽内存或时间
+const (
+	maxScryptMemory = 4 << 30 // 字节，scrypt 占用 128*N*r
+	maxScryptP      = 64
+	maxArgon2Memory = 4 << 20 // KiB，即 4 GiB
+	maxArgon2Time   = 64
+	maxPBKDF2Rounds = 100_000_000
+)
+
+const (
+	envelopeKeyLen   = 32
+	envelopeParamLen = 12 // 三个 uint32 参数
+)
+
+// EnvelopeInfo 密文头部记录的格式版本、算法和 KDF 参数，不需要密码即可读取
+type EnvelopeInfo struct {
+	Version int    // 0 表示旧格式：盐、nonce 和密文直接拼接，算法和参数取决于加密时的配置
+	Cipher  string // aes-256-gcm 或 chacha20-poly1305，旧格式为空
+	KDF     string // 如 scrypt (N=32768, r=8, p=1)，旧格式为空
+
+	kdf KDF
+}
+
+// Legacy 是否为旧格式
+func (i EnvelopeInfo) Legacy() bool {
+	return i.Version == 0
+}
+
+// BelowBaseline 密文的加密强度低于当前加密配置时返回原因，否则返回空字符串：
+// 旧格式没有记录参数，pbkdf2-sha256 不是内存困难的 KDF，同一 KDF 的参数低于当前配置时也算；
+// scrypt 和 argon2id 之间不比较
+func (i EnvelopeInfo) BelowBaseline() string {
+	if i.Legacy() {
+		return "legacy format without recorded algorithm or parameters"
+	}
+	if i.kdf == nil {
+		return ""
+	}
+	switch k := i.kdf.(type) {
+	case *ScryptKDF:
+		if current, ok := currentKDF().(*ScryptKDF); ok && k.N*k.R < current.N*current.R {
+			return fmt.Sprintf("scrypt N=%d, r=%d is below the configured N=%d, r=%d", k.N, k.R, current.N, current.R)
+		}
+	case *Argon2KDF:
+		if current, ok := currentKDF().(*Argon2KDF); ok && (k.Memory < current.Memory || k.Time < current.Time) {
+			return fmt.Sprintf("argon2id t=%d, %d MiB is below the configured t=%d, %d MiB", k.Time, k.Memory/1024, current.Time, current.Memory/1024)
+		}
+	case *PBKDF2SHA256:
+		current, ok := currentKDF().(*PBKDF2SHA256)
+		if !ok {
+			return "pbkdf2-sha256 is not memory-hard, the configured KDF is " + GetCurrentKDF()
+		}
+		if k.Iterations < current.Iterations {
+			return fmt.Sprintf("pbkdf2-sha256 with %d iterations is below the configured %d", k.Iterations, current.Iterations)
+		}
+	}
+	return ""
+}
+
+// CurrentEnvelope 当前加密配置写入的格式、算法和 KDF 参数，即 BelowBaseline 比较的基线
+func CurrentEnvelope() EnvelopeInfo {
+	info := EnvelopeInfo{Version: EnvelopeVersion, KDF: GetCurrentKDF(), kdf: currentKDF()}
+	switch GetDefaultCryptoService().(type) {
+	case *AESGCMService:
+		info.Cipher = "aes-256-gcm"
+	case *ChaCha20Poly1305Service:
+		info.Cipher = "chacha20-poly1305"
+	}
+	return info
+}
+
+// NeedsReencryption 密文是否低于当前加密配置，空字符串、无法解码和更新版本的密文返回 false
+func NeedsReencryption(encoded string) bool {
+	if encoded == "" {
+		return false
+	}
+	info, err := InspectCiphertext(encoded)
+	return err == nil && info.BelowBaseline() != ""
+}
+
+// envelope 解析后的信封。header 是密文之前的全部字节，作为 AEAD 的附加数据，
+// 篡改算法或参数会导致解密失败
+type envelope struct {
+	cipher     byte
+	kdf        KDF
+	salt       []byte
+	nonce      []byte
+	header     []byte
+	ciphertext []byte
+}
+
+// InspectCiphertext 读取十六进制密文的格式信息，不解密。
+// 更新版本的程序写入的信封返回它的版本号和 ErrUnsupportedEnvelope
+func InspectCiphertext(encoded string) (EnvelopeInfo, error) {
+	data, err := hex.DecodeString(encoded)
+	if err != nil {
+		return EnvelopeInfo{}, ErrInvalidCiphertext
+	}
+	env, ok := parseEnvelope(data)
+	if !ok {
+		if len(data) > len(envelopeMagic) && bytes.HasPrefix(data, envelopeMagic) && data[len(envelopeMagic)] > EnvelopeVersion {
+			version := int(data[len(envelopeMagic)])
+			return EnvelopeInfo{Version: version}, fmt.Errorf("%w: version %d", ErrUnsupportedEnvelope, version)
+		}
+		return EnvelopeInfo{}, nil
+	}
+	name := "aes-256-gcm"
+	if env.cipher == cipherChaCha20 {
+		name = "chacha20-poly1305"
+	}
+	return EnvelopeInfo{Version: EnvelopeVersion, Cipher: name, KDF: describeKDF(env.kdf), kdf: env.kdf}, nil
+}
+
+// IsLegacyCiphertext 密文是否仍为旧格式，空字符串和无法解码的内容返回 false
+func IsLegacyCiphertext(encoded string) bool {
+	if encoded == "" {
+		return false
+	}
+	info, err := InspectCiphertext(encoded)
+	return err == nil && info.Legacy()
+}
+
+// sealEnvelope 用指定算法和 KDF 加密，返回十六进制编码的信封。
+// 密钥按头部记录的参数重新构造的 KDF 派生，保证解密时得到同样的密钥
+func sealEnvelope(cipherID byte, kdf KDF, saltLen int, plaintext []byte, password string) (string, error) {
+	kdfID, params, err := kdfParams(kdf)
+	if err != nil {
+		return "", err
+	}
+	if kdf, err = kdfFromParams(kdfID, params); err != nil {
+		return "", err
+	}
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	key, err := kdf.DeriveKey(password, salt)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(cipherID, key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	header := append(append([]byte{}, envelopeMagic...), EnvelopeVersion, cipherID, kdfID)
+	for _, p := range params {
+		header = binary.BigEndian.AppendUint32(header, p)
+	}
+	header = append(header, byte(len(salt)))
+	header = append(header, salt...)
+	header = append(header, byte(len(nonce)))
+	header = append(header, nonce...)
+	return hex.EncodeToString(aead.Seal(header, nonce, plaintext, header)), nil
+}
+
+// parseEnvelope 解析信封头部，不是信封或头部不合法时返回 false
+func parseEnvelope(data []byte) (*envelope, bool) {
+	fixed := len(envelopeMagic) + 3 + envelopeParamLen
+	if len(data) < fixed+1 || !bytes.HasPrefix(data, envelopeMagic) || data[len(envelopeMagic)] != EnvelopeVersion {
+		return nil, false
+	}
+	env := &envelope{cipher: data[len(envelopeMagic)+1]}
+	var params [3]uint32
+	for i := range params {
+		offset := len(envelopeMagic) + 3 + 4*i
+		params[i] = binary.BigEndian.Uint32(data[offset : offset+4])
+	}
+	kdf, err := kdfFromParams(data[len(envelopeMagic)+2], params)
+	if err != nil {
+		return nil, false
+	}
+	env.kdf = kdf
+
+	rest := data[fixed:]
+	saltLen := int(rest[0])
+	if saltLen < 8 || len(rest) < 1+saltLen+1 {
+		return nil, false
+	}
+	env.salt, rest = rest[1:1+saltLen], rest[1+saltLen:]
+	nonceLen := int(rest[0])
+	expected := 12
+	switch env.cipher {
+	case cipherAESGCM:
+	case cipherChaCha20:
+		expected = chacha20poly1305.NonceSize
+	default:
+		return nil, false
+	}
+	if nonceLen != expected || len(rest) < 1+nonceLen {
+		return nil, false
+	}
+	env.nonce = rest[1 : 1+nonceLen]
+	env.header = data[:len(data)-len(rest)+1+nonceLen]
+	env.ciphertext = rest[1+nonceLen:]
+	return env, true
+}
+
+// open 按头部记录的算法和参数解密，与当前配置无关
+func (e *envelope) open(password string) ([]byte, error) {
+	key, err := e.kdf.DeriveKey(password, e.salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(e.cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, e.nonce, e.ciphertext, e.header)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func newAEAD(cipherID byte, key []byte) (cipher.AEAD, error) {
+	switch cipherID {
+	case cipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case cipherChaCha20:
+		return chacha20poly1305.New(key)
+	}
+	return nil, ErrUnsupportedEnvelope
+}
+
+// kdfParams 把 KDF 及其参数编码为信封中的编号和三个参数
+func kdfParams(kdf KDF) (byte, [3]uint32, error) {
+	switch k := kdf.(type) {
+	case *ScryptKDF:
+		return kdfIDScrypt, [3]uint32{uint32(k.N), uint32(k.R), uint32(k.P)}, nil
+	case *Argon2KDF:
+		return kdfIDArgon2, [3]uint32{k.Time, k.Memory, uint32(k.Threads)}, nil
+	case *PBKDF2SHA256:
+		return kdfIDPBKDF2, [3]uint32{uint32(k.Iterations), 0, 0}, nil
+	}
+	return 0, [3]uint32{}, fmt.Errorf("%w: key derivation %s", ErrUnsupportedEnvelope, kdf.GetName())
+}
+
+// kdfFromParams 由信封中的编号和参数重建 KDF，参数超出上限时拒绝
+func kdfFromParams(id byte, params [3]uint32) (KDF, error) {
+	switch id {
+	case kdfIDScrypt:
+		n, r, p := params[0], params[1], params[2]
+		if n < 2 || n&(n-1) != 0 || r == 0 || p == 0 || p > maxScryptP || 128*uint64(n)*uint64(r) > maxScryptMemory {
+			return nil, ErrUnsupportedEnvelope
+		}
+		return &ScryptKDF{N: int(n), R: int(r), P: int(p), KeyLen: envelopeKeyLen}, nil
+	case kdfIDArgon2:
+		t, m, threads := params[0], params[1], params[2]
+		if t == 0 || t > maxArgon2Time || m < 8 || m > maxArgon2Memory || threads == 0 || threads > 255 {
+			return nil, ErrUnsupportedEnvelope
+		}
+		return &Argon2KDF{Time: t, Memory: m, Threads: uint8(threads), KeyLen: envelopeKeyLen}, nil
+	case kdfIDPBKDF2:
+		if params[0] == 0 || params[0] > maxPBKDF2Rounds || params[1] != 0 || params[2] != 0 {
+			return nil, ErrUnsupportedEnvelope
+		}
+		return &PBKDF2SHA256{Iterations: int(params[0]), KeyLen: envelopeKeyLen}, nil
+	}
+	return nil, ErrUnsupportedEnvelope
+}
+
+// describeKDF KDF 名称及参数，如 scrypt (N=32768, r=8, p=1)
+func describeKDF(kdf KDF) string {
+	switch k := kdf.(type) {
+	case *ScryptKDF:
+		return fmt.Sprintf("scrypt (N=%d, r=%d, p=%d)", k.N, k.R, k.P)
+	case *Argon2KDF:
+		return fmt.Sprintf("argon2id (t=%d, m=%d MiB, p=%d)", k.Time, k.Memory/1024, k.Threads)
+	case *PBKDF2SHA256:
+		return fmt.Sprintf("pbkdf2-sha256 (%d iterations)", k.Iterations)
+	}
+	return kdf.GetName()
+}
@@ -0,0 +1,171 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// testKDF 参数较低的 scrypt，测试中派生快
+func testKDF() *ScryptKDF {
+	return &ScryptKDF{N: 1024, R: 8, P: 1, KeyLen: 32, SaltLen: 16}
+}
+
+// 已知答案向量按格式说明用标准库独立构造：盐为 16 个 0x5a，nonce 为 12 个 0xa5，
+// 密码 correct horse，scrypt N=1024, r=8, p=1
+const (
+	// 旧格式：盐、nonce 和 AES-256-GCM 密文直接拼接，明文 legacy mnemonic
+	legacyAESVector = "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5aa5a5a5a5a5a5a5a5a5a5a5a55326ec3301175f09225b3f6bd158fc5459c72edbefdf59f3ff66fdd5c59401"
+	// 旧格式，ChaCha20-Poly1305，明文 legacy mnemonic
+	legacyChaChaVector = "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5aa5a5a5a5a5a5a5a5a5a5a5a54a337c8d9d40c153be51e39f4a7b9e2e5016efc005d1e0901e4905199386e3"
+	// 版本 1 信封，ChaCha20-Poly1305，明文 envelope mnemonic
+	envelopeVector = "534d454e56010201000004000000000800000001105a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a0ca5a5a5a5a5a5a5a5a5a5a5a543386d899256915bf059e095497d9325384399c739d9cfb3cf27060a5fc5d6b631"
+)
+
+const testPassword = "correct horse"
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	services := map[string]CryptoService{
+		"aes-256-gcm scrypt":       NewAESGCMService(testKDF()),
+		"chacha20-poly1305 argon2": NewChaCha20Poly1305Service(&Argon2KDF{Time: 1, Memory: 64, Threads: 1, KeyLen: 32, SaltLen: 16}),
+		"aes-256-gcm pbkdf2":       NewAESGCMService(&PBKDF2SHA256{Iterations: 1000, KeyLen: 32, SaltLen: 16}),
+	}
+	// 信封按头部记录的算法和参数解密，与解密方的配置无关
+	other := NewChaCha20Poly1305Service(&PBKDF2SHA256{Iterations: 1, KeyLen: 32, SaltLen: 8})
+	plaintext := []byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	for name, service := range services {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := service.Encrypt(plaintext, testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info, err := InspectCiphertext(encoded); err != nil || info.Version != EnvelopeVersion {
+				t.Fatalf("InspectCiphertext = %+v, %v, want version %d", info, err, EnvelopeVersion)
+			}
+			for _, decrypter := range []CryptoService{service, other} {
+				got, err := decrypter.Decrypt(encoded, testPassword)
+				if err != nil || !bytes.Equal(got, plaintext) {
+					t.Errorf("%s Decrypt = %q, %v, want the plaintext", decrypter.GetAlgorithm(), got, err)
+				}
+			}
+			if _, err := service.Decrypt(encoded, "wrong password"); !errors.Is(err, ErrDecryptionFailed) {
+				t.Errorf("Decrypt with a wrong password error = %v, want ErrDecryptionFailed", err)
+			}
+		})
+	}
+}
+
+func TestEnvelopeRejectsTampering(t *testing.T) {
+	service := NewAESGCMService(testKDF())
+	encoded, err := service.Encrypt([]byte("secret"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(encoded)
+
+	// 头部：标识 5 字节、版本、算法、KDF、三个 uint32 参数，然后是盐长度和盐、nonce 长度和 nonce
+	const (
+		cipherOffset = 6
+		paramOffset  = 8
+		saltOffset   = 21
+		nonceOffset  = saltOffset + 16 + 1
+		bodyOffset   = nonceOffset + 12
+	)
+	flips := map[string]int{
+		"magic":      0,
+		"cipher":     cipherOffset,
+		"kdf":        cipherOffset + 1,
+		"kdf param":  paramOffset + 2,
+		"salt":       saltOffset,
+		"nonce len":  nonceOffset - 1,
+		"nonce":      nonceOffset + 3,
+		"ciphertext": bodyOffset,
+		"tag":        len(data) - 1,
+	}
+	for name, offset := range flips {
+		tampered := append([]byte(nil), data...)
+		tampered[offset] ^= 0x0c
+		if got, err := service.Decrypt(hex.EncodeToString(tampered), testPassword); err == nil {
+			t.Errorf("Decrypt with tampered %s = %q, want an error", name, got)
+		}
+	}
+
+	// 伪造的 KDF 参数超出上限时不派生
+	oversized := append([]byte(nil), data...)
+	copy(oversized[paramOffset:], []byte{0x80, 0, 0, 0})
+	if info, _ := InspectCiphertext(hex.EncodeToString(oversized)); !info.Legacy() {
+		t.Errorf("envelope with scrypt N=2^31 parsed as %+v", info)
+	}
+	if _, err := service.Decrypt(hex.EncodeToString(oversized), testPassword); err == nil {
+		t.Error("Decrypt with oversized KDF parameters succeeded")
+	}
+
+	truncations := map[string]int{
+		"magic":       3,
+		"params":      paramOffset + 5,
+		"salt":        saltOffset + 4,
+		"nonce":       nonceOffset + 6,
+		"header only": bodyOffset,
+		"tag":         len(data) - 1,
+		"whole tag":   len(data) - 16,
+	}
+	for name, size := range truncations {
+		if got, err := service.Decrypt(hex.EncodeToString(data[:size]), testPassword); err == nil {
+			t.Errorf("Decrypt truncated in %s = %q, want an error", name, got)
+		}
+	}
+}
+
+func TestEnvelopeWrongVersion(t *testing.T) {
+	service := NewAESGCMService(testKDF())
+	encoded, err := service.Encrypt([]byte("secret"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(encoded)
+	data[len(envelopeMagic)] = EnvelopeVersion + 1
+	newer := hex.EncodeToString(data)
+
+	info, err := InspectCiphertext(newer)
+	if !errors.Is(err, ErrUnsupportedEnvelope) || info.Version != EnvelopeVersion+1 {
+		t.Errorf("InspectCiphertext of a newer envelope = %+v, %v, want version %d and ErrUnsupportedEnvelope", info, err, EnvelopeVersion+1)
+	}
+	if _, err := service.Decrypt(newer, testPassword); err == nil {
+		t.Error("Decrypt of a newer envelope succeeded")
+	}
+	if NeedsReencryption(newer) || IsLegacyCiphertext(newer) {
+		t.Error("newer envelope reported as legacy or below baseline")
+	}
+}
+
+func TestDecryptKnownAnswers(t *testing.T) {
+	tests := []struct {
+		name    string
+		service CryptoService
+		vector  string
+		want    string
+		legacy  bool
+	}{
+		{"legacy aes-256-gcm", NewAESGCMService(testKDF()), legacyAESVector, "legacy mnemonic", true},
+		{"legacy chacha20-poly1305", NewChaCha20Poly1305Service(testKDF()), legacyChaChaVector, "legacy mnemonic", true},
+		// 信封记录了算法和参数，由默认配置的服务解密
+		{"envelope", NewAESGCMService(NewScryptKDF()), envelopeVector, "envelope mnemonic", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.service.Decrypt(tt.vector, testPassword)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("Decrypt = %q, %v, want %q", got, err, tt.want)
+			}
+			if IsLegacyCiphertext(tt.vector) != tt.legacy {
+				t.Errorf("IsLegacyCiphertext = %v, want %v", !tt.legacy, tt.legacy)
+			}
+		})
+	}
+
+	info, err := InspectCiphertext(envelopeVector)
+	if err != nil || info.Cipher != "chacha20-poly1305" || info.KDF != "scrypt (N=1024, r=8, p=1)" {
+		t.Errorf("InspectCiphertext = %+v, %v, want chacha20-poly1305 with scrypt (N=1024, r=8, p=1)", info, err)
+	}
+}
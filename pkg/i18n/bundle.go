@@ -1,45 +1,199 @@
 package i18n
 
 import (
+	"embed"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/pelletier/go-toml/v2"
 	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
 	"gopkg.in/yaml.v3"
 )
 
+// 内置的翻译，外部目录中的同名语言按消息覆盖
+//
+//go:embed locales/*.yaml
+var embedded embed.FS
+
+// DefaultLanguage 缺少翻译时回退的语言，也是计算完整度的基准
+const DefaultLanguage = "en"
+
+// 错误定义
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// Locale 一种可用的语言
+type Locale struct {
+	Tag      string
+	Name     string   // 该语言自己的名称，如 Deutsch
+	Messages int      // 已翻译的基准语言消息数
+	Total    int      // 基准语言的消息数
+	Sources  []string // embedded 或外部文件的路径，后者覆盖前者
+}
+
+// Completeness 已翻译消息占基准语言消息的百分比
+func (l Locale) Completeness() float64 {
+	if l.Total == 0 {
+		return 0
+	}
+	return float64(l.Messages) * 100 / float64(l.Total)
+}
+
 var (
 	bundle      *i18n.Bundle
 	localizer   *i18n.Localizer
 	currentLang string
+	localesDir  string
+	catalogs    map[string]*catalog
 	mu          sync.RWMutex
 )
 
-func Init(configPath string) error {
+// catalog 一种语言已加载的消息 ID 和来源
+type catalog struct {
+	ids     map[string]bool
+	sources []string
+}
+
+// Init 加载内置翻译，再加载 dir 中的外部翻译文件（<语言>.json、.toml 或 .yaml，也可以是 active.<语言>.json），
+// 外部文件可以增加新语言或覆盖内置的消息。dir 为空或不存在时只使用内置翻译；
+// 无法解析的外部文件被跳过，错误一并返回，其余翻译照常可用
+func Init(dir string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
 	bundle = i18n.NewBundle(language.English)
 	bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
+	bundle.RegisterUnmarshalFunc("yml", yaml.Unmarshal)
+	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	catalogs = make(map[string]*catalog)
+	localesDir = dir
 
-	// 加载语言文件
-	languages := []string{"en", "zh", "ja"}
-	for _, lang := range languages {
-		_, err := bundle.LoadMessageFile(fmt.Sprintf("pkg/i18n/locales/active.%s.yaml", lang))
+	files, err := embedded.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		path := "locales/" + file.Name()
+		data, err := embedded.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to load language file for %s: %v", lang, err)
+			return err
+		}
+		if err := load(data, path, "embedded"); err != nil {
+			return fmt.Errorf("failed to load embedded language file %s: %w", file.Name(), err)
+		}
+	}
+
+	var errs []error
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".json", ".toml", ".yaml", ".yml":
+			default:
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			data, err := os.ReadFile(path)
+			if err == nil {
+				err = load(data, path, path)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
 		}
 	}
 
-	// 设置默认语言
-	SetLanguage("en")
+	if currentLang == "" {
+		currentLang = DefaultLanguage
+	}
+	localizer = i18n.NewLocalizer(bundle, currentLang)
+	return errors.Join(errs...)
+}
+
+// load 解析一个翻译文件并加入 bundle，调用方持有锁
+func load(data []byte, path, source string) error {
+	file, err := bundle.ParseMessageFileBytes(data, path)
+	if err != nil {
+		return err
+	}
+	if file.Tag == language.Und {
+		return fmt.Errorf("%w: name the file <language>.%s, e.g. de.%s", ErrUnknownLanguage, file.Format, file.Format)
+	}
+	tag := file.Tag.String()
+	if catalogs[tag] == nil {
+		catalogs[tag] = &catalog{ids: make(map[string]bool)}
+	}
+	for _, message := range file.Messages {
+		catalogs[tag].ids[message.ID] = true
+	}
+	catalogs[tag].sources = append(catalogs[tag].sources, source)
 	return nil
 }
 
+// SetLanguage 切换当前语言
 func SetLanguage(lang string) {
 	mu.Lock()
 	defer mu.Unlock()
 
 	currentLang = lang
-	localizer = i18n.NewLocalizer(bundle, lang)
+	if bundle != nil {
+		localizer = i18n.NewLocalizer(bundle, lang)
+	}
+}
+
+// Language 返回当前语言
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return currentLang
+}
+
+// Dir 返回外部翻译文件的目录，未设置时为空
+func Dir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return localesDir
+}
+
+// Locales 返回已加载的语言，基准语言在前，其余按标签排序
+func Locales() []Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	base := catalogs[DefaultLanguage]
+	locales := make([]Locale, 0, len(catalogs))
+	for tag, cat := range catalogs {
+		locale := Locale{Tag: tag, Name: tag, Sources: cat.sources}
+		if parsed, err := language.Parse(tag); err == nil {
+			if name := display.Self.Name(parsed); name != "" {
+				locale.Name = name
+			}
+		}
+		if base != nil {
+			locale.Total = len(base.ids)
+			for id := range base.ids {
+				if cat.ids[id] {
+					locale.Messages++
+				}
+			}
+		}
+		locales = append(locales, locale)
+	}
+	sort.Slice(locales, func(i, j int) bool {
+		if (locales[i].Tag == DefaultLanguage) != (locales[j].Tag == DefaultLanguage) {
+			return locales[i].Tag == DefaultLanguage
+		}
+		return locales[i].Tag < locales[j].Tag
+	})
+	return locales
 }
 
 func Tr(messageID string, args ...interface{}) string {
@@ -54,7 +208,9 @@ func Tr(messageID string, args ...interface{}) string {
 		MessageID: messageID,
 	})
 
-	if err != nil {
+	// 当前语言缺少的消息回退到英文，此时同时返回英文消息和 MessageNotFoundErr
+	var notFound *i18n.MessageNotFoundErr
+	if err != nil && (!errors.As(err, &notFound) || msg == "") {
 		return messageID
 	}
 
@@ -17,9 +17,10 @@ var (
 
 // Config 结构体与您的 TOML 配置匹配
 type Config struct {
-	Level    string `mapstructure:"level"`
-	Encoding string `mapstructure:"encoding"` // console 或 json
-	File     string `mapstructure:"file"`     // 对应配置中的 file 字段
+	Level    string       `mapstructure:"level"`
+	Encoding string       `mapstructure:"encoding"` // console 或 json
+	File     string       `mapstructure:"file"`     // 对应配置中的 file 字段
+	Remote   RemoteConfig `mapstructure:"remote"`   // 同时发送到 syslog 或 OpenTelemetry collector
 }
 
 // Init 初始化日志系统
@@ -64,6 +65,21 @@ func Init(config Config) error {
 
 		// 创建核心
 		core := zapcore.NewCore(encoder, writeSyncer, level)
+		if config.Remote.Protocol != "" {
+			if initErr = config.Remote.Validate(); initErr != nil {
+				return
+			}
+			sink, err := newRemoteSink(config.Remote)
+			if err != nil {
+				initErr = err
+				return
+			}
+			remoteLevel := level
+			if config.Remote.Level != "" {
+				remoteLevel.UnmarshalText([]byte(config.Remote.Level))
+			}
+			core = zapcore.NewTee(core, &remoteCore{LevelEnabler: remoteLevel, sink: sink})
+		}
 
 		// 创建 logger
 		logger = zap.New(core,
@@ -114,7 +130,7 @@ func Get() *zap.Logger {
 	return logger
 }
 
-// Sync 刷新日志缓冲区
+// Sync 刷新日志缓冲区，并发送远程日志队列中剩下的日志
 func Sync() {
 	if logger != nil {
 		_ = logger.Sync()
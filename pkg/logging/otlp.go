@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"time"
+
+	"github.com/palagend/slowmade/pkg/otlp"
+	"go.uber.org/zap/zapcore"
+)
+
+// otlpSender 通过 OTLP/gRPC 发送 ExportLogsServiceRequest
+type otlpSender struct {
+	client   *otlp.Client
+	resource []byte // 编码好的 Resource
+}
+
+func newOTLPSender(config RemoteConfig) (*otlpSender, error) {
+	client, err := otlp.NewClient(config.Address, config.tlsFiles(), config.Headers)
+	if err != nil {
+		return nil, err
+	}
+	service := config.ServiceName
+	if service == "" {
+		service = "slowmade"
+	}
+	return &otlpSender{client: client, resource: otlp.Resource(service, config.Version, hostname())}, nil
+}
+
+func (s *otlpSender) send(records []remoteRecord) error {
+	var scope []byte
+	scope = otlp.AppendMessage(scope, 1, otlp.Scope("github.com/palagend/slowmade/pkg/logging"))
+	for _, record := range records {
+		scope = otlp.AppendMessage(scope, 2, otlpLogRecord(record))
+	}
+	var resourceLogs []byte
+	resourceLogs = otlp.AppendMessage(resourceLogs, 1, s.resource)
+	resourceLogs = otlp.AppendMessage(resourceLogs, 2, scope)
+	return s.client.Export(otlp.LogsExportPath, otlp.AppendMessage(nil, 1, resourceLogs))
+}
+
+// otlpLogRecord 编码 opentelemetry.proto.logs.v1.LogRecord
+func otlpLogRecord(record remoteRecord) []byte {
+	var b []byte
+	b = otlp.AppendFixed64(b, 1, uint64(record.Time.UnixNano()))
+	b = otlp.AppendVarintField(b, 2, otlpSeverity(record.Level))
+	b = otlp.AppendString(b, 3, record.Level.CapitalString())
+	b = otlp.AppendMessage(b, 5, otlp.AppendString(nil, 1, record.Message))
+	if record.Caller != "" {
+		b = otlp.AppendMessage(b, 6, otlp.KeyValue("code.caller", record.Caller))
+	}
+	for _, field := range record.Fields {
+		b = otlp.AppendMessage(b, 6, otlp.KeyValue(field[0], field[1]))
+	}
+	return otlp.AppendFixed64(b, 11, uint64(time.Now().UnixNano()))
+}
+
+// otlpSeverity OTLP 的 SeverityNumber：DEBUG 5、INFO 9、WARN 13、ERROR 17、FATAL 21
+func otlpSeverity(level zapcore.Level) uint64 {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 5
+	case level == zapcore.InfoLevel:
+		return 9
+	case level == zapcore.WarnLevel:
+		return 13
+	case level == zapcore.ErrorLevel:
+		return 17
+	default:
+		return 21
+	}
+}
@@ -0,0 +1,299 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/palagend/slowmade/pkg/otlp"
+	"go.uber.org/zap/zapcore"
+)
+
+// RemoteConfig 远程日志配置，对应 log.remote，Protocol 为空时不发送
+type RemoteConfig struct {
+	Protocol     string            `mapstructure:"protocol"`      // syslog 或 otlp
+	Address      string            `mapstructure:"address"`       // syslog: udp://、tcp://、tls://host:port 或 unix:///dev/log；otlp: https:// 或 http://（明文 HTTP/2）host:4317
+	Level        string            `mapstructure:"level"`         // 发送的最低级别，为空时与 log.level 相同
+	CAFile       string            `mapstructure:"ca_file"`       // 校验服务器证书的 CA，为空时使用系统证书
+	CertFile     string            `mapstructure:"cert_file"`     // 客户端证书（双向 TLS）
+	KeyFile      string            `mapstructure:"key_file"`      // 客户端证书的私钥
+	Headers      map[string]string `mapstructure:"headers"`       // otlp 请求附带的 gRPC 元数据，如鉴权令牌
+	BatchSize    int               `mapstructure:"batch_size"`    // 攒够多少条发送一次
+	FlushSeconds int               `mapstructure:"flush_seconds"` // 不满一批时最多等待多少秒
+	QueueSize    int               `mapstructure:"queue_size"`    // 等待发送的最大条数，发送跟不上时丢弃新日志
+	Facility     string            `mapstructure:"facility"`      // syslog facility，如 daemon、local0
+	ServiceName  string            `mapstructure:"service_name"`  // syslog APP-NAME 和 OTLP service.name
+	ScrubFields  []string          `mapstructure:"scrub_fields"`  // 值总是被遮盖的字段名，在内置列表之外追加
+
+	Version string              `mapstructure:"-"` // OTLP service.version
+	Redact  func(string) string `mapstructure:"-"` // 遮盖消息和字段值中的私密内容
+}
+
+// scrubMask 被遮盖的字段值
+const scrubMask = "[REDACTED]"
+
+// defaultScrubFields 名称包含这些词的字段不发送原值
+var defaultScrubFields = []string{"password", "passphrase", "mnemonic", "seed", "private_key", "privkey", "xprv",
+	"secret", "token", "api_key", "authorization", "cookie"}
+
+// Validate 检查远程日志配置，不连接服务器
+func (c RemoteConfig) Validate() error {
+	if c.Protocol == "" {
+		return nil
+	}
+	if c.Address == "" {
+		return fmt.Errorf("log.remote.address is required for protocol %q", c.Protocol)
+	}
+	switch strings.ToLower(c.Protocol) {
+	case "syslog":
+		if _, _, err := syslogAddress(c.Address); err != nil {
+			return err
+		}
+		if _, ok := syslogFacilities[strings.ToLower(c.Facility)]; c.Facility != "" && !ok {
+			return fmt.Errorf("log.remote.facility %q is not a syslog facility", c.Facility)
+		}
+	case "otlp":
+		if !otlp.ValidAddress(c.Address) {
+			return fmt.Errorf("log.remote.address %q must be https://host:port or http://host:port for otlp", c.Address)
+		}
+	default:
+		return fmt.Errorf("log.remote.protocol %q must be syslog or otlp", c.Protocol)
+	}
+	if c.Level != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+			return fmt.Errorf("log.remote.level %q is not a valid level", c.Level)
+		}
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("log.remote.cert_file and log.remote.key_file must be set together")
+	}
+	return nil
+}
+
+// tlsFiles 服务器 CA 和客户端证书
+func (c RemoteConfig) tlsFiles() otlp.TLSFiles {
+	return otlp.TLSFiles{CAFile: c.CAFile, CertFile: c.CertFile, KeyFile: c.KeyFile}
+}
+
+// remoteRecord 一条待发送的日志，字段值已经遮盖并转为字符串
+type remoteRecord struct {
+	Time    time.Time
+	Level   zapcore.Level
+	Message string
+	Caller  string
+	Fields  [][2]string
+}
+
+// sender 把一批日志发送到远程服务器，连接失败时下次调用重新连接
+type sender interface {
+	send(records []remoteRecord) error
+}
+
+// remoteSink 后台按批发送日志；队列满或发送失败时丢弃，并每分钟最多在标准错误输出提示一次
+type remoteSink struct {
+	sender    sender
+	queue     chan remoteRecord
+	flushes   chan chan struct{}
+	batchSize int
+	interval  time.Duration
+	scrub     map[string]bool
+	redact    func(string) string
+	dropped   atomic.Int64
+	reported  time.Time
+}
+
+func newRemoteSink(config RemoteConfig) (*remoteSink, error) {
+	var (
+		s   sender
+		err error
+	)
+	if strings.EqualFold(config.Protocol, "syslog") {
+		s, err = newSyslogSender(config)
+	} else {
+		s, err = newOTLPSender(config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("log.remote: %w", err)
+	}
+	sink := &remoteSink{
+		sender:    s,
+		queue:     make(chan remoteRecord, max(config.QueueSize, 1)),
+		flushes:   make(chan chan struct{}),
+		batchSize: max(config.BatchSize, 1),
+		interval:  time.Duration(max(config.FlushSeconds, 1)) * time.Second,
+		scrub:     make(map[string]bool),
+		redact:    config.Redact,
+	}
+	for _, name := range append(append([]string(nil), defaultScrubFields...), config.ScrubFields...) {
+		sink.scrub[strings.ToLower(name)] = true
+	}
+	if sink.redact == nil {
+		sink.redact = func(s string) string { return s }
+	}
+	go sink.run()
+	return sink, nil
+}
+
+func (s *remoteSink) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	batch := make([]remoteRecord, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// 重试一次：连接可能在两批之间被服务器关闭
+		if err := s.sender.send(batch); err != nil {
+			if err = s.sender.send(batch); err != nil {
+				s.dropped.Add(int64(len(batch)))
+				s.report(err)
+			}
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-s.flushes:
+			for drained := false; !drained; {
+				select {
+				case record := <-s.queue:
+					batch = append(batch, record)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(done)
+		}
+	}
+}
+
+// report 不能写日志（会再次进入远程发送），直接写标准错误
+func (s *remoteSink) report(err error) {
+	if time.Since(s.reported) < time.Minute {
+		return
+	}
+	s.reported = time.Now()
+	fmt.Fprintf(os.Stderr, "remote logging: %v (%d entries dropped so far)\n", err, s.dropped.Load())
+}
+
+// flush 发送队列中的全部日志，最多等待 5 秒
+func (s *remoteSink) flush() error {
+	done := make(chan struct{})
+	select {
+	case s.flushes <- done:
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("remote logging: flush timed out")
+	}
+	select {
+	case <-done:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("remote logging: flush timed out")
+	}
+}
+
+// scrubField 遮盖私密字段名的值，其他值交给 Redact
+func (s *remoteSink) scrubField(key, value string) string {
+	lower := strings.ToLower(key)
+	for name := range s.scrub {
+		if strings.Contains(lower, name) {
+			return scrubMask
+		}
+	}
+	return s.redact(value)
+}
+
+// remoteCore 把日志交给 remoteSink 的 zapcore.Core，写入不阻塞
+type remoteCore struct {
+	zapcore.LevelEnabler
+	sink   *remoteSink
+	fields []zapcore.Field
+}
+
+func (c *remoteCore) With(fields []zapcore.Field) zapcore.Core {
+	return &remoteCore{LevelEnabler: c.LevelEnabler, sink: c.sink, fields: append(append([]zapcore.Field(nil), c.fields...), fields...)}
+}
+
+func (c *remoteCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *remoteCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	record := remoteRecord{Time: entry.Time, Level: entry.Level, Message: c.sink.redact(entry.Message)}
+	if entry.Caller.Defined {
+		record.Caller = entry.Caller.TrimmedPath()
+	}
+	keys := make([]string, 0, len(encoder.Fields))
+	for key := range encoder.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.Fields = append(record.Fields, [2]string{key, c.sink.scrubField(key, fieldString(encoder.Fields[key]))})
+	}
+	if entry.Stack != "" {
+		record.Fields = append(record.Fields, [2]string{"stacktrace", c.sink.redact(entry.Stack)})
+	}
+	select {
+	case c.sink.queue <- record:
+	default:
+		c.sink.dropped.Add(1)
+	}
+	return nil
+}
+
+func (c *remoteCore) Sync() error {
+	return c.sink.flush()
+}
+
+// fieldString 字段值的文本形式，数组和对象编码为 JSON
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// hostname 发送日志的主机名，取不到时为 -
+var hostname = sync.OnceValue(func() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "-"
+	}
+	return name
+})
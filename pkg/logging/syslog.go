@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogFacilities 常用的 syslog facility 编号
+var syslogFacilities = map[string]int{
+	"user": 1, "daemon": 3, "auth": 4, "authpriv": 10,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSDID 结构化数据的 SD-ID，32473 是 RFC 5612 留给文档和示例的企业号
+const syslogSDID = "fields@32473"
+
+// syslogSender 按 RFC 5424 格式发送：UDP 和 Unix 数据报每条一个报文，
+// TCP 和 TLS 按 RFC 6587 的长度前缀分帧，一批写一次
+type syslogSender struct {
+	network  string
+	address  string
+	tls      *tls.Config
+	facility int
+	appName  string
+	conn     net.Conn
+}
+
+// syslogAddress 解析 udp://、tcp://、tls:// 或 unix:// 地址，返回网络类型和地址
+func syslogAddress(address string) (string, string, error) {
+	u, err := url.Parse(address)
+	if err == nil {
+		switch u.Scheme {
+		case "udp", "tcp", "tls":
+			if u.Host != "" {
+				return u.Scheme, u.Host, nil
+			}
+		case "unix":
+			if u.Path != "" {
+				return "unixgram", u.Path, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("log.remote.address %q must be udp://, tcp://, tls://host:port or unix:///path for syslog", address)
+}
+
+func newSyslogSender(config RemoteConfig) (*syslogSender, error) {
+	network, address, err := syslogAddress(config.Address)
+	if err != nil {
+		return nil, err
+	}
+	s := &syslogSender{network: network, address: address, facility: syslogFacilities["daemon"], appName: "slowmade"}
+	if facility, ok := syslogFacilities[strings.ToLower(config.Facility)]; ok {
+		s.facility = facility
+	}
+	if config.ServiceName != "" {
+		s.appName = config.ServiceName
+	}
+	if network == "tls" {
+		host, _, _ := net.SplitHostPort(address)
+		if s.tls, err = config.tlsFiles().Config(host); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *syslogSender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if s.network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", s.address, s.tls)
+	}
+	return dialer.Dial(s.network, s.address)
+}
+
+func (s *syslogSender) send(records []remoteRecord) error {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	var err error
+	if s.network == "tcp" || s.network == "tls" {
+		var buf bytes.Buffer
+		for _, record := range records {
+			message := s.format(record)
+			fmt.Fprintf(&buf, "%d %s", len(message), message)
+		}
+		_, err = s.conn.Write(buf.Bytes())
+	} else {
+		for _, record := range records {
+			if _, err = s.conn.Write([]byte(s.format(record))); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// format 一条 RFC 5424 消息：<PRI>1 时间 主机 应用 进程号 - [字段] 消息
+func (s *syslogSender) format(record remoteRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - ", s.facility*8+syslogSeverity(record.Level),
+		record.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), hostname(), s.appName, os.Getpid())
+	if record.Caller == "" && len(record.Fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + syslogSDID)
+		if record.Caller != "" {
+			fmt.Fprintf(&b, ` caller="%s"`, syslogEscape(record.Caller))
+		}
+		for _, field := range record.Fields {
+			fmt.Fprintf(&b, ` %s="%s"`, syslogParamName(field[0]), syslogEscape(field[1]))
+		}
+		b.WriteString("]")
+	}
+	b.WriteString(" " + record.Message)
+	return b.String()
+}
+
+// syslogSeverity zap 级别对应的 syslog 严重程度
+func syslogSeverity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 7
+	case level == zapcore.InfoLevel:
+		return 6
+	case level == zapcore.WarnLevel:
+		return 4
+	case level == zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// syslogParamName PARAM-NAME 只能是不含 = ] " 和空格的可打印 ASCII，最长 32 个字符
+func syslogParamName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(mapped) > 32 {
+		mapped = mapped[:32]
+	}
+	return mapped
+}
+
+// syslogEscape PARAM-VALUE 中的 " \ ] 需要转义
+func syslogEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
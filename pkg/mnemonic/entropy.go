@@ -0,0 +1,220 @@
+package mnemonic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 错误定义
+var (
+	ErrRNGUnhealthy = errors.New("system random number generator failed the self-test, refusing to generate keys")
+	ErrInvalidDice  = errors.New("dice rolls must be digits 1 to 6")
+	ErrTooMuch      = errors.New("an extra entropy source provides at most 32 bytes")
+)
+
+// EntropySource 额外的熵来源，与系统随机数异或后生成助记词。
+// 异或不会降低熵：即使来源被攻击者控制，结果也至少和系统随机数一样随机
+type EntropySource interface {
+	Name() string
+	Entropy(n int) ([]byte, error)
+}
+
+// DiceSource 用户掷骰子的结果，如 "3 5 1 6 ..."，经 SHA-256 压缩
+type DiceSource struct {
+	Rolls string
+}
+
+// ParseDice 解析骰子结果，忽略空白和逗号
+func ParseDice(text string) (*DiceSource, error) {
+	var rolls strings.Builder
+	for _, c := range text {
+		switch {
+		case c >= '1' && c <= '6':
+			rolls.WriteRune(c)
+		case c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDice, c)
+		}
+	}
+	if rolls.Len() == 0 {
+		return nil, fmt.Errorf("%w: no rolls", ErrInvalidDice)
+	}
+	return &DiceSource{Rolls: rolls.String()}, nil
+}
+
+// Bits 骰子提供的熵的位数，每次约 2.585 位
+func (d *DiceSource) Bits() int {
+	return len(d.Rolls) * 2585 / 1000
+}
+
+func (d *DiceSource) Name() string {
+	return fmt.Sprintf("%d dice rolls", len(d.Rolls))
+}
+
+func (d *DiceSource) Entropy(n int) ([]byte, error) {
+	return hashed("slowmade-dice:", []byte(d.Rolls), n)
+}
+
+// FileSource 用户提供的文件（如录音、照片），整个文件经 SHA-256 压缩
+type FileSource struct {
+	Path string
+}
+
+func (f *FileSource) Name() string {
+	return "file " + f.Path
+}
+
+func (f *FileSource) Entropy(n int) ([]byte, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	hash.Write([]byte("slowmade-file:"))
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%s is empty", f.Path)
+	}
+	if n > sha256.Size {
+		return nil, ErrTooMuch
+	}
+	return hash.Sum(nil)[:n], nil
+}
+
+// DeviceSource 硬件随机数设备，如 Linux 的 /dev/hwrng，直接读取 n 字节
+type DeviceSource struct {
+	Path string
+}
+
+func (d *DeviceSource) Name() string {
+	return "device " + d.Path
+}
+
+func (d *DeviceSource) Entropy(n int) ([]byte, error) {
+	file, err := os.Open(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", d.Path, err)
+	}
+	if constant(buf) {
+		return nil, fmt.Errorf("%s returned constant bytes, the device looks broken", d.Path)
+	}
+	return buf, nil
+}
+
+func hashed(domain string, data []byte, n int) ([]byte, error) {
+	if n > sha256.Size {
+		return nil, ErrTooMuch
+	}
+	sum := sha256.Sum256(append([]byte(domain), data...))
+	return sum[:n], nil
+}
+
+// MixEntropy 生成 n 字节系统随机数，并依次异或每个额外来源
+func MixEntropy(n int, sources ...EntropySource) ([]byte, error) {
+	if err := CheckRNG(); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, n)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	if constant(entropy) || repeated(entropy) {
+		clear(entropy)
+		return nil, fmt.Errorf("%w: repeated output", ErrRNGUnhealthy)
+	}
+	for _, source := range sources {
+		extra, err := source.Entropy(n)
+		if err != nil {
+			clear(entropy)
+			return nil, fmt.Errorf("%s: %w", source.Name(), err)
+		}
+		for i := range entropy {
+			entropy[i] ^= extra[i]
+		}
+		clear(extra)
+	}
+	return entropy, nil
+}
+
+// 自检参数：读取 selfTestBlocks 块、每块 32 字节
+const (
+	selfTestBlocks    = 64
+	selfTestBlockSize = 32
+)
+
+var (
+	rngOnce sync.Once
+	rngErr  error
+
+	// lastDigest 上一次生成的随机数的摘要，连续两次相同说明随机数来源已经损坏
+	lastMu     sync.Mutex
+	lastDigest [sha256.Size]byte
+)
+
+func repeated(entropy []byte) bool {
+	digest := sha256.Sum256(entropy)
+	lastMu.Lock()
+	defer lastMu.Unlock()
+	same := digest == lastDigest
+	lastDigest = digest
+	return same
+}
+
+// CheckRNG 第一次调用时对系统随机数做自检，之后返回缓存的结果。启动时调用一次，
+// 生成密钥前再检查结果；自检失败时不允许生成助记词
+func CheckRNG() error {
+	rngOnce.Do(func() {
+		rngErr = SelfTest(rand.Reader)
+	})
+	return rngErr
+}
+
+// SelfTest 检查随机数来源是否明显损坏：读取失败、块内字节全部相同、块之间重复，
+// 或全部输出中 1 的比例严重偏离一半（超过约 8 个标准差）
+func SelfTest(reader io.Reader) error {
+	seen := make(map[[selfTestBlockSize]byte]bool, selfTestBlocks)
+	ones := 0
+	for i := 0; i < selfTestBlocks; i++ {
+		var block [selfTestBlockSize]byte
+		if _, err := io.ReadFull(reader, block[:]); err != nil {
+			return fmt.Errorf("%w: %v", ErrRNGUnhealthy, err)
+		}
+		if constant(block[:]) {
+			return fmt.Errorf("%w: constant output", ErrRNGUnhealthy)
+		}
+		if seen[block] {
+			return fmt.Errorf("%w: repeated output", ErrRNGUnhealthy)
+		}
+		seen[block] = true
+		for _, b := range block {
+			ones += bits.OnesCount8(b)
+		}
+	}
+	total := selfTestBlocks * selfTestBlockSize * 8
+	// 二项分布的标准差为 sqrt(total)/2，total=16384 时为 64
+	if deviation := ones - total/2; deviation > 512 || deviation < -512 {
+		return fmt.Errorf("%w: biased output (%d of %d bits set)", ErrRNGUnhealthy, ones, total)
+	}
+	return nil
+}
+
+func constant(data []byte) bool {
+	return len(data) > 1 && bytes.Count(data, data[:1]) == len(data)
+}
@@ -4,6 +4,7 @@ package mnemonic
 import (
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/tyler-smith/go-bip39"
@@ -11,7 +12,7 @@ import (
 
 // MnemonicService 助记词服务接口
 type MnemonicService interface {
-	GenerateMnemonic(strength int) (string, error)
+	GenerateMnemonic(strength int, extra ...EntropySource) (string, error)
 	GenerateSeedFromMnemonic(mnemonic, cloak string) []byte
 }
 
@@ -27,18 +28,19 @@ func NewBIP39MnemonicService() *BIP39MnemonicService {
 	}
 }
 
-// GenerateMnemonic 生成助记词
-func (ms *BIP39MnemonicService) GenerateMnemonic(strength int) (string, error) {
+// GenerateMnemonic 生成助记词，extra 中的来源与系统随机数异或；系统随机数自检失败时拒绝生成
+func (ms *BIP39MnemonicService) GenerateMnemonic(strength int, extra ...EntropySource) (string, error) {
 	// 强度必须是32的倍数，且在128-256之间
 	if strength%32 != 0 || strength < 128 || strength > 256 {
 		return "", errors.New("强度必须是128, 160, 192, 224, 或256")
 	}
 
 	// 生成熵
-	entropy, err := bip39.NewEntropy(strength)
+	entropy, err := MixEntropy(strength/8, extra...)
 	if err != nil {
 		return "", err
 	}
+	defer clear(entropy)
 
 	// 计算校验和
 	checksum := ms.calculateChecksum(entropy)
@@ -77,9 +79,9 @@ func (ms *BIP39MnemonicService) entropyToMnemonic(entropy []byte) string {
 	return strings.Join(words, " ")
 }
 
-// GenerateSeedFromMnemonic 从助记词生成种子
+// GenerateSeedFromMnemonic 从助记词生成种子，助记词先按 Normalize 规范化
 func (ms *BIP39MnemonicService) GenerateSeedFromMnemonic(mnemonic, cloak string) []byte {
-	return bip39.NewSeed(mnemonic, cloak)
+	return bip39.NewSeed(Normalize(mnemonic), cloak)
 }
 
 // 工具方法
@@ -105,6 +107,28 @@ func (ms *BIP39MnemonicService) bitsToInt(bits []int) int {
 	return value
 }
 
+// CheckWordList 检查单词表完整：2048 个按字母排序的小写单词，前四个字母互不相同
+func (ms *BIP39MnemonicService) CheckWordList() error {
+	if len(ms.wordList) != 2048 {
+		return fmt.Errorf("单词表应有 2048 个单词，实际 %d 个", len(ms.wordList))
+	}
+	prefixes := make(map[string]bool, len(ms.wordList))
+	for i, word := range ms.wordList {
+		if word == "" || strings.Trim(word, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return fmt.Errorf("第 %d 个单词 %q 无效", i+1, word)
+		}
+		if i > 0 && word <= ms.wordList[i-1] {
+			return fmt.Errorf("第 %d 个单词 %q 重复或未排序", i+1, word)
+		}
+		prefix := word[:min(4, len(word))]
+		if prefixes[prefix] {
+			return fmt.Errorf("第 %d 个单词 %q 的前四个字母与其他单词相同", i+1, word)
+		}
+		prefixes[prefix] = true
+	}
+	return nil
+}
+
 func (ms *BIP39MnemonicService) isWordInList(word string) bool {
 	for _, w := range ms.wordList {
 		if w == word {
@@ -0,0 +1,45 @@
+package mnemonic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 错误定义
+var (
+	ErrWordCount   = errors.New("a mnemonic has 12, 15, 18, 21 or 24 words")
+	ErrUnknownWord = errors.New("not in the BIP39 word list")
+	ErrChecksum    = errors.New("mnemonic checksum does not match, a word is wrong or the words are out of order")
+)
+
+// invisible 从 PDF、网页和手机复制时常夹带的不可见字符：BOM、零宽空格和连接符、软连字符
+var invisible = strings.NewReplacer("\ufeff", "", "\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\u00ad", "")
+
+// Normalize 整理输入的助记词：去掉不可见字符，按 BIP39 的要求做 NFKD 规范化（日文单词表依赖它，
+// 同时把全角字母和连字拆回普通字母），转为小写，换行、制表符、不换行空格和全角空格等任意空白合并为一个空格
+func Normalize(phrase string) string {
+	phrase = norm.NFKD.String(invisible.Replace(phrase))
+	return strings.Join(strings.Fields(strings.ToLower(phrase)), " ")
+}
+
+// Validate 规范化后校验单词数量、单词表和校验和，返回规范化的助记词；出错时指出第几个单词不对
+func Validate(phrase string) (string, error) {
+	phrase = Normalize(phrase)
+	words := strings.Fields(phrase)
+	if n := len(words); n%3 != 0 || n < 12 || n > 24 {
+		return "", fmt.Errorf("%w, got %d", ErrWordCount, n)
+	}
+	for i, word := range words {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return "", fmt.Errorf("word %d %q is %w", i+1, word, ErrUnknownWord)
+		}
+	}
+	if !bip39.IsMnemonicValid(phrase) {
+		return "", ErrChecksum
+	}
+	return phrase, nil
+}
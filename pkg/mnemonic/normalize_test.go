@@ -0,0 +1,72 @@
+package mnemonic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// valid12 BIP39 测试向量中的 12 词助记词
+const valid12 = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"already normalized", valid12},
+		{"leading BOM", "\ufeff" + valid12},
+		{"zero width characters", "aban\u200bdon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon ab\u00adout\u2060"},
+		{"no-break spaces", strings.ReplaceAll(valid12, " ", "\u00a0")},
+		{"ideographic spaces", strings.ReplaceAll(valid12, " ", "\u3000")},
+		{"fullwidth letters", "\uff41\uff42\uff41\uff4e\uff44\uff4f\uff4e abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon \uff21\uff22\uff2f\uff35\uff34"},
+		{"CRLF line endings", strings.ReplaceAll(valid12, " ", "\r\n")},
+		{"one word per line", "\n" + strings.ReplaceAll(valid12, " ", "\n") + "\n"},
+		{"numbered columns with tabs", strings.ReplaceAll(valid12, " ", " \t ")},
+		{"mixed case", "Abandon ABANDON abandon aBandon abandon abandon abandon abandon abandon abandon abandon About"},
+		{"surrounding whitespace", "  \t" + valid12 + " \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.input); got != valid12 {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, valid12)
+			}
+			got, err := Validate(tt.input)
+			if err != nil {
+				t.Fatalf("Validate(%q) error: %v", tt.input, err)
+			}
+			if got != valid12 {
+				t.Errorf("Validate(%q) = %q, want %q", tt.input, got, valid12)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"invalid checksum", strings.Repeat("abandon ", 11) + "abandon", ErrChecksum},
+		{"words out of order", "about " + strings.Repeat("abandon ", 11), ErrChecksum},
+		{"unknown word", strings.Repeat("abandon ", 11) + "bitcoin", ErrUnknownWord},
+		{"too few words", strings.Repeat("abandon ", 11), ErrWordCount},
+		{"word count not a multiple of three", strings.Repeat("abandon ", 13), ErrWordCount},
+		{"empty", "\ufeff \u00a0\r\n", ErrWordCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Validate(tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("Validate(%q) error = %v, want %v", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsWordPosition(t *testing.T) {
+	_, err := Validate("abandon abandon abandonn abandon abandon abandon abandon abandon abandon abandon abandon about")
+	if err == nil || !strings.Contains(err.Error(), `word 3 "abandonn"`) {
+		t.Errorf("Validate error = %v, want it to name word 3", err)
+	}
+}
@@ -0,0 +1,129 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// gRPC 方法
+const (
+	LogsExportPath   = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	TracesExportPath = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+)
+
+// TLSFiles 校验服务器证书的 CA 和双向 TLS 的客户端证书，都为空时使用系统证书
+type TLSFiles struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// Config 构造 TLS 配置
+func (f TLSFiles) Config(serverName string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+	if f.CAFile != "" {
+		pem, err := os.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", f.CAFile)
+		}
+	}
+	if f.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// ValidAddress 检查 collector 地址：https:// 或 http://（明文 HTTP/2）host:port
+func ValidAddress(address string) bool {
+	u, err := url.Parse(address)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// Client 调用一个 collector 的 gRPC 方法，http:// 地址使用明文 HTTP/2（h2c），与 collector 默认的 4317 端口相同
+type Client struct {
+	base    string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewClient 创建 collector 客户端，headers 作为 gRPC 元数据随每个请求发送（如鉴权令牌）
+func NewClient(address string, files TLSFiles, headers map[string]string) (*Client, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{ForceAttemptHTTP2: true, Protocols: new(http.Protocols)}
+	if u.Scheme == "https" {
+		if transport.TLSClientConfig, err = files.Config(u.Hostname()); err != nil {
+			return nil, err
+		}
+		transport.Protocols.SetHTTP2(true)
+	} else {
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return &Client{
+		base:    strings.TrimSuffix(u.Scheme+"://"+u.Host+u.Path, "/"),
+		headers: headers,
+		client:  &http.Client{Transport: transport, Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Export 发送一个已编码的请求消息，gRPC 状态不是 OK 时返回错误
+func (c *Client) Export(path string, request []byte) error {
+	// gRPC 报文：1 字节压缩标志、4 字节长度、protobuf
+	body := make([]byte, 5, 5+len(request))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(request)))
+	body = append(body, request...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 状态在 trailer 中，读完响应体才能取到
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("otlp collector returned HTTP %d", resp.StatusCode)
+	}
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status != "0" {
+		if message, err := url.PathUnescape(message); err == nil && message != "" {
+			return fmt.Errorf("otlp collector returned gRPC status %s: %s", status, message)
+		}
+		return fmt.Errorf("otlp collector returned gRPC status %q", status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package otlp 向 OpenTelemetry collector 发送 OTLP/gRPC 请求：只手工编码日志和链路追踪用到的
+// protobuf 字段，经标准库的 HTTP/2 客户端调用，不依赖 gRPC 和 OpenTelemetry SDK
+package otlp
+
+import "encoding/binary"
+
+// AppendVarint 追加一个 varint
+func AppendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// AppendVarintField 追加 varint 类型的字段（整数、枚举）
+func AppendVarintField(b []byte, field int, v uint64) []byte {
+	return AppendVarint(AppendVarint(b, uint64(field)<<3), v)
+}
+
+// AppendFixed64 追加 fixed64 类型的字段（时间戳）
+func AppendFixed64(b []byte, field int, v uint64) []byte {
+	b = AppendVarint(b, uint64(field)<<3|1)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+// AppendString 追加字符串字段
+func AppendString(b []byte, field int, s string) []byte {
+	return AppendMessage(b, field, []byte(s))
+}
+
+// AppendMessage 追加已编码的子消息或 bytes 字段
+func AppendMessage(b []byte, field int, message []byte) []byte {
+	b = AppendVarint(b, uint64(field)<<3|2)
+	b = AppendVarint(b, uint64(len(message)))
+	return append(b, message...)
+}
+
+// KeyValue 编码值为字符串的 opentelemetry.proto.common.v1.KeyValue
+func KeyValue(key, value string) []byte {
+	b := AppendString(nil, 1, key)
+	return AppendMessage(b, 2, AppendString(nil, 1, value))
+}
+
+// Resource 编码带 service.name、service.version（为空时省略）和 host.name 属性的 Resource
+func Resource(service, version, host string) []byte {
+	var b []byte
+	b = AppendMessage(b, 1, KeyValue("service.name", service))
+	if version != "" {
+		b = AppendMessage(b, 1, KeyValue("service.version", version))
+	}
+	return AppendMessage(b, 1, KeyValue("host.name", host))
+}
+
+// Scope 编码 InstrumentationScope
+func Scope(name string) []byte {
+	return AppendString(nil, 1, name)
+}
@@ -0,0 +1,256 @@
+// Package qr 实现 QR 码（ISO/IEC 18004）编码，支持字母数字和字节模式、版本 1-40
+package qr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Level 纠错等级
+type Level int
+
+const (
+	LevelL Level = iota // 约 7% 纠错能力
+	LevelM              // 约 15%
+	LevelQ              // 约 25%
+	LevelH              // 约 30%
+)
+
+// ErrTooLarge 数据超出版本 40 的容量
+var ErrTooLarge = errors.New("data too large for a QR code")
+
+// 每个纠错块的纠错码字数，按 [等级][版本] 索引
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// 纠错块数，按 [等级][版本] 索引
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// 格式信息中的纠错等级编码
+var levelFormatBits = [4]int{1, 0, 3, 2}
+
+const alphanumericCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Code 编码完成的二维码
+type Code struct {
+	Version int
+	Size    int
+	Level   Level
+
+	modules    []bool // 深色模块为 true，按行存储
+	isFunction []bool // 定位、校准、格式信息等功能图形，不参与数据填充和掩码
+}
+
+// Encode 选择能容纳数据的最小版本进行编码；数据全部为大写字母数字字符时使用字母数字模式
+func Encode(data []byte, level Level) (*Code, error) {
+	alnum := isAlphanumeric(data)
+	for version := 1; version <= 40; version++ {
+		capacity := numDataCodewords(version, level) * 8
+		if segmentBits(len(data), version, alnum) > capacity {
+			continue
+		}
+		bits := encodeSegment(data, version, alnum)
+		codewords := bits.pad(capacity)
+		return newCode(version, level, addErrorCorrection(codewords, version, level)), nil
+	}
+	return nil, ErrTooLarge
+}
+
+// Black 返回 (x, y) 处是否为深色模块，x 为列、y 为行
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+func isAlphanumeric(data []byte) bool {
+	for _, b := range data {
+		if strings.IndexByte(alphanumericCharset, b) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// charCountBits 字符计数指示符的位数
+func charCountBits(version int, alnum bool) int {
+	switch {
+	case alnum && version <= 9:
+		return 9
+	case alnum && version <= 26:
+		return 11
+	case alnum:
+		return 13
+	case version <= 9:
+		return 8
+	default:
+		return 16
+	}
+}
+
+func segmentBits(n, version int, alnum bool) int {
+	if n >= 1<<charCountBits(version, alnum) {
+		return 1 << 30
+	}
+	if alnum {
+		return 4 + charCountBits(version, alnum) + n/2*11 + n%2*6
+	}
+	return 4 + charCountBits(version, alnum) + n*8
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+// pad 追加终止符、补齐到字节边界并以 0xEC/0x11 填充到 capacity 位
+func (b bitBuffer) pad(capacity int) []byte {
+	terminator := capacity - len(b)
+	if terminator > 4 {
+		terminator = 4
+	}
+	b.append(0, terminator)
+	b.append(0, (8-len(b)%8)%8)
+	for padByte := 0xEC; len(b) < capacity; padByte ^= 0xEC ^ 0x11 {
+		b.append(padByte, 8)
+	}
+
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return result
+}
+
+func encodeSegment(data []byte, version int, alnum bool) bitBuffer {
+	var bits bitBuffer
+	if alnum {
+		bits.append(0x2, 4)
+		bits.append(len(data), charCountBits(version, alnum))
+		for i := 0; i+1 < len(data); i += 2 {
+			a := strings.IndexByte(alphanumericCharset, data[i])
+			b := strings.IndexByte(alphanumericCharset, data[i+1])
+			bits.append(a*45+b, 11)
+		}
+		if len(data)%2 == 1 {
+			bits.append(strings.IndexByte(alphanumericCharset, data[len(data)-1]), 6)
+		}
+		return bits
+	}
+	bits.append(0x4, 4)
+	bits.append(len(data), charCountBits(version, alnum))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	return bits
+}
+
+// numRawDataModules 除功能图形外可用于数据和纠错码的模块数
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// addErrorCorrection 分块计算 Reed-Solomon 纠错码并交织
+func addErrorCorrection(data []byte, version int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - eccLen
+
+	generator := rsGenerator(eccLen)
+	dataBlocks := make([][]byte, numBlocks)
+	eccBlocks := make([][]byte, numBlocks)
+	offset := 0
+	for i := range dataBlocks {
+		n := shortDataLen
+		if i >= numShortBlocks {
+			n++
+		}
+		dataBlocks[i] = data[offset : offset+n]
+		eccBlocks[i] = rsRemainder(dataBlocks[i], generator)
+		offset += n
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i <= shortDataLen; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// gfMultiply GF(2^8) 乘法，本原多项式 x^8+x^4+x^3+x^2+1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsGenerator 返回 degree 次生成多项式的系数（最高次项系数 1 省略）
+func rsGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(generator[i], factor)
+		}
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// QuietZone 渲染时四周保留的空白模块数
+const QuietZone = 4
+
+// ASCII 使用半高块字符渲染，每个字符对应上下两个模块。
+// 浅色模块输出为块字符，适用于深色背景的终端
+func (c *Code) ASCII() string {
+	var sb strings.Builder
+	for y := -QuietZone; y < c.Size+QuietZone; y += 2 {
+		for x := -QuietZone; x < c.Size+QuietZone; x++ {
+			top, bottom := !c.Black(x, y), !c.Black(x, y+1)
+			if y+1 >= c.Size+QuietZone {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Image 返回黑白图像，scale 为每个模块的像素边长
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Black(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// PNG 将二维码编码为 PNG 写入 w
+func (c *Code) PNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+// SVG 将二维码编码为 SVG 写入 w，scale 为每个模块的边长（用户单位）；
+// 每行连续的深色模块合并为一段路径，文件小且缩放不失真
+func (c *Code) SVG(w io.Writer, scale int) error {
+	return c.StyledSVG(w, scale, Style{})
+}
+
+// Sixel 使用 DEC sixel 图形渲染，scale 为每个模块的像素边长，适用于 xterm -ti vt340、foot、mlterm、WezTerm 等终端
+func (c *Code) Sixel(scale int) string {
+	img := c.Image(scale).(*image.Paletted)
+	side := img.Bounds().Dx()
+	var sb strings.Builder
+	// 宽高比 1:1，背景不透明；颜色 0 为白色、1 为黑色（RGB 百分比）
+	fmt.Fprintf(&sb, "\x1bP0;1;0q\"1;1;%d;%d#0;2;100;100;100#1;2;0;0;0", side, side)
+	for band := 0; band < side; band += 6 {
+		for index := uint8(0); index < 2; index++ {
+			fmt.Fprintf(&sb, "#%d", index)
+			var previous byte
+			count := 0
+			flush := func() {
+				switch {
+				case count > 3:
+					fmt.Fprintf(&sb, "!%d%c", count, previous)
+				case count > 0:
+					sb.WriteString(strings.Repeat(string(previous), count))
+				}
+			}
+			for x := 0; x < side; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < side; dy++ {
+					if img.ColorIndexAt(x, band+dy) == index {
+						bits |= 1 << dy
+					}
+				}
+				char := 63 + bits
+				if char == previous {
+					count++
+					continue
+				}
+				flush()
+				previous, count = char, 1
+			}
+			flush()
+			// $ 回到本条带开头画下一种颜色
+			sb.WriteByte('$')
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+// kittyChunk kitty 图形协议每段转义序列携带的 base64 字节数上限
+const kittyChunk = 4096
+
+// Kitty 使用 kitty 图形协议（kitty、WezTerm、Ghostty 等支持）以 PNG 直接显示，scale 为每个模块的像素边长
+func (c *Code) Kitty(scale int) string {
+	var data bytes.Buffer
+	if err := c.PNG(&data, scale); err != nil {
+		return c.ASCII()
+	}
+	payload := base64.StdEncoding.EncodeToString(data.Bytes())
+	var sb strings.Builder
+	for i := 0; i < len(payload); i += kittyChunk {
+		end := min(i+kittyChunk, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			// a=T 传输并显示，f=100 PNG，q=2 不回复
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,q=2,m=%d;%s\x1b\\", more, payload[i:end])
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
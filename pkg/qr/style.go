@@ -0,0 +1,265 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 错误定义
+var (
+	ErrInvalidColor = errors.New("invalid color")
+	ErrUnknownSize  = errors.New("unknown size preset")
+	ErrLowContrast  = errors.New("foreground and background are too similar to scan")
+)
+
+// Transparent 透明背景
+var Transparent = color.NRGBA{}
+
+// 常用颜色名称
+var namedColors = map[string]color.NRGBA{
+	"black":       {0x00, 0x00, 0x00, 0xff},
+	"white":       {0xff, 0xff, 0xff, 0xff},
+	"gray":        {0x80, 0x80, 0x80, 0xff},
+	"red":         {0xff, 0x00, 0x00, 0xff},
+	"green":       {0x00, 0x80, 0x00, 0xff},
+	"blue":        {0x00, 0x00, 0xff, 0xff},
+	"navy":        {0x00, 0x00, 0x80, 0xff},
+	"orange":      {0xff, 0xa5, 0x00, 0xff},
+	"purple":      {0x80, 0x00, 0x80, 0xff},
+	"brown":       {0xa5, 0x2a, 0x2a, 0xff},
+	"transparent": Transparent,
+}
+
+// ParseColor 解析颜色名称、#RGB、#RRGGBB 或带透明度的 #RGBA、#RRGGBBAA，# 可省略
+func ParseColor(s string) (color.NRGBA, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[name]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(name, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		var full []byte
+		for i := 0; i < len(hex); i++ {
+			full = append(full, hex[i], hex[i])
+		}
+		hex = string(full)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%w %q", ErrInvalidColor, s)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w %q", ErrInvalidColor, s)
+	}
+	return color.NRGBA{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
+
+// Sizes 尺寸预设，值为图片的目标边长（像素）
+var Sizes = map[string]int{
+	"small":  256,
+	"medium": 512,
+	"large":  1024,
+	"print":  2048,
+}
+
+// SizeNames 按从小到大排列的预设名称
+var SizeNames = []string{"small", "medium", "large", "print"}
+
+// ScaleFor 返回让图片边长不小于预设尺寸的模块像素边长
+func (c *Code) ScaleFor(size string) (int, error) {
+	target, ok := Sizes[strings.ToLower(size)]
+	if !ok {
+		return 0, fmt.Errorf("%w %q, expected one of %s", ErrUnknownSize, size, strings.Join(SizeNames, ", "))
+	}
+	side := c.Size + 2*QuietZone
+	return (target + side - 1) / side, nil
+}
+
+// logoRatio 标志边长占二维码边长的比例，H 级纠错下遮挡面积约 5%，留有余量
+const logoRatio = 0.22
+
+// LevelFor 嵌入标志时至少使用 H 级纠错，让被遮挡的中心区域可以恢复
+func LevelFor(level Level, logo bool) Level {
+	if logo {
+		return LevelH
+	}
+	return level
+}
+
+// Style 图片的颜色和中心标志，零值等同于白底黑码
+type Style struct {
+	Foreground color.Color // 为空时为黑色
+	Background color.Color // 为空时为白色，可为 Transparent
+	Logo       image.Image // 为空时不嵌入
+}
+
+func (s Style) colors() (color.Color, color.Color) {
+	fg, bg := s.Foreground, s.Background
+	if fg == nil {
+		fg = color.Black
+	}
+	if bg == nil {
+		bg = color.White
+	}
+	return fg, bg
+}
+
+// Check 检查前景色和背景色的亮度差足够扫描：深色码、浅色底，前景不能半透明；透明背景按白色计算
+func (s Style) Check() error {
+	fg, bg := s.colors()
+	if _, _, _, a := fg.RGBA(); a < 0xc000 {
+		return ErrLowContrast
+	}
+	if _, _, _, a := bg.RGBA(); a == 0 {
+		bg = color.White
+	}
+	if diff := luminance(bg) - luminance(fg); diff < 0.4 {
+		return ErrLowContrast
+	}
+	return nil
+}
+
+// luminance 相对亮度，0 为黑、1 为白
+func luminance(c color.Color) float64 {
+	r, g, b, _ := color.NRGBAModel.Convert(c).RGBA()
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+}
+
+// logoRect 标志在图片中的位置，对齐到模块边界并四周留一个模块的空白
+func (c *Code) logoRect(scale int) image.Rectangle {
+	modules := int(float64(c.Size)*logoRatio) | 1
+	start := QuietZone + (c.Size-modules)/2
+	return image.Rect(start*scale, start*scale, (start+modules)*scale, (start+modules)*scale)
+}
+
+// StyledImage 返回按 style 着色的图像，嵌入标志时标志缩放后居中放在空白底块上
+func (c *Code) StyledImage(scale int, style Style) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	fg, bg := style.colors()
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	dark := image.NewUniform(fg)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				cell := image.Rect((x+QuietZone)*scale, (y+QuietZone)*scale, (x+QuietZone+1)*scale, (y+QuietZone+1)*scale)
+				draw.Draw(img, cell, dark, image.Point{}, draw.Src)
+			}
+		}
+	}
+	if style.Logo != nil {
+		area := c.logoRect(scale)
+		draw.Draw(img, area, image.NewUniform(bg), image.Point{}, draw.Src)
+		inner := area.Inset(scale)
+		draw.Draw(img, inner, scaleImage(style.Logo, inner.Dx(), inner.Dy()), image.Point{}, draw.Over)
+	}
+	return img
+}
+
+// StyledPNG 将按 style 着色的二维码编码为 PNG 写入 w，零值 style 使用文件更小的黑白调色板图像
+func (c *Code) StyledPNG(w io.Writer, scale int, style Style) error {
+	if style == (Style{}) {
+		return c.PNG(w, scale)
+	}
+	return png.Encode(w, c.StyledImage(scale, style))
+}
+
+// StyledSVG 将按 style 着色的二维码编码为 SVG 写入 w，标志以内嵌 PNG 的形式放在中心
+func (c *Code) StyledSVG(w io.Writer, scale int, style Style) error {
+	if scale < 1 {
+		scale = 1
+	}
+	fg, bg := style.colors()
+	side := c.Size + 2*QuietZone
+	var logo image.Rectangle
+	if style.Logo != nil {
+		logo = c.logoRect(1)
+	}
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; {
+			if !c.Black(x, y) || image.Pt(x+QuietZone, y+QuietZone).In(logo) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < c.Size && c.Black(x+run, y) && !image.Pt(x+run+QuietZone, y+QuietZone).In(logo) {
+				run++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x+QuietZone, y+QuietZone, run, run)
+			x += run
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+`, side*scale, side*scale, side, side)
+	if fill, opacity := svgColor(bg); opacity != "0" {
+		fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\" fill-opacity=\"%s\"/>\n", fill, opacity)
+	}
+	fill, opacity := svgColor(fg)
+	fmt.Fprintf(&b, "<path fill=\"%s\" fill-opacity=\"%s\" d=\"%s\"/>\n", fill, opacity, path.String())
+	if style.Logo != nil {
+		var data bytes.Buffer
+		if err := png.Encode(&data, style.Logo); err != nil {
+			return err
+		}
+		inner := logo.Inset(1)
+		fmt.Fprintf(&b, "<image x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" preserveAspectRatio=\"xMidYMid meet\" href=\"data:image/png;base64,%s\"/>\n",
+			inner.Min.X, inner.Min.Y, inner.Dx(), inner.Dy(), base64.StdEncoding.EncodeToString(data.Bytes()))
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgColor 返回 SVG 的填充色和不透明度
+func svgColor(c color.Color) (string, string) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), strconv.FormatFloat(float64(n.A)/0xff, 'g', 3, 64)
+}
+
+// scaleImage 保持宽高比缩放到 w×h 以内并居中，使用区域平均，缩小大图标志时不产生锯齿
+func scaleImage(src image.Image, w, h int) image.Image {
+	bounds := src.Bounds()
+	if bounds.Empty() || w <= 0 || h <= 0 {
+		return image.NewNRGBA(image.Rect(0, 0, w, h))
+	}
+	ratio := min(float64(w)/float64(bounds.Dx()), float64(h)/float64(bounds.Dy()))
+	dw, dh := max(1, int(float64(bounds.Dx())*ratio)), max(1, int(float64(bounds.Dy())*ratio))
+	offX, offY := (w-dw)/2, (h-dh)/2
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < dh; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/dh
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/dh)
+		for x := 0; x < dw; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/dw
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/dw)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// 预乘 alpha 的分量，求平均后再还原
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(offX+x, offY+y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
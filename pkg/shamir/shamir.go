@@ -0,0 +1,118 @@
+// Package shamir 实现 GF(256) 上的 Shamir 秘密分享：秘密分成 n 份，任意 k 份可以恢复，少于 k 份得不到任何信息
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// 错误定义
+var (
+	ErrInvalidThreshold = errors.New("threshold must be between 2 and the number of shares (at most 255)")
+	ErrEmptySecret      = errors.New("secret is empty")
+	ErrTooFewShares     = errors.New("at least two shares are required")
+	ErrShareMismatch    = errors.New("shares have different lengths")
+	ErrDuplicateShare   = errors.New("two shares have the same index")
+)
+
+// Share 一份分享：X 为 1 到 255 的序号，Y 与秘密等长
+type Share struct {
+	X byte
+	Y []byte
+}
+
+// exp 和 log 是 GF(256)（多项式 x^8+x^4+x^3+x+1，生成元 3）的指数表和对数表
+var (
+	exp [510]byte
+	log [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = x, x
+		log[x] = byte(i)
+		// 乘以生成元 3 = x*2 ^ x
+		x ^= x<<1 ^ byte(int8(x)>>7)&0x1b
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return exp[int(log[a])+int(log[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return exp[int(log[a])+255-int(log[b])]
+}
+
+// Split 把 secret 分成 n 份，任意 threshold 份可以恢复
+func Split(secret []byte, n, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, ErrInvalidThreshold
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+	// 每个字节一个 threshold-1 次随机多项式，常数项为秘密
+	coefficients := make([]byte, threshold)
+	defer clear(coefficients)
+	for j, b := range secret {
+		coefficients[0] = b
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("生成随机系数失败: %w", err)
+		}
+		for i := range shares {
+			// 霍纳法则求值
+			y := byte(0)
+			for k := threshold - 1; k >= 0; k-- {
+				y = mul(y, shares[i].X) ^ coefficients[k]
+			}
+			shares[i].Y[j] = y
+		}
+	}
+	return shares, nil
+}
+
+// Combine 用拉格朗日插值恢复秘密。份数不足门限时得到的是错误的秘密而不是错误，
+// 调用方需要自己校验结果（如助记词校验和）
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrTooFewShares
+	}
+	size := len(shares[0].Y)
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share.Y) != size || size == 0 {
+			return nil, ErrShareMismatch
+		}
+		if share.X == 0 || seen[share.X] {
+			return nil, ErrDuplicateShare
+		}
+		seen[share.X] = true
+	}
+	secret := make([]byte, size)
+	for i, share := range shares {
+		// x=0 处的拉格朗日基函数：prod x_j / (x_j - x_i)，GF(256) 中减法就是异或
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = mul(basis, div(other.X, other.X^share.X))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= mul(share.Y[k], basis)
+		}
+	}
+	return secret, nil
+}
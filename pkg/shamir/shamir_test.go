@@ -0,0 +1,99 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// FIPS-197 4.2 节的乘法示例：{57}•{83} = {c1}，{57}•{13} = {fe}
+func TestMulKnownAnswers(t *testing.T) {
+	tests := []struct{ a, b, want byte }{
+		{0x57, 0x83, 0xc1},
+		{0x57, 0x13, 0xfe},
+		{0x57, 0x02, 0xae},
+		{0x57, 0x01, 0x57},
+		{0x57, 0x00, 0x00},
+	}
+	for _, tt := range tests {
+		if got := mul(tt.a, tt.b); got != tt.want {
+			t.Errorf("mul(%#x, %#x) = %#x, want %#x", tt.a, tt.b, got, tt.want)
+		}
+		if tt.b != 0 {
+			if got := div(tt.want, tt.b); got != tt.a {
+				t.Errorf("div(%#x, %#x) = %#x, want %#x", tt.want, tt.b, got, tt.a)
+			}
+		}
+	}
+}
+
+// 多项式 f(x) = s + {57}x 在 x={83} 和 x={13} 处的值由上面的乘法示例得出
+func TestCombineKnownAnswer(t *testing.T) {
+	secret := []byte{0x00, 0x2a, 0xff}
+	shares := []Share{{X: 0x83, Y: make([]byte, len(secret))}, {X: 0x13, Y: make([]byte, len(secret))}}
+	for i, s := range secret {
+		shares[0].Y[i] = s ^ 0xc1
+		shares[1].Y[i] = s ^ 0xfe
+	}
+	got, err := Combine(shares)
+	if err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("Combine = %x, %v, want %x", got, err, secret)
+	}
+}
+
+func TestSplitCombineThreshold(t *testing.T) {
+	secret := []byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 任意 3 份都能恢复
+	for i := 0; i < len(shares); i++ {
+		for j := i + 1; j < len(shares); j++ {
+			for k := j + 1; k < len(shares); k++ {
+				got, err := Combine([]Share{shares[k], shares[i], shares[j]})
+				if err != nil || !bytes.Equal(got, secret) {
+					t.Errorf("Combine(%d, %d, %d) = %q, %v, want the secret", i+1, j+1, k+1, got, err)
+				}
+			}
+		}
+	}
+	if got, err := Combine(shares); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("Combine of all shares = %q, %v, want the secret", got, err)
+	}
+
+	// 少于门限时得不到秘密
+	got, err := Combine(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, secret) {
+		t.Error("two shares of a 3-of-5 split recovered the secret")
+	}
+	if _, err := Combine(shares[:1]); !errors.Is(err, ErrTooFewShares) {
+		t.Errorf("Combine of one share error = %v, want ErrTooFewShares", err)
+	}
+}
+
+func TestSplitCombineErrors(t *testing.T) {
+	for _, tt := range []struct{ n, threshold int }{{3, 1}, {3, 4}, {256, 2}} {
+		if _, err := Split([]byte("secret"), tt.n, tt.threshold); !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("Split(n=%d, threshold=%d) error = %v, want ErrInvalidThreshold", tt.n, tt.threshold, err)
+		}
+	}
+	if _, err := Split(nil, 3, 2); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("Split of an empty secret error = %v, want ErrEmptySecret", err)
+	}
+
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine([]Share{shares[0], shares[0]}); !errors.Is(err, ErrDuplicateShare) {
+		t.Errorf("Combine with a repeated share error = %v, want ErrDuplicateShare", err)
+	}
+	short := Share{X: shares[1].X, Y: shares[1].Y[:3]}
+	if _, err := Combine([]Share{shares[0], short}); !errors.Is(err, ErrShareMismatch) {
+		t.Errorf("Combine with a shorter share error = %v, want ErrShareMismatch", err)
+	}
+}
@@ -0,0 +1,72 @@
+package ur
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+)
+
+// ErrInvalidBytewords Bytewords 编码无效或校验和不匹配
+var ErrInvalidBytewords = errors.New("invalid bytewords")
+
+// bytewords BCR-2020-012 定义的 256 个四字母单词，minimal 编码取首尾两个字母
+const bytewords = "able acid also apex aqua arch atom aunt away axis back bald barn belt beta bias " +
+	"blue body brag brew bulb buzz calm cash cats chef city claw code cola cook cost " +
+	"crux curl cusp cyan dark data days deli dice diet door down draw drop drum dull " +
+	"duty each easy echo edge epic even exam exit eyes fact fair fern figs film fish " +
+	"fizz flap flew flux foxy free frog fuel fund gala game gear gems gift girl glow " +
+	"good gray grim guru gush gyro half hang hard hawk heat help high hill holy hope " +
+	"horn huts iced idea idle inch inky into iris iron item jade jazz join jolt jowl " +
+	"judo jugs jump junk jury keep keno kept keys kick kiln king kite kiwi knob lamb " +
+	"lava lazy leaf legs liar limp lion list logo loud love luau luck lung main many " +
+	"math maze memo menu meow mild mint miss monk nail navy need news next noon note " +
+	"numb obey oboe omit onyx open oval owls paid part peck play plus poem pool pose " +
+	"puff puma purr quad quiz race ramp real redo rich road rock roof ruby ruin runs " +
+	"rust safe saga scar sets silk skew slot soap solo song stub surf swan taco task " +
+	"taxi tent tied time tiny toil tomb toys trip tuna twin ugly undo unit urge user " +
+	"vast very veto vial vibe view visa void vows wall wand warm wasp wave waxy webs " +
+	"what when whiz wolf work yank yawn yell yoga yurt zaps zero zest zinc zone zoom"
+
+var wordList = strings.Fields(bytewords)
+
+var minimalIndex = func() map[string]byte {
+	index := make(map[string]byte, len(wordList))
+	for i, word := range wordList {
+		index[word[:1]+word[3:]] = byte(i)
+	}
+	return index
+}()
+
+// EncodeMinimal 以 minimal 形式编码数据，末尾附加 CRC32 校验和
+func EncodeMinimal(data []byte) string {
+	withChecksum := binary.BigEndian.AppendUint32(append([]byte(nil), data...), crc32.ChecksumIEEE(data))
+	var sb strings.Builder
+	for _, b := range withChecksum {
+		word := wordList[b]
+		sb.WriteByte(word[0])
+		sb.WriteByte(word[3])
+	}
+	return sb.String()
+}
+
+// DecodeMinimal 解码 minimal 形式（不区分大小写）并校验 CRC32
+func DecodeMinimal(s string) ([]byte, error) {
+	s = strings.ToLower(s)
+	if len(s)%2 != 0 || len(s) < 10 {
+		return nil, ErrInvalidBytewords
+	}
+	data := make([]byte, len(s)/2)
+	for i := range data {
+		b, ok := minimalIndex[s[2*i:2*i+2]]
+		if !ok {
+			return nil, ErrInvalidBytewords
+		}
+		data[i] = b
+	}
+	body, checksum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(checksum) {
+		return nil, ErrInvalidBytewords
+	}
+	return body, nil
+}
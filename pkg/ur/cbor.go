@@ -0,0 +1,81 @@
+package ur
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidCBOR CBOR 数据无效或不是期望的类型
+var ErrInvalidCBOR = errors.New("invalid CBOR")
+
+// 本包只需要 CBOR 的无符号整数、字节串和数组
+const (
+	majorUnsigned = 0
+	majorBytes    = 2
+	majorArray    = 4
+)
+
+// appendHead 按最短形式追加 CBOR 数据项头部
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= 0xFF:
+		return append(buf, major<<5|24, byte(n))
+	case n <= 0xFFFF:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= 0xFFFFFFFF:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+	}
+}
+
+func appendBytes(buf, data []byte) []byte {
+	return append(appendHead(buf, majorBytes, uint64(len(data))), data...)
+}
+
+type cborReader struct {
+	data []byte
+}
+
+func (r *cborReader) head(major byte) (uint64, error) {
+	if len(r.data) == 0 || r.data[0]>>5 != major {
+		return 0, ErrInvalidCBOR
+	}
+	info := r.data[0] & 0x1F
+	r.data = r.data[1:]
+	if info < 24 {
+		return uint64(info), nil
+	}
+	if info > 27 {
+		return 0, ErrInvalidCBOR
+	}
+	size := 1 << (info - 24)
+	if len(r.data) < size {
+		return 0, ErrInvalidCBOR
+	}
+	var n uint64
+	for _, b := range r.data[:size] {
+		n = n<<8 | uint64(b)
+	}
+	r.data = r.data[size:]
+	return n, nil
+}
+
+func (r *cborReader) uint() (uint64, error) {
+	return r.head(majorUnsigned)
+}
+
+func (r *cborReader) bytes() ([]byte, error) {
+	n, err := r.head(majorBytes)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.data)) < n {
+		return nil, ErrInvalidCBOR
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
@@ -0,0 +1,211 @@
+// Package ur 实现 BC-UR（BCR-2020-005）统一资源编码，用于通过二维码序列传输较大的数据。
+// 多片编码只生成按顺序编号的纯分片，不使用喷泉码混合分片
+package ur
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+)
+
+// 错误定义
+var (
+	ErrInvalidUR      = errors.New("invalid UR")
+	ErrFountainPart   = errors.New("fountain-coded UR parts are not supported")
+	ErrMismatchedPart = errors.New("UR part belongs to a different message")
+	ErrIncomplete     = errors.New("UR message incomplete")
+)
+
+// BytesType 任意字节数据的 UR 类型，消息体为 CBOR 字节串
+const BytesType = "bytes"
+
+// EncodeBytes 将数据编码为 ur:bytes 序列，每个分片最多 maxFragmentLen 字节
+func EncodeBytes(data []byte, maxFragmentLen int) []string {
+	return Encode(BytesType, appendBytes(nil, data), maxFragmentLen)
+}
+
+// Encode 将 CBOR 编码的消息拆分为多个分片；消息不超过 maxFragmentLen 时返回单个 UR
+func Encode(urType string, message []byte, maxFragmentLen int) []string {
+	if len(message) <= maxFragmentLen {
+		return []string{"ur:" + urType + "/" + EncodeMinimal(message)}
+	}
+
+	// 分片长度尽量均匀，最后一片以零补齐
+	count := (len(message) + maxFragmentLen - 1) / maxFragmentLen
+	fragmentLen := (len(message) + count - 1) / count
+	checksum := crc32.ChecksumIEEE(message)
+	parts := make([]string, count)
+	for i := range parts {
+		fragment := make([]byte, fragmentLen)
+		copy(fragment, message[min(i*fragmentLen, len(message)):])
+
+		body := appendHead(nil, majorArray, 5)
+		body = appendHead(body, majorUnsigned, uint64(i+1))
+		body = appendHead(body, majorUnsigned, uint64(count))
+		body = appendHead(body, majorUnsigned, uint64(len(message)))
+		body = appendHead(body, majorUnsigned, uint64(checksum))
+		body = appendBytes(body, fragment)
+		parts[i] = fmt.Sprintf("ur:%s/%d-%d/%s", urType, i+1, count, EncodeMinimal(body))
+	}
+	return parts
+}
+
+// DecodeBytes 从 ur:bytes 消息中取出原始数据
+func DecodeBytes(message []byte) ([]byte, error) {
+	r := &cborReader{data: message}
+	data, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data) != 0 {
+		return nil, ErrInvalidCBOR
+	}
+	return data, nil
+}
+
+// Decoder 收集扫描到的分片并重组消息，分片可以乱序、重复到达
+type Decoder struct {
+	urType     string
+	seqLen     int
+	messageLen int
+	checksum   uint32
+	fragments  map[int][]byte
+	message    []byte
+}
+
+// NewDecoder 创建解码器
+func NewDecoder() *Decoder {
+	return &Decoder{fragments: make(map[int][]byte)}
+}
+
+// Type 返回已接收分片的 UR 类型
+func (d *Decoder) Type() string {
+	return d.urType
+}
+
+// Progress 返回已接收的分片数和总分片数，尚未接收任何分片时总数为 0
+func (d *Decoder) Progress() (received, total int) {
+	if d.message != nil {
+		return 1, 1
+	}
+	return len(d.fragments), d.seqLen
+}
+
+// Complete 是否已收齐全部分片
+func (d *Decoder) Complete() bool {
+	return d.message != nil || (d.seqLen > 0 && len(d.fragments) == d.seqLen)
+}
+
+// Receive 接收一个 UR 字符串（不区分大小写）
+func (d *Decoder) Receive(s string) error {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "ur:") {
+		return ErrInvalidUR
+	}
+	components := strings.Split(s[len("ur:"):], "/")
+	urType := components[0]
+	if urType == "" || strings.Trim(urType, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+		return ErrInvalidUR
+	}
+	if d.urType != "" && d.urType != urType {
+		return fmt.Errorf("%w: type %s, expected %s", ErrMismatchedPart, urType, d.urType)
+	}
+
+	switch len(components) {
+	case 2:
+		message, err := DecodeMinimal(components[1])
+		if err != nil {
+			return err
+		}
+		if d.seqLen > 0 {
+			return ErrMismatchedPart
+		}
+		d.urType, d.message = urType, message
+		return nil
+	case 3:
+		return d.receivePart(urType, components[1], components[2])
+	default:
+		return ErrInvalidUR
+	}
+}
+
+func (d *Decoder) receivePart(urType, seq, encoded string) error {
+	seqNum, seqLen, ok := parseSequence(seq)
+	if !ok {
+		return ErrInvalidUR
+	}
+	body, err := DecodeMinimal(encoded)
+	if err != nil {
+		return err
+	}
+
+	r := &cborReader{data: body}
+	if n, err := r.head(majorArray); err != nil || n != 5 {
+		return ErrInvalidCBOR
+	}
+	var header [4]uint64
+	for i := range header {
+		if header[i], err = r.uint(); err != nil {
+			return err
+		}
+	}
+	fragment, err := r.bytes()
+	if err != nil {
+		return err
+	}
+	if header[0] != uint64(seqNum) || header[1] != uint64(seqLen) || header[3] > 0xFFFFFFFF {
+		return ErrInvalidUR
+	}
+	if seqNum > seqLen {
+		return ErrFountainPart
+	}
+
+	messageLen, checksum := int(header[2]), uint32(header[3])
+	if d.message != nil || (d.seqLen > 0 &&
+		(d.seqLen != seqLen || d.messageLen != messageLen || d.checksum != checksum)) {
+		return ErrMismatchedPart
+	}
+	if len(fragment)*seqLen < messageLen {
+		return ErrInvalidUR
+	}
+	d.urType, d.seqLen, d.messageLen, d.checksum = urType, seqLen, messageLen, checksum
+	d.fragments[seqNum] = fragment
+	return nil
+}
+
+// parseSequence 解析 "序号-总数"
+func parseSequence(s string) (int, int, bool) {
+	num, total, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, false
+	}
+	seqNum, err1 := strconv.Atoi(num)
+	seqLen, err2 := strconv.Atoi(total)
+	if err1 != nil || err2 != nil || seqNum < 1 || seqLen < 1 {
+		return 0, 0, false
+	}
+	return seqNum, seqLen, true
+}
+
+// Message 返回重组并校验后的 CBOR 消息
+func (d *Decoder) Message() ([]byte, error) {
+	if d.message != nil {
+		return d.message, nil
+	}
+	if !d.Complete() {
+		received, total := d.Progress()
+		return nil, fmt.Errorf("%w: %d/%d parts", ErrIncomplete, received, total)
+	}
+
+	var message []byte
+	for i := 1; i <= d.seqLen; i++ {
+		message = append(message, d.fragments[i]...)
+	}
+	message = message[:d.messageLen]
+	if crc32.ChecksumIEEE(message) != d.checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidUR)
+	}
+	return message, nil
+}
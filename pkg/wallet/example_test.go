@@ -0,0 +1,82 @@
+package wallet_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/palagend/slowmade/pkg/wallet"
+)
+
+// testMnemonic BIP39 测试向量中的 12 词助记词
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func Example() {
+	dir, err := os.MkdirTemp("", "slowmade-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := wallet.Open(wallet.Options{Dir: dir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := w.Restore(testMnemonic, "TREZOR"); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Unlock("TREZOR"); err != nil {
+		log.Fatal(err)
+	}
+	account, err := w.CreateAccount("ETH", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	address, err := w.DeriveAddress(account.ID, 0, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(account.Path)
+	fmt.Println(address.Path, address.Address)
+	// Output:
+	// m/44'/60'/0'
+	// m/44'/60'/0'/0/0 0xd24e36efaf809938128b06c267eA3247b7F319aF
+}
+
+func ExampleWallet_SignMessage() {
+	dir, err := os.MkdirTemp("", "slowmade-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := wallet.Open(wallet.Options{Dir: dir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Restore(testMnemonic, "TREZOR"); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Unlock("TREZOR"); err != nil {
+		log.Fatal(err)
+	}
+	account, err := w.CreateAccount("ETH", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	address, err := w.DeriveAddress(account.ID, 0, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sig, err := w.SignMessage(address.Address, []byte("hello"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d bytes, v = %d\n", len(sig), sig[64])
+	// Output:
+	// 65 bytes, v = 27
+}
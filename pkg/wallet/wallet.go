@@ -0,0 +1,377 @@
+// Package wallet 是 slowmade 钱包引擎的公开入口，供其他 Go 程序嵌入使用：
+// 创建和恢复钱包、创建账户、派生地址、签名，以及存储目录的配置。
+// 公开的类型都定义在本包中，数据目录与 slowmade 命令行完全兼容；命令行和 Web 服务同样经 Open 打开数据目录。
+//
+//	w, err := wallet.Open(wallet.Options{Dir: dir})
+//	if err != nil { ... }
+//	defer w.Close()
+//	if err := w.Unlock(password); err != nil { ... }
+//	account, err := w.CreateAccount("ETH", 0)
+//	address, err := w.DeriveAddress(account.ID, 0, 1)
+//	sig, err := w.SignMessage(address.Address, []byte("hello"))
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/palagend/slowmade/internal/config"
+	"github.com/palagend/slowmade/internal/core"
+	"github.com/palagend/slowmade/internal/security"
+	"github.com/palagend/slowmade/internal/signer"
+	"github.com/palagend/slowmade/pkg/coin"
+)
+
+// 错误定义
+var (
+	ErrNoDir          = errors.New("wallet directory not set")
+	ErrLocked         = errors.New("wallet is locked")
+	ErrExists         = errors.New("wallet already exists in this directory")
+	ErrNotFound       = errors.New("no wallet in this directory")
+	ErrUnknownCoin    = errors.New("unsupported coin")
+	ErrUnknownAccount = errors.New("unknown account")
+	ErrRejected       = signer.ErrRejected
+)
+
+// Options 打开钱包的存储配置
+type Options struct {
+	Dir          string // 数据目录，必填
+	ReadOnly     bool   // 不加实例锁、拒绝写入，用于另一个进程运行时查看数据
+	Permissions  string // 目录权限检查：warn（默认）、repair 或 strict
+	CacheEntries int    // 读取缓存最多保存的文件数，0 表示不缓存
+	Cloak        string // 可选的附加口令，不同的值得到不同的地址
+
+	// SharedPasswords 解锁密码保存在进程共享的密码管理器中，与同一进程中的其他组件共用解锁状态；
+	// 同一进程打开多个钱包时保持 false，每个钱包各自保存
+	SharedPasswords bool
+
+	ChainID *big.Int     // 交易未指定 chainId 时的默认值，为空时为 1
+	Approve Approver     // 签名前的确认，为空时直接签名
+	OnWrite func() error // 每次写入存储后调用
+}
+
+// SignRequest 待确认的签名请求
+type SignRequest struct {
+	Method  string   // personal_sign、eth_signTransaction 等
+	Address string   // 签名地址
+	Details []string // 请求摘要，每行一项
+}
+
+// Approver 返回 false 时拒绝签名，签名返回 ErrRejected
+type Approver func(req SignRequest) bool
+
+// Account 币种账户
+type Account struct {
+	ID         string
+	Coin       string
+	Path       string // 账户层级派生路径，如 m/44'/60'/0'
+	Standalone bool   // 从其他钱包导入，不由本钱包的助记词派生
+	Created    time.Time
+}
+
+// Address 账户下派生的地址
+type Address struct {
+	AccountID string
+	Coin      string
+	Address   string
+	PublicKey string
+	Path      string // 完整派生路径
+	Change    uint32 // 0 为收款地址，1 为找零地址
+	Index     uint32
+}
+
+// Wallet 一个数据目录中的钱包，方法可以并发调用
+type Wallet struct {
+	dir       string
+	engine    *core.Engine
+	storage   *core.FileStorage
+	walletMgr *core.DefaultWalletManager
+	accounts  core.AccountManager
+	passwords *security.PasswordManager
+	signer    *signer.Signer
+}
+
+// Open 打开数据目录，目录不存在时创建；目录中还没有钱包时先调用 Create 或 Restore
+func Open(opts Options) (*Wallet, error) {
+	if opts.Dir == "" {
+		return nil, ErrNoDir
+	}
+	passwords := security.NewPasswordManager()
+	if opts.SharedPasswords {
+		passwords = security.GetPasswordManager()
+	}
+	engine, err := core.OpenEngine(core.EngineOptions{
+		Storage: config.StorageConfig{
+			BaseDir:      opts.Dir,
+			ReadOnly:     opts.ReadOnly,
+			Permissions:  opts.Permissions,
+			CacheEntries: opts.CacheEntries,
+		},
+		Cloak:     opts.Cloak,
+		Passwords: passwords,
+		OnWrite:   opts.OnWrite,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chainID := opts.ChainID
+	if chainID == nil {
+		chainID = big.NewInt(1)
+	}
+	var approver signer.Approver = signer.PreApproved{}
+	if opts.Approve != nil {
+		approver = approverFunc(opts.Approve)
+	}
+	return &Wallet{
+		dir:       opts.Dir,
+		engine:    engine,
+		storage:   engine.Storage,
+		walletMgr: engine.WalletMgr,
+		accounts:  engine.AccountMgr,
+		passwords: passwords,
+		signer:    signer.NewSigner(engine.AccountMgr, approver, chainID),
+	}, nil
+}
+
+// Close 锁定钱包并释放数据目录的实例锁
+func (w *Wallet) Close() error {
+	w.Lock()
+	return w.storage.Close()
+}
+
+// Dir 数据目录
+func (w *Wallet) Dir() string {
+	return w.dir
+}
+
+// Engine 钱包底层的存储和管理器，本模块的命令行和 Web 服务在此之上组装其余功能；
+// core 是内部包，模块外的程序只应使用 Wallet 的方法
+func (w *Wallet) Engine() *core.Engine {
+	return w.engine
+}
+
+// Exists 目录中是否已有钱包
+func (w *Wallet) Exists() bool {
+	root, err := w.storage.LoadRootWallet()
+	return err == nil && root != nil
+}
+
+// Create 生成新的助记词并创建钱包，返回助记词，调用方负责让用户抄写备份
+func (w *Wallet) Create(password string) (string, error) {
+	if w.Exists() {
+		return "", ErrExists
+	}
+	if _, err := w.walletMgr.CreateNewWallet(password); err != nil {
+		return "", err
+	}
+	return w.mnemonic(password)
+}
+
+// Restore 从助记词恢复钱包，password 同时是 BIP39 口令和加密密码
+func (w *Wallet) Restore(mnemonic, password string) error {
+	if w.Exists() {
+		return ErrExists
+	}
+	_, err := w.walletMgr.RestoreWalletFromMnemonic(mnemonic, password)
+	return err
+}
+
+// Unlock 解锁钱包，之后才能派生地址和签名
+func (w *Wallet) Unlock(password string) error {
+	if !w.Exists() {
+		return ErrNotFound
+	}
+	if err := w.walletMgr.UnlockWallet(password); err != nil {
+		return err
+	}
+	return w.passwords.SetPassword(password)
+}
+
+// Lock 锁定钱包并清除内存中的密钥和密码
+func (w *Wallet) Lock() {
+	w.walletMgr.LockWallet()
+	w.passwords.Clear()
+}
+
+// IsLocked 钱包是否已锁定
+func (w *Wallet) IsLocked() bool {
+	return w.walletMgr.IsLocked()
+}
+
+// Mnemonic 导出助记词，需要钱包已解锁并再次提供密码
+func (w *Wallet) Mnemonic(password string) (string, error) {
+	if w.IsLocked() {
+		return "", ErrLocked
+	}
+	return w.mnemonic(password)
+}
+
+// mnemonic 解密助记词并复制为字符串交给调用方，解密出的内存随即清除
+func (w *Wallet) mnemonic(password string) (string, error) {
+	secret, err := w.walletMgr.ExportMnemonic(password)
+	if err != nil {
+		return "", err
+	}
+	defer secret.Destroy()
+	return string(secret.Bytes()), nil
+}
+
+// Accounts 全部账户
+func (w *Wallet) Accounts() ([]Account, error) {
+	accounts, err := w.accounts.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Account, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, newAccount(account))
+	}
+	return result, nil
+}
+
+// CreateAccount 按币种的标准派生路径创建第 index 个账户，如 ETH 的 m/44'/60'/index'
+func (w *Wallet) CreateAccount(symbol string, index uint32) (*Account, error) {
+	info, ok := coin.LookupSymbol(symbol)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCoin, symbol)
+	}
+	return w.createAccount(core.ConventionStandard.AccountPath(info.Type, index))
+}
+
+// CreateAccountAt 按账户层级派生路径创建账户，如 m/84'/0'/1'
+func (w *Wallet) CreateAccountAt(path string) (*Account, error) {
+	derivationPath, err := core.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return w.createAccount(derivationPath)
+}
+
+func (w *Wallet) createAccount(path *core.DerivationPath) (*Account, error) {
+	if w.IsLocked() {
+		return nil, ErrLocked
+	}
+	if err := core.PathRuleFor(path.CoinType).Validate(path); err != nil {
+		return nil, err
+	}
+	account, err := w.accounts.CreateNewAccount(path, core.ConventionStandard)
+	if err != nil {
+		return nil, err
+	}
+	result := newAccount(account)
+	return &result, nil
+}
+
+// DeriveAddress 派生账户下的地址，已派生过的地址直接返回
+func (w *Wallet) DeriveAddress(accountID string, change, index uint32) (*Address, error) {
+	if w.IsLocked() {
+		return nil, ErrLocked
+	}
+	account, err := w.account(accountID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := w.accounts.DeriveAddress(accountID, change, index)
+	if err != nil {
+		return nil, err
+	}
+	return newAddress(account, key), nil
+}
+
+// Addresses 账户下已派生的地址
+func (w *Wallet) Addresses(accountID string) ([]Address, error) {
+	account, err := w.account(accountID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := w.accounts.GetAddresses(accountID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Address, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *newAddress(account, key))
+	}
+	return result, nil
+}
+
+// SignMessage 用钱包中的 ETH 地址按 EIP-191（personal_sign）签名，返回 65 字节签名（v 为 27/28）
+func (w *Wallet) SignMessage(address string, message []byte) ([]byte, error) {
+	if w.IsLocked() {
+		return nil, ErrLocked
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid ETH address %q", address)
+	}
+	return w.signer.SignMessage(common.HexToAddress(address), message)
+}
+
+// SignTransaction 签名 ETH 交易，tx 为 eth_signTransaction 的 JSON 参数对象，返回原始交易编码
+func (w *Wallet) SignTransaction(tx []byte) ([]byte, error) {
+	if w.IsLocked() {
+		return nil, ErrLocked
+	}
+	var args signer.TransactionArgs
+	if err := json.Unmarshal(tx, &args); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+	return w.signer.SignTransaction(&args)
+}
+
+// account 按 ID 查找账户
+func (w *Wallet) account(accountID string) (*core.CoinAccount, error) {
+	accounts, err := w.accounts.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.ID == accountID {
+			return account, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, accountID)
+}
+
+func newAccount(account *core.CoinAccount) Account {
+	return Account{
+		ID:         account.ID,
+		Coin:       account.CoinSymbol,
+		Path:       account.DerivationPath,
+		Standalone: account.Standalone,
+		Created:    account.Created(),
+	}
+}
+
+func newAddress(account *core.CoinAccount, key *core.AddressKey) *Address {
+	address := &Address{
+		AccountID: key.AccountID,
+		Coin:      key.CoinSymbol,
+		Address:   key.Address,
+		PublicKey: key.PublicKey,
+		Change:    key.ChangeType,
+		Index:     key.AddressIndex,
+	}
+	if path, err := account.AddressPath(key.ChangeType, key.AddressIndex); err == nil {
+		address.Path = path.String()
+	}
+	return address
+}
+
+// approverFunc 把 Approver 适配为签名器的确认接口，策略要求的二次确认同样交给它
+type approverFunc Approver
+
+func (f approverFunc) Approve(req *signer.ApprovalRequest) bool {
+	return f(SignRequest{Method: req.Method, Address: req.Account.Hex(), Details: req.Details})
+}
+
+func (f approverFunc) Confirm(req *signer.ApprovalRequest) bool {
+	details := req.Details
+	if req.Policy != "" {
+		details = append(append([]string(nil), details...), "Policy: "+req.Policy)
+	}
+	return f(SignRequest{Method: req.Method + " (confirm)", Address: req.Account.Hex(), Details: details})
+}
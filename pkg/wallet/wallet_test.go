@@ -0,0 +1,164 @@
+package wallet_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/palagend/slowmade/pkg/wallet"
+)
+
+const testPassword = "TREZOR"
+
+// openRestored 在临时目录中恢复测试助记词并解锁
+func openRestored(t *testing.T, dir string) *wallet.Wallet {
+	t.Helper()
+	w, err := wallet.Open(wallet.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	if err := w.Restore(testMnemonic, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// knownAddresses 测试助记词 ETH 账户 0 外部链上的地址，派生规则变化会导致已有钱包的地址改变
+var knownAddresses = map[uint32]string{
+	0: "0xd24e36efaf809938128b06c267eA3247b7F319aF",
+	1: "0x2a0e6373F95E251Bca437A41d32a4e3d91061d81",
+	7: "0x9Fab59501D5049962Ccf8F9D56145E9BEF72EFE5",
+}
+
+func TestDeriveAddressKnownAnswers(t *testing.T) {
+	w := openRestored(t, t.TempDir())
+	account, err := w.CreateAccount("ETH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, want := range knownAddresses {
+		address, err := w.DeriveAddress(account.ID, 0, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address.Address != want {
+			t.Errorf("address %d = %s, want %s", index, address.Address, want)
+		}
+	}
+	addresses, err := w.Addresses(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != len(knownAddresses) {
+		t.Errorf("Addresses returned %d addresses, want %d", len(addresses), len(knownAddresses))
+	}
+
+	// 另一个目录恢复同一助记词得到相同的地址
+	other := openRestored(t, t.TempDir())
+	otherAccount, err := other.CreateAccount("ETH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := other.DeriveAddress(otherAccount.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.Address != knownAddresses[0] {
+		t.Errorf("address 0 in another directory = %s, want %s", address.Address, knownAddresses[0])
+	}
+}
+
+func TestSignMessageRecoversAddress(t *testing.T) {
+	w := openRestored(t, t.TempDir())
+	account, err := w.CreateAccount("ETH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := w.DeriveAddress(account.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello")
+	sig, err := w.SignMessage(address.Address, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoverable := append([]byte(nil), sig...)
+	recoverable[64] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(message), recoverable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := crypto.PubkeyToAddress(*pub).Hex(); got != address.Address {
+		t.Errorf("signature recovers %s, want %s", got, address.Address)
+	}
+}
+
+func TestWalletLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	empty, err := wallet.Open(wallet.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := empty.Unlock(testPassword); !errors.Is(err, wallet.ErrNotFound) {
+		t.Errorf("Unlock without a wallet error = %v, want ErrNotFound", err)
+	}
+	empty.Close()
+
+	w := openRestored(t, dir)
+	if err := w.Restore(testMnemonic, testPassword); !errors.Is(err, wallet.ErrExists) {
+		t.Errorf("second Restore error = %v, want ErrExists", err)
+	}
+	if phrase, err := w.Mnemonic(testPassword); err != nil || phrase != testMnemonic {
+		t.Errorf("Mnemonic = %q, %v, want the restored phrase", phrase, err)
+	}
+	account, err := w.CreateAccount("ETH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Lock()
+	if !w.IsLocked() {
+		t.Fatal("wallet still unlocked after Lock")
+	}
+	if _, err := w.DeriveAddress(account.ID, 0, 0); !errors.Is(err, wallet.ErrLocked) {
+		t.Errorf("DeriveAddress while locked error = %v, want ErrLocked", err)
+	}
+	if _, err := w.SignMessage("0x0000000000000000000000000000000000000000", nil); !errors.Is(err, wallet.ErrLocked) {
+		t.Errorf("SignMessage while locked error = %v, want ErrLocked", err)
+	}
+	if err := w.Unlock("wrong password"); err == nil {
+		t.Error("Unlock with a wrong password succeeded")
+	}
+	if _, err := w.CreateAccount("NOPE", 0); !errors.Is(err, wallet.ErrUnknownCoin) {
+		t.Errorf("CreateAccount with an unknown coin error = %v, want ErrUnknownCoin", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开后钱包处于锁定状态，解锁后账户仍在
+	reopened, err := wallet.Open(wallet.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if !reopened.Exists() || !reopened.IsLocked() {
+		t.Fatalf("reopened wallet: exists %v, locked %v", reopened.Exists(), reopened.IsLocked())
+	}
+	if err := reopened.Unlock(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	list, err := reopened.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != account.ID || list[0].Coin != "ETH" {
+		t.Errorf("Accounts after reopen = %+v, want the ETH account", list)
+	}
+}